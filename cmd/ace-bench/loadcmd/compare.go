@@ -0,0 +1,97 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package loadcmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	cmdutils "github.com/trustbloc/edge-core/pkg/utils/cmd"
+)
+
+const (
+	requestFlagName  = "request"
+	requestEnvKey    = "BENCH_REQUEST"
+	requestFlagUsage = "Path of the JSON file with the comparison sent by every request." +
+		" Alternatively, this can be set with the following environment variable: " + requestEnvKey
+
+	comparePath = "/compare"
+)
+
+// GetCompareCmd returns the Cobra compare command.
+func GetCompareCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "compare",
+		Short: "Sends compare requests to Comparator",
+		Long: "Sends compare requests to the Comparator REST API and reports their throughput and latency" +
+			" percentiles.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			t, err := getTarget(cmd)
+			if err != nil {
+				return err
+			}
+
+			path, err := cmdutils.GetUserSetVarFromString(cmd, requestFlagName, requestEnvKey, false)
+			if err != nil {
+				return err
+			}
+
+			body, err := os.ReadFile(filepath.Clean(path))
+			if err != nil {
+				return fmt.Errorf("read %s: %w", requestFlagName, err)
+			}
+
+			if !json.Valid(body) {
+				return fmt.Errorf("%s must be a JSON file", requestFlagName)
+			}
+
+			return run(cmd, func(ctx context.Context) error {
+				return t.compare(ctx, body)
+			})
+		},
+	}
+
+	addLoadFlags(cmd)
+	cmd.Flags().StringP(requestFlagName, "", "", requestFlagUsage)
+
+	return cmd
+}
+
+func (t *target) compare(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(t.baseURL, "/")+comparePath,
+		bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := t.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("send request: %w", err)
+	}
+
+	defer resp.Body.Close() //nolint:errcheck
+
+	// the body is drained so that the connection is reused
+	_, _ = io.Copy(io.Discard, resp.Body) //nolint:errcheck
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+
+	return nil
+}
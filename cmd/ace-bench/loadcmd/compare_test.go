@@ -0,0 +1,57 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package loadcmd //nolint:testpackage
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareCmd(t *testing.T) {
+	request := filepath.Join(t.TempDir(), "request.json")
+	require.NoError(t, os.WriteFile(request, []byte(`{"op":{"type":"EqOp"}}`), 0o600))
+
+	t.Run("test compare", func(t *testing.T) {
+		srv, count := newTestServer(t, "/compare", http.StatusOK, map[string]bool{"result": true})
+
+		out, err := execute(t, GetCompareCmd(), "--"+urlFlagName, srv.URL+"/", "--"+requestFlagName, request,
+			"--"+requestsFlagName, "4")
+		require.NoError(t, err)
+		require.Contains(t, out, `"requests": 4`)
+		require.Contains(t, out, `"errors": 0`)
+		require.EqualValues(t, 4, *count)
+	})
+
+	t.Run("test failed requests", func(t *testing.T) {
+		srv, _ := newTestServer(t, "/compare", http.StatusBadRequest, nil)
+
+		out, err := execute(t, GetCompareCmd(), "--"+urlFlagName, srv.URL, "--"+requestFlagName, request,
+			"--"+requestsFlagName, "1")
+		require.NoError(t, err)
+		require.Contains(t, out, `"error": "status 400"`)
+	})
+
+	t.Run("test missing request file", func(t *testing.T) {
+		_, err := execute(t, GetCompareCmd(), "--"+urlFlagName, "https://comparator.example.com",
+			"--"+requestFlagName, "/missing/request.json")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "read request")
+	})
+
+	t.Run("test invalid request file", func(t *testing.T) {
+		invalid := filepath.Join(t.TempDir(), "invalid.json")
+		require.NoError(t, os.WriteFile(invalid, []byte("invalid"), 0o600))
+
+		_, err := execute(t, GetCompareCmd(), "--"+urlFlagName, "https://comparator.example.com",
+			"--"+requestFlagName, invalid)
+		require.EqualError(t, err, "request must be a JSON file")
+	})
+}
@@ -0,0 +1,60 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package loadcmd
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+	cmdutils "github.com/trustbloc/edge-core/pkg/utils/cmd"
+
+	"github.com/trustbloc/ace/pkg/client/gatekeeper"
+	"github.com/trustbloc/ace/pkg/restapi/gatekeeper/operation"
+)
+
+const (
+	queryIDFlagName  = "query-id"
+	queryIDEnvKey    = "BENCH_QUERY_ID"
+	queryIDFlagUsage = "ID of the query extracted by every request, as returned by the collect of a ticket." +
+		" Alternatively, this can be set with the following environment variable: " + queryIDEnvKey
+)
+
+// GetExtractCmd returns the Cobra extract command.
+func GetExtractCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "extract",
+		Short: "Sends extract requests to Gatekeeper",
+		Long: "Sends extract requests for a collected query to the Gatekeeper REST API and reports their throughput" +
+			" and latency percentiles.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			t, err := getTarget(cmd)
+			if err != nil {
+				return err
+			}
+
+			queryID, err := cmdutils.GetUserSetVarFromString(cmd, queryIDFlagName, queryIDEnvKey, false)
+			if err != nil {
+				return err
+			}
+
+			c := gatekeeper.New(t.baseURL, gatekeeper.WithHTTPClient(t.httpClient))
+			req := &operation.ExtractRequest{QueryID: queryID}
+
+			return run(cmd, func(ctx context.Context) error {
+				_, e := c.Extract(ctx, req)
+
+				return e
+			})
+		},
+	}
+
+	addLoadFlags(cmd)
+	cmd.Flags().StringP(queryIDFlagName, "", "", queryIDFlagUsage)
+
+	return cmd
+}
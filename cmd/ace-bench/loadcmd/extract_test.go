@@ -0,0 +1,36 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package loadcmd //nolint:testpackage
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/ace/pkg/restapi/model"
+)
+
+func TestExtractCmd(t *testing.T) {
+	t.Run("test failed requests", func(t *testing.T) {
+		srv, _ := newTestServer(t, "/v1/extract", http.StatusNotFound, &model.ErrorResponse{Message: "no query"})
+
+		out, err := execute(t, GetExtractCmd(), "--"+urlFlagName, srv.URL, "--"+queryIDFlagName, "query",
+			"--"+requestsFlagName, "2")
+		require.NoError(t, err)
+		require.Contains(t, out, `"errors": 2`)
+		require.Contains(t, out, `"error": "POST /v1/extract: status 404: no query"`)
+	})
+
+	t.Run("test missing query id", func(t *testing.T) {
+		srv, _ := newTestServer(t, "/v1/extract", http.StatusOK, nil)
+
+		_, err := execute(t, GetExtractCmd(), "--"+urlFlagName, srv.URL)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "Neither query-id (command line flag) nor BENCH_QUERY_ID")
+	})
+}
@@ -0,0 +1,180 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package loadcmd contains the commands sending load to running services.
+package loadcmd
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+	cmdutils "github.com/trustbloc/edge-core/pkg/utils/cmd"
+	tlsutils "github.com/trustbloc/edge-core/pkg/utils/tls"
+
+	"github.com/trustbloc/ace/cmd/common"
+	"github.com/trustbloc/ace/pkg/bench"
+)
+
+const (
+	urlFlagName  = "url"
+	urlEnvKey    = "BENCH_URL"
+	urlFlagUsage = "Base URL of the REST API of the service, e.g. https://gatekeeper.example.com." +
+		" Alternatively, this can be set with the following environment variable: " + urlEnvKey
+
+	requestsFlagName  = "requests"
+	requestsEnvKey    = "BENCH_REQUESTS"
+	requestsFlagUsage = "Number of requests sent. Defaults to 100, or no limit if a duration is set." +
+		" Alternatively, this can be set with the following environment variable: " + requestsEnvKey
+
+	concurrencyFlagName  = "concurrency"
+	concurrencyEnvKey    = "BENCH_CONCURRENCY"
+	concurrencyFlagUsage = "Number of requests sent at a time. Defaults to 10." +
+		" Alternatively, this can be set with the following environment variable: " + concurrencyEnvKey
+
+	durationFlagName  = "duration"
+	durationEnvKey    = "BENCH_DURATION"
+	durationFlagUsage = "Time requests are sent for, e.g. 30s. The run stops at the first of the number of requests" +
+		" and the duration that is reached." +
+		" Alternatively, this can be set with the following environment variable: " + durationEnvKey
+
+	tlsSystemCertPoolFlagName  = "tls-systemcertpool"
+	tlsSystemCertPoolEnvKey    = "BENCH_TLS_SYSTEMCERTPOOL"
+	tlsSystemCertPoolFlagUsage = "Use system certificate pool." +
+		" Possible values [true] [false]. Defaults to false if not set." +
+		" Alternatively, this can be set with the following environment variable: " + tlsSystemCertPoolEnvKey
+
+	tlsCACertsFlagName  = "tls-cacerts"
+	tlsCACertsEnvKey    = "BENCH_TLS_CACERTS"
+	tlsCACertsFlagUsage = "Comma-Separated list of ca certs path." +
+		" Alternatively, this can be set with the following environment variable: " + tlsCACertsEnvKey
+
+	requestTimeout = 30 * time.Second
+)
+
+func addLoadFlags(cmd *cobra.Command) {
+	cmd.Flags().StringP(urlFlagName, "", "", urlFlagUsage)
+	cmd.Flags().StringP(requestsFlagName, "", "", requestsFlagUsage)
+	cmd.Flags().StringP(concurrencyFlagName, "", "", concurrencyFlagUsage)
+	cmd.Flags().StringP(durationFlagName, "", "", durationFlagUsage)
+	cmd.Flags().StringP(tlsSystemCertPoolFlagName, "", "", tlsSystemCertPoolFlagUsage)
+	cmd.Flags().StringArrayP(tlsCACertsFlagName, "", []string{}, tlsCACertsFlagUsage)
+}
+
+// target is the service the load is sent to.
+type target struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+func getTarget(cmd *cobra.Command) (*target, error) {
+	baseURL, err := cmdutils.GetUserSetVarFromString(cmd, urlFlagName, urlEnvKey, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = common.ValidateURL(urlFlagName, baseURL); err != nil {
+		return nil, err
+	}
+
+	tlsSystemCertPool := false
+
+	if v := cmdutils.GetUserSetOptionalVarFromString(cmd, tlsSystemCertPoolFlagName,
+		tlsSystemCertPoolEnvKey); v != "" {
+		tlsSystemCertPool, err = strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("parse %s: %w", tlsSystemCertPoolFlagName, err)
+		}
+	}
+
+	tlsCACerts := cmdutils.GetUserSetOptionalVarFromArrayString(cmd, tlsCACertsFlagName, tlsCACertsEnvKey)
+
+	rootCAs, err := tlsutils.GetCertPool(tlsSystemCertPool, tlsCACerts)
+	if err != nil {
+		return nil, fmt.Errorf("get cert pool: %w", err)
+	}
+
+	return &target{
+		baseURL: baseURL,
+		httpClient: &http.Client{
+			Timeout: requestTimeout,
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{RootCAs: rootCAs, MinVersion: tls.VersionTLS12},
+			},
+		},
+	}, nil
+}
+
+func getRunOptions(cmd *cobra.Command) ([]bench.Option, error) {
+	var opts []bench.Option
+
+	for _, f := range []struct {
+		name, env string
+		option    func(int) bench.Option
+	}{
+		{requestsFlagName, requestsEnvKey, bench.WithRequests},
+		{concurrencyFlagName, concurrencyEnvKey, bench.WithConcurrency},
+	} {
+		v := cmdutils.GetUserSetOptionalVarFromString(cmd, f.name, f.env)
+		if v == "" {
+			continue
+		}
+
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("parse %s: %w", f.name, err)
+		}
+
+		if n <= 0 {
+			return nil, fmt.Errorf("%s must be positive", f.name)
+		}
+
+		opts = append(opts, f.option(n))
+	}
+
+	if v := cmdutils.GetUserSetOptionalVarFromString(cmd, durationFlagName, durationEnvKey); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("parse %s: %w", durationFlagName, err)
+		}
+
+		if d <= 0 {
+			return nil, fmt.Errorf("%s must be positive", durationFlagName)
+		}
+
+		opts = append(opts, bench.WithDuration(d))
+	}
+
+	return opts, nil
+}
+
+// run runs the operation under the load set by the flags of the command and prints the result.
+func run(cmd *cobra.Command, op bench.Operation) error {
+	opts, err := getRunOptions(cmd)
+	if err != nil {
+		return err
+	}
+
+	res, err := bench.Run(cmd.Context(), op, opts...)
+	if err != nil {
+		return fmt.Errorf("run load: %w", err)
+	}
+
+	b, err := json.MarshalIndent(res, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal result: %w", err)
+	}
+
+	if _, err = fmt.Fprintln(cmd.OutOrStdout(), string(b)); err != nil {
+		return fmt.Errorf("write result: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,131 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package loadcmd //nolint:testpackage
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetTarget(t *testing.T) {
+	t.Run("test missing url", func(t *testing.T) {
+		t.Setenv(urlEnvKey, "")
+		require.NoError(t, os.Unsetenv(urlEnvKey))
+
+		_, err := execute(t, GetExtractCmd(), "--"+queryIDFlagName, "query")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "Neither url (command line flag) nor BENCH_URL (environment variable)"+
+			" have been set.")
+	})
+
+	t.Run("test invalid url", func(t *testing.T) {
+		_, err := execute(t, GetExtractCmd(), "--"+urlFlagName, "gatekeeper")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid url: gatekeeper is not an absolute URL")
+	})
+
+	t.Run("test invalid tls-systemcertpool", func(t *testing.T) {
+		_, err := execute(t, GetExtractCmd(), "--"+urlFlagName, "https://gatekeeper.example.com",
+			"--"+tlsSystemCertPoolFlagName, "wrongvalue")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "parse tls-systemcertpool")
+	})
+
+	t.Run("test invalid tls-cacerts", func(t *testing.T) {
+		_, err := execute(t, GetExtractCmd(), "--"+urlFlagName, "https://gatekeeper.example.com",
+			"--"+tlsCACertsFlagName, "/missing/ca.pem")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "get cert pool")
+	})
+}
+
+func TestRun(t *testing.T) {
+	srv, count := newTestServer(t, "/v1/extract", http.StatusOK, map[string]string{"target": "123"})
+
+	t.Run("test load options", func(t *testing.T) {
+		t.Setenv(concurrencyEnvKey, "2")
+
+		out, err := execute(t, GetExtractCmd(), "--"+urlFlagName, srv.URL, "--"+queryIDFlagName, "query",
+			"--"+requestsFlagName, "5", "--"+durationFlagName, "1m")
+		require.NoError(t, err)
+
+		var res map[string]interface{}
+
+		require.NoError(t, json.Unmarshal([]byte(out), &res))
+		require.EqualValues(t, 5, res["requests"])
+		require.EqualValues(t, 0, res["errors"])
+		require.Contains(t, res, "p99")
+		require.EqualValues(t, 5, atomic.LoadInt32(count))
+	})
+
+	for _, tc := range []struct {
+		flag, value, err string
+	}{
+		{requestsFlagName, "many", "parse requests"},
+		{requestsFlagName, "0", "requests must be positive"},
+		{concurrencyFlagName, "-1", "concurrency must be positive"},
+		{durationFlagName, "long", "parse duration"},
+		{durationFlagName, "-1s", "duration must be positive"},
+	} {
+		t.Run("test invalid "+tc.flag, func(t *testing.T) {
+			_, err := execute(t, GetExtractCmd(), "--"+urlFlagName, srv.URL, "--"+queryIDFlagName, "query",
+				"--"+tc.flag, tc.value)
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tc.err)
+		})
+	}
+}
+
+// newTestServer returns a server responding to POST requests to the path with the status and JSON encoded payload,
+// and the number of requests it was sent. Other requests are responded to with 404.
+func newTestServer(t *testing.T, path string, status int, payload interface{}) (*httptest.Server, *int32) {
+	t.Helper()
+
+	var count int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != path {
+			rw.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		atomic.AddInt32(&count, 1)
+
+		rw.WriteHeader(status)
+
+		if payload != nil {
+			require.NoError(t, json.NewEncoder(rw).Encode(payload))
+		}
+	}))
+
+	t.Cleanup(srv.Close)
+
+	return srv, &count
+}
+
+func execute(t *testing.T, cmd *cobra.Command, args ...string) (string, error) {
+	t.Helper()
+
+	var out bytes.Buffer
+
+	cmd.SetOut(&out)
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs(args)
+
+	err := cmd.Execute()
+
+	return out.String(), err
+}
@@ -0,0 +1,122 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package loadcmd
+
+import (
+	"context"
+	"crypto/ed25519"
+	"fmt"
+
+	"github.com/btcsuite/btcutil/base58"
+	"github.com/spf13/cobra"
+	cmdutils "github.com/trustbloc/edge-core/pkg/utils/cmd"
+
+	"github.com/trustbloc/ace/pkg/client/gatekeeper"
+	"github.com/trustbloc/ace/pkg/restapi/gatekeeper/operation"
+)
+
+const (
+	policyFlagName  = "policy"
+	policyEnvKey    = "BENCH_POLICY"
+	policyFlagUsage = "ID of the policy the targets are protected with." +
+		" Alternatively, this can be set with the following environment variable: " + policyEnvKey
+
+	targetFlagName  = "target"
+	targetEnvKey    = "BENCH_TARGET"
+	targetFlagUsage = "Sensitive data protected by every request." +
+		" Alternatively, this can be set with the following environment variable: " + targetEnvKey
+
+	keyIDFlagName  = "key-id"
+	keyIDEnvKey    = "BENCH_KEY_ID"
+	keyIDFlagUsage = "DID URL of the key requests are signed with, e.g. did:example:123#key-1. The DID must be a" +
+		" collector of the policy." +
+		" Alternatively, this can be set with the following environment variable: " + keyIDEnvKey
+
+	privateKeyFlagName  = "private-key"
+	privateKeyEnvKey    = "BENCH_PRIVATE_KEY"
+	privateKeyFlagUsage = "Base58 encoded Ed25519 private key requests are signed with." +
+		" Alternatively, this can be set with the following environment variable: " + privateKeyEnvKey
+)
+
+// GetProtectCmd returns the Cobra protect command.
+func GetProtectCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "protect",
+		Short: "Sends protect requests to Gatekeeper",
+		Long: "Sends protect requests to the Gatekeeper REST API, signed with the key of a collector of the policy," +
+			" and reports their throughput and latency percentiles.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			t, err := getTarget(cmd)
+			if err != nil {
+				return err
+			}
+
+			req, err := getProtectRequest(cmd)
+			if err != nil {
+				return err
+			}
+
+			keyID, err := cmdutils.GetUserSetVarFromString(cmd, keyIDFlagName, keyIDEnvKey, false)
+			if err != nil {
+				return err
+			}
+
+			privateKey, err := getPrivateKey(cmd)
+			if err != nil {
+				return err
+			}
+
+			c := gatekeeper.New(t.baseURL,
+				gatekeeper.WithHTTPClient(t.httpClient),
+				gatekeeper.WithSigner(keyID, privateKey),
+			)
+
+			return run(cmd, func(ctx context.Context) error {
+				_, e := c.Protect(ctx, req)
+
+				return e
+			})
+		},
+	}
+
+	addLoadFlags(cmd)
+	cmd.Flags().StringP(policyFlagName, "", "", policyFlagUsage)
+	cmd.Flags().StringP(targetFlagName, "", "", targetFlagUsage)
+	cmd.Flags().StringP(keyIDFlagName, "", "", keyIDFlagUsage)
+	cmd.Flags().StringP(privateKeyFlagName, "", "", privateKeyFlagUsage)
+
+	return cmd
+}
+
+func getProtectRequest(cmd *cobra.Command) (*operation.ProtectRequest, error) {
+	policyID, err := cmdutils.GetUserSetVarFromString(cmd, policyFlagName, policyEnvKey, false)
+	if err != nil {
+		return nil, err
+	}
+
+	target, err := cmdutils.GetUserSetVarFromString(cmd, targetFlagName, targetEnvKey, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return &operation.ProtectRequest{Policy: policyID, Target: target}, nil
+}
+
+func getPrivateKey(cmd *cobra.Command) (ed25519.PrivateKey, error) {
+	v, err := cmdutils.GetUserSetVarFromString(cmd, privateKeyFlagName, privateKeyEnvKey, false)
+	if err != nil {
+		return nil, err
+	}
+
+	key := base58.Decode(v)
+	if len(key) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("%s must be a base58 encoded Ed25519 private key", privateKeyFlagName)
+	}
+
+	return key, nil
+}
@@ -0,0 +1,64 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package loadcmd //nolint:testpackage
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcutil/base58"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/ace/pkg/restapi/gatekeeper/operation"
+)
+
+func TestProtectCmd(t *testing.T) {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		var req operation.ProtectRequest
+
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		require.Equal(t, operation.ProtectRequest{Policy: "policy", Target: "123"}, req)
+		require.True(t, strings.Contains(r.Header.Get("Signature"), `keyId="did:example:123#key-1"`))
+
+		require.NoError(t, json.NewEncoder(rw).Encode(&operation.ProtectResponse{DID: "did:example:protected"}))
+	}))
+	defer srv.Close()
+
+	args := []string{
+		"--" + urlFlagName, srv.URL,
+		"--" + policyFlagName, "policy",
+		"--" + targetFlagName, "123",
+		"--" + keyIDFlagName, "did:example:123#key-1",
+		"--" + requestsFlagName, "3",
+	}
+
+	t.Run("test protect", func(t *testing.T) {
+		out, err := execute(t, GetProtectCmd(), append(args, "--"+privateKeyFlagName, base58.Encode(privateKey))...)
+		require.NoError(t, err)
+		require.Contains(t, out, `"requests": 3`)
+		require.Contains(t, out, `"errors": 0`)
+	})
+
+	t.Run("test invalid private key", func(t *testing.T) {
+		_, err := execute(t, GetProtectCmd(), append(args, "--"+privateKeyFlagName, "key")...)
+		require.EqualError(t, err, "private-key must be a base58 encoded Ed25519 private key")
+	})
+
+	t.Run("test missing policy", func(t *testing.T) {
+		_, err := execute(t, GetProtectCmd(), "--"+urlFlagName, srv.URL)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "Neither policy (command line flag) nor BENCH_POLICY")
+	})
+}
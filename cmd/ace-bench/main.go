@@ -0,0 +1,37 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package main ACE load generator.
+//
+// The command sends protect, extract and compare requests to running services and reports their throughput and
+// latency percentiles.
+package main
+
+import (
+	"github.com/hyperledger/aries-framework-go/pkg/common/log"
+	"github.com/spf13/cobra"
+
+	"github.com/trustbloc/ace/cmd/ace-bench/loadcmd"
+)
+
+var logger = log.New("ace-bench")
+
+func main() {
+	rootCmd := &cobra.Command{
+		Use: "ace-bench",
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.HelpFunc()(cmd, args)
+		},
+	}
+
+	rootCmd.AddCommand(loadcmd.GetProtectCmd())
+	rootCmd.AddCommand(loadcmd.GetExtractCmd())
+	rootCmd.AddCommand(loadcmd.GetCompareCmd())
+
+	if err := rootCmd.Execute(); err != nil {
+		logger.Fatalf("execute root cmd: %s", err.Error())
+	}
+}
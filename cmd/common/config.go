@@ -0,0 +1,221 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package common
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	cmdutils "github.com/trustbloc/edge-core/pkg/utils/cmd"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// ConfigFileFlagName is the flag name used for setting the path to the configuration file.
+	ConfigFileFlagName = "config"
+	// ConfigFileEnvKey is the env var name used for setting the path to the configuration file.
+	ConfigFileEnvKey = "CONFIG_FILE"
+	// ConfigFileFlagUsage is the usage text for the configuration file flag.
+	ConfigFileFlagUsage = "Path to a YAML or JSON file with the settings of the command, keyed by the names of their" +
+		" environment variables. Flags and environment variables take precedence over the file." +
+		" Alternatively, this can be set with the following environment variable: " + ConfigFileEnvKey
+
+	// PrintConfigFlagName is the flag name used for printing the effective configuration.
+	PrintConfigFlagName = "print-config"
+	// PrintConfigFlagUsage is the usage text for the print configuration flag.
+	PrintConfigFlagUsage = "Print the effective configuration with secrets redacted and exit. The output is in the" +
+		" format of the configuration file, but the redacted secrets must be set again to use it as one."
+
+	// Redacted replaces the values of secrets in the printed configuration.
+	Redacted = "REDACTED"
+)
+
+// ConfigFileFlag adds the configuration file and print configuration flags to the command.
+func ConfigFileFlag(cmd *cobra.Command) {
+	cmd.Flags().StringP(ConfigFileFlagName, "", "", ConfigFileFlagUsage)
+	cmd.Flags().BoolP(PrintConfigFlagName, "", false, PrintConfigFlagUsage)
+}
+
+// ConfigFile is a loaded configuration file.
+type ConfigFile struct {
+	path string
+	// keys are the environment variables set from the file
+	keys map[string]struct{}
+}
+
+// LoadConfigFile loads the configuration file of the command, if one is set. Each setting of the file is exported as
+// the environment variable it is keyed by unless that variable is already set, so that flags take precedence over
+// environment variables, which take precedence over the file. Lists are joined with commas, the way the commands
+// read list settings from environment variables.
+func LoadConfigFile(cmd *cobra.Command) (*ConfigFile, error) {
+	f := &ConfigFile{
+		path: cmdutils.GetUserSetOptionalVarFromString(cmd, ConfigFileFlagName, ConfigFileEnvKey),
+		keys: map[string]struct{}{},
+	}
+
+	if err := f.Reload(); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// Reload reads the configuration file again. Settings of the file override the environment variables set from it
+// before, and settings removed from the file are unset. The environment is left unchanged if the file is invalid.
+// Reload is not safe for concurrent use.
+func (f *ConfigFile) Reload() error {
+	if f.path == "" {
+		return nil
+	}
+
+	b, err := os.ReadFile(filepath.Clean(f.path))
+	if err != nil {
+		return fmt.Errorf("read config file: %w", err)
+	}
+
+	var settings map[string]interface{}
+
+	// JSON is a subset of YAML, so both formats are parsed the same way
+	if err = yaml.Unmarshal(b, &settings); err != nil {
+		return fmt.Errorf("parse config file: %w", err)
+	}
+
+	values := make(map[string]string, len(settings))
+
+	for key, value := range settings {
+		v, err := settingValue(value)
+		if err != nil {
+			return fmt.Errorf("parse config file: %s: %w", key, err)
+		}
+
+		values[key] = v
+	}
+
+	for key := range f.keys {
+		if _, ok := values[key]; ok {
+			continue
+		}
+
+		if err = os.Unsetenv(key); err != nil {
+			return fmt.Errorf("unset %s: %w", key, err)
+		}
+
+		delete(f.keys, key)
+	}
+
+	for key, v := range values {
+		if _, fromFile := f.keys[key]; !fromFile {
+			if _, ok := os.LookupEnv(key); ok {
+				continue
+			}
+		}
+
+		if err = os.Setenv(key, v); err != nil {
+			return fmt.Errorf("set %s: %w", key, err)
+		}
+
+		f.keys[key] = struct{}{}
+	}
+
+	return nil
+}
+
+func settingValue(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int:
+		return strconv.Itoa(v), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case []interface{}:
+		values := make([]string, len(v))
+
+		for i, e := range v {
+			s, err := settingValue(e)
+			if err != nil {
+				return "", err
+			}
+
+			values[i] = s
+		}
+
+		return strings.Join(values, ","), nil
+	default:
+		return "", fmt.Errorf("unsupported value of type %T", value)
+	}
+}
+
+// PrintConfigSet tells if the command is asked to print its configuration instead of running.
+func PrintConfigSet(cmd *cobra.Command) bool {
+	v, err := cmd.Flags().GetBool(PrintConfigFlagName)
+
+	return err == nil && v
+}
+
+// PrintConfig prints the settings of the command as YAML keyed by the names of their environment variables, the
+// format of the configuration file. Secrets must be redacted by the caller.
+func PrintConfig(cmd *cobra.Command, settings map[string]interface{}) error {
+	b, err := yaml.Marshal(settings)
+	if err != nil {
+		return fmt.Errorf("marshal config: %w", err)
+	}
+
+	if _, err = cmd.OutOrStdout().Write(b); err != nil {
+		return fmt.Errorf("print config: %w", err)
+	}
+
+	return nil
+}
+
+// RedactSecret returns Redacted if the secret is set.
+func RedactSecret(secret string) string {
+	if secret == "" {
+		return ""
+	}
+
+	return Redacted
+}
+
+// RedactURL returns the URL with its password redacted.
+func RedactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		// the URL may hold credentials that cannot be told apart from the rest of it
+		return RedactSecret(rawURL)
+	}
+
+	if _, ok := u.User.Password(); ok {
+		u.User = url.UserPassword(u.User.Username(), Redacted)
+	}
+
+	return u.String()
+}
+
+// RedactTokens returns the tokens in the name=value format of the request tokens settings, with their values
+// redacted.
+func RedactTokens(tokens map[string]string) []string {
+	redacted := make([]string, 0, len(tokens))
+
+	for name, token := range tokens {
+		redacted = append(redacted, name+"="+RedactSecret(token))
+	}
+
+	sort.Strings(redacted)
+
+	return redacted
+}
@@ -0,0 +1,56 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package common
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/spf13/cobra"
+	cmdutils "github.com/trustbloc/edge-core/pkg/utils/cmd"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+const (
+	// H2CFlagName is the flag name used for serving HTTP/2 without TLS.
+	H2CFlagName = "h2c"
+	// H2CEnvKey is the env var name used for serving HTTP/2 without TLS.
+	H2CEnvKey = "H2C"
+	// H2CFlagUsage is the usage text for the h2c flag.
+	H2CFlagUsage = "Serve HTTP/2 without TLS (h2c) in addition to HTTP/1.1, e.g. behind a service mesh that" +
+		" terminates TLS. Cannot be used with a serve certificate, as HTTP/2 is always served over TLS." +
+		" Possible values [true] [false]. Defaults to false if not set." +
+		" Alternatively, this can be set with the following environment variable: " + H2CEnvKey
+)
+
+// H2CFlag adds the h2c flag to the command.
+func H2CFlag(cmd *cobra.Command) {
+	cmd.Flags().StringP(H2CFlagName, "", "", H2CFlagUsage)
+}
+
+// H2CEnabled tells if the command is asked to serve HTTP/2 without TLS.
+func H2CEnabled(cmd *cobra.Command) (bool, error) {
+	v := cmdutils.GetUserSetOptionalVarFromString(cmd, H2CFlagName, H2CEnvKey)
+	if v == "" {
+		return false, nil
+	}
+
+	enabled, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("parse %s: %w", H2CFlagName, err)
+	}
+
+	return enabled, nil
+}
+
+// H2CHandler returns the handler serving HTTP/2 requests made without TLS, with prior knowledge or upgraded from
+// HTTP/1.1, in addition to HTTP/1.1 requests.
+func H2CHandler(handler http.Handler) http.Handler {
+	return h2c.NewHandler(handler, &http2.Server{})
+}
@@ -0,0 +1,81 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package common_test
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/http2"
+
+	"github.com/trustbloc/ace/cmd/common"
+)
+
+func TestH2CEnabled(t *testing.T) {
+	t.Setenv(common.H2CEnvKey, "")
+
+	tests := []struct {
+		name    string
+		args    []string
+		enabled bool
+		err     string
+	}{
+		{"not set", nil, false, ""},
+		{"enabled", []string{"--" + common.H2CFlagName, "true"}, true, ""},
+		{"disabled", []string{"--" + common.H2CFlagName, "false"}, false, ""},
+		{"invalid", []string{"--" + common.H2CFlagName, "maybe"}, false, "parse h2c"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := &cobra.Command{}
+			common.H2CFlag(cmd)
+
+			require.NoError(t, cmd.ParseFlags(tt.args))
+
+			enabled, err := common.H2CEnabled(cmd)
+			if tt.err != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tt.err)
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.enabled, enabled)
+		})
+	}
+}
+
+func TestH2CHandler(t *testing.T) {
+	srv := httptest.NewServer(common.H2CHandler(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.Header().Set("X-Proto", r.Proto)
+	})))
+	defer srv.Close()
+
+	client := &http.Client{Transport: &http2.Transport{
+		AllowHTTP: true,
+		DialTLS: func(network, addr string, _ *tls.Config) (net.Conn, error) {
+			return net.Dial(network, addr)
+		},
+	}}
+
+	resp, err := client.Get(srv.URL) //nolint:noctx
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, "HTTP/2.0", resp.Header.Get("X-Proto"))
+
+	resp, err = http.Get(srv.URL) //nolint:noctx
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, "HTTP/1.1", resp.Header.Get("X-Proto"))
+}
@@ -0,0 +1,160 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package common
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+	cmdutils "github.com/trustbloc/edge-core/pkg/utils/cmd"
+)
+
+const (
+	// HTTPIdleConnsPerHostFlagName is the flag name used for setting the idle connections kept per host.
+	HTTPIdleConnsPerHostFlagName = "http-idle-conns-per-host"
+	// HTTPIdleConnsPerHostEnvKey is the env var name used for setting the idle connections kept per host.
+	HTTPIdleConnsPerHostEnvKey = "HTTP_IDLE_CONNS_PER_HOST"
+	// HTTPIdleConnsPerHostFlagUsage is the usage text for the idle connections per host flag.
+	HTTPIdleConnsPerHostFlagUsage = "Maximum number of idle connections kept open to each downstream service," +
+		" for reuse by later requests. Defaults to 100 if not set." +
+		" Alternatively, this can be set with the following environment variable: " + HTTPIdleConnsPerHostEnvKey
+
+	// HTTPMaxConnsPerHostFlagName is the flag name used for setting the connections allowed per host.
+	HTTPMaxConnsPerHostFlagName = "http-max-conns-per-host"
+	// HTTPMaxConnsPerHostEnvKey is the env var name used for setting the connections allowed per host.
+	HTTPMaxConnsPerHostEnvKey = "HTTP_MAX_CONNS_PER_HOST"
+	// HTTPMaxConnsPerHostFlagUsage is the usage text for the connections per host flag.
+	HTTPMaxConnsPerHostFlagUsage = "Maximum number of connections open to each downstream service, including the" +
+		" ones in use. Requests wait for a connection once it is reached. Defaults to 0, which does not limit them." +
+		" Alternatively, this can be set with the following environment variable: " + HTTPMaxConnsPerHostEnvKey
+
+	// HTTPIdleConnTimeoutFlagName is the flag name used for setting the time idle connections are kept open.
+	HTTPIdleConnTimeoutFlagName = "http-idle-conn-timeout"
+	// HTTPIdleConnTimeoutEnvKey is the env var name used for setting the time idle connections are kept open.
+	HTTPIdleConnTimeoutEnvKey = "HTTP_IDLE_CONN_TIMEOUT"
+	// HTTPIdleConnTimeoutFlagUsage is the usage text for the idle connection timeout flag.
+	HTTPIdleConnTimeoutFlagUsage = "Time idle connections to downstream services are kept open, e.g. 30s or 2m." +
+		" Defaults to 90s if not set." +
+		" Alternatively, this can be set with the following environment variable: " + HTTPIdleConnTimeoutEnvKey
+
+	// HTTPTimeoutFlagName is the flag name used for setting the timeout of requests to downstream services.
+	HTTPTimeoutFlagName = "http-timeout"
+	// HTTPTimeoutEnvKey is the env var name used for setting the timeout of requests to downstream services.
+	HTTPTimeoutEnvKey = "HTTP_TIMEOUT"
+	// HTTPTimeoutFlagUsage is the usage text for the request timeout flag.
+	HTTPTimeoutFlagUsage = "Time limit of each request to a downstream service, including reading the response," +
+		" e.g. 10s or 1m. Defaults to 1m if not set." +
+		" Alternatively, this can be set with the following environment variable: " + HTTPTimeoutEnvKey
+
+	defaultHTTPMaxIdleConnsPerHost = 100
+	defaultHTTPIdleConnTimeout     = 90 * time.Second
+	defaultHTTPTimeout             = time.Minute
+)
+
+// HTTPClientParameters holds the configuration of the HTTP client used for requests to downstream services.
+type HTTPClientParameters struct {
+	MaxIdleConnsPerHost int
+	MaxConnsPerHost     int
+	IdleConnTimeout     time.Duration
+	Timeout             time.Duration
+}
+
+// Settings returns the HTTP client settings keyed by their environment variables.
+func (p *HTTPClientParameters) Settings() map[string]interface{} {
+	return map[string]interface{}{
+		HTTPIdleConnsPerHostEnvKey: p.MaxIdleConnsPerHost,
+		HTTPMaxConnsPerHostEnvKey:  p.MaxConnsPerHost,
+		HTTPIdleConnTimeoutEnvKey:  p.IdleConnTimeout.String(),
+		HTTPTimeoutEnvKey:          p.Timeout.String(),
+	}
+}
+
+// HTTPClientFlags adds the HTTP client flags to the command.
+func HTTPClientFlags(cmd *cobra.Command) {
+	cmd.Flags().StringP(HTTPIdleConnsPerHostFlagName, "", "", HTTPIdleConnsPerHostFlagUsage)
+	cmd.Flags().StringP(HTTPMaxConnsPerHostFlagName, "", "", HTTPMaxConnsPerHostFlagUsage)
+	cmd.Flags().StringP(HTTPIdleConnTimeoutFlagName, "", "", HTTPIdleConnTimeoutFlagUsage)
+	cmd.Flags().StringP(HTTPTimeoutFlagName, "", "", HTTPTimeoutFlagUsage)
+}
+
+// HTTPClientParams returns the HTTP client parameters set for the command.
+func HTTPClientParams(cmd *cobra.Command) (*HTTPClientParameters, error) {
+	params := &HTTPClientParameters{
+		MaxIdleConnsPerHost: defaultHTTPMaxIdleConnsPerHost,
+		IdleConnTimeout:     defaultHTTPIdleConnTimeout,
+		Timeout:             defaultHTTPTimeout,
+	}
+
+	ints := []struct {
+		name   string
+		envKey string
+		value  *int
+	}{
+		{HTTPIdleConnsPerHostFlagName, HTTPIdleConnsPerHostEnvKey, &params.MaxIdleConnsPerHost},
+		{HTTPMaxConnsPerHostFlagName, HTTPMaxConnsPerHostEnvKey, &params.MaxConnsPerHost},
+	}
+
+	for _, i := range ints {
+		if v := cmdutils.GetUserSetOptionalVarFromString(cmd, i.name, i.envKey); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return nil, fmt.Errorf("parse %s: %w", i.name, err)
+			}
+
+			if n < 0 {
+				return nil, fmt.Errorf("%s must not be negative", i.name)
+			}
+
+			*i.value = n
+		}
+	}
+
+	durations := []struct {
+		name   string
+		envKey string
+		value  *time.Duration
+	}{
+		{HTTPIdleConnTimeoutFlagName, HTTPIdleConnTimeoutEnvKey, &params.IdleConnTimeout},
+		{HTTPTimeoutFlagName, HTTPTimeoutEnvKey, &params.Timeout},
+	}
+
+	for _, d := range durations {
+		if v := cmdutils.GetUserSetOptionalVarFromString(cmd, d.name, d.envKey); v != "" {
+			duration, err := time.ParseDuration(v)
+			if err != nil {
+				return nil, fmt.Errorf("parse %s: %w", d.name, err)
+			}
+
+			if duration <= 0 {
+				return nil, fmt.Errorf("%s must be positive", d.name)
+			}
+
+			*d.value = duration
+		}
+	}
+
+	return params, nil
+}
+
+// NewHTTPClient returns an HTTP client for requests to downstream services, with a transport configured by the
+// parameters and the TLS configuration.
+func NewHTTPClient(params *HTTPClientParameters, tlsConfig *tls.Config) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone() //nolint:forcetypeassert,errcheck
+
+	transport.TLSClientConfig = tlsConfig
+	// idle connections are only limited per host
+	transport.MaxIdleConns = 0
+	transport.MaxIdleConnsPerHost = params.MaxIdleConnsPerHost
+	transport.MaxConnsPerHost = params.MaxConnsPerHost
+	transport.IdleConnTimeout = params.IdleConnTimeout
+
+	return &http.Client{Transport: transport, Timeout: params.Timeout}
+}
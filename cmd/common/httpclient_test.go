@@ -0,0 +1,118 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package common_test
+
+import (
+	"crypto/tls"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/ace/cmd/common"
+)
+
+func TestHTTPClientParams(t *testing.T) {
+	for _, envKey := range []string{
+		common.HTTPIdleConnsPerHostEnvKey, common.HTTPMaxConnsPerHostEnvKey,
+		common.HTTPIdleConnTimeoutEnvKey, common.HTTPTimeoutEnvKey,
+	} {
+		t.Setenv(envKey, "")
+	}
+
+	tests := []struct {
+		name   string
+		args   []string
+		params *common.HTTPClientParameters
+		err    string
+	}{
+		{
+			"defaults",
+			nil,
+			&common.HTTPClientParameters{MaxIdleConnsPerHost: 100, IdleConnTimeout: 90 * time.Second, Timeout: time.Minute},
+			"",
+		},
+		{
+			"set",
+			[]string{
+				"--" + common.HTTPIdleConnsPerHostFlagName, "20", "--" + common.HTTPMaxConnsPerHostFlagName, "50",
+				"--" + common.HTTPIdleConnTimeoutFlagName, "30s", "--" + common.HTTPTimeoutFlagName, "10s",
+			},
+			&common.HTTPClientParameters{
+				MaxIdleConnsPerHost: 20, MaxConnsPerHost: 50, IdleConnTimeout: 30 * time.Second, Timeout: 10 * time.Second,
+			},
+			"",
+		},
+		{
+			"invalid idle conns per host",
+			[]string{"--" + common.HTTPIdleConnsPerHostFlagName, "many"},
+			nil,
+			"parse http-idle-conns-per-host",
+		},
+		{
+			"negative max conns per host",
+			[]string{"--" + common.HTTPMaxConnsPerHostFlagName, "-1"},
+			nil,
+			"http-max-conns-per-host must not be negative",
+		},
+		{
+			"invalid idle conn timeout",
+			[]string{"--" + common.HTTPIdleConnTimeoutFlagName, "long"},
+			nil,
+			"parse http-idle-conn-timeout",
+		},
+		{
+			"zero timeout",
+			[]string{"--" + common.HTTPTimeoutFlagName, "0s"},
+			nil,
+			"http-timeout must be positive",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := &cobra.Command{}
+			common.HTTPClientFlags(cmd)
+
+			require.NoError(t, cmd.ParseFlags(tt.args))
+
+			params, err := common.HTTPClientParams(cmd)
+			if tt.err != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tt.err)
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.params, params)
+		})
+	}
+}
+
+func TestNewHTTPClient(t *testing.T) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	client := common.NewHTTPClient(&common.HTTPClientParameters{
+		MaxIdleConnsPerHost: 20,
+		MaxConnsPerHost:     50,
+		IdleConnTimeout:     30 * time.Second,
+		Timeout:             10 * time.Second,
+	}, tlsConfig)
+
+	require.Equal(t, 10*time.Second, client.Timeout)
+
+	transport, ok := client.Transport.(*http.Transport)
+	require.True(t, ok)
+	require.Equal(t, tlsConfig, transport.TLSClientConfig)
+	require.Equal(t, 20, transport.MaxIdleConnsPerHost)
+	require.Equal(t, 50, transport.MaxConnsPerHost)
+	require.Equal(t, 30*time.Second, transport.IdleConnTimeout)
+	require.Zero(t, transport.MaxIdleConns)
+}
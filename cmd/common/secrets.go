@@ -0,0 +1,382 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package common
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	cmdutils "github.com/trustbloc/edge-core/pkg/utils/cmd"
+
+	"github.com/trustbloc/ace/pkg/secrets"
+)
+
+const (
+	// SecretsVaultAddrFlagName is the flag name used for setting the address of the HashiCorp Vault server.
+	SecretsVaultAddrFlagName = "secrets-vault-addr"
+	// SecretsVaultAddrEnvKey is the env var name used for setting the address of the HashiCorp Vault server.
+	SecretsVaultAddrEnvKey = "SECRETS_VAULT_ADDR"
+	// SecretsVaultAddrFlagUsage is the usage text for the Vault address flag.
+	SecretsVaultAddrFlagUsage = "Address of the HashiCorp Vault server the vault:// secret references are resolved" +
+		" with, e.g. https://vault.example.com:8200." +
+		" Alternatively, this can be set with the following environment variable: " + SecretsVaultAddrEnvKey
+
+	// SecretsVaultTokenFlagName is the flag name used for setting the token of the HashiCorp Vault server.
+	SecretsVaultTokenFlagName = "secrets-vault-token"
+	// SecretsVaultTokenEnvKey is the env var name used for setting the token of the HashiCorp Vault server.
+	SecretsVaultTokenEnvKey = "SECRETS_VAULT_TOKEN" //nolint:gosec
+	// SecretsVaultTokenFlagUsage is the usage text for the Vault token flag.
+	SecretsVaultTokenFlagUsage = "Token used to authenticate to the HashiCorp Vault server." +
+		" Alternatively, this can be set with the following environment variable: " + SecretsVaultTokenEnvKey
+
+	// SecretsAWSRegionFlagName is the flag name used for setting the region of AWS Secrets Manager.
+	SecretsAWSRegionFlagName = "secrets-aws-region"
+	// SecretsAWSRegionEnvKey is the env var name used for setting the region of AWS Secrets Manager.
+	SecretsAWSRegionEnvKey = "SECRETS_AWS_REGION"
+	// SecretsAWSRegionFlagUsage is the usage text for the AWS region flag.
+	SecretsAWSRegionFlagUsage = "Region of AWS Secrets Manager the aws-sm:// secret references are resolved with." +
+		" Defaults to the region of the " + AWSRegionEnvKey + " environment variable, or us-east-1. The credentials" +
+		" are read from the " + AWSAccessKeyIDEnvKey + ", " + AWSSecretAccessKeyEnvKey + " and " +
+		AWSSessionTokenEnvKey + " environment variables, or else are those of the IAM role of the ECS task or of the" +
+		" EC2 instance." +
+		" Alternatively, this can be set with the following environment variable: " + SecretsAWSRegionEnvKey
+
+	// SecretsGCPTokenFlagName is the flag name used for setting the access token of GCP Secret Manager.
+	SecretsGCPTokenFlagName = "secrets-gcp-token"
+	// SecretsGCPTokenEnvKey is the env var name used for setting the access token of GCP Secret Manager.
+	SecretsGCPTokenEnvKey = "SECRETS_GCP_TOKEN" //nolint:gosec
+	// SecretsGCPTokenFlagUsage is the usage text for the GCP token flag.
+	SecretsGCPTokenFlagUsage = "OAuth 2.0 access token used to resolve the gcp-sm:// secret references with GCP" +
+		" Secret Manager. Defaults to a token of the default service account of the compute instance." +
+		" Alternatively, this can be set with the following environment variable: " + SecretsGCPTokenEnvKey
+
+	// SecretsRefreshIntervalFlagName is the flag name used for setting the interval of the refresh of the secrets.
+	SecretsRefreshIntervalFlagName = "secrets-refresh-interval"
+	// SecretsRefreshIntervalEnvKey is the env var name used for setting the interval of the refresh of the secrets.
+	SecretsRefreshIntervalEnvKey = "SECRETS_REFRESH_INTERVAL"
+	// SecretsRefreshIntervalFlagUsage is the usage text for the secrets refresh interval flag.
+	SecretsRefreshIntervalFlagUsage = "Time between two resolutions of the secret references, e.g. 15m or 1h." +
+		" Defaults to 0, which only resolves them at startup." +
+		" Alternatively, this can be set with the following environment variable: " + SecretsRefreshIntervalEnvKey
+
+	// AWSRegionEnvKey is the env var name of the default AWS region.
+	AWSRegionEnvKey = "AWS_REGION"
+	// AWSAccessKeyIDEnvKey is the env var name of the ID of the AWS access key.
+	AWSAccessKeyIDEnvKey = "AWS_ACCESS_KEY_ID"
+	// AWSSecretAccessKeyEnvKey is the env var name of the secret of the AWS access key.
+	AWSSecretAccessKeyEnvKey = "AWS_SECRET_ACCESS_KEY" //nolint:gosec
+	// AWSSessionTokenEnvKey is the env var name of the session token of temporary AWS credentials.
+	AWSSessionTokenEnvKey = "AWS_SESSION_TOKEN" //nolint:gosec
+
+	secretsTimeout = 30 * time.Second
+	secretFileMode = 0o600
+)
+
+// SecretsParameters holds the configuration of the backends the secret references are resolved with.
+type SecretsParameters struct {
+	VaultAddr       string
+	VaultToken      string
+	AWSRegion       string
+	GCPToken        string
+	RefreshInterval time.Duration
+}
+
+// Settings returns the secrets settings keyed by their environment variables, with the tokens redacted.
+func (p *SecretsParameters) Settings() map[string]interface{} {
+	return map[string]interface{}{
+		SecretsVaultAddrEnvKey:       p.VaultAddr,
+		SecretsVaultTokenEnvKey:      RedactSecret(p.VaultToken),
+		SecretsAWSRegionEnvKey:       p.AWSRegion,
+		SecretsGCPTokenEnvKey:        RedactSecret(p.GCPToken),
+		SecretsRefreshIntervalEnvKey: p.RefreshInterval.String(),
+	}
+}
+
+// SecretsFlags adds the secrets flags to the command.
+func SecretsFlags(cmd *cobra.Command) {
+	cmd.Flags().StringP(SecretsVaultAddrFlagName, "", "", SecretsVaultAddrFlagUsage)
+	cmd.Flags().StringP(SecretsVaultTokenFlagName, "", "", SecretsVaultTokenFlagUsage)
+	cmd.Flags().StringP(SecretsAWSRegionFlagName, "", "", SecretsAWSRegionFlagUsage)
+	cmd.Flags().StringP(SecretsGCPTokenFlagName, "", "", SecretsGCPTokenFlagUsage)
+	cmd.Flags().StringP(SecretsRefreshIntervalFlagName, "", "", SecretsRefreshIntervalFlagUsage)
+}
+
+// SecretsParams returns the secrets parameters set for the command.
+func SecretsParams(cmd *cobra.Command) (*SecretsParameters, error) {
+	params := &SecretsParameters{
+		VaultAddr:  cmdutils.GetUserSetOptionalVarFromString(cmd, SecretsVaultAddrFlagName, SecretsVaultAddrEnvKey),
+		VaultToken: cmdutils.GetUserSetOptionalVarFromString(cmd, SecretsVaultTokenFlagName, SecretsVaultTokenEnvKey),
+		AWSRegion:  cmdutils.GetUserSetOptionalVarFromString(cmd, SecretsAWSRegionFlagName, SecretsAWSRegionEnvKey),
+		GCPToken:   cmdutils.GetUserSetOptionalVarFromString(cmd, SecretsGCPTokenFlagName, SecretsGCPTokenEnvKey),
+	}
+
+	if v := cmdutils.GetUserSetOptionalVarFromString(cmd, SecretsRefreshIntervalFlagName,
+		SecretsRefreshIntervalEnvKey); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("parse %s: %w", SecretsRefreshIntervalFlagName, err)
+		}
+
+		if d < 0 {
+			return nil, fmt.Errorf("%s must not be negative", SecretsRefreshIntervalFlagName)
+		}
+
+		params.RefreshInterval = d
+	}
+
+	return params, nil
+}
+
+// Secrets replaces the secret references set as the values of the settings of a command, with its flags, environment
+// variables or configuration file, with the values of the secrets. The elements of list settings are resolved one by
+// one. The settings that are paths to files, e.g. TLS certificates, are set to the paths of files holding the
+// secrets, readable only by the user of the command.
+type Secrets struct {
+	resolver     *secrets.Resolver
+	fileSettings map[string]struct{}
+	dir          string
+	// settings are the settings set to secret references, keyed by their flags or environment variables
+	settings map[string]*secretSetting
+}
+
+type secretSetting struct {
+	// refs are the references of the elements of the setting, empty for the elements that are not secrets
+	refs []string
+	// values are the elements the setting was last set to
+	values []string
+}
+
+// NewSecrets returns a new instance of Secrets resolving references with the backends of the parameters.
+// fileSettings are the names of the flags and environment variables of the settings that are paths to files.
+func NewSecrets(params *SecretsParameters, fileSettings ...string) *Secrets {
+	client := &http.Client{Timeout: secretsTimeout}
+
+	region := params.AWSRegion
+	if region == "" {
+		region = os.Getenv(AWSRegionEnvKey)
+	}
+
+	opts := []secrets.Option{
+		secrets.WithBackend(secrets.AWSScheme, &secrets.AWS{
+			Region:          region,
+			AccessKeyID:     os.Getenv(AWSAccessKeyIDEnvKey),
+			SecretAccessKey: os.Getenv(AWSSecretAccessKeyEnvKey),
+			SessionToken:    os.Getenv(AWSSessionTokenEnvKey),
+			HTTPClient:      client,
+		}),
+		secrets.WithBackend(secrets.GCPScheme, &secrets.GCP{Token: params.GCPToken, HTTPClient: client}),
+	}
+
+	if params.VaultAddr != "" {
+		opts = append(opts, secrets.WithBackend(secrets.VaultScheme, &secrets.Vault{
+			Address:    params.VaultAddr,
+			Token:      params.VaultToken,
+			HTTPClient: client,
+		}))
+	}
+
+	s := &Secrets{
+		resolver:     secrets.NewResolver(opts...),
+		fileSettings: map[string]struct{}{},
+		settings:     map[string]*secretSetting{},
+	}
+
+	for _, name := range fileSettings {
+		s.fileSettings[name] = struct{}{}
+	}
+
+	return s
+}
+
+// Resolve resolves the secret references of the settings of the command and sets the settings to the values of the
+// secrets. Settings resolved before are resolved again, so that they are updated if their secrets changed, unless
+// they were set to other values since, e.g. by reloading the configuration file. Resolve is not safe for concurrent
+// use.
+func (s *Secrets) Resolve(cmd *cobra.Command) error {
+	ctx, cancel := context.WithTimeout(context.Background(), secretsTimeout)
+	defer cancel()
+
+	var err error
+
+	cmd.Flags().VisitAll(func(f *pflag.Flag) {
+		if err != nil || !f.Changed {
+			return
+		}
+
+		sv, isSlice := f.Value.(pflag.SliceValue)
+
+		values := []string{f.Value.String()}
+		if isSlice {
+			values = sv.GetSlice()
+		}
+
+		resolved, changed, e := s.resolve(ctx, "--"+f.Name, f.Name, values)
+		if e != nil || !changed {
+			err = e
+
+			return
+		}
+
+		if isSlice {
+			err = sv.Replace(resolved)
+		} else {
+			err = f.Value.Set(resolved[0])
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, env := range os.Environ() {
+		i := strings.Index(env, "=")
+		if i < 0 {
+			continue
+		}
+
+		key, value := env[:i], env[i+1:]
+
+		resolved, changed, e := s.resolve(ctx, key, key, strings.Split(value, ","))
+		if e != nil {
+			return e
+		}
+
+		if !changed {
+			continue
+		}
+
+		if err = os.Setenv(key, strings.Join(resolved, ",")); err != nil {
+			return fmt.Errorf("set %s: %w", key, err)
+		}
+	}
+
+	return nil
+}
+
+// resolve returns the values of the elements of the setting, and whether they differ from its current values.
+func (s *Secrets) resolve(ctx context.Context, key, name string, values []string) ([]string, bool, error) {
+	setting, ok := s.settings[key]
+	if ok && !equalValues(setting.values, values) {
+		// the setting was set to other values since it was resolved
+		delete(s.settings, key)
+
+		ok = false
+	}
+
+	if !ok {
+		setting = &secretSetting{refs: make([]string, len(values)), values: values}
+
+		found := false
+
+		for i, v := range values {
+			if secrets.IsReference(v) {
+				setting.refs[i] = v
+				found = true
+			}
+		}
+
+		if !found {
+			return nil, false, nil
+		}
+	}
+
+	resolved := make([]string, len(setting.refs))
+
+	for i, ref := range setting.refs {
+		if ref == "" {
+			resolved[i] = setting.values[i]
+
+			continue
+		}
+
+		v, err := s.resolver.Resolve(ctx, ref)
+		if err != nil {
+			return nil, false, fmt.Errorf("resolve %s: %w", name, err)
+		}
+
+		if _, isFile := s.fileSettings[name]; isFile {
+			if v, err = s.writeFile(key, i, v); err != nil {
+				return nil, false, fmt.Errorf("resolve %s: %w", name, err)
+			}
+		}
+
+		resolved[i] = v
+	}
+
+	changed := !equalValues(setting.values, resolved)
+
+	setting.values = resolved
+	s.settings[key] = setting
+
+	return resolved, changed, nil
+}
+
+// writeFile writes the secret of the element of the setting to its file, if the file does not hold it already, and
+// returns the path to the file. The file is replaced at once so that it is never read partly written.
+func (s *Secrets) writeFile(key string, i int, secret string) (string, error) {
+	if s.dir == "" {
+		dir, err := os.MkdirTemp("", "secrets-")
+		if err != nil {
+			return "", fmt.Errorf("create secrets directory: %w", err)
+		}
+
+		s.dir = dir
+	}
+
+	path := filepath.Join(s.dir, strings.TrimPrefix(key, "--")+"-"+strconv.Itoa(i))
+
+	if b, err := os.ReadFile(filepath.Clean(path)); err == nil && bytes.Equal(b, []byte(secret)) {
+		return path, nil
+	}
+
+	tmp := path + ".tmp"
+
+	if err := os.WriteFile(tmp, []byte(secret), secretFileMode); err != nil {
+		return "", fmt.Errorf("write secret file: %w", err)
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		return "", fmt.Errorf("write secret file: %w", err)
+	}
+
+	return path, nil
+}
+
+// Close removes the files the secrets were written to.
+func (s *Secrets) Close() error {
+	if s.dir == "" {
+		return nil
+	}
+
+	if err := os.RemoveAll(s.dir); err != nil {
+		return fmt.Errorf("remove secrets directory: %w", err)
+	}
+
+	return nil
+}
+
+func equalValues(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
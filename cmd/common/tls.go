@@ -0,0 +1,165 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package common
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/trustbloc/edge-core/pkg/log"
+	"golang.org/x/net/http2"
+)
+
+// CertReloadInterval is the time between two checks of the serve certificate files for changes.
+const CertReloadInterval = 30 * time.Second
+
+// CertReloader serves the certificate loaded from a certificate and key file pair, and loads it again when the files
+// change so that rotated certificates are picked up without a restart.
+type CertReloader struct {
+	certFile string
+	keyFile  string
+
+	mu      sync.RWMutex
+	cert    *tls.Certificate
+	version string
+}
+
+// NewCertReloader returns a new CertReloader with the certificate loaded from the given files.
+func NewCertReloader(certFile, keyFile string) (*CertReloader, error) {
+	r := &CertReloader{certFile: certFile, keyFile: keyFile}
+
+	if _, err := r.Reload(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// GetCertificate returns the current certificate. It is meant to be used as tls.Config.GetCertificate.
+func (r *CertReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.cert, nil
+}
+
+// Reload loads the certificate again if its files changed since it was last loaded and tells if it did. The current
+// certificate is kept if the files cannot be loaded, e.g. when only one of them has been replaced yet.
+func (r *CertReloader) Reload() (bool, error) {
+	version, err := r.filesVersion()
+	if err != nil {
+		return false, err
+	}
+
+	r.mu.RLock()
+	unchanged := version == r.version
+	r.mu.RUnlock()
+
+	if unchanged {
+		return false, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return false, fmt.Errorf("load serve certificate: %w", err)
+	}
+
+	r.mu.Lock()
+	r.cert = &cert
+	r.version = version
+	r.mu.Unlock()
+
+	return true, nil
+}
+
+// Watch checks the certificate files for changes at the given interval until the stop channel is closed.
+func (r *CertReloader) Watch(interval time.Duration, stop <-chan struct{}, logger log.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			reloaded, err := r.Reload()
+			if err != nil {
+				logger.Errorf("failed to reload serve certificate: %s", err)
+
+				continue
+			}
+
+			if reloaded {
+				logger.Infof("serve certificate reloaded from %s", r.certFile)
+			}
+		}
+	}
+}
+
+// filesVersion returns a value that changes when any of the certificate files is modified or replaced.
+func (r *CertReloader) filesVersion() (string, error) {
+	var version string
+
+	for _, file := range []string{r.certFile, r.keyFile} {
+		// files are followed through symlinks, which are swapped when mounted secrets are updated
+		info, err := os.Stat(file)
+		if err != nil {
+			return "", fmt.Errorf("stat serve certificate file: %w", err)
+		}
+
+		version += fmt.Sprintf("%s:%d:%d;", file, info.ModTime().UnixNano(), info.Size())
+	}
+
+	return version, nil
+}
+
+// ListenAndServeTLS serves HTTPS with the certificate of the given files, reloading it when the files change. HTTP/2
+// is negotiated with clients that support it.
+func ListenAndServeTLS(host, certFile, keyFile string, handler http.Handler, logger log.Logger) error {
+	return listenAndServeTLS(host, certFile, keyFile, nil, handler, logger)
+}
+
+// ListenAndServeMutualTLS serves HTTPS like ListenAndServeTLS, and requires the clients to present a certificate
+// issued by one of clientCAs.
+func ListenAndServeMutualTLS(host, certFile, keyFile string, clientCAs *x509.CertPool, handler http.Handler,
+	logger log.Logger) error {
+	return listenAndServeTLS(host, certFile, keyFile, clientCAs, handler, logger)
+}
+
+func listenAndServeTLS(host, certFile, keyFile string, clientCAs *x509.CertPool, handler http.Handler,
+	logger log.Logger) error {
+	r, err := NewCertReloader(certFile, keyFile)
+	if err != nil {
+		return err
+	}
+
+	stop := make(chan struct{})
+	defer close(stop)
+
+	go r.Watch(CertReloadInterval, stop, logger)
+
+	srv := &http.Server{ //nolint:gosec
+		Addr:    host,
+		Handler: handler,
+		TLSConfig: &tls.Config{ //nolint:gosec
+			GetCertificate: r.GetCertificate,
+			NextProtos:     []string{http2.NextProtoTLS, "http/1.1"},
+		},
+	}
+
+	if clientCAs != nil {
+		srv.TLSConfig.ClientAuth = tls.RequireAndVerifyClientCert
+		srv.TLSConfig.ClientCAs = clientCAs
+	}
+
+	return srv.ListenAndServeTLS("", "")
+}
@@ -0,0 +1,247 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package common_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/trustbloc/edge-core/pkg/log"
+
+	"github.com/trustbloc/ace/cmd/common"
+)
+
+func TestCertReloader(t *testing.T) {
+	t.Run("reloads certificate when files change", func(t *testing.T) {
+		certFile, keyFile := writeCert(t, t.TempDir(), "one", time.Now())
+
+		r, err := common.NewCertReloader(certFile, keyFile)
+		require.NoError(t, err)
+		require.Equal(t, "one", certCommonName(t, r))
+
+		reloaded, err := r.Reload()
+		require.NoError(t, err)
+		require.False(t, reloaded)
+
+		writeCert(t, filepath.Dir(certFile), "two", time.Now().Add(time.Minute))
+
+		reloaded, err = r.Reload()
+		require.NoError(t, err)
+		require.True(t, reloaded)
+		require.Equal(t, "two", certCommonName(t, r))
+	})
+
+	t.Run("keeps certificate if files are invalid", func(t *testing.T) {
+		certFile, keyFile := writeCert(t, t.TempDir(), "one", time.Now())
+
+		r, err := common.NewCertReloader(certFile, keyFile)
+		require.NoError(t, err)
+
+		require.NoError(t, os.WriteFile(keyFile, []byte("invalid"), 0o600))
+		require.NoError(t, os.Chtimes(keyFile, time.Now(), time.Now().Add(time.Minute)))
+
+		_, err = r.Reload()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "load serve certificate")
+		require.Equal(t, "one", certCommonName(t, r))
+
+		require.NoError(t, os.Remove(keyFile))
+
+		_, err = r.Reload()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "stat serve certificate file")
+		require.Equal(t, "one", certCommonName(t, r))
+	})
+
+	t.Run("watches files until stopped", func(t *testing.T) {
+		certFile, keyFile := writeCert(t, t.TempDir(), "one", time.Now())
+
+		r, err := common.NewCertReloader(certFile, keyFile)
+		require.NoError(t, err)
+
+		stop := make(chan struct{})
+		done := make(chan struct{})
+
+		go func() {
+			r.Watch(time.Millisecond, stop, log.New("test"))
+			close(done)
+		}()
+
+		writeCert(t, filepath.Dir(certFile), "two", time.Now().Add(time.Minute))
+
+		require.Eventually(t, func() bool {
+			return certCommonName(t, r) == "two"
+		}, time.Second, time.Millisecond)
+
+		close(stop)
+		<-done
+	})
+
+	t.Run("error if files cannot be loaded", func(t *testing.T) {
+		_, err := common.NewCertReloader(filepath.Join(t.TempDir(), "cert.pem"), "key.pem")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "stat serve certificate file")
+	})
+}
+
+func TestListenAndServeTLS(t *testing.T) {
+	certFile, keyFile := writeCert(t, t.TempDir(), "one", time.Now())
+
+	err := common.ListenAndServeTLS("wronghost", certFile, keyFile, nil, log.New("test"))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "address wronghost: missing port in address")
+
+	err = common.ListenAndServeTLS("localhost:0", "", keyFile, nil, log.New("test"))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "stat serve certificate file")
+}
+
+func TestListenAndServeTLSHTTP2(t *testing.T) {
+	certFile, keyFile := writeCert(t, t.TempDir(), "one", time.Now())
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	host := l.Addr().String()
+	require.NoError(t, l.Close())
+
+	go func() {
+		_ = common.ListenAndServeTLS(host, certFile, keyFile, http.HandlerFunc( //nolint:errcheck
+			func(rw http.ResponseWriter, r *http.Request) {}), log.New("test"))
+	}()
+
+	client := &http.Client{Transport: &http.Transport{
+		TLSClientConfig:   &tls.Config{InsecureSkipVerify: true}, //nolint:gosec
+		ForceAttemptHTTP2: true,
+	}}
+
+	require.Eventually(t, func() bool {
+		resp, err := client.Get("https://" + host) //nolint:noctx
+		if err != nil {
+			return false
+		}
+
+		require.NoError(t, resp.Body.Close())
+
+		return resp.ProtoMajor == 2
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestListenAndServeMutualTLS(t *testing.T) {
+	certFile, keyFile := writeCert(t, t.TempDir(), "server", time.Now())
+	clientCertFile, clientKeyFile := writeCert(t, t.TempDir(), "client", time.Now())
+
+	clientCert, err := tls.LoadX509KeyPair(clientCertFile, clientKeyFile)
+	require.NoError(t, err)
+
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(parseCert(t, clientCertFile))
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	host := l.Addr().String()
+	require.NoError(t, l.Close())
+
+	go func() {
+		_ = common.ListenAndServeMutualTLS(host, certFile, keyFile, clientCAs, http.HandlerFunc( //nolint:errcheck
+			func(rw http.ResponseWriter, r *http.Request) {}), log.New("test"))
+	}()
+
+	get := func(certs ...tls.Certificate) error {
+		client := &http.Client{Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true, Certificates: certs}, //nolint:gosec
+		}}
+
+		resp, err := client.Get("https://" + host) //nolint:noctx
+		if err != nil {
+			return err
+		}
+
+		return resp.Body.Close()
+	}
+
+	require.Eventually(t, func() bool {
+		return get(clientCert) == nil
+	}, time.Second, 10*time.Millisecond)
+
+	require.Error(t, get())
+}
+
+func parseCert(t *testing.T, certFile string) *x509.Certificate {
+	t.Helper()
+
+	b, err := os.ReadFile(certFile) //nolint:gosec
+	require.NoError(t, err)
+
+	block, _ := pem.Decode(b)
+	require.NotNil(t, block)
+
+	cert, err := x509.ParseCertificate(block.Bytes)
+	require.NoError(t, err)
+
+	return cert
+}
+
+func certCommonName(t *testing.T, r *common.CertReloader) string {
+	t.Helper()
+
+	cert, err := r.GetCertificate(nil)
+	require.NoError(t, err)
+
+	c, err := x509.ParseCertificate(cert.Certificate[0])
+	require.NoError(t, err)
+
+	return c.Subject.CommonName
+}
+
+// writeCert writes a self-signed certificate with the given common name and its key to the directory, with the given
+// modification time.
+func writeCert(t *testing.T, dir, commonName string, modTime time.Time) (string, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+
+	for _, file := range []string{certFile, keyFile} {
+		require.NoError(t, os.Chtimes(file, modTime, modTime))
+	}
+
+	return certFile, keyFile
+}
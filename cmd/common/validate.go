@@ -0,0 +1,53 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package common
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+)
+
+const maxPort = 65535
+
+// ValidateHostURL checks that the host URL of a server has the HostName:Port format.
+func ValidateHostURL(name, hostURL string) error {
+	_, port, err := net.SplitHostPort(hostURL)
+	if err != nil {
+		return fmt.Errorf("invalid %s: %w", name, err)
+	}
+
+	p, err := strconv.Atoi(port)
+	if err != nil || p < 0 || p > maxPort {
+		return fmt.Errorf("invalid %s: port %q is not a number between 0 and %d", name, port, maxPort)
+	}
+
+	return nil
+}
+
+// ValidateURL checks that the URLs are absolute. Empty URLs are skipped, they are checked when the settings are read.
+func ValidateURL(name string, urls ...string) error {
+	for _, rawURL := range urls {
+		if rawURL == "" {
+			continue
+		}
+
+		u, err := url.Parse(rawURL)
+		if err != nil {
+			// the error of url.Parse holds the URL, which may have credentials
+			return fmt.Errorf("invalid %s: %w", name, errors.Unwrap(err))
+		}
+
+		if u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("invalid %s: %s is not an absolute URL", name, RedactURL(rawURL))
+		}
+	}
+
+	return nil
+}
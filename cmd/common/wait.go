@@ -0,0 +1,133 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/spf13/cobra"
+	"github.com/trustbloc/edge-core/pkg/log"
+	cmdutils "github.com/trustbloc/edge-core/pkg/utils/cmd"
+)
+
+const (
+	// StartupTimeoutFlagName is the flag name used for setting the time to wait for dependencies at startup.
+	StartupTimeoutFlagName = "startup-timeout"
+	// StartupTimeoutEnvKey is the env var name used for setting the time to wait for dependencies at startup.
+	StartupTimeoutEnvKey = "STARTUP_TIMEOUT"
+	// StartupTimeoutFlagUsage is the usage text for the startup timeout flag.
+	StartupTimeoutFlagUsage = "Time to wait for the services the command depends on to become reachable at startup," +
+		" e.g. 30s or 2m. Attempts are retried with exponential backoff. Defaults to 0, which does not wait." +
+		" The database is waited for separately, see database-timeout." +
+		" Alternatively, this can be set with the following environment variable: " + StartupTimeoutEnvKey
+
+	maxWaitInterval = 10 * time.Second
+)
+
+// StartupTimeoutFlag adds the startup timeout flag to the command.
+func StartupTimeoutFlag(cmd *cobra.Command) {
+	cmd.Flags().StringP(StartupTimeoutFlagName, "", "", StartupTimeoutFlagUsage)
+}
+
+// StartupTimeout returns the time to wait for dependencies at startup set for the command.
+func StartupTimeout(cmd *cobra.Command) (time.Duration, error) {
+	v := cmdutils.GetUserSetOptionalVarFromString(cmd, StartupTimeoutFlagName, StartupTimeoutEnvKey)
+	if v == "" {
+		return 0, nil
+	}
+
+	timeout, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, fmt.Errorf("parse %s: %w", StartupTimeoutFlagName, err)
+	}
+
+	if timeout < 0 {
+		return 0, fmt.Errorf("%s must not be negative", StartupTimeoutFlagName)
+	}
+
+	return timeout, nil
+}
+
+// WaitFor runs the check until it succeeds, retrying with exponential backoff until the timeout elapses. The check is
+// run once if the timeout is zero.
+func WaitFor(name string, check func() error, timeout time.Duration, logger log.Logger) error {
+	b := backoff.NewExponentialBackOff()
+	b.MaxInterval = maxWaitInterval
+	b.MaxElapsedTime = timeout
+
+	var policy backoff.BackOff = b
+	if timeout == 0 {
+		policy = &backoff.StopBackOff{}
+	}
+
+	err := backoff.RetryNotify(check, policy, func(err error, d time.Duration) {
+		logger.Warnf("%s is not reachable, will try again in %s: %s", name, d, err)
+	})
+	if err != nil {
+		return fmt.Errorf("wait for %s: %w", name, err)
+	}
+
+	return nil
+}
+
+// WaitForURLs waits for the services at the given URLs, keyed by their names, to become reachable. Empty URLs are
+// skipped, and nothing is waited for if the timeout is zero. See WaitFor and Reachable.
+func WaitForURLs(client *http.Client, urls map[string]string, timeout time.Duration, logger log.Logger) error {
+	if timeout == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(urls))
+
+	for name, u := range urls {
+		if u != "" {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		if err := WaitFor(name, Reachable(client, urls[name]), timeout, logger); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Reachable returns a check of the service at the URL. The service is reachable once it responds to a GET request
+// with a status other than a server error, as the URL may not be a resource of its own.
+func Reachable(client *http.Client, u string) func() error {
+	return func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), maxWaitInterval)
+		defer cancel()
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+		if err != nil {
+			return backoff.Permanent(fmt.Errorf("create request: %w", err))
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return fmt.Errorf("get %s: %w", RedactURL(u), err)
+		}
+
+		defer resp.Body.Close() // nolint: errcheck
+
+		if resp.StatusCode >= http.StatusInternalServerError {
+			return fmt.Errorf("get %s: status %d", RedactURL(u), resp.StatusCode)
+		}
+
+		return nil
+	}
+}
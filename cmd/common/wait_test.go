@@ -0,0 +1,149 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package common_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+	"github.com/trustbloc/edge-core/pkg/log"
+
+	"github.com/trustbloc/ace/cmd/common"
+)
+
+func TestStartupTimeout(t *testing.T) {
+	t.Setenv(common.StartupTimeoutEnvKey, "")
+
+	tests := []struct {
+		name    string
+		args    []string
+		timeout time.Duration
+		err     string
+	}{
+		{"not set", nil, 0, ""},
+		{"set", []string{"--" + common.StartupTimeoutFlagName, "2m"}, 2 * time.Minute, ""},
+		{"invalid", []string{"--" + common.StartupTimeoutFlagName, "soon"}, 0, "parse startup-timeout"},
+		{"negative", []string{"--" + common.StartupTimeoutFlagName, "-1s"}, 0, "startup-timeout must not be negative"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := &cobra.Command{}
+			common.StartupTimeoutFlag(cmd)
+
+			require.NoError(t, cmd.ParseFlags(tt.args))
+
+			timeout, err := common.StartupTimeout(cmd)
+			if tt.err != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tt.err)
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.timeout, timeout)
+		})
+	}
+}
+
+func TestWaitFor(t *testing.T) {
+	logger := log.New("test")
+
+	t.Run("retries until check succeeds", func(t *testing.T) {
+		var attempts int
+
+		err := common.WaitFor("service", func() error {
+			attempts++
+
+			if attempts < 3 {
+				return errors.New("not ready")
+			}
+
+			return nil
+		}, time.Minute, logger)
+		require.NoError(t, err)
+		require.Equal(t, 3, attempts)
+	})
+
+	t.Run("error after timeout", func(t *testing.T) {
+		err := common.WaitFor("service", func() error {
+			return errors.New("not ready")
+		}, 100*time.Millisecond, logger)
+		require.EqualError(t, err, "wait for service: not ready")
+	})
+
+	t.Run("checks once without timeout", func(t *testing.T) {
+		var attempts int
+
+		err := common.WaitFor("service", func() error {
+			attempts++
+
+			return errors.New("not ready")
+		}, 0, logger)
+		require.EqualError(t, err, "wait for service: not ready")
+		require.Equal(t, 1, attempts)
+	})
+}
+
+func TestWaitForURLs(t *testing.T) {
+	logger := log.New("test")
+
+	t.Run("waits until services respond", func(t *testing.T) {
+		var requests int32
+
+		unavailable := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+			if atomic.AddInt32(&requests, 1) < 2 {
+				rw.WriteHeader(http.StatusServiceUnavailable)
+
+				return
+			}
+
+			rw.WriteHeader(http.StatusOK)
+		}))
+		defer unavailable.Close()
+
+		notFound := httptest.NewServer(http.NotFoundHandler())
+		defer notFound.Close()
+
+		err := common.WaitForURLs(http.DefaultClient, map[string]string{
+			"unavailable": unavailable.URL,
+			"not found":   notFound.URL,
+			"not set":     "",
+		}, time.Minute, logger)
+		require.NoError(t, err)
+		require.Equal(t, int32(2), atomic.LoadInt32(&requests))
+	})
+
+	t.Run("error if service is not reachable", func(t *testing.T) {
+		srv := httptest.NewServer(http.NotFoundHandler())
+		srv.Close()
+
+		err := common.WaitForURLs(http.DefaultClient, map[string]string{"service": srv.URL}, 100*time.Millisecond,
+			logger)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "wait for service: get "+srv.URL)
+	})
+
+	t.Run("error if url is invalid", func(t *testing.T) {
+		err := common.WaitForURLs(http.DefaultClient, map[string]string{"service": "https://host\x7f"}, time.Minute,
+			logger)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "wait for service: create request")
+	})
+
+	t.Run("does not wait without timeout", func(t *testing.T) {
+		err := common.WaitForURLs(http.DefaultClient, map[string]string{"service": "https://host\x7f"}, 0, logger)
+		require.NoError(t, err)
+	})
+}
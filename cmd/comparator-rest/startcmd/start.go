@@ -34,6 +34,7 @@ import (
 	cmdutils "github.com/trustbloc/edge-core/pkg/utils/cmd"
 	tlsutils "github.com/trustbloc/edge-core/pkg/utils/tls"
 
+	"github.com/trustbloc/ace/cmd/common"
 	"github.com/trustbloc/ace/pkg/ld"
 	"github.com/trustbloc/ace/pkg/restapi/comparator"
 	"github.com/trustbloc/ace/pkg/restapi/comparator/operation"
@@ -83,6 +84,12 @@ const (
 	datasourceTimeoutEnvKey  = "COMPARATOR_DSN_TIMEOUT"
 	datasourceTimeoutDefault = "30"
 
+	datasourceMaxPoolSizeFlagName  = "dsn-max-pool-size"
+	datasourceMaxPoolSizeFlagUsage = "Maximum number of connections kept open to the datasource." +
+		" Only applies to the mongodb driver. Defaults to the driver's default." +
+		" Alternatively, this can be set with the following environment variable: " + datasourceMaxPoolSizeEnvKey
+	datasourceMaxPoolSizeEnvKey = "COMPARATOR_DSN_MAX_POOL_SIZE"
+
 	databasePrefixFlagName  = "database-prefix"
 	databasePrefixFlagUsage = "An optional prefix to be used when creating and retrieving underlying databases. " +
 		" Alternatively, this can be set with the following environment variable: " + databasePrefixEnvKey
@@ -113,12 +120,51 @@ const (
 	requestTokensFlagUsage = "Tokens used for http request " +
 		" Alternatively, this can be set with the following environment variable: " + requestTokensEnvKey
 
+	comparisonCacheTTLFlagName  = "comparison-cache-ttl"
+	comparisonCacheTTLEnvKey    = "COMPARATOR_COMPARISON_CACHE_TTL"
+	comparisonCacheTTLFlagUsage = "Time to live of cached comparison results (e.g. 5m). Caching is disabled by default." +
+		" Alternatively, this can be set with the following environment variable: " + comparisonCacheTTLEnvKey
+
+	comparisonCacheSizeFlagName  = "comparison-cache-size"
+	comparisonCacheSizeEnvKey    = "COMPARATOR_COMPARISON_CACHE_SIZE"
+	comparisonCacheSizeFlagUsage = "Maximum number of cached comparison results, past which the least recently used" +
+		" results are evicted. Defaults to 10000." +
+		" Alternatively, this can be set with the following environment variable: " + comparisonCacheSizeEnvKey
+
+	cshTimeoutFlagName  = "csh-timeout"
+	cshTimeoutEnvKey    = "COMPARATOR_CSH_TIMEOUT"
+	cshTimeoutFlagUsage = "Timeout of each call to the confidential storage hub (e.g. 5s). Defaults to 5s." +
+		" Alternatively, this can be set with the following environment variable: " + cshTimeoutEnvKey
+
+	cshRetriesFlagName  = "csh-retries"
+	cshRetriesEnvKey    = "COMPARATOR_CSH_RETRIES"
+	cshRetriesFlagUsage = "Times comparisons and extractions are retried while the confidential storage hub is" +
+		" unavailable, with exponential backoff. Defaults to 2." +
+		" Alternatively, this can be set with the following environment variable: " + cshRetriesEnvKey
+
+	cshFailureThresholdFlagName  = "csh-failure-threshold"
+	cshFailureThresholdEnvKey    = "COMPARATOR_CSH_FAILURE_THRESHOLD"
+	cshFailureThresholdFlagUsage = "Consecutive calls failing with the confidential storage hub unavailable after" +
+		" which the calls fail fast for the csh-cooldown. Defaults to 5, 0 disables the circuit breaker." +
+		" Alternatively, this can be set with the following environment variable: " + cshFailureThresholdEnvKey
+
+	cshCooldownFlagName  = "csh-cooldown"
+	cshCooldownEnvKey    = "COMPARATOR_CSH_COOLDOWN"
+	cshCooldownFlagUsage = "Time the calls to the confidential storage hub fail fast once the circuit is open" +
+		" (e.g. 30s). Defaults to 30s." +
+		" Alternatively, this can be set with the following environment variable: " + cshCooldownEnvKey
+
 	splitRequestTokenLength = 2
 )
 
 const (
 	keystorePrimaryKeyURI = "local-lock://keystorekms"
 	sleep                 = 1 * time.Second
+
+	defaultCSHTimeout          = 5 * time.Second
+	defaultCSHRetries          = 2
+	defaultCSHFailureThreshold = 5
+	defaultCSHCooldown         = 30 * time.Second
 )
 
 var logger = log.New("comparator-rest")
@@ -165,15 +211,28 @@ type dsnParams struct {
 	dbPrefix string
 }
 
+type cshParams struct {
+	timeout          time.Duration
+	retries          uint64
+	failureThreshold int
+	cooldown         time.Duration
+}
+
 type serviceParameters struct {
-	host            string
-	tlsParams       *tlsParameters
-	dsnParams       *dsnParams
-	didDomain       string
-	cshURL          string
-	vaultURL        string
-	didAnchorOrigin string
-	requestTokens   map[string]string
+	host               string
+	tlsParams          *tlsParameters
+	dsnParams          *dsnParams
+	didDomain          string
+	cshURL             string
+	vaultURL           string
+	didAnchorOrigin    string
+	requestTokens      map[string]string
+	comparisonCacheTTL time.Duration
+	// comparisonCacheSize is 0 for the default size.
+	comparisonCacheSize int
+	cshParams           *cshParams
+	h2c                 bool
+	startupTimeout      time.Duration
 }
 
 type server interface {
@@ -183,13 +242,14 @@ type server interface {
 // HTTPServer represents an actual HTTP server implementation.
 type HTTPServer struct{}
 
-// ListenAndServe starts the server using the standard Go HTTP server implementation.
+// ListenAndServe starts the server using the standard Go HTTP server implementation. The serve certificate is
+// reloaded when its files change.
 func (s *HTTPServer) ListenAndServe(host, certFile, keyFile string, router http.Handler) error {
 	if certFile == "" || keyFile == "" {
 		return http.ListenAndServe(host, router)
 	}
 
-	return http.ListenAndServeTLS(host, certFile, keyFile, router)
+	return common.ListenAndServeTLS(host, certFile, keyFile, router, logger)
 }
 
 // GetStartCmd returns the Cobra start command.
@@ -235,11 +295,23 @@ func createStartCmd(srv server) *cobra.Command {
 		Use:   "start",
 		Short: "Starts a comparator server",
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if _, err := common.LoadConfigFile(cmd); err != nil {
+				return err
+			}
+
 			params, err := getParameters(cmd)
 			if err != nil {
 				return err
 			}
 
+			if err = params.validate(); err != nil {
+				return err
+			}
+
+			if common.PrintConfigSet(cmd) {
+				return common.PrintConfig(cmd, params.settings())
+			}
+
 			return startService(params, srv)
 		},
 	}
@@ -256,6 +328,16 @@ func getParameters(cmd *cobra.Command) (*serviceParameters, error) {
 		return nil, err
 	}
 
+	h2c, err := common.H2CEnabled(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	startupTimeout, err := common.StartupTimeout(cmd)
+	if err != nil {
+		return nil, err
+	}
+
 	dsnParams, err := getDsnParams(cmd)
 	if err != nil {
 		return nil, err
@@ -280,18 +362,106 @@ func getParameters(cmd *cobra.Command) (*serviceParameters, error) {
 
 	requestTokens := getRequestTokens(cmd)
 
+	comparisonCacheTTL, comparisonCacheSize, err := getComparisonCacheParams(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	cshParams, err := getCSHParams(cmd)
+	if err != nil {
+		return nil, err
+	}
+
 	return &serviceParameters{
-		host:            host,
-		tlsParams:       tlsParams,
-		dsnParams:       dsnParams,
-		didDomain:       didDomain,
-		cshURL:          cshURL,
-		vaultURL:        vaultURL,
-		didAnchorOrigin: didAnchorOrigin,
-		requestTokens:   requestTokens,
+		host:                host,
+		tlsParams:           tlsParams,
+		dsnParams:           dsnParams,
+		didDomain:           didDomain,
+		cshURL:              cshURL,
+		vaultURL:            vaultURL,
+		didAnchorOrigin:     didAnchorOrigin,
+		requestTokens:       requestTokens,
+		comparisonCacheTTL:  comparisonCacheTTL,
+		comparisonCacheSize: comparisonCacheSize,
+		cshParams:           cshParams,
+		h2c:                 h2c,
+		startupTimeout:      startupTimeout,
 	}, err
 }
 
+func getComparisonCacheParams(cmd *cobra.Command) (time.Duration, int, error) {
+	var (
+		ttl  time.Duration
+		size int
+		err  error
+	)
+
+	if v := cmdutils.GetUserSetOptionalVarFromString(cmd, comparisonCacheTTLFlagName,
+		comparisonCacheTTLEnvKey); v != "" {
+		ttl, err = time.ParseDuration(v)
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to parse comparison cache ttl %s: %w", v, err)
+		}
+	}
+
+	if v := cmdutils.GetUserSetOptionalVarFromString(cmd, comparisonCacheSizeFlagName,
+		comparisonCacheSizeEnvKey); v != "" {
+		size, err = strconv.Atoi(v)
+		if err != nil || size <= 0 {
+			return 0, 0, fmt.Errorf("%s must be a positive integer: %q", comparisonCacheSizeFlagName, v)
+		}
+	}
+
+	return ttl, size, nil
+}
+
+func getCSHParams(cmd *cobra.Command) (*cshParams, error) {
+	params := &cshParams{
+		timeout:          defaultCSHTimeout,
+		retries:          defaultCSHRetries,
+		failureThreshold: defaultCSHFailureThreshold,
+		cooldown:         defaultCSHCooldown,
+	}
+
+	for _, d := range []struct {
+		name, envKey string
+		value        *time.Duration
+	}{
+		{cshTimeoutFlagName, cshTimeoutEnvKey, &params.timeout},
+		{cshCooldownFlagName, cshCooldownEnvKey, &params.cooldown},
+	} {
+		if v := cmdutils.GetUserSetOptionalVarFromString(cmd, d.name, d.envKey); v != "" {
+			t, err := time.ParseDuration(v)
+			if err != nil || t <= 0 {
+				return nil, fmt.Errorf("%s must be a positive duration: %q", d.name, v)
+			}
+
+			*d.value = t
+		}
+	}
+
+	if v := cmdutils.GetUserSetOptionalVarFromString(cmd, cshRetriesFlagName, cshRetriesEnvKey); v != "" {
+		retries, err := strconv.ParseUint(v, 10, 64) //nolint:gomnd
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse csh retries %s: %w", v, err)
+		}
+
+		params.retries = retries
+	}
+
+	if v := cmdutils.GetUserSetOptionalVarFromString(cmd, cshFailureThresholdFlagName,
+		cshFailureThresholdEnvKey); v != "" {
+		threshold, err := strconv.Atoi(v)
+		if err != nil || threshold < 0 {
+			return nil, fmt.Errorf("%s must be a non-negative integer: %q", cshFailureThresholdFlagName, v)
+		}
+
+		params.failureThreshold = threshold
+	}
+
+	return params, nil
+}
+
 func getDsnParams(cmd *cobra.Command) (*dsnParams, error) {
 	params := &dsnParams{}
 
@@ -317,6 +487,25 @@ func getDsnParams(cmd *cobra.Command) (*dsnParams, error) {
 
 	params.dbPrefix = cmdutils.GetUserSetOptionalVarFromString(cmd, databasePrefixFlagName, databasePrefixEnvKey)
 
+	maxPoolSize := cmdutils.GetUserSetOptionalVarFromString(cmd, datasourceMaxPoolSizeFlagName,
+		datasourceMaxPoolSizeEnvKey)
+
+	if maxPoolSize == "" {
+		return params, nil
+	}
+
+	size, err := strconv.ParseUint(maxPoolSize, 10, 64) //nolint:gomnd
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse dsn max pool size %s: %w", maxPoolSize, err)
+	}
+
+	if strings.HasPrefix(params.dsn, "mongodb:") {
+		params.dsn, err = common.MongoDBConnString(params.dsn, size)
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure dsn max pool size: %w", err)
+		}
+	}
+
 	return params, nil
 }
 
@@ -408,6 +597,66 @@ func getDBParams(dbURL string) (driver, dsn string, err error) {
 	return driver, dsn, nil
 }
 
+// validate checks the sanity of the parameters. Required parameters are checked when they are read.
+func (p *serviceParameters) validate() error {
+	if err := common.ValidateHostURL(hostURLFlagName, p.host); err != nil {
+		return err
+	}
+
+	if err := common.ValidateURL(cshURLFlagName, p.cshURL); err != nil {
+		return err
+	}
+
+	if err := common.ValidateURL(vaultURLFlagName, p.vaultURL); err != nil {
+		return err
+	}
+
+	if err := common.ValidateURL(didAnchorOriginFlagName, p.didAnchorOrigin); err != nil {
+		return err
+	}
+
+	if (p.tlsParams.serveCertPath == "") != (p.tlsParams.serveKeyPath == "") {
+		return fmt.Errorf("%s and %s must be set together", tlsServeCertPathFlagName, tlsServeKeyPathFlagName)
+	}
+
+	if p.h2c && p.tlsParams.serveCertPath != "" {
+		return fmt.Errorf("%s cannot be used with %s", common.H2CFlagName, tlsServeCertPathFlagName)
+	}
+
+	if p.comparisonCacheTTL < 0 {
+		return fmt.Errorf("%s must not be negative", comparisonCacheTTLFlagName)
+	}
+
+	return nil
+}
+
+// settings returns the parameters keyed by their environment variables, with secrets redacted.
+func (p *serviceParameters) settings() map[string]interface{} {
+	return map[string]interface{}{
+		hostURLEnvKey:               p.host,
+		tlsSystemCertPoolEnvKey:     p.tlsParams.systemCertPool,
+		tlsCACertsEnvKey:            p.tlsParams.caCerts,
+		tlsServeCertPathEnvKey:      p.tlsParams.serveCertPath,
+		tlsServeKeyPathFlagEnvKey:   p.tlsParams.serveKeyPath,
+		datasourceNameEnvKey:        common.RedactURL(p.dsnParams.dsn),
+		datasourceTimeoutEnvKey:     p.dsnParams.timeout,
+		databasePrefixEnvKey:        p.dsnParams.dbPrefix,
+		didDomainEnvKey:             p.didDomain,
+		cshURLEnvKey:                p.cshURL,
+		vaultURLEnvKey:              p.vaultURL,
+		didAnchorOriginEnvKey:       p.didAnchorOrigin,
+		requestTokensEnvKey:         common.RedactTokens(p.requestTokens),
+		common.StartupTimeoutEnvKey: p.startupTimeout.String(),
+		common.H2CEnvKey:            p.h2c,
+		comparisonCacheTTLEnvKey:    p.comparisonCacheTTL.String(),
+		comparisonCacheSizeEnvKey:   p.comparisonCacheSize,
+		cshTimeoutEnvKey:            p.cshParams.timeout.String(),
+		cshRetriesEnvKey:            p.cshParams.retries,
+		cshFailureThresholdEnvKey:   p.cshParams.failureThreshold,
+		cshCooldownEnvKey:           p.cshParams.cooldown.String(),
+	}
+}
+
 func createFlags(cmd *cobra.Command) {
 	cmd.Flags().StringP(hostURLFlagName, hostURLFlagShorthand, "", hostURLFlagUsage)
 	cmd.Flags().StringP(tlsSystemCertPoolFlagName, "", "", tlsSystemCertPoolFlagUsage)
@@ -416,12 +665,23 @@ func createFlags(cmd *cobra.Command) {
 	cmd.Flags().StringP(tlsServeKeyPathFlagName, "", "", tlsServeKeyPathFlagUsage)
 	cmd.Flags().StringP(datasourceNameFlagName, "", "", datasourceNameFlagUsage)
 	cmd.Flags().StringP(datasourceTimeoutFlagName, "", "", datasourceTimeoutFlagUsage)
+	cmd.Flags().StringP(datasourceMaxPoolSizeFlagName, "", "", datasourceMaxPoolSizeFlagUsage)
 	cmd.Flags().StringP(databasePrefixFlagName, "", "", databasePrefixFlagUsage)
 	cmd.Flags().StringP(didDomainFlagName, "", "", didDomainFlagUsage)
 	cmd.Flags().StringP(cshURLFlagName, "", "", cshURLFlagUsage)
 	cmd.Flags().StringP(vaultURLFlagName, "", "", vaultURLFlagUsage)
 	cmd.Flags().StringP(didAnchorOriginFlagName, "", "", didAnchorOriginFlagUsage)
 	cmd.Flags().StringArrayP(requestTokensFlagName, "", []string{}, requestTokensFlagUsage)
+	cmd.Flags().StringP(comparisonCacheTTLFlagName, "", "", comparisonCacheTTLFlagUsage)
+	cmd.Flags().StringP(comparisonCacheSizeFlagName, "", "", comparisonCacheSizeFlagUsage)
+	cmd.Flags().StringP(cshTimeoutFlagName, "", "", cshTimeoutFlagUsage)
+	cmd.Flags().StringP(cshRetriesFlagName, "", "", cshRetriesFlagUsage)
+	cmd.Flags().StringP(cshFailureThresholdFlagName, "", "", cshFailureThresholdFlagUsage)
+	cmd.Flags().StringP(cshCooldownFlagName, "", "", cshCooldownFlagUsage)
+
+	common.ConfigFileFlag(cmd)
+	common.H2CFlag(cmd)
+	common.StartupTimeoutFlag(cmd)
 }
 
 //nolint:funlen,gocyclo
@@ -433,6 +693,15 @@ func startService(params *serviceParameters, srv server) error {
 
 	tlsConfig := &tls.Config{RootCAs: rootCAs, MinVersion: tls.VersionTLS12}
 
+	err = common.WaitForURLs(&http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig}},
+		map[string]string{
+			"vault server": params.vaultURL,
+			"CSH":          params.cshURL,
+		}, params.startupTimeout, logger)
+	if err != nil {
+		return err
+	}
+
 	storeProvider, err := initStore(params.dsnParams.dsn, params.dsnParams.timeout, params.dsnParams.dbPrefix)
 	if err != nil {
 		return err
@@ -473,16 +742,22 @@ func startService(params *serviceParameters, srv server) error {
 	}
 
 	service, err := comparator.New(&operation.Config{
-		VDR:             vdr.New(vdr.WithVDR(trustblocVDR)),
-		KeyManager:      keyManager,
-		TLSConfig:       tlsConfig,
-		DIDMethod:       orb.DIDMethod,
-		StoreProvider:   storeProvider,
-		CSHBaseURL:      params.cshURL,
-		VaultBaseURL:    params.vaultURL,
-		DIDDomain:       params.didDomain,
-		DIDAnchorOrigin: params.didAnchorOrigin,
-		DocumentLoader:  loader,
+		VDR:                 vdr.New(vdr.WithVDR(trustblocVDR)),
+		KeyManager:          keyManager,
+		TLSConfig:           tlsConfig,
+		DIDMethod:           orb.DIDMethod,
+		StoreProvider:       storeProvider,
+		CSHBaseURL:          params.cshURL,
+		VaultBaseURL:        params.vaultURL,
+		DIDDomain:           params.didDomain,
+		DIDAnchorOrigin:     params.didAnchorOrigin,
+		DocumentLoader:      loader,
+		ComparisonCacheTTL:  params.comparisonCacheTTL,
+		ComparisonCacheSize: params.comparisonCacheSize,
+		CSHTimeout:          params.cshParams.timeout,
+		CSHRetries:          params.cshParams.retries,
+		CSHFailureThreshold: params.cshParams.failureThreshold,
+		CSHCooldown:         params.cshParams.cooldown,
 	})
 	if err != nil {
 		return err
@@ -496,21 +771,26 @@ func startService(params *serviceParameters, srv server) error {
 		router.HandleFunc(handler.Path(), handler.Handle()).Methods(handler.Method())
 	}
 
+	var handler http.Handler = cors.New(cors.Options{
+		AllowedMethods: []string{
+			http.MethodHead,
+			http.MethodGet,
+			http.MethodPost,
+			http.MethodDelete,
+		},
+		AllowedHeaders: []string{
+			"Origin",
+			"Accept",
+			"Content-Type",
+			"X-Requested-With",
+			"Authorization",
+		},
+	}).Handler(router)
+
+	if params.h2c {
+		handler = common.H2CHandler(handler)
+	}
+
 	// start server on given port and serve using given handlers
-	return srv.ListenAndServe(params.host, params.tlsParams.serveCertPath, params.tlsParams.serveKeyPath,
-		cors.New(cors.Options{
-			AllowedMethods: []string{
-				http.MethodHead,
-				http.MethodGet,
-				http.MethodPost,
-				http.MethodDelete,
-			},
-			AllowedHeaders: []string{
-				"Origin",
-				"Accept",
-				"Content-Type",
-				"X-Requested-With",
-				"Authorization",
-			},
-		}).Handler(router))
+	return srv.ListenAndServe(params.host, params.tlsParams.serveCertPath, params.tlsParams.serveKeyPath, handler)
 }
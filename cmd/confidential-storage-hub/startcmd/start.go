@@ -12,6 +12,7 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/hyperledger/aries-framework-go-ext/component/vdr/orb"
@@ -28,6 +29,9 @@ import (
 	"github.com/hyperledger/aries-framework-go/pkg/vdr"
 	"github.com/hyperledger/aries-framework-go/pkg/vdr/key"
 	ariesstorage "github.com/hyperledger/aries-framework-go/spi/storage"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/rs/cors"
 	"github.com/spf13/cobra"
 	"github.com/trustbloc/edge-core/pkg/log"
@@ -99,24 +103,36 @@ const (
 	requestTokensFlagUsage = "Tokens used for http request " +
 		" Alternatively, this can be set with the following environment variable: " + requestTokensEnvKey
 
+	capabilityCacheTTLFlagName  = "capability-cache-ttl"
+	capabilityCacheTTLEnvKey    = "CSH_CAPABILITY_CACHE_TTL"
+	capabilityCacheTTLFlagUsage = "Time the zcaps checked against the revocation registry are cached for, e.g. 30s" +
+		" or 5m. Zcaps revoked through other instances are rejected once their entries expire. 0 disables the cache." +
+		" Defaults to 1m if not set." +
+		" Alternatively, this can be set with the following environment variable: " + capabilityCacheTTLEnvKey
+
+	metricsEndpoint = "/metrics"
+
 	splitRequestTokenLength = 2
 )
 
 var logger = log.New("confidential-storage-hub/start")
 
 type serviceParameters struct {
-	host              string
-	baseURL           string
-	tlsParams         *tlsParameters
-	dbParams          *common.DBParameters
-	trustblocDomain   string
-	identityDIDMethod string
-	didAnchorOrigin   string
-	requestTokens     map[string]string
+	host               string
+	baseURL            string
+	tlsParams          *tlsParameters
+	dbParams           *common.DBParameters
+	trustblocDomain    string
+	identityDIDMethod  string
+	didAnchorOrigin    string
+	requestTokens      map[string]string
+	h2c                bool
+	capabilityCacheTTL time.Duration
 }
 
 type tlsParameters struct {
 	systemCertPool bool
+	caCerts        []string
 	serveCertPath  string
 	serveKeyPath   string
 	tlsConfig      *tls.Config
@@ -129,13 +145,14 @@ type server interface {
 // HTTPServer represents an actual HTTP server implementation.
 type HTTPServer struct{}
 
-// ListenAndServe starts the server using the standard Go HTTP server implementation.
+// ListenAndServe starts the server using the standard Go HTTP server implementation. The serve certificate is
+// reloaded when its files change.
 func (s *HTTPServer) ListenAndServe(host, certFile, keyFile string, router http.Handler) error {
 	if certFile == "" || keyFile == "" {
 		return http.ListenAndServe(host, router)
 	}
 
-	return http.ListenAndServeTLS(host, certFile, keyFile, router)
+	return common.ListenAndServeTLS(host, certFile, keyFile, router, logger)
 }
 
 // GetStartCmd returns the Cobra start command.
@@ -152,11 +169,23 @@ func createStartCmd(srv server) *cobra.Command {
 		Use:   "start",
 		Short: "Starts a confidential-storage-hub server",
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if _, err := common.LoadConfigFile(cmd); err != nil {
+				return err
+			}
+
 			params, err := getParameters(cmd)
 			if err != nil {
 				return err
 			}
 
+			if err = params.validate(); err != nil {
+				return err
+			}
+
+			if common.PrintConfigSet(cmd) {
+				return common.PrintConfig(cmd, params.settings())
+			}
+
 			return startService(params, srv)
 		},
 	}
@@ -178,6 +207,11 @@ func getParameters(cmd *cobra.Command) (*serviceParameters, error) {
 		return nil, err
 	}
 
+	h2c, err := common.H2CEnabled(cmd)
+	if err != nil {
+		return nil, err
+	}
+
 	dbParams, err := common.DBParams(cmd)
 	if err != nil {
 		return nil, err
@@ -202,20 +236,78 @@ func getParameters(cmd *cobra.Command) (*serviceParameters, error) {
 
 	requestTokens := getRequestTokens(cmd)
 
+	capabilityCacheTTL, err := getCapabilityCacheTTL(cmd)
+	if err != nil {
+		return nil, err
+	}
+
 	return &serviceParameters{
-		host:              host,
-		tlsParams:         tlsParams,
-		dbParams:          dbParams,
-		baseURL:           baseURL,
-		trustblocDomain:   trustblocDomain,
-		identityDIDMethod: identityDIDMethod,
-		didAnchorOrigin:   didAnchorOrigin,
-		requestTokens:     requestTokens,
+		host:               host,
+		tlsParams:          tlsParams,
+		dbParams:           dbParams,
+		baseURL:            baseURL,
+		trustblocDomain:    trustblocDomain,
+		identityDIDMethod:  identityDIDMethod,
+		didAnchorOrigin:    didAnchorOrigin,
+		requestTokens:      requestTokens,
+		h2c:                h2c,
+		capabilityCacheTTL: capabilityCacheTTL,
 	}, err
 }
 
+// validate checks the sanity of the parameters. Required parameters are checked when they are read.
+func (p *serviceParameters) validate() error {
+	if err := common.ValidateHostURL(hostURLFlagName, p.host); err != nil {
+		return err
+	}
+
+	if err := common.ValidateURL(baseURLFlagName, p.baseURL); err != nil {
+		return err
+	}
+
+	if err := common.ValidateURL(didAnchorOriginFlagName, p.didAnchorOrigin); err != nil {
+		return err
+	}
+
+	if (p.tlsParams.serveCertPath == "") != (p.tlsParams.serveKeyPath == "") {
+		return fmt.Errorf("%s and %s must be set together", tlsServeCertPathFlagName, tlsServeKeyPathFlagName)
+	}
+
+	if p.h2c && p.tlsParams.serveCertPath != "" {
+		return fmt.Errorf("%s cannot be used with %s", common.H2CFlagName, tlsServeCertPathFlagName)
+	}
+
+	return nil
+}
+
+// settings returns the parameters keyed by their environment variables, with secrets redacted.
+func (p *serviceParameters) settings() map[string]interface{} {
+	settings := map[string]interface{}{
+		hostURLEnvKey:             p.host,
+		baseURLEnvKey:             p.baseURL,
+		tlsSystemCertPoolEnvKey:   p.tlsParams.systemCertPool,
+		tlsCACertsEnvKey:          p.tlsParams.caCerts,
+		tlsServeCertPathEnvKey:    p.tlsParams.serveCertPath,
+		tlsServeKeyPathFlagEnvKey: p.tlsParams.serveKeyPath,
+		didDomainEnvKey:           p.trustblocDomain,
+		identityDIDMethodEnvKey:   p.identityDIDMethod,
+		didAnchorOriginEnvKey:     p.didAnchorOrigin,
+		requestTokensEnvKey:       common.RedactTokens(p.requestTokens),
+		common.H2CEnvKey:          p.h2c,
+		capabilityCacheTTLEnvKey:  p.capabilityCacheTTL.String(),
+	}
+
+	for k, v := range p.dbParams.Settings() {
+		settings[k] = v
+	}
+
+	return settings
+}
+
 func createFlags(cmd *cobra.Command) {
 	common.Flags(cmd)
+	common.ConfigFileFlag(cmd)
+	common.H2CFlag(cmd)
 	cmd.Flags().StringP(hostURLFlagName, hostURLFlagShorthand, "", hostURLFlagUsage)
 	cmd.Flags().StringP(baseURLFlagName, "", "", baseURLFlagUsage)
 	cmd.Flags().StringP(tlsSystemCertPoolFlagName, "", "", tlsSystemCertPoolFlagUsage)
@@ -226,6 +318,7 @@ func createFlags(cmd *cobra.Command) {
 	cmd.Flags().StringP(identityDIDMethodFlagName, "", "", identityDIDMethodFlagUsage)
 	cmd.Flags().StringP(didAnchorOriginFlagName, "", "", didAnchorOriginFlagUsage)
 	cmd.Flags().StringArrayP(requestTokensFlagName, "", []string{}, requestTokensFlagUsage)
+	cmd.Flags().StringP(capabilityCacheTTLFlagName, "", "", capabilityCacheTTLFlagUsage)
 }
 
 func getTLS(cmd *cobra.Command) (*tlsParameters, error) {
@@ -256,6 +349,7 @@ func getTLS(cmd *cobra.Command) (*tlsParameters, error) {
 
 	return &tlsParameters{
 		systemCertPool: tlsSystemCertPool,
+		caCerts:        tlsCACerts,
 		serveCertPath:  tlsServeCertPath,
 		serveKeyPath:   tlsServeKeyPath,
 		tlsConfig: &tls.Config{
@@ -284,6 +378,20 @@ func getRequestTokens(cmd *cobra.Command) map[string]string {
 	return tokens
 }
 
+func getCapabilityCacheTTL(cmd *cobra.Command) (time.Duration, error) {
+	v := cmdutils.GetUserSetOptionalVarFromString(cmd, capabilityCacheTTLFlagName, capabilityCacheTTLEnvKey)
+	if v == "" {
+		return operation.DefaultCapabilityCacheTTL, nil
+	}
+
+	ttl, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, fmt.Errorf("parse %s: %w", capabilityCacheTTLFlagName, err)
+	}
+
+	return ttl, nil
+}
+
 func startService(params *serviceParameters, srv server) error { // nolint:funlen
 	router := mux.NewRouter()
 
@@ -305,6 +413,10 @@ func startService(params *serviceParameters, srv server) error { // nolint:funle
 		router.HandleFunc(handler.Path(), handler.Handle()).Methods(handler.Method())
 	}
 
+	metrics := newMetricsRegistry()
+
+	router.Handle(metricsEndpoint, promhttp.HandlerFor(metrics, promhttp.HandlerOpts{})).Methods(http.MethodGet)
+
 	baseURL := params.baseURL
 	if baseURL == "" {
 		baseURL = params.host
@@ -327,9 +439,11 @@ func startService(params *serviceParameters, srv server) error { // nolint:funle
 		HTTPClient: &http.Client{Transport: &http.Transport{
 			TLSClientConfig: params.tlsParams.tlsConfig,
 		}},
-		BaseURL:        baseURL,
-		DIDDomain:      params.trustblocDomain,
-		DocumentLoader: loader,
+		BaseURL:            baseURL,
+		DIDDomain:          params.trustblocDomain,
+		DocumentLoader:     loader,
+		CapabilityCacheTTL: params.capabilityCacheTTL,
+		MetricsRegisterer:  metrics,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to initialize confidential storage hub operations: %w", err)
@@ -345,26 +459,37 @@ func startService(params *serviceParameters, srv server) error { // nolint:funle
 
 	logger.Infof("starting server on host: %s", params.host)
 
-	// start server on given port and serve using given handlers
-	return srv.ListenAndServe(
-		params.host,
-		params.tlsParams.serveCertPath,
-		params.tlsParams.serveKeyPath,
-		cors.New(cors.Options{
-			AllowedMethods: []string{
-				http.MethodHead,
-				http.MethodGet,
-				http.MethodPost,
-			},
-			AllowedHeaders: []string{
-				"Origin",
-				"Accept",
-				"Content-Type",
-				"X-Requested-With",
-				"Authorization",
-			},
+	var handler http.Handler = cors.New(cors.Options{
+		AllowedMethods: []string{
+			http.MethodHead,
+			http.MethodGet,
+			http.MethodPost,
+		},
+		AllowedHeaders: []string{
+			"Origin",
+			"Accept",
+			"Content-Type",
+			"X-Requested-With",
+			"Authorization",
 		},
-		).Handler(router))
+	}).Handler(router)
+
+	if params.h2c {
+		handler = common.H2CHandler(handler)
+	}
+
+	// start server on given port and serve using given handlers
+	return srv.ListenAndServe(params.host, params.tlsParams.serveCertPath, params.tlsParams.serveKeyPath, handler)
+}
+
+// newMetricsRegistry returns the registry of the metrics served by the metrics endpoint, with the Go runtime and
+// process metrics registered.
+func newMetricsRegistry() *prometheus.Registry {
+	registry := prometheus.NewRegistry()
+
+	registry.MustRegister(collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+
+	return registry
 }
 
 // TODO make KMS and crypto configurable: https://github.com/trustbloc/ace/issues/578
@@ -0,0 +1,158 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package admincmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/trustbloc/ace/pkg/client/gatekeeper"
+	"github.com/trustbloc/ace/pkg/gatekeeper/audit"
+	"github.com/trustbloc/ace/pkg/restapi/model"
+)
+
+// GetAuditCmd returns the Cobra audit command.
+func GetAuditCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Verifies the audit log of the actions taken on tickets",
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.HelpFunc()(cmd, args)
+		},
+	}
+
+	addClientFlags(cmd)
+
+	cmd.AddCommand(verifyAuditCmd())
+
+	return cmd
+}
+
+func verifyAuditCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "verify",
+		Short: "Verifies that the entries of the audit log were not modified after the fact",
+		Long: "Verifies the hashes chaining the entries of the audit log, and that its checkpoints match the" +
+			" entries, on the entries read from the Gatekeeper server. The signatures of the checkpoints are then" +
+			" verified by the server, which resolves the DID of the gatekeeper. Fails on the first mismatch found.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := newClient(cmd)
+			if err != nil {
+				return err
+			}
+
+			head, checkpoints, err := verifyAuditLog(cmd.Context(), c)
+			if err != nil {
+				return err
+			}
+
+			if _, err = fmt.Fprintf(cmd.OutOrStdout(), "%d entries verified, head %s\n%d checkpoints match the log\n",
+				head.Seq, head.Hash, checkpoints); err != nil {
+				return err
+			}
+
+			report, err := c.VerifyAudit(cmd.Context())
+			if err != nil {
+				return fmt.Errorf("verify audit log: %w", err)
+			}
+
+			if !report.Verified {
+				return fmt.Errorf("server verification: %s", report.Error)
+			}
+
+			_, err = fmt.Fprintf(cmd.OutOrStdout(), "%d checkpoint signatures verified by the server\n",
+				report.Checkpoints)
+
+			return err
+		},
+	}
+}
+
+// verifyAuditLog verifies the chain of the entries of the log up to its head, and the checkpoints matching the
+// entries, and returns the head verified with the number of checkpoints.
+func verifyAuditLog(ctx context.Context, c *gatekeeper.Client) (*audit.Head, int, error) {
+	raw, err := c.AuditCheckpoints(ctx)
+	if err != nil {
+		return nil, 0, fmt.Errorf("list audit checkpoints: %w", err)
+	}
+
+	checkpoints, err := parseCheckpoints(raw)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	checked := &audit.Head{}
+	matched := 0
+
+	var head *audit.Head
+
+	req := &model.PageRequest{Limit: audit.MaxEntries}
+
+	for {
+		page, e := c.AuditEntries(ctx, req)
+		if e != nil {
+			return nil, 0, fmt.Errorf("list audit entries: %w", e)
+		}
+
+		if page.Head == nil {
+			return nil, 0, fmt.Errorf("list audit entries: missing head")
+		}
+
+		head = page.Head
+
+		if checked, e = audit.VerifyChain(checked, page.Items); e != nil {
+			return nil, 0, e
+		}
+
+		for _, entry := range page.Items {
+			if cp, ok := checkpoints[entry.Seq]; ok {
+				if e = audit.VerifyCheckpoint(cp, entry); e != nil {
+					return nil, 0, e
+				}
+
+				matched++
+			}
+		}
+
+		if page.NextCursor == "" || checked.Seq >= head.Seq {
+			break
+		}
+
+		req.Cursor = page.NextCursor
+	}
+
+	if checked.Seq != head.Seq || checked.Hash != head.Hash {
+		return nil, 0, fmt.Errorf("%w: head %d does not match entry %d", audit.ErrTampered, head.Seq, checked.Seq)
+	}
+
+	if matched != len(checkpoints) {
+		return nil, 0, fmt.Errorf("%w: %d checkpoints past head %d", audit.ErrTampered, len(checkpoints)-matched,
+			checked.Seq)
+	}
+
+	return checked, matched, nil
+}
+
+// parseCheckpoints returns the checkpoints keyed by the sequence number of the entries they were signed with.
+func parseCheckpoints(raw []json.RawMessage) (map[int]*audit.Checkpoint, error) {
+	checkpoints := make(map[int]*audit.Checkpoint, len(raw))
+
+	for _, r := range raw {
+		cp, err := audit.ParseCheckpoint(r)
+		if err != nil {
+			return nil, err
+		}
+
+		checkpoints[cp.Seq] = cp
+	}
+
+	return checkpoints, nil
+}
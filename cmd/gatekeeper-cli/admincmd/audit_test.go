@@ -0,0 +1,166 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package admincmd //nolint:testpackage
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/ace/pkg/gatekeeper/audit"
+	"github.com/trustbloc/ace/pkg/restapi/gatekeeper/operation"
+	"github.com/trustbloc/ace/pkg/restapi/model"
+)
+
+func TestVerifyAuditCmd(t *testing.T) {
+	t.Run("test verify", func(t *testing.T) {
+		entries := newAuditEntries(t, 3)
+
+		srv := newAuditServer(t, entries, []*audit.Checkpoint{{Seq: 2, Hash: entries[1].Hash}},
+			&audit.Report{Verified: true, Entries: 3, Checkpoints: 1})
+
+		out, err := execute(t, GetAuditCmd(), "verify", "--"+urlFlagName, srv.URL, "--"+authTokenFlagName, testToken)
+		require.NoError(t, err)
+		require.Equal(t, fmt.Sprintf("3 entries verified, head %s\n1 checkpoints match the log\n"+
+			"1 checkpoint signatures verified by the server\n", entries[2].Hash), out)
+	})
+
+	t.Run("test modified entry", func(t *testing.T) {
+		entries := newAuditEntries(t, 3)
+		entries[1].DID = "did:example:other"
+
+		srv := newAuditServer(t, entries, nil, &audit.Report{Verified: true})
+
+		_, err := execute(t, GetAuditCmd(), "verify", "--"+urlFlagName, srv.URL, "--"+authTokenFlagName, testToken)
+		require.ErrorIs(t, err, audit.ErrTampered)
+		require.Contains(t, err.Error(), "entry 2 does not match its hash")
+	})
+
+	t.Run("test rehashed entry", func(t *testing.T) {
+		entries := newAuditEntries(t, 3)
+		checkpoint := &audit.Checkpoint{Seq: 3, Hash: entries[2].Hash}
+
+		entries[2].DID = "did:example:other"
+		entries[2].Hash = hash(t, entries[2])
+
+		srv := newAuditServer(t, entries, []*audit.Checkpoint{checkpoint}, &audit.Report{Verified: true})
+
+		_, err := execute(t, GetAuditCmd(), "verify", "--"+urlFlagName, srv.URL, "--"+authTokenFlagName, testToken)
+		require.ErrorIs(t, err, audit.ErrTampered)
+		require.Contains(t, err.Error(), "entry 3 does not match its checkpoint")
+	})
+
+	t.Run("test truncated log", func(t *testing.T) {
+		entries := newAuditEntries(t, 3)
+
+		srv := newAuditServer(t, entries[:2], []*audit.Checkpoint{{Seq: 3, Hash: entries[2].Hash}},
+			&audit.Report{Verified: true})
+
+		_, err := execute(t, GetAuditCmd(), "verify", "--"+urlFlagName, srv.URL, "--"+authTokenFlagName, testToken)
+		require.ErrorIs(t, err, audit.ErrTampered)
+		require.Contains(t, err.Error(), "1 checkpoints past head 2")
+	})
+
+	t.Run("test server verification failed", func(t *testing.T) {
+		srv := newAuditServer(t, newAuditEntries(t, 1), nil,
+			&audit.Report{Error: "audit log tampered: checkpoint not verified"})
+
+		_, err := execute(t, GetAuditCmd(), "verify", "--"+urlFlagName, srv.URL, "--"+authTokenFlagName, testToken)
+		require.EqualError(t, err, "server verification: audit log tampered: checkpoint not verified")
+	})
+
+	t.Run("test list failed", func(t *testing.T) {
+		srv := newTestServer(t, http.MethodGet, "/v1/audit/checkpoints", http.StatusInternalServerError, nil)
+
+		_, err := execute(t, GetAuditCmd(), "verify", "--"+urlFlagName, srv.URL, "--"+authTokenFlagName, testToken)
+		require.EqualError(t, err, "list audit checkpoints: GET /v1/audit/checkpoints: status 500")
+	})
+}
+
+// newAuditEntries returns n entries chained to each other.
+func newAuditEntries(t *testing.T, n int) []*audit.Entry {
+	t.Helper()
+
+	entries := make([]*audit.Entry, n)
+	prev := ""
+
+	for i := range entries {
+		entries[i] = &audit.Entry{Seq: i + 1, TicketID: "test-ticket", DID: "did:example:target", PrevHash: prev}
+		entries[i].Hash = hash(t, entries[i])
+		prev = entries[i].Hash
+	}
+
+	return entries
+}
+
+func hash(t *testing.T, e *audit.Entry) string {
+	t.Helper()
+
+	h, err := audit.Hash(e)
+	require.NoError(t, err)
+
+	return h
+}
+
+// newAuditServer serves the entries, with the head of the last entry, two at a time.
+func newAuditServer(t *testing.T, entries []*audit.Entry, checkpoints []*audit.Checkpoint,
+	report *audit.Report) *httptest.Server {
+	t.Helper()
+
+	head := &audit.Head{}
+	if len(entries) > 0 {
+		head = &audit.Head{Seq: entries[len(entries)-1].Seq, Hash: entries[len(entries)-1].Hash}
+	}
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/v1/audit/entries", func(rw http.ResponseWriter, r *http.Request) {
+		req, err := model.ParsePageRequest(r.URL.Query())
+		require.NoError(t, err)
+
+		after, err := req.Offset()
+		require.NoError(t, err)
+
+		page := []*audit.Entry{}
+
+		for _, e := range entries {
+			if e.Seq > after && len(page) < 2 {
+				page = append(page, e)
+			}
+		}
+
+		require.NoError(t, json.NewEncoder(rw).Encode(&operation.AuditEntriesResponse{
+			Items: page,
+			Head:  head,
+			Page:  model.NewPage(after, 2, head.Seq),
+		}))
+	})
+	mux.HandleFunc("/v1/audit/checkpoints", func(rw http.ResponseWriter, r *http.Request) {
+		resp := &operation.CheckpointsResponse{Checkpoints: []json.RawMessage{}}
+
+		for _, c := range checkpoints {
+			b, err := json.Marshal(map[string]interface{}{"type": audit.CheckpointType, "credentialSubject": c})
+			require.NoError(t, err)
+
+			resp.Checkpoints = append(resp.Checkpoints, b)
+		}
+
+		require.NoError(t, json.NewEncoder(rw).Encode(resp))
+	})
+	mux.HandleFunc("/v1/audit/verify", func(rw http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewEncoder(rw).Encode(report))
+	})
+
+	srv := httptest.NewServer(mux)
+
+	t.Cleanup(srv.Close)
+
+	return srv
+}
@@ -0,0 +1,128 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package admincmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	snapshotFileFlagName  = "file"
+	snapshotFileFlagUsage = "Path to the file of the encrypted snapshot."
+	snapshotFileMode      = 0o600
+)
+
+// GetBackupCmd returns the Cobra backup command.
+func GetBackupCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "backup",
+		Short: "Exports and restores encrypted snapshots of policies, protected data and tickets",
+		Long: "Exports and restores encrypted snapshots of policies, protected data and tickets, for disaster recovery" +
+			" drills. Snapshots hold the metadata of the protected data, not the vault contents. The Gatekeeper" +
+			" server must have a backup key set, and the url must be the one of the admin listener if there is one.",
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.HelpFunc()(cmd, args)
+		},
+	}
+
+	addClientFlags(cmd)
+
+	cmd.AddCommand(exportBackupCmd(), restoreBackupCmd())
+
+	return cmd
+}
+
+func exportBackupCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Writes the encrypted snapshot of the instance to the file",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			file, err := snapshotFile(cmd)
+			if err != nil {
+				return err
+			}
+
+			c, err := newClient(cmd)
+			if err != nil {
+				return err
+			}
+
+			snapshot, err := c.Backup(cmd.Context())
+			if err != nil {
+				return fmt.Errorf("backup: %w", err)
+			}
+
+			if err = os.WriteFile(file, snapshot, snapshotFileMode); err != nil {
+				return fmt.Errorf("write snapshot file: %w", err)
+			}
+
+			_, err = fmt.Fprintf(cmd.OutOrStdout(), "snapshot written to %s\n", file)
+
+			return err
+		},
+	}
+
+	cmd.Flags().StringP(snapshotFileFlagName, "f", "", snapshotFileFlagUsage)
+
+	return cmd
+}
+
+func restoreBackupCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "restore",
+		Short: "Restores the encrypted snapshot from the file into a fresh instance",
+		Long: "Restores the encrypted snapshot from the file into a fresh instance. Nothing is restored if the" +
+			" instance already has any of the entries of the snapshot.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			file, err := snapshotFile(cmd)
+			if err != nil {
+				return err
+			}
+
+			snapshot, err := os.ReadFile(file) //nolint:gosec
+			if err != nil {
+				return fmt.Errorf("read snapshot file: %w", err)
+			}
+
+			c, err := newClient(cmd)
+			if err != nil {
+				return err
+			}
+
+			results, err := c.Restore(cmd.Context(), snapshot)
+			if err != nil {
+				return fmt.Errorf("restore: %w", err)
+			}
+
+			for _, r := range results {
+				if _, err = fmt.Fprintf(cmd.OutOrStdout(), "%s: restored %d\n", r.Store, r.Entries); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringP(snapshotFileFlagName, "f", "", snapshotFileFlagUsage)
+
+	return cmd
+}
+
+func snapshotFile(cmd *cobra.Command) (string, error) {
+	file, err := cmd.Flags().GetString(snapshotFileFlagName)
+	if err != nil || file == "" {
+		return "", fmt.Errorf("%s must be set", snapshotFileFlagName)
+	}
+
+	return file, nil
+}
@@ -0,0 +1,63 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package admincmd //nolint:testpackage
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/ace/pkg/gatekeeper/backup"
+)
+
+func TestBackupCmd(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "snapshot.json")
+
+	t.Run("test export", func(t *testing.T) {
+		srv := newTestServer(t, http.MethodPost, "/backup", http.StatusOK, map[string]interface{}{"version": 1})
+
+		out, err := execute(t, GetBackupCmd(), "export", "--"+snapshotFileFlagName, file,
+			"--"+urlFlagName, srv.URL, "--"+authTokenFlagName, testToken)
+		require.NoError(t, err)
+		require.Equal(t, "snapshot written to "+file+"\n", out)
+
+		b, err := os.ReadFile(file) //nolint:gosec
+		require.NoError(t, err)
+		require.JSONEq(t, `{"version":1}`, string(b))
+	})
+
+	t.Run("test restore", func(t *testing.T) {
+		srv := newTestServer(t, http.MethodPost, "/restore", http.StatusOK, map[string]interface{}{
+			"stores": []*backup.Result{{Store: "policy", Entries: 2}, {Store: "ticket", Entries: 1}},
+		})
+
+		out, err := execute(t, GetBackupCmd(), "restore", "--"+snapshotFileFlagName, file,
+			"--"+urlFlagName, srv.URL, "--"+authTokenFlagName, testToken)
+		require.NoError(t, err)
+		require.Equal(t, "policy: restored 2\nticket: restored 1\n", out)
+	})
+
+	t.Run("test restore into an instance that is not fresh", func(t *testing.T) {
+		srv := newTestServer(t, http.MethodPost, "/restore", http.StatusConflict, nil)
+
+		_, err := execute(t, GetBackupCmd(), "restore", "--"+snapshotFileFlagName, file,
+			"--"+urlFlagName, srv.URL, "--"+authTokenFlagName, testToken)
+		require.EqualError(t, err, "restore: POST /restore: status 409")
+	})
+
+	t.Run("test missing file", func(t *testing.T) {
+		_, err := execute(t, GetBackupCmd(), "export", "--"+urlFlagName, "https://gatekeeper.example.com")
+		require.EqualError(t, err, "file must be set")
+
+		_, err = execute(t, GetBackupCmd(), "restore", "--"+snapshotFileFlagName, filepath.Join(t.TempDir(), "none"),
+			"--"+urlFlagName, "https://gatekeeper.example.com")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "read snapshot file")
+	})
+}
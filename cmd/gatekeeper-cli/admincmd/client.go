@@ -0,0 +1,110 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package admincmd
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+	cmdutils "github.com/trustbloc/edge-core/pkg/utils/cmd"
+	tlsutils "github.com/trustbloc/edge-core/pkg/utils/tls"
+
+	"github.com/trustbloc/ace/cmd/common"
+	"github.com/trustbloc/ace/pkg/client/gatekeeper"
+)
+
+const (
+	urlFlagName  = "url"
+	urlEnvKey    = "GK_CLI_URL"
+	urlFlagUsage = "URL of the Gatekeeper REST API, e.g. https://gatekeeper.example.com." +
+		" Alternatively, this can be set with the following environment variable: " + urlEnvKey
+
+	authTokenFlagName  = "api-token"
+	authTokenEnvKey    = "GK_REST_API_TOKEN" //nolint: gosec
+	authTokenFlagUsage = "Bearer token used for a token protected api calls. Must match the api-token of the" +
+		" Gatekeeper server." +
+		" Alternatively, this can be set with the following environment variable: " + authTokenEnvKey
+
+	tlsSystemCertPoolFlagName  = "tls-systemcertpool"
+	tlsSystemCertPoolEnvKey    = "GK_TLS_SYSTEMCERTPOOL"
+	tlsSystemCertPoolFlagUsage = "Use system certificate pool." +
+		" Possible values [true] [false]. Defaults to false if not set." +
+		" Alternatively, this can be set with the following environment variable: " + tlsSystemCertPoolEnvKey
+
+	tlsCACertsFlagName  = "tls-cacerts"
+	tlsCACertsEnvKey    = "GK_TLS_CACERTS"
+	tlsCACertsFlagUsage = "Comma-Separated list of ca certs path." +
+		" Alternatively, this can be set with the following environment variable: " + tlsCACertsEnvKey
+
+	requestTimeout = 30 * time.Second
+)
+
+func addClientFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringP(urlFlagName, "", "", urlFlagUsage)
+	cmd.PersistentFlags().StringP(authTokenFlagName, "", "", authTokenFlagUsage)
+	cmd.PersistentFlags().StringP(tlsSystemCertPoolFlagName, "", "", tlsSystemCertPoolFlagUsage)
+	cmd.PersistentFlags().StringArrayP(tlsCACertsFlagName, "", []string{}, tlsCACertsFlagUsage)
+}
+
+func newClient(cmd *cobra.Command) (*gatekeeper.Client, error) {
+	baseURL, err := cmdutils.GetUserSetVarFromString(cmd, urlFlagName, urlEnvKey, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = common.ValidateURL(urlFlagName, baseURL); err != nil {
+		return nil, err
+	}
+
+	authToken := cmdutils.GetUserSetOptionalVarFromString(cmd, authTokenFlagName, authTokenEnvKey)
+
+	tlsSystemCertPool := false
+
+	if v := cmdutils.GetUserSetOptionalVarFromString(cmd, tlsSystemCertPoolFlagName,
+		tlsSystemCertPoolEnvKey); v != "" {
+		tlsSystemCertPool, err = strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("parse %s: %w", tlsSystemCertPoolFlagName, err)
+		}
+	}
+
+	tlsCACerts := cmdutils.GetUserSetOptionalVarFromArrayString(cmd, tlsCACertsFlagName, tlsCACertsEnvKey)
+
+	rootCAs, err := tlsutils.GetCertPool(tlsSystemCertPool, tlsCACerts)
+	if err != nil {
+		return nil, fmt.Errorf("get cert pool: %w", err)
+	}
+
+	return gatekeeper.New(baseURL,
+		gatekeeper.WithAuthToken(authToken),
+		gatekeeper.WithHTTPClient(&http.Client{
+			Timeout: requestTimeout,
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{RootCAs: rootCAs, MinVersion: tls.VersionTLS12},
+			},
+		}),
+	), nil
+}
+
+// printJSON writes the value to the output of the command as indented JSON.
+func printJSON(cmd *cobra.Command, v interface{}) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal output: %w", err)
+	}
+
+	if _, err = fmt.Fprintln(cmd.OutOrStdout(), string(b)); err != nil {
+		return fmt.Errorf("write output: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,153 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package admincmd //nolint:testpackage
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/ace/pkg/restapi/model"
+)
+
+const testToken = "test-token"
+
+func TestNewClient(t *testing.T) {
+	t.Run("test missing url", func(t *testing.T) {
+		t.Setenv(urlEnvKey, "")
+		require.NoError(t, os.Unsetenv(urlEnvKey))
+
+		_, err := execute(t, GetPurgeCmd())
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "Neither url (command line flag) nor GK_CLI_URL (environment variable)"+
+			" have been set.")
+	})
+
+	t.Run("test invalid url", func(t *testing.T) {
+		_, err := execute(t, GetPurgeCmd(), "--"+urlFlagName, "gatekeeper")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid url: gatekeeper is not an absolute URL")
+	})
+
+	t.Run("test invalid tls-systemcertpool", func(t *testing.T) {
+		_, err := execute(t, GetPurgeCmd(), "--"+urlFlagName, "https://gatekeeper.example.com",
+			"--"+tlsSystemCertPoolFlagName, "wrongvalue")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "parse tls-systemcertpool")
+	})
+
+	t.Run("test invalid tls-cacerts", func(t *testing.T) {
+		_, err := execute(t, GetPurgeCmd(), "--"+urlFlagName, "https://gatekeeper.example.com",
+			"--"+tlsCACertsFlagName, "/missing/ca.pem")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "get cert pool")
+	})
+
+	t.Run("test url from env", func(t *testing.T) {
+		srv := newTestServer(t, http.MethodPost, "/v1/purge", http.StatusOK, nil)
+
+		t.Setenv(urlEnvKey, srv.URL+"/")
+		t.Setenv(authTokenEnvKey, testToken)
+
+		out, err := execute(t, GetPurgeCmd())
+		require.NoError(t, err)
+		require.Equal(t, "purge done\n", out)
+	})
+}
+
+func TestClientErrors(t *testing.T) {
+	t.Run("test error response", func(t *testing.T) {
+		srv := newTestServer(t, http.MethodPost, "/v1/purge", http.StatusInternalServerError,
+			&model.ErrorResponse{Message: "purge failed"})
+
+		_, err := execute(t, GetPurgeCmd(), "--"+urlFlagName, srv.URL, "--"+authTokenFlagName, testToken)
+		require.EqualError(t, err, "purge: POST /v1/purge: status 500: purge failed")
+	})
+
+	t.Run("test error response without message", func(t *testing.T) {
+		srv := newTestServer(t, http.MethodPost, "/v1/purge", http.StatusNotFound, nil)
+
+		_, err := execute(t, GetPurgeCmd(), "--"+urlFlagName, srv.URL, "--"+authTokenFlagName, testToken)
+		require.EqualError(t, err, "purge: POST /v1/purge: status 404")
+	})
+
+	t.Run("test unauthorized", func(t *testing.T) {
+		srv := newTestServer(t, http.MethodPost, "/v1/purge", http.StatusOK, nil)
+
+		_, err := execute(t, GetPurgeCmd(), "--"+urlFlagName, srv.URL, "--"+authTokenFlagName, "wrong-token")
+		require.EqualError(t, err, "purge: POST /v1/purge: status 401")
+	})
+
+	t.Run("test server not reachable", func(t *testing.T) {
+		srv := httptest.NewServer(http.NotFoundHandler())
+		srv.Close()
+
+		_, err := execute(t, GetPurgeCmd(), "--"+urlFlagName, srv.URL)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "purge: POST /v1/purge")
+	})
+
+	t.Run("test invalid response", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+			rw.Write([]byte("invalid json")) //nolint:errcheck
+		}))
+		defer srv.Close()
+
+		_, err := execute(t, GetTicketCmd(), "get", "test-ticket", "--"+urlFlagName, srv.URL)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "get ticket: unmarshal response")
+	})
+}
+
+// newTestServer returns a server responding to the token authorized request with the given method and path with the
+// status and JSON encoded payload, if any. Other requests are responded to with an error status.
+func newTestServer(t *testing.T, method, path string, status int, payload interface{}) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.Method != method || r.URL.Path != path {
+			rw.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		if r.Header.Get("Authorization") != "Bearer "+testToken {
+			rw.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		rw.WriteHeader(status)
+
+		if payload != nil {
+			require.NoError(t, json.NewEncoder(rw).Encode(payload))
+		}
+	}))
+
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func execute(t *testing.T, cmd *cobra.Command, args ...string) (string, error) {
+	t.Helper()
+
+	var out bytes.Buffer
+
+	cmd.SetOut(&out)
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs(args)
+
+	err := cmd.Execute()
+
+	return out.String(), err
+}
@@ -0,0 +1,199 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package admincmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/trustbloc/ace/pkg/gatekeeper/policy"
+)
+
+const (
+	policyFileFlagName  = "file"
+	policyFileFlagUsage = "Path to the JSON file with the policy configuration."
+
+	ifMatchFlagName  = "if-match"
+	ifMatchFlagUsage = "ETag of the policy, as printed by the get command, to replace the existing policy only if it" +
+		" was not modified since."
+)
+
+// GetPolicyCmd returns the Cobra policy command.
+func GetPolicyCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "policy",
+		Short: "Manages policies",
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.HelpFunc()(cmd, args)
+		},
+	}
+
+	addClientFlags(cmd)
+
+	cmd.AddCommand(createPolicyCmd(), validatePolicyCmd(), listPoliciesCmd(), getPolicyCmd())
+
+	return cmd
+}
+
+func createPolicyCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "create <policy-id>",
+		Short: "Creates or replaces the policy with the configuration from the file",
+		Long: "Creates or replaces the policy with the configuration from the file. The configuration is validated" +
+			" before it is sent, see the validate command. Existing policies are only replaced with the " +
+			ifMatchFlagName + " flag set to their ETag.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			p, err := readPolicy(cmd)
+			if err != nil {
+				return err
+			}
+
+			c, err := newClient(cmd)
+			if err != nil {
+				return err
+			}
+
+			etag, err := cmd.Flags().GetString(ifMatchFlagName)
+			if err != nil {
+				return err
+			}
+
+			if etag != "" {
+				_, err = c.UpdatePolicy(cmd.Context(), args[0], etag, p)
+			} else {
+				err = c.CreatePolicy(cmd.Context(), args[0], p)
+			}
+
+			if err != nil {
+				return fmt.Errorf("create policy: %w", err)
+			}
+
+			_, err = fmt.Fprintf(cmd.OutOrStdout(), "policy %s saved\n", strings.ToLower(args[0]))
+
+			return err
+		},
+	}
+
+	cmd.Flags().StringP(policyFileFlagName, "f", "", policyFileFlagUsage)
+	cmd.Flags().String(ifMatchFlagName, "", ifMatchFlagUsage)
+
+	return cmd
+}
+
+func validatePolicyCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "validate",
+		Short: "Validates the policy configuration from the file without sending it",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if _, err := readPolicy(cmd); err != nil {
+				return err
+			}
+
+			_, err := fmt.Fprintln(cmd.OutOrStdout(), "policy is valid")
+
+			return err
+		},
+	}
+
+	cmd.Flags().StringP(policyFileFlagName, "f", "", policyFileFlagUsage)
+
+	return cmd
+}
+
+func listPoliciesCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "Lists policies",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := newClient(cmd)
+			if err != nil {
+				return err
+			}
+
+			policies, err := c.ListPolicies(cmd.Context())
+			if err != nil {
+				return fmt.Errorf("list policies: %w", err)
+			}
+
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0) //nolint:gomnd
+
+			fmt.Fprintln(w, "ID\tCOLLECTORS\tHANDLERS\tAPPROVERS\tMIN APPROVERS") //nolint:errcheck
+
+			for _, p := range policies {
+				fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\n", p.ID, len(p.Collectors), len(p.Handlers), //nolint:errcheck
+					len(p.Approvers), p.MinApprovers)
+			}
+
+			return w.Flush()
+		},
+	}
+}
+
+func getPolicyCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "get <policy-id>",
+		Short: "Prints the policy configuration",
+		Long: "Prints the policy configuration, and its ETag to the standard error, to replace the policy with the" +
+			" create command.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := newClient(cmd)
+			if err != nil {
+				return err
+			}
+
+			p, etag, err := c.GetPolicyWithETag(cmd.Context(), args[0])
+			if err != nil {
+				return fmt.Errorf("get policy: %w", err)
+			}
+
+			if etag != "" {
+				fmt.Fprintf(cmd.ErrOrStderr(), "ETag: %s\n", etag) //nolint:errcheck
+			}
+
+			return printJSON(cmd, p)
+		},
+	}
+}
+
+// readPolicy reads the policy configuration from the file set for the command and validates it. Unknown fields are
+// rejected, as they are most likely misspelled ones.
+func readPolicy(cmd *cobra.Command) (*policy.Policy, error) {
+	file, err := cmd.Flags().GetString(policyFileFlagName)
+	if err != nil || file == "" {
+		return nil, fmt.Errorf("%s must be set", policyFileFlagName)
+	}
+
+	b, err := os.ReadFile(file) //nolint:gosec
+	if err != nil {
+		return nil, fmt.Errorf("read policy file: %w", err)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+
+	var p policy.Policy
+
+	if err = dec.Decode(&p); err != nil {
+		return nil, fmt.Errorf("parse policy file: %w", err)
+	}
+
+	if err = p.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid policy: %w", err)
+	}
+
+	return &p, nil
+}
@@ -0,0 +1,201 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package admincmd //nolint:testpackage
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/ace/pkg/gatekeeper/policy"
+	"github.com/trustbloc/ace/pkg/restapi/gatekeeper/operation"
+)
+
+const testPolicy = `{
+  "collectors": ["did:example:ray_stantz"],
+  "handlers": ["did:example:alter_peck"],
+  "approvers": ["did:example:peter_venkman", "did:example:eon_spengler", "did:example:winton_zeddemore"],
+  "min_approvers": 2
+}`
+
+func TestCreatePolicyCmd(t *testing.T) {
+	file := writePolicyFile(t, testPolicy)
+
+	t.Run("test create policy", func(t *testing.T) {
+		var saved policy.Policy
+
+		srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			require.Equal(t, http.MethodPut, r.Method)
+			require.Equal(t, "/v1/policy/Containment-Policy", r.URL.Path)
+			require.Equal(t, "Bearer "+testToken, r.Header.Get("Authorization"))
+
+			b, err := io.ReadAll(r.Body)
+			require.NoError(t, err)
+			require.NoError(t, json.Unmarshal(b, &saved))
+		}))
+		defer srv.Close()
+
+		out, err := execute(t, GetPolicyCmd(), "create", "Containment-Policy", "--"+policyFileFlagName, file,
+			"--"+urlFlagName, srv.URL, "--"+authTokenFlagName, testToken)
+		require.NoError(t, err)
+		require.Equal(t, "policy containment-policy saved\n", out)
+		require.Equal(t, 2, saved.MinApprovers)
+		require.Len(t, saved.Approvers, 3)
+	})
+
+	t.Run("test replace policy", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			require.Equal(t, http.MethodPut, r.Method)
+
+			if r.Header.Get("If-Match") != `"v1"` {
+				rw.WriteHeader(http.StatusPreconditionFailed)
+			}
+		}))
+		defer srv.Close()
+
+		out, err := execute(t, GetPolicyCmd(), "create", "containment-policy", "--"+policyFileFlagName, file,
+			"--"+ifMatchFlagName, `"v1"`, "--"+urlFlagName, srv.URL, "--"+authTokenFlagName, testToken)
+		require.NoError(t, err)
+		require.Equal(t, "policy containment-policy saved\n", out)
+
+		_, err = execute(t, GetPolicyCmd(), "create", "containment-policy", "--"+policyFileFlagName, file,
+			"--"+ifMatchFlagName, `"v0"`, "--"+urlFlagName, srv.URL, "--"+authTokenFlagName, testToken)
+		require.EqualError(t, err, "create policy: PUT /v1/policy/containment-policy: status 412")
+	})
+
+	t.Run("test invalid policy is not sent", func(t *testing.T) {
+		_, err := execute(t, GetPolicyCmd(), "create", "containment-policy",
+			"--"+policyFileFlagName, writePolicyFile(t, `{"approvers": ["did:example:peter_venkman"]}`),
+			"--"+urlFlagName, "https://gatekeeper.example.com")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid policy: min_approvers must be greater than 0")
+	})
+
+	t.Run("test fail to save policy", func(t *testing.T) {
+		srv := newTestServer(t, http.MethodPut, "/v1/policy/other-policy", http.StatusOK, nil)
+
+		_, err := execute(t, GetPolicyCmd(), "create", "containment-policy", "--"+policyFileFlagName, file,
+			"--"+urlFlagName, srv.URL, "--"+authTokenFlagName, testToken)
+		require.EqualError(t, err, "create policy: PUT /v1/policy/containment-policy: status 404")
+	})
+
+	t.Run("test missing policy id", func(t *testing.T) {
+		_, err := execute(t, GetPolicyCmd(), "create", "--"+policyFileFlagName, file)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "accepts 1 arg(s), received 0")
+	})
+}
+
+func TestValidatePolicyCmd(t *testing.T) {
+	t.Run("test valid policy", func(t *testing.T) {
+		out, err := execute(t, GetPolicyCmd(), "validate", "--"+policyFileFlagName, writePolicyFile(t, testPolicy))
+		require.NoError(t, err)
+		require.Equal(t, "policy is valid\n", out)
+	})
+
+	t.Run("test missing file", func(t *testing.T) {
+		_, err := execute(t, GetPolicyCmd(), "validate")
+		require.EqualError(t, err, "file must be set")
+
+		_, err = execute(t, GetPolicyCmd(), "validate", "--"+policyFileFlagName, filepath.Join(t.TempDir(), "none"))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "read policy file")
+	})
+
+	t.Run("test invalid file", func(t *testing.T) {
+		_, err := execute(t, GetPolicyCmd(), "validate", "--"+policyFileFlagName, writePolicyFile(t, "invalid"))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "parse policy file")
+	})
+
+	t.Run("test unknown field", func(t *testing.T) {
+		_, err := execute(t, GetPolicyCmd(), "validate",
+			"--"+policyFileFlagName, writePolicyFile(t, `{"min_aprovers": 2}`))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), `parse policy file: json: unknown field "min_aprovers"`)
+	})
+
+	t.Run("test invalid policy", func(t *testing.T) {
+		_, err := execute(t, GetPolicyCmd(), "validate", "--"+policyFileFlagName,
+			writePolicyFile(t, `{"approvers": ["did:example:a", "did:example:a", "did:example:b"], "min_approvers": 2}`))
+		require.EqualError(t, err, "invalid policy: duplicate approver did:example:a")
+	})
+}
+
+func TestListPoliciesCmd(t *testing.T) {
+	t.Run("test list policies", func(t *testing.T) {
+		srv := newTestServer(t, http.MethodGet, "/v1/policy", http.StatusOK, &operation.ListPoliciesResponse{
+			Policies: []*policy.Policy{
+				{
+					ID:           "containment-policy",
+					Collectors:   []string{"did:example:ray_stantz"},
+					Handlers:     []string{"did:example:alter_peck"},
+					Approvers:    []string{"did:example:peter_venkman", "did:example:eon_spengler"},
+					MinApprovers: 1,
+				},
+				{ID: "empty"},
+			},
+		})
+
+		out, err := execute(t, GetPolicyCmd(), "list", "--"+urlFlagName, srv.URL, "--"+authTokenFlagName, testToken)
+		require.NoError(t, err)
+		require.Equal(t, ""+
+			"ID                  COLLECTORS  HANDLERS  APPROVERS  MIN APPROVERS\n"+
+			"containment-policy  1           1         2          1\n"+
+			"empty               0           0         0          0\n", out)
+	})
+
+	t.Run("test fail to list policies", func(t *testing.T) {
+		srv := newTestServer(t, http.MethodGet, "/v1/policy", http.StatusOK, nil)
+
+		_, err := execute(t, GetPolicyCmd(), "list", "--"+urlFlagName, srv.URL)
+		require.EqualError(t, err, "list policies: GET /v1/policy: status 401")
+	})
+}
+
+func TestGetPolicyCmd(t *testing.T) {
+	t.Run("test get policy", func(t *testing.T) {
+		srv := newTestServer(t, http.MethodGet, "/v1/policy/containment-policy", http.StatusOK, &policy.Policy{
+			ID:           "containment-policy",
+			Approvers:    []string{"did:example:peter_venkman", "did:example:eon_spengler"},
+			MinApprovers: 1,
+		})
+
+		out, err := execute(t, GetPolicyCmd(), "get", "containment-policy", "--"+urlFlagName, srv.URL,
+			"--"+authTokenFlagName, testToken)
+		require.NoError(t, err)
+
+		var p policy.Policy
+
+		require.NoError(t, json.Unmarshal([]byte(out), &p))
+		require.Equal(t, "containment-policy", p.ID)
+		require.Equal(t, 1, p.MinApprovers)
+	})
+
+	t.Run("test policy not found", func(t *testing.T) {
+		srv := newTestServer(t, http.MethodGet, "/v1/policy/containment-policy", http.StatusOK, nil)
+
+		_, err := execute(t, GetPolicyCmd(), "get", "other-policy", "--"+urlFlagName, srv.URL,
+			"--"+authTokenFlagName, testToken)
+		require.EqualError(t, err, "get policy: GET /v1/policy/other-policy: status 404")
+	})
+}
+
+func writePolicyFile(t *testing.T, content string) string {
+	t.Helper()
+
+	file := filepath.Join(t.TempDir(), "policy.json")
+
+	require.NoError(t, os.WriteFile(file, []byte(content), 0o600))
+
+	return file
+}
@@ -0,0 +1,42 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package admincmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// GetPurgeCmd returns the Cobra purge command.
+func GetPurgeCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "purge",
+		Short: "Purges deleted data past its retention window",
+		Long: "Purges deleted policies and protected data past their retention window without waiting for the next" +
+			" scheduled purge of the Gatekeeper server.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := newClient(cmd)
+			if err != nil {
+				return err
+			}
+
+			if err = c.Purge(cmd.Context()); err != nil {
+				return fmt.Errorf("purge: %w", err)
+			}
+
+			_, err = fmt.Fprintln(cmd.OutOrStdout(), "purge done")
+
+			return err
+		},
+	}
+
+	addClientFlags(cmd)
+
+	return cmd
+}
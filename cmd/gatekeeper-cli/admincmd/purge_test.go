@@ -0,0 +1,25 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package admincmd //nolint:testpackage
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPurgeCmd(t *testing.T) {
+	srv := newTestServer(t, http.MethodPost, "/v1/purge", http.StatusOK, nil)
+
+	out, err := execute(t, GetPurgeCmd(), "--"+urlFlagName, srv.URL, "--"+authTokenFlagName, testToken)
+	require.NoError(t, err)
+	require.Equal(t, "purge done\n", out)
+
+	_, err = execute(t, GetPurgeCmd(), "extra", "--"+urlFlagName, srv.URL)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), `unknown command "extra"`)
+}
@@ -0,0 +1,51 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package admincmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// GetTicketCmd returns the Cobra ticket command.
+func GetTicketCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "ticket",
+		Short: "Inspects release tickets",
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.HelpFunc()(cmd, args)
+		},
+	}
+
+	addClientFlags(cmd)
+
+	cmd.AddCommand(getTicketCmd())
+
+	return cmd
+}
+
+func getTicketCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "get <ticket-id>",
+		Short: "Prints the ticket with its status and approvals",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := newClient(cmd)
+			if err != nil {
+				return err
+			}
+
+			t, err := c.GetTicket(cmd.Context(), args[0])
+			if err != nil {
+				return fmt.Errorf("get ticket: %w", err)
+			}
+
+			return printJSON(cmd, t)
+		},
+	}
+}
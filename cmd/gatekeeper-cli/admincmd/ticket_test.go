@@ -0,0 +1,46 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package admincmd //nolint:testpackage
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/ace/pkg/restapi/gatekeeper/operation"
+)
+
+func TestGetTicketCmd(t *testing.T) {
+	ticket := &operation.TicketResponse{
+		ID:         "test-ticket",
+		DID:        "did:example:target",
+		Status:     "READY_TO_COLLECT",
+		ApprovedBy: []string{"did:example:peter_venkman"},
+	}
+
+	t.Run("test get ticket", func(t *testing.T) {
+		srv := newTestServer(t, http.MethodGet, "/v1/release/test-ticket", http.StatusOK, ticket)
+
+		out, err := execute(t, GetTicketCmd(), "get", "test-ticket", "--"+urlFlagName, srv.URL,
+			"--"+authTokenFlagName, testToken)
+		require.NoError(t, err)
+
+		var resp operation.TicketResponse
+
+		require.NoError(t, json.Unmarshal([]byte(out), &resp))
+		require.Equal(t, ticket, &resp)
+	})
+
+	t.Run("test ticket not found", func(t *testing.T) {
+		srv := newTestServer(t, http.MethodGet, "/v1/release/test-ticket", http.StatusOK, ticket)
+
+		_, err := execute(t, GetTicketCmd(), "get", "other-ticket", "--"+urlFlagName, srv.URL,
+			"--"+authTokenFlagName, testToken)
+		require.EqualError(t, err, "get ticket: GET /v1/release/other-ticket: status 404")
+	})
+}
@@ -0,0 +1,38 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package main Gatekeeper admin CLI.
+//
+// The CLI manages policies and tickets of a running Gatekeeper instance through its REST API.
+package main
+
+import (
+	"github.com/hyperledger/aries-framework-go/pkg/common/log"
+	"github.com/spf13/cobra"
+
+	"github.com/trustbloc/ace/cmd/gatekeeper-cli/admincmd"
+)
+
+var logger = log.New("gatekeeper-cli")
+
+func main() {
+	rootCmd := &cobra.Command{
+		Use: "gatekeeper-cli",
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.HelpFunc()(cmd, args)
+		},
+	}
+
+	rootCmd.AddCommand(admincmd.GetPolicyCmd())
+	rootCmd.AddCommand(admincmd.GetTicketCmd())
+	rootCmd.AddCommand(admincmd.GetPurgeCmd())
+	rootCmd.AddCommand(admincmd.GetBackupCmd())
+	rootCmd.AddCommand(admincmd.GetAuditCmd())
+
+	if err := rootCmd.Execute(); err != nil {
+		logger.Fatalf("execute root cmd: %s", err.Error())
+	}
+}
@@ -26,6 +26,7 @@ import (
 	"github.com/hyperledger/aries-framework-go/pkg/common/log"
 	"github.com/spf13/cobra"
 
+	"github.com/trustbloc/ace/cmd/gatekeeper/migratecmd"
 	"github.com/trustbloc/ace/cmd/gatekeeper/startcmd"
 )
 
@@ -40,6 +41,7 @@ func main() {
 	}
 
 	rootCmd.AddCommand(startcmd.GetStartCmd(&startcmd.HTTPServer{}))
+	rootCmd.AddCommand(migratecmd.GetMigrateCmd())
 
 	if err := rootCmd.Execute(); err != nil {
 		logger.Fatalf("execute root cmd: %s", err.Error())
@@ -0,0 +1,176 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package migratecmd
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/hyperledger/aries-framework-go/pkg/common/log"
+	"github.com/spf13/cobra"
+	cmdutils "github.com/trustbloc/edge-core/pkg/utils/cmd"
+
+	"github.com/trustbloc/ace/cmd/common"
+	"github.com/trustbloc/ace/pkg/gatekeeper/stores"
+	"github.com/trustbloc/ace/pkg/storage/migrate"
+)
+
+const (
+	sourceDatabaseURLFlagName  = "source-database-url"
+	sourceDatabaseURLEnvKey    = "GK_MIGRATE_SOURCE_DATABASE_URL"
+	sourceDatabaseURLFlagUsage = "URL of the database to migrate from. Format is the same as for the database-url" +
+		" flag of the start command." +
+		" Alternatively, this can be set with the following environment variable: " + sourceDatabaseURLEnvKey
+
+	sourceDatabasePrefixFlagName  = "source-database-prefix"
+	sourceDatabasePrefixEnvKey    = "GK_MIGRATE_SOURCE_DATABASE_PREFIX"
+	sourceDatabasePrefixFlagUsage = "An optional prefix of the databases to migrate from." +
+		" Alternatively, this can be set with the following environment variable: " + sourceDatabasePrefixEnvKey
+
+	targetDatabaseURLFlagName  = "target-database-url"
+	targetDatabaseURLEnvKey    = "GK_MIGRATE_TARGET_DATABASE_URL"
+	targetDatabaseURLFlagUsage = "URL of the database to migrate to. Format is the same as for the database-url" +
+		" flag of the start command." +
+		" Alternatively, this can be set with the following environment variable: " + targetDatabaseURLEnvKey
+
+	targetDatabasePrefixFlagName  = "target-database-prefix"
+	targetDatabasePrefixEnvKey    = "GK_MIGRATE_TARGET_DATABASE_PREFIX"
+	targetDatabasePrefixFlagUsage = "An optional prefix of the databases to migrate to." +
+		" Alternatively, this can be set with the following environment variable: " + targetDatabasePrefixEnvKey
+
+	batchSizeFlagName  = "batch-size"
+	batchSizeEnvKey    = "GK_MIGRATE_BATCH_SIZE"
+	batchSizeFlagUsage = "Number of entries written to the target database in one batch. Defaults to 100 if not set." +
+		" Alternatively, this can be set with the following environment variable: " + batchSizeEnvKey
+)
+
+var logger = log.New("gatekeeper-migrate")
+
+type migrateParameters struct {
+	source    *common.DBParameters
+	target    *common.DBParameters
+	batchSize int
+}
+
+// GetMigrateCmd returns the Cobra migrate command.
+func GetMigrateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Copies the state of Gatekeeper from one database to another",
+		Long: "Copies the state of Gatekeeper from one database to another and verifies the copies: policies," +
+			" protected data, tickets, the config with its KMS keys, the audit log and the other durable stores." +
+			" Entries already present in the target database are skipped, so an interrupted migration is resumed" +
+			" by running the command again.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			params, err := getParameters(cmd)
+			if err != nil {
+				return err
+			}
+
+			return runMigration(cmd, params)
+		},
+	}
+
+	cmd.Flags().StringP(sourceDatabaseURLFlagName, "", "", sourceDatabaseURLFlagUsage)
+	cmd.Flags().StringP(sourceDatabasePrefixFlagName, "", "", sourceDatabasePrefixFlagUsage)
+	cmd.Flags().StringP(targetDatabaseURLFlagName, "", "", targetDatabaseURLFlagUsage)
+	cmd.Flags().StringP(targetDatabasePrefixFlagName, "", "", targetDatabasePrefixFlagUsage)
+	cmd.Flags().StringP(batchSizeFlagName, "", "", batchSizeFlagUsage)
+	cmd.Flags().StringP(common.DatabaseTimeoutFlagName, "", "", common.DatabaseTimeoutFlagUsage)
+
+	return cmd
+}
+
+func getParameters(cmd *cobra.Command) (*migrateParameters, error) {
+	source, err := dbParams(cmd, sourceDatabaseURLFlagName, sourceDatabaseURLEnvKey,
+		sourceDatabasePrefixFlagName, sourceDatabasePrefixEnvKey)
+	if err != nil {
+		return nil, err
+	}
+
+	target, err := dbParams(cmd, targetDatabaseURLFlagName, targetDatabaseURLEnvKey,
+		targetDatabasePrefixFlagName, targetDatabasePrefixEnvKey)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := uint64(common.DatabaseTimeoutDefault)
+
+	if v := cmdutils.GetUserSetOptionalVarFromString(cmd, common.DatabaseTimeoutFlagName,
+		common.DatabaseTimeoutEnvKey); v != "" {
+		timeout, err = strconv.ParseUint(v, 10, 64) //nolint:gomnd
+		if err != nil {
+			return nil, fmt.Errorf("parse %s: %w", common.DatabaseTimeoutFlagName, err)
+		}
+	}
+
+	source.Timeout = timeout
+	target.Timeout = timeout
+
+	batchSize := migrate.DefaultBatchSize
+
+	if v := cmdutils.GetUserSetOptionalVarFromString(cmd, batchSizeFlagName, batchSizeEnvKey); v != "" {
+		batchSize, err = strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("parse %s: %w", batchSizeFlagName, err)
+		}
+
+		if batchSize <= 0 {
+			return nil, fmt.Errorf("%s must be positive", batchSizeFlagName)
+		}
+	}
+
+	return &migrateParameters{
+		source:    source,
+		target:    target,
+		batchSize: batchSize,
+	}, nil
+}
+
+func dbParams(cmd *cobra.Command, urlFlagName, urlEnvKey, prefixFlagName,
+	prefixEnvKey string) (*common.DBParameters, error) {
+	dbURL, err := cmdutils.GetUserSetVarFromString(cmd, urlFlagName, urlEnvKey, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return &common.DBParameters{
+		URL:    dbURL,
+		Prefix: cmdutils.GetUserSetOptionalVarFromString(cmd, prefixFlagName, prefixEnvKey),
+	}, nil
+}
+
+func runMigration(cmd *cobra.Command, params *migrateParameters) error {
+	source, err := common.InitStore(params.source, logger)
+	if err != nil {
+		return fmt.Errorf("init source store: %w", err)
+	}
+
+	target, err := common.InitStore(params.target, logger)
+	if err != nil {
+		return fmt.Errorf("init target store: %w", err)
+	}
+
+	migrations, err := stores.Migrations(source)
+	if err != nil {
+		return err
+	}
+
+	m := migrate.New(source, target, migrate.WithBatchSize(params.batchSize))
+
+	for _, s := range migrations {
+		result, err := m.Migrate(s)
+		if err != nil {
+			return fmt.Errorf("migrate: %w", err)
+		}
+
+		cmd.Printf("%s: copied %d, skipped %d, verified %d\n",
+			result.Store, result.Copied, result.Skipped, result.Verified)
+	}
+
+	return nil
+}
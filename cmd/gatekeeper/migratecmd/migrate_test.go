@@ -0,0 +1,99 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package migratecmd //nolint:testpackage
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/ace/cmd/common"
+)
+
+func TestMigrateCmd(t *testing.T) {
+	args := []string{
+		"--" + sourceDatabaseURLFlagName, "mem://source",
+		"--" + targetDatabaseURLFlagName, "mem://target",
+	}
+
+	t.Run("test migration", func(t *testing.T) {
+		var out bytes.Buffer
+
+		cmd := GetMigrateCmd()
+		cmd.SetOut(&out)
+		cmd.SetArgs(append(args, "--"+batchSizeFlagName, "10", "--"+common.DatabaseTimeoutFlagName, "1"))
+
+		require.NoError(t, cmd.Execute())
+		require.Contains(t, out.String(), "policy: copied 0, skipped 0, verified 0\n")
+		require.Contains(t, out.String(), "kmsdb: copied 0, skipped 0, verified 0\n")
+		require.Contains(t, out.String(), "audit_log: copied 0, skipped 0, verified 0\n")
+		require.Contains(t, out.String(), "lease: copied 0, skipped 0, verified 0\n")
+	})
+
+	t.Run("test missing database urls", func(t *testing.T) {
+		cmd := GetMigrateCmd()
+
+		err := cmd.Execute()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "Neither source-database-url (command line flag) nor "+
+			"GK_MIGRATE_SOURCE_DATABASE_URL (environment variable) have been set.")
+
+		cmd = GetMigrateCmd()
+		cmd.SetArgs(args[:2])
+
+		err = cmd.Execute()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "Neither target-database-url (command line flag) nor "+
+			"GK_MIGRATE_TARGET_DATABASE_URL (environment variable) have been set.")
+	})
+
+	t.Run("test invalid database timeout", func(t *testing.T) {
+		cmd := GetMigrateCmd()
+		cmd.SetArgs(append(args, "--"+common.DatabaseTimeoutFlagName, "soon"))
+
+		err := cmd.Execute()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "parse database-timeout")
+	})
+
+	t.Run("test invalid batch size", func(t *testing.T) {
+		cmd := GetMigrateCmd()
+		cmd.SetArgs(append(args, "--"+batchSizeFlagName, "many"))
+
+		err := cmd.Execute()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "parse batch-size")
+
+		cmd = GetMigrateCmd()
+		cmd.SetArgs(append(args, "--"+batchSizeFlagName, "0"))
+
+		err = cmd.Execute()
+		require.EqualError(t, err, "batch-size must be positive")
+	})
+
+	t.Run("test unsupported database driver", func(t *testing.T) {
+		cmd := GetMigrateCmd()
+		cmd.SetArgs([]string{
+			"--" + sourceDatabaseURLFlagName, "unsupported://source",
+			"--" + targetDatabaseURLFlagName, "mem://target",
+		})
+
+		err := cmd.Execute()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "init source store")
+
+		cmd = GetMigrateCmd()
+		cmd.SetArgs([]string{
+			"--" + sourceDatabaseURLFlagName, "mem://source",
+			"--" + targetDatabaseURLFlagName, "unsupported://target",
+		})
+
+		err = cmd.Execute()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "init target store")
+	})
+}
@@ -0,0 +1,14 @@
+//go:build postgres
+// +build postgres
+
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+// The PostgreSQL driver is only linked in builds with the postgres tag, as the postgres database driver of
+// --database-url needs a database/sql driver registered.
+import _ "github.com/lib/pq" // registers the postgres driver
@@ -0,0 +1,133 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package startcmd
+
+import (
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/http/pprof"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	tlsutils "github.com/trustbloc/edge-core/pkg/utils/tls"
+
+	"github.com/trustbloc/ace/pkg/restapi/healthcheck"
+)
+
+const (
+	pprofPathPrefix = "/debug/pprof/"
+	metricsEndpoint = "/metrics"
+)
+
+// addHealthCheck adds the health check and the version endpoints to the router.
+func addHealthCheck(router *mux.Router) {
+	for _, handler := range healthcheck.New().GetOperations() {
+		router.HandleFunc(handler.Path(), handler.Handle()).Methods(handler.Method())
+	}
+}
+
+// newMetricsRegistry returns the registry of the metrics served by the metrics endpoint, with the Go runtime and
+// process metrics registered.
+func newMetricsRegistry() *prometheus.Registry {
+	registry := prometheus.NewRegistry()
+
+	registry.MustRegister(collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+
+	return registry
+}
+
+// metricsHandler serves the metrics of the registry in the Prometheus exposition format.
+func metricsHandler(registry *prometheus.Registry) http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// newAdminRouter returns the router of the admin listener: the health check, the version, the configuration reload
+// if its handler is set, the DID key rotation, the metrics, the health history and, with profiles set, the pprof
+// profiles. The auth middleware protects all endpoints but the health check and the version.
+func newAdminRouter(reload, rotateKey, metrics, healthHistory http.Handler,
+	auth func(http.Handler) http.Handler, profiles bool) *mux.Router {
+	router := mux.NewRouter()
+
+	addHealthCheck(router)
+
+	if reload != nil {
+		router.Handle(reloadEndpoint, auth(reload)).Methods(http.MethodPost)
+	}
+
+	router.Handle(rotateKeyEndpoint, auth(rotateKey)).Methods(http.MethodPost)
+	router.Handle(metricsEndpoint, auth(metrics)).Methods(http.MethodGet)
+	router.Handle(healthHistoryEndpoint, auth(healthHistory)).Methods(http.MethodGet)
+
+	if !profiles {
+		return router
+	}
+
+	router.Handle(pprofPathPrefix+"cmdline", auth(http.HandlerFunc(pprof.Cmdline)))
+	router.Handle(pprofPathPrefix+"profile", auth(http.HandlerFunc(pprof.Profile)))
+	router.Handle(pprofPathPrefix+"symbol", auth(http.HandlerFunc(pprof.Symbol)))
+	router.Handle(pprofPathPrefix+"trace", auth(http.HandlerFunc(pprof.Trace)))
+	router.PathPrefix(pprofPathPrefix).Handler(auth(http.HandlerFunc(pprof.Index)))
+
+	return router
+}
+
+// serve serves the public API on the host URL and on the Unix socket if one is set, the admin endpoints on the
+// admin host URL if one is set, over mutual TLS if its client CA certs are set, and the gRPC API on the gRPC host URL
+// if one is set. It returns when any of the listeners stops.
+func serve(srv server, params *serviceParameters, public, admin, grpcAPI http.Handler) error {
+	certPath, keyPath := params.tlsParams.serveCertPath, params.tlsParams.serveKeyPath
+
+	var adminClientCAs *x509.CertPool
+
+	if len(params.tlsParams.adminClientCACerts) > 0 {
+		var err error
+
+		adminClientCAs, err = tlsutils.GetCertPool(false, params.tlsParams.adminClientCACerts)
+		if err != nil {
+			return fmt.Errorf("load admin client CA certs: %w", err)
+		}
+	}
+
+	listeners := []func() error{
+		func() error { return srv.ListenAndServe(params.host, certPath, keyPath, nil, public) },
+	}
+
+	if params.adminHost != "" {
+		listeners = append(listeners, func() error {
+			return srv.ListenAndServe(params.adminHost, certPath, keyPath, adminClientCAs, admin)
+		})
+	}
+
+	if params.unixSocket != "" {
+		listeners = append(listeners, func() error {
+			// TLS is terminated by the proxy in front of the socket
+			return srv.ListenAndServe(unixSocketPrefix+params.unixSocket, "", "", nil, public)
+		})
+	}
+
+	if params.grpcHost != "" {
+		listeners = append(listeners, func() error {
+			return srv.ListenAndServe(params.grpcHost, certPath, keyPath, nil, grpcAPI)
+		})
+	}
+
+	if len(listeners) == 1 {
+		return listeners[0]()
+	}
+
+	errs := make(chan error, len(listeners))
+
+	for _, listen := range listeners {
+		go func(listen func() error) {
+			errs <- listen()
+		}(listen)
+	}
+
+	return <-errs
+}
@@ -0,0 +1,276 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package startcmd //nolint:testpackage
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewAdminRouter(t *testing.T) {
+	reload := http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+		rw.WriteHeader(http.StatusAccepted)
+	})
+
+	rotateKey := http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+		rw.WriteHeader(http.StatusCreated)
+	})
+
+	auth := func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer token" {
+				rw.WriteHeader(http.StatusUnauthorized)
+
+				return
+			}
+
+			h.ServeHTTP(rw, r)
+		})
+	}
+
+	health := newHealthMonitor(map[string]func() error{})
+
+	router := newAdminRouter(reload, rotateKey, metricsHandler(newMetricsRegistry()),
+		http.HandlerFunc(health.historyHandler), auth, true)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		token  bool
+		status int
+	}{
+		{"health check", http.MethodGet, "/healthcheck", false, http.StatusOK},
+		{"version", http.MethodGet, "/version", false, http.StatusOK},
+		{"reload", http.MethodPost, reloadEndpoint, true, http.StatusAccepted},
+		{"reload without token", http.MethodPost, reloadEndpoint, false, http.StatusUnauthorized},
+		{"rotate key", http.MethodPost, rotateKeyEndpoint, true, http.StatusCreated},
+		{"rotate key without token", http.MethodPost, rotateKeyEndpoint, false, http.StatusUnauthorized},
+		{"metrics", http.MethodGet, metricsEndpoint, true, http.StatusOK},
+		{"metrics without token", http.MethodGet, metricsEndpoint, false, http.StatusUnauthorized},
+		{"health history", http.MethodGet, healthHistoryEndpoint, true, http.StatusOK},
+		{"health history without token", http.MethodGet, healthHistoryEndpoint, false, http.StatusUnauthorized},
+		{"pprof index", http.MethodGet, pprofPathPrefix, true, http.StatusOK},
+		{"pprof profile", http.MethodGet, pprofPathPrefix + "goroutine", true, http.StatusOK},
+		{"pprof cmdline", http.MethodGet, pprofPathPrefix + "cmdline", true, http.StatusOK},
+		{"pprof without token", http.MethodGet, pprofPathPrefix, false, http.StatusUnauthorized},
+		{"public api", http.MethodGet, "/policy/test", true, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.token {
+				req.Header.Set("Authorization", "Bearer token")
+			}
+
+			rw := httptest.NewRecorder()
+			router.ServeHTTP(rw, req)
+
+			require.Equal(t, tt.status, rw.Code)
+		})
+	}
+}
+
+func TestNewAdminRouterWithoutToken(t *testing.T) {
+	health := newHealthMonitor(map[string]func() error{})
+
+	router := newAdminRouter(nil, http.NotFoundHandler(), metricsHandler(newMetricsRegistry()),
+		http.HandlerFunc(health.historyHandler), func(h http.Handler) http.Handler { return h }, false)
+
+	for _, r := range []*http.Request{
+		httptest.NewRequest(http.MethodPost, reloadEndpoint, nil),
+		httptest.NewRequest(http.MethodGet, pprofPathPrefix, nil),
+		httptest.NewRequest(http.MethodGet, pprofPathPrefix+"cmdline", nil),
+	} {
+		rw := httptest.NewRecorder()
+		router.ServeHTTP(rw, r)
+
+		require.Equal(t, http.StatusNotFound, rw.Code, r.URL.Path)
+	}
+}
+
+type recordingServer struct {
+	mu        sync.Mutex
+	hosts     map[string]http.Handler
+	clientCAs map[string]*x509.CertPool
+	err       map[string]error
+	block     chan struct{}
+}
+
+func (s *recordingServer) ListenAndServe(host, _, _ string, clientCAs *x509.CertPool, handler http.Handler) error {
+	s.mu.Lock()
+	s.hosts[host] = handler
+
+	if clientCAs != nil {
+		s.clientCAs[host] = clientCAs
+	}
+
+	err := s.err[host]
+	s.mu.Unlock()
+
+	if err == nil && s.block != nil {
+		<-s.block
+	}
+
+	return err
+}
+
+func TestServe(t *testing.T) {
+	public := http.NotFoundHandler()
+	admin := http.NewServeMux()
+
+	t.Run("serves public api only", func(t *testing.T) {
+		srv := &recordingServer{hosts: map[string]http.Handler{}, clientCAs: map[string]*x509.CertPool{}}
+
+		err := serve(srv, &serviceParameters{host: "localhost:8080", tlsParams: &tlsParameters{}}, public, admin, nil)
+		require.NoError(t, err)
+		require.Len(t, srv.hosts, 1)
+		require.NotNil(t, srv.hosts["localhost:8080"])
+	})
+
+	t.Run("serves public api on unix socket", func(t *testing.T) {
+		srv := &recordingServer{
+			hosts:     map[string]http.Handler{},
+			clientCAs: map[string]*x509.CertPool{},
+			err:       map[string]error{"unix:/tmp/gk.sock": errors.New("listen error")},
+			block:     make(chan struct{}),
+		}
+		defer close(srv.block)
+
+		params := &serviceParameters{host: "localhost:8080", unixSocket: "/tmp/gk.sock", tlsParams: &tlsParameters{}}
+
+		err := serve(srv, params, public, admin, nil)
+		require.EqualError(t, err, "listen error")
+
+		srv.mu.Lock()
+		defer srv.mu.Unlock()
+
+		require.NotNil(t, srv.hosts["unix:/tmp/gk.sock"])
+	})
+
+	t.Run("serves admin endpoints on admin listener", func(t *testing.T) {
+		srv := &recordingServer{
+			hosts:     map[string]http.Handler{},
+			clientCAs: map[string]*x509.CertPool{},
+			err:       map[string]error{"localhost:8081": errors.New("listen error")},
+			block:     make(chan struct{}),
+		}
+		defer close(srv.block)
+
+		params := &serviceParameters{host: "localhost:8080", adminHost: "localhost:8081", tlsParams: &tlsParameters{}}
+
+		err := serve(srv, params, public, admin, nil)
+		require.EqualError(t, err, "listen error")
+
+		srv.mu.Lock()
+		defer srv.mu.Unlock()
+
+		require.Equal(t, admin, srv.hosts["localhost:8081"])
+		require.Empty(t, srv.clientCAs)
+	})
+
+	t.Run("requires client certificates on admin listener", func(t *testing.T) {
+		srv := &recordingServer{
+			hosts:     map[string]http.Handler{},
+			clientCAs: map[string]*x509.CertPool{},
+			err:       map[string]error{"localhost:8081": errors.New("listen error")},
+			block:     make(chan struct{}),
+		}
+		defer close(srv.block)
+
+		params := &serviceParameters{
+			host:      "localhost:8080",
+			adminHost: "localhost:8081",
+			tlsParams: &tlsParameters{adminClientCACerts: []string{writeCACert(t)}},
+		}
+
+		err := serve(srv, params, public, admin, nil)
+		require.EqualError(t, err, "listen error")
+
+		srv.mu.Lock()
+		defer srv.mu.Unlock()
+
+		require.Len(t, srv.clientCAs, 1)
+		require.NotNil(t, srv.clientCAs["localhost:8081"])
+	})
+
+	t.Run("fails to load admin client ca certs", func(t *testing.T) {
+		srv := &recordingServer{hosts: map[string]http.Handler{}, clientCAs: map[string]*x509.CertPool{}}
+
+		params := &serviceParameters{
+			host:      "localhost:8080",
+			adminHost: "localhost:8081",
+			tlsParams: &tlsParameters{adminClientCACerts: []string{filepath.Join(t.TempDir(), "missing.pem")}},
+		}
+
+		err := serve(srv, params, public, admin, nil)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "load admin client CA certs")
+		require.Empty(t, srv.hosts)
+	})
+
+	t.Run("serves grpc api on grpc listener", func(t *testing.T) {
+		srv := &recordingServer{
+			hosts:     map[string]http.Handler{},
+			clientCAs: map[string]*x509.CertPool{},
+			err:       map[string]error{"localhost:8082": errors.New("listen error")},
+			block:     make(chan struct{}),
+		}
+		defer close(srv.block)
+
+		grpcAPI := http.NewServeMux()
+		params := &serviceParameters{host: "localhost:8080", grpcHost: "localhost:8082", tlsParams: &tlsParameters{}}
+
+		err := serve(srv, params, public, admin, grpcAPI)
+		require.EqualError(t, err, "listen error")
+
+		srv.mu.Lock()
+		defer srv.mu.Unlock()
+
+		require.Equal(t, grpcAPI, srv.hosts["localhost:8082"])
+	})
+}
+
+// writeCACert writes a self-signed CA certificate to a temporary file and returns its path.
+func writeCACert(t *testing.T) string {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "ca"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	file := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+
+	return file
+}
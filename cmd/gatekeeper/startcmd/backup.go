@@ -0,0 +1,138 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package startcmd
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/hyperledger/aries-framework-go/spi/storage"
+
+	"github.com/trustbloc/ace/pkg/gatekeeper/backup"
+)
+
+const (
+	backupEndpoint  = "/backup"
+	restoreEndpoint = "/restore"
+	maxSnapshotSize = 1 << 30
+)
+
+type snapshotService interface {
+	Export(w io.Writer) ([]*backup.Result, error)
+	Restore(data []byte) ([]*backup.Result, error)
+}
+
+// backupHandler exports the encrypted snapshots of the policies, the protected data, the tickets and the quota usages,
+// and restores them into a fresh instance.
+type backupHandler struct {
+	snapshots snapshotService
+}
+
+type restoreResponse struct {
+	Stores []*backup.Result `json:"stores"`
+}
+
+// newBackupHandler returns the backup handler of the stores of the provider, with the base64url-encoded key the
+// snapshots are encrypted with.
+func newBackupHandler(provider storage.Provider, key string) (*backupHandler, error) {
+	k, err := base64.URLEncoding.DecodeString(key)
+	if err != nil {
+		return nil, fmt.Errorf("decode %s: %w", backupKeyFlagName, err)
+	}
+
+	snapshots, err := backup.New(provider, k)
+	if err != nil {
+		return nil, fmt.Errorf("create backup service: %w", err)
+	}
+
+	return &backupHandler{snapshots: snapshots}, nil
+}
+
+// addBackup adds the backup and restore endpoints to the router.
+func addBackup(router *mux.Router, b *backupHandler, auth func(http.Handler) http.Handler) {
+	router.Handle(backupEndpoint, auth(http.HandlerFunc(b.backupHandler))).Methods(http.MethodPost)
+	router.Handle(restoreEndpoint, auth(http.HandlerFunc(b.restoreHandler))).Methods(http.MethodPost)
+}
+
+// backupHandler streams the snapshot as it is exported. The export failing once the snapshot is partly written aborts
+// the response, so that the client doesn't take the truncated snapshot for a complete one.
+func (b *backupHandler) backupHandler(rw http.ResponseWriter, _ *http.Request) {
+	w := &snapshotWriter{rw: rw}
+
+	results, err := b.snapshots.Export(w)
+	if err != nil {
+		logger.Errorf("failed to export snapshot: %s", err)
+
+		if w.written {
+			panic(http.ErrAbortHandler)
+		}
+
+		http.Error(rw, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	for _, r := range results {
+		logger.Infof("exported store %s: entries=%d", r.Store, r.Entries)
+	}
+}
+
+// snapshotWriter writes the snapshot to the response, setting its headers on the first write.
+type snapshotWriter struct {
+	rw      http.ResponseWriter
+	written bool
+}
+
+func (w *snapshotWriter) Write(p []byte) (int, error) {
+	if !w.written {
+		w.written = true
+
+		w.rw.Header().Set("Content-Type", "application/json")
+		w.rw.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=gatekeeper-snapshot-%s.json",
+			time.Now().UTC().Format("20060102T150405Z")))
+	}
+
+	return w.rw.Write(p)
+}
+
+func (b *backupHandler) restoreHandler(rw http.ResponseWriter, r *http.Request) {
+	snapshot, err := io.ReadAll(http.MaxBytesReader(rw, r.Body, maxSnapshotSize))
+	if err != nil {
+		http.Error(rw, fmt.Sprintf("read snapshot: %s", err), http.StatusBadRequest)
+
+		return
+	}
+
+	results, err := b.snapshots.Restore(snapshot)
+	if err != nil {
+		logger.Errorf("failed to restore snapshot: %s", err)
+
+		status := http.StatusInternalServerError
+
+		switch {
+		case errors.Is(err, backup.ErrInvalidSnapshot):
+			status = http.StatusBadRequest
+		case errors.Is(err, backup.ErrNotEmpty):
+			status = http.StatusConflict
+		}
+
+		http.Error(rw, err.Error(), status)
+
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+
+	if err = json.NewEncoder(rw).Encode(&restoreResponse{Stores: results}); err != nil {
+		logger.Errorf("failed to write restore response: %s", err)
+	}
+}
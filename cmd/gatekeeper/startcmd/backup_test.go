@@ -0,0 +1,143 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package startcmd //nolint:testpackage
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/hyperledger/aries-framework-go/component/storageutil/mem"
+	"github.com/hyperledger/aries-framework-go/spi/storage"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/ace/pkg/gatekeeper/backup"
+)
+
+func TestBackupHandler(t *testing.T) {
+	key := base64.URLEncoding.EncodeToString(make([]byte, backup.KeySize))
+
+	t.Run("backs up and restores into a fresh instance", func(t *testing.T) {
+		source := mem.NewProvider()
+
+		store, err := source.OpenStore("ticket")
+		require.NoError(t, err)
+		require.NoError(t, store.Put("t1", []byte(`{"id":"t1"}`), storage.Tag{Name: "status", Value: "new"}))
+
+		b, err := newBackupHandler(source, key)
+		require.NoError(t, err)
+
+		router := mux.NewRouter()
+		addBackup(router, b, func(h http.Handler) http.Handler { return h })
+
+		rw := httptest.NewRecorder()
+		router.ServeHTTP(rw, httptest.NewRequest(http.MethodPost, backupEndpoint, nil))
+
+		require.Equal(t, http.StatusOK, rw.Code)
+		require.Contains(t, rw.Header().Get("Content-Disposition"), "gatekeeper-snapshot-")
+
+		snapshot := rw.Body.Bytes()
+
+		b, err = newBackupHandler(mem.NewProvider(), key)
+		require.NoError(t, err)
+
+		router = mux.NewRouter()
+		addBackup(router, b, func(h http.Handler) http.Handler { return h })
+
+		rw = httptest.NewRecorder()
+		router.ServeHTTP(rw, httptest.NewRequest(http.MethodPost, restoreEndpoint, bytes.NewReader(snapshot)))
+
+		require.Equal(t, http.StatusOK, rw.Code)
+
+		var resp restoreResponse
+
+		require.NoError(t, json.Unmarshal(rw.Body.Bytes(), &resp))
+		require.Contains(t, resp.Stores, &backup.Result{Store: "ticket", Entries: 1})
+
+		rw = httptest.NewRecorder()
+		router.ServeHTTP(rw, httptest.NewRequest(http.MethodPost, restoreEndpoint, bytes.NewReader(snapshot)))
+
+		require.Equal(t, http.StatusConflict, rw.Code)
+	})
+
+	t.Run("fails to back up", func(t *testing.T) {
+		b := &backupHandler{snapshots: &mockSnapshotService{err: errors.New("query failed")}}
+
+		rw := httptest.NewRecorder()
+		b.backupHandler(rw, httptest.NewRequest(http.MethodPost, backupEndpoint, nil))
+
+		require.Equal(t, http.StatusInternalServerError, rw.Code)
+		require.Contains(t, rw.Body.String(), "query failed")
+		require.Empty(t, rw.Header().Get("Content-Disposition"))
+	})
+
+	t.Run("aborts a partly written backup", func(t *testing.T) {
+		b := &backupHandler{snapshots: &mockSnapshotService{written: "{", err: errors.New("query failed")}}
+
+		rw := httptest.NewRecorder()
+
+		require.PanicsWithValue(t, http.ErrAbortHandler, func() {
+			b.backupHandler(rw, httptest.NewRequest(http.MethodPost, backupEndpoint, nil))
+		})
+	})
+
+	t.Run("fails to restore", func(t *testing.T) {
+		tests := []struct {
+			err    error
+			status int
+		}{
+			{fmt.Errorf("%w: decrypt", backup.ErrInvalidSnapshot), http.StatusBadRequest},
+			{fmt.Errorf("%w: entry t1 exists", backup.ErrNotEmpty), http.StatusConflict},
+			{errors.New("write failed"), http.StatusInternalServerError},
+		}
+
+		for _, tt := range tests {
+			b := &backupHandler{snapshots: &mockSnapshotService{err: tt.err}}
+
+			rw := httptest.NewRecorder()
+			b.restoreHandler(rw, httptest.NewRequest(http.MethodPost, restoreEndpoint, bytes.NewReader([]byte("{}"))))
+
+			require.Equal(t, tt.status, rw.Code)
+			require.Contains(t, rw.Body.String(), tt.err.Error())
+		}
+	})
+
+	t.Run("invalid key", func(t *testing.T) {
+		_, err := newBackupHandler(mem.NewProvider(), "not base64!")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "decode backup-key")
+
+		_, err = newBackupHandler(mem.NewProvider(), base64.URLEncoding.EncodeToString([]byte("short")))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "create backup service")
+	})
+}
+
+type mockSnapshotService struct {
+	written string
+	err     error
+}
+
+func (m *mockSnapshotService) Export(w io.Writer) ([]*backup.Result, error) {
+	if m.written != "" {
+		if _, err := io.WriteString(w, m.written); err != nil {
+			return nil, err
+		}
+	}
+
+	return nil, m.err
+}
+
+func (m *mockSnapshotService) Restore([]byte) ([]*backup.Result, error) {
+	return nil, m.err
+}
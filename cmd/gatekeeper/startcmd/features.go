@@ -0,0 +1,110 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package startcmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/trustbloc/ace/pkg/feature"
+	"github.com/trustbloc/ace/pkg/version"
+)
+
+const (
+	featuresEndpoint = "/features"
+	featureEndpoint  = featuresEndpoint + "/{" + featureVarName + "}"
+	featureVarName   = "name"
+	maxFeatureSize   = 1 << 10
+)
+
+type featuresResponse struct {
+	Features []*feature.Flag `json:"features"`
+}
+
+type setFeatureRequest struct {
+	Enabled *bool `json:"enabled"`
+}
+
+// newFeatureFlags returns the feature flags of Gatekeeper, disabled unless the build enables them, configured with the
+// comma-separated list of the feature flags option.
+func newFeatureFlags(list string) (*feature.Flags, error) {
+	flags := feature.New(map[string]bool{
+		feature.DIDComm:     false,
+		feature.BBSIssuance: false,
+	})
+
+	// the features of the build are shared by all the services, each enabling the ones it declares
+	for _, name := range version.Get().Features {
+		if err := flags.Set(name, true); err != nil {
+			logger.Debugf("feature %s of the build not gated by the gatekeeper", name)
+		}
+	}
+
+	if err := flags.Configure(list); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", featureFlagsFlagName, err)
+	}
+
+	return flags, nil
+}
+
+// addFeatures adds the endpoints listing and toggling the feature flags to the router. Toggles only apply to the
+// instance serving them, until it restarts.
+func addFeatures(router *mux.Router, flags *feature.Flags, auth func(http.Handler) http.Handler) {
+	h := &featuresHandler{flags: flags}
+
+	router.Handle(featuresEndpoint, auth(http.HandlerFunc(h.listHandler))).Methods(http.MethodGet)
+	router.Handle(featureEndpoint, auth(http.HandlerFunc(h.setHandler))).Methods(http.MethodPut)
+}
+
+// featuresHandler lists and toggles the feature flags.
+type featuresHandler struct {
+	flags *feature.Flags
+}
+
+func (h *featuresHandler) listHandler(rw http.ResponseWriter, _ *http.Request) {
+	h.respond(rw)
+}
+
+func (h *featuresHandler) setHandler(rw http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)[featureVarName]
+
+	var req setFeatureRequest
+
+	if err := json.NewDecoder(http.MaxBytesReader(rw, r.Body, maxFeatureSize)).Decode(&req); err != nil ||
+		req.Enabled == nil {
+		http.Error(rw, "request must be {\"enabled\": true|false}", http.StatusBadRequest)
+
+		return
+	}
+
+	if err := h.flags.Set(name, *req.Enabled); err != nil {
+		status := http.StatusInternalServerError
+
+		if errors.Is(err, feature.ErrUnknownFlag) {
+			status = http.StatusNotFound
+		}
+
+		http.Error(rw, err.Error(), status)
+
+		return
+	}
+
+	logger.Infof("feature flag %s set to enabled=%t", name, *req.Enabled)
+
+	h.respond(rw)
+}
+
+func (h *featuresHandler) respond(rw http.ResponseWriter) {
+	rw.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewEncoder(rw).Encode(&featuresResponse{Features: h.flags.List()}); err != nil {
+		logger.Errorf("failed to write features response: %s", err)
+	}
+}
@@ -0,0 +1,105 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package startcmd //nolint:testpackage
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/ace/pkg/feature"
+	"github.com/trustbloc/ace/pkg/version"
+)
+
+func TestNewFeatureFlags(t *testing.T) {
+	t.Run("disabled unless enabled", func(t *testing.T) {
+		flags, err := newFeatureFlags("didcomm")
+		require.NoError(t, err)
+		require.True(t, flags.Enabled(feature.DIDComm))
+		require.False(t, flags.Enabled(feature.BBSIssuance))
+	})
+
+	t.Run("enabled by the build", func(t *testing.T) {
+		prev := version.Features
+		version.Features = "async_protect,bbs_issuance,didcomm"
+
+		t.Cleanup(func() { version.Features = prev })
+
+		flags, err := newFeatureFlags("didcomm=false")
+		require.NoError(t, err)
+		require.False(t, flags.Enabled(feature.DIDComm))
+		require.True(t, flags.Enabled(feature.BBSIssuance))
+	})
+}
+
+func TestFeaturesHandler(t *testing.T) {
+	newRouter := func(t *testing.T) (*mux.Router, *feature.Flags) {
+		t.Helper()
+
+		flags, err := newFeatureFlags("")
+		require.NoError(t, err)
+
+		router := mux.NewRouter()
+		addFeatures(router, flags, func(h http.Handler) http.Handler { return h })
+
+		return router, flags
+	}
+
+	t.Run("lists the feature flags", func(t *testing.T) {
+		router, _ := newRouter(t)
+
+		rw := httptest.NewRecorder()
+		router.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, featuresEndpoint, nil))
+
+		require.Equal(t, http.StatusOK, rw.Code)
+
+		var resp featuresResponse
+
+		require.NoError(t, json.Unmarshal(rw.Body.Bytes(), &resp))
+		require.Equal(t, []*feature.Flag{
+			{Name: feature.BBSIssuance, Enabled: false},
+			{Name: feature.DIDComm, Enabled: false},
+		}, resp.Features)
+	})
+
+	t.Run("toggles a feature flag", func(t *testing.T) {
+		router, flags := newRouter(t)
+
+		rw := httptest.NewRecorder()
+		router.ServeHTTP(rw, httptest.NewRequest(http.MethodPut, featuresEndpoint+"/didcomm",
+			bytes.NewBufferString(`{"enabled":true}`)))
+
+		require.Equal(t, http.StatusOK, rw.Code)
+		require.True(t, flags.Enabled(feature.DIDComm))
+		require.Contains(t, rw.Body.String(), `{"name":"didcomm","enabled":true}`)
+	})
+
+	t.Run("rejects invalid requests", func(t *testing.T) {
+		router, flags := newRouter(t)
+
+		for path, tc := range map[string]struct {
+			body   string
+			status int
+		}{
+			"/didcomm":       {`{}`, http.StatusBadRequest},
+			"/bbs_issuance":  {"invalid json", http.StatusBadRequest},
+			"/async_protect": {`{"enabled":true}`, http.StatusNotFound},
+		} {
+			rw := httptest.NewRecorder()
+			router.ServeHTTP(rw, httptest.NewRequest(http.MethodPut, featuresEndpoint+path, bytes.NewBufferString(tc.body)))
+
+			require.Equal(t, tc.status, rw.Code, path)
+		}
+
+		require.False(t, flags.Enabled(feature.DIDComm))
+		require.False(t, flags.Enabled(feature.BBSIssuance))
+	})
+}
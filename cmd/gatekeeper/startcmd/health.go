@@ -0,0 +1,191 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package startcmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/aries-framework-go/spi/storage"
+
+	"github.com/trustbloc/ace/cmd/common"
+)
+
+const (
+	healthHistoryEndpoint = "/health/history"
+	healthStoreName       = "health"
+	healthProbeKey        = "probe"
+	// healthHistorySize is the number of probe results kept for every dependency.
+	healthHistorySize = 60
+	// flapThreshold is the number of changes of status in the history from which a dependency is flapping.
+	flapThreshold = 3
+)
+
+// healthResult is the result of a probe of a dependency.
+type healthResult struct {
+	Time    time.Time `json:"time"`
+	Healthy bool      `json:"healthy"`
+	Error   string    `json:"error,omitempty"`
+}
+
+// dependencyHealth is the health history of a dependency, oldest result first.
+type dependencyHealth struct {
+	Name    string `json:"name"`
+	Healthy bool   `json:"healthy"`
+	// Since is the time of the last change of status in the history, or of its oldest result.
+	Since time.Time `json:"since"`
+	// Changes is the number of changes of status in the history. The dependency is flapping from flapThreshold.
+	Changes  int             `json:"changes"`
+	Flapping bool            `json:"flapping"`
+	History  []*healthResult `json:"history"`
+}
+
+type healthHistoryResponse struct {
+	Dependencies []*dependencyHealth `json:"dependencies"`
+}
+
+// healthMonitor probes the health of the dependencies on every tick and keeps a rolling history of the results in
+// memory, so that dependencies flapping can be told apart from dependencies down.
+type healthMonitor struct {
+	probes map[string]func() error
+	size   int
+
+	mu      sync.Mutex
+	history map[string][]*healthResult
+}
+
+// newHealthMonitor returns a health monitor of the dependencies probed by the checks, keyed by their names.
+func newHealthMonitor(probes map[string]func() error) *healthMonitor {
+	return &healthMonitor{probes: probes, size: healthHistorySize, history: map[string][]*healthResult{}}
+}
+
+// run probes the dependencies, then again on every tick until done is closed.
+func (m *healthMonitor) run(ticks <-chan time.Time, done <-chan struct{}) {
+	m.probe()
+
+	for {
+		select {
+		case <-ticks:
+			m.probe()
+		case <-done:
+			return
+		}
+	}
+}
+
+// probe probes the dependencies and records the results.
+func (m *healthMonitor) probe() {
+	for name, check := range m.probes {
+		r := &healthResult{Time: time.Now().UTC(), Healthy: true}
+
+		if err := check(); err != nil {
+			r.Healthy, r.Error = false, err.Error()
+		}
+
+		m.record(name, r)
+	}
+}
+
+func (m *healthMonitor) record(name string, r *healthResult) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	h := m.history[name]
+	wasHealthy := len(h) == 0 || h[len(h)-1].Healthy
+
+	switch {
+	case wasHealthy && !r.Healthy:
+		logger.Warnf("%s is unhealthy: %s", name, r.Error)
+	case !wasHealthy && r.Healthy:
+		logger.Infof("%s is healthy again", name)
+	}
+
+	h = append(h, r)
+
+	if len(h) > m.size {
+		h = h[len(h)-m.size:]
+	}
+
+	m.history[name] = h
+}
+
+// dependencies returns the health history of the dependencies, sorted by name.
+func (m *healthMonitor) dependencies() []*dependencyHealth {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	deps := make([]*dependencyHealth, 0, len(m.history))
+
+	for name, h := range m.history {
+		d := &dependencyHealth{
+			Name:    name,
+			Healthy: h[len(h)-1].Healthy,
+			Since:   h[0].Time,
+			History: append([]*healthResult(nil), h...),
+		}
+
+		for i := 1; i < len(h); i++ {
+			if h[i].Healthy != h[i-1].Healthy {
+				d.Changes++
+				d.Since = h[i].Time
+			}
+		}
+
+		d.Flapping = d.Changes >= flapThreshold
+
+		deps = append(deps, d)
+	}
+
+	sort.Slice(deps, func(i, j int) bool { return deps[i].Name < deps[j].Name })
+
+	return deps
+}
+
+func (m *healthMonitor) historyHandler(rw http.ResponseWriter, _ *http.Request) {
+	rw.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewEncoder(rw).Encode(&healthHistoryResponse{Dependencies: m.dependencies()}); err != nil {
+		logger.Errorf("failed to write health history response: %s", err)
+	}
+}
+
+// healthProbes returns the checks of the dependencies of the gatekeeper: the vault server, the DID resolver and the
+// database.
+func healthProbes(params *serviceParameters, client *http.Client, provider storage.Provider) map[string]func() error {
+	probes := map[string]func() error{"database": storageProbe(provider)}
+
+	for name, u := range map[string]string{
+		"vault server": params.vaultServerURL,
+		"DID resolver": params.didResolverURL,
+	} {
+		if u != "" {
+			probes[name] = common.Reachable(client, u)
+		}
+	}
+
+	return probes
+}
+
+// storageProbe returns a check of the storage backend, which reads a key from the health store.
+func storageProbe(provider storage.Provider) func() error {
+	return func() error {
+		store, err := provider.OpenStore(healthStoreName)
+		if err != nil {
+			return fmt.Errorf("open health store: %w", err)
+		}
+
+		if _, err = store.Get(healthProbeKey); err != nil && !errors.Is(err, storage.ErrDataNotFound) {
+			return fmt.Errorf("read health store: %w", err)
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,108 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package startcmd //nolint:testpackage
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/aries-framework-go/component/storageutil/mem"
+	mockstorage "github.com/hyperledger/aries-framework-go/pkg/mock/storage"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealthMonitor(t *testing.T) {
+	t.Run("keeps a rolling history and detects flapping", func(t *testing.T) {
+		var vaultErr error
+
+		m := newHealthMonitor(map[string]func() error{
+			"vault server": func() error { return vaultErr },
+			"database":     func() error { return nil },
+		})
+		m.size = 5
+
+		for _, err := range []error{nil, errors.New("connection refused"), nil, errors.New("timeout"), nil, nil} {
+			vaultErr = err
+			m.probe()
+		}
+
+		rw := httptest.NewRecorder()
+		m.historyHandler(rw, httptest.NewRequest(http.MethodGet, healthHistoryEndpoint, nil))
+
+		require.Equal(t, http.StatusOK, rw.Code)
+
+		var resp healthHistoryResponse
+
+		require.NoError(t, json.Unmarshal(rw.Body.Bytes(), &resp))
+		require.Len(t, resp.Dependencies, 2)
+
+		db, vault := resp.Dependencies[0], resp.Dependencies[1]
+
+		require.Equal(t, "database", db.Name)
+		require.True(t, db.Healthy)
+		require.Zero(t, db.Changes)
+		require.False(t, db.Flapping)
+		require.Len(t, db.History, 5)
+		require.Equal(t, db.History[0].Time, db.Since)
+
+		require.Equal(t, "vault server", vault.Name)
+		require.True(t, vault.Healthy)
+		require.Equal(t, 3, vault.Changes)
+		require.True(t, vault.Flapping)
+		require.Len(t, vault.History, 5)
+		require.Equal(t, "connection refused", vault.History[0].Error)
+		require.Equal(t, "timeout", vault.History[2].Error)
+		require.Equal(t, vault.History[3].Time, vault.Since)
+	})
+
+	t.Run("probes on every tick", func(t *testing.T) {
+		probed := make(chan struct{})
+
+		m := newHealthMonitor(map[string]func() error{
+			"database": func() error {
+				probed <- struct{}{}
+
+				return nil
+			},
+		})
+
+		ticks := make(chan time.Time)
+		done := make(chan struct{})
+		stopped := make(chan struct{})
+
+		go func() {
+			m.run(ticks, done)
+			close(stopped)
+		}()
+
+		<-probed
+
+		ticks <- time.Now()
+		<-probed
+
+		close(done)
+		<-stopped
+
+		require.Len(t, m.dependencies()[0].History, 2)
+	})
+}
+
+func TestStorageProbe(t *testing.T) {
+	require.NoError(t, storageProbe(mem.NewProvider())())
+
+	err := storageProbe(&mockstorage.MockStoreProvider{ErrOpenStoreHandle: errors.New("connection refused")})()
+	require.EqualError(t, err, "open health store: connection refused")
+
+	p := mockstorage.NewMockStoreProvider()
+	p.Store.ErrGet = errors.New("timeout")
+
+	err = storageProbe(p)()
+	require.EqualError(t, err, "read health store: timeout")
+}
@@ -0,0 +1,136 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package startcmd
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hyperledger/aries-framework-go/pkg/common/log"
+	"github.com/rs/cors"
+
+	"github.com/trustbloc/ace/cmd/common"
+)
+
+const reloadEndpoint = "/reload"
+
+// reloader applies the settings that can change without a restart: the log level and the CORS allowed origins.
+// Other settings are read again to be validated, but their changes only take effect after a restart.
+type reloader struct {
+	mu   sync.Mutex
+	load func() (*serviceParameters, error)
+	cors *corsHandler
+}
+
+func (r *reloader) reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	params, err := r.load()
+	if err != nil {
+		return fmt.Errorf("reload configuration: %w", err)
+	}
+
+	if err = setLogLevel(params.logLevel); err != nil {
+		return fmt.Errorf("reload configuration: %w", err)
+	}
+
+	r.cors.setAllowedOrigins(params.corsAllowedOrigins)
+
+	logger.Infof("configuration reloaded")
+
+	return nil
+}
+
+// watch reloads the configuration on every signal until the channel is closed.
+func (r *reloader) watch(signals <-chan os.Signal) {
+	for range signals {
+		if err := r.reload(); err != nil {
+			logger.Errorf("failed to reload configuration: %s", err)
+		}
+	}
+}
+
+// refresh reloads the configuration on every tick, resolving the secret references again, until done is closed.
+func (r *reloader) refresh(ticks <-chan time.Time, done <-chan struct{}) {
+	for {
+		select {
+		case <-ticks:
+			if err := r.reload(); err != nil {
+				logger.Errorf("failed to refresh secrets: %s", err)
+			}
+		case <-done:
+			return
+		}
+	}
+}
+
+func (r *reloader) reloadHandler(rw http.ResponseWriter, _ *http.Request) {
+	if err := r.reload(); err != nil {
+		logger.Errorf("failed to reload configuration: %s", err)
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	rw.WriteHeader(http.StatusOK)
+}
+
+// setLogLevel sets the level of all loggers. The default level is info.
+func setLogLevel(level string) error {
+	if level == "" {
+		level = "INFO"
+	}
+
+	l, err := log.ParseLevel(level)
+	if err != nil {
+		return fmt.Errorf("parse %s: %w", common.LogLevelFlagName, err)
+	}
+
+	log.SetLevel("", l)
+
+	return nil
+}
+
+// corsHandler handles CORS with allowed origins that can be changed while requests are served.
+type corsHandler struct {
+	next http.Handler
+	cors atomic.Value
+}
+
+func newCORSHandler(next http.Handler, allowedOrigins []string) *corsHandler {
+	h := &corsHandler{next: next}
+	h.setAllowedOrigins(allowedOrigins)
+
+	return h
+}
+
+func (h *corsHandler) setAllowedOrigins(allowedOrigins []string) {
+	h.cors.Store(cors.New(cors.Options{
+		AllowedOrigins: allowedOrigins,
+		AllowedMethods: []string{
+			http.MethodHead,
+			http.MethodGet,
+			http.MethodPost,
+			http.MethodDelete,
+		},
+		AllowedHeaders: []string{
+			"Origin",
+			"Accept",
+			"Content-Type",
+			"X-Requested-With",
+			"Authorization",
+		},
+	}).Handler(h.next))
+}
+
+func (h *corsHandler) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	h.cors.Load().(http.Handler).ServeHTTP(rw, r) //nolint:forcetypeassert
+}
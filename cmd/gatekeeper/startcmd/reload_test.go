@@ -0,0 +1,155 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package startcmd //nolint:testpackage
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/aries-framework-go/pkg/common/log"
+	spilog "github.com/hyperledger/aries-framework-go/spi/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReloader(t *testing.T) {
+	t.Cleanup(func() { require.NoError(t, setLogLevel("")) })
+
+	t.Run("reloads log level and cors allowed origins", func(t *testing.T) {
+		params := &serviceParameters{logLevel: "DEBUG", corsAllowedOrigins: []string{"https://one.example.com"}}
+
+		r := &reloader{
+			load: func() (*serviceParameters, error) { return params, nil },
+			cors: newCORSHandler(http.NotFoundHandler(), nil),
+		}
+
+		require.Equal(t, "*", corsPreflight(t, r.cors, "https://two.example.com"))
+
+		rw := httptest.NewRecorder()
+		r.reloadHandler(rw, httptest.NewRequest(http.MethodPost, reloadEndpoint, nil))
+
+		require.Equal(t, http.StatusOK, rw.Code)
+		require.Equal(t, spilog.DEBUG, log.GetLevel(""))
+		require.Empty(t, corsPreflight(t, r.cors, "https://two.example.com"))
+		require.Equal(t, "https://one.example.com", corsPreflight(t, r.cors, "https://one.example.com"))
+	})
+
+	t.Run("keeps settings if configuration cannot be loaded", func(t *testing.T) {
+		require.NoError(t, setLogLevel("WARNING"))
+
+		r := &reloader{
+			load: func() (*serviceParameters, error) { return nil, errors.New("load error") },
+			cors: newCORSHandler(http.NotFoundHandler(), nil),
+		}
+
+		rw := httptest.NewRecorder()
+		r.reloadHandler(rw, httptest.NewRequest(http.MethodPost, reloadEndpoint, nil))
+
+		require.Equal(t, http.StatusBadRequest, rw.Code)
+		require.Contains(t, rw.Body.String(), "reload configuration: load error")
+		require.Equal(t, spilog.WARNING, log.GetLevel(""))
+		require.Equal(t, "*", corsPreflight(t, r.cors, "https://two.example.com"))
+	})
+
+	t.Run("reloads on signal", func(t *testing.T) {
+		loaded := make(chan struct{}, 1)
+
+		r := &reloader{
+			load: func() (*serviceParameters, error) {
+				loaded <- struct{}{}
+
+				return &serviceParameters{logLevel: "verbose"}, nil
+			},
+			cors: newCORSHandler(http.NotFoundHandler(), nil),
+		}
+
+		signals := make(chan os.Signal)
+		done := make(chan struct{})
+
+		go func() {
+			r.watch(signals)
+			close(done)
+		}()
+
+		signals <- syscall.SIGHUP
+
+		select {
+		case <-loaded:
+		case <-time.After(time.Second):
+			require.Fail(t, "configuration not reloaded")
+		}
+
+		close(signals)
+		<-done
+	})
+
+	t.Run("reloads on every refresh tick", func(t *testing.T) {
+		loaded := make(chan struct{}, 1)
+
+		r := &reloader{
+			load: func() (*serviceParameters, error) {
+				loaded <- struct{}{}
+
+				return &serviceParameters{logLevel: "verbose"}, nil
+			},
+			cors: newCORSHandler(http.NotFoundHandler(), nil),
+		}
+
+		ticks := make(chan time.Time)
+		stop := make(chan struct{})
+		done := make(chan struct{})
+
+		go func() {
+			r.refresh(ticks, stop)
+			close(done)
+		}()
+
+		for i := 0; i < 2; i++ {
+			ticks <- time.Now()
+
+			select {
+			case <-loaded:
+			case <-time.After(time.Second):
+				require.Fail(t, "configuration not refreshed")
+			}
+		}
+
+		close(stop)
+		<-done
+	})
+}
+
+func TestSetLogLevel(t *testing.T) {
+	t.Cleanup(func() { require.NoError(t, setLogLevel("")) })
+
+	require.NoError(t, setLogLevel("ERROR"))
+	require.Equal(t, spilog.ERROR, log.GetLevel(""))
+
+	require.NoError(t, setLogLevel(""))
+	require.Equal(t, spilog.INFO, log.GetLevel(""))
+
+	err := setLogLevel("verbose")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "parse log-level")
+}
+
+// corsPreflight returns the origin allowed by the handler in response to a preflight request from the given origin.
+func corsPreflight(t *testing.T, h http.Handler, origin string) string {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodOptions, "/", nil)
+	req.Header.Set("Origin", origin)
+	req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+
+	rw := httptest.NewRecorder()
+	h.ServeHTTP(rw, req)
+
+	return rw.Header().Get("Access-Control-Allow-Origin")
+}
@@ -0,0 +1,93 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package startcmd
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/trustbloc/ace/pkg/gatekeeper/config"
+	"github.com/trustbloc/ace/pkg/lease"
+)
+
+const (
+	rotateKeyEndpoint     = "/did/rotate"
+	keyRetirementInterval = time.Minute
+)
+
+type keyRotationService interface {
+	RotateKey(gracePeriod time.Duration) (*config.Config, error)
+	RetirePreviousKey() error
+}
+
+// keyRotator rotates the gatekeeper's DID key on request and retires the previous key once its grace period ends.
+// The previous key is only retired while the instance holds the leader's lease, if it is set.
+type keyRotator struct {
+	config      keyRotationService
+	gracePeriod time.Duration
+	leader      *lease.Lease
+}
+
+type rotateKeyResponse struct {
+	DID               string    `json:"did"`
+	KeyID             string    `json:"key_id"`
+	PreviousKeyID     string    `json:"previous_key_id"`
+	PreviousKeyExpiry time.Time `json:"previous_key_expiry"`
+}
+
+func (k *keyRotator) rotateHandler(rw http.ResponseWriter, _ *http.Request) {
+	cfg, err := k.config.RotateKey(k.gracePeriod)
+	if err != nil {
+		logger.Errorf("failed to rotate DID key: %s", err)
+
+		status := http.StatusInternalServerError
+
+		switch {
+		case errors.Is(err, config.ErrKeyRotationInProgress):
+			status = http.StatusConflict
+		case errors.Is(err, config.ErrKeyRotationNotSupported):
+			status = http.StatusBadRequest
+		}
+
+		http.Error(rw, err.Error(), status)
+
+		return
+	}
+
+	logger.Infof("DID key of %s rotated to %s, previous key %s retires at %s", cfg.DID, cfg.PubKeyID,
+		cfg.PreviousPubKeyID, cfg.PreviousKeyExpiry)
+
+	rw.Header().Set("Content-Type", "application/json")
+
+	if err = json.NewEncoder(rw).Encode(&rotateKeyResponse{
+		DID:               cfg.DID,
+		KeyID:             cfg.PubKeyID,
+		PreviousKeyID:     cfg.PreviousPubKeyID,
+		PreviousKeyExpiry: *cfg.PreviousKeyExpiry,
+	}); err != nil {
+		logger.Errorf("failed to write rotate key response: %s", err)
+	}
+}
+
+// retire retires the previous DID key on every tick once its grace period has ended, until done is closed.
+func (k *keyRotator) retire(ticks <-chan time.Time, done <-chan struct{}) {
+	for {
+		select {
+		case <-ticks:
+			if k.leader != nil && !k.leader.Held() {
+				continue
+			}
+
+			if err := k.config.RetirePreviousKey(); err != nil {
+				logger.Errorf("failed to retire previous DID key: %s", err)
+			}
+		case <-done:
+			return
+		}
+	}
+}
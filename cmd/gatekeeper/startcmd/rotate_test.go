@@ -0,0 +1,112 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package startcmd //nolint:testpackage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/ace/pkg/gatekeeper/config"
+)
+
+func TestKeyRotator(t *testing.T) {
+	t.Run("rotates key", func(t *testing.T) {
+		expiry := time.Now().Add(time.Hour).UTC()
+
+		s := &mockKeyRotationService{config: &config.Config{
+			DID:               "did:orb:gatekeeper",
+			PubKeyID:          "key-2",
+			PreviousPubKeyID:  "key-1",
+			PreviousKeyExpiry: &expiry,
+		}}
+
+		k := &keyRotator{config: s, gracePeriod: time.Hour}
+
+		rw := httptest.NewRecorder()
+		k.rotateHandler(rw, httptest.NewRequest(http.MethodPost, rotateKeyEndpoint, nil))
+
+		require.Equal(t, http.StatusOK, rw.Code)
+		require.Equal(t, time.Hour, s.gracePeriod)
+
+		var resp rotateKeyResponse
+
+		require.NoError(t, json.Unmarshal(rw.Body.Bytes(), &resp))
+		require.Equal(t, "did:orb:gatekeeper", resp.DID)
+		require.Equal(t, "key-2", resp.KeyID)
+		require.Equal(t, "key-1", resp.PreviousKeyID)
+		require.True(t, expiry.Equal(resp.PreviousKeyExpiry))
+	})
+
+	t.Run("fails to rotate key", func(t *testing.T) {
+		tests := []struct {
+			err    error
+			status int
+		}{
+			{fmt.Errorf("rotate key: %w", config.ErrKeyRotationInProgress), http.StatusConflict},
+			{fmt.Errorf("rotate key: %w", config.ErrKeyRotationNotSupported), http.StatusBadRequest},
+			{errors.New("update DID: anchor failed"), http.StatusInternalServerError},
+		}
+
+		for _, tt := range tests {
+			k := &keyRotator{config: &mockKeyRotationService{err: tt.err}}
+
+			rw := httptest.NewRecorder()
+			k.rotateHandler(rw, httptest.NewRequest(http.MethodPost, rotateKeyEndpoint, nil))
+
+			require.Equal(t, tt.status, rw.Code)
+			require.Contains(t, rw.Body.String(), tt.err.Error())
+		}
+	})
+
+	t.Run("retires previous key on every tick", func(t *testing.T) {
+		s := &mockKeyRotationService{err: errors.New("resolve DID failed"), retired: make(chan struct{})}
+		k := &keyRotator{config: s}
+
+		ticks := make(chan time.Time)
+		done := make(chan struct{})
+		stopped := make(chan struct{})
+
+		go func() {
+			k.retire(ticks, done)
+			close(stopped)
+		}()
+
+		ticks <- time.Now()
+		<-s.retired
+
+		ticks <- time.Now()
+		<-s.retired
+
+		close(done)
+		<-stopped
+	})
+}
+
+type mockKeyRotationService struct {
+	config      *config.Config
+	err         error
+	gracePeriod time.Duration
+	retired     chan struct{}
+}
+
+func (s *mockKeyRotationService) RotateKey(gracePeriod time.Duration) (*config.Config, error) {
+	s.gracePeriod = gracePeriod
+
+	return s.config, s.err
+}
+
+func (s *mockKeyRotationService) RetirePreviousKey() error {
+	s.retired <- struct{}{}
+
+	return s.err
+}
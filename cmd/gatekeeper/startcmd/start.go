@@ -9,38 +9,71 @@ package startcmd
 import (
 	"context"
 	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"syscall"
+	"time"
 
 	httptransport "github.com/go-openapi/runtime/client"
 	"github.com/go-openapi/strfmt"
 	"github.com/gorilla/mux"
 	"github.com/hyperledger/aries-framework-go-ext/component/vdr/orb"
 	"github.com/hyperledger/aries-framework-go/pkg/common/log"
+	ariescrypto "github.com/hyperledger/aries-framework-go/pkg/crypto"
+	"github.com/hyperledger/aries-framework-go/pkg/crypto/tinkcrypto"
+	webcrypto "github.com/hyperledger/aries-framework-go/pkg/crypto/webkms"
 	vdrapi "github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdr"
+	"github.com/hyperledger/aries-framework-go/pkg/kms"
 	"github.com/hyperledger/aries-framework-go/pkg/kms/localkms"
+	"github.com/hyperledger/aries-framework-go/pkg/kms/webkms"
 	"github.com/hyperledger/aries-framework-go/pkg/secretlock"
+	"github.com/hyperledger/aries-framework-go/pkg/secretlock/local"
 	"github.com/hyperledger/aries-framework-go/pkg/secretlock/noop"
 	vdrpkg "github.com/hyperledger/aries-framework-go/pkg/vdr"
 	"github.com/hyperledger/aries-framework-go/pkg/vdr/httpbinding"
 	"github.com/hyperledger/aries-framework-go/spi/storage"
-	"github.com/rs/cors"
 	"github.com/spf13/cobra"
 	cmdutils "github.com/trustbloc/edge-core/pkg/utils/cmd"
 	tlsutils "github.com/trustbloc/edge-core/pkg/utils/tls"
 
 	"github.com/trustbloc/ace/cmd/common"
+	"github.com/trustbloc/ace/pkg/awskms"
 	"github.com/trustbloc/ace/pkg/client/csh/client"
 	vaultclient "github.com/trustbloc/ace/pkg/client/vault"
+	didcache "github.com/trustbloc/ace/pkg/did/cache"
+	vccrypto "github.com/trustbloc/ace/pkg/doc/vc/crypto"
+	"github.com/trustbloc/ace/pkg/doc/vc/schema"
+	"github.com/trustbloc/ace/pkg/feature"
+	"github.com/trustbloc/ace/pkg/gatekeeper/backup"
 	"github.com/trustbloc/ace/pkg/gatekeeper/config"
+	"github.com/trustbloc/ace/pkg/gatekeeper/stores"
+	grpcgatekeeper "github.com/trustbloc/ace/pkg/grpcapi/gatekeeper"
+	"github.com/trustbloc/ace/pkg/lease"
 	"github.com/trustbloc/ace/pkg/restapi/gatekeeper"
 	"github.com/trustbloc/ace/pkg/restapi/handler"
-	"github.com/trustbloc/ace/pkg/restapi/healthcheck"
+	"github.com/trustbloc/ace/pkg/restapi/model"
+	"github.com/trustbloc/ace/pkg/restapi/mw/accesslog"
+	"github.com/trustbloc/ace/pkg/restapi/mw/errorreport"
 	"github.com/trustbloc/ace/pkg/restapi/mw/httpsigmw"
+	"github.com/trustbloc/ace/pkg/restapi/mw/requestid"
+	"github.com/trustbloc/ace/pkg/restapi/mw/slowrequest"
 	"github.com/trustbloc/ace/pkg/restapi/mw/tokenauth"
-	"github.com/trustbloc/ace/pkg/vcissuer"
+	"github.com/trustbloc/ace/pkg/storage/cache"
+	"github.com/trustbloc/ace/pkg/storage/encrypted"
+	"github.com/trustbloc/ace/pkg/storage/redis"
+	"github.com/trustbloc/ace/pkg/storage/tombstone"
+	"github.com/trustbloc/ace/pkg/vcprovider"
+	vckms "github.com/trustbloc/ace/pkg/vcprovider/kms"
+	"github.com/trustbloc/ace/pkg/vcprovider/vcs"
 )
 
 const (
@@ -49,6 +82,28 @@ const (
 	hostURLFlagUsage     = "Host URL to run the gatekeeper instance on. Format: HostName:Port."
 	hostURLEnvKey        = "GK_HOST_URL"
 
+	unixSocketFlagName  = "unix-socket"
+	unixSocketEnvKey    = "GK_UNIX_SOCKET"
+	unixSocketFlagUsage = "Path to a Unix socket to serve the public API on over HTTP, in addition to the host URL." +
+		" Meant for a reverse proxy on the same host that terminates TLS." +
+		" Alternatively, this can be set with the following environment variable: " + unixSocketEnvKey
+	unixSocketPrefix = "unix:"
+	unixSocketMode   = 0o660
+
+	adminHostURLFlagName  = "admin-host-url"
+	adminHostURLEnvKey    = "GK_ADMIN_HOST_URL"
+	adminHostURLFlagUsage = "Host URL to serve the admin endpoints on, separately from the public API." +
+		" Format: HostName:Port. The admin endpoints are the configuration reload and the pprof profiles," +
+		" which are only served on this listener. If not set, the configuration reload is served with the public API." +
+		" Alternatively, this can be set with the following environment variable: " + adminHostURLEnvKey
+
+	grpcHostURLFlagName  = "grpc-host-url"
+	grpcHostURLEnvKey    = "GK_GRPC_HOST_URL"
+	grpcHostURLFlagUsage = "Host URL to serve the gRPC API on, separately from the REST API. Format: HostName:Port." +
+		" The gRPC API is meant for internal callers and requires the api-token. It is served over TLS with the" +
+		" serve certificate if one is set. The gRPC API is not served if not set." +
+		" Alternatively, this can be set with the following environment variable: " + grpcHostURLEnvKey
+
 	tlsSystemCertPoolFlagName  = "tls-systemcertpool"
 	tlsSystemCertPoolFlagUsage = "Use system certificate pool." +
 		" Possible values [true] [false]. Defaults to false if not set." +
@@ -70,6 +125,13 @@ const (
 		" Alternatively, this can be set with the following environment variable: " + tlsServeKeyPathFlagEnvKey
 	tlsServeKeyPathFlagEnvKey = "GK_TLS_SERVE_KEY"
 
+	tlsAdminClientCACertsFlagName  = "tls-admin-client-cacerts"
+	tlsAdminClientCACertsFlagUsage = "Comma-Separated list of paths to the CA certs the clients of the admin listener" +
+		" must present a certificate issued by, for mutual TLS. Requires " + adminHostURLFlagName + " and " +
+		tlsServeCertPathFlagName + "." +
+		" Alternatively, this can be set with the following environment variable: " + tlsAdminClientCACertsEnvKey
+	tlsAdminClientCACertsEnvKey = "GK_TLS_ADMIN_CLIENT_CACERTS"
+
 	// did resolver url.
 	didResolverURLFlagName  = "did-resolver-url"
 	didResolverURLFlagUsage = "DID Resolver URL."
@@ -92,6 +154,28 @@ const (
 	vaultServerURLFlagUsage = "URL of the vault server. This field is mandatory."
 	vaultServerURLEnvKey    = "GK_VAULT_SERVER_URL"
 
+	vaultFailoverURLsFlagName  = "vault-server-failover-url"
+	vaultFailoverURLsEnvKey    = "GK_VAULT_SERVER_FAILOVER_URLS"
+	vaultFailoverURLsFlagUsage = "URL of another instance of the vault server, sharing its storage. Requests" +
+		" fail over to it, in order, when the vault server is unavailable." +
+		" This flag can be repeated, allowing setting up multiple instances." +
+		" Alternatively, this can be set with the following environment variable (in CSV format): " +
+		vaultFailoverURLsEnvKey
+
+	regionFlagName  = "region"
+	regionEnvKey    = "GK_REGION"
+	regionFlagUsage = "Residency region Gatekeeper runs in. Data protected in another region is not extracted." +
+		" Alternatively, this can be set with the following environment variable: " + regionEnvKey
+
+	regionVaultServerURLsFlagName  = "region-vault-server-url"
+	regionVaultServerURLsEnvKey    = "GK_REGION_VAULT_SERVER_URLS"
+	regionVaultServerURLsFlagUsage = "URL of the vault server of a residency region, as region=url. The vaults of" +
+		" the data protected in the region are created on it, and the data is collected from it." +
+		" This flag can be repeated, allowing setting up multiple regions. A region set more than once fails over" +
+		" to its next URLs, in order, when its vault server is unavailable." +
+		" Alternatively, this can be set with the following environment variable (in CSV format): " +
+		regionVaultServerURLsEnvKey
+
 	// did anchor origin.
 	didAnchorOriginFlagName  = "did-anchor-origin"
 	didAnchorOriginEnvKey    = "GK_DID_ANCHOR_ORIGIN"
@@ -104,16 +188,114 @@ const (
 	cshURLFlagUsage = "URL of the csh. This field is mandatory."
 	cshURLEnvKey    = "GK_CSH_URL"
 
+	// vc provider type.
+	vcProviderFlagName  = "vc-provider"
+	vcProviderEnvKey    = "GK_VC_PROVIDER"
+	vcProviderFlagUsage = "Provider used to issue verifiable credentials. Supported options: [vcs] [kms]." +
+		" vcs registers the private key of the gatekeeper's DID with the VCS issuer, kms signs credentials in" +
+		" process with the key in the signing KMS. Defaults to vcs if not set." +
+		" Alternatively, this can be set with the following environment variable: " + vcProviderEnvKey
+
 	// vc issuer server url.
 	vcIssuerURLFlagName  = "vc-issuer-url"
-	vcIssuerURLFlagUsage = "URL of the VC VCIssuer service. This field is mandatory."
+	vcIssuerURLFlagUsage = "URL of the VC VCIssuer service. This field is mandatory with the vcs provider."
 	vcIssuerURLEnvKey    = "GK_VC_ISSUER_URL"
 
 	// vc issuer profile.
 	vcIssuerProfileFlagName  = "vc-issuer-profile"
-	vcIssuerProfileFlagUsage = "Profile of the VC VCIssuer service. This field is mandatory."
+	vcIssuerProfileFlagUsage = "Profile of the VC VCIssuer service. This field is mandatory with the vcs provider."
 	vcIssuerProfileEnvKey    = "GK_VC_ISSUER_PROFILE"
 
+	// signing KMS.
+	signingKMSFlagName  = "signing-kms"
+	signingKMSEnvKey    = "GK_SIGNING_KMS"
+	signingKMSFlagUsage = "KMS the gatekeeper's DID key is created in with the kms provider." +
+		" Supported options: [local] [aws] [web]. local stores the key encrypted with kms-master-key, aws creates" +
+		" it in AWS KMS with the credentials of the AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN" +
+		" environment variables, or else with those of the IAM role of the ECS task or of the EC2 instance, web" +
+		" creates it in the WebKMS keystore of signing-kms-url. Defaults to local." +
+		" Alternatively, this can be set with the following environment variable: " + signingKMSEnvKey
+
+	signingKMSURLFlagName  = "signing-kms-url"
+	signingKMSURLEnvKey    = "GK_SIGNING_KMS_URL"
+	signingKMSURLFlagUsage = "URL of the WebKMS keystore, e.g. https://kms.example.com/v1/keystores/{id}," +
+		" or of the AWS KMS endpoint if not the endpoint of the region. Requests to the WebKMS are authorized" +
+		" with the webkms request token if set." +
+		" Alternatively, this can be set with the following environment variable: " + signingKMSURLEnvKey
+
+	signingKMSRegionFlagName  = "signing-kms-region"
+	signingKMSRegionEnvKey    = "GK_SIGNING_KMS_REGION"
+	signingKMSRegionFlagUsage = "Region of AWS KMS. Defaults to the region of the AWS_REGION environment variable," +
+		" or us-east-1." +
+		" Alternatively, this can be set with the following environment variable: " + signingKMSRegionEnvKey
+
+	// signature suite.
+	signatureTypeFlagName  = "signature-type"
+	signatureTypeEnvKey    = "GK_SIGNATURE_TYPE"
+	signatureTypeFlagUsage = "Signature suite used for credentials issued on behalf of the gatekeeper." +
+		" Supported options: [Ed25519Signature2018] [Ed25519Signature2020] [JsonWebSignature2020]" +
+		" [BbsBlsSignature2020]. Defaults to Ed25519Signature2018 if not set." +
+		" Alternatively, this can be set with the following environment variable: " + signatureTypeEnvKey
+
+	// gatekeeper DID key type.
+	keyTypeFlagName  = "key-type"
+	keyTypeEnvKey    = "GK_KEY_TYPE"
+	keyTypeFlagUsage = "Type of the gatekeeper's DID key. Supported options: [Ed25519] [P256] [BLS12381G2]." +
+		" Must be compatible with the signature suite, defaults to the suite's default key type if not set." +
+		" Alternatively, this can be set with the following environment variable: " + keyTypeEnvKey
+
+	// credential schemas.
+	credentialSchemaFlagName  = "credential-schema"
+	credentialSchemaEnvKey    = "GK_CREDENTIAL_SCHEMA"
+	credentialSchemaFlagUsage = "Path to a JSON Schema used to validate credentials, identified by its $id." +
+		" Optionally prefixed with a credential type the schema applies to. Format: [CredentialType=]Path." +
+		" This flag can be repeated, allowing setting up multiple schemas." +
+		" Alternatively, this can be set with the following environment variable (in CSV format): " +
+		credentialSchemaEnvKey
+
+	credentialStrictJSONLDFlagName  = "credential-strict-jsonld"
+	credentialStrictJSONLDEnvKey    = "GK_CREDENTIAL_STRICT_JSONLD"
+	credentialStrictJSONLDFlagUsage = "Reject credentials with properties not defined by their JSON-LD contexts." +
+		" Possible values [true] [false]. Defaults to false if not set." +
+		" Alternatively, this can be set with the following environment variable: " + credentialStrictJSONLDEnvKey
+
+	strictJSONFlagName  = "strict-json"
+	strictJSONEnvKey    = "GK_STRICT_JSON"
+	strictJSONFlagUsage = "Reject policy and protect requests with unknown fields, e.g. misspelled ones, with 400." +
+		" Possible values [true] [false]. Defaults to false if not set." +
+		" Alternatively, this can be set with the following environment variable: " + strictJSONEnvKey
+
+	didcommApprovalsFlagName  = "didcomm-approvals"
+	didcommApprovalsEnvKey    = "GK_DIDCOMM_APPROVALS"
+	didcommApprovalsFlagUsage = "Push the approval requests of new tickets to the agents of the approvers as DIDComm v2" +
+		" messages, and accept their decisions as signed DIDComm messages on /v1/didcomm." +
+		" Possible values [true] [false]. Defaults to false if not set." +
+		" Alternatively, this can be set with the following environment variable: " + didcommApprovalsEnvKey
+
+	didcommEndpointFlagName  = "didcomm-endpoint"
+	didcommEndpointEnvKey    = "GK_DIDCOMM_ENDPOINT"
+	didcommEndpointFlagUsage = "Public URL of /v1/didcomm, the endpoint the out-of-band invitations of new handlers" +
+		" and approvers are accepted on. Invitations are created on /v1/didcomm/invitations if it is set, and" +
+		" " + didcommApprovalsFlagName + " is enabled, and the connected handlers can be issued authorized handler" +
+		" credentials." +
+		" Alternatively, this can be set with the following environment variable: " + didcommEndpointEnvKey
+
+	featureFlagsFlagName  = "feature-flags"
+	featureFlagsEnvKey    = "GK_FEATURE_FLAGS"
+	featureFlagsFlagUsage = "Comma-separated feature flags of the experimental capabilities, enabled, or set to the" +
+		" boolean following them, e.g. didcomm,bbs_issuance=false. didcomm gates the DIDComm endpoints and the" +
+		" approval requests pushed to the approvers, bbs_issuance the " + vccrypto.BbsBlsSignature2020 +
+		" signature type. Flags are disabled unless the build enables them, and can be toggled on " +
+		featuresEndpoint + " of the admin API." +
+		" Alternatively, this can be set with the following environment variable: " + featureFlagsEnvKey
+
+	oidc4vpVerifierURLFlagName  = "oidc4vp-verifier-url"
+	oidc4vpVerifierURLEnvKey    = "GK_OIDC4VP_VERIFIER_URL"
+	oidc4vpVerifierURLFlagUsage = "URL of the interactions API of an OIDC4VP verifier. If set, handlers can present" +
+		" the credentials required by the policies from their wallets with OIDC4VP, initiated on /v1/oidc4vp." +
+		" Requests are authorized with the " + oidc4vpRequestTokenName + " token of " + requestTokensFlagName +
+		". Alternatively, this can be set with the following environment variable: " + oidc4vpVerifierURLEnvKey
+
 	requestTokensFlagName  = "request-tokens"
 	requestTokensEnvKey    = "GK_REQUEST_TOKENS"
 	requestTokensFlagUsage = "Tokens used for HTTP requests to other services" +
@@ -124,51 +306,310 @@ const (
 	authTokenFlagUsage = "Bearer token used for a token protected api calls. " +
 		" Alternatively, this can be set with the following environment variable: " + authTokenEnvKey
 
+	cacheURLFlagName  = "cache-url"
+	cacheURLEnvKey    = "GK_CACHE_URL"
+	cacheURLFlagUsage = "URL of a Redis server used to cache policies and tickets." +
+		" Format: redis://[:password@]host:port[/db]. Caching is disabled if not set." +
+		" Alternatively, this can be set with the following environment variable: " + cacheURLEnvKey
+
+	cacheTTLFlagName  = "cache-ttl"
+	cacheTTLEnvKey    = "GK_CACHE_TTL"
+	cacheTTLFlagUsage = "Time to live of cached values, e.g. 30s or 5m. Defaults to 5m if not set." +
+		" Alternatively, this can be set with the following environment variable: " + cacheTTLEnvKey
+
+	storeEncryptionFlagName  = "store-encryption"
+	storeEncryptionEnvKey    = "GK_STORE_ENCRYPTION"
+	storeEncryptionFlagUsage = "Encrypt protected data and tickets at rest with a key held by the KMS." +
+		" Requires " + kmsMasterKeyFlagName + ", so that the key is not stored in plaintext next to the data." +
+		" Possible values [true] [false]. Defaults to false if not set." +
+		" Alternatively, this can be set with the following environment variable: " + storeEncryptionEnvKey
+
+	kmsMasterKeyFlagName  = "kms-master-key"
+	kmsMasterKeyEnvKey    = "GK_KMS_MASTER_KEY"
+	kmsMasterKeyFlagUsage = "Base64url-encoded AES key of 16, 24 or 32 bytes the keys stored by the KMS are encrypted" +
+		" with, e.g. a secret reference such as vault://secret/data/gatekeeper#master_key. Keys are stored" +
+		" unencrypted if not set. Keys stored with another master key, or none, cannot be read." +
+		" Alternatively, this can be set with the following environment variable: " + kmsMasterKeyEnvKey
+
+	backupKeyFlagName  = "backup-key"
+	backupKeyEnvKey    = "GK_BACKUP_KEY"
+	backupKeyFlagUsage = "Base64url-encoded AES key of 32 bytes the snapshots of the backup admin endpoint are encrypted" +
+		" with, and the snapshots restored by the restore admin endpoint are decrypted with. The backup and restore" +
+		" endpoints are only served if it is set." +
+		" Alternatively, this can be set with the following environment variable: " + backupKeyEnvKey
+
+	deletedRetentionFlagName  = "deleted-retention"
+	deletedRetentionEnvKey    = "GK_DELETED_RETENTION"
+	deletedRetentionFlagUsage = "Time deleted policies and protected data can be restored before they are purged," +
+		" e.g. 24h or 720h. Defaults to 720h if not set." +
+		" Alternatively, this can be set with the following environment variable: " + deletedRetentionEnvKey
+
+	purgeIntervalFlagName  = "purge-interval"
+	purgeIntervalEnvKey    = "GK_PURGE_INTERVAL"
+	purgeIntervalFlagUsage = "Time between two purges of deleted policies and protected data, e.g. 30m or 1h." +
+		" Defaults to 1h if not set." +
+		" Alternatively, this can be set with the following environment variable: " + purgeIntervalEnvKey
+
+	leaderLeaseTTLFlagName  = "leader-lease-ttl"
+	leaderLeaseTTLEnvKey    = "GK_LEADER_LEASE_TTL"
+	leaderLeaseTTLFlagUsage = "Time the instance running the scheduled jobs, such as the purges, the anchoring checks" +
+		" and the DID key retirements, holds its lease in the database without renewing it, e.g. 30s." +
+		" If set, only the instance holding the lease runs the jobs, and another instance takes it over once the" +
+		" lease expires. It also updates the tickets with a database that doesn't write them conditionally," +
+		" i.e. other than mongodb, postgres or mem. The jobs run on every instance if not set, which must then be" +
+		" the only instance sharing the database." +
+		" Alternatively, this can be set with the following environment variable: " + leaderLeaseTTLEnvKey
+
+	didCacheSizeFlagName  = "did-cache-size"
+	didCacheSizeEnvKey    = "GK_DID_CACHE_SIZE"
+	didCacheSizeFlagUsage = "Number of DID resolutions cached, or 0 to disable caching. Defaults to 1000 if not set." +
+		" Alternatively, this can be set with the following environment variable: " + didCacheSizeEnvKey
+
+	didCacheTTLFlagName  = "did-cache-ttl"
+	didCacheTTLEnvKey    = "GK_DID_CACHE_TTL"
+	didCacheTTLFlagUsage = "Time DID resolutions are cached for, e.g. 30s or 5m. Defaults to 5m if not set." +
+		" Alternatively, this can be set with the following environment variable: " + didCacheTTLEnvKey
+
+	didKeyGracePeriodFlagName  = "did-key-grace-period"
+	didKeyGracePeriodEnvKey    = "GK_DID_KEY_GRACE_PERIOD"
+	didKeyGracePeriodFlagUsage = "Time the previous DID key keeps verifying after the DID key is rotated, e.g. 24h." +
+		" Defaults to 24h if not set." +
+		" Alternatively, this can be set with the following environment variable: " + didKeyGracePeriodEnvKey
+
+	anchorCheckIntervalFlagName  = "anchor-check-interval"
+	anchorCheckIntervalEnvKey    = "GK_ANCHOR_CHECK_INTERVAL"
+	anchorCheckIntervalFlagUsage = "Time between two checks of the DIDs of protected data pending anchoring on Orb," +
+		" e.g. 30s or 5m, or 0 to disable the checks. Defaults to 1m if not set." +
+		" Alternatively, this can be set with the following environment variable: " + anchorCheckIntervalEnvKey
+	defaultAnchorCheckInterval = time.Minute
+
+	checkpointIntervalFlagName  = "audit-checkpoint-interval"
+	checkpointIntervalEnvKey    = "GK_AUDIT_CHECKPOINT_INTERVAL"
+	checkpointIntervalFlagUsage = "Time between two checkpoints of the audit log signed with the key of" +
+		" Gatekeeper, e.g. 10m, or 0 to disable the checkpoints. Defaults to 1h if not set." +
+		" Alternatively, this can be set with the following environment variable: " + checkpointIntervalEnvKey
+	defaultCheckpointInterval = time.Hour
+
+	healthProbeIntervalFlagName  = "health-probe-interval"
+	healthProbeIntervalEnvKey    = "GK_HEALTH_PROBE_INTERVAL"
+	healthProbeIntervalFlagUsage = "Time between two probes of the health of the vault server, the DID resolver and" +
+		" the database, kept in a rolling history served by the admin API, e.g. 10s, or 0 to disable the probes." +
+		" Defaults to 30s if not set." +
+		" Alternatively, this can be set with the following environment variable: " + healthProbeIntervalEnvKey
+	defaultHealthProbeInterval = 30 * time.Second
+
+	slowRequestThresholdFlagName  = "slow-request-threshold"
+	slowRequestThresholdEnvKey    = "GK_SLOW_REQUEST_THRESHOLD"
+	slowRequestThresholdFlagUsage = "Duration from which the requests to the REST API are logged as slow, with the" +
+		" breakdown of their calls to downstream services, e.g. 2s, or 0 to disable the logging." +
+		" Defaults to 5s if not set." +
+		" Alternatively, this can be set with the following environment variable: " + slowRequestThresholdEnvKey
+	defaultSlowRequestThreshold = 5 * time.Second
+
+	accessLogSampleRateFlagName  = "access-log-sample-rate"
+	accessLogSampleRateEnvKey    = "GK_ACCESS_LOG_SAMPLE_RATE"
+	accessLogSampleRateFlagUsage = "Fraction of the requests to the REST API logged with their method, path, status," +
+		" duration, size and caller, from 0 to 1, e.g. 0.1 to log one request out of ten. Server errors are always" +
+		" logged, unless the rate is 0. Defaults to 1 if not set." +
+		" Alternatively, this can be set with the following environment variable: " + accessLogSampleRateEnvKey
+	defaultAccessLogSampleRate = 1
+
+	errorReportDSNFlagName  = "error-report-dsn"
+	errorReportDSNEnvKey    = "GK_ERROR_REPORT_DSN"
+	errorReportDSNFlagUsage = "DSN of a Sentry-compatible endpoint the server errors and the panics of the REST API" +
+		" are reported to, with their stack traces and the scrubbed context of the requests," +
+		" e.g. https://<key>@sentry.example.com/<project>. Errors are not reported if not set." +
+		" Alternatively, this can be set with the following environment variable: " + errorReportDSNEnvKey
+
+	maxRequestSizeFlagName  = "max-request-size"
+	maxRequestSizeEnvKey    = "GK_MAX_REQUEST_SIZE"
+	maxRequestSizeFlagUsage = "Maximum size, in bytes, of the JSON bodies of the requests, checked as they are" +
+		" decoded. Larger requests are rejected with 413 Request Entity Too Large. Defaults to 4194304 if not set." +
+		" Alternatively, this can be set with the following environment variable: " + maxRequestSizeEnvKey
+
+	maxJSONDepthFlagName  = "max-json-depth"
+	maxJSONDepthEnvKey    = "GK_MAX_JSON_DEPTH"
+	maxJSONDepthFlagUsage = "Maximum nesting of the objects and arrays of the JSON bodies of the requests." +
+		" Defaults to 32 if not set." +
+		" Alternatively, this can be set with the following environment variable: " + maxJSONDepthEnvKey
+
+	maxJSONStringLengthFlagName  = "max-json-string-length"
+	maxJSONStringLengthEnvKey    = "GK_MAX_JSON_STRING_LENGTH"
+	maxJSONStringLengthFlagUsage = "Maximum length, in bytes, of the strings of the JSON bodies of the requests." +
+		" Defaults to 1048576 if not set." +
+		" Alternatively, this can be set with the following environment variable: " + maxJSONStringLengthEnvKey
+
+	protectWorkersFlagName  = "protect-workers"
+	protectWorkersEnvKey    = "GK_PROTECT_WORKERS"
+	protectWorkersFlagUsage = "Number of protect requests processed at a time, or 0 for no limit." +
+		" Defaults to 32 if not set." +
+		" Alternatively, this can be set with the following environment variable: " + protectWorkersEnvKey
+
+	protectQueueSizeFlagName  = "protect-queue-size"
+	protectQueueSizeEnvKey    = "GK_PROTECT_QUEUE_SIZE"
+	protectQueueSizeFlagUsage = "Number of protect requests waiting to be processed, beyond which requests are" +
+		" rejected with 503 Service Unavailable. Defaults to 128 if not set." +
+		" Alternatively, this can be set with the following environment variable: " + protectQueueSizeEnvKey
+
+	collectQueueSizeFlagName  = "collect-queue-size"
+	collectQueueSizeEnvKey    = "GK_COLLECT_QUEUE_SIZE"
+	collectQueueSizeFlagUsage = "Number of collect requests queued in the database, so that they are processed" +
+		" after a restart, beyond which requests are rejected with 503 Service Unavailable." +
+		" 0 processes collect requests without a queue. Defaults to 1000 if not set." +
+		" Alternatively, this can be set with the following environment variable: " + collectQueueSizeEnvKey
+
+	collectWorkersFlagName  = "collect-workers"
+	collectWorkersEnvKey    = "GK_COLLECT_WORKERS"
+	collectWorkersFlagUsage = "Number of queued collect requests processed at a time. Defaults to 16 if not set." +
+		" Alternatively, this can be set with the following environment variable: " + collectWorkersEnvKey
+
+	corsAllowedOriginsFlagName  = "cors-allowed-origins"
+	corsAllowedOriginsEnvKey    = "GK_CORS_ALLOWED_ORIGINS"
+	corsAllowedOriginsFlagUsage = "Origins allowed to make cross-origin requests, e.g. https://*.example.com." +
+		" All origins are allowed if not set. Can be reloaded without a restart." +
+		" Alternatively, this can be set with the following environment variable: " + corsAllowedOriginsEnvKey
+
 	tokenLength2              = 2
 	vcsIssuerRequestTokenName = "vcs_issuer"
+	webKMSRequestTokenName    = "webkms"
 	sidetreeRequestTokenName  = "sidetreeToken"
+	oidc4vpRequestTokenName   = "oidc4vp_verifier"
 	keystorePrimaryKeyURI     = "local-lock://localkms"
+	defaultDIDKeyGracePeriod  = 24 * time.Hour
+	vaultFailureThreshold     = 3
+	vaultCooldown             = 30 * time.Second
+
+	localSigningKMS = "local"
+	awsSigningKMS   = "aws"
+	webSigningKMS   = "web"
+
+	aes128KeySize = 16
+	aes192KeySize = 24
+	aes256KeySize = 32
 )
 
 var logger = log.New("gatekeeper-rest")
 
 type tlsParameters struct {
-	systemCertPool bool
-	caCerts        []string
-	serveCertPath  string
-	serveKeyPath   string
+	systemCertPool     bool
+	caCerts            []string
+	serveCertPath      string
+	serveKeyPath       string
+	adminClientCACerts []string
 }
 
 type serviceParameters struct {
-	host                string
-	tlsParams           *tlsParameters
-	dbParams            *common.DBParameters
-	blocDomain          string
-	didResolverURL      string
-	contextProviderURLs []string
-	vcIssuerURL         string
-	vcIssuerProfile     string
-	vaultServerURL      string
-	didAnchorOrigin     string
-	cshURL              string
-	authToken           string
-	requestTokens       map[string]string
+	host                  string
+	adminHost             string
+	unixSocket            string
+	grpcHost              string
+	tlsParams             *tlsParameters
+	dbParams              *common.DBParameters
+	secretsParams         *common.SecretsParameters
+	httpClientParams      *common.HTTPClientParameters
+	blocDomain            string
+	didResolverURL        string
+	contextProviderURLs   []string
+	vcProvider            string
+	vcIssuerURL           string
+	vcIssuerProfile       string
+	signingKMS            string
+	signingKMSURL         string
+	signingKMSRegion      string
+	vaultServerURL        string
+	vaultFailoverURLs     []string
+	region                string
+	regionVaultServerURLs map[string][]string
+	didAnchorOrigin       string
+	cshURL                string
+	authToken             string
+	requestTokens         map[string]string
+	credentialSchemas     []*schema.Schema
+	credentialSchemaFiles []string
+	strictJSONLD          bool
+	strictJSON            bool
+	didcommApprovals      bool
+	didcommEndpoint       string
+	featureFlags          string
+	features              *feature.Flags
+	oidc4vpVerifierURL    string
+	signatureType         string
+	keyType               string
+	cacheURL              string
+	cacheTTL              time.Duration
+	storeEncryption       bool
+	kmsMasterKey          string
+	backupKey             string
+	deletedRetention      time.Duration
+	purgeInterval         time.Duration
+	leaderLeaseTTL        time.Duration
+	didCacheSize          int
+	didCacheTTL           time.Duration
+	didKeyGracePeriod     time.Duration
+	anchorCheckInterval   time.Duration
+	checkpointInterval    time.Duration
+	healthProbeInterval   time.Duration
+	slowRequestThreshold  time.Duration
+	accessLogSampleRate   float64
+	errorReportDSN        string
+	requestLimits         model.Limits
+	protectWorkers        int
+	protectQueueSize      int
+	collectQueueSize      int
+	collectWorkers        int
+	logLevel              string
+	corsAllowedOrigins    []string
+	h2c                   bool
+	startupTimeout        time.Duration
 }
 
 type server interface {
-	ListenAndServe(host string, certFile, keyFile string, router http.Handler) error
+	ListenAndServe(host string, certFile, keyFile string, clientCAs *x509.CertPool, router http.Handler) error
 }
 
 // HTTPServer represents an actual HTTP server implementation.
 type HTTPServer struct{}
 
-// ListenAndServe starts the server using the standard Go HTTP server implementation.
-func (s *HTTPServer) ListenAndServe(host, certFile, keyFile string, router http.Handler) error {
+// ListenAndServe starts the server using the standard Go HTTP server implementation. The serve certificate is
+// reloaded when its files change, and client certificates issued by clientCAs are required if it is set. A host with
+// the unix: prefix is served over HTTP on the Unix socket of the given path.
+func (s *HTTPServer) ListenAndServe(host, certFile, keyFile string, clientCAs *x509.CertPool,
+	router http.Handler) error {
+	if socket := strings.TrimPrefix(host, unixSocketPrefix); socket != host {
+		return listenAndServeUnix(socket, router)
+	}
+
 	if certFile == "" || keyFile == "" {
 		return http.ListenAndServe(host, router)
 	}
 
-	return http.ListenAndServeTLS(host, certFile, keyFile, router)
+	if clientCAs != nil {
+		return common.ListenAndServeMutualTLS(host, certFile, keyFile, clientCAs, router, logger)
+	}
+
+	return common.ListenAndServeTLS(host, certFile, keyFile, router, logger)
+}
+
+// listenAndServeUnix serves HTTP on the Unix socket of the given path. A socket left by a previous run is removed.
+func listenAndServeUnix(socket string, router http.Handler) error {
+	if info, err := os.Lstat(socket); err == nil && info.Mode()&os.ModeSocket != 0 {
+		if err = os.Remove(socket); err != nil {
+			return fmt.Errorf("remove unix socket: %w", err)
+		}
+	}
+
+	l, err := net.Listen("unix", socket)
+	if err != nil {
+		return fmt.Errorf("listen on unix socket: %w", err)
+	}
+
+	defer l.Close() // nolint: errcheck
+
+	if err = os.Chmod(socket, unixSocketMode); err != nil {
+		return fmt.Errorf("set unix socket mode: %w", err)
+	}
+
+	return http.Serve(l, router) //nolint:gosec
 }
 
 // GetStartCmd returns the Cobra start command.
@@ -201,11 +642,15 @@ func getTLS(cmd *cobra.Command) (*tlsParameters, error) {
 
 	tlsServeKeyPath := cmdutils.GetUserSetOptionalVarFromString(cmd, tlsServeKeyPathFlagName, tlsServeKeyPathFlagEnvKey)
 
+	tlsAdminClientCACerts := cmdutils.GetUserSetOptionalVarFromArrayString(cmd, tlsAdminClientCACertsFlagName,
+		tlsAdminClientCACertsEnvKey)
+
 	return &tlsParameters{
-		systemCertPool: tlsSystemCertPool,
-		caCerts:        tlsCACerts,
-		serveCertPath:  tlsServeCertPath,
-		serveKeyPath:   tlsServeKeyPath,
+		systemCertPool:     tlsSystemCertPool,
+		caCerts:            tlsCACerts,
+		serveCertPath:      tlsServeCertPath,
+		serveKeyPath:       tlsServeKeyPath,
+		adminClientCACerts: tlsAdminClientCACerts,
 	}, nil
 }
 
@@ -214,32 +659,114 @@ func createStartCmd(srv server) *cobra.Command {
 		Use:   "start",
 		Short: "Starts Gatekeeper server",
 		RunE: func(cmd *cobra.Command, args []string) error {
+			configFile, err := common.LoadConfigFile(cmd)
+			if err != nil {
+				return err
+			}
+
+			secrets, err := loadSecrets(cmd)
+			if err != nil {
+				return err
+			}
+
+			defer secrets.Close() //nolint:errcheck
+
 			params, err := getParameters(cmd)
 			if err != nil {
 				return err
 			}
 
-			return startService(params, srv)
+			if err = params.validate(); err != nil {
+				return err
+			}
+
+			if common.PrintConfigSet(cmd) {
+				return common.PrintConfig(cmd, params.settings())
+			}
+
+			return startService(params, srv, func() (*serviceParameters, error) {
+				if err := configFile.Reload(); err != nil {
+					return nil, err
+				}
+
+				if err := secrets.Resolve(cmd); err != nil {
+					return nil, err
+				}
+
+				p, err := getParameters(cmd)
+				if err != nil {
+					return nil, err
+				}
+
+				return p, p.validate()
+			})
 		},
 	}
 }
 
+// loadSecrets resolves the secret references of the settings. The TLS settings are paths to files.
+func loadSecrets(cmd *cobra.Command) (*common.Secrets, error) {
+	params, err := common.SecretsParams(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	secrets := common.NewSecrets(params,
+		tlsCACertsFlagName, tlsCACertsEnvKey,
+		tlsServeCertPathFlagName, tlsServeCertPathEnvKey,
+		tlsServeKeyPathFlagName, tlsServeKeyPathFlagEnvKey,
+		tlsAdminClientCACertsFlagName, tlsAdminClientCACertsEnvKey,
+	)
+
+	if err = secrets.Resolve(cmd); err != nil {
+		return nil, err
+	}
+
+	return secrets, nil
+}
+
 func getParameters(cmd *cobra.Command) (*serviceParameters, error) { //nolint: funlen
 	host, err := cmdutils.GetUserSetVarFromString(cmd, hostURLFlagName, hostURLEnvKey, false)
 	if err != nil {
 		return nil, err
 	}
 
+	adminHost := cmdutils.GetUserSetOptionalVarFromString(cmd, adminHostURLFlagName, adminHostURLEnvKey)
+
+	unixSocket := cmdutils.GetUserSetOptionalVarFromString(cmd, unixSocketFlagName, unixSocketEnvKey)
+
+	grpcHost := cmdutils.GetUserSetOptionalVarFromString(cmd, grpcHostURLFlagName, grpcHostURLEnvKey)
+
 	tlsParams, err := getTLS(cmd)
 	if err != nil {
 		return nil, err
 	}
 
+	h2c, err := common.H2CEnabled(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	startupTimeout, err := common.StartupTimeout(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	httpClientParams, err := common.HTTPClientParams(cmd)
+	if err != nil {
+		return nil, err
+	}
+
 	dbParams, err := common.DBParams(cmd)
 	if err != nil {
 		return nil, err
 	}
 
+	secretsParams, err := common.SecretsParams(cmd)
+	if err != nil {
+		return nil, err
+	}
+
 	blocDomain, err := cmdutils.GetUserSetVarFromString(cmd, blocDomainFlagName, blocDomainEnvKey, true)
 	if err != nil {
 		return nil, err
@@ -263,6 +790,16 @@ func getParameters(cmd *cobra.Command) (*serviceParameters, error) { //nolint: f
 		return nil, err
 	}
 
+	vaultFailoverURLs := cmdutils.GetUserSetOptionalVarFromArrayString(cmd, vaultFailoverURLsFlagName,
+		vaultFailoverURLsEnvKey)
+
+	region := cmdutils.GetUserSetOptionalVarFromString(cmd, regionFlagName, regionEnvKey)
+
+	regionVaultServerURLs, err := getRegionVaultServerURLs(cmd)
+	if err != nil {
+		return nil, err
+	}
+
 	didAnchorOrigin, err := cmdutils.GetUserSetVarFromString(cmd, didAnchorOriginFlagName,
 		didAnchorOriginEnvKey, false)
 	if err != nil {
@@ -275,254 +812,1383 @@ func getParameters(cmd *cobra.Command) (*serviceParameters, error) { //nolint: f
 		return nil, err
 	}
 
-	vcIssuerURL, err := cmdutils.GetUserSetVarFromString(cmd, vcIssuerURLFlagName, vcIssuerURLEnvKey, false)
-	if err != nil {
-		return nil, err
+	vcProvider := cmdutils.GetUserSetOptionalVarFromString(cmd, vcProviderFlagName, vcProviderEnvKey)
+	if vcProvider == "" {
+		vcProvider = vcprovider.VCS
 	}
 
-	vcIssuerProfile, err := cmdutils.GetUserSetVarFromString(cmd, vcIssuerProfileFlagName, vcIssuerProfileEnvKey, false)
+	// the VCS issuer is only called by the vcs provider
+	vcIssuerURL, err := cmdutils.GetUserSetVarFromString(cmd, vcIssuerURLFlagName, vcIssuerURLEnvKey,
+		vcProvider != vcprovider.VCS)
 	if err != nil {
 		return nil, err
 	}
 
-	requestTokens, err := getRequestTokens(cmd)
+	vcIssuerProfile, err := cmdutils.GetUserSetVarFromString(cmd, vcIssuerProfileFlagName, vcIssuerProfileEnvKey,
+		vcProvider != vcprovider.VCS)
 	if err != nil {
 		return nil, err
 	}
 
-	authToken, err := cmdutils.GetUserSetVarFromString(cmd, authTokenFlagName,
-		authTokenEnvKey, true)
+	signingKMS := cmdutils.GetUserSetOptionalVarFromString(cmd, signingKMSFlagName, signingKMSEnvKey)
+	if signingKMS == "" {
+		signingKMS = localSigningKMS
+	}
 
-	return &serviceParameters{
-		host:                host,
-		tlsParams:           tlsParams,
-		dbParams:            dbParams,
-		blocDomain:          blocDomain,
-		didResolverURL:      didResolverURL,
-		contextProviderURLs: contextProviderURLs,
-		vcIssuerURL:         vcIssuerURL,
-		vcIssuerProfile:     vcIssuerProfile,
-		vaultServerURL:      vaultServerURL,
-		didAnchorOrigin:     didAnchorOrigin,
-		cshURL:              cshURL,
-		authToken:           authToken,
-		requestTokens:       requestTokens,
-	}, err
-}
+	signingKMSURL := cmdutils.GetUserSetOptionalVarFromString(cmd, signingKMSURLFlagName, signingKMSURLEnvKey)
 
-func createFlags(cmd *cobra.Command) {
-	cmd.Flags().StringP(hostURLFlagName, hostURLFlagShorthand, "", hostURLFlagUsage)
-	cmd.Flags().StringP(tlsSystemCertPoolFlagName, "", "", tlsSystemCertPoolFlagUsage)
-	cmd.Flags().StringArrayP(tlsCACertsFlagName, "", []string{}, tlsCACertsFlagUsage)
-	cmd.Flags().StringP(tlsServeCertPathFlagName, "", "", tlsServeCertPathFlagUsage)
-	cmd.Flags().StringP(tlsServeKeyPathFlagName, "", "", tlsServeKeyPathFlagUsage)
-	cmd.Flags().StringP(blocDomainFlagName, "", "", blocDomainFlagUsage)
-	cmd.Flags().StringP(didResolverURLFlagName, "", "", didResolverURLFlagUsage)
-	cmd.Flags().StringArrayP(contextProviderFlagName, "", []string{}, contextProviderFlagUsage)
-	cmd.Flags().StringP(vaultServerURLFlagName, "", "", vaultServerURLFlagUsage)
-	cmd.Flags().StringP(didAnchorOriginFlagName, "", "", didAnchorOriginFlagUsage)
-	cmd.Flags().StringP(cshURLFlagName, "", "", cshURLFlagUsage)
-	cmd.Flags().StringP(vcIssuerURLFlagName, "", "", vcIssuerURLFlagUsage)
-	cmd.Flags().StringP(vcIssuerProfileFlagName, "", "", vcIssuerProfileFlagUsage)
-	cmd.Flags().StringArrayP(requestTokensFlagName, "", []string{}, requestTokensFlagUsage)
-	cmd.Flags().StringP(authTokenFlagName, "", "", authTokenFlagUsage)
+	signingKMSRegion := cmdutils.GetUserSetOptionalVarFromString(cmd, signingKMSRegionFlagName,
+		signingKMSRegionEnvKey)
+	if signingKMSRegion == "" {
+		signingKMSRegion = os.Getenv(common.AWSRegionEnvKey)
+	}
 
-	common.Flags(cmd)
-}
+	requestTokens, err := getRequestTokens(cmd)
+	if err != nil {
+		return nil, err
+	}
 
-func startService(params *serviceParameters, srv server) error { // nolint: funlen,gocyclo
-	rootCAs, err := tlsutils.GetCertPool(params.tlsParams.systemCertPool, params.tlsParams.caCerts)
+	signatureType, keyType, err := getSignatureSuite(cmd)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	tlsConfig := &tls.Config{RootCAs: rootCAs, MinVersion: tls.VersionTLS12}
+	credentialSchemaFiles := cmdutils.GetUserSetOptionalVarFromArrayString(cmd, credentialSchemaFlagName,
+		credentialSchemaEnvKey)
 
-	storeProvider, err := common.InitStore(params.dbParams, logger)
+	credentialSchemas, err := getCredentialSchemas(credentialSchemaFiles)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	router := mux.NewRouter()
-
-	// add health check endpoint
-	healthCheckService := healthcheck.New()
+	strictJSONLD := false
 
-	healthCheckHandlers := healthCheckService.GetOperations()
-	for _, handler := range healthCheckHandlers {
-		router.HandleFunc(handler.Path(), handler.Handle()).Methods(handler.Method())
+	if v := cmdutils.GetUserSetOptionalVarFromString(cmd, credentialStrictJSONLDFlagName,
+		credentialStrictJSONLDEnvKey); v != "" {
+		strictJSONLD, err = strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("parse %s: %w", credentialStrictJSONLDFlagName, err)
+		}
 	}
 
-	httpClient := &http.Client{Transport: &http.Transport{
-		TLSClientConfig: tlsConfig,
-	}}
+	strictJSON := false
 
-	vdr, err := createVDR(params.didResolverURL, params.blocDomain, params.requestTokens[sidetreeRequestTokenName],
-		httpClient)
-	if err != nil {
-		return err
+	if v := cmdutils.GetUserSetOptionalVarFromString(cmd, strictJSONFlagName, strictJSONEnvKey); v != "" {
+		strictJSON, err = strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("parse %s: %w", strictJSONFlagName, err)
+		}
 	}
 
-	ldStore, err := common.CreateLDStoreProvider(storeProvider)
-	if err != nil {
-		return err
+	didcommApprovals := false
+
+	if v := cmdutils.GetUserSetOptionalVarFromString(cmd, didcommApprovalsFlagName, didcommApprovalsEnvKey); v != "" {
+		didcommApprovals, err = strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("parse %s: %w", didcommApprovalsFlagName, err)
+		}
 	}
 
-	documentLoader, err := common.CreateJSONLDDocumentLoader(ldStore, httpClient, params.contextProviderURLs)
+	didcommEndpoint := cmdutils.GetUserSetOptionalVarFromString(cmd, didcommEndpointFlagName, didcommEndpointEnvKey)
+
+	featureFlags := cmdutils.GetUserSetOptionalVarFromString(cmd, featureFlagsFlagName, featureFlagsEnvKey)
+
+	features, err := newFeatureFlags(featureFlags)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	vClient := vaultclient.New(params.vaultServerURL, vaultclient.WithHTTPClient(httpClient))
+	oidc4vpVerifierURL := cmdutils.GetUserSetOptionalVarFromString(cmd, oidc4vpVerifierURLFlagName,
+		oidc4vpVerifierURLEnvKey)
 
-	cshClient := createCSHClient(params.cshURL, httpClient).Operations
+	cacheURL := cmdutils.GetUserSetOptionalVarFromString(cmd, cacheURLFlagName, cacheURLEnvKey)
 
-	vcIssuer := vcissuer.New(&vcissuer.Config{
-		VCIssuerURL:    params.vcIssuerURL,
-		AuthToken:      params.requestTokens[vcsIssuerRequestTokenName],
-		ProfileName:    params.vcIssuerProfile,
-		DocumentLoader: documentLoader,
-		HTTPClient:     httpClient,
-	})
+	cacheTTL := cache.DefaultTTL
 
-	keyManager, err := localkms.New(keystorePrimaryKeyURI, &kmsProvider{
-		storageProvider: storeProvider,
-		secretLock:      &noop.NoLock{},
-	})
-	if err != nil {
-		return err
+	if v := cmdutils.GetUserSetOptionalVarFromString(cmd, cacheTTLFlagName, cacheTTLEnvKey); v != "" {
+		cacheTTL, err = time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("parse %s: %w", cacheTTLFlagName, err)
+		}
 	}
 
-	configService, err := config.NewService(&config.ServiceParams{
-		StoreProvider:   storeProvider,
-		CSHClient:       cshClient,
-		VDR:             vdr,
-		KeyManager:      keyManager,
-		DidMethod:       orb.DIDMethod,
-		DidAnchorOrigin: params.didAnchorOrigin,
-	})
-	if err != nil {
-		return err
-	}
+	storeEncryption := false
 
-	service, err := gatekeeper.New(&gatekeeper.Config{
-		StorageProvider:        storeProvider,
-		VaultClient:            vClient,
-		ConfigService:          configService,
-		VDR:                    vdr,
-		VCIssuer:               vcIssuer,
-		ConfidentialStorageHub: cshClient,
-	})
-	if err != nil {
-		return err
+	if v := cmdutils.GetUserSetOptionalVarFromString(cmd, storeEncryptionFlagName, storeEncryptionEnvKey); v != "" {
+		storeEncryption, err = strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("parse %s: %w", storeEncryptionFlagName, err)
+		}
 	}
 
-	httpSigMW := httpsigmw.New(&httpsigmw.Config{
-		VDR: vdr,
-	})
+	kmsMasterKey := cmdutils.GetUserSetOptionalVarFromString(cmd, kmsMasterKeyFlagName, kmsMasterKeyEnvKey)
 
-	tokenAuthMW := tokenauth.New(params.authToken)
+	backupKey := cmdutils.GetUserSetOptionalVarFromString(cmd, backupKeyFlagName, backupKeyEnvKey)
 
-	for _, operation := range service.GetOperations() {
-		var h http.Handler
-		h = operation.Handle()
+	deletedRetention := tombstone.DefaultRetention
 
-		if operation.Auth() == handler.AuthHTTPSig {
-			h = httpSigMW.Middleware(h)
+	if v := cmdutils.GetUserSetOptionalVarFromString(cmd, deletedRetentionFlagName, deletedRetentionEnvKey); v != "" {
+		deletedRetention, err = time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("parse %s: %w", deletedRetentionFlagName, err)
 		}
+	}
 
-		if operation.Auth() == handler.AuthToken && params.authToken != "" {
-			h = tokenAuthMW.Middleware(h)
+	purgeInterval := tombstone.DefaultPurgeInterval
+
+	if v := cmdutils.GetUserSetOptionalVarFromString(cmd, purgeIntervalFlagName, purgeIntervalEnvKey); v != "" {
+		purgeInterval, err = time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("parse %s: %w", purgeIntervalFlagName, err)
 		}
+	}
 
-		router.Handle(operation.Path(), h).Methods(operation.Method())
+	var leaderLeaseTTL time.Duration
+
+	if v := cmdutils.GetUserSetOptionalVarFromString(cmd, leaderLeaseTTLFlagName, leaderLeaseTTLEnvKey); v != "" {
+		leaderLeaseTTL, err = time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("parse %s: %w", leaderLeaseTTLFlagName, err)
+		}
 	}
 
-	hasConfig, err := configService.HasConfig()
+	didCacheSize, err := getInt(cmd, didCacheSizeFlagName, didCacheSizeEnvKey, didcache.DefaultSize)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	if !hasConfig {
-		err = configService.CreateConfig()
+	didCacheTTL := didcache.DefaultTTL
+
+	if v := cmdutils.GetUserSetOptionalVarFromString(cmd, didCacheTTLFlagName, didCacheTTLEnvKey); v != "" {
+		didCacheTTL, err = time.ParseDuration(v)
 		if err != nil {
-			return err
+			return nil, fmt.Errorf("parse %s: %w", didCacheTTLFlagName, err)
 		}
+	}
 
-		var conf *config.Config
+	didKeyGracePeriod := defaultDIDKeyGracePeriod
 
-		conf, err = configService.Get()
+	if v := cmdutils.GetUserSetOptionalVarFromString(cmd, didKeyGracePeriodFlagName, didKeyGracePeriodEnvKey); v != "" {
+		didKeyGracePeriod, err = time.ParseDuration(v)
 		if err != nil {
-			return err
+			return nil, fmt.Errorf("parse %s: %w", didKeyGracePeriodFlagName, err)
 		}
+	}
 
-		err = vcIssuer.CreateIssuerProfile(
-			context.Background(),
-			conf.DID,
-			conf.PubKeyID,
-			conf.PrivateKey,
-		)
+	anchorCheckInterval := defaultAnchorCheckInterval
 
+	if v := cmdutils.GetUserSetOptionalVarFromString(cmd, anchorCheckIntervalFlagName,
+		anchorCheckIntervalEnvKey); v != "" {
+		anchorCheckInterval, err = time.ParseDuration(v)
 		if err != nil {
-			return err
+			return nil, fmt.Errorf("parse %s: %w", anchorCheckIntervalFlagName, err)
 		}
 	}
 
-	// start server on given port and serve using given handlers
-	return srv.ListenAndServe(params.host, params.tlsParams.serveCertPath, params.tlsParams.serveKeyPath,
-		cors.New(cors.Options{
-			AllowedMethods: []string{
-				http.MethodHead,
-				http.MethodGet,
-				http.MethodPost,
-				http.MethodDelete,
-			},
-			AllowedHeaders: []string{
-				"Origin",
-				"Accept",
-				"Content-Type",
-				"X-Requested-With",
-				"Authorization",
-			},
-		}).Handler(router))
-}
+	checkpointInterval := defaultCheckpointInterval
 
-func createCSHClient(cshURL string, httpClient *http.Client) *client.ConfidentialStorageHub {
-	cshURLParts := strings.Split(cshURL, "://")
+	if v := cmdutils.GetUserSetOptionalVarFromString(cmd, checkpointIntervalFlagName,
+		checkpointIntervalEnvKey); v != "" {
+		checkpointInterval, err = time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("parse %s: %w", checkpointIntervalFlagName, err)
+		}
+	}
 
-	transport := httptransport.NewWithClient(
-		cshURLParts[1],
-		client.DefaultBasePath,
-		[]string{cshURLParts[0]},
-		httpClient,
-	)
+	healthProbeInterval := defaultHealthProbeInterval
 
-	return client.New(transport, strfmt.Default)
-}
+	if v := cmdutils.GetUserSetOptionalVarFromString(cmd, healthProbeIntervalFlagName,
+		healthProbeIntervalEnvKey); v != "" {
+		healthProbeInterval, err = time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("parse %s: %w", healthProbeIntervalFlagName, err)
+		}
+	}
 
-func getRequestTokens(cmd *cobra.Command) (map[string]string, error) {
-	requestTokens, err := cmdutils.GetUserSetVarFromArrayString(cmd, requestTokensFlagName,
-		requestTokensEnvKey, true)
-	if err != nil {
-		return nil, err
+	slowRequestThreshold := defaultSlowRequestThreshold
+
+	if v := cmdutils.GetUserSetOptionalVarFromString(cmd, slowRequestThresholdFlagName,
+		slowRequestThresholdEnvKey); v != "" {
+		slowRequestThreshold, err = time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("parse %s: %w", slowRequestThresholdFlagName, err)
+		}
 	}
 
-	tokens := make(map[string]string)
+	accessLogSampleRate := float64(defaultAccessLogSampleRate)
 
-	for _, token := range requestTokens {
-		split := strings.Split(token, "=")
-		switch len(split) {
-		case tokenLength2:
-			tokens[split[0]] = split[1]
-		default:
-			logger.Warnf("invalid token '%s'", token)
+	if v := cmdutils.GetUserSetOptionalVarFromString(cmd, accessLogSampleRateFlagName,
+		accessLogSampleRateEnvKey); v != "" {
+		accessLogSampleRate, err = strconv.ParseFloat(v, 64)
+		if err != nil {
+			return nil, fmt.Errorf("parse %s: %w", accessLogSampleRateFlagName, err)
 		}
 	}
 
-	return tokens, nil
+	errorReportDSN := cmdutils.GetUserSetOptionalVarFromString(cmd, errorReportDSNFlagName, errorReportDSNEnvKey)
+
+	requestLimits, err := getRequestLimits(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	protectWorkers, err := getInt(cmd, protectWorkersFlagName, protectWorkersEnvKey, gatekeeper.DefaultProtectWorkers)
+	if err != nil {
+		return nil, err
+	}
+
+	protectQueueSize, err := getInt(cmd, protectQueueSizeFlagName, protectQueueSizeEnvKey,
+		gatekeeper.DefaultProtectQueueSize)
+	if err != nil {
+		return nil, err
+	}
+
+	collectQueueSize, err := getInt(cmd, collectQueueSizeFlagName, collectQueueSizeEnvKey,
+		gatekeeper.DefaultCollectQueueSize)
+	if err != nil {
+		return nil, err
+	}
+
+	collectWorkers, err := getInt(cmd, collectWorkersFlagName, collectWorkersEnvKey, gatekeeper.DefaultCollectWorkers)
+	if err != nil {
+		return nil, err
+	}
+
+	logLevel := cmdutils.GetUserSetOptionalVarFromString(cmd, common.LogLevelFlagName, common.LogLevelEnvKey)
+
+	corsAllowedOrigins := cmdutils.GetUserSetOptionalVarFromArrayString(cmd, corsAllowedOriginsFlagName,
+		corsAllowedOriginsEnvKey)
+
+	authToken, err := cmdutils.GetUserSetVarFromString(cmd, authTokenFlagName,
+		authTokenEnvKey, true)
+
+	return &serviceParameters{
+		host:                  host,
+		adminHost:             adminHost,
+		unixSocket:            unixSocket,
+		grpcHost:              grpcHost,
+		tlsParams:             tlsParams,
+		dbParams:              dbParams,
+		secretsParams:         secretsParams,
+		httpClientParams:      httpClientParams,
+		blocDomain:            blocDomain,
+		didResolverURL:        didResolverURL,
+		contextProviderURLs:   contextProviderURLs,
+		vcProvider:            vcProvider,
+		vcIssuerURL:           vcIssuerURL,
+		vcIssuerProfile:       vcIssuerProfile,
+		signingKMS:            signingKMS,
+		signingKMSURL:         signingKMSURL,
+		signingKMSRegion:      signingKMSRegion,
+		vaultServerURL:        vaultServerURL,
+		vaultFailoverURLs:     vaultFailoverURLs,
+		region:                region,
+		regionVaultServerURLs: regionVaultServerURLs,
+		didAnchorOrigin:       didAnchorOrigin,
+		cshURL:                cshURL,
+		authToken:             authToken,
+		requestTokens:         requestTokens,
+		credentialSchemas:     credentialSchemas,
+		credentialSchemaFiles: credentialSchemaFiles,
+		strictJSONLD:          strictJSONLD,
+		strictJSON:            strictJSON,
+		didcommApprovals:      didcommApprovals,
+		didcommEndpoint:       didcommEndpoint,
+		featureFlags:          featureFlags,
+		features:              features,
+		oidc4vpVerifierURL:    oidc4vpVerifierURL,
+		signatureType:         signatureType,
+		keyType:               keyType,
+		cacheURL:              cacheURL,
+		cacheTTL:              cacheTTL,
+		storeEncryption:       storeEncryption,
+		kmsMasterKey:          kmsMasterKey,
+		backupKey:             backupKey,
+		deletedRetention:      deletedRetention,
+		purgeInterval:         purgeInterval,
+		leaderLeaseTTL:        leaderLeaseTTL,
+		didCacheSize:          didCacheSize,
+		didCacheTTL:           didCacheTTL,
+		didKeyGracePeriod:     didKeyGracePeriod,
+		anchorCheckInterval:   anchorCheckInterval,
+		checkpointInterval:    checkpointInterval,
+		healthProbeInterval:   healthProbeInterval,
+		slowRequestThreshold:  slowRequestThreshold,
+		accessLogSampleRate:   accessLogSampleRate,
+		errorReportDSN:        errorReportDSN,
+		requestLimits:         requestLimits,
+		protectWorkers:        protectWorkers,
+		protectQueueSize:      protectQueueSize,
+		collectQueueSize:      collectQueueSize,
+		collectWorkers:        collectWorkers,
+		logLevel:              logLevel,
+		corsAllowedOrigins:    corsAllowedOrigins,
+		h2c:                   h2c,
+		startupTimeout:        startupTimeout,
+	}, err
+}
+
+// validate checks the sanity of the parameters. Required parameters are checked when they are read.
+func (p *serviceParameters) validate() error {
+	if err := common.ValidateHostURL(hostURLFlagName, p.host); err != nil {
+		return err
+	}
+
+	if p.adminHost != "" {
+		if err := common.ValidateHostURL(adminHostURLFlagName, p.adminHost); err != nil {
+			return err
+		}
+
+		if p.adminHost == p.host {
+			return fmt.Errorf("%s and %s must be different", adminHostURLFlagName, hostURLFlagName)
+		}
+	}
+
+	if p.grpcHost != "" {
+		if err := common.ValidateHostURL(grpcHostURLFlagName, p.grpcHost); err != nil {
+			return err
+		}
+
+		if p.grpcHost == p.host || p.grpcHost == p.adminHost {
+			return fmt.Errorf("%s must be different from %s and %s", grpcHostURLFlagName, hostURLFlagName,
+				adminHostURLFlagName)
+		}
+
+		if p.authToken == "" {
+			return fmt.Errorf("%s must be set to serve the gRPC API", authTokenFlagName)
+		}
+	}
+
+	urls := []struct {
+		name string
+		urls []string
+	}{
+		{didResolverURLFlagName, []string{p.didResolverURL}},
+		{contextProviderFlagName, p.contextProviderURLs},
+		{vaultServerURLFlagName, []string{p.vaultServerURL}},
+		{vaultFailoverURLsFlagName, p.vaultFailoverURLs},
+		{regionVaultServerURLsFlagName, regionURLs(p.regionVaultServerURLs)},
+		{didAnchorOriginFlagName, []string{p.didAnchorOrigin}},
+		{cshURLFlagName, []string{p.cshURL}},
+		{vcIssuerURLFlagName, []string{p.vcIssuerURL}},
+		{signingKMSURLFlagName, []string{p.signingKMSURL}},
+		{cacheURLFlagName, []string{p.cacheURL}},
+		{didcommEndpointFlagName, []string{p.didcommEndpoint}},
+		{oidc4vpVerifierURLFlagName, []string{p.oidc4vpVerifierURL}},
+		{corsAllowedOriginsFlagName, allowedOrigins(p.corsAllowedOrigins)},
+	}
+
+	for _, u := range urls {
+		if err := common.ValidateURL(u.name, u.urls...); err != nil {
+			return err
+		}
+	}
+
+	if (p.tlsParams.serveCertPath == "") != (p.tlsParams.serveKeyPath == "") {
+		return fmt.Errorf("%s and %s must be set together", tlsServeCertPathFlagName, tlsServeKeyPathFlagName)
+	}
+
+	if p.h2c && p.tlsParams.serveCertPath != "" {
+		return fmt.Errorf("%s cannot be used with %s", common.H2CFlagName, tlsServeCertPathFlagName)
+	}
+
+	if len(p.tlsParams.adminClientCACerts) > 0 && (p.adminHost == "" || p.tlsParams.serveCertPath == "") {
+		return fmt.Errorf("%s requires %s and %s", tlsAdminClientCACertsFlagName, adminHostURLFlagName,
+			tlsServeCertPathFlagName)
+	}
+
+	if p.logLevel != "" {
+		if _, err := log.ParseLevel(p.logLevel); err != nil {
+			return fmt.Errorf("invalid %s: %w", common.LogLevelFlagName, err)
+		}
+	}
+
+	durations := []struct {
+		name  string
+		value time.Duration
+	}{
+		{cacheTTLFlagName, p.cacheTTL},
+		{deletedRetentionFlagName, p.deletedRetention},
+		{purgeIntervalFlagName, p.purgeInterval},
+		{didCacheTTLFlagName, p.didCacheTTL},
+		{didKeyGracePeriodFlagName, p.didKeyGracePeriod},
+	}
+
+	for _, d := range durations {
+		if d.value <= 0 {
+			return fmt.Errorf("%s must be positive", d.name)
+		}
+	}
+
+	intervals := []struct {
+		name  string
+		value time.Duration
+	}{
+		{leaderLeaseTTLFlagName, p.leaderLeaseTTL},
+		{anchorCheckIntervalFlagName, p.anchorCheckInterval},
+		{checkpointIntervalFlagName, p.checkpointInterval},
+		{healthProbeIntervalFlagName, p.healthProbeInterval},
+		{slowRequestThresholdFlagName, p.slowRequestThreshold},
+	}
+
+	for _, i := range intervals {
+		if i.value < 0 {
+			return fmt.Errorf("%s must not be negative", i.name)
+		}
+	}
+
+	if err := p.validateSigningKMS(); err != nil {
+		return err
+	}
+
+	if p.signatureType == vccrypto.BbsBlsSignature2020 && !p.features.Enabled(feature.BBSIssuance) {
+		return fmt.Errorf("%s %s requires the %s feature flag", signatureTypeFlagName, vccrypto.BbsBlsSignature2020,
+			feature.BBSIssuance)
+	}
+
+	if p.kmsMasterKey != "" {
+		if k, err := base64.URLEncoding.DecodeString(p.kmsMasterKey); err != nil || !validAESKeySize(len(k)) {
+			return fmt.Errorf("%s must be a base64url-encoded AES key of 16, 24 or 32 bytes", kmsMasterKeyFlagName)
+		}
+	}
+
+	if p.storeEncryption && p.kmsMasterKey == "" {
+		return fmt.Errorf("%s requires %s", storeEncryptionFlagName, kmsMasterKeyFlagName)
+	}
+
+	if p.backupKey != "" {
+		if k, err := base64.URLEncoding.DecodeString(p.backupKey); err != nil || len(k) != backup.KeySize {
+			return fmt.Errorf("%s must be a base64url-encoded AES key of 32 bytes", backupKeyFlagName)
+		}
+	}
+
+	if p.accessLogSampleRate < 0 || p.accessLogSampleRate > 1 {
+		return fmt.Errorf("%s must be between 0 and 1", accessLogSampleRateFlagName)
+	}
+
+	if p.errorReportDSN != "" {
+		if err := errorreport.ValidateDSN(p.errorReportDSN); err != nil {
+			return fmt.Errorf("%s: %w", errorReportDSNFlagName, err)
+		}
+	}
+
+	if p.didCacheSize < 0 {
+		return fmt.Errorf("%s must not be negative", didCacheSizeFlagName)
+	}
+
+	if p.requestLimits.MaxSize <= 0 || p.requestLimits.MaxDepth <= 0 || p.requestLimits.MaxStringLength <= 0 {
+		return fmt.Errorf("%s, %s and %s must be positive", maxRequestSizeFlagName, maxJSONDepthFlagName,
+			maxJSONStringLengthFlagName)
+	}
+
+	if p.protectWorkers < 0 {
+		return fmt.Errorf("%s must not be negative", protectWorkersFlagName)
+	}
+
+	if p.protectQueueSize < 0 {
+		return fmt.Errorf("%s must not be negative", protectQueueSizeFlagName)
+	}
+
+	if p.collectQueueSize < 0 {
+		return fmt.Errorf("%s must not be negative", collectQueueSizeFlagName)
+	}
+
+	if p.collectWorkers <= 0 {
+		return fmt.Errorf("%s must be positive", collectWorkersFlagName)
+	}
+
+	return nil
+}
+
+// validateSigningKMS checks that the signing KMS can hold the gatekeeper's DID key for the VC provider.
+func (p *serviceParameters) validateSigningKMS() error {
+	switch p.signingKMS {
+	case localSigningKMS:
+		return nil
+	case awsSigningKMS:
+		if p.keyType != vccrypto.P256KeyType {
+			return fmt.Errorf("%s %s requires %s %s", signingKMSFlagName, p.signingKMS, keyTypeFlagName,
+				vccrypto.P256KeyType)
+		}
+	case webSigningKMS:
+		if p.signingKMSURL == "" {
+			return fmt.Errorf("%s must be set with %s %s", signingKMSURLFlagName, signingKMSFlagName, p.signingKMS)
+		}
+	default:
+		return fmt.Errorf("invalid %s: %s is not one of %s, %s or %s", signingKMSFlagName, p.signingKMS,
+			localSigningKMS, awsSigningKMS, webSigningKMS)
+	}
+
+	// the private key of a remote KMS cannot be exported to the VCS issuer
+	if p.vcProvider != vcprovider.KMS {
+		return fmt.Errorf("%s %s requires %s %s", signingKMSFlagName, p.signingKMS, vcProviderFlagName,
+			vcprovider.KMS)
+	}
+
+	return nil
+}
+
+// settings returns the parameters keyed by their environment variables, with secrets redacted.
+func (p *serviceParameters) settings() map[string]interface{} {
+	settings := map[string]interface{}{
+		hostURLEnvKey:                p.host,
+		adminHostURLEnvKey:           p.adminHost,
+		unixSocketEnvKey:             p.unixSocket,
+		grpcHostURLEnvKey:            p.grpcHost,
+		tlsSystemCertPoolEnvKey:      p.tlsParams.systemCertPool,
+		tlsCACertsEnvKey:             p.tlsParams.caCerts,
+		tlsServeCertPathEnvKey:       p.tlsParams.serveCertPath,
+		tlsServeKeyPathFlagEnvKey:    p.tlsParams.serveKeyPath,
+		tlsAdminClientCACertsEnvKey:  p.tlsParams.adminClientCACerts,
+		blocDomainEnvKey:             p.blocDomain,
+		didResolverURLEnvKey:         p.didResolverURL,
+		contextProviderEnvKey:        p.contextProviderURLs,
+		vcProviderEnvKey:             p.vcProvider,
+		vcIssuerURLEnvKey:            p.vcIssuerURL,
+		vcIssuerProfileEnvKey:        p.vcIssuerProfile,
+		signingKMSEnvKey:             p.signingKMS,
+		signingKMSURLEnvKey:          p.signingKMSURL,
+		signingKMSRegionEnvKey:       p.signingKMSRegion,
+		vaultServerURLEnvKey:         p.vaultServerURL,
+		vaultFailoverURLsEnvKey:      p.vaultFailoverURLs,
+		regionEnvKey:                 p.region,
+		regionVaultServerURLsEnvKey:  p.regionVaultServerURLs,
+		didAnchorOriginEnvKey:        p.didAnchorOrigin,
+		cshURLEnvKey:                 p.cshURL,
+		authTokenEnvKey:              common.RedactSecret(p.authToken),
+		common.StartupTimeoutEnvKey:  p.startupTimeout.String(),
+		common.H2CEnvKey:             p.h2c,
+		requestTokensEnvKey:          common.RedactTokens(p.requestTokens),
+		credentialSchemaEnvKey:       p.credentialSchemaFiles,
+		credentialStrictJSONLDEnvKey: p.strictJSONLD,
+		strictJSONEnvKey:             p.strictJSON,
+		didcommApprovalsEnvKey:       p.didcommApprovals,
+		didcommEndpointEnvKey:        p.didcommEndpoint,
+		featureFlagsEnvKey:           p.featureFlags,
+		oidc4vpVerifierURLEnvKey:     common.RedactURL(p.oidc4vpVerifierURL),
+		signatureTypeEnvKey:          p.signatureType,
+		keyTypeEnvKey:                p.keyType,
+		cacheURLEnvKey:               common.RedactURL(p.cacheURL),
+		cacheTTLEnvKey:               p.cacheTTL.String(),
+		storeEncryptionEnvKey:        p.storeEncryption,
+		kmsMasterKeyEnvKey:           common.RedactSecret(p.kmsMasterKey),
+		backupKeyEnvKey:              common.RedactSecret(p.backupKey),
+		deletedRetentionEnvKey:       p.deletedRetention.String(),
+		purgeIntervalEnvKey:          p.purgeInterval.String(),
+		leaderLeaseTTLEnvKey:         p.leaderLeaseTTL.String(),
+		didCacheSizeEnvKey:           p.didCacheSize,
+		didCacheTTLEnvKey:            p.didCacheTTL.String(),
+		didKeyGracePeriodEnvKey:      p.didKeyGracePeriod.String(),
+		anchorCheckIntervalEnvKey:    p.anchorCheckInterval.String(),
+		checkpointIntervalEnvKey:     p.checkpointInterval.String(),
+		healthProbeIntervalEnvKey:    p.healthProbeInterval.String(),
+		slowRequestThresholdEnvKey:   p.slowRequestThreshold.String(),
+		accessLogSampleRateEnvKey:    p.accessLogSampleRate,
+		errorReportDSNEnvKey:         common.RedactSecret(p.errorReportDSN),
+		maxRequestSizeEnvKey:         p.requestLimits.MaxSize,
+		maxJSONDepthEnvKey:           p.requestLimits.MaxDepth,
+		maxJSONStringLengthEnvKey:    p.requestLimits.MaxStringLength,
+		protectWorkersEnvKey:         p.protectWorkers,
+		protectQueueSizeEnvKey:       p.protectQueueSize,
+		collectQueueSizeEnvKey:       p.collectQueueSize,
+		collectWorkersEnvKey:         p.collectWorkers,
+		common.LogLevelEnvKey:        p.logLevel,
+		corsAllowedOriginsEnvKey:     p.corsAllowedOrigins,
+	}
+
+	for k, v := range p.dbParams.Settings() {
+		settings[k] = v
+	}
+
+	for k, v := range p.httpClientParams.Settings() {
+		settings[k] = v
+	}
+
+	for k, v := range p.secretsParams.Settings() {
+		settings[k] = v
+	}
+
+	return settings
+}
+
+// regionURLs returns the URLs of the vault servers of all the regions.
+func regionURLs(urls map[string][]string) []string {
+	var values []string
+
+	for _, u := range urls {
+		values = append(values, u...)
+	}
+
+	return values
+}
+
+// allowedOrigins returns the allowed origins that are URLs, leaving out the wildcard allowing all origins.
+func allowedOrigins(origins []string) []string {
+	var urls []string
+
+	for _, o := range origins {
+		if o != "*" {
+			urls = append(urls, o)
+		}
+	}
+
+	return urls
+}
+
+func createFlags(cmd *cobra.Command) {
+	cmd.Flags().StringP(hostURLFlagName, hostURLFlagShorthand, "", hostURLFlagUsage)
+	cmd.Flags().StringP(adminHostURLFlagName, "", "", adminHostURLFlagUsage)
+	cmd.Flags().StringP(unixSocketFlagName, "", "", unixSocketFlagUsage)
+	cmd.Flags().StringP(grpcHostURLFlagName, "", "", grpcHostURLFlagUsage)
+	cmd.Flags().StringP(tlsSystemCertPoolFlagName, "", "", tlsSystemCertPoolFlagUsage)
+	cmd.Flags().StringArrayP(tlsCACertsFlagName, "", []string{}, tlsCACertsFlagUsage)
+	cmd.Flags().StringP(tlsServeCertPathFlagName, "", "", tlsServeCertPathFlagUsage)
+	cmd.Flags().StringP(tlsServeKeyPathFlagName, "", "", tlsServeKeyPathFlagUsage)
+	cmd.Flags().StringArrayP(tlsAdminClientCACertsFlagName, "", []string{}, tlsAdminClientCACertsFlagUsage)
+	cmd.Flags().StringP(blocDomainFlagName, "", "", blocDomainFlagUsage)
+	cmd.Flags().StringP(didResolverURLFlagName, "", "", didResolverURLFlagUsage)
+	cmd.Flags().StringArrayP(contextProviderFlagName, "", []string{}, contextProviderFlagUsage)
+	cmd.Flags().StringP(vaultServerURLFlagName, "", "", vaultServerURLFlagUsage)
+	cmd.Flags().StringArrayP(vaultFailoverURLsFlagName, "", []string{}, vaultFailoverURLsFlagUsage)
+	cmd.Flags().StringP(regionFlagName, "", "", regionFlagUsage)
+	cmd.Flags().StringArrayP(regionVaultServerURLsFlagName, "", []string{}, regionVaultServerURLsFlagUsage)
+	cmd.Flags().StringP(didAnchorOriginFlagName, "", "", didAnchorOriginFlagUsage)
+	cmd.Flags().StringP(cshURLFlagName, "", "", cshURLFlagUsage)
+	cmd.Flags().StringP(vcProviderFlagName, "", "", vcProviderFlagUsage)
+	cmd.Flags().StringP(vcIssuerURLFlagName, "", "", vcIssuerURLFlagUsage)
+	cmd.Flags().StringP(signingKMSFlagName, "", "", signingKMSFlagUsage)
+	cmd.Flags().StringP(signingKMSURLFlagName, "", "", signingKMSURLFlagUsage)
+	cmd.Flags().StringP(signingKMSRegionFlagName, "", "", signingKMSRegionFlagUsage)
+	cmd.Flags().StringP(vcIssuerProfileFlagName, "", "", vcIssuerProfileFlagUsage)
+	cmd.Flags().StringP(signatureTypeFlagName, "", "", signatureTypeFlagUsage)
+	cmd.Flags().StringP(keyTypeFlagName, "", "", keyTypeFlagUsage)
+	cmd.Flags().StringArrayP(credentialSchemaFlagName, "", []string{}, credentialSchemaFlagUsage)
+	cmd.Flags().StringP(credentialStrictJSONLDFlagName, "", "", credentialStrictJSONLDFlagUsage)
+	cmd.Flags().StringP(strictJSONFlagName, "", "", strictJSONFlagUsage)
+	cmd.Flags().StringP(didcommApprovalsFlagName, "", "", didcommApprovalsFlagUsage)
+	cmd.Flags().StringP(didcommEndpointFlagName, "", "", didcommEndpointFlagUsage)
+	cmd.Flags().StringP(featureFlagsFlagName, "", "", featureFlagsFlagUsage)
+	cmd.Flags().StringP(oidc4vpVerifierURLFlagName, "", "", oidc4vpVerifierURLFlagUsage)
+	cmd.Flags().StringArrayP(requestTokensFlagName, "", []string{}, requestTokensFlagUsage)
+	cmd.Flags().StringP(authTokenFlagName, "", "", authTokenFlagUsage)
+	cmd.Flags().StringP(cacheURLFlagName, "", "", cacheURLFlagUsage)
+	cmd.Flags().StringP(cacheTTLFlagName, "", "", cacheTTLFlagUsage)
+	cmd.Flags().StringP(storeEncryptionFlagName, "", "", storeEncryptionFlagUsage)
+	cmd.Flags().StringP(kmsMasterKeyFlagName, "", "", kmsMasterKeyFlagUsage)
+	cmd.Flags().StringP(backupKeyFlagName, "", "", backupKeyFlagUsage)
+	cmd.Flags().StringP(deletedRetentionFlagName, "", "", deletedRetentionFlagUsage)
+	cmd.Flags().StringP(purgeIntervalFlagName, "", "", purgeIntervalFlagUsage)
+	cmd.Flags().StringP(leaderLeaseTTLFlagName, "", "", leaderLeaseTTLFlagUsage)
+	cmd.Flags().StringP(didCacheSizeFlagName, "", "", didCacheSizeFlagUsage)
+	cmd.Flags().StringP(didCacheTTLFlagName, "", "", didCacheTTLFlagUsage)
+	cmd.Flags().StringP(didKeyGracePeriodFlagName, "", "", didKeyGracePeriodFlagUsage)
+	cmd.Flags().StringP(anchorCheckIntervalFlagName, "", "", anchorCheckIntervalFlagUsage)
+	cmd.Flags().StringP(checkpointIntervalFlagName, "", "", checkpointIntervalFlagUsage)
+	cmd.Flags().StringP(healthProbeIntervalFlagName, "", "", healthProbeIntervalFlagUsage)
+	cmd.Flags().StringP(slowRequestThresholdFlagName, "", "", slowRequestThresholdFlagUsage)
+	cmd.Flags().StringP(accessLogSampleRateFlagName, "", "", accessLogSampleRateFlagUsage)
+	cmd.Flags().StringP(errorReportDSNFlagName, "", "", errorReportDSNFlagUsage)
+	cmd.Flags().StringP(maxRequestSizeFlagName, "", "", maxRequestSizeFlagUsage)
+	cmd.Flags().StringP(maxJSONDepthFlagName, "", "", maxJSONDepthFlagUsage)
+	cmd.Flags().StringP(maxJSONStringLengthFlagName, "", "", maxJSONStringLengthFlagUsage)
+	cmd.Flags().StringP(protectWorkersFlagName, "", "", protectWorkersFlagUsage)
+	cmd.Flags().StringP(protectQueueSizeFlagName, "", "", protectQueueSizeFlagUsage)
+	cmd.Flags().StringP(collectQueueSizeFlagName, "", "", collectQueueSizeFlagUsage)
+	cmd.Flags().StringP(collectWorkersFlagName, "", "", collectWorkersFlagUsage)
+	cmd.Flags().StringP(common.LogLevelFlagName, common.LogLevelFlagShorthand, "", common.LogLevelPrefixFlagUsage)
+	cmd.Flags().StringArrayP(corsAllowedOriginsFlagName, "", []string{}, corsAllowedOriginsFlagUsage)
+
+	common.Flags(cmd)
+	common.HTTPClientFlags(cmd)
+	common.SecretsFlags(cmd)
+	common.ConfigFileFlag(cmd)
+	common.H2CFlag(cmd)
+	common.StartupTimeoutFlag(cmd)
+}
+
+func startService(params *serviceParameters, srv server, //nolint: funlen,gocyclo
+	load func() (*serviceParameters, error),
+) error {
+	if err := setLogLevel(params.logLevel); err != nil {
+		return err
+	}
+
+	rootCAs, err := tlsutils.GetCertPool(params.tlsParams.systemCertPool, params.tlsParams.caCerts)
+	if err != nil {
+		return err
+	}
+
+	tlsConfig := &tls.Config{RootCAs: rootCAs, MinVersion: tls.VersionTLS12}
+
+	storeProvider, err := common.InitStore(params.dbParams, logger)
+	if err != nil {
+		return err
+	}
+
+	if params.cacheURL != "" {
+		storeProvider, err = cacheStores(storeProvider, params)
+		if err != nil {
+			return err
+		}
+	}
+
+	router := mux.NewRouter()
+
+	// add health check endpoint
+	addHealthCheck(router)
+
+	httpClient := common.NewHTTPClient(params.httpClientParams, tlsConfig)
+	httpClient.Transport = requestid.Transport(httpClient.Transport)
+
+	if params.slowRequestThreshold > 0 {
+		httpClient.Transport = slowrequest.Transport(httpClient.Transport)
+	}
+
+	err = common.WaitForURLs(httpClient, map[string]string{
+		"vault server": params.vaultServerURL,
+		"DID resolver": params.didResolverURL,
+	}, params.startupTimeout, logger)
+	if err != nil {
+		return err
+	}
+
+	router.Use(requestid.New())
+
+	if params.accessLogSampleRate > 0 {
+		router.Use(accesslog.New(&accesslog.Config{SampleRate: params.accessLogSampleRate}))
+	}
+
+	var reporter *errorreport.Reporter
+
+	if params.errorReportDSN != "" {
+		reporter, err = errorreport.New(params.errorReportDSN, httpClient)
+		if err != nil {
+			return fmt.Errorf("create error reporter: %w", err)
+		}
+
+		defer reporter.Close()
+
+		router.Use(reporter.Middleware)
+	}
+
+	if params.slowRequestThreshold > 0 {
+		router.Use(slowrequest.New(&slowrequest.Config{Threshold: params.slowRequestThreshold}))
+	}
+
+	updateKeys := config.NewUpdateKeys()
+
+	vdr, err := createVDR(params.didResolverURL, params.blocDomain, params.requestTokens[sidetreeRequestTokenName],
+		httpClient, updateKeys)
+	if err != nil {
+		return err
+	}
+
+	if params.didCacheSize > 0 {
+		vdr = didcache.New(vdr, didcache.WithSize(params.didCacheSize), didcache.WithTTL(params.didCacheTTL))
+	}
+
+	ldStore, err := common.CreateLDStoreProvider(storeProvider)
+	if err != nil {
+		return err
+	}
+
+	documentLoader, err := common.CreateJSONLDDocumentLoader(ldStore, httpClient, params.contextProviderURLs)
+	if err != nil {
+		return err
+	}
+
+	vClient := newVaultClient(append([]string{params.vaultServerURL}, params.vaultFailoverURLs...), httpClient)
+
+	regionVaultClients := make(map[string]vaultclient.Vault)
+
+	for region, urls := range params.regionVaultServerURLs {
+		regionVaultClients[region] = newVaultClient(urls, httpClient)
+	}
+
+	cshClient := createCSHClient(params.cshURL, httpClient).Operations
+
+	secretLock, err := createSecretLock(params.kmsMasterKey)
+	if err != nil {
+		return err
+	}
+
+	keyManager, err := localkms.New(keystorePrimaryKeyURI, &kmsProvider{
+		storageProvider: storeProvider,
+		secretLock:      secretLock,
+	})
+	if err != nil {
+		return err
+	}
+
+	if params.storeEncryption {
+		storeProvider, err = encryptStores(storeProvider, keyManager)
+		if err != nil {
+			return err
+		}
+	}
+
+	localCrypto, err := tinkcrypto.New()
+	if err != nil {
+		return fmt.Errorf("create crypto: %w", err)
+	}
+
+	signingKMS, signingCrypto := createSigningKMS(params, keyManager, localCrypto, httpClient)
+
+	configParams := &config.ServiceParams{
+		StoreProvider:   storeProvider,
+		CSHClient:       cshClient,
+		VDR:             vdr,
+		KeyManager:      keyManager,
+		DidMethod:       orb.DIDMethod,
+		DidAnchorOrigin: params.didAnchorOrigin,
+		KeyType:         params.keyType,
+		Crypto:          localCrypto,
+		UpdateKeys:      updateKeys,
+	}
+
+	// the vcs provider needs the private key of the DID
+	if params.vcProvider == vcprovider.KMS {
+		configParams.SigningKeyManager = signingKMS
+	}
+
+	configService, err := config.NewService(configParams)
+	if err != nil {
+		return err
+	}
+
+	vcProvider, err := vcprovider.New(&vcprovider.Config{
+		Type: params.vcProvider,
+		VCS: &vcs.Config{
+			URL:            params.vcIssuerURL,
+			AuthToken:      params.requestTokens[vcsIssuerRequestTokenName],
+			ProfileName:    params.vcIssuerProfile,
+			DocumentLoader: documentLoader,
+			HTTPClient:     httpClient,
+			SignatureType:  params.signatureType,
+		},
+		KMS: &vckms.Config{
+			KeyManager:     signingKMS,
+			Crypto:         signingCrypto,
+			VDR:            vdr,
+			ConfigService:  configService,
+			DocumentLoader: documentLoader,
+			SignatureType:  params.signatureType,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	var leader *lease.Lease
+
+	if params.leaderLeaseTTL > 0 {
+		leader, err = lease.New(storeProvider, stores.SchedulerLease, lease.WithTTL(params.leaderLeaseTTL))
+		if err != nil {
+			return err
+		}
+
+		leader.Start()
+		defer leader.Stop()
+	}
+
+	purger, err := tombstone.NewPurger(storeProvider, params.deletedRetention, params.purgeInterval,
+		"policy", "protected_data")
+	if err != nil {
+		return err
+	}
+
+	if leader != nil {
+		purger.SetLeader(leader)
+	}
+
+	purger.Start()
+	defer purger.Stop()
+
+	metrics := newMetricsRegistry()
+
+	if params.didcommApprovals && !params.features.Enabled(feature.DIDComm) {
+		logger.Warnf("%s is set, but DIDComm is not served until the %s feature flag is enabled",
+			didcommApprovalsFlagName, feature.DIDComm)
+	}
+
+	service, err := gatekeeper.New(&gatekeeper.Config{
+		StorageProvider:        storeProvider,
+		VaultClient:            vClient,
+		ConfigService:          configService,
+		VDR:                    vdr,
+		VCProvider:             vcProvider,
+		ConfidentialStorageHub: cshClient,
+		DocumentLoader:         documentLoader,
+		CredentialSchemas:      params.credentialSchemas,
+		StrictJSONLD:           params.strictJSONLD,
+		HTTPClient:             httpClient,
+		KeyManager:             keyManager,
+		Purger:                 purger,
+		RequestLimits:          params.requestLimits,
+		ProtectWorkers:         params.protectWorkers,
+		ProtectQueueSize:       params.protectQueueSize,
+		CollectQueueSize:       params.collectQueueSize,
+		CollectWorkers:         params.collectWorkers,
+		MetricsRegisterer:      metrics,
+		AnchorCheckInterval:    params.anchorCheckInterval,
+		CheckpointInterval:     params.checkpointInterval,
+		Leader:                 leader,
+		StrictJSON:             params.strictJSON,
+		DIDCommApprovals:       params.didcommApprovals,
+		DIDCommEndpoint:        params.didcommEndpoint,
+		OIDC4VPVerifierURL:     params.oidc4vpVerifierURL,
+		OIDC4VPAuthToken:       params.requestTokens[oidc4vpRequestTokenName],
+		Region:                 params.region,
+		RegionVaultClients:     regionVaultClients,
+		Features:               params.features,
+	})
+	if err != nil {
+		return err
+	}
+
+	defer service.Close()
+
+	httpSigMW := httpsigmw.New(&httpsigmw.Config{
+		VDR: vdr,
+	})
+
+	tokenAuthMW := tokenauth.New(params.authToken)
+
+	for _, operation := range service.GetOperations() {
+		var h http.Handler
+		h = operation.Handle()
+
+		if operation.Auth() == handler.AuthHTTPSig {
+			h = httpSigMW.Middleware(h)
+		}
+
+		if operation.Auth() == handler.AuthToken && params.authToken != "" {
+			h = tokenAuthMW.Middleware(h)
+		}
+
+		router.Handle(operation.Path(), h).Methods(operation.Method())
+	}
+
+	hasConfig, err := configService.HasConfig()
+	if err != nil {
+		return err
+	}
+
+	if !hasConfig {
+		err = configService.CreateConfig()
+		if err != nil {
+			return err
+		}
+
+		var conf *config.Config
+
+		conf, err = configService.Get()
+		if err != nil {
+			return err
+		}
+
+		err = vcProvider.CreateIssuerProfile(
+			context.Background(),
+			conf.DID,
+			conf.PubKeyID,
+			conf.KeyType,
+			conf.PrivateKey,
+		)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	corsHandler := newCORSHandler(router, params.corsAllowedOrigins)
+
+	r := &reloader{load: load, cors: corsHandler}
+
+	adminAuth := func(h http.Handler) http.Handler {
+		if params.authToken != "" {
+			return tokenAuthMW.Middleware(h)
+		}
+
+		return h
+	}
+
+	var reload http.Handler
+
+	// without an api token to protect the reload endpoint, the configuration is only reloaded with SIGHUP
+	if params.authToken != "" {
+		reload = http.HandlerFunc(r.reloadHandler)
+	} else {
+		logger.Warnf("%s is not served: no api token is set", reloadEndpoint)
+	}
+
+	rotator := &keyRotator{config: configService, gracePeriod: params.didKeyGracePeriod, leader: leader}
+
+	health := newHealthMonitor(healthProbes(params, httpClient, storeProvider))
+
+	var backups *backupHandler
+
+	if params.backupKey != "" {
+		backups, err = newBackupHandler(storeProvider, params.backupKey)
+		if err != nil {
+			return err
+		}
+	}
+
+	var adminRouter *mux.Router
+
+	// admin endpoints are only served on the public API if there is no admin listener
+	if params.adminHost == "" {
+		if reload != nil {
+			router.Handle(reloadEndpoint, adminAuth(reload)).Methods(http.MethodPost)
+		}
+
+		router.Handle(rotateKeyEndpoint, adminAuth(http.HandlerFunc(rotator.rotateHandler))).Methods(http.MethodPost)
+		router.Handle(metricsEndpoint, adminAuth(metricsHandler(metrics))).Methods(http.MethodGet)
+		router.Handle(healthHistoryEndpoint, adminAuth(http.HandlerFunc(health.historyHandler))).Methods(http.MethodGet)
+
+		if backups != nil {
+			addBackup(router, backups, adminAuth)
+		}
+
+		addFeatures(router, params.features, adminAuth)
+	} else {
+		adminRouter = newAdminRouter(reload, http.HandlerFunc(rotator.rotateHandler),
+			metricsHandler(metrics), http.HandlerFunc(health.historyHandler), adminAuth, params.authToken != "")
+
+		if params.authToken == "" {
+			logger.Warnf("%s is not served: no api token is set", pprofPathPrefix)
+		}
+
+		if backups != nil {
+			addBackup(adminRouter, backups, adminAuth)
+		}
+
+		addFeatures(adminRouter, params.features, adminAuth)
+
+		adminRouter.Use(requestid.New())
+
+		if reporter != nil {
+			adminRouter.Use(reporter.Middleware)
+		}
+	}
+
+	if params.healthProbeInterval > 0 {
+		probes := time.NewTicker(params.healthProbeInterval)
+		probesDone := make(chan struct{})
+
+		defer func() {
+			probes.Stop()
+			close(probesDone)
+		}()
+
+		go health.run(probes.C, probesDone)
+	}
+
+	retire := time.NewTicker(keyRetirementInterval)
+	retireDone := make(chan struct{})
+
+	defer func() {
+		retire.Stop()
+		close(retireDone)
+	}()
+
+	go rotator.retire(retire.C, retireDone)
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+
+	defer func() {
+		signal.Stop(signals)
+		close(signals)
+	}()
+
+	go r.watch(signals)
+
+	if params.secretsParams.RefreshInterval > 0 {
+		refresh := time.NewTicker(params.secretsParams.RefreshInterval)
+		done := make(chan struct{})
+
+		defer func() {
+			refresh.Stop()
+			close(done)
+		}()
+
+		go r.refresh(refresh.C, done)
+	}
+
+	var public http.Handler = corsHandler
+
+	if params.h2c {
+		public = common.H2CHandler(public)
+	}
+
+	var grpcHandler http.Handler
+
+	if params.grpcHost != "" {
+		grpcHandler = grpcgatekeeper.NewGRPCServer(service.Operation(), params.authToken, vdr)
+
+		// gRPC requires HTTP/2, which is only served over TLS otherwise
+		if params.tlsParams.serveCertPath == "" {
+			grpcHandler = common.H2CHandler(grpcHandler)
+		}
+	}
+
+	// start server on given port and serve using given handlers
+	return serve(srv, params, public, adminRouter, grpcHandler)
+}
+
+func createCSHClient(cshURL string, httpClient *http.Client) *client.ConfidentialStorageHub {
+	cshURLParts := strings.Split(cshURL, "://")
+
+	transport := httptransport.NewWithClient(
+		cshURLParts[1],
+		client.DefaultBasePath,
+		[]string{cshURLParts[0]},
+		httpClient,
+	)
+
+	return client.New(transport, strfmt.Default)
+}
+
+// getInt returns the integer value of the flag, or defaultValue if it is not set.
+func getInt(cmd *cobra.Command, flagName, envKey string, defaultValue int) (int, error) {
+	v := cmdutils.GetUserSetOptionalVarFromString(cmd, flagName, envKey)
+	if v == "" {
+		return defaultValue, nil
+	}
+
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, fmt.Errorf("parse %s: %w", flagName, err)
+	}
+
+	return n, nil
+}
+
+// getRequestLimits returns the limits of the JSON request bodies, defaulting to the ones of model.NewDecoder.
+func getRequestLimits(cmd *cobra.Command) (model.Limits, error) {
+	maxSize, err := getInt(cmd, maxRequestSizeFlagName, maxRequestSizeEnvKey, model.DefaultMaxJSONSize)
+	if err != nil {
+		return model.Limits{}, err
+	}
+
+	maxDepth, err := getInt(cmd, maxJSONDepthFlagName, maxJSONDepthEnvKey, model.DefaultMaxJSONDepth)
+	if err != nil {
+		return model.Limits{}, err
+	}
+
+	maxStringLength, err := getInt(cmd, maxJSONStringLengthFlagName, maxJSONStringLengthEnvKey,
+		model.DefaultMaxJSONStringLength)
+	if err != nil {
+		return model.Limits{}, err
+	}
+
+	return model.Limits{MaxSize: int64(maxSize), MaxDepth: maxDepth, MaxStringLength: maxStringLength}, nil
+}
+
+func getSignatureSuite(cmd *cobra.Command) (string, string, error) {
+	signatureType := cmdutils.GetUserSetOptionalVarFromString(cmd, signatureTypeFlagName, signatureTypeEnvKey)
+	if signatureType == "" {
+		signatureType = vccrypto.Ed25519Signature2018
+	}
+
+	keyType := cmdutils.GetUserSetOptionalVarFromString(cmd, keyTypeFlagName, keyTypeEnvKey)
+	if keyType == "" {
+		var err error
+
+		keyType, err = vccrypto.DefaultKeyType(signatureType)
+		if err != nil {
+			return "", "", err
+		}
+	}
+
+	if err := vccrypto.ValidateSignatureSuite(signatureType, keyType); err != nil {
+		return "", "", err
+	}
+
+	return signatureType, keyType, nil
+}
+
+func getCredentialSchemas(values []string) ([]*schema.Schema, error) {
+	schemas := make([]*schema.Schema, 0, len(values))
+
+	for _, v := range values {
+		var credentialType string
+
+		path := v
+
+		if i := strings.Index(v, "="); i > 0 {
+			credentialType, path = v[:i], v[i+1:]
+		}
+
+		doc, err := os.ReadFile(filepath.Clean(path))
+		if err != nil {
+			return nil, fmt.Errorf("read credential schema: %w", err)
+		}
+
+		var meta struct {
+			ID string `json:"$id"`
+		}
+
+		if err = json.Unmarshal(doc, &meta); err != nil {
+			return nil, fmt.Errorf("unmarshal credential schema %s: %w", path, err)
+		}
+
+		if meta.ID == "" {
+			return nil, fmt.Errorf("missing $id in credential schema %s", path)
+		}
+
+		schemas = append(schemas, &schema.Schema{
+			ID:             meta.ID,
+			CredentialType: credentialType,
+			Document:       doc,
+		})
+	}
+
+	return schemas, nil
+}
+
+func getRequestTokens(cmd *cobra.Command) (map[string]string, error) {
+	requestTokens, err := cmdutils.GetUserSetVarFromArrayString(cmd, requestTokensFlagName,
+		requestTokensEnvKey, true)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens := make(map[string]string)
+
+	for _, token := range requestTokens {
+		split := strings.Split(token, "=")
+		switch len(split) {
+		case tokenLength2:
+			tokens[split[0]] = split[1]
+		default:
+			logger.Warnf("invalid token '%s'", token)
+		}
+	}
+
+	return tokens, nil
+}
+
+// getRegionVaultServerURLs returns the URLs of the vault servers of the residency regions, keyed by region, in the
+// order they are failed over.
+func getRegionVaultServerURLs(cmd *cobra.Command) (map[string][]string, error) {
+	values := cmdutils.GetUserSetOptionalVarFromArrayString(cmd, regionVaultServerURLsFlagName,
+		regionVaultServerURLsEnvKey)
+
+	urls := make(map[string][]string)
+
+	for _, v := range values {
+		split := strings.SplitN(v, "=", tokenLength2)
+		if len(split) != tokenLength2 || split[0] == "" {
+			return nil, fmt.Errorf("%s must be set as region=url: %q", regionVaultServerURLsFlagName, v)
+		}
+
+		urls[split[0]] = append(urls[split[0]], split[1])
+	}
+
+	return urls, nil
+}
+
+// newVaultClient returns the client of the vault server at the first URL, failing over to the next ones. Each
+// instance is skipped for a while after failed requests if there is another one to fail over to.
+func newVaultClient(urls []string, httpClient *http.Client) *vaultclient.Client {
+	opts := []vaultclient.Option{vaultclient.WithHTTPClient(httpClient), vaultclient.WithFailoverURLs(urls[1:]...)}
+
+	if len(urls) > 1 {
+		opts = append(opts, vaultclient.WithCircuitBreaker(vaultFailureThreshold, vaultCooldown))
+	}
+
+	return vaultclient.New(urls[0], opts...)
+}
+
+// cacheStores fronts the policy and ticket stores with the Redis cache, they are read on every release request.
+func cacheStores(storeProvider storage.Provider, params *serviceParameters) (storage.Provider, error) {
+	redisClient, err := redis.New(params.cacheURL)
+	if err != nil {
+		return nil, fmt.Errorf("create cache client: %w", err)
+	}
+
+	return cache.NewProvider(storeProvider, redisClient,
+		cache.WithTTL(params.cacheTTL),
+		cache.WithStores("policy", "ticket"),
+		cache.WithKeyPrefix(params.dbParams.Prefix),
+	), nil
+}
+
+// createSigningKMS returns the KMS the gatekeeper's DID key is created in by the kms provider, with its crypto.
+func createSigningKMS(params *serviceParameters, localKMS kms.KeyManager, //nolint:ireturn
+	localCrypto ariescrypto.Crypto, httpClient *http.Client) (kms.KeyManager, ariescrypto.Crypto) {
+	switch params.signingKMS {
+	case awsSigningKMS:
+		s := awskms.New(&awskms.Config{
+			Region:          params.signingKMSRegion,
+			AccessKeyID:     os.Getenv(common.AWSAccessKeyIDEnvKey),
+			SecretAccessKey: os.Getenv(common.AWSSecretAccessKeyEnvKey),
+			SessionToken:    os.Getenv(common.AWSSessionTokenEnvKey),
+			Endpoint:        params.signingKMSURL,
+			HTTPClient:      httpClient,
+		})
+
+		return s, s
+	case webSigningKMS:
+		var opts []webkms.Opt
+
+		if token := params.requestTokens[webKMSRequestTokenName]; token != "" {
+			opts = append(opts, webkms.WithHeaders(func(req *http.Request) (*http.Header, error) {
+				h := req.Header.Clone()
+				h.Set("Authorization", "Bearer "+token)
+
+				return &h, nil
+			}))
+		}
+
+		return webkms.New(params.signingKMSURL, httpClient, opts...),
+			webcrypto.New(params.signingKMSURL, httpClient, opts...)
+	default:
+		return localKMS, localCrypto
+	}
+}
+
+// createSecretLock returns the lock of the keys stored by the KMS: a lock with the master key, or no lock if it is not
+// set.
+func createSecretLock(masterKey string) (secretlock.Service, error) { //nolint:ireturn
+	if masterKey == "" {
+		return &noop.NoLock{}, nil
+	}
+
+	l, err := local.NewService(strings.NewReader(masterKey), nil)
+	if err != nil {
+		return nil, fmt.Errorf("create secret lock: %w", err)
+	}
+
+	return l, nil
+}
+
+func validAESKeySize(n int) bool {
+	return n == aes128KeySize || n == aes192KeySize || n == aes256KeySize
+}
+
+// encryptStores encrypts the protected data and ticket stores at rest, as well as deleted protected data. It wraps the
+// cache, so cached values are encrypted as well.
+func encryptStores(storeProvider storage.Provider, keyManager kms.KeyManager) (storage.Provider, error) {
+	crypto, err := tinkcrypto.New()
+	if err != nil {
+		return nil, fmt.Errorf("create crypto: %w", err)
+	}
+
+	provider, err := encrypted.NewProvider(storeProvider, keyManager, crypto,
+		encrypted.WithStores("protected_data", tombstone.StoreName("protected_data"), "ticket"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("create encrypted store provider: %w", err)
+	}
+
+	return provider, nil
 }
 
-func createVDR(didResolverURL, blocDomain, sidetreeToken string, httpClient *http.Client) (vdrapi.Registry, error) {
+func createVDR(didResolverURL, blocDomain, sidetreeToken string, httpClient *http.Client,
+	keyRetriever orb.KeyRetriever) (vdrapi.Registry, error) {
 	var opts []vdrpkg.Option
 
 	if didResolverURL != "" {
@@ -540,7 +2206,7 @@ func createVDR(didResolverURL, blocDomain, sidetreeToken string, httpClient *htt
 	}
 
 	if blocDomain != "" {
-		vdr, err := orb.New(nil, orb.WithDomain(blocDomain), orb.WithHTTPClient(httpClient),
+		vdr, err := orb.New(keyRetriever, orb.WithDomain(blocDomain), orb.WithHTTPClient(httpClient),
 			orb.WithAuthToken(sidetreeToken))
 		if err != nil {
 			return nil, err
@@ -35,6 +35,7 @@ import (
 	cmdutils "github.com/trustbloc/edge-core/pkg/utils/cmd"
 	tlsutils "github.com/trustbloc/edge-core/pkg/utils/tls"
 
+	"github.com/trustbloc/ace/cmd/common"
 	"github.com/trustbloc/ace/pkg/ld"
 	"github.com/trustbloc/ace/pkg/restapi/healthcheck"
 	"github.com/trustbloc/ace/pkg/restapi/vault"
@@ -92,6 +93,12 @@ const (
 	datasourceTimeoutEnvKey  = "VAULT_DSN_TIMEOUT"
 	datasourceTimeoutDefault = "30"
 
+	datasourceMaxPoolSizeFlagName  = "dsn-max-pool-size"
+	datasourceMaxPoolSizeFlagUsage = "Maximum number of connections kept open to the datasource." +
+		" Only applies to the mongodb driver. Defaults to the driver's default." +
+		" Alternatively, this can be set with the following environment variable: " + datasourceMaxPoolSizeEnvKey
+	datasourceMaxPoolSizeEnvKey = "VAULT_DSN_MAX_POOL_SIZE"
+
 	databasePrefixFlagName  = "database-prefix"
 	databasePrefixFlagUsage = "An optional prefix to be used when creating and retrieving underlying databases. " +
 		" Alternatively, this can be set with the following environment variable: " + databasePrefixEnvKey
@@ -132,6 +139,8 @@ type serviceParameters struct {
 	dsnParams       *dsnParams
 	didAnchorOrigin string
 	requestTokens   map[string]string
+	h2c             bool
+	startupTimeout  time.Duration
 }
 
 type dsnParams struct {
@@ -170,13 +179,14 @@ type server interface {
 // HTTPServer represents an actual HTTP server implementation.
 type HTTPServer struct{}
 
-// ListenAndServe starts the server using the standard Go HTTP server implementation.
+// ListenAndServe starts the server using the standard Go HTTP server implementation. The serve certificate is
+// reloaded when its files change.
 func (s *HTTPServer) ListenAndServe(host, certFile, keyFile string, router http.Handler) error {
 	if certFile == "" || keyFile == "" {
 		return http.ListenAndServe(host, router)
 	}
 
-	return http.ListenAndServeTLS(host, certFile, keyFile, router)
+	return common.ListenAndServeTLS(host, certFile, keyFile, router, logger)
 }
 
 // GetStartCmd returns the Cobra start command.
@@ -193,11 +203,23 @@ func createStartCmd(srv server) *cobra.Command {
 		Use:   "start",
 		Short: "Starts a vault server",
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if _, err := common.LoadConfigFile(cmd); err != nil {
+				return err
+			}
+
 			params, err := getParameters(cmd)
 			if err != nil {
 				return err
 			}
 
+			if err = params.validate(); err != nil {
+				return err
+			}
+
+			if common.PrintConfigSet(cmd) {
+				return common.PrintConfig(cmd, params.settings())
+			}
+
 			return startService(params, srv)
 		},
 	}
@@ -234,6 +256,16 @@ func getParameters(cmd *cobra.Command) (*serviceParameters, error) {
 		return nil, err
 	}
 
+	h2c, err := common.H2CEnabled(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	startupTimeout, err := common.StartupTimeout(cmd)
+	if err != nil {
+		return nil, err
+	}
+
 	dsn, err := getDsnParams(cmd)
 	if err != nil {
 		return nil, err
@@ -253,6 +285,8 @@ func getParameters(cmd *cobra.Command) (*serviceParameters, error) {
 		tlsParams:       tlsParams,
 		didAnchorOrigin: didAnchorOrigin,
 		requestTokens:   requestTokens,
+		h2c:             h2c,
+		startupTimeout:  startupTimeout,
 	}, err
 }
 
@@ -304,6 +338,57 @@ func getRequestTokens(cmd *cobra.Command) map[string]string {
 	return tokens
 }
 
+// validate checks the sanity of the parameters. Required parameters are checked when they are read.
+func (p *serviceParameters) validate() error {
+	if err := common.ValidateHostURL(hostURLFlagName, p.host); err != nil {
+		return err
+	}
+
+	if err := common.ValidateURL(remoteKMSURLFlagName, p.remoteKMSURL); err != nil {
+		return err
+	}
+
+	if err := common.ValidateURL(edvURLFlagName, p.edvURL); err != nil {
+		return err
+	}
+
+	if err := common.ValidateURL(didAnchorOriginFlagName, p.didAnchorOrigin); err != nil {
+		return err
+	}
+
+	if (p.tlsParams.serveCertPath == "") != (p.tlsParams.serveKeyPath == "") {
+		return fmt.Errorf("%s and %s must be set together", tlsServeCertPathFlagName, tlsServeKeyPathFlagName)
+	}
+
+	if p.h2c && p.tlsParams.serveCertPath != "" {
+		return fmt.Errorf("%s cannot be used with %s", common.H2CFlagName, tlsServeCertPathFlagName)
+	}
+
+	return nil
+}
+
+// settings returns the parameters keyed by their environment variables, with secrets redacted.
+func (p *serviceParameters) settings() map[string]interface{} {
+	return map[string]interface{}{
+		hostURLEnvKey:               p.host,
+		remoteKMSURLEnvKey:          p.remoteKMSURL,
+		edvURLEnvKey:                p.edvURL,
+		tlsSystemCertPoolEnvKey:     p.tlsParams.systemCertPool,
+		tlsCACertsEnvKey:            p.tlsParams.caCerts,
+		tlsServeCertPathEnvKey:      p.tlsParams.serveCertPath,
+		tlsServeKeyPathFlagEnvKey:   p.tlsParams.serveKeyPath,
+		datasourceNameEnvKey:        common.RedactURL(p.dsnParams.dsn),
+		datasourceTimeoutEnvKey:     p.dsnParams.timeout,
+		databasePrefixEnvKey:        p.dsnParams.dbPrefix,
+		didDomainEnvKey:             p.didDomain,
+		didMethodEnvKey:             p.didMethod,
+		didAnchorOriginEnvKey:       p.didAnchorOrigin,
+		requestTokensEnvKey:         common.RedactTokens(p.requestTokens),
+		common.StartupTimeoutEnvKey: p.startupTimeout.String(),
+		common.H2CEnvKey:            p.h2c,
+	}
+}
+
 func createFlags(cmd *cobra.Command) {
 	cmd.Flags().StringP(hostURLFlagName, hostURLFlagShorthand, "", hostURLFlagUsage)
 	cmd.Flags().StringP(remoteKMSURLFlagName, "", "", remoteKMSURLFlagUsage)
@@ -314,11 +399,16 @@ func createFlags(cmd *cobra.Command) {
 	cmd.Flags().StringP(tlsServeKeyPathFlagName, "", "", tlsServeKeyPathFlagUsage)
 	cmd.Flags().StringP(datasourceNameFlagName, "", "", datasourceNameFlagUsage)
 	cmd.Flags().StringP(datasourceTimeoutFlagName, "", "", datasourceTimeoutFlagUsage)
+	cmd.Flags().StringP(datasourceMaxPoolSizeFlagName, "", "", datasourceMaxPoolSizeFlagUsage)
 	cmd.Flags().StringP(databasePrefixFlagName, "", "", databasePrefixFlagUsage)
 	cmd.Flags().StringP(didDomainFlagName, "", "", didDomainFlagUsage)
 	cmd.Flags().StringP(didMethodFlagName, "", "key", didMethodFlagUsage)
 	cmd.Flags().StringP(didAnchorOriginFlagName, "", "", didAnchorOriginFlagUsage)
 	cmd.Flags().StringArrayP(requestTokensFlagName, "", []string{}, requestTokensFlagUsage)
+
+	common.ConfigFileFlag(cmd)
+	common.H2CFlag(cmd)
+	common.StartupTimeoutFlag(cmd)
 }
 
 const (
@@ -363,6 +453,15 @@ func startService(params *serviceParameters, srv server) error { // nolint: funl
 		MinVersion: tls.VersionTLS12,
 	}
 
+	err = common.WaitForURLs(&http.Client{Transport: &http.Transport{TLSClientConfig: tCfg}},
+		map[string]string{
+			"remote KMS": params.remoteKMSURL,
+			"EDV":        params.edvURL,
+		}, params.startupTimeout, logger)
+	if err != nil {
+		return err
+	}
+
 	vdrBloc, err := orb.New(
 		nil,
 		orb.WithDomain(params.didDomain),
@@ -424,25 +523,28 @@ func startService(params *serviceParameters, srv server) error { // nolint: funl
 		router.HandleFunc(handler.Path(), handler.Handle()).Methods(handler.Method())
 	}
 
+	var handler http.Handler = cors.New(cors.Options{
+		AllowedMethods: []string{
+			http.MethodHead,
+			http.MethodGet,
+			http.MethodPost,
+			http.MethodDelete,
+		},
+		AllowedHeaders: []string{
+			"Origin",
+			"Accept",
+			"Content-Type",
+			"X-Requested-With",
+			"Authorization",
+		},
+	}).Handler(router)
+
+	if params.h2c {
+		handler = common.H2CHandler(handler)
+	}
+
 	// start server on given port and serve using given handlers
-	return srv.ListenAndServe(params.host,
-		params.tlsParams.serveCertPath,
-		params.tlsParams.serveKeyPath,
-		cors.New(cors.Options{
-			AllowedMethods: []string{
-				http.MethodHead,
-				http.MethodGet,
-				http.MethodPost,
-				http.MethodDelete,
-			},
-			AllowedHeaders: []string{
-				"Origin",
-				"Accept",
-				"Content-Type",
-				"X-Requested-With",
-				"Authorization",
-			},
-		}).Handler(router))
+	return srv.ListenAndServe(params.host, params.tlsParams.serveCertPath, params.tlsParams.serveKeyPath, handler)
 }
 
 func initStore(dbURL string, timeout uint64, prefix string) (storage.Provider, error) {
@@ -528,5 +630,24 @@ func getDsnParams(cmd *cobra.Command) (*dsnParams, error) {
 
 	params.dbPrefix = cmdutils.GetUserSetOptionalVarFromString(cmd, databasePrefixFlagName, databasePrefixEnvKey)
 
+	maxPoolSize := cmdutils.GetUserSetOptionalVarFromString(cmd, datasourceMaxPoolSizeFlagName,
+		datasourceMaxPoolSizeEnvKey)
+
+	if maxPoolSize == "" {
+		return params, nil
+	}
+
+	size, err := strconv.ParseUint(maxPoolSize, 10, 64) //nolint:gomnd
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse dsn max pool size %s: %w", maxPoolSize, err)
+	}
+
+	if strings.HasPrefix(params.dsn, "mongodb:") {
+		params.dsn, err = common.MongoDBConnString(params.dsn, size)
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure dsn max pool size: %w", err)
+		}
+	}
+
 	return params, nil
 }
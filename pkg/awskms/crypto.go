@@ -0,0 +1,144 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package awskms
+
+import (
+	"crypto/ecdsa"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/hyperledger/aries-framework-go/pkg/crypto"
+)
+
+// Sign signs the digest of msg with the key of kh in AWS KMS.
+func (s *Service) Sign(msg []byte, kh interface{}) ([]byte, error) {
+	h, ok := kh.(*keyHandle)
+	if !ok {
+		return nil, errors.New("invalid key handle")
+	}
+
+	digest := h.spec.hash()
+	digest.Write(msg) //nolint:errcheck // hash writes never fail
+
+	var resp struct {
+		Signature []byte `json:"Signature"`
+	}
+
+	if err := s.do("Sign", map[string]interface{}{
+		"KeyId":            h.keyID,
+		"Message":          digest.Sum(nil),
+		"MessageType":      messageType,
+		"SigningAlgorithm": h.spec.algorithm,
+	}, &resp); err != nil {
+		return nil, fmt.Errorf("sign: %w", err)
+	}
+
+	var sig struct{ R, S *big.Int }
+
+	if _, err := asn1.Unmarshal(resp.Signature, &sig); err != nil {
+		return nil, fmt.Errorf("parse signature: %w", err)
+	}
+
+	size := (h.publicKey.Curve.Params().BitSize + 7) / 8 //nolint:gomnd
+
+	return append(sig.R.FillBytes(make([]byte, size)), sig.S.FillBytes(make([]byte, size))...), nil
+}
+
+// Verify verifies the signature of msg with the public key of kh.
+func (s *Service) Verify(signature, msg []byte, kh interface{}) error {
+	h, ok := kh.(*keyHandle)
+	if !ok {
+		return errors.New("invalid key handle")
+	}
+
+	size := (h.publicKey.Curve.Params().BitSize + 7) / 8 //nolint:gomnd
+
+	if len(signature) != 2*size {
+		return errors.New("invalid signature")
+	}
+
+	digest := h.spec.hash()
+	digest.Write(msg) //nolint:errcheck // hash writes never fail
+
+	r := new(big.Int).SetBytes(signature[:size])
+	sig := new(big.Int).SetBytes(signature[size:])
+
+	if !ecdsa.Verify(h.publicKey, digest.Sum(nil), r, sig) {
+		return errors.New("invalid signature")
+	}
+
+	return nil
+}
+
+// Encrypt is not supported: the keys of the Service are signing keys.
+func (s *Service) Encrypt([]byte, []byte, interface{}) ([]byte, []byte, error) {
+	return nil, nil, fmt.Errorf("encrypt: %w", ErrNotSupported)
+}
+
+// Decrypt is not supported: the keys of the Service are signing keys.
+func (s *Service) Decrypt([]byte, []byte, []byte, interface{}) ([]byte, error) {
+	return nil, fmt.Errorf("decrypt: %w", ErrNotSupported)
+}
+
+// ComputeMAC is not supported: the keys of the Service are signing keys.
+func (s *Service) ComputeMAC([]byte, interface{}) ([]byte, error) {
+	return nil, fmt.Errorf("compute MAC: %w", ErrNotSupported)
+}
+
+// VerifyMAC is not supported: the keys of the Service are signing keys.
+func (s *Service) VerifyMAC([]byte, []byte, interface{}) error {
+	return fmt.Errorf("verify MAC: %w", ErrNotSupported)
+}
+
+// WrapKey is not supported: the keys of the Service are signing keys.
+func (s *Service) WrapKey([]byte, []byte, []byte, *crypto.PublicKey,
+	...crypto.WrapKeyOpts) (*crypto.RecipientWrappedKey, error) {
+	return nil, fmt.Errorf("wrap key: %w", ErrNotSupported)
+}
+
+// UnwrapKey is not supported: the keys of the Service are signing keys.
+func (s *Service) UnwrapKey(*crypto.RecipientWrappedKey, interface{}, ...crypto.WrapKeyOpts) ([]byte, error) {
+	return nil, fmt.Errorf("unwrap key: %w", ErrNotSupported)
+}
+
+// SignMulti is not supported: AWS KMS has no BBS+ keys.
+func (s *Service) SignMulti([][]byte, interface{}) ([]byte, error) {
+	return nil, fmt.Errorf("sign multi: %w", ErrNotSupported)
+}
+
+// VerifyMulti is not supported: AWS KMS has no BBS+ keys.
+func (s *Service) VerifyMulti([][]byte, []byte, interface{}) error {
+	return fmt.Errorf("verify multi: %w", ErrNotSupported)
+}
+
+// VerifyProof is not supported: AWS KMS has no BBS+ keys.
+func (s *Service) VerifyProof([][]byte, []byte, []byte, interface{}) error {
+	return fmt.Errorf("verify proof: %w", ErrNotSupported)
+}
+
+// DeriveProof is not supported: AWS KMS has no BBS+ keys.
+func (s *Service) DeriveProof([][]byte, []byte, []byte, []int, interface{}) ([]byte, error) {
+	return nil, fmt.Errorf("derive proof: %w", ErrNotSupported)
+}
+
+// Blind is not supported: AWS KMS has no CL keys.
+func (s *Service) Blind(interface{}, ...map[string]interface{}) ([][]byte, error) {
+	return nil, fmt.Errorf("blind: %w", ErrNotSupported)
+}
+
+// GetCorrectnessProof is not supported: AWS KMS has no CL keys.
+func (s *Service) GetCorrectnessProof(interface{}) ([]byte, error) {
+	return nil, fmt.Errorf("get correctness proof: %w", ErrNotSupported)
+}
+
+// SignWithSecrets is not supported: AWS KMS has no CL keys.
+func (s *Service) SignWithSecrets(interface{}, map[string]interface{}, []byte, []byte, [][]byte,
+	string) ([]byte, []byte, error) {
+	return nil, nil, fmt.Errorf("sign with secrets: %w", ErrNotSupported)
+}
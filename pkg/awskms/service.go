@@ -0,0 +1,327 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package awskms manages signing keys in AWS Key Management Service. The private keys are created and used in AWS KMS
+// and never leave it.
+package awskms
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/aries-framework-go/pkg/kms"
+
+	"github.com/trustbloc/ace/pkg/internal/awsutil"
+)
+
+const (
+	service        = "kms"
+	targetPrefix   = "TrentService."
+	contentType    = "application/x-amz-json-1.1"
+	targetHeader   = "X-Amz-Target"
+	defaultRegion  = "us-east-1"
+	keyUsage       = "SIGN_VERIFY"
+	messageType    = "DIGEST"
+	keyDescription = "Signing key of the gatekeeper"
+)
+
+// ErrNotSupported is returned by the operations of the Aries KeyManager and Crypto interfaces that do not apply to
+// signing keys in AWS KMS.
+var ErrNotSupported = errors.New("not supported by AWS KMS")
+
+// keySpec describes a key type supported by AWS KMS. Signatures are in the IEEE P1363 format used by JWS.
+type keySpec struct {
+	keyType   kms.KeyType
+	spec      string
+	algorithm string
+	hash      func() hash.Hash
+}
+
+//nolint:gochecknoglobals
+var keySpecs = []*keySpec{
+	{kms.ECDSAP256TypeIEEEP1363, "ECC_NIST_P256", "ECDSA_SHA_256", sha256.New},
+	{kms.ECDSAP384TypeIEEEP1363, "ECC_NIST_P384", "ECDSA_SHA_384", sha512.New384},
+}
+
+type httpClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// Config of the Service. Requests are signed with the access key if it is set, or else with the credentials of the
+// IAM role of the ECS task or of the EC2 instance.
+type Config struct {
+	// Region of AWS KMS. Defaults to us-east-1.
+	Region          string
+	AccessKeyID     string
+	SecretAccessKey string
+	// SessionToken is only set with temporary credentials.
+	SessionToken string
+	// Endpoint defaults to the endpoint of the region.
+	Endpoint string
+	// MetadataURL defaults to the URL of the instance metadata service.
+	MetadataURL string
+	HTTPClient  httpClient
+	// Now returns the time requests are signed at. Defaults to time.Now.
+	Now func() time.Time
+}
+
+// Service implements the KeyManager and the Crypto interfaces of Aries with AWS KMS, for ECDSA signing keys. Key
+// handles are the public keys of the keys, with their IDs.
+type Service struct {
+	region      string
+	endpoint    string
+	credentials awsutil.CredentialsProvider
+	httpClient  httpClient
+	now         func() time.Time
+	// handles caches the handles of the keys, whose public keys never change
+	handles sync.Map
+}
+
+// New returns a new instance of Service.
+func New(config *Config) *Service {
+	region := config.Region
+	if region == "" {
+		region = defaultRegion
+	}
+
+	endpoint := config.Endpoint
+	if endpoint == "" {
+		endpoint = "https://" + service + "." + region + ".amazonaws.com"
+	}
+
+	var client httpClient = http.DefaultClient
+	if config.HTTPClient != nil {
+		client = config.HTTPClient
+	}
+
+	now := time.Now
+	if config.Now != nil {
+		now = config.Now
+	}
+
+	return &Service{
+		region:   region,
+		endpoint: strings.TrimSuffix(endpoint, "/"),
+		credentials: awsutil.NewCredentials(&awsutil.Credentials{
+			AccessKeyID:     config.AccessKeyID,
+			SecretAccessKey: config.SecretAccessKey,
+			SessionToken:    config.SessionToken,
+		}, config.MetadataURL, client, now),
+		httpClient: client,
+		now:        now,
+	}
+}
+
+type keyHandle struct {
+	keyID     string
+	publicKey *ecdsa.PublicKey
+	spec      *keySpec
+}
+
+// Create creates a signing key of type kt and returns its ID and handle.
+func (s *Service) Create(kt kms.KeyType) (string, interface{}, error) {
+	keyID, err := s.createKey(kt)
+	if err != nil {
+		return "", nil, err
+	}
+
+	kh, err := s.handle(keyID)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return keyID, kh, nil
+}
+
+// Get returns the handle of the key with the given ID.
+func (s *Service) Get(keyID string) (interface{}, error) {
+	return s.handle(keyID)
+}
+
+// Rotate is not supported: AWS KMS does not rotate asymmetric keys.
+func (s *Service) Rotate(kms.KeyType, string) (string, interface{}, error) {
+	return "", nil, fmt.Errorf("rotate: %w", ErrNotSupported)
+}
+
+// ExportPubKeyBytes returns the public key with the given ID as an uncompressed point, with its type.
+func (s *Service) ExportPubKeyBytes(keyID string) ([]byte, kms.KeyType, error) {
+	kh, err := s.handle(keyID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return elliptic.Marshal(kh.publicKey.Curve, kh.publicKey.X, kh.publicKey.Y), kh.spec.keyType, nil
+}
+
+// CreateAndExportPubKeyBytes creates a signing key of type kt and returns its ID and public key.
+func (s *Service) CreateAndExportPubKeyBytes(kt kms.KeyType) (string, []byte, error) {
+	keyID, err := s.createKey(kt)
+	if err != nil {
+		return "", nil, err
+	}
+
+	pubKey, _, err := s.ExportPubKeyBytes(keyID)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return keyID, pubKey, nil
+}
+
+// PubKeyBytesToHandle is not supported: the keys of the Service are in AWS KMS.
+func (s *Service) PubKeyBytesToHandle([]byte, kms.KeyType) (interface{}, error) {
+	return nil, fmt.Errorf("public key to handle: %w", ErrNotSupported)
+}
+
+// ImportPrivateKey is not supported: the private keys of the Service are created in AWS KMS.
+func (s *Service) ImportPrivateKey(interface{}, kms.KeyType, ...kms.PrivateKeyOpts) (string, interface{}, error) {
+	return "", nil, fmt.Errorf("import private key: %w", ErrNotSupported)
+}
+
+func (s *Service) createKey(kt kms.KeyType) (string, error) {
+	spec, err := specOfType(kt)
+	if err != nil {
+		return "", err
+	}
+
+	var resp struct {
+		KeyMetadata struct {
+			KeyID string `json:"KeyId"`
+		} `json:"KeyMetadata"`
+	}
+
+	if err = s.do("CreateKey", map[string]string{
+		"KeySpec":     spec.spec,
+		"KeyUsage":    keyUsage,
+		"Description": keyDescription,
+	}, &resp); err != nil {
+		return "", fmt.Errorf("create key: %w", err)
+	}
+
+	return resp.KeyMetadata.KeyID, nil
+}
+
+func (s *Service) handle(keyID string) (*keyHandle, error) {
+	if kh, ok := s.handles.Load(keyID); ok {
+		return kh.(*keyHandle), nil //nolint:forcetypeassert
+	}
+
+	var resp struct {
+		KeySpec   string `json:"KeySpec"`
+		PublicKey []byte `json:"PublicKey"`
+	}
+
+	if err := s.do("GetPublicKey", map[string]string{"KeyId": keyID}, &resp); err != nil {
+		return nil, fmt.Errorf("get public key %s: %w", keyID, err)
+	}
+
+	var spec *keySpec
+
+	for _, ks := range keySpecs {
+		if ks.spec == resp.KeySpec {
+			spec = ks
+		}
+	}
+
+	if spec == nil {
+		return nil, fmt.Errorf("key %s has unsupported key spec %s", keyID, resp.KeySpec)
+	}
+
+	pub, err := x509.ParsePKIXPublicKey(resp.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("parse public key %s: %w", keyID, err)
+	}
+
+	publicKey, ok := pub.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("key %s is not an ECDSA key", keyID)
+	}
+
+	kh := &keyHandle{keyID: keyID, publicKey: publicKey, spec: spec}
+
+	s.handles.Store(keyID, kh)
+
+	return kh, nil
+}
+
+// do sends the request of the action to AWS KMS and decodes its response into result.
+func (s *Service) do(action string, request, result interface{}) error {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return fmt.Errorf("marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, s.endpoint+"/",
+		bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set(targetHeader, targetPrefix+action)
+
+	credentials, err := s.credentials.Retrieve(req.Context())
+	if err != nil {
+		return err
+	}
+
+	awsutil.Sign(req, body, credentials, s.region, service, s.now())
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("send request: %w", err)
+	}
+
+	defer resp.Body.Close() //nolint:errcheck
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var e struct {
+			Type    string `json:"__type"`
+			Message string `json:"message"`
+		}
+
+		if json.Unmarshal(b, &e) == nil && e.Type != "" {
+			return fmt.Errorf("unexpected response status %d: %s: %s", resp.StatusCode, e.Type, e.Message)
+		}
+
+		return fmt.Errorf("unexpected response status %d", resp.StatusCode)
+	}
+
+	if err = json.Unmarshal(b, result); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+
+	return nil
+}
+
+func specOfType(kt kms.KeyType) (*keySpec, error) {
+	for _, spec := range keySpecs {
+		if spec.keyType == kt {
+			return spec, nil
+		}
+	}
+
+	return nil, fmt.Errorf("key type %s: %w", kt, ErrNotSupported)
+}
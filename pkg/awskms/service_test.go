@@ -0,0 +1,194 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package awskms_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/hyperledger/aries-framework-go/pkg/crypto"
+	"github.com/hyperledger/aries-framework-go/pkg/kms"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/ace/pkg/awskms"
+)
+
+var (
+	_ kms.KeyManager = (*awskms.Service)(nil)
+	_ crypto.Crypto  = (*awskms.Service)(nil)
+)
+
+func TestService(t *testing.T) {
+	k := newFakeKMS(t)
+
+	srv := httptest.NewServer(k)
+	defer srv.Close()
+
+	s := awskms.New(&awskms.Config{
+		Region:          "eu-west-1",
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+		Endpoint:        srv.URL,
+		HTTPClient:      srv.Client(),
+	})
+
+	keyID, pubKey, err := s.CreateAndExportPubKeyBytes(kms.ECDSAP256TypeIEEEP1363)
+	require.NoError(t, err)
+
+	x, y := elliptic.Unmarshal(elliptic.P256(), pubKey)
+	require.Equal(t, k.keys[keyID].X, x)
+	require.Equal(t, k.keys[keyID].Y, y)
+
+	exported, kt, err := s.ExportPubKeyBytes(keyID)
+	require.NoError(t, err)
+	require.Equal(t, pubKey, exported)
+	require.Equal(t, kms.ECDSAP256TypeIEEEP1363, kt)
+
+	kh, err := s.Get(keyID)
+	require.NoError(t, err)
+
+	msg := []byte(strings.Repeat("a credential larger than the 4096 bytes signed raw by AWS KMS", 100))
+
+	sig, err := s.Sign(msg, kh)
+	require.NoError(t, err)
+	require.Len(t, sig, 64)
+	require.NoError(t, s.Verify(sig, msg, kh))
+	require.Error(t, s.Verify(sig, []byte("other message"), kh))
+	require.Error(t, s.Verify(sig[1:], msg, kh))
+
+	// the public keys are cached
+	require.Equal(t, 1, k.getPublicKeyCalls)
+
+	t.Run("Create", func(t *testing.T) {
+		id, h, e := s.Create(kms.ECDSAP384TypeIEEEP1363)
+		require.NoError(t, e)
+		require.NotEqual(t, keyID, id)
+
+		sig, e = s.Sign(msg, h)
+		require.NoError(t, e)
+		require.Len(t, sig, 96)
+		require.NoError(t, s.Verify(sig, msg, h))
+	})
+
+	t.Run("Unsupported key type", func(t *testing.T) {
+		_, _, err = s.CreateAndExportPubKeyBytes(kms.ED25519Type)
+		require.True(t, errors.Is(err, awskms.ErrNotSupported))
+	})
+
+	t.Run("Unknown key", func(t *testing.T) {
+		_, err = s.Get("unknown")
+		require.EqualError(t, err, "get public key unknown: unexpected response status 400: "+
+			"NotFoundException: Key 'unknown' does not exist")
+	})
+
+	t.Run("Invalid key handle", func(t *testing.T) {
+		_, err = s.Sign(msg, "key")
+		require.EqualError(t, err, "invalid key handle")
+	})
+
+	t.Run("Unsupported operations", func(t *testing.T) {
+		_, _, err = s.Rotate(kms.ECDSAP256TypeIEEEP1363, keyID)
+		require.True(t, errors.Is(err, awskms.ErrNotSupported))
+
+		_, err = s.SignMulti([][]byte{msg}, kh)
+		require.True(t, errors.Is(err, awskms.ErrNotSupported))
+
+		_, _, err = s.Encrypt(msg, nil, kh)
+		require.True(t, errors.Is(err, awskms.ErrNotSupported))
+	})
+}
+
+type fakeKMS struct {
+	t                 *testing.T
+	mu                sync.Mutex
+	keys              map[string]*ecdsa.PrivateKey
+	getPublicKeyCalls int
+}
+
+func newFakeKMS(t *testing.T) *fakeKMS {
+	t.Helper()
+
+	return &fakeKMS{t: t, keys: map[string]*ecdsa.PrivateKey{}}
+}
+
+func (k *fakeKMS) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	require.Equal(k.t, "application/x-amz-json-1.1", r.Header.Get("Content-Type"))
+	require.Contains(k.t, r.Header.Get("Authorization"), "/eu-west-1/kms/aws4_request")
+
+	var req struct {
+		KeyID            string `json:"KeyId"`
+		KeySpec          string `json:"KeySpec"`
+		Message          []byte `json:"Message"`
+		MessageType      string `json:"MessageType"`
+		SigningAlgorithm string `json:"SigningAlgorithm"`
+	}
+
+	require.NoError(k.t, json.NewDecoder(r.Body).Decode(&req))
+
+	var resp interface{}
+
+	switch r.Header.Get("X-Amz-Target") {
+	case "TrentService.CreateKey":
+		curves := map[string]elliptic.Curve{"ECC_NIST_P256": elliptic.P256(), "ECC_NIST_P384": elliptic.P384()}
+
+		key, err := ecdsa.GenerateKey(curves[req.KeySpec], rand.Reader)
+		require.NoError(k.t, err)
+
+		id := uuid.New().String()
+		k.keys[id] = key
+
+		resp = map[string]interface{}{"KeyMetadata": map[string]string{"KeyId": id}}
+	case "TrentService.GetPublicKey":
+		k.getPublicKeyCalls++
+
+		key, ok := k.keys[req.KeyID]
+		if !ok {
+			rw.WriteHeader(http.StatusBadRequest)
+			_, _ = rw.Write([]byte(`{"__type":"NotFoundException","message":"Key '` + req.KeyID + `' does not exist"}`))
+
+			return
+		}
+
+		der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+		require.NoError(k.t, err)
+
+		specs := map[int]string{256: "ECC_NIST_P256", 384: "ECC_NIST_P384"}
+
+		resp = map[string]interface{}{"KeySpec": specs[key.Curve.Params().BitSize], "PublicKey": der}
+	case "TrentService.Sign":
+		require.Equal(k.t, "DIGEST", req.MessageType)
+
+		if req.SigningAlgorithm == "ECDSA_SHA_256" {
+			require.Len(k.t, req.Message, sha256.Size)
+		}
+
+		sig, err := ecdsa.SignASN1(rand.Reader, k.keys[req.KeyID], req.Message)
+		require.NoError(k.t, err)
+
+		resp = map[string]interface{}{"KeyId": req.KeyID, "Signature": sig}
+	default:
+		rw.WriteHeader(http.StatusBadRequest)
+
+		return
+	}
+
+	require.NoError(k.t, json.NewEncoder(rw).Encode(resp))
+}
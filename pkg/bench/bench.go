@@ -0,0 +1,246 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package bench runs operations under load and reports their throughput and latency percentiles.
+package bench
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultRequests is the default number of operations run.
+	DefaultRequests = 100
+	// DefaultConcurrency is the default number of operations run at a time.
+	DefaultConcurrency = 10
+)
+
+// Operation is an operation run under load, e.g. a request to a service.
+type Operation func(ctx context.Context) error
+
+// Option configures a run.
+type Option func(r *runner)
+
+// WithRequests sets the number of operations run. Defaults to DefaultRequests, or no limit if a duration is set.
+func WithRequests(requests int) Option {
+	return func(r *runner) {
+		r.requests = requests
+	}
+}
+
+// WithConcurrency sets the number of operations run at a time. Defaults to DefaultConcurrency.
+func WithConcurrency(concurrency int) Option {
+	return func(r *runner) {
+		r.concurrency = concurrency
+	}
+}
+
+// WithDuration sets the time operations are run for. The run stops at the first of the number of operations and the
+// duration that is reached.
+func WithDuration(duration time.Duration) Option {
+	return func(r *runner) {
+		r.duration = duration
+	}
+}
+
+// Result is the result of a run. The latencies include the failed operations.
+type Result struct {
+	Requests int
+	Errors   int
+	// Error is the error of the first operation that failed.
+	Error   string
+	Elapsed time.Duration
+	// Throughput is the number of operations completed per second.
+	Throughput float64
+	Mean       time.Duration
+	P50        time.Duration
+	P90        time.Duration
+	P99        time.Duration
+	Max        time.Duration
+}
+
+// MarshalJSON marshals the result with durations formatted as strings, e.g. 1.5ms.
+func (r *Result) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&struct {
+		Requests   int     `json:"requests"`
+		Errors     int     `json:"errors"`
+		Error      string  `json:"error,omitempty"`
+		Elapsed    string  `json:"elapsed"`
+		Throughput float64 `json:"throughput"`
+		Mean       string  `json:"mean"`
+		P50        string  `json:"p50"`
+		P90        string  `json:"p90"`
+		P99        string  `json:"p99"`
+		Max        string  `json:"max"`
+	}{
+		Requests:   r.Requests,
+		Errors:     r.Errors,
+		Error:      r.Error,
+		Elapsed:    r.Elapsed.String(),
+		Throughput: math.Round(r.Throughput*100) / 100, //nolint:gomnd
+		Mean:       r.Mean.String(),
+		P50:        r.P50.String(),
+		P90:        r.P90.String(),
+		P99:        r.P99.String(),
+		Max:        r.Max.String(),
+	})
+}
+
+type runner struct {
+	requests    int
+	concurrency int
+	duration    time.Duration
+
+	mu        sync.Mutex
+	latencies []time.Duration
+	errors    int
+	err       error
+}
+
+// Run runs the operation under load until the number of operations or the duration of the run is reached, or the
+// context is done. Operations are passed a context that is canceled once the run ends.
+func Run(ctx context.Context, op Operation, opts ...Option) (*Result, error) {
+	r := &runner{concurrency: DefaultConcurrency}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	if r.requests == 0 && r.duration == 0 {
+		r.requests = DefaultRequests
+	}
+
+	if r.requests < 0 || r.concurrency <= 0 || r.duration < 0 {
+		return nil, errors.New("requests and duration must not be negative, and concurrency must be positive")
+	}
+
+	if r.duration > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, r.duration)
+		defer cancel()
+	}
+
+	start := time.Now()
+
+	r.run(ctx, op)
+
+	return r.result(time.Since(start)), nil
+}
+
+func (r *runner) run(ctx context.Context, op Operation) {
+	tickets := make(chan struct{})
+
+	go func() {
+		defer close(tickets)
+
+		for i := 0; r.requests == 0 || i < r.requests; i++ {
+			select {
+			case tickets <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < r.concurrency; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for range tickets {
+				start := time.Now()
+				err := op(ctx)
+				latency := time.Since(start)
+
+				// operations interrupted by the end of the run are not recorded
+				if err != nil && ctx.Err() != nil {
+					return
+				}
+
+				r.record(latency, err)
+			}
+		}()
+	}
+
+	wg.Wait()
+}
+
+func (r *runner) record(latency time.Duration, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.latencies = append(r.latencies, latency)
+
+	if err != nil {
+		r.errors++
+
+		if r.err == nil {
+			r.err = err
+		}
+	}
+}
+
+func (r *runner) result(elapsed time.Duration) *Result {
+	res := &Result{
+		Requests: len(r.latencies),
+		Errors:   r.errors,
+		Elapsed:  elapsed,
+	}
+
+	if r.err != nil {
+		res.Error = r.err.Error()
+	}
+
+	if len(r.latencies) == 0 {
+		return res
+	}
+
+	sort.Slice(r.latencies, func(i, j int) bool { return r.latencies[i] < r.latencies[j] })
+
+	var total time.Duration
+
+	for _, l := range r.latencies {
+		total += l
+	}
+
+	res.Throughput = float64(len(r.latencies)) / elapsed.Seconds()
+	res.Mean = total / time.Duration(len(r.latencies))
+	res.P50 = Percentile(r.latencies, 50) //nolint:gomnd
+	res.P90 = Percentile(r.latencies, 90) //nolint:gomnd
+	res.P99 = Percentile(r.latencies, 99) //nolint:gomnd
+	res.Max = r.latencies[len(r.latencies)-1]
+
+	return res
+}
+
+// Percentile returns the p-th percentile of the sorted latencies with the nearest-rank method, or 0 if there are no
+// latencies.
+func Percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	rank := int(math.Ceil(p / 100 * float64(len(sorted)))) //nolint:gomnd
+	if rank < 1 {
+		rank = 1
+	}
+
+	if rank > len(sorted) {
+		rank = len(sorted)
+	}
+
+	return sorted[rank-1]
+}
@@ -0,0 +1,119 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package bench_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/ace/pkg/bench"
+)
+
+func TestRun(t *testing.T) {
+	t.Run("Runs the number of requests", func(t *testing.T) {
+		var calls int32
+
+		res, err := bench.Run(context.Background(), func(context.Context) error {
+			if atomic.AddInt32(&calls, 1)%4 == 0 {
+				return errors.New("operation error")
+			}
+
+			return nil
+		}, bench.WithRequests(20), bench.WithConcurrency(3))
+		require.NoError(t, err)
+
+		require.EqualValues(t, 20, calls)
+		require.Equal(t, 20, res.Requests)
+		require.Equal(t, 5, res.Errors)
+		require.Equal(t, "operation error", res.Error)
+		require.Positive(t, res.Throughput)
+		require.LessOrEqual(t, res.P50, res.P90)
+		require.LessOrEqual(t, res.P90, res.P99)
+		require.LessOrEqual(t, res.P99, res.Max)
+	})
+
+	t.Run("Bounds concurrency", func(t *testing.T) {
+		var running, peak int32
+
+		_, err := bench.Run(context.Background(), func(context.Context) error {
+			n := atomic.AddInt32(&running, 1)
+			defer atomic.AddInt32(&running, -1)
+
+			for {
+				p := atomic.LoadInt32(&peak)
+				if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+					break
+				}
+			}
+
+			time.Sleep(time.Millisecond)
+
+			return nil
+		}, bench.WithRequests(50), bench.WithConcurrency(4))
+		require.NoError(t, err)
+
+		require.LessOrEqual(t, peak, int32(4))
+	})
+
+	t.Run("Stops after the duration", func(t *testing.T) {
+		res, err := bench.Run(context.Background(), func(ctx context.Context) error {
+			select {
+			case <-time.After(time.Millisecond):
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}, bench.WithDuration(50*time.Millisecond), bench.WithConcurrency(2))
+		require.NoError(t, err)
+
+		require.Positive(t, res.Requests)
+		require.Zero(t, res.Errors)
+		require.GreaterOrEqual(t, res.Elapsed, 50*time.Millisecond)
+	})
+
+	t.Run("Invalid options", func(t *testing.T) {
+		_, err := bench.Run(context.Background(), func(context.Context) error { return nil }, bench.WithConcurrency(0))
+		require.Error(t, err)
+	})
+}
+
+func TestPercentile(t *testing.T) {
+	latencies := make([]time.Duration, 100)
+	for i := range latencies {
+		latencies[i] = time.Duration(i+1) * time.Millisecond
+	}
+
+	require.Equal(t, 50*time.Millisecond, bench.Percentile(latencies, 50))
+	require.Equal(t, 99*time.Millisecond, bench.Percentile(latencies, 99))
+	require.Equal(t, 100*time.Millisecond, bench.Percentile(latencies, 100))
+	require.Equal(t, time.Millisecond, bench.Percentile(latencies, 0))
+	require.Zero(t, bench.Percentile(nil, 50))
+}
+
+func TestResult_MarshalJSON(t *testing.T) {
+	b, err := json.Marshal(&bench.Result{
+		Requests:   10,
+		Elapsed:    time.Second,
+		Throughput: 10.0 / 3,
+		P50:        1500 * time.Microsecond,
+	})
+	require.NoError(t, err)
+
+	var v map[string]interface{}
+
+	require.NoError(t, json.Unmarshal(b, &v))
+	require.Equal(t, "1s", v["elapsed"])
+	require.Equal(t, "1.5ms", v["p50"])
+	require.Equal(t, 3.33, v["throughput"])
+	require.NotContains(t, v, "error")
+}
@@ -0,0 +1,245 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package bench_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	httptransport "github.com/go-openapi/runtime/client"
+	"github.com/go-openapi/strfmt"
+	"github.com/gorilla/mux"
+	"github.com/hyperledger/aries-framework-go/component/storageutil/mem"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
+	mockstorage "github.com/hyperledger/aries-framework-go/pkg/mock/storage"
+	"github.com/stretchr/testify/require"
+
+	cshclient "github.com/trustbloc/ace/pkg/client/csh/client"
+	cshclientmodels "github.com/trustbloc/ace/pkg/client/csh/models"
+	"github.com/trustbloc/ace/pkg/gatekeeper/extract"
+	"github.com/trustbloc/ace/pkg/gatekeeper/policy"
+	"github.com/trustbloc/ace/pkg/gatekeeper/protect"
+	"github.com/trustbloc/ace/pkg/gatekeeper/release"
+	comparator "github.com/trustbloc/ace/pkg/restapi/comparator/operation"
+	"github.com/trustbloc/ace/pkg/restapi/comparator/operation/models"
+	"github.com/trustbloc/ace/pkg/restapi/gatekeeper/operation"
+	"github.com/trustbloc/ace/pkg/restapi/vault"
+)
+
+// The benchmarks below measure the processing of the services themselves: downstream services are stubbed, with
+// local HTTP servers where the operations call them over HTTP. Use the ace-bench command to load running services.
+
+const collectorDID = "did:example:collector"
+
+func BenchmarkProtect(b *testing.B) {
+	policyService, err := policy.NewService(mem.NewProvider())
+	require.NoError(b, err)
+
+	require.NoError(b, policyService.Save(context.Background(), &policy.Policy{
+		ID:         "bench-policy",
+		Collectors: []string{collectorDID},
+	}))
+
+	router := gatekeeperRouter(&operation.Operation{
+		SubjectResolver: subject(collectorDID),
+		PolicyService:   policyService,
+		ProtectService:  &protectService{},
+	})
+
+	body := marshal(b, &operation.ProtectRequest{Policy: "bench-policy", Target: "123456789"})
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		serve(b, router, "/v1/protect", body)
+	}
+}
+
+func BenchmarkExtract(b *testing.B) {
+	csh := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+		rw.Header().Set("Content-Type", "application/json")
+
+		_ = json.NewEncoder(rw).Encode([]*cshclientmodels.ExtractionResponseItems0{{ //nolint:errcheck
+			Document: "123456789",
+		}})
+	}))
+	defer csh.Close()
+
+	transport := httptransport.New(strings.TrimPrefix(csh.URL, "http://"), cshclient.DefaultBasePath,
+		[]string{"http"})
+
+	releaseService, err := release.NewService(&release.Config{StoreProvider: mem.NewProvider()})
+	require.NoError(b, err)
+
+	router := gatekeeperRouter(&operation.Operation{
+		ExtractService: extract.NewService(cshclient.New(transport, strfmt.Default).Operations),
+		ReleaseService: releaseService,
+	})
+
+	body := marshal(b, &operation.ExtractRequest{QueryID: "bench-query"})
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		serve(b, router, "/v1/extract", body)
+	}
+}
+
+func BenchmarkCompare(b *testing.B) {
+	vaultServer := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(rw).Encode(&vault.DocumentMetadata{ID: "id", URI: "/vaults/bench/documents/doc"}) //nolint:errcheck
+	}))
+	defer vaultServer.Close()
+
+	csh := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+		rw.Header().Set("Content-Type", "application/json")
+
+		_ = json.NewEncoder(rw).Encode(&cshclientmodels.Comparison{Result: true}) //nolint:errcheck
+	}))
+	defer csh.Close()
+
+	store := &mockstorage.MockStore{Store: map[string]mockstorage.DBEntry{
+		"config":     {Value: []byte(`{}`)},
+		"csh_config": {Value: []byte(`{}`)},
+	}}
+
+	op, err := comparator.New(&comparator.Config{
+		CSHBaseURL:    csh.URL,
+		VaultBaseURL:  vaultServer.URL,
+		StoreProvider: &mockstorage.MockStoreProvider{Store: store},
+	})
+	require.NoError(b, err)
+
+	router := mux.NewRouter()
+
+	for _, h := range op.GetRESTHandlers() {
+		router.HandleFunc(h.Path(), h.Handle()).Methods(h.Method())
+	}
+
+	queries := make([]models.Query, 2)
+
+	for i := range queries {
+		vaultID, docID := "vault", "doc"
+
+		queries[i] = &models.DocQuery{
+			VaultID:    &vaultID,
+			DocID:      &docID,
+			AuthTokens: &models.DocQueryAO1AuthTokens{Edv: "edv", Kms: "kms"},
+		}
+	}
+
+	eq := &models.EqOp{}
+	eq.SetArgs(queries)
+
+	comparison := &models.Comparison{}
+	comparison.SetOp(eq)
+
+	body := marshal(b, comparison)
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		serve(b, router, "/compare", body)
+	}
+}
+
+func gatekeeperRouter(op *operation.Operation) *mux.Router {
+	router := mux.NewRouter()
+
+	for _, h := range op.GetRESTHandlers() {
+		router.HandleFunc(h.Path(), h.Handle()).Methods(h.Method())
+	}
+
+	return router
+}
+
+func serve(b *testing.B, router http.Handler, path string, body []byte) {
+	b.Helper()
+
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(body))
+	rw := httptest.NewRecorder()
+
+	router.ServeHTTP(rw, req)
+
+	if rw.Code != http.StatusOK {
+		b.Fatalf("unexpected status %d: %s", rw.Code, rw.Body.String())
+	}
+}
+
+func marshal(b *testing.B, v interface{}) []byte {
+	b.Helper()
+
+	body, err := json.Marshal(v)
+	require.NoError(b, err)
+
+	return body
+}
+
+type subject string
+
+func (s subject) Resolve(context.Context) (string, error) {
+	return string(s), nil
+}
+
+// protectService stands for the protect service, which creates a vault and issues a credential for every request.
+type protectService struct{}
+
+func (s *protectService) Protect(_ context.Context, _, policyID string,
+	_ ...protect.Option) (*protect.ProtectedData, error) {
+	return &protect.ProtectedData{DID: "did:example:protected", PolicyID: policyID}, nil
+}
+
+func (s *protectService) ProtectAll(_ context.Context, policyID string,
+	targets ...string) ([]*protect.ProtectedData, error) {
+	data := make([]*protect.ProtectedData, len(targets))
+
+	for i := range targets {
+		data[i] = &protect.ProtectedData{DID: "did:example:protected", PolicyID: policyID}
+	}
+
+	return data, nil
+}
+
+func (s *protectService) Get(context.Context, string) (*protect.ProtectedData, error) {
+	return nil, nil
+}
+
+func (s *protectService) Rotate(context.Context, *protect.ProtectedData,
+	string) (*protect.ProtectedData, *verifiable.Credential, error) {
+	return nil, nil, nil
+}
+
+func (s *protectService) Repolicy(context.Context, *protect.ProtectedData, string, string,
+	bool) (*protect.ProtectedData, error) {
+	return nil, nil
+}
+
+func (s *protectService) Iterate(context.Context, string, int, func(*protect.ProtectedData) error) error {
+	return nil
+}
+
+func (s *protectService) Link(context.Context, string, []*protect.ProtectedData,
+	[]string) ([]*protect.ProtectedData, error) {
+	return nil, nil
+}
+
+func (s *protectService) IterateSubject(context.Context, string, int, func(*protect.ProtectedData) error) error {
+	return nil
+}
+
+func (s *protectService) Delete(context.Context, ...string) error {
+	return nil
+}
+
+func (s *protectService) Anchoring(_ context.Context, did string) (*protect.Anchoring, error) {
+	return &protect.Anchoring{DID: did, Status: protect.AnchorNone}, nil
+}
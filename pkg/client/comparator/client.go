@@ -0,0 +1,293 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package comparator provides a client for the Comparator REST API.
+package comparator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/trustbloc/edge-core/pkg/log"
+
+	"github.com/trustbloc/ace/pkg/client/comparator/models"
+	"github.com/trustbloc/ace/pkg/restapi/model"
+)
+
+const (
+	authorizationsPath = "/authorizations"
+	comparePath        = "/compare"
+	extractPath        = "/extract"
+	configPath         = "/config"
+
+	defaultMaxRetries    = 3
+	defaultRetryInterval = 500 * time.Millisecond
+)
+
+var logger = log.New("comparator-client")
+
+// HTTPClient interface for the http client.
+type HTTPClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// HTTPError is returned when the comparator responds with an error status.
+type HTTPError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *HTTPError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("status %d", e.StatusCode)
+	}
+
+	return fmt.Sprintf("status %d: %s", e.StatusCode, e.Message)
+}
+
+// Client for the comparator.
+type Client struct {
+	httpClient    HTTPClient
+	baseURL       string
+	authToken     string
+	maxRetries    uint64
+	retryInterval time.Duration
+}
+
+// New returns a new instance of comparator client.
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		httpClient: &http.Client{
+			Timeout: time.Minute,
+		},
+		baseURL:       strings.TrimSuffix(baseURL, "/"),
+		maxRetries:    defaultMaxRetries,
+		retryInterval: defaultRetryInterval,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// GetConfig returns the configuration of the comparator, with its DID and public keys.
+func (c *Client) GetConfig(ctx context.Context) (*models.Config, error) {
+	var result models.Config
+
+	err := c.do(ctx, &request{
+		method:     http.MethodGet,
+		path:       configPath,
+		result:     &result,
+		idempotent: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// CreateAuthorization authorizes the requesting party of the authorization to run queries on a document.
+func (c *Client) CreateAuthorization(ctx context.Context, authz *models.Authorization) (*models.Authorization, error) {
+	var result models.Authorization
+
+	err := c.do(ctx, &request{
+		method:  http.MethodPost,
+		path:    authorizationsPath,
+		payload: authz,
+		result:  &result,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// Compare runs the comparison, its operator set with SetOp.
+func (c *Client) Compare(ctx context.Context, comparison *models.Comparison) (*models.ComparisonResult, error) {
+	var result models.ComparisonResult
+
+	err := c.do(ctx, &request{
+		method:     http.MethodPost,
+		path:       comparePath,
+		payload:    comparison,
+		result:     &result,
+		idempotent: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// Extract returns the contents of the documents of the queries, set with SetQueries.
+func (c *Client) Extract(ctx context.Context, extract *models.Extract) (*models.ExtractResp, error) {
+	var result models.ExtractResp
+
+	err := c.do(ctx, &request{
+		method:     http.MethodPost,
+		path:       extractPath,
+		payload:    extract,
+		result:     &result,
+		idempotent: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+type request struct {
+	method  string
+	path    string
+	payload interface{}
+	result  interface{}
+	// idempotent requests are retried on transient failures.
+	idempotent bool
+}
+
+func (c *Client) do(ctx context.Context, r *request) error {
+	var body []byte
+
+	if r.payload != nil {
+		var err error
+
+		body, err = json.Marshal(r.payload)
+		if err != nil {
+			return fmt.Errorf("marshal request: %w", err)
+		}
+	}
+
+	var retry backoff.BackOff = &backoff.StopBackOff{}
+
+	if r.idempotent {
+		b := backoff.NewExponentialBackOff()
+		b.InitialInterval = c.retryInterval
+
+		retry = backoff.WithMaxRetries(b, c.maxRetries)
+	}
+
+	var respBody []byte
+
+	err := backoff.RetryNotify(func() error {
+		var err error
+
+		respBody, err = c.send(ctx, r, body)
+
+		return err
+	}, backoff.WithContext(retry, ctx), func(err error, d time.Duration) {
+		logger.Debugf("%s %s failed, will try again in %s: %s", r.method, r.path, d, err)
+	})
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", r.method, r.path, err)
+	}
+
+	if err = json.Unmarshal(respBody, r.result); err != nil {
+		return fmt.Errorf("unmarshal response: %w", err)
+	}
+
+	return nil
+}
+
+// send sends the request once.
+func (c *Client) send(ctx context.Context, r *request, body []byte) ([]byte, error) {
+	var reqBody io.Reader = http.NoBody
+	if body != nil {
+		reqBody = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, r.method, c.baseURL+r.path, reqBody)
+	if err != nil {
+		return nil, backoff.Permanent(fmt.Errorf("new request: %w", err))
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	if c.authToken != "" {
+		req.Header.Set("Authorization", "Bearer "+c.authToken)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			logger.Warnf("failed to close response body")
+		}
+	}()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		httpErr := &HTTPError{StatusCode: resp.StatusCode}
+
+		var errResp model.ErrorResponse
+
+		if json.Unmarshal(respBody, &errResp) == nil {
+			httpErr.Message = errResp.Message
+		}
+
+		if !retryable(resp.StatusCode) {
+			return nil, backoff.Permanent(httpErr)
+		}
+
+		return nil, httpErr
+	}
+
+	return respBody, nil
+}
+
+func retryable(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// Option is a comparator client instance option.
+type Option func(opts *Client)
+
+// WithHTTPClient allows providing HTTP client.
+func WithHTTPClient(c HTTPClient) Option {
+	return func(opts *Client) {
+		opts.httpClient = c
+	}
+}
+
+// WithAuthToken sets the bearer token sent with every request, e.g. to a gateway in front of the comparator.
+func WithAuthToken(token string) Option {
+	return func(opts *Client) {
+		opts.authToken = token
+	}
+}
+
+// WithRetries sets how many times the config, compare and extract requests are retried on connection errors and on
+// 429, 502, 503 and 504 responses, and the interval before the first retry, which grows exponentially. Authorizations
+// are not retried, they create a query on the Confidential Storage Hub. Defaults to 3 retries after 500ms.
+func WithRetries(maxRetries uint64, interval time.Duration) Option {
+	return func(opts *Client) {
+		opts.maxRetries = maxRetries
+		opts.retryInterval = interval
+	}
+}
@@ -0,0 +1,165 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package comparator_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/ace/pkg/client/comparator"
+	"github.com/trustbloc/ace/pkg/client/comparator/models"
+	"github.com/trustbloc/ace/pkg/restapi/model"
+)
+
+const (
+	testToken   = "test-token"
+	noRetryWait = time.Millisecond
+)
+
+func TestClient(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "Bearer "+testToken, r.Header.Get("Authorization"))
+
+		var resp interface{}
+
+		switch r.URL.Path {
+		case "/config":
+			did := "did:example:comparator"
+			resp = &models.Config{Did: &did, Key: map[string]interface{}{"keys": []interface{}{}}}
+		case "/authorizations":
+			var authz models.Authorization
+
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&authz))
+
+			authz.ID = "authz-1"
+			authz.AuthToken = "zcap"
+			resp = &authz
+		case "/compare":
+			var comparison models.Comparison
+
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&comparison))
+			require.IsType(t, &models.EqOp{}, comparison.Op())
+
+			resp = &models.ComparisonResult{Result: true}
+		case "/extract":
+			var extract models.Extract
+
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&extract))
+			require.Len(t, extract.Queries(), 1)
+
+			resp = &models.ExtractResp{Documents: []*models.ExtractRespDocumentsItems0{{Contents: "secret"}}}
+		}
+
+		require.NoError(t, json.NewEncoder(w).Encode(resp))
+	}))
+	defer srv.Close()
+
+	c := comparator.New(srv.URL+"/", comparator.WithAuthToken(testToken))
+
+	ctx := context.Background()
+
+	config, err := c.GetConfig(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "did:example:comparator", *config.Did)
+
+	party := "did:example:party#key-1"
+
+	authz, err := c.CreateAuthorization(ctx, &models.Authorization{RequestingParty: &party, Scope: &models.Scope{}})
+	require.NoError(t, err)
+	require.Equal(t, "authz-1", authz.ID)
+	require.Equal(t, "zcap", authz.AuthToken)
+
+	comparison := &models.Comparison{}
+	comparison.SetOp(&models.EqOp{})
+
+	result, err := c.Compare(ctx, comparison)
+	require.NoError(t, err)
+	require.True(t, result.Result)
+
+	extract := &models.Extract{}
+	extract.SetQueries([]models.Query{&models.AuthorizedQuery{AuthToken: &authz.AuthToken}})
+
+	extracted, err := c.Extract(ctx, extract)
+	require.NoError(t, err)
+	require.Equal(t, "secret", extracted.Documents[0].Contents)
+}
+
+func TestClientErrors(t *testing.T) {
+	t.Run("test error response", func(t *testing.T) {
+		srv := newErrorServer(t, http.StatusNotImplemented, nil)
+
+		_, err := comparator.New(srv.URL).Compare(context.Background(), &models.Comparison{})
+		require.EqualError(t, err, "POST /compare: status 501: operator not yet implemented")
+
+		var httpErr *comparator.HTTPError
+
+		require.True(t, errors.As(err, &httpErr))
+		require.Equal(t, http.StatusNotImplemented, httpErr.StatusCode)
+	})
+
+	t.Run("test retries transient failures", func(t *testing.T) {
+		var calls int32
+
+		srv := newErrorServer(t, http.StatusServiceUnavailable, &calls)
+
+		_, err := comparator.New(srv.URL, comparator.WithRetries(2, noRetryWait)).GetConfig(context.Background())
+		require.Error(t, err)
+		require.Equal(t, int32(3), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("test does not retry authorizations", func(t *testing.T) {
+		var calls int32
+
+		srv := newErrorServer(t, http.StatusServiceUnavailable, &calls)
+
+		_, err := comparator.New(srv.URL, comparator.WithRetries(2, noRetryWait)).
+			CreateAuthorization(context.Background(), &models.Authorization{})
+		require.Error(t, err)
+		require.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("test invalid response", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, err := w.Write([]byte("not json"))
+			require.NoError(t, err)
+		}))
+		defer srv.Close()
+
+		_, err := comparator.New(srv.URL).GetConfig(context.Background())
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "unmarshal response")
+	})
+
+	t.Run("test invalid url", func(t *testing.T) {
+		_, err := comparator.New("http://user^foo.com").GetConfig(context.Background())
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "new request")
+	})
+}
+
+func newErrorServer(t *testing.T, status int, calls *int32) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls != nil {
+			atomic.AddInt32(calls, 1)
+		}
+
+		w.WriteHeader(status)
+		require.NoError(t, json.NewEncoder(w).Encode(&model.ErrorResponse{Message: "operator not yet implemented"}))
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv
+}
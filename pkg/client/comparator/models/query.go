@@ -132,6 +132,12 @@ func unmarshalQuery(data []byte, consumer runtime.Consumer) (Query, error) {
 			return nil, err
 		}
 		return &result, nil
+	case "ValueQuery":
+		var result ValueQuery
+		if err := consumer.Consume(buf2, &result); err != nil {
+			return nil, err
+		}
+		return &result, nil
 	}
 	return nil, errors.New(422, "invalid type value: %q", getType.Type)
 }
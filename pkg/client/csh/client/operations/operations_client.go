@@ -45,6 +45,8 @@ type ClientService interface {
 
 	PostHubstoreProfilesProfileIDQueries(params *PostHubstoreProfilesProfileIDQueriesParams, opts ...ClientOption) (*PostHubstoreProfilesProfileIDQueriesCreated, error)
 
+	PostRevocationsStatus(params *PostRevocationsStatusParams, opts ...ClientOption) (*PostRevocationsStatusOK, error)
+
 	SetTransport(transport runtime.ClientTransport)
 }
 
@@ -238,6 +240,44 @@ func (a *Client) PostHubstoreProfilesProfileIDQueries(params *PostHubstoreProfil
 	panic(msg)
 }
 
+/*
+  PostRevocationsStatus Tells whether any zcap of the capability chains of the zcaps is revoked.
+*/
+func (a *Client) PostRevocationsStatus(params *PostRevocationsStatusParams, opts ...ClientOption) (*PostRevocationsStatusOK, error) {
+	// TODO: Validate the params before sending
+	if params == nil {
+		params = NewPostRevocationsStatusParams()
+	}
+	op := &runtime.ClientOperation{
+		ID:                 "PostRevocationsStatus",
+		Method:             "POST",
+		PathPattern:        "/revocations/status",
+		ProducesMediaTypes: []string{"application/json"},
+		ConsumesMediaTypes: []string{"application/json"},
+		Schemes:            []string{"http", "https"},
+		Params:             params,
+		Reader:             &PostRevocationsStatusReader{formats: a.formats},
+		Context:            params.Context,
+		Client:             params.HTTPClient,
+	}
+	for _, opt := range opts {
+		opt(op)
+	}
+
+	result, err := a.transport.Submit(op)
+	if err != nil {
+		return nil, err
+	}
+	success, ok := result.(*PostRevocationsStatusOK)
+	if ok {
+		return success, nil
+	}
+	// unexpected success response
+	// safeguard: normally, absent a default response, unknown success responses return an error above: so this is a codegen issue
+	msg := fmt.Sprintf("unexpected success response for PostRevocationsStatus: API contract not enforced by server. Client expected to get an error, but got: %T", result)
+	panic(msg)
+}
+
 // SetTransport changes the transport on the client
 func (a *Client) SetTransport(transport runtime.ClientTransport) {
 	a.transport = transport
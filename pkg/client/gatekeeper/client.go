@@ -0,0 +1,947 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package gatekeeper provides a client for the Gatekeeper REST API.
+package gatekeeper
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/trustbloc/edge-core/pkg/log"
+
+	"github.com/trustbloc/ace/pkg/gatekeeper/audit"
+	"github.com/trustbloc/ace/pkg/gatekeeper/backup"
+	"github.com/trustbloc/ace/pkg/gatekeeper/policy"
+	"github.com/trustbloc/ace/pkg/httpsig"
+	"github.com/trustbloc/ace/pkg/restapi/gatekeeper/operation"
+	"github.com/trustbloc/ace/pkg/restapi/model"
+	"github.com/trustbloc/ace/pkg/restapi/mw/requestid"
+)
+
+const (
+	policyPath       = "/v1/policy"
+	delegationPath   = policyPath + "/%s/delegation"
+	evaluatePath     = policyPath + "/evaluate"
+	protectPath      = "/v1/protect"
+	rotatePath       = protectPath + "/rotate"
+	repolicyPath     = protectPath + "/%s/repolicy"
+	releasePath      = "/v1/release"
+	ticketPath       = releasePath + "/%s"
+	authorizePath    = ticketPath + "/authorize"
+	rejectPath       = ticketPath + "/reject"
+	historyPath      = ticketPath + "/history"
+	ticketStatusPath = ticketPath + "/status"
+	collectPath      = ticketPath + "/collect"
+	breakGlassPath   = "/v1/breakglass"
+	subscribePath    = "/v1/subscriptions"
+	subscriptionPath = subscribePath + "/%s"
+	extractPath      = "/v1/extract"
+	extractBatchPath = extractPath + "/batch"
+	purgePath        = "/v1/purge"
+	dsarPath         = "/v1/dsar"
+	linkPath         = dsarPath + "/link"
+	tracePath        = "/v1/trace"
+	auditPath        = "/v1/audit"
+	entriesPath      = auditPath + "/entries"
+	checkpointsPath  = auditPath + "/checkpoints"
+	auditVerifyPath  = auditPath + "/verify"
+	backupPath       = "/backup"
+	restorePath      = "/restore"
+
+	defaultMaxRetries    = 3
+	defaultRetryInterval = 500 * time.Millisecond
+)
+
+var logger = log.New("gatekeeper-client")
+
+// HTTPClient interface for the http client.
+type HTTPClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// HTTPError is returned when Gatekeeper responds with an error status.
+type HTTPError struct {
+	StatusCode int
+	Message    string
+	// RequestID is the ID Gatekeeper gave the request, to be quoted when reporting the error.
+	RequestID string
+}
+
+func (e *HTTPError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("status %d", e.StatusCode)
+	}
+
+	return fmt.Sprintf("status %d: %s", e.StatusCode, e.Message)
+}
+
+// Client for Gatekeeper.
+type Client struct {
+	httpClient    HTTPClient
+	baseURL       string
+	authToken     string
+	signer        *requestSigner
+	maxRetries    uint64
+	retryInterval time.Duration
+}
+
+type requestSigner struct {
+	publicKeyID string
+	privateKey  ed25519.PrivateKey
+}
+
+// New returns a new instance of Gatekeeper client.
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		httpClient: &http.Client{
+			Timeout: time.Minute,
+		},
+		baseURL:       strings.TrimSuffix(baseURL, "/"),
+		maxRetries:    defaultMaxRetries,
+		retryInterval: defaultRetryInterval,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// CreatePolicy creates the policy with the given ID. Existing policies are replaced with UpdatePolicy, creating them
+// fails with status 428. Requires the API token.
+func (c *Client) CreatePolicy(ctx context.Context, policyID string, p *policy.Policy) error {
+	// not retried, the retry of a request that was applied would fail
+	return c.do(ctx, &request{
+		method:  http.MethodPut,
+		path:    policyPath + "/" + url.PathEscape(policyID),
+		payload: p,
+	})
+}
+
+// UpdatePolicy replaces the policy with the given ID if its ETag, as returned by GetPolicyWithETag, is still the
+// given one, and returns the ETag of the new policy. It fails with status 412 if the policy was modified since.
+// Requires the API token.
+func (c *Client) UpdatePolicy(ctx context.Context, policyID, etag string, p *policy.Policy) (string, error) {
+	var newETag string
+
+	err := c.do(ctx, &request{
+		method:  http.MethodPut,
+		path:    policyPath + "/" + url.PathEscape(policyID),
+		payload: p,
+		ifMatch: etag,
+		etag:    &newETag,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return newETag, nil
+}
+
+// GetPolicy returns the policy with the given ID. Requires the API token.
+func (c *Client) GetPolicy(ctx context.Context, policyID string) (*policy.Policy, error) {
+	p, _, err := c.GetPolicyWithETag(ctx, policyID)
+
+	return p, err
+}
+
+// GetPolicyWithETag returns the policy with the given ID and its ETag, to update it with UpdatePolicy. Requires the
+// API token.
+func (c *Client) GetPolicyWithETag(ctx context.Context, policyID string) (*policy.Policy, string, error) {
+	var (
+		result policy.Policy
+		etag   string
+	)
+
+	err := c.do(ctx, &request{
+		method:     http.MethodGet,
+		path:       policyPath + "/" + url.PathEscape(policyID),
+		result:     &result,
+		etag:       &etag,
+		idempotent: true,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	return &result, etag, nil
+}
+
+// DeletePolicy deletes the policy with the given ID, which can be restored until it is purged. Requires the API
+// token.
+func (c *Client) DeletePolicy(ctx context.Context, policyID string) error {
+	return c.do(ctx, &request{
+		method: http.MethodDelete,
+		path:   policyPath + "/" + url.PathEscape(policyID),
+	})
+}
+
+// ListPolicies returns all policies. Requires the API token.
+func (c *Client) ListPolicies(ctx context.Context) ([]*policy.Policy, error) {
+	var result operation.ListPoliciesResponse
+
+	err := c.do(ctx, &request{
+		method:     http.MethodGet,
+		path:       policyPath,
+		result:     &result,
+		idempotent: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result.Policies, nil
+}
+
+// EvaluatePolicies tells whether the actor of the request may run each of its operations under their policies.
+// Requires the API token.
+func (c *Client) EvaluatePolicies(ctx context.Context,
+	req *operation.EvaluateRequest) (*operation.EvaluateResponse, error) {
+	var result operation.EvaluateResponse
+
+	err := c.do(ctx, &request{
+		method:     http.MethodPost,
+		path:       evaluatePath,
+		payload:    req,
+		result:     &result,
+		idempotent: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// Delegate delegates the approval authority of the signing approver under the policy to another DID for the time
+// window of the request. Requires the signer.
+func (c *Client) Delegate(ctx context.Context, policyID string,
+	req *operation.DelegateRequest) (*operation.DelegateResponse, error) {
+	var result operation.DelegateResponse
+
+	err := c.do(ctx, &request{
+		method:  http.MethodPost,
+		path:    fmt.Sprintf(delegationPath, url.PathEscape(policyID)),
+		payload: req,
+		result:  &result,
+		signed:  true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// Protect protects the target under the policy and returns the DID of the protected data. Requires the signer.
+func (c *Client) Protect(ctx context.Context, req *operation.ProtectRequest) (*operation.ProtectResponse, error) {
+	var result operation.ProtectResponse
+
+	err := c.do(ctx, &request{
+		method:  http.MethodPost,
+		path:    protectPath,
+		payload: req,
+		result:  &result,
+		signed:  true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// Rotate replaces the DID of the protected data with a new one and returns the credential linking the previous DID
+// to the new one. Requires the signer.
+func (c *Client) Rotate(ctx context.Context, req *operation.RotateRequest) (*operation.RotateResponse, error) {
+	var result operation.RotateResponse
+
+	err := c.do(ctx, &request{
+		method:  http.MethodPost,
+		path:    rotatePath,
+		payload: req,
+		result:  &result,
+		signed:  true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// Repolicy moves the protected data with the given DID under another policy. Requires the signer.
+func (c *Client) Repolicy(ctx context.Context, did string,
+	req *operation.RepolicyRequest) (*operation.RepolicyResponse, error) {
+	var result operation.RepolicyResponse
+
+	err := c.do(ctx, &request{
+		method:  http.MethodPost,
+		path:    fmt.Sprintf(repolicyPath, url.PathEscape(did)),
+		payload: req,
+		result:  &result,
+		signed:  true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// Release creates a release ticket for the protected data. Requires the signer.
+func (c *Client) Release(ctx context.Context, req *operation.ReleaseRequest) (*operation.ReleaseResponse, error) {
+	var result operation.ReleaseResponse
+
+	err := c.do(ctx, &request{
+		method:  http.MethodPost,
+		path:    releasePath,
+		payload: req,
+		result:  &result,
+		signed:  true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// Authorize approves the release of the ticket by the signing approver, with the optional comment of the request.
+// Requires the signer.
+func (c *Client) Authorize(ctx context.Context, ticketID string, req *operation.DecisionRequest) error {
+	return c.do(ctx, &request{
+		method:     http.MethodPost,
+		path:       fmt.Sprintf(authorizePath, url.PathEscape(ticketID)),
+		payload:    req,
+		signed:     true,
+		idempotent: true,
+	})
+}
+
+// Reject rejects the release of the ticket by the signing approver, with the optional comment of the request.
+// Requires the signer.
+func (c *Client) Reject(ctx context.Context, ticketID string, req *operation.DecisionRequest) error {
+	return c.do(ctx, &request{
+		method:  http.MethodPost,
+		path:    fmt.Sprintf(rejectPath, url.PathEscape(ticketID)),
+		payload: req,
+		signed:  true,
+	})
+}
+
+// BreakGlass collects the protected data in an emergency, without the approvals of the approvers, and returns the
+// ticket recording the access with the query to extract the data. Requires the signer.
+func (c *Client) BreakGlass(ctx context.Context,
+	req *operation.BreakGlassRequest) (*operation.BreakGlassResponse, error) {
+	var result operation.BreakGlassResponse
+
+	err := c.do(ctx, &request{
+		method:  http.MethodPost,
+		path:    breakGlassPath,
+		payload: req,
+		result:  &result,
+		signed:  true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// Subscribe subscribes the signer to the events of the tickets they are involved in. Requires the signer.
+func (c *Client) Subscribe(ctx context.Context,
+	req *operation.SubscribeRequest) (*operation.SubscribeResponse, error) {
+	var result operation.SubscribeResponse
+
+	err := c.do(ctx, &request{
+		method:  http.MethodPost,
+		path:    subscribePath,
+		payload: req,
+		result:  &result,
+		signed:  true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// Subscriptions returns the page of the subscriptions of the signer. Requires the signer.
+func (c *Client) Subscriptions(ctx context.Context,
+	page *model.PageRequest) (*operation.ListSubscriptionsResponse, error) {
+	var result operation.ListSubscriptionsResponse
+
+	err := c.do(ctx, &request{
+		method:     http.MethodGet,
+		path:       pagePath(subscribePath, page),
+		result:     &result,
+		signed:     true,
+		idempotent: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// Unsubscribe deletes the subscription of the signer. Requires the signer.
+func (c *Client) Unsubscribe(ctx context.Context, subscriptionID string) error {
+	return c.do(ctx, &request{
+		method: http.MethodDelete,
+		path:   fmt.Sprintf(subscriptionPath, url.PathEscape(subscriptionID)),
+		signed: true,
+	})
+}
+
+// TicketStatus returns the status of the ticket. Requires the signer.
+func (c *Client) TicketStatus(ctx context.Context, ticketID string) (*operation.TicketStatusResponse, error) {
+	var result operation.TicketStatusResponse
+
+	err := c.do(ctx, &request{
+		method:     http.MethodGet,
+		path:       fmt.Sprintf(ticketStatusPath, url.PathEscape(ticketID)),
+		result:     &result,
+		signed:     true,
+		idempotent: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// ListTickets returns the page of the tickets selected by the filters of the request. Requires the API token.
+func (c *Client) ListTickets(ctx context.Context,
+	req *operation.ListTicketsRequest) (*operation.ListTicketsResponse, error) {
+	var result operation.ListTicketsResponse
+
+	path := releasePath
+	if query := req.Values().Encode(); query != "" {
+		path += "?" + query
+	}
+
+	err := c.do(ctx, &request{
+		method:     http.MethodGet,
+		path:       path,
+		result:     &result,
+		idempotent: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// GetTicket returns the ticket with its approvals. Requires the API token.
+func (c *Client) GetTicket(ctx context.Context, ticketID string) (*operation.TicketResponse, error) {
+	var result operation.TicketResponse
+
+	err := c.do(ctx, &request{
+		method:     http.MethodGet,
+		path:       fmt.Sprintf(ticketPath, url.PathEscape(ticketID)),
+		result:     &result,
+		idempotent: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// TicketHistory returns the history of the ticket, with every action taken on it. Requires the API token.
+func (c *Client) TicketHistory(ctx context.Context, ticketID string) (*operation.TicketHistoryResponse, error) {
+	var result operation.TicketHistoryResponse
+
+	err := c.do(ctx, &request{
+		method:     http.MethodGet,
+		path:       fmt.Sprintf(historyPath, url.PathEscape(ticketID)),
+		result:     &result,
+		idempotent: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// Collect returns the query for the data released by the ticket. Requires the signer.
+func (c *Client) Collect(ctx context.Context, ticketID string) (*operation.CollectResponse, error) {
+	var result operation.CollectResponse
+
+	err := c.do(ctx, &request{
+		method: http.MethodPost,
+		path:   fmt.Sprintf(collectPath, url.PathEscape(ticketID)),
+		result: &result,
+		signed: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// Extract returns the data the query was created for.
+func (c *Client) Extract(ctx context.Context, req *operation.ExtractRequest) (*operation.ExtractResponse, error) {
+	var result operation.ExtractResponse
+
+	err := c.do(ctx, &request{
+		method:     http.MethodPost,
+		path:       extractPath,
+		payload:    req,
+		result:     &result,
+		idempotent: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// Purge purges deleted data past its retention window. Requires the API token.
+func (c *Client) Purge(ctx context.Context) error {
+	return c.do(ctx, &request{
+		method:     http.MethodPost,
+		path:       purgePath,
+		idempotent: true,
+	})
+}
+
+// Backup returns the encrypted snapshot of the policies, the protected data, the tickets and the quota usages. Requires
+// the API token and a backup key set on the server. The client has to use the URL of the admin listener if the server
+// has one.
+func (c *Client) Backup(ctx context.Context) ([]byte, error) {
+	var snapshot []byte
+
+	err := c.do(ctx, &request{
+		method: http.MethodPost,
+		path:   backupPath,
+		decode: func(body []byte) error {
+			snapshot = body
+
+			return nil
+		},
+		idempotent: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return snapshot, nil
+}
+
+// Restore restores the encrypted snapshot into a fresh instance and returns the number of entries restored per
+// store. Requires the API token and the backup key the snapshot was encrypted with set on the server.
+func (c *Client) Restore(ctx context.Context, snapshot []byte) ([]*backup.Result, error) {
+	var result struct {
+		Stores []*backup.Result `json:"stores"`
+	}
+
+	err := c.do(ctx, &request{
+		method:  http.MethodPost,
+		path:    restorePath,
+		payload: json.RawMessage(snapshot),
+		result:  &result,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result.Stores, nil
+}
+
+// DSAR returns the report of the data protected about the subject signing the request. Requires the signer.
+func (c *Client) DSAR(ctx context.Context) (*operation.DSARResponse, error) {
+	var result operation.DSARResponse
+
+	err := c.do(ctx, &request{
+		method:     http.MethodGet,
+		path:       dsarPath,
+		result:     &result,
+		signed:     true,
+		idempotent: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// ExtractBatch returns the results of the extraction of the data of several queries, in order. The queries that
+// failed to be extracted have the error in their results.
+func (c *Client) ExtractBatch(ctx context.Context,
+	req *operation.ExtractBatchRequest) ([]*operation.ExtractBatchItem, error) {
+	var items []*operation.ExtractBatchItem
+
+	err := c.do(ctx, &request{
+		method:  http.MethodPost,
+		path:    extractBatchPath,
+		payload: req,
+		decode: func(body []byte) error {
+			items = nil
+
+			dec := json.NewDecoder(bytes.NewReader(body))
+
+			for dec.More() {
+				var item operation.ExtractBatchItem
+
+				if err := dec.Decode(&item); err != nil {
+					return fmt.Errorf("decode response: %w", err)
+				}
+
+				items = append(items, &item)
+			}
+
+			return nil
+		},
+		idempotent: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// Erase deletes the data protected about the subject signing the request. Requires the signer.
+func (c *Client) Erase(ctx context.Context) (*operation.EraseResponse, error) {
+	var result operation.EraseResponse
+
+	err := c.do(ctx, &request{
+		method:     http.MethodDelete,
+		path:       dsarPath,
+		result:     &result,
+		signed:     true,
+		idempotent: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// Link links protected data to the subject signing the request. Requires the signer.
+func (c *Client) Link(ctx context.Context, req *operation.LinkRequest) (*operation.LinkResponse, error) {
+	var result operation.LinkResponse
+
+	err := c.do(ctx, &request{
+		method:     http.MethodPost,
+		path:       linkPath,
+		payload:    req,
+		result:     &result,
+		signed:     true,
+		idempotent: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// Trace returns the extraction the watermark of the data identifies. Requires the API token.
+func (c *Client) Trace(ctx context.Context, req *operation.TraceRequest) (*operation.TraceResponse, error) {
+	var result operation.TraceResponse
+
+	err := c.do(ctx, &request{
+		method:     http.MethodPost,
+		path:       tracePath,
+		payload:    req,
+		result:     &result,
+		idempotent: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// AuditEntries returns the page of the entries of the audit log, with the head of the log. Requires the API token.
+func (c *Client) AuditEntries(ctx context.Context, page *model.PageRequest) (*operation.AuditEntriesResponse, error) {
+	var result operation.AuditEntriesResponse
+
+	err := c.do(ctx, &request{
+		method:     http.MethodGet,
+		path:       pagePath(entriesPath, page),
+		result:     &result,
+		idempotent: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// pagePath returns the path of the listing endpoint with the query parameters of the page requested, if any.
+func pagePath(path string, page *model.PageRequest) string {
+	query := url.Values{}
+
+	if page != nil {
+		page.SetValues(query)
+	}
+
+	if len(query) == 0 {
+		return path
+	}
+
+	return path + "?" + query.Encode()
+}
+
+// AuditCheckpoints returns the signed checkpoints of the audit log, oldest first. Requires the API token.
+func (c *Client) AuditCheckpoints(ctx context.Context) ([]json.RawMessage, error) {
+	var result operation.CheckpointsResponse
+
+	err := c.do(ctx, &request{
+		method:     http.MethodGet,
+		path:       checkpointsPath,
+		result:     &result,
+		idempotent: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result.Checkpoints, nil
+}
+
+// VerifyAudit returns the report of the verification of the audit log by Gatekeeper. Requires the API token.
+func (c *Client) VerifyAudit(ctx context.Context) (*audit.Report, error) {
+	var result audit.Report
+
+	err := c.do(ctx, &request{
+		method:     http.MethodGet,
+		path:       auditVerifyPath,
+		result:     &result,
+		idempotent: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+type request struct {
+	method  string
+	path    string
+	payload interface{}
+	result  interface{}
+	// decode, if set, decodes the response body instead of unmarshaling it into result.
+	decode func(body []byte) error
+	// signed requests are authenticated with HTTP signatures, others with the API token.
+	signed bool
+	// idempotent requests are retried on transient failures.
+	idempotent bool
+	// ifMatch, if set, is sent in the If-Match header, for the request to only apply to the representation with the
+	// ETag.
+	ifMatch string
+	// etag, if set, is set to the ETag header of the response.
+	etag *string
+}
+
+func (c *Client) do(ctx context.Context, r *request) error {
+	var body []byte
+
+	if r.payload != nil {
+		var err error
+
+		body, err = json.Marshal(r.payload)
+		if err != nil {
+			return fmt.Errorf("marshal request: %w", err)
+		}
+	}
+
+	var retry backoff.BackOff = &backoff.StopBackOff{}
+
+	if r.idempotent {
+		b := backoff.NewExponentialBackOff()
+		b.InitialInterval = c.retryInterval
+
+		retry = backoff.WithMaxRetries(b, c.maxRetries)
+	}
+
+	var respBody []byte
+
+	err := backoff.RetryNotify(func() error {
+		var err error
+
+		respBody, err = c.send(ctx, r, body)
+
+		return err
+	}, backoff.WithContext(retry, ctx), func(err error, d time.Duration) {
+		logger.Debugf("%s %s failed, will try again in %s: %s", r.method, r.path, d, err)
+	})
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", r.method, r.path, err)
+	}
+
+	if r.decode != nil {
+		return r.decode(respBody)
+	}
+
+	if r.result == nil {
+		return nil
+	}
+
+	if err = json.Unmarshal(respBody, r.result); err != nil {
+		return fmt.Errorf("unmarshal response: %w", err)
+	}
+
+	return nil
+}
+
+// send sends the request once. The request is created, and signed, for each attempt, as signatures include the date.
+func (c *Client) send(ctx context.Context, r *request, body []byte) ([]byte, error) {
+	var reqBody io.Reader = http.NoBody
+	if body != nil {
+		reqBody = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, r.method, c.baseURL+r.path, reqBody)
+	if err != nil {
+		return nil, backoff.Permanent(fmt.Errorf("new request: %w", err))
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	if r.ifMatch != "" {
+		req.Header.Set("If-Match", r.ifMatch)
+	}
+
+	if err = c.authorize(req, r.signed); err != nil {
+		return nil, backoff.Permanent(err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			logger.Warnf("failed to close response body")
+		}
+	}()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		httpErr := &HTTPError{StatusCode: resp.StatusCode, RequestID: resp.Header.Get(requestid.Header)}
+
+		var errResp model.ErrorResponse
+
+		if json.Unmarshal(respBody, &errResp) == nil {
+			httpErr.Message = errResp.Message
+		}
+
+		if !retryable(resp.StatusCode) {
+			return nil, backoff.Permanent(httpErr)
+		}
+
+		return nil, httpErr
+	}
+
+	if r.etag != nil {
+		*r.etag = resp.Header.Get("ETag")
+	}
+
+	return respBody, nil
+}
+
+func (c *Client) authorize(req *http.Request, signed bool) error {
+	if !signed {
+		if c.authToken != "" {
+			req.Header.Set("Authorization", "Bearer "+c.authToken)
+		}
+
+		return nil
+	}
+
+	if c.signer == nil {
+		return fmt.Errorf("signer is not set")
+	}
+
+	// the digest of the body is signed, if there is one
+	cfg := httpsig.DefaultGetSignerConfig()
+	if req.Body != http.NoBody {
+		cfg = httpsig.DefaultPostSignerConfig()
+	}
+
+	if err := httpsig.NewSigner(cfg, c.signer.privateKey).SignRequest(c.signer.publicKeyID, req); err != nil {
+		return fmt.Errorf("sign request: %w", err)
+	}
+
+	return nil
+}
+
+func retryable(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// Option is a Gatekeeper client instance option.
+type Option func(opts *Client)
+
+// WithHTTPClient allows providing HTTP client.
+func WithHTTPClient(c HTTPClient) Option {
+	return func(opts *Client) {
+		opts.httpClient = c
+	}
+}
+
+// WithAuthToken sets the bearer token for the token protected endpoints, i.e. the api-token of the Gatekeeper server.
+func WithAuthToken(token string) Option {
+	return func(opts *Client) {
+		opts.authToken = token
+	}
+}
+
+// WithSigner sets the key to sign requests to the endpoints authenticated with HTTP signatures. The public key ID is
+// a DID URL of the key in the authentication section of the DID document, e.g. did:example:123#key-1.
+func WithSigner(publicKeyID string, privateKey ed25519.PrivateKey) Option {
+	return func(opts *Client) {
+		opts.signer = &requestSigner{publicKeyID: publicKeyID, privateKey: privateKey}
+	}
+}
+
+// WithRetries sets how many times idempotent requests are retried on connection errors and on 429, 502, 503 and 504
+// responses, and the interval before the first retry, which grows exponentially. Defaults to 3 retries after 500ms.
+func WithRetries(maxRetries uint64, interval time.Duration) Option {
+	return func(opts *Client) {
+		opts.maxRetries = maxRetries
+		opts.retryInterval = interval
+	}
+}
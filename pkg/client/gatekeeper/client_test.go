@@ -0,0 +1,634 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package gatekeeper_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/aries-framework-go/pkg/doc/signature/verifier"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/ace/pkg/client/gatekeeper"
+	"github.com/trustbloc/ace/pkg/gatekeeper/audit"
+	"github.com/trustbloc/ace/pkg/gatekeeper/backup"
+	"github.com/trustbloc/ace/pkg/gatekeeper/delegation"
+	"github.com/trustbloc/ace/pkg/gatekeeper/policy"
+	"github.com/trustbloc/ace/pkg/gatekeeper/subscription"
+	"github.com/trustbloc/ace/pkg/gatekeeper/watermark"
+	"github.com/trustbloc/ace/pkg/httpsig"
+	"github.com/trustbloc/ace/pkg/restapi/gatekeeper/operation"
+	"github.com/trustbloc/ace/pkg/restapi/model"
+	"github.com/trustbloc/ace/pkg/restapi/mw/requestid"
+)
+
+const (
+	testToken   = "test-token"
+	testKeyID   = "did:example:collector#key-1"
+	testTicket  = "test-ticket"
+	testPolicy  = "containment-policy"
+	testDID     = "did:example:target"
+	noRetryWait = time.Millisecond
+)
+
+func TestClient(t *testing.T) {
+	pubKey, privKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	srv := newTestServer(t, pubKey)
+
+	c := gatekeeper.New(srv.URL+"/",
+		gatekeeper.WithAuthToken(testToken),
+		gatekeeper.WithSigner(testKeyID, privKey),
+	)
+
+	ctx := context.Background()
+
+	t.Run("test policies", func(t *testing.T) {
+		require.NoError(t, c.CreatePolicy(ctx, testPolicy, &policy.Policy{MinApprovers: 2}))
+
+		p, err := c.GetPolicy(ctx, testPolicy)
+		require.NoError(t, err)
+		require.Equal(t, testPolicy, p.ID)
+
+		p, etag, err := c.GetPolicyWithETag(ctx, testPolicy)
+		require.NoError(t, err)
+		require.Equal(t, `"v1"`, etag)
+
+		p.MinApprovers = 2
+
+		etag, err = c.UpdatePolicy(ctx, testPolicy, etag, p)
+		require.NoError(t, err)
+		require.Equal(t, `"v2"`, etag)
+
+		_, err = c.UpdatePolicy(ctx, testPolicy, `"v0"`, p)
+
+		var httpErr *gatekeeper.HTTPError
+
+		require.True(t, errors.As(err, &httpErr))
+		require.Equal(t, http.StatusPreconditionFailed, httpErr.StatusCode)
+
+		policies, err := c.ListPolicies(ctx)
+		require.NoError(t, err)
+		require.Len(t, policies, 1)
+
+		evaluated, err := c.EvaluatePolicies(ctx, &operation.EvaluateRequest{
+			Actor:       "did:example:collector",
+			Evaluations: []*operation.Evaluation{{PolicyID: testPolicy, Operation: "protect"}},
+		})
+		require.NoError(t, err)
+		require.True(t, evaluated.Results[0].Allowed)
+
+		require.NoError(t, c.DeletePolicy(ctx, testPolicy))
+	})
+
+	t.Run("test release flow", func(t *testing.T) {
+		protected, err := c.Protect(ctx, &operation.ProtectRequest{Policy: testPolicy, Target: "test ssn"})
+		require.NoError(t, err)
+		require.Equal(t, testDID, protected.DID)
+
+		rotated, err := c.Rotate(ctx, &operation.RotateRequest{DID: protected.DID, Target: "test ssn"})
+		require.NoError(t, err)
+		require.Equal(t, "did:example:rotated", rotated.DID)
+		require.JSONEq(t, `{"id":"continuity"}`, string(rotated.ContinuityCredential))
+
+		moved, err := c.Repolicy(ctx, protected.DID, &operation.RepolicyRequest{Target: "test ssn", Policy: "other"})
+		require.NoError(t, err)
+		require.Equal(t, protected.DID, moved.DID)
+
+		released, err := c.Release(ctx, &operation.ReleaseRequest{DID: protected.DID})
+		require.NoError(t, err)
+		require.Equal(t, testTicket, released.TicketID)
+
+		delegated, err := c.Delegate(ctx, testPolicy, &operation.DelegateRequest{
+			Delegate: "did:example:delegate",
+			NotAfter: time.Now().Add(time.Hour),
+		})
+		require.NoError(t, err)
+		require.Equal(t, "test-delegation", delegated.Delegation.ID)
+
+		subscribed, err := c.Subscribe(ctx, &operation.SubscribeRequest{
+			Notification: policy.Notification{Webhook: "https://handler.example.com/events"},
+		})
+		require.NoError(t, err)
+		require.Equal(t, "test-subscription", subscribed.Subscription.ID)
+
+		subscriptions, err := c.Subscriptions(ctx, nil)
+		require.NoError(t, err)
+		require.Len(t, subscriptions.Items, 1)
+
+		require.NoError(t, c.Unsubscribe(ctx, subscribed.Subscription.ID))
+
+		require.NoError(t, c.Authorize(ctx, released.TicketID, &operation.DecisionRequest{Comment: "approved"}))
+
+		status, err := c.TicketStatus(ctx, released.TicketID)
+		require.NoError(t, err)
+		require.Equal(t, "READY_TO_COLLECT", status.Status)
+
+		listed, err := c.ListTickets(ctx, &operation.ListTicketsRequest{
+			Requestor: "did:example:handler",
+			Statuses:  []string{"NEW"},
+		})
+		require.NoError(t, err)
+		require.Equal(t, 1, *listed.Total)
+		require.Equal(t, testTicket, listed.Items[0].ID)
+
+		ticket, err := c.GetTicket(ctx, released.TicketID)
+		require.NoError(t, err)
+		require.Equal(t, []string{"did:example:collector"}, ticket.ApprovedBy)
+
+		history, err := c.TicketHistory(ctx, released.TicketID)
+		require.NoError(t, err)
+		require.Len(t, history.History, 1)
+		require.Equal(t, "approve", history.History[0].Action)
+
+		collected, err := c.Collect(ctx, released.TicketID)
+		require.NoError(t, err)
+		require.Equal(t, "test-query", collected.QueryID)
+
+		brokenGlass, err := c.BreakGlass(ctx, &operation.BreakGlassRequest{
+			DID:           protected.DID,
+			Justification: "cardiac arrest",
+		})
+		require.NoError(t, err)
+		require.Equal(t, "test-query", brokenGlass.QueryID)
+
+		extracted, err := c.Extract(ctx, &operation.ExtractRequest{QueryID: collected.QueryID})
+		require.NoError(t, err)
+		require.Equal(t, "test ssn", extracted.Target)
+
+		batch, err := c.ExtractBatch(ctx, &operation.ExtractBatchRequest{QueryIDs: []string{collected.QueryID, "expired"}})
+		require.NoError(t, err)
+		require.Equal(t, []*operation.ExtractBatchItem{
+			{QueryID: collected.QueryID, Target: "test ssn"},
+			{QueryID: "expired", Error: "query expired", Status: http.StatusNotFound},
+		}, batch)
+
+		require.NoError(t, c.Purge(ctx))
+
+		snapshot, err := c.Backup(ctx)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"version":1}`, string(snapshot))
+
+		restored, err := c.Restore(ctx, snapshot)
+		require.NoError(t, err)
+		require.Equal(t, []*backup.Result{{Store: "ticket", Entries: 1}}, restored)
+
+		linked, err := c.Link(ctx, &operation.LinkRequest{Data: []*operation.LinkedData{{DID: testDID, Target: "test ssn"}}})
+		require.NoError(t, err)
+		require.Equal(t, []string{testDID}, linked.DIDs)
+
+		report, err := c.DSAR(ctx)
+		require.NoError(t, err)
+		require.Len(t, report.Data, 1)
+		require.Equal(t, testDID, report.Data[0].DID)
+
+		erased, err := c.Erase(ctx)
+		require.NoError(t, err)
+		require.Equal(t, []string{testDID}, erased.DIDs)
+
+		traced, err := c.Trace(ctx, &operation.TraceRequest{Data: extracted.Target})
+		require.NoError(t, err)
+		require.Equal(t, testTicket, traced.Extraction.TicketID)
+	})
+
+	t.Run("test audit log", func(t *testing.T) {
+		entries, err := c.AuditEntries(ctx, &model.PageRequest{Cursor: model.EncodeCursor(1), Limit: 10})
+		require.NoError(t, err)
+		require.Len(t, entries.Items, 1)
+		require.Equal(t, 2, entries.Items[0].Seq)
+		require.Equal(t, 2, entries.Head.Seq)
+
+		checkpoints, err := c.AuditCheckpoints(ctx)
+		require.NoError(t, err)
+		require.Len(t, checkpoints, 1)
+
+		report, err := c.VerifyAudit(ctx)
+		require.NoError(t, err)
+		require.True(t, report.Verified)
+	})
+
+	t.Run("test error response", func(t *testing.T) {
+		_, err := c.GetPolicy(ctx, "other-policy")
+		require.EqualError(t, err, "GET /v1/policy/other-policy: status 404: policy not found")
+
+		var httpErr *gatekeeper.HTTPError
+
+		require.True(t, errors.As(err, &httpErr))
+		require.Equal(t, http.StatusNotFound, httpErr.StatusCode)
+		require.Len(t, httpErr.RequestID, 32)
+	})
+
+	t.Run("test rejected ticket", func(t *testing.T) {
+		err := c.Reject(ctx, testTicket, &operation.DecisionRequest{Comment: "no legal basis"})
+		require.EqualError(t, err, "POST /v1/release/test-ticket/reject: status 409: ticket is rejected")
+	})
+
+	t.Run("test wrong token", func(t *testing.T) {
+		_, err := gatekeeper.New(srv.URL, gatekeeper.WithAuthToken("wrong")).ListPolicies(ctx)
+		require.EqualError(t, err, "GET /v1/policy: status 401")
+	})
+
+	t.Run("test missing signer", func(t *testing.T) {
+		_, err := gatekeeper.New(srv.URL).Protect(ctx, &operation.ProtectRequest{})
+		require.EqualError(t, err, "POST /v1/protect: signer is not set")
+	})
+
+	t.Run("test wrong signer", func(t *testing.T) {
+		_, otherKey, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+
+		_, err = gatekeeper.New(srv.URL, gatekeeper.WithSigner(testKeyID, otherKey)).Protect(ctx,
+			&operation.ProtectRequest{})
+		require.EqualError(t, err, "POST /v1/protect: status 401")
+	})
+}
+
+func TestClientRetries(t *testing.T) {
+	t.Run("test idempotent request is retried", func(t *testing.T) {
+		var requests int32
+
+		srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(&requests, 1) < 3 {
+				rw.WriteHeader(http.StatusServiceUnavailable)
+
+				return
+			}
+
+			respond(t, rw, &operation.TicketResponse{ID: testTicket})
+		}))
+		defer srv.Close()
+
+		ticket, err := gatekeeper.New(srv.URL, gatekeeper.WithRetries(3, noRetryWait)).GetTicket(context.Background(),
+			testTicket)
+		require.NoError(t, err)
+		require.Equal(t, testTicket, ticket.ID)
+		require.Equal(t, int32(3), atomic.LoadInt32(&requests))
+	})
+
+	t.Run("test error after retries", func(t *testing.T) {
+		var requests int32
+
+		srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&requests, 1)
+			rw.WriteHeader(http.StatusBadGateway)
+		}))
+		defer srv.Close()
+
+		err := gatekeeper.New(srv.URL, gatekeeper.WithRetries(2, noRetryWait)).Purge(context.Background())
+		require.EqualError(t, err, "POST /v1/purge: status 502")
+		require.Equal(t, int32(3), atomic.LoadInt32(&requests))
+	})
+
+	t.Run("test client error is not retried", func(t *testing.T) {
+		var requests int32
+
+		srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&requests, 1)
+			rw.WriteHeader(http.StatusBadRequest)
+		}))
+		defer srv.Close()
+
+		_, err := gatekeeper.New(srv.URL, gatekeeper.WithRetries(3, noRetryWait)).ListPolicies(context.Background())
+		require.EqualError(t, err, "GET /v1/policy: status 400")
+		require.Equal(t, int32(1), atomic.LoadInt32(&requests))
+	})
+
+	t.Run("test non idempotent request is not retried", func(t *testing.T) {
+		_, privKey, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+
+		var requests int32
+
+		srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&requests, 1)
+			rw.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer srv.Close()
+
+		_, err = gatekeeper.New(srv.URL, gatekeeper.WithSigner(testKeyID, privKey),
+			gatekeeper.WithRetries(3, noRetryWait)).Release(context.Background(), &operation.ReleaseRequest{})
+		require.EqualError(t, err, "POST /v1/release: status 503")
+		require.Equal(t, int32(1), atomic.LoadInt32(&requests))
+	})
+
+	t.Run("test server not reachable", func(t *testing.T) {
+		srv := httptest.NewServer(http.NotFoundHandler())
+		srv.Close()
+
+		_, err := gatekeeper.New(srv.URL, gatekeeper.WithRetries(1, noRetryWait)).GetPolicy(context.Background(),
+			testPolicy)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "GET /v1/policy/containment-policy")
+	})
+
+	t.Run("test invalid response", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			rw.Write([]byte("invalid json")) //nolint:errcheck
+		}))
+		defer srv.Close()
+
+		_, err := gatekeeper.New(srv.URL).Extract(context.Background(), &operation.ExtractRequest{})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "unmarshal response")
+	})
+}
+
+// newTestServer returns a server with the Gatekeeper endpoints used by the client, authenticated like Gatekeeper does.
+func newTestServer(t *testing.T, pubKey ed25519.PublicKey) *httptest.Server {
+	t.Helper()
+
+	sigVerifier := httpsig.NewVerifier(&keyResolver{pubKey: pubKey})
+
+	signed := func(h http.HandlerFunc) http.HandlerFunc {
+		return func(rw http.ResponseWriter, r *http.Request) {
+			if ok, _ := sigVerifier.VerifyRequest(r); !ok {
+				rw.WriteHeader(http.StatusUnauthorized)
+
+				return
+			}
+
+			h(rw, r)
+		}
+	}
+
+	token := func(h http.HandlerFunc) http.HandlerFunc {
+		return func(rw http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer "+testToken {
+				rw.WriteHeader(http.StatusUnauthorized)
+
+				return
+			}
+
+			h(rw, r)
+		}
+	}
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/v1/policy/"+testPolicy, token(func(rw http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPut {
+			var p policy.Policy
+
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&p))
+			require.Equal(t, 2, p.MinApprovers)
+
+			switch r.Header.Get("If-Match") {
+			case "", `"v1"`:
+				rw.Header().Set("ETag", `"v2"`)
+				respond(t, rw, nil)
+			default:
+				rw.WriteHeader(http.StatusPreconditionFailed)
+			}
+
+			return
+		}
+
+		if r.Method == http.MethodDelete {
+			respond(t, rw, nil)
+
+			return
+		}
+
+		rw.Header().Set("ETag", `"v1"`)
+		respond(t, rw, &policy.Policy{ID: testPolicy})
+	}))
+	mux.HandleFunc("/v1/policy/"+testPolicy+"/delegation", signed(func(rw http.ResponseWriter, r *http.Request) {
+		var req operation.DelegateRequest
+
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		require.Equal(t, "did:example:delegate", req.Delegate)
+
+		respond(t, rw, &operation.DelegateResponse{Delegation: &delegation.Delegation{
+			ID:       "test-delegation",
+			PolicyID: testPolicy,
+			Delegate: req.Delegate,
+			NotAfter: req.NotAfter,
+		}})
+	}))
+	mux.HandleFunc("/v1/policy/evaluate", token(func(rw http.ResponseWriter, r *http.Request) {
+		var req operation.EvaluateRequest
+
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		require.Equal(t, "did:example:collector", req.Actor)
+
+		respond(t, rw, &operation.EvaluateResponse{Results: []*operation.EvaluationResult{
+			{PolicyID: testPolicy, Operation: "protect", Allowed: true},
+		}})
+	}))
+	mux.HandleFunc("/v1/policy/", token(func(rw http.ResponseWriter, r *http.Request) {
+		rw.WriteHeader(http.StatusNotFound)
+		respond(t, rw, &model.ErrorResponse{Message: "policy not found"})
+	}))
+	mux.HandleFunc("/v1/policy", token(func(rw http.ResponseWriter, r *http.Request) {
+		respond(t, rw, &operation.ListPoliciesResponse{Policies: []*policy.Policy{{ID: testPolicy}}})
+	}))
+	mux.HandleFunc("/v1/protect", signed(func(rw http.ResponseWriter, r *http.Request) {
+		var req operation.ProtectRequest
+
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		require.Equal(t, testPolicy, req.Policy)
+
+		respond(t, rw, &operation.ProtectResponse{DID: testDID})
+	}))
+	mux.HandleFunc("/v1/protect/rotate", signed(func(rw http.ResponseWriter, r *http.Request) {
+		var req operation.RotateRequest
+
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		require.Equal(t, testDID, req.DID)
+
+		respond(t, rw, &operation.RotateResponse{
+			DID:                  "did:example:rotated",
+			ContinuityCredential: json.RawMessage(`{"id":"continuity"}`),
+		})
+	}))
+	mux.HandleFunc("/v1/protect/"+testDID+"/repolicy", signed(func(rw http.ResponseWriter, r *http.Request) {
+		var req operation.RepolicyRequest
+
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		require.Equal(t, "other", req.Policy)
+
+		respond(t, rw, &operation.RepolicyResponse{DID: testDID})
+	}))
+	mux.HandleFunc("/v1/breakglass", signed(func(rw http.ResponseWriter, r *http.Request) {
+		var req operation.BreakGlassRequest
+
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		require.Equal(t, "cardiac arrest", req.Justification)
+
+		respond(t, rw, &operation.BreakGlassResponse{TicketID: "break-glass-ticket", QueryID: "test-query"})
+	}))
+	mux.HandleFunc("/v1/subscriptions", signed(func(rw http.ResponseWriter, r *http.Request) {
+		subscriptions := []*subscription.Subscription{{ID: "test-subscription"}}
+
+		if r.Method == http.MethodGet {
+			respond(t, rw, &operation.ListSubscriptionsResponse{Items: subscriptions})
+
+			return
+		}
+
+		var req operation.SubscribeRequest
+
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		require.Equal(t, "https://handler.example.com/events", req.Webhook)
+
+		respond(t, rw, &operation.SubscribeResponse{Subscription: subscriptions[0]})
+	}))
+	mux.HandleFunc("/v1/subscriptions/test-subscription", signed(func(rw http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodDelete, r.Method)
+
+		respond(t, rw, nil)
+	}))
+	mux.HandleFunc("/v1/release", func(rw http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			token(func(rw http.ResponseWriter, r *http.Request) {
+				require.Equal(t, "requestor=did%3Aexample%3Ahandler&status=NEW", r.URL.RawQuery)
+
+				respond(t, rw, &operation.ListTicketsResponse{
+					Items: []*operation.TicketResponse{{ID: testTicket}},
+					Page:  model.NewPage(0, 1, 1).WithTotal(1),
+				})
+			})(rw, r)
+
+			return
+		}
+
+		signed(func(rw http.ResponseWriter, r *http.Request) {
+			respond(t, rw, &operation.ReleaseResponse{TicketID: testTicket})
+		})(rw, r)
+	})
+	mux.HandleFunc("/v1/release/"+testTicket+"/authorize", signed(func(rw http.ResponseWriter, r *http.Request) {
+		var req operation.DecisionRequest
+
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		require.Equal(t, "approved", req.Comment)
+
+		respond(t, rw, nil)
+	}))
+	mux.HandleFunc("/v1/release/"+testTicket+"/reject", signed(func(rw http.ResponseWriter, r *http.Request) {
+		rw.WriteHeader(http.StatusConflict)
+		respond(t, rw, &model.ErrorResponse{Message: "ticket is rejected"})
+	}))
+	mux.HandleFunc("/v1/release/"+testTicket+"/status", signed(func(rw http.ResponseWriter, r *http.Request) {
+		respond(t, rw, &operation.TicketStatusResponse{Status: "READY_TO_COLLECT"})
+	}))
+	mux.HandleFunc("/v1/release/"+testTicket, token(func(rw http.ResponseWriter, r *http.Request) {
+		respond(t, rw, &operation.TicketResponse{ID: testTicket, ApprovedBy: []string{"did:example:collector"}})
+	}))
+	mux.HandleFunc("/v1/release/"+testTicket+"/history", token(func(rw http.ResponseWriter, r *http.Request) {
+		respond(t, rw, &operation.TicketHistoryResponse{
+			TicketID: testTicket,
+			History:  []*operation.TicketEvent{{Action: "approve", Actor: "did:example:collector"}},
+		})
+	}))
+	mux.HandleFunc("/v1/release/"+testTicket+"/collect", signed(func(rw http.ResponseWriter, r *http.Request) {
+		respond(t, rw, &operation.CollectResponse{QueryID: "test-query"})
+	}))
+	mux.HandleFunc("/v1/extract", func(rw http.ResponseWriter, r *http.Request) {
+		respond(t, rw, &operation.ExtractResponse{Target: "test ssn"})
+	})
+	mux.HandleFunc("/v1/purge", token(func(rw http.ResponseWriter, r *http.Request) {
+		respond(t, rw, nil)
+	}))
+	mux.HandleFunc("/backup", token(func(rw http.ResponseWriter, r *http.Request) {
+		rw.Write([]byte(`{"version":1}`)) //nolint:errcheck
+	}))
+	mux.HandleFunc("/restore", token(func(rw http.ResponseWriter, r *http.Request) {
+		b, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"version":1}`, string(b))
+
+		respond(t, rw, map[string]interface{}{"stores": []*backup.Result{{Store: "ticket", Entries: 1}}})
+	}))
+	mux.HandleFunc("/v1/trace", token(func(rw http.ResponseWriter, r *http.Request) {
+		var req operation.TraceRequest
+
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		require.Equal(t, "test ssn", req.Data)
+
+		respond(t, rw, &operation.TraceResponse{Extraction: &watermark.Record{DID: testDID, TicketID: testTicket}})
+	}))
+	mux.HandleFunc("/v1/audit/entries", token(func(rw http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "cursor="+model.EncodeCursor(1)+"&limit=10", r.URL.RawQuery)
+
+		respond(t, rw, &operation.AuditEntriesResponse{
+			Items: []*audit.Entry{{Seq: 2, TicketID: testTicket, DID: testDID}},
+			Head:  &audit.Head{Seq: 2},
+		})
+	}))
+	mux.HandleFunc("/v1/audit/checkpoints", token(func(rw http.ResponseWriter, r *http.Request) {
+		respond(t, rw, &operation.CheckpointsResponse{Checkpoints: []json.RawMessage{[]byte(`{"id":"checkpoint"}`)}})
+	}))
+	mux.HandleFunc("/v1/audit/verify", token(func(rw http.ResponseWriter, r *http.Request) {
+		respond(t, rw, &audit.Report{Verified: true, Entries: 2, Head: &audit.Head{Seq: 2}})
+	}))
+	mux.HandleFunc("/v1/extract/batch", func(rw http.ResponseWriter, r *http.Request) {
+		var req operation.ExtractBatchRequest
+
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		require.Equal(t, []string{"test-query", "expired"}, req.QueryIDs)
+
+		rw.Header().Set("Content-Type", "application/x-ndjson")
+
+		enc := json.NewEncoder(rw)
+		require.NoError(t, enc.Encode(&operation.ExtractBatchItem{QueryID: "test-query", Target: "test ssn"}))
+		require.NoError(t, enc.Encode(&operation.ExtractBatchItem{
+			QueryID: "expired", Error: "query expired", Status: http.StatusNotFound,
+		}))
+	})
+	mux.HandleFunc("/v1/dsar/link", signed(func(rw http.ResponseWriter, r *http.Request) {
+		var req operation.LinkRequest
+
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		require.Len(t, req.Data, 1)
+
+		respond(t, rw, &operation.LinkResponse{Subject: "did:example:subject", DIDs: []string{req.Data[0].DID}})
+	}))
+	mux.HandleFunc("/v1/dsar", signed(func(rw http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			respond(t, rw, &operation.EraseResponse{Subject: "did:example:subject", DIDs: []string{testDID}})
+
+			return
+		}
+
+		respond(t, rw, &operation.DSARResponse{Subject: "did:example:subject", Data: []*operation.SubjectData{{
+			DID:      testDID,
+			PolicyID: testPolicy,
+		}}})
+	}))
+
+	srv := httptest.NewServer(requestid.New()(mux))
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func respond(t *testing.T, rw http.ResponseWriter, v interface{}) {
+	t.Helper()
+
+	require.NoError(t, json.NewEncoder(rw).Encode(v))
+}
+
+type keyResolver struct {
+	pubKey ed25519.PublicKey
+}
+
+func (r *keyResolver) Resolve(keyID string) (*verifier.PublicKey, error) {
+	if keyID != testKeyID {
+		return nil, errors.New("key not found")
+	}
+
+	return &verifier.PublicKey{Type: "Ed25519VerificationKey2018", Value: r.pubKey}, nil
+}
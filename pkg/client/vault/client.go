@@ -6,7 +6,6 @@ SPDX-License-Identifier: Apache-2.0
 package vault
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -22,7 +21,9 @@ import (
 )
 
 const (
+	deleteVaultPath          = "/vaults/%s"
 	saveDocPath              = "/vaults/%s/docs"
+	queryDocsPath            = "/vaults/%s/docs?tag=%s"
 	getDocMetadataPath       = "/vaults/%s/docs/%s/metadata"
 	getAuthorizationsPath    = "/vaults/%s/authorizations/%s"
 	createAuthorizationsPath = "/vaults/%s/authorizations"
@@ -37,18 +38,23 @@ type HTTPClient interface {
 
 // Vault defines vault client interface.
 type Vault interface {
-	CreateVault() (*vault.CreatedVault, error)
-	SaveDoc(vaultID, id string, content interface{}) (*vault.DocumentMetadata, error)
-	GetDocMetaData(vaultID, docID string) (*vault.DocumentMetadata, error)
-	CreateAuthorization(vaultID, requestingParty string,
+	CreateVault(ctx context.Context) (*vault.CreatedVault, error)
+	DeleteVault(ctx context.Context, vaultID string) error
+	SaveDoc(ctx context.Context, vaultID, id string, content interface{},
+		tags ...string) (*vault.DocumentMetadata, error)
+	GetDocMetaData(ctx context.Context, vaultID, docID string) (*vault.DocumentMetadata, error)
+	QueryDocs(ctx context.Context, vaultID, tag string) ([]*vault.DocumentMetadata, error)
+	CreateAuthorization(ctx context.Context, vaultID, requestingParty string,
 		scope *vault.AuthorizationsScope) (*vault.CreatedAuthorization, error)
-	GetAuthorization(vaultID, id string) (*vault.CreatedAuthorization, error)
+	GetAuthorization(ctx context.Context, vaultID, id string) (*vault.CreatedAuthorization, error)
 }
 
 // Client for vault.
 type Client struct {
-	httpClient HTTPClient
-	baseURL    string
+	httpClient       HTTPClient
+	instances        []*instance
+	failureThreshold int
+	cooldown         time.Duration
 }
 
 // New return new instance of vault client.
@@ -57,7 +63,7 @@ func New(baseURL string, opts ...Option) *Client {
 		httpClient: &http.Client{
 			Timeout: time.Minute,
 		},
-		baseURL: baseURL,
+		instances: []*instance{{baseURL: baseURL}},
 	}
 
 	for _, opt := range opts {
@@ -68,14 +74,8 @@ func New(baseURL string, opts ...Option) *Client {
 }
 
 // CreateVault creates a new vault.
-func (c *Client) CreateVault() (*vault.CreatedVault, error) {
-	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, c.baseURL+operation.CreateVaultPath,
-		http.NoBody)
-	if err != nil {
-		return nil, fmt.Errorf("new request: %w", err)
-	}
-
-	resp, err := c.sendHTTPRequest(req, http.StatusCreated)
+func (c *Client) CreateVault(ctx context.Context) (*vault.CreatedVault, error) {
+	resp, err := c.send(ctx, http.MethodPost, operation.CreateVaultPath, nil, http.StatusCreated)
 	if err != nil {
 		return nil, fmt.Errorf("http request: %w", err)
 	}
@@ -90,9 +90,21 @@ func (c *Client) CreateVault() (*vault.CreatedVault, error) {
 	return &result, nil
 }
 
-// SaveDoc saves a document.
-func (c *Client) SaveDoc(vaultID, id string, content interface{}) (*vault.DocumentMetadata, error) {
-	target := c.baseURL + fmt.Sprintf(saveDocPath, url.QueryEscape(vaultID))
+// DeleteVault deletes a vault.
+func (c *Client) DeleteVault(ctx context.Context, vaultID string) error {
+	path := fmt.Sprintf(deleteVaultPath, url.QueryEscape(vaultID))
+
+	if _, err := c.send(ctx, http.MethodDelete, path, nil, http.StatusOK); err != nil {
+		return fmt.Errorf("http request: %w", err)
+	}
+
+	return nil
+}
+
+// SaveDoc saves a document, indexed with the tags, as name:value or name.
+func (c *Client) SaveDoc(ctx context.Context, vaultID, id string, content interface{},
+	tags ...string) (*vault.DocumentMetadata, error) {
+	path := fmt.Sprintf(saveDocPath, url.QueryEscape(vaultID))
 
 	raw, err := json.Marshal(content)
 	if err != nil {
@@ -102,17 +114,13 @@ func (c *Client) SaveDoc(vaultID, id string, content interface{}) (*vault.Docume
 	src, err := json.Marshal(operation.SaveDocRequestBody{
 		ID:      id,
 		Content: raw,
+		Tags:    tags,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("marshal: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, target, bytes.NewReader(src))
-	if err != nil {
-		return nil, fmt.Errorf("new request: %w", err)
-	}
-
-	resp, err := c.sendHTTPRequest(req, http.StatusCreated)
+	resp, err := c.send(ctx, http.MethodPost, path, src, http.StatusCreated)
 	if err != nil {
 		return nil, fmt.Errorf("http request: %w", err)
 	}
@@ -128,15 +136,11 @@ func (c *Client) SaveDoc(vaultID, id string, content interface{}) (*vault.Docume
 }
 
 // GetDocMetaData get doc metadata.
-func (c *Client) GetDocMetaData(vaultID, docID string) (*vault.DocumentMetadata, error) { // nolint: dupl
-	target := c.baseURL + fmt.Sprintf(getDocMetadataPath, url.QueryEscape(vaultID), url.QueryEscape(docID))
+func (c *Client) GetDocMetaData( // nolint: dupl
+	ctx context.Context, vaultID, docID string) (*vault.DocumentMetadata, error) {
+	path := fmt.Sprintf(getDocMetadataPath, url.QueryEscape(vaultID), url.QueryEscape(docID))
 
-	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, target, http.NoBody)
-	if err != nil {
-		return nil, fmt.Errorf("new request: %w", err)
-	}
-
-	resp, err := c.sendHTTPRequest(req, http.StatusOK)
+	resp, err := c.send(ctx, http.MethodGet, path, nil, http.StatusOK)
 	if err != nil {
 		return nil, fmt.Errorf("http request: %w", err)
 	}
@@ -149,10 +153,29 @@ func (c *Client) GetDocMetaData(vaultID, docID string) (*vault.DocumentMetadata,
 	return &docMeta, nil
 }
 
+// QueryDocs returns the metadata of the documents saved with the tag, as name:value, or name for any value.
+func (c *Client) QueryDocs(ctx context.Context, vaultID, tag string) ([]*vault.DocumentMetadata, error) {
+	path := fmt.Sprintf(queryDocsPath, url.QueryEscape(vaultID), url.QueryEscape(tag))
+
+	resp, err := c.send(ctx, http.MethodGet, path, nil, http.StatusOK)
+	if err != nil {
+		return nil, fmt.Errorf("http request: %w", err)
+	}
+
+	var docs []*vault.DocumentMetadata
+
+	err = json.Unmarshal(resp, &docs)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshal to DocumentMetadata: %w", err)
+	}
+
+	return docs, nil
+}
+
 // CreateAuthorization creates an authorization.
-func (c *Client) CreateAuthorization(vaultID, requestingParty string, scope *vault.AuthorizationsScope,
-) (*vault.CreatedAuthorization, error) {
-	target := c.baseURL + fmt.Sprintf(createAuthorizationsPath, url.QueryEscape(vaultID))
+func (c *Client) CreateAuthorization(ctx context.Context, vaultID, requestingParty string,
+	scope *vault.AuthorizationsScope) (*vault.CreatedAuthorization, error) {
+	path := fmt.Sprintf(createAuthorizationsPath, url.QueryEscape(vaultID))
 
 	src, err := json.Marshal(operation.CreateAuthorizationsBody{
 		RequestingParty: requestingParty,
@@ -162,12 +185,7 @@ func (c *Client) CreateAuthorization(vaultID, requestingParty string, scope *vau
 		return nil, fmt.Errorf("marshal: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, target, bytes.NewReader(src))
-	if err != nil {
-		return nil, fmt.Errorf("new request: %w", err)
-	}
-
-	resp, err := c.sendHTTPRequest(req, http.StatusCreated)
+	resp, err := c.send(ctx, http.MethodPost, path, src, http.StatusCreated)
 	if err != nil {
 		return nil, fmt.Errorf("http request: %w", err)
 	}
@@ -181,15 +199,11 @@ func (c *Client) CreateAuthorization(vaultID, requestingParty string, scope *vau
 }
 
 // GetAuthorization returns an authorization.
-func (c *Client) GetAuthorization(vaultID, id string) (*vault.CreatedAuthorization, error) { // nolint: dupl
-	target := c.baseURL + fmt.Sprintf(getAuthorizationsPath, url.QueryEscape(vaultID), url.QueryEscape(id))
+func (c *Client) GetAuthorization( // nolint: dupl
+	ctx context.Context, vaultID, id string) (*vault.CreatedAuthorization, error) {
+	path := fmt.Sprintf(getAuthorizationsPath, url.QueryEscape(vaultID), url.QueryEscape(id))
 
-	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, target, http.NoBody)
-	if err != nil {
-		return nil, fmt.Errorf("new request: %w", err)
-	}
-
-	resp, err := c.sendHTTPRequest(req, http.StatusOK)
+	resp, err := c.send(ctx, http.MethodGet, path, nil, http.StatusOK)
 	if err != nil {
 		return nil, fmt.Errorf("http request: %w", err)
 	}
@@ -205,7 +219,7 @@ func (c *Client) GetAuthorization(vaultID, id string) (*vault.CreatedAuthorizati
 func (c *Client) sendHTTPRequest(req *http.Request, status int) ([]byte, error) {
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, err
+		return nil, unavailableError{err: err}
 	}
 
 	defer func() {
@@ -221,7 +235,13 @@ func (c *Client) sendHTTPRequest(req *http.Request, status int) ([]byte, error)
 	}
 
 	if resp.StatusCode != status {
-		return nil, fmt.Errorf("failed to read response body for status %d: %s", resp.StatusCode, string(body))
+		err = fmt.Errorf("failed to read response body for status %d: %s", resp.StatusCode, string(body))
+
+		if unavailableStatus(resp.StatusCode) {
+			return nil, unavailableError{err: err}
+		}
+
+		return nil, err
 	}
 
 	return body, nil
@@ -236,3 +256,22 @@ func WithHTTPClient(c HTTPClient) Option {
 		opts.httpClient = c
 	}
 }
+
+// WithFailoverURLs sets the base URLs of other instances of the vault server, sharing the storage of the first one.
+// The requests fail over to them, in order, when an instance can't be reached or responds with 502, 503 or 504.
+func WithFailoverURLs(urls ...string) Option {
+	return func(opts *Client) {
+		for _, u := range urls {
+			opts.instances = append(opts.instances, &instance{baseURL: u})
+		}
+	}
+}
+
+// WithCircuitBreaker skips an instance for the cooldown after the threshold of consecutive failed requests, it is
+// used again once its health check succeeds. Disabled by default.
+func WithCircuitBreaker(threshold int, cooldown time.Duration) Option {
+	return func(opts *Client) {
+		opts.failureThreshold = threshold
+		opts.cooldown = cooldown
+	}
+}
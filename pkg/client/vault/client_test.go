@@ -6,12 +6,16 @@ SPDX-License-Identifier: Apache-2.0
 package vault //nolint: testpackage
 
 import (
+	"context"
 	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
@@ -22,7 +26,7 @@ func TestClient_GetDocMetaData(t *testing.T) {
 	t.Run("test error from http post", func(t *testing.T) {
 		v := New("")
 
-		_, err := v.GetDocMetaData("v1", "doc1")
+		_, err := v.GetDocMetaData(context.Background(), "v1", "doc1")
 		require.Error(t, err)
 		require.Contains(t, err.Error(), "unsupported protocol scheme")
 	})
@@ -35,7 +39,7 @@ func TestClient_GetDocMetaData(t *testing.T) {
 
 		v := New(serv.URL)
 
-		_, err := v.GetDocMetaData("v1", "doc1")
+		_, err := v.GetDocMetaData(context.Background(), "v1", "doc1")
 		require.Error(t, err)
 		require.Contains(t, err.Error(), "failed to read response body for status 500")
 	})
@@ -50,7 +54,7 @@ func TestClient_GetDocMetaData(t *testing.T) {
 
 		v := New(serv.URL)
 
-		_, err := v.GetDocMetaData("v1", "doc1")
+		_, err := v.GetDocMetaData(context.Background(), "v1", "doc1")
 		require.Error(t, err)
 		require.Contains(t, err.Error(), "failed to unmarshal resp to vault doc meta")
 	})
@@ -73,7 +77,7 @@ func TestClient_GetDocMetaData(t *testing.T) {
 			},
 		}))
 
-		p, err := v.GetDocMetaData("v1", "doc1")
+		p, err := v.GetDocMetaData(context.Background(), "v1", "doc1")
 		require.NoError(t, err)
 		require.Equal(t, "test", p.ID)
 	})
@@ -81,13 +85,13 @@ func TestClient_GetDocMetaData(t *testing.T) {
 
 func TestClient_CreateVault(t *testing.T) {
 	t.Run("Send request (error)", func(t *testing.T) {
-		_, err := New("").CreateVault()
+		_, err := New("").CreateVault(context.Background())
 		require.Error(t, err)
 		require.Contains(t, err.Error(), "unsupported protocol scheme")
 	})
 
 	t.Run("Invalid URL", func(t *testing.T) {
-		_, err := New("http://user^foo.com").CreateVault()
+		_, err := New("http://user^foo.com").CreateVault(context.Background())
 		require.Error(t, err)
 		require.Contains(t, err.Error(), "invalid character \"^\" in host name")
 	})
@@ -100,7 +104,7 @@ func TestClient_CreateVault(t *testing.T) {
 		}))
 		defer serv.Close()
 
-		_, err := New(serv.URL).CreateVault()
+		_, err := New(serv.URL).CreateVault(context.Background())
 		require.Error(t, err)
 		require.Contains(t, err.Error(), "unmarshal to CreatedVault")
 	})
@@ -119,12 +123,48 @@ func TestClient_CreateVault(t *testing.T) {
 		}))
 		defer serv.Close()
 
-		p, err := New(serv.URL).CreateVault()
+		p, err := New(serv.URL).CreateVault(context.Background())
 		require.NoError(t, err)
 		require.Equal(t, ID, p.ID)
 	})
 }
 
+func TestClient_DeleteVault(t *testing.T) {
+	t.Run("Send request (error)", func(t *testing.T) {
+		err := New("").DeleteVault(context.Background(), "did:example:vault")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "unsupported protocol scheme")
+	})
+
+	t.Run("Invalid URL", func(t *testing.T) {
+		err := New("http://user^foo.com").DeleteVault(context.Background(), "did:example:vault")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid character \"^\" in host name")
+	})
+
+	t.Run("Unexpected status", func(t *testing.T) {
+		serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		}))
+		defer serv.Close()
+
+		err := New(serv.URL).DeleteVault(context.Background(), "did:example:vault")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "status 404")
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, http.MethodDelete, r.Method)
+			require.Equal(t, "/vaults/did:example:vault", r.URL.Path)
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer serv.Close()
+
+		require.NoError(t, New(serv.URL).DeleteVault(context.Background(), "did:example:vault"))
+	})
+}
+
 func TestClient_CreateAuthorization(t *testing.T) {
 	const (
 		vID = "vID"
@@ -132,7 +172,7 @@ func TestClient_CreateAuthorization(t *testing.T) {
 	)
 
 	t.Run("Send request (error)", func(t *testing.T) {
-		_, err := New("").CreateAuthorization(vID, rp, &vault.AuthorizationsScope{})
+		_, err := New("").CreateAuthorization(context.Background(), vID, rp, &vault.AuthorizationsScope{})
 		require.Error(t, err)
 		require.Contains(t, err.Error(), "unsupported protocol scheme")
 	})
@@ -145,7 +185,7 @@ func TestClient_CreateAuthorization(t *testing.T) {
 		}))
 		defer serv.Close()
 
-		_, err := New(serv.URL).CreateAuthorization(vID, rp, &vault.AuthorizationsScope{})
+		_, err := New(serv.URL).CreateAuthorization(context.Background(), vID, rp, &vault.AuthorizationsScope{})
 		require.Error(t, err)
 		require.Contains(t, err.Error(), "unmarshal to CreatedAuthorization")
 	})
@@ -164,7 +204,7 @@ func TestClient_CreateAuthorization(t *testing.T) {
 		}))
 		defer serv.Close()
 
-		p, err := New(serv.URL).CreateAuthorization(vID, rp, &vault.AuthorizationsScope{})
+		p, err := New(serv.URL).CreateAuthorization(context.Background(), vID, rp, &vault.AuthorizationsScope{})
 		require.NoError(t, err)
 		require.Equal(t, ID, p.ID)
 	})
@@ -177,7 +217,7 @@ func TestClient_SaveDoc(t *testing.T) {
 	)
 
 	t.Run("Send request (error)", func(t *testing.T) {
-		_, err := New("").SaveDoc(vID, ID, nil)
+		_, err := New("").SaveDoc(context.Background(), vID, ID, nil)
 		require.Error(t, err)
 		require.Contains(t, err.Error(), "unsupported protocol scheme")
 	})
@@ -190,7 +230,7 @@ func TestClient_SaveDoc(t *testing.T) {
 		}))
 		defer serv.Close()
 
-		_, err := New(serv.URL).SaveDoc(vID, ID, nil)
+		_, err := New(serv.URL).SaveDoc(context.Background(), vID, ID, nil)
 		require.Error(t, err)
 		require.Contains(t, err.Error(), "unmarshal to DocumentMetadata")
 	})
@@ -209,15 +249,68 @@ func TestClient_SaveDoc(t *testing.T) {
 		}))
 		defer serv.Close()
 
-		p, err := New(serv.URL).SaveDoc(vID, ID, nil)
+		p, err := New(serv.URL).SaveDoc(context.Background(), vID, ID, nil)
 		require.NoError(t, err)
 		require.Equal(t, ID, p.ID)
 	})
 }
 
+func TestClient_QueryDocs(t *testing.T) {
+	t.Run("Send request (error)", func(t *testing.T) {
+		_, err := New("").QueryDocs(context.Background(), "vid", "type:consent")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "unsupported protocol scheme")
+	})
+
+	t.Run("Unmarshal (error)", func(t *testing.T) {
+		serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, err := fmt.Fprint(w, "wrongValue")
+			require.NoError(t, err)
+		}))
+		defer serv.Close()
+
+		_, err := New(serv.URL).QueryDocs(context.Background(), "vid", "type:consent")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "unmarshal to DocumentMetadata")
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		var saved map[string]interface{}
+
+		serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodPost {
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&saved))
+
+				w.WriteHeader(http.StatusCreated)
+				_, err := fmt.Fprint(w, `{"docID":"ID"}`)
+				require.NoError(t, err)
+
+				return
+			}
+
+			require.Equal(t, "/vaults/vid/docs", r.URL.Path)
+			require.Equal(t, "type:consent", r.URL.Query().Get("tag"))
+
+			_, err := fmt.Fprint(w, `[{"docID":"ID"}]`)
+			require.NoError(t, err)
+		}))
+		defer serv.Close()
+
+		c := New(serv.URL)
+
+		_, err := c.SaveDoc(context.Background(), "vid", "ID", nil, "type:consent")
+		require.NoError(t, err)
+		require.Equal(t, []interface{}{"type:consent"}, saved["tags"])
+
+		docs, err := c.QueryDocs(context.Background(), "vid", "type:consent")
+		require.NoError(t, err)
+		require.Equal(t, []*vault.DocumentMetadata{{ID: "ID"}}, docs)
+	})
+}
+
 func TestClient_GetAuthorization(t *testing.T) {
 	t.Run("Send request (error)", func(t *testing.T) {
-		_, err := New("").GetAuthorization("vid", "id")
+		_, err := New("").GetAuthorization(context.Background(), "vid", "id")
 		require.Error(t, err)
 		require.Contains(t, err.Error(), "unsupported protocol scheme")
 	})
@@ -230,7 +323,7 @@ func TestClient_GetAuthorization(t *testing.T) {
 		}))
 		defer serv.Close()
 
-		_, err := New(serv.URL).GetAuthorization("vid", "id")
+		_, err := New(serv.URL).GetAuthorization(context.Background(), "vid", "id")
 		require.Error(t, err)
 		require.Contains(t, err.Error(), "unmarshal to CreatedAuthorization")
 	})
@@ -249,8 +342,109 @@ func TestClient_GetAuthorization(t *testing.T) {
 		}))
 		defer serv.Close()
 
-		p, err := New(serv.URL).GetAuthorization("vid", "id")
+		p, err := New(serv.URL).GetAuthorization(context.Background(), "vid", "id")
 		require.NoError(t, err)
 		require.Equal(t, ID, p.ID)
 	})
 }
+
+func TestClient_Failover(t *testing.T) {
+	newServer := func(status int, hits *int32) *httptest.Server {
+		serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == healthCheckPath {
+				w.WriteHeader(http.StatusOK)
+
+				return
+			}
+
+			atomic.AddInt32(hits, 1)
+
+			w.WriteHeader(status)
+			_, err := fmt.Fprint(w, `{"docID":"doc1"}`)
+			require.NoError(t, err)
+		}))
+		t.Cleanup(serv.Close)
+
+		return serv
+	}
+
+	t.Run("Fails over to the next instance", func(t *testing.T) {
+		var primaryHits, secondaryHits int32
+
+		primary := newServer(http.StatusServiceUnavailable, &primaryHits)
+		secondary := newServer(http.StatusOK, &secondaryHits)
+
+		v := New(primary.URL, WithFailoverURLs("", secondary.URL))
+
+		doc, err := v.GetDocMetaData(context.Background(), "v1", "doc1")
+		require.NoError(t, err)
+		require.Equal(t, "doc1", doc.ID)
+		require.Equal(t, int32(1), primaryHits)
+		require.Equal(t, int32(1), secondaryHits)
+	})
+
+	t.Run("Does not fail over on other errors", func(t *testing.T) {
+		var primaryHits, secondaryHits int32
+
+		primary := newServer(http.StatusInternalServerError, &primaryHits)
+		secondary := newServer(http.StatusOK, &secondaryHits)
+
+		_, err := New(primary.URL, WithFailoverURLs(secondary.URL)).GetDocMetaData(context.Background(), "v1", "doc1")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "status 500")
+		require.Zero(t, secondaryHits)
+	})
+
+	t.Run("Skips the instance while its circuit is open", func(t *testing.T) {
+		var primaryHits, secondaryHits int32
+
+		primary := newServer(http.StatusBadGateway, &primaryHits)
+		secondary := newServer(http.StatusOK, &secondaryHits)
+
+		v := New(primary.URL, WithFailoverURLs(secondary.URL), WithCircuitBreaker(1, time.Hour))
+
+		for i := 0; i < 3; i++ {
+			_, err := v.GetDocMetaData(context.Background(), "v1", "doc1")
+			require.NoError(t, err)
+		}
+
+		require.Equal(t, int32(1), primaryHits)
+		require.Equal(t, int32(3), secondaryHits)
+	})
+
+	t.Run("Uses the instance again once it is healthy", func(t *testing.T) {
+		var primaryHits, secondaryHits int32
+
+		primary := newServer(http.StatusOK, &primaryHits)
+		secondary := newServer(http.StatusOK, &secondaryHits)
+
+		v := New(primary.URL, WithFailoverURLs(secondary.URL), WithCircuitBreaker(1, time.Millisecond))
+		v.failed(v.instances[0])
+
+		time.Sleep(5 * time.Millisecond)
+
+		_, err := v.GetDocMetaData(context.Background(), "v1", "doc1")
+		require.NoError(t, err)
+		require.Equal(t, int32(1), primaryHits)
+		require.Zero(t, secondaryHits)
+	})
+
+	t.Run("All instances are unavailable", func(t *testing.T) {
+		var primaryHits, secondaryHits int32
+
+		primary := newServer(http.StatusServiceUnavailable, &primaryHits)
+		secondary := newServer(http.StatusGatewayTimeout, &secondaryHits)
+
+		v := New(primary.URL, WithFailoverURLs(secondary.URL), WithCircuitBreaker(1, time.Hour))
+
+		_, err := v.GetDocMetaData(context.Background(), "v1", "doc1")
+		require.True(t, errors.Is(err, ErrUnavailable), err)
+		require.Contains(t, err.Error(), "status 504")
+
+		_, err = v.GetDocMetaData(context.Background(), "v1", "doc1")
+		require.True(t, errors.Is(err, ErrUnavailable), err)
+		require.Contains(t, err.Error(), "circuit open")
+		require.Equal(t, int32(1), primaryHits)
+		require.Equal(t, int32(1), secondaryHits)
+	})
+}
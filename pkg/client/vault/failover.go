@@ -0,0 +1,169 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vault
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const healthCheckPath = "/healthcheck"
+
+// ErrUnavailable is returned when none of the vault-server instances could serve the request.
+var ErrUnavailable = errors.New("vault server unavailable")
+
+// instance is a vault-server instance with its circuit breaker. The breaker opens after the failure threshold of
+// consecutive failures, the instance is then skipped until the cooldown elapses and its health check succeeds.
+type instance struct {
+	baseURL string
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+}
+
+// send sends the request to the first available instance, failing over to the next one if the instance is
+// unavailable: it can't be reached or it responds with 502, 503 or 504. Any other response is final.
+func (c *Client) send(ctx context.Context, method, path string, body []byte, status int) ([]byte, error) {
+	var lastErr error
+
+	errs := make([]string, 0, len(c.instances))
+
+	for _, in := range c.instances {
+		if !c.available(ctx, in) {
+			errs = append(errs, fmt.Sprintf("%s: circuit open", in.baseURL))
+
+			continue
+		}
+
+		var reqBody io.Reader = http.NoBody
+		if body != nil {
+			reqBody = bytes.NewReader(body)
+		}
+
+		req, err := http.NewRequestWithContext(ctx, method, in.baseURL+path, reqBody)
+		if err != nil {
+			return nil, fmt.Errorf("new request: %w", err)
+		}
+
+		resp, err := c.sendHTTPRequest(req, status)
+		if !errors.As(err, &unavailableError{}) {
+			c.succeeded(in)
+
+			return resp, err
+		}
+
+		if ctx.Err() != nil {
+			return nil, err
+		}
+
+		c.failed(in)
+
+		lastErr = err
+
+		errs = append(errs, fmt.Sprintf("%s: %s", in.baseURL, err))
+	}
+
+	if len(c.instances) == 1 && lastErr != nil {
+		return nil, lastErr
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrUnavailable, strings.Join(errs, "; "))
+}
+
+// available checks the instance's circuit breaker, probing the health check of the instance once the cooldown
+// elapsed.
+func (c *Client) available(ctx context.Context, in *instance) bool {
+	if c.failureThreshold == 0 {
+		return true
+	}
+
+	in.mu.Lock()
+	defer in.mu.Unlock()
+
+	if in.failures < c.failureThreshold {
+		return true
+	}
+
+	if time.Now().Before(in.openUntil) {
+		return false
+	}
+
+	if err := c.healthCheck(ctx, in.baseURL); err != nil {
+		logger.Warnf("vault server %s is still unavailable: %s", in.baseURL, err)
+
+		in.openUntil = time.Now().Add(c.cooldown)
+
+		return false
+	}
+
+	in.failures = 0
+
+	return true
+}
+
+func (c *Client) healthCheck(ctx context.Context, baseURL string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+healthCheckPath, http.NoBody)
+	if err != nil {
+		return fmt.Errorf("new request: %w", err)
+	}
+
+	_, err = c.sendHTTPRequest(req, http.StatusOK)
+
+	return err
+}
+
+func (c *Client) succeeded(in *instance) {
+	if c.failureThreshold == 0 {
+		return
+	}
+
+	in.mu.Lock()
+	in.failures = 0
+	in.mu.Unlock()
+}
+
+func (c *Client) failed(in *instance) {
+	if c.failureThreshold == 0 {
+		return
+	}
+
+	in.mu.Lock()
+	defer in.mu.Unlock()
+
+	in.failures++
+
+	if in.failures == c.failureThreshold {
+		logger.Warnf("vault server %s is unavailable, failing over for %s", in.baseURL, c.cooldown)
+
+		in.openUntil = time.Now().Add(c.cooldown)
+	}
+}
+
+// unavailableError is the error of a request the instance couldn't serve, the request is sent to the next one.
+type unavailableError struct {
+	err error
+}
+
+func (e unavailableError) Error() string {
+	return e.err.Error()
+}
+
+func (e unavailableError) Unwrap() error {
+	return e.err
+}
+
+func unavailableStatus(status int) bool {
+	return status == http.StatusBadGateway || status == http.StatusServiceUnavailable ||
+		status == http.StatusGatewayTimeout
+}
@@ -0,0 +1,200 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package cache caches DID resolutions in front of a VDR registry.
+package cache
+
+import (
+	"container/list"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/aries-framework-go/pkg/doc/did"
+	"github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdr"
+)
+
+const (
+	// DefaultSize is the default number of resolutions kept in the cache.
+	DefaultSize = 1000
+	// DefaultTTL is the default time resolutions are cached for.
+	DefaultTTL = 5 * time.Minute
+)
+
+// Option configures the caching registry.
+type Option func(r *Registry)
+
+// WithSize sets the number of resolutions kept in the cache, the least recently used ones being evicted first.
+// Defaults to DefaultSize.
+func WithSize(size int) Option {
+	return func(r *Registry) {
+		r.size = size
+	}
+}
+
+// WithTTL sets the time resolutions are cached for. Defaults to DefaultTTL.
+func WithTTL(ttl time.Duration) Option {
+	return func(r *Registry) {
+		r.ttl = ttl
+	}
+}
+
+// WithMethodTTL sets the time resolutions of the DID method are cached for, in place of the TTL set with WithTTL.
+// A TTL of zero disables caching for the method.
+func WithMethodTTL(method string, ttl time.Duration) Option {
+	return func(r *Registry) {
+		r.methodTTLs[method] = ttl
+	}
+}
+
+// Registry fronts a vdr.Registry with an LRU cache of resolutions with expiring entries. Resolutions with options
+// are not cached, as the options may change the result. Updating or deactivating a DID evicts it from the cache.
+//
+// The resolution metadata is taken as a cache hint: resolutions of DIDs that are not published yet, e.g. orb
+// DIDs waiting to be anchored, are not cached since their document changes once they are published.
+type Registry struct {
+	vdr.Registry
+	size       int
+	ttl        time.Duration
+	methodTTLs map[string]time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List
+}
+
+type entry struct {
+	did        string
+	resolution *did.DocResolution
+	expiry     time.Time
+}
+
+// New returns a registry caching the resolutions of registry.
+func New(registry vdr.Registry, opts ...Option) *Registry {
+	r := &Registry{
+		Registry:   registry,
+		size:       DefaultSize,
+		ttl:        DefaultTTL,
+		methodTTLs: map[string]time.Duration{},
+		entries:    map[string]*list.Element{},
+		lru:        list.New(),
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
+}
+
+// Resolve returns the cached resolution of the DID, resolving it with the underlying registry on a cache miss.
+func (r *Registry) Resolve(didID string, opts ...vdr.DIDMethodOption) (*did.DocResolution, error) {
+	if len(opts) > 0 {
+		return r.Registry.Resolve(didID, opts...)
+	}
+
+	if res, ok := r.get(didID); ok {
+		return res, nil
+	}
+
+	res, err := r.Registry.Resolve(didID)
+	if err != nil {
+		return nil, err
+	}
+
+	if ttl := r.ttlOf(didID, res); ttl > 0 {
+		r.set(didID, res, ttl)
+	}
+
+	return res, nil
+}
+
+// Update updates the DID with the underlying registry and evicts it from the cache.
+func (r *Registry) Update(doc *did.Doc, opts ...vdr.DIDMethodOption) error {
+	defer r.evict(doc.ID)
+
+	return r.Registry.Update(doc, opts...)
+}
+
+// Deactivate deactivates the DID with the underlying registry and evicts it from the cache.
+func (r *Registry) Deactivate(didID string, opts ...vdr.DIDMethodOption) error {
+	defer r.evict(didID)
+
+	return r.Registry.Deactivate(didID, opts...)
+}
+
+func (r *Registry) ttlOf(didID string, res *did.DocResolution) time.Duration {
+	if m := res.DocumentMetadata; m != nil && m.Method != nil && !m.Method.Published {
+		return 0
+	}
+
+	if ttl, ok := r.methodTTLs[method(didID)]; ok {
+		return ttl
+	}
+
+	return r.ttl
+}
+
+func (r *Registry) get(didID string) (*did.DocResolution, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	el, ok := r.entries[didID]
+	if !ok {
+		return nil, false
+	}
+
+	e := el.Value.(*entry) //nolint:forcetypeassert,errcheck // the list only holds entries
+
+	if !time.Now().Before(e.expiry) {
+		r.remove(el)
+
+		return nil, false
+	}
+
+	r.lru.MoveToFront(el)
+
+	return e.resolution, true
+}
+
+func (r *Registry) set(didID string, res *did.DocResolution, ttl time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if el, ok := r.entries[didID]; ok {
+		r.remove(el)
+	}
+
+	r.entries[didID] = r.lru.PushFront(&entry{did: didID, resolution: res, expiry: time.Now().Add(ttl)})
+
+	for r.lru.Len() > r.size {
+		r.remove(r.lru.Back())
+	}
+}
+
+func (r *Registry) evict(didID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if el, ok := r.entries[didID]; ok {
+		r.remove(el)
+	}
+}
+
+func (r *Registry) remove(el *list.Element) {
+	r.lru.Remove(el)
+	delete(r.entries, el.Value.(*entry).did) //nolint:forcetypeassert,errcheck // the list only holds entries
+}
+
+// method returns the method of the DID, e.g. orb for did:orb:..., or an empty string if it is not a DID.
+func method(didID string) string {
+	parts := strings.SplitN(didID, ":", 3)   //nolint:gomnd
+	if len(parts) < 3 || parts[0] != "did" { //nolint:gomnd
+		return ""
+	}
+
+	return parts[1]
+}
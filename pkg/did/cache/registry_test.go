@@ -0,0 +1,167 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package cache_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/aries-framework-go/pkg/doc/did"
+	vdrapi "github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdr"
+	mockvdr "github.com/hyperledger/aries-framework-go/pkg/mock/vdr"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/ace/pkg/did/cache"
+)
+
+// countingRegistry counts the resolutions of every DID.
+func countingRegistry(metadata *did.DocumentMetadata) (*mockvdr.MockVDRegistry, map[string]int) {
+	counts := map[string]int{}
+
+	return &mockvdr.MockVDRegistry{
+		ResolveFunc: func(didID string, _ ...vdrapi.DIDMethodOption) (*did.DocResolution, error) {
+			counts[didID]++
+
+			if didID == "did:example:unknown" {
+				return nil, vdrapi.ErrNotFound
+			}
+
+			return &did.DocResolution{DIDDocument: &did.Doc{ID: didID}, DocumentMetadata: metadata}, nil
+		},
+	}, counts
+}
+
+func TestRegistry_Resolve(t *testing.T) {
+	t.Run("Caches resolutions", func(t *testing.T) {
+		registry, counts := countingRegistry(nil)
+
+		r := cache.New(registry)
+
+		for i := 0; i < 3; i++ {
+			res, err := r.Resolve("did:example:1")
+			require.NoError(t, err)
+			require.Equal(t, "did:example:1", res.DIDDocument.ID)
+		}
+
+		require.Equal(t, 1, counts["did:example:1"])
+	})
+
+	t.Run("Does not cache errors", func(t *testing.T) {
+		registry, counts := countingRegistry(nil)
+
+		r := cache.New(registry)
+
+		for i := 0; i < 2; i++ {
+			_, err := r.Resolve("did:example:unknown")
+			require.True(t, errors.Is(err, vdrapi.ErrNotFound))
+		}
+
+		require.Equal(t, 2, counts["did:example:unknown"])
+	})
+
+	t.Run("Does not cache resolutions with options", func(t *testing.T) {
+		registry, counts := countingRegistry(nil)
+
+		r := cache.New(registry)
+
+		for i := 0; i < 2; i++ {
+			_, err := r.Resolve("did:example:1", vdrapi.WithOption("versionId", "1"))
+			require.NoError(t, err)
+		}
+
+		require.Equal(t, 2, counts["did:example:1"])
+	})
+
+	t.Run("Does not cache unpublished DIDs", func(t *testing.T) {
+		registry, counts := countingRegistry(&did.DocumentMetadata{Method: &did.MethodMetadata{Published: false}})
+
+		r := cache.New(registry)
+
+		for i := 0; i < 2; i++ {
+			_, err := r.Resolve("did:orb:1")
+			require.NoError(t, err)
+		}
+
+		require.Equal(t, 2, counts["did:orb:1"])
+	})
+
+	t.Run("Expires resolutions", func(t *testing.T) {
+		registry, counts := countingRegistry(&did.DocumentMetadata{Method: &did.MethodMetadata{Published: true}})
+
+		r := cache.New(registry, cache.WithTTL(time.Millisecond), cache.WithMethodTTL("key", time.Hour))
+
+		for _, id := range []string{"did:orb:1", "did:key:1"} {
+			_, err := r.Resolve(id)
+			require.NoError(t, err)
+		}
+
+		time.Sleep(2 * time.Millisecond)
+
+		for _, id := range []string{"did:orb:1", "did:key:1"} {
+			_, err := r.Resolve(id)
+			require.NoError(t, err)
+		}
+
+		require.Equal(t, 2, counts["did:orb:1"])
+		require.Equal(t, 1, counts["did:key:1"])
+	})
+
+	t.Run("Disables caching for a method", func(t *testing.T) {
+		registry, counts := countingRegistry(nil)
+
+		r := cache.New(registry, cache.WithMethodTTL("web", 0))
+
+		for i := 0; i < 2; i++ {
+			_, err := r.Resolve("did:web:example.com")
+			require.NoError(t, err)
+		}
+
+		require.Equal(t, 2, counts["did:web:example.com"])
+	})
+
+	t.Run("Evicts the least recently used resolutions", func(t *testing.T) {
+		registry, counts := countingRegistry(nil)
+
+		r := cache.New(registry, cache.WithSize(2))
+
+		for _, id := range []string{"did:example:1", "did:example:2", "did:example:1", "did:example:3", "did:example:1",
+			"did:example:2"} {
+			_, err := r.Resolve(id)
+			require.NoError(t, err)
+		}
+
+		require.Equal(t, 1, counts["did:example:1"])
+		require.Equal(t, 2, counts["did:example:2"])
+		require.Equal(t, 1, counts["did:example:3"])
+	})
+}
+
+func TestRegistry_UpdateAndDeactivate(t *testing.T) {
+	registry, counts := countingRegistry(nil)
+
+	r := cache.New(registry)
+
+	_, err := r.Resolve("did:example:1")
+	require.NoError(t, err)
+
+	require.NoError(t, r.Update(&did.Doc{ID: "did:example:1"}))
+
+	_, err = r.Resolve("did:example:1")
+	require.NoError(t, err)
+
+	registry.DeactivateFunc = func(string, ...vdrapi.DIDMethodOption) error {
+		return errors.New("deactivate error")
+	}
+
+	require.EqualError(t, r.Deactivate("did:example:1"), "deactivate error")
+
+	_, err = r.Resolve("did:example:1")
+	require.NoError(t, err)
+
+	require.Equal(t, 3, counts["did:example:1"])
+}
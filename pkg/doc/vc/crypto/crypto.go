@@ -18,6 +18,7 @@ import (
 	"github.com/hyperledger/aries-framework-go/pkg/doc/signature/suite"
 	"github.com/hyperledger/aries-framework-go/pkg/doc/signature/suite/bbsblssignature2020"
 	"github.com/hyperledger/aries-framework-go/pkg/doc/signature/suite/ed25519signature2018"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/signature/suite/ed25519signature2020"
 	"github.com/hyperledger/aries-framework-go/pkg/doc/signature/suite/jsonwebsignature2020"
 	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
 	vdrapi "github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdr"
@@ -31,6 +32,8 @@ import (
 const (
 	// Ed25519Signature2018 ed25519 signature suite.
 	Ed25519Signature2018 = "Ed25519Signature2018"
+	// Ed25519Signature2020 ed25519 signature suite.
+	Ed25519Signature2020 = "Ed25519Signature2020"
 	// JSONWebSignature2020 json web signature suite.
 	JSONWebSignature2020 = "JsonWebSignature2020"
 	// BbsBlsSignature2020 signature suite.
@@ -48,6 +51,9 @@ const (
 
 	// P256KeyType EC P-256 key type.
 	P256KeyType = "P256"
+
+	// BLS12381G2KeyType BLS12-381 G2 key type.
+	BLS12381G2KeyType = "BLS12381G2"
 )
 
 const (
@@ -280,6 +286,8 @@ func (c *Crypto) getLinkedDataProofContext(creator, signatureType, proofPurpose
 	switch signatureType {
 	case Ed25519Signature2018:
 		signatureSuite = ed25519signature2018.New(suite.WithSigner(s))
+	case Ed25519Signature2020:
+		signatureSuite = ed25519signature2020.New(suite.WithSigner(s))
 	case JSONWebSignature2020:
 		signatureSuite = jsonwebsignature2020.New(suite.WithSigner(s))
 	case BbsBlsSignature2020:
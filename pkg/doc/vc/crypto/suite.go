@@ -0,0 +1,44 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package crypto
+
+import "fmt"
+
+// signatureKeyTypes maps supported signature suites to the key types they can be used with.
+// The first key type is the default one.
+var signatureKeyTypes = map[string][]string{ //nolint:gochecknoglobals
+	Ed25519Signature2018: {Ed25519KeyType},
+	Ed25519Signature2020: {Ed25519KeyType},
+	JSONWebSignature2020: {Ed25519KeyType, P256KeyType},
+	BbsBlsSignature2020:  {BLS12381G2KeyType},
+}
+
+// DefaultKeyType returns the key type used with the given signature suite if no key type is configured.
+func DefaultKeyType(signatureType string) (string, error) {
+	keyTypes, ok := signatureKeyTypes[signatureType]
+	if !ok {
+		return "", fmt.Errorf("signature type unsupported %s", signatureType)
+	}
+
+	return keyTypes[0], nil
+}
+
+// ValidateSignatureSuite checks that a key of keyType can be used to create signatures of signatureType.
+func ValidateSignatureSuite(signatureType, keyType string) error {
+	keyTypes, ok := signatureKeyTypes[signatureType]
+	if !ok {
+		return fmt.Errorf("signature type unsupported %s", signatureType)
+	}
+
+	for _, kt := range keyTypes {
+		if kt == keyType {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("key type %s is not supported by signature type %s", keyType, signatureType)
+}
@@ -0,0 +1,48 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package crypto //nolint: testpackage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultKeyType(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		keyType, err := DefaultKeyType(Ed25519Signature2020)
+		require.NoError(t, err)
+		require.Equal(t, Ed25519KeyType, keyType)
+
+		keyType, err = DefaultKeyType(BbsBlsSignature2020)
+		require.NoError(t, err)
+		require.Equal(t, BLS12381G2KeyType, keyType)
+	})
+
+	t.Run("unsupported signature type", func(t *testing.T) {
+		_, err := DefaultKeyType("RsaSignature2018")
+		require.EqualError(t, err, "signature type unsupported RsaSignature2018")
+	})
+}
+
+func TestValidateSignatureSuite(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		require.NoError(t, ValidateSignatureSuite(Ed25519Signature2018, Ed25519KeyType))
+		require.NoError(t, ValidateSignatureSuite(JSONWebSignature2020, P256KeyType))
+		require.NoError(t, ValidateSignatureSuite(BbsBlsSignature2020, BLS12381G2KeyType))
+	})
+
+	t.Run("unsupported key type", func(t *testing.T) {
+		err := ValidateSignatureSuite(Ed25519Signature2018, P256KeyType)
+		require.EqualError(t, err, "key type P256 is not supported by signature type Ed25519Signature2018")
+	})
+
+	t.Run("unsupported signature type", func(t *testing.T) {
+		err := ValidateSignatureSuite("RsaSignature2018", Ed25519KeyType)
+		require.EqualError(t, err, "signature type unsupported RsaSignature2018")
+	})
+}
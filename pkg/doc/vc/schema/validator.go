@@ -0,0 +1,163 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package schema
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
+	"github.com/piprate/json-gold/ld"
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// ErrInvalidCredential is returned when a credential does not conform to configured credential schemas.
+var ErrInvalidCredential = errors.New("invalid credential")
+
+// Schema is a JSON Schema used to validate credentials.
+type Schema struct {
+	// ID of the schema. Credentials referencing this ID in the credentialSchema property are validated against it.
+	ID string
+	// Optional credential type. All credentials of this type are validated against the schema.
+	CredentialType string
+	// JSON Schema document.
+	Document []byte
+}
+
+// Config defines configuration for Validator.
+type Config struct {
+	Schemas        []*Schema
+	DocumentLoader ld.DocumentLoader
+	// StrictJSONLD enables validation that all credential properties are defined by the JSON-LD contexts.
+	StrictJSONLD bool
+}
+
+type compiledSchema struct {
+	id     string
+	schema *gojsonschema.Schema
+}
+
+// Validator validates credentials against JSON-LD contexts and JSON Schema credential schemas.
+type Validator struct {
+	byID           map[string]*compiledSchema
+	byType         map[string][]*compiledSchema
+	documentLoader ld.DocumentLoader
+	strictJSONLD   bool
+}
+
+// NewValidator returns a new instance of Validator.
+func NewValidator(config *Config) (*Validator, error) {
+	v := &Validator{
+		byID:           make(map[string]*compiledSchema),
+		byType:         make(map[string][]*compiledSchema),
+		documentLoader: config.DocumentLoader,
+		strictJSONLD:   config.StrictJSONLD,
+	}
+
+	for _, s := range config.Schemas {
+		if s.ID == "" {
+			return nil, errors.New("missing schema id")
+		}
+
+		compiled, err := gojsonschema.NewSchema(gojsonschema.NewBytesLoader(s.Document))
+		if err != nil {
+			return nil, fmt.Errorf("compile schema %q: %w", s.ID, err)
+		}
+
+		cs := &compiledSchema{id: s.ID, schema: compiled}
+
+		v.byID[s.ID] = cs
+
+		if s.CredentialType != "" {
+			v.byType[s.CredentialType] = append(v.byType[s.CredentialType], cs)
+		}
+	}
+
+	return v, nil
+}
+
+// Validate checks that credential conforms to the JSON-LD contexts (if strict validation is enabled), the schemas
+// it references and the schemas configured for its types.
+func (v *Validator) Validate(vc *verifiable.Credential) error {
+	b, err := vc.MarshalJSON()
+	if err != nil {
+		return fmt.Errorf("marshal credential: %w", err)
+	}
+
+	if v.strictJSONLD {
+		_, err = verifiable.ParseCredential(b,
+			verifiable.WithDisabledProofCheck(),
+			verifiable.WithNoCustomSchemaCheck(),
+			verifiable.WithJSONLDDocumentLoader(v.documentLoader),
+			verifiable.WithJSONLDValidation(),
+			verifiable.WithStrictValidation(),
+		)
+		if err != nil {
+			return fmt.Errorf("%w: json-ld validation: %s", ErrInvalidCredential, err)
+		}
+	}
+
+	schemas, err := v.schemasFor(vc)
+	if err != nil {
+		return err
+	}
+
+	for _, s := range schemas {
+		result, err := s.schema.Validate(gojsonschema.NewBytesLoader(b))
+		if err != nil {
+			return fmt.Errorf("validate against schema %q: %w", s.id, err)
+		}
+
+		if !result.Valid() {
+			return fmt.Errorf("%w: does not conform to schema %q: %s", ErrInvalidCredential, s.id,
+				describe(result.Errors()))
+		}
+	}
+
+	return nil
+}
+
+func (v *Validator) schemasFor(vc *verifiable.Credential) ([]*compiledSchema, error) {
+	var schemas []*compiledSchema
+
+	seen := make(map[string]struct{})
+
+	add := func(s *compiledSchema) {
+		if _, ok := seen[s.id]; !ok {
+			seen[s.id] = struct{}{}
+			schemas = append(schemas, s)
+		}
+	}
+
+	for _, ref := range vc.Schemas {
+		s, ok := v.byID[ref.ID]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown credential schema %q", ErrInvalidCredential, ref.ID)
+		}
+
+		add(s)
+	}
+
+	for _, t := range vc.Types {
+		for _, s := range v.byType[t] {
+			add(s)
+		}
+	}
+
+	return schemas, nil
+}
+
+func describe(errs []gojsonschema.ResultError) string {
+	descriptions := make([]string, 0, len(errs))
+
+	for _, e := range errs {
+		descriptions = append(descriptions, fmt.Sprintf("%s: %s", e.Field(), e.Description()))
+	}
+
+	return strings.Join(descriptions, "; ")
+}
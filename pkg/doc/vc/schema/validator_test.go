@@ -0,0 +1,129 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package schema_test
+
+import (
+	"testing"
+
+	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/ace/pkg/doc/vc/schema"
+	"github.com/trustbloc/ace/pkg/internal/testutil"
+)
+
+const (
+	testSchemaID   = "https://example.com/schemas/protected-data.json"
+	testSchemaType = "ProtectedDataCredential"
+	testSchema     = `{
+  "$schema": "http://json-schema.org/draft-07/schema#",
+  "type": "object",
+  "required": ["credentialSubject"],
+  "properties": {
+    "credentialSubject": {
+      "type": "object",
+      "required": ["id", "data"],
+      "properties": {
+        "data": {"type": "string", "minLength": 1}
+      }
+    }
+  }
+}`
+)
+
+func TestNewValidator(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		v, err := schema.NewValidator(&schema.Config{
+			Schemas: []*schema.Schema{{ID: testSchemaID, Document: []byte(testSchema)}},
+		})
+		require.NoError(t, err)
+		require.NotNil(t, v)
+	})
+
+	t.Run("Missing schema id", func(t *testing.T) {
+		_, err := schema.NewValidator(&schema.Config{
+			Schemas: []*schema.Schema{{Document: []byte(testSchema)}},
+		})
+		require.EqualError(t, err, "missing schema id")
+	})
+
+	t.Run("Invalid schema", func(t *testing.T) {
+		_, err := schema.NewValidator(&schema.Config{
+			Schemas: []*schema.Schema{{ID: testSchemaID, Document: []byte("invalid")}},
+		})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "compile schema")
+	})
+}
+
+func TestValidator_Validate(t *testing.T) {
+	v, err := schema.NewValidator(&schema.Config{
+		Schemas: []*schema.Schema{{
+			ID:             testSchemaID,
+			CredentialType: testSchemaType,
+			Document:       []byte(testSchema),
+		}},
+		DocumentLoader: testutil.DocumentLoader(t),
+	})
+	require.NoError(t, err)
+
+	t.Run("Success: credential references schema", func(t *testing.T) {
+		vc := newCredential(map[string]interface{}{"id": "did:example:123", "data": "sensitive"})
+		vc.Schemas = []verifiable.TypedID{{ID: testSchemaID, Type: "JsonSchemaValidator2018"}}
+
+		require.NoError(t, v.Validate(vc))
+	})
+
+	t.Run("Success: no applicable schemas", func(t *testing.T) {
+		vc := newCredential(map[string]interface{}{"id": "did:example:123"})
+
+		require.NoError(t, v.Validate(vc))
+	})
+
+	t.Run("Fail: credential type schema is not satisfied", func(t *testing.T) {
+		vc := newCredential(map[string]interface{}{"id": "did:example:123", "name": "test"})
+		vc.Types = append(vc.Types, testSchemaType)
+
+		err := v.Validate(vc)
+		require.ErrorIs(t, err, schema.ErrInvalidCredential)
+		require.Contains(t, err.Error(), "does not conform to schema")
+		require.Contains(t, err.Error(), "data is required")
+	})
+
+	t.Run("Fail: unknown credential schema", func(t *testing.T) {
+		vc := newCredential(map[string]interface{}{"id": "did:example:123", "data": "sensitive"})
+		vc.Schemas = []verifiable.TypedID{{ID: "https://example.com/unknown.json", Type: "JsonSchemaValidator2018"}}
+
+		err := v.Validate(vc)
+		require.ErrorIs(t, err, schema.ErrInvalidCredential)
+		require.Contains(t, err.Error(), "unknown credential schema")
+	})
+
+	t.Run("Fail: strict JSON-LD validation", func(t *testing.T) {
+		strict, err := schema.NewValidator(&schema.Config{
+			DocumentLoader: testutil.DocumentLoader(t),
+			StrictJSONLD:   true,
+		})
+		require.NoError(t, err)
+
+		vc := newCredential(map[string]interface{}{"id": "did:example:123", "data": "sensitive"})
+
+		err = strict.Validate(vc)
+		require.ErrorIs(t, err, schema.ErrInvalidCredential)
+		require.Contains(t, err.Error(), "json-ld validation")
+	})
+}
+
+func newCredential(subject map[string]interface{}) *verifiable.Credential {
+	return &verifiable.Credential{
+		Context: []string{verifiable.ContextURI},
+		ID:      "http://example.com/credentials/1",
+		Types:   []string{verifiable.VCType},
+		Issuer:  verifiable.Issuer{ID: "did:example:issuer"},
+		Subject: subject,
+	}
+}
@@ -0,0 +1,76 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package fakedata generates fake sensitive data, such as SSNs, emails, social media handles and DIDs, for tests.
+// Generators created with the same seed generate the same values, so that failed tests can be reproduced.
+package fakedata
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+)
+
+const (
+	alphanumeric = "abcdefghijklmnopqrstuvwxyz0123456789"
+	hexDigits    = "0123456789abcdef"
+)
+
+//nolint:gochecknoglobals
+var (
+	words   = []string{"thanos", "pikachu", "loki", "gozer", "slimer", "zuul", "vinz", "dana", "louis", "janine"}
+	domains = []string{"example.com", "example.org", "example.net"}
+)
+
+// Generator generates fake data. It is not safe for concurrent use.
+type Generator struct {
+	rand *rand.Rand
+}
+
+// New returns a generator seeded with the seed.
+func New(seed int64) *Generator {
+	return &Generator{rand: rand.New(rand.NewSource(seed))} //nolint:gosec
+}
+
+// SSN returns a US social security number formatted as AAA-GG-SSSS, with the area, group and serial numbers in
+// their valid ranges, i.e. area 001-899 except 666, group 01-99 and serial 0001-9999.
+func (g *Generator) SSN() string {
+	area := 1 + g.rand.Intn(899) //nolint:gomnd
+	if area == 666 {             //nolint:gomnd
+		area++
+	}
+
+	return fmt.Sprintf("%03d-%02d-%04d", area, 1+g.rand.Intn(99), 1+g.rand.Intn(9999)) //nolint:gomnd
+}
+
+// Email returns an email address of a reserved example domain.
+func (g *Generator) Email() string {
+	return fmt.Sprintf("%s.%s@%s", g.word(), g.string(alphanumeric, 6), domains[g.rand.Intn(len(domains))]) //nolint:gomnd
+}
+
+// SocialHandle returns a social media handle, e.g. @thanos_4k2.
+func (g *Generator) SocialHandle() string {
+	return fmt.Sprintf("@%s_%s", g.word(), g.string(alphanumeric, 3)) //nolint:gomnd
+}
+
+// DID returns a DID of the example method.
+func (g *Generator) DID() string {
+	return "did:example:" + g.string(hexDigits, 32) //nolint:gomnd
+}
+
+func (g *Generator) word() string {
+	return words[g.rand.Intn(len(words))]
+}
+
+func (g *Generator) string(charset string, n int) string {
+	var sb strings.Builder
+
+	for i := 0; i < n; i++ {
+		sb.WriteByte(charset[g.rand.Intn(len(charset))])
+	}
+
+	return sb.String()
+}
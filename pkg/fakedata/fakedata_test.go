@@ -0,0 +1,45 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package fakedata_test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/ace/pkg/fakedata"
+)
+
+func TestGenerator(t *testing.T) {
+	t.Run("Generates values in their formats", func(t *testing.T) {
+		g := fakedata.New(1)
+
+		for i := 0; i < 1000; i++ {
+			ssn := g.SSN()
+			require.Regexp(t, `^\d{3}-\d{2}-\d{4}$`, ssn)
+			require.NotRegexp(t, `^(000|666|9\d\d)-|-00-|-0000$`, ssn)
+
+			require.Regexp(t, `^[a-z]+\.[a-z0-9]{6}@example\.(com|org|net)$`, g.Email())
+			require.Regexp(t, `^@[a-z]+_[a-z0-9]{3}$`, g.SocialHandle())
+			require.Regexp(t, regexp.MustCompile(`^did:example:[0-9a-f]{32}$`), g.DID())
+		}
+	})
+
+	t.Run("Generates the same values with the same seed", func(t *testing.T) {
+		g1, g2 := fakedata.New(42), fakedata.New(42)
+
+		for i := 0; i < 10; i++ {
+			require.Equal(t, g1.SSN(), g2.SSN())
+			require.Equal(t, g1.Email(), g2.Email())
+			require.Equal(t, g1.SocialHandle(), g2.SocialHandle())
+			require.Equal(t, g1.DID(), g2.DID())
+		}
+
+		require.NotEqual(t, fakedata.New(1).DID(), fakedata.New(2).DID())
+	})
+}
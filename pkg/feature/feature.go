@@ -0,0 +1,126 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package feature gates capabilities behind named feature flags, so that experimental capabilities ship disabled and
+// are enabled per environment. The flags are set from the configuration and can be toggled while the service runs.
+package feature
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// The feature flags of Gatekeeper.
+const (
+	// DIDComm gates the DIDComm coordination of the approvals: the DIDComm endpoints and the approval requests pushed
+	// to the agents of the approvers.
+	DIDComm = "didcomm"
+	// BBSIssuance gates the issuance of credentials signed with BBS+ signatures (BbsBlsSignature2020).
+	BBSIssuance = "bbs_issuance"
+)
+
+// ErrUnknownFlag is returned when setting a flag that is not declared.
+var ErrUnknownFlag = errors.New("unknown feature flag")
+
+// Flag is the state of a feature flag.
+type Flag struct {
+	Name    string `json:"name"`
+	Enabled bool   `json:"enabled"`
+}
+
+// Flags are the declared feature flags and whether they are enabled. A nil Flags enables all flags, for the services
+// that don't gate their capabilities.
+type Flags struct {
+	mu      sync.RWMutex
+	enabled map[string]bool
+}
+
+// New returns the flags declared with their defaults.
+func New(defaults map[string]bool) *Flags {
+	f := &Flags{enabled: make(map[string]bool, len(defaults))}
+
+	for name, enabled := range defaults {
+		f.enabled[name] = enabled
+	}
+
+	return f
+}
+
+// Enabled tells whether the flag is enabled. Undeclared flags are disabled.
+func (f *Flags) Enabled(name string) bool {
+	if f == nil {
+		return true
+	}
+
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	return f.enabled[name]
+}
+
+// Set enables or disables the flag.
+func (f *Flags) Set(name string, enabled bool) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.enabled[name]; !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownFlag, name)
+	}
+
+	f.enabled[name] = enabled
+
+	return nil
+}
+
+// Configure sets the flags of the comma-separated list, e.g. "didcomm,bbs_issuance=false": the flags are enabled,
+// or set to the boolean following them.
+func (f *Flags) Configure(list string) error {
+	for _, item := range strings.Split(list, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		name, enabled := item, true
+
+		if i := strings.Index(item, "="); i >= 0 {
+			var err error
+
+			name = strings.TrimSpace(item[:i])
+
+			enabled, err = strconv.ParseBool(strings.TrimSpace(item[i+1:]))
+			if err != nil {
+				return fmt.Errorf("parse feature flag %s: %w", name, err)
+			}
+		}
+
+		if err := f.Set(name, enabled); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// List returns the flags sorted by name.
+func (f *Flags) List() []*Flag {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	flags := make([]*Flag, 0, len(f.enabled))
+
+	for name, enabled := range f.enabled {
+		flags = append(flags, &Flag{Name: name, Enabled: enabled})
+	}
+
+	sort.Slice(flags, func(i, j int) bool { return flags[i].Name < flags[j].Name })
+
+	return flags
+}
@@ -0,0 +1,67 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package feature_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/ace/pkg/feature"
+)
+
+func TestFlags(t *testing.T) {
+	t.Run("test defaults", func(t *testing.T) {
+		flags := feature.New(map[string]bool{feature.DIDComm: true, feature.BBSIssuance: false})
+
+		require.True(t, flags.Enabled(feature.DIDComm))
+		require.False(t, flags.Enabled(feature.BBSIssuance))
+		require.False(t, flags.Enabled("unknown"))
+		require.Equal(t, []*feature.Flag{
+			{Name: feature.BBSIssuance, Enabled: false},
+			{Name: feature.DIDComm, Enabled: true},
+		}, flags.List())
+	})
+
+	t.Run("test nil flags", func(t *testing.T) {
+		var flags *feature.Flags
+
+		require.True(t, flags.Enabled(feature.DIDComm))
+	})
+
+	t.Run("test set", func(t *testing.T) {
+		flags := feature.New(map[string]bool{feature.DIDComm: false})
+
+		require.NoError(t, flags.Set(feature.DIDComm, true))
+		require.True(t, flags.Enabled(feature.DIDComm))
+
+		err := flags.Set("unknown", true)
+		require.ErrorIs(t, err, feature.ErrUnknownFlag)
+		require.Contains(t, err.Error(), "unknown")
+	})
+
+	t.Run("test configure", func(t *testing.T) {
+		flags := feature.New(map[string]bool{feature.DIDComm: false, feature.BBSIssuance: true})
+
+		require.NoError(t, flags.Configure(" didcomm, bbs_issuance = false,"))
+		require.True(t, flags.Enabled(feature.DIDComm))
+		require.False(t, flags.Enabled(feature.BBSIssuance))
+
+		require.NoError(t, flags.Configure(""))
+		require.True(t, flags.Enabled(feature.DIDComm))
+	})
+
+	t.Run("test configure error", func(t *testing.T) {
+		flags := feature.New(map[string]bool{feature.DIDComm: false})
+
+		err := flags.Configure("didcomm=maybe")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "parse feature flag didcomm")
+
+		require.ErrorIs(t, flags.Configure("async_protect"), feature.ErrUnknownFlag)
+	})
+}
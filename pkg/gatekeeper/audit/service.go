@@ -0,0 +1,867 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package audit keeps the tamper-evident log of the actions taken on tickets, the access records of protected data.
+// Every entry is chained to the previous one with its hash, and checkpoints of the chain are signed with the
+// gatekeeper's key, so that auditors detect the entries modified, inserted or removed after the fact.
+package audit
+
+//nolint:lll
+//go:generate mockgen -destination gomocks_test.go -package audit_test -source=service.go -mock_names vcIssuer=MockVCIssuer
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hyperledger/aries-framework-go/pkg/common/log"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/util"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
+	vdrapi "github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdr"
+	"github.com/hyperledger/aries-framework-go/spi/storage"
+	"github.com/piprate/json-gold/ld"
+
+	"github.com/trustbloc/ace/pkg/gatekeeper/release/ticket"
+	"github.com/trustbloc/ace/pkg/storage/conditional"
+	"github.com/trustbloc/ace/pkg/storage/index"
+)
+
+const (
+	// CheckpointType is the type of the credentials of the checkpoints of the log.
+	CheckpointType = "AuditCheckpoint"
+	// MaxEntries is the maximum number of entries read at a time.
+	MaxEntries = 1000
+
+	credentialContext = "https://www.w3.org/2018/credentials/v1" //nolint:gosec
+	// checkpointVocab defines the terms of the checkpoints, so that they are signed with the credentials.
+	checkpointVocab   = "https://trustbloc.dev/ace/audit#"
+	storeName         = "audit_log"
+	checkpointTag     = "checkpoint"
+	queuedTag         = "queued"
+	headKey           = "head"
+	lastCheckpointKey = "last_checkpoint"
+	entryKeyFormat    = "entry_%020d"
+	checkpointFormat  = "checkpoint_%020d"
+	queuedFormat      = "queued_%020d_%s"
+	// seqTag is the tag of the sequence numbers of the entries and of the head, which they are written conditionally
+	// on.
+	seqTag = "seq"
+	// maxChainAttempts is the number of times an entry is chained when other instances chain entries concurrently.
+	maxChainAttempts = 10
+)
+
+var logger = log.New("gatekeeper-audit")
+
+var (
+	// ErrTampered is returned when the entries or the checkpoints of the log don't match their hashes.
+	ErrTampered = errors.New("audit log tampered")
+	// ErrConflict is returned when an entry is appended with the sequence number of an entry appended by another
+	// instance.
+	ErrConflict = errors.New("audit log appended concurrently")
+)
+
+type vcIssuer interface {
+	IssueCredential(ctx context.Context, cred []byte) (*verifiable.Credential, error)
+}
+
+type leader interface {
+	Held() bool
+}
+
+// Entry is an action taken on a ticket, chained to the previous entry of the log.
+type Entry struct {
+	// Seq is the sequence number of the entry, starting at 1.
+	Seq      int           `json:"seq"`
+	TicketID string        `json:"ticket_id"`
+	DID      string        `json:"did"`
+	PolicyID string        `json:"policy_id,omitempty"`
+	Event    *ticket.Event `json:"event"`
+	// PrevHash is the hash of the previous entry, empty for the first entry.
+	PrevHash string `json:"prev_hash,omitempty"`
+	// Hash is the hex-encoded SHA-256 digest of the JSON of the entry without its hash.
+	Hash string `json:"hash"`
+}
+
+// Head is the last entry of the log, the zero Head for an empty log.
+type Head struct {
+	Seq  int    `json:"seq"`
+	Hash string `json:"hash,omitempty"`
+}
+
+// Checkpoint is the subject of the checkpoint credentials: the head of the log at the time it was signed.
+type Checkpoint struct {
+	Seq       int       `json:"seq"`
+	Hash      string    `json:"hash"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Report is the result of the verification of the log.
+type Report struct {
+	Verified    bool  `json:"verified"`
+	Entries     int   `json:"entries"`
+	Checkpoints int   `json:"checkpoints"`
+	Head        *Head `json:"head"`
+	// Error, if the log is not verified, is the first mismatch found.
+	Error string `json:"error,omitempty"`
+}
+
+// Config defines dependencies for Service.
+type Config struct {
+	StoreProvider storage.Provider
+	// VCIssuer signs the checkpoints on behalf of the gatekeeper.
+	VCIssuer vcIssuer
+	// VDR and DocumentLoader verify the proofs of the checkpoints.
+	VDR            vdrapi.Registry
+	DocumentLoader ld.DocumentLoader
+	// Leader, if set, is the lease of the instance chaining the entries of the log. Instances that don't hold it
+	// queue their entries, which are chained by the holder.
+	Leader leader
+}
+
+// Service appends the actions taken on tickets to the log, signs its checkpoints and verifies it. Appends are
+// serialized within the instance, and across the instances sharing the store by the leader's lease: only the
+// instance holding it chains entries. Instances chaining entries at the same time, such as a former holder of the
+// lease while it is taken over, or instances without a lease, don't fork the chain if the store writes conditionally,
+// see conditional.Putter: an entry is only stored if no entry is stored with its sequence number, and is otherwise
+// chained after the entry of the other instance, and the head never moves back. With a store that doesn't write
+// conditionally, such as CouchDB or MySQL, an entry is checked against the entries stored within the instance only,
+// so that the chain is only kept by a single instance chaining entries at a time.
+type Service struct {
+	store          storage.Store
+	issuer         vcIssuer
+	vdr            vdrapi.Registry
+	documentLoader ld.DocumentLoader
+	leader         leader
+	// conditional tells if the store writes the entries conditionally.
+	conditional bool
+	// mu serializes the reads of the head with the appends.
+	mu sync.Mutex
+}
+
+// NewService returns a new instance of Service.
+func NewService(config *Config) (*Service, error) {
+	store, err := index.OpenStore(config.StoreProvider, &index.Declaration{
+		Store:    storeName,
+		TagNames: []string{checkpointTag, queuedTag},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("open audit log store: %w", err)
+	}
+
+	return &Service{
+		store:          store,
+		issuer:         config.VCIssuer,
+		vdr:            config.VDR,
+		documentLoader: config.DocumentLoader,
+		leader:         config.Leader,
+		conditional:    conditional.Supported(store),
+	}, nil
+}
+
+// Record appends the action taken on the ticket, the last one of its history, to the log. Tickets without history
+// are not recorded.
+func (s *Service) Record(ctx context.Context, t *ticket.Ticket) error {
+	if len(t.History) == 0 {
+		return nil
+	}
+
+	e := &Entry{TicketID: t.ID, DID: t.DID, PolicyID: t.PolicyID, Event: t.History[len(t.History)-1]}
+
+	if err := s.Append(ctx, e); err != nil {
+		return fmt.Errorf("append %s to audit log: %w", e.Event.Action, err)
+	}
+
+	return nil
+}
+
+// Append appends the entry to the log, chained to the head of the log once the queued entries are chained, and sets
+// the sequence number and the hashes of the entry. The entry is queued instead, and left unchained, if another
+// instance holds the leader's lease.
+func (s *Service) Append(ctx context.Context, e *Entry) error {
+	if s.leader != nil && !s.leader.Held() {
+		return s.enqueue(e)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.chainQueued(ctx); err != nil {
+		return err
+	}
+
+	head, err := s.Head(ctx)
+	if err != nil {
+		return err
+	}
+
+	for attempt := 1; ; attempt++ {
+		err = s.chain(e, head)
+		if !errors.Is(err, ErrConflict) || attempt == maxChainAttempts {
+			return err
+		}
+
+		logger.Debugf("audit log appended concurrently, attempt %d to chain entry %d", attempt, e.Seq)
+
+		if head, err = s.tail(head); err != nil {
+			return err
+		}
+	}
+}
+
+// Flush chains the entries queued by the other instances, if the instance holds the leader's lease.
+func (s *Service) Flush(ctx context.Context) error {
+	if s.leader != nil && !s.leader.Held() {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.chainQueued(ctx)
+}
+
+// chain chains the entry to the head of the log, unless another entry was already chained with its sequence number,
+// and moves the head to it. The same entry chained by another instance to the same head is left as is.
+func (s *Service) chain(e *Entry, head *Head) error {
+	e.Seq = head.Seq + 1
+	e.PrevHash = head.Hash
+
+	var err error
+
+	if e.Hash, err = Hash(e); err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("marshal entry: %w", err)
+	}
+
+	err = s.insert(e.Seq, b)
+	if errors.Is(err, ErrConflict) {
+		existing, getErr := s.entry(e.Seq)
+		if getErr != nil {
+			return getErr
+		}
+
+		if existing.Hash != e.Hash {
+			return err
+		}
+	} else if err != nil {
+		return err
+	}
+
+	return s.advance(&Head{Seq: e.Seq, Hash: e.Hash})
+}
+
+// insert stores the entry with the sequence number, or returns ErrConflict if an entry is stored with it. The entry
+// is written conditionally on there being none, or, if the store doesn't write conditionally, checked within the
+// instance only.
+func (s *Service) insert(seq int, b []byte) error {
+	_, err := s.entry(seq)
+	if err == nil {
+		return fmt.Errorf("%w: entry %d exists past head", ErrConflict, seq)
+	}
+
+	if !errors.Is(err, storage.ErrDataNotFound) {
+		return err
+	}
+
+	key, tag := fmt.Sprintf(entryKeyFormat, seq), seqTagOf(seq)
+
+	if !s.conditional {
+		err = s.store.Put(key, b, tag)
+	} else {
+		err = conditional.PutIf(s.store, key, b, storage.Tag{Name: seqTag}, tag)
+	}
+
+	if errors.Is(err, conditional.ErrConflict) {
+		return fmt.Errorf("%w: entry %d stored concurrently", ErrConflict, seq)
+	}
+
+	if err != nil {
+		return fmt.Errorf("store entry: %w", err)
+	}
+
+	return nil
+}
+
+// advance moves the head of the log to the entry, unless another instance moved it further. The head is written
+// conditionally on the head it replaces, so that it never moves back, or, if the store doesn't write conditionally,
+// replaced.
+func (s *Service) advance(h *Head) error {
+	if !s.conditional {
+		return s.put(headKey, h)
+	}
+
+	b, err := json.Marshal(h)
+	if err != nil {
+		return fmt.Errorf("marshal %s: %w", headKey, err)
+	}
+
+	for attempt := 1; attempt <= maxChainAttempts; attempt++ {
+		current, e := s.getHead(headKey)
+		if e != nil {
+			return e
+		}
+
+		if current.Seq >= h.Seq {
+			return nil
+		}
+
+		// the head stored before it was tagged with its sequence number is matched by the tag without a value
+		condition := storage.Tag{Name: seqTag}
+
+		tags, e := s.store.GetTags(headKey)
+		if e != nil && !errors.Is(e, storage.ErrDataNotFound) {
+			return fmt.Errorf("get %s tags: %w", headKey, e)
+		}
+
+		if !conditional.Met(tags, condition) {
+			condition = seqTagOf(current.Seq)
+		}
+
+		err = conditional.PutIf(s.store, headKey, b, condition, seqTagOf(h.Seq))
+		if !errors.Is(err, conditional.ErrConflict) {
+			break
+		}
+	}
+
+	if errors.Is(err, conditional.ErrConflict) {
+		return fmt.Errorf("%w: head %d moved concurrently", ErrConflict, h.Seq)
+	}
+
+	if err != nil {
+		return fmt.Errorf("store %s: %w", headKey, err)
+	}
+
+	return nil
+}
+
+// tail returns the last entry chained past the head, by another instance that hasn't moved the head to it yet.
+func (s *Service) tail(head *Head) (*Head, error) {
+	for {
+		e, err := s.entry(head.Seq + 1)
+		if errors.Is(err, storage.ErrDataNotFound) {
+			return head, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		head = &Head{Seq: e.Seq, Hash: e.Hash}
+	}
+}
+
+// enqueue stores the entry to be chained by the instance holding the leader's lease, keyed by the time it is queued
+// at so that entries are chained in order.
+func (s *Service) enqueue(e *Entry) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("marshal entry: %w", err)
+	}
+
+	key := fmt.Sprintf(queuedFormat, time.Now().UnixNano(), uuid.New().String())
+
+	if err = s.store.Put(key, b, storage.Tag{Name: queuedTag}); err != nil {
+		return fmt.Errorf("queue entry: %w", err)
+	}
+
+	return nil
+}
+
+// chainQueued chains the queued entries in the order they were queued in, removing them from the queue once chained.
+// Entries chained by another instance chaining the queue at the same time are chained once: an entry removed from the
+// queue, or the entry at the head of the log, is skipped.
+func (s *Service) chainQueued(ctx context.Context) error {
+	keys, values, err := s.queued()
+	if err != nil {
+		return err
+	}
+
+	for i, key := range keys {
+		if err = s.chainQueuedEntry(ctx, key, values[key]); err != nil {
+			return fmt.Errorf("chain queued entry %d: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) chainQueuedEntry(ctx context.Context, key string, value []byte) error {
+	var e Entry
+
+	if err := json.Unmarshal(value, &e); err != nil {
+		return fmt.Errorf("unmarshal queued entry: %w", err)
+	}
+
+	head, err := s.Head(ctx)
+	if err != nil {
+		return err
+	}
+
+	for attempt := 1; ; attempt++ {
+		var chained bool
+
+		chained, err = s.chained(key, &e, head)
+		if err == nil && !chained {
+			err = s.chain(&e, head)
+		}
+
+		if !errors.Is(err, ErrConflict) || attempt == maxChainAttempts {
+			break
+		}
+
+		if head, err = s.tail(head); err != nil {
+			break
+		}
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if err = s.store.Delete(key); err != nil {
+		return fmt.Errorf("delete queued entry: %w", err)
+	}
+
+	return nil
+}
+
+// chained tells if the queued entry was chained by another instance, read after the head of the log: the entry was
+// removed from the queue once chained, or it is the entry at the head.
+func (s *Service) chained(key string, e *Entry, head *Head) (bool, error) {
+	_, err := s.store.Get(key)
+	if errors.Is(err, storage.ErrDataNotFound) {
+		return true, nil
+	}
+
+	if err != nil {
+		return false, fmt.Errorf("get queued entry: %w", err)
+	}
+
+	if head.Seq == 0 {
+		return false, nil
+	}
+
+	last, err := s.entry(head.Seq)
+	if err != nil {
+		return false, err
+	}
+
+	c := *e
+	c.Seq, c.PrevHash = last.Seq, last.PrevHash
+
+	hash, err := Hash(&c)
+	if err != nil {
+		return false, err
+	}
+
+	return hash == last.Hash, nil
+}
+
+func (s *Service) queued() ([]string, map[string][]byte, error) {
+	it, err := s.store.Query(queuedTag)
+	if err != nil {
+		return nil, nil, fmt.Errorf("query queued entries: %w", err)
+	}
+
+	defer func() {
+		if e := it.Close(); e != nil {
+			logger.Warnf("Failed to close iterator: %s", e)
+		}
+	}()
+
+	var keys []string
+
+	values := map[string][]byte{}
+
+	for {
+		ok, e := it.Next()
+		if e != nil {
+			return nil, nil, fmt.Errorf("next queued entry: %w", e)
+		}
+
+		if !ok {
+			break
+		}
+
+		key, e := it.Key()
+		if e != nil {
+			return nil, nil, fmt.Errorf("queued entry key: %w", e)
+		}
+
+		if values[key], e = it.Value(); e != nil {
+			return nil, nil, fmt.Errorf("queued entry value: %w", e)
+		}
+
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys, values, nil
+}
+
+// Head returns the head of the log.
+func (s *Service) Head(_ context.Context) (*Head, error) {
+	return s.getHead(headKey)
+}
+
+// Entries returns up to limit entries of the log following the entry with the given sequence number, or MaxEntries
+// if limit is not positive or exceeds it.
+func (s *Service) Entries(ctx context.Context, after, limit int) ([]*Entry, error) {
+	if limit <= 0 || limit > MaxEntries {
+		limit = MaxEntries
+	}
+
+	head, err := s.Head(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []*Entry{}
+
+	for seq := after + 1; seq <= head.Seq && len(entries) < limit; seq++ {
+		entry, e := s.entry(seq)
+		if e != nil {
+			return nil, e
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// Checkpoint signs the head of the log as a credential issued by the gatekeeper and stores it. No checkpoint is
+// signed, and a nil credential returned, if the log has no entries since the last checkpoint.
+func (s *Service) Checkpoint(ctx context.Context) (*verifiable.Credential, error) {
+	head, err := s.Head(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	last, err := s.getHead(lastCheckpointKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if head.Seq == last.Seq {
+		return nil, nil
+	}
+
+	now := time.Now().UTC()
+
+	cred := verifiable.Credential{
+		ID:            uuid.New().URN(),
+		Context:       []string{credentialContext},
+		CustomContext: []interface{}{map[string]interface{}{"@vocab": checkpointVocab}},
+		Types:         []string{"VerifiableCredential", CheckpointType},
+		// issuerID will be overwritten in the issuer
+		Issuer: verifiable.Issuer{ID: uuid.New().URN()},
+		Issued: util.NewTime(now),
+		Subject: map[string]interface{}{
+			"seq":       head.Seq,
+			"hash":      head.Hash,
+			"timestamp": now,
+		},
+	}
+
+	credBytes, err := cred.MarshalJSON()
+	if err != nil {
+		return nil, fmt.Errorf("marshal credential: %w", err)
+	}
+
+	vc, err := s.issuer.IssueCredential(ctx, credBytes)
+	if err != nil {
+		return nil, fmt.Errorf("issue credential: %w", err)
+	}
+
+	vcBytes, err := vc.MarshalJSON()
+	if err != nil {
+		return nil, fmt.Errorf("marshal issued credential: %w", err)
+	}
+
+	if err = s.store.Put(fmt.Sprintf(checkpointFormat, head.Seq), vcBytes, storage.Tag{Name: checkpointTag}); err != nil {
+		return nil, fmt.Errorf("store checkpoint: %w", err)
+	}
+
+	if err = s.put(lastCheckpointKey, head); err != nil {
+		return nil, err
+	}
+
+	return vc, nil
+}
+
+// Checkpoints returns the signed checkpoints of the log, oldest first.
+func (s *Service) Checkpoints(_ context.Context) ([]json.RawMessage, error) {
+	it, err := s.store.Query(checkpointTag)
+	if err != nil {
+		return nil, fmt.Errorf("query checkpoints: %w", err)
+	}
+
+	defer func() {
+		if e := it.Close(); e != nil {
+			logger.Warnf("Failed to close iterator: %s", e)
+		}
+	}()
+
+	type checkpoint struct {
+		key string
+		vc  json.RawMessage
+	}
+
+	var checkpoints []checkpoint
+
+	for {
+		ok, e := it.Next()
+		if e != nil {
+			return nil, fmt.Errorf("next checkpoint: %w", e)
+		}
+
+		if !ok {
+			break
+		}
+
+		key, e := it.Key()
+		if e != nil {
+			return nil, fmt.Errorf("checkpoint key: %w", e)
+		}
+
+		v, e := it.Value()
+		if e != nil {
+			return nil, fmt.Errorf("checkpoint value: %w", e)
+		}
+
+		checkpoints = append(checkpoints, checkpoint{key: key, vc: v})
+	}
+
+	// the keys are ordered by the zero-padded sequence numbers of the checkpoints
+	sort.Slice(checkpoints, func(i, j int) bool { return checkpoints[i].key < checkpoints[j].key })
+
+	vcs := make([]json.RawMessage, len(checkpoints))
+
+	for i, c := range checkpoints {
+		vcs[i] = c.vc
+	}
+
+	return vcs, nil
+}
+
+// Verify verifies the log: the hashes chaining its entries, its head, and the checkpoints, which must be signed and
+// match the entries. Mismatches are reported, errors reading the log returned.
+func (s *Service) Verify(ctx context.Context) (*Report, error) {
+	head, err := s.Head(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Report{Head: head}
+
+	checked := &Head{}
+
+	for checked.Seq < head.Seq {
+		entries, e := s.Entries(ctx, checked.Seq, MaxEntries)
+		if errors.Is(e, storage.ErrDataNotFound) {
+			r.Error = fmt.Errorf("%w: %s", ErrTampered, e).Error()
+
+			return r, nil
+		}
+
+		if e != nil {
+			return nil, e
+		}
+
+		checked, e = VerifyChain(checked, entries)
+		r.Entries = checked.Seq
+
+		if e != nil {
+			r.Error = e.Error()
+
+			return r, nil
+		}
+	}
+
+	if checked.Hash != head.Hash {
+		r.Error = fmt.Errorf("%w: head %d does not match its entry", ErrTampered, head.Seq).Error()
+
+		return r, nil
+	}
+
+	checkpoints, err := s.Checkpoints(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, raw := range checkpoints {
+		if e := s.verifyCheckpoint(ctx, raw, head); e != nil {
+			r.Error = e.Error()
+
+			return r, nil
+		}
+
+		r.Checkpoints++
+	}
+
+	r.Verified = true
+
+	return r, nil
+}
+
+func (s *Service) verifyCheckpoint(ctx context.Context, raw json.RawMessage, head *Head) error {
+	vc, err := verifiable.ParseCredential(raw,
+		verifiable.WithPublicKeyFetcher(verifiable.NewVDRKeyResolver(s.vdr).PublicKeyFetcher()),
+		verifiable.WithJSONLDDocumentLoader(s.documentLoader),
+	)
+	if err != nil {
+		return fmt.Errorf("%w: checkpoint not verified: %s", ErrTampered, err)
+	}
+
+	if len(vc.Proofs) == 0 {
+		return fmt.Errorf("%w: checkpoint not signed", ErrTampered)
+	}
+
+	c, err := ParseCheckpoint(raw)
+	if err != nil {
+		return err
+	}
+
+	if c.Seq < 1 || c.Seq > head.Seq {
+		return fmt.Errorf("%w: checkpoint %d past head %d", ErrTampered, c.Seq, head.Seq)
+	}
+
+	entries, err := s.Entries(ctx, c.Seq-1, 1)
+	if err != nil {
+		return err
+	}
+
+	return VerifyCheckpoint(c, entries[0])
+}
+
+// Hash returns the hash of the entry: the hex-encoded SHA-256 digest of its JSON without its hash.
+func Hash(e *Entry) (string, error) {
+	unhashed := *e
+	unhashed.Hash = ""
+
+	b, err := json.Marshal(&unhashed)
+	if err != nil {
+		return "", fmt.Errorf("marshal entry: %w", err)
+	}
+
+	sum := sha256.Sum256(b)
+
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// VerifyChain verifies that the entries follow the head, chained to each other, and returns the new head.
+func VerifyChain(head *Head, entries []*Entry) (*Head, error) {
+	for _, e := range entries {
+		if e.Seq != head.Seq+1 {
+			return head, fmt.Errorf("%w: entry %d follows entry %d", ErrTampered, e.Seq, head.Seq)
+		}
+
+		if e.PrevHash != head.Hash {
+			return head, fmt.Errorf("%w: entry %d not chained to entry %d", ErrTampered, e.Seq, head.Seq)
+		}
+
+		h, err := Hash(e)
+		if err != nil {
+			return head, err
+		}
+
+		if h != e.Hash {
+			return head, fmt.Errorf("%w: entry %d does not match its hash", ErrTampered, e.Seq)
+		}
+
+		head = &Head{Seq: e.Seq, Hash: e.Hash}
+	}
+
+	return head, nil
+}
+
+// VerifyCheckpoint verifies that the checkpoint matches the entry, once the chain of the entries is verified.
+func VerifyCheckpoint(c *Checkpoint, e *Entry) error {
+	if e == nil || e.Seq != c.Seq || e.Hash != c.Hash {
+		return fmt.Errorf("%w: entry %d does not match its checkpoint", ErrTampered, c.Seq)
+	}
+
+	return nil
+}
+
+// ParseCheckpoint returns the checkpoint of the credential, without verifying its proof.
+func ParseCheckpoint(raw json.RawMessage) (*Checkpoint, error) {
+	var vc struct {
+		Subject Checkpoint `json:"credentialSubject"`
+	}
+
+	if err := json.Unmarshal(raw, &vc); err != nil {
+		return nil, fmt.Errorf("%w: unmarshal checkpoint: %s", ErrTampered, err)
+	}
+
+	return &vc.Subject, nil
+}
+
+func (s *Service) getHead(key string) (*Head, error) {
+	b, err := s.store.Get(key)
+	if errors.Is(err, storage.ErrDataNotFound) {
+		return &Head{}, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("get %s: %w", key, err)
+	}
+
+	var h Head
+
+	if err = json.Unmarshal(b, &h); err != nil {
+		return nil, fmt.Errorf("unmarshal %s: %w", key, err)
+	}
+
+	return &h, nil
+}
+
+func (s *Service) entry(seq int) (*Entry, error) {
+	b, err := s.store.Get(fmt.Sprintf(entryKeyFormat, seq))
+	if err != nil {
+		return nil, fmt.Errorf("get entry %d: %w", seq, err)
+	}
+
+	var e Entry
+
+	if err = json.Unmarshal(b, &e); err != nil {
+		return nil, fmt.Errorf("unmarshal entry %d: %w", seq, err)
+	}
+
+	return &e, nil
+}
+
+// seqTagOf returns the tag of the sequence number.
+func seqTagOf(seq int) storage.Tag {
+	return storage.Tag{Name: seqTag, Value: strconv.Itoa(seq)}
+}
+
+func (s *Service) put(key string, h *Head) error {
+	b, err := json.Marshal(h)
+	if err != nil {
+		return fmt.Errorf("marshal %s: %w", key, err)
+	}
+
+	if err = s.store.Put(key, b); err != nil {
+		return fmt.Errorf("store %s: %w", key, err)
+	}
+
+	return nil
+}
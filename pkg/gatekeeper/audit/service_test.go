@@ -0,0 +1,631 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package audit_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/hyperledger/aries-framework-go/component/storageutil/mem"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/did"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/signature/jsonld"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/signature/suite"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/signature/suite/ed25519signature2018"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
+	vdrapi "github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdr"
+	mockstorage "github.com/hyperledger/aries-framework-go/pkg/mock/storage"
+	mockvdr "github.com/hyperledger/aries-framework-go/pkg/mock/vdr"
+	"github.com/hyperledger/aries-framework-go/spi/storage"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/ace/pkg/gatekeeper/audit"
+	"github.com/trustbloc/ace/pkg/gatekeeper/release/ticket"
+	"github.com/trustbloc/ace/pkg/internal/testutil"
+	"github.com/trustbloc/ace/pkg/storage/conditional"
+)
+
+const (
+	gatekeeperDID = "did:example:gatekeeper"
+	handlerDID    = "did:example:handler"
+	storeName     = "audit_log"
+)
+
+func TestNewService(t *testing.T) {
+	_, err := audit.NewService(&audit.Config{
+		StoreProvider: &mockstorage.MockStoreProvider{ErrOpenStoreHandle: errors.New("open error")},
+	})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "open audit log store")
+}
+
+func TestService_Append(t *testing.T) {
+	t.Run("chains the actions taken on tickets", func(t *testing.T) {
+		svc, _ := newService(t, nil)
+
+		appendActions(t, svc, ticket.ReleaseAction, ticket.ApproveAction, ticket.CollectAction)
+
+		// tickets without history are not recorded
+		require.NoError(t, svc.Record(context.Background(), &ticket.Ticket{ID: "t2"}))
+
+		head, err := svc.Head(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, 3, head.Seq)
+
+		entries, err := svc.Entries(context.Background(), 0, 0)
+		require.NoError(t, err)
+		require.Len(t, entries, 3)
+		require.Equal(t, ticket.CollectAction, entries[2].Event.Action)
+		require.Equal(t, head.Hash, entries[2].Hash)
+		require.Empty(t, entries[0].PrevHash)
+
+		verified, err := audit.VerifyChain(&audit.Head{}, entries)
+		require.NoError(t, err)
+		require.Equal(t, head, verified)
+
+		entries, err = svc.Entries(context.Background(), 1, 1)
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		require.Equal(t, 2, entries[0].Seq)
+		require.Equal(t, entries[0].PrevHash, mustEntry(t, svc, 1).Hash)
+	})
+
+	t.Run("fails to store entry", func(t *testing.T) {
+		provider := mockstorage.NewMockStoreProvider()
+
+		svc, err := audit.NewService(&audit.Config{StoreProvider: provider})
+		require.NoError(t, err)
+
+		provider.Store.ErrPut = errors.New("put error")
+
+		err = svc.Append(context.Background(), &audit.Entry{TicketID: "t1", Event: &ticket.Event{}})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "store entry")
+
+		err = svc.Record(context.Background(), &ticket.Ticket{
+			ID:      "t1",
+			History: []*ticket.Event{{Action: ticket.ApproveAction}},
+		})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "append approve to audit log")
+	})
+
+	t.Run("queues entries while another instance holds the lease", func(t *testing.T) {
+		provider := mem.NewProvider()
+		holder := &mockLeader{held: true}
+
+		leaderSvc, err := audit.NewService(&audit.Config{StoreProvider: provider, Leader: holder})
+		require.NoError(t, err)
+
+		other, err := audit.NewService(&audit.Config{StoreProvider: provider, Leader: &mockLeader{}})
+		require.NoError(t, err)
+
+		require.NoError(t, other.Append(context.Background(), &audit.Entry{TicketID: "t1", Event: &ticket.Event{}}))
+		require.NoError(t, other.Append(context.Background(), &audit.Entry{TicketID: "t2", Event: &ticket.Event{}}))
+		require.NoError(t, other.Flush(context.Background()))
+
+		head, err := leaderSvc.Head(context.Background())
+		require.NoError(t, err)
+		require.Zero(t, head.Seq)
+
+		require.NoError(t, leaderSvc.Append(context.Background(), &audit.Entry{TicketID: "t3", Event: &ticket.Event{}}))
+		require.NoError(t, other.Append(context.Background(), &audit.Entry{TicketID: "t4", Event: &ticket.Event{}}))
+		require.NoError(t, leaderSvc.Flush(context.Background()))
+
+		entries, err := leaderSvc.Entries(context.Background(), 0, 0)
+		require.NoError(t, err)
+		require.Len(t, entries, 4)
+
+		for i, id := range []string{"t1", "t2", "t3", "t4"} {
+			require.Equal(t, id, entries[i].TicketID)
+		}
+
+		_, err = audit.VerifyChain(&audit.Head{}, entries)
+		require.NoError(t, err)
+
+		// the queue is empty once chained
+		require.NoError(t, leaderSvc.Flush(context.Background()))
+
+		entries, err = leaderSvc.Entries(context.Background(), 0, 0)
+		require.NoError(t, err)
+		require.Len(t, entries, 4)
+	})
+
+	t.Run("chains a queued entry once while two instances hold the lease", func(t *testing.T) {
+		provider := mem.NewProvider()
+
+		leaderSvc, err := audit.NewService(&audit.Config{StoreProvider: provider, Leader: &mockLeader{held: true}})
+		require.NoError(t, err)
+
+		other, err := audit.NewService(&audit.Config{StoreProvider: provider, Leader: &mockLeader{}})
+		require.NoError(t, err)
+
+		require.NoError(t, other.Append(context.Background(), &audit.Entry{TicketID: "t1", Event: &ticket.Event{}}))
+
+		store, err := provider.OpenStore("audit_log")
+		require.NoError(t, err)
+
+		it, err := store.Query("queued")
+		require.NoError(t, err)
+
+		ok, err := it.Next()
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		key, err := it.Key()
+		require.NoError(t, err)
+
+		value, err := it.Value()
+		require.NoError(t, err)
+		require.NoError(t, it.Close())
+
+		require.NoError(t, leaderSvc.Flush(context.Background()))
+
+		// another instance holding the lease read the queue before the entry was removed from it, and the head either
+		// after the entry was chained or before
+		for _, head := range []*audit.Head{nil, {}} {
+			if head != nil {
+				putJSON(t, store, "head", head)
+			}
+
+			require.NoError(t, store.Put(key, value, storage.Tag{Name: "queued"}))
+			require.NoError(t, leaderSvc.Flush(context.Background()))
+
+			entries, e := leaderSvc.Entries(context.Background(), 0, 0)
+			require.NoError(t, e)
+			require.Len(t, entries, 1)
+			require.Equal(t, "t1", entries[0].TicketID)
+			require.Equal(t, 1, mustHead(t, leaderSvc).Seq)
+
+			_, e = store.Get(key)
+			require.ErrorIs(t, e, storage.ErrDataNotFound)
+		}
+	})
+
+	t.Run("chains after an entry chained by another instance without writing over it", func(t *testing.T) {
+		svc, store := newService(t, nil)
+
+		putJSON(t, store, "entry_00000000000000000001", &audit.Entry{Seq: 1, Hash: "other"})
+
+		require.NoError(t, svc.Append(context.Background(), &audit.Entry{TicketID: "t1", Event: &ticket.Event{}}))
+
+		require.Equal(t, "other", mustEntry(t, svc, 1).Hash)
+		require.Equal(t, "other", mustEntry(t, svc, 2).PrevHash)
+		require.Equal(t, 2, mustHead(t, svc).Seq)
+	})
+
+	t.Run("instances sharing the store chain their entries without forking the chain", func(t *testing.T) {
+		const (
+			instances = 2
+			appends   = 20
+		)
+
+		// the entries are written slowly after they are checked, so that the instances chain them at the same time
+		provider := conditional.NewLockingProvider(&slowProvider{Provider: mem.NewProvider()})
+		svcs := make([]*audit.Service, instances)
+
+		for i := range svcs {
+			svc, err := audit.NewService(&audit.Config{StoreProvider: provider})
+			require.NoError(t, err)
+
+			svcs[i] = svc
+		}
+
+		var wg sync.WaitGroup
+
+		errs := make(chan error, instances*appends)
+
+		for i := 0; i < instances*appends; i++ {
+			wg.Add(1)
+
+			go func(svc *audit.Service, i int) {
+				defer wg.Done()
+
+				errs <- svc.Append(context.Background(), &audit.Entry{TicketID: fmt.Sprint(i), Event: &ticket.Event{}})
+			}(svcs[i%instances], i)
+		}
+
+		wg.Wait()
+		close(errs)
+
+		for e := range errs {
+			require.NoError(t, e)
+		}
+
+		r, err := svcs[0].Verify(context.Background())
+		require.NoError(t, err)
+		require.True(t, r.Verified, r.Error)
+		require.Equal(t, instances*appends, r.Entries)
+		require.Equal(t, instances*appends, mustHead(t, svcs[1]).Seq)
+
+		tickets := map[string]struct{}{}
+
+		for _, e := range mustEntries(t, svcs[1]) {
+			tickets[e.TicketID] = struct{}{}
+		}
+
+		require.Len(t, tickets, instances*appends)
+	})
+
+	t.Run("fails to queue entry", func(t *testing.T) {
+		provider := mockstorage.NewMockStoreProvider()
+
+		svc, err := audit.NewService(&audit.Config{StoreProvider: provider, Leader: &mockLeader{}})
+		require.NoError(t, err)
+
+		provider.Store.ErrPut = errors.New("put error")
+
+		err = svc.Append(context.Background(), &audit.Entry{TicketID: "t1", Event: &ticket.Event{}})
+		require.EqualError(t, err, "queue entry: put error")
+	})
+}
+
+type slowProvider struct {
+	storage.Provider
+}
+
+func (p *slowProvider) OpenStore(name string) (storage.Store, error) {
+	s, err := p.Provider.OpenStore(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &slowStore{Store: s}, nil
+}
+
+type slowStore struct {
+	storage.Store
+}
+
+func (s *slowStore) Put(key string, value []byte, tags ...storage.Tag) error {
+	time.Sleep(time.Millisecond)
+
+	return s.Store.Put(key, value, tags...)
+}
+
+type mockLeader struct {
+	held bool
+}
+
+func (l *mockLeader) Held() bool {
+	return l.held
+}
+
+func TestService_Checkpoint(t *testing.T) {
+	t.Run("signs the new entries", func(t *testing.T) {
+		svc, _ := newService(t, nil)
+
+		vc, err := svc.Checkpoint(context.Background())
+		require.NoError(t, err)
+		require.Nil(t, vc, "empty log")
+
+		appendActions(t, svc, ticket.ReleaseAction)
+
+		vc, err = svc.Checkpoint(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, gatekeeperDID, vc.Issuer.ID)
+
+		vc, err = svc.Checkpoint(context.Background())
+		require.NoError(t, err)
+		require.Nil(t, vc, "no entries since the last checkpoint")
+
+		appendActions(t, svc, ticket.ApproveAction)
+
+		_, err = svc.Checkpoint(context.Background())
+		require.NoError(t, err)
+
+		checkpoints, err := svc.Checkpoints(context.Background())
+		require.NoError(t, err)
+		require.Len(t, checkpoints, 2)
+
+		for i, raw := range checkpoints {
+			c, e := audit.ParseCheckpoint(raw)
+			require.NoError(t, e)
+			require.Equal(t, i+1, c.Seq)
+			require.NoError(t, audit.VerifyCheckpoint(c, mustEntry(t, svc, c.Seq)))
+		}
+	})
+
+	t.Run("fails to issue credential", func(t *testing.T) {
+		svc, _ := newService(t, errors.New("issuer error"))
+
+		appendActions(t, svc, ticket.ReleaseAction)
+
+		_, err := svc.Checkpoint(context.Background())
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "issue credential: issuer error")
+	})
+}
+
+func TestService_Verify(t *testing.T) {
+	newLog := func(t *testing.T) (*audit.Service, storage.Store) {
+		t.Helper()
+
+		svc, store := newService(t, nil)
+
+		appendActions(t, svc, ticket.ReleaseAction, ticket.ApproveAction)
+
+		_, err := svc.Checkpoint(context.Background())
+		require.NoError(t, err)
+
+		appendActions(t, svc, ticket.CollectAction)
+
+		return svc, store
+	}
+
+	t.Run("verified", func(t *testing.T) {
+		svc, _ := newLog(t)
+
+		r, err := svc.Verify(context.Background())
+		require.NoError(t, err)
+		require.True(t, r.Verified, r.Error)
+		require.Equal(t, 3, r.Entries)
+		require.Equal(t, 1, r.Checkpoints)
+		require.Equal(t, 3, r.Head.Seq)
+	})
+
+	t.Run("modified entry", func(t *testing.T) {
+		svc, store := newLog(t)
+
+		e := mustEntry(t, svc, 2)
+		e.Event.Actor = handlerDID
+		putJSON(t, store, "entry_00000000000000000002", e)
+
+		r, err := svc.Verify(context.Background())
+		require.NoError(t, err)
+		require.False(t, r.Verified)
+		require.Equal(t, 1, r.Entries)
+		require.Contains(t, r.Error, "entry 2 does not match its hash")
+	})
+
+	t.Run("rehashed entry", func(t *testing.T) {
+		svc, store := newLog(t)
+
+		e := mustEntry(t, svc, 3)
+		e.Event.Actor = handlerDID
+
+		var err error
+
+		e.Hash, err = audit.Hash(e)
+		require.NoError(t, err)
+
+		putJSON(t, store, "entry_00000000000000000003", e)
+
+		r, err := svc.Verify(context.Background())
+		require.NoError(t, err)
+		require.False(t, r.Verified)
+		require.Contains(t, r.Error, "head 3 does not match its entry")
+	})
+
+	t.Run("removed entry", func(t *testing.T) {
+		svc, store := newLog(t)
+
+		require.NoError(t, store.Delete("entry_00000000000000000002"))
+
+		r, err := svc.Verify(context.Background())
+		require.NoError(t, err)
+		require.False(t, r.Verified)
+		require.Contains(t, r.Error, "get entry 2")
+	})
+
+	t.Run("truncated log", func(t *testing.T) {
+		svc, store := newLog(t)
+
+		putJSON(t, store, "head", &audit.Head{Seq: 1, Hash: mustEntry(t, svc, 1).Hash})
+
+		r, err := svc.Verify(context.Background())
+		require.NoError(t, err)
+		require.False(t, r.Verified)
+		require.Contains(t, r.Error, "checkpoint 2 past head 1")
+	})
+
+	t.Run("modified checkpoint", func(t *testing.T) {
+		svc, store := newLog(t)
+
+		b, err := store.Get("checkpoint_00000000000000000002")
+		require.NoError(t, err)
+
+		var vc map[string]interface{}
+
+		require.NoError(t, json.Unmarshal(b, &vc))
+		vc["credentialSubject"].(map[string]interface{})["seq"] = 1 //nolint:forcetypeassert
+		putJSON(t, store, "checkpoint_00000000000000000002", vc, storage.Tag{Name: "checkpoint"})
+
+		r, err := svc.Verify(context.Background())
+		require.NoError(t, err)
+		require.False(t, r.Verified)
+		require.Contains(t, r.Error, "checkpoint not verified")
+	})
+
+	t.Run("unsigned checkpoint", func(t *testing.T) {
+		svc, store := newLog(t)
+
+		b, err := store.Get("checkpoint_00000000000000000002")
+		require.NoError(t, err)
+
+		var vc map[string]interface{}
+
+		require.NoError(t, json.Unmarshal(b, &vc))
+		delete(vc, "proof")
+		putJSON(t, store, "checkpoint_00000000000000000002", vc, storage.Tag{Name: "checkpoint"})
+
+		r, err := svc.Verify(context.Background())
+		require.NoError(t, err)
+		require.False(t, r.Verified)
+		require.Contains(t, r.Error, "checkpoint not signed")
+	})
+}
+
+func TestVerifyChain(t *testing.T) {
+	entry := func(seq int, prev string) *audit.Entry {
+		e := &audit.Entry{Seq: seq, TicketID: "t1", Event: &ticket.Event{Action: ticket.ReleaseAction}, PrevHash: prev}
+
+		h, err := audit.Hash(e)
+		require.NoError(t, err)
+
+		e.Hash = h
+
+		return e
+	}
+
+	first := entry(1, "")
+
+	_, err := audit.VerifyChain(&audit.Head{}, []*audit.Entry{first, entry(3, first.Hash)})
+	require.ErrorIs(t, err, audit.ErrTampered)
+	require.Contains(t, err.Error(), "entry 3 follows entry 1")
+
+	head, err := audit.VerifyChain(&audit.Head{}, []*audit.Entry{first, entry(2, "other")})
+	require.ErrorIs(t, err, audit.ErrTampered)
+	require.Contains(t, err.Error(), "entry 2 not chained to entry 1")
+	require.Equal(t, &audit.Head{Seq: 1, Hash: first.Hash}, head)
+
+	err = audit.VerifyCheckpoint(&audit.Checkpoint{Seq: 1, Hash: "other"}, first)
+	require.ErrorIs(t, err, audit.ErrTampered)
+
+	_, err = audit.ParseCheckpoint([]byte("invalid"))
+	require.ErrorIs(t, err, audit.ErrTampered)
+}
+
+// newService returns the service of a log in memory, whose checkpoints are signed by the gatekeeper, or fail with
+// the issuer error if it is set.
+func newService(t *testing.T, issueErr error) (*audit.Service, storage.Store) {
+	t.Helper()
+
+	loader := testutil.DocumentLoader(t)
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	issuer := NewMockVCIssuer(gomock.NewController(t))
+	issuer.EXPECT().IssueCredential(gomock.Any(), gomock.Any()).AnyTimes().
+		DoAndReturn(func(_ context.Context, cred []byte) (*verifiable.Credential, error) {
+			if issueErr != nil {
+				return nil, issueErr
+			}
+
+			vc, e := verifiable.ParseCredential(cred, verifiable.WithDisabledProofCheck(),
+				verifiable.WithJSONLDDocumentLoader(loader))
+			if e != nil {
+				return nil, e
+			}
+
+			vc.Issuer.ID = gatekeeperDID
+
+			return vc, vc.AddLinkedDataProof(&verifiable.LinkedDataProofContext{
+				SignatureType:           "Ed25519Signature2018",
+				Suite:                   ed25519signature2018.New(suite.WithSigner(&signer{key: key})),
+				SignatureRepresentation: verifiable.SignatureJWS,
+				VerificationMethod:      gatekeeperDID + "#key1",
+			}, jsonld.WithDocumentLoader(loader))
+		})
+
+	provider := mem.NewProvider()
+
+	svc, err := audit.NewService(&audit.Config{
+		StoreProvider:  provider,
+		VCIssuer:       issuer,
+		VDR:            newMockVDR(key),
+		DocumentLoader: loader,
+	})
+	require.NoError(t, err)
+
+	store, err := provider.OpenStore(storeName)
+	require.NoError(t, err)
+
+	return svc, store
+}
+
+func appendActions(t *testing.T, svc *audit.Service, actions ...ticket.Action) {
+	t.Helper()
+
+	for _, a := range actions {
+		require.NoError(t, svc.Record(context.Background(), &ticket.Ticket{
+			ID:       "t1",
+			DID:      "did:example:data",
+			PolicyID: "p1",
+			History:  []*ticket.Event{{Action: a, Actor: gatekeeperDID, Timestamp: time.Now().UTC()}},
+		}))
+	}
+}
+
+func mustEntry(t *testing.T, svc *audit.Service, seq int) *audit.Entry {
+	t.Helper()
+
+	entries, err := svc.Entries(context.Background(), seq-1, 1)
+	require.NoError(t, err)
+	require.Len(t, entries, 1, fmt.Sprintf("entry %d", seq))
+
+	return entries[0]
+}
+
+func mustEntries(t *testing.T, svc *audit.Service) []*audit.Entry {
+	t.Helper()
+
+	entries, err := svc.Entries(context.Background(), 0, 0)
+	require.NoError(t, err)
+
+	return entries
+}
+
+func mustHead(t *testing.T, svc *audit.Service) *audit.Head {
+	t.Helper()
+
+	head, err := svc.Head(context.Background())
+	require.NoError(t, err)
+
+	return head
+}
+
+func putJSON(t *testing.T, store storage.Store, key string, v interface{}, tags ...storage.Tag) {
+	t.Helper()
+
+	b, err := json.Marshal(v)
+	require.NoError(t, err)
+	require.NoError(t, store.Put(key, b, tags...))
+}
+
+func newMockVDR(key ed25519.PrivateKey) *mockvdr.MockVDRegistry {
+	return &mockvdr.MockVDRegistry{
+		ResolveFunc: func(id string, _ ...vdrapi.DIDMethodOption) (*did.DocResolution, error) {
+			if id != gatekeeperDID {
+				return nil, vdrapi.ErrNotFound
+			}
+
+			vm := did.VerificationMethod{
+				ID:         id + "#key1",
+				Type:       "Ed25519VerificationKey2018",
+				Controller: id,
+				Value:      key.Public().(ed25519.PublicKey), //nolint:forcetypeassert
+			}
+
+			return &did.DocResolution{DIDDocument: &did.Doc{
+				Context:         []string{did.ContextV1},
+				ID:              id,
+				AssertionMethod: []did.Verification{{VerificationMethod: vm}},
+			}}, nil
+		},
+	}
+}
+
+type signer struct {
+	key ed25519.PrivateKey
+}
+
+func (s *signer) Sign(data []byte) ([]byte, error) {
+	return ed25519.Sign(s.key, data), nil
+}
+
+func (s *signer) Alg() string {
+	return ""
+}
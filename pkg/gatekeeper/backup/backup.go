@@ -0,0 +1,493 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package backup exports the policies, the protected data, the tickets and the quota usages of Gatekeeper to an
+// encrypted snapshot, and restores a snapshot into a fresh instance, for disaster recovery drills. Snapshots hold the
+// metadata of the protected data only: the documents themselves stay in the vault, which the restored instance has to
+// share.
+package backup
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/hyperledger/aries-framework-go/pkg/common/log"
+	"github.com/hyperledger/aries-framework-go/spi/storage"
+
+	"github.com/trustbloc/ace/pkg/gatekeeper/stores"
+	"github.com/trustbloc/ace/pkg/storage/migrate"
+	"github.com/trustbloc/ace/pkg/storage/tombstone"
+)
+
+const (
+	// KeySize is the size of the AES-256-GCM keys the snapshots are encrypted with.
+	KeySize = 32
+
+	// snapshotVersion is the version of the snapshots exported, encrypted a batch of entries at a time. Snapshots of
+	// version 1, encrypted at once, are still restored.
+	snapshotVersion    = 2
+	wholeVersion       = 1
+	snapshotAlgorithm  = "A256GCM"
+	batchSize          = 100
+	counterSize        = 8
+	policyStore        = "policy"
+	protectedDataStore = "protected_data"
+	ticketStore        = "ticket"
+	quotaStore         = "quota"
+)
+
+var (
+	// ErrInvalidSnapshot is returned when restoring a snapshot that cannot be decrypted with the key or is malformed.
+	ErrInvalidSnapshot = errors.New("invalid snapshot")
+	// ErrNotEmpty is returned when restoring a snapshot into an instance that already has some of its entries.
+	ErrNotEmpty = errors.New("instance not empty")
+)
+
+var logger = log.New("gatekeeper-backup")
+
+// envelope is the encrypted snapshot. Snapshots of version 1 are encrypted at once into Ciphertext, later ones a
+// chunk at a time into Chunks, each sealed with Nonce combined with the index of the chunk.
+type envelope struct {
+	Version    int      `json:"version"`
+	Algorithm  string   `json:"alg"`
+	Nonce      []byte   `json:"nonce"`
+	Ciphertext []byte   `json:"ciphertext,omitempty"`
+	Chunks     [][]byte `json:"chunks,omitempty"`
+}
+
+type snapshot struct {
+	CreatedAt time.Time        `json:"created_at"`
+	Stores    []*storeSnapshot `json:"stores"`
+}
+
+type storeSnapshot struct {
+	Name     string   `json:"name"`
+	TagNames []string `json:"tag_names,omitempty"`
+	Entries  []*entry `json:"entries"`
+}
+
+// chunk is a batch of entries of a store, the plaintext of a chunk of the snapshot. The last chunk has no entries
+// and is Final, so that truncated snapshots are detected.
+type chunk struct {
+	Store     string    `json:"store,omitempty"`
+	TagNames  []string  `json:"tag_names,omitempty"`
+	Entries   []*entry  `json:"entries,omitempty"`
+	Final     bool      `json:"final,omitempty"`
+	CreatedAt time.Time `json:"created_at,omitempty"`
+}
+
+type entry struct {
+	Key   string `json:"key"`
+	Value []byte `json:"value"`
+	Tags  []tag  `json:"tags,omitempty"`
+}
+
+type tag struct {
+	Name  string `json:"name"`
+	Value string `json:"value,omitempty"`
+}
+
+// Result summarizes the export or the restore of a store.
+type Result struct {
+	Store   string `json:"store"`
+	Entries int    `json:"entries"`
+}
+
+// Service exports and restores the snapshots of the stores of a storage provider.
+type Service struct {
+	provider storage.Provider
+	aead     cipher.AEAD
+}
+
+// New returns a new instance of Service, encrypting the snapshots with the AES-256-GCM key.
+func New(provider storage.Provider, key []byte) (*Service, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("invalid key size %d, must be %d bytes", len(key), KeySize)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("create cipher: %w", err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("create GCM: %w", err)
+	}
+
+	return &Service{provider: provider, aead: aead}, nil
+}
+
+// Export writes the encrypted snapshot of the policies, the protected data and the tickets, including those deleted
+// and not purged yet, and of the quota usages to w as the entries are read, a batch at a time. The stores and their
+// entries are found as for the migration of the instance. The snapshot written is truncated, and cannot be restored,
+// if the export fails.
+func (s *Service) Export(w io.Writer) ([]*Result, error) {
+	migrations, err := stores.Migrations(s.provider)
+	if err != nil {
+		return nil, fmt.Errorf("find entries: %w", err)
+	}
+
+	nonce := make([]byte, s.aead.NonceSize())
+
+	if _, err = rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("generate nonce: %w", err)
+	}
+
+	out := &chunkWriter{w: w, aead: s.aead, nonce: nonce}
+
+	var results []*Result
+
+	for _, m := range migrations {
+		if !knownStore(m.Name) {
+			continue
+		}
+
+		r, e := s.exportStore(out, m)
+		if e != nil {
+			return nil, e
+		}
+
+		results = append(results, r)
+	}
+
+	if err = out.close(&chunk{Final: true, CreatedAt: time.Now().UTC()}); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+func (s *Service) exportStore(out *chunkWriter, m *migrate.Store) (*Result, error) {
+	config, err := s.provider.GetStoreConfig(m.Name)
+	if err != nil && !errors.Is(err, storage.ErrStoreNotFound) {
+		return nil, fmt.Errorf("get store config %s: %w", m.Name, err)
+	}
+
+	result := &Result{Store: m.Name}
+
+	// the first chunk of each store carries its config, even if the store has no entries
+	c := &chunk{Store: m.Name, TagNames: config.TagNames}
+
+	err = migrate.Each(s.provider, m, batchSize, func(batch []*migrate.Entry) error {
+		for _, e := range batch {
+			c.Entries = append(c.Entries, &entry{Key: e.Key, Value: e.Value, Tags: fromStorageTags(e.Tags)})
+		}
+
+		result.Entries += len(batch)
+
+		if e := out.write(c); e != nil {
+			return e
+		}
+
+		c = &chunk{Store: m.Name}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("export %s: %w", m.Name, err)
+	}
+
+	if result.Entries == 0 {
+		if err = out.write(c); err != nil {
+			return nil, fmt.Errorf("export %s: %w", m.Name, err)
+		}
+	}
+
+	return result, nil
+}
+
+// chunkWriter writes the envelope of a snapshot, sealing each chunk as it is written.
+type chunkWriter struct {
+	w     io.Writer
+	aead  cipher.AEAD
+	nonce []byte
+	count uint64
+}
+
+func (c *chunkWriter) write(ch *chunk) error {
+	if c.count == 0 {
+		nonce, err := json.Marshal(c.nonce)
+		if err != nil {
+			return fmt.Errorf("marshal nonce: %w", err)
+		}
+
+		prologue := fmt.Sprintf(`{"version":%d,"alg":%q,"nonce":%s,"chunks":[`, snapshotVersion, snapshotAlgorithm,
+			nonce)
+
+		if _, err = io.WriteString(c.w, prologue); err != nil {
+			return fmt.Errorf("write snapshot: %w", err)
+		}
+	} else if _, err := io.WriteString(c.w, ","); err != nil {
+		return fmt.Errorf("write snapshot: %w", err)
+	}
+
+	plaintext, err := json.Marshal(ch)
+	if err != nil {
+		return fmt.Errorf("marshal chunk: %w", err)
+	}
+
+	b, err := json.Marshal(c.aead.Seal(nil, chunkNonce(c.nonce, c.count), plaintext, nil))
+	if err != nil {
+		return fmt.Errorf("marshal chunk: %w", err)
+	}
+
+	if _, err = c.w.Write(b); err != nil {
+		return fmt.Errorf("write snapshot: %w", err)
+	}
+
+	c.count++
+
+	return nil
+}
+
+func (c *chunkWriter) close(final *chunk) error {
+	if err := c.write(final); err != nil {
+		return err
+	}
+
+	if _, err := io.WriteString(c.w, "]}"); err != nil {
+		return fmt.Errorf("write snapshot: %w", err)
+	}
+
+	return nil
+}
+
+// chunkNonce returns the nonce of the chunk at index i: the nonce of the snapshot with its last bytes XORed with i,
+// so that chunks cannot be reordered or dropped.
+func chunkNonce(nonce []byte, i uint64) []byte {
+	n := make([]byte, len(nonce))
+	copy(n, nonce)
+
+	var counter [counterSize]byte
+
+	binary.BigEndian.PutUint64(counter[:], i)
+
+	for j := range counter {
+		n[len(n)-counterSize+j] ^= counter[j]
+	}
+
+	return n
+}
+
+// Restore decrypts the snapshot and writes its entries, with their tags and the indexes of their stores. The instance
+// is expected to be fresh: nothing is written if any of the entries is already present.
+func (s *Service) Restore(data []byte) ([]*Result, error) {
+	snap, err := s.decrypt(data)
+	if err != nil {
+		return nil, err
+	}
+
+	stores := make([]storage.Store, len(snap.Stores))
+
+	for i, ss := range snap.Stores {
+		if !knownStore(ss.Name) {
+			return nil, fmt.Errorf("%w: unknown store %q", ErrInvalidSnapshot, ss.Name)
+		}
+
+		if stores[i], err = s.provider.OpenStore(ss.Name); err != nil {
+			return nil, fmt.Errorf("open store %s: %w", ss.Name, err)
+		}
+
+		if err = checkEmpty(stores[i], ss); err != nil {
+			return nil, err
+		}
+	}
+
+	results := make([]*Result, len(snap.Stores))
+
+	for i, ss := range snap.Stores {
+		if len(ss.TagNames) > 0 {
+			if err = s.provider.SetStoreConfig(ss.Name, storage.StoreConfiguration{TagNames: ss.TagNames}); err != nil {
+				return nil, fmt.Errorf("set store config %s: %w", ss.Name, err)
+			}
+		}
+
+		if err = restoreStore(stores[i], ss); err != nil {
+			return nil, err
+		}
+
+		results[i] = &Result{Store: ss.Name, Entries: len(ss.Entries)}
+
+		logger.Infof("restored store %s: entries=%d", ss.Name, len(ss.Entries))
+	}
+
+	return results, nil
+}
+
+func (s *Service) decrypt(data []byte) (*snapshot, error) {
+	var env envelope
+
+	if err := json.Unmarshal(data, &env); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidSnapshot, err)
+	}
+
+	if env.Version != snapshotVersion && env.Version != wholeVersion || env.Algorithm != snapshotAlgorithm {
+		return nil, fmt.Errorf("%w: unsupported version %d or algorithm %q", ErrInvalidSnapshot, env.Version,
+			env.Algorithm)
+	}
+
+	if len(env.Nonce) != s.aead.NonceSize() {
+		return nil, fmt.Errorf("%w: invalid nonce", ErrInvalidSnapshot)
+	}
+
+	if env.Version == wholeVersion {
+		return s.decryptWhole(&env)
+	}
+
+	return s.decryptChunks(&env)
+}
+
+func (s *Service) decryptWhole(env *envelope) (*snapshot, error) {
+	plaintext, err := s.aead.Open(nil, env.Nonce, env.Ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: decrypt: %s", ErrInvalidSnapshot, err)
+	}
+
+	var snap snapshot
+
+	if err = json.Unmarshal(plaintext, &snap); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidSnapshot, err)
+	}
+
+	return &snap, nil
+}
+
+// decryptChunks decrypts the chunks of the snapshot in order, and merges the chunks of each store.
+func (s *Service) decryptChunks(env *envelope) (*snapshot, error) {
+	snap := &snapshot{}
+
+	for i, sealed := range env.Chunks {
+		plaintext, err := s.aead.Open(nil, chunkNonce(env.Nonce, uint64(i)), sealed, nil)
+		if err != nil {
+			return nil, fmt.Errorf("%w: decrypt chunk %d: %s", ErrInvalidSnapshot, i, err)
+		}
+
+		var c chunk
+
+		if err = json.Unmarshal(plaintext, &c); err != nil {
+			return nil, fmt.Errorf("%w: chunk %d: %s", ErrInvalidSnapshot, i, err)
+		}
+
+		if c.Final {
+			if i != len(env.Chunks)-1 {
+				return nil, fmt.Errorf("%w: chunks after the final chunk", ErrInvalidSnapshot)
+			}
+
+			snap.CreatedAt = c.CreatedAt
+
+			return snap, nil
+		}
+
+		n := len(snap.Stores)
+
+		if n == 0 || snap.Stores[n-1].Name != c.Store {
+			snap.Stores = append(snap.Stores, &storeSnapshot{Name: c.Store, TagNames: c.TagNames, Entries: []*entry{}})
+			n++
+		}
+
+		snap.Stores[n-1].Entries = append(snap.Stores[n-1].Entries, c.Entries...)
+	}
+
+	return nil, fmt.Errorf("%w: truncated, no final chunk", ErrInvalidSnapshot)
+}
+
+func checkEmpty(store storage.Store, ss *storeSnapshot) error {
+	for start := 0; start < len(ss.Entries); start += batchSize {
+		batch := ss.Entries[start:batchEnd(start, len(ss.Entries))]
+
+		keys := make([]string, len(batch))
+
+		for i, e := range batch {
+			keys[i] = e.Key
+		}
+
+		values, err := store.GetBulk(keys...)
+		if err != nil {
+			return fmt.Errorf("get entries of %s: %w", ss.Name, err)
+		}
+
+		for i, v := range values {
+			if v != nil {
+				return fmt.Errorf("%w: entry %s of store %s already exists", ErrNotEmpty, keys[i], ss.Name)
+			}
+		}
+	}
+
+	return nil
+}
+
+func restoreStore(store storage.Store, ss *storeSnapshot) error {
+	for start := 0; start < len(ss.Entries); start += batchSize {
+		batch := ss.Entries[start:batchEnd(start, len(ss.Entries))]
+
+		operations := make([]storage.Operation, len(batch))
+
+		for i, e := range batch {
+			operations[i] = storage.Operation{Key: e.Key, Value: e.Value, Tags: toStorageTags(e.Tags)}
+		}
+
+		if err := store.Batch(operations); err != nil {
+			return fmt.Errorf("restore %s: %w", ss.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func knownStore(name string) bool {
+	switch name {
+	case policyStore, protectedDataStore, ticketStore, tombstone.StoreName(policyStore),
+		tombstone.StoreName(protectedDataStore), quotaStore:
+		return true
+	default:
+		return false
+	}
+}
+
+func fromStorageTags(tags []storage.Tag) []tag {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	t := make([]tag, len(tags))
+
+	for i := range tags {
+		t[i] = tag{Name: tags[i].Name, Value: tags[i].Value}
+	}
+
+	return t
+}
+
+func toStorageTags(tags []tag) []storage.Tag {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	t := make([]storage.Tag, len(tags))
+
+	for i := range tags {
+		t[i] = storage.Tag{Name: tags[i].Name, Value: tags[i].Value}
+	}
+
+	return t
+}
+
+// batchEnd returns the end of the batch of entries starting at start.
+func batchEnd(start, n int) int {
+	if start+batchSize < n {
+		return start + batchSize
+	}
+
+	return n
+}
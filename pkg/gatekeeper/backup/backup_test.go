@@ -0,0 +1,205 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package backup_test
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/hyperledger/aries-framework-go/component/storageutil/mem"
+	"github.com/hyperledger/aries-framework-go/spi/storage"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/ace/pkg/gatekeeper/backup"
+)
+
+func TestService(t *testing.T) {
+	key := bytes.Repeat([]byte{1}, backup.KeySize)
+
+	source := mem.NewProvider()
+
+	put(t, source, "policy", "p1", `{"id":"p1"}`, storage.Tag{Name: "id", Value: "p1"})
+	// saved before policies were tagged, found through the protected data referencing it
+	put(t, source, "policy", "p2", `{"id":"p2"}`)
+	put(t, source, "protected_data", "d1", `{"did":"did:example:1"}`, storage.Tag{Name: "policyID", Value: "p2"})
+	put(t, source, "ticket", "t1", `{"id":"t1"}`, storage.Tag{Name: "status", Value: "new"})
+	put(t, source, "quota", "did:example:1", `{"extractions":[],"revision":1}`,
+		storage.Tag{Name: "revision", Value: "1"})
+
+	require.NoError(t, source.SetStoreConfig("ticket", storage.StoreConfiguration{TagNames: []string{"status"}}))
+
+	svc, err := backup.New(source, key)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+
+	results, err := svc.Export(&buf)
+	require.NoError(t, err)
+	require.Equal(t, []*backup.Result{
+		{Store: "policy", Entries: 2},
+		{Store: "protected_data", Entries: 1},
+		{Store: "ticket", Entries: 1},
+		{Store: "policy_deleted", Entries: 0},
+		{Store: "protected_data_deleted", Entries: 0},
+		{Store: "quota", Entries: 1},
+	}, results)
+
+	snapshot := buf.Bytes()
+
+	// the snapshot is encrypted
+	require.NotContains(t, string(snapshot), "did:example:1")
+
+	t.Run("Restore into a fresh instance", func(t *testing.T) {
+		target := mem.NewProvider()
+
+		restorer, err := backup.New(target, key)
+		require.NoError(t, err)
+
+		restored, err := restorer.Restore(snapshot)
+		require.NoError(t, err)
+		require.Equal(t, results, restored)
+
+		store, err := target.OpenStore("policy")
+		require.NoError(t, err)
+
+		v, err := store.Get("p2")
+		require.NoError(t, err)
+		require.Equal(t, `{"id":"p2"}`, string(v))
+
+		store, err = target.OpenStore("ticket")
+		require.NoError(t, err)
+
+		iter, err := store.Query("status:new")
+		require.NoError(t, err)
+
+		ok, err := iter.Next()
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		config, err := target.GetStoreConfig("ticket")
+		require.NoError(t, err)
+		require.Equal(t, []string{"status"}, config.TagNames)
+
+		// the instance is not fresh anymore
+		_, err = restorer.Restore(snapshot)
+		require.True(t, errors.Is(err, backup.ErrNotEmpty), err)
+	})
+
+	t.Run("Invalid snapshots", func(t *testing.T) {
+		restorer, err := backup.New(mem.NewProvider(), bytes.Repeat([]byte{2}, backup.KeySize))
+		require.NoError(t, err)
+
+		_, err = restorer.Restore(snapshot)
+		require.True(t, errors.Is(err, backup.ErrInvalidSnapshot), err)
+		require.Contains(t, err.Error(), "decrypt")
+
+		var env map[string]interface{}
+
+		require.NoError(t, json.Unmarshal(snapshot, &env))
+
+		env["version"] = 3
+
+		b, err := json.Marshal(env)
+		require.NoError(t, err)
+
+		for _, s := range [][]byte{[]byte("not json"), b} {
+			_, err = restorer.Restore(s)
+			require.True(t, errors.Is(err, backup.ErrInvalidSnapshot), err)
+		}
+	})
+
+	t.Run("Truncated snapshots", func(t *testing.T) {
+		restorer, err := backup.New(mem.NewProvider(), key)
+		require.NoError(t, err)
+
+		var env map[string]interface{}
+
+		require.NoError(t, json.Unmarshal(snapshot, &env))
+
+		chunks := env["chunks"].([]interface{}) //nolint:forcetypeassert
+
+		for _, c := range [][]interface{}{chunks[:len(chunks)-1], chunks[1:]} {
+			env["chunks"] = c
+
+			b, err := json.Marshal(env)
+			require.NoError(t, err)
+
+			_, err = restorer.Restore(b)
+			require.True(t, errors.Is(err, backup.ErrInvalidSnapshot), err)
+		}
+	})
+
+	t.Run("Restore a snapshot of version 1", func(t *testing.T) {
+		restorer, err := backup.New(mem.NewProvider(), key)
+		require.NoError(t, err)
+
+		restored, err := restorer.Restore(sealWhole(t, key, `{"stores":[{"name":"policy","entries":[{"key":"p1",`+
+			`"value":"e30="}]}]}`))
+		require.NoError(t, err)
+		require.Equal(t, []*backup.Result{{Store: "policy", Entries: 1}}, restored)
+	})
+
+	t.Run("Fails to export", func(t *testing.T) {
+		failing, err := backup.New(&failingProvider{Provider: source, name: "ticket"}, key)
+		require.NoError(t, err)
+
+		_, err = failing.Export(&bytes.Buffer{})
+		require.EqualError(t, err, "export ticket: open store ticket: test error")
+	})
+
+	t.Run("Invalid key", func(t *testing.T) {
+		_, err := backup.New(source, []byte("short"))
+		require.EqualError(t, err, "invalid key size 5, must be 32 bytes")
+	})
+}
+
+func sealWhole(t *testing.T, key []byte, plaintext string) []byte {
+	t.Helper()
+
+	block, err := aes.NewCipher(key)
+	require.NoError(t, err)
+
+	aead, err := cipher.NewGCM(block)
+	require.NoError(t, err)
+
+	nonce := make([]byte, aead.NonceSize())
+
+	b, err := json.Marshal(map[string]interface{}{
+		"version":    1,
+		"alg":        "A256GCM",
+		"nonce":      nonce,
+		"ciphertext": aead.Seal(nil, nonce, []byte(plaintext), nil),
+	})
+	require.NoError(t, err)
+
+	return b
+}
+
+func put(t *testing.T, provider storage.Provider, name, key, value string, tags ...storage.Tag) {
+	t.Helper()
+
+	store, err := provider.OpenStore(name)
+	require.NoError(t, err)
+	require.NoError(t, store.Put(key, []byte(value), tags...))
+}
+
+type failingProvider struct {
+	storage.Provider
+	name string
+}
+
+func (p *failingProvider) OpenStore(name string) (storage.Store, error) {
+	if name == p.name {
+		return nil, errors.New("test error")
+	}
+
+	return p.Provider.OpenStore(name)
+}
@@ -20,6 +20,7 @@ import (
 
 	"github.com/trustbloc/ace/pkg/client/csh/client/operations"
 	cshclientmodels "github.com/trustbloc/ace/pkg/client/csh/models"
+	vaultclient "github.com/trustbloc/ace/pkg/client/vault"
 	"github.com/trustbloc/ace/pkg/gatekeeper/config"
 	"github.com/trustbloc/ace/pkg/gatekeeper/protect"
 	"github.com/trustbloc/ace/pkg/restapi/vault"
@@ -40,31 +41,62 @@ type cshClient interface {
 }
 
 type vaultClient interface {
-	CreateAuthorization(vaultID, requestingParty string,
+	CreateAuthorization(ctx context.Context, vaultID, requestingParty string,
 		scope *vault.AuthorizationsScope) (*vault.CreatedAuthorization, error)
-	GetDocMetaData(vaultID, docID string) (*vault.DocumentMetadata, error)
+	GetDocMetaData(ctx context.Context, vaultID, docID string) (*vault.DocumentMetadata, error)
 }
 
 // Service is a service for collecting protected resources.
 type Service struct {
 	configService configService
 	vClient       vaultClient
+	regionVaults  map[string]vaultclient.Vault
 	cshClient     cshClient
 }
 
+// Option configures the collect service.
+type Option func(s *Service)
+
+// WithRegionVaults sets the vault clients of the residency regions, keyed by region, the data protected in the regions
+// is collected with.
+func WithRegionVaults(clients map[string]vaultclient.Vault) Option {
+	return func(s *Service) {
+		s.regionVaults = clients
+	}
+}
+
 // NewService returns new collect service.
-func NewService(configService configService, vClient vaultClient, cshClient cshClient) *Service {
-	return &Service{
+func NewService(configService configService, vClient vaultClient, cshClient cshClient, opts ...Option) *Service {
+	s := &Service{
 		configService: configService,
 		vClient:       vClient,
 		cshClient:     cshClient,
 	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
 }
 
-// Collect collects protected resource and returns access handle for it.
+// Collect collects protected resource and returns access handle for it. Data protected in a residency region is
+// collected from the vault of the region.
 func (s *Service) Collect(
-	_ context.Context, protectedData *protect.ProtectedData, requestingPartyDID string) (string, error) {
+	ctx context.Context, protectedData *protect.ProtectedData, requestingPartyDID string) (string, error) {
+	vClient := s.vClient
+
+	if protectedData.Region != "" {
+		var ok bool
+
+		if vClient, ok = s.regionVaults[protectedData.Region]; !ok {
+			return "", fmt.Errorf("%w: %s", protect.ErrUnknownRegion, protectedData.Region)
+		}
+	}
+
 	auth, err := s.createQueryOnCSH(
+		ctx,
+		vClient,
 		protectedData.DID,
 		protectedData.VCDocID,
 		requestingPartyDID,
@@ -76,13 +108,15 @@ func (s *Service) Collect(
 	return auth, nil
 }
 
-func (s *Service) createQueryOnCSH(vaultID, docID, _ string) (string, error) { // nolint:funlen
+func (s *Service) createQueryOnCSH(ctx context.Context, vClient vaultClient, vaultID, docID, // nolint:funlen
+	_ string) (string, error) {
 	cfg, err := s.configService.Get()
 	if err != nil {
 		return "", fmt.Errorf("failed get config: %w", err)
 	}
 
-	docAuth, err := s.vClient.CreateAuthorization(
+	docAuth, err := vClient.CreateAuthorization(
+		ctx,
 		vaultID,
 		cfg.CSHPubKeyURL,
 		&vault.AuthorizationsScope{
@@ -99,7 +133,7 @@ func (s *Service) createQueryOnCSH(vaultID, docID, _ string) (string, error) { /
 		return "", errors.New("missing auth token from vault-server")
 	}
 
-	docMeta, err := s.vClient.GetDocMetaData(vaultID, docID)
+	docMeta, err := vClient.GetDocMetaData(ctx, vaultID, docID)
 	if err != nil {
 		return "", fmt.Errorf("failed to get doc meta: %w", err)
 	}
@@ -121,7 +155,7 @@ func (s *Service) createQueryOnCSH(vaultID, docID, _ string) (string, error) { /
 	docAttrPath := "$.credentialSubject.data"
 
 	response, err := s.cshClient.PostHubstoreProfilesProfileIDQueries(
-		operations.NewPostHubstoreProfilesProfileIDQueriesParams().
+		operations.NewPostHubstoreProfilesProfileIDQueriesParamsWithContext(ctx).
 			WithTimeout(requestTimeout).
 			WithProfileID(cfg.CSHProfileID).
 			WithRequest(&cshclientmodels.DocQuery{
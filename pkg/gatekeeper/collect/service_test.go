@@ -15,6 +15,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/trustbloc/ace/pkg/client/csh/client/operations"
+	vaultclient "github.com/trustbloc/ace/pkg/client/vault"
 	"github.com/trustbloc/ace/pkg/gatekeeper/collect"
 	"github.com/trustbloc/ace/pkg/gatekeeper/config"
 	"github.com/trustbloc/ace/pkg/gatekeeper/protect"
@@ -39,7 +40,7 @@ func TestCollect_Success(t *testing.T) {
 			Location: "http://csh-domin/profle/1/queries/query1234",
 		}, nil)
 
-	vaultClient.EXPECT().CreateAuthorization(
+	vaultClient.EXPECT().CreateAuthorization(gomock.Any(),
 		"did:orb:vault12345", "did:orb:csh123456#122344", gomock.Any()).Return(
 		&vault.CreatedAuthorization{
 			Tokens: &vault.Tokens{
@@ -50,7 +51,7 @@ func TestCollect_Success(t *testing.T) {
 		nil,
 	)
 
-	vaultClient.EXPECT().GetDocMetaData("did:orb:vault12345", "did:orb:vc12345").Return(
+	vaultClient.EXPECT().GetDocMetaData(gomock.Any(), "did:orb:vault12345", "did:orb:vc12345").Return(
 		&vault.DocumentMetadata{
 			ID:        "did:orb:vault12345",
 			URI:       "https://edv/vaultId/doc/docID",
@@ -102,7 +103,7 @@ func TestCollect_BadAuthorization(t *testing.T) {
 			CSHPubKeyURL: "did:orb:csh123456#122344",
 		}, nil)
 
-	vaultClient.EXPECT().CreateAuthorization(gomock.Any(), gomock.Any(), gomock.Any()).
+	vaultClient.EXPECT().CreateAuthorization(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
 		Return(nil, errors.New("create authorization failed"))
 
 	srv := collect.NewService(cfgService, vaultClient, cshService)
@@ -130,7 +131,7 @@ func TestCollect_PostAuthorizationFailed(t *testing.T) {
 	cshService.EXPECT().PostHubstoreProfilesProfileIDQueries(gomock.Any()).
 		Return(nil, errors.New("post authorization failed"))
 
-	vaultClient.EXPECT().CreateAuthorization(
+	vaultClient.EXPECT().CreateAuthorization(gomock.Any(),
 		"did:orb:vault12345", "did:orb:csh123456#122344", gomock.Any()).Return(
 		&vault.CreatedAuthorization{
 			Tokens: &vault.Tokens{
@@ -141,7 +142,7 @@ func TestCollect_PostAuthorizationFailed(t *testing.T) {
 		nil,
 	)
 
-	vaultClient.EXPECT().GetDocMetaData("did:orb:vault12345", "did:orb:vc12345").Return(
+	vaultClient.EXPECT().GetDocMetaData(gomock.Any(), "did:orb:vault12345", "did:orb:vc12345").Return(
 		&vault.DocumentMetadata{
 			ID:        "did:orb:vault12345",
 			URI:       "https://edv/vaultId/doc/docID",
@@ -159,3 +160,77 @@ func TestCollect_PostAuthorizationFailed(t *testing.T) {
 
 	require.Contains(t, err.Error(), "post authorization failed")
 }
+
+func TestCollect_Region(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cfgService := NewMockConfigService(ctrl)
+	cshService := NewMockCSHClient(ctrl)
+	vaultClient := NewMockVault(ctrl)
+	euVault := NewMockVault(ctrl)
+
+	srv := collect.NewService(cfgService, vaultClient, cshService,
+		collect.WithRegionVaults(map[string]vaultclient.Vault{"eu": &regionVault{MockVault: euVault}}))
+
+	t.Run("Collects from the vault of the region", func(t *testing.T) {
+		cfgService.EXPECT().Get().Return(&config.Config{CSHPubKeyURL: "did:orb:csh123456#122344"}, nil)
+
+		euVault.EXPECT().CreateAuthorization(gomock.Any(), "did:orb:eu", gomock.Any(), gomock.Any()).Return(
+			&vault.CreatedAuthorization{Tokens: &vault.Tokens{EDV: "edv-token", KMS: "kms-token"}}, nil)
+
+		euVault.EXPECT().GetDocMetaData(gomock.Any(), "did:orb:eu", "did:orb:vc12345").Return(
+			&vault.DocumentMetadata{
+				ID:        "did:orb:eu",
+				URI:       "https://edv.eu/vaultId/doc/docID",
+				EncKeyURI: "https://kms.eu/keystores/storeId/key/keyId",
+			}, nil)
+
+		cshService.EXPECT().PostHubstoreProfilesProfileIDQueries(gomock.Any()).Return(
+			&operations.PostHubstoreProfilesProfileIDQueriesCreated{
+				Location: "http://csh-domin/profle/1/queries/query1234",
+			}, nil)
+
+		auth, err := srv.Collect(context.Background(), &protect.ProtectedData{
+			DID:     "did:orb:eu",
+			VCDocID: "did:orb:vc12345",
+			Region:  "eu",
+		}, "did:orb:rp123456")
+
+		require.NoError(t, err)
+		require.Equal(t, "query1234", auth)
+	})
+
+	t.Run("Unknown region", func(t *testing.T) {
+		_, err := srv.Collect(context.Background(), &protect.ProtectedData{DID: "did:orb:us", Region: "us"},
+			"did:orb:rp123456")
+
+		require.True(t, errors.Is(err, protect.ErrUnknownRegion), err)
+	})
+}
+
+// regionVault is the vault client of a region, only creating authorizations and getting document metadata.
+type regionVault struct {
+	*MockVault
+}
+
+func (v *regionVault) CreateVault(context.Context) (*vault.CreatedVault, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (v *regionVault) DeleteVault(context.Context, string) error {
+	return errors.New("not implemented")
+}
+
+func (v *regionVault) SaveDoc(context.Context, string, string, interface{},
+	...string) (*vault.DocumentMetadata, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (v *regionVault) GetAuthorization(context.Context, string, string) (*vault.CreatedAuthorization, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (v *regionVault) QueryDocs(context.Context, string, string) ([]*vault.DocumentMetadata, error) {
+	return nil, errors.New("not implemented")
+}
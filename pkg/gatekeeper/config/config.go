@@ -5,7 +5,7 @@ SPDX-License-Identifier: Apache-2.0
 
 package config
 
-import "crypto/ed25519"
+import "time"
 
 // Config contains configuration of Gatekeeper identity and csh profile.
 type Config struct {
@@ -15,12 +15,45 @@ type Config struct {
 	// The gatekeeper's DID public key.
 	PubKeyID string `json:"pubKeyID"`
 
-	// The gatekeeper's DID private key.
-	PrivateKey ed25519.PrivateKey `json:"privateKey"`
+	// The gatekeeper's DID key type. Empty for configs created before key types were configurable (Ed25519).
+	KeyType string `json:"keyType,omitempty"`
+
+	// The gatekeeper's DID private key. Empty if the key is in the signing KMS.
+	PrivateKey []byte `json:"privateKey"`
+
+	// The ID in the gatekeeper's KMS of the key that signs the next update of the DID. Empty for configs created before
+	// the DID key could be rotated.
+	UpdateKeyID string `json:"updateKeyID,omitempty"`
+
+	// The DID public key replaced by the last rotation, kept in the DID document until PreviousKeyExpiry.
+	PreviousPubKeyID string `json:"previousPubKeyID,omitempty"`
+
+	// The end of the grace period of the previous DID public key.
+	PreviousKeyExpiry *time.Time `json:"previousKeyExpiry,omitempty"`
 
 	// The CSH public key's keyID in the format of a DID URL.
 	CSHPubKeyURL string `json:"cshPubKeyURL"`
 
 	// The CSH profile created by gatekeeper.
 	CSHProfileID string `json:"cshProfileID"`
+
+	// The update of the DID in progress, stored before the DID is updated. Nil once the update is resolved and the
+	// config updated with it.
+	PendingUpdate *PendingUpdate `json:"pendingUpdate,omitempty"`
+}
+
+// PendingUpdate is an update of the DID that may have been made without the config being updated with it: a rotation
+// of the DID key, or the retirement of the previous DID key.
+type PendingUpdate struct {
+	// The ID in the gatekeeper's KMS of the key that signs the update after the pending one.
+	NextUpdateKeyID string `json:"nextUpdateKeyID"`
+
+	// The new DID public key of a rotation. Empty for the retirement of the previous DID key.
+	PubKeyID string `json:"pubKeyID,omitempty"`
+
+	// The key type of the new DID public key.
+	KeyType string `json:"keyType,omitempty"`
+
+	// The end of the grace period of the DID public key replaced by a rotation.
+	PreviousKeyExpiry *time.Time `json:"previousKeyExpiry,omitempty"`
 }
@@ -0,0 +1,469 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package config
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/aries-framework-go-ext/component/vdr/orb"
+	"github.com/hyperledger/aries-framework-go-ext/component/vdr/sidetree/api"
+	ariescrypto "github.com/hyperledger/aries-framework-go/pkg/crypto"
+	docdid "github.com/hyperledger/aries-framework-go/pkg/doc/did"
+	"github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdr"
+	"github.com/trustbloc/sidetree-core-go/pkg/jws"
+	"github.com/trustbloc/sidetree-core-go/pkg/util/pubkey"
+
+	vccrypto "github.com/trustbloc/ace/pkg/doc/vc/crypto"
+)
+
+const (
+	updateRetries       = 10
+	updateRetryInterval = time.Second
+)
+
+// ErrKeyRotationInProgress is returned if the DID key is rotated again before the grace period of its previous key
+// has ended.
+var ErrKeyRotationInProgress = errors.New("the previous DID key is still in its grace period")
+
+// ErrKeyRotationNotSupported is returned if the DID key cannot be rotated: only keys in the signing KMS can be, and
+// only if the update key of the DID was recorded when the config was created.
+var ErrKeyRotationNotSupported = errors.New("the DID key cannot be rotated")
+
+// RotateKey replaces the gatekeeper's DID key with a new key in the signing KMS. The new key is added to the DID
+// document and used once the update of the DID is resolved, while the previous key keeps verifying until the grace
+// period ends and RetirePreviousKey removes it from the DID document. The rotation is stored as pending before the DID
+// is updated: a rotation whose DID update was made without the config being updated is completed on retry, and one
+// whose update was not made is retried with the same keys.
+func (s *Service) RotateKey(gracePeriod time.Duration) (*Config, error) {
+	s.rotationMu.Lock()
+	defer s.rotationMu.Unlock()
+
+	config, err := s.Get()
+	if err != nil {
+		return nil, fmt.Errorf("rotate key: %w", err)
+	}
+
+	rotated, err := s.reconcile(config)
+	if err != nil {
+		return nil, fmt.Errorf("rotate key: %w", err)
+	}
+
+	if rotated {
+		return config, nil
+	}
+
+	if err = s.checkRotation(config); err != nil {
+		return nil, fmt.Errorf("rotate key: %w", err)
+	}
+
+	if config.PreviousPubKeyID != "" {
+		if err = s.retirePreviousKey(config); err != nil {
+			return nil, fmt.Errorf("rotate key: %w", err)
+		}
+	}
+
+	if err = s.rotateKey(config, gracePeriod); err != nil {
+		return nil, fmt.Errorf("rotate key: %w", err)
+	}
+
+	return config, nil
+}
+
+func (s *Service) rotateKey(config *Config, gracePeriod time.Duration) error {
+	pending, publicKey, err := s.pendingRotation(config)
+	if err != nil {
+		return err
+	}
+
+	expiry := time.Now().Add(gracePeriod)
+	pending.PreviousKeyExpiry = &expiry
+
+	if err = s.put(config); err != nil {
+		return fmt.Errorf("store pending rotation: %w", err)
+	}
+
+	didDoc, err := s.resolveDoc(config.DID)
+	if err != nil {
+		return err
+	}
+
+	if err = addPublicKey(didDoc, pending.PubKeyID, publicKey); err != nil {
+		return err
+	}
+
+	if err = s.updateDID(didDoc, config.UpdateKeyID, pending.NextUpdateKeyID); err != nil {
+		return err
+	}
+
+	complete(config)
+
+	return s.put(config)
+}
+
+// pendingRotation returns the pending rotation of the config, with the public key of its new DID key, creating the
+// keys of a new one unless a rotation not yet made is pending.
+func (s *Service) pendingRotation(config *Config) (*PendingUpdate, crypto.PublicKey, error) {
+	if pending := config.PendingUpdate; pending != nil && pending.PubKeyID != "" {
+		publicKey, err := s.signingKey(pending.PubKeyID)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return pending, publicKey, nil
+	}
+
+	keyType := config.KeyType
+	if keyType == "" {
+		keyType = vccrypto.Ed25519KeyType
+	}
+
+	keyID, publicKey, err := s.createSigningKey(keyType)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	nextUpdateKeyID, _, err := s.newKey()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	config.PendingUpdate = &PendingUpdate{NextUpdateKeyID: nextUpdateKeyID, PubKeyID: keyID, KeyType: keyType}
+
+	return config.PendingUpdate, publicKey, nil
+}
+
+// reconcile completes the pending update of the config if the DID was updated with it, and reports whether it was a
+// rotation. A pending update that was not made is left to be retried.
+func (s *Service) reconcile(config *Config) (bool, error) {
+	pending := config.PendingUpdate
+	if pending == nil {
+		return false, nil
+	}
+
+	didDoc, err := s.resolveDoc(config.DID)
+	if err != nil {
+		return false, err
+	}
+
+	if pending.PubKeyID != "" && !hasPublicKey(didDoc, pending.PubKeyID) ||
+		pending.PubKeyID == "" && hasPublicKey(didDoc, config.PreviousPubKeyID) {
+		return false, nil
+	}
+
+	complete(config)
+
+	if err = s.put(config); err != nil {
+		return false, fmt.Errorf("store reconciled config: %w", err)
+	}
+
+	return pending.PubKeyID != "", nil
+}
+
+// complete updates the config with its pending update, once the DID is updated with it.
+func complete(config *Config) {
+	pending := config.PendingUpdate
+
+	if pending.PubKeyID == "" {
+		config.PreviousPubKeyID, config.PreviousKeyExpiry = "", nil
+	} else {
+		config.PreviousPubKeyID, config.PreviousKeyExpiry = config.PubKeyID, pending.PreviousKeyExpiry
+		config.PubKeyID, config.KeyType = pending.PubKeyID, pending.KeyType
+	}
+
+	config.UpdateKeyID, config.PendingUpdate = pending.NextUpdateKeyID, nil
+}
+
+// checkRotation checks that the DID key of the config can be rotated now.
+func (s *Service) checkRotation(config *Config) error {
+	if s.signingKMS == nil || len(config.PrivateKey) != 0 {
+		return fmt.Errorf("%w: the DID key is not in the signing KMS", ErrKeyRotationNotSupported)
+	}
+
+	if config.UpdateKeyID == "" {
+		return fmt.Errorf("%w: the update key of the DID is unknown", ErrKeyRotationNotSupported)
+	}
+
+	if config.PreviousPubKeyID != "" && time.Now().Before(*config.PreviousKeyExpiry) {
+		return ErrKeyRotationInProgress
+	}
+
+	return nil
+}
+
+// RetirePreviousKey removes the DID key replaced by the last rotation from the DID document once its grace period has
+// ended. It does nothing otherwise. It is idempotent across the instances sharing the config, which may retire the key
+// at the same time while they both hold the leader's lease: the DID accepts a single update with the update key, and
+// the instances whose update is refused find the key retired by the other one. Like RotateKey, it completes or retries
+// the pending update of the config first.
+func (s *Service) RetirePreviousKey() error {
+	s.rotationMu.Lock()
+	defer s.rotationMu.Unlock()
+
+	config, err := s.Get()
+	if err != nil {
+		return fmt.Errorf("retire previous key: %w", err)
+	}
+
+	if _, err = s.reconcile(config); err != nil {
+		return fmt.Errorf("retire previous key: %w", err)
+	}
+
+	if config.PreviousPubKeyID == "" || time.Now().Before(*config.PreviousKeyExpiry) {
+		return nil
+	}
+
+	if err = s.retirePreviousKey(config); err == nil {
+		return nil
+	}
+
+	// the update was refused if another instance retired the key with the same update key in the meantime
+	current, e := s.Get()
+	if e == nil && current.PreviousPubKeyID != config.PreviousPubKeyID {
+		return nil
+	}
+
+	return fmt.Errorf("retire previous key: %w", err)
+}
+
+func (s *Service) retirePreviousKey(config *Config) error {
+	if config.PendingUpdate == nil {
+		nextUpdateKeyID, _, err := s.newKey()
+		if err != nil {
+			return err
+		}
+
+		config.PendingUpdate = &PendingUpdate{NextUpdateKeyID: nextUpdateKeyID}
+
+		if err = s.put(config); err != nil {
+			return fmt.Errorf("store pending retirement: %w", err)
+		}
+	}
+
+	didDoc, err := s.resolveDoc(config.DID)
+	if err != nil {
+		return err
+	}
+
+	removePublicKey(didDoc, config.PreviousPubKeyID)
+
+	if err = s.updateDID(didDoc, config.UpdateKeyID, config.PendingUpdate.NextUpdateKeyID); err != nil {
+		return err
+	}
+
+	complete(config)
+
+	return s.put(config)
+}
+
+func (s *Service) resolveDoc(didID string) (*docdid.Doc, error) {
+	docResolution, err := s.vdr.Resolve(didID)
+	if err != nil {
+		return nil, fmt.Errorf("resolve DID %s: %w", didID, err)
+	}
+
+	return docResolution.DIDDocument, nil
+}
+
+// updateDID updates the DID document, signing the update with the update key and committing to the key that signs
+// the next update, and waits for the update to be resolved.
+func (s *Service) updateDID(didDoc *docdid.Doc, updateKeyID, nextUpdateKeyID string) error {
+	signer, err := s.newUpdateKeySigner(updateKeyID)
+	if err != nil {
+		return err
+	}
+
+	b, _, err := s.keyManager.ExportPubKeyBytes(nextUpdateKeyID)
+	if err != nil {
+		return fmt.Errorf("export next update key: %w", err)
+	}
+
+	nextUpdateKey := ed25519.PublicKey(b)
+
+	s.updateKeys.start(didDoc.ID, &didUpdate{signer: signer, nextUpdateKey: nextUpdateKey})
+	defer s.updateKeys.end(didDoc.ID)
+
+	retryInterval := updateRetryInterval
+
+	err = s.vdr.Update(didDoc, vdr.WithOption(orb.CheckDIDUpdated, &orb.ResolveDIDRetry{
+		MaxNumber: updateRetries,
+		SleepTime: &retryInterval,
+	}))
+	if err != nil {
+		return fmt.Errorf("update DID %s: %w", didDoc.ID, err)
+	}
+
+	return nil
+}
+
+func (s *Service) newUpdateKeySigner(keyID string) (*updateKeySigner, error) {
+	kh, err := s.keyManager.Get(keyID)
+	if err != nil {
+		return nil, fmt.Errorf("get update key: %w", err)
+	}
+
+	b, _, err := s.keyManager.ExportPubKeyBytes(keyID)
+	if err != nil {
+		return nil, fmt.Errorf("export update key: %w", err)
+	}
+
+	jwk, err := pubkey.GetPublicKeyJWK(ed25519.PublicKey(b))
+	if err != nil {
+		return nil, fmt.Errorf("update key to JWK: %w", err)
+	}
+
+	return &updateKeySigner{crypto: s.crypto, kh: kh, jwk: jwk}, nil
+}
+
+// hasPublicKey tells whether the public key is a verification method of the DID document.
+func hasPublicKey(didDoc *docdid.Doc, publicKeyID string) bool {
+	for i := range didDoc.VerificationMethod {
+		if isPublicKey(&didDoc.VerificationMethod[i], publicKeyID) {
+			return true
+		}
+	}
+
+	for _, verifications := range [][]docdid.Verification{didDoc.Authentication, didDoc.AssertionMethod} {
+		for i := range verifications {
+			if isPublicKey(&verifications[i].VerificationMethod, publicKeyID) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func isPublicKey(vm *docdid.VerificationMethod, publicKeyID string) bool {
+	return vm.ID == publicKeyID || strings.HasSuffix(vm.ID, "#"+publicKeyID)
+}
+
+// removePublicKey removes the public key from the verification methods of the DID document.
+func removePublicKey(didDoc *docdid.Doc, publicKeyID string) {
+	keep := func(verifications []docdid.Verification) []docdid.Verification {
+		var kept []docdid.Verification
+
+		for i := range verifications {
+			if !isPublicKey(&verifications[i].VerificationMethod, publicKeyID) {
+				kept = append(kept, verifications[i])
+			}
+		}
+
+		return kept
+	}
+
+	didDoc.Authentication = keep(didDoc.Authentication)
+	didDoc.AssertionMethod = keep(didDoc.AssertionMethod)
+	didDoc.CapabilityDelegation = keep(didDoc.CapabilityDelegation)
+	didDoc.CapabilityInvocation = keep(didDoc.CapabilityInvocation)
+	didDoc.KeyAgreement = keep(didDoc.KeyAgreement)
+
+	var vms []docdid.VerificationMethod
+
+	for i := range didDoc.VerificationMethod {
+		if !isPublicKey(&didDoc.VerificationMethod[i], publicKeyID) {
+			vms = append(vms, didDoc.VerificationMethod[i])
+		}
+	}
+
+	didDoc.VerificationMethod = vms
+}
+
+// updateKeySigner signs DID updates with an Ed25519 update key in the KMS.
+type updateKeySigner struct {
+	crypto ariescrypto.Crypto
+	kh     interface{}
+	jwk    *jws.JWK
+}
+
+func (s *updateKeySigner) Sign(data []byte) ([]byte, error) {
+	return s.crypto.Sign(data, s.kh)
+}
+
+func (s *updateKeySigner) Headers() jws.Headers {
+	return jws.Headers{jws.HeaderAlgorithm: "EdDSA"}
+}
+
+func (s *updateKeySigner) PublicKeyJWK() *jws.JWK {
+	return s.jwk
+}
+
+type didUpdate struct {
+	signer        api.Signer
+	nextUpdateKey crypto.PublicKey
+}
+
+// UpdateKeys implements the KeyRetriever of the orb VDR, which retrieves the keys of a DID update from it while the
+// update is built. It only holds keys while the Service updates the gatekeeper's DID.
+type UpdateKeys struct {
+	mu      sync.Mutex
+	updates map[string]*didUpdate
+}
+
+// NewUpdateKeys returns a new instance of UpdateKeys.
+func NewUpdateKeys() *UpdateKeys {
+	return &UpdateKeys{updates: map[string]*didUpdate{}}
+}
+
+// GetNextRecoveryPublicKey is not supported: the gatekeeper does not recover its DID.
+func (k *UpdateKeys) GetNextRecoveryPublicKey(didID, _ string) (crypto.PublicKey, error) {
+	return nil, fmt.Errorf("recover DID %s: not supported", didID)
+}
+
+// GetNextUpdatePublicKey returns the public key that will sign the update after the one in progress.
+func (k *UpdateKeys) GetNextUpdatePublicKey(didID, _ string) (crypto.PublicKey, error) {
+	u, err := k.get(didID)
+	if err != nil {
+		return nil, err
+	}
+
+	return u.nextUpdateKey, nil
+}
+
+// GetSigner returns the signer of the update in progress.
+func (k *UpdateKeys) GetSigner(didID string, ot orb.OperationType, _ string) (api.Signer, error) {
+	if ot != orb.Update {
+		return nil, fmt.Errorf("operation %d on DID %s: not supported", ot, didID)
+	}
+
+	u, err := k.get(didID)
+	if err != nil {
+		return nil, err
+	}
+
+	return u.signer, nil
+}
+
+func (k *UpdateKeys) get(didID string) (*didUpdate, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	u, ok := k.updates[didID]
+	if !ok {
+		return nil, fmt.Errorf("no update of DID %s in progress", didID)
+	}
+
+	return u, nil
+}
+
+func (k *UpdateKeys) start(didID string, u *didUpdate) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.updates[didID] = u
+}
+
+func (k *UpdateKeys) end(didID string) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	delete(k.updates, didID)
+}
@@ -0,0 +1,406 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package config_test
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/aries-framework-go-ext/component/vdr/orb"
+	"github.com/hyperledger/aries-framework-go/component/storageutil/mem"
+	docdid "github.com/hyperledger/aries-framework-go/pkg/doc/did"
+	"github.com/hyperledger/aries-framework-go/pkg/framework/aries"
+	"github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdr"
+	"github.com/hyperledger/aries-framework-go/pkg/framework/context"
+	"github.com/hyperledger/aries-framework-go/pkg/kms"
+	"github.com/stretchr/testify/require"
+	"github.com/trustbloc/sidetree-core-go/pkg/jws"
+
+	vccrypto "github.com/trustbloc/ace/pkg/doc/vc/crypto"
+	"github.com/trustbloc/ace/pkg/gatekeeper/config"
+)
+
+const gatekeeperDID = "did:orb:gatekeeper"
+
+func TestService_RotateKey(t *testing.T) {
+	ctx := newAriesContext(t)
+
+	t.Run("Success", func(t *testing.T) {
+		s, v, cfg := newRotationService(t, ctx, nil)
+
+		rotated, err := s.RotateKey(time.Hour)
+		require.NoError(t, err)
+		require.NotEqual(t, cfg.PubKeyID, rotated.PubKeyID)
+		require.Equal(t, cfg.PubKeyID, rotated.PreviousPubKeyID)
+		require.NotEqual(t, cfg.UpdateKeyID, rotated.UpdateKeyID)
+		require.True(t, rotated.PreviousKeyExpiry.After(time.Now()))
+		require.Equal(t, vccrypto.Ed25519KeyType, rotated.KeyType)
+
+		// both keys verify during the grace period
+		require.Equal(t, 1, v.updates)
+		require.True(t, v.hasKey(cfg.PubKeyID))
+		require.True(t, v.hasKey(rotated.PubKeyID))
+
+		stored, err := s.Get()
+		require.NoError(t, err)
+		require.Equal(t, rotated.PubKeyID, stored.PubKeyID)
+		require.Equal(t, rotated.PreviousPubKeyID, stored.PreviousPubKeyID)
+		require.Equal(t, rotated.UpdateKeyID, stored.UpdateKeyID)
+		require.True(t, rotated.PreviousKeyExpiry.Equal(*stored.PreviousKeyExpiry))
+
+		_, err = s.RotateKey(time.Hour)
+		require.True(t, errors.Is(err, config.ErrKeyRotationInProgress))
+
+		// the previous key is kept until its grace period ends
+		require.NoError(t, s.RetirePreviousKey())
+		require.Equal(t, 1, v.updates)
+	})
+
+	t.Run("Retire previous key", func(t *testing.T) {
+		s, v, cfg := newRotationService(t, ctx, nil)
+
+		rotated, err := s.RotateKey(0)
+		require.NoError(t, err)
+
+		require.NoError(t, s.RetirePreviousKey())
+		require.Equal(t, 2, v.updates)
+		require.False(t, v.hasKey(cfg.PubKeyID))
+		require.True(t, v.hasKey(rotated.PubKeyID))
+
+		stored, err := s.Get()
+		require.NoError(t, err)
+		require.Empty(t, stored.PreviousPubKeyID)
+		require.Nil(t, stored.PreviousKeyExpiry)
+		require.NotEqual(t, rotated.UpdateKeyID, stored.UpdateKeyID)
+
+		require.NoError(t, s.RetirePreviousKey())
+		require.Equal(t, 2, v.updates)
+	})
+
+	t.Run("Retire previous key on another instance at the same time", func(t *testing.T) {
+		s, v, cfg := newRotationService(t, ctx, nil)
+
+		_, err := s.RotateKey(0)
+		require.NoError(t, err)
+
+		other, err := config.NewService(v.params)
+		require.NoError(t, err)
+
+		// the other instance retires the key once this one has read the config
+		v.onResolve = func() {
+			require.NoError(t, other.RetirePreviousKey())
+		}
+
+		require.NoError(t, s.RetirePreviousKey())
+		require.Equal(t, 2, v.updates)
+		require.False(t, v.hasKey(cfg.PubKeyID))
+
+		stored, err := s.Get()
+		require.NoError(t, err)
+		require.Empty(t, stored.PreviousPubKeyID)
+	})
+
+	t.Run("Rotate again after the grace period", func(t *testing.T) {
+		s, v, cfg := newRotationService(t, ctx, nil)
+
+		rotated, err := s.RotateKey(0)
+		require.NoError(t, err)
+
+		rotated, err = s.RotateKey(time.Hour)
+		require.NoError(t, err)
+
+		// the previous key is retired first
+		require.Equal(t, 3, v.updates)
+		require.False(t, v.hasKey(cfg.PubKeyID))
+		require.True(t, v.hasKey(rotated.PreviousPubKeyID))
+		require.True(t, v.hasKey(rotated.PubKeyID))
+	})
+
+	t.Run("Key not in the signing KMS", func(t *testing.T) {
+		s, _, _ := newRotationService(t, ctx, func(c *config.Config) {
+			c.PrivateKey = []byte("private key")
+		})
+
+		_, err := s.RotateKey(time.Hour)
+		require.True(t, errors.Is(err, config.ErrKeyRotationNotSupported))
+		require.Contains(t, err.Error(), "the DID key is not in the signing KMS")
+	})
+
+	t.Run("Update key of the DID unknown", func(t *testing.T) {
+		s, _, _ := newRotationService(t, ctx, func(c *config.Config) {
+			c.UpdateKeyID = ""
+		})
+
+		_, err := s.RotateKey(time.Hour)
+		require.True(t, errors.Is(err, config.ErrKeyRotationNotSupported))
+		require.Contains(t, err.Error(), "the update key of the DID is unknown")
+	})
+
+	t.Run("Update DID failed", func(t *testing.T) {
+		s, v, cfg := newRotationService(t, ctx, nil)
+
+		v.updateErr = errors.New("anchor failed")
+
+		_, err := s.RotateKey(time.Hour)
+		require.EqualError(t, err, "rotate key: update DID did:orb:gatekeeper: anchor failed")
+
+		stored, err := s.Get()
+		require.NoError(t, err)
+		require.Equal(t, cfg.PubKeyID, stored.PubKeyID)
+		require.Equal(t, cfg.UpdateKeyID, stored.UpdateKeyID)
+		require.NotNil(t, stored.PendingUpdate)
+
+		// the rotation is retried with the same keys
+		v.updateErr = nil
+
+		rotated, err := s.RotateKey(time.Hour)
+		require.NoError(t, err)
+		require.Equal(t, stored.PendingUpdate.PubKeyID, rotated.PubKeyID)
+		require.Equal(t, stored.PendingUpdate.NextUpdateKeyID, rotated.UpdateKeyID)
+		require.Nil(t, rotated.PendingUpdate)
+		require.Equal(t, 1, v.updates)
+	})
+
+	t.Run("DID updated without the config", func(t *testing.T) {
+		s, v, cfg := newRotationService(t, ctx, nil)
+
+		v.unresolvedErr = errors.New("update not resolved")
+
+		_, err := s.RotateKey(0)
+		require.EqualError(t, err, "rotate key: update DID did:orb:gatekeeper: update not resolved")
+		require.Equal(t, 1, v.updates)
+
+		v.unresolvedErr = nil
+
+		// the rotation is completed without updating the DID again
+		rotated, err := s.RotateKey(time.Hour)
+		require.NoError(t, err)
+		require.Equal(t, 1, v.updates)
+		require.Equal(t, cfg.PubKeyID, rotated.PreviousPubKeyID)
+		require.True(t, v.hasKey(rotated.PubKeyID))
+
+		stored, err := s.Get()
+		require.NoError(t, err)
+		require.Equal(t, rotated, stored)
+
+		v.unresolvedErr = errors.New("update not resolved")
+
+		require.Error(t, s.RetirePreviousKey())
+		require.Equal(t, 2, v.updates)
+
+		v.unresolvedErr = nil
+
+		// the retirement is completed, and the next update signed with the update key it committed to
+		require.NoError(t, s.RetirePreviousKey())
+		require.Equal(t, 2, v.updates)
+
+		stored, err = s.Get()
+		require.NoError(t, err)
+		require.Empty(t, stored.PreviousPubKeyID)
+		require.Nil(t, stored.PendingUpdate)
+
+		_, err = s.RotateKey(time.Hour)
+		require.NoError(t, err)
+		require.Equal(t, 3, v.updates)
+	})
+
+	t.Run("Resolve DID failed", func(t *testing.T) {
+		s, v, _ := newRotationService(t, ctx, nil)
+
+		v.resolveErr = errors.New("not found")
+
+		_, err := s.RotateKey(time.Hour)
+		require.EqualError(t, err, "rotate key: resolve DID did:orb:gatekeeper: not found")
+	})
+
+	t.Run("No config", func(t *testing.T) {
+		s, err := config.NewService(&config.ServiceParams{StoreProvider: mem.NewProvider()})
+		require.NoError(t, err)
+
+		_, err = s.RotateKey(time.Hour)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "rotate key: get config")
+
+		err = s.RetirePreviousKey()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "retire previous key: get config")
+	})
+}
+
+func TestUpdateKeys(t *testing.T) {
+	k := config.NewUpdateKeys()
+
+	_, err := k.GetNextRecoveryPublicKey(gatekeeperDID, "")
+	require.EqualError(t, err, "recover DID did:orb:gatekeeper: not supported")
+
+	_, err = k.GetSigner(gatekeeperDID, orb.Recover, "")
+	require.Error(t, err)
+
+	_, err = k.GetSigner(gatekeeperDID, orb.Update, "")
+	require.EqualError(t, err, "no update of DID did:orb:gatekeeper in progress")
+
+	_, err = k.GetNextUpdatePublicKey(gatekeeperDID, "")
+	require.EqualError(t, err, "no update of DID did:orb:gatekeeper in progress")
+}
+
+func newAriesContext(t *testing.T) *context.Provider {
+	t.Helper()
+
+	a, err := aries.New(aries.WithStoreProvider(mem.NewProvider()))
+	require.NoError(t, err)
+
+	ctx, err := a.Context()
+	require.NoError(t, err)
+
+	return ctx
+}
+
+// newRotationService returns a Service whose config and DID have a key in the signing KMS and an update key. The
+// config is changed by update, if not nil, before it is stored.
+func newRotationService(t *testing.T, ctx *context.Provider, update func(*config.Config)) (*config.Service, *fakeVDR,
+	*config.Config) {
+	t.Helper()
+
+	updateKeys := config.NewUpdateKeys()
+
+	keyID, pubKey, err := ctx.KMS().CreateAndExportPubKeyBytes(kms.ED25519Type)
+	require.NoError(t, err)
+
+	updateKeyID, updateKey, err := ctx.KMS().CreateAndExportPubKeyBytes(kms.ED25519Type)
+	require.NoError(t, err)
+
+	vm := docdid.NewVerificationMethodFromBytes(gatekeeperDID+"#"+keyID, vccrypto.Ed25519VerificationKey2018,
+		gatekeeperDID, pubKey)
+
+	v := &fakeVDR{
+		t:          t,
+		updateKeys: updateKeys,
+		updateKey:  ed25519.PublicKey(updateKey),
+		doc: &docdid.Doc{
+			ID:                 gatekeeperDID,
+			VerificationMethod: []docdid.VerificationMethod{*vm},
+			Authentication:     []docdid.Verification{*docdid.NewReferencedVerification(vm, docdid.Authentication)},
+			AssertionMethod:    []docdid.Verification{*docdid.NewReferencedVerification(vm, docdid.AssertionMethod)},
+		},
+	}
+
+	cfg := &config.Config{DID: gatekeeperDID, PubKeyID: keyID, UpdateKeyID: updateKeyID}
+
+	if update != nil {
+		update(cfg)
+	}
+
+	storeProvider := mem.NewProvider()
+
+	store, err := storeProvider.OpenStore("config")
+	require.NoError(t, err)
+
+	b, err := json.Marshal(cfg)
+	require.NoError(t, err)
+
+	require.NoError(t, store.Put("config", b))
+
+	v.params = &config.ServiceParams{
+		StoreProvider:     storeProvider,
+		VDR:               v,
+		KeyManager:        ctx.KMS(),
+		SigningKeyManager: ctx.KMS(),
+		Crypto:            ctx.Crypto(),
+		UpdateKeys:        updateKeys,
+	}
+
+	s, err := config.NewService(v.params)
+	require.NoError(t, err)
+
+	return s, v, cfg
+}
+
+// fakeVDR updates the DID document like the orb VDR, checking that each update is signed with the update key whose
+// commitment was made by the previous update. It holds the params of the service it was created for, to create other
+// instances sharing its config.
+type fakeVDR struct {
+	t          *testing.T
+	params     *config.ServiceParams
+	updateKeys *config.UpdateKeys
+	updateKey  crypto.PublicKey
+	doc        *docdid.Doc
+	updates    int
+	resolveErr error
+	updateErr  error
+	// unresolvedErr is returned once the DID is updated, like an update not resolved in time
+	unresolvedErr error
+	onResolve     func()
+}
+
+func (v *fakeVDR) Resolve(string, ...vdr.DIDMethodOption) (*docdid.DocResolution, error) {
+	if v.resolveErr != nil {
+		return nil, v.resolveErr
+	}
+
+	if onResolve := v.onResolve; onResolve != nil {
+		v.onResolve = nil
+
+		onResolve()
+	}
+
+	// updates made to the resolved document are not made to the DID
+	doc := *v.doc
+	doc.VerificationMethod = append([]docdid.VerificationMethod(nil), v.doc.VerificationMethod...)
+	doc.Authentication = append([]docdid.Verification(nil), v.doc.Authentication...)
+	doc.AssertionMethod = append([]docdid.Verification(nil), v.doc.AssertionMethod...)
+
+	return &docdid.DocResolution{DIDDocument: &doc}, nil
+}
+
+func (v *fakeVDR) Create(string, *docdid.Doc, ...vdr.DIDMethodOption) (*docdid.DocResolution, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (v *fakeVDR) Update(doc *docdid.Doc, _ ...vdr.DIDMethodOption) error {
+	if v.updateErr != nil {
+		return v.updateErr
+	}
+
+	signer, err := v.updateKeys.GetSigner(doc.ID, orb.Update, "")
+	require.NoError(v.t, err)
+
+	x, err := base64.RawURLEncoding.DecodeString(signer.PublicKeyJWK().X)
+	require.NoError(v.t, err)
+
+	if !ed25519.PublicKey(x).Equal(v.updateKey) {
+		return errors.New("update not signed with the update key")
+	}
+
+	require.Equal(v.t, "EdDSA", signer.Headers()[jws.HeaderAlgorithm])
+
+	sig, err := signer.Sign([]byte("update"))
+	require.NoError(v.t, err)
+	require.True(v.t, ed25519.Verify(x, []byte("update"), sig))
+
+	v.updateKey, err = v.updateKeys.GetNextUpdatePublicKey(doc.ID, "")
+	require.NoError(v.t, err)
+
+	v.doc = doc
+	v.updates++
+
+	return v.unresolvedErr
+}
+
+func (v *fakeVDR) hasKey(keyID string) bool {
+	for _, vm := range v.doc.AssertionMethod {
+		if strings.HasSuffix(vm.VerificationMethod.ID, keyID) {
+			return true
+		}
+	}
+
+	return false
+}
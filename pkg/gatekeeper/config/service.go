@@ -10,16 +10,22 @@ package config
 
 import (
 	"crypto"
+	"crypto/ecdsa"
 	"crypto/ed25519"
+	"crypto/elliptic"
 	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/hyperledger/aries-framework-go-ext/component/vdr/orb"
+	ariescrypto "github.com/hyperledger/aries-framework-go/pkg/crypto"
+	"github.com/hyperledger/aries-framework-go/pkg/crypto/primitive/bbs12381g2pub"
 	docdid "github.com/hyperledger/aries-framework-go/pkg/doc/did"
 	"github.com/hyperledger/aries-framework-go/pkg/doc/jose/jwk/jwksupport"
 	"github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdr"
@@ -46,6 +52,7 @@ type cshClient interface {
 type vdrRegistry interface {
 	Resolve(DID string, opts ...vdr.DIDMethodOption) (*docdid.DocResolution, error)
 	Create(method string, DID *docdid.Doc, opts ...vdr.DIDMethodOption) (*docdid.DocResolution, error)
+	Update(DID *docdid.Doc, opts ...vdr.DIDMethodOption) error
 }
 
 // ServiceParams contains parameters of config Service.
@@ -56,6 +63,15 @@ type ServiceParams struct {
 	KeyManager      kms.KeyManager
 	DidMethod       string
 	DidAnchorOrigin string
+	// Type of the gatekeeper's DID key. Defaults to Ed25519 if not set.
+	KeyType string
+	// SigningKeyManager creates the gatekeeper's DID key, whose private key is then never exported. If not set, the
+	// key is generated in process and its private key is stored in the config for the VC provider.
+	SigningKeyManager kms.KeyManager
+	// Crypto signs the updates of the gatekeeper's DID with its update keys, which are in KeyManager.
+	Crypto ariescrypto.Crypto
+	// UpdateKeys hands the update keys to the orb VDR when the DID is updated. The VDR must be created with it.
+	UpdateKeys *UpdateKeys
 }
 
 // Service responsible for creating and storing gatekeeper config.
@@ -66,6 +82,12 @@ type Service struct {
 	keyManager      kms.KeyManager
 	didMethod       string
 	didAnchorOrigin string
+	keyType         string
+	signingKMS      kms.KeyManager
+	crypto          ariescrypto.Crypto
+	updateKeys      *UpdateKeys
+	// rotationMu serializes the updates of the DID
+	rotationMu sync.Mutex
 }
 
 // NewService returns a new instance of Service.
@@ -75,6 +97,16 @@ func NewService(params *ServiceParams) (*Service, error) {
 		return nil, fmt.Errorf("open policy store: %w", err)
 	}
 
+	keyType := params.KeyType
+	if keyType == "" {
+		keyType = vccrypto.Ed25519KeyType
+	}
+
+	updateKeys := params.UpdateKeys
+	if updateKeys == nil {
+		updateKeys = NewUpdateKeys()
+	}
+
 	return &Service{
 		store:           store,
 		cshClient:       params.CSHClient,
@@ -82,6 +114,10 @@ func NewService(params *ServiceParams) (*Service, error) {
 		keyManager:      params.KeyManager,
 		didMethod:       params.DidMethod,
 		didAnchorOrigin: params.DidAnchorOrigin,
+		keyType:         keyType,
+		signingKMS:      params.SigningKeyManager,
+		crypto:          params.Crypto,
+		updateKeys:      updateKeys,
 	}, nil
 }
 
@@ -118,17 +154,17 @@ func (s *Service) Get() (*Config, error) {
 // CreateConfig creates gatekeeper DID, CSH profile, and store them in Config.
 func (s *Service) CreateConfig() error { //nolint: funlen
 	// create did
-	didDoc, pubKeyID, privateKey, err := s.newPublicKeys()
+	didDoc, pubKeyID, privateKey, err := s.newPublicKeys(s.keyType)
 	if err != nil {
 		return fmt.Errorf("failed to create public keys : %w", err)
 	}
 
-	recoverKey, err := s.newKey()
+	_, recoverKey, err := s.newKey()
 	if err != nil {
 		return fmt.Errorf("failed to create recover key : %w", err)
 	}
 
-	updateKey, err := s.newKey()
+	updateKeyID, updateKey, err := s.newKey()
 	if err != nil {
 		return fmt.Errorf("failed to update recover key : %w", err)
 	}
@@ -172,53 +208,173 @@ func (s *Service) CreateConfig() error { //nolint: funlen
 	config := &Config{
 		DID:          didID,
 		PubKeyID:     pubKeyID,
+		KeyType:      s.keyType,
 		PrivateKey:   privateKey,
+		UpdateKeyID:  updateKeyID,
 		CSHPubKeyURL: cshPubKeyURL,
 		CSHProfileID: cshProfile.Payload.ID,
 	}
 
+	// store config
+	return s.put(config)
+}
+
+func (s *Service) put(config *Config) error {
 	configBytes, err := json.Marshal(config)
 	if err != nil {
 		return err
 	}
 
-	// store config
 	return s.store.Put(configKeyDB, configBytes)
 }
 
-func (s *Service) newPublicKeys() (*docdid.Doc, string, ed25519.PrivateKey, error) {
+func (s *Service) newPublicKeys(keyType string) (*docdid.Doc, string, []byte, error) {
 	didDoc := &docdid.Doc{}
 
-	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	var (
+		publicKey   crypto.PublicKey
+		publicKeyID string
+		privateKey  []byte
+		err         error
+	)
+
+	if s.signingKMS != nil {
+		publicKeyID, publicKey, err = s.createSigningKey(keyType)
+	} else {
+		publicKeyID = uuid.New().String()
+		publicKey, privateKey, err = generateKey(keyType)
+	}
+
 	if err != nil {
 		return nil, "", nil, err
 	}
 
-	publicKeyID := uuid.New().String()
+	if err = addPublicKey(didDoc, publicKeyID, publicKey); err != nil {
+		return nil, "", nil, err
+	}
+
+	return didDoc, publicKeyID, privateKey, nil
+}
 
+// addPublicKey adds the public key to the authentication and assertion methods of the DID document.
+func addPublicKey(didDoc *docdid.Doc, publicKeyID string, publicKey crypto.PublicKey) error {
 	jwk, err := jwksupport.JWKFromKey(publicKey)
 	if err != nil {
-		return nil, "", nil, err
+		return err
 	}
 
 	vm, err := docdid.NewVerificationMethodFromJWK(publicKeyID, vccrypto.JSONWebKey2020, "", jwk)
 	if err != nil {
-		return nil, "", nil, err
+		return err
 	}
 
 	didDoc.Authentication = append(didDoc.Authentication, *docdid.NewReferencedVerification(vm, docdid.Authentication))
 	didDoc.AssertionMethod = append(didDoc.AssertionMethod, *docdid.NewReferencedVerification(vm, docdid.AssertionMethod))
 
-	return didDoc, publicKeyID, privateKey, nil
+	return nil
+}
+
+// generateKey generates a key pair of the given type and returns the public key along with the serialized private key.
+func generateKey(keyType string) (crypto.PublicKey, []byte, error) {
+	switch keyType {
+	case vccrypto.Ed25519KeyType:
+		publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return publicKey, privateKey, nil
+	case vccrypto.P256KeyType:
+		privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		der, err := x509.MarshalECPrivateKey(privateKey)
+		if err != nil {
+			return nil, nil, fmt.Errorf("marshal P-256 private key: %w", err)
+		}
+
+		return &privateKey.PublicKey, der, nil
+	case vccrypto.BLS12381G2KeyType:
+		publicKey, privateKey, err := bbs12381g2pub.GenerateKeyPair(sha256.New, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		b, err := privateKey.Marshal()
+		if err != nil {
+			return nil, nil, fmt.Errorf("marshal BLS12-381 G2 private key: %w", err)
+		}
+
+		return publicKey, b, nil
+	default:
+		return nil, nil, fmt.Errorf("unsupported key type: %s", keyType)
+	}
+}
+
+// createSigningKey creates the DID key in the signing KMS and returns its ID and public key.
+func (s *Service) createSigningKey(keyType string) (string, crypto.PublicKey, error) {
+	kt, ok := map[string]kms.KeyType{
+		vccrypto.Ed25519KeyType:    kms.ED25519Type,
+		vccrypto.P256KeyType:       kms.ECDSAP256TypeIEEEP1363,
+		vccrypto.BLS12381G2KeyType: kms.BLS12381G2Type,
+	}[keyType]
+	if !ok {
+		return "", nil, fmt.Errorf("unsupported key type: %s", keyType)
+	}
+
+	keyID, b, err := s.signingKMS.CreateAndExportPubKeyBytes(kt)
+	if err != nil {
+		return "", nil, fmt.Errorf("create signing key: %w", err)
+	}
+
+	publicKey, err := signingPublicKey(kt, b)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return keyID, publicKey, nil
+}
+
+// signingKey returns the public key of the DID key in the signing KMS.
+func (s *Service) signingKey(keyID string) (crypto.PublicKey, error) {
+	b, kt, err := s.signingKMS.ExportPubKeyBytes(keyID)
+	if err != nil {
+		return nil, fmt.Errorf("export signing key: %w", err)
+	}
+
+	return signingPublicKey(kt, b)
+}
+
+func signingPublicKey(kt kms.KeyType, b []byte) (crypto.PublicKey, error) {
+	switch kt { //nolint:exhaustive
+	case kms.ED25519Type:
+		return ed25519.PublicKey(b), nil
+	case kms.ECDSAP256TypeIEEEP1363:
+		x, y := elliptic.Unmarshal(elliptic.P256(), b)
+		if x == nil {
+			return nil, errors.New("invalid P-256 signing key")
+		}
+
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}, nil
+	default:
+		publicKey, err := bbs12381g2pub.UnmarshalPublicKey(b)
+		if err != nil {
+			return nil, fmt.Errorf("unmarshal BLS12-381 G2 signing key: %w", err)
+		}
+
+		return publicKey, nil
+	}
 }
 
-func (s *Service) newKey() (crypto.PublicKey, error) {
-	_, bits, err := s.keyManager.CreateAndExportPubKeyBytes(kms.ED25519Type)
+func (s *Service) newKey() (string, crypto.PublicKey, error) {
+	keyID, bits, err := s.keyManager.CreateAndExportPubKeyBytes(kms.ED25519Type)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create key : %w", err)
+		return "", nil, fmt.Errorf("failed to create key : %w", err)
 	}
 
-	return ed25519.PublicKey(bits), nil
+	return keyID, ed25519.PublicKey(bits), nil
 }
 
 func resolveDID(vdrRegistry vdrRegistry, resolveDID string, maxRetry int) error {
@@ -228,7 +384,7 @@ func resolveDID(vdrRegistry vdrRegistry, resolveDID string, maxRetry int) error
 			return nil
 		}
 
-		if !strings.Contains(err.Error(), "DID does not exist") {
+		if !errors.Is(err, vdr.ErrNotFound) {
 			return err
 		}
 
@@ -6,12 +6,20 @@ SPDX-License-Identifier: Apache-2.0
 package config_test
 
 import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
 	"errors"
+	"fmt"
 	"testing"
 
 	"github.com/golang/mock/gomock"
+	"github.com/hyperledger/aries-framework-go/pkg/crypto/primitive/bbs12381g2pub"
 	docdid "github.com/hyperledger/aries-framework-go/pkg/doc/did"
 	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
+	vdrapi "github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdr"
 	"github.com/hyperledger/aries-framework-go/pkg/mock/kms"
 	"github.com/hyperledger/aries-framework-go/pkg/mock/storage"
 	"github.com/stretchr/testify/require"
@@ -19,6 +27,7 @@ import (
 
 	"github.com/trustbloc/ace/pkg/client/csh/client/operations"
 	"github.com/trustbloc/ace/pkg/client/csh/models"
+	vccrypto "github.com/trustbloc/ace/pkg/doc/vc/crypto"
 	"github.com/trustbloc/ace/pkg/gatekeeper/config"
 )
 
@@ -280,7 +289,7 @@ func TestService_CreateConfig(t *testing.T) {
 			}, nil)
 
 		vdr.EXPECT().Resolve("did:orb:test123456").
-			Return(nil, errors.New("DID does not exist")).Times(10)
+			Return(nil, fmt.Errorf("resolve: %w", vdrapi.ErrNotFound)).Times(10)
 
 		kmsService := &kms.KeyManager{}
 
@@ -333,6 +342,174 @@ func TestService_CreateConfig(t *testing.T) {
 		require.Contains(t, err.Error(), "create did failed")
 	})
 
+	t.Run("Success with configured key type", func(t *testing.T) {
+		for _, keyType := range []string{vccrypto.P256KeyType, vccrypto.BLS12381G2KeyType} {
+			ctrl := gomock.NewController(t)
+
+			storeProvider := storage.NewMockStoreProvider()
+
+			csh := NewMockCSHClient(ctrl)
+
+			vdr := NewMockVDRRegistry(ctrl)
+
+			vdr.EXPECT().Create(gomock.Any(), gomock.Any(), gomock.Any()).Return(
+				&docdid.DocResolution{
+					DIDDocument: &docdid.Doc{
+						ID: "did:orb:test123456",
+					},
+				}, nil)
+
+			vdr.EXPECT().Resolve("did:orb:test123456").Return(nil, nil)
+
+			compZCAP, err := zcapld.CompressZCAP(&zcapld.Capability{
+				Proof: []verifiable.Proof{
+					map[string]interface{}{
+						"verificationMethod": "did:orb:test12345#key1234",
+					},
+				},
+			})
+			require.NoError(t, err)
+
+			csh.EXPECT().PostHubstoreProfiles(gomock.Any()).Return(
+				&operations.PostHubstoreProfilesCreated{
+					Payload: &models.Profile{
+						Zcap: compZCAP,
+					},
+				}, nil)
+
+			cfgService, err := config.NewService(&config.ServiceParams{
+				StoreProvider:   storeProvider,
+				CSHClient:       csh,
+				VDR:             vdr,
+				KeyManager:      &kms.KeyManager{},
+				DidMethod:       "test",
+				DidAnchorOrigin: "test",
+				KeyType:         keyType,
+			})
+			require.NoError(t, err)
+
+			require.NoError(t, cfgService.CreateConfig())
+
+			conf, err := cfgService.Get()
+			require.NoError(t, err)
+			require.Equal(t, keyType, conf.KeyType)
+			require.NotEmpty(t, conf.PrivateKey)
+
+			ctrl.Finish()
+		}
+	})
+
+	t.Run("Success with signing KMS", func(t *testing.T) {
+		_, ed25519Key, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+
+		p256Key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+
+		blsKey, _, err := bbs12381g2pub.GenerateKeyPair(sha256.New, nil)
+		require.NoError(t, err)
+
+		blsKeyBytes, err := blsKey.Marshal()
+		require.NoError(t, err)
+
+		for keyType, pubKey := range map[string][]byte{
+			vccrypto.Ed25519KeyType:    ed25519Key.Public().(ed25519.PublicKey), //nolint:forcetypeassert
+			vccrypto.P256KeyType:       elliptic.Marshal(elliptic.P256(), p256Key.X, p256Key.Y),
+			vccrypto.BLS12381G2KeyType: blsKeyBytes,
+		} {
+			ctrl := gomock.NewController(t)
+
+			csh := NewMockCSHClient(ctrl)
+			vdr := NewMockVDRRegistry(ctrl)
+
+			vdr.EXPECT().Create(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ string, doc *docdid.Doc, _ ...interface{}) (*docdid.DocResolution, error) {
+					require.Equal(t, "kms-key-id", doc.AssertionMethod[0].VerificationMethod.ID)
+
+					return &docdid.DocResolution{DIDDocument: &docdid.Doc{ID: "did:orb:test123456"}}, nil
+				})
+
+			vdr.EXPECT().Resolve("did:orb:test123456").Return(nil, nil)
+
+			compZCAP, err := zcapld.CompressZCAP(&zcapld.Capability{
+				Proof: []verifiable.Proof{
+					map[string]interface{}{
+						"verificationMethod": "did:orb:test12345#key1234",
+					},
+				},
+			})
+			require.NoError(t, err)
+
+			csh.EXPECT().PostHubstoreProfiles(gomock.Any()).Return(
+				&operations.PostHubstoreProfilesCreated{Payload: &models.Profile{Zcap: compZCAP}}, nil)
+
+			cfgService, err := config.NewService(&config.ServiceParams{
+				StoreProvider:   storage.NewMockStoreProvider(),
+				CSHClient:       csh,
+				VDR:             vdr,
+				KeyManager:      &kms.KeyManager{CrAndExportPubKeyID: "update-key-id"},
+				DidMethod:       "test",
+				DidAnchorOrigin: "test",
+				KeyType:         keyType,
+				SigningKeyManager: &kms.KeyManager{
+					CrAndExportPubKeyID:    "kms-key-id",
+					CrAndExportPubKeyValue: pubKey,
+				},
+			})
+			require.NoError(t, err)
+
+			require.NoError(t, cfgService.CreateConfig())
+
+			conf, err := cfgService.Get()
+			require.NoError(t, err)
+			require.Equal(t, keyType, conf.KeyType)
+			require.Equal(t, "kms-key-id", conf.PubKeyID)
+			require.Empty(t, conf.PrivateKey)
+			require.Equal(t, "update-key-id", conf.UpdateKeyID)
+
+			ctrl.Finish()
+		}
+	})
+
+	t.Run("Signing KMS fails to create key", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		cfgService, err := config.NewService(&config.ServiceParams{
+			StoreProvider:     storage.NewMockStoreProvider(),
+			CSHClient:         NewMockCSHClient(ctrl),
+			VDR:               NewMockVDRRegistry(ctrl),
+			KeyManager:        &kms.KeyManager{},
+			DidMethod:         "test",
+			DidAnchorOrigin:   "test",
+			KeyType:           vccrypto.P256KeyType,
+			SigningKeyManager: &kms.KeyManager{CrAndExportPubKeyErr: errors.New("access denied")},
+		})
+		require.NoError(t, err)
+
+		err = cfgService.CreateConfig()
+		require.Contains(t, err.Error(), "create signing key: access denied")
+	})
+
+	t.Run("Unsupported key type", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		cfgService, err := config.NewService(&config.ServiceParams{
+			StoreProvider:   storage.NewMockStoreProvider(),
+			CSHClient:       NewMockCSHClient(ctrl),
+			VDR:             NewMockVDRRegistry(ctrl),
+			KeyManager:      &kms.KeyManager{},
+			DidMethod:       "test",
+			DidAnchorOrigin: "test",
+			KeyType:         "RSA",
+		})
+		require.NoError(t, err)
+
+		err = cfgService.CreateConfig()
+		require.Contains(t, err.Error(), "unsupported key type: RSA")
+	})
+
 	t.Run("Create key failed", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		defer ctrl.Finish()
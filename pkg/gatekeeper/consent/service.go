@@ -0,0 +1,230 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package consent issues and stores the consent receipts of protected data.
+package consent
+
+//nolint:lll
+//go:generate mockgen -destination gomocks_test.go -package consent_test -source=service.go -mock_names vcIssuer=MockVCIssuer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/util"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
+	"github.com/hyperledger/aries-framework-go/spi/storage"
+
+	"github.com/trustbloc/ace/pkg/storage/cursor"
+	"github.com/trustbloc/ace/pkg/storage/index"
+)
+
+const (
+	// ReceiptVersion is the version of the Kantara Initiative consent receipt specification the receipts follow.
+	ReceiptVersion = "KI-CR-v1.1.0"
+	// CredentialType is the type of the credentials of consent receipts.
+	CredentialType = "ConsentReceipt"
+
+	credentialContext = "https://www.w3.org/2018/credentials/v1" //nolint:gosec
+	storeName         = "consent_receipt"
+	didIndex          = "did"
+	subjectIndex      = "subject"
+	// subjectPageSize is the number of receipts read at a time when looking for the subject of protected data.
+	subjectPageSize = 10
+)
+
+type vcIssuer interface {
+	IssueCredential(ctx context.Context, cred []byte) (*verifiable.Credential, error)
+}
+
+// Receipt records the consent of the subject to the protection of their data under a policy.
+type Receipt struct {
+	ID      string `json:"receipt_id"`
+	Version string `json:"version"`
+	// Timestamp is the time the consent was given, when the data was protected.
+	Timestamp time.Time `json:"timestamp"`
+	// DID is the DID of the protected data.
+	DID string `json:"did"`
+	// Subject, if known, is the DID of the subject of the data, the PII principal.
+	Subject string `json:"subject,omitempty"`
+	// Controller is the DID of the collector that protected the data, the PII controller.
+	Controller string   `json:"controller"`
+	PolicyID   string   `json:"policy_id"`
+	Purposes   []string `json:"purposes"`
+	// Retention is how long the data is retained for, e.g. P1Y.
+	Retention string `json:"retention,omitempty"`
+}
+
+// Config defines dependencies for Service.
+type Config struct {
+	StoreProvider storage.Provider
+	VCIssuer      vcIssuer
+}
+
+// Service issues consent receipts as verifiable credentials, and stores them for audit.
+type Service struct {
+	store  storage.Store
+	issuer vcIssuer
+}
+
+// NewService returns a new instance of Service.
+func NewService(config *Config) (*Service, error) {
+	store, err := index.OpenStore(config.StoreProvider, &index.Declaration{
+		Store:    storeName,
+		TagNames: []string{didIndex, subjectIndex},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("open consent receipt store: %w", err)
+	}
+
+	return &Service{
+		store:  store,
+		issuer: config.VCIssuer,
+	}, nil
+}
+
+// Issue issues the receipt as a credential signed by the issuer and stores it. The ID, version and timestamp of the
+// receipt are set.
+func (s *Service) Issue(ctx context.Context, r *Receipt) (*verifiable.Credential, error) {
+	r.ID = uuid.New().String()
+	r.Version = ReceiptVersion
+	r.Timestamp = time.Now().UTC()
+
+	subject := make(map[string]interface{})
+
+	b, err := json.Marshal(r)
+	if err != nil {
+		return nil, fmt.Errorf("marshal receipt: %w", err)
+	}
+
+	if err = json.Unmarshal(b, &subject); err != nil {
+		return nil, fmt.Errorf("unmarshal receipt: %w", err)
+	}
+
+	subject["id"] = r.DID
+
+	cred := verifiable.Credential{
+		ID:      "urn:uuid:" + r.ID,
+		Context: []string{credentialContext},
+		Types:   []string{"VerifiableCredential", CredentialType},
+		// issuerID will be overwritten in the issuer
+		Issuer:  verifiable.Issuer{ID: uuid.New().URN()},
+		Issued:  util.NewTime(r.Timestamp),
+		Subject: subject,
+	}
+
+	credBytes, err := cred.MarshalJSON()
+	if err != nil {
+		return nil, fmt.Errorf("marshal credential: %w", err)
+	}
+
+	vc, err := s.issuer.IssueCredential(ctx, credBytes)
+	if err != nil {
+		return nil, fmt.Errorf("issue credential: %w", err)
+	}
+
+	vcBytes, err := vc.MarshalJSON()
+	if err != nil {
+		return nil, fmt.Errorf("marshal issued credential: %w", err)
+	}
+
+	tags := []storage.Tag{{Name: didIndex, Value: index.TagValue(r.DID)}}
+
+	if r.Subject != "" {
+		tags = append(tags, storage.Tag{Name: subjectIndex, Value: index.TagValue(r.Subject)})
+	}
+
+	if err = s.store.Put(r.ID, vcBytes, tags...); err != nil {
+		return nil, fmt.Errorf("store receipt: %w", err)
+	}
+
+	return vc, nil
+}
+
+// Iterate calls fn with the signed receipts of the protected data, reading pageSize receipts at a time. Returning
+// cursor.ErrStop from fn stops the iteration.
+func (s *Service) Iterate(_ context.Context, did string, pageSize int, fn func(receipt json.RawMessage) error) error {
+	c, err := cursor.New(s.store, didIndex+":"+index.TagValue(did), pageSize)
+	if err != nil {
+		return fmt.Errorf("query receipts: %w", err)
+	}
+
+	return cursor.Iterate(c, func(values [][]byte) error {
+		for _, v := range values {
+			if e := fn(v); e != nil {
+				return e
+			}
+		}
+
+		return nil
+	})
+}
+
+// IterateSubject calls fn with the receipts of the data of the subject, reading pageSize receipts at a time. Only the
+// receipts issued with the DID of the subject are found. Returning cursor.ErrStop from fn stops the iteration.
+func (s *Service) IterateSubject(_ context.Context, subject string, pageSize int, fn func(r *Receipt) error) error {
+	c, err := cursor.New(s.store, subjectIndex+":"+index.TagValue(subject), pageSize)
+	if err != nil {
+		return fmt.Errorf("query receipts: %w", err)
+	}
+
+	return cursor.Iterate(c, func(values [][]byte) error {
+		for _, v := range values {
+			r, e := unmarshalReceipt(v)
+			if e != nil {
+				return e
+			}
+
+			if e = fn(r); e != nil {
+				return e
+			}
+		}
+
+		return nil
+	})
+}
+
+// Subject returns the DID of the subject of the protected data recorded on its receipts, or an empty string if it is
+// not known.
+func (s *Service) Subject(ctx context.Context, did string) (string, error) {
+	var subject string
+
+	err := s.Iterate(ctx, did, subjectPageSize, func(receipt json.RawMessage) error {
+		r, err := unmarshalReceipt(receipt)
+		if err != nil {
+			return err
+		}
+
+		if r.Subject == "" {
+			return nil
+		}
+
+		subject = r.Subject
+
+		return cursor.ErrStop
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return subject, nil
+}
+
+// unmarshalReceipt returns the receipt the signed receipt was issued for.
+func unmarshalReceipt(signed []byte) (*Receipt, error) {
+	var vc struct {
+		Subject Receipt `json:"credentialSubject"`
+	}
+
+	if err := json.Unmarshal(signed, &vc); err != nil {
+		return nil, fmt.Errorf("unmarshal receipt: %w", err)
+	}
+
+	return &vc.Subject, nil
+}
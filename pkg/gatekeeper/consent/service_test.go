@@ -0,0 +1,181 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package consent_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/hyperledger/aries-framework-go/component/storageutil/mem"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
+	mockstorage "github.com/hyperledger/aries-framework-go/pkg/mock/storage"
+	"github.com/hyperledger/aries-framework-go/spi/storage"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/ace/pkg/gatekeeper/consent"
+	"github.com/trustbloc/ace/pkg/internal/testutil"
+	"github.com/trustbloc/ace/pkg/storage/index"
+)
+
+const resourceDID = "did:example:resource"
+
+func TestNewService(t *testing.T) {
+	provider := mockstorage.NewMockStoreProvider()
+	provider.ErrOpenStoreHandle = errors.New("open error")
+
+	_, err := consent.NewService(&consent.Config{StoreProvider: provider})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "open consent receipt store")
+}
+
+func TestService_Issue(t *testing.T) {
+	t.Run("Issues and stores receipt", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		issuer := NewMockVCIssuer(ctrl)
+		issuer.EXPECT().IssueCredential(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, cred []byte) (*verifiable.Credential, error) {
+				return verifiable.ParseCredential(cred, verifiable.WithDisabledProofCheck(),
+					verifiable.WithJSONLDDocumentLoader(testutil.DocumentLoader(t)))
+			}).AnyTimes()
+
+		svc, err := consent.NewService(&consent.Config{StoreProvider: mem.NewProvider(), VCIssuer: issuer})
+		require.NoError(t, err)
+
+		r := &consent.Receipt{
+			DID:        resourceDID,
+			Subject:    "did:example:subject",
+			Controller: "did:example:collector",
+			PolicyID:   "policy",
+			Purposes:   []string{"fraud prevention"},
+			Retention:  "P1Y",
+		}
+
+		vc, err := svc.Issue(context.Background(), r)
+		require.NoError(t, err)
+		require.NotEmpty(t, r.ID)
+		require.Equal(t, consent.ReceiptVersion, r.Version)
+		require.False(t, r.Timestamp.IsZero())
+		require.Contains(t, vc.Types, consent.CredentialType)
+		require.Equal(t, "urn:uuid:"+r.ID, vc.ID)
+
+		subjects, ok := vc.Subject.([]verifiable.Subject)
+		require.True(t, ok)
+		require.Equal(t, resourceDID, subjects[0].ID)
+		require.Equal(t, "did:example:collector", subjects[0].CustomFields["controller"])
+
+		_, err = svc.Issue(context.Background(), &consent.Receipt{DID: "did:example:other"})
+		require.NoError(t, err)
+
+		var receipts []string
+
+		err = svc.Iterate(context.Background(), resourceDID, 10, func(receipt json.RawMessage) error {
+			receipts = append(receipts, string(receipt))
+
+			return nil
+		})
+		require.NoError(t, err)
+		require.Len(t, receipts, 1)
+		require.Contains(t, receipts[0], r.ID)
+
+		var subjectReceipts []*consent.Receipt
+
+		err = svc.IterateSubject(context.Background(), "did:example:subject", 10, func(r *consent.Receipt) error {
+			subjectReceipts = append(subjectReceipts, r)
+
+			return nil
+		})
+		require.NoError(t, err)
+		require.Len(t, subjectReceipts, 1)
+		require.Equal(t, r.ID, subjectReceipts[0].ID)
+		require.Equal(t, resourceDID, subjectReceipts[0].DID)
+		require.Equal(t, "policy", subjectReceipts[0].PolicyID)
+		require.Equal(t, []string{"fraud prevention"}, subjectReceipts[0].Purposes)
+
+		subject, err := svc.Subject(context.Background(), resourceDID)
+		require.NoError(t, err)
+		require.Equal(t, "did:example:subject", subject)
+
+		subject, err = svc.Subject(context.Background(), "did:example:other")
+		require.NoError(t, err)
+		require.Empty(t, subject)
+	})
+
+	t.Run("Fail to issue credential", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		issuer := NewMockVCIssuer(ctrl)
+		issuer.EXPECT().IssueCredential(gomock.Any(), gomock.Any()).Return(nil, errors.New("issue error"))
+
+		svc, err := consent.NewService(&consent.Config{StoreProvider: mem.NewProvider(), VCIssuer: issuer})
+		require.NoError(t, err)
+
+		_, err = svc.Issue(context.Background(), &consent.Receipt{DID: resourceDID})
+		require.EqualError(t, err, "issue credential: issue error")
+	})
+
+	t.Run("Fail to store receipt", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		issuer := NewMockVCIssuer(ctrl)
+		issuer.EXPECT().IssueCredential(gomock.Any(), gomock.Any()).Return(&verifiable.Credential{
+			ID:      "urn:uuid:receipt",
+			Context: []string{"https://www.w3.org/2018/credentials/v1"},
+			Types:   []string{"VerifiableCredential"},
+			Issuer:  verifiable.Issuer{ID: "did:example:gatekeeper"},
+			Subject: resourceDID,
+		}, nil)
+
+		provider := mockstorage.NewMockStoreProvider()
+		provider.Store.ErrPut = errors.New("put error")
+
+		svc, err := consent.NewService(&consent.Config{StoreProvider: provider, VCIssuer: issuer})
+		require.NoError(t, err)
+
+		_, err = svc.Issue(context.Background(), &consent.Receipt{DID: resourceDID})
+		require.EqualError(t, err, "store receipt: put error")
+	})
+}
+
+func TestService_IterateSubject(t *testing.T) {
+	t.Run("Fail to query receipts", func(t *testing.T) {
+		provider := mockstorage.NewMockStoreProvider()
+		provider.Store.ErrQuery = errors.New("query error")
+
+		svc, err := consent.NewService(&consent.Config{StoreProvider: provider})
+		require.NoError(t, err)
+
+		err = svc.IterateSubject(context.Background(), "did:example:subject", 10, func(*consent.Receipt) error {
+			return nil
+		})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "query receipts")
+	})
+
+	t.Run("Fail to read receipt", func(t *testing.T) {
+		provider := mockstorage.NewMockStoreProvider()
+		provider.Store.Store["receipt"] = mockstorage.DBEntry{
+			Value: []byte("invalid"),
+			Tags:  []storage.Tag{{Name: "subject", Value: index.TagValue("did:example:subject")}},
+		}
+
+		svc, err := consent.NewService(&consent.Config{StoreProvider: provider})
+		require.NoError(t, err)
+
+		err = svc.IterateSubject(context.Background(), "did:example:subject", 10, func(*consent.Receipt) error {
+			return nil
+		})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "unmarshal receipt")
+	})
+}
@@ -0,0 +1,130 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package delegation stores the delegations of the approval authority of approvers to other DIDs.
+package delegation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hyperledger/aries-framework-go/spi/storage"
+
+	"github.com/trustbloc/ace/pkg/storage/cursor"
+	"github.com/trustbloc/ace/pkg/storage/index"
+)
+
+const (
+	storeName     = "delegation"
+	delegateIndex = "delegate"
+	// pageSize is the number of delegations read at a time when looking for the delegations of a delegate.
+	pageSize = 100
+)
+
+// ErrInvalidDelegation is returned when storing a delegation without a delegate or a valid time window.
+var ErrInvalidDelegation = errors.New("invalid delegation")
+
+// Delegation is a capability granted by an approver of a policy to another DID, the delegate, to decide on the release
+// tickets of the policy on behalf of the approver during a time window.
+type Delegation struct {
+	ID       string `json:"id"`
+	PolicyID string `json:"policy_id"`
+	// Delegator is the DID of the approver delegating their approval authority.
+	Delegator string `json:"delegator"`
+	Delegate  string `json:"delegate"`
+	// NotBefore and NotAfter are the start and the end of the time window of the delegation.
+	NotBefore time.Time `json:"not_before"`
+	NotAfter  time.Time `json:"not_after"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Active reports whether the delegation is active at the given time.
+func (d *Delegation) Active(at time.Time) bool {
+	return !at.Before(d.NotBefore) && at.Before(d.NotAfter)
+}
+
+// Service stores delegations and finds the active delegations of delegates.
+type Service struct {
+	store storage.Store
+}
+
+// NewService returns a new instance of Service.
+func NewService(storeProvider storage.Provider) (*Service, error) {
+	store, err := index.OpenStore(storeProvider, &index.Declaration{
+		Store:    storeName,
+		TagNames: []string{delegateIndex},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("open delegation store: %w", err)
+	}
+
+	return &Service{store: store}, nil
+}
+
+// Delegate stores the delegation. Its ID and creation time are set, and its time window starts at the creation time
+// if NotBefore is not set.
+func (s *Service) Delegate(_ context.Context, d *Delegation) error {
+	d.ID = uuid.New().String()
+	d.CreatedAt = time.Now().UTC()
+
+	if d.NotBefore.IsZero() {
+		d.NotBefore = d.CreatedAt
+	}
+
+	if d.Delegate == "" || d.Delegate == d.Delegator {
+		return fmt.Errorf("%w: the delegate must be another DID", ErrInvalidDelegation)
+	}
+
+	if !d.NotAfter.After(d.NotBefore) || !d.NotAfter.After(d.CreatedAt) {
+		return fmt.Errorf("%w: the time window must end in the future, after its start", ErrInvalidDelegation)
+	}
+
+	b, err := json.Marshal(d)
+	if err != nil {
+		return fmt.Errorf("marshal delegation: %w", err)
+	}
+
+	if err = s.store.Put(d.ID, b, storage.Tag{Name: delegateIndex, Value: index.TagValue(d.Delegate)}); err != nil {
+		return fmt.Errorf("store delegation: %w", err)
+	}
+
+	return nil
+}
+
+// Active returns the delegations to the delegate under the policy that are active at the given time.
+func (s *Service) Active(_ context.Context, policyID, delegate string, at time.Time) ([]*Delegation, error) {
+	c, err := cursor.New(s.store, delegateIndex+":"+index.TagValue(delegate), pageSize)
+	if err != nil {
+		return nil, fmt.Errorf("query delegations: %w", err)
+	}
+
+	var active []*Delegation
+
+	err = cursor.Iterate(c, func(values [][]byte) error {
+		for _, v := range values {
+			var d Delegation
+
+			if e := json.Unmarshal(v, &d); e != nil {
+				return fmt.Errorf("unmarshal delegation: %w", e)
+			}
+
+			if d.PolicyID == policyID && d.Active(at) {
+				active = append(active, &d)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return active, nil
+}
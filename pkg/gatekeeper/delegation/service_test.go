@@ -0,0 +1,139 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package delegation_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/aries-framework-go/component/storageutil/mem"
+	"github.com/hyperledger/aries-framework-go/pkg/mock/storage"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/ace/pkg/gatekeeper/delegation"
+)
+
+const (
+	testPolicyID = "test-policy"
+	approverDID  = "did:example:approver"
+	delegateDID  = "did:example:delegate"
+)
+
+func TestService(t *testing.T) {
+	ctx := context.Background()
+
+	svc, openErr := delegation.NewService(mem.NewProvider())
+	require.NoError(t, openErr)
+
+	now := time.Now()
+
+	d := &delegation.Delegation{
+		PolicyID:  testPolicyID,
+		Delegator: approverDID,
+		Delegate:  delegateDID,
+		NotAfter:  now.Add(time.Hour),
+	}
+
+	require.NoError(t, svc.Delegate(ctx, d))
+	require.NotEmpty(t, d.ID)
+	require.False(t, d.CreatedAt.IsZero())
+	require.Equal(t, d.CreatedAt, d.NotBefore)
+
+	require.NoError(t, svc.Delegate(ctx, &delegation.Delegation{
+		PolicyID:  testPolicyID,
+		Delegator: "did:example:other",
+		Delegate:  delegateDID,
+		NotBefore: now.Add(2 * time.Hour),
+		NotAfter:  now.Add(3 * time.Hour),
+	}))
+
+	require.NoError(t, svc.Delegate(ctx, &delegation.Delegation{
+		PolicyID:  "other-policy",
+		Delegator: approverDID,
+		Delegate:  delegateDID,
+		NotAfter:  now.Add(time.Hour),
+	}))
+
+	t.Run("Active delegations", func(t *testing.T) {
+		active, err := svc.Active(ctx, testPolicyID, delegateDID, now.Add(time.Minute))
+		require.NoError(t, err)
+		require.Len(t, active, 1)
+		require.Equal(t, d.ID, active[0].ID)
+		require.Equal(t, approverDID, active[0].Delegator)
+
+		active, err = svc.Active(ctx, testPolicyID, delegateDID, now.Add(150*time.Minute))
+		require.NoError(t, err)
+		require.Len(t, active, 1)
+		require.Equal(t, "did:example:other", active[0].Delegator)
+	})
+
+	t.Run("No active delegations", func(t *testing.T) {
+		for _, at := range []time.Time{now.Add(-time.Minute), now.Add(90 * time.Minute), now.Add(4 * time.Hour)} {
+			active, err := svc.Active(ctx, testPolicyID, delegateDID, at)
+			require.NoError(t, err)
+			require.Empty(t, active)
+		}
+
+		active, err := svc.Active(ctx, testPolicyID, approverDID, now.Add(time.Minute))
+		require.NoError(t, err)
+		require.Empty(t, active)
+	})
+
+	t.Run("Invalid delegations", func(t *testing.T) {
+		for _, invalid := range []*delegation.Delegation{
+			{PolicyID: testPolicyID, Delegator: approverDID, NotAfter: now.Add(time.Hour)},
+			{PolicyID: testPolicyID, Delegator: approverDID, Delegate: approverDID, NotAfter: now.Add(time.Hour)},
+			{PolicyID: testPolicyID, Delegator: approverDID, Delegate: delegateDID},
+			{PolicyID: testPolicyID, Delegator: approverDID, Delegate: delegateDID, NotAfter: now.Add(-time.Minute)},
+			{
+				PolicyID:  testPolicyID,
+				Delegator: approverDID,
+				Delegate:  delegateDID,
+				NotBefore: now.Add(2 * time.Hour),
+				NotAfter:  now.Add(time.Hour),
+			},
+		} {
+			require.ErrorIs(t, svc.Delegate(ctx, invalid), delegation.ErrInvalidDelegation)
+		}
+	})
+}
+
+func TestService_Failures(t *testing.T) {
+	t.Run("Fail to open store", func(t *testing.T) {
+		_, err := delegation.NewService(&storage.MockStoreProvider{ErrOpenStoreHandle: errors.New("open error")})
+		require.EqualError(t, err, "open delegation store: open error")
+	})
+
+	t.Run("Fail to store delegation", func(t *testing.T) {
+		provider := storage.NewMockStoreProvider()
+		provider.Store.ErrPut = errors.New("put error")
+
+		svc, err := delegation.NewService(provider)
+		require.NoError(t, err)
+
+		err = svc.Delegate(context.Background(), &delegation.Delegation{
+			Delegator: approverDID,
+			Delegate:  delegateDID,
+			NotAfter:  time.Now().Add(time.Hour),
+		})
+		require.EqualError(t, err, "store delegation: put error")
+	})
+
+	t.Run("Fail to query delegations", func(t *testing.T) {
+		provider := storage.NewMockStoreProvider()
+		provider.Store.ErrQuery = errors.New("query error")
+
+		svc, err := delegation.NewService(provider)
+		require.NoError(t, err)
+
+		_, err = svc.Active(context.Background(), testPolicyID, delegateDID, time.Now())
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "query error")
+	})
+}
@@ -0,0 +1,36 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package didcomm
+
+import "context"
+
+type contextKey struct{}
+
+// WithMessage returns a context of the operations carried out on behalf of the sender of the received message.
+func WithMessage(ctx context.Context, msg *Message) context.Context {
+	return context.WithValue(ctx, contextKey{}, msg)
+}
+
+// Sender returns the DID of the sender of the message of the context, verified when the message was received.
+func Sender(ctx context.Context) (string, bool) {
+	msg, ok := ctx.Value(contextKey{}).(*Message)
+	if !ok {
+		return "", false
+	}
+
+	return msg.From, true
+}
+
+// Signature returns the signature the message of the context was received with.
+func Signature(ctx context.Context) (string, bool) {
+	msg, ok := ctx.Value(contextKey{}).(*Message)
+	if !ok {
+		return "", false
+	}
+
+	return msg.Signature, true
+}
@@ -0,0 +1,298 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package didcomm
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hyperledger/aries-framework-go/spi/storage"
+
+	"github.com/trustbloc/ace/pkg/storage/cursor"
+	"github.com/trustbloc/ace/pkg/storage/index"
+)
+
+const (
+	// InvitationType is the type of the out-of-band invitations of new participants.
+	InvitationType = "https://didcomm.org/out-of-band/2.0/invitation"
+	// ConnectType is the type of the messages of participants accepting an invitation.
+	ConnectType = "https://trustbloc.dev/ace/2.0/connect"
+
+	// HandlerRole and ApproverRole are the roles participants are invited as.
+	HandlerRole  = "handler"
+	ApproverRole = "approver"
+
+	// DefaultInvitationTTL is the default time invitations can be accepted for.
+	DefaultInvitationTTL = 7 * 24 * time.Hour
+
+	invitationStoreName = "didcomm_invitation"
+	connectionStoreName = "didcomm_connection"
+	didIndex            = "did"
+	oobParam            = "_oob"
+	// pageSize is the number of connections read at a time.
+	pageSize = 100
+)
+
+// ErrInvalidInvitation is returned when inviting a participant with an unknown role, or without the endpoint the
+// invitations are accepted on.
+var ErrInvalidInvitation = errors.New("invalid invitation")
+
+// Invitation is an out-of-band invitation, with its URL.
+type Invitation struct {
+	Message *Message `json:"invitation"`
+	URL     string   `json:"invitation_url"`
+}
+
+// InvitationBody is the body of the out-of-band invitations.
+type InvitationBody struct {
+	GoalCode string   `json:"goal_code"`
+	Goal     string   `json:"goal,omitempty"`
+	Label    string   `json:"label,omitempty"`
+	Accept   []string `json:"accept"`
+	// Endpoint is the endpoint the message accepting the invitation is posted to, as the DID document of Gatekeeper
+	// has no DIDCommMessaging service.
+	Endpoint string `json:"endpoint"`
+}
+
+// ConnectBody is the body of the messages accepting an invitation.
+type ConnectBody struct {
+	Label string `json:"label,omitempty"`
+	// Endpoint, if set, is the endpoint the messages are sent to if the DID document of the participant has no
+	// DIDCommMessaging service, such as a did:key.
+	Endpoint string `json:"endpoint,omitempty"`
+}
+
+// Connection is the connection of a participant that accepted an invitation.
+type Connection struct {
+	ID string `json:"id"`
+	// DID is the DID the participant accepted the invitation with.
+	DID          string    `json:"did"`
+	Role         string    `json:"role"`
+	Label        string    `json:"label,omitempty"`
+	Endpoint     string    `json:"endpoint,omitempty"`
+	InvitationID string    `json:"invitation_id"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// invitation is the stored invitation, until it is accepted or expires.
+type invitation struct {
+	ID        string    `json:"id"`
+	Role      string    `json:"role"`
+	Label     string    `json:"label,omitempty"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Accepted  bool      `json:"accepted,omitempty"`
+}
+
+// Invite creates a single-use out-of-band invitation of a participant with the given role, from the DID of
+// Gatekeeper. Its URL is the endpoint the invitation is accepted on, with the invitation in its _oob parameter.
+func (s *Service) Invite(_ context.Context, role, label string) (*Invitation, error) {
+	if role != HandlerRole && role != ApproverRole {
+		return nil, fmt.Errorf("%w: unknown role %q", ErrInvalidInvitation, role)
+	}
+
+	if s.endpoint == "" {
+		return nil, fmt.Errorf("%w: no endpoint to accept invitations on", ErrInvalidInvitation)
+	}
+
+	conf, err := s.configService.Get()
+	if err != nil {
+		return nil, fmt.Errorf("get gatekeeper DID: %w", err)
+	}
+
+	now := time.Now()
+	inv := &invitation{ID: uuid.New().String(), Role: role, Label: label, ExpiresAt: now.Add(s.invitationTTL).UTC()}
+
+	body, err := json.Marshal(&InvitationBody{
+		GoalCode: "ace.onboard." + role,
+		Goal:     "Connect to Gatekeeper as " + role,
+		Label:    label,
+		Accept:   []string{"didcomm/v2"},
+		Endpoint: s.endpoint,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("marshal invitation body: %w", err)
+	}
+
+	msg := &Message{
+		ID:          inv.ID,
+		Type:        InvitationType,
+		From:        conf.DID,
+		CreatedTime: now.Unix(),
+		ExpiresTime: inv.ExpiresAt.Unix(),
+		Body:        body,
+	}
+
+	invitationURL, err := s.invitationURL(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.putInvitation(inv); err != nil {
+		return nil, err
+	}
+
+	return &Invitation{Message: msg, URL: invitationURL}, nil
+}
+
+func (s *Service) invitationURL(msg *Message) (string, error) {
+	b, err := json.Marshal(msg)
+	if err != nil {
+		return "", fmt.Errorf("marshal invitation: %w", err)
+	}
+
+	u, err := url.Parse(s.endpoint)
+	if err != nil {
+		return "", fmt.Errorf("parse endpoint: %w", err)
+	}
+
+	q := u.Query()
+	q.Set(oobParam, base64.RawURLEncoding.EncodeToString(b))
+	u.RawQuery = q.Encode()
+
+	return u.String(), nil
+}
+
+// Accept records the connection of the sender of a received connect message, replying to an invitation with its
+// parent thread ID. Invitations are accepted once, before they expire.
+func (s *Service) Accept(_ context.Context, msg *Message) (*Connection, error) {
+	var body ConnectBody
+
+	if err := json.Unmarshal(msg.Body, &body); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidMessage, err)
+	}
+
+	if body.Endpoint != "" {
+		if u, err := url.Parse(body.Endpoint); err != nil || (u.Scheme != "https" && u.Scheme != "http") {
+			return nil, fmt.Errorf("%w: invalid endpoint %q", ErrInvalidMessage, body.Endpoint)
+		}
+	}
+
+	b, err := s.invitationStore.Get(msg.ParentThreadID)
+	if errors.Is(err, storage.ErrDataNotFound) {
+		return nil, fmt.Errorf("%w: unknown invitation %q", ErrInvalidMessage, msg.ParentThreadID)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("get invitation: %w", err)
+	}
+
+	var inv invitation
+
+	if err = json.Unmarshal(b, &inv); err != nil {
+		return nil, fmt.Errorf("unmarshal invitation: %w", err)
+	}
+
+	if inv.Accepted || time.Now().After(inv.ExpiresAt) {
+		return nil, fmt.Errorf("%w: invitation was accepted or expired", ErrInvalidMessage)
+	}
+
+	label := body.Label
+	if label == "" {
+		label = inv.Label
+	}
+
+	c := &Connection{
+		ID:           uuid.New().String(),
+		DID:          msg.From,
+		Role:         inv.Role,
+		Label:        label,
+		Endpoint:     body.Endpoint,
+		InvitationID: inv.ID,
+		CreatedAt:    time.Now().UTC(),
+	}
+
+	inv.Accepted = true
+
+	if err = s.putInvitation(&inv); err != nil {
+		return nil, err
+	}
+
+	if b, err = json.Marshal(c); err != nil {
+		return nil, fmt.Errorf("marshal connection: %w", err)
+	}
+
+	if err = s.connectionStore.Put(c.ID, b, storage.Tag{Name: didIndex, Value: index.TagValue(c.DID)}); err != nil {
+		return nil, fmt.Errorf("store connection: %w", err)
+	}
+
+	return c, nil
+}
+
+func (s *Service) putInvitation(inv *invitation) error {
+	b, err := json.Marshal(inv)
+	if err != nil {
+		return fmt.Errorf("marshal invitation: %w", err)
+	}
+
+	if err = s.invitationStore.Put(inv.ID, b); err != nil {
+		return fmt.Errorf("store invitation: %w", err)
+	}
+
+	return nil
+}
+
+// Connections returns the connections of the participants, or of the participant with the given DID if it is set.
+func (s *Service) Connections(_ context.Context, did string) ([]*Connection, error) {
+	query := didIndex
+	if did != "" {
+		query += ":" + index.TagValue(did)
+	}
+
+	c, err := cursor.New(s.connectionStore, query, pageSize)
+	if err != nil {
+		return nil, fmt.Errorf("query connections: %w", err)
+	}
+
+	var connections []*Connection
+
+	err = cursor.Iterate(c, func(values [][]byte) error {
+		for _, v := range values {
+			var conn Connection
+
+			if e := json.Unmarshal(v, &conn); e != nil {
+				return fmt.Errorf("unmarshal connection: %w", e)
+			}
+
+			connections = append(connections, &conn)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return connections, nil
+}
+
+// connectionEndpoint returns the endpoint of the latest connection of the participant with an endpoint.
+func (s *Service) connectionEndpoint(ctx context.Context, did string) (string, error) {
+	connections, err := s.Connections(ctx, did)
+	if err != nil {
+		return "", err
+	}
+
+	var latest *Connection
+
+	for _, c := range connections {
+		if c.Endpoint != "" && (latest == nil || c.CreatedAt.After(latest.CreatedAt)) {
+			latest = c
+		}
+	}
+
+	if latest == nil {
+		return "", ErrNoService
+	}
+
+	return latest.Endpoint, nil
+}
@@ -0,0 +1,164 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package didcomm_test
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/did"
+	vdrmock "github.com/hyperledger/aries-framework-go/pkg/mock/vdr"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/ace/pkg/gatekeeper/config"
+	"github.com/trustbloc/ace/pkg/gatekeeper/didcomm"
+)
+
+const (
+	gatekeeperDID = "did:example:gatekeeper"
+	endpoint      = "https://gatekeeper.example.com/v1/didcomm"
+)
+
+func TestService_Invite(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	configService := NewMockConfigService(ctrl)
+	configService.EXPECT().Get().Return(&config.Config{DID: gatekeeperDID}, nil).AnyTimes()
+
+	t.Run("Success", func(t *testing.T) {
+		svc := newService(t, &didcomm.Config{ConfigService: configService, Endpoint: endpoint})
+
+		inv, err := svc.Invite(context.Background(), didcomm.ApproverRole, "Compliance")
+		require.NoError(t, err)
+		require.Equal(t, didcomm.InvitationType, inv.Message.Type)
+		require.Equal(t, gatekeeperDID, inv.Message.From)
+		require.Greater(t, inv.Message.ExpiresTime, inv.Message.CreatedTime)
+
+		var body didcomm.InvitationBody
+
+		require.NoError(t, json.Unmarshal(inv.Message.Body, &body))
+		require.Equal(t, "ace.onboard.approver", body.GoalCode)
+		require.Equal(t, endpoint, body.Endpoint)
+		require.Equal(t, []string{"didcomm/v2"}, body.Accept)
+
+		u, err := url.Parse(inv.URL)
+		require.NoError(t, err)
+
+		b, err := base64.RawURLEncoding.DecodeString(u.Query().Get("_oob"))
+		require.NoError(t, err)
+
+		var msg didcomm.Message
+
+		require.NoError(t, json.Unmarshal(b, &msg))
+		require.Equal(t, inv.Message.ID, msg.ID)
+	})
+
+	t.Run("Invalid invitations", func(t *testing.T) {
+		svc := newService(t, &didcomm.Config{ConfigService: configService, Endpoint: endpoint})
+
+		_, err := svc.Invite(context.Background(), "collector", "")
+		require.True(t, errors.Is(err, didcomm.ErrInvalidInvitation))
+
+		svc = newService(t, &didcomm.Config{ConfigService: configService})
+
+		_, err = svc.Invite(context.Background(), didcomm.HandlerRole, "")
+		require.True(t, errors.Is(err, didcomm.ErrInvalidInvitation))
+	})
+}
+
+func TestService_Accept(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	configService := NewMockConfigService(ctrl)
+	configService.EXPECT().Get().Return(&config.Config{DID: gatekeeperDID}, nil).AnyTimes()
+
+	connect := func(invitationID, agent string) *didcomm.Message {
+		return &didcomm.Message{
+			ID:             "1",
+			Type:           didcomm.ConnectType,
+			From:           approverDID,
+			ParentThreadID: invitationID,
+			Body:           json.RawMessage(`{"endpoint":"` + agent + `"}`),
+		}
+	}
+
+	t.Run("Connection is used to reach the participant", func(t *testing.T) {
+		received := make(chan struct{}, 1)
+
+		agent := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			received <- struct{}{}
+		}))
+		defer agent.Close()
+
+		encrypter := NewMockEncrypter(ctrl)
+		encrypter.EXPECT().EncryptAs(gomock.Any(), approverDID, gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(json.RawMessage(`{}`), nil)
+
+		svc := newService(t, &didcomm.Config{
+			ConfigService: configService,
+			Endpoint:      endpoint,
+			HTTPClient:    http.DefaultClient,
+			VDR:           &vdrmock.MockVDRegistry{ResolveValue: &did.Doc{ID: approverDID}},
+			Encrypter:     encrypter,
+		})
+
+		inv, err := svc.Invite(context.Background(), didcomm.ApproverRole, "Compliance")
+		require.NoError(t, err)
+
+		c, err := svc.Accept(context.Background(), connect(inv.Message.ID, agent.URL))
+		require.NoError(t, err)
+		require.Equal(t, approverDID, c.DID)
+		require.Equal(t, didcomm.ApproverRole, c.Role)
+		require.Equal(t, "Compliance", c.Label)
+
+		connections, err := svc.Connections(context.Background(), "")
+		require.NoError(t, err)
+		require.Equal(t, []*didcomm.Connection{c}, connections)
+
+		connections, err = svc.Connections(context.Background(), "did:example:other")
+		require.NoError(t, err)
+		require.Empty(t, connections)
+
+		require.NoError(t, svc.Send(context.Background(), approverDID, &didcomm.Message{}))
+		<-received
+
+		// invitations are single-use
+		_, err = svc.Accept(context.Background(), connect(inv.Message.ID, agent.URL))
+		require.True(t, errors.Is(err, didcomm.ErrInvalidMessage))
+	})
+
+	t.Run("Invalid connect messages", func(t *testing.T) {
+		svc := newService(t, &didcomm.Config{
+			ConfigService: configService,
+			Endpoint:      endpoint,
+			InvitationTTL: time.Nanosecond,
+		})
+
+		inv, err := svc.Invite(context.Background(), didcomm.HandlerRole, "")
+		require.NoError(t, err)
+
+		for _, msg := range []*didcomm.Message{
+			connect(inv.Message.ID, "https://agent.example.com"),
+			connect("unknown", "https://agent.example.com"),
+			connect(inv.Message.ID, "ftp://agent.example.com"),
+			{ParentThreadID: inv.Message.ID, Body: json.RawMessage(`[]`)},
+		} {
+			_, err = svc.Accept(context.Background(), msg)
+			require.True(t, errors.Is(err, didcomm.ErrInvalidMessage), err)
+		}
+	})
+}
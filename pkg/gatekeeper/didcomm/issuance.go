@@ -0,0 +1,300 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package didcomm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/util"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
+	"github.com/hyperledger/aries-framework-go/spi/storage"
+
+	"github.com/trustbloc/ace/pkg/storage/cursor"
+	"github.com/trustbloc/ace/pkg/storage/index"
+)
+
+const (
+	// ProposeCredentialType, OfferCredentialType, RequestCredentialType and IssueCredentialType are the types of the
+	// messages of the WACI issuance of the authorized handler credentials, with the Issue Credential 3.0 protocol.
+	ProposeCredentialType = "https://didcomm.org/issue-credential/3.0/propose-credential"
+	OfferCredentialType   = "https://didcomm.org/issue-credential/3.0/offer-credential"
+	RequestCredentialType = "https://didcomm.org/issue-credential/3.0/request-credential"
+	IssueCredentialType   = "https://didcomm.org/issue-credential/3.0/issue-credential"
+
+	// AuthorizedHandlerCredentialType is the type of the credentials issued to the approved handlers, for the
+	// presentation definitions of the policies to require.
+	AuthorizedHandlerCredentialType = "AuthorizedHandler"
+
+	// IssuanceProposed, IssuanceOffered and IssuanceIssued are the statuses of the issuances: proposed by the handler
+	// and waiting for an admin to approve it, offered to the handler once approved, and issued.
+	IssuanceProposed = "proposed"
+	IssuanceOffered  = "offered"
+	IssuanceIssued   = "issued"
+
+	// credentialDetailFormat and credentialFormat are the formats of the attachments of the credential previews
+	// offered and of the credentials issued.
+	credentialDetailFormat = "aries/ld-proof-vc-detail@v1.0"
+	credentialFormat       = "aries/ld-proof-vc@v1.0"
+	credentialContext      = "https://www.w3.org/2018/credentials/v1" //nolint:gosec
+	goalCode               = "ace.authorized-handler"
+
+	issuanceStoreName = "didcomm_issuance"
+	statusIndex       = "status"
+)
+
+var (
+	// ErrIssuanceNotFound is returned when approving an unknown issuance.
+	ErrIssuanceNotFound = errors.New("issuance not found")
+	// ErrInvalidIssuance is returned when approving an issuance that is not waiting for approval.
+	ErrInvalidIssuance = errors.New("invalid issuance")
+)
+
+// Issuance is the issuance of an authorized handler credential to a handler, in the thread of the
+// propose-credential message of the handler.
+type Issuance struct {
+	ID string `json:"id"`
+	// DID is the DID of the handler the credential is issued to.
+	DID    string `json:"did"`
+	Status string `json:"status"`
+	// Credential is the credential issued, once the status is issued.
+	Credential json.RawMessage `json:"credential,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+	UpdatedAt  time.Time       `json:"updated_at"`
+}
+
+// CredentialBody is the body of the offer-credential and issue-credential messages.
+type CredentialBody struct {
+	GoalCode string `json:"goal_code,omitempty"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+// Propose records the issuance proposed by a propose-credential message, for an admin to approve. Only the
+// participants connected as handlers can propose an issuance.
+func (s *Service) Propose(ctx context.Context, msg *Message) (*Issuance, error) {
+	connections, err := s.Connections(ctx, msg.From)
+	if err != nil {
+		return nil, err
+	}
+
+	handler := false
+
+	for _, c := range connections {
+		if c.Role == HandlerRole {
+			handler = true
+
+			break
+		}
+	}
+
+	if !handler {
+		return nil, fmt.Errorf("%w: %s is not connected as a handler", ErrInvalidMessage, msg.From)
+	}
+
+	id := msg.ThreadID
+	if id == "" {
+		id = msg.ID
+	}
+
+	if _, err = s.issuanceStore.Get(id); err == nil {
+		return nil, fmt.Errorf("%w: issuance %q already proposed", ErrInvalidMessage, id)
+	} else if !errors.Is(err, storage.ErrDataNotFound) {
+		return nil, fmt.Errorf("get issuance: %w", err)
+	}
+
+	now := time.Now().UTC()
+	iss := &Issuance{ID: id, DID: msg.From, Status: IssuanceProposed, CreatedAt: now, UpdatedAt: now}
+
+	if err = s.putIssuance(iss); err != nil {
+		return nil, err
+	}
+
+	return iss, nil
+}
+
+// ApproveIssuance approves a proposed issuance and offers the credential to the handler, with a preview of the
+// credential attached.
+func (s *Service) ApproveIssuance(ctx context.Context, id string) (*Issuance, error) {
+	iss, err := s.getIssuance(id)
+	if errors.Is(err, storage.ErrDataNotFound) {
+		return nil, fmt.Errorf("%w: %s", ErrIssuanceNotFound, id)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if iss.Status != IssuanceProposed {
+		return nil, fmt.Errorf("%w: issuance %s is %s", ErrInvalidIssuance, id, iss.Status)
+	}
+
+	preview, err := json.Marshal(authorizedHandlerCredential(iss.DID, time.Now()))
+	if err != nil {
+		return nil, fmt.Errorf("marshal credential preview: %w", err)
+	}
+
+	err = s.sendCredentialMessage(ctx, iss, OfferCredentialType, credentialDetailFormat, preview)
+	if err != nil {
+		return nil, fmt.Errorf("send offer: %w", err)
+	}
+
+	iss.Status = IssuanceOffered
+	iss.UpdatedAt = time.Now().UTC()
+
+	if err = s.putIssuance(iss); err != nil {
+		return nil, err
+	}
+
+	return iss, nil
+}
+
+// Request issues the credential requested by a request-credential message of the handler, in the thread of an
+// offered issuance, and sends it to the handler with an issue-credential message.
+func (s *Service) Request(ctx context.Context, msg *Message) (*Issuance, error) {
+	iss, err := s.getIssuance(msg.ThreadID)
+	if errors.Is(err, storage.ErrDataNotFound) {
+		return nil, fmt.Errorf("%w: unknown issuance %q", ErrInvalidMessage, msg.ThreadID)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if iss.Status != IssuanceOffered || iss.DID != msg.From {
+		return nil, fmt.Errorf("%w: no credential offered to %s in issuance %s", ErrInvalidMessage, msg.From, iss.ID)
+	}
+
+	credBytes, err := json.Marshal(authorizedHandlerCredential(iss.DID, time.Now()))
+	if err != nil {
+		return nil, fmt.Errorf("marshal credential: %w", err)
+	}
+
+	vc, err := s.issuer.IssueCredential(ctx, credBytes)
+	if err != nil {
+		return nil, fmt.Errorf("issue credential: %w", err)
+	}
+
+	if iss.Credential, err = vc.MarshalJSON(); err != nil {
+		return nil, fmt.Errorf("marshal issued credential: %w", err)
+	}
+
+	// the issuance is only recorded once the credential is delivered, for the handler to request it again otherwise
+	if err = s.sendCredentialMessage(ctx, iss, IssueCredentialType, credentialFormat, iss.Credential); err != nil {
+		return nil, fmt.Errorf("send credential: %w", err)
+	}
+
+	iss.Status = IssuanceIssued
+	iss.UpdatedAt = time.Now().UTC()
+
+	if err = s.putIssuance(iss); err != nil {
+		return nil, err
+	}
+
+	return iss, nil
+}
+
+// Issuances returns the issuances, or the issuances with the given status if it is set.
+func (s *Service) Issuances(_ context.Context, status string) ([]*Issuance, error) {
+	query := statusIndex
+	if status != "" {
+		query += ":" + index.TagValue(status)
+	}
+
+	c, err := cursor.New(s.issuanceStore, query, pageSize)
+	if err != nil {
+		return nil, fmt.Errorf("query issuances: %w", err)
+	}
+
+	var issuances []*Issuance
+
+	err = cursor.Iterate(c, func(values [][]byte) error {
+		for _, v := range values {
+			var iss Issuance
+
+			if e := json.Unmarshal(v, &iss); e != nil {
+				return fmt.Errorf("unmarshal issuance: %w", e)
+			}
+
+			issuances = append(issuances, &iss)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return issuances, nil
+}
+
+func (s *Service) sendCredentialMessage(ctx context.Context, iss *Issuance, msgType, format string,
+	cred []byte) error {
+	body, err := json.Marshal(&CredentialBody{GoalCode: goalCode, Comment: "Authorized handler credential"})
+	if err != nil {
+		return fmt.Errorf("marshal body: %w", err)
+	}
+
+	return s.Send(ctx, iss.DID, &Message{
+		ID:       uuid.New().String(),
+		Type:     msgType,
+		ThreadID: iss.ID,
+		Body:     body,
+		Attachments: []*Attachment{{
+			ID:        uuid.New().String(),
+			MediaType: "application/json",
+			Format:    format,
+			Data:      AttachmentData{JSON: cred},
+		}},
+	})
+}
+
+func (s *Service) getIssuance(id string) (*Issuance, error) {
+	b, err := s.issuanceStore.Get(id)
+	if err != nil {
+		return nil, fmt.Errorf("get issuance: %w", err)
+	}
+
+	var iss Issuance
+
+	if err = json.Unmarshal(b, &iss); err != nil {
+		return nil, fmt.Errorf("unmarshal issuance: %w", err)
+	}
+
+	return &iss, nil
+}
+
+func (s *Service) putIssuance(iss *Issuance) error {
+	b, err := json.Marshal(iss)
+	if err != nil {
+		return fmt.Errorf("marshal issuance: %w", err)
+	}
+
+	tag := storage.Tag{Name: statusIndex, Value: index.TagValue(iss.Status)}
+
+	if err = s.issuanceStore.Put(iss.ID, b, tag); err != nil {
+		return fmt.Errorf("store issuance: %w", err)
+	}
+
+	return nil
+}
+
+// authorizedHandlerCredential returns the unsigned authorized handler credential of the handler.
+func authorizedHandlerCredential(handler string, issued time.Time) *verifiable.Credential {
+	return &verifiable.Credential{
+		ID:      uuid.New().URN(),
+		Context: []string{credentialContext},
+		Types:   []string{"VerifiableCredential", AuthorizedHandlerCredentialType},
+		// issuerID will be overwritten in the issuer
+		Issuer:  verifiable.Issuer{ID: uuid.New().URN()},
+		Issued:  util.NewTime(issued.UTC()),
+		Subject: map[string]interface{}{"id": handler},
+	}
+}
@@ -0,0 +1,155 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package didcomm_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/did"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
+	vdrmock "github.com/hyperledger/aries-framework-go/pkg/mock/vdr"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/ace/pkg/gatekeeper/config"
+	"github.com/trustbloc/ace/pkg/gatekeeper/didcomm"
+	"github.com/trustbloc/ace/pkg/internal/testutil"
+)
+
+func TestService_Issuance(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	received := make(chan *didcomm.Message, 1)
+
+	agent := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		b, e := io.ReadAll(r.Body)
+		require.NoError(t, e)
+
+		var msg didcomm.Message
+
+		require.NoError(t, json.Unmarshal(b, &msg))
+
+		received <- &msg
+	}))
+	defer agent.Close()
+
+	configService := NewMockConfigService(ctrl)
+	configService.EXPECT().Get().Return(&config.Config{DID: gatekeeperDID}, nil).AnyTimes()
+
+	// the messages are sent in plaintext, for the agent to read them
+	encrypter := NewMockEncrypter(ctrl)
+	encrypter.EXPECT().EncryptAs(gomock.Any(), approverDID, gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ string, data []byte, _, _ string) (json.RawMessage, error) {
+			return data, nil
+		}).AnyTimes()
+
+	issuer := NewMockVCIssuer(ctrl)
+	issuer.EXPECT().IssueCredential(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, cred []byte) (*verifiable.Credential, error) {
+			return verifiable.ParseCredential(cred, verifiable.WithDisabledProofCheck(),
+				verifiable.WithJSONLDDocumentLoader(testutil.DocumentLoader(t)))
+		}).AnyTimes()
+
+	svc := newService(t, &didcomm.Config{
+		ConfigService: configService,
+		Endpoint:      endpoint,
+		HTTPClient:    http.DefaultClient,
+		VDR:           &vdrmock.MockVDRegistry{ResolveValue: &did.Doc{ID: approverDID}},
+		Encrypter:     encrypter,
+		VCIssuer:      issuer,
+	})
+
+	propose := &didcomm.Message{ID: "propose", Type: didcomm.ProposeCredentialType, From: approverDID}
+	request := &didcomm.Message{ID: "request", Type: didcomm.RequestCredentialType, From: approverDID,
+		ThreadID: propose.ID}
+
+	t.Run("Only handlers can propose", func(t *testing.T) {
+		_, err := svc.Propose(context.Background(), propose)
+		require.True(t, errors.Is(err, didcomm.ErrInvalidMessage), err)
+	})
+
+	inv, err := svc.Invite(context.Background(), didcomm.HandlerRole, "Support")
+	require.NoError(t, err)
+
+	_, err = svc.Accept(context.Background(), &didcomm.Message{
+		ID:             "connect",
+		Type:           didcomm.ConnectType,
+		From:           approverDID,
+		ParentThreadID: inv.Message.ID,
+		Body:           json.RawMessage(`{"endpoint":"` + agent.URL + `"}`),
+	})
+	require.NoError(t, err)
+
+	t.Run("Success", func(t *testing.T) {
+		iss, err := svc.Propose(context.Background(), propose)
+		require.NoError(t, err)
+		require.Equal(t, didcomm.IssuanceProposed, iss.Status)
+		require.Equal(t, approverDID, iss.DID)
+
+		_, err = svc.Propose(context.Background(), propose)
+		require.True(t, errors.Is(err, didcomm.ErrInvalidMessage), err)
+
+		// the credential is only issued once it is offered
+		_, err = svc.Request(context.Background(), request)
+		require.True(t, errors.Is(err, didcomm.ErrInvalidMessage), err)
+
+		issuances, err := svc.Issuances(context.Background(), didcomm.IssuanceProposed)
+		require.NoError(t, err)
+		require.Equal(t, []*didcomm.Issuance{iss}, issuances)
+
+		iss, err = svc.ApproveIssuance(context.Background(), iss.ID)
+		require.NoError(t, err)
+		require.Equal(t, didcomm.IssuanceOffered, iss.Status)
+
+		offer := <-received
+		require.Equal(t, didcomm.OfferCredentialType, offer.Type)
+		require.Equal(t, propose.ID, offer.ThreadID)
+		require.Len(t, offer.Attachments, 1)
+		require.Contains(t, string(offer.Attachments[0].Data.JSON), didcomm.AuthorizedHandlerCredentialType)
+
+		_, err = svc.ApproveIssuance(context.Background(), iss.ID)
+		require.True(t, errors.Is(err, didcomm.ErrInvalidIssuance), err)
+
+		iss, err = svc.Request(context.Background(), request)
+		require.NoError(t, err)
+		require.Equal(t, didcomm.IssuanceIssued, iss.Status)
+
+		issued := <-received
+		require.Equal(t, didcomm.IssueCredentialType, issued.Type)
+		require.Equal(t, propose.ID, issued.ThreadID)
+		require.Len(t, issued.Attachments, 1)
+
+		vc, err := verifiable.ParseCredential(issued.Attachments[0].Data.JSON, verifiable.WithDisabledProofCheck(),
+			verifiable.WithJSONLDDocumentLoader(testutil.DocumentLoader(t)))
+		require.NoError(t, err)
+		require.Contains(t, vc.Types, didcomm.AuthorizedHandlerCredentialType)
+		require.JSONEq(t, string(iss.Credential), string(issued.Attachments[0].Data.JSON))
+
+		issuances, err = svc.Issuances(context.Background(), didcomm.IssuanceProposed)
+		require.NoError(t, err)
+		require.Empty(t, issuances)
+
+		issuances, err = svc.Issuances(context.Background(), "")
+		require.NoError(t, err)
+		require.Len(t, issuances, 1)
+	})
+
+	t.Run("Unknown issuances", func(t *testing.T) {
+		_, err := svc.ApproveIssuance(context.Background(), "unknown")
+		require.True(t, errors.Is(err, didcomm.ErrIssuanceNotFound), err)
+
+		_, err = svc.Request(context.Background(), &didcomm.Message{ID: "1", From: approverDID, ThreadID: "unknown"})
+		require.True(t, errors.Is(err, didcomm.ErrInvalidMessage), err)
+	})
+}
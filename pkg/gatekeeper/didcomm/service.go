@@ -0,0 +1,493 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package didcomm coordinates the approvals of release tickets over DIDComm v2, as an alternative to the approvers
+// calling the REST API: the approval requests of new tickets are pushed to the agents of the approvers as encrypted
+// messages, and the approvers authorize or reject the tickets with signed messages. New participants are onboarded
+// with out-of-band invitations, establishing the connections their agents are reached with, and the handlers approved
+// by an admin are issued authorized handler credentials with the WACI issuance protocol.
+package didcomm
+
+//nolint: lll
+//go:generate mockgen -destination gomocks_test.go -package didcomm_test -source=service.go -mock_names httpClient=MockHTTPClient,policyService=MockPolicyService,encrypter=MockEncrypter,configService=MockConfigService,vcIssuer=MockVCIssuer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hyperledger/aries-framework-go/pkg/common/log"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/did"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/jose"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/signature/verifier"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
+	vdrapi "github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdr"
+	"github.com/hyperledger/aries-framework-go/spi/storage"
+
+	"github.com/trustbloc/ace/pkg/gatekeeper/config"
+	"github.com/trustbloc/ace/pkg/gatekeeper/policy"
+	"github.com/trustbloc/ace/pkg/gatekeeper/release/ticket"
+	"github.com/trustbloc/ace/pkg/storage/index"
+)
+
+const (
+	// ApprovalRequestType is the type of the messages requesting the approvers to decide on a new ticket.
+	ApprovalRequestType = "https://trustbloc.dev/ace/2.0/approval-request"
+	// AuthorizeType is the type of the messages of approvers authorizing a ticket.
+	AuthorizeType = "https://trustbloc.dev/ace/2.0/authorize"
+	// RejectType is the type of the messages of approvers rejecting a ticket.
+	RejectType = "https://trustbloc.dev/ace/2.0/reject"
+
+	// EncryptedMediaType is the media type of the anonymously encrypted messages sent to the approvers.
+	EncryptedMediaType = "application/didcomm-encrypted+json"
+	// SignedMediaType is the media type of the signed messages received from the approvers, in the JWS compact
+	// serialization.
+	SignedMediaType = "application/didcomm-signed+json"
+	// PlaintextMediaType is the media type of the messages before they are encrypted or signed.
+	PlaintextMediaType = "application/didcomm-plain+json"
+
+	// DefaultTimeout is the default time to wait for a message to be delivered.
+	DefaultTimeout = 10 * time.Second
+	// DefaultMaxAge is the default maximum age of the messages received, from their created_time.
+	DefaultMaxAge = 5 * time.Minute
+
+	serviceType = "DIDCommMessaging"
+	// clockSkew is the time the messages received can be created ahead of the clock of Gatekeeper.
+	clockSkew = time.Minute
+)
+
+var logger = log.New("didcomm-svc")
+
+var (
+	// ErrNoService is returned when sending a message to a DID without a DIDCommMessaging service.
+	ErrNoService = errors.New("no DIDCommMessaging service in the DID document")
+	// ErrInvalidSignature is returned when receiving a message that is not signed with an authentication key of its
+	// sender.
+	ErrInvalidSignature = errors.New("invalid signature of the DIDComm message")
+	// ErrInvalidMessage is returned when receiving a message that is malformed or no longer valid.
+	ErrInvalidMessage = errors.New("invalid DIDComm message")
+)
+
+type httpClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+type vdrRegistry interface {
+	Resolve(DID string, opts ...vdrapi.DIDMethodOption) (*did.DocResolution, error)
+}
+
+type policyService interface {
+	Get(ctx context.Context, policyID string) (*policy.Policy, error)
+}
+
+type encrypter interface {
+	EncryptAs(ctx context.Context, recipientDID string, data []byte, mediaType,
+		contentType string) (json.RawMessage, error)
+}
+
+type configService interface {
+	Get() (*config.Config, error)
+}
+
+type vcIssuer interface {
+	IssueCredential(ctx context.Context, cred []byte) (*verifiable.Credential, error)
+}
+
+// Message is a DIDComm v2 plaintext message.
+type Message struct {
+	ID   string   `json:"id"`
+	Type string   `json:"type"`
+	From string   `json:"from,omitempty"`
+	To   []string `json:"to,omitempty"`
+	// ThreadID, if set, is the ID of the message the message replies to, e.g. an approval request, and
+	// ParentThreadID the ID of the invitation a connect message accepts.
+	ThreadID       string `json:"thid,omitempty"`
+	ParentThreadID string `json:"pthid,omitempty"`
+	// CreatedTime and ExpiresTime are the times the message was created and expires at, in seconds since the epoch.
+	CreatedTime int64           `json:"created_time,omitempty"`
+	ExpiresTime int64           `json:"expires_time,omitempty"`
+	Body        json.RawMessage `json:"body"`
+	Attachments []*Attachment   `json:"attachments,omitempty"`
+	// Signature is the signature the message was received with, base64url encoded, recorded as the reference of the
+	// decision.
+	Signature string `json:"-"`
+}
+
+// Attachment is an attachment of a message, such as the credential of an issue-credential message.
+type Attachment struct {
+	ID        string         `json:"id"`
+	MediaType string         `json:"media_type,omitempty"`
+	Format    string         `json:"format,omitempty"`
+	Data      AttachmentData `json:"data"`
+}
+
+// AttachmentData is the data of an attachment, embedded as JSON.
+type AttachmentData struct {
+	JSON json.RawMessage `json:"json"`
+}
+
+// ApprovalRequest is the body of the messages requesting the approvers to decide on a new ticket.
+type ApprovalRequest struct {
+	TicketID string `json:"ticket_id"`
+	// DID is the DID of the protected data the ticket releases.
+	DID      string `json:"did"`
+	PolicyID string `json:"policy_id"`
+	// RequestingParty is the DID of the handler that requested the release, and Justification the reason they gave.
+	RequestingParty string    `json:"requesting_party,omitempty"`
+	Justification   string    `json:"justification,omitempty"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// Decision is the body of the messages authorizing or rejecting a ticket.
+type Decision struct {
+	TicketID string `json:"ticket_id"`
+	// Comment of the approver on their decision, stored on the ticket.
+	Comment string `json:"comment,omitempty"`
+	// OnBehalfOf is the DID of the approver a delegate decides on behalf of, as in the REST API.
+	OnBehalfOf string `json:"on_behalf_of,omitempty"`
+}
+
+// Config defines dependencies for Service.
+type Config struct {
+	// StoreProvider stores the invitations, the connections of the participants and the credential issuances.
+	StoreProvider storage.Provider
+	HTTPClient    httpClient
+	// VDR resolves the DID documents of the approvers, with the DIDComm services of their agents and the keys their
+	// messages are signed with.
+	VDR vdrRegistry
+	// Encrypter encrypts the messages to the key agreement keys of the approvers.
+	Encrypter encrypter
+	// PolicyService gets the approvers of the policies of the tickets.
+	PolicyService policyService
+	// Timeout is the time to wait for a message to be delivered. It defaults to DefaultTimeout.
+	Timeout time.Duration
+	// MaxAge is the maximum age of the messages received. It defaults to DefaultMaxAge.
+	MaxAge time.Duration
+	// ConfigService gets the DID of Gatekeeper the invitations are sent from.
+	ConfigService configService
+	// Endpoint, if set, is the public URL of the endpoint the messages of the participants are received on, such as
+	// the messages accepting invitations. Participants cannot be invited if it is not set.
+	Endpoint string
+	// InvitationTTL is the time invitations can be accepted for. It defaults to DefaultInvitationTTL.
+	InvitationTTL time.Duration
+	// VCIssuer signs the authorized handler credentials issued to the approved handlers.
+	VCIssuer vcIssuer
+}
+
+// Service sends and receives the DIDComm messages coordinating the approvals of the tickets.
+type Service struct {
+	invitationStore storage.Store
+	connectionStore storage.Store
+	issuanceStore   storage.Store
+	httpClient      httpClient
+	vdr             vdrRegistry
+	encrypter       encrypter
+	policyService   policyService
+	configService   configService
+	issuer          vcIssuer
+	endpoint        string
+	timeout         time.Duration
+	maxAge          time.Duration
+	invitationTTL   time.Duration
+}
+
+// NewService returns a new instance of Service.
+func NewService(config *Config) (*Service, error) {
+	invitationStore, err := config.StoreProvider.OpenStore(invitationStoreName)
+	if err != nil {
+		return nil, fmt.Errorf("open invitation store: %w", err)
+	}
+
+	connectionStore, err := index.OpenStore(config.StoreProvider, &index.Declaration{
+		Store:    connectionStoreName,
+		TagNames: []string{didIndex},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("open connection store: %w", err)
+	}
+
+	issuanceStore, err := index.OpenStore(config.StoreProvider, &index.Declaration{
+		Store:    issuanceStoreName,
+		TagNames: []string{statusIndex},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("open issuance store: %w", err)
+	}
+
+	s := &Service{
+		invitationStore: invitationStore,
+		connectionStore: connectionStore,
+		issuanceStore:   issuanceStore,
+		httpClient:      config.HTTPClient,
+		vdr:             config.VDR,
+		encrypter:       config.Encrypter,
+		policyService:   config.PolicyService,
+		configService:   config.ConfigService,
+		issuer:          config.VCIssuer,
+		endpoint:        config.Endpoint,
+		timeout:         config.Timeout,
+		maxAge:          config.MaxAge,
+		invitationTTL:   config.InvitationTTL,
+	}
+
+	if s.timeout <= 0 {
+		s.timeout = DefaultTimeout
+	}
+
+	if s.maxAge <= 0 {
+		s.maxAge = DefaultMaxAge
+	}
+
+	if s.invitationTTL <= 0 {
+		s.invitationTTL = DefaultInvitationTTL
+	}
+
+	return s, nil
+}
+
+// TicketUpdated sends the approval request of a new ticket to the agents of the approvers of its policy. Approvers
+// without a DIDCommMessaging service are skipped and failures are logged: the approvers can always decide with the
+// REST API.
+func (s *Service) TicketUpdated(ctx context.Context, t *ticket.Ticket) {
+	if len(t.History) == 0 || t.History[len(t.History)-1].Action != ticket.ReleaseAction || t.PolicyID == "" {
+		return
+	}
+
+	p, err := s.policyService.Get(ctx, t.PolicyID)
+	if err != nil {
+		logger.Warnf("Failed to get the approvers of ticket %s: %s", t.ID, err)
+
+		return
+	}
+
+	body, err := json.Marshal(&ApprovalRequest{
+		TicketID:        t.ID,
+		DID:             t.DID,
+		PolicyID:        t.PolicyID,
+		RequestingParty: t.RequestedBy,
+		Justification:   t.Justification,
+		CreatedAt:       t.CreatedAt,
+	})
+	if err != nil {
+		logger.Errorf("Failed to marshal the approval request of ticket %s: %s", t.ID, err)
+
+		return
+	}
+
+	for _, approver := range p.Approvers {
+		err = s.Send(ctx, approver, &Message{ID: uuid.New().String(), Type: ApprovalRequestType, Body: body})
+
+		switch {
+		case errors.Is(err, ErrNoService):
+			logger.Debugf("Approval request of ticket %s not sent to %s: %s", t.ID, approver, err)
+		case err != nil:
+			logger.Warnf("Failed to send the approval request of ticket %s to %s: %s", t.ID, approver, err)
+		}
+	}
+}
+
+// Send encrypts the message to the key agreement keys of the recipient and posts it to the endpoint of their
+// DIDCommMessaging service, or else to the endpoint of their connection. The recipient and the creation time of the
+// message are set if they are not.
+func (s *Service) Send(ctx context.Context, to string, msg *Message) error {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	docResolution, err := s.vdr.Resolve(to)
+	if err != nil {
+		return fmt.Errorf("resolve recipient: %w", err)
+	}
+
+	uri, err := serviceEndpoint(docResolution.DIDDocument)
+	if errors.Is(err, ErrNoService) {
+		uri, err = s.connectionEndpoint(ctx, to)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if len(msg.To) == 0 {
+		msg.To = []string{to}
+	}
+
+	if msg.CreatedTime == 0 {
+		msg.CreatedTime = time.Now().Unix()
+	}
+
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("marshal message: %w", err)
+	}
+
+	envelope, err := s.encrypter.EncryptAs(ctx, to, payload, EncryptedMediaType, PlaintextMediaType)
+	if err != nil {
+		return fmt.Errorf("encrypt message: %w", err)
+	}
+
+	return s.post(ctx, uri, envelope)
+}
+
+// serviceEndpoint returns the URI of the first DIDCommMessaging service of the DID document.
+func serviceEndpoint(doc *did.Doc) (string, error) {
+	for i := range doc.Service {
+		if doc.Service[i].Type != serviceType {
+			continue
+		}
+
+		uri, err := doc.Service[i].ServiceEndpoint.URI()
+		if err != nil {
+			return "", fmt.Errorf("service endpoint: %w", err)
+		}
+
+		return uri, nil
+	}
+
+	return "", ErrNoService
+}
+
+func (s *Service) post(ctx context.Context, targetURL string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, targetURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", EncryptedMediaType)
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("send request: %w", err)
+	}
+
+	defer resp.Body.Close() //nolint:errcheck
+
+	_, _ = io.Copy(io.Discard, resp.Body) //nolint:errcheck
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("unexpected status %d from %s", resp.StatusCode, targetURL)
+	}
+
+	return nil
+}
+
+// Receive verifies a signed message, in the JWS compact serialization, and returns it. The message must be signed
+// with an authentication key of its sender, and created within the maximum age of the messages.
+func (s *Service) Receive(_ context.Context, signed []byte) (*Message, error) {
+	serialized := strings.TrimSpace(string(signed))
+
+	jws, err := jose.ParseJWS(serialized, s.signatureVerifier())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidSignature, err)
+	}
+
+	if typ, ok := jws.ProtectedHeaders.Type(); ok && typ != SignedMediaType {
+		return nil, fmt.Errorf("%w: unexpected type %s", ErrInvalidMessage, typ)
+	}
+
+	var msg Message
+
+	if err = json.Unmarshal(jws.Payload, &msg); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidMessage, err)
+	}
+
+	if err = s.checkMessage(&msg, time.Now()); err != nil {
+		return nil, err
+	}
+
+	msg.Signature = serialized[strings.LastIndex(serialized, ".")+1:]
+
+	return &msg, nil
+}
+
+func (s *Service) checkMessage(msg *Message, now time.Time) error {
+	switch {
+	case msg.ID == "" || msg.Type == "":
+		return fmt.Errorf("%w: missing id or type", ErrInvalidMessage)
+	case msg.CreatedTime == 0:
+		return fmt.Errorf("%w: missing created_time", ErrInvalidMessage)
+	case time.Unix(msg.CreatedTime, 0).After(now.Add(clockSkew)):
+		return fmt.Errorf("%w: created in the future", ErrInvalidMessage)
+	case time.Unix(msg.CreatedTime, 0).Before(now.Add(-s.maxAge)):
+		return fmt.Errorf("%w: created more than %s ago", ErrInvalidMessage, s.maxAge)
+	case msg.ExpiresTime != 0 && time.Unix(msg.ExpiresTime, 0).Before(now):
+		return fmt.Errorf("%w: expired", ErrInvalidMessage)
+	}
+
+	return nil
+}
+
+// signatureVerifier returns a verifier of the signatures of the messages made with an authentication key of the DID
+// of their sender.
+func (s *Service) signatureVerifier() jose.SignatureVerifier { //nolint:ireturn
+	verifiers := []verifier.SignatureVerifier{
+		verifier.NewEd25519SignatureVerifier(),
+		verifier.NewECDSAES256SignatureVerifier(),
+		verifier.NewECDSAES384SignatureVerifier(),
+		verifier.NewECDSASecp256k1SignatureVerifier(),
+	}
+
+	algVerifiers := make([]jose.AlgSignatureVerifier, len(verifiers))
+
+	for i := range verifiers {
+		v := verifiers[i]
+
+		algVerifiers[i] = jose.AlgSignatureVerifier{
+			Alg: v.Algorithm(),
+			Verifier: jose.SignatureVerifierFunc(func(h jose.Headers, payload, signingInput, signature []byte) error {
+				key, err := s.senderKey(h, payload)
+				if err != nil {
+					return err
+				}
+
+				return v.Verify(key, signingInput, signature)
+			}),
+		}
+	}
+
+	return jose.NewCompositeAlgSigVerifier(algVerifiers[0], algVerifiers[1:]...)
+}
+
+// senderKey returns the authentication key of the sender of the message the JWS is signed with.
+func (s *Service) senderKey(h jose.Headers, payload []byte) (*verifier.PublicKey, error) {
+	var msg struct {
+		From string `json:"from"`
+	}
+
+	if err := json.Unmarshal(payload, &msg); err != nil {
+		return nil, fmt.Errorf("parse message: %w", err)
+	}
+
+	kid, _ := h.KeyID()
+
+	if msg.From == "" || !strings.HasPrefix(kid, msg.From+"#") {
+		return nil, fmt.Errorf("key %q is not a key of the sender %q", kid, msg.From)
+	}
+
+	docResolution, err := s.vdr.Resolve(msg.From)
+	if err != nil {
+		return nil, fmt.Errorf("resolve sender: %w", err)
+	}
+
+	doc := docResolution.DIDDocument
+
+	for _, verifications := range doc.VerificationMethods(did.Authentication) {
+		for _, verification := range verifications {
+			vm := verification.VerificationMethod
+			if vm.ID == kid || doc.ID+vm.ID == kid {
+				return &verifier.PublicKey{Type: vm.Type, Value: vm.Value, JWK: vm.JSONWebKey()}, nil
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("authentication key %s of the sender not found", kid)
+}
@@ -0,0 +1,294 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package didcomm_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/hyperledger/aries-framework-go/component/storageutil/mem"
+	"github.com/hyperledger/aries-framework-go/pkg/common/model"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/did"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/jose"
+	vdrapi "github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdr"
+	vdrmock "github.com/hyperledger/aries-framework-go/pkg/mock/vdr"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/ace/pkg/gatekeeper/didcomm"
+	"github.com/trustbloc/ace/pkg/gatekeeper/policy"
+	"github.com/trustbloc/ace/pkg/gatekeeper/release/ticket"
+)
+
+const approverDID = "did:example:approver"
+
+func TestService_TicketUpdated(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	received := make(chan []byte, 1)
+
+	agent := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		require.Equal(t, didcomm.EncryptedMediaType, r.Header.Get("Content-Type"))
+
+		b, e := io.ReadAll(r.Body)
+		require.NoError(t, e)
+
+		received <- b
+
+		rw.WriteHeader(http.StatusAccepted)
+	}))
+	defer agent.Close()
+
+	policyService := NewMockPolicyService(ctrl)
+	policyService.EXPECT().Get(gomock.Any(), "policy").
+		Return(&policy.Policy{Approvers: []string{approverDID, "did:example:no-agent"}}, nil)
+
+	var msg didcomm.Message
+
+	encrypter := NewMockEncrypter(ctrl)
+	encrypter.EXPECT().EncryptAs(gomock.Any(), approverDID, gomock.Any(), didcomm.EncryptedMediaType,
+		didcomm.PlaintextMediaType).DoAndReturn(
+		func(_ context.Context, _ string, data []byte, _, _ string) (json.RawMessage, error) {
+			require.NoError(t, json.Unmarshal(data, &msg))
+
+			return json.RawMessage(`{"protected":"jwe"}`), nil
+		})
+
+	resolve := func(id string, _ ...vdrapi.DIDMethodOption) (*did.DocResolution, error) {
+		if id != approverDID {
+			return &did.DocResolution{DIDDocument: &did.Doc{ID: id}}, nil
+		}
+
+		return &did.DocResolution{DIDDocument: approverDoc(nil, agent.URL)}, nil
+	}
+
+	svc := newService(t, &didcomm.Config{
+		HTTPClient:    http.DefaultClient,
+		VDR:           &vdrmock.MockVDRegistry{ResolveFunc: resolve},
+		Encrypter:     encrypter,
+		PolicyService: policyService,
+	})
+
+	// only the new tickets are sent
+	svc.TicketUpdated(context.Background(), &ticket.Ticket{ID: "ticket", PolicyID: "policy",
+		History: []*ticket.Event{{Action: ticket.ApproveAction}}})
+
+	svc.TicketUpdated(context.Background(), &ticket.Ticket{
+		ID:            "ticket",
+		DID:           "did:example:protected",
+		PolicyID:      "policy",
+		RequestedBy:   "did:example:handler",
+		Justification: "fraud investigation",
+		History:       []*ticket.Event{{Action: ticket.ReleaseAction}},
+	})
+
+	require.JSONEq(t, `{"protected":"jwe"}`, string(<-received))
+	require.Equal(t, didcomm.ApprovalRequestType, msg.Type)
+	require.Equal(t, []string{approverDID}, msg.To)
+	require.NotEmpty(t, msg.ID)
+	require.NotZero(t, msg.CreatedTime)
+
+	var req didcomm.ApprovalRequest
+
+	require.NoError(t, json.Unmarshal(msg.Body, &req))
+	require.Equal(t, "ticket", req.TicketID)
+	require.Equal(t, "did:example:handler", req.RequestingParty)
+	require.Equal(t, "fraud investigation", req.Justification)
+}
+
+func TestService_Send(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	t.Run("No DIDCommMessaging service", func(t *testing.T) {
+		svc := newService(t, &didcomm.Config{
+			VDR: &vdrmock.MockVDRegistry{ResolveValue: &did.Doc{ID: approverDID}},
+		})
+
+		err := svc.Send(context.Background(), approverDID, &didcomm.Message{})
+		require.True(t, errors.Is(err, didcomm.ErrNoService))
+	})
+
+	t.Run("Unexpected status", func(t *testing.T) {
+		agent := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			rw.WriteHeader(http.StatusBadRequest)
+		}))
+		defer agent.Close()
+
+		encrypter := NewMockEncrypter(ctrl)
+		encrypter.EXPECT().EncryptAs(gomock.Any(), approverDID, gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(json.RawMessage(`{}`), nil)
+
+		svc := newService(t, &didcomm.Config{
+			HTTPClient: http.DefaultClient,
+			VDR:        &vdrmock.MockVDRegistry{ResolveValue: approverDoc(nil, agent.URL)},
+			Encrypter:  encrypter,
+		})
+
+		err := svc.Send(context.Background(), approverDID, &didcomm.Message{})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "unexpected status 400")
+	})
+
+	t.Run("Fail to encrypt", func(t *testing.T) {
+		encrypter := NewMockEncrypter(ctrl)
+		encrypter.EXPECT().EncryptAs(gomock.Any(), approverDID, gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(nil, errors.New("no key agreement key"))
+
+		svc := newService(t, &didcomm.Config{
+			VDR:       &vdrmock.MockVDRegistry{ResolveValue: approverDoc(nil, "https://agent.example.com")},
+			Encrypter: encrypter,
+		})
+
+		err := svc.Send(context.Background(), approverDID, &didcomm.Message{})
+		require.EqualError(t, err, "encrypt message: no key agreement key")
+	})
+}
+
+func TestService_Receive(t *testing.T) {
+	pubKey, privKey, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+
+	svc := newService(t, &didcomm.Config{
+		VDR: &vdrmock.MockVDRegistry{ResolveValue: approverDoc(pubKey, "")},
+	})
+
+	decision := json.RawMessage(`{"ticket_id":"ticket","comment":"ok"}`)
+
+	t.Run("Success", func(t *testing.T) {
+		signed := sign(t, privKey, approverDID+"#key-1", &didcomm.Message{
+			ID:          "1",
+			Type:        didcomm.AuthorizeType,
+			From:        approverDID,
+			CreatedTime: time.Now().Unix(),
+			Body:        decision,
+		})
+
+		msg, err := svc.Receive(context.Background(), []byte(signed))
+		require.NoError(t, err)
+		require.Equal(t, didcomm.AuthorizeType, msg.Type)
+		require.Equal(t, approverDID, msg.From)
+		require.JSONEq(t, string(decision), string(msg.Body))
+		require.NotEmpty(t, msg.Signature)
+
+		ctx := didcomm.WithMessage(context.Background(), msg)
+
+		sender, ok := didcomm.Sender(ctx)
+		require.True(t, ok)
+		require.Equal(t, approverDID, sender)
+
+		signature, ok := didcomm.Signature(ctx)
+		require.True(t, ok)
+		require.Equal(t, msg.Signature, signature)
+	})
+
+	t.Run("Invalid signatures", func(t *testing.T) {
+		_, otherKey, err := ed25519.GenerateKey(nil)
+		require.NoError(t, err)
+
+		msg := &didcomm.Message{ID: "1", Type: didcomm.AuthorizeType, From: approverDID,
+			CreatedTime: time.Now().Unix(), Body: decision}
+
+		for _, signed := range []string{
+			sign(t, otherKey, approverDID+"#key-1", msg),
+			sign(t, privKey, "did:example:other#key-1", msg),
+			sign(t, privKey, approverDID+"#key-2", msg),
+			"not a JWS",
+		} {
+			_, err = svc.Receive(context.Background(), []byte(signed))
+			require.True(t, errors.Is(err, didcomm.ErrInvalidSignature), err)
+		}
+	})
+
+	t.Run("Invalid messages", func(t *testing.T) {
+		now := time.Now()
+
+		for _, msg := range []*didcomm.Message{
+			{Type: didcomm.AuthorizeType, CreatedTime: now.Unix()},
+			{ID: "1", Type: didcomm.AuthorizeType},
+			{ID: "1", Type: didcomm.AuthorizeType, CreatedTime: now.Add(-time.Hour).Unix()},
+			{ID: "1", Type: didcomm.AuthorizeType, CreatedTime: now.Add(time.Hour).Unix()},
+			{ID: "1", Type: didcomm.AuthorizeType, CreatedTime: now.Unix(), ExpiresTime: now.Add(-time.Second).Unix()},
+		} {
+			msg.From = approverDID
+
+			_, err = svc.Receive(context.Background(), []byte(sign(t, privKey, approverDID+"#key-1", msg)))
+			require.True(t, errors.Is(err, didcomm.ErrInvalidMessage), err)
+		}
+	})
+}
+
+// approverDoc returns the DID document of the approver, with an authentication key if pubKey is set and a
+// DIDCommMessaging service if endpoint is set.
+func approverDoc(pubKey ed25519.PublicKey, endpoint string) *did.Doc {
+	doc := &did.Doc{ID: approverDID}
+
+	if pubKey != nil {
+		vm := did.NewVerificationMethodFromBytes(approverDID+"#key-1", "Ed25519VerificationKey2018", approverDID, pubKey)
+		doc.Authentication = append(doc.Authentication, *did.NewEmbeddedVerification(vm, did.Authentication))
+	}
+
+	if endpoint != "" {
+		doc.Service = append(doc.Service, did.Service{
+			ID:              approverDID + "#didcomm",
+			Type:            "DIDCommMessaging",
+			ServiceEndpoint: model.NewDIDCommV2Endpoint([]model.DIDCommV2Endpoint{{URI: endpoint}}),
+		})
+	}
+
+	return doc
+}
+
+func newService(t *testing.T, config *didcomm.Config) *didcomm.Service {
+	t.Helper()
+
+	if config.StoreProvider == nil {
+		config.StoreProvider = mem.NewProvider()
+	}
+
+	svc, err := didcomm.NewService(config)
+	require.NoError(t, err)
+
+	return svc
+}
+
+func sign(t *testing.T, key ed25519.PrivateKey, kid string, msg *didcomm.Message) string {
+	t.Helper()
+
+	payload, err := json.Marshal(msg)
+	require.NoError(t, err)
+
+	jws, err := jose.NewJWS(jose.Headers{jose.HeaderType: didcomm.SignedMediaType}, nil, payload,
+		&signer{key: key, kid: kid})
+	require.NoError(t, err)
+
+	signed, err := jws.SerializeCompact(false)
+	require.NoError(t, err)
+
+	return signed
+}
+
+type signer struct {
+	key ed25519.PrivateKey
+	kid string
+}
+
+func (s *signer) Sign(data []byte) ([]byte, error) {
+	return ed25519.Sign(s.key, data), nil
+}
+
+func (s *signer) Headers() jose.Headers {
+	return jose.Headers{jose.HeaderAlgorithm: "EdDSA", jose.HeaderKeyID: s.kid}
+}
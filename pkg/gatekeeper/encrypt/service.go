@@ -0,0 +1,161 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package encrypt encrypts extracted data as JWEs to the key agreement keys of the handlers it is released to, so that
+// only they can read it.
+package encrypt
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	cryptoapi "github.com/hyperledger/aries-framework-go/pkg/crypto"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/did"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/jose"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/jose/jwk/jwksupport"
+	vdrapi "github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdr"
+)
+
+const (
+	// MediaType is the type of the JWEs, in their JSON serialization.
+	MediaType = "JOSE+JSON"
+	// ContentType is the content type of the data encrypted in the JWEs.
+	ContentType = "text/plain"
+
+	x25519KeyAgreementKey2019 = "X25519KeyAgreementKey2019"
+	x25519Curve               = "X25519"
+	okpKeyType                = "OKP"
+	ecKeyType                 = "EC"
+)
+
+// ErrNoKeyAgreementKey is returned when encrypting to a DID without a key agreement key data can be encrypted to.
+var ErrNoKeyAgreementKey = errors.New("no X25519 or NIST P key agreement key in the DID document")
+
+var errUnsupportedKey = errors.New("unsupported key")
+
+type vdrRegistry interface {
+	Resolve(DID string, opts ...vdrapi.DIDMethodOption) (*did.DocResolution, error)
+}
+
+// Config defines dependencies for Service.
+type Config struct {
+	// VDR resolves the DID documents of the recipients, with their key agreement keys.
+	VDR vdrRegistry
+	// Crypto wraps the content encryption keys to the keys of the recipients.
+	Crypto cryptoapi.Crypto
+}
+
+// Service encrypts data to the key agreement keys of DIDs.
+type Service struct {
+	vdr    vdrRegistry
+	crypto cryptoapi.Crypto
+}
+
+// NewService returns a new instance of Service.
+func NewService(config *Config) *Service {
+	return &Service{vdr: config.VDR, crypto: config.Crypto}
+}
+
+// Encrypt encrypts the data as an anonymous JWE, in its JSON serialization, to the key agreement keys of the
+// recipient's DID document. If the keys are of several curves, the data is encrypted to the keys of the curve of the
+// first one.
+func (s *Service) Encrypt(ctx context.Context, recipientDID string, data []byte) (json.RawMessage, error) {
+	return s.EncryptAs(ctx, recipientDID, data, MediaType, ContentType)
+}
+
+// EncryptAs encrypts the data like Encrypt, with the given media type of the JWE and content type of the data, e.g. for
+// the JWE to be sent as a DIDComm v2 encrypted message.
+func (s *Service) EncryptAs(_ context.Context, recipientDID string, data []byte,
+	mediaType, contentType string) (json.RawMessage, error) {
+	docResolution, err := s.vdr.Resolve(recipientDID)
+	if err != nil {
+		return nil, fmt.Errorf("resolve recipient: %w", err)
+	}
+
+	keys, err := recipientKeys(docResolution.DIDDocument)
+	if err != nil {
+		return nil, fmt.Errorf("recipient %s: %w", recipientDID, err)
+	}
+
+	encrypter, err := jose.NewJWEEncrypt(jose.A256GCM, mediaType, contentType, "", nil, keys, s.crypto)
+	if err != nil {
+		return nil, fmt.Errorf("create JWE encrypter: %w", err)
+	}
+
+	jwe, err := encrypter.Encrypt(data)
+	if err != nil {
+		return nil, fmt.Errorf("encrypt data: %w", err)
+	}
+
+	serialized, err := jwe.FullSerialize(json.Marshal)
+	if err != nil {
+		return nil, fmt.Errorf("serialize JWE: %w", err)
+	}
+
+	return json.RawMessage(serialized), nil
+}
+
+// recipientKeys returns the key agreement keys of the DID document of the curve of the first supported one.
+func recipientKeys(doc *did.Doc) ([]*cryptoapi.PublicKey, error) {
+	var keys []*cryptoapi.PublicKey
+
+	for i := range doc.KeyAgreement {
+		vm := &doc.KeyAgreement[i].VerificationMethod
+
+		key, err := publicKey(vm)
+		if errors.Is(err, errUnsupportedKey) {
+			continue
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("key agreement key %s: %w", vm.ID, err)
+		}
+
+		if len(keys) > 0 && key.Curve != keys[0].Curve {
+			continue
+		}
+
+		key.KID = vm.ID
+		if strings.HasPrefix(key.KID, "#") {
+			key.KID = doc.ID + key.KID
+		}
+
+		keys = append(keys, key)
+	}
+
+	if len(keys) == 0 {
+		return nil, ErrNoKeyAgreementKey
+	}
+
+	return keys, nil
+}
+
+// publicKey returns the public key of the verification method, or errUnsupportedKey if data cannot be encrypted to
+// it.
+func publicKey(vm *did.VerificationMethod) (*cryptoapi.PublicKey, error) {
+	j := vm.JSONWebKey()
+
+	switch {
+	case j == nil && vm.Type == x25519KeyAgreementKey2019:
+		return &cryptoapi.PublicKey{X: vm.Value, Curve: x25519Curve, Type: okpKeyType}, nil
+	case j == nil:
+		return nil, errUnsupportedKey
+	case j.Kty == okpKeyType && j.Crv == x25519Curve:
+		x, ok := j.Key.([]byte)
+		if !ok {
+			return nil, fmt.Errorf("unexpected X25519 key %T", j.Key)
+		}
+
+		return &cryptoapi.PublicKey{X: x, Curve: x25519Curve, Type: okpKeyType}, nil
+	case j.Kty == ecKeyType:
+		return jwksupport.PublicKeyFromJWK(j)
+	default:
+		return nil, errUnsupportedKey
+	}
+}
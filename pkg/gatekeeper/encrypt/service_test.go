@@ -0,0 +1,162 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package encrypt_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/hyperledger/aries-framework-go/component/storageutil/mem"
+	cryptoapi "github.com/hyperledger/aries-framework-go/pkg/crypto"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/did"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/jose"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/jose/kid/resolver"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/util/jwkkid"
+	"github.com/hyperledger/aries-framework-go/pkg/framework/aries"
+	"github.com/hyperledger/aries-framework-go/pkg/kms"
+	vdrmock "github.com/hyperledger/aries-framework-go/pkg/mock/vdr"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/ace/pkg/gatekeeper/encrypt"
+)
+
+const handlerDID = "did:example:handler"
+
+func TestService_Encrypt(t *testing.T) {
+	a, err := aries.New(aries.WithStoreProvider(mem.NewProvider()))
+	require.NoError(t, err)
+
+	ctx, err := a.Context()
+	require.NoError(t, err)
+
+	signingKey, _, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+
+	t.Run("X25519 key", func(t *testing.T) {
+		_, b, err := ctx.KMS().CreateAndExportPubKeyBytes(kms.X25519ECDHKWType)
+		require.NoError(t, err)
+
+		var pubKey cryptoapi.PublicKey
+
+		require.NoError(t, json.Unmarshal(b, &pubKey))
+
+		vdr := &vdrmock.MockVDRegistry{ResolveValue: newDoc(
+			did.NewVerificationMethodFromBytes("#key-1", "X25519KeyAgreementKey2019", handlerDID, pubKey.X),
+		)}
+
+		jwe, err := encrypt.NewService(&encrypt.Config{VDR: vdr, Crypto: ctx.Crypto()}).
+			Encrypt(context.Background(), handlerDID, []byte("sensitive data"))
+		require.NoError(t, err)
+		require.Equal(t, "sensitive data", decrypt(t, ctx.KMS(), ctx.Crypto(), vdr, jwe))
+	})
+
+	t.Run("NIST P-256 key", func(t *testing.T) {
+		_, b, err := ctx.KMS().CreateAndExportPubKeyBytes(kms.NISTP256ECDHKWType)
+		require.NoError(t, err)
+
+		j, err := jwkkid.BuildJWK(b, kms.NISTP256ECDHKWType)
+		require.NoError(t, err)
+
+		vm, err := did.NewVerificationMethodFromJWK(handlerDID+"#key-1", "JsonWebKey2020", handlerDID, j)
+		require.NoError(t, err)
+
+		_, x25519Key, err := ctx.KMS().CreateAndExportPubKeyBytes(kms.X25519ECDHKWType)
+		require.NoError(t, err)
+
+		// keys of other curves than the first supported one are ignored
+		vdr := &vdrmock.MockVDRegistry{ResolveValue: newDoc(
+			did.NewVerificationMethodFromBytes("#key-0", "Ed25519VerificationKey2018", handlerDID, signingKey),
+			vm,
+			did.NewVerificationMethodFromBytes("#key-2", "X25519KeyAgreementKey2019", handlerDID, x25519Key),
+		)}
+
+		jwe, err := encrypt.NewService(&encrypt.Config{VDR: vdr, Crypto: ctx.Crypto()}).
+			Encrypt(context.Background(), handlerDID, []byte("sensitive data"))
+		require.NoError(t, err)
+
+		// the resolver of the decrypter only finds the last key agreement key of the DID document
+		require.Equal(t, "sensitive data", decrypt(t, ctx.KMS(), ctx.Crypto(),
+			&vdrmock.MockVDRegistry{ResolveValue: newDoc(vm)}, jwe))
+
+		var serialized struct {
+			Recipients []json.RawMessage `json:"recipients"`
+		}
+
+		require.NoError(t, json.Unmarshal(jwe, &serialized))
+		require.Empty(t, serialized.Recipients)
+	})
+
+	t.Run("Media and content types", func(t *testing.T) {
+		_, b, err := ctx.KMS().CreateAndExportPubKeyBytes(kms.X25519ECDHKWType)
+		require.NoError(t, err)
+
+		var pubKey cryptoapi.PublicKey
+
+		require.NoError(t, json.Unmarshal(b, &pubKey))
+
+		vdr := &vdrmock.MockVDRegistry{ResolveValue: newDoc(
+			did.NewVerificationMethodFromBytes("#key-1", "X25519KeyAgreementKey2019", handlerDID, pubKey.X),
+		)}
+
+		serialized, err := encrypt.NewService(&encrypt.Config{VDR: vdr, Crypto: ctx.Crypto()}).EncryptAs(
+			context.Background(), handlerDID, []byte("{}"), "application/didcomm-encrypted+json",
+			"application/didcomm-plain+json")
+		require.NoError(t, err)
+
+		jwe, err := jose.Deserialize(string(serialized))
+		require.NoError(t, err)
+		require.Equal(t, "application/didcomm-encrypted+json", jwe.ProtectedHeaders[jose.HeaderType])
+		require.Equal(t, "application/didcomm-plain+json", jwe.ProtectedHeaders[jose.HeaderContentType])
+	})
+
+	t.Run("No key agreement key", func(t *testing.T) {
+		vdr := &vdrmock.MockVDRegistry{ResolveValue: newDoc(
+			did.NewVerificationMethodFromBytes("#key-0", "Ed25519VerificationKey2018", handlerDID, signingKey),
+		)}
+
+		_, err = encrypt.NewService(&encrypt.Config{VDR: vdr, Crypto: ctx.Crypto()}).
+			Encrypt(context.Background(), handlerDID, []byte("sensitive data"))
+		require.True(t, errors.Is(err, encrypt.ErrNoKeyAgreementKey))
+	})
+
+	t.Run("Fail to resolve recipient", func(t *testing.T) {
+		vdr := &vdrmock.MockVDRegistry{ResolveErr: errors.New("not found")}
+
+		_, err = encrypt.NewService(&encrypt.Config{VDR: vdr, Crypto: ctx.Crypto()}).
+			Encrypt(context.Background(), handlerDID, []byte("sensitive data"))
+		require.EqualError(t, err, "resolve recipient: not found")
+	})
+}
+
+func newDoc(keyAgreement ...*did.VerificationMethod) *did.Doc {
+	doc := &did.Doc{ID: handlerDID}
+
+	for _, vm := range keyAgreement {
+		doc.KeyAgreement = append(doc.KeyAgreement, *did.NewEmbeddedVerification(vm, did.KeyAgreement))
+	}
+
+	return doc
+}
+
+func decrypt(t *testing.T, k kms.KeyManager, c cryptoapi.Crypto, vdr *vdrmock.MockVDRegistry,
+	serialized json.RawMessage) string {
+	t.Helper()
+
+	jwe, err := jose.Deserialize(string(serialized))
+	require.NoError(t, err)
+	require.Equal(t, encrypt.MediaType, jwe.ProtectedHeaders[jose.HeaderType])
+	require.Equal(t, encrypt.ContentType, jwe.ProtectedHeaders[jose.HeaderContentType])
+
+	b, err := jose.NewJWEDecrypt([]resolver.KIDResolver{&resolver.DIDDocResolver{VDRRegistry: vdr}}, c, k).
+		Decrypt(jwe)
+	require.NoError(t, err)
+
+	return string(b)
+}
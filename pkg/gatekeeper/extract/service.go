@@ -41,12 +41,12 @@ func NewService(cshClient cshClient) *Service {
 }
 
 // Extract extracts protected data from access handle.
-func (s *Service) Extract(_ context.Context, queryID string) (string, error) {
+func (s *Service) Extract(ctx context.Context, queryID string) (string, error) {
 	refQuery := &cshclientmodels.RefQuery{Ref: &queryID}
 	refQuery.SetID(uuid.NewString())
 
 	extractions, err := s.cshClient.PostExtract(
-		operations.NewPostExtractParams().
+		operations.NewPostExtractParamsWithContext(ctx).
 			WithTimeout(requestTimeout).
 			WithRequest([]cshclientmodels.Query{refQuery}),
 	)
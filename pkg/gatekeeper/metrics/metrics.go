@@ -0,0 +1,140 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package metrics records the release decisions taken on the tickets as Prometheus metrics, broken down by policy, so
+// that unusual access patterns can be monitored.
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/trustbloc/ace/pkg/gatekeeper/release/ticket"
+)
+
+// Decision is a release decision recorded by the metrics.
+type Decision string
+
+const (
+	// TicketCreated is the request of a handler to release protected data.
+	TicketCreated Decision = "ticket_created"
+	// BreakGlass is the emergency access of a responder to protected data.
+	BreakGlass Decision = "break_glass"
+	// Approval is the approval of a ticket by an approver.
+	Approval Decision = "approval"
+	// Rejection is the rejection of a ticket by an approver.
+	Rejection Decision = "rejection"
+	// QuorumFailure is an attempt to collect the data of a ticket that does not have the approvals of enough
+	// approvers yet.
+	QuorumFailure Decision = "quorum_failure"
+	// Extraction is the extraction of released data by a handler.
+	Extraction Decision = "extraction"
+)
+
+const (
+	namespace = "gatekeeper"
+
+	// the delays of the decisions range from seconds, for break-glass accesses, to days, for approvals
+	delayBucketStart  = 1
+	delayBucketFactor = 4
+	delayBucketCount  = 10
+)
+
+// Decisions records the release decisions.
+type Decisions struct {
+	decisions *prometheus.CounterVec
+	delays    *prometheus.HistogramVec
+}
+
+// NewDecisions returns a new instance of Decisions, with its metrics registered with the registerer. Metrics already
+// registered, by other instances, are shared with them.
+func NewDecisions(registerer prometheus.Registerer) (*Decisions, error) {
+	decisions, err := register(registerer, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "release_decisions_total",
+		Help:      "Number of release decisions taken on tickets, by policy and decision.",
+	}, []string{"policy_id", "decision"}))
+	if err != nil {
+		return nil, err
+	}
+
+	delays, err := register(registerer, prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "release_decision_delay_seconds",
+		Help:      "Time from the creation of tickets to the release decisions taken on them, by policy and decision.",
+		Buckets:   prometheus.ExponentialBuckets(delayBucketStart, delayBucketFactor, delayBucketCount),
+	}, []string{"policy_id", "decision"}))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Decisions{
+		decisions: decisions.(*prometheus.CounterVec), //nolint:forcetypeassert,errcheck
+		delays:    delays.(*prometheus.HistogramVec),  //nolint:forcetypeassert,errcheck
+	}, nil
+}
+
+// register registers the collector, or returns the collector already registered in its place.
+func register(registerer prometheus.Registerer, c prometheus.Collector) (prometheus.Collector, error) {
+	err := registerer.Register(c)
+
+	var are prometheus.AlreadyRegisteredError
+
+	if errors.As(err, &are) {
+		return are.ExistingCollector, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("register decision metrics: %w", err)
+	}
+
+	return c, nil
+}
+
+// TicketUpdated records the decision of the last action taken on the ticket. It implements the observer of the
+// release service.
+func (d *Decisions) TicketUpdated(_ context.Context, t *ticket.Ticket) {
+	if len(t.History) == 0 {
+		return
+	}
+
+	switch t.History[len(t.History)-1].Action {
+	case ticket.ReleaseAction:
+		d.record(t.PolicyID, TicketCreated, nil)
+	case ticket.BreakGlassAction:
+		d.record(t.PolicyID, BreakGlass, nil)
+	case ticket.ApproveAction:
+		d.record(t.PolicyID, Approval, t)
+	case ticket.RejectAction:
+		d.record(t.PolicyID, Rejection, t)
+	case ticket.CollectAction:
+		// collections are counted as quorum failures when they are refused, and as extractions once extracted
+	}
+}
+
+// QuorumFailed records an attempt to collect the data of a ticket of the policy before it has the approvals of enough
+// approvers.
+func (d *Decisions) QuorumFailed(policyID string) {
+	d.record(policyID, QuorumFailure, nil)
+}
+
+// Extracted records the extraction of the data released by the ticket.
+func (d *Decisions) Extracted(t *ticket.Ticket, policyID string) {
+	d.record(policyID, Extraction, t)
+}
+
+// record counts the decision, and observes its delay since the creation of the ticket if it is set.
+func (d *Decisions) record(policyID string, decision Decision, t *ticket.Ticket) {
+	d.decisions.WithLabelValues(policyID, string(decision)).Inc()
+
+	if t != nil && !t.CreatedAt.IsZero() {
+		d.delays.WithLabelValues(policyID, string(decision)).Observe(time.Since(t.CreatedAt).Seconds())
+	}
+}
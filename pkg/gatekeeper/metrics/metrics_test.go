@@ -0,0 +1,118 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package metrics_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/ace/pkg/gatekeeper/metrics"
+	"github.com/trustbloc/ace/pkg/gatekeeper/release/ticket"
+)
+
+const policyID = "test-policy"
+
+func TestDecisions(t *testing.T) {
+	t.Run("records decisions by policy", func(t *testing.T) {
+		registry := prometheus.NewRegistry()
+
+		d, err := metrics.NewDecisions(registry)
+		require.NoError(t, err)
+
+		tk := &ticket.Ticket{PolicyID: policyID, CreatedAt: time.Now().Add(-time.Minute)}
+
+		for _, action := range []ticket.Action{
+			ticket.ReleaseAction, ticket.ApproveAction, ticket.ApproveAction, ticket.CollectAction,
+			ticket.RejectAction, ticket.BreakGlassAction,
+		} {
+			tk.History = append(tk.History, &ticket.Event{Action: action})
+			d.TicketUpdated(context.Background(), tk)
+		}
+
+		d.TicketUpdated(context.Background(), &ticket.Ticket{PolicyID: policyID})
+		d.QuorumFailed(policyID)
+		d.QuorumFailed("other-policy")
+		d.Extracted(tk, policyID)
+
+		require.Equal(t, map[string]float64{
+			policyID + "/ticket_created":  1,
+			policyID + "/approval":        2,
+			policyID + "/rejection":       1,
+			policyID + "/break_glass":     1,
+			policyID + "/quorum_failure":  1,
+			policyID + "/extraction":      1,
+			"other-policy/quorum_failure": 1,
+		}, counts(t, registry))
+
+		// approvals, rejections and extractions are timed since the creation of the ticket
+		n, err := testutil.GatherAndCount(registry, "gatekeeper_release_decision_delay_seconds")
+		require.NoError(t, err)
+		require.Equal(t, 3, n)
+	})
+
+	t.Run("shares metrics already registered", func(t *testing.T) {
+		registry := prometheus.NewRegistry()
+
+		d1, err := metrics.NewDecisions(registry)
+		require.NoError(t, err)
+
+		d2, err := metrics.NewDecisions(registry)
+		require.NoError(t, err)
+
+		d1.QuorumFailed(policyID)
+		d2.QuorumFailed(policyID)
+
+		require.Equal(t, map[string]float64{policyID + "/quorum_failure": 2}, counts(t, registry))
+	})
+
+	t.Run("fails to register metrics", func(t *testing.T) {
+		registry := prometheus.NewRegistry()
+		registry.MustRegister(prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "gatekeeper_release_decisions_total",
+			Help: "Another metric.",
+		}))
+
+		_, err := metrics.NewDecisions(registry)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "register decision metrics")
+		require.False(t, errors.As(err, &prometheus.AlreadyRegisteredError{}))
+	})
+}
+
+// counts returns the release decisions counted, by policy and decision.
+func counts(t *testing.T, registry *prometheus.Registry) map[string]float64 {
+	t.Helper()
+
+	families, err := registry.Gather()
+	require.NoError(t, err)
+
+	c := map[string]float64{}
+
+	for _, f := range families {
+		if f.GetName() != "gatekeeper_release_decisions_total" {
+			continue
+		}
+
+		for _, m := range f.GetMetric() {
+			labels := map[string]string{}
+
+			for _, l := range m.GetLabel() {
+				labels[l.GetName()] = l.GetValue()
+			}
+
+			c[labels["policy_id"]+"/"+labels["decision"]] = m.GetCounter().GetValue()
+		}
+	}
+
+	return c
+}
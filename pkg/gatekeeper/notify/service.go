@@ -0,0 +1,287 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package notify notifies the subjects of protected data when it is released or extracted, and other parties of
+// events of the data.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/btcsuite/btcutil/base58"
+	"github.com/google/uuid"
+	cryptoapi "github.com/hyperledger/aries-framework-go/pkg/crypto"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
+	anoncrypt "github.com/hyperledger/aries-framework-go/pkg/didcomm/packer/legacy/anoncrypt"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/did"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/util/kmsdidkey"
+	vdrapi "github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdr"
+	"github.com/hyperledger/aries-framework-go/pkg/kms"
+	"github.com/hyperledger/aries-framework-go/spi/storage"
+
+	"github.com/trustbloc/ace/pkg/gatekeeper/policy"
+)
+
+const (
+	// Released is the type of the events of protected data released to a handler.
+	Released = "released"
+	// Extracted is the type of the events of released data extracted by a handler.
+	Extracted = "extracted"
+	// BreakGlass is the type of the events of protected data collected by a responder without the approvals of the
+	// approvers.
+	BreakGlass = "break_glass"
+	// TicketUpdated is the type of the events of actions taken on release tickets, notified to their participants.
+	TicketUpdated = "ticket_updated"
+	// MessageType is the type of the DIDComm messages notifying the subjects.
+	MessageType = "https://trustbloc.dev/ace/1.0/notification"
+
+	// DefaultTimeout is the default time to wait for a notification to be delivered.
+	DefaultTimeout = 10 * time.Second
+
+	envelopeContentType = "application/didcomm-envelope-enc"
+)
+
+type httpClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+type vdrRegistry interface {
+	Resolve(DID string, opts ...vdrapi.DIDMethodOption) (*did.DocResolution, error)
+}
+
+type packer interface {
+	Pack(contentType string, payload, senderKey []byte, recipients [][]byte) ([]byte, error)
+}
+
+// Event is the release or the extraction of protected data notified to its subject.
+type Event struct {
+	// Type is Released, Extracted, BreakGlass or TicketUpdated.
+	Type string `json:"event"`
+	// DID is the DID of the protected data.
+	DID      string `json:"did"`
+	PolicyID string `json:"policy_id"`
+	// Subject, if known, is the DID of the subject of the data.
+	Subject  string `json:"subject,omitempty"`
+	TicketID string `json:"ticket_id"`
+	// RequestingParty is the DID of the handler the data is released to.
+	RequestingParty string    `json:"requesting_party,omitempty"`
+	Justification   string    `json:"justification,omitempty"`
+	Timestamp       time.Time `json:"timestamp"`
+	// Recipient, if set, is the DID the DIDComm message is sent to instead of the subject, e.g. an approver.
+	Recipient string `json:"recipient,omitempty"`
+	// Action, Status and Actor are the action taken on the ticket, the status of the ticket after it and the DID of
+	// the party that took it, for TicketUpdated events.
+	Action string `json:"action,omitempty"`
+	Status string `json:"status,omitempty"`
+	Actor  string `json:"actor,omitempty"`
+}
+
+// Config defines dependencies for Service.
+type Config struct {
+	HTTPClient httpClient
+	// VDR resolves the DID documents of the subjects, with the DIDComm services of their agents.
+	VDR vdrRegistry
+	// KeyManager creates the crypto box the DIDComm messages are encrypted with.
+	KeyManager kms.KeyManager
+	// Timeout is the time to wait for a notification to be delivered. It defaults to DefaultTimeout.
+	Timeout time.Duration
+}
+
+// Service notifies the subjects of protected data on the channels set by the notification of the policy.
+type Service struct {
+	httpClient httpClient
+	vdr        vdrRegistry
+	packer     packer
+	timeout    time.Duration
+}
+
+// NewService returns a new instance of Service.
+func NewService(config *Config) *Service {
+	timeout := config.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	return &Service{
+		httpClient: config.HTTPClient,
+		vdr:        config.VDR,
+		packer:     anoncrypt.New(&packerProvider{kms: config.KeyManager}),
+		timeout:    timeout,
+	}
+}
+
+// Notify delivers the event to the webhook of the notification and, if enabled, as a DIDComm message to the agent of
+// the subject. The event is delivered to the agent even if the webhook fails.
+func (s *Service) Notify(ctx context.Context, n *policy.Notification, e *Event) error {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	var errs []string
+
+	if n.Webhook != "" {
+		if err := s.postWebhook(ctx, n.Webhook, e); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	if n.DIDComm {
+		if err := s.sendMessage(ctx, e); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+
+	return nil
+}
+
+func (s *Service) postWebhook(ctx context.Context, webhookURL string, e *Event) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("marshal event: %w", err)
+	}
+
+	if err = s.post(ctx, webhookURL, "application/json", body); err != nil {
+		return fmt.Errorf("post webhook: %w", err)
+	}
+
+	return nil
+}
+
+// message is the DIDComm message notifying the subject of the event.
+type message struct {
+	ID   string `json:"@id"`
+	Type string `json:"@type"`
+	*Event
+}
+
+func (s *Service) sendMessage(ctx context.Context, e *Event) error {
+	to := e.Subject
+	if e.Recipient != "" {
+		to = e.Recipient
+	}
+
+	if to == "" {
+		return errors.New("send message: subject of the protected data is not known")
+	}
+
+	docResolution, err := s.vdr.Resolve(to)
+	if err != nil {
+		return fmt.Errorf("send message: resolve subject: %w", err)
+	}
+
+	dest, err := service.CreateDestination(docResolution.DIDDocument)
+	if err != nil {
+		return fmt.Errorf("send message: %w", err)
+	}
+
+	uri, err := dest.ServiceEndpoint.URI()
+	if err != nil {
+		return fmt.Errorf("send message: service endpoint: %w", err)
+	}
+
+	recipients, err := recipientKeys(dest.RecipientKeys)
+	if err != nil {
+		return fmt.Errorf("send message: %w", err)
+	}
+
+	payload, err := json.Marshal(&message{ID: uuid.New().String(), Type: MessageType, Event: e})
+	if err != nil {
+		return fmt.Errorf("send message: marshal message: %w", err)
+	}
+
+	envelope, err := s.packer.Pack("", payload, nil, recipients)
+	if err != nil {
+		return fmt.Errorf("send message: pack message: %w", err)
+	}
+
+	if err = s.post(ctx, uri, envelopeContentType, envelope); err != nil {
+		return fmt.Errorf("send message: %w", err)
+	}
+
+	return nil
+}
+
+// recipientKeys returns the raw public keys of the recipients, set as did:key or base58 in the DIDComm service.
+func recipientKeys(keys []string) ([][]byte, error) {
+	recipients := make([][]byte, len(keys))
+
+	for i, k := range keys {
+		if strings.Contains(k, "#") {
+			return nil, fmt.Errorf("recipient key %s: DIDComm v2 services are not supported", k)
+		}
+
+		if !strings.HasPrefix(k, "did:key:") {
+			recipients[i] = base58.Decode(k)
+
+			continue
+		}
+
+		pubKey, err := kmsdidkey.EncryptionPubKeyFromDIDKey(k)
+		if err != nil {
+			return nil, fmt.Errorf("parse recipient key %s: %w", k, err)
+		}
+
+		recipients[i] = pubKey.X
+	}
+
+	return recipients, nil
+}
+
+func (s *Service) post(ctx context.Context, targetURL, contentType string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, targetURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", contentType)
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("send request: %w", err)
+	}
+
+	defer resp.Body.Close() //nolint:errcheck
+
+	_, _ = io.Copy(io.Discard, resp.Body) //nolint:errcheck
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("unexpected status %d from %s", resp.StatusCode, targetURL)
+	}
+
+	return nil
+}
+
+// packerProvider provides the key manager to the DIDComm packer, which needs no other dependency to encrypt messages.
+type packerProvider struct {
+	kms kms.KeyManager
+}
+
+func (p *packerProvider) KMS() kms.KeyManager { //nolint:ireturn
+	return p.kms
+}
+
+func (p *packerProvider) Crypto() cryptoapi.Crypto { //nolint:ireturn
+	return nil
+}
+
+func (p *packerProvider) StorageProvider() storage.Provider { //nolint:ireturn
+	return nil
+}
+
+func (p *packerProvider) VDRegistry() vdrapi.Registry { //nolint:ireturn
+	return nil
+}
@@ -0,0 +1,262 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package notify_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/aries-framework-go/component/storageutil/mem"
+	"github.com/hyperledger/aries-framework-go/pkg/common/model"
+	cryptoapi "github.com/hyperledger/aries-framework-go/pkg/crypto"
+	anoncrypt "github.com/hyperledger/aries-framework-go/pkg/didcomm/packer/legacy/anoncrypt"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/did"
+	vdrapi "github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdr"
+	"github.com/hyperledger/aries-framework-go/pkg/kms"
+	"github.com/hyperledger/aries-framework-go/pkg/kms/localkms"
+	vdrmock "github.com/hyperledger/aries-framework-go/pkg/mock/vdr"
+	"github.com/hyperledger/aries-framework-go/pkg/secretlock"
+	"github.com/hyperledger/aries-framework-go/pkg/secretlock/noop"
+	"github.com/hyperledger/aries-framework-go/pkg/vdr/fingerprint"
+	"github.com/hyperledger/aries-framework-go/spi/storage"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/ace/pkg/gatekeeper/notify"
+	"github.com/trustbloc/ace/pkg/gatekeeper/policy"
+)
+
+const (
+	subjectDID            = "did:example:subject"
+	keystorePrimaryKeyURI = "local-lock://test/key-uri/"
+)
+
+func TestService_Notify(t *testing.T) {
+	event := &notify.Event{
+		Type:            notify.Released,
+		DID:             "did:example:protected",
+		PolicyID:        "policy",
+		Subject:         subjectDID,
+		TicketID:        "ticket",
+		RequestingParty: "did:example:handler",
+		Justification:   "fraud investigation",
+		Timestamp:       time.Now().UTC(),
+	}
+
+	t.Run("Posts event to webhook", func(t *testing.T) {
+		received := make(chan *notify.Event, 1)
+
+		srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+
+			var e notify.Event
+
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&e))
+
+			received <- &e
+		}))
+		defer srv.Close()
+
+		svc := notify.NewService(&notify.Config{HTTPClient: http.DefaultClient})
+
+		require.NoError(t, svc.Notify(context.Background(), &policy.Notification{Webhook: srv.URL}, event))
+
+		e := <-received
+		require.Equal(t, event.TicketID, e.TicketID)
+		require.Equal(t, event.Justification, e.Justification)
+	})
+
+	t.Run("Sends DIDComm message to the agent of the subject", func(t *testing.T) {
+		agentKMS := newLocalKMS(t)
+
+		_, pubKey, err := agentKMS.CreateAndExportPubKeyBytes(kms.ED25519Type)
+		require.NoError(t, err)
+
+		didKey, _ := fingerprint.CreateDIDKey(pubKey)
+
+		received := make(chan []byte, 1)
+
+		agent := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			require.Equal(t, "application/didcomm-envelope-enc", r.Header.Get("Content-Type"))
+
+			b, e := io.ReadAll(r.Body)
+			require.NoError(t, e)
+
+			received <- b
+
+			rw.WriteHeader(http.StatusAccepted)
+		}))
+		defer agent.Close()
+
+		svc := notify.NewService(&notify.Config{
+			HTTPClient: http.DefaultClient,
+			VDR:        &vdrmock.MockVDRegistry{ResolveValue: agentDoc(agent.URL, didKey)},
+			KeyManager: newLocalKMS(t),
+		})
+
+		require.NoError(t, svc.Notify(context.Background(), &policy.Notification{DIDComm: true}, event))
+
+		envelope, err := anoncrypt.New(&packerProvider{kms: agentKMS}).Unpack(<-received)
+		require.NoError(t, err)
+
+		var msg map[string]interface{}
+
+		require.NoError(t, json.Unmarshal(envelope.Message, &msg))
+		require.Equal(t, notify.MessageType, msg["@type"])
+		require.NotEmpty(t, msg["@id"])
+		require.Equal(t, notify.Released, msg["event"])
+		require.Equal(t, "did:example:handler", msg["requesting_party"])
+	})
+
+	t.Run("Sends DIDComm message to the recipient", func(t *testing.T) {
+		received := make(chan struct{}, 1)
+
+		agent := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+			received <- struct{}{}
+		}))
+		defer agent.Close()
+
+		_, pubKey, err := newLocalKMS(t).CreateAndExportPubKeyBytes(kms.ED25519Type)
+		require.NoError(t, err)
+
+		didKey, _ := fingerprint.CreateDIDKey(pubKey)
+
+		svc := notify.NewService(&notify.Config{
+			HTTPClient: http.DefaultClient,
+			VDR: &vdrmock.MockVDRegistry{
+				ResolveFunc: func(didID string, _ ...vdrapi.DIDMethodOption) (*did.DocResolution, error) {
+					require.Equal(t, "did:example:approver", didID)
+
+					return &did.DocResolution{DIDDocument: agentDoc(agent.URL, didKey)}, nil
+				},
+			},
+			KeyManager: newLocalKMS(t),
+		})
+
+		approverEvent := *event
+		approverEvent.Type = notify.BreakGlass
+		approverEvent.Recipient = "did:example:approver"
+
+		require.NoError(t, svc.Notify(context.Background(), &policy.Notification{DIDComm: true}, &approverEvent))
+
+		<-received
+	})
+
+	t.Run("Fails on webhook error status and unknown subject", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+			rw.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer srv.Close()
+
+		svc := notify.NewService(&notify.Config{HTTPClient: http.DefaultClient})
+
+		err := svc.Notify(context.Background(), &policy.Notification{Webhook: srv.URL, DIDComm: true},
+			&notify.Event{Type: notify.Extracted})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "post webhook: unexpected status 500")
+		require.Contains(t, err.Error(), "send message: subject of the protected data is not known")
+	})
+
+	t.Run("Fails to resolve subject", func(t *testing.T) {
+		svc := notify.NewService(&notify.Config{
+			VDR: &vdrmock.MockVDRegistry{ResolveErr: errors.New("resolve error")},
+		})
+
+		err := svc.Notify(context.Background(), &policy.Notification{DIDComm: true}, event)
+		require.EqualError(t, err, "send message: resolve subject: resolve error")
+	})
+
+	t.Run("Fails without DIDComm service", func(t *testing.T) {
+		svc := notify.NewService(&notify.Config{
+			VDR: &vdrmock.MockVDRegistry{ResolveValue: &did.Doc{ID: subjectDID}},
+		})
+
+		err := svc.Notify(context.Background(), &policy.Notification{DIDComm: true}, event)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "missing DID doc service")
+	})
+
+	t.Run("Fails with DIDComm v2 recipient keys", func(t *testing.T) {
+		svc := notify.NewService(&notify.Config{
+			VDR: &vdrmock.MockVDRegistry{ResolveValue: agentDoc("https://agent.example.com", subjectDID+"#key-1")},
+		})
+
+		err := svc.Notify(context.Background(), &policy.Notification{DIDComm: true}, event)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "DIDComm v2 services are not supported")
+	})
+
+	t.Run("Fails to send request", func(t *testing.T) {
+		svc := notify.NewService(&notify.Config{HTTPClient: http.DefaultClient, Timeout: time.Second})
+
+		err := svc.Notify(context.Background(), &policy.Notification{Webhook: "http://127.0.0.1:0/notify"}, event)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "post webhook: send request")
+	})
+}
+
+func agentDoc(endpoint, recipientKey string) *did.Doc {
+	return &did.Doc{
+		ID: subjectDID,
+		Service: []did.Service{{
+			ID:              subjectDID + "#didcomm",
+			Type:            "did-communication",
+			ServiceEndpoint: model.NewDIDCommV1Endpoint(endpoint),
+			RecipientKeys:   []string{recipientKey},
+		}},
+	}
+}
+
+func newLocalKMS(t *testing.T) *localkms.LocalKMS {
+	t.Helper()
+
+	keyManager, err := localkms.New(keystorePrimaryKeyURI, &kmsProvider{
+		storageProvider: mem.NewProvider(),
+		secretLock:      &noop.NoLock{},
+	})
+	require.NoError(t, err)
+
+	return keyManager
+}
+
+type kmsProvider struct {
+	storageProvider storage.Provider
+	secretLock      secretlock.Service
+}
+
+func (k kmsProvider) StorageProvider() storage.Provider { //nolint:ireturn
+	return k.storageProvider
+}
+
+func (k kmsProvider) SecretLock() secretlock.Service { //nolint:ireturn
+	return k.secretLock
+}
+
+type packerProvider struct {
+	kms kms.KeyManager
+}
+
+func (p *packerProvider) KMS() kms.KeyManager { //nolint:ireturn
+	return p.kms
+}
+
+func (p *packerProvider) Crypto() cryptoapi.Crypto { //nolint:ireturn
+	return nil
+}
+
+func (p *packerProvider) StorageProvider() storage.Provider { //nolint:ireturn
+	return nil
+}
+
+func (p *packerProvider) VDRegistry() vdrapi.Registry { //nolint:ireturn
+	return nil
+}
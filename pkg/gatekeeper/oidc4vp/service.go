@@ -0,0 +1,226 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package oidc4vp verifies the credentials of the participants with OpenID for Verifiable Presentations, as an
+// alternative to posting verifiable presentations: the presentation is requested from the wallet of the participant
+// by a configured verifier, and Gatekeeper checks the result of the interaction with the verifier.
+package oidc4vp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/aries-framework-go/pkg/doc/presexch"
+	"github.com/hyperledger/aries-framework-go/spi/storage"
+)
+
+const (
+	// StatusPending, StatusVerified and StatusFailed are the statuses of the interactions with the verifier.
+	StatusPending  = "pending"
+	StatusVerified = "verified"
+	StatusFailed   = "failed"
+
+	// DefaultTimeout is the default time to wait for the verifier.
+	DefaultTimeout = 10 * time.Second
+	// DefaultMaxAge is the default maximum age of the presentations, from the time they were verified.
+	DefaultMaxAge = 10 * time.Minute
+
+	storeName           = "oidc4vp_interaction"
+	interactionsPath    = "/interactions"
+	maxResponseBodySize = 1 << 20
+)
+
+// ErrNotVerified is returned when the presentation of an interaction was not verified, or does not satisfy the
+// presentation definition or the holder it is checked against.
+var ErrNotVerified = errors.New("presentation not verified")
+
+type httpClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// Interaction is an interaction with the verifier, requesting a presentation from the wallet of a participant.
+type Interaction struct {
+	TxID string `json:"tx_id"`
+	// AuthorizationRequest is the OpenID4VP authorization request the wallet of the participant is given, e.g. as a QR
+	// code or a redirect.
+	AuthorizationRequest string `json:"authorization_request"`
+}
+
+// Result is the result of an interaction, as reported by the verifier.
+type Result struct {
+	Status string `json:"status"`
+	// Holder is the DID of the holder of the verified presentation.
+	Holder string `json:"holder,omitempty"`
+	// DefinitionID is the ID of the presentation definition the presentation satisfies.
+	DefinitionID string     `json:"definition_id,omitempty"`
+	VerifiedAt   *time.Time `json:"verified_at,omitempty"`
+}
+
+// Config defines dependencies for Service.
+type Config struct {
+	// VerifierURL is the base URL of the interactions API of the verifier.
+	VerifierURL string
+	// AuthToken, if set, is the bearer token the requests to the verifier are authenticated with.
+	AuthToken  string
+	HTTPClient httpClient
+	// StoreProvider stores the interactions already used, as each presentation is used once.
+	StoreProvider storage.Provider
+	// Timeout is the time to wait for the verifier. It defaults to DefaultTimeout.
+	Timeout time.Duration
+	// MaxAge is the maximum age of the presentations. It defaults to DefaultMaxAge.
+	MaxAge time.Duration
+}
+
+// Service initiates the interactions with the verifier and checks their results.
+type Service struct {
+	verifierURL string
+	authToken   string
+	httpClient  httpClient
+	store       storage.Store
+	timeout     time.Duration
+	maxAge      time.Duration
+}
+
+// NewService returns a new instance of Service.
+func NewService(config *Config) (*Service, error) {
+	store, err := config.StoreProvider.OpenStore(storeName)
+	if err != nil {
+		return nil, fmt.Errorf("open interaction store: %w", err)
+	}
+
+	s := &Service{
+		verifierURL: strings.TrimSuffix(config.VerifierURL, "/"),
+		authToken:   config.AuthToken,
+		httpClient:  config.HTTPClient,
+		store:       store,
+		timeout:     config.Timeout,
+		maxAge:      config.MaxAge,
+	}
+
+	if s.timeout <= 0 {
+		s.timeout = DefaultTimeout
+	}
+
+	if s.maxAge <= 0 {
+		s.maxAge = DefaultMaxAge
+	}
+
+	return s, nil
+}
+
+// Initiate initiates an interaction requesting a presentation satisfying the presentation definition.
+func (s *Service) Initiate(ctx context.Context, definition *presexch.PresentationDefinition) (*Interaction, error) {
+	body, err := json.Marshal(map[string]interface{}{"presentation_definition": definition})
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+
+	var interaction Interaction
+
+	if err = s.send(ctx, http.MethodPost, s.verifierURL+interactionsPath, body, &interaction); err != nil {
+		return nil, fmt.Errorf("initiate interaction: %w", err)
+	}
+
+	if interaction.TxID == "" || interaction.AuthorizationRequest == "" {
+		return nil, errors.New("initiate interaction: missing tx_id or authorization_request")
+	}
+
+	return &interaction, nil
+}
+
+// Verify checks that the presentation of the interaction was verified within the maximum age of the presentations,
+// satisfies the presentation definition and is held by the holder. An interaction is used to verify a presentation
+// once.
+func (s *Service) Verify(ctx context.Context, definition *presexch.PresentationDefinition, txID,
+	holder string) error {
+	if _, err := s.store.Get(txID); err == nil {
+		return fmt.Errorf("%w: interaction %s was already used", ErrNotVerified, txID)
+	} else if !errors.Is(err, storage.ErrDataNotFound) {
+		return fmt.Errorf("get interaction: %w", err)
+	}
+
+	var result Result
+
+	err := s.send(ctx, http.MethodGet, s.verifierURL+interactionsPath+"/"+url.PathEscape(txID), nil, &result)
+	if err != nil {
+		return fmt.Errorf("get interaction result: %w", err)
+	}
+
+	switch {
+	case result.Status != StatusVerified:
+		return fmt.Errorf("%w: interaction %s is %s", ErrNotVerified, txID, result.Status)
+	case result.Holder != holder:
+		return fmt.Errorf("%w: presentation is not held by %s", ErrNotVerified, holder)
+	case result.DefinitionID != definition.ID:
+		return fmt.Errorf("%w: presentation does not satisfy presentation definition %q", ErrNotVerified, definition.ID)
+	case result.VerifiedAt == nil || result.VerifiedAt.Before(time.Now().Add(-s.maxAge)):
+		return fmt.Errorf("%w: presentation verified more than %s ago", ErrNotVerified, s.maxAge)
+	}
+
+	b, err := json.Marshal(&result)
+	if err != nil {
+		return fmt.Errorf("marshal result: %w", err)
+	}
+
+	if err = s.store.Put(txID, b); err != nil {
+		return fmt.Errorf("store interaction: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Service) send(ctx context.Context, method, targetURL string, body []byte, result interface{}) error {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, targetURL, reader)
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	if s.authToken != "" {
+		req.Header.Set("Authorization", "Bearer "+s.authToken)
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("send request: %w", err)
+	}
+
+	defer resp.Body.Close() //nolint:errcheck
+
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBodySize))
+	if err != nil {
+		return fmt.Errorf("read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d from %s", resp.StatusCode, targetURL)
+	}
+
+	if err = json.Unmarshal(respBody, result); err != nil {
+		return fmt.Errorf("unmarshal response: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,149 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package oidc4vp_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/aries-framework-go/component/storageutil/mem"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/presexch"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/ace/pkg/gatekeeper/oidc4vp"
+)
+
+const holderDID = "did:example:handler"
+
+func TestService_Initiate(t *testing.T) {
+	definition := &presexch.PresentationDefinition{ID: "authorized-handler"}
+
+	t.Run("Success", func(t *testing.T) {
+		verifier := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			require.Equal(t, http.MethodPost, r.Method)
+			require.Equal(t, "/oidc4vp/interactions", r.URL.Path)
+			require.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+
+			var req struct {
+				PresentationDefinition *presexch.PresentationDefinition `json:"presentation_definition"`
+			}
+
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			require.Equal(t, definition.ID, req.PresentationDefinition.ID)
+
+			_, _ = rw.Write([]byte(`{"tx_id":"tx","authorization_request":"openid4vp://?request_uri=..."}`)) //nolint:errcheck
+		}))
+		defer verifier.Close()
+
+		svc := newService(t, verifier.URL+"/oidc4vp/")
+
+		interaction, err := svc.Initiate(context.Background(), definition)
+		require.NoError(t, err)
+		require.Equal(t, "tx", interaction.TxID)
+		require.Equal(t, "openid4vp://?request_uri=...", interaction.AuthorizationRequest)
+	})
+
+	t.Run("Invalid responses", func(t *testing.T) {
+		for _, resp := range []string{`{"tx_id":"tx"}`, "not json"} {
+			verifier := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+				_, _ = rw.Write([]byte(resp)) //nolint:errcheck
+			}))
+
+			_, err := newService(t, verifier.URL).Initiate(context.Background(), definition)
+			require.Error(t, err)
+			require.Contains(t, err.Error(), "initiate interaction")
+
+			verifier.Close()
+		}
+	})
+
+	t.Run("Unexpected status", func(t *testing.T) {
+		verifier := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			rw.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer verifier.Close()
+
+		_, err := newService(t, verifier.URL).Initiate(context.Background(), definition)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "unexpected status 503")
+	})
+}
+
+func TestService_Verify(t *testing.T) {
+	definition := &presexch.PresentationDefinition{ID: "authorized-handler"}
+	now := time.Now()
+
+	results := map[string]*oidc4vp.Result{
+		"verified": {Status: oidc4vp.StatusVerified, Holder: holderDID, DefinitionID: definition.ID, VerifiedAt: &now},
+		"pending":  {Status: oidc4vp.StatusPending},
+		"other-holder": {Status: oidc4vp.StatusVerified, Holder: "did:example:other", DefinitionID: definition.ID,
+			VerifiedAt: &now},
+		"other-definition": {Status: oidc4vp.StatusVerified, Holder: holderDID, DefinitionID: "other",
+			VerifiedAt: &now},
+		"stale": {Status: oidc4vp.StatusVerified, Holder: holderDID, DefinitionID: definition.ID,
+			VerifiedAt: timePtr(now.Add(-time.Hour))},
+	}
+
+	verifier := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		result, ok := results[r.URL.Path[len("/interactions/"):]]
+		if !ok {
+			rw.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		require.NoError(t, json.NewEncoder(rw).Encode(result))
+	}))
+	defer verifier.Close()
+
+	svc := newService(t, verifier.URL)
+
+	t.Run("Success", func(t *testing.T) {
+		require.NoError(t, svc.Verify(context.Background(), definition, "verified", holderDID))
+
+		// interactions are single-use
+		err := svc.Verify(context.Background(), definition, "verified", holderDID)
+		require.True(t, errors.Is(err, oidc4vp.ErrNotVerified), err)
+	})
+
+	t.Run("Not verified", func(t *testing.T) {
+		for _, txID := range []string{"pending", "other-holder", "other-definition", "stale"} {
+			err := svc.Verify(context.Background(), definition, txID, holderDID)
+			require.True(t, errors.Is(err, oidc4vp.ErrNotVerified), txID)
+		}
+	})
+
+	t.Run("Unknown interaction", func(t *testing.T) {
+		err := svc.Verify(context.Background(), definition, "unknown", holderDID)
+		require.Error(t, err)
+		require.False(t, errors.Is(err, oidc4vp.ErrNotVerified))
+		require.Contains(t, err.Error(), "unexpected status 404")
+	})
+}
+
+func newService(t *testing.T, verifierURL string) *oidc4vp.Service {
+	t.Helper()
+
+	svc, err := oidc4vp.NewService(&oidc4vp.Config{
+		VerifierURL:   verifierURL,
+		AuthToken:     "token",
+		HTTPClient:    http.DefaultClient,
+		StoreProvider: mem.NewProvider(),
+	})
+	require.NoError(t, err)
+
+	return svc
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
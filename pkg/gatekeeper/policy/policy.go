@@ -6,6 +6,16 @@ SPDX-License-Identifier: Apache-2.0
 
 package policy
 
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/hyperledger/aries-framework-go/pkg/doc/presexch"
+)
+
 // Policy contains policy configuration for storing and releasing protected data.
 type Policy struct {
 	// Policy ID.
@@ -21,6 +31,267 @@ type Policy struct {
 	// The minimum number of (unique) approvers required before an object may be released back to the handler.
 	// This allows for an "m of N" approval scenario. Constraints: 0 < min_approvers < approvers.length.
 	MinApprovers int `json:"min_approvers"`
+	// An optional presentation definition the handler must satisfy with a verifiable presentation when requesting
+	// the release of protected data.
+	PresentationDefinition *presexch.PresentationDefinition `json:"presentation_definition,omitempty"`
+	// Whether the handlers must give a justification when requesting the release of protected data.
+	RequireJustification bool `json:"require_justification,omitempty"`
+	// An optional consent the subjects give to the protection of their data with this policy. A signed consent
+	// receipt is issued to the collector for every object protected if it is set.
+	Consent *Consent `json:"consent,omitempty"`
+	// An optional notification of the subjects of the data protected with this policy whenever it is released or
+	// extracted.
+	Notification *Notification `json:"notification,omitempty"`
+	// An optional mask applied to the protected data when the handlers extract it, so that they only get the part
+	// they need.
+	Mask *Mask `json:"mask,omitempty"`
+	// An optional watermark embedded in the protected data when the handlers extract it, so that leaked data can be
+	// traced back to its extraction.
+	Watermark *Watermark `json:"watermark,omitempty"`
+	// Whether the handlers get the data protected with this policy encrypted, as a JWE to the key agreement key in the
+	// DID documents of their DIDs, so that it is never returned in plaintext.
+	EncryptExtract bool `json:"encrypt_extract,omitempty"`
+	// An optional break-glass access to the data protected with this policy in emergencies, without the approvals of
+	// the approvers. It requires the notification of the subjects.
+	BreakGlass *BreakGlass `json:"break_glass,omitempty"`
+	// An optional quota of the extractions of every object protected with this policy, e.g. 3 extractions per 30
+	// days. Extractions over the quota are refused.
+	Quota *Quota `json:"quota,omitempty"`
+}
+
+// Consent is the consent the subjects give to the protection of their data under a policy.
+type Consent struct {
+	// The purposes the data is collected for.
+	Purposes []string `json:"purposes"`
+	// How long the data is retained for, as an ISO 8601 duration, e.g. P1Y.
+	Retention string `json:"retention,omitempty"`
+}
+
+// Notification sets the channels the subjects of protected data are notified on.
+type Notification struct {
+	// URL of a webhook the notifications are posted to.
+	Webhook string `json:"webhook,omitempty"`
+	// Whether the notifications are sent as DIDComm messages to the agents of the subjects, found in their DID
+	// documents. Only the subjects whose DIDs were recorded when their data was protected are notified.
+	DIDComm bool `json:"didcomm,omitempty"`
+}
+
+// Masking rules.
+const (
+	// MaskLast reveals only the last characters of the data, e.g. the last 4 digits of an SSN.
+	MaskLast = "last"
+	// MaskFirst reveals only the first characters of the data.
+	MaskFirst = "first"
+	// MaskEmailDomain reveals only the domain of an email address.
+	MaskEmailDomain = "email_domain"
+)
+
+// maskChar replaces the characters of the data that are not revealed.
+const maskChar = '*'
+
+// Mask is the partial disclosure of protected data to the handlers.
+type Mask struct {
+	// The masking rule: MaskLast, MaskFirst or MaskEmailDomain.
+	Rule string `json:"rule"`
+	// The number of characters revealed by the MaskLast and MaskFirst rules.
+	Count int `json:"count,omitempty"`
+}
+
+// Apply returns the data with the characters that are not revealed by the rule replaced with asterisks. Data no
+// longer than the number of characters revealed, or an email address without a domain, is masked entirely.
+func (m *Mask) Apply(data string) string {
+	runes := []rune(data)
+
+	// the characters from index from to index to (excluded) are revealed
+	var from, to int
+
+	switch m.Rule {
+	case MaskLast:
+		if m.Count < len(runes) {
+			from, to = len(runes)-m.Count, len(runes)
+		}
+	case MaskFirst:
+		if m.Count < len(runes) {
+			to = m.Count
+		}
+	case MaskEmailDomain:
+		if i := strings.LastIndex(data, "@"); i > 0 && i < len(data)-1 {
+			from, to = utf8.RuneCountInString(data[:i]), len(runes)
+		}
+	}
+
+	for i := range runes {
+		if i < from || i >= to {
+			runes[i] = maskChar
+		}
+	}
+
+	return string(runes)
+}
+
+func (m *Mask) validate() error {
+	switch m.Rule {
+	case MaskLast, MaskFirst:
+		if m.Count <= 0 {
+			return fmt.Errorf("mask count must be greater than 0 for rule %s", m.Rule)
+		}
+	case MaskEmailDomain:
+	default:
+		return fmt.Errorf("unknown mask rule %q", m.Rule)
+	}
+
+	return nil
+}
+
+// Kinds of watermarks.
+const (
+	// WatermarkZeroWidth embeds the watermark as invisible zero-width characters, for free text such as names.
+	WatermarkZeroWidth = "zero_width"
+	// WatermarkEmailTag embeds the watermark as a subaddress tag of email addresses, e.g. jane+tag@example.com.
+	// Data that is not an email address gets a zero-width watermark.
+	WatermarkEmailTag = "email_tag"
+)
+
+// Watermark is the watermark embedded in protected data extracted by the handlers.
+type Watermark struct {
+	// The kind of watermark: WatermarkZeroWidth or WatermarkEmailTag.
+	Type string `json:"type"`
+}
+
+// BreakGlass is an emergency access to protected data, collected without the approvals of the approvers.
+type BreakGlass struct {
+	// A list of DIDs identifying the entities of the emergency role, permitted to collect protected data associated
+	// with this policy with a justification.
+	Responders []string `json:"responders"`
+	// The notification of the approvers of every break-glass access. The DIDComm messages are sent to the agents of
+	// the approvers.
+	Notification *Notification `json:"notification"`
+}
+
+func (b *BreakGlass) validate(p *Policy) error {
+	if len(b.Responders) == 0 {
+		return fmt.Errorf("break_glass must have at least one responder")
+	}
+
+	if b.Notification == nil {
+		return fmt.Errorf("break_glass must set a notification of the approvers")
+	}
+
+	if p.Notification == nil {
+		return fmt.Errorf("break_glass requires a notification of the subjects")
+	}
+
+	return b.Notification.Validate()
+}
+
+const day = 24 * time.Hour
+
+// Quota caps the number of extractions of protected data per time window.
+type Quota struct {
+	// The maximum number of extractions of an object in the window.
+	Extractions int `json:"extractions"`
+	// The length of the sliding window the extractions are counted in, in days.
+	Days int `json:"days"`
+}
+
+// Window returns the length of the window of the quota.
+func (q *Quota) Window() time.Duration {
+	return time.Duration(q.Days) * day
+}
+
+// Validate checks the policy configuration against the constraints of its fields.
+func (p *Policy) Validate() error {
+	if p.MinApprovers <= 0 || p.MinApprovers >= len(p.Approvers) {
+		return fmt.Errorf("min_approvers must be greater than 0 and less than the number of approvers (%d)",
+			len(p.Approvers))
+	}
+
+	seen := make(map[string]struct{}, len(p.Approvers))
+
+	for _, approver := range p.Approvers {
+		if _, ok := seen[approver]; ok {
+			return fmt.Errorf("duplicate approver %s", approver)
+		}
+
+		seen[approver] = struct{}{}
+	}
+
+	if p.Consent != nil && len(p.Consent.Purposes) == 0 {
+		return fmt.Errorf("consent must have at least one purpose")
+	}
+
+	if p.Notification != nil {
+		if err := p.Notification.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if p.Mask != nil {
+		if err := p.Mask.validate(); err != nil {
+			return err
+		}
+	}
+
+	if p.Watermark != nil && p.Watermark.Type != WatermarkZeroWidth && p.Watermark.Type != WatermarkEmailTag {
+		return fmt.Errorf("unknown watermark type %q", p.Watermark.Type)
+	}
+
+	if p.Quota != nil && (p.Quota.Extractions <= 0 || p.Quota.Days <= 0) {
+		return fmt.Errorf("quota extractions and days must be greater than 0")
+	}
+
+	if p.BreakGlass != nil {
+		if err := p.BreakGlass.validate(p); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Validate checks that the notification sets a channel, and that its webhook is an absolute http or https URL.
+func (n *Notification) Validate() error {
+	if n.Webhook == "" && !n.DIDComm {
+		return fmt.Errorf("notification must set a webhook or didcomm")
+	}
+
+	if n.Webhook == "" {
+		return nil
+	}
+
+	u, err := url.Parse(n.Webhook)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("notification webhook must be an absolute http or https URL")
+	}
+
+	return nil
+}
+
+// Allows reports whether the DID has the role under the policy. Responders only have their role if break-glass access
+// is configured.
+func (p *Policy) Allows(did string, role Role) bool {
+	var dids []string
+
+	switch role {
+	case Collector:
+		dids = p.Collectors
+	case Handler:
+		dids = p.Handlers
+	case Approver:
+		dids = p.Approvers
+	case Responder:
+		if p.BreakGlass != nil {
+			dids = p.BreakGlass.Responders
+		}
+	}
+
+	for _, d := range dids {
+		if d == did {
+			return true
+		}
+	}
+
+	return false
 }
 
 // Role is a role of entity represented by DID.
@@ -33,4 +304,7 @@ const (
 	Handler
 	// Approver represents an entity that provides authorization for the release of the protected data.
 	Approver
+	// Responder represents an entity of the emergency role, permitted to collect protected data without
+	// authorization.
+	Responder
 )
@@ -0,0 +1,212 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package policy_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/ace/pkg/gatekeeper/policy"
+)
+
+func TestPolicy_Validate(t *testing.T) {
+	approvers := []string{"did:example:peter_venkman", "did:example:eon_spengler", "did:example:winton_zeddemore"}
+
+	tests := []struct {
+		name         string
+		approvers    []string
+		minApprovers int
+		consent      *policy.Consent
+		notification *policy.Notification
+		err          string
+	}{
+		{"valid", approvers, 2, nil, nil, ""},
+		{"min approvers not set", approvers, 0, nil, nil, "min_approvers must be greater than 0"},
+		{"min approvers equal to approvers", approvers, 3, nil, nil, "less than the number of approvers (3)"},
+		{"no approvers", nil, 1, nil, nil, "less than the number of approvers (0)"},
+		{
+			"duplicate approver", append(approvers, approvers[0]), 2, nil, nil,
+			"duplicate approver did:example:peter_venkman",
+		},
+		{"valid consent", approvers, 2, &policy.Consent{Purposes: []string{"marketing"}, Retention: "P1Y"}, nil, ""},
+		{"consent without purposes", approvers, 2, &policy.Consent{}, nil, "consent must have at least one purpose"},
+		{
+			"valid notification", approvers, 2, nil,
+			&policy.Notification{Webhook: "https://subjects.example.com/notify", DIDComm: true}, "",
+		},
+		{"didcomm notification", approvers, 2, nil, &policy.Notification{DIDComm: true}, ""},
+		{
+			"notification without channel", approvers, 2, nil, &policy.Notification{},
+			"notification must set a webhook or didcomm",
+		},
+		{
+			"notification with relative webhook", approvers, 2, nil, &policy.Notification{Webhook: "/notify"},
+			"notification webhook must be an absolute http or https URL",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &policy.Policy{
+				Approvers:    tt.approvers,
+				MinApprovers: tt.minApprovers,
+				Consent:      tt.consent,
+				Notification: tt.notification,
+			}
+
+			err := p.Validate()
+			if tt.err != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tt.err)
+
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestPolicy_ValidateMask(t *testing.T) {
+	approvers := []string{"did:example:peter_venkman", "did:example:eon_spengler", "did:example:winton_zeddemore"}
+
+	tests := []struct {
+		name string
+		mask *policy.Mask
+		err  string
+	}{
+		{"last", &policy.Mask{Rule: policy.MaskLast, Count: 4}, ""},
+		{"first", &policy.Mask{Rule: policy.MaskFirst, Count: 1}, ""},
+		{"email domain", &policy.Mask{Rule: policy.MaskEmailDomain}, ""},
+		{"last without count", &policy.Mask{Rule: policy.MaskLast}, "mask count must be greater than 0 for rule last"},
+		{"first with negative count", &policy.Mask{Rule: policy.MaskFirst, Count: -1}, "mask count must be greater"},
+		{"unknown rule", &policy.Mask{Rule: "middle"}, `unknown mask rule "middle"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &policy.Policy{Approvers: approvers, MinApprovers: 2, Mask: tt.mask}
+
+			err := p.Validate()
+			if tt.err != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tt.err)
+
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestMask_Apply(t *testing.T) {
+	tests := []struct {
+		name string
+		mask *policy.Mask
+		data string
+		want string
+	}{
+		{"last digits of ssn", &policy.Mask{Rule: policy.MaskLast, Count: 4}, "123-45-6789", "*******6789"},
+		{"first characters", &policy.Mask{Rule: policy.MaskFirst, Count: 2}, "ab1234", "ab****"},
+		{"data no longer than count", &policy.Mask{Rule: policy.MaskLast, Count: 4}, "6789", "****"},
+		{"multibyte characters", &policy.Mask{Rule: policy.MaskLast, Count: 2}, "ñandú", "***dú"},
+		{"email domain", &policy.Mask{Rule: policy.MaskEmailDomain}, "jane.doe@example.com", "********@example.com"},
+		{"email without domain", &policy.Mask{Rule: policy.MaskEmailDomain}, "jane.doe@", "*********"},
+		{"not an email", &policy.Mask{Rule: policy.MaskEmailDomain}, "jane.doe", "********"},
+		{"unknown rule", &policy.Mask{Rule: "middle"}, "secret", "******"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, tt.mask.Apply(tt.data))
+		})
+	}
+}
+
+func TestPolicy_ValidateWatermark(t *testing.T) {
+	approvers := []string{"did:example:peter_venkman", "did:example:eon_spengler", "did:example:winton_zeddemore"}
+
+	for _, typ := range []string{policy.WatermarkZeroWidth, policy.WatermarkEmailTag} {
+		p := &policy.Policy{Approvers: approvers, MinApprovers: 2, Watermark: &policy.Watermark{Type: typ}}
+		require.NoError(t, p.Validate(), typ)
+	}
+
+	p := &policy.Policy{Approvers: approvers, MinApprovers: 2, Watermark: &policy.Watermark{Type: "visible"}}
+	require.EqualError(t, p.Validate(), `unknown watermark type "visible"`)
+}
+
+func TestPolicy_ValidateBreakGlass(t *testing.T) {
+	approvers := []string{"did:example:peter_venkman", "did:example:eon_spengler", "did:example:winton_zeddemore"}
+	notification := &policy.Notification{Webhook: "https://subjects.example.com/notify"}
+
+	tests := []struct {
+		name         string
+		breakGlass   *policy.BreakGlass
+		notification *policy.Notification
+		err          string
+	}{
+		{
+			"valid", &policy.BreakGlass{
+				Responders:   []string{"did:example:dana_barrett"},
+				Notification: &policy.Notification{DIDComm: true},
+			}, notification, "",
+		},
+		{
+			"no responder", &policy.BreakGlass{Notification: &policy.Notification{DIDComm: true}}, notification,
+			"break_glass must have at least one responder",
+		},
+		{
+			"no notification of the approvers", &policy.BreakGlass{Responders: []string{"did:example:dana_barrett"}},
+			notification, "break_glass must set a notification of the approvers",
+		},
+		{
+			"no notification of the subjects", &policy.BreakGlass{
+				Responders:   []string{"did:example:dana_barrett"},
+				Notification: &policy.Notification{DIDComm: true},
+			}, nil, "break_glass requires a notification of the subjects",
+		},
+		{
+			"invalid notification of the approvers", &policy.BreakGlass{
+				Responders:   []string{"did:example:dana_barrett"},
+				Notification: &policy.Notification{},
+			}, notification, "notification must set a webhook or didcomm",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &policy.Policy{
+				Approvers:    approvers,
+				MinApprovers: 2,
+				Notification: tt.notification,
+				BreakGlass:   tt.breakGlass,
+			}
+
+			if tt.err == "" {
+				require.NoError(t, p.Validate())
+			} else {
+				require.EqualError(t, p.Validate(), tt.err)
+			}
+		})
+	}
+}
+
+func TestPolicy_ValidateQuota(t *testing.T) {
+	approvers := []string{"did:example:peter_venkman", "did:example:eon_spengler", "did:example:winton_zeddemore"}
+
+	p := &policy.Policy{Approvers: approvers, MinApprovers: 2, Quota: &policy.Quota{Extractions: 3, Days: 30}}
+	require.NoError(t, p.Validate())
+	require.Equal(t, 30*24*time.Hour, p.Quota.Window())
+
+	for _, q := range []*policy.Quota{{Days: 30}, {Extractions: 3}, {Extractions: -1, Days: 30}} {
+		p = &policy.Policy{Approvers: approvers, MinApprovers: 2, Quota: q}
+		require.EqualError(t, p.Validate(), "quota extractions and days must be greater than 0")
+	}
+}
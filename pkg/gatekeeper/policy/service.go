@@ -11,30 +11,65 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/hyperledger/aries-framework-go/spi/storage"
+
+	"github.com/trustbloc/ace/pkg/storage/cursor"
+	"github.com/trustbloc/ace/pkg/storage/index"
+	"github.com/trustbloc/ace/pkg/storage/tombstone"
 )
 
 const (
 	storeName = "policy"
+	idIndex   = "id"
 )
 
 // ErrNotAllowed is returned when a subject DID is not allowed to proceed under the given policy.
 var ErrNotAllowed = errors.New("not allowed")
 
+// ErrPolicyNotFound is returned when the policy does not exist. The error also matches storage.ErrDataNotFound.
+var ErrPolicyNotFound = errors.New("policy not found")
+
+// notFoundError is a storage.ErrDataNotFound error of a policy that matches ErrPolicyNotFound.
+type notFoundError struct {
+	err error
+}
+
+func (e *notFoundError) Error() string {
+	return e.err.Error()
+}
+
+func (e *notFoundError) Unwrap() error {
+	return e.err
+}
+
+func (e *notFoundError) Is(target error) bool {
+	return target == ErrPolicyNotFound
+}
+
 // Service works with policy configurations.
 type Service struct {
-	store storage.Store
+	store      storage.Store
+	tombstones *tombstone.Tombstones
 }
 
 // NewService returns a new instance of Service.
 func NewService(storeProvider storage.Provider) (*Service, error) {
-	store, err := storeProvider.OpenStore(storeName)
+	store, err := index.OpenStore(storeProvider, &index.Declaration{
+		Store:    storeName,
+		TagNames: []string{idIndex},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("open policy store: %w", err)
+	}
+
+	tombstones, err := tombstone.New(storeProvider, storeName, store)
 	if err != nil {
 		return nil, fmt.Errorf("open policy store: %w", err)
 	}
 
-	return &Service{store: store}, nil
+	return &Service{store: store, tombstones: tombstones}, nil
 }
 
 // Save stores policy configuration.
@@ -44,53 +79,52 @@ func (s *Service) Save(_ context.Context, doc *Policy) error {
 		return fmt.Errorf("marshal policy: %w", err)
 	}
 
-	if err = s.store.Put(doc.ID, b); err != nil {
+	if err = s.store.Put(doc.ID, b, tags(doc)...); err != nil {
 		return fmt.Errorf("save policy: %w", err)
 	}
 
 	return nil
 }
 
-// Check checks if DID is allowed to proceed under the given policy.
-func (s *Service) Check(_ context.Context, policyID, did string, role Role) error {
-	b, err := s.store.Get(policyID)
-	if err != nil {
-		return fmt.Errorf("get policy: %w", err)
+// Delete deletes policy configuration. The policy can be restored until it is purged.
+func (s *Service) Delete(_ context.Context, policyID string) error {
+	if err := s.tombstones.Delete(time.Now(), policyID); err != nil {
+		return fmt.Errorf("delete policy: %w", err)
 	}
 
-	var policy Policy
+	return nil
+}
 
-	if err = json.Unmarshal(b, &policy); err != nil {
-		return fmt.Errorf("unmarshal policy: %w", err)
+// Restore restores deleted policy configuration.
+func (s *Service) Restore(_ context.Context, policyID string) error {
+	if err := s.tombstones.Restore(policyID); err != nil {
+		return fmt.Errorf("restore policy: %w", err)
 	}
 
-	switch role {
-	case Collector:
-		for _, c := range policy.Collectors {
-			if c == did {
-				return nil
-			}
-		}
-	case Handler:
-		for _, h := range policy.Handlers {
-			if h == did {
-				return nil
-			}
-		}
-	case Approver:
-		for _, a := range policy.Approvers {
-			if a == did {
-				return nil
-			}
-		}
+	return nil
+}
+
+// Check checks if DID is allowed to proceed under the given policy.
+func (s *Service) Check(ctx context.Context, policyID, did string, role Role) error {
+	p, err := s.Get(ctx, policyID)
+	if err != nil {
+		return err
 	}
 
-	return ErrNotAllowed
+	if !p.Allows(did, role) {
+		return ErrNotAllowed
+	}
+
+	return nil
 }
 
 // Get gets policy from the underlying storage by ID.
 func (s *Service) Get(_ context.Context, policyID string) (*Policy, error) {
 	b, err := s.store.Get(policyID)
+	if errors.Is(err, storage.ErrDataNotFound) {
+		return nil, &notFoundError{err: fmt.Errorf("get policy: %w", err)}
+	}
+
 	if err != nil {
 		return nil, fmt.Errorf("get policy: %w", err)
 	}
@@ -103,3 +137,77 @@ func (s *Service) Get(_ context.Context, policyID string) (*Policy, error) {
 
 	return &policy, nil
 }
+
+// Cursor iterates over policy configurations a page at a time.
+type Cursor struct {
+	cursor *cursor.Cursor
+}
+
+// Next returns the policies of the next page. It returns no policies once all of them have been read.
+func (c *Cursor) Next() ([]*Policy, error) {
+	values, err := c.cursor.Next()
+	if err != nil {
+		return nil, fmt.Errorf("next policies: %w", err)
+	}
+
+	return unmarshalPolicies(values)
+}
+
+// Close releases the cursor.
+func (c *Cursor) Close() error {
+	return c.cursor.Close()
+}
+
+// Query returns a cursor over all policy configurations, reading pageSize policies at a time.
+func (s *Service) Query(_ context.Context, pageSize int) (*Cursor, error) {
+	c, err := cursor.New(s.store, idIndex, pageSize)
+	if err != nil {
+		return nil, fmt.Errorf("query policies: %w", err)
+	}
+
+	return &Cursor{cursor: c}, nil
+}
+
+// Iterate calls fn for every policy configuration, reading pageSize policies at a time. Returning cursor.ErrStop
+// from fn stops the iteration.
+func (s *Service) Iterate(ctx context.Context, pageSize int, fn func(p *Policy) error) error {
+	c, err := s.Query(ctx, pageSize)
+	if err != nil {
+		return err
+	}
+
+	return cursor.Iterate(c.cursor, func(values [][]byte) error {
+		policies, err := unmarshalPolicies(values)
+		if err != nil {
+			return err
+		}
+
+		for _, p := range policies {
+			if err = fn(p); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func unmarshalPolicies(values [][]byte) ([]*Policy, error) {
+	policies := make([]*Policy, len(values))
+
+	for i, v := range values {
+		var p Policy
+
+		if err := json.Unmarshal(v, &p); err != nil {
+			return nil, fmt.Errorf("unmarshal policy: %w", err)
+		}
+
+		policies[i] = &p
+	}
+
+	return policies, nil
+}
+
+func tags(doc *Policy) []storage.Tag {
+	return []storage.Tag{{Name: idIndex, Value: index.TagValue(doc.ID)}}
+}
@@ -13,10 +13,13 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/hyperledger/aries-framework-go/component/storageutil/mem"
 	"github.com/hyperledger/aries-framework-go/pkg/mock/storage"
+	storageapi "github.com/hyperledger/aries-framework-go/spi/storage"
 	"github.com/stretchr/testify/require"
 
 	"github.com/trustbloc/ace/pkg/gatekeeper/policy"
+	"github.com/trustbloc/ace/pkg/storage/tombstone"
 )
 
 const (
@@ -35,7 +38,18 @@ const (
     "did:example:eon_spengler",
     "did:example:winton_zeddemore"
   ],
-  "min_approvers": 2
+  "min_approvers": 2,
+  "notification": {
+    "webhook": "https://subjects.example.com/notify"
+  },
+  "break_glass": {
+    "responders": [
+      "did:example:dana_barrett"
+    ],
+    "notification": {
+      "didcomm": true
+    }
+  }
 }`
 )
 
@@ -64,12 +78,16 @@ func TestService_Save(t *testing.T) {
 	require.NoError(t, json.Unmarshal([]byte(testPolicy), &p))
 
 	t.Run("Success", func(t *testing.T) {
-		svc, err := policy.NewService(storage.NewMockStoreProvider())
+		store := storage.NewMockStoreProvider()
+
+		svc, err := policy.NewService(store)
 		require.NoError(t, err)
 
 		err = svc.Save(context.Background(), &p)
 
 		require.NoError(t, err)
+		require.Len(t, store.Store.Store[testPolicyID].Tags, 1)
+		require.Equal(t, "id", store.Store.Store[testPolicyID].Tags[0].Name)
 	})
 
 	t.Run("Fail to save policy", func(t *testing.T) {
@@ -85,6 +103,96 @@ func TestService_Save(t *testing.T) {
 	})
 }
 
+func TestService_Delete(t *testing.T) {
+	var p policy.Policy
+
+	require.NoError(t, json.Unmarshal([]byte(testPolicy), &p))
+
+	t.Run("Success", func(t *testing.T) {
+		svc, err := policy.NewService(mem.NewProvider())
+		require.NoError(t, err)
+
+		require.NoError(t, svc.Save(context.Background(), &p))
+
+		require.NoError(t, svc.Delete(context.Background(), testPolicyID))
+		require.NoError(t, svc.Delete(context.Background(), "missing-policy"))
+
+		_, err = svc.Get(context.Background(), testPolicyID)
+		require.ErrorIs(t, err, storageapi.ErrDataNotFound)
+
+		err = svc.Check(context.Background(), testPolicyID, "did:example:ray_stantz", policy.Collector)
+		require.ErrorIs(t, err, storageapi.ErrDataNotFound)
+	})
+
+	t.Run("Fail to delete policy", func(t *testing.T) {
+		store := &batchErrProvider{Provider: mem.NewProvider()}
+
+		svc, err := policy.NewService(store)
+		require.NoError(t, err)
+
+		require.NoError(t, svc.Save(context.Background(), &p))
+
+		store.errBatch = errors.New("batch error")
+
+		err = svc.Delete(context.Background(), testPolicyID)
+		require.EqualError(t, err, "delete policy: save tombstones: batch error")
+
+		store.errBatch = nil
+
+		_, err = svc.Get(context.Background(), testPolicyID)
+		require.NoError(t, err)
+	})
+}
+
+func TestService_Restore(t *testing.T) {
+	var p policy.Policy
+
+	require.NoError(t, json.Unmarshal([]byte(testPolicy), &p))
+
+	t.Run("Success", func(t *testing.T) {
+		svc, err := policy.NewService(mem.NewProvider())
+		require.NoError(t, err)
+
+		require.NoError(t, svc.Save(context.Background(), &p))
+		require.NoError(t, svc.Delete(context.Background(), testPolicyID))
+		require.NoError(t, svc.Restore(context.Background(), testPolicyID))
+
+		doc, err := svc.Get(context.Background(), testPolicyID)
+		require.NoError(t, err)
+		require.Equal(t, &p, doc)
+
+		var ids []string
+
+		require.NoError(t, svc.Iterate(context.Background(), 0, func(p *policy.Policy) error {
+			ids = append(ids, p.ID)
+
+			return nil
+		}))
+		require.Equal(t, []string{testPolicyID}, ids)
+	})
+
+	t.Run("Policy saved again", func(t *testing.T) {
+		svc, err := policy.NewService(mem.NewProvider())
+		require.NoError(t, err)
+
+		require.NoError(t, svc.Save(context.Background(), &p))
+		require.NoError(t, svc.Delete(context.Background(), testPolicyID))
+		require.NoError(t, svc.Save(context.Background(), &p))
+
+		err = svc.Restore(context.Background(), testPolicyID)
+		require.ErrorIs(t, err, tombstone.ErrEntryExists)
+	})
+
+	t.Run("Policy not deleted", func(t *testing.T) {
+		svc, err := policy.NewService(mem.NewProvider())
+		require.NoError(t, err)
+
+		err = svc.Restore(context.Background(), testPolicyID)
+		require.ErrorIs(t, err, storageapi.ErrDataNotFound)
+		require.Contains(t, err.Error(), "restore policy")
+	})
+}
+
 func TestService_Check(t *testing.T) {
 	t.Run("Fail to get policy", func(t *testing.T) {
 		store := storage.NewMockStoreProvider()
@@ -125,6 +233,7 @@ func TestService_Check(t *testing.T) {
 			{"did:example:eon_spengler", policy.Collector},
 			{"did:example:peter_venkman", policy.Handler},
 			{"did:example:ray_stantz", policy.Approver},
+			{"did:example:alter_peck", policy.Responder},
 		}
 
 		for _, tt := range tests {
@@ -150,6 +259,7 @@ func TestService_Check(t *testing.T) {
 			{"did:example:ray_stantz", policy.Collector},
 			{"did:example:alter_peck", policy.Handler},
 			{"did:example:eon_spengler", policy.Approver},
+			{"did:example:dana_barrett", policy.Responder},
 		}
 
 		for _, tt := range tests {
@@ -176,6 +286,17 @@ func TestService_Get(t *testing.T) {
 		require.Nil(t, p)
 	})
 
+	t.Run("Policy not found", func(t *testing.T) {
+		svc, err := policy.NewService(mem.NewProvider())
+		require.NoError(t, err)
+
+		_, err = svc.Get(context.Background(), testPolicyID)
+
+		require.True(t, errors.Is(err, policy.ErrPolicyNotFound))
+		require.True(t, errors.Is(err, storageapi.ErrDataNotFound))
+		require.EqualError(t, err, "get policy: data not found")
+	})
+
 	t.Run("Success", func(t *testing.T) {
 		store := storage.NewMockStoreProvider()
 		store.Store.Store[testPolicyID] = storage.DBEntry{Value: []byte(testPolicy)}
@@ -189,3 +310,132 @@ func TestService_Get(t *testing.T) {
 		require.NotNil(t, p)
 	})
 }
+
+func TestService_Query(t *testing.T) {
+	var p policy.Policy
+
+	require.NoError(t, json.Unmarshal([]byte(testPolicy), &p))
+
+	t.Run("Success", func(t *testing.T) {
+		svc, err := policy.NewService(mem.NewProvider())
+		require.NoError(t, err)
+
+		docs := make([]*policy.Policy, 3)
+
+		for i := range docs {
+			doc := p
+			doc.ID = fmt.Sprintf("policy-%d", i)
+			docs[i] = &doc
+		}
+
+		for _, doc := range docs {
+			require.NoError(t, svc.Save(context.Background(), doc))
+		}
+
+		c, err := svc.Query(context.Background(), 2)
+		require.NoError(t, err)
+
+		defer func() {
+			require.NoError(t, c.Close())
+		}()
+
+		page, err := c.Next()
+		require.NoError(t, err)
+		require.Len(t, page, 2)
+
+		page, err = c.Next()
+		require.NoError(t, err)
+		require.Len(t, page, 1)
+
+		var ids []string
+
+		err = svc.Iterate(context.Background(), 2, func(p *policy.Policy) error {
+			ids = append(ids, p.ID)
+
+			return nil
+		})
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{"policy-0", "policy-1", "policy-2"}, ids)
+
+		err = svc.Iterate(context.Background(), 2, func(p *policy.Policy) error {
+			return errors.New("fn error")
+		})
+		require.EqualError(t, err, "fn error")
+	})
+
+	t.Run("Fail to query policies", func(t *testing.T) {
+		store := storage.NewMockStoreProvider()
+		store.Store.ErrQuery = errors.New("query error")
+
+		svc, err := policy.NewService(store)
+		require.NoError(t, err)
+
+		_, err = svc.Query(context.Background(), 2)
+		require.EqualError(t, err, "query policies: query id: query error")
+
+		err = svc.Iterate(context.Background(), 2, func(p *policy.Policy) error {
+			return nil
+		})
+		require.EqualError(t, err, "query policies: query id: query error")
+	})
+
+	t.Run("Fail to read policies", func(t *testing.T) {
+		store := storage.NewMockStoreProvider()
+		store.Store.Store[testPolicyID] = storage.DBEntry{
+			Value: []byte("invalid policy"),
+			Tags:  []storageapi.Tag{{Name: "id"}},
+		}
+
+		svc, err := policy.NewService(store)
+		require.NoError(t, err)
+
+		c, err := svc.Query(context.Background(), 2)
+		require.NoError(t, err)
+
+		_, err = c.Next()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "unmarshal policy")
+
+		err = svc.Iterate(context.Background(), 2, func(p *policy.Policy) error {
+			return nil
+		})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "unmarshal policy")
+
+		store.Store.ErrNext = errors.New("next error")
+
+		c, err = svc.Query(context.Background(), 2)
+		require.NoError(t, err)
+
+		_, err = c.Next()
+		require.EqualError(t, err, "next policies: next entry: next error")
+	})
+}
+
+// batchErrProvider is a mem provider whose stores fail batches with the configured error.
+type batchErrProvider struct {
+	storageapi.Provider
+	errBatch error
+}
+
+func (p *batchErrProvider) OpenStore(name string) (storageapi.Store, error) {
+	s, err := p.Provider.OpenStore(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &batchErrStore{Store: s, provider: p}, nil
+}
+
+type batchErrStore struct {
+	storageapi.Store
+	provider *batchErrProvider
+}
+
+func (s *batchErrStore) Batch(operations []storageapi.Operation) error {
+	if s.provider.errBatch != nil {
+		return s.provider.errBatch
+	}
+
+	return s.Store.Batch(operations)
+}
@@ -0,0 +1,109 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package presentation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/aries-framework-go/pkg/doc/presexch"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
+	"github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdr"
+	"github.com/piprate/json-gold/ld"
+)
+
+// ErrInvalidPresentation is returned when a presentation does not satisfy the presentation definition.
+var ErrInvalidPresentation = errors.New("invalid presentation")
+
+type credentialValidator interface {
+	Validate(vc *verifiable.Credential) error
+}
+
+// Config defines dependencies for Service.
+type Config struct {
+	VDR            vdr.Registry
+	DocumentLoader ld.DocumentLoader
+	// Optional validator for presented credentials.
+	CredentialValidator credentialValidator
+}
+
+// Service verifies presentations submitted by participants against presentation definitions.
+type Service struct {
+	keyFetcher     verifiable.PublicKeyFetcher
+	documentLoader ld.DocumentLoader
+	validator      credentialValidator
+}
+
+// NewService returns a new instance of Service.
+func NewService(config *Config) *Service {
+	return &Service{
+		keyFetcher:     verifiable.NewVDRKeyResolver(config.VDR).PublicKeyFetcher(),
+		documentLoader: config.DocumentLoader,
+		validator:      config.CredentialValidator,
+	}
+}
+
+// Verify checks that presentation is signed by the holder and its credentials satisfy the presentation definition.
+func (s *Service) Verify(_ context.Context, definition *presexch.PresentationDefinition, vpBytes []byte,
+	holder string) error {
+	vp, err := verifiable.ParsePresentation(vpBytes,
+		verifiable.WithPresPublicKeyFetcher(s.keyFetcher),
+		verifiable.WithPresJSONLDDocumentLoader(s.documentLoader),
+	)
+	if err != nil {
+		return fmt.Errorf("%w: parse presentation: %s", ErrInvalidPresentation, err)
+	}
+
+	if err = checkProofs(vp.Proofs, holder); err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidPresentation, err)
+	}
+
+	matched, err := definition.Match(vp, s.documentLoader,
+		presexch.WithCredentialOptions(
+			verifiable.WithPublicKeyFetcher(s.keyFetcher),
+			verifiable.WithJSONLDDocumentLoader(s.documentLoader),
+		),
+	)
+	if err != nil {
+		return fmt.Errorf("%w: match presentation definition: %s", ErrInvalidPresentation, err)
+	}
+
+	for id, vc := range matched {
+		if len(vc.Proofs) == 0 {
+			return fmt.Errorf("%w: credential for input descriptor %q is not signed", ErrInvalidPresentation, id)
+		}
+
+		if s.validator != nil {
+			if err = s.validator.Validate(vc); err != nil {
+				return fmt.Errorf("%w: credential for input descriptor %q: %s", ErrInvalidPresentation, id, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func checkProofs(proofs []verifiable.Proof, holder string) error {
+	if len(proofs) == 0 {
+		return errors.New("presentation is not signed")
+	}
+
+	for _, proof := range proofs {
+		verificationMethod, ok := proof["verificationMethod"].(string)
+		if !ok {
+			return errors.New("missing verification method in presentation proof")
+		}
+
+		if strings.Split(verificationMethod, "#")[0] != holder {
+			return fmt.Errorf("presentation is not signed by %s", holder)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,250 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package presentation_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/aries-framework-go/pkg/doc/did"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/presexch"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/signature/jsonld"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/signature/suite"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/signature/suite/ed25519signature2018"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/util"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
+	vdrapi "github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdr"
+	mockvdr "github.com/hyperledger/aries-framework-go/pkg/mock/vdr"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/ace/pkg/doc/vc/schema"
+	"github.com/trustbloc/ace/pkg/gatekeeper/presentation"
+	"github.com/trustbloc/ace/pkg/internal/testutil"
+)
+
+const (
+	holderDID    = "did:example:holder"
+	issuerDID    = "did:example:issuer"
+	descriptorID = "degree"
+)
+
+func TestService_Verify(t *testing.T) {
+	loader := testutil.DocumentLoader(t)
+	keys := map[string]ed25519.PrivateKey{
+		holderDID: generateKey(t),
+		issuerDID: generateKey(t),
+	}
+
+	svc := presentation.NewService(&presentation.Config{
+		VDR:            newMockVDR(keys),
+		DocumentLoader: loader,
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		vc := createCredential(t, keys[issuerDID], issuerDID)
+		vp := createPresentation(t, vc, keys[holderDID], holderDID, true)
+
+		err := svc.Verify(context.Background(), presentationDefinition(), vp, holderDID)
+		require.NoError(t, err)
+	})
+
+	t.Run("Fail to parse presentation", func(t *testing.T) {
+		err := svc.Verify(context.Background(), presentationDefinition(), []byte("invalid"), holderDID)
+		require.ErrorIs(t, err, presentation.ErrInvalidPresentation)
+		require.Contains(t, err.Error(), "parse presentation")
+	})
+
+	t.Run("Presentation is not signed", func(t *testing.T) {
+		vc := createCredential(t, keys[issuerDID], issuerDID)
+		vp := createPresentation(t, vc, nil, holderDID, true)
+
+		err := svc.Verify(context.Background(), presentationDefinition(), vp, holderDID)
+		require.ErrorIs(t, err, presentation.ErrInvalidPresentation)
+		require.Contains(t, err.Error(), "presentation is not signed")
+	})
+
+	t.Run("Presentation is not signed by holder", func(t *testing.T) {
+		vc := createCredential(t, keys[issuerDID], issuerDID)
+		vp := createPresentation(t, vc, keys[issuerDID], issuerDID, true)
+
+		err := svc.Verify(context.Background(), presentationDefinition(), vp, holderDID)
+		require.ErrorIs(t, err, presentation.ErrInvalidPresentation)
+		require.Contains(t, err.Error(), "presentation is not signed by "+holderDID)
+	})
+
+	t.Run("Missing presentation submission", func(t *testing.T) {
+		vc := createCredential(t, keys[issuerDID], issuerDID)
+		vp := createPresentation(t, vc, keys[holderDID], holderDID, false)
+
+		err := svc.Verify(context.Background(), presentationDefinition(), vp, holderDID)
+		require.ErrorIs(t, err, presentation.ErrInvalidPresentation)
+		require.Contains(t, err.Error(), "match presentation definition")
+	})
+
+	t.Run("Credential does not conform to schema", func(t *testing.T) {
+		validator, err := schema.NewValidator(&schema.Config{
+			Schemas: []*schema.Schema{{
+				ID:             "https://example.com/schemas/subject.json",
+				CredentialType: verifiable.VCType,
+				Document:       []byte(`{"required":["credentialSubject"],"properties":{"credentialSubject":{"type":"object"}}}`),
+			}},
+		})
+		require.NoError(t, err)
+
+		s := presentation.NewService(&presentation.Config{
+			VDR:                 newMockVDR(keys),
+			DocumentLoader:      loader,
+			CredentialValidator: validator,
+		})
+
+		vc := createCredential(t, keys[issuerDID], issuerDID)
+		vp := createPresentation(t, vc, keys[holderDID], holderDID, true)
+
+		err = s.Verify(context.Background(), presentationDefinition(), vp, holderDID)
+		require.ErrorIs(t, err, presentation.ErrInvalidPresentation)
+		require.Contains(t, err.Error(), "does not conform to schema")
+	})
+
+	t.Run("Credential is not signed", func(t *testing.T) {
+		vc := createCredential(t, nil, issuerDID)
+		vp := createPresentation(t, vc, keys[holderDID], holderDID, true)
+
+		err := svc.Verify(context.Background(), presentationDefinition(), vp, holderDID)
+		require.ErrorIs(t, err, presentation.ErrInvalidPresentation)
+		require.Contains(t, err.Error(), "is not signed")
+	})
+}
+
+func presentationDefinition() *presexch.PresentationDefinition {
+	return &presexch.PresentationDefinition{
+		ID: "test-definition",
+		InputDescriptors: []*presexch.InputDescriptor{{
+			ID: descriptorID,
+			Schema: []*presexch.Schema{{
+				URI: "https://www.w3.org/2018/credentials#VerifiableCredential",
+			}},
+		}},
+	}
+}
+
+func createCredential(t *testing.T, key ed25519.PrivateKey, issuer string) *verifiable.Credential {
+	t.Helper()
+
+	vc := &verifiable.Credential{
+		Context: []string{verifiable.ContextURI},
+		ID:      "http://example.com/credentials/1",
+		Types:   []string{verifiable.VCType},
+		Issuer:  verifiable.Issuer{ID: issuer},
+		Issued:  &util.TimeWrapper{Time: time.Now()},
+		Subject: holderDID,
+	}
+
+	if key != nil {
+		err := vc.AddLinkedDataProof(proofContext(key, issuer),
+			jsonld.WithDocumentLoader(testutil.DocumentLoader(t)))
+		require.NoError(t, err)
+	}
+
+	return vc
+}
+
+func createPresentation(t *testing.T, vc *verifiable.Credential, key ed25519.PrivateKey, holder string,
+	withSubmission bool) []byte {
+	t.Helper()
+
+	vp, err := verifiable.NewPresentation(verifiable.WithCredentials(vc))
+	require.NoError(t, err)
+
+	vp.Holder = holder
+
+	if withSubmission {
+		vp.Context = append(vp.Context, presexch.PresentationSubmissionJSONLDContextIRI)
+		vp.Type = append(vp.Type, presexch.PresentationSubmissionJSONLDType)
+		vp.CustomFields = verifiable.CustomFields{
+			"presentation_submission": &presexch.PresentationSubmission{
+				ID:           "test-submission",
+				DefinitionID: "test-definition",
+				DescriptorMap: []*presexch.InputDescriptorMapping{{
+					ID:     descriptorID,
+					Format: "ldp_vc",
+					Path:   "$.verifiableCredential[0]",
+				}},
+			},
+		}
+	}
+
+	if key != nil {
+		err = vp.AddLinkedDataProof(proofContext(key, holder),
+			jsonld.WithDocumentLoader(testutil.DocumentLoader(t)))
+		require.NoError(t, err)
+	}
+
+	b, err := json.Marshal(vp)
+	require.NoError(t, err)
+
+	return b
+}
+
+func proofContext(key ed25519.PrivateKey, controller string) *verifiable.LinkedDataProofContext {
+	return &verifiable.LinkedDataProofContext{
+		SignatureType:           "Ed25519Signature2018",
+		Suite:                   ed25519signature2018.New(suite.WithSigner(&signer{key: key})),
+		SignatureRepresentation: verifiable.SignatureJWS,
+		VerificationMethod:      controller + "#key1",
+	}
+}
+
+func newMockVDR(keys map[string]ed25519.PrivateKey) *mockvdr.MockVDRegistry {
+	return &mockvdr.MockVDRegistry{
+		ResolveFunc: func(id string, _ ...vdrapi.DIDMethodOption) (*did.DocResolution, error) {
+			key, ok := keys[id]
+			if !ok {
+				return nil, vdrapi.ErrNotFound
+			}
+
+			vm := did.VerificationMethod{
+				ID:         id + "#key1",
+				Type:       "Ed25519VerificationKey2018",
+				Controller: id,
+				Value:      key.Public().(ed25519.PublicKey), //nolint:forcetypeassert
+			}
+
+			return &did.DocResolution{DIDDocument: &did.Doc{
+				Context:            []string{did.ContextV1},
+				ID:                 id,
+				VerificationMethod: []did.VerificationMethod{vm},
+				AssertionMethod:    []did.Verification{{VerificationMethod: vm}},
+				Authentication:     []did.Verification{{VerificationMethod: vm}},
+			}}, nil
+		},
+	}
+}
+
+func generateKey(t *testing.T) ed25519.PrivateKey {
+	t.Helper()
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	return key
+}
+
+type signer struct {
+	key ed25519.PrivateKey
+}
+
+func (s *signer) Sign(data []byte) ([]byte, error) {
+	return ed25519.Sign(s.key, data), nil
+}
+
+func (s *signer) Alg() string {
+	return ""
+}
@@ -0,0 +1,195 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package protect
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/aries-framework-go/pkg/doc/did"
+	"github.com/hyperledger/aries-framework-go/spi/storage"
+)
+
+const (
+	anchoringStoreName = "protected_data_anchoring"
+	anchorStatusIndex  = "anchorStatus"
+)
+
+// AnchorStatus is the status of the anchoring of the DID of protected data on a Sidetree-based ledger, such as Orb.
+type AnchorStatus string
+
+const (
+	// AnchorNone is the status of DIDs of methods that are not anchored, such as did:key, and of DIDs created before
+	// their anchoring was tracked.
+	AnchorNone AnchorStatus = "none"
+	// AnchorPending is the status of DIDs created but not anchored yet. They resolve as unpublished DIDs.
+	AnchorPending AnchorStatus = "pending"
+	// AnchorAnchored is the status of DIDs anchored on the ledger.
+	AnchorAnchored AnchorStatus = "anchored"
+)
+
+// Anchoring is the anchoring status of the DID of protected data.
+type Anchoring struct {
+	DID    string       `json:"did"`
+	Status AnchorStatus `json:"status"`
+	// CanonicalDID, once the DID is anchored, is the DID it is anchored with. The DID of the protected data keeps
+	// resolving to the same document.
+	CanonicalDID string `json:"canonical_did,omitempty"`
+	// Updated is the time the status was last changed.
+	Updated time.Time `json:"updated"`
+}
+
+// Anchoring returns the anchoring status of the DID of protected data.
+func (s *Service) Anchoring(_ context.Context, targetDID string) (*Anchoring, error) {
+	b, err := s.anchoring.Get(targetDID)
+	if errors.Is(err, storage.ErrDataNotFound) {
+		return &Anchoring{DID: targetDID, Status: AnchorNone}, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("get anchoring: %w", err)
+	}
+
+	var a Anchoring
+
+	if err = json.Unmarshal(b, &a); err != nil {
+		return nil, fmt.Errorf("unmarshal anchoring: %w", err)
+	}
+
+	return &a, nil
+}
+
+// CheckAnchoring resolves the DIDs pending anchoring and records the ones that have been anchored since. It returns
+// the number of DIDs found anchored. DIDs that fail to resolve are checked again on the next call.
+func (s *Service) CheckAnchoring(_ context.Context) (int, error) {
+	pending, err := s.pendingAnchoring()
+	if err != nil {
+		return 0, err
+	}
+
+	var ops []storage.Operation
+
+	for _, a := range pending {
+		res, e := s.vdr.Resolve(a.DID)
+		if e != nil {
+			logger.Warnf("Failed to resolve DID %s pending anchoring: %s", a.DID, e)
+
+			continue
+		}
+
+		anchored := anchoringOf(a.DID, res)
+		if anchored == nil || anchored.Status == AnchorPending {
+			continue
+		}
+
+		op, e := anchoringOperation(anchored)
+		if e != nil {
+			return 0, e
+		}
+
+		ops = append(ops, op)
+	}
+
+	if len(ops) == 0 {
+		return 0, nil
+	}
+
+	if err = s.anchoring.Batch(ops); err != nil {
+		return 0, fmt.Errorf("save anchoring: %w", err)
+	}
+
+	return len(ops), nil
+}
+
+func (s *Service) pendingAnchoring() ([]*Anchoring, error) {
+	iter, err := s.anchoring.Query(anchorStatusIndex + ":" + string(AnchorPending))
+	if err != nil {
+		return nil, fmt.Errorf("query anchoring: %w", err)
+	}
+
+	defer func() {
+		if e := iter.Close(); e != nil {
+			logger.Errorf("Failed to close iterator: %s", e.Error())
+		}
+	}()
+
+	var pending []*Anchoring
+
+	for {
+		ok, e := iter.Next()
+		if e != nil {
+			return nil, fmt.Errorf("next entry: %w", e)
+		}
+
+		if !ok {
+			return pending, nil
+		}
+
+		v, e := iter.Value()
+		if e != nil {
+			return nil, fmt.Errorf("get value: %w", e)
+		}
+
+		var a Anchoring
+
+		if e = json.Unmarshal(v, &a); e != nil {
+			return nil, fmt.Errorf("unmarshal anchoring: %w", e)
+		}
+
+		pending = append(pending, &a)
+	}
+}
+
+// saveAnchoring records the anchoring status of a DID just created, if its method anchors DIDs.
+func (s *Service) saveAnchoring(didID string, res *did.DocResolution) error {
+	a := anchoringOf(didID, res)
+	if a == nil {
+		return nil
+	}
+
+	op, err := anchoringOperation(a)
+	if err != nil {
+		return err
+	}
+
+	if err = s.anchoring.Put(op.Key, op.Value, op.Tags...); err != nil {
+		return fmt.Errorf("save anchoring: %w", err)
+	}
+
+	return nil
+}
+
+// anchoringOf returns the anchoring status of the resolved DID, or nil if its method does not anchor DIDs.
+func anchoringOf(didID string, res *did.DocResolution) *Anchoring {
+	if res == nil || res.DocumentMetadata == nil || res.DocumentMetadata.Method == nil {
+		return nil
+	}
+
+	a := &Anchoring{DID: didID, Status: AnchorPending, Updated: time.Now().UTC()}
+
+	if res.DocumentMetadata.Method.Published {
+		a.Status, a.CanonicalDID = AnchorAnchored, res.DocumentMetadata.CanonicalID
+	}
+
+	return a
+}
+
+func anchoringOperation(a *Anchoring) (storage.Operation, error) {
+	b, err := json.Marshal(a)
+	if err != nil {
+		return storage.Operation{}, fmt.Errorf("marshal anchoring: %w", err)
+	}
+
+	return storage.Operation{
+		Key:   a.DID,
+		Value: b,
+		Tags:  []storage.Tag{{Name: anchorStatusIndex, Value: string(a.Status)}},
+	}, nil
+}
@@ -7,7 +7,7 @@ SPDX-License-Identifier: Apache-2.0
 package protect
 
 //nolint: lll
-//go:generate mockgen -destination gomocks_test.go -package protect_test -source=service.go -mock_names vaultClient=MockVault,vdrRegistry=MockVDR,vcIssuer=MockVCIssuer
+//go:generate mockgen -destination gomocks_test.go -package protect_test -source=service.go -mock_names vaultClient=MockVault,vdrRegistry=MockVDR,vcIssuer=MockVCIssuer,credentialValidator=MockCredentialValidator
 
 import (
 	"context"
@@ -16,7 +16,6 @@ import (
 	"errors"
 	"fmt"
 	"hash/fnv"
-	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -27,8 +26,13 @@ import (
 	"github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdr"
 	"github.com/hyperledger/aries-framework-go/spi/storage"
 	"github.com/trustbloc/edv/pkg/edvutils"
+	"golang.org/x/sync/errgroup"
 
+	vaultclient "github.com/trustbloc/ace/pkg/client/vault"
 	"github.com/trustbloc/ace/pkg/restapi/vault"
+	"github.com/trustbloc/ace/pkg/storage/cursor"
+	"github.com/trustbloc/ace/pkg/storage/index"
+	"github.com/trustbloc/ace/pkg/storage/tombstone"
 )
 
 const (
@@ -36,13 +40,34 @@ const (
 	storeName         = "protected_data"
 	resolveMaxRetry   = 10
 	policyIndex       = "policyID"
+	didIndex          = "did"
+	subjectIndex      = "subject"
+
+	// ContinuityCredentialType is the type of the credentials linking the previous DID of rotated protected data to
+	// the new one.
+	ContinuityCredentialType = "DIDContinuityCredential"
+)
+
+var (
+	// ErrTargetMismatch is returned when rotating or moving protected data with a target it was not protected from.
+	ErrTargetMismatch = errors.New("target does not match the protected data")
+	// ErrAlreadyProtected is returned when moving protected data under a policy its target is already protected with.
+	ErrAlreadyProtected = errors.New("target is already protected with the policy")
+	// ErrAlreadyLinked is returned when linking protected data to a subject it is not already linked to.
+	ErrAlreadyLinked = errors.New("protected data is linked to another subject")
+	// ErrUnknownRegion is returned when protecting data in a residency region without a vault client.
+	ErrUnknownRegion = errors.New("unknown residency region")
+	// ErrRegionMismatch is returned when protecting data already protected with the policy in another region.
+	ErrRegionMismatch = errors.New("target is already protected in another region")
 )
 
 var logger = log.New("protect-svc")
 
 type vaultClient interface {
-	CreateVault() (*vault.CreatedVault, error)
-	SaveDoc(vaultID, id string, content interface{}) (*vault.DocumentMetadata, error)
+	CreateVault(ctx context.Context) (*vault.CreatedVault, error)
+	DeleteVault(ctx context.Context, vaultID string) error
+	SaveDoc(ctx context.Context, vaultID, id string, content interface{},
+		tags ...string) (*vault.DocumentMetadata, error)
 }
 
 type vdrRegistry interface {
@@ -53,39 +78,81 @@ type vcIssuer interface {
 	IssueCredential(ctx context.Context, cred []byte) (*verifiable.Credential, error)
 }
 
+type credentialValidator interface {
+	Validate(vc *verifiable.Credential) error
+}
+
 // Config defines dependencies for Service.
 type Config struct {
 	StoreProvider storage.Provider
 	VaultClient   vaultClient
 	VDR           vdrRegistry
 	VCIssuer      vcIssuer
+	// Optional validator for credentials produced by the VC issuer.
+	CredentialValidator credentialValidator
+	// RegionVaultClients are the vault clients of the residency regions, keyed by region. The vaults of the data
+	// protected in a region are created with its client, and those of the data protected without one with VaultClient.
+	RegionVaultClients map[string]vaultclient.Vault
+}
+
+// Option configures the protection of data.
+type Option func(opts *options)
+
+type options struct {
+	region string
+}
+
+// WithRegion protects the data in the residency region: its vault is created with the vault client of the region.
+func WithRegion(region string) Option {
+	return func(opts *options) {
+		opts.region = region
+	}
 }
 
 // Service is a service for converting sensitive data into DID.
 type Service struct {
-	store       storage.Store
-	vaultClient vaultClient
-	vdr         vdrRegistry
-	issuer      vcIssuer
+	store        storage.Store
+	tombstones   *tombstone.Tombstones
+	anchoring    storage.Store
+	vaultClient  vaultClient
+	regionVaults map[string]vaultclient.Vault
+	vdr          vdrRegistry
+	issuer       vcIssuer
+	validator    credentialValidator
 }
 
 // NewService returns a new instance of Service.
 func NewService(config *Config) (*Service, error) {
-	store, err := config.StoreProvider.OpenStore(storeName)
+	store, err := index.OpenStore(config.StoreProvider, &index.Declaration{
+		Store:    storeName,
+		TagNames: []string{policyIndex, didIndex, subjectIndex},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("open protected data store: %w", err)
+	}
+
+	tombstones, err := tombstone.New(config.StoreProvider, storeName, store)
 	if err != nil {
 		return nil, fmt.Errorf("open protected data store: %w", err)
 	}
 
-	err = config.StoreProvider.SetStoreConfig(storeName, storage.StoreConfiguration{TagNames: []string{policyIndex}})
+	anchoring, err := index.OpenStore(config.StoreProvider, &index.Declaration{
+		Store:    anchoringStoreName,
+		TagNames: []string{anchorStatusIndex},
+	})
 	if err != nil {
-		return nil, fmt.Errorf("set protected data store configuration: %w", err)
+		return nil, fmt.Errorf("open anchoring store: %w", err)
 	}
 
 	return &Service{
-		store:       store,
-		vaultClient: config.VaultClient,
-		vdr:         config.VDR,
-		issuer:      config.VCIssuer,
+		store:        store,
+		tombstones:   tombstones,
+		anchoring:    anchoring,
+		vaultClient:  config.VaultClient,
+		regionVaults: config.RegionVaultClients,
+		vdr:          config.VDR,
+		issuer:       config.VCIssuer,
+		validator:    config.CredentialValidator,
 	}, nil
 }
 
@@ -94,13 +161,34 @@ type ProtectedData struct {
 	DID      string `json:"did"`
 	VCDocID  string `json:"vc_doc_id,omitempty"`
 	PolicyID string `json:"policy_id,omitempty"`
+	// Subject, if linked, is the DID of the subject the data belongs to.
+	Subject string `json:"subject,omitempty"`
+	// Region, if set, is the residency region the vault of the data is kept in.
+	Region string `json:"region,omitempty"`
 }
 
 // Get gets protected data for target DID.
 func (s *Service) Get(_ context.Context, targetDID string) (*ProtectedData, error) {
-	iter, err := s.store.Query(policyIndex)
+	_, data, err := s.get(targetDID)
+
+	return data, err
+}
+
+// get returns the hash and the protected data of the DID.
+func (s *Service) get(targetDID string) (string, *ProtectedData, error) {
+	hash, data, err := s.find(didIndex+":"+index.TagValue(targetDID), targetDID)
+	if errors.Is(err, storage.ErrDataNotFound) {
+		// protected data stored before the did index was introduced is only tagged with its policy
+		hash, data, err = s.find(policyIndex, targetDID)
+	}
+
+	return hash, data, err
+}
+
+func (s *Service) find(expression, targetDID string) (string, *ProtectedData, error) {
+	iter, err := s.store.Query(expression)
 	if err != nil {
-		return nil, fmt.Errorf("query protected data: %w", err)
+		return "", nil, fmt.Errorf("query protected data: %w", err)
 	}
 
 	defer func() {
@@ -113,7 +201,7 @@ func (s *Service) Get(_ context.Context, targetDID string) (*ProtectedData, erro
 	for {
 		if ok, err := iter.Next(); !ok || err != nil {
 			if err != nil {
-				return nil, fmt.Errorf("next entry: %w", err)
+				return "", nil, fmt.Errorf("next entry: %w", err)
 			}
 
 			break
@@ -121,25 +209,39 @@ func (s *Service) Get(_ context.Context, targetDID string) (*ProtectedData, erro
 
 		v, err := iter.Value()
 		if err != nil {
-			return nil, fmt.Errorf("get value: %w", err)
+			return "", nil, fmt.Errorf("get value: %w", err)
 		}
 
 		var data ProtectedData
 
 		if err = json.Unmarshal(v, &data); err != nil {
-			return nil, fmt.Errorf("unmarshal data: %w", err)
+			return "", nil, fmt.Errorf("unmarshal data: %w", err)
+		}
+
+		if data.DID != targetDID {
+			continue
 		}
 
-		if data.DID == targetDID {
-			return &data, nil
+		key, err := iter.Key()
+		if err != nil {
+			return "", nil, fmt.Errorf("get key: %w", err)
 		}
+
+		return key, &data, nil
 	}
 
-	return nil, fmt.Errorf("get protected data: %w", storage.ErrDataNotFound)
+	return "", nil, fmt.Errorf("get protected data: %w", storage.ErrDataNotFound)
 }
 
-// Protect converts sensitive data into DID.
-func (s *Service) Protect(ctx context.Context, target, policyID string) (*ProtectedData, error) {
+// Protect converts sensitive data into DID. Data already protected with the policy is returned as is, if it is kept
+// in the same residency region.
+func (s *Service) Protect(ctx context.Context, target, policyID string, opts ...Option) (*ProtectedData, error) {
+	o := &options{}
+
+	for _, opt := range opts {
+		opt(o)
+	}
+
 	hash, err := calculateHash(target, policyID)
 	if err != nil {
 		return nil, fmt.Errorf("calculate hash: %w", err)
@@ -151,51 +253,558 @@ func (s *Service) Protect(ctx context.Context, target, policyID string) (*Protec
 	}
 
 	if b != nil {
-		var data ProtectedData
+		return existingData(b, o.region)
+	}
+
+	data, err := s.create(ctx, target, policyID, o.region)
+	if err != nil {
+		return nil, err
+	}
+
+	op, err := putOperation(hash, data)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.store.Put(op.Key, op.Value, op.Tags...); err != nil {
+		s.deleteVaults(data)
+
+		return nil, fmt.Errorf("save protected data: %w", err)
+	}
+
+	return data, nil
+}
+
+// ProtectAll converts several sensitive data under the same policy into DIDs. Existing protected data is read and
+// new protected data is saved in one batch each. The results are in the order of targets.
+func (s *Service) ProtectAll(ctx context.Context, policyID string, targets ...string) ([]*ProtectedData, error) {
+	hashes, err := calculateHashes(policyID, targets)
+	if err != nil {
+		return nil, err
+	}
+
+	values, err := s.store.GetBulk(hashes...)
+	if err != nil {
+		return nil, fmt.Errorf("get protected data by hash: %w", err)
+	}
+
+	results := make([]*ProtectedData, len(targets))
+	created := make(map[string]*ProtectedData)
+
+	var operations []storage.Operation
+
+	for i, target := range targets {
+		if values[i] != nil {
+			if results[i], err = unmarshalData(values[i]); err != nil {
+				return nil, err
+			}
+
+			continue
+		}
+
+		// the same target may be listed more than once
+		if data, ok := created[hashes[i]]; ok {
+			results[i] = data
+
+			continue
+		}
+
+		data, err := s.create(ctx, target, policyID, "")
+		if err != nil {
+			s.deleteVaults(createdData(created)...)
+
+			return nil, err
+		}
+
+		op, err := putOperation(hashes[i], data)
+		if err != nil {
+			s.deleteVaults(append(createdData(created), data)...)
+
+			return nil, err
+		}
+
+		operations = append(operations, op)
+		created[hashes[i]] = data
+		results[i] = data
+	}
+
+	if len(operations) == 0 {
+		return results, nil
+	}
+
+	if err = s.store.Batch(operations); err != nil {
+		s.deleteVaults(createdData(created)...)
+
+		return nil, fmt.Errorf("save protected data: %w", err)
+	}
+
+	return results, nil
+}
+
+// Repolicy moves the protected data under another policy. The target must be the one the data was protected from.
+// If reanonymize is set, the data gets a new DID, like rotated data, and the vault of the previous DID is deleted.
+func (s *Service) Repolicy(ctx context.Context, previous *ProtectedData, target, policyID string,
+	reanonymize bool) (*ProtectedData, error) {
+	previousHash, stored, err := s.getStored(previous, target)
+	if err != nil {
+		return nil, err
+	}
+
+	hash, err := calculateHash(target, policyID)
+	if err != nil {
+		return nil, fmt.Errorf("calculate hash: %w", err)
+	}
+
+	if _, err = s.store.Get(hash); err == nil {
+		return nil, ErrAlreadyProtected
+	} else if !errors.Is(err, storage.ErrDataNotFound) {
+		return nil, fmt.Errorf("get protected data by hash: %w", err)
+	}
+
+	data := &ProtectedData{DID: stored.DID, VCDocID: stored.VCDocID, PolicyID: policyID, Region: stored.Region}
 
-		if err = json.Unmarshal(b, &data); err != nil {
-			return nil, fmt.Errorf("unmarshal protected data: %w", err)
+	if reanonymize {
+		if data, err = s.create(ctx, target, policyID, stored.Region); err != nil {
+			return nil, err
 		}
+	}
 
-		return &data, nil
+	data.Subject = stored.Subject
+
+	op, err := putOperation(hash, data)
+	if err == nil {
+		// the previous entry is deleted in the same batch
+		err = s.store.Batch([]storage.Operation{op, {Key: previousHash}})
 	}
 
-	vaultData, err := s.vaultClient.CreateVault()
 	if err != nil {
-		return nil, fmt.Errorf("create vault: %w", err)
+		if reanonymize {
+			s.deleteVaults(data)
+		}
+
+		return nil, fmt.Errorf("move protected data: %w", err)
 	}
 
-	vaultID := vaultData.ID
+	if reanonymize {
+		s.deleteVaults(stored)
+	}
+
+	return data, nil
+}
 
-	vc, err := s.wrapDataIntoVC(ctx, vaultID, target)
+// getStored returns the hash and the stored protected data of the target, if it is the previous protected data.
+func (s *Service) getStored(previous *ProtectedData, target string) (string, *ProtectedData, error) {
+	hash, err := calculateHash(target, previous.PolicyID)
 	if err != nil {
-		return nil, fmt.Errorf("wrap data into vc: %w", err)
+		return "", nil, fmt.Errorf("calculate hash: %w", err)
 	}
 
-	// resolve DID
-	err = resolveDID(s.vdr, vaultID, resolveMaxRetry)
+	b, err := s.store.Get(hash)
+	if errors.Is(err, storage.ErrDataNotFound) {
+		return "", nil, ErrTargetMismatch
+	}
+
+	if err != nil {
+		return "", nil, fmt.Errorf("get protected data by hash: %w", err)
+	}
+
+	stored, err := unmarshalData(b)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if stored.DID != previous.DID {
+		return "", nil, ErrTargetMismatch
+	}
+
+	return hash, stored, nil
+}
+
+// Rotate replaces the DID of the protected data with a new one, so that the data cannot be correlated by the parties
+// the previous DID was disclosed to. The target must be the one the data was protected from. A continuity credential
+// linking the previous DID to the new one is issued, and the vault of the previous DID is deleted.
+func (s *Service) Rotate(ctx context.Context, previous *ProtectedData,
+	target string) (*ProtectedData, *verifiable.Credential, error) {
+	hash, stored, err := s.getStored(previous, target)
 	if err != nil {
-		return nil, fmt.Errorf("resolve did %s : %w", vaultID, err)
+		return nil, nil, err
 	}
 
-	vcDocID, err := s.saveVCDoc(vaultID, vc)
+	data, err := s.create(ctx, target, previous.PolicyID, stored.Region)
 	if err != nil {
+		return nil, nil, err
+	}
+
+	vc, err := s.issueContinuityCredential(ctx, previous.DID, data.DID)
+	if err != nil {
+		s.deleteVaults(data)
+
+		return nil, nil, fmt.Errorf("issue continuity credential: %w", err)
+	}
+
+	data.Subject = stored.Subject
+
+	op, err := putOperation(hash, data)
+	if err != nil {
+		s.deleteVaults(data)
+
+		return nil, nil, err
+	}
+
+	if err = s.store.Put(op.Key, op.Value, op.Tags...); err != nil {
+		s.deleteVaults(data)
+
+		return nil, nil, fmt.Errorf("save protected data: %w", err)
+	}
+
+	s.deleteVaults(stored)
+
+	return data, vc, nil
+}
+
+// Link links protected data to the subject it belongs to. The targets, in the order of the protected data, are the
+// proof: they must be the ones the data was protected from. Either all of the data is linked or none of it.
+func (s *Service) Link(_ context.Context, subject string, data []*ProtectedData,
+	targets []string) ([]*ProtectedData, error) {
+	if len(targets) != len(data) {
+		return nil, ErrTargetMismatch
+	}
+
+	ops := make([]storage.Operation, len(data))
+	linked := make([]*ProtectedData, len(data))
+
+	for i, d := range data {
+		hash, stored, err := s.getStored(d, targets[i])
+		if err != nil {
+			return nil, err
+		}
+
+		if stored.Subject != "" && stored.Subject != subject {
+			return nil, ErrAlreadyLinked
+		}
+
+		stored.Subject = subject
+
+		if ops[i], err = putOperation(hash, stored); err != nil {
+			return nil, err
+		}
+
+		linked[i] = stored
+	}
+
+	if err := s.store.Batch(ops); err != nil {
+		return nil, fmt.Errorf("link protected data: %w", err)
+	}
+
+	return linked, nil
+}
+
+// IterateSubject calls fn for the protected data linked to the subject, reading pageSize entries at a time.
+// Returning cursor.ErrStop from fn stops the iteration.
+func (s *Service) IterateSubject(_ context.Context, subject string, pageSize int,
+	fn func(data *ProtectedData) error) error {
+	c, err := cursor.New(s.store, subjectIndex+":"+index.TagValue(subject), pageSize)
+	if err != nil {
+		return fmt.Errorf("query protected data: %w", err)
+	}
+
+	return iterate(c, fn)
+}
+
+// Delete deletes the protected data of several DIDs, e.g. all of the data of a subject on their request for
+// erasure. Missing data is ignored. The protected data can be restored until it is purged.
+func (s *Service) Delete(_ context.Context, dids ...string) error {
+	hashes := make([]string, 0, len(dids))
+
+	for _, d := range dids {
+		hash, _, err := s.get(d)
+		if errors.Is(err, storage.ErrDataNotFound) {
+			continue
+		}
+
+		if err != nil {
+			return err
+		}
+
+		hashes = append(hashes, hash)
+	}
+
+	if err := s.tombstones.Delete(time.Now(), hashes...); err != nil {
+		return fmt.Errorf("delete protected data: %w", err)
+	}
+
+	return nil
+}
+
+// issueContinuityCredential issues a credential stating that the protected data with the new DID is the one that
+// had the previous DID.
+func (s *Service) issueContinuityCredential(ctx context.Context, previousDID,
+	newDID string) (*verifiable.Credential, error) {
+	cred := verifiable.Credential{
+		ID:      uuid.New().URN(),
+		Context: []string{credentialContext},
+		Types:   []string{"VerifiableCredential", ContinuityCredentialType},
+		// issuerID will be overwritten in the issuer
+		Issuer: verifiable.Issuer{ID: uuid.New().URN()},
+		Issued: util.NewTime(time.Now().UTC()),
+		Subject: map[string]interface{}{
+			"id":          newDID,
+			"previousDID": previousDID,
+		},
+	}
+
+	credBytes, err := cred.MarshalJSON()
+	if err != nil {
+		return nil, fmt.Errorf("marshal credential: %w", err)
+	}
+
+	return s.issuer.IssueCredential(ctx, credBytes)
+}
+
+func createdData(created map[string]*ProtectedData) []*ProtectedData {
+	all := make([]*ProtectedData, 0, len(created))
+
+	for _, data := range created {
+		all = append(all, data)
+	}
+
+	return all
+}
+
+// Restore restores the deleted protected data of target under the policy.
+func (s *Service) Restore(_ context.Context, policyID, target string) error {
+	hash, err := calculateHash(target, policyID)
+	if err != nil {
+		return fmt.Errorf("calculate hash: %w", err)
+	}
+
+	if err = s.tombstones.Restore(hash); err != nil {
+		return fmt.Errorf("restore protected data: %w", err)
+	}
+
+	return nil
+}
+
+// Cursor iterates over protected data a page at a time.
+type Cursor struct {
+	cursor *cursor.Cursor
+}
+
+// Next returns the protected data of the next page. It returns no data once all of it has been read.
+func (c *Cursor) Next() ([]*ProtectedData, error) {
+	values, err := c.cursor.Next()
+	if err != nil {
+		return nil, fmt.Errorf("next protected data: %w", err)
+	}
+
+	return unmarshalAll(values)
+}
+
+// Close releases the cursor.
+func (c *Cursor) Close() error {
+	return c.cursor.Close()
+}
+
+// Query returns a cursor over the protected data under policyID, or over all protected data if policyID is empty,
+// reading pageSize entries at a time.
+func (s *Service) Query(_ context.Context, policyID string, pageSize int) (*Cursor, error) {
+	expression := policyIndex
+	if policyID != "" {
+		expression += ":" + policyID
+	}
+
+	c, err := cursor.New(s.store, expression, pageSize)
+	if err != nil {
+		return nil, fmt.Errorf("query protected data: %w", err)
+	}
+
+	return &Cursor{cursor: c}, nil
+}
+
+// Iterate calls fn for the protected data under policyID, or for all protected data if policyID is empty, reading
+// pageSize entries at a time. Returning cursor.ErrStop from fn stops the iteration.
+func (s *Service) Iterate(ctx context.Context, policyID string, pageSize int,
+	fn func(data *ProtectedData) error) error {
+	c, err := s.Query(ctx, policyID, pageSize)
+	if err != nil {
+		return err
+	}
+
+	return iterate(c.cursor, fn)
+}
+
+func iterate(c *cursor.Cursor, fn func(data *ProtectedData) error) error {
+	return cursor.Iterate(c, func(values [][]byte) error {
+		all, err := unmarshalAll(values)
+		if err != nil {
+			return err
+		}
+
+		for _, data := range all {
+			if err = fn(data); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// create creates a vault holding target wrapped into a VC, the vault ID is the DID of the protected data.
+// create creates a vault for the target and saves the credential with the target in it. The credential is issued
+// while the DID of the vault is being resolved. The vault is deleted if any step fails. If the DID is anchored on a
+// ledger, its anchoring status is recorded. The vault is created in the residency region, if one is set.
+func (s *Service) create(ctx context.Context, target, policyID, region string) (*ProtectedData, error) {
+	vc, err := s.vault(region)
+	if err != nil {
+		return nil, err
+	}
+
+	vaultData, err := vc.CreateVault(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("create vault: %w", err)
+	}
+
+	vaultID := vaultData.ID
+	created := &ProtectedData{DID: vaultID, Region: region}
+
+	var (
+		cred *verifiable.Credential
+		res  *did.DocResolution
+	)
+
+	g, gctx := errgroup.WithContext(ctx)
+
+	g.Go(func() error {
+		var e error
+
+		cred, e = s.wrapDataIntoVC(gctx, vaultID, target)
+		if e != nil {
+			return fmt.Errorf("wrap data into vc: %w", e)
+		}
+
+		return nil
+	})
+
+	g.Go(func() error {
+		var e error
+
+		res, e = resolveDID(gctx, s.vdr, vaultID, resolveMaxRetry)
+		if e != nil {
+			return fmt.Errorf("resolve did %s : %w", vaultID, e)
+		}
+
+		return nil
+	})
+
+	if err = g.Wait(); err != nil {
+		s.deleteVaults(created)
+
+		return nil, err
+	}
+
+	vcDocID, err := saveVCDoc(ctx, vc, vaultID, cred)
+	if err != nil {
+		s.deleteVaults(created)
+
 		return nil, fmt.Errorf("save vc doc: %w", err)
 	}
 
-	data := ProtectedData{
+	if err = s.saveAnchoring(vaultID, res); err != nil {
+		s.deleteVaults(created)
+
+		return nil, err
+	}
+
+	return &ProtectedData{
 		DID:      vaultID,
 		VCDocID:  vcDocID,
 		PolicyID: policyID,
+		Region:   region,
+	}, nil
+}
+
+// vault returns the vault client of the residency region, or the default vault client if the region is not set.
+func (s *Service) vault(region string) (vaultClient, error) { //nolint:ireturn
+	if region == "" {
+		return s.vaultClient, nil
+	}
+
+	vc, ok := s.regionVaults[region]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownRegion, region)
 	}
 
-	b, err = json.Marshal(&data)
+	return vc, nil
+}
+
+// existingData returns the protected data already stored, if it is kept in the residency region.
+func existingData(b []byte, region string) (*ProtectedData, error) {
+	data, err := unmarshalData(b)
 	if err != nil {
-		return nil, fmt.Errorf("marshal protected data: %w", err)
+		return nil, err
 	}
 
-	if err = s.store.Put(hash, b, storage.Tag{Name: policyIndex, Value: data.PolicyID}); err != nil {
-		return nil, fmt.Errorf("save protected data: %w", err)
+	if data.Region != region {
+		return nil, fmt.Errorf("%w: %q", ErrRegionMismatch, data.Region)
+	}
+
+	return data, nil
+}
+
+// deleteVaults deletes the vaults of protected data that could not be saved. Failures are only logged, as the
+// vaults are not referenced by any protected data. They are deleted even if the request is canceled.
+func (s *Service) deleteVaults(data ...*ProtectedData) {
+	for _, d := range data {
+		vc, err := s.vault(d.Region)
+		if err == nil {
+			err = vc.DeleteVault(context.Background(), d.DID)
+		}
+
+		if err != nil {
+			logger.Warnf("Failed to delete vault %s: %s", d.DID, err)
+		}
+	}
+}
+
+func putOperation(hash string, data *ProtectedData) (storage.Operation, error) {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return storage.Operation{}, fmt.Errorf("marshal protected data: %w", err)
+	}
+
+	tags := []storage.Tag{
+		{Name: policyIndex, Value: data.PolicyID},
+		{Name: didIndex, Value: index.TagValue(data.DID)},
+	}
+
+	if data.Subject != "" {
+		tags = append(tags, storage.Tag{Name: subjectIndex, Value: index.TagValue(data.Subject)})
+	}
+
+	return storage.Operation{Key: hash, Value: b, Tags: tags}, nil
+}
+
+func unmarshalAll(values [][]byte) ([]*ProtectedData, error) {
+	all := make([]*ProtectedData, len(values))
+
+	for i, v := range values {
+		data, err := unmarshalData(v)
+		if err != nil {
+			return nil, err
+		}
+
+		all[i] = data
+	}
+
+	return all, nil
+}
+
+func unmarshalData(b []byte) (*ProtectedData, error) {
+	var data ProtectedData
+
+	if err := json.Unmarshal(b, &data); err != nil {
+		return nil, fmt.Errorf("unmarshal protected data: %w", err)
 	}
 
 	return &data, nil
@@ -230,16 +839,22 @@ func (s *Service) wrapDataIntoVC(ctx context.Context, sub, data string) (*verifi
 		return nil, err
 	}
 
+	if s.validator != nil {
+		if err = s.validator.Validate(vc); err != nil {
+			return nil, fmt.Errorf("validate issued credential: %w", err)
+		}
+	}
+
 	return vc, nil
 }
 
-func (s *Service) saveVCDoc(vaultID string, vc *verifiable.Credential) (string, error) {
+func saveVCDoc(ctx context.Context, client vaultClient, vaultID string, vc *verifiable.Credential) (string, error) {
 	docID, err := edvutils.GenerateEDVCompatibleID()
 	if err != nil {
 		return "", fmt.Errorf("create edv doc id : %w", err)
 	}
 
-	_, err = s.vaultClient.SaveDoc(vaultID, docID, vc)
+	_, err = client.SaveDoc(ctx, vaultID, docID, vc)
 	if err != nil {
 		return "", fmt.Errorf("failed to save doc : %w", err)
 	}
@@ -257,23 +872,43 @@ func calculateHash(target, policyID string) (string, error) {
 	return base64.URLEncoding.EncodeToString(h.Sum(nil)), nil
 }
 
-func resolveDID(vdrRegistry vdrRegistry, resolveDID string, maxRetry int) error {
+func calculateHashes(policyID string, targets []string) ([]string, error) {
+	hashes := make([]string, len(targets))
+
+	for i, target := range targets {
+		hash, err := calculateHash(target, policyID)
+		if err != nil {
+			return nil, fmt.Errorf("calculate hash: %w", err)
+		}
+
+		hashes[i] = hash
+	}
+
+	return hashes, nil
+}
+
+func resolveDID(ctx context.Context, vdrRegistry vdrRegistry, resolveDID string,
+	maxRetry int) (*did.DocResolution, error) {
 	for i := 1; i <= maxRetry; i++ {
-		_, err := vdrRegistry.Resolve(resolveDID)
+		res, err := vdrRegistry.Resolve(resolveDID)
 		if err == nil {
-			return nil
+			return res, nil
 		}
 
-		if !strings.Contains(err.Error(), "DID does not exist") {
-			return err
+		if !errors.Is(err, vdr.ErrNotFound) {
+			return nil, err
 		}
 
 		if i == maxRetry {
-			return fmt.Errorf("resolve did: %w", err)
+			return nil, fmt.Errorf("resolve did: %w", err)
 		}
 
-		time.Sleep(1 * time.Second)
+		select {
+		case <-time.After(1 * time.Second):
+		case <-ctx.Done():
+			return nil, fmt.Errorf("resolve did: %w", ctx.Err())
+		}
 	}
 
-	return nil
+	return nil, nil
 }
@@ -17,13 +17,17 @@ import (
 
 	"github.com/golang/mock/gomock"
 	"github.com/hyperledger/aries-framework-go/component/storageutil/mem"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/did"
 	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
+	vdrapi "github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdr"
 	"github.com/hyperledger/aries-framework-go/pkg/mock/storage"
 	storageapi "github.com/hyperledger/aries-framework-go/spi/storage"
 	"github.com/stretchr/testify/require"
 
+	vaultclient "github.com/trustbloc/ace/pkg/client/vault"
 	"github.com/trustbloc/ace/pkg/gatekeeper/protect"
 	"github.com/trustbloc/ace/pkg/restapi/vault"
+	"github.com/trustbloc/ace/pkg/storage/index"
 )
 
 const (
@@ -114,7 +118,7 @@ func TestProtect_CreateVaultFailed(t *testing.T) {
 	})
 	require.NoError(t, err)
 
-	vaultClient.EXPECT().CreateVault().Return(nil, errors.New("create vaultClient failed"))
+	vaultClient.EXPECT().CreateVault(gomock.Any()).Return(nil, errors.New("create vaultClient failed"))
 
 	_, err = svc.Protect(context.Background(), "test data", "policyID")
 
@@ -138,17 +142,59 @@ func TestProtect_WrapVcFailed(t *testing.T) {
 	})
 	require.NoError(t, err)
 
-	vaultClient.EXPECT().CreateVault().Return(&vault.CreatedVault{
+	vaultClient.EXPECT().CreateVault(gomock.Any()).Return(&vault.CreatedVault{
 		ID: "did:orb:test",
 	}, nil)
 
 	vcIssuer.EXPECT().IssueCredential(gomock.Any(), gomock.Any()).Return(nil, errors.New("issues credential failed"))
 
+	// the did is resolved while the credential is issued
+	vdr.EXPECT().Resolve("did:orb:test").Return(nil, nil).AnyTimes()
+
+	vaultClient.EXPECT().DeleteVault(gomock.Any(), "did:orb:test").Return(nil)
+
 	_, err = svc.Protect(context.Background(), "test data", "policyID")
 
 	require.EqualError(t, err, "wrap data into vc: issues credential failed")
 }
 
+func TestProtect_ValidateVcFailed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := storage.NewMockStoreProvider()
+	vaultClient := NewMockVault(ctrl)
+	vdr := NewMockVDR(ctrl)
+	vcIssuer := NewMockVCIssuer(ctrl)
+	validator := NewMockCredentialValidator(ctrl)
+
+	svc, err := protect.NewService(&protect.Config{
+		StoreProvider:       store,
+		VaultClient:         vaultClient,
+		VDR:                 vdr,
+		VCIssuer:            vcIssuer,
+		CredentialValidator: validator,
+	})
+	require.NoError(t, err)
+
+	vaultClient.EXPECT().CreateVault(gomock.Any()).Return(&vault.CreatedVault{
+		ID: "did:orb:test",
+	}, nil)
+
+	vc := &verifiable.Credential{}
+
+	vcIssuer.EXPECT().IssueCredential(gomock.Any(), gomock.Any()).Return(vc, nil)
+	validator.EXPECT().Validate(vc).Return(errors.New("invalid credential"))
+
+	vdr.EXPECT().Resolve("did:orb:test").Return(nil, nil).AnyTimes()
+
+	vaultClient.EXPECT().DeleteVault(gomock.Any(), "did:orb:test").Return(nil)
+
+	_, err = svc.Protect(context.Background(), "test data", "policyID")
+
+	require.EqualError(t, err, "wrap data into vc: validate issued credential: invalid credential")
+}
+
 func TestProtect_DidDoesNotExists(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -166,17 +212,57 @@ func TestProtect_DidDoesNotExists(t *testing.T) {
 	})
 	require.NoError(t, err)
 
-	vaultClient.EXPECT().CreateVault().Return(&vault.CreatedVault{
+	vaultClient.EXPECT().CreateVault(gomock.Any()).Return(&vault.CreatedVault{
 		ID: "did:orb:test",
 	}, nil)
 
 	vcIssuer.EXPECT().IssueCredential(gomock.Any(), gomock.Any()).Return(&verifiable.Credential{}, nil)
 
-	vdr.EXPECT().Resolve("did:orb:test").Return(nil, errors.New("DID does not exist")).Times(10)
+	vdr.EXPECT().Resolve("did:orb:test").Return(nil, fmt.Errorf("resolve: %w", vdrapi.ErrNotFound)).Times(10)
+
+	vaultClient.EXPECT().DeleteVault(gomock.Any(), "did:orb:test").Return(errors.New("delete vault failed"))
 
 	_, err = svc.Protect(context.Background(), "test data", "policyID")
 
-	require.Contains(t, err.Error(), "DID does not exist")
+	require.ErrorIs(t, err, vdrapi.ErrNotFound)
+}
+
+func TestProtect_ResolveFailedWhileIssuing(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := storage.NewMockStoreProvider()
+	vaultClient := NewMockVault(ctrl)
+	vdr := NewMockVDR(ctrl)
+	vcIssuer := NewMockVCIssuer(ctrl)
+
+	svc, err := protect.NewService(&protect.Config{
+		StoreProvider: store,
+		VaultClient:   vaultClient,
+		VDR:           vdr,
+		VCIssuer:      vcIssuer,
+	})
+	require.NoError(t, err)
+
+	vaultClient.EXPECT().CreateVault(gomock.Any()).Return(&vault.CreatedVault{
+		ID: "did:orb:test",
+	}, nil)
+
+	// the issuance is canceled once the did fails to resolve
+	vcIssuer.EXPECT().IssueCredential(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, _ []byte) (*verifiable.Credential, error) {
+			<-ctx.Done()
+
+			return nil, ctx.Err()
+		})
+
+	vdr.EXPECT().Resolve("did:orb:test").Return(nil, errors.New("resolver unavailable"))
+
+	vaultClient.EXPECT().DeleteVault(gomock.Any(), "did:orb:test").Return(nil)
+
+	_, err = svc.Protect(context.Background(), "test data", "policyID")
+
+	require.EqualError(t, err, "resolve did did:orb:test : resolver unavailable")
 }
 
 func TestProtect_SaveDocFailed(t *testing.T) {
@@ -196,7 +282,7 @@ func TestProtect_SaveDocFailed(t *testing.T) {
 	})
 	require.NoError(t, err)
 
-	vaultClient.EXPECT().CreateVault().Return(&vault.CreatedVault{
+	vaultClient.EXPECT().CreateVault(gomock.Any()).Return(&vault.CreatedVault{
 		ID: "did:orb:vault",
 	}, nil)
 
@@ -206,7 +292,9 @@ func TestProtect_SaveDocFailed(t *testing.T) {
 
 	vdr.EXPECT().Resolve("did:orb:vault").Return(nil, nil)
 
-	vaultClient.EXPECT().SaveDoc("did:orb:vault", gomock.Any(), vc).Return(nil, errors.New("save doc failed"))
+	vaultClient.EXPECT().SaveDoc(gomock.Any(), "did:orb:vault", gomock.Any(), vc).
+		Return(nil, errors.New("save doc failed"))
+	vaultClient.EXPECT().DeleteVault(gomock.Any(), "did:orb:vault").Return(nil)
 
 	_, err = svc.Protect(context.Background(), "test data", "policyID")
 
@@ -232,7 +320,7 @@ func TestProtect_StorePutFailed(t *testing.T) {
 	})
 	require.NoError(t, err)
 
-	vaultClient.EXPECT().CreateVault().Return(&vault.CreatedVault{
+	vaultClient.EXPECT().CreateVault(gomock.Any()).Return(&vault.CreatedVault{
 		ID: "did:orb:vault",
 	}, nil)
 
@@ -242,7 +330,8 @@ func TestProtect_StorePutFailed(t *testing.T) {
 
 	vdr.EXPECT().Resolve("did:orb:vault").Return(nil, nil)
 
-	vaultClient.EXPECT().SaveDoc("did:orb:vault", gomock.Any(), vc).Return(nil, nil)
+	vaultClient.EXPECT().SaveDoc(gomock.Any(), "did:orb:vault", gomock.Any(), vc).Return(nil, nil)
+	vaultClient.EXPECT().DeleteVault(gomock.Any(), "did:orb:vault").Return(nil)
 
 	_, err = svc.Protect(context.Background(), "test data", "policyID")
 
@@ -266,7 +355,7 @@ func TestProtect_Success(t *testing.T) {
 	})
 	require.NoError(t, err)
 
-	vaultClient.EXPECT().CreateVault().Return(&vault.CreatedVault{
+	vaultClient.EXPECT().CreateVault(gomock.Any()).Return(&vault.CreatedVault{
 		ID: "did:orb:vault",
 	}, nil)
 
@@ -276,7 +365,7 @@ func TestProtect_Success(t *testing.T) {
 
 	vdr.EXPECT().Resolve("did:orb:vault").Return(nil, nil)
 
-	vaultClient.EXPECT().SaveDoc("did:orb:vault", gomock.Any(), vc).Return(nil, nil)
+	vaultClient.EXPECT().SaveDoc(gomock.Any(), "did:orb:vault", gomock.Any(), vc).Return(nil, nil)
 
 	protectedData, err := svc.Protect(context.Background(), "test data", "policyID")
 
@@ -284,6 +373,77 @@ func TestProtect_Success(t *testing.T) {
 	require.Equal(t, protectedData.DID, "did:orb:vault")
 }
 
+func TestProtect_Region(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := storage.NewMockStoreProvider()
+	vaultClient := NewMockVault(ctrl)
+	euVault := NewMockVault(ctrl)
+	vdr := NewMockVDR(ctrl)
+	vcIssuer := NewMockVCIssuer(ctrl)
+
+	svc, err := protect.NewService(&protect.Config{
+		StoreProvider:      store,
+		VaultClient:        vaultClient,
+		VDR:                vdr,
+		VCIssuer:           vcIssuer,
+		RegionVaultClients: map[string]vaultclient.Vault{"eu": &regionVault{MockVault: euVault}},
+	})
+	require.NoError(t, err)
+
+	t.Run("Protects in the vault of the region", func(t *testing.T) {
+		euVault.EXPECT().CreateVault(gomock.Any()).Return(&vault.CreatedVault{ID: "did:orb:eu"}, nil)
+
+		vc := &verifiable.Credential{}
+
+		vcIssuer.EXPECT().IssueCredential(gomock.Any(), gomock.Any()).Return(vc, nil)
+		vdr.EXPECT().Resolve("did:orb:eu").Return(nil, nil)
+		euVault.EXPECT().SaveDoc(gomock.Any(), "did:orb:eu", gomock.Any(), vc).Return(nil, nil)
+
+		data, err := svc.Protect(context.Background(), "test data", "policyID", protect.WithRegion("eu"))
+		require.NoError(t, err)
+		require.Equal(t, "did:orb:eu", data.DID)
+		require.Equal(t, "eu", data.Region)
+
+		data, err = svc.Protect(context.Background(), "test data", "policyID", protect.WithRegion("eu"))
+		require.NoError(t, err)
+		require.Equal(t, "did:orb:eu", data.DID)
+	})
+
+	t.Run("Already protected in another region", func(t *testing.T) {
+		_, err := svc.Protect(context.Background(), "test data", "policyID")
+		require.True(t, errors.Is(err, protect.ErrRegionMismatch), err)
+	})
+
+	t.Run("Unknown region", func(t *testing.T) {
+		_, err := svc.Protect(context.Background(), "other data", "policyID", protect.WithRegion("us"))
+		require.True(t, errors.Is(err, protect.ErrUnknownRegion), err)
+	})
+}
+
+// regionVault is the vault client of a region, only creating vaults and saving documents.
+type regionVault struct {
+	*MockVault
+}
+
+func (v *regionVault) GetDocMetaData(context.Context, string, string) (*vault.DocumentMetadata, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (v *regionVault) CreateAuthorization(context.Context, string, string,
+	*vault.AuthorizationsScope) (*vault.CreatedAuthorization, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (v *regionVault) GetAuthorization(context.Context, string, string) (*vault.CreatedAuthorization, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (v *regionVault) QueryDocs(context.Context, string, string) ([]*vault.DocumentMetadata, error) {
+	return nil, errors.New("not implemented")
+}
+
 func TestProtect_GetSuccess(t *testing.T) {
 	storeProvider := mem.NewProvider()
 
@@ -308,6 +468,22 @@ func TestProtect_GetSuccess(t *testing.T) {
 		require.Equal(t, "did:example:test#2", data.DID)
 	})
 
+	t.Run("Success by did index", func(t *testing.T) {
+		b, err := json.Marshal(&protect.ProtectedData{DID: "did:example:indexed"})
+		require.NoError(t, err)
+
+		err = store.Put("indexed", b, storageapi.Tag{Name: "did", Value: index.TagValue("did:example:indexed")})
+		require.NoError(t, err)
+
+		svc, err := protect.NewService(&protect.Config{StoreProvider: storeProvider})
+		require.NoError(t, err)
+
+		data, err := svc.Get(context.Background(), "did:example:indexed")
+
+		require.NoError(t, err)
+		require.Equal(t, "did:example:indexed", data.DID)
+	})
+
 	t.Run("ErrDataNotFound", func(t *testing.T) {
 		svc, err := protect.NewService(&protect.Config{StoreProvider: storeProvider})
 		require.NoError(t, err)
@@ -318,3 +494,878 @@ func TestProtect_GetSuccess(t *testing.T) {
 		require.Nil(t, data)
 	})
 }
+
+func TestProtectAll(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		storeProvider := mem.NewProvider()
+
+		store, err := storeProvider.OpenStore(storeName)
+		require.NoError(t, err)
+
+		existing, err := json.Marshal(&protect.ProtectedData{DID: "did:orb:existing", PolicyID: testPolicyID})
+		require.NoError(t, err)
+
+		hash, err := calculateHash("existing data", testPolicyID)
+		require.NoError(t, err)
+
+		require.NoError(t, store.Put(hash, existing))
+
+		vaultClient := NewMockVault(ctrl)
+		vdr := NewMockVDR(ctrl)
+		vcIssuer := NewMockVCIssuer(ctrl)
+
+		svc, err := protect.NewService(&protect.Config{
+			StoreProvider: storeProvider,
+			VaultClient:   vaultClient,
+			VDR:           vdr,
+			VCIssuer:      vcIssuer,
+		})
+		require.NoError(t, err)
+
+		vc := &verifiable.Credential{}
+
+		vaultClient.EXPECT().CreateVault(gomock.Any()).Return(&vault.CreatedVault{ID: "did:orb:vault1"}, nil)
+		vaultClient.EXPECT().CreateVault(gomock.Any()).Return(&vault.CreatedVault{ID: "did:orb:vault2"}, nil)
+		vcIssuer.EXPECT().IssueCredential(gomock.Any(), gomock.Any()).Return(vc, nil).Times(2)
+		vdr.EXPECT().Resolve(gomock.Any()).Return(nil, nil).Times(2)
+		vaultClient.EXPECT().SaveDoc(gomock.Any(), gomock.Any(), gomock.Any(), vc).Return(nil, nil).Times(2)
+
+		data, err := svc.ProtectAll(context.Background(), testPolicyID,
+			"data 1", "existing data", "data 2", "data 1")
+		require.NoError(t, err)
+		require.Len(t, data, 4)
+		require.Equal(t, "did:orb:vault1", data[0].DID)
+		require.Equal(t, "did:orb:existing", data[1].DID)
+		require.Equal(t, "did:orb:vault2", data[2].DID)
+		require.Equal(t, "did:orb:vault1", data[3].DID)
+
+		saved, err := svc.Get(context.Background(), "did:orb:vault2")
+		require.NoError(t, err)
+		require.Equal(t, testPolicyID, saved.PolicyID)
+
+		data, err = svc.ProtectAll(context.Background(), testPolicyID, "data 1", "data 2")
+		require.NoError(t, err)
+		require.Equal(t, "did:orb:vault1", data[0].DID)
+		require.Equal(t, "did:orb:vault2", data[1].DID)
+	})
+
+	t.Run("Fail to get protected data", func(t *testing.T) {
+		storeProvider := newFailingProvider()
+		storeProvider.errGetBulk = errors.New("get bulk error")
+
+		svc, err := protect.NewService(&protect.Config{StoreProvider: storeProvider})
+		require.NoError(t, err)
+
+		_, err = svc.ProtectAll(context.Background(), testPolicyID, "data")
+		require.EqualError(t, err, "get protected data by hash: get bulk error")
+	})
+
+	t.Run("Fail to unmarshal protected data", func(t *testing.T) {
+		storeProvider := mem.NewProvider()
+
+		store, err := storeProvider.OpenStore(storeName)
+		require.NoError(t, err)
+
+		hash, err := calculateHash("data", testPolicyID)
+		require.NoError(t, err)
+
+		require.NoError(t, store.Put(hash, []byte("invalid")))
+
+		svc, err := protect.NewService(&protect.Config{StoreProvider: storeProvider})
+		require.NoError(t, err)
+
+		_, err = svc.ProtectAll(context.Background(), testPolicyID, "data")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "unmarshal protected data")
+	})
+
+	t.Run("Fail to create vault", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		vaultClient := NewMockVault(ctrl)
+
+		svc, err := protect.NewService(&protect.Config{
+			StoreProvider: mem.NewProvider(),
+			VaultClient:   vaultClient,
+		})
+		require.NoError(t, err)
+
+		vaultClient.EXPECT().CreateVault(gomock.Any()).Return(nil, errors.New("create error"))
+
+		_, err = svc.ProtectAll(context.Background(), testPolicyID, "data")
+		require.EqualError(t, err, "create vault: create error")
+	})
+
+	t.Run("Fail to save protected data", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		storeProvider := newFailingProvider()
+		storeProvider.errBatch = errors.New("batch error")
+
+		vaultClient := NewMockVault(ctrl)
+		vdr := NewMockVDR(ctrl)
+		vcIssuer := NewMockVCIssuer(ctrl)
+
+		svc, err := protect.NewService(&protect.Config{
+			StoreProvider: storeProvider,
+			VaultClient:   vaultClient,
+			VDR:           vdr,
+			VCIssuer:      vcIssuer,
+		})
+		require.NoError(t, err)
+
+		vc := &verifiable.Credential{}
+
+		vaultClient.EXPECT().CreateVault(gomock.Any()).Return(&vault.CreatedVault{ID: "did:orb:vault"}, nil)
+		vcIssuer.EXPECT().IssueCredential(gomock.Any(), gomock.Any()).Return(vc, nil)
+		vdr.EXPECT().Resolve("did:orb:vault").Return(nil, nil)
+		vaultClient.EXPECT().SaveDoc(gomock.Any(), "did:orb:vault", gomock.Any(), vc).Return(nil, nil)
+		vaultClient.EXPECT().DeleteVault(gomock.Any(), "did:orb:vault").Return(nil)
+
+		_, err = svc.ProtectAll(context.Background(), testPolicyID, "data")
+		require.EqualError(t, err, "save protected data: batch error")
+	})
+}
+
+func TestRotate(t *testing.T) {
+	previous := &protect.ProtectedData{DID: "did:orb:previous", VCDocID: "doc", PolicyID: testPolicyID}
+
+	setup := func(t *testing.T) (*protect.Service, *MockVault, *MockVDR, *MockVCIssuer) {
+		t.Helper()
+
+		ctrl := gomock.NewController(t)
+
+		storeProvider := mem.NewProvider()
+
+		store, err := storeProvider.OpenStore(storeName)
+		require.NoError(t, err)
+
+		hash, err := calculateHash("data", testPolicyID)
+		require.NoError(t, err)
+
+		b, err := json.Marshal(previous)
+		require.NoError(t, err)
+
+		require.NoError(t, store.Put(hash, b, storageapi.Tag{Name: "did", Value: index.TagValue(previous.DID)}))
+
+		vaultClient := NewMockVault(ctrl)
+		vdr := NewMockVDR(ctrl)
+		vcIssuer := NewMockVCIssuer(ctrl)
+
+		svc, err := protect.NewService(&protect.Config{
+			StoreProvider: storeProvider,
+			VaultClient:   vaultClient,
+			VDR:           vdr,
+			VCIssuer:      vcIssuer,
+		})
+		require.NoError(t, err)
+
+		return svc, vaultClient, vdr, vcIssuer
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		svc, vaultClient, vdr, vcIssuer := setup(t)
+
+		vc := &verifiable.Credential{}
+		continuity := &verifiable.Credential{ID: "continuity"}
+
+		vaultClient.EXPECT().CreateVault(gomock.Any()).Return(&vault.CreatedVault{ID: "did:orb:new"}, nil)
+		vdr.EXPECT().Resolve("did:orb:new").Return(nil, nil)
+		vaultClient.EXPECT().SaveDoc(gomock.Any(), "did:orb:new", gomock.Any(), vc).Return(nil, nil)
+		vaultClient.EXPECT().DeleteVault(gomock.Any(), previous.DID).Return(errors.New("delete error"))
+
+		gomock.InOrder(
+			vcIssuer.EXPECT().IssueCredential(gomock.Any(), gomock.Any()).Return(vc, nil),
+			vcIssuer.EXPECT().IssueCredential(gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ context.Context, cred []byte) (*verifiable.Credential, error) {
+					var c map[string]interface{}
+
+					require.NoError(t, json.Unmarshal(cred, &c))
+					require.Equal(t, []interface{}{"VerifiableCredential", protect.ContinuityCredentialType}, c["type"])
+					require.Equal(t, map[string]interface{}{
+						"id":          "did:orb:new",
+						"previousDID": previous.DID,
+					}, c["credentialSubject"])
+
+					return continuity, nil
+				}),
+		)
+
+		data, vc, err := svc.Rotate(context.Background(), previous, "data")
+		require.NoError(t, err)
+		require.Equal(t, "did:orb:new", data.DID)
+		require.Equal(t, testPolicyID, data.PolicyID)
+		require.Equal(t, continuity, vc)
+
+		saved, err := svc.Get(context.Background(), "did:orb:new")
+		require.NoError(t, err)
+		require.Equal(t, data, saved)
+
+		_, err = svc.Get(context.Background(), previous.DID)
+		require.ErrorIs(t, err, storageapi.ErrDataNotFound)
+	})
+
+	t.Run("Target does not match", func(t *testing.T) {
+		svc, _, _, _ := setup(t)
+
+		_, _, err := svc.Rotate(context.Background(), previous, "other data")
+		require.ErrorIs(t, err, protect.ErrTargetMismatch)
+
+		_, _, err = svc.Rotate(context.Background(),
+			&protect.ProtectedData{DID: "did:orb:other", PolicyID: testPolicyID}, "data")
+		require.ErrorIs(t, err, protect.ErrTargetMismatch)
+	})
+
+	t.Run("Fail to create vault", func(t *testing.T) {
+		svc, vaultClient, _, _ := setup(t)
+
+		vaultClient.EXPECT().CreateVault(gomock.Any()).Return(nil, errors.New("create error"))
+
+		_, _, err := svc.Rotate(context.Background(), previous, "data")
+		require.EqualError(t, err, "create vault: create error")
+	})
+
+	t.Run("Fail to issue continuity credential", func(t *testing.T) {
+		svc, vaultClient, vdr, vcIssuer := setup(t)
+
+		vc := &verifiable.Credential{}
+
+		vaultClient.EXPECT().CreateVault(gomock.Any()).Return(&vault.CreatedVault{ID: "did:orb:new"}, nil)
+		vdr.EXPECT().Resolve("did:orb:new").Return(nil, nil)
+		vaultClient.EXPECT().SaveDoc(gomock.Any(), "did:orb:new", gomock.Any(), vc).Return(nil, nil)
+		vaultClient.EXPECT().DeleteVault(gomock.Any(), "did:orb:new").Return(nil)
+
+		gomock.InOrder(
+			vcIssuer.EXPECT().IssueCredential(gomock.Any(), gomock.Any()).Return(vc, nil),
+			vcIssuer.EXPECT().IssueCredential(gomock.Any(), gomock.Any()).Return(nil, errors.New("issue error")),
+		)
+
+		_, _, err := svc.Rotate(context.Background(), previous, "data")
+		require.EqualError(t, err, "issue continuity credential: issue error")
+
+		data, err := svc.Get(context.Background(), previous.DID)
+		require.NoError(t, err)
+		require.Equal(t, previous, data)
+	})
+}
+
+func TestRepolicy(t *testing.T) {
+	previous := &protect.ProtectedData{DID: "did:orb:previous", VCDocID: "doc", PolicyID: testPolicyID}
+
+	setup := func(t *testing.T) (*protect.Service, storageapi.Store, *MockVault, *MockVDR, *MockVCIssuer) {
+		t.Helper()
+
+		ctrl := gomock.NewController(t)
+
+		storeProvider := mem.NewProvider()
+
+		store, err := storeProvider.OpenStore(storeName)
+		require.NoError(t, err)
+
+		hash, err := calculateHash("data", testPolicyID)
+		require.NoError(t, err)
+
+		b, err := json.Marshal(previous)
+		require.NoError(t, err)
+
+		require.NoError(t, store.Put(hash, b, storageapi.Tag{Name: "did", Value: index.TagValue(previous.DID)}))
+
+		vaultClient := NewMockVault(ctrl)
+		vdr := NewMockVDR(ctrl)
+		vcIssuer := NewMockVCIssuer(ctrl)
+
+		svc, err := protect.NewService(&protect.Config{
+			StoreProvider: storeProvider,
+			VaultClient:   vaultClient,
+			VDR:           vdr,
+			VCIssuer:      vcIssuer,
+		})
+		require.NoError(t, err)
+
+		return svc, store, vaultClient, vdr, vcIssuer
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		svc, _, _, _, _ := setup(t)
+
+		data, err := svc.Repolicy(context.Background(), previous, "data", "new-policy", false)
+		require.NoError(t, err)
+		require.Equal(t, &protect.ProtectedData{DID: previous.DID, VCDocID: "doc", PolicyID: "new-policy"}, data)
+
+		saved, err := svc.Get(context.Background(), previous.DID)
+		require.NoError(t, err)
+		require.Equal(t, data, saved)
+
+		// the target is protected with the same DID under the new policy
+		protected, err := svc.Protect(context.Background(), "data", "new-policy")
+		require.NoError(t, err)
+		require.Equal(t, data, protected)
+	})
+
+	t.Run("Success: re-anonymized", func(t *testing.T) {
+		svc, _, vaultClient, vdr, vcIssuer := setup(t)
+
+		vc := &verifiable.Credential{}
+
+		vaultClient.EXPECT().CreateVault(gomock.Any()).Return(&vault.CreatedVault{ID: "did:orb:new"}, nil)
+		vdr.EXPECT().Resolve("did:orb:new").Return(nil, nil)
+		vcIssuer.EXPECT().IssueCredential(gomock.Any(), gomock.Any()).Return(vc, nil)
+		vaultClient.EXPECT().SaveDoc(gomock.Any(), "did:orb:new", gomock.Any(), vc).Return(nil, nil)
+		vaultClient.EXPECT().DeleteVault(gomock.Any(), previous.DID).Return(nil)
+
+		data, err := svc.Repolicy(context.Background(), previous, "data", "new-policy", true)
+		require.NoError(t, err)
+		require.Equal(t, "did:orb:new", data.DID)
+		require.Equal(t, "new-policy", data.PolicyID)
+
+		saved, err := svc.Get(context.Background(), "did:orb:new")
+		require.NoError(t, err)
+		require.Equal(t, data, saved)
+
+		_, err = svc.Get(context.Background(), previous.DID)
+		require.ErrorIs(t, err, storageapi.ErrDataNotFound)
+	})
+
+	t.Run("Target does not match", func(t *testing.T) {
+		svc, _, _, _, _ := setup(t)
+
+		_, err := svc.Repolicy(context.Background(), previous, "other data", "new-policy", false)
+		require.ErrorIs(t, err, protect.ErrTargetMismatch)
+	})
+
+	t.Run("Target is already protected with the policy", func(t *testing.T) {
+		svc, store, _, _, _ := setup(t)
+
+		hash, err := calculateHash("data", "new-policy")
+		require.NoError(t, err)
+
+		require.NoError(t, store.Put(hash, []byte(`{"did":"did:orb:other","policy_id":"new-policy"}`)))
+
+		_, err = svc.Repolicy(context.Background(), previous, "data", "new-policy", false)
+		require.ErrorIs(t, err, protect.ErrAlreadyProtected)
+	})
+
+	t.Run("Fail to create vault", func(t *testing.T) {
+		svc, _, vaultClient, _, _ := setup(t)
+
+		vaultClient.EXPECT().CreateVault(gomock.Any()).Return(nil, errors.New("create error"))
+
+		_, err := svc.Repolicy(context.Background(), previous, "data", "new-policy", true)
+		require.EqualError(t, err, "create vault: create error")
+
+		data, err := svc.Get(context.Background(), previous.DID)
+		require.NoError(t, err)
+		require.Equal(t, previous, data)
+	})
+}
+
+func TestLink(t *testing.T) {
+	const subject = "did:example:subject"
+
+	setup := func(t *testing.T) (*protect.Service, []*protect.ProtectedData) {
+		t.Helper()
+
+		storeProvider := mem.NewProvider()
+
+		store, err := storeProvider.OpenStore(storeName)
+		require.NoError(t, err)
+
+		var data []*protect.ProtectedData
+
+		for i, target := range []string{"data 1", "data 2"} {
+			hash, hashErr := calculateHash(target, testPolicyID)
+			require.NoError(t, hashErr)
+
+			d := &protect.ProtectedData{DID: fmt.Sprintf("did:example:%d", i+1), PolicyID: testPolicyID}
+
+			b, marshalErr := json.Marshal(d)
+			require.NoError(t, marshalErr)
+
+			require.NoError(t, store.Put(hash, b, storageapi.Tag{Name: "did", Value: index.TagValue(d.DID)}))
+
+			data = append(data, d)
+		}
+
+		svc, err := protect.NewService(&protect.Config{StoreProvider: storeProvider})
+		require.NoError(t, err)
+
+		return svc, data
+	}
+
+	linkedDIDs := func(t *testing.T, svc *protect.Service, subject string) []string {
+		t.Helper()
+
+		var dids []string
+
+		require.NoError(t, svc.IterateSubject(context.Background(), subject, 1, func(d *protect.ProtectedData) error {
+			require.Equal(t, subject, d.Subject)
+
+			dids = append(dids, d.DID)
+
+			return nil
+		}))
+
+		return dids
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		svc, data := setup(t)
+
+		linked, err := svc.Link(context.Background(), subject, data, []string{"data 1", "data 2"})
+		require.NoError(t, err)
+		require.Len(t, linked, 2)
+		require.Equal(t, subject, linked[0].Subject)
+
+		require.ElementsMatch(t, []string{"did:example:1", "did:example:2"}, linkedDIDs(t, svc, subject))
+		require.Empty(t, linkedDIDs(t, svc, "did:example:other"))
+
+		d, err := svc.Get(context.Background(), "did:example:1")
+		require.NoError(t, err)
+		require.Equal(t, subject, d.Subject)
+
+		// linking again to the same subject is a no-op
+		_, err = svc.Link(context.Background(), subject, data[:1], []string{"data 1"})
+		require.NoError(t, err)
+	})
+
+	t.Run("Target does not match", func(t *testing.T) {
+		svc, data := setup(t)
+
+		_, err := svc.Link(context.Background(), subject, data, []string{"data 1", "data 3"})
+		require.ErrorIs(t, err, protect.ErrTargetMismatch)
+
+		_, err = svc.Link(context.Background(), subject, data, []string{"data 1"})
+		require.ErrorIs(t, err, protect.ErrTargetMismatch)
+
+		require.Empty(t, linkedDIDs(t, svc, subject))
+	})
+
+	t.Run("Linked to another subject", func(t *testing.T) {
+		svc, data := setup(t)
+
+		_, err := svc.Link(context.Background(), "did:example:other", data[:1], []string{"data 1"})
+		require.NoError(t, err)
+
+		_, err = svc.Link(context.Background(), subject, data, []string{"data 1", "data 2"})
+		require.ErrorIs(t, err, protect.ErrAlreadyLinked)
+	})
+
+	t.Run("Subject is kept on rotation", func(t *testing.T) {
+		svc, data := setup(t)
+
+		_, err := svc.Link(context.Background(), subject, data[:1], []string{"data 1"})
+		require.NoError(t, err)
+
+		moved, err := svc.Repolicy(context.Background(), data[0], "data 1", "new-policy", false)
+		require.NoError(t, err)
+		require.Equal(t, subject, moved.Subject)
+
+		require.Equal(t, []string{"did:example:1"}, linkedDIDs(t, svc, subject))
+	})
+}
+
+func TestDelete(t *testing.T) {
+	storeProvider := mem.NewProvider()
+
+	store, err := storeProvider.OpenStore(storeName)
+	require.NoError(t, err)
+
+	for i, target := range []string{"data 1", "data 2"} {
+		hash, hashErr := calculateHash(target, testPolicyID)
+		require.NoError(t, hashErr)
+
+		did := fmt.Sprintf("did:example:%d", i+1)
+
+		b, marshalErr := json.Marshal(&protect.ProtectedData{DID: did, PolicyID: testPolicyID})
+		require.NoError(t, marshalErr)
+
+		require.NoError(t, store.Put(hash, b, storageapi.Tag{Name: "did", Value: index.TagValue(did)}))
+	}
+
+	svc, err := protect.NewService(&protect.Config{StoreProvider: storeProvider})
+	require.NoError(t, err)
+
+	require.NoError(t, svc.Delete(context.Background(), "did:example:1", "did:example:missing"))
+
+	_, err = svc.Get(context.Background(), "did:example:1")
+	require.ErrorIs(t, err, storageapi.ErrDataNotFound)
+
+	_, err = svc.Get(context.Background(), "did:example:2")
+	require.NoError(t, err)
+
+	require.NoError(t, svc.Restore(context.Background(), testPolicyID, "data 1"))
+
+	_, err = svc.Get(context.Background(), "did:example:1")
+	require.NoError(t, err)
+}
+
+func TestDelete_Fail(t *testing.T) {
+	t.Run("Fail to open tombstone store", func(t *testing.T) {
+		store := storage.NewMockStoreProvider()
+		store.FailNamespace = "protected_data_deleted"
+
+		_, err := protect.NewService(&protect.Config{StoreProvider: store})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "open protected data store: open tombstone store")
+	})
+
+	t.Run("Fail to delete protected data", func(t *testing.T) {
+		storeProvider := newFailingProvider()
+
+		store, err := storeProvider.OpenStore(storeName)
+		require.NoError(t, err)
+
+		hash, err := calculateHash("data", testPolicyID)
+		require.NoError(t, err)
+
+		require.NoError(t, store.Put(hash, []byte(`{"did":"did:example:1"}`),
+			storageapi.Tag{Name: "did", Value: index.TagValue("did:example:1")}))
+
+		svc, err := protect.NewService(&protect.Config{StoreProvider: storeProvider})
+		require.NoError(t, err)
+
+		storeProvider.errBatch = errors.New("batch error")
+
+		err = svc.Delete(context.Background(), "did:example:1")
+		require.EqualError(t, err, "delete protected data: save tombstones: batch error")
+	})
+}
+
+func TestAnchoring(t *testing.T) {
+	unpublished := &did.DocResolution{DocumentMetadata: &did.DocumentMetadata{Method: &did.MethodMetadata{}}}
+	published := &did.DocResolution{DocumentMetadata: &did.DocumentMetadata{
+		CanonicalID: "did:orb:canonical:vault",
+		Method:      &did.MethodMetadata{Published: true},
+	}}
+
+	newService := func(t *testing.T, storeProvider storageapi.Provider, vdr *MockVDR) *protect.Service {
+		t.Helper()
+
+		vaultClient := NewMockVault(gomock.NewController(t))
+		vaultClient.EXPECT().CreateVault(gomock.Any()).Return(&vault.CreatedVault{ID: "did:orb:vault"}, nil).AnyTimes()
+		vaultClient.EXPECT().SaveDoc(gomock.Any(), "did:orb:vault", gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+		vaultClient.EXPECT().DeleteVault(gomock.Any(), "did:orb:vault").Return(nil).AnyTimes()
+
+		vcIssuer := NewMockVCIssuer(gomock.NewController(t))
+		vcIssuer.EXPECT().IssueCredential(gomock.Any(), gomock.Any()).Return(&verifiable.Credential{}, nil).AnyTimes()
+
+		svc, err := protect.NewService(&protect.Config{
+			StoreProvider: storeProvider,
+			VaultClient:   vaultClient,
+			VDR:           vdr,
+			VCIssuer:      vcIssuer,
+		})
+		require.NoError(t, err)
+
+		return svc
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		vdr := NewMockVDR(gomock.NewController(t))
+		svc := newService(t, mem.NewProvider(), vdr)
+
+		vdr.EXPECT().Resolve("did:orb:vault").Return(unpublished, nil)
+
+		_, err := svc.Protect(context.Background(), "test data", testPolicyID)
+		require.NoError(t, err)
+
+		a, err := svc.Anchoring(context.Background(), "did:orb:vault")
+		require.NoError(t, err)
+		require.Equal(t, protect.AnchorPending, a.Status)
+		require.Empty(t, a.CanonicalDID)
+
+		vdr.EXPECT().Resolve("did:orb:vault").Return(nil, errors.New("resolve error"))
+
+		n, err := svc.CheckAnchoring(context.Background())
+		require.NoError(t, err)
+		require.Zero(t, n)
+
+		vdr.EXPECT().Resolve("did:orb:vault").Return(unpublished, nil)
+
+		n, err = svc.CheckAnchoring(context.Background())
+		require.NoError(t, err)
+		require.Zero(t, n)
+
+		vdr.EXPECT().Resolve("did:orb:vault").Return(published, nil)
+
+		n, err = svc.CheckAnchoring(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, 1, n)
+
+		a, err = svc.Anchoring(context.Background(), "did:orb:vault")
+		require.NoError(t, err)
+		require.Equal(t, protect.AnchorAnchored, a.Status)
+		require.Equal(t, "did:orb:canonical:vault", a.CanonicalDID)
+
+		// anchored DIDs are not resolved again
+		n, err = svc.CheckAnchoring(context.Background())
+		require.NoError(t, err)
+		require.Zero(t, n)
+	})
+
+	t.Run("Anchored when created", func(t *testing.T) {
+		vdr := NewMockVDR(gomock.NewController(t))
+		svc := newService(t, mem.NewProvider(), vdr)
+
+		vdr.EXPECT().Resolve("did:orb:vault").Return(published, nil)
+
+		_, err := svc.Protect(context.Background(), "test data", testPolicyID)
+		require.NoError(t, err)
+
+		a, err := svc.Anchoring(context.Background(), "did:orb:vault")
+		require.NoError(t, err)
+		require.Equal(t, protect.AnchorAnchored, a.Status)
+	})
+
+	t.Run("DID method without anchoring", func(t *testing.T) {
+		vdr := NewMockVDR(gomock.NewController(t))
+		svc := newService(t, mem.NewProvider(), vdr)
+
+		vdr.EXPECT().Resolve("did:orb:vault").Return(&did.DocResolution{}, nil)
+
+		_, err := svc.Protect(context.Background(), "test data", testPolicyID)
+		require.NoError(t, err)
+
+		a, err := svc.Anchoring(context.Background(), "did:orb:vault")
+		require.NoError(t, err)
+		require.Equal(t, &protect.Anchoring{DID: "did:orb:vault", Status: protect.AnchorNone}, a)
+	})
+
+	t.Run("Fail to save anchoring", func(t *testing.T) {
+		storeProvider := storage.NewMockStoreProvider()
+
+		vdr := NewMockVDR(gomock.NewController(t))
+		svc := newService(t, storeProvider, vdr)
+
+		vdr.EXPECT().Resolve("did:orb:vault").Return(unpublished, nil)
+
+		storeProvider.Store.ErrPut = errors.New("put error")
+
+		_, err := svc.Protect(context.Background(), "test data", testPolicyID)
+		require.EqualError(t, err, "save anchoring: put error")
+	})
+
+	t.Run("Fail to check anchoring", func(t *testing.T) {
+		storeProvider := storage.NewMockStoreProvider()
+		storeProvider.Store.ErrQuery = errors.New("query error")
+
+		svc := newService(t, storeProvider, nil)
+
+		_, err := svc.CheckAnchoring(context.Background())
+		require.EqualError(t, err, "query anchoring: query error")
+
+		storeProvider.Store.ErrGet = errors.New("get error")
+
+		_, err = svc.Anchoring(context.Background(), "did:orb:vault")
+		require.EqualError(t, err, "get anchoring: get error")
+	})
+}
+
+// failingProvider is a mem provider whose stores return the configured errors.
+type failingProvider struct {
+	storageapi.Provider
+	errGetBulk error
+	errBatch   error
+}
+
+func newFailingProvider() *failingProvider {
+	return &failingProvider{Provider: mem.NewProvider()}
+}
+
+func (p *failingProvider) OpenStore(name string) (storageapi.Store, error) {
+	s, err := p.Provider.OpenStore(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &failingStore{Store: s, provider: p}, nil
+}
+
+type failingStore struct {
+	storageapi.Store
+	provider *failingProvider
+}
+
+func (s *failingStore) GetBulk(keys ...string) ([][]byte, error) {
+	if s.provider.errGetBulk != nil {
+		return nil, s.provider.errGetBulk
+	}
+
+	return s.Store.GetBulk(keys...)
+}
+
+func (s *failingStore) Batch(operations []storageapi.Operation) error {
+	if s.provider.errBatch != nil {
+		return s.provider.errBatch
+	}
+
+	return s.Store.Batch(operations)
+}
+
+func TestService_Query(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		storeProvider := mem.NewProvider()
+
+		store, err := storeProvider.OpenStore(storeName)
+		require.NoError(t, err)
+
+		for i, policyID := range []string{testPolicyID, testPolicyID, testPolicyID, "other-policy"} {
+			hash, hashErr := calculateHash(fmt.Sprintf("data %d", i), policyID)
+			require.NoError(t, hashErr)
+
+			did := fmt.Sprintf("did:example:%d", i)
+
+			b, marshalErr := json.Marshal(&protect.ProtectedData{DID: did, PolicyID: policyID})
+			require.NoError(t, marshalErr)
+
+			require.NoError(t, store.Put(hash, b,
+				storageapi.Tag{Name: policyIndex, Value: policyID},
+				storageapi.Tag{Name: "did", Value: index.TagValue(did)},
+			))
+		}
+
+		svc, err := protect.NewService(&protect.Config{StoreProvider: storeProvider})
+		require.NoError(t, err)
+
+		require.ElementsMatch(t, []string{"did:example:0", "did:example:1", "did:example:2"},
+			queryDIDs(t, svc, testPolicyID))
+
+		count := 0
+
+		err = svc.Iterate(context.Background(), "", 2, func(data *protect.ProtectedData) error {
+			count++
+
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, 4, count)
+
+		err = svc.Iterate(context.Background(), "", 2, func(data *protect.ProtectedData) error {
+			return errors.New("fn error")
+		})
+		require.EqualError(t, err, "fn error")
+	})
+
+	t.Run("Excludes deleted data", func(t *testing.T) {
+		storeProvider := mem.NewProvider()
+
+		store, err := storeProvider.OpenStore(storeName)
+		require.NoError(t, err)
+
+		for i := 0; i < 3; i++ {
+			hash, hashErr := calculateHash(fmt.Sprintf("data %d", i), testPolicyID)
+			require.NoError(t, hashErr)
+
+			did := fmt.Sprintf("did:example:%d", i)
+
+			b, marshalErr := json.Marshal(&protect.ProtectedData{DID: did, PolicyID: testPolicyID})
+			require.NoError(t, marshalErr)
+
+			require.NoError(t, store.Put(hash, b,
+				storageapi.Tag{Name: policyIndex, Value: testPolicyID},
+				storageapi.Tag{Name: "did", Value: index.TagValue(did)},
+			))
+		}
+
+		svc, err := protect.NewService(&protect.Config{StoreProvider: storeProvider})
+		require.NoError(t, err)
+
+		require.NoError(t, svc.Delete(context.Background(), "did:example:1", "did:example:2"))
+
+		require.Equal(t, []string{"did:example:0"}, queryDIDs(t, svc, testPolicyID))
+
+		var dids []string
+
+		err = svc.Iterate(context.Background(), "", 2, func(data *protect.ProtectedData) error {
+			dids = append(dids, data.DID)
+
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, []string{"did:example:0"}, dids)
+
+		require.NoError(t, svc.Restore(context.Background(), testPolicyID, "data 1"))
+
+		require.ElementsMatch(t, []string{"did:example:0", "did:example:1"}, queryDIDs(t, svc, ""))
+	})
+
+	t.Run("Fail to query protected data", func(t *testing.T) {
+		store := storage.NewMockStoreProvider()
+		store.Store.ErrQuery = errors.New("query error")
+
+		svc, err := protect.NewService(&protect.Config{StoreProvider: store})
+		require.NoError(t, err)
+
+		_, err = svc.Query(context.Background(), testPolicyID, 1)
+		require.EqualError(t, err, "query protected data: query policyID:test-policy: query error")
+
+		err = svc.Iterate(context.Background(), "", 1, func(data *protect.ProtectedData) error {
+			return nil
+		})
+		require.EqualError(t, err, "query protected data: query policyID: query error")
+	})
+
+	t.Run("Fail to read protected data", func(t *testing.T) {
+		store := storage.NewMockStoreProvider()
+		store.Store.Store["key"] = storage.DBEntry{
+			Value: []byte("invalid"),
+			Tags:  []storageapi.Tag{{Name: policyIndex, Value: testPolicyID}},
+		}
+
+		svc, err := protect.NewService(&protect.Config{StoreProvider: store})
+		require.NoError(t, err)
+
+		c, err := svc.Query(context.Background(), "", 1)
+		require.NoError(t, err)
+
+		_, err = c.Next()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "unmarshal protected data")
+
+		err = svc.Iterate(context.Background(), "", 1, func(data *protect.ProtectedData) error {
+			return nil
+		})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "unmarshal protected data")
+
+		store.Store.ErrNext = errors.New("next error")
+
+		c, err = svc.Query(context.Background(), "", 1)
+		require.NoError(t, err)
+
+		_, err = c.Next()
+		require.EqualError(t, err, "next protected data: next entry: next error")
+	})
+}
+
+// queryDIDs returns the DIDs of the protected data under policyID, read a page of one entry at a time.
+func queryDIDs(t *testing.T, svc *protect.Service, policyID string) []string {
+	t.Helper()
+
+	c, err := svc.Query(context.Background(), policyID, 1)
+	require.NoError(t, err)
+
+	defer func() {
+		require.NoError(t, c.Close())
+	}()
+
+	var dids []string
+
+	for {
+		page, e := c.Next()
+		require.NoError(t, e)
+
+		if len(page) == 0 {
+			return dids
+		}
+
+		require.Len(t, page, 1)
+
+		dids = append(dids, page[0].DID)
+	}
+}
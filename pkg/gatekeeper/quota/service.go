@@ -0,0 +1,178 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package quota counts the extractions of protected data against the extraction quotas of their policies.
+package quota
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/aries-framework-go/spi/storage"
+
+	"github.com/trustbloc/ace/pkg/gatekeeper/policy"
+	"github.com/trustbloc/ace/pkg/storage/conditional"
+	"github.com/trustbloc/ace/pkg/storage/index"
+)
+
+const (
+	storeName = "quota"
+	// revisionTag is the tag of the revision of a usage, which its updates are written conditionally on.
+	revisionTag = "revision"
+
+	// maxUseAttempts is the number of times an extraction is recorded when the usage is updated concurrently.
+	maxUseAttempts = 5
+)
+
+// ErrExceeded is returned when extracting protected data whose extraction quota is used up.
+var ErrExceeded = errors.New("extraction quota exceeded")
+
+// usage is the extractions of protected data within the window of its quota, oldest first.
+type usage struct {
+	Extractions []time.Time `json:"extractions"`
+	// Revision is incremented on every update of the usage, so that concurrent updates are detected.
+	Revision int `json:"revision,omitempty"`
+}
+
+// Service counts the extractions of protected data per DID. The usages are written conditionally on the revision
+// they were read at, see conditional.Putter, so that a quota is enforced across the instances sharing the store. With
+// a store that doesn't write conditionally, such as CouchDB or MySQL, the usages are counted under a lock of the
+// instance, which only enforces the quotas with a single instance.
+type Service struct {
+	store storage.Store
+	// conditional tells if the store writes the usages conditionally on their revisions.
+	conditional bool
+	// mu serializes the updates of usages, counted with a read and a write, if the store doesn't write conditionally.
+	mu sync.Mutex
+}
+
+// NewService returns a new instance of Service.
+func NewService(storeProvider storage.Provider) (*Service, error) {
+	store, err := index.OpenStore(storeProvider, &index.Declaration{Store: storeName, TagNames: []string{revisionTag}})
+	if err != nil {
+		return nil, fmt.Errorf("open quota store: %w", err)
+	}
+
+	return &Service{store: store, conditional: conditional.Supported(store)}, nil
+}
+
+// Remaining returns the number of extractions of the protected data with the given DID left in the window of the
+// quota ending at the given time.
+func (s *Service) Remaining(_ context.Context, did string, q *policy.Quota, at time.Time) (int, error) {
+	u, err := s.get(did)
+	if err != nil {
+		return 0, err
+	}
+
+	return remaining(u.within(q, at), q), nil
+}
+
+// Use records an extraction of the protected data with the given DID at the given time and returns the number of
+// extractions left, or returns ErrExceeded if the quota is used up. If the usage is updated concurrently, the
+// extraction is counted again against the usage read anew, up to maxUseAttempts times.
+func (s *Service) Use(_ context.Context, did string, q *policy.Quota, at time.Time) (int, error) {
+	if !s.conditional {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+	}
+
+	for attempt := 1; ; attempt++ {
+		n, err := s.use(did, q, at)
+		if !errors.Is(err, conditional.ErrConflict) || attempt == maxUseAttempts {
+			return n, err
+		}
+	}
+}
+
+func (s *Service) use(did string, q *policy.Quota, at time.Time) (int, error) {
+	u, err := s.get(did)
+	if err != nil {
+		return 0, err
+	}
+
+	// extractions out of the window are no longer counted, nor kept
+	u.Extractions = u.within(q, at)
+
+	if remaining(u.Extractions, q) == 0 {
+		return 0, ErrExceeded
+	}
+
+	u.Extractions = append(u.Extractions, at)
+
+	// the usages recorded before they had revisions are untagged
+	condition := storage.Tag{Name: revisionTag}
+	if u.Revision > 0 {
+		condition.Value = strconv.Itoa(u.Revision)
+	}
+
+	u.Revision++
+
+	b, err := json.Marshal(u)
+	if err != nil {
+		return 0, fmt.Errorf("marshal usage: %w", err)
+	}
+
+	tag := storage.Tag{Name: revisionTag, Value: strconv.Itoa(u.Revision)}
+
+	if s.conditional {
+		err = conditional.PutIf(s.store, did, b, condition, tag)
+	} else {
+		err = s.store.Put(did, b, tag)
+	}
+
+	if err != nil {
+		return 0, fmt.Errorf("store usage: %w", err)
+	}
+
+	return remaining(u.Extractions, q), nil
+}
+
+func (s *Service) get(did string) (*usage, error) {
+	b, err := s.store.Get(did)
+	if errors.Is(err, storage.ErrDataNotFound) {
+		return &usage{}, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("get usage: %w", err)
+	}
+
+	var u usage
+
+	if err = json.Unmarshal(b, &u); err != nil {
+		return nil, fmt.Errorf("unmarshal usage: %w", err)
+	}
+
+	return &u, nil
+}
+
+// within returns the extractions within the window of the quota ending at the given time.
+func (u *usage) within(q *policy.Quota, at time.Time) []time.Time {
+	start := at.Add(-q.Window())
+
+	var extractions []time.Time
+
+	for _, e := range u.Extractions {
+		if e.After(start) {
+			extractions = append(extractions, e)
+		}
+	}
+
+	return extractions
+}
+
+func remaining(extractions []time.Time, q *policy.Quota) int {
+	if n := q.Extractions - len(extractions); n > 0 {
+		return n
+	}
+
+	return 0
+}
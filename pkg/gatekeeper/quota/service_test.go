@@ -0,0 +1,236 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package quota_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/aries-framework-go/component/storageutil/mem"
+	"github.com/hyperledger/aries-framework-go/pkg/mock/storage"
+	spi "github.com/hyperledger/aries-framework-go/spi/storage"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/ace/pkg/gatekeeper/policy"
+	"github.com/trustbloc/ace/pkg/gatekeeper/quota"
+	"github.com/trustbloc/ace/pkg/storage/conditional"
+)
+
+const testDID = "did:example:protected"
+
+func TestService(t *testing.T) {
+	ctx := context.Background()
+	q := &policy.Quota{Extractions: 2, Days: 30}
+	now := time.Now()
+
+	svc, openErr := quota.NewService(mem.NewProvider())
+	require.NoError(t, openErr)
+
+	remaining, err := svc.Remaining(ctx, testDID, q, now)
+	require.NoError(t, err)
+	require.Equal(t, 2, remaining)
+
+	remaining, err = svc.Use(ctx, testDID, q, now.Add(-29*24*time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, 1, remaining)
+
+	remaining, err = svc.Use(ctx, testDID, q, now)
+	require.NoError(t, err)
+	require.Equal(t, 0, remaining)
+
+	_, err = svc.Use(ctx, testDID, q, now.Add(time.Hour))
+	require.ErrorIs(t, err, quota.ErrExceeded)
+
+	remaining, err = svc.Remaining(ctx, "did:example:other", q, now)
+	require.NoError(t, err)
+	require.Equal(t, 2, remaining)
+
+	// the first extraction leaves the window after 30 days
+	remaining, err = svc.Remaining(ctx, testDID, q, now.Add(2*24*time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, 1, remaining)
+
+	remaining, err = svc.Use(ctx, testDID, q, now.Add(2*24*time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, 0, remaining)
+}
+
+func TestService_Instances(t *testing.T) {
+	ctx := context.Background()
+	q := &policy.Quota{Extractions: 5, Days: 30}
+
+	t.Run("instances sharing the store don't exceed the quota", func(t *testing.T) {
+		provider := conditional.NewLockingProvider(&slowProvider{Provider: mem.NewProvider()})
+
+		services := make([]*quota.Service, 2)
+
+		for i := range services {
+			svc, err := quota.NewService(provider)
+			require.NoError(t, err)
+
+			services[i] = svc
+		}
+
+		const uses = 20
+
+		var (
+			wg   sync.WaitGroup
+			mu   sync.Mutex
+			used int
+		)
+
+		for i := 0; i < uses; i++ {
+			wg.Add(1)
+
+			go func(svc *quota.Service) {
+				defer wg.Done()
+
+				_, err := svc.Use(ctx, testDID, q, time.Now())
+				if errors.Is(err, quota.ErrExceeded) || errors.Is(err, conditional.ErrConflict) {
+					return
+				}
+
+				require.NoError(t, err)
+
+				mu.Lock()
+				used++
+				mu.Unlock()
+			}(services[i%len(services)])
+		}
+
+		wg.Wait()
+
+		require.LessOrEqual(t, used, q.Extractions)
+
+		remaining, err := services[0].Remaining(ctx, testDID, q, time.Now())
+		require.NoError(t, err)
+		require.Equal(t, q.Extractions-used, remaining)
+	})
+
+	t.Run("usage recorded before it had a revision is updated", func(t *testing.T) {
+		provider := conditional.NewLockingProvider(mem.NewProvider())
+
+		store, err := provider.OpenStore("quota")
+		require.NoError(t, err)
+		require.NoError(t, store.Put(testDID, []byte(`{"extractions":["`+time.Now().Format(time.RFC3339)+`"]}`)))
+
+		svc, err := quota.NewService(provider)
+		require.NoError(t, err)
+
+		remaining, err := svc.Use(ctx, testDID, q, time.Now())
+		require.NoError(t, err)
+		require.Equal(t, 3, remaining)
+
+		tags, err := store.GetTags(testDID)
+		require.NoError(t, err)
+		require.Equal(t, []spi.Tag{{Name: "revision", Value: "1"}}, tags)
+	})
+}
+
+func TestService_Failures(t *testing.T) {
+	q := &policy.Quota{Extractions: 2, Days: 30}
+
+	t.Run("Fail to open store", func(t *testing.T) {
+		_, err := quota.NewService(&storage.MockStoreProvider{ErrOpenStoreHandle: errors.New("open error")})
+		require.EqualError(t, err, "open quota store: open error")
+	})
+
+	t.Run("Fail to get usage", func(t *testing.T) {
+		provider := storage.NewMockStoreProvider()
+		provider.Store.ErrGet = errors.New("get error")
+
+		svc, err := quota.NewService(provider)
+		require.NoError(t, err)
+
+		_, err = svc.Remaining(context.Background(), testDID, q, time.Now())
+		require.EqualError(t, err, "get usage: get error")
+
+		_, err = svc.Use(context.Background(), testDID, q, time.Now())
+		require.EqualError(t, err, "get usage: get error")
+	})
+
+	t.Run("Fail to unmarshal usage", func(t *testing.T) {
+		provider := storage.NewMockStoreProvider()
+		provider.Store.Store[testDID] = storage.DBEntry{Value: []byte("invalid")}
+
+		svc, err := quota.NewService(provider)
+		require.NoError(t, err)
+
+		_, err = svc.Remaining(context.Background(), testDID, q, time.Now())
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "unmarshal usage")
+	})
+
+	t.Run("Fail to store usage", func(t *testing.T) {
+		provider := storage.NewMockStoreProvider()
+		provider.Store.ErrPut = errors.New("put error")
+
+		svc, err := quota.NewService(provider)
+		require.NoError(t, err)
+
+		_, err = svc.Use(context.Background(), testDID, q, time.Now())
+		require.EqualError(t, err, "store usage: put error")
+	})
+
+	t.Run("Fail to store usage updated concurrently", func(t *testing.T) {
+		svc, err := quota.NewService(&conflictingProvider{Provider: mem.NewProvider()})
+		require.NoError(t, err)
+
+		_, err = svc.Use(context.Background(), testDID, q, time.Now())
+		require.ErrorIs(t, err, conditional.ErrConflict)
+	})
+}
+
+type conflictingProvider struct {
+	spi.Provider
+}
+
+func (p *conflictingProvider) OpenStore(name string) (spi.Store, error) {
+	s, err := p.Provider.OpenStore(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &conflictingStore{Store: s}, nil
+}
+
+// conflictingStore conflicts on every conditional write, as if the entry were always updated concurrently.
+type conflictingStore struct {
+	spi.Store
+}
+
+func (s *conflictingStore) PutIf(key string, _ []byte, condition spi.Tag, _ ...spi.Tag) error {
+	return fmt.Errorf("%w: %s of %s", conditional.ErrConflict, condition.Name, key)
+}
+
+type slowProvider struct {
+	spi.Provider
+}
+
+func (p *slowProvider) OpenStore(name string) (spi.Store, error) {
+	s, err := p.Provider.OpenStore(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &slowStore{Store: s}, nil
+}
+
+// slowStore delays the writes, so that the instances read the usages while they are updated.
+type slowStore struct {
+	spi.Store
+}
+
+func (s *slowStore) Put(key string, value []byte, tags ...spi.Tag) error {
+	time.Sleep(time.Millisecond)
+
+	return s.Store.Put(key, value, tags...)
+}
@@ -7,22 +7,82 @@ SPDX-License-Identifier: Apache-2.0
 package release
 
 //nolint: lll
-//go:generate mockgen -destination gomocks_test.go -package release_test -source=service.go -mock_names policyService=MockPolicyService,protectService=MockProtectService
+//go:generate mockgen -destination gomocks_test.go -package release_test -source=service.go -mock_names policyService=MockPolicyService,protectService=MockProtectService,observer=MockObserver,auditor=MockAuditor
 
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strconv"
+	"sync"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/hyperledger/aries-framework-go/pkg/common/log"
 	"github.com/hyperledger/aries-framework-go/spi/storage"
 
 	"github.com/trustbloc/ace/pkg/gatekeeper/policy"
 	"github.com/trustbloc/ace/pkg/gatekeeper/protect"
 	"github.com/trustbloc/ace/pkg/gatekeeper/release/ticket"
+	"github.com/trustbloc/ace/pkg/storage/conditional"
+	"github.com/trustbloc/ace/pkg/storage/cursor"
+	"github.com/trustbloc/ace/pkg/storage/index"
 )
 
-const storeName = "ticket"
+const (
+	storeName        = "ticket"
+	didIndex         = "did"
+	statusIndex      = "status"
+	queryIndex       = "queryID"
+	requestedByIndex = "requestedBy"
+	policyIndex      = "policyID"
+	// revisionTag is the tag of the revision of a ticket, which its updates are written conditionally on.
+	revisionTag = "revision"
+
+	// maxUpdateAttempts is the number of times an update of a ticket is attempted when the ticket is updated
+	// concurrently.
+	maxUpdateAttempts = 5
+)
+
+var logger = log.New("release-svc")
+
+// ErrRejected is returned when deciding on a ticket that was rejected.
+var ErrRejected = errors.New("ticket is rejected")
+
+// ErrQuorumNotMet is returned when collecting the data of a ticket that does not have the approvals of enough
+// approvers yet.
+var ErrQuorumNotMet = errors.New("ticket does not have the approvals of enough approvers")
+
+// ErrConflict is returned when a ticket keeps being updated concurrently with an update.
+var ErrConflict = errors.New("ticket updated concurrently")
+
+// ErrNotLeader is returned when updating a ticket on an instance that doesn't hold the leader's lease, if the store
+// doesn't write conditionally.
+var ErrNotLeader = errors.New("tickets are updated by the instance holding the leader's lease")
+
+type (
+	referenceKey  struct{}
+	delegationKey struct{}
+)
+
+// delegationValue is the delegation the decisions taken with a context are taken with.
+type delegationValue struct {
+	id       string
+	delegate string
+}
+
+// WithReference returns the context with the reference of the signature or the capability the actions taken on
+// tickets with the context are authorized with, recorded in the history of the tickets.
+func WithReference(ctx context.Context, reference string) context.Context {
+	return context.WithValue(ctx, referenceKey{}, reference)
+}
+
+// WithDelegation returns the context with the delegation the decisions taken on tickets with the context are taken
+// with. The decisions are recorded as taken by the delegate on behalf of the approver.
+func WithDelegation(ctx context.Context, delegationID, delegate string) context.Context {
+	return context.WithValue(ctx, delegationKey{}, &delegationValue{id: delegationID, delegate: delegate})
+}
 
 type policyService interface {
 	Get(ctx context.Context, policyID string) (*policy.Policy, error)
@@ -32,11 +92,34 @@ type protectService interface {
 	Get(ctx context.Context, did string) (*protect.ProtectedData, error)
 }
 
+type observer interface {
+	TicketUpdated(ctx context.Context, t *ticket.Ticket)
+}
+
+type auditor interface {
+	Record(ctx context.Context, t *ticket.Ticket) error
+}
+
+type leader interface {
+	Held() bool
+}
+
 // Config defines dependencies for a service.
 type Config struct {
 	StoreProvider  storage.Provider
 	PolicyService  policyService
 	ProtectService protectService
+	// Observer, if set, is called with the tickets created or updated, once they are stored. The action taken on a
+	// ticket is the last one of its history.
+	Observer observer
+	// Auditor, if set, records the tickets created or updated before they are stored, so that no action is taken on a
+	// ticket without being recorded: the action fails if it cannot be recorded.
+	Auditor auditor
+	// Leader, if set, is the lease of the instance updating the tickets if the store doesn't write conditionally, see
+	// conditional.Putter. The revision of a ticket is then checked within the instance updating it, so that the updates
+	// fail with ErrNotLeader on the instances that don't hold the lease. Without it, the instance must be the only one
+	// sharing such a store. It isn't used with a store writing conditionally, which every instance updates.
+	Leader leader
 }
 
 // Service is a service for releasing protected resources.
@@ -44,11 +127,22 @@ type Service struct {
 	store          storage.Store
 	policyService  policyService
 	protectService protectService
+	observer       observer
+	auditor        auditor
+	leader         leader
+	// conditional tells if the store writes the tickets conditionally on their revisions.
+	conditional bool
+	// mu serializes the checks of the revisions of the tickets with their writes if the store doesn't write
+	// conditionally.
+	mu sync.Mutex
 }
 
 // NewService returns a new instance of Service.
 func NewService(config *Config) (*Service, error) {
-	store, err := config.StoreProvider.OpenStore(storeName)
+	store, err := index.OpenStore(config.StoreProvider, &index.Declaration{
+		Store:    storeName,
+		TagNames: []string{didIndex, statusIndex, queryIndex, requestedByIndex, policyIndex},
+	})
 	if err != nil {
 		return nil, fmt.Errorf("open ticket store: %w", err)
 	}
@@ -57,26 +151,73 @@ func NewService(config *Config) (*Service, error) {
 		store:          store,
 		policyService:  config.PolicyService,
 		protectService: config.ProtectService,
+		observer:       config.Observer,
+		auditor:        config.Auditor,
+		leader:         config.Leader,
+		conditional:    conditional.Supported(store),
 	}, nil
 }
 
-// Release creates release transaction (ticket) on the protected resource (DID).
-func (s *Service) Release(_ context.Context, did string) (*ticket.Ticket, error) {
+// RequiresLeader tells if the tickets are only updated by the instance holding the leader's lease, since the store
+// doesn't write conditionally, see Config.Leader.
+func (s *Service) RequiresLeader() bool {
+	return !s.conditional && s.leader != nil
+}
+
+// Release creates release transaction (ticket) on the protected resource (DID), protected with the given policy and
+// requested by the handler with the given DID for the given justification.
+func (s *Service) Release(ctx context.Context, did, policyID, requestedBy,
+	justification string) (*ticket.Ticket, error) {
 	t := &ticket.Ticket{
-		ID:     uuid.New().String(),
-		DID:    did,
-		Status: ticket.New,
+		ID:            uuid.New().String(),
+		DID:           did,
+		Status:        ticket.New,
+		PolicyID:      policyID,
+		CreatedAt:     time.Now().UTC(),
+		RequestedBy:   requestedBy,
+		Justification: justification,
 	}
 
+	return s.create(ctx, t, ticket.ReleaseAction)
+}
+
+// BreakGlass creates a ticket releasing the protected resource (DID) to the responder with the given DID in an
+// emergency, for the given justification. The ticket is ready to collect without the approvals of the approvers.
+func (s *Service) BreakGlass(ctx context.Context, did, policyID, responder, justification string) (*ticket.Ticket,
+	error) {
+	t := &ticket.Ticket{
+		ID:            uuid.New().String(),
+		DID:           did,
+		Status:        ticket.ReadyToCollect,
+		PolicyID:      policyID,
+		CreatedAt:     time.Now().UTC(),
+		RequestedBy:   responder,
+		Justification: justification,
+		BreakGlass:    true,
+	}
+
+	return s.create(ctx, t, ticket.BreakGlassAction)
+}
+
+// create stores the new ticket, with the action of its requestor creating it recorded in its history.
+func (s *Service) create(ctx context.Context, t *ticket.Ticket, action ticket.Action) (*ticket.Ticket, error) {
+	record(ctx, t, action, t.RequestedBy)
+
 	b, err := json.Marshal(t)
 	if err != nil {
 		return nil, fmt.Errorf("marshal ticket: %w", err)
 	}
 
-	if err = s.store.Put(t.ID, b); err != nil {
+	if err = s.audit(ctx, t); err != nil {
+		return nil, err
+	}
+
+	if err = s.store.Put(t.ID, b, tags(t)...); err != nil {
 		return nil, fmt.Errorf("store ticket: %w", err)
 	}
 
+	s.notify(ctx, t)
+
 	return t, nil
 }
 
@@ -96,11 +237,16 @@ func (s *Service) Get(_ context.Context, ticketID string) (*ticket.Ticket, error
 	return &t, nil
 }
 
-// Authorize authorizes ticket by approver.
-func (s *Service) Authorize(ctx context.Context, ticketID, approver string) error {
-	t, err := s.Get(ctx, ticketID)
-	if err != nil {
-		return fmt.Errorf("get ticket to authorize: %w", err)
+// Authorize authorizes ticket by approver, with the approver's comment if it is not empty.
+func (s *Service) Authorize(ctx context.Context, ticketID, approver, comment string) error {
+	return s.modify(ctx, ticketID, "authorize", func(t *ticket.Ticket) error {
+		return s.authorize(ctx, t, approver, comment)
+	})
+}
+
+func (s *Service) authorize(ctx context.Context, t *ticket.Ticket, approver, comment string) error {
+	if t.Status == ticket.Rejected {
+		return ErrRejected
 	}
 
 	data, err := s.protectService.Get(ctx, t.DID)
@@ -137,14 +283,464 @@ func (s *Service) Authorize(ctx context.Context, ticketID, approver string) erro
 		t.Status = ticket.ReadyToCollect
 	}
 
+	addComment(t, approver, ticket.Approve, comment)
+	record(ctx, t, ticket.ApproveAction, approver)
+
+	return nil
+}
+
+// Reject rejects the ticket by approver, with the approver's comment if it is not empty. Rejected tickets can no
+// longer be authorized or collected.
+func (s *Service) Reject(ctx context.Context, ticketID, approver, comment string) error {
+	return s.modify(ctx, ticketID, "reject", func(t *ticket.Ticket) error {
+		if t.Status == ticket.Rejected {
+			return ErrRejected
+		}
+
+		t.Status = ticket.Rejected
+		t.RejectedBy = approver
+
+		addComment(t, approver, ticket.Reject, comment)
+		record(ctx, t, ticket.RejectAction, approver)
+
+		return nil
+	})
+}
+
+func addComment(t *ticket.Ticket, approver string, decision ticket.Decision, comment string) {
+	if comment == "" {
+		return
+	}
+
+	t.Comments = append(t.Comments, &ticket.Comment{
+		Approver:  approver,
+		Decision:  decision,
+		Comment:   comment,
+		Timestamp: time.Now().UTC(),
+	})
+}
+
+// record records the action taken by the actor in the history of the ticket, with the reference of the context. If
+// the context has a delegation, the action is recorded as taken by the delegate on behalf of the actor.
+func record(ctx context.Context, t *ticket.Ticket, action ticket.Action, actor string) {
+	reference, _ := ctx.Value(referenceKey{}).(string)
+
+	e := &ticket.Event{
+		Action:    action,
+		Status:    t.Status,
+		Actor:     actor,
+		Reference: reference,
+		Timestamp: time.Now().UTC(),
+	}
+
+	if d, ok := ctx.Value(delegationKey{}).(*delegationValue); ok {
+		e.Actor = d.delegate
+		e.OnBehalfOf = actor
+		e.Delegation = d.id
+	}
+
+	t.History = append(t.History, e)
+}
+
+// modify applies fn to the ticket and stores the ticket, unless fn fails. If the ticket is updated concurrently,
+// fn is applied again to the ticket read anew, up to maxUpdateAttempts times, so that no update is lost.
+func (s *Service) modify(ctx context.Context, ticketID, operation string, fn func(t *ticket.Ticket) error) error {
+	err := s.checkLeader()
+	if err != nil {
+		return err
+	}
+
+	for attempt := 1; attempt <= maxUpdateAttempts; attempt++ {
+		var t *ticket.Ticket
+
+		t, err = s.Get(ctx, ticketID)
+		if err != nil {
+			return fmt.Errorf("get ticket to %s: %w", operation, err)
+		}
+
+		if err = fn(t); err != nil {
+			return err
+		}
+
+		err = s.update(ctx, t)
+		if !errors.Is(err, ErrConflict) {
+			return err
+		}
+
+		logger.Debugf("ticket %s updated concurrently, attempt %d to %s", ticketID, attempt, operation)
+	}
+
+	return err
+}
+
+// checkLeader returns ErrNotLeader if the tickets are updated by another instance.
+func (s *Service) checkLeader() error {
+	if s.RequiresLeader() && !s.leader.Held() {
+		return ErrNotLeader
+	}
+
+	return nil
+}
+
+// update stores the ticket with its revision incremented, or returns ErrConflict if the ticket was updated since it
+// was read. The ticket is written conditionally on the revision it was read at, or, if the store doesn't write
+// conditionally, checked against it within the service, which is then the only one updating the tickets, see
+// Config.Leader.
+func (s *Service) update(ctx context.Context, t *ticket.Ticket) error {
+	if !s.conditional {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+	}
+
+	if err := s.put(ctx, t); err != nil {
+		return err
+	}
+
+	s.notify(ctx, t)
+
+	return nil
+}
+
+// put checks the revision of the ticket before it is recorded, so that the updates known to conflict are not audited.
+// An update conflicting after it is recorded, with a store writing conditionally, is recorded again when it is
+// retried.
+func (s *Service) put(ctx context.Context, t *ticket.Ticket) error {
+	current, err := s.Get(ctx, t.ID)
+	if err != nil {
+		return fmt.Errorf("update ticket: %w", err)
+	}
+
+	if current.Revision != t.Revision {
+		return fmt.Errorf("%w: revision %d, expected %d", ErrConflict, current.Revision, t.Revision)
+	}
+
+	condition, err := s.condition(t)
+	if err != nil {
+		return err
+	}
+
+	t.Revision++
+
 	b, err := json.Marshal(t)
 	if err != nil {
 		return fmt.Errorf("marshal ticket: %w", err)
 	}
 
-	if err = s.store.Put(t.ID, b); err != nil {
+	if err = s.audit(ctx, t); err != nil {
+		t.Revision--
+
+		return err
+	}
+
+	if s.conditional {
+		err = conditional.PutIf(s.store, t.ID, b, condition, tags(t)...)
+	} else {
+		err = s.store.Put(t.ID, b, tags(t)...)
+	}
+
+	if errors.Is(err, conditional.ErrConflict) {
+		t.Revision--
+
+		return fmt.Errorf("%w: revision %d: %s", ErrConflict, t.Revision, err.Error())
+	}
+
+	if err != nil {
+		t.Revision--
+
 		return fmt.Errorf("update ticket: %w", err)
 	}
 
 	return nil
 }
+
+// condition returns the tag of the revision of the ticket, which the ticket is written conditionally on, or the tag
+// without a value for the tickets stored before they were tagged with their revisions.
+func (s *Service) condition(t *ticket.Ticket) (storage.Tag, error) {
+	r := revision(t)
+
+	if !s.conditional {
+		return r, nil
+	}
+
+	current, err := s.store.GetTags(t.ID)
+	if err != nil {
+		return storage.Tag{}, fmt.Errorf("get ticket tags: %w", err)
+	}
+
+	if conditional.Met(current, storage.Tag{Name: revisionTag}) {
+		return storage.Tag{Name: revisionTag}, nil
+	}
+
+	return r, nil
+}
+
+func (s *Service) audit(ctx context.Context, t *ticket.Ticket) error {
+	if s.auditor == nil {
+		return nil
+	}
+
+	if err := s.auditor.Record(ctx, t); err != nil {
+		return fmt.Errorf("audit ticket: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Service) notify(ctx context.Context, t *ticket.Ticket) {
+	if s.observer != nil {
+		s.observer.TicketUpdated(ctx, t)
+	}
+}
+
+// SetQueryID records the query created by the handler with the given DID to collect the data released by the ticket.
+func (s *Service) SetQueryID(ctx context.Context, ticketID, queryID, handler string) error {
+	return s.modify(ctx, ticketID, "set query", func(t *ticket.Ticket) error {
+		t.QueryID = queryID
+
+		record(ctx, t, ticket.CollectAction, handler)
+
+		return nil
+	})
+}
+
+// GetByQueryID retrieves the ticket the query was created for.
+func (s *Service) GetByQueryID(_ context.Context, queryID string) (*ticket.Ticket, error) {
+	iter, err := s.store.Query(queryIndex + ":" + index.TagValue(queryID))
+	if err != nil {
+		return nil, fmt.Errorf("query ticket: %w", err)
+	}
+
+	defer func() {
+		if e := iter.Close(); e != nil {
+			logger.Errorf("Failed to close iterator: %s", e.Error())
+		}
+	}()
+
+	ok, err := iter.Next()
+	if err != nil {
+		return nil, fmt.Errorf("query ticket: %w", err)
+	}
+
+	if !ok {
+		return nil, fmt.Errorf("get ticket by query: %w", storage.ErrDataNotFound)
+	}
+
+	v, err := iter.Value()
+	if err != nil {
+		return nil, fmt.Errorf("get ticket by query: %w", err)
+	}
+
+	var t ticket.Ticket
+
+	if err = json.Unmarshal(v, &t); err != nil {
+		return nil, fmt.Errorf("unmarshal ticket: %w", err)
+	}
+
+	return &t, nil
+}
+
+// Cursor iterates over tickets a page at a time.
+type Cursor struct {
+	cursor *cursor.Cursor
+}
+
+// Next returns the tickets of the next page. It returns no tickets once all of them have been read.
+func (c *Cursor) Next() ([]*ticket.Ticket, error) {
+	values, err := c.cursor.Next()
+	if err != nil {
+		return nil, fmt.Errorf("next tickets: %w", err)
+	}
+
+	return unmarshalTickets(values)
+}
+
+// Close releases the cursor.
+func (c *Cursor) Close() error {
+	return c.cursor.Close()
+}
+
+// Query returns a cursor over the tickets with the given status, reading pageSize tickets at a time.
+func (s *Service) Query(_ context.Context, status ticket.Status, pageSize int) (*Cursor, error) {
+	c, err := cursor.New(s.store, statusIndex+":"+status.String(), pageSize)
+	if err != nil {
+		return nil, fmt.Errorf("query tickets: %w", err)
+	}
+
+	return &Cursor{cursor: c}, nil
+}
+
+// Iterate calls fn for every ticket with the given status, reading pageSize tickets at a time. Returning
+// cursor.ErrStop from fn stops the iteration.
+func (s *Service) Iterate(ctx context.Context, status ticket.Status, pageSize int,
+	fn func(t *ticket.Ticket) error) error {
+	c, err := s.Query(ctx, status, pageSize)
+	if err != nil {
+		return err
+	}
+
+	return cursor.Iterate(c.cursor, func(values [][]byte) error {
+		tickets, err := unmarshalTickets(values)
+		if err != nil {
+			return err
+		}
+
+		for _, t := range tickets {
+			if err = fn(t); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// IterateDID calls fn for every ticket releasing the protected data with the given DID, reading pageSize tickets at
+// a time. Returning cursor.ErrStop from fn stops the iteration.
+func (s *Service) IterateDID(_ context.Context, did string, pageSize int, fn func(t *ticket.Ticket) error) error {
+	c, err := cursor.New(s.store, didIndex+":"+index.TagValue(did), pageSize)
+	if err != nil {
+		return fmt.Errorf("query tickets: %w", err)
+	}
+
+	return cursor.Iterate(c, func(values [][]byte) error {
+		tickets, err := unmarshalTickets(values)
+		if err != nil {
+			return err
+		}
+
+		for _, t := range tickets {
+			if err = fn(t); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// Filter selects tickets by the handler that requested them, their statuses, the policy of the released data and the
+// time they were created at. Empty fields select all tickets.
+type Filter struct {
+	RequestedBy string
+	Statuses    []ticket.Status
+	PolicyID    string
+	// From and To, if set, select the tickets created at or after From and before To.
+	From time.Time
+	To   time.Time
+}
+
+// Matches reports whether the ticket is selected by the filter.
+func (f *Filter) Matches(t *ticket.Ticket) bool {
+	switch {
+	case f.RequestedBy != "" && t.RequestedBy != f.RequestedBy,
+		f.PolicyID != "" && t.PolicyID != f.PolicyID,
+		!f.From.IsZero() && t.CreatedAt.Before(f.From),
+		!f.To.IsZero() && !t.CreatedAt.Before(f.To):
+		return false
+	}
+
+	if len(f.Statuses) == 0 {
+		return true
+	}
+
+	for _, status := range f.Statuses {
+		if t.Status == status {
+			return true
+		}
+	}
+
+	return false
+}
+
+// expression returns the query expression of the most selective index set by the filter.
+func (f *Filter) expression() string {
+	switch {
+	case f.RequestedBy != "":
+		return requestedByIndex + ":" + index.TagValue(f.RequestedBy)
+	case f.PolicyID != "":
+		return policyIndex + ":" + index.TagValue(f.PolicyID)
+	case len(f.Statuses) == 1:
+		return statusIndex + ":" + f.Statuses[0].String()
+	default:
+		// every ticket has a status
+		return statusIndex
+	}
+}
+
+// IterateFilter calls fn for every ticket selected by the filter, reading pageSize tickets at a time. Returning
+// cursor.ErrStop from fn stops the iteration.
+func (s *Service) IterateFilter(_ context.Context, f *Filter, pageSize int, fn func(t *ticket.Ticket) error) error {
+	c, err := cursor.New(s.store, f.expression(), pageSize)
+	if err != nil {
+		return fmt.Errorf("query tickets: %w", err)
+	}
+
+	return cursor.Iterate(c, func(values [][]byte) error {
+		tickets, err := unmarshalTickets(values)
+		if err != nil {
+			return err
+		}
+
+		for _, t := range tickets {
+			if !f.Matches(t) {
+				continue
+			}
+
+			if err = fn(t); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func unmarshalTickets(values [][]byte) ([]*ticket.Ticket, error) {
+	tickets := make([]*ticket.Ticket, len(values))
+
+	for i, v := range values {
+		var t ticket.Ticket
+
+		if err := json.Unmarshal(v, &t); err != nil {
+			return nil, fmt.Errorf("unmarshal ticket: %w", err)
+		}
+
+		tickets[i] = &t
+	}
+
+	return tickets, nil
+}
+
+func tags(t *ticket.Ticket) []storage.Tag {
+	tags := []storage.Tag{
+		{Name: didIndex, Value: index.TagValue(t.DID)},
+		{Name: statusIndex, Value: t.Status.String()},
+	}
+
+	if r := revision(t); r.Value != "" {
+		tags = append(tags, r)
+	}
+
+	if t.QueryID != "" {
+		tags = append(tags, storage.Tag{Name: queryIndex, Value: index.TagValue(t.QueryID)})
+	}
+
+	if t.RequestedBy != "" {
+		tags = append(tags, storage.Tag{Name: requestedByIndex, Value: index.TagValue(t.RequestedBy)})
+	}
+
+	if t.PolicyID != "" {
+		tags = append(tags, storage.Tag{Name: policyIndex, Value: index.TagValue(t.PolicyID)})
+	}
+
+	return tags
+}
+
+// revision returns the tag of the revision of the ticket, without a value for the first one, which isn't tagged.
+func revision(t *ticket.Ticket) storage.Tag {
+	if t.Revision == 0 {
+		return storage.Tag{Name: revisionTag}
+	}
+
+	return storage.Tag{Name: revisionTag, Value: strconv.Itoa(t.Revision)}
+}
@@ -9,15 +9,23 @@ package release_test
 import (
 	"context"
 	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
 	"testing"
 
 	"github.com/golang/mock/gomock"
+	"github.com/hyperledger/aries-framework-go/component/storageutil/mem"
 	"github.com/hyperledger/aries-framework-go/pkg/mock/storage"
+	storageapi "github.com/hyperledger/aries-framework-go/spi/storage"
 	"github.com/stretchr/testify/require"
 
 	"github.com/trustbloc/ace/pkg/gatekeeper/policy"
 	"github.com/trustbloc/ace/pkg/gatekeeper/protect"
 	"github.com/trustbloc/ace/pkg/gatekeeper/release"
+	releaseticket "github.com/trustbloc/ace/pkg/gatekeeper/release/ticket"
+	"github.com/trustbloc/ace/pkg/storage/conditional"
+	"github.com/trustbloc/ace/pkg/storage/index"
 )
 
 const (
@@ -74,7 +82,7 @@ func TestService_Release(t *testing.T) {
 		})
 		require.NoError(t, err)
 
-		ticket, err := svc.Release(context.Background(), testDID)
+		ticket, err := svc.Release(context.Background(), testDID, testPolicyID, "", "")
 
 		require.EqualError(t, err, "store ticket: put error")
 		require.Nil(t, ticket)
@@ -86,10 +94,79 @@ func TestService_Release(t *testing.T) {
 		})
 		require.NoError(t, err)
 
-		ticket, err := svc.Release(context.Background(), testDID)
+		ticket, err := svc.Release(context.Background(), testDID, testPolicyID, "did:example:handler",
+			"fraud investigation")
 
 		require.NoError(t, err)
 		require.NotNil(t, ticket)
+		require.Equal(t, "did:example:handler", ticket.RequestedBy)
+		require.Equal(t, "fraud investigation", ticket.Justification)
+		require.Equal(t, testPolicyID, ticket.PolicyID)
+		require.False(t, ticket.CreatedAt.IsZero())
+	})
+
+	t.Run("Success: ticket is indexed by did and status", func(t *testing.T) {
+		provider := mem.NewProvider()
+
+		svc, err := release.NewService(&release.Config{
+			StoreProvider: provider,
+		})
+		require.NoError(t, err)
+
+		ticket, err := svc.Release(context.Background(), testDID, "", "", "")
+		require.NoError(t, err)
+
+		config, err := provider.GetStoreConfig("ticket")
+		require.NoError(t, err)
+		require.Equal(t, []string{"did", "status", "queryID", "requestedBy", "policyID"}, config.TagNames)
+
+		store, err := provider.OpenStore("ticket")
+		require.NoError(t, err)
+
+		tags, err := store.GetTags(ticket.ID)
+		require.NoError(t, err)
+		require.Equal(t, []storageapi.Tag{
+			{Name: "did", Value: index.TagValue(testDID)},
+			{Name: "status", Value: "NEW"},
+		}, tags)
+	})
+}
+
+func TestService_BreakGlass(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		svc, err := release.NewService(&release.Config{
+			StoreProvider: mem.NewProvider(),
+		})
+		require.NoError(t, err)
+
+		tk, err := svc.BreakGlass(release.WithReference(context.Background(), "signature"), testDID, testPolicyID,
+			"did:example:responder", "cardiac arrest")
+		require.NoError(t, err)
+
+		stored, err := svc.Get(context.Background(), tk.ID)
+		require.NoError(t, err)
+		require.True(t, stored.BreakGlass)
+		require.Equal(t, releaseticket.ReadyToCollect, stored.Status)
+		require.Empty(t, stored.ApprovedBy)
+		require.Equal(t, "did:example:responder", stored.RequestedBy)
+		require.Equal(t, "cardiac arrest", stored.Justification)
+		require.Len(t, stored.History, 1)
+		require.Equal(t, releaseticket.BreakGlassAction, stored.History[0].Action)
+		require.Equal(t, "did:example:responder", stored.History[0].Actor)
+		require.Equal(t, "signature", stored.History[0].Reference)
+	})
+
+	t.Run("Fail to store ticket", func(t *testing.T) {
+		store := storage.NewMockStoreProvider()
+		store.Store.ErrPut = errors.New("put error")
+
+		svc, err := release.NewService(&release.Config{
+			StoreProvider: store,
+		})
+		require.NoError(t, err)
+
+		_, err = svc.BreakGlass(context.Background(), testDID, testPolicyID, "did:example:responder", "")
+		require.EqualError(t, err, "store ticket: put error")
 	})
 }
 
@@ -135,7 +212,7 @@ func TestService_Authorize(t *testing.T) {
 		})
 		require.NoError(t, err)
 
-		err = svc.Authorize(context.Background(), testTicketID, testApprover)
+		err = svc.Authorize(context.Background(), testTicketID, testApprover, "")
 
 		require.EqualError(t, err, "get ticket to authorize: get ticket: get error")
 	})
@@ -155,7 +232,7 @@ func TestService_Authorize(t *testing.T) {
 		})
 		require.NoError(t, err)
 
-		err = svc.Authorize(context.Background(), testTicketID, testApprover)
+		err = svc.Authorize(context.Background(), testTicketID, testApprover, "")
 
 		require.EqualError(t, err, "get protected data: get error")
 	})
@@ -179,7 +256,7 @@ func TestService_Authorize(t *testing.T) {
 		})
 		require.NoError(t, err)
 
-		err = svc.Authorize(context.Background(), testTicketID, testApprover)
+		err = svc.Authorize(context.Background(), testTicketID, testApprover, "")
 
 		require.EqualError(t, err, "get policy: get error")
 	})
@@ -208,7 +285,7 @@ func TestService_Authorize(t *testing.T) {
 		})
 		require.NoError(t, err)
 
-		err = svc.Authorize(context.Background(), testTicketID, testApprover)
+		err = svc.Authorize(context.Background(), testTicketID, testApprover, "")
 
 		require.EqualError(t, err, "update ticket: put error")
 	})
@@ -236,7 +313,7 @@ func TestService_Authorize(t *testing.T) {
 		})
 		require.NoError(t, err)
 
-		err = svc.Authorize(context.Background(), testTicketID, testApprover)
+		err = svc.Authorize(context.Background(), testTicketID, testApprover, "")
 
 		require.NoError(t, err)
 	})
@@ -264,8 +341,778 @@ func TestService_Authorize(t *testing.T) {
 		})
 		require.NoError(t, err)
 
-		err = svc.Authorize(context.Background(), testTicketID, testApprover)
+		err = svc.Authorize(context.Background(), testTicketID, testApprover, "")
+
+		require.NoError(t, err)
+	})
+}
+
+func TestService_AuthorizeConcurrently(t *testing.T) {
+	const (
+		approvers = 4
+		calls     = 2 * approvers
+	)
+
+	ctrl := gomock.NewController(t)
+
+	p := &policy.Policy{ID: testPolicyID, MinApprovers: approvers}
+
+	for i := 0; i < approvers; i++ {
+		p.Approvers = append(p.Approvers, fmt.Sprintf("did:example:approver-%d", i))
+	}
+
+	var (
+		read    sync.WaitGroup
+		reads   int32
+		started = make(chan struct{})
+	)
+
+	read.Add(calls)
+
+	// the first attempts all read the ticket before any of them updates it
+	protectService := NewMockProtectService(ctrl)
+	protectService.EXPECT().Get(gomock.Any(), testDID).DoAndReturn(
+		func(context.Context, string) (*protect.ProtectedData, error) {
+			if atomic.AddInt32(&reads, 1) <= calls {
+				read.Done()
+				<-started
+			}
+
+			return &protect.ProtectedData{PolicyID: testPolicyID}, nil
+		}).AnyTimes()
+
+	policyService := NewMockPolicyService(ctrl)
+	policyService.EXPECT().Get(gomock.Any(), testPolicyID).Return(p, nil).AnyTimes()
+
+	svc, err := release.NewService(&release.Config{
+		StoreProvider:  mem.NewProvider(),
+		ProtectService: protectService,
+		PolicyService:  policyService,
+	})
+	require.NoError(t, err)
+
+	ticket, err := svc.Release(context.Background(), testDID, testPolicyID, "", "")
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+
+	errs := make(chan error, calls)
+
+	for _, approver := range p.Approvers {
+		for i := 0; i < 2; i++ {
+			wg.Add(1)
+
+			go func(approver string) {
+				defer wg.Done()
+
+				errs <- svc.Authorize(context.Background(), ticket.ID, approver, "")
+			}(approver)
+		}
+	}
+
+	read.Wait()
+	close(started)
+	wg.Wait()
+	close(errs)
+
+	for e := range errs {
+		if e != nil {
+			require.NoError(t, e)
+		}
+	}
+
+	ticket, err = svc.Get(context.Background(), ticket.ID)
+	require.NoError(t, err)
+	require.ElementsMatch(t, p.Approvers, ticket.ApprovedBy)
+	require.Equal(t, releaseticket.ReadyToCollect, ticket.Status)
+	require.Equal(t, len(ticket.History)-1, ticket.Revision)
+	require.Greater(t, atomic.LoadInt32(&reads), int32(calls))
+}
+
+func TestService_AuthorizeConcurrentlyOnInstances(t *testing.T) {
+	const (
+		instances = 2
+		approvers = 4
+	)
+
+	ctrl := gomock.NewController(t)
+
+	p := &policy.Policy{ID: testPolicyID, MinApprovers: approvers}
+
+	for i := 0; i < approvers; i++ {
+		p.Approvers = append(p.Approvers, fmt.Sprintf("did:example:approver-%d", i))
+	}
+
+	var (
+		read    sync.WaitGroup
+		reads   int32
+		started = make(chan struct{})
+	)
+
+	read.Add(approvers)
+
+	// the first attempts all read the ticket before any of them updates it
+	protectService := NewMockProtectService(ctrl)
+	protectService.EXPECT().Get(gomock.Any(), testDID).DoAndReturn(
+		func(context.Context, string) (*protect.ProtectedData, error) {
+			if atomic.AddInt32(&reads, 1) <= approvers {
+				read.Done()
+				<-started
+			}
+
+			return &protect.ProtectedData{PolicyID: testPolicyID}, nil
+		}).AnyTimes()
+
+	policyService := NewMockPolicyService(ctrl)
+	policyService.EXPECT().Get(gomock.Any(), testPolicyID).Return(p, nil).AnyTimes()
+
+	// the instances share a store writing conditionally, so that none of them needs the leader's lease
+	provider := conditional.NewLockingProvider(mem.NewProvider())
+	svcs := make([]*release.Service, instances)
+
+	for i := range svcs {
+		svc, err := release.NewService(&release.Config{
+			StoreProvider:  provider,
+			ProtectService: protectService,
+			PolicyService:  policyService,
+			Leader:         &testLeader{},
+		})
+		require.NoError(t, err)
+		require.False(t, svc.RequiresLeader())
+
+		svcs[i] = svc
+	}
+
+	ticket, err := svcs[0].Release(context.Background(), testDID, testPolicyID, "", "")
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+
+	errs := make(chan error, approvers)
+
+	for i, approver := range p.Approvers {
+		wg.Add(1)
+
+		go func(svc *release.Service, approver string) {
+			defer wg.Done()
+
+			errs <- svc.Authorize(context.Background(), ticket.ID, approver, "")
+		}(svcs[i%instances], approver)
+	}
+
+	read.Wait()
+	close(started)
+	wg.Wait()
+	close(errs)
+
+	for e := range errs {
+		require.NoError(t, e)
+	}
+
+	ticket, err = svcs[1].Get(context.Background(), ticket.ID)
+	require.NoError(t, err)
+	require.ElementsMatch(t, p.Approvers, ticket.ApprovedBy)
+	require.Equal(t, releaseticket.ReadyToCollect, ticket.Status)
+	require.Equal(t, len(ticket.History)-1, ticket.Revision)
+	require.Greater(t, atomic.LoadInt32(&reads), int32(approvers))
+}
+
+func TestService_AuthorizeWithComment(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	storeProvider := mem.NewProvider()
+
+	protectService := NewMockProtectService(ctrl)
+	protectService.EXPECT().Get(gomock.Any(), testDID).
+		Return(&protect.ProtectedData{PolicyID: testPolicyID}, nil).AnyTimes()
+
+	policyService := NewMockPolicyService(ctrl)
+	policyService.EXPECT().Get(gomock.Any(), testPolicyID).Return(&policy.Policy{
+		ID:           testPolicyID,
+		Approvers:    []string{testApprover, "did:example:another-approver"},
+		MinApprovers: 2,
+	}, nil).AnyTimes()
+
+	svc, err := release.NewService(&release.Config{
+		StoreProvider:  storeProvider,
+		ProtectService: protectService,
+		PolicyService:  policyService,
+	})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	tk, err := svc.Release(ctx, testDID, testPolicyID, "did:example:handler", "")
+	require.NoError(t, err)
+
+	require.NoError(t, svc.Authorize(ctx, tk.ID, testApprover, "verified the request with the handler"))
+	require.NoError(t, svc.Authorize(ctx, tk.ID, testApprover, ""))
+
+	tk, err = svc.Get(ctx, tk.ID)
+	require.NoError(t, err)
+	require.Equal(t, releaseticket.Collecting, tk.Status)
+	require.Len(t, tk.Comments, 1)
+	require.Equal(t, testApprover, tk.Comments[0].Approver)
+	require.Equal(t, releaseticket.Approve, tk.Comments[0].Decision)
+	require.Equal(t, "verified the request with the handler", tk.Comments[0].Comment)
+	require.False(t, tk.Comments[0].Timestamp.IsZero())
+}
+
+func TestService_History(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	protectService := NewMockProtectService(ctrl)
+	protectService.EXPECT().Get(gomock.Any(), testDID).Return(&protect.ProtectedData{PolicyID: testPolicyID}, nil).
+		Times(2)
+
+	policyService := NewMockPolicyService(ctrl)
+	policyService.EXPECT().Get(gomock.Any(), testPolicyID).Return(&policy.Policy{
+		ID:           testPolicyID,
+		Approvers:    []string{testApprover},
+		MinApprovers: 1,
+	}, nil).Times(2)
+
+	svc, err := release.NewService(&release.Config{
+		StoreProvider:  mem.NewProvider(),
+		ProtectService: protectService,
+		PolicyService:  policyService,
+	})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	tk, err := svc.Release(release.WithReference(ctx, "release-signature"), testDID, testPolicyID,
+		"did:example:handler", "")
+	require.NoError(t, err)
+
+	require.NoError(t, svc.Authorize(release.WithReference(ctx, "approve-signature"), tk.ID, testApprover, ""))
+	require.NoError(t, svc.Authorize(release.WithDelegation(ctx, "delegation", "did:example:delegate"), tk.ID,
+		testApprover, ""))
+	require.NoError(t, svc.SetQueryID(ctx, tk.ID, "query", "did:example:handler"))
+
+	tk, err = svc.Get(ctx, tk.ID)
+	require.NoError(t, err)
+	require.Len(t, tk.History, 4)
+
+	for i, expected := range []*releaseticket.Event{
+		{Action: releaseticket.ReleaseAction, Status: releaseticket.New, Actor: "did:example:handler",
+			Reference: "release-signature"},
+		{Action: releaseticket.ApproveAction, Status: releaseticket.ReadyToCollect, Actor: testApprover,
+			Reference: "approve-signature"},
+		{Action: releaseticket.ApproveAction, Status: releaseticket.ReadyToCollect, Actor: "did:example:delegate",
+			OnBehalfOf: testApprover, Delegation: "delegation"},
+		{Action: releaseticket.CollectAction, Status: releaseticket.ReadyToCollect, Actor: "did:example:handler"},
+	} {
+		require.False(t, tk.History[i].Timestamp.IsZero())
+
+		expected.Timestamp = tk.History[i].Timestamp
+		require.Equal(t, expected, tk.History[i])
+	}
+}
+
+func TestService_Observer(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	var actions []releaseticket.Action
+
+	observer := NewMockObserver(ctrl)
+	observer.EXPECT().TicketUpdated(gomock.Any(), gomock.Any()).Do(func(_ context.Context, tk *releaseticket.Ticket) {
+		actions = append(actions, tk.History[len(tk.History)-1].Action)
+	}).Times(3)
+
+	svc, err := release.NewService(&release.Config{
+		StoreProvider: mem.NewProvider(),
+		Observer:      observer,
+	})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	tk, err := svc.Release(ctx, testDID, testPolicyID, "did:example:handler", "")
+	require.NoError(t, err)
+
+	require.NoError(t, svc.Reject(ctx, tk.ID, testApprover, "no"))
+
+	_, err = svc.BreakGlass(ctx, testDID, testPolicyID, "did:example:responder", "emergency")
+	require.NoError(t, err)
+
+	require.Equal(t, []releaseticket.Action{
+		releaseticket.ReleaseAction, releaseticket.RejectAction, releaseticket.BreakGlassAction,
+	}, actions)
+
+	t.Run("Not called if the ticket fails to be stored", func(t *testing.T) {
+		store := storage.NewMockStoreProvider()
+		store.Store.ErrPut = errors.New("put error")
+
+		s, e := release.NewService(&release.Config{
+			StoreProvider: store,
+			Observer:      NewMockObserver(gomock.NewController(t)),
+		})
+		require.NoError(t, e)
+
+		_, e = s.Release(ctx, testDID, testPolicyID, "did:example:handler", "")
+		require.Error(t, e)
+	})
+}
+
+func TestService_Auditor(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Records the actions before the tickets are stored", func(t *testing.T) {
+		var actions []releaseticket.Action
+
+		auditor := NewMockAuditor(gomock.NewController(t))
+		auditor.EXPECT().Record(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, tk *releaseticket.Ticket) error {
+				actions = append(actions, tk.History[len(tk.History)-1].Action)
+
+				return nil
+			}).Times(2)
+
+		svc, err := release.NewService(&release.Config{StoreProvider: mem.NewProvider(), Auditor: auditor})
+		require.NoError(t, err)
+
+		tk, err := svc.Release(ctx, testDID, testPolicyID, "did:example:handler", "")
+		require.NoError(t, err)
+
+		require.NoError(t, svc.Reject(ctx, tk.ID, testApprover, "no"))
+		require.Equal(t, []releaseticket.Action{releaseticket.ReleaseAction, releaseticket.RejectAction}, actions)
+	})
+
+	t.Run("Actions fail if they cannot be recorded", func(t *testing.T) {
+		auditor := NewMockAuditor(gomock.NewController(t))
+		auditor.EXPECT().Record(gomock.Any(), gomock.Any()).Return(errors.New("append error")).Times(2)
+
+		svc, err := release.NewService(&release.Config{StoreProvider: mem.NewProvider(), Auditor: auditor})
+		require.NoError(t, err)
+
+		_, err = svc.Release(ctx, testDID, testPolicyID, "did:example:handler", "")
+		require.EqualError(t, err, "audit ticket: append error")
+
+		provider := mem.NewProvider()
+
+		svc, err = release.NewService(&release.Config{StoreProvider: provider})
+		require.NoError(t, err)
+
+		tk, err := svc.Release(ctx, testDID, testPolicyID, "did:example:handler", "")
+		require.NoError(t, err)
+
+		svc, err = release.NewService(&release.Config{StoreProvider: provider, Auditor: auditor})
+		require.NoError(t, err)
+
+		err = svc.Reject(ctx, tk.ID, testApprover, "no")
+		require.EqualError(t, err, "audit ticket: append error")
+
+		stored, err := svc.Get(ctx, tk.ID)
+		require.NoError(t, err)
+		require.Equal(t, releaseticket.New, stored.Status)
+	})
+}
+
+func TestService_Leader(t *testing.T) {
+	leader := &testLeader{}
+
+	svc, err := release.NewService(&release.Config{StoreProvider: mem.NewProvider(), Leader: leader})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	tk, err := svc.Release(ctx, testDID, testPolicyID, "did:example:handler", "")
+	require.NoError(t, err)
+
+	t.Run("Updates fail on the other instances", func(t *testing.T) {
+		require.ErrorIs(t, svc.Reject(ctx, tk.ID, testApprover, ""), release.ErrNotLeader)
+		require.ErrorIs(t, svc.SetQueryID(ctx, tk.ID, "query", "did:example:handler"), release.ErrNotLeader)
+
+		stored, e := svc.Get(ctx, tk.ID)
+		require.NoError(t, e)
+		require.Equal(t, releaseticket.New, stored.Status)
+		require.Empty(t, stored.QueryID)
+	})
+
+	t.Run("Updates succeed on the instance holding the lease", func(t *testing.T) {
+		leader.held = true
+
+		require.NoError(t, svc.Reject(ctx, tk.ID, testApprover, ""))
+	})
+
+	t.Run("Updates succeed on every instance if the store writes conditionally", func(t *testing.T) {
+		s, e := release.NewService(&release.Config{
+			StoreProvider: conditional.NewLockingProvider(mem.NewProvider()),
+			Leader:        &testLeader{},
+		})
+		require.NoError(t, e)
+		require.False(t, s.RequiresLeader())
+
+		created, e := s.Release(ctx, testDID, testPolicyID, "did:example:handler", "")
+		require.NoError(t, e)
+
+		require.NoError(t, s.SetQueryID(ctx, created.ID, "query", "did:example:handler"))
+		require.NoError(t, s.Reject(ctx, created.ID, testApprover, ""))
+	})
+}
+
+type testLeader struct {
+	held bool
+}
+
+func (l *testLeader) Held() bool {
+	return l.held
+}
+
+func TestService_Reject(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		svc, err := release.NewService(&release.Config{StoreProvider: mem.NewProvider()})
+		require.NoError(t, err)
+
+		ctx := context.Background()
+
+		tk, err := svc.Release(ctx, testDID, testPolicyID, "did:example:handler", "")
+		require.NoError(t, err)
+
+		require.NoError(t, svc.Reject(ctx, tk.ID, testApprover, "no active investigation"))
+
+		tk, err = svc.Get(ctx, tk.ID)
+		require.NoError(t, err)
+		require.Equal(t, releaseticket.Rejected, tk.Status)
+		require.Equal(t, "REJECTED", tk.Status.String())
+		require.Equal(t, testApprover, tk.RejectedBy)
+		require.Len(t, tk.Comments, 1)
+		require.Equal(t, releaseticket.Reject, tk.Comments[0].Decision)
+
+		require.ErrorIs(t, svc.Reject(ctx, tk.ID, testApprover, ""), release.ErrRejected)
+		require.ErrorIs(t, svc.Authorize(ctx, tk.ID, testApprover, ""), release.ErrRejected)
+	})
 
+	t.Run("Fail to get ticket to reject", func(t *testing.T) {
+		svc, err := release.NewService(&release.Config{StoreProvider: mem.NewProvider()})
 		require.NoError(t, err)
+
+		err = svc.Reject(context.Background(), testTicketID, testApprover, "")
+		require.ErrorIs(t, err, storageapi.ErrDataNotFound)
+		require.Contains(t, err.Error(), "get ticket to reject")
+	})
+
+	t.Run("Fail to store ticket", func(t *testing.T) {
+		store := storage.NewMockStoreProvider()
+		store.Store.Store[testTicketID] = storage.DBEntry{Value: []byte(testTicket)}
+		store.Store.ErrPut = errors.New("put error")
+
+		svc, err := release.NewService(&release.Config{StoreProvider: store})
+		require.NoError(t, err)
+
+		err = svc.Reject(context.Background(), testTicketID, testApprover, "")
+		require.EqualError(t, err, "update ticket: put error")
+	})
+}
+
+func TestService_SetQueryID(t *testing.T) {
+	t.Run("Fail to get ticket", func(t *testing.T) {
+		svc, err := release.NewService(&release.Config{
+			StoreProvider: storage.NewMockStoreProvider(),
+		})
+		require.NoError(t, err)
+
+		err = svc.SetQueryID(context.Background(), testTicketID, "query", "did:example:handler")
+
+		require.EqualError(t, err, "get ticket to set query: get ticket: data not found")
+	})
+
+	t.Run("Fail to update ticket", func(t *testing.T) {
+		store := storage.NewMockStoreProvider()
+		store.Store.Store[testTicketID] = storage.DBEntry{Value: []byte(testTicket)}
+		store.Store.ErrPut = errors.New("put error")
+
+		svc, err := release.NewService(&release.Config{
+			StoreProvider: store,
+		})
+		require.NoError(t, err)
+
+		err = svc.SetQueryID(context.Background(), testTicketID, "query", "did:example:handler")
+
+		require.EqualError(t, err, "update ticket: put error")
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		svc, err := release.NewService(&release.Config{
+			StoreProvider: mem.NewProvider(),
+		})
+		require.NoError(t, err)
+
+		ticket, err := svc.Release(context.Background(), testDID, testPolicyID, "", "")
+		require.NoError(t, err)
+
+		require.NoError(t, svc.SetQueryID(context.Background(), ticket.ID, "query", "did:example:handler"))
+
+		ticket, err = svc.Get(context.Background(), ticket.ID)
+		require.NoError(t, err)
+		require.Equal(t, "query", ticket.QueryID)
+
+		byQuery, err := svc.GetByQueryID(context.Background(), "query")
+		require.NoError(t, err)
+		require.Equal(t, ticket.ID, byQuery.ID)
+	})
+}
+
+func TestService_GetByQueryID(t *testing.T) {
+	t.Run("Ticket not found", func(t *testing.T) {
+		svc, err := release.NewService(&release.Config{
+			StoreProvider: mem.NewProvider(),
+		})
+		require.NoError(t, err)
+
+		_, err = svc.GetByQueryID(context.Background(), "query")
+		require.ErrorIs(t, err, storageapi.ErrDataNotFound)
+	})
+
+	t.Run("Fail to query ticket", func(t *testing.T) {
+		store := storage.NewMockStoreProvider()
+		store.Store.ErrQuery = errors.New("query error")
+
+		svc, err := release.NewService(&release.Config{
+			StoreProvider: store,
+		})
+		require.NoError(t, err)
+
+		_, err = svc.GetByQueryID(context.Background(), "query")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "query ticket")
+	})
+
+	t.Run("Fail to read ticket", func(t *testing.T) {
+		store := storage.NewMockStoreProvider()
+		store.Store.Store[testTicketID] = storage.DBEntry{
+			Value: []byte("invalid"),
+			Tags:  []storageapi.Tag{{Name: "queryID", Value: index.TagValue("query")}},
+		}
+
+		svc, err := release.NewService(&release.Config{
+			StoreProvider: store,
+		})
+		require.NoError(t, err)
+
+		_, err = svc.GetByQueryID(context.Background(), "query")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "unmarshal ticket")
+	})
+}
+
+func TestService_Query(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		svc, err := release.NewService(&release.Config{
+			StoreProvider: mem.NewProvider(),
+		})
+		require.NoError(t, err)
+
+		for i := 0; i < 3; i++ {
+			_, err = svc.Release(context.Background(), testDID, testPolicyID, "", "")
+			require.NoError(t, err)
+		}
+
+		c, err := svc.Query(context.Background(), releaseticket.New, 2)
+		require.NoError(t, err)
+
+		defer func() {
+			require.NoError(t, c.Close())
+		}()
+
+		tickets, err := c.Next()
+		require.NoError(t, err)
+		require.Len(t, tickets, 2)
+
+		tickets, err = c.Next()
+		require.NoError(t, err)
+		require.Len(t, tickets, 1)
+		require.Equal(t, testDID, tickets[0].DID)
+
+		tickets, err = c.Next()
+		require.NoError(t, err)
+		require.Empty(t, tickets)
+
+		count := 0
+
+		err = svc.Iterate(context.Background(), releaseticket.New, 2, func(t *releaseticket.Ticket) error {
+			count++
+
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, 3, count)
+
+		err = svc.Iterate(context.Background(), releaseticket.Collecting, 2, func(t *releaseticket.Ticket) error {
+			return errors.New("no tickets expected")
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("Fail to query tickets", func(t *testing.T) {
+		store := storage.NewMockStoreProvider()
+		store.Store.ErrQuery = errors.New("query error")
+
+		svc, err := release.NewService(&release.Config{
+			StoreProvider: store,
+		})
+		require.NoError(t, err)
+
+		_, err = svc.Query(context.Background(), releaseticket.New, 2)
+		require.EqualError(t, err, "query tickets: query status:NEW: query error")
+
+		err = svc.Iterate(context.Background(), releaseticket.New, 2, func(t *releaseticket.Ticket) error {
+			return nil
+		})
+		require.EqualError(t, err, "query tickets: query status:NEW: query error")
+	})
+
+	t.Run("Fail to read tickets", func(t *testing.T) {
+		store := storage.NewMockStoreProvider()
+		store.Store.Store[testTicketID] = storage.DBEntry{
+			Value: []byte("invalid"),
+			Tags:  []storageapi.Tag{{Name: "status", Value: "NEW"}},
+		}
+
+		svc, err := release.NewService(&release.Config{
+			StoreProvider: store,
+		})
+		require.NoError(t, err)
+
+		c, err := svc.Query(context.Background(), releaseticket.New, 2)
+		require.NoError(t, err)
+
+		_, err = c.Next()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "unmarshal ticket")
+
+		err = svc.Iterate(context.Background(), releaseticket.New, 2, func(t *releaseticket.Ticket) error {
+			return nil
+		})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "unmarshal ticket")
+
+		store.Store.ErrNext = errors.New("next error")
+
+		c, err = svc.Query(context.Background(), releaseticket.New, 2)
+		require.NoError(t, err)
+
+		_, err = c.Next()
+		require.EqualError(t, err, "next tickets: next entry: next error")
+	})
+}
+
+func TestService_IterateDID(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		svc, err := release.NewService(&release.Config{
+			StoreProvider: mem.NewProvider(),
+		})
+		require.NoError(t, err)
+
+		for i := 0; i < 3; i++ {
+			_, err = svc.Release(context.Background(), testDID, testPolicyID, "", "")
+			require.NoError(t, err)
+		}
+
+		_, err = svc.Release(context.Background(), "did:example:other", testPolicyID, "", "")
+		require.NoError(t, err)
+
+		var tickets []*releaseticket.Ticket
+
+		err = svc.IterateDID(context.Background(), testDID, 2, func(t *releaseticket.Ticket) error {
+			tickets = append(tickets, t)
+
+			return nil
+		})
+		require.NoError(t, err)
+		require.Len(t, tickets, 3)
+
+		for _, ticket := range tickets {
+			require.Equal(t, testDID, ticket.DID)
+		}
+	})
+
+	t.Run("Fail to query tickets", func(t *testing.T) {
+		store := storage.NewMockStoreProvider()
+		store.Store.ErrQuery = errors.New("query error")
+
+		svc, err := release.NewService(&release.Config{
+			StoreProvider: store,
+		})
+		require.NoError(t, err)
+
+		err = svc.IterateDID(context.Background(), testDID, 2, func(t *releaseticket.Ticket) error {
+			return nil
+		})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "query tickets")
+		require.Contains(t, err.Error(), "query error")
+	})
+}
+
+func TestService_IterateFilter(t *testing.T) {
+	ctx := context.Background()
+
+	svc, err := release.NewService(&release.Config{
+		StoreProvider: mem.NewProvider(),
+	})
+	require.NoError(t, err)
+
+	handlerTicket, err := svc.Release(ctx, testDID, testPolicyID, "did:example:handler", "")
+	require.NoError(t, err)
+
+	_, err = svc.Release(ctx, testDID, "other-policy", "did:example:handler", "")
+	require.NoError(t, err)
+
+	_, err = svc.Release(ctx, testDID, testPolicyID, "did:example:other", "")
+	require.NoError(t, err)
+
+	list := func(t *testing.T, f *release.Filter) []*releaseticket.Ticket {
+		t.Helper()
+
+		var tickets []*releaseticket.Ticket
+
+		require.NoError(t, svc.IterateFilter(ctx, f, 1, func(t *releaseticket.Ticket) error {
+			tickets = append(tickets, t)
+
+			return nil
+		}))
+
+		return tickets
+	}
+
+	t.Run("All tickets", func(t *testing.T) {
+		require.Len(t, list(t, &release.Filter{}), 3)
+	})
+
+	t.Run("Tickets of the requestor and the policy", func(t *testing.T) {
+		tickets := list(t, &release.Filter{RequestedBy: "did:example:handler", PolicyID: testPolicyID})
+		require.Len(t, tickets, 1)
+		require.Equal(t, handlerTicket.ID, tickets[0].ID)
+	})
+
+	t.Run("Tickets of the policy with a status", func(t *testing.T) {
+		require.Len(t, list(t, &release.Filter{
+			PolicyID: testPolicyID,
+			Statuses: []releaseticket.Status{releaseticket.New},
+		}), 2)
+		require.Empty(t, list(t, &release.Filter{Statuses: []releaseticket.Status{releaseticket.Rejected}}))
+	})
+
+	t.Run("Tickets created in a time range", func(t *testing.T) {
+		require.Len(t, list(t, &release.Filter{From: handlerTicket.CreatedAt}), 3)
+		require.Empty(t, list(t, &release.Filter{To: handlerTicket.CreatedAt}))
+	})
+
+	t.Run("Fail to query tickets", func(t *testing.T) {
+		store := storage.NewMockStoreProvider()
+		store.Store.ErrQuery = errors.New("query error")
+
+		s, err := release.NewService(&release.Config{
+			StoreProvider: store,
+		})
+		require.NoError(t, err)
+
+		err = s.IterateFilter(ctx, &release.Filter{}, 1, func(t *releaseticket.Ticket) error {
+			return nil
+		})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "query error")
 	})
 }
@@ -6,6 +6,8 @@ SPDX-License-Identifier: Apache-2.0
 
 package ticket
 
+import "time"
+
 // Status is a ticket release status.
 type Status int
 
@@ -16,6 +18,8 @@ const (
 	Collecting
 	// ReadyToCollect represents a ticket ready to collect.
 	ReadyToCollect
+	// Rejected represents a ticket rejected by an approver, which can no longer be authorized or collected.
+	Rejected
 )
 
 // String returns string representation of Status.
@@ -27,6 +31,8 @@ func (s Status) String() string {
 		return "COLLECTING"
 	case ReadyToCollect:
 		return "READY_TO_COLLECT"
+	case Rejected:
+		return "REJECTED"
 	default:
 		return ""
 	}
@@ -38,4 +44,77 @@ type Ticket struct {
 	DID        string   `json:"did"`
 	Status     Status   `json:"status"`
 	ApprovedBy []string `json:"approved_by"`
+	// PolicyID is the ID of the policy the released data is protected with.
+	PolicyID string `json:"policy_id,omitempty"`
+	// CreatedAt is the time the release was requested.
+	CreatedAt time.Time `json:"created_at"`
+	// RequestedBy is the DID of the handler that requested the release, and Justification the reason they gave.
+	RequestedBy   string `json:"requested_by,omitempty"`
+	Justification string `json:"justification,omitempty"`
+	// QueryID is the query last created to collect the released data.
+	QueryID string `json:"query_id,omitempty"`
+	// RejectedBy is the DID of the approver that rejected the ticket.
+	RejectedBy string `json:"rejected_by,omitempty"`
+	// Comments are the comments of the approvers on their decisions.
+	Comments []*Comment `json:"comments,omitempty"`
+	// BreakGlass is set if the data was collected by a responder in an emergency, without the approvals of the
+	// approvers.
+	BreakGlass bool `json:"break_glass,omitempty"`
+	// History is the actions taken on the ticket, oldest first.
+	History []*Event `json:"history,omitempty"`
+	// Revision is incremented on every update of the ticket, so that concurrent updates are detected.
+	Revision int `json:"revision,omitempty"`
+}
+
+// Decision is the decision of an approver on a ticket.
+type Decision string
+
+const (
+	// Approve is the decision of approvers authorizing a ticket.
+	Approve Decision = "approve"
+	// Reject is the decision of approvers rejecting a ticket.
+	Reject Decision = "reject"
+)
+
+// Comment is the comment of an approver on their decision.
+type Comment struct {
+	Approver  string    `json:"approver"`
+	Decision  Decision  `json:"decision"`
+	Comment   string    `json:"comment"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Action is an action taken on a ticket.
+type Action string
+
+const (
+	// ReleaseAction is the request of a handler to release the data, creating the ticket.
+	ReleaseAction Action = "release"
+	// ApproveAction is the approval of the ticket by an approver.
+	ApproveAction Action = "approve"
+	// RejectAction is the rejection of the ticket by an approver.
+	RejectAction Action = "reject"
+	// CollectAction is the creation of a query by a handler to collect the released data.
+	CollectAction Action = "collect"
+	// BreakGlassAction is the emergency access of a responder to the data, creating the ticket without the approvals
+	// of the approvers.
+	BreakGlassAction Action = "break_glass"
+	// BreakGlassNotifiedAction is the notification of a break-glass access to the subject of the data and to the
+	// approvers, recorded in the audit log before the data is collected.
+	BreakGlassNotifiedAction Action = "break_glass_notified"
+)
+
+// Event is an action taken on a ticket, with the status of the ticket after it.
+type Event struct {
+	Action Action `json:"action"`
+	Status Status `json:"status"`
+	// Actor is the DID of the party that took the action.
+	Actor string `json:"actor"`
+	// Reference, if known, references the signature or the capability the action was authorized with.
+	Reference string    `json:"reference,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+	// OnBehalfOf, if the actor decided as a delegate of an approver, is the DID of the approver, and Delegation the ID
+	// of the delegation the actor decided with.
+	OnBehalfOf string `json:"on_behalf_of,omitempty"`
+	Delegation string `json:"delegation,omitempty"`
 }
@@ -0,0 +1,79 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package watch lets the tickets of the release service be watched for updates, for them to be streamed to the
+// handlers as they happen. Only the updates made by the instance of Gatekeeper the tickets are watched on are seen.
+package watch
+
+import (
+	"context"
+	"sync"
+
+	"github.com/trustbloc/ace/pkg/gatekeeper/release/ticket"
+)
+
+// bufferSize is the number of updates of a ticket waiting for a watcher to receive them.
+const bufferSize = 16
+
+// Hub dispatches the updates of the tickets it observes to their watchers.
+type Hub struct {
+	mu       sync.Mutex
+	watchers map[string]map[chan *ticket.Ticket]struct{}
+}
+
+// NewHub returns a hub without watchers.
+func NewHub() *Hub {
+	return &Hub{watchers: map[string]map[chan *ticket.Ticket]struct{}{}}
+}
+
+// Watch returns a channel receiving the ticket with the given ID every time it is updated, and a function to stop
+// watching it. The channel is closed once the ticket is no longer watched, or if the watcher falls behind by more
+// than a few updates, in which case it should read the ticket again before watching it anew.
+func (h *Hub) Watch(ticketID string) (<-chan *ticket.Ticket, func()) {
+	ch := make(chan *ticket.Ticket, bufferSize)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.watchers[ticketID] == nil {
+		h.watchers[ticketID] = map[chan *ticket.Ticket]struct{}{}
+	}
+
+	h.watchers[ticketID][ch] = struct{}{}
+
+	return ch, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+
+		if _, ok := h.watchers[ticketID][ch]; ok {
+			h.remove(ticketID, ch)
+		}
+	}
+}
+
+// TicketUpdated sends the ticket to its watchers.
+func (h *Hub) TicketUpdated(_ context.Context, t *ticket.Ticket) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for ch := range h.watchers[t.ID] {
+		select {
+		case ch <- t:
+		default:
+			h.remove(t.ID, ch)
+		}
+	}
+}
+
+// remove closes the channel of a watcher of the ticket. The lock must be held.
+func (h *Hub) remove(ticketID string, ch chan *ticket.Ticket) {
+	delete(h.watchers[ticketID], ch)
+	close(ch)
+
+	if len(h.watchers[ticketID]) == 0 {
+		delete(h.watchers, ticketID)
+	}
+}
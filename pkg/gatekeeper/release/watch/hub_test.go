@@ -0,0 +1,68 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package watch_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/ace/pkg/gatekeeper/release/ticket"
+	"github.com/trustbloc/ace/pkg/gatekeeper/release/watch"
+)
+
+func TestHub(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Updates are sent to the watchers of the ticket", func(t *testing.T) {
+		h := watch.NewHub()
+
+		first, stopFirst := h.Watch("1")
+		second, stopSecond := h.Watch("1")
+		other, stopOther := h.Watch("2")
+
+		defer stopOther()
+
+		h.TicketUpdated(ctx, &ticket.Ticket{ID: "1", Status: ticket.Collecting})
+
+		require.Equal(t, ticket.Collecting, (<-first).Status)
+		require.Equal(t, ticket.Collecting, (<-second).Status)
+		require.Empty(t, other)
+
+		stopFirst()
+		stopFirst()
+
+		_, ok := <-first
+		require.False(t, ok)
+
+		h.TicketUpdated(ctx, &ticket.Ticket{ID: "1", Status: ticket.ReadyToCollect})
+		require.Equal(t, ticket.ReadyToCollect, (<-second).Status)
+
+		stopSecond()
+		h.TicketUpdated(ctx, &ticket.Ticket{ID: "1"})
+	})
+
+	t.Run("Watcher falling behind is dropped", func(t *testing.T) {
+		h := watch.NewHub()
+
+		updates, stop := h.Watch("1")
+		defer stop()
+
+		for i := 0; i < 17; i++ {
+			h.TicketUpdated(ctx, &ticket.Ticket{ID: "1"})
+		}
+
+		n := 0
+
+		for range updates {
+			n++
+		}
+
+		require.Equal(t, 16, n)
+	})
+}
@@ -0,0 +1,280 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package stores lists the stores of Gatekeeper holding durable state, and how their entries are found, for the
+// commands copying the whole state of an instance such as migrate. Stores of short-lived or derived state are not
+// listed: OIDC4VP interactions, DIDComm invitations, health probes and the JSON-LD contexts loaded on start.
+package stores
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/hyperledger/aries-framework-go/pkg/common/log"
+	"github.com/hyperledger/aries-framework-go/pkg/kms/localkms"
+	"github.com/hyperledger/aries-framework-go/pkg/store/wrapper/prefix"
+	"github.com/hyperledger/aries-framework-go/spi/storage"
+
+	"github.com/trustbloc/ace/pkg/gatekeeper/audit"
+	"github.com/trustbloc/ace/pkg/gatekeeper/config"
+	"github.com/trustbloc/ace/pkg/storage/migrate"
+	"github.com/trustbloc/ace/pkg/storage/tombstone"
+)
+
+// SchedulerLease is the lease of the instance running the scheduled jobs.
+const SchedulerLease = "scheduler"
+
+const (
+	policyStore            = "policy"
+	protectedDataStore     = "protected_data"
+	ticketStore            = "ticket"
+	configStore            = "config"
+	encryptionKeyStore     = "storage_encryption"
+	auditLogStore          = "audit_log"
+	collectQueueStore      = "collect_queue"
+	leaseStore             = "lease"
+	quotaStore             = "quota"
+	policyIDTag            = "policyID"
+	didTag                 = "did"
+	configKey              = "config"
+	encryptionKeyIDKey     = "key_id"
+	encryptionTagKeyIDKey  = "tag_key_id"
+	auditHeadKey           = "head"
+	auditLastCheckpointKey = "last_checkpoint"
+	auditEntryKeyFormat    = "entry_%020d"
+)
+
+var logger = log.New("gatekeeper-stores")
+
+// keysFunc returns the keys of the entries of a store that are not tagged, found in the provider.
+type keysFunc func(provider storage.Provider) ([]string, error)
+
+type store struct {
+	name        string
+	expressions []string
+	keys        keysFunc
+}
+
+func all() []*store {
+	return []*store{
+		// policies saved before they were tagged are found through the protected data referencing them
+		{name: policyStore, expressions: []string{"id"}, keys: referencedPolicies},
+		{name: protectedDataStore, expressions: []string{policyIDTag}},
+		{name: ticketStore, expressions: []string{"status"}},
+		{name: tombstone.StoreName(policyStore), expressions: []string{tombstone.Tag}},
+		{name: tombstone.StoreName(protectedDataStore), expressions: []string{tombstone.Tag}},
+		{name: configStore, keys: static(configKey)},
+		{name: localkms.Namespace, keys: kmsKeys},
+		{name: encryptionKeyStore, keys: static(encryptionKeyIDKey, encryptionTagKeyIDKey)},
+		{name: auditLogStore, expressions: []string{"checkpoint", "queued"}, keys: auditKeys},
+		{name: collectQueueStore, expressions: []string{"job"}},
+		{name: leaseStore, keys: static(SchedulerLease)},
+		// usages recorded before they were tagged are found through the DIDs of the protected data
+		{name: quotaStore, expressions: []string{"revision"}, keys: protectedDIDs},
+		{name: "protected_data_anchoring", expressions: []string{"anchorStatus"}},
+		{name: "consent_receipt", expressions: []string{"did"}},
+		{name: "delegation", expressions: []string{"delegate"}},
+		{name: "subscription", expressions: []string{"did"}},
+		{name: "watermark", expressions: []string{"did"}},
+		{name: "didcomm_connection", expressions: []string{"did"}},
+		{name: "didcomm_issuance", expressions: []string{"status"}},
+	}
+}
+
+// Migrations returns the stores of Gatekeeper to migrate, with the keys of their untagged entries found in the
+// source provider.
+func Migrations(source storage.Provider) ([]*migrate.Store, error) {
+	stores := all()
+	migrations := make([]*migrate.Store, len(stores))
+
+	for i, s := range stores {
+		migrations[i] = &migrate.Store{Name: s.name, Expressions: s.expressions}
+
+		if s.keys == nil {
+			continue
+		}
+
+		keys, err := s.keys(source)
+		if err != nil {
+			return nil, fmt.Errorf("find keys of store %s: %w", s.name, err)
+		}
+
+		migrations[i].Keys = keys
+	}
+
+	return migrations, nil
+}
+
+func static(keys ...string) keysFunc {
+	return func(storage.Provider) ([]string, error) {
+		return keys, nil
+	}
+}
+
+func referencedPolicies(provider storage.Provider) ([]string, error) {
+	return protectedDataTags(provider, policyIDTag)
+}
+
+func protectedDIDs(provider storage.Provider) ([]string, error) {
+	values, err := protectedDataTags(provider, didTag)
+	if err != nil {
+		return nil, err
+	}
+
+	dids := make([]string, 0, len(values))
+
+	for _, v := range values {
+		// DIDs are encoded as tag values, see index.TagValue
+		did, err := base64.RawURLEncoding.DecodeString(v)
+		if err != nil {
+			return nil, fmt.Errorf("decode DID: %w", err)
+		}
+
+		dids = append(dids, string(did))
+	}
+
+	return dids, nil
+}
+
+// protectedDataTags returns the distinct values of the tag named name of the protected data.
+func protectedDataTags(provider storage.Provider, name string) ([]string, error) {
+	store, err := provider.OpenStore(protectedDataStore)
+	if err != nil {
+		return nil, fmt.Errorf("open protected data store: %w", err)
+	}
+
+	iter, err := store.Query(name)
+	if err != nil {
+		return nil, fmt.Errorf("query protected data: %w", err)
+	}
+
+	defer storage.Close(iter, logger)
+
+	var values []string
+
+	seen := make(map[string]struct{})
+
+	for {
+		ok, err := iter.Next()
+		if err != nil {
+			return nil, fmt.Errorf("next entry: %w", err)
+		}
+
+		if !ok {
+			return values, nil
+		}
+
+		tags, err := iter.Tags()
+		if err != nil {
+			return nil, fmt.Errorf("get tags: %w", err)
+		}
+
+		for _, tag := range tags {
+			if _, dup := seen[tag.Value]; tag.Name != name || tag.Value == "" || dup {
+				continue
+			}
+
+			seen[tag.Value] = struct{}{}
+			values = append(values, tag.Value)
+		}
+	}
+}
+
+// kmsKeys returns the keys of the local KMS entries of the keys referenced by the config and the storage encryption.
+// Keys held by a remote signing KMS aren't in the local KMS, and are skipped by the migration.
+func kmsKeys(provider storage.Provider) ([]string, error) {
+	var keyIDs []string
+
+	b, err := get(provider, configStore, configKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if b != nil {
+		var c config.Config
+
+		if err = json.Unmarshal(b, &c); err != nil {
+			return nil, fmt.Errorf("unmarshal config: %w", err)
+		}
+
+		keyIDs = append(keyIDs, c.UpdateKeyID, c.PreviousPubKeyID)
+
+		if p := c.PendingUpdate; p != nil {
+			keyIDs = append(keyIDs, p.NextUpdateKeyID, p.PubKeyID)
+		}
+
+		// an in-process DID key is stored in the config itself
+		if len(c.PrivateKey) == 0 {
+			keyIDs = append(keyIDs, c.PubKeyID)
+		}
+	}
+
+	for _, key := range []string{encryptionKeyIDKey, encryptionTagKeyIDKey} {
+		b, err = get(provider, encryptionKeyStore, key)
+		if err != nil {
+			return nil, err
+		}
+
+		keyIDs = append(keyIDs, string(b))
+	}
+
+	var keys []string
+
+	for _, id := range keyIDs {
+		if id != "" {
+			keys = append(keys, prefix.StorageKIDPrefix+id)
+		}
+	}
+
+	return keys, nil
+}
+
+// auditKeys returns the keys of the heads and of the entries of the audit log, which are chained by sequence number.
+func auditKeys(provider storage.Provider) ([]string, error) {
+	keys := []string{auditHeadKey, auditLastCheckpointKey}
+
+	b, err := get(provider, auditLogStore, auditHeadKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if b == nil {
+		return keys, nil
+	}
+
+	var head audit.Head
+
+	if err = json.Unmarshal(b, &head); err != nil {
+		return nil, fmt.Errorf("unmarshal audit head: %w", err)
+	}
+
+	for seq := 1; seq <= head.Seq; seq++ {
+		keys = append(keys, fmt.Sprintf(auditEntryKeyFormat, seq))
+	}
+
+	return keys, nil
+}
+
+// get returns the value of the entry, or nil if it is not found.
+func get(provider storage.Provider, name, key string) ([]byte, error) {
+	store, err := provider.OpenStore(name)
+	if err != nil {
+		return nil, fmt.Errorf("open store %s: %w", name, err)
+	}
+
+	b, err := store.Get(key)
+	if errors.Is(err, storage.ErrDataNotFound) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("get %s from store %s: %w", key, name, err)
+	}
+
+	return b, nil
+}
@@ -0,0 +1,145 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package stores_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/hyperledger/aries-framework-go/component/storageutil/mem"
+	"github.com/hyperledger/aries-framework-go/spi/storage"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/ace/pkg/gatekeeper/audit"
+	"github.com/trustbloc/ace/pkg/gatekeeper/config"
+	"github.com/trustbloc/ace/pkg/gatekeeper/stores"
+	"github.com/trustbloc/ace/pkg/storage/index"
+	"github.com/trustbloc/ace/pkg/storage/migrate"
+)
+
+func TestMigrations(t *testing.T) {
+	t.Run("finds the untagged entries", func(t *testing.T) {
+		provider := mem.NewProvider()
+
+		put(t, provider, "protected_data", "k1", []byte("{}"), storage.Tag{Name: "policyID", Value: "p1"})
+		put(t, provider, "protected_data", "k2", []byte("{}"), storage.Tag{Name: "policyID", Value: "p1"},
+			storage.Tag{Name: "did", Value: index.TagValue("did:example:k2")})
+		put(t, provider, "protected_data", "k3", []byte("{}"), storage.Tag{Name: "policyID", Value: "p2"})
+		put(t, provider, "config", "config", marshal(t, &config.Config{
+			PubKeyID:         "pub",
+			UpdateKeyID:      "update",
+			PreviousPubKeyID: "previous",
+			PendingUpdate:    &config.PendingUpdate{NextUpdateKeyID: "next", PubKeyID: "pending"},
+		}))
+		put(t, provider, "storage_encryption", "key_id", []byte("enc"))
+		put(t, provider, "audit_log", "head", marshal(t, &audit.Head{Seq: 2}))
+
+		migrations, err := stores.Migrations(provider)
+		require.NoError(t, err)
+
+		require.ElementsMatch(t, []string{"p1", "p2"}, find(t, migrations, "policy").Keys)
+		require.Equal(t, []string{"config"}, find(t, migrations, "config").Keys)
+		require.ElementsMatch(t, []string{"kupdate", "kprevious", "knext", "kpending", "kpub", "kenc"},
+			find(t, migrations, "kmsdb").Keys)
+		require.Equal(t, []string{"key_id", "tag_key_id"}, find(t, migrations, "storage_encryption").Keys)
+		require.Equal(t, []string{"scheduler"}, find(t, migrations, "lease").Keys)
+
+		quota := find(t, migrations, "quota")
+		require.Equal(t, []string{"revision"}, quota.Expressions)
+		require.Equal(t, []string{"did:example:k2"}, quota.Keys)
+
+		auditLog := find(t, migrations, "audit_log")
+		require.Equal(t, []string{"checkpoint", "queued"}, auditLog.Expressions)
+		require.Equal(t, []string{
+			"head", "last_checkpoint", "entry_00000000000000000001", "entry_00000000000000000002",
+		}, auditLog.Keys)
+
+		require.Equal(t, []string{"job"}, find(t, migrations, "collect_queue").Expressions)
+	})
+
+	t.Run("skips the in-process DID key", func(t *testing.T) {
+		provider := mem.NewProvider()
+
+		put(t, provider, "config", "config", marshal(t, &config.Config{PubKeyID: "pub", PrivateKey: []byte("key")}))
+
+		migrations, err := stores.Migrations(provider)
+		require.NoError(t, err)
+		require.Empty(t, find(t, migrations, "kmsdb").Keys)
+		require.Equal(t, []string{"head", "last_checkpoint"}, find(t, migrations, "audit_log").Keys)
+	})
+
+	t.Run("fails to decode a DID", func(t *testing.T) {
+		provider := mem.NewProvider()
+
+		put(t, provider, "protected_data", "k1", []byte("{}"), storage.Tag{Name: "did", Value: "!"})
+
+		_, err := stores.Migrations(provider)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "find keys of store quota: decode DID")
+	})
+
+	t.Run("fails to parse the config", func(t *testing.T) {
+		provider := mem.NewProvider()
+
+		put(t, provider, "config", "config", []byte("{"))
+
+		_, err := stores.Migrations(provider)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "find keys of store kmsdb: unmarshal config")
+	})
+
+	t.Run("fails to open a store", func(t *testing.T) {
+		_, err := stores.Migrations(&failingProvider{Provider: mem.NewProvider(), name: "audit_log"})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "find keys of store audit_log: open store audit_log: test error")
+	})
+}
+
+func find(t *testing.T, migrations []*migrate.Store, name string) *migrate.Store {
+	t.Helper()
+
+	for _, m := range migrations {
+		if m.Name == name {
+			return m
+		}
+	}
+
+	require.Failf(t, "store not found", "store %s", name)
+
+	return nil
+}
+
+func put(t *testing.T, provider storage.Provider, name, key string, value []byte, tags ...storage.Tag) {
+	t.Helper()
+
+	store, err := provider.OpenStore(name)
+	require.NoError(t, err)
+	require.NoError(t, store.Put(key, value, tags...))
+}
+
+func marshal(t *testing.T, v interface{}) []byte {
+	t.Helper()
+
+	b, err := json.Marshal(v)
+	require.NoError(t, err)
+
+	return b
+}
+
+type failingProvider struct {
+	storage.Provider
+	name string
+}
+
+func (p *failingProvider) OpenStore(name string) (storage.Store, error) {
+	if name == p.name {
+		return nil, errors.New("test error")
+	}
+
+	return p.Provider.OpenStore(name)
+}
@@ -0,0 +1,265 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package subscription notifies the participants of release tickets, the handlers and the approvers, of the actions
+// taken on the tickets they are involved in.
+package subscription
+
+//nolint: lll
+//go:generate mockgen -destination gomocks_test.go -package subscription_test -source=service.go -mock_names policyService=MockPolicyService,notifier=MockNotifier
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hyperledger/aries-framework-go/pkg/common/log"
+	"github.com/hyperledger/aries-framework-go/spi/storage"
+
+	"github.com/trustbloc/ace/pkg/gatekeeper/notify"
+	"github.com/trustbloc/ace/pkg/gatekeeper/policy"
+	"github.com/trustbloc/ace/pkg/gatekeeper/release/ticket"
+	"github.com/trustbloc/ace/pkg/storage/cursor"
+	"github.com/trustbloc/ace/pkg/storage/index"
+)
+
+const (
+	storeName = "subscription"
+	didIndex  = "did"
+	// pageSize is the number of subscriptions read at a time when looking for the subscriptions of a participant.
+	pageSize = 100
+)
+
+var logger = log.New("subscription-svc")
+
+// ErrInvalidSubscription is returned when subscribing without a valid notification channel.
+var ErrInvalidSubscription = errors.New("invalid subscription")
+
+type policyService interface {
+	Get(ctx context.Context, policyID string) (*policy.Policy, error)
+}
+
+type notifier interface {
+	Notify(ctx context.Context, n *policy.Notification, e *notify.Event) error
+}
+
+// Subscription is the subscription of a participant to the events of the tickets they are involved in: the tickets
+// they requested, the tickets of the policies they approve and the tickets they took actions on.
+type Subscription struct {
+	ID string `json:"id"`
+	// DID is the DID of the participant.
+	DID string `json:"did"`
+	// Webhook the events are posted to, and whether they are sent as DIDComm messages to the agent of the participant.
+	policy.Notification
+	// Actions, if set, are the actions the participant is notified of, e.g. approve, reject. It defaults to all.
+	Actions   []string  `json:"actions,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (s *Subscription) matches(action ticket.Action) bool {
+	if len(s.Actions) == 0 {
+		return true
+	}
+
+	for _, a := range s.Actions {
+		if a == string(action) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Config defines dependencies for Service.
+type Config struct {
+	StoreProvider storage.Provider
+	PolicyService policyService
+	Notifier      notifier
+}
+
+// Service stores the subscriptions of participants and notifies them of the events of their tickets.
+type Service struct {
+	store         storage.Store
+	policyService policyService
+	notifier      notifier
+}
+
+// NewService returns a new instance of Service.
+func NewService(config *Config) (*Service, error) {
+	store, err := index.OpenStore(config.StoreProvider, &index.Declaration{
+		Store:    storeName,
+		TagNames: []string{didIndex},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("open subscription store: %w", err)
+	}
+
+	return &Service{
+		store:         store,
+		policyService: config.PolicyService,
+		notifier:      config.Notifier,
+	}, nil
+}
+
+// Subscribe stores the subscription. Its ID and creation time are set.
+func (s *Service) Subscribe(_ context.Context, sub *Subscription) error {
+	if err := sub.Validate(); err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidSubscription, err)
+	}
+
+	sub.ID = uuid.New().String()
+	sub.CreatedAt = time.Now().UTC()
+
+	b, err := json.Marshal(sub)
+	if err != nil {
+		return fmt.Errorf("marshal subscription: %w", err)
+	}
+
+	if err = s.store.Put(sub.ID, b, storage.Tag{Name: didIndex, Value: index.TagValue(sub.DID)}); err != nil {
+		return fmt.Errorf("store subscription: %w", err)
+	}
+
+	return nil
+}
+
+// List returns the subscriptions of the participant with the given DID.
+func (s *Service) List(_ context.Context, did string) ([]*Subscription, error) {
+	c, err := cursor.New(s.store, didIndex+":"+index.TagValue(did), pageSize)
+	if err != nil {
+		return nil, fmt.Errorf("query subscriptions: %w", err)
+	}
+
+	var subs []*Subscription
+
+	err = cursor.Iterate(c, func(values [][]byte) error {
+		for _, v := range values {
+			var sub Subscription
+
+			if e := json.Unmarshal(v, &sub); e != nil {
+				return fmt.Errorf("unmarshal subscription: %w", e)
+			}
+
+			subs = append(subs, &sub)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return subs, nil
+}
+
+// Unsubscribe deletes the subscription with the given ID of the participant with the given DID. It returns
+// storage.ErrDataNotFound if the participant has no such subscription.
+func (s *Service) Unsubscribe(_ context.Context, did, id string) error {
+	b, err := s.store.Get(id)
+	if err != nil {
+		return fmt.Errorf("get subscription: %w", err)
+	}
+
+	var sub Subscription
+
+	if err = json.Unmarshal(b, &sub); err != nil {
+		return fmt.Errorf("unmarshal subscription: %w", err)
+	}
+
+	if sub.DID != did {
+		return fmt.Errorf("get subscription: %w", storage.ErrDataNotFound)
+	}
+
+	if err = s.store.Delete(id); err != nil {
+		return fmt.Errorf("delete subscription: %w", err)
+	}
+
+	return nil
+}
+
+// TicketUpdated notifies the subscribed participants of the ticket of the action last recorded in its history.
+// Failures are logged: the participants are notified on a best-effort basis.
+func (s *Service) TicketUpdated(ctx context.Context, t *ticket.Ticket) {
+	if len(t.History) == 0 {
+		return
+	}
+
+	last := t.History[len(t.History)-1]
+
+	e := &notify.Event{
+		Type:            notify.TicketUpdated,
+		DID:             t.DID,
+		PolicyID:        t.PolicyID,
+		TicketID:        t.ID,
+		RequestingParty: t.RequestedBy,
+		Justification:   t.Justification,
+		Timestamp:       last.Timestamp,
+		Action:          string(last.Action),
+		Status:          last.Status.String(),
+		Actor:           last.Actor,
+	}
+
+	for _, participant := range s.participants(ctx, t) {
+		subs, err := s.List(ctx, participant)
+		if err != nil {
+			logger.Warnf("Failed to get the subscriptions of %s: %s", participant, err)
+
+			continue
+		}
+
+		for _, sub := range subs {
+			if !sub.matches(last.Action) {
+				continue
+			}
+
+			participantEvent := *e
+			participantEvent.Recipient = participant
+
+			if err = s.notifier.Notify(ctx, &sub.Notification, &participantEvent); err != nil {
+				logger.Warnf("Failed to notify %s of ticket %s: %s", participant, t.ID, err)
+			}
+		}
+	}
+}
+
+// participants returns the DIDs of the participants involved in the ticket: its requestor, the approvers of its
+// policy and the actors of its history.
+func (s *Service) participants(ctx context.Context, t *ticket.Ticket) []string {
+	seen := map[string]struct{}{}
+
+	var participants []string
+
+	add := func(did string) {
+		if _, ok := seen[did]; ok || did == "" {
+			return
+		}
+
+		seen[did] = struct{}{}
+		participants = append(participants, did)
+	}
+
+	add(t.RequestedBy)
+
+	if t.PolicyID != "" {
+		p, err := s.policyService.Get(ctx, t.PolicyID)
+		if err != nil {
+			logger.Warnf("Failed to get the approvers of ticket %s: %s", t.ID, err)
+		} else {
+			for _, approver := range p.Approvers {
+				add(approver)
+			}
+		}
+	}
+
+	for _, e := range t.History {
+		add(e.Actor)
+		add(e.OnBehalfOf)
+	}
+
+	return participants
+}
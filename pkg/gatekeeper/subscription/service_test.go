@@ -0,0 +1,216 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package subscription_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/hyperledger/aries-framework-go/component/storageutil/mem"
+	"github.com/hyperledger/aries-framework-go/pkg/mock/storage"
+	storageapi "github.com/hyperledger/aries-framework-go/spi/storage"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/ace/pkg/gatekeeper/notify"
+	"github.com/trustbloc/ace/pkg/gatekeeper/policy"
+	"github.com/trustbloc/ace/pkg/gatekeeper/release/ticket"
+	"github.com/trustbloc/ace/pkg/gatekeeper/subscription"
+)
+
+const (
+	handlerDID   = "did:example:handler"
+	approverDID  = "did:example:approver"
+	testPolicyID = "test-policy"
+)
+
+func TestService_Subscriptions(t *testing.T) {
+	ctx := context.Background()
+
+	svc, openErr := subscription.NewService(&subscription.Config{StoreProvider: mem.NewProvider()})
+	require.NoError(t, openErr)
+
+	sub := &subscription.Subscription{
+		DID:          handlerDID,
+		Notification: policy.Notification{Webhook: "https://handler.example.com/events"},
+	}
+
+	require.NoError(t, svc.Subscribe(ctx, sub))
+	require.NotEmpty(t, sub.ID)
+	require.False(t, sub.CreatedAt.IsZero())
+
+	subs, err := svc.List(ctx, handlerDID)
+	require.NoError(t, err)
+	require.Len(t, subs, 1)
+	require.Equal(t, sub.ID, subs[0].ID)
+	require.Equal(t, sub.Webhook, subs[0].Webhook)
+
+	subs, err = svc.List(ctx, approverDID)
+	require.NoError(t, err)
+	require.Empty(t, subs)
+
+	t.Run("Invalid subscription", func(t *testing.T) {
+		for _, n := range []policy.Notification{{}, {Webhook: "/events"}} {
+			e := svc.Subscribe(ctx, &subscription.Subscription{DID: handlerDID, Notification: n})
+			require.ErrorIs(t, e, subscription.ErrInvalidSubscription)
+		}
+	})
+
+	t.Run("Unsubscribe another participant", func(t *testing.T) {
+		require.ErrorIs(t, svc.Unsubscribe(ctx, approverDID, sub.ID), storageapi.ErrDataNotFound)
+		require.ErrorIs(t, svc.Unsubscribe(ctx, handlerDID, "unknown"), storageapi.ErrDataNotFound)
+	})
+
+	require.NoError(t, svc.Unsubscribe(ctx, handlerDID, sub.ID))
+
+	subs, err = svc.List(ctx, handlerDID)
+	require.NoError(t, err)
+	require.Empty(t, subs)
+}
+
+func TestService_Failures(t *testing.T) {
+	t.Run("Fail to open store", func(t *testing.T) {
+		_, err := subscription.NewService(&subscription.Config{
+			StoreProvider: &storage.MockStoreProvider{ErrOpenStoreHandle: errors.New("open error")},
+		})
+		require.EqualError(t, err, "open subscription store: open error")
+	})
+
+	t.Run("Fail to store subscription", func(t *testing.T) {
+		provider := storage.NewMockStoreProvider()
+		provider.Store.ErrPut = errors.New("put error")
+
+		svc, err := subscription.NewService(&subscription.Config{StoreProvider: provider})
+		require.NoError(t, err)
+
+		err = svc.Subscribe(context.Background(), &subscription.Subscription{
+			DID:          handlerDID,
+			Notification: policy.Notification{DIDComm: true},
+		})
+		require.EqualError(t, err, "store subscription: put error")
+	})
+
+	t.Run("Fail to query subscriptions", func(t *testing.T) {
+		provider := storage.NewMockStoreProvider()
+		provider.Store.ErrQuery = errors.New("query error")
+
+		svc, err := subscription.NewService(&subscription.Config{StoreProvider: provider})
+		require.NoError(t, err)
+
+		_, err = svc.List(context.Background(), handlerDID)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "query error")
+	})
+
+	t.Run("Fail to delete subscription", func(t *testing.T) {
+		provider := storage.NewMockStoreProvider()
+		provider.Store.ErrDelete = errors.New("delete error")
+
+		svc, err := subscription.NewService(&subscription.Config{StoreProvider: provider})
+		require.NoError(t, err)
+
+		sub := &subscription.Subscription{DID: handlerDID, Notification: policy.Notification{DIDComm: true}}
+
+		require.NoError(t, svc.Subscribe(context.Background(), sub))
+		require.EqualError(t, svc.Unsubscribe(context.Background(), handlerDID, sub.ID),
+			"delete subscription: delete error")
+	})
+}
+
+func TestService_TicketUpdated(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	tk := &ticket.Ticket{
+		ID:          "ticket",
+		DID:         "did:example:protected",
+		PolicyID:    testPolicyID,
+		RequestedBy: handlerDID,
+		History: []*ticket.Event{
+			{Action: ticket.ReleaseAction, Status: ticket.New, Actor: handlerDID, Timestamp: now},
+			{
+				Action: ticket.ApproveAction, Status: ticket.ReadyToCollect, Actor: "did:example:delegate",
+				OnBehalfOf: approverDID, Timestamp: now,
+			},
+		},
+	}
+
+	newService := func(t *testing.T, n *MockNotifier, policyErr error) *subscription.Service {
+		t.Helper()
+
+		policyService := NewMockPolicyService(gomock.NewController(t))
+		policyService.EXPECT().Get(gomock.Any(), testPolicyID).
+			Return(&policy.Policy{ID: testPolicyID, Approvers: []string{approverDID, "did:example:other"}}, policyErr).
+			AnyTimes()
+
+		svc, err := subscription.NewService(&subscription.Config{
+			StoreProvider: mem.NewProvider(),
+			PolicyService: policyService,
+			Notifier:      n,
+		})
+		require.NoError(t, err)
+
+		for _, sub := range []*subscription.Subscription{
+			{DID: handlerDID, Notification: policy.Notification{Webhook: "https://handler.example.com/events"}},
+			{DID: approverDID, Notification: policy.Notification{DIDComm: true}},
+			{DID: "did:example:other", Notification: policy.Notification{DIDComm: true}, Actions: []string{"reject"}},
+			{DID: "did:example:delegate", Notification: policy.Notification{DIDComm: true}},
+			{DID: "did:example:uninvolved", Notification: policy.Notification{DIDComm: true}},
+		} {
+			require.NoError(t, svc.Subscribe(ctx, sub))
+		}
+
+		return svc
+	}
+
+	t.Run("Notifies the subscribed participants", func(t *testing.T) {
+		n := NewMockNotifier(gomock.NewController(t))
+
+		var recipients []string
+
+		n.EXPECT().Notify(gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ *policy.Notification, e *notify.Event) error {
+				require.Equal(t, notify.TicketUpdated, e.Type)
+				require.Equal(t, "ticket", e.TicketID)
+				require.Equal(t, "approve", e.Action)
+				require.Equal(t, "READY_TO_COLLECT", e.Status)
+				require.Equal(t, "did:example:delegate", e.Actor)
+
+				recipients = append(recipients, e.Recipient)
+
+				return errors.New("notify error")
+			}).Times(3)
+
+		newService(t, n, nil).TicketUpdated(ctx, tk)
+
+		require.ElementsMatch(t, []string{handlerDID, approverDID, "did:example:delegate"}, recipients)
+	})
+
+	t.Run("Approvers of the policy are not notified if it is not found", func(t *testing.T) {
+		n := NewMockNotifier(gomock.NewController(t))
+
+		var recipients []string
+
+		n.EXPECT().Notify(gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ *policy.Notification, e *notify.Event) error {
+				recipients = append(recipients, e.Recipient)
+
+				return nil
+			}).Times(3)
+
+		newService(t, n, errors.New("get error")).TicketUpdated(ctx, tk)
+
+		// the approver is still notified as the approver the delegate decided on behalf of
+		require.ElementsMatch(t, []string{handlerDID, approverDID, "did:example:delegate"}, recipients)
+	})
+
+	t.Run("Ticket without history", func(t *testing.T) {
+		newService(t, NewMockNotifier(gomock.NewController(t)), nil).TicketUpdated(ctx, &ticket.Ticket{ID: "ticket"})
+	})
+}
@@ -0,0 +1,189 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package watermark embeds traceable watermarks in extracted data and records the extractions they identify.
+package watermark
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/hyperledger/aries-framework-go/spi/storage"
+
+	"github.com/trustbloc/ace/pkg/gatekeeper/policy"
+	"github.com/trustbloc/ace/pkg/storage/index"
+)
+
+const (
+	storeName = "watermark"
+	didIndex  = "did"
+
+	// markSize is the size of the random marks in bytes.
+	markSize    = 8
+	bitsPerByte = 8
+
+	// characters encoding the bits of zero-width watermarks
+	zeroBit = '\u200b' // zero width space
+	oneBit  = '\u200c' // zero width non-joiner
+)
+
+// ErrNoWatermark is returned when tracing data without a watermark.
+var ErrNoWatermark = errors.New("no watermark found")
+
+// Record is an extraction identified by a watermark.
+type Record struct {
+	Mark string `json:"mark"`
+	// DID is the DID of the protected data.
+	DID      string `json:"did"`
+	TicketID string `json:"ticket_id"`
+	QueryID  string `json:"query_id"`
+	// Handler is the DID of the handler the data was released to.
+	Handler   string    `json:"handler,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Service embeds watermarks and traces watermarked data back to its extraction.
+type Service struct {
+	store storage.Store
+}
+
+// NewService returns a new instance of Service.
+func NewService(storeProvider storage.Provider) (*Service, error) {
+	store, err := index.OpenStore(storeProvider, &index.Declaration{
+		Store:    storeName,
+		TagNames: []string{didIndex},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("open watermark store: %w", err)
+	}
+
+	return &Service{store: store}, nil
+}
+
+// Embed returns the data with a new watermark of the given kind and records the extraction it identifies. The mark
+// and the timestamp of the record are set. Data that is not an email address gets a zero-width watermark.
+func (s *Service) Embed(_ context.Context, data string, w *policy.Watermark, r *Record) (string, error) {
+	b := make([]byte, markSize)
+
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate mark: %w", err)
+	}
+
+	r.Mark = hex.EncodeToString(b)
+	r.Timestamp = time.Now().UTC()
+
+	v, err := json.Marshal(r)
+	if err != nil {
+		return "", fmt.Errorf("marshal record: %w", err)
+	}
+
+	if err = s.store.Put(r.Mark, v, storage.Tag{Name: didIndex, Value: index.TagValue(r.DID)}); err != nil {
+		return "", fmt.Errorf("store record: %w", err)
+	}
+
+	if w.Type == policy.WatermarkEmailTag {
+		if i := strings.LastIndex(data, "@"); i > 0 {
+			return data[:i] + "+" + r.Mark + data[i:], nil
+		}
+	}
+
+	return embedZeroWidth(data, b), nil
+}
+
+// Trace returns the record of the extraction the watermark of the data identifies.
+func (s *Service) Trace(_ context.Context, data string) (*Record, error) {
+	mark, err := detect(data)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := s.store.Get(mark)
+	if err != nil {
+		return nil, fmt.Errorf("get record: %w", err)
+	}
+
+	var r Record
+
+	if err = json.Unmarshal(v, &r); err != nil {
+		return nil, fmt.Errorf("unmarshal record: %w", err)
+	}
+
+	return &r, nil
+}
+
+// embedZeroWidth inserts the bits of the mark as zero-width characters after the first character of the data, so
+// that the watermark is kept when the data is trimmed.
+func embedZeroWidth(data string, mark []byte) string {
+	var sb strings.Builder
+
+	_, size := utf8.DecodeRuneInString(data)
+
+	sb.WriteString(data[:size])
+
+	for _, c := range mark {
+		for i := bitsPerByte - 1; i >= 0; i-- {
+			if c>>i&1 == 1 {
+				sb.WriteRune(oneBit)
+			} else {
+				sb.WriteRune(zeroBit)
+			}
+		}
+	}
+
+	sb.WriteString(data[size:])
+
+	return sb.String()
+}
+
+// detect returns the mark of the email tag or the zero-width watermark of the data.
+func detect(data string) (string, error) {
+	if at := strings.LastIndex(data, "@"); at > 0 {
+		if plus := strings.LastIndex(data[:at], "+"); plus >= 0 {
+			if mark := data[plus+1 : at]; isMark(mark) {
+				return mark, nil
+			}
+		}
+	}
+
+	mark := make([]byte, 0, markSize)
+
+	var c, bits byte
+
+	for _, r := range data {
+		if r != zeroBit && r != oneBit {
+			continue
+		}
+
+		c <<= 1
+		if r == oneBit {
+			c |= 1
+		}
+
+		if bits++; bits == bitsPerByte {
+			mark = append(mark, c)
+			c, bits = 0, 0
+		}
+	}
+
+	if len(mark) != markSize || bits != 0 {
+		return "", ErrNoWatermark
+	}
+
+	return hex.EncodeToString(mark), nil
+}
+
+func isMark(s string) bool {
+	b, err := hex.DecodeString(s)
+
+	return err == nil && len(b) == markSize
+}
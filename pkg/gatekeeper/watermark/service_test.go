@@ -0,0 +1,110 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package watermark_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/aries-framework-go/component/storageutil/mem"
+	"github.com/hyperledger/aries-framework-go/pkg/mock/storage"
+	storageapi "github.com/hyperledger/aries-framework-go/spi/storage"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/ace/pkg/gatekeeper/policy"
+	"github.com/trustbloc/ace/pkg/gatekeeper/watermark"
+)
+
+func TestService(t *testing.T) {
+	ctx := context.Background()
+
+	svc, openErr := watermark.NewService(mem.NewProvider())
+	require.NoError(t, openErr)
+
+	record := func() *watermark.Record {
+		return &watermark.Record{
+			DID:      "did:example:target",
+			TicketID: "ticket",
+			QueryID:  "query",
+			Handler:  "did:example:handler",
+		}
+	}
+
+	t.Run("Zero-width watermark", func(t *testing.T) {
+		r := record()
+
+		marked, err := svc.Embed(ctx, "Jane Doe", &policy.Watermark{Type: policy.WatermarkZeroWidth}, r)
+		require.NoError(t, err)
+		require.NotEqual(t, "Jane Doe", marked)
+		require.Equal(t, "Jane Doe", strings.NewReplacer("\u200b", "", "\u200c", "").Replace(marked))
+		require.True(t, strings.HasPrefix(marked, "J\u200b") || strings.HasPrefix(marked, "J\u200c"))
+		require.Len(t, r.Mark, 16)
+		require.False(t, r.Timestamp.IsZero())
+
+		traced, err := svc.Trace(ctx, "Leaked: "+strings.TrimSpace(marked))
+		require.NoError(t, err)
+		require.Equal(t, r, traced)
+	})
+
+	t.Run("Email tag watermark", func(t *testing.T) {
+		r := record()
+
+		marked, err := svc.Embed(ctx, "jane@example.com", &policy.Watermark{Type: policy.WatermarkEmailTag}, r)
+		require.NoError(t, err)
+		require.Equal(t, "jane+"+r.Mark+"@example.com", marked)
+
+		traced, err := svc.Trace(ctx, marked)
+		require.NoError(t, err)
+		require.Equal(t, r.TicketID, traced.TicketID)
+		require.Equal(t, r.Handler, traced.Handler)
+	})
+
+	t.Run("Email tag watermark on data that is not an email", func(t *testing.T) {
+		r := record()
+
+		marked, err := svc.Embed(ctx, "Jane Doe", &policy.Watermark{Type: policy.WatermarkEmailTag}, r)
+		require.NoError(t, err)
+		require.NotContains(t, marked, "+")
+
+		traced, err := svc.Trace(ctx, marked)
+		require.NoError(t, err)
+		require.Equal(t, r.Mark, traced.Mark)
+	})
+
+	t.Run("No watermark", func(t *testing.T) {
+		for _, data := range []string{"Jane Doe", "jane+tag@example.com", "J\u200b\u200cane"} {
+			_, err := svc.Trace(ctx, data)
+			require.ErrorIs(t, err, watermark.ErrNoWatermark, data)
+		}
+	})
+
+	t.Run("Unknown watermark", func(t *testing.T) {
+		_, err := svc.Trace(ctx, "jane+0123456789abcdef@example.com")
+		require.ErrorIs(t, err, storageapi.ErrDataNotFound)
+	})
+}
+
+func TestService_Failures(t *testing.T) {
+	t.Run("Fail to open store", func(t *testing.T) {
+		_, err := watermark.NewService(&storage.MockStoreProvider{ErrOpenStoreHandle: errors.New("open error")})
+		require.EqualError(t, err, "open watermark store: open error")
+	})
+
+	t.Run("Fail to store record", func(t *testing.T) {
+		provider := storage.NewMockStoreProvider()
+		provider.Store.ErrPut = errors.New("put error")
+
+		svc, err := watermark.NewService(provider)
+		require.NoError(t, err)
+
+		_, err = svc.Embed(context.Background(), "data", &policy.Watermark{Type: policy.WatermarkZeroWidth},
+			&watermark.Record{})
+		require.EqualError(t, err, "store record: put error")
+	})
+}
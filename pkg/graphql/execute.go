@@ -0,0 +1,402 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+const typeNameField = "__typename"
+
+// validate checks the selections of the operation against the schema, so that queries are rejected as a whole
+// before any field is resolved.
+func (s *Schema) validate(doc *document, op *operation) error {
+	v := &validator{schema: s, doc: doc, variables: map[string]bool{}}
+
+	for _, d := range op.variables {
+		v.variables[d.name] = true
+	}
+
+	for name := range doc.fragments {
+		if err := doc.checkSpreads(name, map[string]bool{}); err != nil {
+			return err
+		}
+	}
+
+	return v.selectionSet(s.Query, op.selections, 1)
+}
+
+// checkSpreads checks that the fragment exists and does not spread itself, directly or through other fragments.
+func (d *document) checkSpreads(name string, visiting map[string]bool) error {
+	frag, ok := d.fragments[name]
+	if !ok {
+		return fmt.Errorf("unknown fragment %q", name)
+	}
+
+	if visiting[name] {
+		return fmt.Errorf("fragment %q spreads itself", name)
+	}
+
+	visiting[name] = true
+	defer delete(visiting, name)
+
+	return d.checkSelectionSpreads(frag.selections, visiting)
+}
+
+func (d *document) checkSelectionSpreads(selections []selection, visiting map[string]bool) error {
+	for _, s := range selections {
+		var err error
+
+		switch sel := s.(type) {
+		case *field:
+			err = d.checkSelectionSpreads(sel.selections, visiting)
+		case *inlineFragment:
+			err = d.checkSelectionSpreads(sel.selections, visiting)
+		case *fragmentSpread:
+			err = d.checkSpreads(sel.name, visiting)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type validator struct {
+	schema    *Schema
+	doc       *document
+	variables map[string]bool
+}
+
+func (v *validator) selectionSet(obj *Object, selections []selection, depth int) error {
+	if v.schema.MaxDepth > 0 && depth > v.schema.MaxDepth {
+		return fmt.Errorf("query exceeds the maximum depth of %d", v.schema.MaxDepth)
+	}
+
+	fields, err := collectFields(v.doc, obj, selections)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range fields {
+		if err = v.field(obj, f, depth); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (v *validator) field(obj *Object, f *field, depth int) error {
+	if f.name == typeNameField {
+		if f.selections != nil || len(f.args) > 0 {
+			return fmt.Errorf("field %s of type %s must not have arguments or selections", f.name, obj.Name)
+		}
+
+		return nil
+	}
+
+	def, ok := obj.Fields[f.name]
+	if !ok {
+		return fmt.Errorf("cannot query field %q on type %s", f.name, obj.Name)
+	}
+
+	for name, value := range f.args {
+		if !contains(def.Args, name) {
+			return fmt.Errorf("unknown argument %q on field %s.%s", name, obj.Name, f.name)
+		}
+
+		if err := v.value(value); err != nil {
+			return err
+		}
+	}
+
+	fieldType := def.Type
+	for {
+		l, ok := fieldType.(*List)
+		if !ok {
+			break
+		}
+
+		fieldType = l.OfType
+	}
+
+	if o, ok := fieldType.(*Object); ok {
+		if f.selections == nil {
+			return fmt.Errorf("field %s of type %s must have a selection of subfields", f.name, def.Type)
+		}
+
+		return v.selectionSet(o, f.selections, depth+1)
+	}
+
+	if f.selections != nil {
+		return fmt.Errorf("field %s of type %s must not have a selection of subfields", f.name, def.Type)
+	}
+
+	return nil
+}
+
+func (v *validator) value(value interface{}) error {
+	switch val := value.(type) {
+	case variable:
+		if !v.variables[string(val)] {
+			return fmt.Errorf("variable $%s is not defined", val)
+		}
+	case []interface{}:
+		for _, item := range val {
+			if err := v.value(item); err != nil {
+				return err
+			}
+		}
+	case map[string]interface{}:
+		for _, item := range val {
+			if err := v.value(item); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// collectFields returns the fields selected on the object, in order and with the fields of matching fragments, by
+// response key. The selections of fields with the same response key are merged. Fragments must not spread themselves.
+func collectFields(doc *document, obj *Object, selections []selection) ([]*field, error) {
+	var fields []*field
+
+	byKey := map[string]*field{}
+
+	var collect func(selections []selection) error
+
+	collect = func(selections []selection) error {
+		for _, s := range selections {
+			switch sel := s.(type) {
+			case *field:
+				existing, ok := byKey[sel.key()]
+				if !ok {
+					f := *sel
+					byKey[sel.key()] = &f
+					fields = append(fields, &f)
+
+					continue
+				}
+
+				if existing.name != sel.name || !reflect.DeepEqual(existing.args, sel.args) {
+					return fmt.Errorf("fields %q conflict because they select different fields or arguments",
+						sel.key())
+				}
+
+				existing.selections = append(append([]selection{}, existing.selections...), sel.selections...)
+			case *inlineFragment:
+				if sel.typeCondition == "" || sel.typeCondition == obj.Name {
+					if err := collect(sel.selections); err != nil {
+						return err
+					}
+				}
+			case *fragmentSpread:
+				frag, ok := doc.fragments[sel.name]
+				if !ok {
+					return fmt.Errorf("unknown fragment %q", sel.name)
+				}
+
+				if frag.typeCondition != obj.Name {
+					continue
+				}
+
+				if err := collect(frag.selections); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	}
+
+	if err := collect(selections); err != nil {
+		return nil, err
+	}
+
+	return fields, nil
+}
+
+type executor struct {
+	doc       *document
+	variables map[string]interface{}
+	errors    []*Error
+}
+
+func (e *executor) selectionSet(ctx context.Context, obj *Object, source interface{}, selections []selection,
+	path []interface{}) *orderedMap {
+	// the selections have been validated, so that they can be collected without errors
+	fields, _ := collectFields(e.doc, obj, selections) //nolint:errcheck
+
+	result := &orderedMap{values: map[string]interface{}{}}
+
+	for _, f := range fields {
+		fieldPath := append(append([]interface{}{}, path...), f.key())
+
+		result.set(f.key(), e.field(ctx, obj, source, f, fieldPath))
+	}
+
+	return result
+}
+
+func (e *executor) field(ctx context.Context, obj *Object, source interface{}, f *field,
+	path []interface{}) interface{} {
+	if f.name == typeNameField {
+		return obj.Name
+	}
+
+	def := obj.Fields[f.name]
+
+	args := Args{}
+
+	for name, value := range f.args {
+		args[name] = e.resolveValue(value)
+	}
+
+	value, err := def.Resolve(ctx, source, args)
+	if err != nil {
+		e.errors = append(e.errors, &Error{Message: err.Error(), Path: path})
+
+		return nil
+	}
+
+	return e.complete(ctx, def.Type, value, f.selections, path)
+}
+
+func (e *executor) complete(ctx context.Context, t Type, value interface{}, selections []selection,
+	path []interface{}) interface{} {
+	if isNil(value) {
+		return nil
+	}
+
+	switch typ := t.(type) {
+	case *List:
+		v := reflect.ValueOf(value)
+		if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+			e.errors = append(e.errors, &Error{Message: fmt.Sprintf("expected a list for %s", typ), Path: path})
+
+			return nil
+		}
+
+		list := make([]interface{}, v.Len())
+
+		for i := range list {
+			itemPath := append(append([]interface{}{}, path...), i)
+
+			list[i] = e.complete(ctx, typ.OfType, v.Index(i).Interface(), selections, itemPath)
+		}
+
+		return list
+	case *Object:
+		return e.selectionSet(ctx, typ, value, selections, path)
+	default:
+		return value
+	}
+}
+
+func (e *executor) resolveValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case variable:
+		return e.variables[string(v)]
+	case enum:
+		return string(v)
+	case []interface{}:
+		list := make([]interface{}, len(v))
+
+		for i, item := range v {
+			list[i] = e.resolveValue(item)
+		}
+
+		return list
+	case map[string]interface{}:
+		obj := make(map[string]interface{}, len(v))
+
+		for k, item := range v {
+			obj[k] = e.resolveValue(item)
+		}
+
+		return obj
+	default:
+		return v
+	}
+}
+
+func isNil(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+
+	switch v := reflect.ValueOf(value); v.Kind() { //nolint:exhaustive
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface:
+		return v.IsNil()
+	default:
+		return false
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// orderedMap is an object of the response data, serialized with its fields in the order they were selected.
+type orderedMap struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func (m *orderedMap) set(key string, value interface{}) {
+	if _, ok := m.values[key]; !ok {
+		m.keys = append(m.keys, key)
+	}
+
+	m.values[key] = value
+}
+
+// MarshalJSON serializes the fields of the object in order.
+func (m *orderedMap) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+
+	b.WriteByte('{')
+
+	for i, k := range m.keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+
+		key, err := json.Marshal(k)
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := json.Marshal(m.values[k])
+		if err != nil {
+			return nil, err
+		}
+
+		b.Write(key)
+		b.WriteByte(':')
+		b.Write(value)
+	}
+
+	b.WriteByte('}')
+
+	return b.Bytes(), nil
+}
@@ -0,0 +1,74 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package graphql
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/trustbloc/edge-core/pkg/log"
+)
+
+const maxRequestSize = 1 << 20
+
+var logger = log.New("graphql")
+
+// ServeHTTP executes the GraphQL request of a GET request with the query, operationName and variables parameters,
+// or of a POST request with a JSON body, and writes the JSON response. Invalid requests are answered with 400 Bad
+// Request, while field errors are reported with the data.
+func (s *Schema) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	req, err := parseRequest(rw, r)
+	if err != nil {
+		writeResponse(rw, http.StatusBadRequest, requestError(err))
+
+		return
+	}
+
+	resp := s.Execute(r.Context(), req)
+
+	status := http.StatusOK
+	if resp.Data == nil {
+		status = http.StatusBadRequest
+	}
+
+	writeResponse(rw, status, resp)
+}
+
+func parseRequest(rw http.ResponseWriter, r *http.Request) (*Request, error) {
+	req := &Request{}
+
+	if r.Method == http.MethodGet {
+		q := r.URL.Query()
+
+		req.Query = q.Get("query")
+		req.OperationName = q.Get("operationName")
+
+		if v := q.Get("variables"); v != "" {
+			if err := json.Unmarshal([]byte(v), &req.Variables); err != nil {
+				return nil, fmt.Errorf("parse variables: %w", err)
+			}
+		}
+	} else if err := json.NewDecoder(http.MaxBytesReader(rw, r.Body, maxRequestSize)).Decode(req); err != nil {
+		return nil, fmt.Errorf("parse request: %w", err)
+	}
+
+	if req.Query == "" {
+		return nil, fmt.Errorf("query is required")
+	}
+
+	return req, nil
+}
+
+func writeResponse(rw http.ResponseWriter, status int, resp *Response) {
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(status)
+
+	if err := json.NewEncoder(rw).Encode(resp); err != nil {
+		logger.Errorf("Failed to write response: %s", err)
+	}
+}
@@ -0,0 +1,87 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package graphql_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchema_ServeHTTP(t *testing.T) {
+	tests := []struct {
+		name   string
+		req    *http.Request
+		status int
+		body   string
+	}{
+		{
+			"post",
+			httptest.NewRequest(http.MethodPost, "/graphql",
+				strings.NewReader(`{"query":"query($id:String){author(id:$id){name}}","variables":{"id":"bob"}}`)),
+			http.StatusOK,
+			`{"data":{"author":{"name":"Bob"}}}`,
+		},
+		{
+			"get",
+			httptest.NewRequest(http.MethodGet, "/graphql?"+url.Values{
+				"query":         {`query A($id:String){author(id:$id){name}}`},
+				"operationName": {"A"},
+				"variables":     {`{"id":"alice"}`},
+			}.Encode(), nil),
+			http.StatusOK,
+			`{"data":{"author":{"name":"Alice"}}}`,
+		},
+		{
+			"field errors",
+			httptest.NewRequest(http.MethodPost, "/graphql",
+				strings.NewReader(`{"query":"{author(id:\"bob\"){failing}}"}`)),
+			http.StatusOK,
+			`{"data":{"author":{"failing":null}},"errors":[{"message":"resolve error","path":["author","failing"]}]}`,
+		},
+		{
+			"invalid query",
+			httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{"query":"{book{name}}"}`)),
+			http.StatusBadRequest,
+			`{"errors":[{"message":"cannot query field \"book\" on type Query"}]}`,
+		},
+		{
+			"invalid body",
+			httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{`)),
+			http.StatusBadRequest,
+			`{"errors":[{"message":"parse request: unexpected EOF"}]}`,
+		},
+		{
+			"invalid variables",
+			httptest.NewRequest(http.MethodGet, "/graphql?query=%7Bauthors%7Bname%7D%7D&variables=x", nil),
+			http.StatusBadRequest,
+			`{"errors":[{"message":"parse variables: invalid character 'x' looking for beginning of value"}]}`,
+		},
+		{
+			"missing query",
+			httptest.NewRequest(http.MethodGet, "/graphql", nil),
+			http.StatusBadRequest,
+			`{"errors":[{"message":"query is required"}]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rw := httptest.NewRecorder()
+
+			testSchema().ServeHTTP(rw, tt.req)
+
+			require.Equal(t, tt.status, rw.Code)
+			require.Equal(t, "application/json", rw.Header().Get("Content-Type"))
+			require.JSONEq(t, tt.body, rw.Body.String())
+		})
+	}
+}
@@ -0,0 +1,215 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenPunctuator
+	tokenName
+	tokenInt
+	tokenFloat
+	tokenString
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+	pos   int
+}
+
+// lexer splits a GraphQL document into tokens, skipping whitespace, commas and comments.
+type lexer struct {
+	src string
+	pos int
+}
+
+func (l *lexer) next() (token, error) {
+	l.skipIgnored()
+
+	if l.pos >= len(l.src) {
+		return token{kind: tokenEOF, pos: l.pos}, nil
+	}
+
+	start := l.pos
+	c := l.src[l.pos]
+
+	switch {
+	case strings.HasPrefix(l.src[l.pos:], "..."):
+		l.pos += 3
+
+		return token{kind: tokenPunctuator, value: "...", pos: start}, nil
+	case strings.IndexByte("!$():=@[]{}|&", c) >= 0:
+		l.pos++
+
+		return token{kind: tokenPunctuator, value: string(c), pos: start}, nil
+	case c == '_' || isLetter(c):
+		for l.pos < len(l.src) && (l.src[l.pos] == '_' || isLetter(l.src[l.pos]) || isDigit(l.src[l.pos])) {
+			l.pos++
+		}
+
+		return token{kind: tokenName, value: l.src[start:l.pos], pos: start}, nil
+	case c == '-' || isDigit(c):
+		return l.number()
+	case c == '"':
+		return l.string()
+	default:
+		return token{}, fmt.Errorf("unexpected character %q at %d", c, start)
+	}
+}
+
+func (l *lexer) skipIgnored() {
+	for l.pos < len(l.src) {
+		switch c := l.src[l.pos]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			l.pos++
+		case c == '#':
+			for l.pos < len(l.src) && l.src[l.pos] != '\n' && l.src[l.pos] != '\r' {
+				l.pos++
+			}
+		case strings.HasPrefix(l.src[l.pos:], "\uFEFF"):
+			l.pos += len("\uFEFF")
+		default:
+			return
+		}
+	}
+}
+
+func (l *lexer) number() (token, error) {
+	start := l.pos
+	kind := tokenInt
+
+	if l.src[l.pos] == '-' {
+		l.pos++
+	}
+
+	l.digits()
+
+	if l.pos < len(l.src) && l.src[l.pos] == '.' {
+		kind = tokenFloat
+		l.pos++
+		l.digits()
+	}
+
+	if l.pos < len(l.src) && (l.src[l.pos] == 'e' || l.src[l.pos] == 'E') {
+		kind = tokenFloat
+		l.pos++
+
+		if l.pos < len(l.src) && (l.src[l.pos] == '+' || l.src[l.pos] == '-') {
+			l.pos++
+		}
+
+		l.digits()
+	}
+
+	value := l.src[start:l.pos]
+
+	var err error
+
+	if kind == tokenInt {
+		_, err = strconv.ParseInt(value, 10, 32)
+	} else {
+		_, err = strconv.ParseFloat(value, 64)
+	}
+
+	if err != nil {
+		return token{}, fmt.Errorf("invalid number %s at %d", value, start)
+	}
+
+	return token{kind: kind, value: value, pos: start}, nil
+}
+
+func (l *lexer) digits() {
+	for l.pos < len(l.src) && isDigit(l.src[l.pos]) {
+		l.pos++
+	}
+}
+
+func (l *lexer) string() (token, error) {
+	start := l.pos
+
+	if strings.HasPrefix(l.src[l.pos:], `"""`) {
+		end := strings.Index(l.src[l.pos+3:], `"""`)
+		if end < 0 {
+			return token{}, fmt.Errorf("unterminated string at %d", start)
+		}
+
+		l.pos += 3 + end + 3
+
+		return token{kind: tokenString, value: l.src[start+3 : l.pos-3], pos: start}, nil
+	}
+
+	var b strings.Builder
+
+	l.pos++
+
+	for {
+		if l.pos >= len(l.src) || l.src[l.pos] == '\n' || l.src[l.pos] == '\r' {
+			return token{}, fmt.Errorf("unterminated string at %d", start)
+		}
+
+		c := l.src[l.pos]
+
+		switch c {
+		case '"':
+			l.pos++
+
+			return token{kind: tokenString, value: b.String(), pos: start}, nil
+		case '\\':
+			if err := l.escape(&b); err != nil {
+				return token{}, err
+			}
+		default:
+			r, size := utf8.DecodeRuneInString(l.src[l.pos:])
+			b.WriteRune(r)
+			l.pos += size
+		}
+	}
+}
+
+func (l *lexer) escape(b *strings.Builder) error {
+	if l.pos+1 >= len(l.src) {
+		return fmt.Errorf("invalid escape sequence at %d", l.pos)
+	}
+
+	escapes := map[byte]byte{'"': '"', '\\': '\\', '/': '/', 'b': '\b', 'f': '\f', 'n': '\n', 'r': '\r', 't': '\t'}
+
+	if c, ok := escapes[l.src[l.pos+1]]; ok {
+		b.WriteByte(c)
+		l.pos += 2
+
+		return nil
+	}
+
+	if l.src[l.pos+1] == 'u' && l.pos+6 <= len(l.src) {
+		r, err := strconv.ParseUint(l.src[l.pos+2:l.pos+6], 16, 32)
+		if err == nil {
+			b.WriteRune(rune(r))
+			l.pos += 6
+
+			return nil
+		}
+	}
+
+	return fmt.Errorf("invalid escape sequence at %d", l.pos)
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
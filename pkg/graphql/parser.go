@@ -0,0 +1,542 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+)
+
+type document struct {
+	operations []*operation
+	fragments  map[string]*fragment
+}
+
+type operation struct {
+	kind       string
+	name       string
+	variables  []*variableDefinition
+	selections []selection
+}
+
+type variableDefinition struct {
+	name         string
+	nonNull      bool
+	defaultValue interface{}
+}
+
+type selection interface{}
+
+type field struct {
+	alias      string
+	name       string
+	args       map[string]interface{}
+	selections []selection
+}
+
+func (f *field) key() string {
+	if f.alias != "" {
+		return f.alias
+	}
+
+	return f.name
+}
+
+type fragmentSpread struct {
+	name string
+}
+
+type inlineFragment struct {
+	typeCondition string
+	selections    []selection
+}
+
+type fragment struct {
+	typeCondition string
+	selections    []selection
+}
+
+// variable is a reference to a variable in a value, replaced by the variable's value at execution.
+type variable string
+
+// enum is an enum value, which is passed to resolvers as a string.
+type enum string
+
+// parser parses a GraphQL executable document. Type system definitions and directives are not supported.
+type parser struct {
+	lexer *lexer
+	tok   token
+}
+
+func parse(src string) (*document, error) {
+	p := &parser{lexer: &lexer{src: src}}
+
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	doc := &document{fragments: map[string]*fragment{}}
+
+	for p.tok.kind != tokenEOF {
+		if err := p.definition(doc); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(doc.operations) == 0 {
+		return nil, fmt.Errorf("document does not contain any operation")
+	}
+
+	return doc, nil
+}
+
+func (p *parser) definition(doc *document) error {
+	if p.peek("{") {
+		selections, err := p.selectionSet()
+		if err != nil {
+			return err
+		}
+
+		doc.operations = append(doc.operations, &operation{kind: "query", selections: selections})
+
+		return nil
+	}
+
+	if p.tok.kind != tokenName {
+		return p.unexpected()
+	}
+
+	if p.tok.value == "fragment" {
+		return p.fragmentDefinition(doc)
+	}
+
+	op, err := p.operation()
+	if err != nil {
+		return err
+	}
+
+	doc.operations = append(doc.operations, op)
+
+	return nil
+}
+
+func (p *parser) operation() (*operation, error) {
+	op := &operation{kind: p.tok.value}
+
+	switch op.kind {
+	case "query", "mutation", "subscription":
+	default:
+		return nil, p.unexpected()
+	}
+
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	if p.tok.kind == tokenName {
+		op.name = p.tok.value
+
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.peek("(") {
+		variables, err := p.variableDefinitions()
+		if err != nil {
+			return nil, err
+		}
+
+		op.variables = variables
+	}
+
+	selections, err := p.selectionSet()
+	if err != nil {
+		return nil, err
+	}
+
+	op.selections = selections
+
+	return op, nil
+}
+
+func (p *parser) fragmentDefinition(doc *document) error {
+	if err := p.advance(); err != nil {
+		return err
+	}
+
+	name, err := p.name()
+	if err != nil {
+		return err
+	}
+
+	if name == "on" {
+		return fmt.Errorf("invalid fragment name %q", name)
+	}
+
+	if _, ok := doc.fragments[name]; ok {
+		return fmt.Errorf("duplicate fragment %q", name)
+	}
+
+	typeCondition, err := p.typeCondition()
+	if err != nil {
+		return err
+	}
+
+	selections, err := p.selectionSet()
+	if err != nil {
+		return err
+	}
+
+	doc.fragments[name] = &fragment{typeCondition: typeCondition, selections: selections}
+
+	return nil
+}
+
+func (p *parser) typeCondition() (string, error) {
+	if p.tok.kind != tokenName || p.tok.value != "on" {
+		return "", p.unexpected()
+	}
+
+	if err := p.advance(); err != nil {
+		return "", err
+	}
+
+	return p.name()
+}
+
+func (p *parser) variableDefinitions() ([]*variableDefinition, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+
+	var definitions []*variableDefinition
+
+	for !p.peek(")") {
+		if err := p.expect("$"); err != nil {
+			return nil, err
+		}
+
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+
+		if err = p.expect(":"); err != nil {
+			return nil, err
+		}
+
+		nonNull, err := p.variableType()
+		if err != nil {
+			return nil, err
+		}
+
+		v := &variableDefinition{name: name, nonNull: nonNull}
+
+		if p.peek("=") {
+			if err = p.advance(); err != nil {
+				return nil, err
+			}
+
+			if v.defaultValue, err = p.value(true); err != nil {
+				return nil, err
+			}
+		}
+
+		definitions = append(definitions, v)
+	}
+
+	return definitions, p.expect(")")
+}
+
+// variableType parses the type of a variable and tells if it is non-null. Values are checked by the resolvers, so
+// the type itself is not kept.
+func (p *parser) variableType() (bool, error) {
+	if p.peek("[") {
+		if err := p.advance(); err != nil {
+			return false, err
+		}
+
+		if _, err := p.variableType(); err != nil {
+			return false, err
+		}
+
+		if err := p.expect("]"); err != nil {
+			return false, err
+		}
+	} else if _, err := p.name(); err != nil {
+		return false, err
+	}
+
+	if p.peek("!") {
+		return true, p.advance()
+	}
+
+	return false, nil
+}
+
+func (p *parser) selectionSet() ([]selection, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+
+	var selections []selection
+
+	for !p.peek("}") {
+		s, err := p.selection()
+		if err != nil {
+			return nil, err
+		}
+
+		selections = append(selections, s)
+	}
+
+	if len(selections) == 0 {
+		return nil, fmt.Errorf("empty selection set at %d", p.tok.pos)
+	}
+
+	return selections, p.expect("}")
+}
+
+func (p *parser) selection() (selection, error) {
+	if p.peek("...") {
+		return p.fragmentSelection()
+	}
+
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+
+	f := &field{name: name}
+
+	if p.peek(":") {
+		if err = p.advance(); err != nil {
+			return nil, err
+		}
+
+		f.alias = name
+
+		if f.name, err = p.name(); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.peek("(") {
+		if f.args, err = p.arguments(); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.peek("@") {
+		return nil, fmt.Errorf("directives are not supported, at %d", p.tok.pos)
+	}
+
+	if p.peek("{") {
+		if f.selections, err = p.selectionSet(); err != nil {
+			return nil, err
+		}
+	}
+
+	return f, nil
+}
+
+func (p *parser) fragmentSelection() (selection, error) {
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	if p.tok.kind == tokenName && p.tok.value != "on" {
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+
+		return &fragmentSpread{name: name}, nil
+	}
+
+	f := &inlineFragment{}
+
+	if p.tok.kind == tokenName {
+		typeCondition, err := p.typeCondition()
+		if err != nil {
+			return nil, err
+		}
+
+		f.typeCondition = typeCondition
+	}
+
+	selections, err := p.selectionSet()
+	if err != nil {
+		return nil, err
+	}
+
+	f.selections = selections
+
+	return f, nil
+}
+
+func (p *parser) arguments() (map[string]interface{}, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+
+	args := map[string]interface{}{}
+
+	for !p.peek(")") {
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+
+		if _, ok := args[name]; ok {
+			return nil, fmt.Errorf("duplicate argument %q", name)
+		}
+
+		if err = p.expect(":"); err != nil {
+			return nil, err
+		}
+
+		if args[name], err = p.value(false); err != nil {
+			return nil, err
+		}
+	}
+
+	return args, p.expect(")")
+}
+
+// value parses a value. Constant values, e.g. default values of variables, cannot reference variables.
+func (p *parser) value(constant bool) (interface{}, error) { //nolint:gocyclo
+	tok := p.tok
+
+	switch {
+	case tok.kind == tokenPunctuator && tok.value == "$" && !constant:
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+
+		name, err := p.name()
+
+		return variable(name), err
+	case tok.kind == tokenPunctuator && tok.value == "[":
+		return p.listValue(constant)
+	case tok.kind == tokenPunctuator && tok.value == "{":
+		return p.objectValue(constant)
+	case tok.kind == tokenInt:
+		i, _ := strconv.Atoi(tok.value) //nolint:errcheck // validated by the lexer
+
+		return i, p.advance()
+	case tok.kind == tokenFloat:
+		f, _ := strconv.ParseFloat(tok.value, 64) //nolint:errcheck // validated by the lexer
+
+		return f, p.advance()
+	case tok.kind == tokenString:
+		return tok.value, p.advance()
+	case tok.kind == tokenName:
+		var v interface{}
+
+		switch tok.value {
+		case "true":
+			v = true
+		case "false":
+			v = false
+		case "null":
+			v = nil
+		default:
+			v = enum(tok.value)
+		}
+
+		return v, p.advance()
+	default:
+		return nil, p.unexpected()
+	}
+}
+
+func (p *parser) listValue(constant bool) (interface{}, error) {
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	list := []interface{}{}
+
+	for !p.peek("]") {
+		v, err := p.value(constant)
+		if err != nil {
+			return nil, err
+		}
+
+		list = append(list, v)
+	}
+
+	return list, p.expect("]")
+}
+
+func (p *parser) objectValue(constant bool) (interface{}, error) {
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	obj := map[string]interface{}{}
+
+	for !p.peek("}") {
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+
+		if err = p.expect(":"); err != nil {
+			return nil, err
+		}
+
+		if obj[name], err = p.value(constant); err != nil {
+			return nil, err
+		}
+	}
+
+	return obj, p.expect("}")
+}
+
+func (p *parser) name() (string, error) {
+	if p.tok.kind != tokenName {
+		return "", p.unexpected()
+	}
+
+	name := p.tok.value
+
+	return name, p.advance()
+}
+
+func (p *parser) peek(punctuator string) bool {
+	return p.tok.kind == tokenPunctuator && p.tok.value == punctuator
+}
+
+func (p *parser) expect(punctuator string) error {
+	if !p.peek(punctuator) {
+		return p.unexpected()
+	}
+
+	return p.advance()
+}
+
+func (p *parser) advance() error {
+	tok, err := p.lexer.next()
+	if err != nil {
+		return fmt.Errorf("syntax error: %w", err)
+	}
+
+	p.tok = tok
+
+	return nil
+}
+
+func (p *parser) unexpected() error {
+	if p.tok.kind == tokenEOF {
+		return fmt.Errorf("syntax error: unexpected end of document")
+	}
+
+	return fmt.Errorf("syntax error: unexpected %q at %d", p.tok.value, p.tok.pos)
+}
@@ -0,0 +1,206 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package graphql serves read-only GraphQL queries over a schema of objects with Go resolvers. It supports the
+// query language needed by clients fetching views of their own shape: fields with arguments and aliases, variables,
+// fragments and __typename. Mutations, subscriptions, directives and introspection are not supported.
+package graphql
+
+import (
+	"context"
+	"fmt"
+	"math"
+)
+
+// Type is the type of a field.
+type Type interface {
+	String() string
+}
+
+// Scalar is a leaf type. Values of scalar fields are serialized as returned by their resolvers.
+type Scalar struct {
+	Name string
+}
+
+func (s *Scalar) String() string {
+	return s.Name
+}
+
+// Built-in scalar types.
+var (
+	String  = &Scalar{Name: "String"}  //nolint:gochecknoglobals
+	Int     = &Scalar{Name: "Int"}     //nolint:gochecknoglobals
+	Boolean = &Scalar{Name: "Boolean"} //nolint:gochecknoglobals
+	ID      = &Scalar{Name: "ID"}      //nolint:gochecknoglobals
+)
+
+// List is a list of values of the same type. Resolvers return slices for list fields.
+type List struct {
+	OfType Type
+}
+
+func (l *List) String() string {
+	return "[" + l.OfType.String() + "]"
+}
+
+// Object is an object type with named fields, which must be selected in queries.
+type Object struct {
+	Name   string
+	Fields map[string]*Field
+}
+
+func (o *Object) String() string {
+	return o.Name
+}
+
+// ResolveFunc resolves the value of a field of the source object. The source is nil for the fields of the query
+// type. Returning nil resolves the field to null.
+type ResolveFunc func(ctx context.Context, source interface{}, args Args) (interface{}, error)
+
+// Field is a field of an object type.
+type Field struct {
+	Type Type
+	// Args are the names of the arguments the field accepts.
+	Args    []string
+	Resolve ResolveFunc
+}
+
+// Args are the arguments of a field, with variables replaced by their values. Enum values are passed as strings.
+type Args map[string]interface{}
+
+// String returns the string argument with the given name, and tells if it is set.
+func (a Args) String(name string) (string, bool, error) {
+	v, ok := a[name]
+	if !ok || v == nil {
+		return "", false, nil
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		return "", false, fmt.Errorf("argument %s must be a string", name)
+	}
+
+	return s, true, nil
+}
+
+// Int returns the integer argument with the given name, and tells if it is set.
+func (a Args) Int(name string) (int, bool, error) {
+	v, ok := a[name]
+	if !ok || v == nil {
+		return 0, false, nil
+	}
+
+	switch i := v.(type) {
+	case int:
+		return i, true, nil
+	case float64: // variables decoded from JSON
+		if i == math.Trunc(i) && math.Abs(i) <= math.MaxInt32 {
+			return int(i), true, nil
+		}
+	}
+
+	return 0, false, fmt.Errorf("argument %s must be an integer", name)
+}
+
+// Schema is a GraphQL schema serving queries of the fields of its query type.
+type Schema struct {
+	Query *Object
+	// MaxDepth limits the nesting of the selection sets of queries, if set.
+	MaxDepth int
+}
+
+// Request is a GraphQL request.
+type Request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
+
+// Response is a GraphQL response. Data is not set if the request is invalid.
+type Response struct {
+	Data   interface{} `json:"data,omitempty"`
+	Errors []*Error    `json:"errors,omitempty"`
+}
+
+// Error is an error of a GraphQL request, with the path of the field it occurred on for field errors.
+type Error struct {
+	Message string        `json:"message"`
+	Path    []interface{} `json:"path,omitempty"`
+}
+
+func requestError(err error) *Response {
+	return &Response{Errors: []*Error{{Message: err.Error()}}}
+}
+
+// Execute executes the query of the request. Fields that fail to resolve are null, with their errors in the
+// response.
+func (s *Schema) Execute(ctx context.Context, req *Request) *Response {
+	doc, err := parse(req.Query)
+	if err != nil {
+		return requestError(err)
+	}
+
+	op, err := doc.operation(req.OperationName)
+	if err != nil {
+		return requestError(err)
+	}
+
+	if op.kind != "query" {
+		return requestError(fmt.Errorf("%s operations are not supported", op.kind))
+	}
+
+	variables, err := op.coerceVariables(req.Variables)
+	if err != nil {
+		return requestError(err)
+	}
+
+	if err = s.validate(doc, op); err != nil {
+		return requestError(err)
+	}
+
+	e := &executor{doc: doc, variables: variables}
+
+	data := e.selectionSet(ctx, s.Query, nil, op.selections, nil)
+
+	return &Response{Data: data, Errors: e.errors}
+}
+
+func (d *document) operation(name string) (*operation, error) {
+	if name == "" {
+		if len(d.operations) > 1 {
+			return nil, fmt.Errorf("operation name is required for a document with several operations")
+		}
+
+		return d.operations[0], nil
+	}
+
+	for _, op := range d.operations {
+		if op.name == name {
+			return op, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unknown operation %q", name)
+}
+
+func (o *operation) coerceVariables(values map[string]interface{}) (map[string]interface{}, error) {
+	variables := map[string]interface{}{}
+
+	for _, v := range o.variables {
+		value, ok := values[v.name]
+		if !ok {
+			value = v.defaultValue
+		}
+
+		if value == nil && v.nonNull {
+			return nil, fmt.Errorf("variable $%s is required", v.name)
+		}
+
+		variables[v.name] = value
+	}
+
+	return variables, nil
+}
@@ -0,0 +1,218 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package graphql_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/ace/pkg/graphql"
+)
+
+type author struct {
+	Name  string
+	Books []string
+}
+
+func testSchema() *graphql.Schema {
+	authors := map[string]*author{
+		"alice": {Name: "Alice", Books: []string{"one", "two"}},
+		"bob":   {Name: "Bob"},
+	}
+
+	authorType := &graphql.Object{Name: "Author"}
+	authorType.Fields = map[string]*graphql.Field{
+		"name": {
+			Type: graphql.String,
+			Resolve: func(_ context.Context, source interface{}, _ graphql.Args) (interface{}, error) {
+				return source.(*author).Name, nil
+			},
+		},
+		"books": {
+			Type: &graphql.List{OfType: graphql.String},
+			Args: []string{"first"},
+			Resolve: func(_ context.Context, source interface{}, args graphql.Args) (interface{}, error) {
+				books := source.(*author).Books
+
+				first, ok, err := args.Int("first")
+				if err != nil {
+					return nil, err
+				}
+
+				if ok && first < len(books) {
+					books = books[:first]
+				}
+
+				return books, nil
+			},
+		},
+		"coauthor": {
+			Type: authorType,
+			Resolve: func(_ context.Context, source interface{}, _ graphql.Args) (interface{}, error) {
+				if source.(*author).Name == "Alice" {
+					return authors["bob"], nil
+				}
+
+				return (*author)(nil), nil
+			},
+		},
+		"failing": {
+			Type: graphql.String,
+			Resolve: func(context.Context, interface{}, graphql.Args) (interface{}, error) {
+				return nil, errors.New("resolve error")
+			},
+		},
+	}
+
+	return &graphql.Schema{
+		Query: &graphql.Object{
+			Name: "Query",
+			Fields: map[string]*graphql.Field{
+				"author": {
+					Type: authorType,
+					Args: []string{"id"},
+					Resolve: func(_ context.Context, _ interface{}, args graphql.Args) (interface{}, error) {
+						id, _, err := args.String("id")
+						if err != nil {
+							return nil, err
+						}
+
+						return authors[id], nil
+					},
+				},
+				"authors": {
+					Type: &graphql.List{OfType: authorType},
+					Resolve: func(context.Context, interface{}, graphql.Args) (interface{}, error) {
+						return []*author{authors["alice"], authors["bob"]}, nil
+					},
+				},
+			},
+		},
+		MaxDepth: 3,
+	}
+}
+
+func execute(t *testing.T, req *graphql.Request) string {
+	t.Helper()
+
+	b, err := json.Marshal(testSchema().Execute(context.Background(), req))
+	require.NoError(t, err)
+
+	return string(b)
+}
+
+func TestSchema_Execute(t *testing.T) {
+	tests := []struct {
+		name string
+		req  *graphql.Request
+		resp string
+	}{
+		{
+			"nested fields in selection order",
+			&graphql.Request{Query: `{ authors { name books } }`},
+			`{"data":{"authors":[{"name":"Alice","books":["one","two"]},{"name":"Bob","books":null}]}}`,
+		},
+		{
+			"arguments and aliases",
+			&graphql.Request{Query: `query {
+				a: author(id: "alice") { books(first: 1) coauthor { name } }
+				b: author(id: "bob") { name coauthor { name } }
+				c: author(id: "carol") { name }
+			}`},
+			`{"data":{"a":{"books":["one"],"coauthor":{"name":"Bob"}},"b":{"name":"Bob","coauthor":null},"c":null}}`,
+		},
+		{
+			"variables",
+			&graphql.Request{
+				Query:     `query Author($id: String!, $first: Int = 5) { author(id: $id) { books(first: $first) } }`,
+				Variables: map[string]interface{}{"id": "alice", "first": float64(1)},
+			},
+			`{"data":{"author":{"books":["one"]}}}`,
+		},
+		{
+			"operation name",
+			&graphql.Request{
+				Query:         `query A { author(id: "alice") { name } } query B { author(id: "bob") { name } }`,
+				OperationName: "B",
+			},
+			`{"data":{"author":{"name":"Bob"}}}`,
+		},
+		{
+			"fragments and typename",
+			&graphql.Request{Query: `
+				{ author(id: "alice") { ...names ... on Author { books } ... { __typename } } }
+				fragment names on Author { name coauthor { name } }
+				fragment other on Query { authors { name } }
+			`},
+			`{"data":{"author":{"name":"Alice","coauthor":{"name":"Bob"},"books":["one","two"],"__typename":"Author"}}}`,
+		},
+		{
+			"merged fields",
+			&graphql.Request{Query: `{ author(id: "alice") { coauthor { name } coauthor { books } } }`},
+			`{"data":{"author":{"coauthor":{"name":"Bob","books":null}}}}`,
+		},
+		{
+			"field errors",
+			&graphql.Request{Query: `{ authors { failing books(first: "x") } }`},
+			`{"data":{"authors":[{"failing":null,"books":null},{"failing":null,"books":null}]},"errors":[` +
+				`{"message":"resolve error","path":["authors",0,"failing"]},` +
+				`{"message":"argument first must be an integer","path":["authors",0,"books"]},` +
+				`{"message":"resolve error","path":["authors",1,"failing"]},` +
+				`{"message":"argument first must be an integer","path":["authors",1,"books"]}]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.resp, execute(t, tt.req))
+		})
+	}
+}
+
+func TestSchema_ExecuteInvalidRequest(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		err   string
+	}{
+		{"syntax error", `{ author(id: "alice" { name } }`, `syntax error: unexpected \"{\" at 21`},
+		{"unterminated string", `{ author(id: "alice) { name } }`, "unterminated string at 13"},
+		{"empty document", ` # comment`, "document does not contain any operation"},
+		{"empty selection", `{ }`, "empty selection set at 2"},
+		{"mutation", `mutation { author { name } }`, "mutation operations are not supported"},
+		{"directive", `{ author @skip(if: true) { name } }`, "directives are not supported, at 9"},
+		{"unknown field", `{ book { name } }`, `cannot query field \"book\" on type Query`},
+		{"unknown argument", `{ author(name: "Alice") { name } }`, `unknown argument \"name\" on field Query.author`},
+		{"missing subfields", `{ author(id: "alice") }`, "field author of type Author must have a selection of subfields"},
+		{"scalar subfields", `{ authors { name { first } } }`, "field name of type String must not have a selection"},
+		{"undefined variable", `{ author(id: $id) { name } }`, "variable $id is not defined"},
+		{"required variable", `query ($id: String!) { author(id: $id) { name } }`, "variable $id is required"},
+		{"unknown fragment", `{ authors { ...names } }`, `unknown fragment \"names\"`},
+		{"fragment cycle", `{ authors { ...a } } fragment a on Author { coauthor { ...a } }`, "spreads itself"},
+		{"conflicting fields", `{ authors { name: books name } }`, `fields \"name\" conflict`},
+		{"max depth", `{ authors { coauthor { coauthor { name } } } }`, "query exceeds the maximum depth of 3"},
+		{"several operations", `query A { authors { name } } query B { authors { name } }`, "operation name is required"},
+		{"unknown operation", `query A { authors { name } }`, `unknown operation \"B\"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &graphql.Request{Query: tt.query}
+			if tt.name == "unknown operation" {
+				req.OperationName = "B"
+			}
+
+			resp := execute(t, req)
+			require.NotContains(t, resp, `"data"`)
+			require.Contains(t, resp, tt.err)
+		})
+	}
+}
@@ -0,0 +1,345 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package gatekeeper
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/hyperledger/aries-framework-go/pkg/common/log"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/presexch"
+	vdrapi "github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdr"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/trustbloc/ace/pkg/gatekeeper/policy"
+	"github.com/trustbloc/ace/pkg/grpcapi/gatekeeper/gatekeeperpb"
+	"github.com/trustbloc/ace/pkg/restapi/gatekeeper/operation"
+	"github.com/trustbloc/ace/pkg/restapi/mw/httpsigmw"
+)
+
+// Metadata keys of the API token, and of the entity tags of the policies, as with the REST API.
+const (
+	authorizationKey = "authorization"
+	ifMatchKey       = "if-match"
+	etagKey          = "etag"
+)
+
+var logger = log.New("gatekeeper-grpc")
+
+type contextKey struct{}
+
+// SubjectDID returns the DID of the subject the gRPC call is made on behalf of.
+func SubjectDID(ctx context.Context) (string, bool) {
+	sub, ok := ctx.Value(contextKey{}).(string)
+
+	return sub, ok
+}
+
+// NewGRPCServer returns a gRPC server serving the Gatekeeper API with the given operations. Calls are authorized with
+// the API token, and those made on behalf of a subject are signed by the subject, whose DID is resolved with the VDR.
+func NewGRPCServer(op *operation.Operation, token string, vdr vdrapi.Registry) *grpc.Server {
+	a := &authorizer{token: token, keyResolver: httpsigmw.NewKeyResolver(vdr)}
+
+	s := grpc.NewServer(
+		grpc.UnaryInterceptor(a.unaryInterceptor),
+		grpc.StreamInterceptor(a.streamInterceptor),
+	)
+
+	gatekeeperpb.RegisterGatekeeperServer(s, NewServer(op))
+
+	return s
+}
+
+// Server implements the Gatekeeper gRPC service with the same operations as the REST API.
+type Server struct {
+	gatekeeperpb.UnimplementedGatekeeperServer
+
+	op *operation.Operation
+}
+
+// NewServer returns a new Server instance.
+func NewServer(op *operation.Operation) *Server {
+	return &Server{op: op}
+}
+
+// CreatePolicy creates policy configuration, or replaces it if the if-match metadata of the call matches the entity
+// tag of the policy, as the If-Match header of the REST API. The entity tag of the saved policy is returned in the
+// etag header.
+func (s *Server) CreatePolicy(ctx context.Context,
+	req *gatekeeperpb.CreatePolicyRequest) (*gatekeeperpb.CreatePolicyResponse, error) {
+	p, err := fromPolicyPB(req.GetPolicy())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	var ifMatch string
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	if v := md.Get(ifMatchKey); len(v) > 0 {
+		ifMatch = v[0]
+	}
+
+	etag, err := s.op.SavePolicy(ctx, req.GetPolicyId(), ifMatch, p)
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	if err = grpc.SetHeader(ctx, metadata.Pairs(etagKey, etag)); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return &gatekeeperpb.CreatePolicyResponse{}, nil
+}
+
+// GetPolicy gets policy configuration, with its entity tag in the etag header.
+func (s *Server) GetPolicy(ctx context.Context, req *gatekeeperpb.GetPolicyRequest) (*gatekeeperpb.Policy, error) {
+	p, err := s.op.GetPolicy(ctx, req.GetPolicyId())
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	etag, err := operation.ETag(p)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	if err = grpc.SetHeader(ctx, metadata.Pairs(etagKey, etag)); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return toPolicyPB(p)
+}
+
+// ListPolicies streams all policy configurations.
+func (s *Server) ListPolicies(_ *gatekeeperpb.ListPoliciesRequest,
+	stream gatekeeperpb.Gatekeeper_ListPoliciesServer) error {
+	err := s.op.ListPolicies(stream.Context(), func(p *policy.Policy) error {
+		pb, err := toPolicyPB(p)
+		if err != nil {
+			return err
+		}
+
+		return stream.Send(pb)
+	})
+	if err != nil {
+		return statusError(err)
+	}
+
+	return nil
+}
+
+// Protect protects the target under the policy.
+func (s *Server) Protect(ctx context.Context,
+	req *gatekeeperpb.ProtectRequest) (*gatekeeperpb.ProtectResponse, error) {
+	resp, err := s.op.Protect(ctx, &operation.ProtectRequest{Policy: req.GetPolicy(), Target: req.GetTarget()})
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	return &gatekeeperpb.ProtectResponse{Did: resp.DID}, nil
+}
+
+// Release creates a release transaction (ticket) on a DID.
+func (s *Server) Release(ctx context.Context,
+	req *gatekeeperpb.ReleaseRequest) (*gatekeeperpb.ReleaseResponse, error) {
+	resp, err := s.op.Release(ctx, &operation.ReleaseRequest{DID: req.GetDid(), Presentation: req.GetPresentation()})
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	return &gatekeeperpb.ReleaseResponse{TicketId: resp.TicketID}, nil
+}
+
+// Authorize authorizes release transaction (ticket).
+func (s *Server) Authorize(ctx context.Context,
+	req *gatekeeperpb.AuthorizeRequest) (*gatekeeperpb.AuthorizeResponse, error) {
+	if err := s.op.Authorize(ctx, req.GetTicketId(), &operation.DecisionRequest{}); err != nil {
+		return nil, statusError(err)
+	}
+
+	return &gatekeeperpb.AuthorizeResponse{}, nil
+}
+
+// GetTicketStatus gets the status of the ticket.
+func (s *Server) GetTicketStatus(ctx context.Context,
+	req *gatekeeperpb.GetTicketStatusRequest) (*gatekeeperpb.GetTicketStatusResponse, error) {
+	resp, err := s.op.TicketStatus(ctx, req.GetTicketId())
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	return &gatekeeperpb.GetTicketStatusResponse{Status: resp.Status}, nil
+}
+
+// GetTicket gets the ticket with its approvals.
+func (s *Server) GetTicket(ctx context.Context, req *gatekeeperpb.GetTicketRequest) (*gatekeeperpb.Ticket, error) {
+	resp, err := s.op.GetTicket(ctx, req.GetTicketId())
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	return &gatekeeperpb.Ticket{
+		Id:         resp.ID,
+		Did:        resp.DID,
+		Status:     resp.Status,
+		ApprovedBy: resp.ApprovedBy,
+	}, nil
+}
+
+// Collect generates extract query for the ticket that has completed authorization process.
+func (s *Server) Collect(ctx context.Context,
+	req *gatekeeperpb.CollectRequest) (*gatekeeperpb.CollectResponse, error) {
+	resp, err := s.op.Collect(ctx, req.GetTicketId())
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	return &gatekeeperpb.CollectResponse{QueryId: resp.QueryID}, nil
+}
+
+// Extract extracts protected data. Data whose policy requires its encryption cannot be extracted over gRPC, as the
+// response has no JWE.
+func (s *Server) Extract(ctx context.Context,
+	req *gatekeeperpb.ExtractRequest) (*gatekeeperpb.ExtractResponse, error) {
+	resp, err := s.op.Extract(ctx, &operation.ExtractRequest{QueryID: req.GetQueryId()})
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	if len(resp.JWE) != 0 {
+		return nil, status.Error(codes.FailedPrecondition, "encrypted data cannot be extracted over gRPC")
+	}
+
+	return &gatekeeperpb.ExtractResponse{Target: resp.Target}, nil
+}
+
+func fromPolicyPB(pb *gatekeeperpb.Policy) (*policy.Policy, error) {
+	p := &policy.Policy{
+		ID:           pb.GetId(),
+		Collectors:   pb.GetCollectors(),
+		Handlers:     pb.GetHandlers(),
+		Approvers:    pb.GetApprovers(),
+		MinApprovers: int(pb.GetMinApprovers()),
+	}
+
+	if len(pb.GetPresentationDefinition()) > 0 {
+		var pd presexch.PresentationDefinition
+
+		if err := json.Unmarshal(pb.GetPresentationDefinition(), &pd); err != nil {
+			return nil, fmt.Errorf("parse presentation definition: %w", err)
+		}
+
+		p.PresentationDefinition = &pd
+	}
+
+	return p, nil
+}
+
+func toPolicyPB(p *policy.Policy) (*gatekeeperpb.Policy, error) {
+	pb := &gatekeeperpb.Policy{
+		Id:           p.ID,
+		Collectors:   p.Collectors,
+		Handlers:     p.Handlers,
+		Approvers:    p.Approvers,
+		MinApprovers: int32(p.MinApprovers),
+	}
+
+	if p.PresentationDefinition != nil {
+		pd, err := json.Marshal(p.PresentationDefinition)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "marshal presentation definition: %s", err)
+		}
+
+		pb.PresentationDefinition = pd
+	}
+
+	return pb, nil
+}
+
+// statusError converts the error of an operation to a gRPC status error with the code matching its HTTP status.
+func statusError(err error) error {
+	logger.Errorf(err.Error())
+
+	var code codes.Code
+
+	switch operation.ErrorStatus(err) {
+	case http.StatusBadRequest:
+		code = codes.InvalidArgument
+	case http.StatusUnauthorized:
+		code = codes.PermissionDenied
+	case http.StatusNotFound:
+		code = codes.NotFound
+	case http.StatusMisdirectedRequest, http.StatusPreconditionFailed, http.StatusPreconditionRequired:
+		code = codes.FailedPrecondition
+	case http.StatusServiceUnavailable:
+		code = codes.Unavailable
+	default:
+		code = codes.Internal
+	}
+
+	return status.Error(code, err.Error())
+}
+
+// authorizer authorizes the calls with the API token and the signature of their subject.
+type authorizer struct {
+	token       string
+	keyResolver *httpsigmw.KeyResolver
+}
+
+// authorize checks the API token of the call to the method and returns the context with the DID of the subject, if
+// the call is signed.
+func (a *authorizer) authorize(ctx context.Context, method string, req interface{}) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	var actual string
+
+	if v := md.Get(authorizationKey); len(v) > 0 {
+		actual = v[0]
+	}
+
+	if subtle.ConstantTimeCompare([]byte(actual), []byte("Bearer "+a.token)) != 1 {
+		return nil, status.Error(codes.Unauthenticated, "unauthorised")
+	}
+
+	return a.verifySubject(ctx, md, method, req)
+}
+
+func (a *authorizer) unaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, err := a.authorize(ctx, info.FullMethod, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return handler(ctx, req)
+}
+
+func (a *authorizer) streamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler) error {
+	ctx, err := a.authorize(ss.Context(), info.FullMethod, nil)
+	if err != nil {
+		return err
+	}
+
+	return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+}
+
+// serverStream is a server stream with the context of the authorized call.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,317 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package gatekeeper_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/aries-framework-go/component/storageutil/mem"
+	"github.com/hyperledger/aries-framework-go/pkg/vdr"
+	"github.com/hyperledger/aries-framework-go/pkg/vdr/fingerprint"
+	"github.com/hyperledger/aries-framework-go/pkg/vdr/key"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+
+	vaultclient "github.com/trustbloc/ace/pkg/client/vault"
+	grpcgatekeeper "github.com/trustbloc/ace/pkg/grpcapi/gatekeeper"
+	"github.com/trustbloc/ace/pkg/grpcapi/gatekeeper/gatekeeperpb"
+	"github.com/trustbloc/ace/pkg/httpsig"
+	"github.com/trustbloc/ace/pkg/restapi/gatekeeper"
+	"github.com/trustbloc/ace/pkg/restapi/vault"
+)
+
+const (
+	testToken    = "test-token"
+	collectorDID = "did:example:collector"
+)
+
+func TestServer_Policy(t *testing.T) {
+	client := newTestClient(t)
+	ctx := authContext(testToken)
+
+	_, err := client.CreatePolicy(ctx, &gatekeeperpb.CreatePolicyRequest{
+		PolicyId: "Containment-Policy",
+		Policy: &gatekeeperpb.Policy{
+			Collectors:             []string{collectorDID},
+			Handlers:               []string{"did:example:handler"},
+			Approvers:              []string{"did:example:approver1", "did:example:approver2"},
+			MinApprovers:           1,
+			PresentationDefinition: []byte(`{"id":"pd","input_descriptors":[]}`),
+		},
+	})
+	require.NoError(t, err)
+
+	p, err := client.GetPolicy(ctx, &gatekeeperpb.GetPolicyRequest{PolicyId: "containment-policy"})
+	require.NoError(t, err)
+	require.Equal(t, "containment-policy", p.GetId())
+	require.Equal(t, []string{collectorDID}, p.GetCollectors())
+	require.Equal(t, int32(1), p.GetMinApprovers())
+	require.Contains(t, string(p.GetPresentationDefinition()), `"id":"pd"`)
+
+	stream, err := client.ListPolicies(ctx, &gatekeeperpb.ListPoliciesRequest{})
+	require.NoError(t, err)
+
+	var ids []string
+
+	for {
+		p, err = stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		require.NoError(t, err)
+
+		ids = append(ids, p.GetId())
+	}
+
+	require.Equal(t, []string{"containment-policy"}, ids)
+
+	_, err = client.GetPolicy(ctx, &gatekeeperpb.GetPolicyRequest{PolicyId: "unknown"})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = client.CreatePolicy(ctx, &gatekeeperpb.CreatePolicyRequest{
+		PolicyId: "invalid",
+		Policy:   &gatekeeperpb.Policy{PresentationDefinition: []byte("invalid")},
+	})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	require.Contains(t, err.Error(), "parse presentation definition")
+}
+
+func TestServer_ReplacePolicy(t *testing.T) {
+	client := newTestClient(t)
+	ctx := authContext(testToken)
+
+	var header metadata.MD
+
+	_, err := client.CreatePolicy(ctx, &gatekeeperpb.CreatePolicyRequest{
+		PolicyId: "policy",
+		Policy:   &gatekeeperpb.Policy{Collectors: []string{collectorDID}},
+	}, grpc.Header(&header))
+	require.NoError(t, err)
+
+	etag := header.Get("etag")
+	require.Len(t, etag, 1)
+
+	_, err = client.CreatePolicy(ctx, &gatekeeperpb.CreatePolicyRequest{
+		PolicyId: "policy",
+		Policy:   &gatekeeperpb.Policy{MinApprovers: 1},
+	})
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+	require.Contains(t, err.Error(), "If-Match header not set")
+
+	_, err = client.CreatePolicy(metadata.AppendToOutgoingContext(ctx, "if-match", `"other"`),
+		&gatekeeperpb.CreatePolicyRequest{PolicyId: "policy", Policy: &gatekeeperpb.Policy{MinApprovers: 1}})
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+	require.Contains(t, err.Error(), "modified since it was read")
+
+	_, err = client.CreatePolicy(metadata.AppendToOutgoingContext(ctx, "if-match", etag[0]),
+		&gatekeeperpb.CreatePolicyRequest{PolicyId: "policy", Policy: &gatekeeperpb.Policy{MinApprovers: 1}},
+		grpc.Header(&header))
+	require.NoError(t, err)
+	require.NotEqual(t, etag, header.Get("etag"))
+
+	var getHeader metadata.MD
+
+	p, err := client.GetPolicy(ctx, &gatekeeperpb.GetPolicyRequest{PolicyId: "policy"}, grpc.Header(&getHeader))
+	require.NoError(t, err)
+	require.Equal(t, int32(1), p.GetMinApprovers())
+	require.Equal(t, header.Get("etag"), getHeader.Get("etag"))
+}
+
+func TestServer_Subject(t *testing.T) {
+	client := newTestClient(t)
+	ctx := authContext(testToken)
+
+	_, err := client.CreatePolicy(ctx, &gatekeeperpb.CreatePolicyRequest{
+		PolicyId: "policy",
+		Policy:   &gatekeeperpb.Policy{Collectors: []string{collectorDID}},
+	})
+	require.NoError(t, err)
+
+	t.Run("missing subject DID", func(t *testing.T) {
+		_, err = client.Protect(ctx, &gatekeeperpb.ProtectRequest{Policy: "policy", Target: "data"})
+		require.Equal(t, codes.PermissionDenied, status.Code(err))
+		require.Contains(t, err.Error(), "missing subject DID")
+	})
+
+	t.Run("unsigned subject DID", func(t *testing.T) {
+		subCtx := metadata.AppendToOutgoingContext(ctx, "subject-did", collectorDID)
+
+		_, err = client.Protect(subCtx, &gatekeeperpb.ProtectRequest{Policy: "policy", Target: "data"})
+		require.Equal(t, codes.PermissionDenied, status.Code(err))
+		require.Contains(t, err.Error(), "missing subject DID")
+	})
+
+	t.Run("subject is not allowed", func(t *testing.T) {
+		req := &gatekeeperpb.ProtectRequest{Policy: "policy", Target: "data"}
+
+		_, err = client.Protect(signCall(t, ctx, newSubject(t), "Protect", req), req)
+		require.Equal(t, codes.PermissionDenied, status.Code(err))
+		require.NotContains(t, err.Error(), "missing subject DID")
+	})
+
+	t.Run("subject is authenticated by the signature", func(t *testing.T) {
+		sub := newSubject(t)
+		subDID := strings.Split(sub.keyID, "#")[0]
+
+		_, err = client.CreatePolicy(ctx, &gatekeeperpb.CreatePolicyRequest{
+			PolicyId: "signed",
+			Policy:   &gatekeeperpb.Policy{Collectors: []string{subDID}},
+		})
+		require.NoError(t, err)
+
+		req := &gatekeeperpb.ProtectRequest{Policy: "signed", Target: "data"}
+
+		// the call is authorized, and fails on the vault
+		_, err = client.Protect(signCall(t, ctx, sub, "Protect", req), req)
+		require.Equal(t, codes.Internal, status.Code(err))
+		require.Contains(t, err.Error(), "create vault")
+	})
+
+	t.Run("signature of another request", func(t *testing.T) {
+		req := &gatekeeperpb.ProtectRequest{Policy: "policy", Target: "data"}
+
+		_, err = client.Protect(signCall(t, ctx, newSubject(t), "Protect", req),
+			&gatekeeperpb.ProtectRequest{Policy: "policy", Target: "other"})
+		require.Equal(t, codes.Unauthenticated, status.Code(err))
+		require.Contains(t, err.Error(), "invalid signature")
+
+		_, err = client.Release(signCall(t, ctx, newSubject(t), "Protect", req), &gatekeeperpb.ReleaseRequest{})
+		require.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+
+	t.Run("signature without digest", func(t *testing.T) {
+		sub := newSubject(t)
+
+		r := httptest.NewRequest(http.MethodPost, "/"+gatekeeperpb.Gatekeeper_ServiceDesc.ServiceName+"/Protect", nil)
+		require.NoError(t, httpsig.NewSigner(httpsig.DefaultGetSignerConfig(), sub.privateKey).SignRequest(sub.keyID, r))
+
+		subCtx := metadata.AppendToOutgoingContext(ctx, "signature", r.Header.Get("Signature"),
+			"date", r.Header.Get("Date"))
+
+		_, err = client.Protect(subCtx, &gatekeeperpb.ProtectRequest{Policy: "policy", Target: "data"})
+		require.Equal(t, codes.Unauthenticated, status.Code(err))
+		require.Contains(t, err.Error(), "signature does not cover the request digest")
+	})
+
+	t.Run("unknown ticket", func(t *testing.T) {
+		_, err = client.GetTicket(ctx, &gatekeeperpb.GetTicketRequest{TicketId: "unknown"})
+		require.Equal(t, codes.NotFound, status.Code(err))
+
+		_, err = client.Authorize(ctx, &gatekeeperpb.AuthorizeRequest{TicketId: "unknown"})
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
+
+func TestServer_Auth(t *testing.T) {
+	client := newTestClient(t)
+
+	t.Run("unary call", func(t *testing.T) {
+		_, err := client.GetPolicy(context.Background(), &gatekeeperpb.GetPolicyRequest{PolicyId: "policy"})
+		require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+		_, err = client.GetPolicy(authContext("invalid"), &gatekeeperpb.GetPolicyRequest{PolicyId: "policy"})
+		require.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+
+	t.Run("stream call", func(t *testing.T) {
+		stream, err := client.ListPolicies(authContext("invalid"), &gatekeeperpb.ListPoliciesRequest{})
+		require.NoError(t, err)
+
+		_, err = stream.Recv()
+		require.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+}
+
+// subject signs calls with a did:key.
+type subject struct {
+	keyID      string
+	privateKey ed25519.PrivateKey
+}
+
+func newSubject(t *testing.T) *subject {
+	t.Helper()
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	_, keyID := fingerprint.CreateDIDKey(pub)
+
+	return &subject{keyID: keyID, privateKey: priv}
+}
+
+func signCall(t *testing.T, ctx context.Context, sub *subject, method string, req proto.Message) context.Context {
+	t.Helper()
+
+	method = "/" + gatekeeperpb.Gatekeeper_ServiceDesc.ServiceName + "/" + method
+
+	ctx, err := grpcgatekeeper.SignCall(ctx, method, req, sub.keyID, sub.privateKey)
+	require.NoError(t, err)
+
+	return ctx
+}
+
+func newTestClient(t *testing.T) gatekeeperpb.GatekeeperClient {
+	t.Helper()
+
+	controller, err := gatekeeper.New(&gatekeeper.Config{
+		StorageProvider: mem.NewProvider(),
+		VaultClient:     &failingVault{},
+	})
+	require.NoError(t, err)
+
+	l := bufconn.Listen(1024 * 1024)
+
+	srv := grpcgatekeeper.NewGRPCServer(controller.Operation(), testToken, vdr.New(vdr.WithVDR(key.New())))
+
+	go func() {
+		_ = srv.Serve(l) //nolint:errcheck
+	}()
+
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return l.DialContext(ctx)
+		}),
+		grpc.WithInsecure(),
+	)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		require.NoError(t, conn.Close())
+	})
+
+	return gatekeeperpb.NewGatekeeperClient(conn)
+}
+
+// failingVault fails to create vaults.
+type failingVault struct {
+	vaultclient.Vault
+}
+
+func (v *failingVault) CreateVault(context.Context) (*vault.CreatedVault, error) {
+	return nil, errors.New("test")
+}
+
+func authContext(token string) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token)
+}
@@ -0,0 +1,135 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package gatekeeper
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"fmt"
+	"net/http"
+	"strings"
+
+	httpsignatures "github.com/igor-pavlenko/httpsignatures-go"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/trustbloc/ace/pkg/httpsig"
+)
+
+// Metadata keys of the HTTP signature headers of the calls made on behalf of a subject.
+const (
+	signatureKey = "signature"
+	dateKey      = "date"
+	digestKey    = "digest"
+)
+
+type signatureContextKey struct{}
+
+// Signature returns the signature the subject of the gRPC call is authenticated with.
+func Signature(ctx context.Context) (string, bool) {
+	signature, ok := ctx.Value(signatureContextKey{}).(string)
+
+	return signature, ok
+}
+
+// SignCall returns the context of a call to the method, made on behalf of the subject owning the key, with the request
+// signed as by the HTTP signature of the REST API: the signature covers the full method name, as the request target,
+// the date and the digest of the deterministic encoding of the request. Calls to streaming methods are signed with no
+// request.
+func SignCall(ctx context.Context, method string, req proto.Message, keyID string,
+	privateKey ed25519.PrivateKey) (context.Context, error) {
+	r, err := callRequest(method, req, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = httpsig.NewSigner(httpsig.DefaultPostSignerConfig(), privateKey).SignRequest(keyID, r); err != nil {
+		return nil, err
+	}
+
+	return metadata.AppendToOutgoingContext(ctx,
+		signatureKey, r.Header.Get(signatureKey),
+		dateKey, r.Header.Get(dateKey),
+		digestKey, r.Header.Get(digestKey),
+	), nil
+}
+
+// verifySubject verifies the signature of the call, if it has one, and returns the context with the DID of the subject
+// that signed it.
+func (a *authorizer) verifySubject(ctx context.Context, md metadata.MD, method string,
+	req interface{}) (context.Context, error) {
+	if len(md.Get(signatureKey)) == 0 {
+		return ctx, nil
+	}
+
+	r, err := callRequest(method, req, md)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	signature := r.Header.Get(signatureKey)
+
+	if !signsDigest(signature) {
+		return nil, status.Error(codes.Unauthenticated, "signature does not cover the request digest")
+	}
+
+	ok, subjectDID := httpsig.NewVerifier(a.keyResolver).VerifyRequest(r)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "invalid signature")
+	}
+
+	ctx = context.WithValue(ctx, contextKey{}, subjectDID)
+
+	return context.WithValue(ctx, signatureContextKey{}, signature), nil
+}
+
+// callRequest returns the HTTP request the call to the method is signed as, with the signature headers from the
+// metadata.
+func callRequest(method string, req interface{}, md metadata.MD) (*http.Request, error) {
+	var body []byte
+
+	if m, ok := req.(proto.Message); ok {
+		var err error
+
+		body, err = proto.MarshalOptions{Deterministic: true}.Marshal(m)
+		if err != nil {
+			return nil, fmt.Errorf("marshal request: %w", err)
+		}
+	}
+
+	r, err := http.NewRequestWithContext(context.Background(), http.MethodPost, method, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+
+	for _, k := range []string{signatureKey, dateKey, digestKey} {
+		if v := md.Get(k); len(v) > 0 {
+			r.Header.Set(k, v[0])
+		}
+	}
+
+	return r, nil
+}
+
+// signsDigest returns true if the digest header is signed, so that the signature covers the request.
+func signsDigest(signature string) bool {
+	h, err := httpsignatures.NewParser().ParseSignatureHeader(signature)
+	if err != nil {
+		return false
+	}
+
+	for _, header := range h.Headers {
+		if strings.EqualFold(header, digestKey) {
+			return true
+		}
+	}
+
+	return false
+}
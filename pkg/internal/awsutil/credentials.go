@@ -0,0 +1,222 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package awsutil
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// MetadataURL is the URL of the instance metadata service of EC2 instances.
+	MetadataURL = "http://169.254.169.254"
+	// ContainerRelativeURIEnvKey is the env var set by ECS to the path of the credentials of the task role.
+	ContainerRelativeURIEnvKey = "AWS_CONTAINER_CREDENTIALS_RELATIVE_URI"
+	// ContainerFullURIEnvKey is the env var set to the full URL of the credentials of the container.
+	ContainerFullURIEnvKey = "AWS_CONTAINER_CREDENTIALS_FULL_URI"
+	// ContainerTokenEnvKey is the env var of the authorization token sent to the full URL of the credentials.
+	ContainerTokenEnvKey = "AWS_CONTAINER_AUTHORIZATION_TOKEN" //nolint:gosec
+
+	containerHost      = "http://169.254.170.2"
+	metadataTokenPath  = "/latest/api/token" //nolint:gosec
+	metadataRolesPath  = "/latest/meta-data/iam/security-credentials/"
+	metadataTokenTTL   = "X-aws-ec2-metadata-token-ttl-seconds" //nolint:gosec
+	metadataToken      = "X-aws-ec2-metadata-token"             //nolint:gosec
+	metadataTokenValue = "21600"
+	// refreshWindow is the time before their expiration the credentials are retrieved again.
+	refreshWindow = 5 * time.Minute
+)
+
+var errNoRole = errors.New("no role attached to the instance")
+
+// CredentialsProvider returns the credentials requests are signed with.
+type CredentialsProvider interface {
+	Retrieve(ctx context.Context) (*Credentials, error)
+}
+
+// Retrieve returns the static credentials.
+func (c *Credentials) Retrieve(context.Context) (*Credentials, error) {
+	return c, nil
+}
+
+type httpClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// RoleCredentials retrieves the temporary credentials of the IAM role of the ECS task or of the EC2 instance, and
+// caches them until shortly before they expire. The credentials of the task role are retrieved if ECS sets their
+// URL in the environment, or else those of the instance profile from the instance metadata service.
+type RoleCredentials struct {
+	// MetadataURL defaults to the URL of the instance metadata service.
+	MetadataURL string
+	HTTPClient  httpClient
+	// Now returns the time the expiration of the credentials is checked at. Defaults to time.Now.
+	Now func() time.Time
+
+	mu          sync.Mutex
+	credentials *Credentials
+	expiration  time.Time
+}
+
+// NewCredentials returns the provider of the static credentials if an access key is set, or else of the
+// credentials of the IAM role.
+func NewCredentials(static *Credentials, metadataURL string, client httpClient, //nolint:ireturn
+	now func() time.Time) CredentialsProvider {
+	if static.AccessKeyID != "" {
+		return static
+	}
+
+	return &RoleCredentials{MetadataURL: metadataURL, HTTPClient: client, Now: now}
+}
+
+type roleCredentials struct {
+	AccessKeyID     string    `json:"AccessKeyId"`
+	SecretAccessKey string    `json:"SecretAccessKey"`
+	Token           string    `json:"Token"`
+	Expiration      time.Time `json:"Expiration"`
+}
+
+// Retrieve returns the cached credentials of the role, or retrieves them if they are about to expire.
+func (r *RoleCredentials) Retrieve(ctx context.Context) (*Credentials, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now
+	if r.Now != nil {
+		now = r.Now
+	}
+
+	if r.credentials != nil && now().Before(r.expiration.Add(-refreshWindow)) {
+		return r.credentials, nil
+	}
+
+	var (
+		b   []byte
+		err error
+	)
+
+	if url, token := containerURL(); url != "" {
+		b, err = r.get(ctx, url, map[string]string{"Authorization": token})
+	} else {
+		b, err = r.metadata(ctx)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("retrieve role credentials: %w", err)
+	}
+
+	var resp roleCredentials
+
+	if err = json.Unmarshal(b, &resp); err != nil {
+		return nil, fmt.Errorf("decode role credentials: %w", err)
+	}
+
+	r.credentials = &Credentials{
+		AccessKeyID:     resp.AccessKeyID,
+		SecretAccessKey: resp.SecretAccessKey,
+		SessionToken:    resp.Token,
+	}
+	r.expiration = resp.Expiration
+
+	return r.credentials, nil
+}
+
+// metadata reads the credentials of the instance profile with a session token of the instance metadata service.
+func (r *RoleCredentials) metadata(ctx context.Context) ([]byte, error) {
+	base := r.MetadataURL
+	if base == "" {
+		base = MetadataURL
+	}
+
+	base = strings.TrimSuffix(base, "/")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, base+metadataTokenPath, http.NoBody)
+	if err != nil {
+		return nil, fmt.Errorf("create token request: %w", err)
+	}
+
+	req.Header.Set(metadataTokenTTL, metadataTokenValue)
+
+	token, err := r.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("get metadata token: %w", err)
+	}
+
+	headers := map[string]string{metadataToken: string(token)}
+
+	role, err := r.get(ctx, base+metadataRolesPath, headers)
+	if err != nil {
+		return nil, fmt.Errorf("get instance role: %w", err)
+	}
+
+	// the instance profile has one role, listed on the first line
+	name := strings.TrimSpace(strings.SplitN(string(role), "\n", 2)[0]) //nolint:gomnd
+
+	if name == "" {
+		return nil, errNoRole
+	}
+
+	return r.get(ctx, base+metadataRolesPath+name, headers)
+}
+
+// get returns the body of the response to a GET request with the headers that are set.
+func (r *RoleCredentials) get(ctx context.Context, url string, headers map[string]string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, http.NoBody)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+
+	for name, value := range headers {
+		if value != "" {
+			req.Header.Set(name, value)
+		}
+	}
+
+	return r.do(req)
+}
+
+func (r *RoleCredentials) do(req *http.Request) ([]byte, error) {
+	var client httpClient = http.DefaultClient
+	if r.HTTPClient != nil {
+		client = r.HTTPClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("send request: %w", err)
+	}
+
+	defer resp.Body.Close() //nolint:errcheck
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected response status %d", resp.StatusCode)
+	}
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+
+	return b, nil
+}
+
+// containerURL returns the URL of the credentials of the container set in the environment, with its token.
+func containerURL() (string, string) {
+	if uri := os.Getenv(ContainerRelativeURIEnvKey); uri != "" {
+		return containerHost + uri, ""
+	}
+
+	return os.Getenv(ContainerFullURIEnvKey), os.Getenv(ContainerTokenEnvKey)
+}
@@ -0,0 +1,129 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package awsutil_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/ace/pkg/internal/awsutil"
+)
+
+const roleCredentials = `{"AccessKeyId":"ASIAEXAMPLE","SecretAccessKey":"secret","Token":"session",` +
+	`"Expiration":"2022-08-30T18:00:00Z"}`
+
+func TestNewCredentials(t *testing.T) {
+	static := &awsutil.Credentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "secret"}
+
+	c, err := awsutil.NewCredentials(static, "", nil, nil).Retrieve(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, static, c)
+
+	require.IsType(t, &awsutil.RoleCredentials{},
+		awsutil.NewCredentials(&awsutil.Credentials{}, "", nil, nil))
+}
+
+func TestRoleCredentials_Retrieve(t *testing.T) {
+	t.Setenv(awsutil.ContainerRelativeURIEnvKey, "")
+	t.Setenv(awsutil.ContainerFullURIEnvKey, "")
+
+	var requests int
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/latest/api/token", func(rw http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPut, r.Method)
+		require.Equal(t, "21600", r.Header.Get("X-aws-ec2-metadata-token-ttl-seconds"))
+
+		_, _ = rw.Write([]byte("imds-token"))
+	})
+	mux.HandleFunc("/latest/meta-data/iam/security-credentials/", func(rw http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "imds-token", r.Header.Get("X-aws-ec2-metadata-token"))
+
+		switch r.URL.Path {
+		case "/latest/meta-data/iam/security-credentials/":
+			_, _ = rw.Write([]byte("gatekeeper\n"))
+		case "/latest/meta-data/iam/security-credentials/gatekeeper":
+			requests++
+
+			_, _ = rw.Write([]byte(roleCredentials))
+		default:
+			rw.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	now := time.Date(2022, 8, 30, 12, 36, 0, 0, time.UTC)
+
+	r := &awsutil.RoleCredentials{MetadataURL: srv.URL, HTTPClient: srv.Client(), Now: func() time.Time {
+		return now
+	}}
+
+	expected := &awsutil.Credentials{AccessKeyID: "ASIAEXAMPLE", SecretAccessKey: "secret", SessionToken: "session"}
+
+	for i := 0; i < 2; i++ {
+		c, err := r.Retrieve(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, expected, c)
+	}
+
+	require.Equal(t, 1, requests)
+
+	t.Run("Retrieve again before expiration", func(t *testing.T) {
+		now = time.Date(2022, 8, 30, 17, 56, 0, 0, time.UTC)
+
+		_, err := r.Retrieve(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, 2, requests)
+	})
+
+	t.Run("Container credentials", func(t *testing.T) {
+		container := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			require.Equal(t, "/v2/credentials", r.URL.Path)
+			require.Equal(t, "container-token", r.Header.Get("Authorization"))
+
+			_, _ = rw.Write([]byte(roleCredentials))
+		}))
+		defer container.Close()
+
+		t.Setenv(awsutil.ContainerFullURIEnvKey, container.URL+"/v2/credentials")
+		t.Setenv(awsutil.ContainerTokenEnvKey, "container-token")
+
+		c, err := (&awsutil.RoleCredentials{HTTPClient: container.Client()}).Retrieve(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, expected, c)
+	})
+
+	t.Run("No role attached to the instance", func(t *testing.T) {
+		empty := http.NewServeMux()
+		empty.Handle("/latest/api/token", mux)
+		empty.HandleFunc("/latest/meta-data/iam/security-credentials/", func(http.ResponseWriter, *http.Request) {})
+
+		s := httptest.NewServer(empty)
+		defer s.Close()
+
+		_, err := (&awsutil.RoleCredentials{MetadataURL: s.URL, HTTPClient: s.Client()}).
+			Retrieve(context.Background())
+		require.EqualError(t, err, "retrieve role credentials: no role attached to the instance")
+	})
+
+	t.Run("Fail to get metadata token", func(t *testing.T) {
+		s := httptest.NewServer(http.NotFoundHandler())
+		defer s.Close()
+
+		_, err := (&awsutil.RoleCredentials{MetadataURL: s.URL, HTTPClient: s.Client()}).
+			Retrieve(context.Background())
+		require.EqualError(t, err,
+			"retrieve role credentials: get metadata token: unexpected response status 404")
+	})
+}
@@ -0,0 +1,111 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package awsutil
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	algorithm   = "AWS4-HMAC-SHA256"
+	dateFormat  = "20060102"
+	timeFormat  = "20060102T150405Z"
+	request     = "aws4_request"
+	dateHeader  = "X-Amz-Date"
+	tokenHeader = "X-Amz-Security-Token" //nolint:gosec
+)
+
+// Credentials of an AWS identity.
+type Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	// SessionToken is only set with temporary credentials.
+	SessionToken string
+}
+
+// Sign adds the Signature Version 4 authorization of the request to its headers. All headers set on the request are
+// signed.
+func Sign(req *http.Request, body []byte, credentials *Credentials, region, service string, at time.Time) {
+	at = at.UTC()
+
+	req.Header.Set(dateHeader, at.Format(timeFormat))
+
+	if credentials.SessionToken != "" {
+		req.Header.Set(tokenHeader, credentials.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.Join(values, ",")
+	}
+
+	names := make([]string, 0, len(headers))
+
+	for name := range headers {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(headers[name]) + "\n")
+	}
+
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		// url.Values.Encode sorts the query parameters by key
+		req.URL.Query().Encode(),
+		canonicalHeaders.String(),
+		signedHeaders,
+		hexSHA256(body),
+	}, "\n")
+
+	scope := strings.Join([]string{at.Format(dateFormat), region, service, request}, "/")
+
+	stringToSign := strings.Join([]string{algorithm, at.Format(timeFormat), scope,
+		hexSHA256([]byte(canonicalRequest))}, "\n")
+
+	key := []byte("AWS4" + credentials.SecretAccessKey)
+
+	for _, s := range []string{at.Format(dateFormat), region, service, request} {
+		key = hmacSHA256(key, s)
+	}
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		algorithm, credentials.AccessKeyID, scope, signedHeaders, hex.EncodeToString(hmacSHA256(key, stringToSign))))
+}
+
+func hexSHA256(b []byte) string {
+	h := sha256.Sum256(b)
+
+	return hex.EncodeToString(h[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data)) //nolint:errcheck // hash writes never fail
+
+	return h.Sum(nil)
+}
@@ -0,0 +1,228 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package lease elects the instance running the scheduled jobs among the instances of a service sharing a store.
+package lease
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hyperledger/aries-framework-go/pkg/common/log"
+	"github.com/hyperledger/aries-framework-go/spi/storage"
+)
+
+const (
+	// DefaultTTL is the default time a lease is held for without being renewed.
+	DefaultTTL = 30 * time.Second
+
+	storeName = "lease"
+	// renewals is the number of times a lease is renewed within its TTL.
+	renewals = 3
+)
+
+var logger = log.New("lease")
+
+// record is the lease as stored.
+type record struct {
+	Holder  string    `json:"holder"`
+	Expires time.Time `json:"expires"`
+}
+
+// Option configures the lease.
+type Option func(l *Lease)
+
+// WithHolder sets the ID of the instance acquiring the lease. Defaults to the host name followed by a random UUID.
+func WithHolder(holder string) Option {
+	return func(l *Lease) {
+		l.holder = holder
+	}
+}
+
+// WithTTL sets the time the lease is held for without being renewed, after which another instance takes it over.
+// Defaults to DefaultTTL.
+func WithTTL(ttl time.Duration) Option {
+	return func(l *Lease) {
+		l.ttl = ttl
+	}
+}
+
+// Lease is a named lease stored in a store shared by the instances of a service. The instance holding the lease is
+// the leader, the lease is renewed while the instance runs and taken over by another instance once it expires.
+//
+// The stores don't write conditionally: an instance writes the lease once it expired, then reads the lease back to
+// check that it holds it. Instances taking over the lease at the same time all hold it until their next renewal,
+// when the ones that don't hold the stored lease step down. The lease is thus best-effort: the jobs it guards must be
+// idempotent, so that running them on several instances at once is harmless.
+type Lease struct {
+	store  storage.Store
+	name   string
+	holder string
+	ttl    time.Duration
+
+	mu      sync.Mutex
+	expires time.Time
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+// New returns the lease with the given name, to be started with Start.
+func New(provider storage.Provider, name string, opts ...Option) (*Lease, error) {
+	store, err := provider.OpenStore(storeName)
+	if err != nil {
+		return nil, fmt.Errorf("open lease store: %w", err)
+	}
+
+	l := &Lease{
+		store: store,
+		name:  name,
+		ttl:   DefaultTTL,
+		done:  make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(l)
+	}
+
+	if l.holder == "" {
+		host, _ := os.Hostname() //nolint:errcheck // the UUID identifies the instance on its own
+
+		l.holder = host + "-" + uuid.New().String()
+	}
+
+	return l, nil
+}
+
+// Held reports whether the instance holds the lease, without reading the store.
+func (l *Lease) Held() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return time.Now().Before(l.expires)
+}
+
+// Start acquires the lease once it is free and renews it while it is held, until Stop is called.
+func (l *Lease) Start() {
+	l.renew()
+
+	l.wg.Add(1)
+
+	go func() {
+		defer l.wg.Done()
+
+		ticker := time.NewTicker(l.ttl / renewals)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				l.renew()
+			case <-l.done:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops renewing the lease and releases it if it is held, so that another instance takes it over right away.
+func (l *Lease) Stop() {
+	close(l.done)
+	l.wg.Wait()
+
+	if !l.Held() {
+		return
+	}
+
+	l.mu.Lock()
+	l.expires = time.Time{}
+	l.mu.Unlock()
+
+	current, err := l.get()
+	if err == nil && current != nil && current.Holder == l.holder {
+		err = l.store.Delete(l.name)
+	}
+
+	if err != nil {
+		logger.Warnf("failed to release lease %s: %s", l.name, err)
+	}
+}
+
+func (l *Lease) renew() {
+	held, err := l.Renew(time.Now())
+	if err != nil {
+		logger.Errorf("failed to renew lease %s: %s", l.name, err)
+
+		return
+	}
+
+	if held != l.Held() {
+		logger.Infof("lease %s held by %s: %t", l.name, l.holder, held)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if held {
+		l.expires = time.Now().Add(l.ttl)
+	} else {
+		l.expires = time.Time{}
+	}
+}
+
+// Renew acquires or renews the lease at the given time if it is free, expired or held by the instance, and reports
+// whether the instance holds it.
+func (l *Lease) Renew(at time.Time) (bool, error) {
+	current, err := l.get()
+	if err != nil {
+		return false, err
+	}
+
+	if current != nil && current.Holder != l.holder && at.Before(current.Expires) {
+		return false, nil
+	}
+
+	b, err := json.Marshal(&record{Holder: l.holder, Expires: at.Add(l.ttl)})
+	if err != nil {
+		return false, fmt.Errorf("marshal lease: %w", err)
+	}
+
+	if err = l.store.Put(l.name, b); err != nil {
+		return false, fmt.Errorf("store lease: %w", err)
+	}
+
+	// another instance may have written the lease since it was read
+	current, err = l.get()
+	if err != nil {
+		return false, err
+	}
+
+	return current != nil && current.Holder == l.holder, nil
+}
+
+func (l *Lease) get() (*record, error) {
+	b, err := l.store.Get(l.name)
+	if errors.Is(err, storage.ErrDataNotFound) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("get lease: %w", err)
+	}
+
+	var r record
+
+	if err = json.Unmarshal(b, &r); err != nil {
+		return nil, fmt.Errorf("unmarshal lease: %w", err)
+	}
+
+	return &r, nil
+}
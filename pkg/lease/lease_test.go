@@ -0,0 +1,128 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package lease_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/aries-framework-go/component/storageutil/mem"
+	"github.com/hyperledger/aries-framework-go/pkg/mock/storage"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/ace/pkg/lease"
+)
+
+const testLease = "jobs"
+
+func TestLease_Renew(t *testing.T) {
+	provider := mem.NewProvider()
+
+	a, err := lease.New(provider, testLease, lease.WithHolder("a"), lease.WithTTL(time.Minute))
+	require.NoError(t, err)
+
+	b, err := lease.New(provider, testLease, lease.WithHolder("b"), lease.WithTTL(time.Minute))
+	require.NoError(t, err)
+
+	now := time.Now()
+
+	held, err := a.Renew(now)
+	require.NoError(t, err)
+	require.True(t, held)
+
+	held, err = b.Renew(now.Add(30 * time.Second))
+	require.NoError(t, err)
+	require.False(t, held)
+
+	held, err = a.Renew(now.Add(30 * time.Second))
+	require.NoError(t, err)
+	require.True(t, held)
+
+	// a failed to renew the lease in time
+	held, err = b.Renew(now.Add(2 * time.Minute))
+	require.NoError(t, err)
+	require.True(t, held)
+
+	held, err = a.Renew(now.Add(2 * time.Minute))
+	require.NoError(t, err)
+	require.False(t, held)
+}
+
+func TestLease_StartStop(t *testing.T) {
+	provider := mem.NewProvider()
+
+	a, err := lease.New(provider, testLease)
+	require.NoError(t, err)
+
+	b, err := lease.New(provider, testLease)
+	require.NoError(t, err)
+
+	a.Start()
+	require.True(t, a.Held())
+
+	b.Start()
+	require.False(t, b.Held())
+
+	b.Stop()
+
+	a.Stop()
+	require.False(t, a.Held())
+
+	// the lease is released by the instance stopping
+	held, err := b.Renew(time.Now())
+	require.NoError(t, err)
+	require.True(t, held)
+}
+
+func TestLease_Errors(t *testing.T) {
+	t.Run("Fail to open store", func(t *testing.T) {
+		provider := storage.NewMockStoreProvider()
+		provider.ErrOpenStoreHandle = errors.New("open error")
+
+		_, err := lease.New(provider, testLease)
+		require.EqualError(t, err, "open lease store: open error")
+	})
+
+	t.Run("Fail to get lease", func(t *testing.T) {
+		provider := storage.NewMockStoreProvider()
+		provider.Store.ErrGet = errors.New("get error")
+
+		l, err := lease.New(provider, testLease)
+		require.NoError(t, err)
+
+		_, err = l.Renew(time.Now())
+		require.EqualError(t, err, "get lease: get error")
+
+		l.Start()
+		require.False(t, l.Held())
+		l.Stop()
+	})
+
+	t.Run("Fail to store lease", func(t *testing.T) {
+		provider := storage.NewMockStoreProvider()
+		provider.Store.ErrPut = errors.New("put error")
+
+		l, err := lease.New(provider, testLease)
+		require.NoError(t, err)
+
+		_, err = l.Renew(time.Now())
+		require.EqualError(t, err, "store lease: put error")
+	})
+
+	t.Run("Invalid lease", func(t *testing.T) {
+		provider := storage.NewMockStoreProvider()
+		provider.Store.Store[testLease] = storage.DBEntry{Value: []byte("{")}
+
+		l, err := lease.New(provider, testLease)
+		require.NoError(t, err)
+
+		_, err = l.Renew(time.Now())
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "unmarshal lease")
+	})
+}
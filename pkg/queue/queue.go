@@ -0,0 +1,275 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package queue processes work in the background from a bounded queue persisted in a store.
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hyperledger/aries-framework-go/pkg/common/log"
+	"github.com/hyperledger/aries-framework-go/spi/storage"
+
+	"github.com/trustbloc/ace/pkg/storage/cursor"
+	"github.com/trustbloc/ace/pkg/storage/index"
+)
+
+const (
+	// DefaultCapacity is the default number of jobs the queue holds, including the ones being processed.
+	DefaultCapacity = 1000
+	// DefaultWorkers is the default number of jobs processed at a time.
+	DefaultWorkers = 8
+
+	jobTag = "job"
+)
+
+// ErrFull is returned when the queue holds as many jobs as its capacity.
+var ErrFull = errors.New("queue is full")
+
+var logger = log.New("queue")
+
+// Processor processes the payload of a job.
+type Processor func(ctx context.Context, payload []byte) error
+
+// Option configures the queue.
+type Option func(q *Queue)
+
+// WithCapacity sets the number of jobs the queue holds, including the ones being processed. Defaults to
+// DefaultCapacity.
+func WithCapacity(capacity int) Option {
+	return func(q *Queue) {
+		q.capacity = capacity
+	}
+}
+
+// WithWorkers sets the number of jobs processed at a time. Defaults to DefaultWorkers.
+func WithWorkers(workers int) Option {
+	return func(q *Queue) {
+		q.workers = workers
+	}
+}
+
+// Queue processes jobs in the background, in the order they are enqueued. Jobs are kept in the store until they are
+// processed, so that the jobs left when the service stops are processed once it starts again. A job is processed
+// once: it is removed from the queue whether it succeeds or fails, unless the queue is stopped while it is processed.
+type Queue struct {
+	store    storage.Store
+	process  Processor
+	capacity int
+	workers  int
+	jobs     chan *job
+
+	mu    sync.Mutex
+	depth int
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+type job struct {
+	ID      string    `json:"id"`
+	Payload []byte    `json:"payload"`
+	Created time.Time `json:"created"`
+	done    chan error
+}
+
+// New returns a queue persisted in the named store of the provider, with the jobs left in the store queued first.
+func New(provider storage.Provider, name string, process Processor, opts ...Option) (*Queue, error) {
+	store, err := index.OpenStore(provider, &index.Declaration{Store: name, TagNames: []string{jobTag}})
+	if err != nil {
+		return nil, fmt.Errorf("open queue store: %w", err)
+	}
+
+	q := &Queue{
+		store:    store,
+		process:  process,
+		capacity: DefaultCapacity,
+		workers:  DefaultWorkers,
+	}
+
+	for _, opt := range opts {
+		opt(q)
+	}
+
+	jobs, err := q.load()
+	if err != nil {
+		return nil, err
+	}
+
+	// jobs left by a previous run are queued even if the capacity has been lowered since
+	size := q.capacity
+	if len(jobs) > size {
+		size = len(jobs)
+	}
+
+	q.jobs = make(chan *job, size)
+	q.depth = len(jobs)
+
+	for _, j := range jobs {
+		q.jobs <- j
+	}
+
+	return q, nil
+}
+
+// Start starts processing jobs until Stop is called.
+func (q *Queue) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	q.cancel = cancel
+
+	for i := 0; i < q.workers; i++ {
+		q.wg.Add(1)
+
+		go func() {
+			defer q.wg.Done()
+
+			q.work(ctx)
+		}()
+	}
+}
+
+// Stop stops processing jobs and waits for the jobs being processed to return. Their processing is canceled and they
+// are kept in the store, to be processed again once a queue is created for the store.
+func (q *Queue) Stop() {
+	if q.cancel != nil {
+		q.cancel()
+	}
+
+	q.wg.Wait()
+}
+
+// Enqueue stores the payload as a job and queues it. The returned channel receives the error the job is processed
+// with, which is nil if it succeeds. ErrFull is returned if the queue holds as many jobs as its capacity.
+func (q *Queue) Enqueue(payload []byte) (<-chan error, error) {
+	if !q.reserve() {
+		return nil, ErrFull
+	}
+
+	j := &job{
+		ID:      uuid.New().String(),
+		Payload: payload,
+		Created: time.Now().UTC(),
+		done:    make(chan error, 1),
+	}
+
+	b, err := json.Marshal(j)
+	if err != nil {
+		q.release()
+
+		return nil, fmt.Errorf("marshal job: %w", err)
+	}
+
+	if err = q.store.Put(j.ID, b, storage.Tag{Name: jobTag}); err != nil {
+		q.release()
+
+		return nil, fmt.Errorf("store job: %w", err)
+	}
+
+	q.jobs <- j
+
+	return j.done, nil
+}
+
+// Depth returns the number of jobs in the queue, including the ones being processed.
+func (q *Queue) Depth() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.depth
+}
+
+func (q *Queue) work(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case j := <-q.jobs:
+			err := q.process(ctx, j.Payload)
+			if ctx.Err() != nil {
+				j.finish(ctx.Err())
+
+				return
+			}
+
+			if err != nil {
+				logger.Warnf("failed to process job %s: %s", j.ID, err)
+			}
+
+			if e := q.store.Delete(j.ID); e != nil {
+				logger.Errorf("failed to delete processed job %s: %s", j.ID, e)
+			}
+
+			q.release()
+			j.finish(err)
+		}
+	}
+}
+
+func (q *Queue) reserve() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.depth >= q.capacity {
+		return false
+	}
+
+	q.depth++
+
+	return true
+}
+
+func (q *Queue) release() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.depth--
+}
+
+// load returns the jobs of the store in the order they were enqueued.
+func (q *Queue) load() ([]*job, error) {
+	c, err := cursor.New(q.store, jobTag, 0)
+	if err != nil {
+		return nil, fmt.Errorf("query jobs: %w", err)
+	}
+
+	var jobs []*job
+
+	err = cursor.Iterate(c, func(values [][]byte) error {
+		for _, v := range values {
+			var j job
+
+			if e := json.Unmarshal(v, &j); e != nil {
+				return fmt.Errorf("unmarshal job: %w", e)
+			}
+
+			jobs = append(jobs, &j)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("load jobs: %w", err)
+	}
+
+	sort.SliceStable(jobs, func(i, k int) bool {
+		return jobs[i].Created.Before(jobs[k].Created)
+	})
+
+	return jobs, nil
+}
+
+func (j *job) finish(err error) {
+	if j.done != nil {
+		j.done <- err
+	}
+}
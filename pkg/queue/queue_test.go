@@ -0,0 +1,169 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package queue_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/aries-framework-go/component/storageutil/mem"
+	mockstorage "github.com/hyperledger/aries-framework-go/pkg/mock/storage"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/ace/pkg/queue"
+)
+
+func TestQueue_Enqueue(t *testing.T) {
+	t.Run("Processes jobs", func(t *testing.T) {
+		var (
+			mu        sync.Mutex
+			processed []string
+		)
+
+		q, err := queue.New(mem.NewProvider(), "queue", func(_ context.Context, payload []byte) error {
+			mu.Lock()
+			defer mu.Unlock()
+
+			processed = append(processed, string(payload))
+
+			if string(payload) == "fail" {
+				return errors.New("process error")
+			}
+
+			return nil
+		})
+		require.NoError(t, err)
+
+		q.Start()
+		defer q.Stop()
+
+		done, err := q.Enqueue([]byte("job"))
+		require.NoError(t, err)
+		require.NoError(t, <-done)
+
+		done, err = q.Enqueue([]byte("fail"))
+		require.NoError(t, err)
+		require.EqualError(t, <-done, "process error")
+
+		require.Equal(t, []string{"job", "fail"}, processed)
+		require.Zero(t, q.Depth())
+	})
+
+	t.Run("Rejects jobs once full", func(t *testing.T) {
+		release := make(chan struct{})
+
+		q, err := queue.New(mem.NewProvider(), "queue", func(context.Context, []byte) error {
+			<-release
+
+			return nil
+		}, queue.WithCapacity(2), queue.WithWorkers(1))
+		require.NoError(t, err)
+
+		q.Start()
+		defer q.Stop()
+
+		var dones []<-chan error
+
+		for i := 0; i < 2; i++ {
+			done, e := q.Enqueue([]byte("job"))
+			require.NoError(t, e)
+
+			dones = append(dones, done)
+		}
+
+		require.Equal(t, 2, q.Depth())
+
+		_, err = q.Enqueue([]byte("job"))
+		require.ErrorIs(t, err, queue.ErrFull)
+
+		close(release)
+
+		for _, done := range dones {
+			require.NoError(t, <-done)
+		}
+
+		require.Zero(t, q.Depth())
+	})
+
+	t.Run("Fails to store job", func(t *testing.T) {
+		provider := mockstorage.NewMockStoreProvider()
+		provider.Store.ErrPut = errors.New("put error")
+
+		q, err := queue.New(provider, "queue", func(context.Context, []byte) error { return nil })
+		require.NoError(t, err)
+
+		_, err = q.Enqueue([]byte("job"))
+		require.EqualError(t, err, "store job: put error")
+		require.Zero(t, q.Depth())
+	})
+}
+
+func TestNew(t *testing.T) {
+	provider := mockstorage.NewMockStoreProvider()
+	provider.Store.ErrQuery = errors.New("query error")
+
+	_, err := queue.New(provider, "queue", func(context.Context, []byte) error { return nil })
+	require.EqualError(t, err, "query jobs: query job: query error")
+}
+
+func TestQueue_Restart(t *testing.T) {
+	provider := mem.NewProvider()
+
+	started := make(chan struct{})
+
+	q, err := queue.New(provider, "queue", func(ctx context.Context, _ []byte) error {
+		close(started)
+		<-ctx.Done()
+
+		return ctx.Err()
+	}, queue.WithWorkers(1))
+	require.NoError(t, err)
+
+	q.Start()
+
+	done, err := q.Enqueue([]byte("interrupted"))
+	require.NoError(t, err)
+
+	_, err = q.Enqueue([]byte("pending"))
+	require.NoError(t, err)
+
+	<-started
+	q.Stop()
+
+	require.ErrorIs(t, <-done, context.Canceled)
+
+	processed := make(chan string, 2)
+
+	q, err = queue.New(provider, "queue", func(_ context.Context, payload []byte) error {
+		processed <- string(payload)
+
+		return nil
+	}, queue.WithCapacity(1), queue.WithWorkers(1))
+	require.NoError(t, err)
+
+	require.Equal(t, 2, q.Depth())
+
+	_, err = q.Enqueue([]byte("job"))
+	require.ErrorIs(t, err, queue.ErrFull)
+
+	q.Start()
+	defer q.Stop()
+
+	for _, payload := range []string{"interrupted", "pending"} {
+		select {
+		case p := <-processed:
+			require.Equal(t, payload, p)
+		case <-time.After(time.Second):
+			t.Fatalf("job %s was not processed", payload)
+		}
+	}
+
+	require.Eventually(t, func() bool { return q.Depth() == 0 }, time.Second, time.Millisecond)
+}
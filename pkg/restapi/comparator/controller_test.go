@@ -55,5 +55,5 @@ func TestController_GetOperations(t *testing.T) {
 
 	ops := controller.GetOperations()
 
-	require.Equal(t, 4, len(ops))
+	require.Equal(t, 5, len(ops))
 }
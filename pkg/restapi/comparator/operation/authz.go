@@ -7,6 +7,7 @@ SPDX-License-Identifier: Apache-2.0
 package operation
 
 import (
+	"context"
 	"crypto/ed25519"
 	"encoding/json"
 	"fmt"
@@ -26,8 +27,9 @@ import (
 )
 
 // HandleAuthz handles a CreateAuthzReq.
-func (o *Operation) HandleAuthz(w http.ResponseWriter, authz *models.Authorization) { //nolint: funlen
-	docMeta, err := o.vaultClient.GetDocMetaData(authz.Scope.VaultID, *authz.Scope.DocID)
+func (o *Operation) HandleAuthz( //nolint: funlen
+	ctx context.Context, w http.ResponseWriter, authz *models.Authorization) {
+	docMeta, err := o.vaultClient.GetDocMetaData(ctx, authz.Scope.VaultID, *authz.Scope.DocID)
 	if err != nil {
 		respondErrorf(w, http.StatusInternalServerError, "failed to get doc meta: %s", err.Error())
 
@@ -54,7 +56,7 @@ func (o *Operation) HandleAuthz(w http.ResponseWriter, authz *models.Authorizati
 	docID := parts[len(parts)-1]
 
 	response, err := o.cshClient.PostHubstoreProfilesProfileIDQueries(
-		operations.NewPostHubstoreProfilesProfileIDQueriesParams().
+		operations.NewPostHubstoreProfilesProfileIDQueriesParamsWithContext(ctx).
 			WithTimeout(requestTimeout).
 			WithProfileID(o.cshProfile.ID).
 			WithRequest(&cshclientmodels.DocQuery{
@@ -73,7 +75,7 @@ func (o *Operation) HandleAuthz(w http.ResponseWriter, authz *models.Authorizati
 				},
 			}))
 	if err != nil {
-		respondErrorf(w, http.StatusInternalServerError, "failed to create query: %s", err.Error())
+		respondErrorf(w, hubErrorStatus(err), "failed to create query: %s", err.Error())
 
 		return
 	}
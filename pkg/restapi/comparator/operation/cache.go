@@ -0,0 +1,149 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package operation
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	cshclientmodels "github.com/trustbloc/ace/pkg/client/csh/models"
+	"github.com/trustbloc/ace/pkg/restapi/comparator/operation/models"
+	"github.com/trustbloc/ace/pkg/restapi/vault"
+)
+
+// resultCache caches comparison results for a limited time, evicting the least recently used results once it holds
+// its maximum number of results.
+type resultCache struct {
+	mutex   sync.Mutex
+	ttl     time.Duration
+	size    int
+	entries map[string]*list.Element
+	lru     *list.List
+}
+
+type cacheEntry struct {
+	key    string
+	result models.ComparisonResult
+	// zcaps are the zcaps invoked by the comparison, whose revocation is checked before the result is served.
+	zcaps   []string
+	expires time.Time
+}
+
+func newResultCache(ttl time.Duration, size int) *resultCache {
+	return &resultCache{
+		ttl:     ttl,
+		size:    size,
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+// get returns the cached result for the key, with the zcaps invoked by the comparison, if it has not expired.
+func (c *resultCache) get(key string) (models.ComparisonResult, []string, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return models.ComparisonResult{}, nil, false
+	}
+
+	entry := element.Value.(*cacheEntry) //nolint:forcetypeassert
+
+	if time.Now().After(entry.expires) {
+		c.lru.Remove(element)
+		delete(c.entries, key)
+
+		return models.ComparisonResult{}, nil, false
+	}
+
+	c.lru.MoveToFront(element)
+
+	return entry.result, entry.zcaps, true
+}
+
+// put caches the result for the key, with the zcaps invoked by the comparison, and evicts the least recently used
+// results beyond the size of the cache.
+func (c *resultCache) put(key string, result models.ComparisonResult, zcaps []string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	entry := &cacheEntry{key: key, result: result, zcaps: zcaps, expires: time.Now().Add(c.ttl)}
+
+	if element, ok := c.entries[key]; ok {
+		element.Value = entry
+		c.lru.MoveToFront(element)
+
+		return
+	}
+
+	c.entries[key] = c.lru.PushFront(entry)
+
+	for c.lru.Len() > c.size {
+		oldest := c.lru.Back()
+
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key) //nolint:forcetypeassert
+	}
+}
+
+// remove removes the result for the key from the cache.
+func (c *resultCache) remove(key string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		c.lru.Remove(element)
+		delete(c.entries, key)
+	}
+}
+
+// comparisonKey derives the cache key of a comparison from the operator sent to the Confidential Storage Hub and
+// the metadata of the compared documents. The operator includes the document references, attribute paths and
+// authorization tokens, while the metadata digests and encryption key URIs change when the documents are updated
+// or their keys are rotated.
+func comparisonKey(op cshOperator, docs []*vault.DocumentMetadata) (string, error) {
+	raw, err := json.Marshal(struct {
+		Op   cshOperator               `json:"op"`
+		Docs []*vault.DocumentMetadata `json:"docs"`
+	}{
+		Op:   op,
+		Docs: docs,
+	})
+	if err != nil {
+		return "", fmt.Errorf("marshal comparison: %w", err)
+	}
+
+	sum := sha256.Sum256(raw)
+
+	return base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
+
+// queryZCAPs returns the zcaps of the upstream authorizations of the document queries.
+func queryZCAPs(queries []cshclientmodels.Query) []string {
+	var zcaps []string
+
+	for _, query := range queries {
+		q, ok := query.(*cshclientmodels.DocQuery)
+		if !ok || q.UpstreamAuth == nil {
+			continue
+		}
+
+		for _, auth := range []*cshclientmodels.UpstreamAuthorization{q.UpstreamAuth.Edv, q.UpstreamAuth.Kms} {
+			if auth != nil && auth.Zcap != "" {
+				zcaps = append(zcaps, auth.Zcap)
+			}
+		}
+	}
+
+	return zcaps
+}
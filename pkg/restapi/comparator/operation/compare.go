@@ -7,6 +7,7 @@ SPDX-License-Identifier: Apache-2.0
 package operation
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -17,24 +18,167 @@ import (
 	"github.com/trustbloc/ace/pkg/client/csh/client/operations"
 	cshclientmodels "github.com/trustbloc/ace/pkg/client/csh/models"
 	"github.com/trustbloc/ace/pkg/restapi/comparator/operation/models"
+	"github.com/trustbloc/ace/pkg/restapi/vault"
 )
 
+// defaultResourceAttrPath is the JSONPath of the protected value within the document stored by the Gatekeeper.
+const defaultResourceAttrPath = "$.credentialSubject.data"
+
+// HandleResourceComparison handles a ResourceComparison by comparing the protected resources using the EqOp operator.
+// Resources without auth tokens are compared without them.
+func (o *Operation) HandleResourceComparison(
+	ctx context.Context, w http.ResponseWriter, rc *models.ResourceComparison) {
+	queries := make([]models.Query, 0, len(rc.Resources))
+
+	for i, resource := range rc.Resources {
+		if resource == nil || resource.DID == nil || resource.DocID == nil {
+			respondErrorf(w, http.StatusBadRequest, "bad request: resources.%d must have a did and a docID", i)
+
+			return
+		}
+
+		attrPath := resource.AttrPath
+		if attrPath == "" {
+			attrPath = defaultResourceAttrPath
+		}
+
+		tokens := &models.DocQueryAO1AuthTokens{}
+
+		if resource.AuthTokens != nil {
+			tokens.Edv = resource.AuthTokens.Edv
+			tokens.Kms = resource.AuthTokens.Kms
+		}
+
+		queries = append(queries, &models.DocQuery{
+			VaultID:     resource.DID,
+			DocID:       resource.DocID,
+			DocAttrPath: attrPath,
+			AuthTokens:  tokens,
+		})
+	}
+
+	op := &models.EqOp{Match: rc.Match}
+	op.SetArgs(queries)
+
+	o.HandleEqOp(ctx, w, op)
+}
+
 // HandleEqOp handles a ComparisonRequest using the EqOp operator.
-func (o *Operation) HandleEqOp(w http.ResponseWriter, op *models.EqOp) { //nolint: funlen
+func (o *Operation) HandleEqOp(ctx context.Context, w http.ResponseWriter, op *models.EqOp) {
+	o.forwardOp(ctx, w, op.Args(), &cshclientmodels.EqOp{Match: op.Match, Proof: op.Proof})
+}
+
+// HandleFuzzyOp handles a ComparisonRequest using the FuzzyOp operator.
+func (o *Operation) HandleFuzzyOp(ctx context.Context, w http.ResponseWriter, op *models.FuzzyOp) {
+	o.forwardOp(ctx, w, op.Args(), &cshclientmodels.FuzzyOp{
+		Algorithm: op.Algorithm,
+		Threshold: op.Threshold,
+	})
+}
+
+// HandleGtOp handles a ComparisonRequest using the GtOp operator.
+func (o *Operation) HandleGtOp(ctx context.Context, w http.ResponseWriter, op *models.GtOp) {
+	o.forwardOp(ctx, w, op.Args(), &cshclientmodels.GtOp{})
+}
+
+// HandleLtOp handles a ComparisonRequest using the LtOp operator.
+func (o *Operation) HandleLtOp(ctx context.Context, w http.ResponseWriter, op *models.LtOp) {
+	o.forwardOp(ctx, w, op.Args(), &cshclientmodels.LtOp{})
+}
+
+// HandleRangeOp handles a ComparisonRequest using the RangeOp operator.
+func (o *Operation) HandleRangeOp(ctx context.Context, w http.ResponseWriter, op *models.RangeOp) {
+	o.forwardOp(ctx, w, op.Args(), &cshclientmodels.RangeOp{})
+}
+
+type cshOperator interface {
+	cshclientmodels.Operator
+	SetArgs([]cshclientmodels.Query)
+}
+
+// forwardOp translates the queries to the hub's queries and executes the comparison remotely with the given operator.
+// Results are served from the cache, if enabled, as long as none of the compared documents have changed and the hub
+// confirms that none of the zcaps invoked by the comparison are revoked.
+func (o *Operation) forwardOp(ctx context.Context, w http.ResponseWriter, args []models.Query, op cshOperator) {
+	queries, docs, proceed := o.cshQueries(ctx, w, args)
+	if !proceed {
+		return
+	}
+
+	op.SetArgs(queries)
+
+	var cacheKey string
+
+	if o.resultCache != nil && docs != nil {
+		var err error
+
+		cacheKey, err = comparisonKey(op, docs)
+		if err != nil {
+			respondErrorf(w, http.StatusInternalServerError, "failed to compute cache key: %s", err.Error())
+
+			return
+		}
+
+		if result, zcaps, ok := o.resultCache.get(cacheKey); ok {
+			if o.notRevoked(ctx, zcaps) {
+				respondComparison(w, result)
+
+				return
+			}
+
+			o.resultCache.remove(cacheKey)
+		}
+	}
+
+	result, err := o.postComparison(ctx, op)
+	if err != nil {
+		respondErrorf(w, hubErrorStatus(err), "failed to execute comparison: %s", err)
+
+		return
+	}
+
+	if cacheKey != "" {
+		o.resultCache.put(cacheKey, *result, queryZCAPs(queries))
+	}
+
+	respondComparison(w, *result)
+}
+
+// cshQueries translates the queries to the hub's queries. It also returns the metadata of the queried documents,
+// or nil if the comparison cannot be cached because the state of some of the documents is unknown.
+func (o *Operation) cshQueries(ctx context.Context, w http.ResponseWriter, //nolint: funlen,gocyclo
+	args []models.Query) ([]cshclientmodels.Query, []*vault.DocumentMetadata, bool) {
 	queries := make([]cshclientmodels.Query, 0)
+	docs := make([]*vault.DocumentMetadata, 0)
+	cacheable := true
 
-	for i := range op.Args() {
-		query := op.Args()[i]
+	for i := range args {
+		query := args[i]
 
 		switch q := query.(type) {
 		case *models.DocQuery:
-			docMeta, err := o.vaultClient.GetDocMetaData(*q.VaultID, *q.DocID)
+			if q.VaultID == nil || q.DocID == nil {
+				respondErrorf(w, http.StatusBadRequest, "bad request: query %d must have a vaultID and a docID", i)
+
+				return nil, nil, false
+			}
+
+			edvToken, kmsToken := "", ""
+
+			if q.AuthTokens != nil {
+				edvToken, kmsToken = q.AuthTokens.Edv, q.AuthTokens.Kms
+			}
+
+			docMeta, err := o.vaultClient.GetDocMetaData(ctx, *q.VaultID, *q.DocID)
 			if err != nil {
 				respondErrorf(w, http.StatusInternalServerError, "failed to get doc meta: %s", err.Error())
 
-				return
+				return nil, nil, false
 			}
 
+			docs = append(docs, docMeta)
+			cacheable = cacheable && docMeta.Digest != ""
+
 			parts := strings.Split(docMeta.URI, "/")
 
 			vaultID := parts[len(parts)-3]
@@ -44,14 +188,14 @@ func (o *Operation) HandleEqOp(w http.ResponseWriter, op *models.EqOp) { //nolin
 			if err != nil {
 				respondErrorf(w, http.StatusInternalServerError, "failed to parse url: %s", err.Error())
 
-				return
+				return nil, nil, false
 			}
 
 			edvURL, err := url.Parse(docMeta.URI)
 			if err != nil {
 				respondErrorf(w, http.StatusInternalServerError, "failed to parse url: %s", err.Error())
 
-				return
+				return nil, nil, false
 			}
 
 			queries = append(
@@ -63,49 +207,111 @@ func (o *Operation) HandleEqOp(w http.ResponseWriter, op *models.EqOp) { //nolin
 					UpstreamAuth: &cshclientmodels.DocQueryAO1UpstreamAuth{
 						Edv: &cshclientmodels.UpstreamAuthorization{
 							BaseURL: fmt.Sprintf("%s://%s/%s", edvURL.Scheme, edvURL.Host, parts[3]),
-							Zcap:    q.AuthTokens.Edv,
+							Zcap:    edvToken,
 						},
 						Kms: &cshclientmodels.UpstreamAuthorization{
 							BaseURL: fmt.Sprintf("%s://%s", kmsURL.Scheme, kmsURL.Host),
-							Zcap:    q.AuthTokens.Kms,
+							Zcap:    kmsToken,
 						},
 					},
 				},
 			)
 		case *models.AuthorizedQuery:
+			if q.AuthToken == nil {
+				respondErrorf(w, http.StatusBadRequest, "bad request: query %d must have an authToken", i)
+
+				return nil, nil, false
+			}
+
 			orgZCAP, err := zcapld.DecompressZCAP(*q.AuthToken)
 			if err != nil {
 				respondErrorf(w, http.StatusInternalServerError, "failed to parse org zcap: %s", err.Error())
 
-				return
+				return nil, nil, false
 			}
 
 			queryPath := strings.Split(orgZCAP.InvocationTarget.ID, "/queries/")
 
 			queries = append(queries, &cshclientmodels.RefQuery{Ref: &queryPath[1]})
+			// the referenced document is resolved by the hub, so its changes cannot be detected
+			cacheable = false
+		case *models.ValueQuery:
+			queries = append(queries, &cshclientmodels.ValueQuery{Value: q.Value})
 		}
 	}
 
-	cshOP := &cshclientmodels.EqOp{}
-	cshOP.SetArgs(queries)
+	if !cacheable {
+		docs = nil
+	}
+
+	return queries, docs, true
+}
+
+// notRevoked tells if the hub confirms that none of the zcaps, nor any zcap of their capability chains, are revoked.
+func (o *Operation) notRevoked(ctx context.Context, zcaps []string) bool {
+	if len(zcaps) == 0 {
+		return true
+	}
+
+	response, err := o.cshClient.PostRevocationsStatus(
+		operations.NewPostRevocationsStatusParamsWithContext(ctx).
+			WithTimeout(requestTimeout).
+			WithRequest(&cshclientmodels.RevocationStatusRequest{Zcaps: zcaps}),
+	)
+	if err != nil {
+		logger.Warnf("failed to check revocation of cached comparison zcaps: %s", err)
+
+		return false
+	}
+
+	return !response.Payload.Revoked
+}
 
+func (o *Operation) postComparison(ctx context.Context, op cshclientmodels.Operator) (*models.ComparisonResult, error) {
 	request := &cshclientmodels.ComparisonRequest{}
-	request.SetOp(cshOP)
+	request.SetOp(op)
 
 	response, err := o.cshClient.PostCompare(
-		operations.NewPostCompareParams().
+		operations.NewPostCompareParamsWithContext(ctx).
 			WithTimeout(requestTimeout).
 			WithRequest(request),
 	)
 	if err != nil {
-		respondErrorf(w, http.StatusInternalServerError, "failed to execute comparison: %s", err)
+		return nil, err
+	}
 
-		return
+	return &models.ComparisonResult{
+		Result: response.Payload.Result,
+		Score:  response.Payload.Score,
+		Proof:  equalityProof(response.Payload.Proof),
+	}, nil
+}
+
+// equalityProof relays the zero-knowledge proof produced by the Confidential Storage Hub.
+func equalityProof(p *cshclientmodels.EqualityProof) *models.EqualityProof {
+	if p == nil {
+		return nil
 	}
 
+	proof := &models.EqualityProof{
+		Scheme:              p.Scheme,
+		Commitments:         p.Commitments,
+		Proofs:              make([]*models.SchnorrProof, len(p.Proofs)),
+		Signatures:          p.Signatures,
+		VerificationMethods: p.VerificationMethods,
+	}
+
+	for i := range p.Proofs {
+		proof.Proofs[i] = &models.SchnorrProof{T: p.Proofs[i].T, S: p.Proofs[i].S}
+	}
+
+	return proof
+}
+
+func respondComparison(w http.ResponseWriter, result models.ComparisonResult) {
 	headers := map[string]string{
 		"Content-Type": "application/json",
 	}
 
-	respond(w, http.StatusOK, headers, models.ComparisonResult{Result: response.Payload.Result})
+	respond(w, http.StatusOK, headers, result)
 }
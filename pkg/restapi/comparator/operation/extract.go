@@ -50,7 +50,7 @@ func (o *Operation) HandleExtract(w http.ResponseWriter, extract *models.Extract
 			WithRequest(queries),
 	)
 	if err != nil {
-		respondErrorf(w, http.StatusInternalServerError, "failed to execute extract: %s", err)
+		respondErrorf(w, hubErrorStatus(err), "failed to execute extract: %s", err)
 
 		return
 	}
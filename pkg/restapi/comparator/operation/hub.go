@@ -0,0 +1,248 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package operation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/go-openapi/runtime"
+
+	"github.com/trustbloc/ace/pkg/client/csh/client/operations"
+)
+
+const hubRetryInterval = 500 * time.Millisecond
+
+var (
+	// ErrHubUnavailable is returned when the Confidential Storage Hub can't be reached, times out, answers with 429,
+	// 502, 503 or 504, or is skipped while its circuit is open.
+	ErrHubUnavailable = errors.New("confidential storage hub unavailable")
+	// ErrComparisonFailed is returned when the Confidential Storage Hub fails to run the comparison.
+	ErrComparisonFailed = errors.New("comparison failed")
+)
+
+// hubClient calls the Confidential Storage Hub with a timeout per call, retrying the calls that don't create anything
+// while the hub is unavailable. After the failure threshold of consecutive calls failing with the hub unavailable,
+// the calls fail fast for the cooldown.
+type hubClient struct {
+	client           cshClient
+	timeout          time.Duration
+	retries          uint64
+	failureThreshold int
+	cooldown         time.Duration
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+}
+
+func newHubClient(client cshClient, cfg *Config) *hubClient {
+	timeout := cfg.CSHTimeout
+	if timeout == 0 {
+		timeout = requestTimeout
+	}
+
+	return &hubClient{
+		client:           client,
+		timeout:          timeout,
+		retries:          cfg.CSHRetries,
+		failureThreshold: cfg.CSHFailureThreshold,
+		cooldown:         cfg.CSHCooldown,
+	}
+}
+
+// hubErrorStatus is the status of the response to a request failing with the error of a hub call.
+func hubErrorStatus(err error) int {
+	if errors.Is(err, ErrHubUnavailable) {
+		return http.StatusServiceUnavailable
+	}
+
+	return http.StatusInternalServerError
+}
+
+func (h *hubClient) PostCompare(params *operations.PostCompareParams,
+	opts ...operations.ClientOption) (*operations.PostCompareOK, error) {
+	var resp *operations.PostCompareOK
+
+	err := h.call(params.Context, true, func(ctx context.Context) error {
+		var err error
+
+		params.SetContext(ctx)
+
+		resp, err = h.client.PostCompare(params, opts...)
+
+		return err
+	})
+	if err != nil && !errors.Is(err, ErrHubUnavailable) {
+		return nil, fmt.Errorf("%w: %s", ErrComparisonFailed, err)
+	}
+
+	return resp, err
+}
+
+func (h *hubClient) PostHubstoreProfiles(params *operations.PostHubstoreProfilesParams,
+	opts ...operations.ClientOption) (*operations.PostHubstoreProfilesCreated, error) {
+	var resp *operations.PostHubstoreProfilesCreated
+
+	err := h.call(params.Context, false, func(ctx context.Context) error {
+		var err error
+
+		params.SetContext(ctx)
+
+		resp, err = h.client.PostHubstoreProfiles(params, opts...)
+
+		return err
+	})
+
+	return resp, err
+}
+
+func (h *hubClient) PostHubstoreProfilesProfileIDQueries(params *operations.PostHubstoreProfilesProfileIDQueriesParams,
+	opts ...operations.ClientOption) (*operations.PostHubstoreProfilesProfileIDQueriesCreated, error) {
+	var resp *operations.PostHubstoreProfilesProfileIDQueriesCreated
+
+	err := h.call(params.Context, false, func(ctx context.Context) error {
+		var err error
+
+		params.SetContext(ctx)
+
+		resp, err = h.client.PostHubstoreProfilesProfileIDQueries(params, opts...)
+
+		return err
+	})
+
+	return resp, err
+}
+
+func (h *hubClient) PostExtract(params *operations.PostExtractParams,
+	opts ...operations.ClientOption) (*operations.PostExtractOK, error) {
+	var resp *operations.PostExtractOK
+
+	err := h.call(params.Context, true, func(ctx context.Context) error {
+		var err error
+
+		params.SetContext(ctx)
+
+		resp, err = h.client.PostExtract(params, opts...)
+
+		return err
+	})
+
+	return resp, err
+}
+
+func (h *hubClient) PostRevocationsStatus(params *operations.PostRevocationsStatusParams,
+	opts ...operations.ClientOption) (*operations.PostRevocationsStatusOK, error) {
+	var resp *operations.PostRevocationsStatusOK
+
+	err := h.call(params.Context, true, func(ctx context.Context) error {
+		var err error
+
+		params.SetContext(ctx)
+
+		resp, err = h.client.PostRevocationsStatus(params, opts...)
+
+		return err
+	})
+
+	return resp, err
+}
+
+// call runs the call with the timeout, retried while the hub is unavailable if it is idempotent. The timeout is set
+// on the context of the call, the one of the parameters only applies to calls without a context.
+func (h *hubClient) call(ctx context.Context, idempotent bool, call func(ctx context.Context) error) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	var retry backoff.BackOff = &backoff.StopBackOff{}
+
+	if idempotent {
+		b := backoff.NewExponentialBackOff()
+		b.InitialInterval = hubRetryInterval
+
+		retry = backoff.WithMaxRetries(b, h.retries)
+	}
+
+	return backoff.RetryNotify(func() error {
+		if !h.closed() {
+			return backoff.Permanent(fmt.Errorf("%w: circuit open", ErrHubUnavailable))
+		}
+
+		callCtx, cancel := context.WithTimeout(ctx, h.timeout)
+		defer cancel()
+
+		err := call(callCtx)
+		if err == nil {
+			h.succeeded()
+
+			return nil
+		}
+
+		if ctx.Err() != nil || !unavailable(err) {
+			return backoff.Permanent(err)
+		}
+
+		h.failed()
+
+		return fmt.Errorf("%w: %s", ErrHubUnavailable, err)
+	}, backoff.WithContext(retry, ctx), func(err error, d time.Duration) {
+		logger.Warnf("confidential storage hub call failed, retrying in %s: %s", d, err)
+	})
+}
+
+func (h *hubClient) closed() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.failureThreshold == 0 || h.failures < h.failureThreshold || !time.Now().Before(h.openUntil)
+}
+
+func (h *hubClient) succeeded() {
+	h.mu.Lock()
+	h.failures = 0
+	h.mu.Unlock()
+}
+
+func (h *hubClient) failed() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.failures++
+
+	if h.failureThreshold > 0 && h.failures >= h.failureThreshold {
+		logger.Warnf("confidential storage hub unavailable, failing calls for %s", h.cooldown)
+
+		h.openUntil = time.Now().Add(h.cooldown)
+	}
+}
+
+// unavailable tells whether the call failed without the hub answering, or with the hub answering that it is
+// unavailable.
+func unavailable(err error) bool {
+	var apiErr *runtime.APIError
+
+	if errors.As(err, &apiErr) {
+		switch apiErr.Code {
+		case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout:
+			return true
+		default:
+			return false
+		}
+	}
+
+	var urlErr *url.Error
+
+	return errors.As(err, &urlErr) || errors.Is(err, context.DeadlineExceeded)
+}
@@ -29,6 +29,16 @@ import (
 // swagger:model EqOp
 type EqOp struct {
 	argsField []Query
+
+	// How the documents must match. `all` requires all documents to be equal and `any` requires at least two of the
+	// documents to be equal. Defaults to `all`.
+	//
+	// Enum: [all any]
+	Match string `json:"match,omitempty"`
+
+	// Return a zero-knowledge proof of equality with the result. Requires `match` to be `all`, and arguments
+	// querying whole documents, which are committed to when they are protected.
+	Proof bool `json:"proof,omitempty"`
 }
 
 // Type gets the type of this subtype
@@ -54,6 +64,16 @@ func (m *EqOp) SetArgs(val []Query) {
 func (m *EqOp) UnmarshalJSON(raw []byte) error {
 	var data struct {
 		Args json.RawMessage `json:"args"`
+
+		// How the documents must match. `all` requires all documents to be equal and `any` requires at least two of the
+		// documents to be equal. Defaults to `all`.
+		//
+		// Enum: [all any]
+		Match string `json:"match,omitempty"`
+
+		// Return a zero-knowledge proof of equality with the result. Requires `match` to be `all`, and arguments
+		// querying whole documents, which are committed to when they are protected.
+		Proof bool `json:"proof,omitempty"`
 	}
 	buf := bytes.NewBuffer(raw)
 	dec := json.NewDecoder(buf)
@@ -90,6 +110,10 @@ func (m *EqOp) UnmarshalJSON(raw []byte) error {
 
 	result.argsField = allOfArgs
 
+	result.Match = data.Match
+
+	result.Proof = data.Proof
+
 	*m = result
 
 	return nil
@@ -100,7 +124,22 @@ func (m EqOp) MarshalJSON() ([]byte, error) {
 	var b1, b2, b3 []byte
 	var err error
 	b1, err = json.Marshal(struct {
-	}{})
+
+		// How the documents must match. `all` requires all documents to be equal and `any` requires at least two of the
+		// documents to be equal. Defaults to `all`.
+		//
+		// Enum: [all any]
+		Match string `json:"match,omitempty"`
+
+		// Return a zero-knowledge proof of equality with the result. Requires `match` to be `all`, and arguments
+		// querying whole documents, which are committed to when they are protected.
+		Proof bool `json:"proof,omitempty"`
+	}{
+
+		Match: m.Match,
+
+		Proof: m.Proof,
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -129,6 +168,10 @@ func (m *EqOp) Validate(formats strfmt.Registry) error {
 		res = append(res, err)
 	}
 
+	if err := m.validateMatch(formats); err != nil {
+		res = append(res, err)
+	}
+
 	if len(res) > 0 {
 		return errors.CompositeValidationError(res...)
 	}
@@ -163,6 +206,48 @@ func (m *EqOp) validateArgs(formats strfmt.Registry) error {
 	return nil
 }
 
+var eqOpTypeMatchPropEnum []interface{}
+
+func init() {
+	var res []string
+	if err := json.Unmarshal([]byte(`["all","any"]`), &res); err != nil {
+		panic(err)
+	}
+	for _, v := range res {
+		eqOpTypeMatchPropEnum = append(eqOpTypeMatchPropEnum, v)
+	}
+}
+
+const (
+
+	// EqOpMatchAll captures enum value "all"
+	EqOpMatchAll string = "all"
+
+	// EqOpMatchAny captures enum value "any"
+	EqOpMatchAny string = "any"
+)
+
+// prop value enum
+func (m *EqOp) validateMatchEnum(path, location string, value string) error {
+	if err := validate.EnumCase(path, location, value, eqOpTypeMatchPropEnum, true); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (m *EqOp) validateMatch(formats strfmt.Registry) error {
+	if swag.IsZero(m.Match) { // not required
+		return nil
+	}
+
+	// value enum
+	if err := m.validateMatchEnum("match", "body", m.Match); err != nil {
+		return err
+	}
+
+	return nil
+}
+
 // ContextValidate validate this eq op based on the context it is used
 func (m *EqOp) ContextValidate(ctx context.Context, formats strfmt.Registry) error {
 	var res []error
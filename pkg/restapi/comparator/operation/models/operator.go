@@ -105,12 +105,36 @@ func unmarshalOperator(data []byte, consumer runtime.Consumer) (Operator, error)
 			return nil, err
 		}
 		return &result, nil
+	case "FuzzyOp":
+		var result FuzzyOp
+		if err := consumer.Consume(buf2, &result); err != nil {
+			return nil, err
+		}
+		return &result, nil
+	case "GtOp":
+		var result GtOp
+		if err := consumer.Consume(buf2, &result); err != nil {
+			return nil, err
+		}
+		return &result, nil
+	case "LtOp":
+		var result LtOp
+		if err := consumer.Consume(buf2, &result); err != nil {
+			return nil, err
+		}
+		return &result, nil
 	case "Operator":
 		var result operator
 		if err := consumer.Consume(buf2, &result); err != nil {
 			return nil, err
 		}
 		return &result, nil
+	case "RangeOp":
+		var result RangeOp
+		if err := consumer.Consume(buf2, &result); err != nil {
+			return nil, err
+		}
+		return &result, nil
 	}
 	return nil, errors.New(422, "invalid type value: %q", getType.Type)
 }
@@ -44,6 +44,14 @@ type compareReq struct { // nolint:deadcode,unused // swagger model
 	Body models.Comparison
 }
 
+// compareResourcesReq model.
+//
+// swagger:parameters compareResourcesReq
+type compareResourcesReq struct { // nolint:deadcode,unused // swagger model
+	// in: body
+	Body models.ResourceComparison
+}
+
 // comparisonResp model.
 //
 // swagger:response comparisonResp
@@ -7,6 +7,7 @@ SPDX-License-Identifier: Apache-2.0
 package operation
 
 import (
+	"context"
 	"crypto"
 	"crypto/ed25519"
 	"crypto/rand"
@@ -44,10 +45,11 @@ import (
 )
 
 const (
-	createAuthzPath = "/authorizations"
-	comparePath     = "/compare"
-	extractPath     = "/extract"
-	getConfigPath   = "/config"
+	createAuthzPath      = "/authorizations"
+	comparePath          = "/compare"
+	compareResourcesPath = "/compare/resources"
+	extractPath          = "/extract"
+	getConfigPath        = "/config"
 )
 
 const (
@@ -55,6 +57,8 @@ const (
 	cshConfigKeyDB = "csh_config"
 	storeName      = "comparator"
 	requestTimeout = 5 * time.Second
+
+	defaultComparisonCacheSize = 10000
 )
 
 type cshClient interface {
@@ -66,10 +70,12 @@ type cshClient interface {
 		opts ...operations.ClientOption) (*operations.PostHubstoreProfilesProfileIDQueriesCreated, error)
 	PostExtract(params *operations.PostExtractParams,
 		opts ...operations.ClientOption) (*operations.PostExtractOK, error)
+	PostRevocationsStatus(params *operations.PostRevocationsStatusParams,
+		opts ...operations.ClientOption) (*operations.PostRevocationsStatusOK, error)
 }
 
 type vaultClient interface {
-	GetDocMetaData(vaultID, docID string) (*vault.DocumentMetadata, error)
+	GetDocMetaData(ctx context.Context, vaultID, docID string) (*vault.DocumentMetadata, error)
 }
 
 var logger = log.New("comparator-ops")
@@ -88,20 +94,33 @@ type Operation struct {
 	didDomain        string
 	didAnchorOrigin  string
 	documentLoader   ld.DocumentLoader
+	resultCache      *resultCache
 }
 
 // Config defines configuration for comparator operations.
 type Config struct {
-	VDR             vdr.Registry
-	KeyManager      kms.KeyManager
-	TLSConfig       *tls.Config
-	DIDMethod       string
-	StoreProvider   storage.Provider
-	CSHBaseURL      string
-	VaultBaseURL    string
-	DIDDomain       string
-	DIDAnchorOrigin string
-	DocumentLoader  ld.DocumentLoader
+	VDR                vdr.Registry
+	KeyManager         kms.KeyManager
+	TLSConfig          *tls.Config
+	DIDMethod          string
+	StoreProvider      storage.Provider
+	CSHBaseURL         string
+	VaultBaseURL       string
+	DIDDomain          string
+	DIDAnchorOrigin    string
+	DocumentLoader     ld.DocumentLoader
+	ComparisonCacheTTL time.Duration
+	// ComparisonCacheSize is the maximum number of cached comparison results, past which the least recently used
+	// results are evicted. Defaults to 10000.
+	ComparisonCacheSize int
+	// CSHTimeout is the timeout of each call to the Confidential Storage Hub. Defaults to 5s.
+	CSHTimeout time.Duration
+	// CSHRetries is the number of times comparisons and extractions are retried while the hub is unavailable.
+	CSHRetries uint64
+	// CSHFailureThreshold is the number of consecutive calls failing with the hub unavailable after which the calls
+	// fail fast for CSHCooldown. Disabled if 0.
+	CSHFailureThreshold int
+	CSHCooldown         time.Duration
 }
 
 // New returns operation instance.
@@ -129,7 +148,7 @@ func New(cfg *Config) (*Operation, error) {
 	op := &Operation{
 		didAnchorOrigin: cfg.DIDAnchorOrigin, didDomain: cfg.DIDDomain, vdr: cfg.VDR, keyManager: cfg.KeyManager,
 		tlsConfig: cfg.TLSConfig, didMethod: cfg.DIDMethod, store: store,
-		cshClient: client.New(transport, strfmt.Default).Operations,
+		cshClient: newHubClient(client.New(transport, strfmt.Default).Operations, cfg),
 		vaultClient: vaultclient.New(cfg.VaultBaseURL, vaultclient.WithHTTPClient(&http.Client{
 			Transport: &http.Transport{
 				TLSClientConfig: cfg.TLSConfig,
@@ -138,6 +157,15 @@ func New(cfg *Config) (*Operation, error) {
 		documentLoader: cfg.DocumentLoader,
 	}
 
+	if cfg.ComparisonCacheTTL > 0 {
+		size := cfg.ComparisonCacheSize
+		if size <= 0 {
+			size = defaultComparisonCacheSize
+		}
+
+		op.resultCache = newResultCache(cfg.ComparisonCacheTTL, size)
+	}
+
 	if _, err := op.getConfig(); err != nil { //nolint: nestif
 		if errors.Is(err, storage.ErrDataNotFound) {
 			if errCreate := op.createConfig(); errCreate != nil {
@@ -170,6 +198,7 @@ func (o *Operation) GetRESTHandlers() []handler.Handler {
 	return []handler.Handler{
 		handler.NewHTTPHandler(createAuthzPath, http.MethodPost, o.CreateAuthorization),
 		handler.NewHTTPHandler(comparePath, http.MethodPost, o.Compare),
+		handler.NewHTTPHandler(compareResourcesPath, http.MethodPost, o.CompareResources),
 		handler.NewHTTPHandler(extractPath, http.MethodPost, o.Extract),
 		handler.NewHTTPHandler(getConfigPath, http.MethodGet, o.GetConfig),
 	}
@@ -181,12 +210,16 @@ func (o *Operation) GetRESTHandlers() []handler.Handler {
 //
 // Consumes:
 //   - application/json
+//
 // Produces:
 //   - application/json
+//
 // Responses:
-//   201: createAuthorizationResp
-//   403: Error
-//   500: Error
+//
+//	201: createAuthorizationResp
+//	403: Error
+//	500: Error
+//	503: Error
 func (o *Operation) CreateAuthorization(w http.ResponseWriter, r *http.Request) {
 	request := &models.Authorization{}
 
@@ -197,7 +230,7 @@ func (o *Operation) CreateAuthorization(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	o.HandleAuthz(w, request)
+	o.HandleAuthz(r.Context(), w, request)
 }
 
 // Compare swagger:route POST /compare compareReq
@@ -206,11 +239,15 @@ func (o *Operation) CreateAuthorization(w http.ResponseWriter, r *http.Request)
 //
 // Consumes:
 //   - application/json
+//
 // Produces:
 //   - application/json
+//
 // Responses:
-//   200: comparisonResp
-//   500: Error
+//
+//	200: comparisonResp
+//	500: Error
+//	503: Error
 func (o *Operation) Compare(w http.ResponseWriter, r *http.Request) {
 	request := &models.Comparison{}
 
@@ -223,21 +260,66 @@ func (o *Operation) Compare(w http.ResponseWriter, r *http.Request) {
 
 	switch t := request.Op().(type) {
 	case *models.EqOp:
-		o.HandleEqOp(w, t)
+		o.HandleEqOp(r.Context(), w, t)
+	case *models.FuzzyOp:
+		o.HandleFuzzyOp(r.Context(), w, t)
+	case *models.GtOp:
+		o.HandleGtOp(r.Context(), w, t)
+	case *models.LtOp:
+		o.HandleLtOp(r.Context(), w, t)
+	case *models.RangeOp:
+		o.HandleRangeOp(r.Context(), w, t)
 	default:
 		respondErrorf(w, http.StatusNotImplemented, "operator not yet implemented: %s", request.Op().Type())
 	}
 }
 
+// CompareResources swagger:route POST /compare/resources compareResourcesReq
+//
+// Compares the values of two or more protected resources for equality.
+//
+// Consumes:
+//   - application/json
+//
+// Produces:
+//   - application/json
+//
+// Responses:
+//
+//	200: comparisonResp
+//	400: Error
+//	500: Error
+func (o *Operation) CompareResources(w http.ResponseWriter, r *http.Request) {
+	request := &models.ResourceComparison{}
+
+	err := json.NewDecoder(r.Body).Decode(request)
+	if err != nil {
+		respondErrorf(w, http.StatusBadRequest, "bad request: %s", err.Error())
+
+		return
+	}
+
+	if err = request.Validate(strfmt.Default); err != nil {
+		respondErrorf(w, http.StatusBadRequest, "bad request: %s", err.Error())
+
+		return
+	}
+
+	o.HandleResourceComparison(r.Context(), w, request)
+}
+
 // Extract swagger:route POST /extract extractReq
 //
 // Extracts the contents of a document.
 //
 // Produces:
 //   - application/json
+//
 // Responses:
-//   200: extractionResp
-//   500: Error
+//
+//	200: extractionResp
+//	500: Error
+//	503: Error
 func (o *Operation) Extract(w http.ResponseWriter, r *http.Request) {
 	request := &models.Extract{}
 
@@ -257,9 +339,11 @@ func (o *Operation) Extract(w http.ResponseWriter, r *http.Request) {
 //
 // Produces:
 //   - application/json
+//
 // Responses:
-//   200: configResp
-//   500: Error
+//
+//	200: configResp
+//	500: Error
 func (o *Operation) GetConfig(w http.ResponseWriter, _ *http.Request) {
 	cc, err := o.getConfig()
 	if err != nil {
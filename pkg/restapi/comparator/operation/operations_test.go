@@ -17,7 +17,10 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/hyperledger/aries-framework-go/component/storageutil/mem"
@@ -72,7 +75,7 @@ func Test_New(t *testing.T) {
 		require.NoError(t, err)
 		require.NotNil(t, op)
 
-		require.Equal(t, 4, len(op.GetRESTHandlers()))
+		require.Equal(t, 5, len(op.GetRESTHandlers()))
 	})
 
 	t.Run("test failed to create profile from csh", func(t *testing.T) {
@@ -676,6 +679,892 @@ func TestOperation_Compare(t *testing.T) {
 		require.Equal(t, http.StatusOK, result.Code)
 		require.Contains(t, result.Body.String(), "true")
 	})
+
+	t.Run("test fuzzy match success", func(t *testing.T) {
+		serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			p := vault.DocumentMetadata{ID: "id", URI: "/test/test/test/test"}
+			b, err := json.Marshal(p)
+			require.NoError(t, err)
+
+			_, err = fmt.Fprint(w, string(b))
+			require.NoError(t, err)
+		}))
+		defer serv.Close()
+
+		var cshOP *cshclientmodels.FuzzyOp
+
+		cshServ := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			request := &cshclientmodels.ComparisonRequest{}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(request))
+			require.IsType(t, &cshclientmodels.FuzzyOp{}, request.Op())
+			cshOP = request.Op().(*cshclientmodels.FuzzyOp)
+
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusOK)
+			p := cshclientmodels.Comparison{Result: true, Score: 0.9}
+			b, err := p.MarshalBinary()
+			require.NoError(t, err)
+
+			_, err = fmt.Fprint(w, string(b))
+			require.NoError(t, err)
+		}))
+		defer cshServ.Close()
+
+		s := &mockstorage.MockStore{Store: make(map[string]mockstorage.DBEntry)}
+		s.Store["config"] = mockstorage.DBEntry{Value: []byte(`{}`)}
+		s.Store["csh_config"] = mockstorage.DBEntry{Value: []byte(`{}`)}
+		op, err := operation.New(&operation.Config{
+			CSHBaseURL: cshServ.URL, VaultBaseURL: serv.URL,
+			StoreProvider: &mockstorage.MockStoreProvider{Store: s},
+		})
+		require.NoError(t, err)
+		require.NotNil(t, op)
+		result := httptest.NewRecorder()
+		cr := &models.Comparison{}
+		threshold := 0.8
+		fuzzy := &models.FuzzyOp{Algorithm: models.FuzzyOpAlgorithmSoundex, Threshold: &threshold}
+		query := make([]models.Query, 0)
+		docID1, docID2 := "docID4", "docID5"
+		vaultID := "vaultID4"
+		query = append(query, &models.DocQuery{
+			DocID: &docID1, VaultID: &vaultID,
+			AuthTokens: &models.DocQueryAO1AuthTokens{Edv: "edvToken", Kms: "kmsToken"},
+		}, &models.DocQuery{
+			DocID: &docID2, VaultID: &vaultID,
+			AuthTokens: &models.DocQueryAO1AuthTokens{Edv: "edvToken", Kms: "kmsToken"},
+		})
+		fuzzy.SetArgs(query)
+		cr.SetOp(fuzzy)
+		op.Compare(result, newReq(t,
+			http.MethodPost,
+			"/compare",
+			cr,
+		))
+
+		require.Equal(t, http.StatusOK, result.Code)
+
+		comparison := &models.ComparisonResult{}
+		require.NoError(t, json.Unmarshal(result.Body.Bytes(), comparison))
+		require.True(t, comparison.Result)
+		require.Equal(t, 0.9, comparison.Score)
+
+		require.NotNil(t, cshOP)
+		require.Equal(t, models.FuzzyOpAlgorithmSoundex, cshOP.Algorithm)
+		require.Equal(t, threshold, *cshOP.Threshold)
+		require.Len(t, cshOP.Args(), 2)
+	})
+
+	t.Run("test equality proof is relayed", func(t *testing.T) {
+		serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			p := vault.DocumentMetadata{ID: "id", URI: "/test/test/test/test"}
+			b, err := json.Marshal(p)
+			require.NoError(t, err)
+
+			_, err = fmt.Fprint(w, string(b))
+			require.NoError(t, err)
+		}))
+		defer serv.Close()
+
+		var cshOP *cshclientmodels.EqOp
+
+		cshServ := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			request := &cshclientmodels.ComparisonRequest{}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(request))
+			require.IsType(t, &cshclientmodels.EqOp{}, request.Op())
+			cshOP = request.Op().(*cshclientmodels.EqOp)
+
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusOK)
+			p := cshclientmodels.Comparison{Result: true, Proof: &cshclientmodels.EqualityProof{
+				Scheme:              "scheme",
+				Commitments:         []string{"c1", "c2"},
+				Proofs:              []*cshclientmodels.SchnorrProof{{T: "t", S: "s"}},
+				Signatures:          []string{"s1", "s2"},
+				VerificationMethods: []string{"did:example:vault#key1", "did:example:vault#key1"},
+			}}
+			b, err := p.MarshalBinary()
+			require.NoError(t, err)
+
+			_, err = fmt.Fprint(w, string(b))
+			require.NoError(t, err)
+		}))
+		defer cshServ.Close()
+
+		s := &mockstorage.MockStore{Store: make(map[string]mockstorage.DBEntry)}
+		s.Store["config"] = mockstorage.DBEntry{Value: []byte(`{}`)}
+		s.Store["csh_config"] = mockstorage.DBEntry{Value: []byte(`{}`)}
+		op, err := operation.New(&operation.Config{
+			CSHBaseURL: cshServ.URL, VaultBaseURL: serv.URL,
+			StoreProvider: &mockstorage.MockStoreProvider{Store: s},
+		})
+		require.NoError(t, err)
+		require.NotNil(t, op)
+		result := httptest.NewRecorder()
+		cr := &models.Comparison{}
+		eq := &models.EqOp{Proof: true}
+		query := make([]models.Query, 0)
+		docID1, docID2 := "docID4", "docID5"
+		vaultID := "vaultID4"
+		query = append(query, &models.DocQuery{
+			DocID: &docID1, VaultID: &vaultID,
+			AuthTokens: &models.DocQueryAO1AuthTokens{Edv: "edvToken", Kms: "kmsToken"},
+		}, &models.DocQuery{
+			DocID: &docID2, VaultID: &vaultID,
+			AuthTokens: &models.DocQueryAO1AuthTokens{Edv: "edvToken", Kms: "kmsToken"},
+		})
+		eq.SetArgs(query)
+		cr.SetOp(eq)
+		op.Compare(result, newReq(t,
+			http.MethodPost,
+			"/compare",
+			cr,
+		))
+
+		require.Equal(t, http.StatusOK, result.Code)
+
+		comparison := &models.ComparisonResult{}
+		require.NoError(t, json.Unmarshal(result.Body.Bytes(), comparison))
+		require.True(t, comparison.Result)
+		require.Equal(t, &models.EqualityProof{
+			Scheme:              "scheme",
+			Commitments:         []string{"c1", "c2"},
+			Proofs:              []*models.SchnorrProof{{T: "t", S: "s"}},
+			Signatures:          []string{"s1", "s2"},
+			VerificationMethods: []string{"did:example:vault#key1", "did:example:vault#key1"},
+		}, comparison.Proof)
+
+		require.NotNil(t, cshOP)
+		require.True(t, cshOP.Proof)
+	})
+
+	t.Run("test ordering ops success", func(t *testing.T) {
+		serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			p := vault.DocumentMetadata{ID: "id", URI: "/test/test/test/test"}
+			b, err := json.Marshal(p)
+			require.NoError(t, err)
+
+			_, err = fmt.Fprint(w, string(b))
+			require.NoError(t, err)
+		}))
+		defer serv.Close()
+
+		var cshOP cshclientmodels.Operator
+
+		cshServ := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			request := &cshclientmodels.ComparisonRequest{}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(request))
+			cshOP = request.Op()
+
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusOK)
+			p := cshclientmodels.Comparison{Result: true}
+			b, err := p.MarshalBinary()
+			require.NoError(t, err)
+
+			_, err = fmt.Fprint(w, string(b))
+			require.NoError(t, err)
+		}))
+		defer cshServ.Close()
+
+		s := &mockstorage.MockStore{Store: make(map[string]mockstorage.DBEntry)}
+		s.Store["config"] = mockstorage.DBEntry{Value: []byte(`{}`)}
+		s.Store["csh_config"] = mockstorage.DBEntry{Value: []byte(`{}`)}
+		op, err := operation.New(&operation.Config{
+			CSHBaseURL: cshServ.URL, VaultBaseURL: serv.URL,
+			StoreProvider: &mockstorage.MockStoreProvider{Store: s},
+		})
+		require.NoError(t, err)
+		require.NotNil(t, op)
+
+		docID := "docID6"
+		vaultID := "vaultID6"
+		minAge, maxAge := "18", "65"
+		query := []models.Query{
+			&models.DocQuery{
+				DocID: &docID, VaultID: &vaultID,
+				AuthTokens: &models.DocQueryAO1AuthTokens{Edv: "edvToken", Kms: "kmsToken"},
+			},
+			&models.ValueQuery{Value: &minAge},
+			&models.ValueQuery{Value: &maxAge},
+		}
+
+		gt := &models.GtOp{}
+		gt.SetArgs(query[:2])
+		lt := &models.LtOp{}
+		lt.SetArgs(query[:2])
+		rng := &models.RangeOp{}
+		rng.SetArgs(query)
+
+		for _, tc := range []models.Operator{gt, lt, rng} {
+			cr := &models.Comparison{}
+			cr.SetOp(tc)
+
+			result := httptest.NewRecorder()
+			op.Compare(result, newReq(t,
+				http.MethodPost,
+				"/compare",
+				cr,
+			))
+
+			require.Equal(t, http.StatusOK, result.Code)
+			require.Contains(t, result.Body.String(), "true")
+			require.Equal(t, tc.Type(), cshOP.Type())
+		}
+
+		cshRange, ok := cshOP.(*cshclientmodels.RangeOp)
+		require.True(t, ok)
+		require.Len(t, cshRange.Args(), 3)
+		require.Equal(t, minAge, *cshRange.Args()[1].(*cshclientmodels.ValueQuery).Value)
+		require.Equal(t, maxAge, *cshRange.Args()[2].(*cshclientmodels.ValueQuery).Value)
+	})
+}
+
+func TestOperation_CompareHubFailures(t *testing.T) {
+	vaultServ := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewEncoder(w).Encode(vault.DocumentMetadata{ID: "id", URI: "/test/test/test/test"}))
+	}))
+	defer vaultServ.Close()
+
+	newOp := func(t *testing.T, status int, calls *int32, cfg *operation.Config) *operation.Operation {
+		t.Helper()
+
+		cshServ := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(calls, 1)
+			w.WriteHeader(status)
+		}))
+		t.Cleanup(cshServ.Close)
+
+		s := &mockstorage.MockStore{Store: make(map[string]mockstorage.DBEntry)}
+		s.Store["config"] = mockstorage.DBEntry{Value: []byte(`{}`)}
+		s.Store["csh_config"] = mockstorage.DBEntry{Value: []byte(`{}`)}
+
+		cfg.CSHBaseURL = cshServ.URL
+		cfg.VaultBaseURL = vaultServ.URL
+		cfg.StoreProvider = &mockstorage.MockStoreProvider{Store: s}
+
+		op, err := operation.New(cfg)
+		require.NoError(t, err)
+
+		return op
+	}
+
+	compare := func(op *operation.Operation) *httptest.ResponseRecorder {
+		docID := "docID"
+		vaultID := "vaultID"
+
+		eq := &models.EqOp{}
+		eq.SetArgs([]models.Query{&models.DocQuery{
+			DocID: &docID, VaultID: &vaultID,
+			AuthTokens: &models.DocQueryAO1AuthTokens{Edv: "edvToken", Kms: "kmsToken"},
+		}})
+
+		cr := &models.Comparison{}
+		cr.SetOp(eq)
+
+		result := httptest.NewRecorder()
+		op.Compare(result, newReq(t, http.MethodPost, "/compare", cr))
+
+		return result
+	}
+
+	t.Run("test comparison failed", func(t *testing.T) {
+		var calls int32
+
+		op := newOp(t, http.StatusInternalServerError, &calls, &operation.Config{CSHRetries: 1})
+
+		result := compare(op)
+		require.Equal(t, http.StatusInternalServerError, result.Code)
+		require.Contains(t, result.Body.String(), operation.ErrComparisonFailed.Error())
+		require.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("test hub unavailable is retried", func(t *testing.T) {
+		var calls int32
+
+		op := newOp(t, http.StatusServiceUnavailable, &calls, &operation.Config{CSHRetries: 1})
+
+		result := compare(op)
+		require.Equal(t, http.StatusServiceUnavailable, result.Code)
+		require.Contains(t, result.Body.String(), operation.ErrHubUnavailable.Error())
+		require.Equal(t, int32(2), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("test calls fail fast while the circuit is open", func(t *testing.T) {
+		var calls int32
+
+		op := newOp(t, http.StatusBadGateway, &calls, &operation.Config{
+			CSHFailureThreshold: 1,
+			CSHCooldown:         time.Hour,
+		})
+
+		require.Equal(t, http.StatusServiceUnavailable, compare(op).Code)
+
+		result := compare(op)
+		require.Equal(t, http.StatusServiceUnavailable, result.Code)
+		require.Contains(t, result.Body.String(), "circuit open")
+		require.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("test calls time out", func(t *testing.T) {
+		cshServ := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(100 * time.Millisecond)
+		}))
+		defer cshServ.Close()
+
+		s := &mockstorage.MockStore{Store: make(map[string]mockstorage.DBEntry)}
+		s.Store["config"] = mockstorage.DBEntry{Value: []byte(`{}`)}
+		s.Store["csh_config"] = mockstorage.DBEntry{Value: []byte(`{}`)}
+
+		op, err := operation.New(&operation.Config{
+			CSHBaseURL: cshServ.URL, VaultBaseURL: vaultServ.URL, CSHTimeout: 10 * time.Millisecond,
+			StoreProvider: &mockstorage.MockStoreProvider{Store: s},
+		})
+		require.NoError(t, err)
+
+		result := compare(op)
+		require.Equal(t, http.StatusServiceUnavailable, result.Code)
+		require.Contains(t, result.Body.String(), operation.ErrHubUnavailable.Error())
+	})
+}
+
+func TestOperation_CompareResources(t *testing.T) {
+	t.Run("test bad request", func(t *testing.T) {
+		s := &mockstorage.MockStore{Store: make(map[string]mockstorage.DBEntry)}
+		s.Store["config"] = mockstorage.DBEntry{Value: []byte(`{}`)}
+		s.Store["csh_config"] = mockstorage.DBEntry{Value: []byte(`{}`)}
+		op, err := operation.New(&operation.Config{
+			CSHBaseURL:    "https://localhost",
+			StoreProvider: &mockstorage.MockStoreProvider{Store: s},
+		})
+		require.NoError(t, err)
+		require.NotNil(t, op)
+		result := httptest.NewRecorder()
+		op.CompareResources(result, newReq(t,
+			http.MethodPost,
+			"/compare/resources",
+			nil,
+		))
+
+		require.Equal(t, http.StatusBadRequest, result.Code)
+		require.Contains(t, result.Body.String(), "bad request")
+	})
+
+	t.Run("test invalid number of resources", func(t *testing.T) {
+		s := &mockstorage.MockStore{Store: make(map[string]mockstorage.DBEntry)}
+		s.Store["config"] = mockstorage.DBEntry{Value: []byte(`{}`)}
+		s.Store["csh_config"] = mockstorage.DBEntry{Value: []byte(`{}`)}
+		op, err := operation.New(&operation.Config{
+			CSHBaseURL:    "https://localhost",
+			StoreProvider: &mockstorage.MockStoreProvider{Store: s},
+		})
+		require.NoError(t, err)
+		require.NotNil(t, op)
+		result := httptest.NewRecorder()
+		op.CompareResources(result, newReq(t,
+			http.MethodPost,
+			"/compare/resources",
+			&models.ResourceComparison{Resources: []*models.ProtectedResource{newProtectedResource("did:ex:1")}},
+		))
+
+		require.Equal(t, http.StatusBadRequest, result.Code)
+		require.Contains(t, result.Body.String(), "resources in body should have at least 2 items")
+	})
+
+	t.Run("test missing resource did", func(t *testing.T) {
+		s := &mockstorage.MockStore{Store: make(map[string]mockstorage.DBEntry)}
+		s.Store["config"] = mockstorage.DBEntry{Value: []byte(`{}`)}
+		s.Store["csh_config"] = mockstorage.DBEntry{Value: []byte(`{}`)}
+		op, err := operation.New(&operation.Config{
+			CSHBaseURL:    "https://localhost",
+			StoreProvider: &mockstorage.MockStoreProvider{Store: s},
+		})
+		require.NoError(t, err)
+		require.NotNil(t, op)
+		result := httptest.NewRecorder()
+		missingDID := newProtectedResource("")
+		missingDID.DID = nil
+		op.CompareResources(result, newReq(t,
+			http.MethodPost,
+			"/compare/resources",
+			&models.ResourceComparison{Resources: []*models.ProtectedResource{
+				newProtectedResource("did:ex:1"), missingDID,
+			}},
+		))
+
+		require.Equal(t, http.StatusBadRequest, result.Code)
+		require.Contains(t, result.Body.String(), "resources.1.did in body is required")
+	})
+
+	t.Run("test null resource", func(t *testing.T) {
+		s := &mockstorage.MockStore{Store: make(map[string]mockstorage.DBEntry)}
+		s.Store["config"] = mockstorage.DBEntry{Value: []byte(`{}`)}
+		s.Store["csh_config"] = mockstorage.DBEntry{Value: []byte(`{}`)}
+		op, err := operation.New(&operation.Config{
+			CSHBaseURL:    "https://localhost",
+			StoreProvider: &mockstorage.MockStoreProvider{Store: s},
+		})
+		require.NoError(t, err)
+		require.NotNil(t, op)
+		result := httptest.NewRecorder()
+		op.CompareResources(result, newReq(t,
+			http.MethodPost,
+			"/compare/resources",
+			&models.ResourceComparison{Resources: []*models.ProtectedResource{
+				newProtectedResource("did:ex:1"), nil,
+			}},
+		))
+
+		require.Equal(t, http.StatusBadRequest, result.Code)
+		require.Contains(t, result.Body.String(), "resources.1 must have a did and a docID")
+	})
+
+	t.Run("test resource without auth tokens", func(t *testing.T) {
+		var docs int
+
+		serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			docs++
+
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer serv.Close()
+
+		s := &mockstorage.MockStore{Store: make(map[string]mockstorage.DBEntry)}
+		s.Store["config"] = mockstorage.DBEntry{Value: []byte(`{}`)}
+		s.Store["csh_config"] = mockstorage.DBEntry{Value: []byte(`{}`)}
+		op, err := operation.New(&operation.Config{
+			CSHBaseURL: "https://localhost", VaultBaseURL: serv.URL,
+			StoreProvider: &mockstorage.MockStoreProvider{Store: s},
+		})
+		require.NoError(t, err)
+		require.NotNil(t, op)
+		noTokens := newProtectedResource("did:ex:2")
+		noTokens.AuthTokens = nil
+		result := httptest.NewRecorder()
+		op.HandleResourceComparison(newReq(t, http.MethodPost, "/compare/resources", nil).Context(), result,
+			&models.ResourceComparison{
+				Resources: []*models.ProtectedResource{newProtectedResource("did:ex:1"), noTokens},
+			})
+
+		require.Equal(t, http.StatusInternalServerError, result.Code)
+		require.Contains(t, result.Body.String(), "failed to get doc meta")
+		require.Equal(t, 1, docs)
+	})
+
+	t.Run("test failed to get doc meta from vault server", func(t *testing.T) {
+		serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer serv.Close()
+
+		s := &mockstorage.MockStore{Store: make(map[string]mockstorage.DBEntry)}
+		s.Store["config"] = mockstorage.DBEntry{Value: []byte(`{}`)}
+		s.Store["csh_config"] = mockstorage.DBEntry{Value: []byte(`{}`)}
+		op, err := operation.New(&operation.Config{
+			CSHBaseURL: "https://localhost", VaultBaseURL: serv.URL,
+			StoreProvider: &mockstorage.MockStoreProvider{Store: s},
+		})
+		require.NoError(t, err)
+		require.NotNil(t, op)
+		result := httptest.NewRecorder()
+		op.CompareResources(result, newReq(t,
+			http.MethodPost,
+			"/compare/resources",
+			&models.ResourceComparison{Resources: []*models.ProtectedResource{
+				newProtectedResource("did:ex:1"), newProtectedResource("did:ex:2"),
+			}},
+		))
+
+		require.Equal(t, http.StatusInternalServerError, result.Code)
+		require.Contains(t, result.Body.String(), "failed to get doc meta")
+	})
+
+	t.Run("test success", func(t *testing.T) {
+		requestedVaults := make(map[string]struct{})
+
+		serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestedVaults[strings.Split(r.URL.Path, "/")[2]] = struct{}{}
+
+			w.WriteHeader(http.StatusOK)
+			p := vault.DocumentMetadata{ID: "id", URI: "/test/test/test/test"}
+			b, err := json.Marshal(p)
+			require.NoError(t, err)
+
+			_, err = fmt.Fprint(w, string(b))
+			require.NoError(t, err)
+		}))
+		defer serv.Close()
+
+		var paths []string
+
+		cshServ := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			request := &cshclientmodels.ComparisonRequest{}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(request))
+
+			for _, q := range request.Op().(*cshclientmodels.EqOp).Args() {
+				paths = append(paths, q.(*cshclientmodels.DocQuery).Path)
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusOK)
+			p := cshclientmodels.Comparison{Result: true}
+			b, err := p.MarshalBinary()
+			require.NoError(t, err)
+
+			_, err = fmt.Fprint(w, string(b))
+			require.NoError(t, err)
+		}))
+		defer cshServ.Close()
+
+		s := &mockstorage.MockStore{Store: make(map[string]mockstorage.DBEntry)}
+		s.Store["config"] = mockstorage.DBEntry{Value: []byte(`{}`)}
+		s.Store["csh_config"] = mockstorage.DBEntry{Value: []byte(`{}`)}
+		op, err := operation.New(&operation.Config{
+			CSHBaseURL: cshServ.URL, VaultBaseURL: serv.URL,
+			StoreProvider: &mockstorage.MockStoreProvider{Store: s},
+		})
+		require.NoError(t, err)
+		require.NotNil(t, op)
+		result := httptest.NewRecorder()
+		withPath := newProtectedResource("did:ex:2")
+		withPath.AttrPath = "$.credentialSubject.data.ssn"
+		op.CompareResources(result, newReq(t,
+			http.MethodPost,
+			"/compare/resources",
+			&models.ResourceComparison{Resources: []*models.ProtectedResource{
+				newProtectedResource("did:ex:1"), withPath,
+			}},
+		))
+
+		require.Equal(t, http.StatusOK, result.Code)
+		require.Contains(t, result.Body.String(), "true")
+		require.Equal(t, []string{"$.credentialSubject.data", "$.credentialSubject.data.ssn"}, paths)
+		require.Contains(t, requestedVaults, "did:ex:1")
+		require.Contains(t, requestedVaults, "did:ex:2")
+	})
+
+	t.Run("test invalid match", func(t *testing.T) {
+		s := &mockstorage.MockStore{Store: make(map[string]mockstorage.DBEntry)}
+		s.Store["config"] = mockstorage.DBEntry{Value: []byte(`{}`)}
+		s.Store["csh_config"] = mockstorage.DBEntry{Value: []byte(`{}`)}
+		op, err := operation.New(&operation.Config{
+			CSHBaseURL:    "https://localhost",
+			StoreProvider: &mockstorage.MockStoreProvider{Store: s},
+		})
+		require.NoError(t, err)
+		require.NotNil(t, op)
+		result := httptest.NewRecorder()
+		op.CompareResources(result, newReq(t,
+			http.MethodPost,
+			"/compare/resources",
+			&models.ResourceComparison{
+				Match: "most",
+				Resources: []*models.ProtectedResource{
+					newProtectedResource("did:ex:1"), newProtectedResource("did:ex:2"),
+				},
+			},
+		))
+
+		require.Equal(t, http.StatusBadRequest, result.Code)
+		require.Contains(t, result.Body.String(), "match in body should be one of [all any]")
+	})
+
+	t.Run("test success with any of multiple resources", func(t *testing.T) {
+		serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			p := vault.DocumentMetadata{ID: "id", URI: "/test/test/test/test"}
+			b, err := json.Marshal(p)
+			require.NoError(t, err)
+
+			_, err = fmt.Fprint(w, string(b))
+			require.NoError(t, err)
+		}))
+		defer serv.Close()
+
+		var cshOP *cshclientmodels.EqOp
+
+		cshServ := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			request := &cshclientmodels.ComparisonRequest{}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(request))
+			require.IsType(t, &cshclientmodels.EqOp{}, request.Op())
+			cshOP = request.Op().(*cshclientmodels.EqOp)
+
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusOK)
+			p := cshclientmodels.Comparison{Result: true}
+			b, err := p.MarshalBinary()
+			require.NoError(t, err)
+
+			_, err = fmt.Fprint(w, string(b))
+			require.NoError(t, err)
+		}))
+		defer cshServ.Close()
+
+		s := &mockstorage.MockStore{Store: make(map[string]mockstorage.DBEntry)}
+		s.Store["config"] = mockstorage.DBEntry{Value: []byte(`{}`)}
+		s.Store["csh_config"] = mockstorage.DBEntry{Value: []byte(`{}`)}
+		op, err := operation.New(&operation.Config{
+			CSHBaseURL: cshServ.URL, VaultBaseURL: serv.URL,
+			StoreProvider: &mockstorage.MockStoreProvider{Store: s},
+		})
+		require.NoError(t, err)
+		require.NotNil(t, op)
+		result := httptest.NewRecorder()
+		op.CompareResources(result, newReq(t,
+			http.MethodPost,
+			"/compare/resources",
+			&models.ResourceComparison{
+				Match: models.ResourceComparisonMatchAny,
+				Resources: []*models.ProtectedResource{
+					newProtectedResource("did:ex:1"), newProtectedResource("did:ex:2"), newProtectedResource("did:ex:3"),
+				},
+			},
+		))
+
+		require.Equal(t, http.StatusOK, result.Code)
+		require.Contains(t, result.Body.String(), "true")
+		require.NotNil(t, cshOP)
+		require.Equal(t, cshclientmodels.EqOpMatchAny, cshOP.Match)
+		require.Len(t, cshOP.Args(), 3)
+	})
+}
+
+func TestOperation_CompareCache(t *testing.T) {
+	// revocation is the answer of the hub to the revocation checks: revoked, failed, or not revoked if empty
+	newServers := func(t *testing.T, digest, revocation *string) (*httptest.Server, *httptest.Server, *int) {
+		t.Helper()
+
+		serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			p := vault.DocumentMetadata{ID: "id", URI: "/test/test/test/test", Digest: *digest}
+			b, err := json.Marshal(p)
+			require.NoError(t, err)
+
+			_, err = fmt.Fprint(w, string(b))
+			require.NoError(t, err)
+		}))
+
+		calls := 0
+
+		cshServ := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var p interface{ MarshalBinary() ([]byte, error) } = &cshclientmodels.Comparison{Result: true}
+
+			if r.URL.Path == "/revocations/status" {
+				if *revocation == "failed" {
+					w.WriteHeader(http.StatusInternalServerError)
+
+					return
+				}
+
+				p = &cshclientmodels.RevocationStatus{Revoked: *revocation == "revoked"}
+			} else {
+				calls++
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusOK)
+			b, err := p.MarshalBinary()
+			require.NoError(t, err)
+
+			_, err = fmt.Fprint(w, string(b))
+			require.NoError(t, err)
+		}))
+
+		return serv, cshServ, &calls
+	}
+
+	newOp := func(t *testing.T, vaultURL, cshURL string, ttl time.Duration, size int) *operation.Operation {
+		t.Helper()
+
+		s := &mockstorage.MockStore{Store: make(map[string]mockstorage.DBEntry)}
+		s.Store["config"] = mockstorage.DBEntry{Value: []byte(`{}`)}
+		s.Store["csh_config"] = mockstorage.DBEntry{Value: []byte(`{}`)}
+		op, err := operation.New(&operation.Config{
+			CSHBaseURL: cshURL, VaultBaseURL: vaultURL,
+			StoreProvider:       &mockstorage.MockStoreProvider{Store: s},
+			ComparisonCacheTTL:  ttl,
+			ComparisonCacheSize: size,
+		})
+		require.NoError(t, err)
+
+		return op
+	}
+
+	compare := func(t *testing.T, op *operation.Operation, queries ...models.Query) {
+		t.Helper()
+
+		docID1, docID2 := "docID7", "docID8"
+		vaultID := "vaultID7"
+		args := []models.Query{
+			&models.DocQuery{
+				DocID: &docID1, VaultID: &vaultID,
+				AuthTokens: &models.DocQueryAO1AuthTokens{Edv: "edvToken", Kms: "kmsToken"},
+			},
+			&models.DocQuery{
+				DocID: &docID2, VaultID: &vaultID,
+				AuthTokens: &models.DocQueryAO1AuthTokens{Edv: "edvToken", Kms: "kmsToken"},
+			},
+		}
+
+		eq := &models.EqOp{}
+		eq.SetArgs(append(args, queries...))
+		cr := &models.Comparison{}
+		cr.SetOp(eq)
+
+		result := httptest.NewRecorder()
+		op.Compare(result, newReq(t,
+			http.MethodPost,
+			"/compare",
+			cr,
+		))
+
+		require.Equal(t, http.StatusOK, result.Code)
+		require.Contains(t, result.Body.String(), "true")
+	}
+
+	t.Run("test result is cached", func(t *testing.T) {
+		digest := "digest1"
+		serv, cshServ, calls := newServers(t, &digest, new(string))
+		defer serv.Close()
+		defer cshServ.Close()
+
+		op := newOp(t, serv.URL, cshServ.URL, time.Minute, 0)
+
+		compare(t, op)
+		compare(t, op)
+
+		require.Equal(t, 1, *calls)
+	})
+
+	t.Run("test cached result is not served once a zcap is revoked", func(t *testing.T) {
+		digest, revocation := "digest1", ""
+		serv, cshServ, calls := newServers(t, &digest, &revocation)
+		defer serv.Close()
+		defer cshServ.Close()
+
+		op := newOp(t, serv.URL, cshServ.URL, time.Minute, 0)
+
+		compare(t, op)
+
+		revocation = "revoked"
+
+		compare(t, op)
+		require.Equal(t, 2, *calls)
+	})
+
+	t.Run("test cached result is not served if revocation cannot be checked", func(t *testing.T) {
+		digest, revocation := "digest1", "failed"
+		serv, cshServ, calls := newServers(t, &digest, &revocation)
+		defer serv.Close()
+		defer cshServ.Close()
+
+		op := newOp(t, serv.URL, cshServ.URL, time.Minute, 0)
+
+		compare(t, op)
+		compare(t, op)
+
+		require.Equal(t, 2, *calls)
+	})
+
+	t.Run("test least recently used result is evicted", func(t *testing.T) {
+		digest := "digest1"
+		serv, cshServ, calls := newServers(t, &digest, new(string))
+		defer serv.Close()
+		defer cshServ.Close()
+
+		op := newOp(t, serv.URL, cshServ.URL, time.Minute, 2)
+		value1, value2 := "value1", "value2"
+
+		compare(t, op)
+		compare(t, op, &models.ValueQuery{Value: &value1})
+		compare(t, op)
+		compare(t, op, &models.ValueQuery{Value: &value2})
+		require.Equal(t, 3, *calls)
+
+		// the comparison with value1 was the least recently used
+		compare(t, op)
+		compare(t, op, &models.ValueQuery{Value: &value1})
+		require.Equal(t, 4, *calls)
+	})
+
+	t.Run("test cache is invalidated on document change", func(t *testing.T) {
+		digest := "digest1"
+		serv, cshServ, calls := newServers(t, &digest, new(string))
+		defer serv.Close()
+		defer cshServ.Close()
+
+		op := newOp(t, serv.URL, cshServ.URL, time.Minute, 0)
+
+		compare(t, op)
+
+		digest = "digest2"
+
+		compare(t, op)
+
+		require.Equal(t, 2, *calls)
+	})
+
+	t.Run("test cached result expires", func(t *testing.T) {
+		digest := "digest1"
+		serv, cshServ, calls := newServers(t, &digest, new(string))
+		defer serv.Close()
+		defer cshServ.Close()
+
+		op := newOp(t, serv.URL, cshServ.URL, 10*time.Millisecond, 0)
+
+		compare(t, op)
+		time.Sleep(20 * time.Millisecond)
+		compare(t, op)
+
+		require.Equal(t, 2, *calls)
+	})
+
+	t.Run("test cache is disabled by default", func(t *testing.T) {
+		digest := "digest1"
+		serv, cshServ, calls := newServers(t, &digest, new(string))
+		defer serv.Close()
+		defer cshServ.Close()
+
+		op := newOp(t, serv.URL, cshServ.URL, 0, 0)
+
+		compare(t, op)
+		compare(t, op)
+
+		require.Equal(t, 2, *calls)
+	})
+
+	t.Run("test result is not cached without document digest", func(t *testing.T) {
+		digest := ""
+		serv, cshServ, calls := newServers(t, &digest, new(string))
+		defer serv.Close()
+		defer cshServ.Close()
+
+		op := newOp(t, serv.URL, cshServ.URL, time.Minute, 0)
+
+		compare(t, op)
+		compare(t, op)
+
+		require.Equal(t, 2, *calls)
+	})
+
+	t.Run("test result is not cached with authorized query", func(t *testing.T) {
+		digest := "digest1"
+		serv, cshServ, calls := newServers(t, &digest, new(string))
+		defer serv.Close()
+		defer cshServ.Close()
+
+		op := newOp(t, serv.URL, cshServ.URL, time.Minute, 0)
+
+		chs := newAgent(t)
+		chsZCAP := compress(t, marshal(t, newZCAP(t, chs, chs)))
+
+		compare(t, op, &models.AuthorizedQuery{AuthToken: &chsZCAP})
+		compare(t, op, &models.AuthorizedQuery{AuthToken: &chsZCAP})
+
+		require.Equal(t, 2, *calls)
+	})
 }
 
 func TestOperation_Extract(t *testing.T) {
@@ -965,3 +1854,13 @@ func marshal(t *testing.T, v interface{}) []byte {
 
 	return bits
 }
+
+func newProtectedResource(did string) *models.ProtectedResource {
+	docID := uuid.New().String()
+
+	return &models.ProtectedResource{
+		DID:        &did,
+		DocID:      &docID,
+		AuthTokens: &models.ProtectedResourceAuthTokens{Edv: "edvToken", Kms: "kmsToken"},
+	}
+}
@@ -0,0 +1,150 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package operation
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// DefaultCapabilityCacheTTL is the default time the zcaps checked against the revocation registry are cached for.
+	DefaultCapabilityCacheTTL = time.Minute
+
+	capabilityCacheSize = 1000
+
+	metricsNamespace = "csh"
+)
+
+// capabilityCache is an LRU cache, with expiring entries, of the zcaps whose capability chains were checked against
+// the revocation registry and found unrevoked. Entries are keyed by the IDs of the zcaps, and only match the zcap they
+// were checked for, so that a zcap reusing the ID of another, with another chain, is checked anew.
+//
+// Revoking a zcap evicts the entries with the zcap in their chain. Revocations stored by other instances are only
+// seen once the entries expire.
+type capabilityCache struct {
+	ttl     time.Duration
+	lookups *prometheus.CounterVec
+
+	mu          sync.Mutex
+	entries     map[string]*list.Element
+	lru         *list.List
+	revocations uint64
+}
+
+type capabilityEntry struct {
+	id     string
+	digest [sha256.Size]byte
+	chain  []string
+	expiry time.Time
+}
+
+// newCapabilityCache returns a cache keeping the zcaps for ttl, with its lookups counted by the metrics of the
+// registerer if it is set.
+func newCapabilityCache(ttl time.Duration, registerer prometheus.Registerer) (*capabilityCache, error) {
+	c := &capabilityCache{
+		ttl: ttl,
+		lookups: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "capability_cache_lookups_total",
+			Help:      "Number of revocation checks of capability chains looked up in the cache, by hit or miss.",
+		}, []string{"result"}),
+		entries: map[string]*list.Element{},
+		lru:     list.New(),
+	}
+
+	if registerer != nil {
+		if err := registerer.Register(c.lookups); err != nil {
+			return nil, fmt.Errorf("register capability cache metrics: %w", err)
+		}
+	}
+
+	return c, nil
+}
+
+// get tells if the zcap was found unrevoked, and returns the count of revocations to pass to set on a miss, so that a
+// check racing with a revocation isn't cached.
+func (c *capabilityCache) get(id, compressedZCAP string) (bool, uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.entries[id]; ok {
+		e := el.Value.(*capabilityEntry) //nolint:forcetypeassert,errcheck // the list only holds entries
+
+		if time.Now().Before(e.expiry) && e.digest == sha256.Sum256([]byte(compressedZCAP)) {
+			c.lru.MoveToFront(el)
+			c.lookups.WithLabelValues("hit").Inc()
+
+			return true, c.revocations
+		}
+
+		c.remove(el)
+	}
+
+	c.lookups.WithLabelValues("miss").Inc()
+
+	return false, c.revocations
+}
+
+// set caches the zcap with the IDs of its chain, unless a zcap was revoked since the lookup returning revocations.
+func (c *capabilityCache) set(id, compressedZCAP string, chain []string, revocations uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if revocations != c.revocations {
+		return
+	}
+
+	if el, ok := c.entries[id]; ok {
+		c.remove(el)
+	}
+
+	c.entries[id] = c.lru.PushFront(&capabilityEntry{
+		id:     id,
+		digest: sha256.Sum256([]byte(compressedZCAP)),
+		chain:  chain,
+		expiry: time.Now().Add(c.ttl),
+	})
+
+	for c.lru.Len() > capabilityCacheSize {
+		c.remove(c.lru.Back())
+	}
+}
+
+// revoke evicts the zcaps with the revoked zcap in their chain.
+func (c *capabilityCache) revoke(revokedID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.revocations++
+
+	for el := c.lru.Front(); el != nil; {
+		next := el.Next()
+
+		e := el.Value.(*capabilityEntry) //nolint:forcetypeassert,errcheck // the list only holds entries
+
+		for _, id := range e.chain {
+			if id == revokedID {
+				c.remove(el)
+
+				break
+			}
+		}
+
+		el = next
+	}
+}
+
+func (c *capabilityCache) remove(el *list.Element) {
+	c.lru.Remove(el)
+	delete(c.entries, el.Value.(*capabilityEntry).id) //nolint:forcetypeassert,errcheck // the list only holds entries
+}
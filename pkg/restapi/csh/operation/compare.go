@@ -24,6 +24,11 @@ import (
 	openapi "github.com/trustbloc/ace/pkg/restapi/csh/operation/models"
 )
 
+const (
+	binaryOpArgs = 2
+	rangeOpArgs  = 3
+)
+
 // HandleEqOp handles a ComparisonRequest using the EqOp operator.
 func (o *Operation) HandleEqOp(w http.ResponseWriter, op *openapi.EqOp) {
 	const minArgs = 2
@@ -34,62 +39,349 @@ func (o *Operation) HandleEqOp(w http.ResponseWriter, op *openapi.EqOp) {
 		return
 	}
 
-	comparison := &openapi.Comparison{Result: true}
+	if op.Proof {
+		o.handleEqProof(w, op)
 
-	var prevDoc interface{}
+		return
+	}
+
+	var (
+		result  bool
+		proceed bool
+	)
+
+	switch op.Match {
+	case "", openapi.EqOpMatchAll:
+		result, proceed = o.allEqual(w, op.Args())
+	case openapi.EqOpMatchAny:
+		result, proceed = o.anyEqual(w, op.Args())
+	default:
+		respondErrorf(w, http.StatusBadRequest, "unsupported 'EqOp' match: %s", op.Match)
+
+		return
+	}
+
+	if !proceed {
+		return
+	}
+
+	headers := map[string]string{
+		"Content-Type": "application/json",
+	}
+
+	respond(w, http.StatusOK, headers, &openapi.Comparison{Result: result})
+}
+
+// handleEqProof handles an EqOp that requests a zero-knowledge proof of its result.
+func (o *Operation) handleEqProof(w http.ResponseWriter, op *openapi.EqOp) {
+	if op.Match != "" && op.Match != openapi.EqOpMatchAll {
+		respondErrorf(w, http.StatusBadRequest, "'EqOp' proofs require match: %s", openapi.EqOpMatchAll)
+
+		return
+	}
+
+	documents := make([]*committedDocument, len(op.Args()))
 
 	for i := range op.Args() {
-		query := op.Args()[i]
+		var proceed bool
 
-		var document interface{}
+		documents[i], proceed = o.resolveCommittedQuery(w, op.Args()[i])
+		if !proceed {
+			return
+		}
+	}
 
-		switch q := query.(type) {
-		case *openapi.DocQuery:
-			var err error
+	result, err := o.proveEqual(documents)
+	if err != nil {
+		respondErrorf(w, http.StatusInternalServerError, "failed to prove comparison result: %s", err.Error())
 
-			document, err = o.fetchDocument(q)
-			if err != nil {
-				respondErrorf(w, http.StatusInternalServerError,
-					"failed to fetch Confidential Storage document for docquery: %s", err.Error())
+		return
+	}
 
-				return
-			}
-		case *openapi.RefQuery:
-			var proceed bool
+	headers := map[string]string{
+		"Content-Type": "application/json",
+	}
 
-			document, proceed = o.resolveRefQuery(w, q)
-			if !proceed {
-				return
-			}
+	respond(w, http.StatusOK, headers, result)
+}
+
+// resolveCommittedQuery fetches the document of a DocQuery, or of the DocQuery a RefQuery references, with the
+// commitment to it. Only whole documents are committed to, when they are protected.
+func (o *Operation) resolveCommittedQuery(w http.ResponseWriter, query openapi.Query) (*committedDocument, bool) {
+	if ref, ok := query.(*openapi.RefQuery); ok {
+		var proceed bool
+
+		query, proceed = o.refQuerySpec(w, ref)
+		if !proceed {
+			return nil, false
 		}
+	}
 
-		if i == 0 {
-			prevDoc = document
+	docQuery, ok := query.(*openapi.DocQuery)
+	if !ok {
+		respondErrorf(w, http.StatusBadRequest, "'EqOp' proofs require document queries, not: %s", query.Type())
 
-			continue
+		return nil, false
+	}
+
+	document, err := o.fetchCommittedDocument(docQuery)
+	if errors.Is(err, errNotCommitted) {
+		respondErrorf(w, http.StatusBadRequest, "'EqOp' proofs require committed documents: %s", err.Error())
+
+		return nil, false
+	}
+
+	if err != nil {
+		respondErrorf(w, fetchErrorStatus(err),
+			"failed to fetch Confidential Storage document for docquery: %s", err.Error())
+
+		return nil, false
+	}
+
+	return document, true
+}
+
+// allEqual reports whether the documents of all queries are equal. It stops fetching documents at the first mismatch.
+func (o *Operation) allEqual(w http.ResponseWriter, args []openapi.Query) (bool, bool) {
+	var prevDoc interface{}
+
+	for i := range args {
+		document, proceed := o.resolveQuery(w, args[i])
+		if !proceed {
+			return false, false
 		}
 
-		comparison.Result = reflect.DeepEqual(prevDoc, document)
-		if !comparison.Result {
-			break
+		if i > 0 && !reflect.DeepEqual(prevDoc, document) {
+			return false, true
 		}
 
 		prevDoc = document
 	}
 
+	return true, true
+}
+
+// anyEqual reports whether the documents of at least two of the queries are equal.
+func (o *Operation) anyEqual(w http.ResponseWriter, args []openapi.Query) (bool, bool) {
+	documents := make([]interface{}, 0, len(args))
+
+	for i := range args {
+		document, proceed := o.resolveQuery(w, args[i])
+		if !proceed {
+			return false, false
+		}
+
+		for _, prevDoc := range documents {
+			if reflect.DeepEqual(prevDoc, document) {
+				return true, true
+			}
+		}
+
+		documents = append(documents, document)
+	}
+
+	return false, true
+}
+
+// HandleFuzzyOp handles a ComparisonRequest using the FuzzyOp operator.
+func (o *Operation) HandleFuzzyOp(w http.ResponseWriter, op *openapi.FuzzyOp) {
+	const numArgs = 2
+
+	if len(op.Args()) != numArgs {
+		respondErrorf(w, http.StatusBadRequest, "'FuzzyOp' requires exactly two arguments")
+
+		return
+	}
+
+	if op.Threshold == nil || *op.Threshold < 0 || *op.Threshold > 1 {
+		respondErrorf(w, http.StatusBadRequest, "'FuzzyOp' requires a threshold between 0 and 1")
+
+		return
+	}
+
+	if _, ok := similarityFuncs[op.Algorithm]; !ok {
+		respondErrorf(w, http.StatusBadRequest, "unsupported fuzzy matching algorithm: %s", op.Algorithm)
+
+		return
+	}
+
+	documents := make([]interface{}, len(op.Args()))
+
+	for i := range op.Args() {
+		var proceed bool
+
+		documents[i], proceed = o.resolveQuery(w, op.Args()[i])
+		if !proceed {
+			return
+		}
+	}
+
+	score, err := similarity(op.Algorithm, documents[0], documents[1])
+	if err != nil {
+		respondErrorf(w, http.StatusInternalServerError, "failed to compute similarity: %s", err.Error())
+
+		return
+	}
+
+	headers := map[string]string{
+		"Content-Type": "application/json",
+	}
+
+	respond(w, http.StatusOK, headers, &openapi.Comparison{
+		Result: score >= *op.Threshold,
+		Score:  score,
+	})
+}
+
+// HandleGtOp handles a ComparisonRequest using the GtOp operator.
+func (o *Operation) HandleGtOp(w http.ResponseWriter, op *openapi.GtOp) {
+	o.handleOrderingOp(w, op.Type(), op.Args(), binaryOpArgs, func(cmp []int) bool {
+		return cmp[0] > 0
+	})
+}
+
+// HandleLtOp handles a ComparisonRequest using the LtOp operator.
+func (o *Operation) HandleLtOp(w http.ResponseWriter, op *openapi.LtOp) {
+	o.handleOrderingOp(w, op.Type(), op.Args(), binaryOpArgs, func(cmp []int) bool {
+		return cmp[0] < 0
+	})
+}
+
+// HandleRangeOp handles a ComparisonRequest using the RangeOp operator.
+func (o *Operation) HandleRangeOp(w http.ResponseWriter, op *openapi.RangeOp) {
+	o.handleOrderingOp(w, op.Type(), op.Args(), rangeOpArgs, func(cmp []int) bool {
+		return cmp[0] >= 0 && cmp[1] <= 0
+	})
+}
+
+// handleOrderingOp compares the document of the first query against the documents of the remaining queries and
+// responds with the result of matches over the comparisons.
+func (o *Operation) handleOrderingOp(w http.ResponseWriter, opType string, args []openapi.Query, numArgs int,
+	matches func(cmp []int) bool) {
+	if len(args) != numArgs {
+		respondErrorf(w, http.StatusBadRequest, "'%s' requires exactly %d arguments", opType, numArgs)
+
+		return
+	}
+
+	documents := make([]interface{}, len(args))
+
+	for i := range args {
+		var proceed bool
+
+		documents[i], proceed = o.resolveQuery(w, args[i])
+		if !proceed {
+			return
+		}
+	}
+
+	cmp := make([]int, 0, len(documents)-1)
+
+	for _, document := range documents[1:] {
+		c, err := compareValues(documents[0], document)
+		if err != nil {
+			respondErrorf(w, http.StatusBadRequest, "failed to compare documents: %s", err.Error())
+
+			return
+		}
+
+		cmp = append(cmp, c)
+	}
+
 	headers := map[string]string{
 		"Content-Type": "application/json",
 	}
 
-	respond(w, http.StatusOK, headers, comparison)
+	respond(w, http.StatusOK, headers, &openapi.Comparison{Result: matches(cmp)})
+}
+
+func (o *Operation) resolveQuery(w http.ResponseWriter, query openapi.Query) (interface{}, bool) {
+	switch q := query.(type) {
+	case *openapi.DocQuery:
+		document, err := o.fetchDocument(q)
+		if err != nil {
+			respondErrorf(w, fetchErrorStatus(err),
+				"failed to fetch Confidential Storage document for docquery: %s", err.Error())
+
+			return nil, false
+		}
+
+		return document, true
+	case *openapi.MultiDocQuery:
+		documents, err := o.fetchDocuments(q)
+		if err != nil {
+			respondErrorf(w, fetchErrorStatus(err),
+				"failed to fetch Confidential Storage documents for multidocquery: %s", err.Error())
+
+			return nil, false
+		}
+
+		return documents, true
+	case *openapi.RefQuery:
+		return o.resolveRefQuery(w, q)
+	case *openapi.ValueQuery:
+		if q.Value == nil {
+			respondErrorf(w, http.StatusBadRequest, "'ValueQuery' requires a value")
+
+			return nil, false
+		}
+
+		return *q.Value, true
+	}
+
+	return nil, true
+}
+
+// fetchErrorStatus maps a document fetch error to the HTTP status code returned to the client.
+func fetchErrorStatus(err error) int {
+	if errors.Is(err, errCapabilityRevoked) {
+		return http.StatusForbidden
+	}
+
+	return http.StatusInternalServerError
 }
 
 func (o *Operation) fetchDocument(query openapi.Query) (interface{}, error) {
-	docQuery, ok := query.(*openapi.DocQuery)
-	if !ok {
+	switch q := query.(type) {
+	case *openapi.DocQuery:
+		return o.fetchStructuredDocument(q)
+	case *openapi.MultiDocQuery:
+		return o.fetchDocuments(q)
+	default:
 		return nil, fmt.Errorf("cannot fetch structured documents for query type: %s", query.Type())
 	}
+}
+
+// fetchDocuments resolves each document referenced by the MultiDocQuery in the order of its docIDs.
+func (o *Operation) fetchDocuments(query *openapi.MultiDocQuery) ([]interface{}, error) {
+	if len(query.DocIDs) == 0 {
+		return nil, errors.New("'MultiDocQuery' requires at least one docID")
+	}
+
+	if query.VaultID == nil || query.UpstreamAuth == nil {
+		return nil, errors.New("'MultiDocQuery' requires a vaultID and upstreamAuth")
+	}
+
+	documents := make([]interface{}, 0, len(query.DocIDs))
+
+	for i := range query.DocIDs {
+		document, err := o.fetchStructuredDocument(&openapi.DocQuery{
+			VaultID:      query.VaultID,
+			DocID:        &query.DocIDs[i],
+			Path:         query.Path,
+			UpstreamAuth: (*openapi.DocQueryAO1UpstreamAuth)(query.UpstreamAuth),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch document %s: %w", query.DocIDs[i], err)
+		}
+
+		documents = append(documents, document)
+	}
+
+	return documents, nil
+}
 
+func (o *Operation) fetchStructuredDocument(docQuery *openapi.DocQuery) (interface{}, error) {
 	contents, err := o.ReadDocQuery(docQuery)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read Confidential Storage document: %w", err)
@@ -122,6 +414,24 @@ func (o *Operation) fetchDocument(query openapi.Query) (interface{}, error) {
 }
 
 func (o *Operation) resolveRefQuery(w http.ResponseWriter, query *openapi.RefQuery) (interface{}, bool) {
+	querySpec, proceed := o.refQuerySpec(w, query)
+	if !proceed {
+		return nil, false
+	}
+
+	document, err := o.fetchDocument(querySpec)
+	if err != nil {
+		respondErrorf(w, fetchErrorStatus(err),
+			"failed to fetch Confidential Storage document for refquery: %s", err.Error())
+
+		return nil, false
+	}
+
+	return document, true
+}
+
+// refQuerySpec returns the query saved with the reference of the RefQuery.
+func (o *Operation) refQuerySpec(w http.ResponseWriter, query *openapi.RefQuery) (openapi.Query, bool) {
 	raw, err := o.storage.queries.Get(*query.Ref)
 	if errors.Is(err, storage.ErrDataNotFound) {
 		respondErrorf(w, http.StatusBadRequest, "no such query: %s", *query.Ref)
@@ -152,13 +462,5 @@ func (o *Operation) resolveRefQuery(w http.ResponseWriter, query *openapi.RefQue
 		return nil, false
 	}
 
-	document, err := o.fetchDocument(querySpec)
-	if err != nil {
-		respondErrorf(w, http.StatusInternalServerError,
-			"failed to fetch Confidential Storage document for refquery: %s", err.Error())
-
-		return nil, false
-	}
-
-	return document, true
+	return querySpec, true
 }
@@ -8,6 +8,9 @@ package operation_test
 
 import (
 	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"io"
@@ -19,14 +22,18 @@ import (
 	"github.com/google/uuid"
 	"github.com/hyperledger/aries-framework-go/component/storageutil/mem"
 	"github.com/hyperledger/aries-framework-go/component/storageutil/mock"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/jose"
+	"github.com/hyperledger/aries-framework-go/pkg/vdr/fingerprint"
 	spi "github.com/hyperledger/aries-framework-go/spi/storage"
 	"github.com/stretchr/testify/require"
 	edv "github.com/trustbloc/edv/pkg/client"
+	"github.com/trustbloc/edv/pkg/restapi/models"
 
 	"github.com/trustbloc/ace/pkg/client/vault"
 	"github.com/trustbloc/ace/pkg/internal/mock/storage"
 	"github.com/trustbloc/ace/pkg/restapi/csh/operation"
 	openapi "github.com/trustbloc/ace/pkg/restapi/csh/operation/models"
+	"github.com/trustbloc/ace/pkg/zkp"
 )
 
 func TestOperation_HandleEqOp(t *testing.T) {
@@ -138,6 +145,259 @@ func TestOperation_HandleEqOp(t *testing.T) {
 		requireCompareResult(t, false, result.Body)
 	})
 
+	t.Run("all of 3 documents equal", func(t *testing.T) {
+		o := newOperationWithDocs(t, nameDoc(t, "alice"), nameDoc(t, "alice"), nameDoc(t, "alice"))
+		result := httptest.NewRecorder()
+
+		o.HandleEqOp(result, newMatchEqOp(t, openapi.EqOpMatchAll, nameQuery(), nameQuery(), nameQuery()))
+		require.Equal(t, http.StatusOK, result.Code)
+		requireCompareResult(t, true, result.Body)
+	})
+
+	t.Run("not all of 3 documents equal", func(t *testing.T) {
+		o := newOperationWithDocs(t, nameDoc(t, "alice"), nameDoc(t, "bob"), nameDoc(t, "alice"))
+		result := httptest.NewRecorder()
+
+		o.HandleEqOp(result, newMatchEqOp(t, openapi.EqOpMatchAll, nameQuery(), nameQuery(), nameQuery()))
+		require.Equal(t, http.StatusOK, result.Code)
+		requireCompareResult(t, false, result.Body)
+	})
+
+	t.Run("any 2 of 4 documents equal", func(t *testing.T) {
+		o := newOperationWithDocs(t,
+			nameDoc(t, "alice"), nameDoc(t, "bob"), nameDoc(t, "carol"), nameDoc(t, "bob"))
+		result := httptest.NewRecorder()
+
+		o.HandleEqOp(result, newMatchEqOp(t, openapi.EqOpMatchAny,
+			nameQuery(), nameQuery(), nameQuery(), nameQuery()))
+		require.Equal(t, http.StatusOK, result.Code)
+		requireCompareResult(t, true, result.Body)
+	})
+
+	t.Run("no 2 of 3 documents equal", func(t *testing.T) {
+		o := newOperationWithDocs(t, nameDoc(t, "alice"), nameDoc(t, "bob"), nameDoc(t, "carol"))
+		result := httptest.NewRecorder()
+
+		o.HandleEqOp(result, newMatchEqOp(t, openapi.EqOpMatchAny, nameQuery(), nameQuery(), nameQuery()))
+		require.Equal(t, http.StatusOK, result.Code)
+		requireCompareResult(t, false, result.Body)
+	})
+
+	t.Run("equal documents - 2 multi doc queries", func(t *testing.T) {
+		o := newOperationWithDocs(t,
+			nameDoc(t, "alice"), nameDoc(t, "bob"), nameDoc(t, "alice"), nameDoc(t, "bob"))
+		result := httptest.NewRecorder()
+
+		o.HandleEqOp(result, newEqOp(t, multiNameQuery(2), multiNameQuery(2)))
+		require.Equal(t, http.StatusOK, result.Code)
+		requireCompareResult(t, true, result.Body)
+	})
+
+	t.Run("unequal documents - 2 multi doc queries", func(t *testing.T) {
+		o := newOperationWithDocs(t,
+			nameDoc(t, "alice"), nameDoc(t, "bob"), nameDoc(t, "alice"), nameDoc(t, "carol"))
+		result := httptest.NewRecorder()
+
+		o.HandleEqOp(result, newEqOp(t, multiNameQuery(2), multiNameQuery(2)))
+		require.Equal(t, http.StatusOK, result.Code)
+		requireCompareResult(t, false, result.Body)
+	})
+
+	t.Run("error InternalServerError if multi doc query has no docIDs", func(t *testing.T) {
+		o := newOperationWithDocs(t, nameDoc(t, "alice"))
+		result := httptest.NewRecorder()
+
+		o.HandleEqOp(result, newEqOp(t, nameQuery(), multiNameQuery(0)))
+		require.Equal(t, http.StatusInternalServerError, result.Code)
+		require.Contains(t, result.Body.String(), "requires at least one docID")
+	})
+
+	t.Run("equal documents with proof", func(t *testing.T) {
+		v := newTestVault(t)
+		o := newOperationWithDocs(t, v.committedDoc(t, "alice"), v.committedDoc(t, "alice"), v.committedDoc(t, "alice"))
+		result := httptest.NewRecorder()
+
+		op := newEqOp(t, v.query(), v.query(), v.query())
+		op.Proof = true
+
+		o.HandleEqOp(result, op)
+		require.Equal(t, http.StatusOK, result.Code)
+
+		comparison := &openapi.Comparison{}
+		unmarshal(t, comparison, result.Body.Bytes())
+		require.True(t, comparison.Result)
+		require.NotNil(t, comparison.Proof)
+		require.Equal(t, zkp.Scheme, comparison.Proof.Scheme)
+		require.Len(t, comparison.Proof.Commitments, 3)
+		require.Len(t, comparison.Proof.Proofs, 2)
+		require.Equal(t, []string{v.keyID, v.keyID, v.keyID}, comparison.Proof.VerificationMethods)
+
+		for i, c := range comparison.Proof.Commitments {
+			require.True(t, ed25519.Verify(v.publicKey, zkp.SigningInput(decodeBase64URL(t, c)),
+				decodeBase64URL(t, comparison.Proof.Signatures[i])), "commitments are the ones signed by the vault")
+		}
+
+		first := decodeBase64URL(t, comparison.Proof.Commitments[0])
+
+		for i, p := range comparison.Proof.Proofs {
+			err := zkp.VerifyEquality(first, decodeBase64URL(t, comparison.Proof.Commitments[i+1]), &zkp.EqualityProof{
+				T: decodeBase64URL(t, p.T),
+				S: decodeBase64URL(t, p.S),
+			})
+			require.NoError(t, err)
+		}
+	})
+
+	t.Run("unequal documents with proof", func(t *testing.T) {
+		v := newTestVault(t)
+		o := newOperationWithDocs(t, v.committedDoc(t, "alice"), v.committedDoc(t, "bob"))
+		result := httptest.NewRecorder()
+
+		op := newEqOp(t, v.query(), v.query())
+		op.Proof = true
+
+		o.HandleEqOp(result, op)
+		require.Equal(t, http.StatusOK, result.Code)
+
+		comparison := &openapi.Comparison{}
+		unmarshal(t, comparison, result.Body.Bytes())
+		require.False(t, comparison.Result)
+		require.Nil(t, comparison.Proof)
+	})
+
+	t.Run("error InternalServerError if document changed since it was committed to", func(t *testing.T) {
+		v := newTestVault(t)
+		changed := v.commit(t, "alice")
+		changed.Content["name"] = "bob"
+
+		o := newOperationWithDocs(t, v.committedDoc(t, "bob"), marshal(t, changed))
+		result := httptest.NewRecorder()
+
+		op := newEqOp(t, v.query(), v.query())
+		op.Proof = true
+
+		o.HandleEqOp(result, op)
+		require.Equal(t, http.StatusInternalServerError, result.Code)
+		require.Contains(t, result.Body.String(), "document 1: "+zkp.ErrNotOpened.Error())
+	})
+
+	t.Run("error InternalServerError if commitment is not signed by the vault", func(t *testing.T) {
+		v, other := newTestVault(t), newTestVault(t)
+
+		forged := v.commit(t, "alice")
+		forged.Meta[zkp.MetaKey].(*zkp.SignedCommitment).Signature = other.commit(t, "alice").
+			Meta[zkp.MetaKey].(*zkp.SignedCommitment).Signature
+
+		o := newOperationWithDocs(t, marshal(t, forged), v.committedDoc(t, "alice"))
+		result := httptest.NewRecorder()
+
+		op := newEqOp(t, v.query(), v.query())
+		op.Proof = true
+
+		o.HandleEqOp(result, op)
+		require.Equal(t, http.StatusInternalServerError, result.Code)
+		require.Contains(t, result.Body.String(), "invalid commitment signature")
+
+		o = newOperationWithDocs(t, other.committedDoc(t, "alice"), v.committedDoc(t, "alice"))
+		result = httptest.NewRecorder()
+
+		o.HandleEqOp(result, op)
+		require.Equal(t, http.StatusInternalServerError, result.Code)
+		require.Contains(t, result.Body.String(), "failed to resolve commitment key")
+	})
+
+	t.Run("error BadRequest if document has no commitment", func(t *testing.T) {
+		v := newTestVault(t)
+		o := newOperationWithDocs(t, nameDoc(t, "alice"), nameDoc(t, "alice"))
+		result := httptest.NewRecorder()
+
+		op := newEqOp(t, v.query(), v.query())
+		op.Proof = true
+
+		o.HandleEqOp(result, op)
+		require.Equal(t, http.StatusBadRequest, result.Code)
+		require.Contains(t, result.Body.String(), "'EqOp' proofs require committed documents")
+	})
+
+	t.Run("error BadRequest if proof is requested over part of a document", func(t *testing.T) {
+		o := newOperationWithDocs(t, nameDoc(t, "alice"), nameDoc(t, "alice"))
+		result := httptest.NewRecorder()
+
+		op := newEqOp(t, nameQuery(), nameQuery())
+		op.Proof = true
+
+		o.HandleEqOp(result, op)
+		require.Equal(t, http.StatusBadRequest, result.Code)
+		require.Contains(t, result.Body.String(), "documents are committed to without a path")
+	})
+
+	t.Run("error BadRequest if proof is requested over a value", func(t *testing.T) {
+		v := newTestVault(t)
+		o := newOperationWithDocs(t, v.committedDoc(t, "alice"))
+		result := httptest.NewRecorder()
+
+		op := newEqOp(t, v.query(), valueQuery("alice"))
+		op.Proof = true
+
+		o.HandleEqOp(result, op)
+		require.Equal(t, http.StatusBadRequest, result.Code)
+		require.Contains(t, result.Body.String(), "'EqOp' proofs require document queries, not: ValueQuery")
+	})
+
+	t.Run("error BadRequest if proof is requested with match any", func(t *testing.T) {
+		o := newOperationWithDocs(t)
+		result := httptest.NewRecorder()
+
+		op := newMatchEqOp(t, openapi.EqOpMatchAny, nameQuery(), nameQuery())
+		op.Proof = true
+
+		o.HandleEqOp(result, op)
+		require.Equal(t, http.StatusBadRequest, result.Code)
+		require.Contains(t, result.Body.String(), "'EqOp' proofs require match: all")
+	})
+
+	t.Run("error reading DocQuery with proof", func(t *testing.T) {
+		config := agentConfig(newAgent(t))
+		config.EDVClient = func(string, ...edv.Option) vault.ConfidentialStorageDocReader {
+			return newMockEDVClient(t, errors.New("test error"))
+		}
+
+		o := newOperation(t, config)
+		result := httptest.NewRecorder()
+
+		v := newTestVault(t)
+
+		op := newEqOp(t, v.query(), v.query())
+		op.Proof = true
+
+		o.HandleEqOp(result, op)
+		require.Equal(t, http.StatusInternalServerError, result.Code)
+		require.Contains(t, result.Body.String(), "test error")
+	})
+
+	t.Run("error BadRequest on unsupported match", func(t *testing.T) {
+		o := newOperation(t, agentConfig(newAgent(t)))
+		result := httptest.NewRecorder()
+
+		o.HandleEqOp(result, newMatchEqOp(t, "most", nameQuery(), nameQuery()))
+		require.Equal(t, http.StatusBadRequest, result.Code)
+		require.Contains(t, result.Body.String(), "unsupported 'EqOp' match: most")
+	})
+
+	t.Run("error reading DocQuery with any match", func(t *testing.T) {
+		config := agentConfig(newAgent(t))
+		config.EDVClient = func(string, ...edv.Option) vault.ConfidentialStorageDocReader {
+			return newMockEDVClient(t, errors.New("test"))
+		}
+
+		o := newOperation(t, config)
+		result := httptest.NewRecorder()
+
+		o.HandleEqOp(result, newMatchEqOp(t, openapi.EqOpMatchAny, newDocQuery(t), newDocQuery(t)))
+		require.Equal(t, http.StatusInternalServerError, result.Code)
+		require.Contains(t, result.Body.String(), "failed to read Confidential Storage document")
+	})
+
 	t.Run("error BadRequest if there are less than 2 args", func(t *testing.T) {
 		o := newOperation(t, agentConfig(newAgent(t)))
 		result := httptest.NewRecorder()
@@ -210,10 +470,11 @@ func TestOperation_HandleEqOp(t *testing.T) {
 		config := config(t)
 		config.StoreProvider = &storage.MockProvider{
 			Stores: map[string]spi.Store{
-				"config":  &mock.Store{GetReturn: marshal(t, &operation.Identity{})},
-				"profile": &mock.Store{},
-				"queries": &mock.Store{ErrGet: expected},
-				"zcap":    &mock.Store{},
+				"config":      &mock.Store{GetReturn: marshal(t, &operation.Identity{})},
+				"profile":     &mock.Store{},
+				"queries":     &mock.Store{ErrGet: expected},
+				"revocations": &mock.Store{},
+				"zcap":        &mock.Store{},
 			},
 		}
 
@@ -375,6 +636,225 @@ func TestOperation_HandleEqOp(t *testing.T) {
 	})
 }
 
+func TestOperation_HandleFuzzyOp(t *testing.T) {
+	t.Run("levenshtein similar documents", func(t *testing.T) {
+		o := newOperationWithDocs(t, nameDoc(t, "Jon Smith"), nameDoc(t, " john  SMITH"))
+		result := httptest.NewRecorder()
+
+		o.HandleFuzzyOp(result, newFuzzyOp(t, "", 0.8, nameQuery(), nameQuery()))
+		require.Equal(t, http.StatusOK, result.Code)
+		requireFuzzyResult(t, true, 0.9, result.Body)
+	})
+
+	t.Run("levenshtein dissimilar documents", func(t *testing.T) {
+		o := newOperationWithDocs(t, nameDoc(t, "Jon Smith"), nameDoc(t, "Jane Smythe"))
+		result := httptest.NewRecorder()
+
+		o.HandleFuzzyOp(result, newFuzzyOp(t, openapi.FuzzyOpAlgorithmLevenshtein, 0.9, nameQuery(), nameQuery()))
+		require.Equal(t, http.StatusOK, result.Code)
+		requireFuzzyResult(t, false, 7.0/11, result.Body)
+	})
+
+	t.Run("soundex similar documents", func(t *testing.T) {
+		o := newOperationWithDocs(t, nameDoc(t, "Robert Ashcraft"), nameDoc(t, "Rupert Ashcroft"))
+		result := httptest.NewRecorder()
+
+		o.HandleFuzzyOp(result, newFuzzyOp(t, openapi.FuzzyOpAlgorithmSoundex, 1, nameQuery(), nameQuery()))
+		require.Equal(t, http.StatusOK, result.Code)
+		requireFuzzyResult(t, true, 1, result.Body)
+	})
+
+	t.Run("soundex dissimilar documents", func(t *testing.T) {
+		o := newOperationWithDocs(t, nameDoc(t, "Robert"), nameDoc(t, "Rubin"))
+		result := httptest.NewRecorder()
+
+		o.HandleFuzzyOp(result, newFuzzyOp(t, openapi.FuzzyOpAlgorithmSoundex, 0.9, nameQuery(), nameQuery()))
+		require.Equal(t, http.StatusOK, result.Code)
+		requireFuzzyResult(t, false, 0.5, result.Body)
+	})
+
+	t.Run("error BadRequest if there are less than 2 args", func(t *testing.T) {
+		o := newOperation(t, agentConfig(newAgent(t)))
+		result := httptest.NewRecorder()
+
+		o.HandleFuzzyOp(result, newFuzzyOp(t, "", 0.8, nameQuery()))
+		require.Equal(t, http.StatusBadRequest, result.Code)
+		require.Contains(t, result.Body.String(), "requires exactly two arguments")
+	})
+
+	t.Run("error BadRequest if threshold is out of range", func(t *testing.T) {
+		o := newOperation(t, agentConfig(newAgent(t)))
+		result := httptest.NewRecorder()
+
+		o.HandleFuzzyOp(result, newFuzzyOp(t, "", 1.5, nameQuery(), nameQuery()))
+		require.Equal(t, http.StatusBadRequest, result.Code)
+		require.Contains(t, result.Body.String(), "requires a threshold between 0 and 1")
+	})
+
+	t.Run("error BadRequest if algorithm is not supported", func(t *testing.T) {
+		o := newOperation(t, agentConfig(newAgent(t)))
+		result := httptest.NewRecorder()
+
+		o.HandleFuzzyOp(result, newFuzzyOp(t, "metaphone", 0.8, nameQuery(), nameQuery()))
+		require.Equal(t, http.StatusBadRequest, result.Code)
+		require.Contains(t, result.Body.String(), "unsupported fuzzy matching algorithm: metaphone")
+	})
+
+	t.Run("error reading DocQuery", func(t *testing.T) {
+		config := agentConfig(newAgent(t))
+		config.EDVClient = func(string, ...edv.Option) vault.ConfidentialStorageDocReader {
+			return newMockEDVClient(t, errors.New("test"))
+		}
+
+		o := newOperation(t, config)
+		result := httptest.NewRecorder()
+
+		o.HandleFuzzyOp(result, newFuzzyOp(t, "", 0.8, newDocQuery(t), newDocQuery(t)))
+		require.Equal(t, http.StatusInternalServerError, result.Code)
+		require.Contains(t, result.Body.String(), "failed to read Confidential Storage document")
+	})
+}
+
+func TestOperation_HandleGtOp(t *testing.T) {
+	t.Run("document greater than value", func(t *testing.T) {
+		o := newOperationWithDocs(t, valueDoc(t, 21))
+		result := httptest.NewRecorder()
+
+		o.HandleGtOp(result, newGtOp(t, valuePathQuery(), valueQuery("18")))
+		require.Equal(t, http.StatusOK, result.Code)
+		requireCompareResult(t, true, result.Body)
+	})
+
+	t.Run("document not greater than document", func(t *testing.T) {
+		o := newOperationWithDocs(t, valueDoc(t, "2003-05-01"), valueDoc(t, "2004-01-01T00:00:00Z"))
+		result := httptest.NewRecorder()
+
+		o.HandleGtOp(result, newGtOp(t, valuePathQuery(), valuePathQuery()))
+		require.Equal(t, http.StatusOK, result.Code)
+		requireCompareResult(t, false, result.Body)
+	})
+
+	t.Run("error BadRequest if there are not 2 args", func(t *testing.T) {
+		o := newOperation(t, agentConfig(newAgent(t)))
+		result := httptest.NewRecorder()
+
+		o.HandleGtOp(result, newGtOp(t, valueQuery("18")))
+		require.Equal(t, http.StatusBadRequest, result.Code)
+		require.Contains(t, result.Body.String(), "'GtOp' requires exactly 2 arguments")
+	})
+
+	t.Run("error BadRequest if values cannot be ordered", func(t *testing.T) {
+		o := newOperationWithDocs(t, valueDoc(t, "abc"))
+		result := httptest.NewRecorder()
+
+		o.HandleGtOp(result, newGtOp(t, valuePathQuery(), valueQuery("18")))
+		require.Equal(t, http.StatusBadRequest, result.Code)
+		require.Contains(t, result.Body.String(), "values must both be numbers or both be dates")
+	})
+
+	t.Run("error BadRequest if value type cannot be ordered", func(t *testing.T) {
+		o := newOperationWithDocs(t, valueDoc(t, map[string]interface{}{"a": "b"}))
+		result := httptest.NewRecorder()
+
+		o.HandleGtOp(result, newGtOp(t, valuePathQuery(), valueQuery("18")))
+		require.Equal(t, http.StatusBadRequest, result.Code)
+		require.Contains(t, result.Body.String(), "value of type map[string]interface {} cannot be ordered")
+	})
+
+	t.Run("error BadRequest if value query has no value", func(t *testing.T) {
+		o := newOperationWithDocs(t, valueDoc(t, 21))
+		result := httptest.NewRecorder()
+
+		o.HandleGtOp(result, newGtOp(t, valuePathQuery(), &openapi.ValueQuery{}))
+		require.Equal(t, http.StatusBadRequest, result.Code)
+		require.Contains(t, result.Body.String(), "'ValueQuery' requires a value")
+	})
+
+	t.Run("error reading DocQuery", func(t *testing.T) {
+		config := agentConfig(newAgent(t))
+		config.EDVClient = func(string, ...edv.Option) vault.ConfidentialStorageDocReader {
+			return newMockEDVClient(t, errors.New("test"))
+		}
+
+		o := newOperation(t, config)
+		result := httptest.NewRecorder()
+
+		o.HandleGtOp(result, newGtOp(t, newDocQuery(t), valueQuery("18")))
+		require.Equal(t, http.StatusInternalServerError, result.Code)
+		require.Contains(t, result.Body.String(), "failed to read Confidential Storage document")
+	})
+}
+
+func TestOperation_HandleLtOp(t *testing.T) {
+	t.Run("date before value", func(t *testing.T) {
+		o := newOperationWithDocs(t, valueDoc(t, "2003-05-01"))
+		result := httptest.NewRecorder()
+
+		o.HandleLtOp(result, newLtOp(t, valuePathQuery(), valueQuery("2004-01-01")))
+		require.Equal(t, http.StatusOK, result.Code)
+		requireCompareResult(t, true, result.Body)
+	})
+
+	t.Run("date not before value", func(t *testing.T) {
+		o := newOperationWithDocs(t, valueDoc(t, "2004-01-01"))
+		result := httptest.NewRecorder()
+
+		o.HandleLtOp(result, newLtOp(t, valuePathQuery(), valueQuery("2004-01-01")))
+		require.Equal(t, http.StatusOK, result.Code)
+		requireCompareResult(t, false, result.Body)
+	})
+
+	t.Run("error BadRequest if there are not 2 args", func(t *testing.T) {
+		o := newOperation(t, agentConfig(newAgent(t)))
+		result := httptest.NewRecorder()
+
+		o.HandleLtOp(result, newLtOp(t))
+		require.Equal(t, http.StatusBadRequest, result.Code)
+		require.Contains(t, result.Body.String(), "'LtOp' requires exactly 2 arguments")
+	})
+}
+
+func TestOperation_HandleRangeOp(t *testing.T) {
+	t.Run("number in range", func(t *testing.T) {
+		o := newOperationWithDocs(t, valueDoc(t, 65))
+		result := httptest.NewRecorder()
+
+		o.HandleRangeOp(result, newRangeOp(t,
+			valuePathQuery(), valueQuery("18"), valueQuery("65")))
+		require.Equal(t, http.StatusOK, result.Code)
+		requireCompareResult(t, true, result.Body)
+	})
+
+	t.Run("number out of range", func(t *testing.T) {
+		o := newOperationWithDocs(t, valueDoc(t, 70.5))
+		result := httptest.NewRecorder()
+
+		o.HandleRangeOp(result, newRangeOp(t,
+			valuePathQuery(), valueQuery("18"), valueQuery("65")))
+		require.Equal(t, http.StatusOK, result.Code)
+		requireCompareResult(t, false, result.Body)
+	})
+
+	t.Run("error BadRequest if there are not 3 args", func(t *testing.T) {
+		o := newOperation(t, agentConfig(newAgent(t)))
+		result := httptest.NewRecorder()
+
+		o.HandleRangeOp(result, newRangeOp(t, valuePathQuery(), valueQuery("18")))
+		require.Equal(t, http.StatusBadRequest, result.Code)
+		require.Contains(t, result.Body.String(), "'RangeOp' requires exactly 3 arguments")
+	})
+
+	t.Run("error BadRequest if bound cannot be ordered", func(t *testing.T) {
+		o := newOperationWithDocs(t, valueDoc(t, 21))
+		result := httptest.NewRecorder()
+
+		o.HandleRangeOp(result, newRangeOp(t,
+			valuePathQuery(), valueQuery("18"), valueQuery("2004-01-01")))
+		require.Equal(t, http.StatusBadRequest, result.Code)
+		require.Contains(t, result.Body.String(), "values must both be numbers or both be dates")
+	})
+}
+
 func requireCompareResult(t *testing.T, expected bool, r io.Reader) {
 	t.Helper()
 
@@ -386,12 +866,28 @@ func requireCompareResult(t *testing.T, expected bool, r io.Reader) {
 	require.Equal(t, expected, actual.Result)
 }
 
+func decodeBase64URL(t *testing.T, s string) []byte {
+	t.Helper()
+
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	require.NoError(t, err)
+
+	return raw
+}
+
 func newEqOp(t *testing.T, queries ...interface{}) *openapi.EqOp {
 	t.Helper()
 
+	return newMatchEqOp(t, "", queries...)
+}
+
+func newMatchEqOp(t *testing.T, match string, queries ...interface{}) *openapi.EqOp {
+	t.Helper()
+
 	payload := map[string]interface{}{
-		"type": "EqOp",
-		"args": queries,
+		"type":  "EqOp",
+		"match": match,
+		"args":  queries,
 	}
 
 	raw, err := json.Marshal(payload)
@@ -404,3 +900,225 @@ func newEqOp(t *testing.T, queries ...interface{}) *openapi.EqOp {
 
 	return op
 }
+
+func requireFuzzyResult(t *testing.T, expected bool, score float64, r io.Reader) {
+	t.Helper()
+
+	actual := &openapi.Comparison{}
+
+	err := json.NewDecoder(r).Decode(actual)
+	require.NoError(t, err)
+
+	require.Equal(t, expected, actual.Result)
+	require.InDelta(t, score, actual.Score, 0.0001)
+}
+
+func newFuzzyOp(t *testing.T, algorithm string, threshold float64, queries ...interface{}) *openapi.FuzzyOp {
+	t.Helper()
+
+	payload := map[string]interface{}{
+		"type":      "FuzzyOp",
+		"algorithm": algorithm,
+		"threshold": threshold,
+		"args":      queries,
+	}
+
+	raw, err := json.Marshal(payload)
+	require.NoError(t, err)
+
+	op := &openapi.FuzzyOp{}
+
+	err = json.Unmarshal(raw, op)
+	require.NoError(t, err)
+
+	return op
+}
+
+func nameDoc(t *testing.T, name string) []byte {
+	t.Helper()
+
+	raw, err := json.Marshal(&models.StructuredDocument{
+		ID:      uuid.New().String(),
+		Content: map[string]interface{}{"name": name},
+	})
+	require.NoError(t, err)
+
+	return raw
+}
+
+// testVault signs the commitments to the documents it holds, as the vault server does when they are protected.
+type testVault struct {
+	did        string
+	keyID      string
+	publicKey  ed25519.PublicKey
+	privateKey ed25519.PrivateKey
+}
+
+func newTestVault(t *testing.T) *testVault {
+	t.Helper()
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	did, keyID := fingerprint.CreateDIDKey(pub)
+
+	return &testVault{did: did, keyID: keyID, publicKey: pub, privateKey: priv}
+}
+
+// commit returns a document with the name, committed to by the vault.
+func (v *testVault) commit(t *testing.T, name string) *models.StructuredDocument {
+	t.Helper()
+
+	content := map[string]interface{}{"name": name}
+
+	raw, err := json.Marshal(content)
+	require.NoError(t, err)
+
+	c, err := zkp.Commit(raw)
+	require.NoError(t, err)
+
+	return &models.StructuredDocument{
+		ID: uuid.New().String(),
+		Meta: map[string]interface{}{zkp.MetaKey: &zkp.SignedCommitment{
+			Scheme:             zkp.Scheme,
+			Point:              base64.RawURLEncoding.EncodeToString(c.Point),
+			Blinding:           base64.RawURLEncoding.EncodeToString(c.Blinding()),
+			VerificationMethod: v.keyID,
+			Signature:          base64.RawURLEncoding.EncodeToString(ed25519.Sign(v.privateKey, zkp.SigningInput(c.Point))),
+		}},
+		Content: content,
+	}
+}
+
+func (v *testVault) committedDoc(t *testing.T, name string) []byte {
+	t.Helper()
+
+	return marshal(t, v.commit(t, name))
+}
+
+// query returns a query of a whole document of the vault.
+func (v *testVault) query() *openapi.DocQuery {
+	query := docQuery(&openapi.UpstreamAuthorization{
+		BaseURL: "https://edv.example.com",
+	}, nil)
+	query.VaultID = &v.did
+
+	return query
+}
+
+func nameQuery() *openapi.DocQuery {
+	query := docQuery(&openapi.UpstreamAuthorization{
+		BaseURL: "https://edv.example.com",
+	}, nil)
+	query.Path = "$.name"
+
+	return query
+}
+
+func multiNameQuery(numDocs int) *openapi.MultiDocQuery {
+	vaultID := uuid.New().String()
+	docIDs := make([]string, numDocs)
+
+	for i := range docIDs {
+		docIDs[i] = uuid.New().String()
+	}
+
+	return &openapi.MultiDocQuery{
+		VaultID: &vaultID,
+		DocIDs:  docIDs,
+		Path:    "$.name",
+		UpstreamAuth: &openapi.MultiDocQueryAO1UpstreamAuth{
+			Edv: &openapi.UpstreamAuthorization{
+				BaseURL: "https://edv.example.com",
+			},
+		},
+	}
+}
+
+func newGtOp(t *testing.T, queries ...interface{}) *openapi.GtOp {
+	t.Helper()
+
+	op := &openapi.GtOp{}
+	unmarshalOp(t, op, queries...)
+
+	return op
+}
+
+func newLtOp(t *testing.T, queries ...interface{}) *openapi.LtOp {
+	t.Helper()
+
+	op := &openapi.LtOp{}
+	unmarshalOp(t, op, queries...)
+
+	return op
+}
+
+func newRangeOp(t *testing.T, queries ...interface{}) *openapi.RangeOp {
+	t.Helper()
+
+	op := &openapi.RangeOp{}
+	unmarshalOp(t, op, queries...)
+
+	return op
+}
+
+func unmarshalOp(t *testing.T, op openapi.Operator, queries ...interface{}) {
+	t.Helper()
+
+	payload := map[string]interface{}{
+		"type": op.Type(),
+		"args": queries,
+	}
+
+	raw, err := json.Marshal(payload)
+	require.NoError(t, err)
+
+	err = json.Unmarshal(raw, op)
+	require.NoError(t, err)
+}
+
+func newOperationWithDocs(t *testing.T, docs ...[]byte) *operation.Operation {
+	t.Helper()
+
+	agent := newAgent(t)
+
+	jwes := make([]*jose.JSONWebEncryption, len(docs))
+
+	for i := range docs {
+		jwes[i] = encryptedJWE(t, agent, docs[i])
+	}
+
+	edvClient := newMockEDVClient(t, nil, jwes...)
+
+	config := agentConfig(agent)
+	config.EDVClient = func(string, ...edv.Option) vault.ConfidentialStorageDocReader {
+		return edvClient
+	}
+
+	return newOperation(t, config)
+}
+
+func valueDoc(t *testing.T, value interface{}) []byte {
+	t.Helper()
+
+	raw, err := json.Marshal(&models.StructuredDocument{
+		ID:      uuid.New().String(),
+		Content: map[string]interface{}{"value": value},
+	})
+	require.NoError(t, err)
+
+	return raw
+}
+
+func valuePathQuery() *openapi.DocQuery {
+	query := docQuery(&openapi.UpstreamAuthorization{
+		BaseURL: "https://edv.example.com",
+	}, nil)
+	query.Path = "$.value"
+
+	return query
+}
+
+func valueQuery(value string) *openapi.ValueQuery {
+	return &openapi.ValueQuery{Value: &value}
+}
@@ -0,0 +1,112 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package operation
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/did"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/signature/verifier"
+	"github.com/trustbloc/edge-core/pkg/zcapld"
+
+	"github.com/trustbloc/ace/pkg/httpsig"
+	zcapld2 "github.com/trustbloc/ace/pkg/restapi/csh/operation/zcapld"
+)
+
+var errNotController = errors.New("request not signed by the profile's controller")
+
+// verifyController verifies that the request is signed with an HTTP signature by the controller of the profile: with
+// the controller's key if the controller is a DID URL, or with an authentication or capability invocation key of the
+// controller's DID otherwise.
+func (o *Operation) verifyController(r *http.Request, controller string) error {
+	v := httpsig.NewVerifier(&didKeyResolver{did: controller, resolvers: o.aries.DIDResolvers})
+
+	if ok, _ := v.VerifyRequest(r); !ok {
+		return errNotController
+	}
+
+	return nil
+}
+
+// didKeyResolver resolves the keys of a DID, or of a DID URL, and only those: the keys of the controller of a profile
+// or of the vault holding a document.
+type didKeyResolver struct {
+	did       string
+	resolvers []zcapld2.DIDResolver
+}
+
+func (c *didKeyResolver) Resolve(keyID string) (*verifier.PublicKey, error) {
+	didID := strings.Split(keyID, "#")[0]
+
+	if keyID != c.did && didID != c.did {
+		return nil, fmt.Errorf("key %s is not a key of %s", keyID, c.did)
+	}
+
+	id, err := did.Parse(didID)
+	if err != nil {
+		return nil, fmt.Errorf("parse DID %s: %w", didID, err)
+	}
+
+	for _, r := range c.resolvers {
+		if !r.Accept(id.Method) {
+			continue
+		}
+
+		resolution, e := r.Read(didID)
+		if e != nil {
+			return nil, fmt.Errorf("resolve DID %s: %w", didID, e)
+		}
+
+		for _, rel := range []did.VerificationRelationship{did.Authentication, did.CapabilityInvocation} {
+			for _, vm := range resolution.DIDDocument.VerificationMethods(rel)[rel] {
+				if vm.VerificationMethod.ID == keyID || didID+vm.VerificationMethod.ID == keyID {
+					return &verifier.PublicKey{
+						Type:  vm.VerificationMethod.Type,
+						Value: vm.VerificationMethod.Value,
+						JWK:   vm.VerificationMethod.JSONWebKey(),
+					}, nil
+				}
+			}
+		}
+
+		return nil, fmt.Errorf("key %s not found in DID %s", keyID, didID)
+	}
+
+	return nil, fmt.Errorf("no resolver configured for method [%s]", id.Method)
+}
+
+// rotateRootZCAP replaces the root zcap of the profile with a new one issued to the controller. The previous root zcap
+// is revoked before it is replaced, so that it is never valid alongside the new one.
+func (o *Operation) rotateRootZCAP(profileID, controller string) (*zcapld.Capability, error) {
+	old := &zcapld.Capability{}
+
+	err := load(o.storage.zcaps, profileID, old)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch zcap: %w", err)
+	}
+
+	zcap, err := o.newProfileZCAP(profileID, uuid.New().URN(), controller)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create zcap: %w", err)
+	}
+
+	err = o.revoke(old.ID, profileID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to revoke zcap: %w", err)
+	}
+
+	err = save(o.storage.zcaps, profileID, zcap)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store zcap: %w", err)
+	}
+
+	return zcap, nil
+}
@@ -8,6 +8,7 @@ package operation
 
 import (
 	"encoding/json"
+	"time"
 
 	"github.com/hyperledger/aries-framework-go/pkg/doc/did"
 )
@@ -19,6 +20,13 @@ type Query struct {
 	Spec      json.RawMessage
 }
 
+// Revocation is an entry in the revocation registry for a zcap delegated under a profile.
+type Revocation struct {
+	ZCAPID    string
+	ProfileID string
+	RevokedAt time.Time
+}
+
 // Identity is the Confidential Storage Hub's identity.
 type Identity struct {
 	DIDDoc           *did.Doc
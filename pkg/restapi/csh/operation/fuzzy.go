@@ -0,0 +1,199 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package operation
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode"
+
+	openapi "github.com/trustbloc/ace/pkg/restapi/csh/operation/models"
+)
+
+const soundexCodeLen = 4
+
+// similarityFuncs maps the supported FuzzyOp algorithms to their similarity functions.
+var similarityFuncs = map[string]func(s1, s2 string) float64{ //nolint:gochecknoglobals
+	"":                                  levenshteinSimilarity,
+	openapi.FuzzyOpAlgorithmLevenshtein: levenshteinSimilarity,
+	openapi.FuzzyOpAlgorithmSoundex: func(s1, s2 string) float64 {
+		return levenshteinSimilarity(soundexPhrase(s1), soundexPhrase(s2))
+	},
+}
+
+// similarity returns the similarity score, between 0 and 1, of two documents using the given algorithm.
+func similarity(algorithm string, doc1, doc2 interface{}) (float64, error) {
+	similarityFunc, ok := similarityFuncs[algorithm]
+	if !ok {
+		return 0, fmt.Errorf("unsupported fuzzy matching algorithm: %s", algorithm)
+	}
+
+	s1, err := normalize(doc1)
+	if err != nil {
+		return 0, err
+	}
+
+	s2, err := normalize(doc2)
+	if err != nil {
+		return 0, err
+	}
+
+	return similarityFunc(s1, s2), nil
+}
+
+// normalize converts a document to a lowercase string with collapsed whitespace.
+func normalize(doc interface{}) (string, error) {
+	s, ok := doc.(string)
+	if !ok {
+		raw, err := json.Marshal(doc)
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal document: %w", err)
+		}
+
+		s = string(raw)
+	}
+
+	return strings.Join(strings.Fields(strings.ToLower(s)), " "), nil
+}
+
+// levenshteinSimilarity returns the Levenshtein distance of two strings normalized to a score between 0 and 1.
+func levenshteinSimilarity(s1, s2 string) float64 {
+	r1, r2 := []rune(s1), []rune(s2)
+
+	maxLen := len(r1)
+	if len(r2) > maxLen {
+		maxLen = len(r2)
+	}
+
+	if maxLen == 0 {
+		return 1
+	}
+
+	return 1 - float64(levenshtein(r1, r2))/float64(maxLen)
+}
+
+func levenshtein(r1, r2 []rune) int {
+	prev := make([]int, len(r2)+1)
+	curr := make([]int, len(r2)+1)
+
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(r1); i++ {
+		curr[0] = i
+
+		for j := 1; j <= len(r2); j++ {
+			cost := 1
+			if r1[i-1] == r2[j-1] {
+				cost = 0
+			}
+
+			curr[j] = minInt(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+
+		prev, curr = curr, prev
+	}
+
+	return prev[len(r2)]
+}
+
+func minInt(values ...int) int {
+	m := values[0]
+
+	for _, v := range values[1:] {
+		if v < m {
+			m = v
+		}
+	}
+
+	return m
+}
+
+// soundexPhrase returns the space-separated Soundex codes of each word in s.
+func soundexPhrase(s string) string {
+	words := strings.Fields(s)
+	codes := make([]string, 0, len(words))
+
+	for _, word := range words {
+		if code := soundex(word); code != "" {
+			codes = append(codes, code)
+		}
+	}
+
+	return strings.Join(codes, " ")
+}
+
+// soundex returns the American Soundex code of a word, ignoring any non-letter characters.
+func soundex(word string) string {
+	var (
+		code     []rune
+		lastCode rune
+	)
+
+	for _, r := range word {
+		if !unicode.IsLetter(r) || r > unicode.MaxASCII {
+			continue
+		}
+
+		r = unicode.ToUpper(r)
+		digit := soundexDigit(r)
+
+		if len(code) == 0 {
+			code = append(code, r)
+			lastCode = digit
+
+			continue
+		}
+
+		switch {
+		case r == 'H' || r == 'W':
+			// H and W do not separate letters with the same code.
+			continue
+		case digit == 0:
+			// vowels separate letters with the same code.
+			lastCode = 0
+		case digit != lastCode:
+			code = append(code, digit)
+			lastCode = digit
+		}
+
+		if len(code) == soundexCodeLen {
+			break
+		}
+	}
+
+	if len(code) == 0 {
+		return ""
+	}
+
+	for len(code) < soundexCodeLen {
+		code = append(code, '0')
+	}
+
+	return string(code)
+}
+
+func soundexDigit(r rune) rune {
+	switch r {
+	case 'B', 'F', 'P', 'V':
+		return '1'
+	case 'C', 'G', 'J', 'K', 'Q', 'S', 'X', 'Z':
+		return '2'
+	case 'D', 'T':
+		return '3'
+	case 'L':
+		return '4'
+	case 'M', 'N':
+		return '5'
+	case 'R':
+		return '6'
+	default:
+		return 0
+	}
+}
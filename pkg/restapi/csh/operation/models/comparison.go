@@ -13,6 +13,7 @@ package models
 import (
 	"context"
 
+	"github.com/go-openapi/errors"
 	"github.com/go-openapi/strfmt"
 	"github.com/go-openapi/swag"
 )
@@ -22,17 +23,76 @@ import (
 // swagger:model Comparison
 type Comparison struct {
 
+	// Zero-knowledge proof of the result. Only set when requested from an EqOp and the documents are equal.
+	Proof *EqualityProof `json:"proof,omitempty"`
+
 	// result
 	Result bool `json:"result,omitempty"`
+
+	// Similarity score between 0 and 1. Only set by approximate comparison operators.
+	Score float64 `json:"score,omitempty"`
 }
 
 // Validate validates this comparison
 func (m *Comparison) Validate(formats strfmt.Registry) error {
+	var res []error
+
+	if err := m.validateProof(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if len(res) > 0 {
+		return errors.CompositeValidationError(res...)
+	}
 	return nil
 }
 
-// ContextValidate validates this comparison based on context it is used
+func (m *Comparison) validateProof(formats strfmt.Registry) error {
+	if swag.IsZero(m.Proof) { // not required
+		return nil
+	}
+
+	if m.Proof != nil {
+		if err := m.Proof.Validate(formats); err != nil {
+			if ve, ok := err.(*errors.Validation); ok {
+				return ve.ValidateName("proof")
+			} else if ce, ok := err.(*errors.CompositeError); ok {
+				return ce.ValidateName("proof")
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ContextValidate validate this comparison based on the context it is used
 func (m *Comparison) ContextValidate(ctx context.Context, formats strfmt.Registry) error {
+	var res []error
+
+	if err := m.contextValidateProof(ctx, formats); err != nil {
+		res = append(res, err)
+	}
+
+	if len(res) > 0 {
+		return errors.CompositeValidationError(res...)
+	}
+	return nil
+}
+
+func (m *Comparison) contextValidateProof(ctx context.Context, formats strfmt.Registry) error {
+
+	if m.Proof != nil {
+		if err := m.Proof.ContextValidate(ctx, formats); err != nil {
+			if ve, ok := err.(*errors.Validation); ok {
+				return ve.ValidateName("proof")
+			} else if ce, ok := err.(*errors.CompositeError); ok {
+				return ce.ValidateName("proof")
+			}
+			return err
+		}
+	}
+
 	return nil
 }
 
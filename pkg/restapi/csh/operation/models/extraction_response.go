@@ -85,6 +85,9 @@ type ExtractionResponseItems0 struct {
 	// document
 	Document interface{} `json:"document,omitempty"`
 
+	// documents
+	Documents map[string]interface{} `json:"documents,omitempty"`
+
 	// id
 	ID string `json:"id,omitempty"`
 }
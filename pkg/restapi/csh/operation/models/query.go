@@ -120,6 +120,12 @@ func unmarshalQuery(data []byte, consumer runtime.Consumer) (Query, error) {
 			return nil, err
 		}
 		return &result, nil
+	case "MultiDocQuery":
+		var result MultiDocQuery
+		if err := consumer.Consume(buf2, &result); err != nil {
+			return nil, err
+		}
+		return &result, nil
 	case "Query":
 		var result query
 		if err := consumer.Consume(buf2, &result); err != nil {
@@ -132,6 +138,12 @@ func unmarshalQuery(data []byte, consumer runtime.Consumer) (Query, error) {
 			return nil, err
 		}
 		return &result, nil
+	case "ValueQuery":
+		var result ValueQuery
+		if err := consumer.Consume(buf2, &result); err != nil {
+			return nil, err
+		}
+		return &result, nil
 	}
 	return nil, errors.New(422, "invalid type value: %q", getType.Type)
 }
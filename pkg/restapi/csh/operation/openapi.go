@@ -77,6 +77,59 @@ type createAuthorizationResp struct { // nolint:deadcode,unused // swagger model
 	Body openapi.Authorization
 }
 
+// revocationReq model
+//
+// swagger:parameters revocationReq
+type revocationReq struct { // nolint:deadcode,unused // swagger model
+	// in: path
+	// required: true
+	ProfileID string `json:"profileID"`
+
+	// in: body
+	Body openapi.Revocation
+}
+
+// revocationResp model
+//
+// swagger:response revocationResp
+type revocationResp struct{} // nolint:deadcode,unused // swagger model
+
+// revocationStatusReq model
+//
+// swagger:parameters revocationStatusReq
+type revocationStatusReq struct { // nolint:deadcode,unused // swagger model
+	// in: body
+	Body openapi.RevocationStatusRequest
+}
+
+// Revocation status of zcaps.
+//
+// swagger:response revocationStatusResp
+type revocationStatusResp struct { // nolint:deadcode,unused // swagger model
+	// in: body
+	Body openapi.RevocationStatus
+}
+
+// rotationReq model
+//
+// swagger:parameters rotationReq
+type rotationReq struct { // nolint:deadcode,unused // swagger model
+	// in: path
+	// required: true
+	ProfileID string `json:"profileID"`
+
+	// in: body
+	Body openapi.Profile
+}
+
+// Profile with its re-issued zcap.
+//
+// swagger:response rotationResp
+type rotationResp struct { // nolint:deadcode,unused // swagger model
+	// in: body
+	Body openapi.Profile
+}
+
 // comparisonReq model
 //
 // swagger:parameters comparisonReq
@@ -7,10 +7,13 @@ SPDX-License-Identifier: Apache-2.0
 package operation
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"net/http"
+	"time"
 
 	"github.com/go-openapi/runtime"
 	"github.com/google/uuid"
@@ -25,6 +28,7 @@ import (
 	"github.com/hyperledger/aries-framework-go/pkg/kms/webkms"
 	"github.com/hyperledger/aries-framework-go/spi/storage"
 	"github.com/piprate/json-gold/ld"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/trustbloc/edge-core/pkg/log"
 	"github.com/trustbloc/edge-core/pkg/zcapld"
 	edv "github.com/trustbloc/edv/pkg/client"
@@ -41,16 +45,20 @@ const (
 	createProfilePath = operationID
 	createQueryPath   = operationID + "/{profileID}/queries"
 	createAuthzPath   = operationID + "/{profileID}/authorizations"
+	revocationPath    = operationID + "/{profileID}/revocations"
+	rotationPath      = operationID + "/{profileID}/rotations"
 
-	comparePath = "/compare"
-	extractPath = "/extract"
+	comparePath          = "/compare"
+	extractPath          = "/extract"
+	revocationStatusPath = "/revocations/status"
 )
 
 const (
-	profileStore = "profile"
-	zcapStore    = "zcap"
-	queryStore   = "queries"
-	configStore  = "config"
+	profileStore    = "profile"
+	zcapStore       = "zcap"
+	queryStore      = "queries"
+	configStore     = "config"
+	revocationStore = "revocations"
 
 	identityKey = "config"
 )
@@ -60,10 +68,11 @@ var logger = log.New("confidential-storage-hub")
 // Operation defines handlers for vault service.
 type Operation struct {
 	storage *struct {
-		profiles storage.Store
-		zcaps    storage.Store
-		queries  storage.Store
-		config   storage.Store
+		profiles    storage.Store
+		zcaps       storage.Store
+		queries     storage.Store
+		config      storage.Store
+		revocations storage.Store
 	}
 	aries          *AriesConfig
 	httpClient     *http.Client
@@ -71,6 +80,7 @@ type Operation struct {
 	baseURL        string
 	didDomain      string
 	documentLoader ld.DocumentLoader
+	capabilities   *capabilityCache
 }
 
 // Config defines configuration for vault operations.
@@ -82,6 +92,11 @@ type Config struct {
 	BaseURL        string
 	DIDDomain      string
 	DocumentLoader ld.DocumentLoader
+	// CapabilityCacheTTL is the time the zcaps checked against the revocation registry are cached for. Zero disables
+	// the cache.
+	CapabilityCacheTTL time.Duration
+	// MetricsRegisterer, if set, registers the metrics of the capability cache.
+	MetricsRegisterer prometheus.Registerer
 }
 
 // AriesConfig holds all configurations for aries-framework-go dependencies.
@@ -110,6 +125,13 @@ func New(cfg *Config) (*Operation, error) {
 		return nil, fmt.Errorf("failed to configure operations: %w", err)
 	}
 
+	if cfg.CapabilityCacheTTL > 0 {
+		ops.capabilities, err = newCapabilityCache(cfg.CapabilityCacheTTL, cfg.MetricsRegisterer)
+		if err != nil {
+			return nil, fmt.Errorf("failed to init capability cache: %w", err)
+		}
+	}
+
 	return ops, nil
 }
 
@@ -119,8 +141,11 @@ func (o *Operation) GetRESTHandlers() []handler.Handler {
 		handler.NewHTTPHandler(createProfilePath, http.MethodPost, o.CreateProfile),
 		handler.NewHTTPHandler(createQueryPath, http.MethodPost, o.CreateQuery),
 		handler.NewHTTPHandler(createAuthzPath, http.MethodPost, o.CreateAuthorization),
+		handler.NewHTTPHandler(revocationPath, http.MethodPost, o.RevokeCapability),
+		handler.NewHTTPHandler(rotationPath, http.MethodPost, o.RotateProfileKeys),
 		handler.NewHTTPHandler(comparePath, http.MethodPost, o.Compare),
 		handler.NewHTTPHandler(extractPath, http.MethodPost, o.Extract),
+		handler.NewHTTPHandler(revocationStatusPath, http.MethodPost, o.RevocationStatus),
 	}
 }
 
@@ -153,7 +178,7 @@ func (o *Operation) CreateProfile(w http.ResponseWriter, r *http.Request) {
 
 	profile.ID = uuid.New().URN()
 
-	zcap, err := o.newProfileZCAP(profile.ID, *profile.Controller)
+	zcap, err := o.newProfileZCAP(profile.ID, profile.ID, *profile.Controller)
 	if err != nil {
 		respondErrorf(w, http.StatusInternalServerError, "failed to create zcap: %s", err.Error())
 
@@ -214,8 +239,14 @@ func (o *Operation) CreateQuery(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	switch query.(type) {
-	case *openapi.DocQuery: // allow DocQuery
+	switch q := query.(type) {
+	case *openapi.DocQuery:
+		// the zcaps of the query are checked again whenever it is resolved
+		if err = o.checkRevocations(q); err != nil {
+			respondErrorf(w, fetchErrorStatus(err), "failed to check query zcaps: %s", err.Error())
+
+			return
+		}
 	case *openapi.RefQuery:
 		respondErrorf(w, http.StatusBadRequest, "query type not allowed: %s", query.Type())
 
@@ -271,6 +302,181 @@ func (o *Operation) CreateAuthorization(w http.ResponseWriter, _ *http.Request)
 	w.WriteHeader(http.StatusCreated)
 }
 
+// RevokeCapability swagger:route POST /hubstore/profiles/{profileID}/revocations revocationReq
+//
+// Revokes a zcap delegated under a profile. Revoked zcaps, and zcaps delegated from them, are rejected when
+// invoked in a query.
+//
+// Consumes:
+//   - application/json
+// Responses:
+//   204: revocationResp
+//   400: Error
+//   404: Error
+//   500: Error
+func (o *Operation) RevokeCapability(w http.ResponseWriter, r *http.Request) {
+	logger.Debugf("handling request")
+
+	request := &openapi.Revocation{}
+
+	err := json.NewDecoder(r.Body).Decode(request)
+	if err != nil {
+		respondErrorf(w, http.StatusBadRequest, "bad request: %s", err.Error())
+
+		return
+	}
+
+	if request.ZcapID == nil || *request.ZcapID == "" {
+		respondErrorf(w, http.StatusBadRequest, "missing zcapID")
+
+		return
+	}
+
+	profileID := mux.Vars(r)["profileID"]
+
+	_, err = o.storage.profiles.Get(profileID)
+	if errors.Is(err, storage.ErrDataNotFound) {
+		respondErrorf(w, http.StatusNotFound, "no such profile: %s", profileID)
+
+		return
+	}
+
+	if err != nil {
+		respondErrorf(w, http.StatusInternalServerError, "failed to fetch profile: %s", err.Error())
+
+		return
+	}
+
+	err = o.revoke(*request.ZcapID, profileID)
+	if err != nil {
+		respondErrorf(w, http.StatusInternalServerError, "failed to store revocation: %s", err.Error())
+
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	logger.Debugf("handled request")
+}
+
+// RotateProfileKeys swagger:route POST /hubstore/profiles/{profileID}/rotations rotationReq
+//
+// Rotates a profile's controller key and re-issues the profile's root zcap to the new controller. The request must be
+// signed with an HTTP signature by the current controller. The previous root zcap is revoked, along with the zcaps
+// delegated from it, and the new root zcap keeps the profile as its invocation target so that queries referencing the
+// profile remain valid.
+//
+// Consumes:
+//   - application/json
+// Produces:
+//   - application/json
+// Responses:
+//   200: rotationResp
+//   400: Error
+//   403: Error
+//   404: Error
+//   500: Error
+func (o *Operation) RotateProfileKeys(w http.ResponseWriter, r *http.Request) {
+	logger.Debugf("handling request")
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		respondErrorf(w, http.StatusBadRequest, "bad request: %s", err.Error())
+
+		return
+	}
+
+	// the body is read again to verify its digest, signed with the request
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	request := &openapi.Profile{}
+
+	err = json.Unmarshal(body, request)
+	if err != nil {
+		respondErrorf(w, http.StatusBadRequest, "bad request: %s", err.Error())
+
+		return
+	}
+
+	if request.Controller == nil || *request.Controller == "" {
+		respondErrorf(w, http.StatusBadRequest, "missing controller")
+
+		return
+	}
+
+	profileID := mux.Vars(r)["profileID"]
+
+	raw, err := o.storage.profiles.Get(profileID)
+	if errors.Is(err, storage.ErrDataNotFound) {
+		respondErrorf(w, http.StatusNotFound, "no such profile: %s", profileID)
+
+		return
+	}
+
+	if err != nil {
+		respondErrorf(w, http.StatusInternalServerError, "failed to fetch profile: %s", err.Error())
+
+		return
+	}
+
+	profile := &openapi.Profile{}
+
+	err = json.Unmarshal(raw, profile)
+	if err != nil {
+		respondErrorf(w, http.StatusInternalServerError, "failed to parse profile: %s", err.Error())
+
+		return
+	}
+
+	if profile.Controller == nil {
+		respondErrorf(w, http.StatusInternalServerError, "profile has no controller: %s", profileID)
+
+		return
+	}
+
+	err = o.verifyController(r, *profile.Controller)
+	if err != nil {
+		respondErrorf(w, http.StatusForbidden, "%s", err.Error())
+
+		return
+	}
+
+	if *profile.Controller == *request.Controller {
+		respondErrorf(w, http.StatusBadRequest, "controller is unchanged")
+
+		return
+	}
+
+	zcap, err := o.rotateRootZCAP(profile.ID, *request.Controller)
+	if err != nil {
+		respondErrorf(w, http.StatusInternalServerError, "%s", err.Error())
+
+		return
+	}
+
+	profile.Controller = request.Controller
+
+	err = save(o.storage.profiles, profile.ID, profile)
+	if err != nil {
+		respondErrorf(w, http.StatusInternalServerError, "failed to store profile: %s", err.Error())
+
+		return
+	}
+
+	profile.Zcap, err = zcapld.CompressZCAP(zcap)
+	if err != nil {
+		respondErrorf(w, http.StatusInternalServerError, "failed to compress zcap: %s", err.Error())
+
+		return
+	}
+
+	headers := map[string]string{
+		"Content-Type": "application/json",
+	}
+
+	respond(w, http.StatusOK, headers, profile)
+	logger.Debugf("handled request")
+}
+
 // Compare swagger:route POST /hubstore/compare comparisonReq
 //
 // Performs a comparison.
@@ -281,6 +487,7 @@ func (o *Operation) CreateAuthorization(w http.ResponseWriter, _ *http.Request)
 //   - application/json
 // Responses:
 //   200: comparisonResp
+//   403: Error
 //   500: Error
 func (o *Operation) Compare(w http.ResponseWriter, r *http.Request) {
 	logger.Debugf("handling request")
@@ -297,6 +504,14 @@ func (o *Operation) Compare(w http.ResponseWriter, r *http.Request) {
 	switch t := request.Op().(type) {
 	case *openapi.EqOp:
 		o.HandleEqOp(w, t)
+	case *openapi.FuzzyOp:
+		o.HandleFuzzyOp(w, t)
+	case *openapi.GtOp:
+		o.HandleGtOp(w, t)
+	case *openapi.LtOp:
+		o.HandleLtOp(w, t)
+	case *openapi.RangeOp:
+		o.HandleRangeOp(w, t)
 	default:
 		respondErrorf(w, http.StatusNotImplemented, "operator not yet implemented: %s", request.Op().Type())
 	}
@@ -315,6 +530,7 @@ func (o *Operation) Compare(w http.ResponseWriter, r *http.Request) {
 // Responses:
 //   200: extractionResp
 //   400: Error
+//   403: Error
 //   500: Error
 func (o *Operation) Extract(w http.ResponseWriter, r *http.Request) {
 	logger.Debugf("handling request")
@@ -331,7 +547,10 @@ func (o *Operation) Extract(w http.ResponseWriter, r *http.Request) {
 	for i := range queries {
 		query := queries[i]
 
-		var doc interface{}
+		var (
+			doc  interface{}
+			docs map[string]interface{}
+		)
 
 		switch q := query.(type) {
 		case *openapi.DocQuery:
@@ -339,9 +558,16 @@ func (o *Operation) Extract(w http.ResponseWriter, r *http.Request) {
 
 			doc, err = o.fetchDocument(q)
 			if err != nil {
-				respondErrorf(w, http.StatusInternalServerError,
+				respondErrorf(w, fetchErrorStatus(err),
 					"failed to fetch document for DocQuery: %s", err.Error())
 
+				return
+			}
+		case *openapi.MultiDocQuery:
+			var proceed bool
+
+			docs, proceed = o.extractDocuments(w, q)
+			if !proceed {
 				return
 			}
 		case *openapi.RefQuery:
@@ -354,8 +580,9 @@ func (o *Operation) Extract(w http.ResponseWriter, r *http.Request) {
 		}
 
 		extractions = append(extractions, &openapi.ExtractionResponseItems0{
-			ID:       query.ID(),
-			Document: doc,
+			ID:        query.ID(),
+			Document:  doc,
+			Documents: docs,
 		})
 	}
 
@@ -367,9 +594,81 @@ func (o *Operation) Extract(w http.ResponseWriter, r *http.Request) {
 	logger.Debugf("handled request")
 }
 
+// RevocationStatus swagger:route POST /revocations/status revocationStatusReq
+//
+// Tells whether any zcap of the capability chains of the zcaps is revoked, for the callers caching the results of
+// queries invoking them.
+//
+// Consumes:
+//   - application/json
+// Produces:
+//   - application/json
+// Responses:
+//   200: revocationStatusResp
+//   400: Error
+//   500: Error
+func (o *Operation) RevocationStatus(w http.ResponseWriter, r *http.Request) {
+	logger.Debugf("handling request")
+
+	request := &openapi.RevocationStatusRequest{}
+
+	err := json.NewDecoder(r.Body).Decode(request)
+	if err != nil {
+		respondErrorf(w, http.StatusBadRequest, "bad request: %s", err.Error())
+
+		return
+	}
+
+	status := &openapi.RevocationStatus{}
+
+	for _, zcap := range request.Zcaps {
+		if _, err = zcapld.DecompressZCAP(zcap); err != nil {
+			respondErrorf(w, http.StatusBadRequest, "bad request: failed to parse zcap: %s", err.Error())
+
+			return
+		}
+
+		err = o.checkRevocation(zcap)
+		if errors.Is(err, errCapabilityRevoked) {
+			status.Revoked = true
+
+			break
+		}
+
+		if err != nil {
+			respondErrorf(w, http.StatusInternalServerError, "failed to check revocation: %s", err.Error())
+
+			return
+		}
+	}
+
+	respond(w, http.StatusOK, map[string]string{"Content-Type": "application/json"}, status)
+	logger.Debugf("handled request")
+}
+
+// extractDocuments resolves the documents of a MultiDocQuery keyed by their document IDs.
+func (o *Operation) extractDocuments(w http.ResponseWriter,
+	query *openapi.MultiDocQuery) (map[string]interface{}, bool) {
+	documents, err := o.fetchDocuments(query)
+	if err != nil {
+		respondErrorf(w, fetchErrorStatus(err),
+			"failed to fetch documents for MultiDocQuery: %s", err.Error())
+
+		return nil, false
+	}
+
+	docs := make(map[string]interface{}, len(documents))
+
+	for i := range documents {
+		docs[query.DocIDs[i]] = documents[i]
+	}
+
+	return docs, true
+}
+
 // TODO add support for caveats in zcap: https://github.com/trustbloc/edge-core/issues/134
 // TODO make supported crypto curves configurable: https://github.com/trustbloc/ace/issues/577
-func (o *Operation) newProfileZCAP(profileID, controller string) (*zcapld.Capability, error) {
+func (o *Operation) newProfileZCAP(profileID, zcapID, controller string) (*zcapld.Capability, error) {
 	identity, err := o.identityConfig()
 	if err != nil {
 		return nil, fmt.Errorf("failed to load identity: %w", err)
@@ -391,7 +690,7 @@ func (o *Operation) newProfileZCAP(profileID, controller string) (*zcapld.Capabi
 			ProcessorOpts:      []jsonld.ProcessorOpts{jsonld.WithDocumentLoader(o.documentLoader)},
 		},
 		zcapld.WithInvocationTarget(profileID, "urn:confidentialstoragehub:profile"),
-		zcapld.WithID(profileID),
+		zcapld.WithID(zcapID),
 		zcapld.WithAllowedActions(allActions()...),
 		zcapld.WithController(controller),
 		zcapld.WithInvoker(controller),
@@ -478,21 +777,23 @@ func (o *Operation) newIdentity() (*Identity, error) {
 }
 
 func initStores(p storage.Provider) (*struct {
-	profiles storage.Store
-	zcaps    storage.Store
-	queries  storage.Store
-	config   storage.Store
+	profiles    storage.Store
+	zcaps       storage.Store
+	queries     storage.Store
+	config      storage.Store
+	revocations storage.Store
 }, error) {
 	stores := &struct {
-		profiles storage.Store
-		zcaps    storage.Store
-		queries  storage.Store
-		config   storage.Store
+		profiles    storage.Store
+		zcaps       storage.Store
+		queries     storage.Store
+		config      storage.Store
+		revocations storage.Store
 	}{}
 
-	s := [4]storage.Store{}
+	s := [5]storage.Store{}
 
-	for i, name := range []string{profileStore, zcapStore, queryStore, configStore} {
+	for i, name := range []string{profileStore, zcapStore, queryStore, configStore, revocationStore} {
 		var err error
 
 		s[i], err = initStore(p, name)
@@ -505,6 +806,7 @@ func initStores(p storage.Provider) (*struct {
 	stores.zcaps = s[1]
 	stores.queries = s[2]
 	stores.config = s[3]
+	stores.revocations = s[4]
 
 	return stores, nil
 }
@@ -545,6 +847,15 @@ func respondErrorf(w http.ResponseWriter, statusCode int, format string, args ..
 	}
 }
 
+func load(s storage.Store, k string, v interface{}) error {
+	raw, err := s.Get(k)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(raw, v)
+}
+
 func save(s storage.Store, k string, v interface{}) error {
 	raw, err := json.Marshal(v)
 	if err != nil {
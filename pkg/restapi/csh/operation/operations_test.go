@@ -8,6 +8,8 @@ package operation_test
 
 import (
 	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -17,9 +19,12 @@ import (
 	"net/url"
 	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/gorilla/mux"
 	"github.com/hyperledger/aries-framework-go/component/storageutil/mem"
 	"github.com/hyperledger/aries-framework-go/component/storageutil/mock"
 	"github.com/hyperledger/aries-framework-go/pkg/doc/did"
@@ -27,10 +32,14 @@ import (
 	"github.com/hyperledger/aries-framework-go/pkg/doc/signature/suite"
 	"github.com/hyperledger/aries-framework-go/pkg/doc/signature/suite/ed25519signature2018"
 	"github.com/hyperledger/aries-framework-go/pkg/doc/util/signature"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
 	"github.com/hyperledger/aries-framework-go/pkg/kms"
 	mockcrypto "github.com/hyperledger/aries-framework-go/pkg/mock/crypto"
 	mockkms "github.com/hyperledger/aries-framework-go/pkg/mock/kms"
+	"github.com/hyperledger/aries-framework-go/pkg/vdr/fingerprint"
+	"github.com/hyperledger/aries-framework-go/pkg/vdr/key"
 	spi "github.com/hyperledger/aries-framework-go/spi/storage"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/require"
 	"github.com/trustbloc/edge-core/pkg/zcapld"
 	edv "github.com/trustbloc/edv/pkg/client"
@@ -38,10 +47,12 @@ import (
 	"github.com/trustbloc/edv/pkg/restapi/models"
 
 	"github.com/trustbloc/ace/pkg/client/vault"
+	"github.com/trustbloc/ace/pkg/httpsig"
 	"github.com/trustbloc/ace/pkg/internal/mock/storage"
 	"github.com/trustbloc/ace/pkg/internal/testutil"
 	"github.com/trustbloc/ace/pkg/restapi/csh/operation"
 	openapi "github.com/trustbloc/ace/pkg/restapi/csh/operation/models"
+	zcapld2 "github.com/trustbloc/ace/pkg/restapi/csh/operation/zcapld"
 )
 
 func TestNew(t *testing.T) {
@@ -153,8 +164,9 @@ func TestOperation_CreateProfile(t *testing.T) {
 				"profile": &mock.Store{
 					ErrPut: errors.New("test"),
 				},
-				"zcap":    &mock.Store{},
-				"queries": &mock.Store{},
+				"zcap":        &mock.Store{},
+				"queries":     &mock.Store{},
+				"revocations": &mock.Store{},
 				"config": &mock.Store{
 					ErrGet: spi.ErrDataNotFound,
 				},
@@ -215,8 +227,9 @@ func TestOperation_CreateProfile(t *testing.T) {
 				"profile": &mock.Store{
 					ErrPut: errors.New("test"),
 				},
-				"zcap":    &mock.Store{},
-				"queries": &mock.Store{},
+				"zcap":        &mock.Store{},
+				"queries":     &mock.Store{},
+				"revocations": &mock.Store{},
 				"config": &mock.Store{
 					GetReturn: marshal(t, &operation.Identity{}),
 				},
@@ -245,7 +258,8 @@ func TestOperation_CreateProfile(t *testing.T) {
 				"zcap": &mock.Store{
 					ErrPut: errors.New("test"),
 				},
-				"queries": &mock.Store{},
+				"queries":     &mock.Store{},
+				"revocations": &mock.Store{},
 				"config": &mock.Store{
 					GetReturn: marshal(t, &operation.Identity{}),
 				},
@@ -397,6 +411,7 @@ func TestOperation_CreateQuery(t *testing.T) {
 				"queries": &mock.Store{
 					ErrPut: expected,
 				},
+				"revocations": &mock.Store{},
 				"config": &mock.Store{
 					GetReturn: marshal(t, &operation.Identity{}),
 				},
@@ -426,6 +441,615 @@ func TestOperation_CreateAuthorization(t *testing.T) {
 	})
 }
 
+func TestOperation_RevokeCapability(t *testing.T) {
+	t.Run("revokes a zcap", func(t *testing.T) {
+		o := newOp(t)
+		profileID := createProfile(t, o)
+		zcapID := uuid.New().URN()
+
+		result := httptest.NewRecorder()
+		o.RevokeCapability(result, revocationReq(t, profileID, &openapi.Revocation{ZcapID: &zcapID}))
+		require.Equal(t, http.StatusNoContent, result.Code)
+
+		query := docQuery(&openapi.UpstreamAuthorization{
+			BaseURL: "https://edv.example.com",
+			Zcap:    compress(t, marshal(t, &zcapld.Capability{ID: zcapID})),
+		}, nil)
+
+		_, err := o.ReadDocQuery(query)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "capability revoked: "+zcapID)
+
+		result = httptest.NewRecorder()
+		o.HandleEqOp(result, newEqOp(t, query, query))
+		require.Equal(t, http.StatusForbidden, result.Code)
+		require.Contains(t, result.Body.String(), "capability revoked")
+	})
+
+	t.Run("rejects zcaps delegated from a revoked zcap", func(t *testing.T) {
+		o := newOp(t)
+		profileID := createProfile(t, o)
+		parentID := uuid.New().URN()
+
+		result := httptest.NewRecorder()
+		o.RevokeCapability(result, revocationReq(t, profileID, &openapi.Revocation{ZcapID: &parentID}))
+		require.Equal(t, http.StatusNoContent, result.Code)
+
+		query := docQuery(nil, &openapi.UpstreamAuthorization{
+			BaseURL: "https://kms.example.com",
+			Zcap: compress(t, marshal(t, &zcapld.Capability{
+				ID:     uuid.New().URN(),
+				Parent: parentID,
+			})),
+		})
+
+		_, err := o.ReadDocQuery(query)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "capability revoked: "+parentID)
+	})
+
+	t.Run("rejects zcaps with a revoked zcap in their capability chain", func(t *testing.T) {
+		o := newOp(t)
+		profileID := createProfile(t, o)
+		rootID := uuid.New().URN()
+		parentID := uuid.New().URN()
+
+		result := httptest.NewRecorder()
+		o.RevokeCapability(result, revocationReq(t, profileID, &openapi.Revocation{ZcapID: &rootID}))
+		require.Equal(t, http.StatusNoContent, result.Code)
+
+		query := docQuery(&openapi.UpstreamAuthorization{
+			BaseURL: "https://edv.example.com",
+			Zcap: compress(t, marshal(t, &zcapld.Capability{
+				ID:     uuid.New().URN(),
+				Parent: parentID,
+				Proof:  []verifiable.Proof{{"capabilityChain": []interface{}{rootID, parentID}}},
+			})),
+		}, nil)
+
+		_, err := o.ReadDocQuery(query)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "capability revoked: "+rootID)
+	})
+
+	t.Run("rejects zcaps with a revoked zcap in an embedded capability chain", func(t *testing.T) {
+		o := newOp(t)
+		profileID := createProfile(t, o)
+		rootID := uuid.New().URN()
+		parentID := uuid.New().URN()
+
+		result := httptest.NewRecorder()
+		o.RevokeCapability(result, revocationReq(t, profileID, &openapi.Revocation{ZcapID: &rootID}))
+		require.Equal(t, http.StatusNoContent, result.Code)
+
+		query := docQuery(&openapi.UpstreamAuthorization{
+			BaseURL: "https://edv.example.com",
+			Zcap: compress(t, marshal(t, &zcapld.Capability{
+				ID:     uuid.New().URN(),
+				Parent: parentID,
+				Proof: []verifiable.Proof{{"capabilityChain": []interface{}{
+					map[string]interface{}{
+						"id":               parentID,
+						"parentCapability": rootID,
+						"proof":            []interface{}{map[string]interface{}{"capabilityChain": []interface{}{rootID}}},
+					},
+				}}},
+			})),
+		}, nil)
+
+		_, err := o.ReadDocQuery(query)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "capability revoked: "+rootID)
+	})
+
+	t.Run("rejects invalid capability chains", func(t *testing.T) {
+		o := newOp(t)
+
+		query := docQuery(&openapi.UpstreamAuthorization{
+			BaseURL: "https://edv.example.com",
+			Zcap: compress(t, marshal(t, &zcapld.Capability{
+				ID:    uuid.New().URN(),
+				Proof: []verifiable.Proof{{"capabilityChain": []interface{}{1}}},
+			})),
+		}, nil)
+
+		_, err := o.ReadDocQuery(query)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid capabilityChain link of type float64")
+	})
+
+	t.Run("rejects revoked zcaps of saved queries", func(t *testing.T) {
+		o := newOp(t)
+		profileID := createProfile(t, o)
+		rootID := uuid.New().URN()
+
+		saved := docQuery(&openapi.UpstreamAuthorization{
+			BaseURL: "https://edv.example.com",
+			Zcap: compress(t, marshal(t, &zcapld.Capability{
+				ID:    uuid.New().URN(),
+				Proof: []verifiable.Proof{{"capabilityChain": []interface{}{rootID}}},
+			})),
+		}, nil)
+
+		result := httptest.NewRecorder()
+		o.CreateQuery(result, httptest.NewRequest(http.MethodPost, "/test", bytes.NewReader(marshal(t, saved))))
+		require.Equal(t, http.StatusCreated, result.Code)
+
+		parts := strings.Split(result.Header().Get("location"), "/")
+		queryID := parts[len(parts)-1]
+
+		result = httptest.NewRecorder()
+		o.RevokeCapability(result, revocationReq(t, profileID, &openapi.Revocation{ZcapID: &rootID}))
+		require.Equal(t, http.StatusNoContent, result.Code)
+
+		result = httptest.NewRecorder()
+		o.Extract(result, httptest.NewRequest(http.MethodPost, "/test",
+			bytes.NewReader(marshal(t, []interface{}{refQuery(queryID)}))))
+		require.Equal(t, http.StatusForbidden, result.Code)
+		require.Contains(t, result.Body.String(), "capability revoked: "+rootID)
+
+		result = httptest.NewRecorder()
+		o.CreateQuery(result, httptest.NewRequest(http.MethodPost, "/test", bytes.NewReader(marshal(t, saved))))
+		require.Equal(t, http.StatusForbidden, result.Code)
+		require.Contains(t, result.Body.String(), "capability revoked: "+rootID)
+	})
+
+	t.Run("error BadRequest if request is malformed", func(t *testing.T) {
+		o := newOp(t)
+		result := httptest.NewRecorder()
+
+		o.RevokeCapability(result, revocationReq(t, uuid.New().URN(), "{}"))
+		require.Equal(t, http.StatusBadRequest, result.Code)
+		require.Contains(t, result.Body.String(), "bad request")
+	})
+
+	t.Run("error BadRequest if zcapID is missing", func(t *testing.T) {
+		o := newOp(t)
+		result := httptest.NewRecorder()
+
+		o.RevokeCapability(result, revocationReq(t, uuid.New().URN(), &openapi.Revocation{}))
+		require.Equal(t, http.StatusBadRequest, result.Code)
+		require.Contains(t, result.Body.String(), "missing zcapID")
+	})
+
+	t.Run("error NotFound if profile does not exist", func(t *testing.T) {
+		o := newOp(t)
+		zcapID := uuid.New().URN()
+		result := httptest.NewRecorder()
+
+		o.RevokeCapability(result, revocationReq(t, uuid.New().URN(), &openapi.Revocation{ZcapID: &zcapID}))
+		require.Equal(t, http.StatusNotFound, result.Code)
+		require.Contains(t, result.Body.String(), "no such profile")
+	})
+
+	t.Run("error InternalServerError if cannot fetch profile", func(t *testing.T) {
+		config := config(t)
+		config.StoreProvider = &storage.MockProvider{
+			Stores: map[string]spi.Store{
+				"profile":     &mock.Store{ErrGet: errors.New("test error")},
+				"zcap":        &mock.Store{},
+				"queries":     &mock.Store{},
+				"revocations": &mock.Store{},
+				"config": &mock.Store{
+					GetReturn: marshal(t, &operation.Identity{}),
+				},
+			},
+		}
+		o := newOperation(t, config)
+		zcapID := uuid.New().URN()
+		result := httptest.NewRecorder()
+
+		o.RevokeCapability(result, revocationReq(t, uuid.New().URN(), &openapi.Revocation{ZcapID: &zcapID}))
+		require.Equal(t, http.StatusInternalServerError, result.Code)
+		require.Contains(t, result.Body.String(), "failed to fetch profile")
+	})
+
+	t.Run("error InternalServerError if cannot store revocation", func(t *testing.T) {
+		config := config(t)
+		config.StoreProvider = &storage.MockProvider{
+			Stores: map[string]spi.Store{
+				"profile":     &mock.Store{GetReturn: marshal(t, &openapi.Profile{})},
+				"zcap":        &mock.Store{},
+				"queries":     &mock.Store{},
+				"revocations": &mock.Store{ErrPut: errors.New("test error")},
+				"config": &mock.Store{
+					GetReturn: marshal(t, &operation.Identity{}),
+				},
+			},
+		}
+		o := newOperation(t, config)
+		zcapID := uuid.New().URN()
+		result := httptest.NewRecorder()
+
+		o.RevokeCapability(result, revocationReq(t, uuid.New().URN(), &openapi.Revocation{ZcapID: &zcapID}))
+		require.Equal(t, http.StatusInternalServerError, result.Code)
+		require.Contains(t, result.Body.String(), "failed to store revocation")
+	})
+
+	t.Run("error InternalServerError if cannot check revocation registry", func(t *testing.T) {
+		config := config(t)
+		config.StoreProvider = &storage.MockProvider{
+			Stores: map[string]spi.Store{
+				"profile":     &mock.Store{},
+				"zcap":        &mock.Store{},
+				"queries":     &mock.Store{},
+				"revocations": &mock.Store{ErrGet: errors.New("test error")},
+				"config": &mock.Store{
+					GetReturn: marshal(t, &operation.Identity{}),
+				},
+			},
+		}
+		o := newOperation(t, config)
+		query := docQuery(&openapi.UpstreamAuthorization{
+			BaseURL: "https://edv.example.com",
+			Zcap:    compress(t, marshal(t, &zcapld.Capability{ID: uuid.New().URN()})),
+		}, nil)
+		result := httptest.NewRecorder()
+
+		o.HandleEqOp(result, newEqOp(t, query, query))
+		require.Equal(t, http.StatusInternalServerError, result.Code)
+		require.Contains(t, result.Body.String(), "failed to check revocation registry")
+	})
+}
+
+func TestOperation_RevocationStatus(t *testing.T) {
+	status := func(t *testing.T, o *operation.Operation, request interface{}) *httptest.ResponseRecorder {
+		t.Helper()
+
+		result := httptest.NewRecorder()
+		o.RevocationStatus(result, httptest.NewRequest(http.MethodPost, "/revocations/status",
+			bytes.NewReader(marshal(t, request))))
+
+		return result
+	}
+
+	t.Run("tells if a zcap of the capability chains is revoked", func(t *testing.T) {
+		o := newOp(t)
+		profileID := createProfile(t, o)
+		rootID := uuid.New().URN()
+
+		valid := compress(t, marshal(t, &zcapld.Capability{ID: uuid.New().URN()}))
+		delegated := compress(t, marshal(t, &zcapld.Capability{
+			ID:    uuid.New().URN(),
+			Proof: []verifiable.Proof{{"capabilityChain": []interface{}{rootID}}},
+		}))
+
+		result := status(t, o, &openapi.RevocationStatusRequest{Zcaps: []string{valid, delegated}})
+		require.Equal(t, http.StatusOK, result.Code)
+		require.NotContains(t, result.Body.String(), "revoked")
+
+		revoked := httptest.NewRecorder()
+		o.RevokeCapability(revoked, revocationReq(t, profileID, &openapi.Revocation{ZcapID: &rootID}))
+		require.Equal(t, http.StatusNoContent, revoked.Code)
+
+		result = status(t, o, &openapi.RevocationStatusRequest{Zcaps: []string{valid, delegated}})
+		require.Equal(t, http.StatusOK, result.Code)
+
+		response := &openapi.RevocationStatus{}
+		require.NoError(t, json.Unmarshal(result.Body.Bytes(), response))
+		require.True(t, response.Revoked)
+	})
+
+	t.Run("error BadRequest if request is malformed", func(t *testing.T) {
+		result := status(t, newOp(t), "{")
+		require.Equal(t, http.StatusBadRequest, result.Code)
+		require.Contains(t, result.Body.String(), "bad request")
+	})
+
+	t.Run("error BadRequest if a zcap is malformed", func(t *testing.T) {
+		result := status(t, newOp(t), &openapi.RevocationStatusRequest{Zcaps: []string{"invalid"}})
+		require.Equal(t, http.StatusBadRequest, result.Code)
+		require.Contains(t, result.Body.String(), "failed to parse zcap")
+	})
+
+	t.Run("error InternalServerError if cannot check revocation registry", func(t *testing.T) {
+		config := config(t)
+		config.StoreProvider = &storage.MockProvider{
+			Stores: map[string]spi.Store{
+				"profile":     &mock.Store{},
+				"zcap":        &mock.Store{},
+				"queries":     &mock.Store{},
+				"revocations": &mock.Store{ErrGet: errors.New("test error")},
+				"config": &mock.Store{
+					GetReturn: marshal(t, &operation.Identity{}),
+				},
+			},
+		}
+		zcap := compress(t, marshal(t, &zcapld.Capability{ID: uuid.New().URN()}))
+
+		result := status(t, newOperation(t, config), &openapi.RevocationStatusRequest{Zcaps: []string{zcap}})
+		require.Equal(t, http.StatusInternalServerError, result.Code)
+		require.Contains(t, result.Body.String(), "failed to check revocation")
+	})
+}
+
+func TestOperation_CapabilityCache(t *testing.T) {
+	newCachingOp := func(t *testing.T) (*operation.Operation, *prometheus.Registry) {
+		t.Helper()
+
+		registry := prometheus.NewRegistry()
+
+		cfg := config(t)
+		cfg.CapabilityCacheTTL = time.Minute
+		cfg.MetricsRegisterer = registry
+
+		return newOperation(t, cfg), registry
+	}
+
+	revoked := func(t *testing.T, o *operation.Operation, zcap string) bool {
+		t.Helper()
+
+		result := httptest.NewRecorder()
+		o.RevocationStatus(result, httptest.NewRequest(http.MethodPost, "/revocations/status",
+			bytes.NewReader(marshal(t, &openapi.RevocationStatusRequest{Zcaps: []string{zcap}}))))
+		require.Equal(t, http.StatusOK, result.Code)
+
+		response := &openapi.RevocationStatus{}
+		require.NoError(t, json.Unmarshal(result.Body.Bytes(), response))
+
+		return response.Revoked
+	}
+
+	t.Run("caches the zcaps found unrevoked until their chain is revoked", func(t *testing.T) {
+		o, registry := newCachingOp(t)
+		profileID := createProfile(t, o)
+		rootID := uuid.New().URN()
+
+		zcap := compress(t, marshal(t, &zcapld.Capability{
+			ID:    uuid.New().URN(),
+			Proof: []verifiable.Proof{{"capabilityChain": []interface{}{rootID}}},
+		}))
+
+		require.False(t, revoked(t, o, zcap))
+		require.False(t, revoked(t, o, zcap))
+		require.Equal(t, map[string]float64{"hit": 1, "miss": 1}, lookups(t, registry))
+
+		result := httptest.NewRecorder()
+		o.RevokeCapability(result, revocationReq(t, profileID, &openapi.Revocation{ZcapID: &rootID}))
+		require.Equal(t, http.StatusNoContent, result.Code)
+
+		require.True(t, revoked(t, o, zcap))
+		require.Equal(t, map[string]float64{"hit": 1, "miss": 2}, lookups(t, registry))
+	})
+
+	t.Run("checks a zcap reusing the ID of a cached zcap anew", func(t *testing.T) {
+		o, registry := newCachingOp(t)
+		profileID := createProfile(t, o)
+		zcapID := uuid.New().URN()
+		parentID := uuid.New().URN()
+
+		result := httptest.NewRecorder()
+		o.RevokeCapability(result, revocationReq(t, profileID, &openapi.Revocation{ZcapID: &parentID}))
+		require.Equal(t, http.StatusNoContent, result.Code)
+
+		require.False(t, revoked(t, o, compress(t, marshal(t, &zcapld.Capability{ID: zcapID}))))
+		require.True(t, revoked(t, o, compress(t, marshal(t, &zcapld.Capability{ID: zcapID, Parent: parentID}))))
+		require.Equal(t, map[string]float64{"miss": 2}, lookups(t, registry))
+	})
+
+	t.Run("fails to register metrics", func(t *testing.T) {
+		registry := prometheus.NewRegistry()
+		registry.MustRegister(prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "csh_capability_cache_lookups_total",
+			Help: "Another metric.",
+		}))
+
+		cfg := config(t)
+		cfg.CapabilityCacheTTL = time.Minute
+		cfg.MetricsRegisterer = registry
+
+		_, err := operation.New(cfg)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "register capability cache metrics")
+	})
+}
+
+// lookups returns the lookups of the capability cache counted, by result.
+func lookups(t *testing.T, registry *prometheus.Registry) map[string]float64 {
+	t.Helper()
+
+	families, err := registry.Gather()
+	require.NoError(t, err)
+
+	c := map[string]float64{}
+
+	for _, f := range families {
+		if f.GetName() != "csh_capability_cache_lookups_total" {
+			continue
+		}
+
+		for _, m := range f.GetMetric() {
+			for _, l := range m.GetLabel() {
+				if l.GetName() == "result" {
+					c[l.GetValue()] = m.GetCounter().GetValue()
+				}
+			}
+		}
+	}
+
+	return c
+}
+
+func TestOperation_RotateProfileKeys(t *testing.T) {
+	t.Run("rotates the controller and re-issues the root zcap", func(t *testing.T) {
+		cfg := config(t)
+		o := newOperation(t, cfg)
+		current := newSigningController(t)
+		profileID := createProfileWithController(t, o, current.keyID)
+		next := newSigningController(t)
+
+		result := httptest.NewRecorder()
+		o.RotateProfileKeys(result, current.rotationReq(t, profileID, &openapi.Profile{Controller: &next.keyID}))
+		require.Equal(t, http.StatusOK, result.Code)
+
+		response := &openapi.Profile{}
+		unmarshal(t, response, result.Body.Bytes())
+		require.Equal(t, profileID, response.ID)
+		require.Equal(t, next.keyID, *response.Controller)
+
+		rootZCAP := decompressZCAP(t, response.Zcap)
+		require.NotEqual(t, profileID, rootZCAP.ID)
+		require.Equal(t, profileID, rootZCAP.InvocationTarget.ID)
+		require.Equal(t, next.keyID, rootZCAP.Invoker)
+		require.Equal(t, next.keyID, rootZCAP.Controller)
+
+		revocations, err := cfg.StoreProvider.OpenStore("revocations")
+		require.NoError(t, err)
+
+		_, err = revocations.Get(profileID)
+		require.NoError(t, err, "the previous root zcap must be revoked")
+
+		result = httptest.NewRecorder()
+		o.RotateProfileKeys(result, current.rotationReq(t, profileID, &openapi.Profile{Controller: &current.keyID}))
+		require.Equal(t, http.StatusForbidden, result.Code)
+		require.Contains(t, result.Body.String(), "not signed by the profile's controller")
+
+		result = httptest.NewRecorder()
+		o.RotateProfileKeys(result, next.rotationReq(t, profileID, &openapi.Profile{Controller: &next.keyID}))
+		require.Equal(t, http.StatusBadRequest, result.Code)
+		require.Contains(t, result.Body.String(), "controller is unchanged")
+	})
+
+	t.Run("error Forbidden if request is not signed by the controller", func(t *testing.T) {
+		o := newOperation(t, config(t))
+		profileID := createProfileWithController(t, o, newSigningController(t).keyID)
+
+		result := httptest.NewRecorder()
+		o.RotateProfileKeys(result, rotationReq(t, profileID, &openapi.Profile{Controller: controller()}))
+		require.Equal(t, http.StatusForbidden, result.Code)
+		require.Contains(t, result.Body.String(), "not signed by the profile's controller")
+
+		result = httptest.NewRecorder()
+		o.RotateProfileKeys(result,
+			newSigningController(t).rotationReq(t, profileID, &openapi.Profile{Controller: controller()}))
+		require.Equal(t, http.StatusForbidden, result.Code)
+		require.Contains(t, result.Body.String(), "not signed by the profile's controller")
+	})
+
+	t.Run("error BadRequest if request is malformed", func(t *testing.T) {
+		o := newOp(t)
+		result := httptest.NewRecorder()
+
+		o.RotateProfileKeys(result, rotationReq(t, uuid.New().URN(), "{}"))
+		require.Equal(t, http.StatusBadRequest, result.Code)
+		require.Contains(t, result.Body.String(), "bad request")
+	})
+
+	t.Run("error BadRequest if controller is missing", func(t *testing.T) {
+		o := newOp(t)
+		result := httptest.NewRecorder()
+
+		o.RotateProfileKeys(result, rotationReq(t, uuid.New().URN(), &openapi.Profile{}))
+		require.Equal(t, http.StatusBadRequest, result.Code)
+		require.Contains(t, result.Body.String(), "missing controller")
+	})
+
+	t.Run("error NotFound if profile does not exist", func(t *testing.T) {
+		o := newOp(t)
+		result := httptest.NewRecorder()
+
+		o.RotateProfileKeys(result, rotationReq(t, uuid.New().URN(), &openapi.Profile{Controller: controller()}))
+		require.Equal(t, http.StatusNotFound, result.Code)
+		require.Contains(t, result.Body.String(), "no such profile")
+	})
+
+	t.Run("error InternalServerError on store failures", func(t *testing.T) {
+		current := newSigningController(t)
+		profile := marshal(t, &openapi.Profile{ID: uuid.New().URN(), Controller: &current.keyID})
+		zcap := marshal(t, &zcapld.Capability{ID: uuid.New().URN()})
+
+		tests := []struct {
+			name        string
+			profiles    *mock.Store
+			zcaps       *mock.Store
+			revocations *mock.Store
+			expected    string
+		}{
+			{
+				name:     "cannot fetch profile",
+				profiles: &mock.Store{ErrGet: errors.New("test")},
+				expected: "failed to fetch profile",
+			},
+			{
+				name:     "cannot parse profile",
+				profiles: &mock.Store{GetReturn: []byte("invalid")},
+				expected: "failed to parse profile",
+			},
+			{
+				name:     "cannot fetch zcap",
+				profiles: &mock.Store{GetReturn: profile},
+				zcaps:    &mock.Store{ErrGet: errors.New("test")},
+				expected: "failed to fetch zcap",
+			},
+			{
+				name:        "cannot revoke zcap",
+				profiles:    &mock.Store{GetReturn: profile},
+				zcaps:       &mock.Store{GetReturn: zcap},
+				revocations: &mock.Store{ErrPut: errors.New("test")},
+				expected:    "failed to revoke zcap",
+			},
+			{
+				name:     "cannot store zcap",
+				profiles: &mock.Store{GetReturn: profile},
+				zcaps:    &mock.Store{GetReturn: zcap, ErrPut: errors.New("test")},
+				expected: "failed to store zcap",
+			},
+			{
+				name:     "cannot store profile",
+				profiles: &mock.Store{GetReturn: profile, ErrPut: errors.New("test")},
+				zcaps:    &mock.Store{GetReturn: zcap},
+				expected: "failed to store profile",
+			},
+		}
+
+		for _, tc := range tests {
+			tc := tc
+
+			t.Run(tc.name, func(t *testing.T) {
+				for _, s := range []**mock.Store{&tc.zcaps, &tc.revocations} {
+					if *s == nil {
+						*s = &mock.Store{}
+					}
+				}
+
+				cfg := config(t)
+				cfg.StoreProvider = &storage.MockProvider{
+					Stores: map[string]spi.Store{
+						"profile":     tc.profiles,
+						"zcap":        tc.zcaps,
+						"queries":     &mock.Store{},
+						"revocations": tc.revocations,
+						"config": &mock.Store{
+							GetReturn: marshal(t, &operation.Identity{}),
+						},
+					},
+				}
+				o := newOperation(t, cfg)
+				result := httptest.NewRecorder()
+
+				o.RotateProfileKeys(result,
+					current.rotationReq(t, uuid.New().URN(), &openapi.Profile{Controller: controller()}))
+				require.Equal(t, http.StatusInternalServerError, result.Code)
+				require.Contains(t, result.Body.String(), tc.expected)
+			})
+		}
+	})
+
+	t.Run("error InternalServerError if failed to create zcap", func(t *testing.T) {
+		cfg := config(t)
+		o := newOperation(t, cfg)
+		current := newSigningController(t)
+		profileID := createProfileWithController(t, o, current.keyID)
+
+		cfg.Aries.KMS.(*mockkms.KeyManager).GetKeyErr = errors.New("test")
+
+		result := httptest.NewRecorder()
+		o.RotateProfileKeys(result, current.rotationReq(t, profileID, &openapi.Profile{Controller: controller()}))
+		require.Equal(t, http.StatusInternalServerError, result.Code)
+		require.Contains(t, result.Body.String(), "failed to create zcap")
+	})
+}
+
 func TestOperation_Compare(t *testing.T) {
 	t.Run("equal documents", func(t *testing.T) {
 		doc := randomDoc(t)
@@ -504,9 +1128,10 @@ func TestOperation_Extract(t *testing.T) {
 
 		config.StoreProvider = &storage.MockProvider{
 			Stores: map[string]spi.Store{
-				"profile": &mock.Store{},
-				"zcap":    &mock.Store{},
-				"queries": queriesStore,
+				"profile":     &mock.Store{},
+				"zcap":        &mock.Store{},
+				"queries":     queriesStore,
+				"revocations": &mock.Store{},
 				"config": &mock.Store{
 					GetReturn: marshal(t, &operation.Identity{}),
 				},
@@ -550,6 +1175,46 @@ func TestOperation_Extract(t *testing.T) {
 		}
 	})
 
+	t.Run("performs an extraction of multiple documents in one query", func(t *testing.T) {
+		o := newOperationWithDocs(t, nameDoc(t, "alice"), nameDoc(t, "bob"))
+		query := multiNameQuery(2)
+
+		request := httptest.NewRequest(http.MethodPost, "/test", bytes.NewReader(marshal(t, []interface{}{query})))
+		result := httptest.NewRecorder()
+
+		o.Extract(result, request)
+		require.Equal(t, http.StatusOK, result.Code)
+
+		var extractions openapi.ExtractionResponse
+
+		err := json.NewDecoder(result.Body).Decode(&extractions)
+		require.NoError(t, err)
+		require.Len(t, extractions, 1)
+		require.Nil(t, extractions[0].Document)
+		require.Equal(t, map[string]interface{}{
+			query.DocIDs[0]: "alice",
+			query.DocIDs[1]: "bob",
+		}, extractions[0].Documents)
+	})
+
+	t.Run("error InternalServerError if cannot fetch multiple documents", func(t *testing.T) {
+		config := agentConfig(newAgent(t))
+		config.EDVClient = func(string, ...edv.Option) vault.ConfidentialStorageDocReader {
+			return newMockEDVClient(t, errors.New("test error"))
+		}
+
+		request := httptest.NewRequest(http.MethodPost, "/test", bytes.NewReader(marshal(t, []interface{}{
+			multiNameQuery(2),
+		})))
+		result := httptest.NewRecorder()
+
+		o := newOperation(t, config)
+		o.Extract(result, request)
+
+		require.Equal(t, http.StatusInternalServerError, result.Code)
+		require.Contains(t, result.Body.String(), "failed to fetch documents for MultiDocQuery")
+	})
+
 	t.Run("error BadRequest if request is malformed", func(t *testing.T) {
 		o := newOperation(t, agentConfig(newAgent(t)))
 		result := httptest.NewRecorder()
@@ -611,8 +1276,9 @@ func config(t *testing.T) *operation.Config {
 	return &operation.Config{
 		StoreProvider: mem.NewProvider(),
 		Aries: &operation.AriesConfig{
-			KMS:    &mockkms.KeyManager{},
-			Crypto: &mockcrypto.Crypto{},
+			KMS:          &mockkms.KeyManager{},
+			Crypto:       &mockcrypto.Crypto{},
+			DIDResolvers: []zcapld2.DIDResolver{key.New()},
 			PublicDIDCreator: func(kms.KeyManager) (*did.DocResolution, error) {
 				return &did.DocResolution{
 					DIDDocument: &did.Doc{
@@ -669,6 +1335,77 @@ func newReq(t *testing.T, method, path string, payload interface{}) *http.Reques
 	return httptest.NewRequest(method, path, body)
 }
 
+func createProfile(t *testing.T, o *operation.Operation) string {
+	t.Helper()
+
+	result := httptest.NewRecorder()
+	o.CreateProfile(result, newReq(t, http.MethodPost, "/profiles", &openapi.Profile{Controller: controller()}))
+	require.Equal(t, http.StatusCreated, result.Code)
+
+	profile := &openapi.Profile{}
+	unmarshal(t, profile, result.Body.Bytes())
+
+	return profile.ID
+}
+
+func revocationReq(t *testing.T, profileID string, payload interface{}) *http.Request {
+	t.Helper()
+
+	return mux.SetURLVars(
+		newReq(t, http.MethodPost, fmt.Sprintf("/hubstore/profiles/%s/revocations", profileID), payload),
+		map[string]string{"profileID": profileID},
+	)
+}
+
+func rotationReq(t *testing.T, profileID string, payload interface{}) *http.Request {
+	t.Helper()
+
+	return mux.SetURLVars(
+		newReq(t, http.MethodPost, fmt.Sprintf("/hubstore/profiles/%s/rotations", profileID), payload),
+		map[string]string{"profileID": profileID},
+	)
+}
+
+// signingController is a did:key controller signing requests with HTTP signatures.
+type signingController struct {
+	keyID      string
+	privateKey ed25519.PrivateKey
+}
+
+func newSigningController(t *testing.T) *signingController {
+	t.Helper()
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	_, keyID := fingerprint.CreateDIDKey(pub)
+
+	return &signingController{keyID: keyID, privateKey: priv}
+}
+
+func (c *signingController) rotationReq(t *testing.T, profileID string, payload interface{}) *http.Request {
+	t.Helper()
+
+	req := rotationReq(t, profileID, payload)
+
+	require.NoError(t, httpsig.NewSigner(httpsig.DefaultPostSignerConfig(), c.privateKey).SignRequest(c.keyID, req))
+
+	return req
+}
+
+func createProfileWithController(t *testing.T, o *operation.Operation, controller string) string {
+	t.Helper()
+
+	result := httptest.NewRecorder()
+	o.CreateProfile(result, newReq(t, http.MethodPost, "/profiles", &openapi.Profile{Controller: &controller}))
+	require.Equal(t, http.StatusCreated, result.Code)
+
+	profile := &openapi.Profile{}
+	unmarshal(t, profile, result.Body.Bytes())
+
+	return profile.ID
+}
+
 func controller() *string {
 	c := fmt.Sprintf("did:example:%s#key1", uuid.New().String())
 
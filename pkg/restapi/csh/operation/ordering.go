@@ -0,0 +1,89 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package operation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// dateLayouts are the layouts of the document values that are ordered as dates.
+var dateLayouts = []string{time.RFC3339Nano, "2006-01-02"} //nolint:gochecknoglobals
+
+// errNotOrdered is returned when document values are neither both numbers nor both dates.
+var errNotOrdered = errors.New("values must both be numbers or both be dates")
+
+// compareValues returns -1, 0 or +1 depending on whether v1 is less than, equal to or greater than v2.
+// The values are compared as numbers if both are numeric and as dates if both are dates.
+func compareValues(v1, v2 interface{}) (int, error) {
+	s1, err := scalar(v1)
+	if err != nil {
+		return 0, err
+	}
+
+	s2, err := scalar(v2)
+	if err != nil {
+		return 0, err
+	}
+
+	f1, err1 := strconv.ParseFloat(s1, 64)
+	f2, err2 := strconv.ParseFloat(s2, 64)
+
+	if err1 == nil && err2 == nil {
+		switch {
+		case f1 < f2:
+			return -1, nil
+		case f1 > f2:
+			return 1, nil
+		default:
+			return 0, nil
+		}
+	}
+
+	t1, ok1 := parseDate(s1)
+	t2, ok2 := parseDate(s2)
+
+	if ok1 && ok2 {
+		switch {
+		case t1.Before(t2):
+			return -1, nil
+		case t1.After(t2):
+			return 1, nil
+		default:
+			return 0, nil
+		}
+	}
+
+	return 0, errNotOrdered
+}
+
+func scalar(v interface{}) (string, error) {
+	switch value := v.(type) {
+	case string:
+		return value, nil
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64), nil
+	case json.Number:
+		return value.String(), nil
+	default:
+		return "", fmt.Errorf("value of type %T cannot be ordered", v)
+	}
+}
+
+func parseDate(s string) (time.Time, bool) {
+	for _, layout := range dateLayouts {
+		t, err := time.Parse(layout, s)
+		if err == nil {
+			return t, true
+		}
+	}
+
+	return time.Time{}, false
+}
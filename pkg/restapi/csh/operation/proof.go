@@ -0,0 +1,154 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package operation
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/trustbloc/edv/pkg/restapi/models"
+
+	openapi "github.com/trustbloc/ace/pkg/restapi/csh/operation/models"
+	"github.com/trustbloc/ace/pkg/zkp"
+)
+
+// errNotCommitted is returned when an equality proof is requested over a document without a commitment.
+var errNotCommitted = errors.New("document was not committed to when it was protected")
+
+// committedDocument is the content of a document with the commitment to it, created when the document was protected
+// in the vault.
+type committedDocument struct {
+	content    interface{}
+	vaultID    string
+	commitment *zkp.SignedCommitment
+}
+
+// proveEqual proves that the documents are equal with their commitments, once the signatures of the commitments are
+// verified and the commitments are opened with the documents. If all documents are equal, it proves that the first
+// commitment and each of the other commitments are to the same value. Unequal documents yield a negative result
+// without a proof.
+func (o *Operation) proveEqual(documents []*committedDocument) (*openapi.Comparison, error) {
+	commitments := make([]*zkp.Commitment, len(documents))
+	proof := &openapi.EqualityProof{
+		Scheme:              zkp.Scheme,
+		Commitments:         make([]string, len(documents)),
+		Signatures:          make([]string, len(documents)),
+		VerificationMethods: make([]string, len(documents)),
+	}
+
+	for i, d := range documents {
+		var err error
+
+		commitments[i], err = o.openCommitment(d)
+		if err != nil {
+			return nil, fmt.Errorf("document %d: %w", i, err)
+		}
+
+		proof.Commitments[i] = d.commitment.Point
+		proof.Signatures[i] = d.commitment.Signature
+		proof.VerificationMethods[i] = d.commitment.VerificationMethod
+	}
+
+	for _, commitment := range commitments[1:] {
+		p, err := zkp.ProveEquality(commitments[0], commitment)
+		if errors.Is(err, zkp.ErrNotEqual) {
+			return &openapi.Comparison{Result: false}, nil
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to prove equality: %w", err)
+		}
+
+		proof.Proofs = append(proof.Proofs, &openapi.SchnorrProof{
+			T: base64.RawURLEncoding.EncodeToString(p.T),
+			S: base64.RawURLEncoding.EncodeToString(p.S),
+		})
+	}
+
+	return &openapi.Comparison{Result: true, Proof: proof}, nil
+}
+
+// openCommitment verifies that the commitment of the document is signed by the vault holding it, and opens it with the
+// content of the document, which fails if the document changed since it was committed to.
+func (o *Operation) openCommitment(d *committedDocument) (*zkp.Commitment, error) {
+	if d.commitment.Scheme != zkp.Scheme {
+		return nil, fmt.Errorf("unsupported commitment scheme: %s", d.commitment.Scheme)
+	}
+
+	point, err := base64.RawURLEncoding.DecodeString(d.commitment.Point)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode commitment: %w", err)
+	}
+
+	blinding, err := base64.RawURLEncoding.DecodeString(d.commitment.Blinding)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode commitment opening: %w", err)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(d.commitment.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode commitment signature: %w", err)
+	}
+
+	key, err := (&didKeyResolver{did: d.vaultID, resolvers: o.aries.DIDResolvers}).Resolve(
+		d.commitment.VerificationMethod)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve commitment key: %w", err)
+	}
+
+	if !ed25519.Verify(key.Value, zkp.SigningInput(point), signature) {
+		return nil, errors.New("invalid commitment signature")
+	}
+
+	raw, err := json.Marshal(d.content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal document: %w", err)
+	}
+
+	return zkp.Open(raw, point, blinding)
+}
+
+// fetchCommittedDocument fetches the whole document of the query with the commitment stored in its metadata.
+func (o *Operation) fetchCommittedDocument(query *openapi.DocQuery) (*committedDocument, error) {
+	if query.Path != "" {
+		return nil, fmt.Errorf("%w: documents are committed to without a path", errNotCommitted)
+	}
+
+	contents, err := o.ReadDocQuery(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Confidential Storage document: %w", err)
+	}
+
+	document := &models.StructuredDocument{}
+
+	err = json.Unmarshal(contents, document)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Confidential Storage structured document: %w", err)
+	}
+
+	meta, ok := document.Meta[zkp.MetaKey]
+	if !ok {
+		return nil, errNotCommitted
+	}
+
+	raw, err := json.Marshal(meta)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal commitment: %w", err)
+	}
+
+	commitment := &zkp.SignedCommitment{}
+
+	err = json.Unmarshal(raw, commitment)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse commitment: %w", err)
+	}
+
+	return &committedDocument{content: document.Content, vaultID: *query.VaultID, commitment: commitment}, nil
+}
@@ -8,14 +8,17 @@ package operation
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"time"
 
 	"github.com/hyperledger/aries-framework-go/pkg/doc/jose"
 	"github.com/hyperledger/aries-framework-go/pkg/kms/webkms"
+	"github.com/hyperledger/aries-framework-go/spi/storage"
 	"github.com/igor-pavlenko/httpsignatures-go"
 	"github.com/trustbloc/edge-core/pkg/zcapld"
 	edv "github.com/trustbloc/edv/pkg/client"
@@ -26,8 +29,17 @@ import (
 	zcapld2 "github.com/trustbloc/ace/pkg/restapi/csh/operation/zcapld"
 )
 
+// capabilityChainField is the field of the zcap proofs listing the zcaps the zcap was delegated from.
+const capabilityChainField = "capabilityChain"
+
+var errCapabilityRevoked = errors.New("capability revoked")
+
 // ReadDocQuery resolves a DocQuery to the contents of a Confidential Storage document.
 func (o *Operation) ReadDocQuery(query *openapi.DocQuery) ([]byte, error) {
+	if err := o.checkRevocations(query); err != nil {
+		return nil, err
+	}
+
 	edvOptions, err := o.edvOptions(query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to determine edv client options: %w", err)
@@ -159,6 +171,145 @@ func (o *Operation) supportedSignatureHashAlgorithms() httpsignatures.SignatureH
 	}
 }
 
+// checkRevocations fails if any zcap of the upstream authorizations of the query is revoked.
+func (o *Operation) checkRevocations(query *openapi.DocQuery) error {
+	if query.UpstreamAuth == nil {
+		return nil
+	}
+
+	for _, auth := range []*openapi.UpstreamAuthorization{query.UpstreamAuth.Edv, query.UpstreamAuth.Kms} {
+		if auth == nil || auth.Zcap == "" {
+			continue
+		}
+
+		if err := o.checkRevocation(auth.Zcap); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkRevocation fails if the zcap, or any zcap it was delegated from, is listed in the revocation registry: its
+// parent and the zcaps of the capability chains of its proofs, up to the root zcap. Zcaps found unrevoked are cached,
+// if the cache is enabled, so that long chains aren't walked on every request.
+func (o *Operation) checkRevocation(compressedZCAP string) error {
+	zcap, err := zcapld.DecompressZCAP(compressedZCAP)
+	if err != nil {
+		return fmt.Errorf("failed to parse zcap: %w", err)
+	}
+
+	var revocations uint64
+
+	if o.capabilities != nil {
+		var cached bool
+
+		cached, revocations = o.capabilities.get(zcap.ID, compressedZCAP)
+		if cached {
+			return nil
+		}
+	}
+
+	ids, err := capabilityIDs(zcap)
+	if err != nil {
+		return fmt.Errorf("failed to parse zcap: %w", err)
+	}
+
+	for _, id := range ids {
+		_, err = o.storage.revocations.Get(id)
+		if err == nil {
+			return fmt.Errorf("%w: %s", errCapabilityRevoked, id)
+		}
+
+		if !errors.Is(err, storage.ErrDataNotFound) {
+			return fmt.Errorf("failed to check revocation registry: %w", err)
+		}
+	}
+
+	if o.capabilities != nil {
+		o.capabilities.set(zcap.ID, compressedZCAP, ids, revocations)
+	}
+
+	return nil
+}
+
+// revoke lists the zcap of the profile in the revocation registry, and evicts the zcaps delegated from it from the
+// capability cache.
+func (o *Operation) revoke(zcapID, profileID string) error {
+	err := save(o.storage.revocations, zcapID, &Revocation{
+		ZCAPID:    zcapID,
+		ProfileID: profileID,
+		RevokedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return err
+	}
+
+	if o.capabilities != nil {
+		o.capabilities.revoke(zcapID)
+	}
+
+	return nil
+}
+
+// capabilityIDs returns the IDs of the zcap, of its parent and of the zcaps of the capability chains of its proofs.
+// The chains list the IDs of the zcaps from the root zcap, and may end with an embedded zcap, whose own chain is
+// walked as well.
+func capabilityIDs(zcap *zcapld.Capability) ([]string, error) {
+	var ids []string
+
+	for _, id := range []string{zcap.ID, zcap.Parent} {
+		if id != "" {
+			ids = append(ids, id)
+		}
+	}
+
+	for _, proof := range zcap.Proof {
+		chain, ok := proof[capabilityChainField].([]interface{})
+		if !ok {
+			continue
+		}
+
+		for _, link := range chain {
+			switch l := link.(type) {
+			case string:
+				ids = append(ids, l)
+			case map[string]interface{}:
+				embedded, err := embeddedCapability(l)
+				if err != nil {
+					return nil, err
+				}
+
+				embeddedIDs, err := capabilityIDs(embedded)
+				if err != nil {
+					return nil, err
+				}
+
+				ids = append(ids, embeddedIDs...)
+			default:
+				return nil, fmt.Errorf("invalid %s link of type %T", capabilityChainField, link)
+			}
+		}
+	}
+
+	return ids, nil
+}
+
+func embeddedCapability(raw map[string]interface{}) (*zcapld.Capability, error) {
+	b, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal embedded zcap: %w", err)
+	}
+
+	zcap := &zcapld.Capability{}
+
+	if err = json.Unmarshal(b, zcap); err != nil {
+		return nil, fmt.Errorf("failed to parse embedded zcap: %w", err)
+	}
+
+	return zcap, nil
+}
+
 func invoker(compressedZCAP string) (string, error) {
 	zcap, err := zcapld.DecompressZCAP(compressedZCAP)
 	if err != nil {
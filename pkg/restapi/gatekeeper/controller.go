@@ -9,34 +9,133 @@ package gatekeeper
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"time"
 
+	"github.com/hyperledger/aries-framework-go/pkg/common/log"
+	"github.com/hyperledger/aries-framework-go/pkg/crypto/tinkcrypto"
 	"github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdr"
+	"github.com/hyperledger/aries-framework-go/pkg/kms"
 	"github.com/hyperledger/aries-framework-go/spi/storage"
+	"github.com/piprate/json-gold/ld"
+	"github.com/prometheus/client_golang/prometheus"
 
 	"github.com/trustbloc/ace/pkg/client/csh/client/operations"
 	"github.com/trustbloc/ace/pkg/client/vault"
+	"github.com/trustbloc/ace/pkg/doc/vc/schema"
+	"github.com/trustbloc/ace/pkg/feature"
+	"github.com/trustbloc/ace/pkg/gatekeeper/audit"
 	"github.com/trustbloc/ace/pkg/gatekeeper/collect"
 	"github.com/trustbloc/ace/pkg/gatekeeper/config"
+	"github.com/trustbloc/ace/pkg/gatekeeper/consent"
+	"github.com/trustbloc/ace/pkg/gatekeeper/delegation"
+	"github.com/trustbloc/ace/pkg/gatekeeper/didcomm"
+	"github.com/trustbloc/ace/pkg/gatekeeper/encrypt"
 	"github.com/trustbloc/ace/pkg/gatekeeper/extract"
+	"github.com/trustbloc/ace/pkg/gatekeeper/metrics"
+	"github.com/trustbloc/ace/pkg/gatekeeper/notify"
+	"github.com/trustbloc/ace/pkg/gatekeeper/oidc4vp"
 	"github.com/trustbloc/ace/pkg/gatekeeper/policy"
+	"github.com/trustbloc/ace/pkg/gatekeeper/presentation"
 	"github.com/trustbloc/ace/pkg/gatekeeper/protect"
+	"github.com/trustbloc/ace/pkg/gatekeeper/quota"
 	"github.com/trustbloc/ace/pkg/gatekeeper/release"
+	"github.com/trustbloc/ace/pkg/gatekeeper/release/ticket"
+	"github.com/trustbloc/ace/pkg/gatekeeper/release/watch"
+	"github.com/trustbloc/ace/pkg/gatekeeper/subscription"
+	"github.com/trustbloc/ace/pkg/gatekeeper/watermark"
+	grpcgatekeeper "github.com/trustbloc/ace/pkg/grpcapi/gatekeeper"
+	"github.com/trustbloc/ace/pkg/lease"
+	"github.com/trustbloc/ace/pkg/queue"
 	"github.com/trustbloc/ace/pkg/restapi/gatekeeper/operation"
 	"github.com/trustbloc/ace/pkg/restapi/handler"
+	"github.com/trustbloc/ace/pkg/restapi/model"
 	"github.com/trustbloc/ace/pkg/restapi/mw/httpsigmw"
-	"github.com/trustbloc/ace/pkg/vcissuer"
+	"github.com/trustbloc/ace/pkg/storage/tombstone"
+	"github.com/trustbloc/ace/pkg/vcprovider"
+	"github.com/trustbloc/ace/pkg/workerpool"
 )
 
+var logger = log.New("gatekeeper-controller")
+
 // Config defines configuration for Gatekeeper operations.
 type Config struct {
 	StorageProvider        storage.Provider
 	VaultClient            vault.Vault
 	ConfigService          *config.Service
 	VDR                    vdr.Registry
-	VCIssuer               *vcissuer.Service
+	VCProvider             vcprovider.Provider
 	ConfidentialStorageHub operations.ClientService
+	DocumentLoader         ld.DocumentLoader
+	CredentialSchemas      []*schema.Schema
+	StrictJSONLD           bool
+	// HTTPClient posts the notifications of the subjects of protected data, encrypted with keys of the KeyManager
+	// when they are sent as DIDComm messages.
+	HTTPClient *http.Client
+	KeyManager kms.KeyManager
+	// Purger, if set, is run on request to purge deleted data.
+	Purger *tombstone.Purger
+	// Leader, if set, is the lease the instance runs the anchoring checks, signs the checkpoints of the audit log and,
+	// if the store doesn't write the tickets conditionally, updates the tickets with: they are skipped while another
+	// instance holds it, and the updates of the tickets fail.
+	Leader *lease.Lease
+	// ProtectWorkers, if positive, is the number of protect operations run at a time, with up to ProtectQueueSize
+	// more waiting for a worker.
+	ProtectWorkers   int
+	ProtectQueueSize int
+	// CollectQueueSize, if positive, is the number of collect requests queued in the store, processed by
+	// CollectWorkers at a time, or DefaultCollectWorkers if it is not positive.
+	CollectQueueSize int
+	CollectWorkers   int
+	// MetricsRegisterer, if set, registers the metrics of the controller, such as the depth of the collect queue.
+	MetricsRegisterer prometheus.Registerer
+	// AnchorCheckInterval, if positive, is the time between two checks of the DIDs of protected data pending
+	// anchoring on a ledger, such as Orb.
+	AnchorCheckInterval time.Duration
+	// CheckpointInterval, if positive, is the time between two checkpoints of the audit log signed with the key
+	// of Gatekeeper, so that the entries modified after the fact are detected.
+	CheckpointInterval time.Duration
+	// StrictJSON, if set, rejects the policy and protect requests with unknown fields.
+	StrictJSON bool
+	// RequestLimits bound the size, nesting and string length of the JSON request bodies.
+	RequestLimits model.Limits
+	// DIDCommApprovals, if set, pushes the approval requests of new tickets to the agents of the approvers as DIDComm
+	// v2 messages, and lets the approvers authorize and reject tickets with signed DIDComm messages.
+	DIDCommApprovals bool
+	// DIDCommEndpoint, if set with DIDCommApprovals, is the public URL of the DIDComm endpoint of Gatekeeper, which
+	// new handlers and approvers are invited to connect to with out-of-band invitations. The connected handlers can
+	// then be issued authorized handler credentials, once an admin approves them.
+	DIDCommEndpoint string
+	// OIDC4VPVerifierURL, if set, is the URL of the interactions API of the verifier the handlers present the
+	// credentials required by the policies to with OIDC4VP, authenticated with OIDC4VPAuthToken if it is set.
+	OIDC4VPVerifierURL string
+	OIDC4VPAuthToken   string
+	// Region, if set, is the residency region Gatekeeper runs in: data protected in another region is not extracted.
+	Region string
+	// RegionVaultClients are the vault clients of the residency regions, keyed by region, the data protected in the
+	// regions is kept with, instead of VaultClient.
+	RegionVaultClients map[string]vault.Vault
+	// Features, if set, are the feature flags gating the experimental capabilities. The DIDComm endpoints are not
+	// found, and the approval requests not pushed to the agents of the approvers, while feature.DIDComm is disabled.
+	Features *feature.Flags
 }
 
+const (
+	// DefaultProtectWorkers is the default number of protect operations run at a time.
+	DefaultProtectWorkers = 32
+	// DefaultProtectQueueSize is the default number of protect operations waiting for a worker.
+	DefaultProtectQueueSize = 128
+	// DefaultCollectQueueSize is the default number of queued collect requests.
+	DefaultCollectQueueSize = 1000
+	// DefaultCollectWorkers is the default number of collect requests processed at a time.
+	DefaultCollectWorkers = 16
+
+	collectQueueStore = "collect_queue"
+	// auditFlushInterval is the time between two runs of the chaining of the audit entries queued by the instances
+	// that don't hold the leader's lease.
+	auditFlushInterval = 10 * time.Second
+)
+
 // New returns a new Controller instance.
 func New(cfg *Config) (*Controller, error) {
 	policyService, err := policy.NewService(cfg.StorageProvider)
@@ -44,21 +143,113 @@ func New(cfg *Config) (*Controller, error) {
 		return nil, fmt.Errorf("create policy service: %w", err)
 	}
 
+	credentialValidator, err := schema.NewValidator(&schema.Config{
+		Schemas:        cfg.CredentialSchemas,
+		DocumentLoader: cfg.DocumentLoader,
+		StrictJSONLD:   cfg.StrictJSONLD,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create credential validator: %w", err)
+	}
+
 	protectService, err := protect.NewService(&protect.Config{
-		StoreProvider: cfg.StorageProvider,
-		VaultClient:   cfg.VaultClient,
-		VDR:           cfg.VDR,
-		VCIssuer:      cfg.VCIssuer,
+		StoreProvider:       cfg.StorageProvider,
+		VaultClient:         cfg.VaultClient,
+		VDR:                 cfg.VDR,
+		VCIssuer:            cfg.VCProvider,
+		CredentialValidator: credentialValidator,
+		RegionVaultClients:  cfg.RegionVaultClients,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("create protect service: %w", err)
 	}
 
-	releaseService, err := release.NewService(&release.Config{
+	notifier := notify.NewService(&notify.Config{
+		HTTPClient: cfg.HTTPClient,
+		VDR:        cfg.VDR,
+		KeyManager: cfg.KeyManager,
+	})
+
+	subscriptionService, err := subscription.NewService(&subscription.Config{
+		StoreProvider: cfg.StorageProvider,
+		PolicyService: policyService,
+		Notifier:      notifier,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create subscription service: %w", err)
+	}
+
+	jweCrypto, err := tinkcrypto.New()
+	if err != nil {
+		return nil, fmt.Errorf("create JWE crypto: %w", err)
+	}
+
+	encrypter := encrypt.NewService(&encrypt.Config{VDR: cfg.VDR, Crypto: jweCrypto})
+
+	auditConfig := &audit.Config{
+		StoreProvider:  cfg.StorageProvider,
+		VCIssuer:       cfg.VCProvider,
+		VDR:            cfg.VDR,
+		DocumentLoader: cfg.DocumentLoader,
+	}
+
+	if cfg.Leader != nil {
+		auditConfig.Leader = cfg.Leader
+	}
+
+	auditLog, err := audit.NewService(auditConfig)
+	if err != nil {
+		return nil, fmt.Errorf("create audit log: %w", err)
+	}
+
+	ticketWatcher := watch.NewHub()
+	observers := ticketObservers{subscriptionService, ticketWatcher}
+
+	var didcommService *didcomm.Service
+
+	if cfg.DIDCommApprovals {
+		didcommService, err = didcomm.NewService(&didcomm.Config{
+			StoreProvider: cfg.StorageProvider,
+			HTTPClient:    cfg.HTTPClient,
+			VDR:           cfg.VDR,
+			Encrypter:     encrypter,
+			PolicyService: policyService,
+			ConfigService: cfg.ConfigService,
+			Endpoint:      cfg.DIDCommEndpoint,
+			VCIssuer:      cfg.VCProvider,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("create DIDComm service: %w", err)
+		}
+
+		observers = append(observers,
+			&featureObserver{flag: feature.DIDComm, features: cfg.Features, observer: didcommService})
+	}
+
+	var decisionMetrics *metrics.Decisions
+
+	if cfg.MetricsRegisterer != nil {
+		decisionMetrics, err = metrics.NewDecisions(cfg.MetricsRegisterer)
+		if err != nil {
+			return nil, fmt.Errorf("create decision metrics: %w", err)
+		}
+
+		observers = append(observers, decisionMetrics)
+	}
+
+	releaseConfig := &release.Config{
 		StoreProvider:  cfg.StorageProvider,
 		PolicyService:  policyService,
 		ProtectService: protectService,
-	})
+		Observer:       observers,
+		Auditor:        auditLog,
+	}
+
+	if cfg.Leader != nil {
+		releaseConfig.Leader = cfg.Leader
+	}
+
+	releaseService, err := release.NewService(releaseConfig)
 	if err != nil {
 		return nil, fmt.Errorf("create release service: %w", err)
 	}
@@ -67,26 +258,286 @@ func New(cfg *Config) (*Controller, error) {
 		cfg.ConfigService,
 		cfg.VaultClient,
 		cfg.ConfidentialStorageHub,
+		collect.WithRegionVaults(cfg.RegionVaultClients),
 	)
 
 	extractService := extract.NewService(cfg.ConfidentialStorageHub)
 
+	consentService, err := consent.NewService(&consent.Config{
+		StoreProvider: cfg.StorageProvider,
+		VCIssuer:      cfg.VCProvider,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create consent service: %w", err)
+	}
+
+	presentationVerifier := presentation.NewService(&presentation.Config{
+		VDR:                 cfg.VDR,
+		DocumentLoader:      cfg.DocumentLoader,
+		CredentialValidator: credentialValidator,
+	})
+
+	watermarker, err := watermark.NewService(cfg.StorageProvider)
+	if err != nil {
+		return nil, fmt.Errorf("create watermark service: %w", err)
+	}
+
+	delegationService, err := delegation.NewService(cfg.StorageProvider)
+	if err != nil {
+		return nil, fmt.Errorf("create delegation service: %w", err)
+	}
+
+	quotaService, err := quota.NewService(cfg.StorageProvider)
+	if err != nil {
+		return nil, fmt.Errorf("create quota service: %w", err)
+	}
+
 	op := &operation.Operation{
-		PolicyService:   policyService,
-		ProtectService:  protectService,
-		ReleaseService:  releaseService,
-		CollectService:  collectService,
-		ExtractService:  extractService,
-		SubjectResolver: &subjectDIDResolver{},
+		PolicyService:        policyService,
+		ProtectService:       protectService,
+		ReleaseService:       releaseService,
+		CollectService:       collectService,
+		ExtractService:       extractService,
+		ConsentService:       consentService,
+		Notifier:             notifier,
+		Watermarker:          watermarker,
+		ExtractEncrypter:     encrypter,
+		PresentationVerifier: presentationVerifier,
+		SubjectResolver:      &subjectDIDResolver{},
+		ReferenceResolver:    &subjectDIDResolver{},
+		DelegationService:    delegationService,
+		QuotaService:         quotaService,
+		SubscriptionService:  subscriptionService,
+		StrictJSON:           cfg.StrictJSON,
+		RequestLimits:        cfg.RequestLimits,
+		TicketWatcher:        ticketWatcher,
+		Region:               cfg.Region,
+		AuditLog:             auditLog,
+	}
+
+	if decisionMetrics != nil {
+		op.DecisionMetrics = decisionMetrics
+	}
+
+	if cfg.Features != nil {
+		op.Features = cfg.Features
+	}
+
+	// the tickets are updated by every instance if the store writes them conditionally
+	if releaseService.RequiresLeader() {
+		op.Leader = cfg.Leader
 	}
 
-	return &Controller{handlers: op.GetRESTHandlers()}, nil
+	if didcommService != nil {
+		op.DIDCommReceiver = didcommService
+
+		if cfg.DIDCommEndpoint != "" {
+			op.DIDCommInviter = didcommService
+			op.DIDCommIssuer = didcommService
+		}
+	}
+
+	if cfg.OIDC4VPVerifierURL != "" {
+		verifier, e := oidc4vp.NewService(&oidc4vp.Config{
+			VerifierURL:   cfg.OIDC4VPVerifierURL,
+			AuthToken:     cfg.OIDC4VPAuthToken,
+			HTTPClient:    cfg.HTTPClient,
+			StoreProvider: cfg.StorageProvider,
+		})
+		if e != nil {
+			return nil, fmt.Errorf("create OIDC4VP verifier: %w", e)
+		}
+
+		op.OIDC4VPVerifier = verifier
+	}
+
+	if cfg.Purger != nil {
+		op.PurgeService = cfg.Purger
+	}
+
+	if cfg.ProtectWorkers > 0 {
+		op.ProtectPool = workerpool.New(cfg.ProtectWorkers, cfg.ProtectQueueSize)
+	}
+
+	c := &Controller{op: op, handlers: op.GetRESTHandlers()}
+
+	if cfg.CollectQueueSize > 0 {
+		if err = c.startCollectQueue(cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.AnchorCheckInterval > 0 {
+		c.startAnchorCheck(protectService, cfg.AnchorCheckInterval, cfg.Leader)
+	}
+
+	if cfg.CheckpointInterval > 0 {
+		c.startAuditCheckpoints(auditLog, cfg.CheckpointInterval, cfg.Leader)
+	}
+
+	if cfg.Leader != nil {
+		c.startAuditFlush(auditLog)
+	}
+
+	return c, nil
+}
+
+// startAnchorCheck records the DIDs of protected data anchored since they were created, until the controller is
+// closed. The checks are only run while the instance holds the leader's lease, if it is set.
+func (c *Controller) startAnchorCheck(protectService *protect.Service, interval time.Duration, leader *lease.Lease) {
+	ticker := time.NewTicker(interval)
+	c.anchorCheckDone = make(chan struct{})
+
+	go func() {
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if leader != nil && !leader.Held() {
+					continue
+				}
+
+				n, err := protectService.CheckAnchoring(context.Background())
+				if err != nil {
+					logger.Errorf("Failed to check anchoring of protected data: %s", err)
+				} else if n > 0 {
+					logger.Infof("%d DIDs of protected data anchored", n)
+				}
+			case <-c.anchorCheckDone:
+				return
+			}
+		}
+	}()
+}
+
+// startAuditCheckpoints signs the checkpoints of the audit log, until the controller is closed. The checkpoints are
+// only signed while the instance holds the leader's lease, if it is set.
+func (c *Controller) startAuditCheckpoints(auditLog *audit.Service, interval time.Duration, leader *lease.Lease) {
+	ticker := time.NewTicker(interval)
+	c.checkpointsDone = make(chan struct{})
+
+	go func() {
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if leader != nil && !leader.Held() {
+					continue
+				}
+
+				if err := auditLog.Flush(context.Background()); err != nil {
+					logger.Errorf("Failed to chain queued entries of audit log: %s", err)
+				}
+
+				vc, err := auditLog.Checkpoint(context.Background())
+				if err != nil {
+					logger.Errorf("Failed to sign checkpoint of audit log: %s", err)
+				} else if vc != nil {
+					logger.Infof("Checkpoint %s of audit log signed", vc.ID)
+				}
+			case <-c.checkpointsDone:
+				return
+			}
+		}
+	}()
+}
+
+// startAuditFlush chains the audit entries queued by the other instances while the instance holds the leader's
+// lease, until the controller is closed.
+func (c *Controller) startAuditFlush(auditLog *audit.Service) {
+	ticker := time.NewTicker(auditFlushInterval)
+	c.auditFlushDone = make(chan struct{})
+
+	go func() {
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if err := auditLog.Flush(context.Background()); err != nil {
+					logger.Errorf("Failed to chain queued entries of audit log: %s", err)
+				}
+			case <-c.auditFlushDone:
+				return
+			}
+		}
+	}()
+}
+
+func (c *Controller) startCollectQueue(cfg *Config) error {
+	workers := cfg.CollectWorkers
+	if workers <= 0 {
+		workers = DefaultCollectWorkers
+	}
+
+	q, err := queue.New(cfg.StorageProvider, collectQueueStore, c.op.ProcessCollect,
+		queue.WithCapacity(cfg.CollectQueueSize), queue.WithWorkers(workers))
+	if err != nil {
+		return fmt.Errorf("create collect queue: %w", err)
+	}
+
+	if cfg.MetricsRegisterer != nil {
+		err = cfg.MetricsRegisterer.Register(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: "gatekeeper",
+			Name:      "collect_queue_depth",
+			Help:      "Number of queued collect requests, including the ones being processed.",
+		}, func() float64 {
+			return float64(q.Depth())
+		}))
+		if err != nil {
+			return fmt.Errorf("register collect queue metrics: %w", err)
+		}
+	}
+
+	q.Start()
+
+	c.op.CollectQueue = q
+	c.collectQueue = q
+
+	return nil
+}
+
+// ticketObservers observes the tickets of the release service on behalf of several observers.
+type ticketObservers []interface {
+	TicketUpdated(ctx context.Context, t *ticket.Ticket)
+}
+
+func (o ticketObservers) TicketUpdated(ctx context.Context, t *ticket.Ticket) {
+	for _, observer := range o {
+		observer.TicketUpdated(ctx, t)
+	}
+}
+
+// featureObserver observes the tickets of the release service on behalf of the observer while the feature flag is
+// enabled.
+type featureObserver struct {
+	flag     string
+	features *feature.Flags
+	observer interface {
+		TicketUpdated(ctx context.Context, t *ticket.Ticket)
+	}
+}
+
+func (o *featureObserver) TicketUpdated(ctx context.Context, t *ticket.Ticket) {
+	if o.features.Enabled(o.flag) {
+		o.observer.TicketUpdated(ctx, t)
+	}
 }
 
 type subjectDIDResolver struct{}
 
 func (r *subjectDIDResolver) Resolve(ctx context.Context) (string, error) {
-	sub, ok := httpsigmw.SubjectDID(ctx)
+	sub, ok := didcomm.Sender(ctx)
+	if !ok {
+		sub, ok = httpsigmw.SubjectDID(ctx)
+	}
+
+	if !ok {
+		sub, ok = grpcgatekeeper.SubjectDID(ctx)
+	}
+
 	if !ok {
 		return "", fmt.Errorf("missing subject DID in context")
 	}
@@ -94,12 +545,58 @@ func (r *subjectDIDResolver) Resolve(ctx context.Context) (string, error) {
 	return sub, nil
 }
 
+// Reference returns the signature of the DIDComm message or the HTTP signature the subject is authenticated with,
+// which is also the signature of gRPC calls.
+func (r *subjectDIDResolver) Reference(ctx context.Context) string {
+	signature, ok := didcomm.Signature(ctx)
+	if !ok {
+		signature, ok = httpsigmw.Signature(ctx)
+	}
+
+	if !ok {
+		signature, _ = grpcgatekeeper.Signature(ctx)
+	}
+
+	return signature
+}
+
 // Controller contains handlers for controller.
 type Controller struct {
-	handlers []handler.Handler
+	op              *operation.Operation
+	handlers        []handler.Handler
+	collectQueue    *queue.Queue
+	anchorCheckDone chan struct{}
+	checkpointsDone chan struct{}
+	auditFlushDone  chan struct{}
 }
 
 // GetOperations returns all controller endpoints.
 func (c *Controller) GetOperations() []handler.Handler {
 	return c.handlers
 }
+
+// Operation returns the operations served by the controller, e.g. to serve them with other APIs.
+func (c *Controller) Operation() *operation.Operation {
+	return c.op
+}
+
+// Close stops processing the collect queue, if any, checking the anchoring of DIDs, and signing the checkpoints of the
+// audit log and chaining its queued entries. The requests left in the queue are processed once the controller is
+// created again with the same storage.
+func (c *Controller) Close() {
+	if c.collectQueue != nil {
+		c.collectQueue.Stop()
+	}
+
+	if c.anchorCheckDone != nil {
+		close(c.anchorCheckDone)
+	}
+
+	if c.checkpointsDone != nil {
+		close(c.checkpointsDone)
+	}
+
+	if c.auditFlushDone != nil {
+		close(c.auditFlushDone)
+	}
+}
@@ -8,10 +8,15 @@ package gatekeeper_test
 
 import (
 	"testing"
+	"time"
 
+	"github.com/hyperledger/aries-framework-go/component/storageutil/mem"
 	"github.com/hyperledger/aries-framework-go/pkg/mock/storage"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/require"
 
+	"github.com/trustbloc/ace/pkg/doc/vc/schema"
+	"github.com/trustbloc/ace/pkg/feature"
 	"github.com/trustbloc/ace/pkg/restapi/gatekeeper"
 )
 
@@ -26,5 +31,136 @@ func TestController_New(t *testing.T) {
 		ops := controller.GetOperations()
 
 		require.Greater(t, len(ops), 0)
+		require.NotNil(t, controller.Operation())
+		require.Nil(t, controller.Operation().ProtectPool)
+		require.Nil(t, controller.Operation().CollectQueue)
+	})
+
+	t.Run("test success: protect worker pool", func(t *testing.T) {
+		controller, err := gatekeeper.New(&gatekeeper.Config{
+			StorageProvider:  storage.NewMockStoreProvider(),
+			ProtectWorkers:   gatekeeper.DefaultProtectWorkers,
+			ProtectQueueSize: gatekeeper.DefaultProtectQueueSize,
+		})
+		require.NoError(t, err)
+		require.NotNil(t, controller.Operation().ProtectPool)
+	})
+
+	t.Run("test success: collect queue", func(t *testing.T) {
+		registry := prometheus.NewRegistry()
+
+		controller, err := gatekeeper.New(&gatekeeper.Config{
+			StorageProvider:   mem.NewProvider(),
+			CollectQueueSize:  gatekeeper.DefaultCollectQueueSize,
+			CollectWorkers:    gatekeeper.DefaultCollectWorkers,
+			MetricsRegisterer: registry,
+		})
+		require.NoError(t, err)
+		require.NotNil(t, controller.Operation().CollectQueue)
+		require.NotNil(t, controller.Operation().DecisionMetrics)
+
+		defer controller.Close()
+
+		metrics, err := registry.Gather()
+		require.NoError(t, err)
+		require.Len(t, metrics, 1)
+		require.Equal(t, "gatekeeper_collect_queue_depth", metrics[0].GetName())
+		require.Zero(t, metrics[0].GetMetric()[0].GetGauge().GetValue())
+	})
+
+	t.Run("test success: anchor check", func(t *testing.T) {
+		controller, err := gatekeeper.New(&gatekeeper.Config{
+			StorageProvider:     mem.NewProvider(),
+			AnchorCheckInterval: time.Millisecond,
+		})
+		require.NoError(t, err)
+
+		time.Sleep(10 * time.Millisecond)
+
+		controller.Close()
+	})
+
+	t.Run("test success: audit checkpoints", func(t *testing.T) {
+		controller, err := gatekeeper.New(&gatekeeper.Config{
+			StorageProvider:    mem.NewProvider(),
+			CheckpointInterval: time.Millisecond,
+		})
+		require.NoError(t, err)
+		require.NotNil(t, controller.Operation().AuditLog)
+
+		time.Sleep(10 * time.Millisecond)
+
+		controller.Close()
+	})
+
+	t.Run("test success: DIDComm approvals", func(t *testing.T) {
+		controller, err := gatekeeper.New(&gatekeeper.Config{
+			StorageProvider:  mem.NewProvider(),
+			DIDCommApprovals: true,
+		})
+		require.NoError(t, err)
+		require.NotNil(t, controller.Operation().DIDCommReceiver)
+		require.Nil(t, controller.Operation().DIDCommInviter)
+
+		controller, err = gatekeeper.New(&gatekeeper.Config{
+			StorageProvider:  mem.NewProvider(),
+			DIDCommApprovals: true,
+			DIDCommEndpoint:  "https://gatekeeper.example.com/v1/didcomm",
+		})
+		require.NoError(t, err)
+		require.NotNil(t, controller.Operation().DIDCommInviter)
+		require.NotNil(t, controller.Operation().DIDCommIssuer)
+	})
+
+	t.Run("test success: feature flags", func(t *testing.T) {
+		flags := feature.New(map[string]bool{feature.DIDComm: false})
+
+		controller, err := gatekeeper.New(&gatekeeper.Config{
+			StorageProvider:  mem.NewProvider(),
+			DIDCommApprovals: true,
+			Features:         flags,
+		})
+		require.NoError(t, err)
+		require.Equal(t, flags, controller.Operation().Features)
+	})
+
+	t.Run("test success: OIDC4VP verifier", func(t *testing.T) {
+		controller, err := gatekeeper.New(&gatekeeper.Config{
+			StorageProvider:    mem.NewProvider(),
+			OIDC4VPVerifierURL: "https://verifier.example.com/oidc4vp",
+		})
+		require.NoError(t, err)
+		require.NotNil(t, controller.Operation().OIDC4VPVerifier)
+	})
+
+	t.Run("test error: register collect queue metrics", func(t *testing.T) {
+		registry := prometheus.NewRegistry()
+
+		for i := 0; i < 2; i++ {
+			controller, err := gatekeeper.New(&gatekeeper.Config{
+				StorageProvider:   mem.NewProvider(),
+				CollectQueueSize:  gatekeeper.DefaultCollectQueueSize,
+				MetricsRegisterer: registry,
+			})
+			if i == 0 {
+				require.NoError(t, err)
+				controller.Close()
+
+				continue
+			}
+
+			require.Error(t, err)
+			require.Contains(t, err.Error(), "register collect queue metrics")
+		}
+	})
+
+	t.Run("test error: invalid credential schema", func(t *testing.T) {
+		controller, err := gatekeeper.New(&gatekeeper.Config{
+			StorageProvider:   storage.NewMockStoreProvider(),
+			CredentialSchemas: []*schema.Schema{{ID: "test-schema", Document: []byte("invalid")}},
+		})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "create credential validator")
+		require.Nil(t, controller)
 	})
 }
@@ -0,0 +1,127 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package operation
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// etagLength is the number of bytes of the digest of a representation kept in its ETag.
+const etagLength = 16
+
+var (
+	// ErrPreconditionFailed is returned when the If-Match header of a request doesn't match the entity tag of the
+	// current representation, reported with 412 Precondition Failed.
+	ErrPreconditionFailed = errors.New("precondition failed")
+	// ErrPreconditionRequired is returned when a request replacing a representation has no If-Match header, reported
+	// with 428 Precondition Required.
+	ErrPreconditionRequired = errors.New("precondition required")
+)
+
+// ETag returns the strong entity tag of the JSON representation of the value, e.g. a policy, which changes whenever
+// the representation does.
+func ETag(v interface{}) (string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("marshal representation: %w", err)
+	}
+
+	sum := sha256.Sum256(b)
+
+	return `"` + hex.EncodeToString(sum[:etagLength]) + `"`, nil
+}
+
+// etagMatches tells whether the If-Match or If-None-Match header, a comma-separated list of entity tags or "*",
+// matches the current entity tag. Weak entity tags only match with the weak comparison of If-None-Match.
+func etagMatches(header, etag string, weak bool) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+
+		if weak {
+			tag = strings.TrimPrefix(tag, "W/")
+		}
+
+		if tag == "*" || tag == etag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkIfMatch checks the If-Match header of a request replacing the representation with the entity tag, or creating
+// it if the entity tag is empty. Representations are only replaced with a matching If-Match header, and only created
+// without one.
+func checkIfMatch(ifMatch, etag string) error {
+	switch {
+	case etag == "" && ifMatch == "":
+		return nil
+	case etag == "":
+		return &Error{Status: http.StatusPreconditionFailed, Err: fmt.Errorf("%w: not found", ErrPreconditionFailed)}
+	case ifMatch == "":
+		return &Error{
+			Status: http.StatusPreconditionRequired,
+			Err:    fmt.Errorf("%w: If-Match header not set", ErrPreconditionRequired),
+		}
+	case !etagMatches(ifMatch, etag, false):
+		return &Error{
+			Status: http.StatusPreconditionFailed,
+			Err:    fmt.Errorf("%w: modified since it was read, current ETag %s", ErrPreconditionFailed, etag),
+		}
+	default:
+		return nil
+	}
+}
+
+// respondCacheable responds with the payload, its ETag and its modification time, if it is known, for the clients and
+// proxies to cache it. Cached responses are private to the authenticated client and revalidated on every use: the
+// response is 304 Not Modified, without the payload, if the If-None-Match header matches the ETag or, without it, if
+// the payload was not modified since the If-Modified-Since header.
+func respondCacheable(rw http.ResponseWriter, r *http.Request, payload interface{}, modified time.Time) {
+	etag, err := ETag(payload)
+	if err != nil {
+		respondError(rw, http.StatusInternalServerError, err)
+
+		return
+	}
+
+	rw.Header().Set("ETag", etag)
+	rw.Header().Set("Cache-Control", "private, no-cache")
+
+	if !modified.IsZero() {
+		rw.Header().Set("Last-Modified", modified.UTC().Format(http.TimeFormat))
+	}
+
+	if notModified(r, etag, modified) {
+		rw.WriteHeader(http.StatusNotModified)
+
+		return
+	}
+
+	respond(rw, http.StatusOK, payload)
+}
+
+func notModified(r *http.Request, etag string, modified time.Time) bool {
+	if ifNoneMatch := r.Header.Get("If-None-Match"); ifNoneMatch != "" {
+		return etagMatches(ifNoneMatch, etag, true)
+	}
+
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil || modified.IsZero() {
+		return false
+	}
+
+	// Last-Modified has a precision of a second
+	return !modified.Truncate(time.Second).After(since)
+}
@@ -0,0 +1,1812 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package operation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/aries-framework-go/pkg/doc/presexch"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
+	"github.com/hyperledger/aries-framework-go/spi/storage"
+
+	"github.com/trustbloc/ace/pkg/gatekeeper/audit"
+	"github.com/trustbloc/ace/pkg/gatekeeper/consent"
+	"github.com/trustbloc/ace/pkg/gatekeeper/delegation"
+	"github.com/trustbloc/ace/pkg/gatekeeper/encrypt"
+	"github.com/trustbloc/ace/pkg/gatekeeper/notify"
+	"github.com/trustbloc/ace/pkg/gatekeeper/oidc4vp"
+	"github.com/trustbloc/ace/pkg/gatekeeper/policy"
+	"github.com/trustbloc/ace/pkg/gatekeeper/protect"
+	"github.com/trustbloc/ace/pkg/gatekeeper/quota"
+	"github.com/trustbloc/ace/pkg/gatekeeper/release"
+	"github.com/trustbloc/ace/pkg/gatekeeper/release/ticket"
+	"github.com/trustbloc/ace/pkg/gatekeeper/subscription"
+	"github.com/trustbloc/ace/pkg/gatekeeper/watermark"
+	"github.com/trustbloc/ace/pkg/queue"
+	"github.com/trustbloc/ace/pkg/restapi/model"
+	"github.com/trustbloc/ace/pkg/workerpool"
+)
+
+// ErrNotAuthorized is matched by the errors of the operations the subject is not authorized to do, reported with
+// 401 Unauthorized.
+var ErrNotAuthorized = errors.New("not authorized")
+
+// notAuthorizedError is the error of an operation the subject is not authorized to do, for the given reason.
+type notAuthorizedError struct {
+	reason error
+}
+
+func (e *notAuthorizedError) Error() string {
+	return e.reason.Error()
+}
+
+// Unwrap returns the reason the subject is not authorized.
+func (e *notAuthorizedError) Unwrap() error {
+	return e.reason
+}
+
+// Is reports whether the target is ErrNotAuthorized.
+func (e *notAuthorizedError) Is(target error) bool {
+	return target == ErrNotAuthorized
+}
+
+// notAuthorized returns the error of an operation the subject is not authorized to do for the reason, matching
+// ErrNotAuthorized.
+func notAuthorized(reason error) *Error {
+	return &Error{Status: http.StatusUnauthorized, Err: &notAuthorizedError{reason: reason}}
+}
+
+// Error is an error of a Gatekeeper operation with the HTTP status it is reported with.
+type Error struct {
+	Status int
+	Err    error
+	// RetryAfter, if set, is the time after which the operation can be retried.
+	RetryAfter time.Duration
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Err.Error()
+	}
+
+	return ""
+}
+
+// Unwrap returns the underlying error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// ErrorStatus returns the HTTP status the error of an operation is reported with.
+func ErrorStatus(err error) int {
+	var e *Error
+
+	if errors.As(err, &e) {
+		return e.Status
+	}
+
+	return http.StatusInternalServerError
+}
+
+// The methods below implement the Gatekeeper operations independently of the transport, so that the REST handlers and
+// other APIs behave the same. The subject DID is resolved from the context with the SubjectResolver.
+
+// SavePolicy creates the policy with the given ID, or replaces it if ifMatch, the If-Match header of the request,
+// matches its entity tag, so that administrators editing the same policy don't overwrite each other's changes. It
+// returns the entity tag of the saved policy. The check and the write are serialized per policy, so that of two
+// updates made with the same entity tag only the first one is saved. The stores don't write conditionally: the saves
+// are only serialized within the instance, policies must be edited on a single instance.
+func (o *Operation) SavePolicy(ctx context.Context, policyID, ifMatch string, p *policy.Policy) (string, error) {
+	p.ID = strings.ToLower(policyID)
+
+	mu := o.policyLock(p.ID)
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	var etag string
+
+	current, err := o.PolicyService.Get(ctx, p.ID)
+
+	switch {
+	case errors.Is(err, policy.ErrPolicyNotFound):
+	case err != nil:
+		return "", &Error{Status: http.StatusInternalServerError, Err: err}
+	default:
+		if etag, err = ETag(current); err != nil {
+			return "", &Error{Status: http.StatusInternalServerError, Err: err}
+		}
+	}
+
+	if err = checkIfMatch(ifMatch, etag); err != nil {
+		return "", err
+	}
+
+	if err = o.PolicyService.Save(ctx, p); err != nil {
+		return "", &Error{Status: http.StatusInternalServerError, Err: fmt.Errorf("save policy: %w", err)}
+	}
+
+	if etag, err = ETag(p); err != nil {
+		return "", &Error{Status: http.StatusInternalServerError, Err: err}
+	}
+
+	return etag, nil
+}
+
+// policyLock returns the lock serializing the saves of the policy.
+func (o *Operation) policyLock(policyID string) *sync.Mutex {
+	mu, _ := o.policyLocks.LoadOrStore(policyID, &sync.Mutex{})
+
+	return mu.(*sync.Mutex) //nolint:forcetypeassert
+}
+
+// GetPolicy returns the policy with the given ID.
+func (o *Operation) GetPolicy(ctx context.Context, policyID string) (*policy.Policy, error) {
+	p, err := o.PolicyService.Get(ctx, strings.ToLower(policyID))
+	if errors.Is(err, policy.ErrPolicyNotFound) {
+		return nil, &Error{Status: http.StatusNotFound, Err: err}
+	}
+
+	if err != nil {
+		return nil, &Error{Status: http.StatusInternalServerError, Err: err}
+	}
+
+	return p, nil
+}
+
+// DeletePolicy deletes the policy with the given ID. The policy can be restored until it is purged.
+func (o *Operation) DeletePolicy(ctx context.Context, policyID string) error {
+	if _, err := o.GetPolicy(ctx, policyID); err != nil {
+		return err
+	}
+
+	if err := o.PolicyService.Delete(ctx, strings.ToLower(policyID)); err != nil {
+		return &Error{Status: http.StatusInternalServerError, Err: err}
+	}
+
+	return nil
+}
+
+// ListPolicies passes all policies to the function, until it returns an error.
+func (o *Operation) ListPolicies(ctx context.Context, fn func(p *policy.Policy) error) error {
+	if err := o.PolicyService.Iterate(ctx, pageSize, fn); err != nil {
+		return &Error{Status: http.StatusInternalServerError, Err: fmt.Errorf("list policies: %w", err)}
+	}
+
+	return nil
+}
+
+// operationRoles are the roles running the operations evaluated by EvaluatePolicies.
+var operationRoles = map[string]policy.Role{ //nolint:gochecknoglobals
+	"protect":     policy.Collector,
+	"release":     policy.Handler,
+	"collect":     policy.Handler,
+	"approve":     policy.Approver,
+	"reject":      policy.Approver,
+	"break_glass": policy.Responder,
+}
+
+// EvaluatePolicies tells for each evaluation whether the actor may run the operation under the policy, reading each
+// policy once.
+func (o *Operation) EvaluatePolicies(ctx context.Context, req *EvaluateRequest) (*EvaluateResponse, error) {
+	if req.Actor == "" {
+		return nil, &Error{Status: http.StatusBadRequest, Err: errors.New("actor is required")}
+	}
+
+	if len(req.Evaluations) > maxEvaluations {
+		return nil, &Error{
+			Status: http.StatusBadRequest,
+			Err:    fmt.Errorf("more than %d evaluations", maxEvaluations),
+		}
+	}
+
+	policies := map[string]*policy.Policy{}
+	resp := &EvaluateResponse{Results: make([]*EvaluationResult, 0, len(req.Evaluations))}
+
+	for _, e := range req.Evaluations {
+		result := &EvaluationResult{PolicyID: e.PolicyID, Operation: e.Operation}
+		resp.Results = append(resp.Results, result)
+
+		role, ok := operationRoles[e.Operation]
+		if !ok {
+			result.Error = fmt.Sprintf("unknown operation %q", e.Operation)
+
+			continue
+		}
+
+		policyID := strings.ToLower(e.PolicyID)
+
+		p, ok := policies[policyID]
+		if !ok {
+			var err error
+
+			p, err = o.PolicyService.Get(ctx, policyID)
+			if err != nil && !errors.Is(err, policy.ErrPolicyNotFound) {
+				return nil, &Error{Status: http.StatusInternalServerError, Err: err}
+			}
+
+			policies[policyID] = p
+		}
+
+		if p == nil {
+			result.Error = policy.ErrPolicyNotFound.Error()
+
+			continue
+		}
+
+		result.Allowed = p.Allows(req.Actor, role)
+	}
+
+	return resp, nil
+}
+
+// Protect protects the target under the policy, if the subject is a collector of the policy. A consent receipt is
+// issued to the collector if the policy defines a consent and the ConsentService is set. The target is protected in
+// the residency region of the request, if any.
+func (o *Operation) Protect(ctx context.Context, req *ProtectRequest) (*ProtectResponse, error) {
+	sub, err := o.checkPolicy(ctx, req.Policy, policy.Collector)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		protectedData *protect.ProtectedData
+		opts          []protect.Option
+	)
+
+	if req.Region != "" {
+		opts = append(opts, protect.WithRegion(req.Region))
+	}
+
+	err = o.doProtect(ctx, func(ctx context.Context) error {
+		var e error
+
+		protectedData, e = o.ProtectService.Protect(ctx, req.Target, req.Policy, opts...)
+
+		return e
+	})
+
+	switch {
+	case errors.Is(err, workerpool.ErrSaturated):
+		return nil, &Error{Status: http.StatusServiceUnavailable, Err: err, RetryAfter: saturatedRetryAfter}
+	case errors.Is(err, protect.ErrUnknownRegion):
+		return nil, &Error{Status: http.StatusBadRequest, Err: err}
+	case errors.Is(err, protect.ErrRegionMismatch):
+		return nil, &Error{Status: http.StatusConflict, Err: err}
+	case err != nil:
+		return nil, &Error{Status: http.StatusInternalServerError, Err: err}
+	}
+
+	receipt, err := o.issueConsentReceipt(ctx, req, protectedData, sub)
+	if err != nil {
+		return nil, &Error{Status: http.StatusInternalServerError, Err: err}
+	}
+
+	return &ProtectResponse{DID: protectedData.DID, ConsentReceipt: receipt}, nil
+}
+
+// ProtectBatch protects the targets under the policy, if the subject is a collector of the policy, saving the new
+// protected data in one batch. A consent receipt is issued to the collector for each target if the policy defines a
+// consent and the ConsentService is set. The targets are protected in no residency region.
+func (o *Operation) ProtectBatch(ctx context.Context, req *ProtectBatchRequest) (*ProtectBatchResponse, error) {
+	if err := validateProtectBatch(req); err != nil {
+		return nil, err
+	}
+
+	sub, err := o.checkPolicy(ctx, req.Policy, policy.Collector)
+	if err != nil {
+		return nil, err
+	}
+
+	var protectedData []*protect.ProtectedData
+
+	err = o.doProtect(ctx, func(ctx context.Context) error {
+		var e error
+
+		protectedData, e = o.ProtectService.ProtectAll(ctx, req.Policy, req.Targets...)
+
+		return e
+	})
+
+	switch {
+	case errors.Is(err, workerpool.ErrSaturated):
+		return nil, &Error{Status: http.StatusServiceUnavailable, Err: err, RetryAfter: saturatedRetryAfter}
+	case err != nil:
+		return nil, &Error{Status: http.StatusInternalServerError, Err: err}
+	}
+
+	resp := &ProtectBatchResponse{DIDs: make([]string, len(protectedData))}
+
+	for i, data := range protectedData {
+		resp.DIDs[i] = data.DID
+	}
+
+	if resp.ConsentReceipts, err = o.issueConsentReceipts(ctx, req.Policy, protectedData, sub); err != nil {
+		return nil, &Error{Status: http.StatusInternalServerError, Err: err}
+	}
+
+	return resp, nil
+}
+
+func validateProtectBatch(req *ProtectBatchRequest) error {
+	if len(req.Targets) == 0 {
+		return &Error{Status: http.StatusBadRequest, Err: errors.New("no targets to protect")}
+	}
+
+	if len(req.Targets) > maxProtectBatch {
+		return &Error{
+			Status: http.StatusBadRequest,
+			Err:    fmt.Errorf("too many targets to protect: %d, at most %d", len(req.Targets), maxProtectBatch),
+		}
+	}
+
+	return nil
+}
+
+// issueConsentReceipt issues the receipt of the consent of the policy of the protected data, if any.
+func (o *Operation) issueConsentReceipt(ctx context.Context, req *ProtectRequest, data *protect.ProtectedData,
+	collector string) (json.RawMessage, error) {
+	if o.ConsentService == nil {
+		return nil, nil
+	}
+
+	p, err := o.PolicyService.Get(ctx, req.Policy)
+	if err != nil {
+		return nil, fmt.Errorf("get policy: %w", err)
+	}
+
+	if p.Consent == nil {
+		return nil, nil
+	}
+
+	return o.consentReceipt(ctx, p, data.DID, req.Subject, collector)
+}
+
+// issueConsentReceipts issues the receipts of the consent of the policy of each of the protected data, if any.
+func (o *Operation) issueConsentReceipts(ctx context.Context, policyID string, data []*protect.ProtectedData,
+	collector string) ([]json.RawMessage, error) {
+	if o.ConsentService == nil {
+		return nil, nil
+	}
+
+	p, err := o.PolicyService.Get(ctx, policyID)
+	if err != nil {
+		return nil, fmt.Errorf("get policy: %w", err)
+	}
+
+	if p.Consent == nil {
+		return nil, nil
+	}
+
+	receipts := make([]json.RawMessage, len(data))
+
+	for i, d := range data {
+		if receipts[i], err = o.consentReceipt(ctx, p, d.DID, "", collector); err != nil {
+			return nil, err
+		}
+	}
+
+	return receipts, nil
+}
+
+// consentReceipt issues the receipt of the consent of the policy to the collector for the protected data.
+func (o *Operation) consentReceipt(ctx context.Context, p *policy.Policy, did, subject,
+	collector string) (json.RawMessage, error) {
+	vc, err := o.ConsentService.Issue(ctx, &consent.Receipt{
+		DID:        did,
+		Subject:    subject,
+		Controller: collector,
+		PolicyID:   p.ID,
+		Purposes:   p.Consent.Purposes,
+		Retention:  p.Consent.Retention,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("issue consent receipt: %w", err)
+	}
+
+	b, err := vc.MarshalJSON()
+	if err != nil {
+		return nil, fmt.Errorf("marshal consent receipt: %w", err)
+	}
+
+	return b, nil
+}
+
+// doProtect runs fn with the ProtectPool if it is set.
+func (o *Operation) doProtect(ctx context.Context, fn func(ctx context.Context) error) error {
+	if o.ProtectPool == nil {
+		return fn(ctx)
+	}
+
+	return o.ProtectPool.Do(ctx, fn)
+}
+
+// Rotate replaces the DID of the protected data with a new one, if the subject is a collector of its policy and knows
+// the target it was protected from. The response carries the continuity credential linking the previous DID to the new
+// one, and a new consent receipt if the policy defines a consent and the ConsentService is set.
+func (o *Operation) Rotate(ctx context.Context, req *RotateRequest) (*RotateResponse, error) {
+	previous, err := o.ProtectService.Get(ctx, req.DID)
+	if err != nil {
+		return nil, notFoundError(err, http.StatusNotFound)
+	}
+
+	sub, err := o.checkPolicy(ctx, previous.PolicyID, policy.Collector)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		protectedData *protect.ProtectedData
+		continuity    *verifiable.Credential
+	)
+
+	err = o.doProtect(ctx, func(ctx context.Context) error {
+		var e error
+
+		protectedData, continuity, e = o.ProtectService.Rotate(ctx, previous, req.Target)
+
+		return e
+	})
+	if errors.Is(err, workerpool.ErrSaturated) {
+		return nil, &Error{Status: http.StatusServiceUnavailable, Err: err, RetryAfter: saturatedRetryAfter}
+	}
+
+	if errors.Is(err, protect.ErrTargetMismatch) {
+		return nil, &Error{Status: http.StatusBadRequest, Err: err}
+	}
+
+	if err != nil {
+		return nil, &Error{Status: http.StatusInternalServerError, Err: fmt.Errorf("rotate did: %w", err)}
+	}
+
+	resp := &RotateResponse{DID: protectedData.DID}
+
+	if resp.ContinuityCredential, err = continuity.MarshalJSON(); err != nil {
+		return nil, &Error{Status: http.StatusInternalServerError, Err: fmt.Errorf("marshal continuity credential: %w", err)}
+	}
+
+	if resp.ConsentReceipt, err = o.reissueConsentReceipt(ctx, previous, protectedData, sub); err != nil {
+		return nil, &Error{Status: http.StatusInternalServerError, Err: err}
+	}
+
+	return resp, nil
+}
+
+// Repolicy moves the protected data under another policy, if the subject is a collector of both policies. If the new
+// policy defines a consent, a consent receipt is issued under it.
+func (o *Operation) Repolicy(ctx context.Context, did string, req *RepolicyRequest) (*RepolicyResponse, error) {
+	previous, err := o.ProtectService.Get(ctx, did)
+	if err != nil {
+		return nil, notFoundError(err, http.StatusNotFound)
+	}
+
+	sub, err := o.checkPolicy(ctx, previous.PolicyID, policy.Collector)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = o.checkPolicy(ctx, req.Policy, policy.Collector); err != nil {
+		return nil, err
+	}
+
+	var protectedData *protect.ProtectedData
+
+	err = o.doProtect(ctx, func(ctx context.Context) error {
+		var e error
+
+		protectedData, e = o.ProtectService.Repolicy(ctx, previous, req.Target, req.Policy, req.Reanonymize)
+
+		return e
+	})
+	if errors.Is(err, workerpool.ErrSaturated) {
+		return nil, &Error{Status: http.StatusServiceUnavailable, Err: err, RetryAfter: saturatedRetryAfter}
+	}
+
+	if errors.Is(err, protect.ErrTargetMismatch) {
+		return nil, &Error{Status: http.StatusBadRequest, Err: err}
+	}
+
+	if errors.Is(err, protect.ErrAlreadyProtected) {
+		return nil, &Error{Status: http.StatusConflict, Err: err}
+	}
+
+	if err != nil {
+		return nil, &Error{Status: http.StatusInternalServerError, Err: fmt.Errorf("repolicy data: %w", err)}
+	}
+
+	resp := &RepolicyResponse{DID: protectedData.DID}
+
+	if resp.ConsentReceipt, err = o.reissueConsentReceipt(ctx, previous, protectedData, sub); err != nil {
+		return nil, &Error{Status: http.StatusInternalServerError, Err: err}
+	}
+
+	return resp, nil
+}
+
+// reissueConsentReceipt issues the consent receipt of rotated or moved protected data, for its DID and under its
+// policy, with the subject recorded on the receipts of the previous DID.
+func (o *Operation) reissueConsentReceipt(ctx context.Context, previous, data *protect.ProtectedData,
+	collector string) (json.RawMessage, error) {
+	if o.ConsentService == nil {
+		return nil, nil
+	}
+
+	subject, err := o.ConsentService.Subject(ctx, previous.DID)
+	if err != nil {
+		return nil, fmt.Errorf("get subject: %w", err)
+	}
+
+	return o.issueConsentReceipt(ctx, &ProtectRequest{Policy: data.PolicyID, Subject: subject}, data, collector)
+}
+
+// Anchoring returns the status of the anchoring of the DID of protected data, if the subject is a collector of its
+// policy.
+func (o *Operation) Anchoring(ctx context.Context, did string) (*AnchoringResponse, error) {
+	protectedData, err := o.ProtectService.Get(ctx, did)
+	if err != nil {
+		return nil, notFoundError(err, http.StatusNotFound)
+	}
+
+	if _, err = o.checkPolicy(ctx, protectedData.PolicyID, policy.Collector); err != nil {
+		return nil, err
+	}
+
+	a, err := o.ProtectService.Anchoring(ctx, protectedData.DID)
+	if err != nil {
+		return nil, &Error{Status: http.StatusInternalServerError, Err: err}
+	}
+
+	resp := &AnchoringResponse{DID: a.DID, Status: string(a.Status), CanonicalDID: a.CanonicalDID}
+
+	if !a.Updated.IsZero() {
+		resp.Updated = &a.Updated
+	}
+
+	return resp, nil
+}
+
+// Release creates a release ticket for the protected data, if the subject is a handler of its policy.
+func (o *Operation) Release(ctx context.Context, req *ReleaseRequest) (*ReleaseResponse, error) {
+	protectedData, err := o.ProtectService.Get(ctx, req.DID)
+	if err != nil {
+		return nil, &Error{Status: http.StatusInternalServerError, Err: err}
+	}
+
+	sub, err := o.checkPolicy(ctx, protectedData.PolicyID, policy.Handler)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := o.PolicyService.Get(ctx, protectedData.PolicyID)
+	if err != nil {
+		return nil, &Error{Status: http.StatusInternalServerError, Err: err}
+	}
+
+	if p.RequireJustification && req.Justification == "" {
+		return nil, &Error{Status: http.StatusBadRequest, Err: errors.New("justification is required by the policy")}
+	}
+
+	if p.PresentationDefinition != nil {
+		if err = o.verifyPresentation(ctx, p.PresentationDefinition, req, sub); err != nil {
+			return nil, err
+		}
+	}
+
+	t, err := o.ReleaseService.Release(o.withReference(ctx), req.DID, protectedData.PolicyID, sub, req.Justification)
+	if err != nil {
+		return nil, &Error{Status: http.StatusInternalServerError, Err: err}
+	}
+
+	return &ReleaseResponse{TicketID: t.ID}, nil
+}
+
+// verifyPresentation verifies the presentation of the release request, made with OIDC4VP or posted with the
+// request.
+func (o *Operation) verifyPresentation(ctx context.Context, definition *presexch.PresentationDefinition,
+	req *ReleaseRequest, sub string) error {
+	var err error
+
+	switch {
+	case req.OIDC4VPTxID != "" && o.OIDC4VPVerifier != nil:
+		err = o.OIDC4VPVerifier.Verify(ctx, definition, req.OIDC4VPTxID, sub)
+		if err != nil && !errors.Is(err, oidc4vp.ErrNotVerified) {
+			return &Error{Status: http.StatusInternalServerError, Err: err}
+		}
+	case len(req.Presentation) > 0:
+		err = o.PresentationVerifier.Verify(ctx, definition, req.Presentation, sub)
+	default:
+		return notAuthorized(errors.New("missing presentation"))
+	}
+
+	if err != nil {
+		return notAuthorized(err)
+	}
+
+	return nil
+}
+
+// InitiateOIDC4VP initiates an OIDC4VP interaction requesting the presentation required by the policy of the
+// protected data, if the subject is a handler of the policy.
+func (o *Operation) InitiateOIDC4VP(ctx context.Context, req *OIDC4VPRequest) (*oidc4vp.Interaction, error) {
+	protectedData, err := o.ProtectService.Get(ctx, req.DID)
+	if err != nil {
+		return nil, notFoundError(err, http.StatusNotFound)
+	}
+
+	if _, err = o.checkPolicy(ctx, protectedData.PolicyID, policy.Handler); err != nil {
+		return nil, err
+	}
+
+	p, err := o.PolicyService.Get(ctx, protectedData.PolicyID)
+	if err != nil {
+		return nil, &Error{Status: http.StatusInternalServerError, Err: err}
+	}
+
+	if p.PresentationDefinition == nil {
+		return nil, &Error{Status: http.StatusBadRequest, Err: errors.New("policy defines no presentation definition")}
+	}
+
+	interaction, err := o.OIDC4VPVerifier.Initiate(ctx, p.PresentationDefinition)
+	if err != nil {
+		return nil, &Error{Status: http.StatusInternalServerError, Err: err}
+	}
+
+	return interaction, nil
+}
+
+// Authorize approves the release of the ticket, if the subject is an approver of the policy or their delegate. The
+// comment of the approver, if any, is stored on the ticket.
+func (o *Operation) Authorize(ctx context.Context, ticketID string, req *DecisionRequest) error {
+	return o.decide(ctx, ticketID, req, o.ReleaseService.Authorize)
+}
+
+// Reject rejects the release of the ticket, if the subject is an approver of the policy or their delegate. The
+// comment of the approver, if any, is stored on the ticket.
+func (o *Operation) Reject(ctx context.Context, ticketID string, req *DecisionRequest) error {
+	return o.decide(ctx, ticketID, req, o.ReleaseService.Reject)
+}
+
+func (o *Operation) decide(ctx context.Context, ticketID string, req *DecisionRequest,
+	decide func(ctx context.Context, ticketID, approverDID, comment string) error) error {
+	t, err := o.ReleaseService.Get(ctx, ticketID)
+	if err != nil {
+		return notFoundError(err, http.StatusBadRequest)
+	}
+
+	if t.BreakGlass {
+		return &Error{Status: http.StatusConflict, Err: errors.New("ticket was created by a break-glass access")}
+	}
+
+	protectedData, err := o.ProtectService.Get(ctx, t.DID)
+	if err != nil {
+		return &Error{Status: http.StatusInternalServerError, Err: err}
+	}
+
+	approver, ctx, err := o.approver(ctx, protectedData.PolicyID, req.OnBehalfOf)
+	if err != nil {
+		return err
+	}
+
+	if err = decide(o.withReference(ctx), ticketID, approver, req.Comment); err != nil {
+		if errors.Is(err, release.ErrRejected) || errors.Is(err, release.ErrConflict) {
+			return &Error{Status: http.StatusConflict, Err: err}
+		}
+
+		if errors.Is(err, release.ErrNotLeader) {
+			return &Error{Status: http.StatusServiceUnavailable, Err: err}
+		}
+
+		return &Error{Status: http.StatusInternalServerError, Err: err}
+	}
+
+	return nil
+}
+
+// approver returns the DID of the approver of the policy the subject decides as. A subject that is not an approver,
+// or that decides on behalf of another approver, decides as a delegate with an active delegation of the approver,
+// recorded in the returned context.
+func (o *Operation) approver(ctx context.Context, policyID, onBehalfOf string) (string, context.Context, error) {
+	if onBehalfOf == "" {
+		sub, err := o.checkPolicy(ctx, policyID, policy.Approver)
+		if o.DelegationService == nil || !errors.Is(err, policy.ErrNotAllowed) {
+			return sub, ctx, err
+		}
+	}
+
+	if o.DelegationService == nil {
+		return "", ctx, &Error{Status: http.StatusBadRequest, Err: errors.New("delegations are not supported")}
+	}
+
+	sub, err := o.SubjectResolver.Resolve(ctx)
+	if err != nil {
+		return "", ctx, notAuthorized(err)
+	}
+
+	active, err := o.DelegationService.Active(ctx, policyID, sub, time.Now())
+	if err != nil {
+		return "", ctx, &Error{Status: http.StatusInternalServerError, Err: fmt.Errorf("get delegations: %w", err)}
+	}
+
+	var d *delegation.Delegation
+
+	for _, a := range active {
+		if onBehalfOf != "" && a.Delegator != onBehalfOf {
+			continue
+		}
+
+		if d != nil && d.Delegator != a.Delegator {
+			return "", ctx, &Error{
+				Status: http.StatusBadRequest,
+				Err:    errors.New("delegations of several approvers are active, on_behalf_of is required"),
+			}
+		}
+
+		if d == nil {
+			d = a
+		}
+	}
+
+	if d == nil {
+		return "", ctx, notAuthorized(policy.ErrNotAllowed)
+	}
+
+	// the delegator must still be an approver of the policy
+	if err = o.PolicyService.Check(ctx, policyID, d.Delegator, policy.Approver); err != nil {
+		if errors.Is(err, policy.ErrNotAllowed) {
+			return "", ctx, notAuthorized(err)
+		}
+
+		return "", ctx, &Error{Status: http.StatusInternalServerError, Err: err}
+	}
+
+	return d.Delegator, release.WithDelegation(ctx, d.ID, sub), nil
+}
+
+// Delegate delegates the approval authority of the subject, an approver of the policy, to another DID for the time
+// window of the request.
+func (o *Operation) Delegate(ctx context.Context, policyID string, req *DelegateRequest) (*DelegateResponse, error) {
+	sub, err := o.checkPolicy(ctx, policyID, policy.Approver)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &delegation.Delegation{
+		PolicyID:  policyID,
+		Delegator: sub,
+		Delegate:  req.Delegate,
+		NotBefore: req.NotBefore,
+		NotAfter:  req.NotAfter,
+	}
+
+	err = o.DelegationService.Delegate(ctx, d)
+	if errors.Is(err, delegation.ErrInvalidDelegation) {
+		return nil, &Error{Status: http.StatusBadRequest, Err: err}
+	}
+
+	if err != nil {
+		return nil, &Error{Status: http.StatusInternalServerError, Err: fmt.Errorf("delegate approval: %w", err)}
+	}
+
+	return &DelegateResponse{Delegation: d}, nil
+}
+
+// Subscribe subscribes the subject to the events of the tickets they are involved in.
+func (o *Operation) Subscribe(ctx context.Context, req *SubscribeRequest) (*SubscribeResponse, error) {
+	sub, err := o.SubjectResolver.Resolve(ctx)
+	if err != nil {
+		return nil, notAuthorized(err)
+	}
+
+	s := &subscription.Subscription{
+		DID:          sub,
+		Notification: req.Notification,
+		Actions:      req.Actions,
+	}
+
+	err = o.SubscriptionService.Subscribe(ctx, s)
+	if errors.Is(err, subscription.ErrInvalidSubscription) {
+		return nil, &Error{Status: http.StatusBadRequest, Err: err}
+	}
+
+	if err != nil {
+		return nil, &Error{Status: http.StatusInternalServerError, Err: fmt.Errorf("subscribe: %w", err)}
+	}
+
+	return &SubscribeResponse{Subscription: s}, nil
+}
+
+// Subscriptions returns the page of the subscriptions of the subject.
+func (o *Operation) Subscriptions(ctx context.Context, page *model.PageRequest) (*ListSubscriptionsResponse, error) {
+	offset, err := page.Offset()
+	if err != nil {
+		return nil, &Error{Status: http.StatusBadRequest, Err: err}
+	}
+
+	sub, err := o.SubjectResolver.Resolve(ctx)
+	if err != nil {
+		return nil, notAuthorized(err)
+	}
+
+	subs, err := o.SubscriptionService.List(ctx, sub)
+	if err != nil {
+		return nil, &Error{Status: http.StatusInternalServerError, Err: fmt.Errorf("list subscriptions: %w", err)}
+	}
+
+	limit := page.PageLimit(pageSize, maxPageLimit)
+	resp := &ListSubscriptionsResponse{
+		Items: []*subscription.Subscription{},
+		Page:  model.NewPage(offset, limit, len(subs)).WithTotal(len(subs)),
+	}
+
+	for i := offset; i < len(subs) && len(resp.Items) < limit; i++ {
+		resp.Items = append(resp.Items, subs[i])
+	}
+
+	return resp, nil
+}
+
+// Unsubscribe deletes the subscription with the given ID of the subject.
+func (o *Operation) Unsubscribe(ctx context.Context, subscriptionID string) error {
+	sub, err := o.SubjectResolver.Resolve(ctx)
+	if err != nil {
+		return notAuthorized(err)
+	}
+
+	if err = o.SubscriptionService.Unsubscribe(ctx, sub, subscriptionID); err != nil {
+		return notFoundError(err, http.StatusNotFound)
+	}
+
+	return nil
+}
+
+// TicketStatus returns the status of the ticket, if the subject is a handler of the policy.
+func (o *Operation) TicketStatus(ctx context.Context, ticketID string) (*TicketStatusResponse, error) {
+	t, err := o.ReleaseService.Get(ctx, ticketID)
+	if err != nil {
+		return nil, notFoundError(err, http.StatusBadRequest)
+	}
+
+	protectedData, err := o.ProtectService.Get(ctx, t.DID)
+	if err != nil {
+		return nil, &Error{Status: http.StatusInternalServerError, Err: err}
+	}
+
+	if _, err = o.checkPolicy(ctx, protectedData.PolicyID, policy.Handler); err != nil {
+		return nil, err
+	}
+
+	q, err := o.quotaStatus(ctx, t, protectedData.PolicyID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TicketStatusResponse{
+		Status:        t.Status.String(),
+		Justification: t.Justification,
+		RejectedBy:    t.RejectedBy,
+		Comments:      t.Comments,
+		Quota:         q,
+	}, nil
+}
+
+// quotaStatus returns the extraction quota of the data released by the ticket with the number of extractions left,
+// or nil if no quota is enforced on the data.
+func (o *Operation) quotaStatus(ctx context.Context, t *ticket.Ticket, policyID string) (*QuotaStatus, error) {
+	if o.QuotaService == nil || t.BreakGlass {
+		return nil, nil
+	}
+
+	p, err := o.PolicyService.Get(ctx, policyID)
+	if err != nil {
+		return nil, &Error{Status: http.StatusInternalServerError, Err: fmt.Errorf("get policy: %w", err)}
+	}
+
+	if p.Quota == nil {
+		return nil, nil
+	}
+
+	remaining, err := o.QuotaService.Remaining(ctx, t.DID, p.Quota, time.Now())
+	if err != nil {
+		return nil, &Error{Status: http.StatusInternalServerError, Err: fmt.Errorf("get extraction quota: %w", err)}
+	}
+
+	return &QuotaStatus{Extractions: p.Quota.Extractions, Days: p.Quota.Days, Remaining: remaining}, nil
+}
+
+// useQuota counts the extraction of the data released by the ticket against the extraction quota of the policy.
+func (o *Operation) useQuota(ctx context.Context, t *ticket.Ticket, p *policy.Policy) error {
+	if o.QuotaService == nil || p.Quota == nil || t.BreakGlass {
+		return nil
+	}
+
+	_, err := o.QuotaService.Use(ctx, t.DID, p.Quota, time.Now())
+	if errors.Is(err, quota.ErrExceeded) {
+		return &Error{Status: http.StatusTooManyRequests, Err: err}
+	}
+
+	if err != nil {
+		return &Error{Status: http.StatusInternalServerError, Err: fmt.Errorf("use extraction quota: %w", err)}
+	}
+
+	return nil
+}
+
+// GetTicket returns the ticket with its approvals.
+func (o *Operation) GetTicket(ctx context.Context, ticketID string) (*TicketResponse, error) {
+	t, err := o.ReleaseService.Get(ctx, ticketID)
+	if err != nil {
+		return nil, notFoundError(err, http.StatusNotFound)
+	}
+
+	return ticketResponse(t), nil
+}
+
+// ListTickets returns the page of the tickets selected by the request, sorted by the time they were created at.
+func (o *Operation) ListTickets(ctx context.Context, req *ListTicketsRequest) (*ListTicketsResponse, error) {
+	f := &release.Filter{RequestedBy: req.Requestor, PolicyID: req.PolicyID, From: req.From, To: req.To}
+
+	for _, s := range req.Statuses {
+		status, err := parseTicketStatus(s)
+		if err != nil {
+			return nil, &Error{Status: http.StatusBadRequest, Err: err}
+		}
+
+		f.Statuses = append(f.Statuses, status)
+	}
+
+	newestFirst, err := parseTicketSort(req.Sort)
+	if err != nil {
+		return nil, &Error{Status: http.StatusBadRequest, Err: err}
+	}
+
+	offset, err := req.Offset()
+	if err != nil {
+		return nil, &Error{Status: http.StatusBadRequest, Err: err}
+	}
+
+	limit := req.PageLimit(pageSize, maxPageLimit)
+
+	var tickets []*ticket.Ticket
+
+	err = o.ReleaseService.IterateFilter(ctx, f, pageSize, func(t *ticket.Ticket) error {
+		tickets = append(tickets, t)
+
+		return nil
+	})
+	if err != nil {
+		return nil, &Error{Status: http.StatusInternalServerError, Err: fmt.Errorf("list tickets: %w", err)}
+	}
+
+	sort.SliceStable(tickets, func(i, j int) bool {
+		if newestFirst {
+			return tickets[i].CreatedAt.After(tickets[j].CreatedAt)
+		}
+
+		return tickets[i].CreatedAt.Before(tickets[j].CreatedAt)
+	})
+
+	resp := &ListTicketsResponse{
+		Items: []*TicketResponse{},
+		Page:  model.NewPage(offset, limit, len(tickets)).WithTotal(len(tickets)),
+	}
+
+	for i := offset; i < len(tickets) && len(resp.Items) < limit; i++ {
+		resp.Items = append(resp.Items, ticketResponse(tickets[i]))
+	}
+
+	return resp, nil
+}
+
+// parseTicketSort reports whether the sort order of the ticket listing is the newest tickets first.
+func parseTicketSort(s string) (bool, error) {
+	switch s {
+	case "", "created_at":
+		return false, nil
+	case "-created_at":
+		return true, nil
+	default:
+		return false, fmt.Errorf("invalid sort %q", s)
+	}
+}
+
+// TicketHistory returns the history of the ticket, with every action taken on it.
+func (o *Operation) TicketHistory(ctx context.Context, ticketID string) (*TicketHistoryResponse, error) {
+	t, err := o.ReleaseService.Get(ctx, ticketID)
+	if err != nil {
+		return nil, notFoundError(err, http.StatusNotFound)
+	}
+
+	resp := &TicketHistoryResponse{TicketID: t.ID, History: []*TicketEvent{}}
+
+	for _, e := range t.History {
+		resp.History = append(resp.History, &TicketEvent{
+			Action:     string(e.Action),
+			Status:     e.Status.String(),
+			Actor:      e.Actor,
+			Reference:  e.Reference,
+			Timestamp:  e.Timestamp,
+			OnBehalfOf: e.OnBehalfOf,
+			Delegation: e.Delegation,
+		})
+	}
+
+	return resp, nil
+}
+
+func ticketResponse(t *ticket.Ticket) *TicketResponse {
+	return &TicketResponse{
+		ID:            t.ID,
+		DID:           t.DID,
+		Status:        t.Status.String(),
+		ApprovedBy:    t.ApprovedBy,
+		QueryID:       t.QueryID,
+		PolicyID:      t.PolicyID,
+		CreatedAt:     t.CreatedAt,
+		RequestedBy:   t.RequestedBy,
+		Justification: t.Justification,
+		RejectedBy:    t.RejectedBy,
+		Comments:      t.Comments,
+		BreakGlass:    t.BreakGlass,
+	}
+}
+
+// Collect returns the query for the data released by the ticket, if the subject is a handler of the policy and the
+// ticket has completed the authorization process. The query is recorded on the ticket.
+func (o *Operation) Collect(ctx context.Context, ticketID string) (*CollectResponse, error) {
+	ticketID = strings.ToLower(ticketID)
+
+	t, err := o.ReleaseService.Get(ctx, ticketID)
+	if err != nil {
+		return nil, &Error{Status: http.StatusBadRequest, Err: err}
+	}
+
+	protectedData, err := o.ProtectService.Get(ctx, t.DID)
+	if err != nil {
+		return nil, &Error{Status: http.StatusInternalServerError, Err: err}
+	}
+
+	// break-glass tickets are only collected by their responder, when they are created
+	if t.Status != ticket.ReadyToCollect || t.BreakGlass {
+		if t.Status == ticket.New || t.Status == ticket.Collecting {
+			if o.DecisionMetrics != nil {
+				o.DecisionMetrics.QuorumFailed(protectedData.PolicyID)
+			}
+
+			return nil, notAuthorized(fmt.Errorf("not authorized to access ticket: %w", release.ErrQuorumNotMet))
+		}
+
+		return nil, notAuthorized(errors.New("not authorized to access ticket"))
+	}
+
+	subDID, err := o.checkPolicy(ctx, protectedData.PolicyID, policy.Handler)
+	if err != nil {
+		return nil, err
+	}
+
+	q, err := o.quotaStatus(ctx, t, protectedData.PolicyID)
+	if err != nil {
+		return nil, err
+	}
+
+	if q != nil && q.Remaining == 0 {
+		return nil, &Error{Status: http.StatusTooManyRequests, Err: quota.ErrExceeded}
+	}
+
+	if o.CollectQueue != nil {
+		return o.enqueueCollect(ctx, ticketID, subDID)
+	}
+
+	queryID, err := o.collect(o.withReference(ctx), t, protectedData, subDID)
+	if err != nil {
+		return nil, collectError(err)
+	}
+
+	return &CollectResponse{QueryID: queryID}, nil
+}
+
+// checkLeader returns release.ErrNotLeader if the tickets are updated by another instance.
+func (o *Operation) checkLeader() error {
+	if o.Leader != nil && !o.Leader.Held() {
+		return release.ErrNotLeader
+	}
+
+	return nil
+}
+
+// collectError returns the error of a collect, answered with 503 on an instance that doesn't update the tickets, so
+// that it is retried on the instance holding the leader's lease.
+func collectError(err error) error {
+	if errors.Is(err, release.ErrNotLeader) {
+		return &Error{Status: http.StatusServiceUnavailable, Err: err}
+	}
+
+	return &Error{Status: http.StatusInternalServerError, Err: fmt.Errorf("fail to collect data: %w", err)}
+}
+
+// collectJob is the payload of the jobs of the CollectQueue.
+type collectJob struct {
+	TicketID        string `json:"ticket_id"`
+	RequestingParty string `json:"requesting_party"`
+	// Reference of the signature or the capability the requesting party is authenticated with.
+	Reference string `json:"reference,omitempty"`
+}
+
+// enqueueCollect queues the collection and waits for it to be processed. The query is still recorded on the ticket
+// if the request ends before, or if the service restarts while the job is queued.
+func (o *Operation) enqueueCollect(ctx context.Context, ticketID, subDID string) (*CollectResponse, error) {
+	payload, err := json.Marshal(&collectJob{
+		TicketID:        ticketID,
+		RequestingParty: subDID,
+		Reference:       o.reference(ctx),
+	})
+	if err != nil {
+		return nil, &Error{Status: http.StatusInternalServerError, Err: fmt.Errorf("marshal collect job: %w", err)}
+	}
+
+	done, err := o.CollectQueue.Enqueue(payload)
+	if errors.Is(err, queue.ErrFull) {
+		return nil, &Error{Status: http.StatusServiceUnavailable, Err: err, RetryAfter: saturatedRetryAfter}
+	}
+
+	if err != nil {
+		return nil, &Error{Status: http.StatusInternalServerError, Err: fmt.Errorf("enqueue collect job: %w", err)}
+	}
+
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		return nil, &Error{Status: http.StatusServiceUnavailable, Err: fmt.Errorf("wait for collect: %w", ctx.Err())}
+	}
+
+	if err != nil {
+		return nil, collectError(err)
+	}
+
+	t, err := o.ReleaseService.Get(ctx, ticketID)
+	if err != nil {
+		return nil, &Error{Status: http.StatusInternalServerError, Err: err}
+	}
+
+	return &CollectResponse{QueryID: t.QueryID}, nil
+}
+
+// ProcessCollect processes a job of the CollectQueue: it creates the query for the data released by the ticket and
+// records it on the ticket.
+func (o *Operation) ProcessCollect(ctx context.Context, payload []byte) error {
+	var job collectJob
+
+	if err := json.Unmarshal(payload, &job); err != nil {
+		return fmt.Errorf("unmarshal collect job: %w", err)
+	}
+
+	t, err := o.ReleaseService.Get(ctx, job.TicketID)
+	if err != nil {
+		return fmt.Errorf("get ticket: %w", err)
+	}
+
+	protectedData, err := o.ProtectService.Get(ctx, t.DID)
+	if err != nil {
+		return fmt.Errorf("get protected data: %w", err)
+	}
+
+	_, err = o.collect(release.WithReference(ctx, job.Reference), t, protectedData, job.RequestingParty)
+
+	return err
+}
+
+func (o *Operation) collect(ctx context.Context, t *ticket.Ticket, protectedData *protect.ProtectedData,
+	requestingParty string) (string, error) {
+	// no query is created that cannot be recorded on the ticket
+	if err := o.checkLeader(); err != nil {
+		return "", err
+	}
+
+	queryID, err := o.CollectService.Collect(ctx, protectedData, requestingParty)
+	if err != nil {
+		return "", err
+	}
+
+	if err = o.ReleaseService.SetQueryID(ctx, t.ID, queryID, requestingParty); err != nil {
+		return "", fmt.Errorf("record query: %w", err)
+	}
+
+	// the subject of the data collected by a break-glass access was notified before the query was created
+	if !t.BreakGlass {
+		o.notifySubject(ctx, notify.Released, t, protectedData.PolicyID, requestingParty)
+	}
+
+	return queryID, nil
+}
+
+// BreakGlass collects the protected data for the subject, a responder of the break-glass access of its policy,
+// without the approvals of the approvers. The justification is required, and the subject of the data and the
+// approvers of the policy are notified before the data is collected: the data is not collected if any of them
+// can't be, and the notifications are recorded in the audit log. It requires the Notifier.
+func (o *Operation) BreakGlass(ctx context.Context, req *BreakGlassRequest) (*BreakGlassResponse, error) {
+	if req.Justification == "" {
+		return nil, &Error{Status: http.StatusBadRequest, Err: errors.New("justification is required for break-glass access")}
+	}
+
+	protectedData, err := o.ProtectService.Get(ctx, req.DID)
+	if err != nil {
+		return nil, notFoundError(err, http.StatusNotFound)
+	}
+
+	sub, err := o.checkPolicy(ctx, protectedData.PolicyID, policy.Responder)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := o.PolicyService.Get(ctx, protectedData.PolicyID)
+	if err != nil {
+		return nil, &Error{Status: http.StatusInternalServerError, Err: err}
+	}
+
+	ctx = o.withReference(ctx)
+
+	// the ticket is only created if the instance can record the query on it
+	if err = o.checkLeader(); err != nil {
+		return nil, collectError(err)
+	}
+
+	t, err := o.ReleaseService.BreakGlass(ctx, req.DID, protectedData.PolicyID, sub, req.Justification)
+	if err != nil {
+		return nil, &Error{Status: http.StatusInternalServerError, Err: fmt.Errorf("break glass: %w", err)}
+	}
+
+	logger.Warnf("Break-glass access to %s by %s under policy %s, ticket %s: %s", t.DID, sub, t.PolicyID, t.ID,
+		t.Justification)
+
+	if err = o.notifyBreakGlass(ctx, t, p); err != nil {
+		return nil, &Error{Status: http.StatusBadGateway, Err: fmt.Errorf("notify break-glass access: %w", err)}
+	}
+
+	queryID, err := o.collect(ctx, t, protectedData, sub)
+	if err != nil {
+		return nil, collectError(err)
+	}
+
+	return &BreakGlassResponse{TicketID: t.ID, QueryID: queryID}, nil
+}
+
+// notifyBreakGlass notifies the subject of the data released by the ticket with the notification of the policy, and
+// the approvers of the policy with the notification of its break-glass access, then records the notifications in the
+// audit log. Every party is notified, and the first failure returned.
+func (o *Operation) notifyBreakGlass(ctx context.Context, t *ticket.Ticket, p *policy.Policy) error {
+	e := o.subjectEvent(ctx, notify.BreakGlass, t, p, t.RequestedBy)
+
+	var failed error
+
+	for _, r := range breakGlassRecipients(p) {
+		event := *e
+		event.Recipient = r.approver
+
+		if err := o.Notifier.Notify(ctx, r.notification, &event); err != nil {
+			logger.Warnf("Failed to notify the break-glass access to %s: %s", t.DID, err)
+
+			if failed == nil {
+				failed = err
+			}
+		}
+	}
+
+	if failed != nil || o.AuditLog == nil {
+		return failed
+	}
+
+	var reference string
+
+	// the notifications are authorized with the break-glass access they notify
+	if len(t.History) > 0 {
+		reference = t.History[len(t.History)-1].Reference
+	}
+
+	err := o.AuditLog.Append(ctx, &audit.Entry{
+		TicketID: t.ID,
+		DID:      t.DID,
+		PolicyID: t.PolicyID,
+		Event: &ticket.Event{
+			Action:    ticket.BreakGlassNotifiedAction,
+			Status:    t.Status,
+			Actor:     t.RequestedBy,
+			Reference: reference,
+			Timestamp: e.Timestamp,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("append %s to audit log: %w", ticket.BreakGlassNotifiedAction, err)
+	}
+
+	return nil
+}
+
+// breakGlassRecipient is a notification of a break-glass access, to an approver of the policy if set.
+type breakGlassRecipient struct {
+	notification *policy.Notification
+	approver     string
+}
+
+// breakGlassRecipients returns the notifications of a break-glass access under the policy: the notification of the
+// subject, the webhook of the approvers and the DIDComm message to each approver.
+func breakGlassRecipients(p *policy.Policy) []breakGlassRecipient {
+	var recipients []breakGlassRecipient
+
+	if p.Notification != nil {
+		recipients = append(recipients, breakGlassRecipient{notification: p.Notification})
+	}
+
+	if p.BreakGlass == nil || p.BreakGlass.Notification == nil {
+		return recipients
+	}
+
+	n := p.BreakGlass.Notification
+
+	if n.Webhook != "" {
+		recipients = append(recipients, breakGlassRecipient{notification: &policy.Notification{Webhook: n.Webhook}})
+	}
+
+	if !n.DIDComm {
+		return recipients
+	}
+
+	for _, approver := range p.Approvers {
+		recipients = append(recipients, breakGlassRecipient{
+			notification: &policy.Notification{DIDComm: true},
+			approver:     approver,
+		})
+	}
+
+	return recipients
+}
+
+// reference returns the reference of the signature or the capability the subject is authenticated with, if the
+// ReferenceResolver is set.
+func (o *Operation) reference(ctx context.Context) string {
+	if o.ReferenceResolver == nil {
+		return ""
+	}
+
+	return o.ReferenceResolver.Reference(ctx)
+}
+
+// withReference returns the context with the reference the subject is authenticated with, recorded in the history
+// of the tickets.
+func (o *Operation) withReference(ctx context.Context) context.Context {
+	return release.WithReference(ctx, o.reference(ctx))
+}
+
+// Extract returns the data the query was created for, masked if its policy defines a mask, and encrypted to the
+// handler if requested or if its policy requires it. Data of queries created before tickets recorded them is neither
+// masked nor encrypted, as their handlers are unknown. Data protected in a residency region is only returned by the
+// gatekeepers of the region.
+func (o *Operation) Extract(ctx context.Context, req *ExtractRequest) (*ExtractResponse, error) {
+	target, err := o.ExtractService.Extract(ctx, req.QueryID)
+	if err != nil {
+		return nil, &Error{
+			Status: http.StatusInternalServerError,
+			Err:    fmt.Errorf("fail to resolve extract data: %w", err),
+		}
+	}
+
+	t, err := o.ReleaseService.GetByQueryID(ctx, req.QueryID)
+	if errors.Is(err, storage.ErrDataNotFound) {
+		if req.Encrypt {
+			return nil, &Error{Status: http.StatusBadRequest, Err: errors.New("encrypt data: the handler is unknown")}
+		}
+
+		return &ExtractResponse{Target: target}, nil
+	}
+
+	if err != nil {
+		return nil, &Error{Status: http.StatusInternalServerError, Err: fmt.Errorf("get ticket: %w", err)}
+	}
+
+	protectedData, err := o.ProtectService.Get(ctx, t.DID)
+	if err != nil {
+		return nil, &Error{Status: http.StatusInternalServerError, Err: fmt.Errorf("get protected data: %w", err)}
+	}
+
+	if protectedData.Region != "" && protectedData.Region != o.Region {
+		return nil, &Error{
+			Status: http.StatusMisdirectedRequest,
+			Err:    fmt.Errorf("data is protected in region %q", protectedData.Region),
+		}
+	}
+
+	p, err := o.PolicyService.Get(ctx, protectedData.PolicyID)
+	if err != nil {
+		return nil, &Error{Status: http.StatusInternalServerError, Err: fmt.Errorf("get policy: %w", err)}
+	}
+
+	encrypted := req.Encrypt || p.EncryptExtract
+	if encrypted && o.ExtractEncrypter == nil {
+		return nil, &Error{Status: http.StatusNotImplemented, Err: errors.New("extracted data cannot be encrypted")}
+	}
+
+	if err = o.useQuota(ctx, t, p); err != nil {
+		return nil, err
+	}
+
+	target, err = o.disclose(ctx, target, t, p, req.QueryID)
+	if err != nil {
+		return nil, &Error{Status: http.StatusInternalServerError, Err: err}
+	}
+
+	resp := &ExtractResponse{Target: target}
+
+	if encrypted {
+		if resp, err = o.encryptExtract(ctx, t.RequestedBy, target); err != nil {
+			return nil, err
+		}
+	}
+
+	o.extracted(ctx, t, p)
+
+	return resp, nil
+}
+
+// extracted records the extraction in the decision metrics and notifies the subjects of it.
+func (o *Operation) extracted(ctx context.Context, t *ticket.Ticket, p *policy.Policy) {
+	if o.DecisionMetrics != nil {
+		o.DecisionMetrics.Extracted(t, p.ID)
+	}
+
+	o.sendNotification(ctx, notify.Extracted, t, p, t.RequestedBy)
+}
+
+// encryptExtract returns the extracted data encrypted to the key agreement key of the handler.
+func (o *Operation) encryptExtract(ctx context.Context, handler, target string) (*ExtractResponse, error) {
+	jwe, err := o.ExtractEncrypter.Encrypt(ctx, handler, []byte(target))
+	if errors.Is(err, encrypt.ErrNoKeyAgreementKey) {
+		return nil, &Error{Status: http.StatusBadRequest, Err: fmt.Errorf("encrypt extracted data: %w", err)}
+	}
+
+	if err != nil {
+		return nil, &Error{Status: http.StatusInternalServerError, Err: fmt.Errorf("encrypt extracted data: %w", err)}
+	}
+
+	return &ExtractResponse{JWE: jwe}, nil
+}
+
+// ExtractBatch extracts the data of the queries one at a time, in order, and calls fn with the result of each. The
+// queries that fail to be extracted are reported in their items. Returning an error from fn, or cancelling the
+// context, stops the extraction.
+func (o *Operation) ExtractBatch(ctx context.Context, req *ExtractBatchRequest,
+	fn func(item *ExtractBatchItem) error) error {
+	if err := validateExtractBatch(req); err != nil {
+		return err
+	}
+
+	for _, queryID := range req.QueryIDs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		item := &ExtractBatchItem{QueryID: queryID}
+
+		resp, err := o.Extract(ctx, &ExtractRequest{QueryID: queryID, Encrypt: req.Encrypt})
+		if err != nil {
+			item.Error = err.Error()
+			item.Status = ErrorStatus(err)
+		} else {
+			item.Target, item.JWE = resp.Target, resp.JWE
+		}
+
+		if err = fn(item); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func validateExtractBatch(req *ExtractBatchRequest) error {
+	if len(req.QueryIDs) == 0 {
+		return &Error{Status: http.StatusBadRequest, Err: errors.New("no queries to extract")}
+	}
+
+	if len(req.QueryIDs) > maxExtractBatch {
+		return &Error{
+			Status: http.StatusBadRequest,
+			Err:    fmt.Errorf("too many queries to extract: %d, at most %d", len(req.QueryIDs), maxExtractBatch),
+		}
+	}
+
+	return nil
+}
+
+// disclose returns the extracted data masked if the policy defines a mask, and with a watermark identifying the
+// extraction if the policy defines a watermark and the Watermarker is set.
+func (o *Operation) disclose(ctx context.Context, target string, t *ticket.Ticket, p *policy.Policy,
+	queryID string) (string, error) {
+	if p.Mask != nil {
+		target = p.Mask.Apply(target)
+	}
+
+	if p.Watermark == nil || o.Watermarker == nil {
+		return target, nil
+	}
+
+	marked, err := o.Watermarker.Embed(ctx, target, p.Watermark, &watermark.Record{
+		DID:      t.DID,
+		TicketID: t.ID,
+		QueryID:  queryID,
+		Handler:  t.RequestedBy,
+	})
+	if err != nil {
+		return "", fmt.Errorf("embed watermark: %w", err)
+	}
+
+	return marked, nil
+}
+
+// Trace returns the extraction the watermark of the data identifies. It requires the Watermarker.
+func (o *Operation) Trace(ctx context.Context, req *TraceRequest) (*TraceResponse, error) {
+	r, err := o.Watermarker.Trace(ctx, req.Data)
+	if errors.Is(err, watermark.ErrNoWatermark) {
+		return nil, &Error{Status: http.StatusNotFound, Err: err}
+	}
+
+	if err != nil {
+		return nil, notFoundError(fmt.Errorf("trace data: %w", err), http.StatusNotFound)
+	}
+
+	return &TraceResponse{Extraction: r}, nil
+}
+
+// notifySubject notifies the subject of the data released by the ticket of the event with the policy of the data.
+// Failures are logged: the subjects are notified on a best-effort basis.
+func (o *Operation) notifySubject(ctx context.Context, eventType string, t *ticket.Ticket, policyID,
+	requestingParty string) {
+	if o.Notifier == nil {
+		return
+	}
+
+	p, err := o.PolicyService.Get(ctx, policyID)
+	if err != nil {
+		logger.Warnf("Failed to notify %s of %s: %s", eventType, t.DID, err)
+
+		return
+	}
+
+	o.sendNotification(ctx, eventType, t, p, requestingParty)
+}
+
+// sendNotification notifies the subject of the data released by the ticket of the event, if the Notifier is set and
+// the policy defines a notification.
+func (o *Operation) sendNotification(ctx context.Context, eventType string, t *ticket.Ticket, p *policy.Policy,
+	requestingParty string) {
+	if o.Notifier == nil || p.Notification == nil {
+		return
+	}
+
+	e := o.subjectEvent(ctx, eventType, t, p, requestingParty)
+
+	if err := o.Notifier.Notify(ctx, p.Notification, e); err != nil {
+		logger.Warnf("Failed to notify %s of %s: %s", eventType, t.DID, err)
+	}
+}
+
+// subjectEvent returns the event of the data released by the ticket notified to its subject.
+func (o *Operation) subjectEvent(ctx context.Context, eventType string, t *ticket.Ticket, p *policy.Policy,
+	requestingParty string) *notify.Event {
+	e := &notify.Event{
+		Type:            eventType,
+		DID:             t.DID,
+		PolicyID:        p.ID,
+		TicketID:        t.ID,
+		RequestingParty: requestingParty,
+		Justification:   t.Justification,
+		Timestamp:       time.Now().UTC(),
+	}
+
+	if o.ConsentService != nil {
+		var err error
+
+		if e.Subject, err = o.ConsentService.Subject(ctx, t.DID); err != nil {
+			logger.Warnf("Failed to find the subject of %s: %s", t.DID, err)
+		}
+	}
+
+	return e
+}
+
+// Purge purges deleted data past its retention window. It does nothing if the PurgeService is not set.
+func (o *Operation) Purge() {
+	if o.PurgeService != nil {
+		o.PurgeService.Purge(time.Now())
+	}
+}
+
+// DSAR reports the data protected about the subject, linked to them or found from their consent receipts, with the
+// tickets releasing it. Data protected without the DID of its subject, or deleted since, is not reported.
+func (o *Operation) DSAR(ctx context.Context) (*DSARResponse, error) {
+	sub, err := o.SubjectResolver.Resolve(ctx)
+	if err != nil {
+		return nil, notAuthorized(err)
+	}
+
+	resp := &DSARResponse{Subject: sub}
+
+	if resp.Data, err = o.subjectData(ctx, sub); err != nil {
+		return nil, err
+	}
+
+	for _, d := range resp.Data {
+		d.Releases = []*TicketResponse{}
+
+		err = o.ReleaseService.IterateDID(ctx, d.DID, pageSize, func(t *ticket.Ticket) error {
+			d.Releases = append(d.Releases, ticketResponse(t))
+
+			return nil
+		})
+		if err != nil {
+			return nil, &Error{Status: http.StatusInternalServerError, Err: fmt.Errorf("read tickets: %w", err)}
+		}
+	}
+
+	return resp, nil
+}
+
+// Erase deletes all of the data protected about the subject that DSAR reports, for data subject erasure requests.
+// The data can be restored until it is purged.
+func (o *Operation) Erase(ctx context.Context) (*EraseResponse, error) {
+	sub, err := o.SubjectResolver.Resolve(ctx)
+	if err != nil {
+		return nil, notAuthorized(err)
+	}
+
+	data, err := o.subjectData(ctx, sub)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &EraseResponse{Subject: sub, DIDs: make([]string, len(data))}
+
+	for i, d := range data {
+		resp.DIDs[i] = d.DID
+	}
+
+	if err = o.ProtectService.Delete(ctx, resp.DIDs...); err != nil {
+		return nil, &Error{Status: http.StatusInternalServerError, Err: fmt.Errorf("erase data: %w", err)}
+	}
+
+	return resp, nil
+}
+
+// Link links protected data to the subject, so that DSAR and Erase cover it even if it was protected without the DID
+// of the subject. The targets the data was protected from are the proof that it belongs to the subject.
+func (o *Operation) Link(ctx context.Context, req *LinkRequest) (*LinkResponse, error) {
+	sub, err := o.SubjectResolver.Resolve(ctx)
+	if err != nil {
+		return nil, notAuthorized(err)
+	}
+
+	if len(req.Data) == 0 {
+		return nil, &Error{Status: http.StatusBadRequest, Err: errors.New("no data to link")}
+	}
+
+	data := make([]*protect.ProtectedData, len(req.Data))
+	targets := make([]string, len(req.Data))
+
+	for i, d := range req.Data {
+		if data[i], err = o.ProtectService.Get(ctx, d.DID); err != nil {
+			return nil, notFoundError(err, http.StatusNotFound)
+		}
+
+		targets[i] = d.Target
+	}
+
+	_, err = o.ProtectService.Link(ctx, sub, data, targets)
+	if errors.Is(err, protect.ErrTargetMismatch) {
+		return nil, &Error{Status: http.StatusBadRequest, Err: err}
+	}
+
+	if errors.Is(err, protect.ErrAlreadyLinked) {
+		return nil, &Error{Status: http.StatusConflict, Err: err}
+	}
+
+	if err != nil {
+		return nil, &Error{Status: http.StatusInternalServerError, Err: fmt.Errorf("link data: %w", err)}
+	}
+
+	resp := &LinkResponse{Subject: sub, DIDs: []string{}}
+
+	err = o.ProtectService.IterateSubject(ctx, sub, pageSize, func(p *protect.ProtectedData) error {
+		resp.DIDs = append(resp.DIDs, p.DID)
+
+		return nil
+	})
+	if err != nil {
+		return nil, &Error{Status: http.StatusInternalServerError, Err: fmt.Errorf("read linked data: %w", err)}
+	}
+
+	return resp, nil
+}
+
+// subjectData returns the data protected about the subject, linked to them or found from their consent receipts,
+// with its consents.
+func (o *Operation) subjectData(ctx context.Context, sub string) ([]*SubjectData, error) {
+	all := []*SubjectData{}
+	data := make(map[string]*SubjectData)
+
+	err := o.ProtectService.IterateSubject(ctx, sub, pageSize, func(p *protect.ProtectedData) error {
+		d := &SubjectData{DID: p.DID, PolicyID: p.PolicyID, Consents: []*consent.Receipt{}, Linked: true}
+
+		data[p.DID] = d
+		all = append(all, d)
+
+		return nil
+	})
+	if err != nil {
+		return nil, &Error{Status: http.StatusInternalServerError, Err: fmt.Errorf("read linked data: %w", err)}
+	}
+
+	err = o.ConsentService.IterateSubject(ctx, sub, pageSize, func(r *consent.Receipt) error {
+		if d, ok := data[r.DID]; ok {
+			d.Consents = append(d.Consents, r)
+
+			return nil
+		}
+
+		protectedData, e := o.ProtectService.Get(ctx, r.DID)
+		if errors.Is(e, storage.ErrDataNotFound) {
+			return nil
+		}
+
+		if e != nil {
+			return e
+		}
+
+		d := &SubjectData{DID: r.DID, PolicyID: protectedData.PolicyID, Consents: []*consent.Receipt{r}}
+
+		data[r.DID] = d
+		all = append(all, d)
+
+		return nil
+	})
+	if err != nil {
+		return nil, &Error{Status: http.StatusInternalServerError, Err: fmt.Errorf("read consent receipts: %w", err)}
+	}
+
+	return all, nil
+}
+
+func (o *Operation) checkPolicy(ctx context.Context, policyID string, role policy.Role) (string, error) {
+	sub, err := o.SubjectResolver.Resolve(ctx)
+	if err != nil {
+		return "", notAuthorized(err)
+	}
+
+	err = o.PolicyService.Check(ctx, policyID, sub, role)
+	if err != nil {
+		if errors.Is(err, policy.ErrNotAllowed) {
+			return "", notAuthorized(err)
+		}
+
+		return "", &Error{Status: http.StatusInternalServerError, Err: err}
+	}
+
+	return sub, nil
+}
+
+// notFoundError reports the error with the given status if it is caused by missing data.
+func notFoundError(err error, status int) error {
+	if errors.Is(err, storage.ErrDataNotFound) {
+		return &Error{Status: status, Err: err}
+	}
+
+	return &Error{Status: http.StatusInternalServerError, Err: err}
+}
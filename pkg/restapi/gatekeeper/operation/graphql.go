@@ -0,0 +1,570 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package operation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/aries-framework-go/spi/storage"
+
+	"github.com/trustbloc/ace/pkg/gatekeeper/audit"
+	"github.com/trustbloc/ace/pkg/gatekeeper/policy"
+	"github.com/trustbloc/ace/pkg/gatekeeper/protect"
+	"github.com/trustbloc/ace/pkg/gatekeeper/release/ticket"
+	"github.com/trustbloc/ace/pkg/graphql"
+	"github.com/trustbloc/ace/pkg/storage/cursor"
+)
+
+// graphQLMaxDepth limits the nesting of queries, as every level of a list may query the store once per item.
+const graphQLMaxDepth = 5
+
+// ticketStatuses are the statuses tickets are listed by when no status is queried.
+var ticketStatuses = []ticket.Status{ //nolint:gochecknoglobals
+	ticket.New, ticket.Collecting, ticket.ReadyToCollect, ticket.Rejected,
+}
+
+// graphQLSchema returns the read-only GraphQL schema over policies, protected resources, tickets and audit events:
+//
+//	type Query {
+//	  policy(id: String!): Policy
+//	  policies(first: Int): [Policy]
+//	  protectedResource(did: String!): ProtectedResource
+//	  protectedResources(policyId: String, first: Int): [ProtectedResource]
+//	  ticket(id: String!): Ticket
+//	  tickets(status: String, first: Int): [Ticket]
+//	  auditEvents(after: Int, first: Int): [AuditEvent] # if the audit log is enabled
+//	}
+//
+//	type Policy {
+//	  id: String
+//	  collectors: [String]
+//	  handlers: [String]
+//	  approvers: [String]
+//	  minApprovers: Int
+//	  presentationDefinition: String # JSON encoded
+//	  protectedResources(first: Int): [ProtectedResource]
+//	}
+//
+//	type ProtectedResource {
+//	  did: String
+//	  policyId: String
+//	  policy: Policy
+//	}
+//
+//	type Ticket {
+//	  id: String
+//	  did: String
+//	  status: String # NEW, COLLECTING, READY_TO_COLLECT or REJECTED
+//	  approvedBy: [String]
+//	  requestedBy: String
+//	  justification: String
+//	  rejectedBy: String
+//	  comments: [Comment]
+//	  protectedResource: ProtectedResource
+//	}
+//
+//	type Comment {
+//	  approver: String
+//	  decision: String # approve or reject
+//	  comment: String
+//	  timestamp: String # RFC 3339
+//	}
+//
+//	type AuditEvent {
+//	  seq: Int
+//	  ticketId: String
+//	  did: String
+//	  policyId: String
+//	  action: String
+//	  status: String
+//	  actor: String
+//	  reference: String
+//	  onBehalfOf: String
+//	  timestamp: String # RFC 3339
+//	  hash: String
+//	  ticket: Ticket
+//	}
+func (o *Operation) graphQLSchema() *graphql.Schema { //nolint:funlen
+	policyType := &graphql.Object{Name: "Policy"}
+	resourceType := &graphql.Object{Name: "ProtectedResource"}
+	ticketType := &graphql.Object{Name: "Ticket"}
+	commentType := &graphql.Object{Name: "Comment", Fields: map[string]*graphql.Field{
+		"approver": commentField(func(c *ticket.Comment) string { return c.Approver }),
+		"decision": commentField(func(c *ticket.Comment) string { return string(c.Decision) }),
+		"comment":  commentField(func(c *ticket.Comment) string { return c.Comment }),
+		"timestamp": commentField(func(c *ticket.Comment) string {
+			return c.Timestamp.Format(time.RFC3339Nano)
+		}),
+	}}
+
+	policyType.Fields = map[string]*graphql.Field{
+		"id":           policyField(graphql.String, func(p *policy.Policy) interface{} { return p.ID }),
+		"collectors":   policyField(stringList, func(p *policy.Policy) interface{} { return p.Collectors }),
+		"handlers":     policyField(stringList, func(p *policy.Policy) interface{} { return p.Handlers }),
+		"approvers":    policyField(stringList, func(p *policy.Policy) interface{} { return p.Approvers }),
+		"minApprovers": policyField(graphql.Int, func(p *policy.Policy) interface{} { return p.MinApprovers }),
+		"presentationDefinition": {
+			Type: graphql.String,
+			Resolve: policySource(func(_ context.Context, p *policy.Policy, _ graphql.Args) (interface{}, error) {
+				if p.PresentationDefinition == nil {
+					return nil, nil
+				}
+
+				b, err := json.Marshal(p.PresentationDefinition)
+				if err != nil {
+					return nil, fmt.Errorf("marshal presentation definition: %w", err)
+				}
+
+				return string(b), nil
+			}),
+		},
+		"protectedResources": {
+			Type: &graphql.List{OfType: resourceType},
+			Args: []string{"first"},
+			Resolve: policySource(func(ctx context.Context, p *policy.Policy, args graphql.Args) (interface{}, error) {
+				return o.queryProtectedResources(ctx, p.ID, args)
+			}),
+		},
+	}
+
+	resourceType.Fields = map[string]*graphql.Field{
+		"did":      resourceField(func(d *protect.ProtectedData) string { return d.DID }),
+		"policyId": resourceField(func(d *protect.ProtectedData) string { return d.PolicyID }),
+		"policy": {
+			Type: policyType,
+			Resolve: resourceSource(func(ctx context.Context, d *protect.ProtectedData) (interface{}, error) {
+				return o.queryPolicy(ctx, d.PolicyID)
+			}),
+		},
+	}
+
+	ticketType.Fields = map[string]*graphql.Field{
+		"id":          ticketField(graphql.String, func(t *ticket.Ticket) interface{} { return t.ID }),
+		"did":         ticketField(graphql.String, func(t *ticket.Ticket) interface{} { return t.DID }),
+		"status":      ticketField(graphql.String, func(t *ticket.Ticket) interface{} { return t.Status.String() }),
+		"approvedBy":  ticketField(stringList, func(t *ticket.Ticket) interface{} { return t.ApprovedBy }),
+		"requestedBy": ticketField(graphql.String, func(t *ticket.Ticket) interface{} { return t.RequestedBy }),
+		"justification": ticketField(graphql.String, func(t *ticket.Ticket) interface{} {
+			return t.Justification
+		}),
+		"rejectedBy": ticketField(graphql.String, func(t *ticket.Ticket) interface{} { return t.RejectedBy }),
+		"comments": ticketField(&graphql.List{OfType: commentType}, func(t *ticket.Ticket) interface{} {
+			return t.Comments
+		}),
+		"protectedResource": {
+			Type: resourceType,
+			Resolve: ticketSource(func(ctx context.Context, t *ticket.Ticket) (interface{}, error) {
+				return o.queryProtectedResource(ctx, t.DID)
+			}),
+		},
+	}
+
+	queryType := &graphql.Object{Name: "Query", Fields: map[string]*graphql.Field{
+		"policy": {
+			Type: policyType,
+			Args: []string{"id"},
+			Resolve: func(ctx context.Context, _ interface{}, args graphql.Args) (interface{}, error) {
+				id, err := requiredString(args, "id")
+				if err != nil {
+					return nil, err
+				}
+
+				return o.queryPolicy(ctx, strings.ToLower(id))
+			},
+		},
+		"policies": {
+			Type: &graphql.List{OfType: policyType},
+			Args: []string{"first"},
+			Resolve: func(ctx context.Context, _ interface{}, args graphql.Args) (interface{}, error) {
+				return o.queryPolicies(ctx, args)
+			},
+		},
+		"protectedResource": {
+			Type: resourceType,
+			Args: []string{"did"},
+			Resolve: func(ctx context.Context, _ interface{}, args graphql.Args) (interface{}, error) {
+				did, err := requiredString(args, "did")
+				if err != nil {
+					return nil, err
+				}
+
+				return o.queryProtectedResource(ctx, did)
+			},
+		},
+		"protectedResources": {
+			Type: &graphql.List{OfType: resourceType},
+			Args: []string{"policyId", "first"},
+			Resolve: func(ctx context.Context, _ interface{}, args graphql.Args) (interface{}, error) {
+				policyID, _, err := args.String("policyId")
+				if err != nil {
+					return nil, err
+				}
+
+				return o.queryProtectedResources(ctx, strings.ToLower(policyID), args)
+			},
+		},
+		"ticket": {
+			Type: ticketType,
+			Args: []string{"id"},
+			Resolve: func(ctx context.Context, _ interface{}, args graphql.Args) (interface{}, error) {
+				id, err := requiredString(args, "id")
+				if err != nil {
+					return nil, err
+				}
+
+				return o.queryTicket(ctx, strings.ToLower(id))
+			},
+		},
+		"tickets": {
+			Type: &graphql.List{OfType: ticketType},
+			Args: []string{"status", "first"},
+			Resolve: func(ctx context.Context, _ interface{}, args graphql.Args) (interface{}, error) {
+				return o.queryTickets(ctx, args)
+			},
+		},
+	}}
+
+	if o.AuditLog != nil {
+		queryType.Fields["auditEvents"] = &graphql.Field{
+			Type: &graphql.List{OfType: o.auditEventType(ticketType)},
+			Args: []string{"after", "first"},
+			Resolve: func(ctx context.Context, _ interface{}, args graphql.Args) (interface{}, error) {
+				return o.queryAuditEvents(ctx, args)
+			},
+		}
+	}
+
+	return &graphql.Schema{Query: queryType, MaxDepth: graphQLMaxDepth}
+}
+
+// auditEventType returns the AuditEvent type, an entry of the audit log with the event of its ticket.
+func (o *Operation) auditEventType(ticketType *graphql.Object) *graphql.Object {
+	event := func(get func(ev *ticket.Event) string) *graphql.Field {
+		return auditField(graphql.String, func(e *audit.Entry) interface{} {
+			if e.Event == nil {
+				return nil
+			}
+
+			return get(e.Event)
+		})
+	}
+
+	return &graphql.Object{Name: "AuditEvent", Fields: map[string]*graphql.Field{
+		"seq":        auditField(graphql.Int, func(e *audit.Entry) interface{} { return e.Seq }),
+		"ticketId":   auditField(graphql.String, func(e *audit.Entry) interface{} { return e.TicketID }),
+		"did":        auditField(graphql.String, func(e *audit.Entry) interface{} { return e.DID }),
+		"policyId":   auditField(graphql.String, func(e *audit.Entry) interface{} { return e.PolicyID }),
+		"hash":       auditField(graphql.String, func(e *audit.Entry) interface{} { return e.Hash }),
+		"action":     event(func(ev *ticket.Event) string { return string(ev.Action) }),
+		"status":     event(func(ev *ticket.Event) string { return ev.Status.String() }),
+		"actor":      event(func(ev *ticket.Event) string { return ev.Actor }),
+		"reference":  event(func(ev *ticket.Event) string { return ev.Reference }),
+		"onBehalfOf": event(func(ev *ticket.Event) string { return ev.OnBehalfOf }),
+		"timestamp":  event(func(ev *ticket.Event) string { return ev.Timestamp.Format(time.RFC3339Nano) }),
+		"ticket": {
+			Type: ticketType,
+			Resolve: func(ctx context.Context, source interface{}, _ graphql.Args) (interface{}, error) {
+				return o.queryTicket(ctx, source.(*audit.Entry).TicketID) //nolint:forcetypeassert
+			},
+		},
+	}}
+}
+
+var stringList = &graphql.List{OfType: graphql.String} //nolint:gochecknoglobals
+
+// policySource returns a resolver of a Policy field. The resolvers of the fields of an object type are only called
+// with sources of that type.
+func policySource(
+	resolve func(ctx context.Context, p *policy.Policy, args graphql.Args) (interface{}, error)) graphql.ResolveFunc {
+	return func(ctx context.Context, source interface{}, args graphql.Args) (interface{}, error) {
+		return resolve(ctx, source.(*policy.Policy), args) //nolint:forcetypeassert,errcheck
+	}
+}
+
+// resourceSource returns a resolver of a ProtectedResource field.
+func resourceSource(
+	resolve func(ctx context.Context, d *protect.ProtectedData) (interface{}, error)) graphql.ResolveFunc {
+	return func(ctx context.Context, source interface{}, _ graphql.Args) (interface{}, error) {
+		return resolve(ctx, source.(*protect.ProtectedData)) //nolint:forcetypeassert,errcheck
+	}
+}
+
+// ticketSource returns a resolver of a Ticket field.
+func ticketSource(resolve func(ctx context.Context, t *ticket.Ticket) (interface{}, error)) graphql.ResolveFunc {
+	return func(ctx context.Context, source interface{}, _ graphql.Args) (interface{}, error) {
+		return resolve(ctx, source.(*ticket.Ticket)) //nolint:forcetypeassert,errcheck
+	}
+}
+
+// policyField returns a field of the given type with the value returned by get for the policy.
+func policyField(t graphql.Type, get func(p *policy.Policy) interface{}) *graphql.Field {
+	return &graphql.Field{
+		Type: t,
+		Resolve: policySource(func(_ context.Context, p *policy.Policy, _ graphql.Args) (interface{}, error) {
+			return get(p), nil
+		}),
+	}
+}
+
+// resourceField returns a string field with the value returned by get for the protected resource.
+func resourceField(get func(d *protect.ProtectedData) string) *graphql.Field {
+	return &graphql.Field{
+		Type: graphql.String,
+		Resolve: resourceSource(func(_ context.Context, d *protect.ProtectedData) (interface{}, error) {
+			return get(d), nil
+		}),
+	}
+}
+
+// ticketField returns a field of the given type with the value returned by get for the ticket.
+func ticketField(t graphql.Type, get func(t *ticket.Ticket) interface{}) *graphql.Field {
+	return &graphql.Field{
+		Type: t,
+		Resolve: ticketSource(func(_ context.Context, tk *ticket.Ticket) (interface{}, error) {
+			return get(tk), nil
+		}),
+	}
+}
+
+// auditField returns a field of the given type with the value returned by get for the entry of the audit log.
+func auditField(t graphql.Type, get func(e *audit.Entry) interface{}) *graphql.Field {
+	return &graphql.Field{
+		Type: t,
+		Resolve: func(_ context.Context, source interface{}, _ graphql.Args) (interface{}, error) {
+			return get(source.(*audit.Entry)), nil //nolint:forcetypeassert
+		},
+	}
+}
+
+// commentField returns a string field with the value returned by get for the comment of a ticket.
+func commentField(get func(c *ticket.Comment) string) *graphql.Field {
+	return &graphql.Field{
+		Type: graphql.String,
+		Resolve: func(_ context.Context, source interface{}, _ graphql.Args) (interface{}, error) {
+			return get(source.(*ticket.Comment)), nil //nolint:forcetypeassert
+		},
+	}
+}
+
+func (o *Operation) queryPolicy(ctx context.Context, policyID string) (*policy.Policy, error) {
+	p, err := o.PolicyService.Get(ctx, policyID)
+	if errors.Is(err, policy.ErrPolicyNotFound) {
+		return nil, nil
+	}
+
+	return p, err
+}
+
+func (o *Operation) queryPolicies(ctx context.Context, args graphql.Args) ([]*policy.Policy, error) {
+	l, err := newLimiter(args)
+	if err != nil {
+		return nil, err
+	}
+
+	var policies []*policy.Policy
+
+	err = o.PolicyService.Iterate(ctx, pageSize, func(p *policy.Policy) error {
+		if e := l.next(); e != nil {
+			return e
+		}
+
+		policies = append(policies, p)
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list policies: %w", err)
+	}
+
+	return policies, nil
+}
+
+func (o *Operation) queryProtectedResource(ctx context.Context, did string) (*protect.ProtectedData, error) {
+	data, err := o.ProtectService.Get(ctx, did)
+	if errors.Is(err, storage.ErrDataNotFound) {
+		return nil, nil
+	}
+
+	return data, err
+}
+
+func (o *Operation) queryProtectedResources(ctx context.Context, policyID string,
+	args graphql.Args) ([]*protect.ProtectedData, error) {
+	l, err := newLimiter(args)
+	if err != nil {
+		return nil, err
+	}
+
+	var resources []*protect.ProtectedData
+
+	err = o.ProtectService.Iterate(ctx, policyID, pageSize, func(data *protect.ProtectedData) error {
+		if e := l.next(); e != nil {
+			return e
+		}
+
+		resources = append(resources, data)
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list protected resources: %w", err)
+	}
+
+	return resources, nil
+}
+
+func (o *Operation) queryTicket(ctx context.Context, ticketID string) (*ticket.Ticket, error) {
+	t, err := o.ReleaseService.Get(ctx, ticketID)
+	if errors.Is(err, storage.ErrDataNotFound) {
+		return nil, nil
+	}
+
+	return t, err
+}
+
+func (o *Operation) queryTickets(ctx context.Context, args graphql.Args) ([]*ticket.Ticket, error) {
+	statuses := ticketStatuses
+
+	s, ok, err := args.String("status")
+	if err != nil {
+		return nil, err
+	}
+
+	if ok {
+		status, e := parseTicketStatus(s)
+		if e != nil {
+			return nil, e
+		}
+
+		statuses = []ticket.Status{status}
+	}
+
+	l, err := newLimiter(args)
+	if err != nil {
+		return nil, err
+	}
+
+	var tickets []*ticket.Ticket
+
+	for _, status := range statuses {
+		err = o.ReleaseService.Iterate(ctx, status, pageSize, func(t *ticket.Ticket) error {
+			if e := l.next(); e != nil {
+				return e
+			}
+
+			tickets = append(tickets, t)
+
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("list tickets: %w", err)
+		}
+	}
+
+	return tickets, nil
+}
+
+// queryAuditEvents returns the entries of the audit log following the sequence number of the after argument, up to
+// the number of the first argument or audit.MaxEntries.
+func (o *Operation) queryAuditEvents(ctx context.Context, args graphql.Args) ([]*audit.Entry, error) {
+	after, _, err := args.Int("after")
+	if err != nil {
+		return nil, err
+	}
+
+	l, err := newLimiter(args)
+	if err != nil {
+		return nil, err
+	}
+
+	if l.set && l.first == 0 {
+		return []*audit.Entry{}, nil
+	}
+
+	entries, err := o.AuditLog.Entries(ctx, after, l.first)
+	if err != nil {
+		return nil, fmt.Errorf("list audit events: %w", err)
+	}
+
+	return entries, nil
+}
+
+func parseTicketStatus(s string) (ticket.Status, error) {
+	for _, status := range ticketStatuses {
+		if status.String() == s {
+			return status, nil
+		}
+	}
+
+	return 0, fmt.Errorf("invalid ticket status %q", s)
+}
+
+// limiter limits a list to the number of items of the first argument, if it is set, by stopping the iteration of
+// the items with cursor.ErrStop.
+type limiter struct {
+	first int
+	set   bool
+	count int
+}
+
+func newLimiter(args graphql.Args) (*limiter, error) {
+	first, ok, err := args.Int("first")
+	if err != nil {
+		return nil, err
+	}
+
+	if ok && first < 0 {
+		return nil, fmt.Errorf("argument first must not be negative")
+	}
+
+	return &limiter{first: first, set: ok}, nil
+}
+
+// next tells if another item can be added to the list.
+func (l *limiter) next() error {
+	if l.set && l.count >= l.first {
+		return cursor.ErrStop
+	}
+
+	l.count++
+
+	return nil
+}
+
+func requiredString(args graphql.Args, name string) (string, error) {
+	v, ok, err := args.String(name)
+	if err != nil {
+		return "", err
+	}
+
+	if !ok {
+		return "", fmt.Errorf("argument %s is required", name)
+	}
+
+	return v, nil
+}
+
+// graphQLHandler swagger:route POST /v1/graphql gatekeeper graphQLReq
+//
+// Queries policies, protected resources, tickets and audit events with GraphQL. Queries can also be made with GET
+// and the query, operationName and variables parameters.
+//
+// Authorization: Bearer token
+//
+// Responses:
+//
+//	200: graphQLResp
+//	400: graphQLResp
+func (o *Operation) graphQLHandler(schema *graphql.Schema) http.HandlerFunc {
+	return schema.ServeHTTP
+}
@@ -6,20 +6,112 @@ SPDX-License-Identifier: Apache-2.0
 
 package operation
 
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/trustbloc/ace/pkg/gatekeeper/audit"
+	"github.com/trustbloc/ace/pkg/gatekeeper/consent"
+	"github.com/trustbloc/ace/pkg/gatekeeper/delegation"
+	"github.com/trustbloc/ace/pkg/gatekeeper/didcomm"
+	"github.com/trustbloc/ace/pkg/gatekeeper/policy"
+	"github.com/trustbloc/ace/pkg/gatekeeper/release/ticket"
+	"github.com/trustbloc/ace/pkg/gatekeeper/subscription"
+	"github.com/trustbloc/ace/pkg/gatekeeper/watermark"
+	"github.com/trustbloc/ace/pkg/restapi/model"
+)
+
 // ProtectRequest is a request to protect Target using policy with ID Policy.
 type ProtectRequest struct {
 	Policy string `json:"policy"`
 	Target string `json:"target"`
+	// DID of the subject of the target, if known, recorded in the consent receipt.
+	Subject string `json:"subject,omitempty"`
+	// Residency region the target is protected in, if it must be kept in one.
+	Region string `json:"region,omitempty"`
 }
 
 // ProtectResponse is a response for ProtectRequest.
 type ProtectResponse struct {
 	DID string `json:"did"`
+	// Consent receipt signed by the gatekeeper, if the policy defines a consent.
+	ConsentReceipt json.RawMessage `json:"consent_receipt,omitempty"`
+}
+
+// ProtectBatchRequest is a request to protect several targets using policy with ID Policy.
+type ProtectBatchRequest struct {
+	Policy  string   `json:"policy"`
+	Targets []string `json:"targets"`
+}
+
+// ProtectBatchResponse is a response for ProtectBatchRequest.
+type ProtectBatchResponse struct {
+	// DIDs of the protected data, in the order of the targets.
+	DIDs []string `json:"dids"`
+	// Consent receipts signed by the gatekeeper, in the order of the targets, if the policy defines a consent.
+	ConsentReceipts []json.RawMessage `json:"consent_receipts,omitempty"`
+}
+
+// RotateRequest is a request to replace the DID of protected data, with the target it was protected from.
+type RotateRequest struct {
+	DID    string `json:"did"`
+	Target string `json:"target"`
+}
+
+// RotateResponse is a response for RotateRequest.
+type RotateResponse struct {
+	DID string `json:"did"`
+	// Credential signed by the gatekeeper stating that the new DID replaces the previous one.
+	ContinuityCredential json.RawMessage `json:"continuity_credential"`
+	// Consent receipt for the new DID, if the policy defines a consent.
+	ConsentReceipt json.RawMessage `json:"consent_receipt,omitempty"`
+}
+
+// RepolicyRequest is a request to move protected data under another policy, with the target it was protected from.
+type RepolicyRequest struct {
+	Target string `json:"target"`
+	Policy string `json:"policy"`
+	// Reanonymize replaces the DID of the protected data with a new one.
+	Reanonymize bool `json:"reanonymize,omitempty"`
+}
+
+// RepolicyResponse is a response for RepolicyRequest.
+type RepolicyResponse struct {
+	DID string `json:"did"`
+	// Consent receipt under the new policy, if the policy defines a consent.
+	ConsentReceipt json.RawMessage `json:"consent_receipt,omitempty"`
+}
+
+// AnchoringResponse is the status of the anchoring of the DID of protected data on a ledger, such as Orb.
+type AnchoringResponse struct {
+	DID string `json:"did"`
+	// Status is none if the DID is not anchored, such as a did:key, otherwise pending or anchored.
+	Status string `json:"status"`
+	// DID the DID is anchored with, once it is anchored.
+	CanonicalDID string `json:"canonical_did,omitempty"`
+	// Time the status was last changed.
+	Updated *time.Time `json:"updated,omitempty"`
 }
 
 // ReleaseRequest is a request to create release transaction on a DID.
 type ReleaseRequest struct {
 	DID string `json:"did"`
+	// Verifiable presentation satisfying the presentation definition of the policy, if the policy defines one.
+	Presentation json.RawMessage `json:"presentation,omitempty"`
+	// ID of the OIDC4VP interaction the presentation was made with instead, if OIDC4VP is enabled.
+	OIDC4VPTxID string `json:"oidc4vp_tx_id,omitempty"`
+	// Reason the data is requested for, notified to the subject of the data if the policy defines a notification.
+	Justification string `json:"justification,omitempty"`
+}
+
+// OIDC4VPRequest is a request to initiate an OIDC4VP interaction presenting the credentials required by the policy
+// of the protected data.
+type OIDC4VPRequest struct {
+	DID string `json:"did"`
 }
 
 // ReleaseResponse is a response for ReleaseRequest.
@@ -27,9 +119,289 @@ type ReleaseResponse struct {
 	TicketID string `json:"ticket_id"`
 }
 
+// ListPoliciesResponse is a response with policy configurations.
+type ListPoliciesResponse struct {
+	Policies []*policy.Policy `json:"policies"`
+}
+
+// EvaluateRequest is a request to evaluate whether the actor may run the operations under the policies.
+type EvaluateRequest struct {
+	Actor       string        `json:"actor"`
+	Evaluations []*Evaluation `json:"evaluations"`
+}
+
+// Evaluation is an operation under a policy: protect, release, collect, approve, reject or break_glass.
+type Evaluation struct {
+	PolicyID  string `json:"policy_id"`
+	Operation string `json:"operation"`
+}
+
+// EvaluateResponse is a response for EvaluateRequest, with a result per evaluation in the order of the request.
+type EvaluateResponse struct {
+	Results []*EvaluationResult `json:"results"`
+}
+
+// EvaluationResult tells whether the actor may run the operation under the policy. Operations under unknown policies
+// and unknown operations are denied, with the reason in Error.
+type EvaluationResult struct {
+	PolicyID  string `json:"policy_id"`
+	Operation string `json:"operation"`
+	Allowed   bool   `json:"allowed"`
+	Error     string `json:"error,omitempty"`
+}
+
+// TicketResponse is a response with the ticket.
+type TicketResponse struct {
+	ID         string   `json:"id"`
+	DID        string   `json:"did"`
+	Status     string   `json:"status"`
+	ApprovedBy []string `json:"approved_by"`
+	QueryID    string   `json:"query_id,omitempty"`
+	PolicyID   string   `json:"policy_id,omitempty"`
+	// Time the release was requested.
+	CreatedAt time.Time `json:"created_at"`
+	// DID of the handler that requested the release, and the reason they gave.
+	RequestedBy   string `json:"requested_by,omitempty"`
+	Justification string `json:"justification,omitempty"`
+	// DID of the approver that rejected the ticket, and the comments of the approvers on their decisions.
+	RejectedBy string            `json:"rejected_by,omitempty"`
+	Comments   []*ticket.Comment `json:"comments,omitempty"`
+	// BreakGlass is set if the data was collected by a responder in an emergency, without the approvals of the
+	// approvers.
+	BreakGlass bool `json:"break_glass,omitempty"`
+}
+
+// ListTicketsRequest is a request to list the tickets selected by filters, sent as query parameters. Empty filters
+// select all tickets.
+type ListTicketsRequest struct {
+	// Requestor is the DID of the handler that requested the releases.
+	Requestor string
+	// Statuses are the statuses of the tickets, e.g. NEW or COLLECTING.
+	Statuses []string
+	PolicyID string
+	// From and To, if set, select the tickets created at or after From and before To.
+	From time.Time
+	To   time.Time
+	// Sort is created_at or -created_at, for the oldest or the newest tickets first. It defaults to created_at.
+	Sort string
+	// PageRequest is the page of the sorted tickets listed.
+	model.PageRequest
+}
+
+// Values returns the query parameters of the request.
+func (r *ListTicketsRequest) Values() url.Values {
+	v := url.Values{}
+
+	setValue(v, requestorParam, r.Requestor)
+	setValue(v, policyIDParam, r.PolicyID)
+	setValue(v, sortParam, r.Sort)
+
+	for _, status := range r.Statuses {
+		v.Add(statusParam, status)
+	}
+
+	if !r.From.IsZero() {
+		v.Set(fromParam, r.From.Format(time.RFC3339Nano))
+	}
+
+	if !r.To.IsZero() {
+		v.Set(toParam, r.To.Format(time.RFC3339Nano))
+	}
+
+	r.SetValues(v)
+
+	return v
+}
+
+func setValue(v url.Values, key, value string) {
+	if value != "" {
+		v.Set(key, value)
+	}
+}
+
+// parseListTicketsRequest parses the query parameters of a ListTicketsRequest. Statuses may be given as repeated or
+// comma-separated parameters.
+func parseListTicketsRequest(v url.Values) (*ListTicketsRequest, error) {
+	r := &ListTicketsRequest{
+		Requestor: v.Get(requestorParam),
+		PolicyID:  v.Get(policyIDParam),
+		Sort:      v.Get(sortParam),
+	}
+
+	for _, s := range v[statusParam] {
+		r.Statuses = append(r.Statuses, strings.Split(s, ",")...)
+	}
+
+	var err error
+
+	if r.From, err = parseTime(v, fromParam); err != nil {
+		return nil, err
+	}
+
+	if r.To, err = parseTime(v, toParam); err != nil {
+		return nil, err
+	}
+
+	page, err := model.ParsePageRequest(v)
+	if err != nil {
+		return nil, err
+	}
+
+	r.PageRequest = *page
+
+	return r, nil
+}
+
+func parseTime(v url.Values, key string) (time.Time, error) {
+	s := v.Get(key)
+	if s == "" {
+		return time.Time{}, nil
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s: %w", key, err)
+	}
+
+	return t, nil
+}
+
+func parseInt(v url.Values, key string) (int, error) {
+	s := v.Get(key)
+	if s == "" {
+		return 0, nil
+	}
+
+	i, err := strconv.Atoi(s)
+	if err != nil || i < 0 {
+		return 0, fmt.Errorf("invalid %s %q", key, s)
+	}
+
+	return i, nil
+}
+
+// ListTicketsResponse is a response with a page of the tickets selected by ListTicketsRequest, with the number of
+// tickets selected, of all pages.
+type ListTicketsResponse struct {
+	Items []*TicketResponse `json:"items"`
+	model.Page
+}
+
+// TicketHistoryResponse is a response with the history of the ticket.
+type TicketHistoryResponse struct {
+	TicketID string `json:"ticket_id"`
+	// Actions taken on the ticket, oldest first.
+	History []*TicketEvent `json:"history"`
+}
+
+// TicketEvent is an action taken on a ticket, with the status of the ticket after it.
+type TicketEvent struct {
+	// Action is release, approve, reject or collect.
+	Action string `json:"action"`
+	Status string `json:"status"`
+	// DID of the party that took the action.
+	Actor string `json:"actor"`
+	// Reference of the signature or the capability the action was authorized with, if known.
+	Reference string    `json:"reference,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+	// DID of the approver the actor decided on behalf of, and ID of the delegation they decided with, if the actor is
+	// a delegate.
+	OnBehalfOf string `json:"on_behalf_of,omitempty"`
+	Delegation string `json:"delegation,omitempty"`
+}
+
 // TicketStatusResponse is a response with status of the ticket.
 type TicketStatusResponse struct {
-	Status string `json:"status"`
+	Status        string `json:"status"`
+	Justification string `json:"justification,omitempty"`
+	// DID of the approver that rejected the ticket.
+	RejectedBy string            `json:"rejected_by,omitempty"`
+	Comments   []*ticket.Comment `json:"comments,omitempty"`
+	// Extraction quota of the released data, if its policy defines one.
+	Quota *QuotaStatus `json:"quota,omitempty"`
+}
+
+// QuotaStatus is the extraction quota of released data, with the number of extractions left in its window.
+type QuotaStatus struct {
+	Extractions int `json:"extractions"`
+	Days        int `json:"days"`
+	Remaining   int `json:"remaining"`
+}
+
+// DecisionRequest is the optional body of the requests authorizing or rejecting a ticket.
+type DecisionRequest struct {
+	// Comment of the approver on their decision, stored on the ticket.
+	Comment string `json:"comment,omitempty"`
+	// OnBehalfOf is the DID of the approver a delegate decides on behalf of. It is only required if the delegate has
+	// active delegations of several approvers.
+	OnBehalfOf string `json:"on_behalf_of,omitempty"`
+}
+
+// DelegateRequest is a request of an approver to delegate their approval authority under a policy.
+type DelegateRequest struct {
+	// Delegate is the DID the approval authority is delegated to.
+	Delegate string `json:"delegate"`
+	// NotBefore and NotAfter are the start and the end of the time window of the delegation. It starts immediately if
+	// NotBefore is not set.
+	NotBefore time.Time `json:"not_before,omitempty"`
+	NotAfter  time.Time `json:"not_after"`
+}
+
+// DelegateResponse is a response for DelegateRequest.
+type DelegateResponse struct {
+	Delegation *delegation.Delegation `json:"delegation"`
+}
+
+// SubscribeRequest is a request of a participant to be notified of the events of the tickets they are involved in.
+type SubscribeRequest struct {
+	// Webhook the events are posted to, and whether they are sent as DIDComm messages to the agent of the participant.
+	policy.Notification
+	// Actions, if set, are the actions the participant is notified of, e.g. approve, reject. It defaults to all.
+	Actions []string `json:"actions,omitempty"`
+}
+
+// SubscribeResponse is a response for SubscribeRequest.
+type SubscribeResponse struct {
+	Subscription *subscription.Subscription `json:"subscription"`
+}
+
+// ListSubscriptionsResponse is a response with a page of the subscriptions of a participant, with the number of
+// subscriptions of all pages.
+type ListSubscriptionsResponse struct {
+	Items []*subscription.Subscription `json:"items"`
+	model.Page
+}
+
+// InvitationRequest is a request to invite a new participant over DIDComm.
+type InvitationRequest struct {
+	// Role is the role the participant is invited as, handler or approver.
+	Role string `json:"role"`
+	// Label, if set, names the participant in their connection.
+	Label string `json:"label,omitempty"`
+}
+
+// ConnectionsResponse is a response for the listing of the DIDComm connections of the participants.
+type ConnectionsResponse struct {
+	Connections []*didcomm.Connection `json:"connections"`
+}
+
+// AuditEntriesResponse is a response with a page of the entries of the audit log, with the number of entries of all
+// pages.
+type AuditEntriesResponse struct {
+	Items []*audit.Entry `json:"items"`
+	// Head is the last entry of the log, read before the entries of the page.
+	Head *audit.Head `json:"head"`
+	model.Page
+}
+
+// CheckpointsResponse is a response with the signed checkpoints of the audit log.
+type CheckpointsResponse struct {
+	Checkpoints []json.RawMessage `json:"checkpoints"`
+}
+
+// IssuancesResponse is a response for the listing of the issuances of authorized handler credentials.
+type IssuancesResponse struct {
+	Issuances []*didcomm.Issuance `json:"issuances"`
 }
 
 // CollectResponse is a response for collect api.
@@ -37,12 +409,99 @@ type CollectResponse struct {
 	QueryID string `json:"query_id"`
 }
 
+// BreakGlassRequest is a request of a responder to collect protected data in an emergency, without the approvals of
+// the approvers.
+type BreakGlassRequest struct {
+	DID string `json:"did"`
+	// Reason of the emergency, required and notified to the subject and the approvers.
+	Justification string `json:"justification"`
+}
+
+// BreakGlassResponse is a response for BreakGlassRequest.
+type BreakGlassResponse struct {
+	TicketID string `json:"ticket_id"`
+	QueryID  string `json:"query_id"`
+}
+
 // ExtractRequest is a response for ReleaseRequest.
 type ExtractRequest struct {
 	QueryID string `json:"query_id"`
+	// Whether the data is returned encrypted, as a JWE to the key agreement key of the handler the data is released
+	// to. Data of policies with encrypt_extract set is always encrypted.
+	Encrypt bool `json:"encrypt,omitempty"`
 }
 
-// ExtractResponse is a response for ExtractRequest.
+// ExtractResponse is a response for ExtractRequest. It has either the data, or the data encrypted as a JWE in its
+// JSON serialization.
 type ExtractResponse struct {
+	Target string          `json:"target,omitempty"`
+	JWE    json.RawMessage `json:"jwe,omitempty"`
+}
+
+// ExtractBatchRequest is a request to extract the data of several queries created by collecting released data.
+type ExtractBatchRequest struct {
+	QueryIDs []string `json:"query_ids"`
+	// Whether the data of the queries is returned encrypted, as in ExtractRequest.
+	Encrypt bool `json:"encrypt,omitempty"`
+}
+
+// ExtractBatchItem is the result of the extraction of a query of ExtractBatchRequest, a line of the response.
+type ExtractBatchItem struct {
+	QueryID string          `json:"query_id"`
+	Target  string          `json:"target,omitempty"`
+	JWE     json.RawMessage `json:"jwe,omitempty"`
+	// Error and Status, if set, are the error message and the HTTP status the query failed to be extracted with.
+	Error  string `json:"error,omitempty"`
+	Status int    `json:"status,omitempty"`
+}
+
+// DSARResponse is a report of the data protected about the subject, for data subject access requests.
+type DSARResponse struct {
+	Subject string         `json:"subject"`
+	Data    []*SubjectData `json:"data"`
+}
+
+// SubjectData is protected data of the subject with the consents it was protected with and the tickets releasing it.
+type SubjectData struct {
+	DID      string             `json:"did"`
+	PolicyID string             `json:"policy_id"`
+	Consents []*consent.Receipt `json:"consents"`
+	Releases []*TicketResponse  `json:"releases"`
+	// Linked is set if the data was linked to the subject, rather than only found from their consent receipts.
+	Linked bool `json:"linked,omitempty"`
+}
+
+// LinkRequest is a request of the subject to link protected data to them.
+type LinkRequest struct {
+	Data []*LinkedData `json:"data"`
+}
+
+// LinkedData is protected data with the target it was protected from, the proof that it belongs to the subject.
+type LinkedData struct {
+	DID    string `json:"did"`
 	Target string `json:"target"`
 }
+
+// LinkResponse is a response for LinkRequest.
+type LinkResponse struct {
+	Subject string `json:"subject"`
+	// DIDs of all of the protected data linked to the subject.
+	DIDs []string `json:"dids"`
+}
+
+// EraseResponse is a response for data subject erasure requests.
+type EraseResponse struct {
+	Subject string `json:"subject"`
+	// DIDs of the protected data deleted.
+	DIDs []string `json:"dids"`
+}
+
+// TraceRequest is a request to trace watermarked data, e.g. leaked data, back to its extraction.
+type TraceRequest struct {
+	Data string `json:"data"`
+}
+
+// TraceResponse is a response for TraceRequest.
+type TraceResponse struct {
+	Extraction *watermark.Record `json:"extraction"`
+}
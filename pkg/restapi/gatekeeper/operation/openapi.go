@@ -6,6 +6,13 @@ SPDX-License-Identifier: Apache-2.0
 
 package operation
 
+import (
+	"github.com/trustbloc/ace/pkg/gatekeeper/audit"
+	"github.com/trustbloc/ace/pkg/gatekeeper/didcomm"
+	"github.com/trustbloc/ace/pkg/gatekeeper/oidc4vp"
+	"github.com/trustbloc/ace/pkg/graphql"
+)
+
 // createPolicyReq model
 //
 // swagger:parameters createPolicyReq
@@ -16,6 +23,11 @@ type createPolicyReq struct { //nolint:unused,deadcode
 	// required: true
 	PolicyID string `json:"policy_id"`
 
+	// ETag of the policy as it was read, required to replace an existing policy.
+	//
+	// in: header
+	IfMatch string `json:"If-Match"`
+
 	// in: body
 	Body struct {
 		Collectors   []string `json:"collectors"`
@@ -28,7 +40,116 @@ type createPolicyReq struct { //nolint:unused,deadcode
 // createPolicyResp model
 //
 // swagger:response createPolicyResp
-type createPolicyResp struct{} //nolint:unused,deadcode
+type createPolicyResp struct { //nolint:unused,deadcode
+	// ETag of the saved policy.
+	ETag string `json:"ETag"`
+}
+
+// getPolicyReq model
+//
+// swagger:parameters getPolicyReq
+type getPolicyReq struct { //nolint:unused,deadcode
+	// Policy ID.
+	//
+	// in: path
+	// required: true
+	PolicyID string `json:"policy_id"`
+
+	// ETag of the cached policy, which is not returned again if it was not modified.
+	//
+	// in: header
+	IfNoneMatch string `json:"If-None-Match"`
+}
+
+// getPolicyResp model
+//
+// swagger:response getPolicyResp
+type getPolicyResp struct { //nolint:unused,deadcode
+	// ETag of the policy, to be sent in the If-Match header of the request replacing it.
+	ETag string `json:"ETag"`
+
+	// in: body
+	Body struct {
+		ID           string   `json:"id"`
+		Collectors   []string `json:"collectors"`
+		Handlers     []string `json:"handlers"`
+		Approvers    []string `json:"approvers"`
+		MinApprovers int      `json:"min_approvers"`
+	}
+}
+
+// deletePolicyReq model
+//
+// swagger:parameters deletePolicyReq
+type deletePolicyReq struct { //nolint:unused,deadcode
+	// Policy ID.
+	//
+	// in: path
+	// required: true
+	PolicyID string `json:"policy_id"`
+}
+
+// notModifiedResp model
+//
+// swagger:response notModifiedResp
+type notModifiedResp struct{} //nolint:unused,deadcode
+
+// deletePolicyResp model
+//
+// swagger:response deletePolicyResp
+type deletePolicyResp struct{} //nolint:unused,deadcode
+
+// listPoliciesResp model
+//
+// swagger:response listPoliciesResp
+type listPoliciesResp struct { //nolint:unused,deadcode
+	// ETag of the list of policies.
+	ETag string `json:"ETag"`
+
+	// in: body
+	Body struct {
+		Policies []struct {
+			ID           string   `json:"id"`
+			Collectors   []string `json:"collectors"`
+			Handlers     []string `json:"handlers"`
+			Approvers    []string `json:"approvers"`
+			MinApprovers int      `json:"min_approvers"`
+		} `json:"policies"`
+	}
+}
+
+// evaluatePoliciesReq model
+//
+// swagger:parameters evaluatePoliciesReq
+type evaluatePoliciesReq struct { //nolint:unused,deadcode
+	// in: body
+	Body struct {
+		// DID of the actor.
+		//
+		// required: true
+		Actor string `json:"actor"`
+		// Operations to evaluate: protect, release, collect, approve, reject or break_glass.
+		Evaluations []struct {
+			PolicyID  string `json:"policy_id"`
+			Operation string `json:"operation"`
+		} `json:"evaluations"`
+	}
+}
+
+// evaluatePoliciesResp model
+//
+// swagger:response evaluatePoliciesResp
+type evaluatePoliciesResp struct { //nolint:unused,deadcode
+	// in: body
+	Body struct {
+		Results []struct {
+			PolicyID  string `json:"policy_id"`
+			Operation string `json:"operation"`
+			Allowed   bool   `json:"allowed"`
+			Error     string `json:"error,omitempty"`
+		} `json:"results"`
+	}
+}
 
 // protectReq model
 //
@@ -50,6 +171,196 @@ type protectResp struct { //nolint:unused,deadcode
 	}
 }
 
+// protectBatchReq model
+//
+// swagger:parameters protectBatchReq
+type protectBatchReq struct { //nolint:unused,deadcode
+	// in: body
+	Body struct {
+		ProtectBatchRequest
+	}
+}
+
+// protectBatchResp model
+//
+// swagger:response protectBatchResp
+type protectBatchResp struct { //nolint:unused,deadcode
+	// in: body
+	Body struct {
+		ProtectBatchResponse
+	}
+}
+
+// rotateReq model
+//
+// swagger:parameters rotateReq
+type rotateReq struct { //nolint:unused,deadcode
+	// in: body
+	Body struct {
+		RotateRequest
+	}
+}
+
+// rotateResp model
+//
+// swagger:response rotateResp
+type rotateResp struct { //nolint:unused,deadcode
+	// in: body
+	Body struct {
+		RotateResponse
+	}
+}
+
+// repolicyReq model
+//
+// swagger:parameters repolicyReq
+type repolicyReq struct { //nolint:unused,deadcode
+	// DID of the protected data.
+	//
+	// in: path
+	// required: true
+	DID string `json:"did"`
+
+	// in: body
+	Body struct {
+		RepolicyRequest
+	}
+}
+
+// repolicyResp model
+//
+// swagger:response repolicyResp
+type repolicyResp struct { //nolint:unused,deadcode
+	// in: body
+	Body struct {
+		RepolicyResponse
+	}
+}
+
+// anchoringReq model
+//
+// swagger:parameters anchoringReq
+type anchoringReq struct { //nolint:unused,deadcode
+	// DID of the protected data.
+	//
+	// in: path
+	// required: true
+	DID string `json:"did"`
+
+	// ETag of the cached anchoring status, which is not returned again if it was not modified.
+	//
+	// in: header
+	IfNoneMatch string `json:"If-None-Match"`
+
+	// Time the anchoring status was cached at, which is not returned again if it was not modified since.
+	//
+	// in: header
+	IfModifiedSince string `json:"If-Modified-Since"`
+}
+
+// anchoringResp model
+//
+// swagger:response anchoringResp
+type anchoringResp struct { //nolint:unused,deadcode
+	// ETag of the anchoring status.
+	ETag string `json:"ETag"`
+
+	// Time the anchoring status was last updated at.
+	LastModified string `json:"Last-Modified"`
+
+	// in: body
+	Body struct {
+		AnchoringResponse
+	}
+}
+
+// delegateReq model
+//
+// swagger:parameters delegateReq
+type delegateReq struct { //nolint:unused,deadcode
+	// Policy ID.
+	//
+	// in: path
+	// required: true
+	PolicyID string `json:"policy_id"`
+
+	// in: body
+	Body struct {
+		DelegateRequest
+	}
+}
+
+// delegateResp model
+//
+// swagger:response delegateResp
+type delegateResp struct { //nolint:unused,deadcode
+	// in: body
+	Body struct {
+		DelegateResponse
+	}
+}
+
+// subscribeReq model
+//
+// swagger:parameters subscribeReq
+type subscribeReq struct { //nolint:unused,deadcode
+	// in: body
+	Body struct {
+		SubscribeRequest
+	}
+}
+
+// subscribeResp model
+//
+// swagger:response subscribeResp
+type subscribeResp struct { //nolint:unused,deadcode
+	// in: body
+	Body struct {
+		SubscribeResponse
+	}
+}
+
+// listSubscriptionsReq model
+//
+// swagger:parameters listSubscriptionsReq
+type listSubscriptionsReq struct { //nolint:unused,deadcode
+	// Cursor of the page, the next_cursor of the previous page. Defaults to the first page.
+	//
+	// in: query
+	Cursor string `json:"cursor"`
+
+	// Maximum number of subscriptions listed, 100 by default, up to 1000.
+	//
+	// in: query
+	Limit int `json:"limit"`
+}
+
+// listSubscriptionsResp model
+//
+// swagger:response listSubscriptionsResp
+type listSubscriptionsResp struct { //nolint:unused,deadcode
+	// in: body
+	Body struct {
+		ListSubscriptionsResponse
+	}
+}
+
+// unsubscribeReq model
+//
+// swagger:parameters unsubscribeReq
+type unsubscribeReq struct { //nolint:unused,deadcode
+	// Subscription ID.
+	//
+	// in: path
+	// required: true
+	SubscriptionID string `json:"subscription_id"`
+}
+
+// unsubscribeResp model
+//
+// swagger:response unsubscribeResp
+type unsubscribeResp struct{} //nolint:unused,deadcode
+
 // releaseReq model
 //
 // swagger:parameters releaseReq
@@ -60,6 +371,26 @@ type releaseReq struct { //nolint:unused,deadcode
 	}
 }
 
+// oidc4vpReq model
+//
+// swagger:parameters oidc4vpReq
+type oidc4vpReq struct { //nolint:unused,deadcode
+	// in: body
+	Body struct {
+		OIDC4VPRequest
+	}
+}
+
+// oidc4vpResp model
+//
+// swagger:response oidc4vpResp
+type oidc4vpResp struct { //nolint:unused,deadcode
+	// in: body
+	Body struct {
+		oidc4vp.Interaction
+	}
+}
+
 // releaseResp model
 //
 // swagger:response releaseResp
@@ -79,6 +410,11 @@ type authorizeReq struct { //nolint:unused,deadcode
 	// in: path
 	// required: true
 	TicketID string `json:"ticket_id"`
+
+	// in: body
+	Body struct {
+		DecisionRequest
+	}
 }
 
 // authorizeResp model
@@ -86,6 +422,103 @@ type authorizeReq struct { //nolint:unused,deadcode
 // swagger:response authorizeResp
 type authorizeResp struct{} //nolint:unused,deadcode
 
+// rejectReq model
+//
+// swagger:parameters rejectReq
+type rejectReq struct { //nolint:unused,deadcode
+	// Ticket ID.
+	//
+	// in: path
+	// required: true
+	TicketID string `json:"ticket_id"`
+
+	// in: body
+	Body struct {
+		DecisionRequest
+	}
+}
+
+// rejectResp model
+//
+// swagger:response rejectResp
+type rejectResp struct{} //nolint:unused,deadcode
+
+// listTicketsReq model
+//
+// swagger:parameters listTicketsReq
+type listTicketsReq struct { //nolint:unused,deadcode
+	// DID of the handler that requested the releases.
+	//
+	// in: query
+	Requestor string `json:"requestor"`
+
+	// Statuses of the tickets: NEW, COLLECTING, READY_TO_COLLECT or REJECTED.
+	//
+	// in: query
+	Status []string `json:"status"`
+
+	// ID of the policy of the released data.
+	//
+	// in: query
+	PolicyID string `json:"policy_id"`
+
+	// Tickets created at or after this time, in RFC 3339 format.
+	//
+	// in: query
+	From string `json:"from"`
+
+	// Tickets created before this time, in RFC 3339 format.
+	//
+	// in: query
+	To string `json:"to"`
+
+	// Sort order: created_at (default) or -created_at.
+	//
+	// in: query
+	Sort string `json:"sort"`
+
+	// Cursor of the page, the next_cursor of the previous page. Defaults to the first page.
+	//
+	// in: query
+	Cursor string `json:"cursor"`
+
+	// Maximum number of tickets listed, 100 by default, up to 1000.
+	//
+	// in: query
+	Limit int `json:"limit"`
+}
+
+// listTicketsResp model
+//
+// swagger:response listTicketsResp
+type listTicketsResp struct { //nolint:unused,deadcode
+	// in: body
+	Body struct {
+		ListTicketsResponse
+	}
+}
+
+// ticketHistoryReq model
+//
+// swagger:parameters ticketHistoryReq
+type ticketHistoryReq struct { //nolint:unused,deadcode
+	// Ticket ID.
+	//
+	// in: path
+	// required: true
+	TicketID string `json:"ticket_id"`
+}
+
+// ticketHistoryResp model
+//
+// swagger:response ticketHistoryResp
+type ticketHistoryResp struct { //nolint:unused,deadcode
+	// in: body
+	Body struct {
+		TicketHistoryResponse
+	}
+}
+
 // ticketStatusReq model
 //
 // swagger:parameters ticketStatusReq
@@ -107,6 +540,190 @@ type ticketStatusResp struct { //nolint:unused,deadcode
 	}
 }
 
+// ticketEventsReq model
+//
+// swagger:parameters ticketEventsReq
+type ticketEventsReq struct { //nolint:unused,deadcode
+	// Ticket ID.
+	//
+	// in: path
+	// required: true
+	TicketID string `json:"ticket_id"`
+}
+
+// ticketEventsResp model
+//
+// swagger:response ticketEventsResp
+type ticketEventsResp struct { //nolint:unused,deadcode
+	// Server-sent status events with the status of the ticket as their data.
+	//
+	// in: body
+	Body []TicketStatusResponse
+}
+
+// didcommReq model
+//
+// swagger:parameters didcommReq
+type didcommReq struct { //nolint:unused,deadcode
+	// Signed DIDComm message, in the JWS compact serialization.
+	//
+	// in: body
+	// required: true
+	Body string
+}
+
+// didcommResp model
+//
+// swagger:response didcommResp
+type didcommResp struct{} //nolint:unused,deadcode
+
+// inviteReq model
+//
+// swagger:parameters inviteReq
+type inviteReq struct { //nolint:unused,deadcode
+	// in: body
+	Body struct {
+		InvitationRequest
+	}
+}
+
+// inviteResp model
+//
+// swagger:response inviteResp
+type inviteResp struct { //nolint:unused,deadcode
+	// in: body
+	Body struct {
+		didcomm.Invitation
+	}
+}
+
+// connectionsReq model
+//
+// swagger:parameters connectionsReq
+type connectionsReq struct { //nolint:unused,deadcode
+	// DID of the participant.
+	//
+	// in: query
+	DID string `json:"did"`
+}
+
+// connectionsResp model
+//
+// swagger:response connectionsResp
+type connectionsResp struct { //nolint:unused,deadcode
+	// in: body
+	Body struct {
+		ConnectionsResponse
+	}
+}
+
+// auditEntriesReq model
+//
+// swagger:parameters auditEntriesReq
+type auditEntriesReq struct { //nolint:unused,deadcode
+	// Cursor of the page, the next_cursor of the previous page. Defaults to the first page, from the first entry.
+	//
+	// in: query
+	Cursor string `json:"cursor"`
+
+	// Maximum number of entries listed, up to 1000.
+	//
+	// in: query
+	Limit int `json:"limit"`
+}
+
+// auditEntriesResp model
+//
+// swagger:response auditEntriesResp
+type auditEntriesResp struct { //nolint:unused,deadcode
+	// in: body
+	Body struct {
+		AuditEntriesResponse
+	}
+}
+
+// checkpointsResp model
+//
+// swagger:response checkpointsResp
+type checkpointsResp struct { //nolint:unused,deadcode
+	// in: body
+	Body struct {
+		CheckpointsResponse
+	}
+}
+
+// auditVerifyResp model
+//
+// swagger:response auditVerifyResp
+type auditVerifyResp struct { //nolint:unused,deadcode
+	// in: body
+	Body struct {
+		audit.Report
+	}
+}
+
+// issuancesReq model
+//
+// swagger:parameters issuancesReq
+type issuancesReq struct { //nolint:unused,deadcode
+	// Status of the issuances: proposed, offered or issued.
+	//
+	// in: query
+	Status string `json:"status"`
+}
+
+// issuancesResp model
+//
+// swagger:response issuancesResp
+type issuancesResp struct { //nolint:unused,deadcode
+	// in: body
+	Body struct {
+		IssuancesResponse
+	}
+}
+
+// approveIssuanceReq model
+//
+// swagger:parameters approveIssuanceReq
+type approveIssuanceReq struct { //nolint:unused,deadcode
+	// Issuance ID.
+	//
+	// in: path
+	// required: true
+	ID string `json:"issuance_id"`
+}
+
+// approveIssuanceResp model
+//
+// swagger:response approveIssuanceResp
+type approveIssuanceResp struct { //nolint:unused,deadcode
+	// in: body
+	Body struct {
+		didcomm.Issuance
+	}
+}
+
+// getTicketReq model
+//
+// swagger:parameters getTicketReq
+type getTicketReq struct { //nolint:unused,deadcode
+	// Ticket ID.
+	//
+	// in: path
+	// required: true
+	TicketID string `json:"ticket_id"`
+}
+
+// getTicketResp model
+//
+// swagger:response getTicketResp
+type getTicketResp struct { //nolint:unused,deadcode
+	// in: body
+	Body struct {
+		TicketResponse
+	}
+}
+
 // collectReq model
 //
 // swagger:parameters collectReq
@@ -128,6 +745,26 @@ type collectResp struct { //nolint:unused,deadcode
 	}
 }
 
+// breakGlassReq model
+//
+// swagger:parameters breakGlassReq
+type breakGlassReq struct { //nolint:unused,deadcode
+	// in: body
+	Body struct {
+		BreakGlassRequest
+	}
+}
+
+// breakGlassResp model
+//
+// swagger:response breakGlassResp
+type breakGlassResp struct { //nolint:unused,deadcode
+	// in: body
+	Body struct {
+		BreakGlassResponse
+	}
+}
+
 // extractReq model
 //
 // swagger:parameters extractReq
@@ -148,6 +785,87 @@ type extractResp struct { //nolint:unused,deadcode
 	}
 }
 
+// extractBatchReq model
+//
+// swagger:parameters extractBatchReq
+type extractBatchReq struct { //nolint:unused,deadcode
+	// in: body
+	Body struct {
+		ExtractBatchRequest
+	}
+}
+
+// extractBatchResp model
+//
+// swagger:response extractBatchResp
+type extractBatchResp struct { //nolint:unused,deadcode
+	// Newline-delimited JSON with an item per query.
+	//
+	// in: body
+	Body []ExtractBatchItem
+}
+
+// purgeResp model
+//
+// swagger:response purgeResp
+type purgeResp struct{} //nolint:unused,deadcode
+
+// dsarResp model
+//
+// swagger:response dsarResp
+type dsarResp struct { //nolint:unused,deadcode
+	// in: body
+	Body struct {
+		DSARResponse
+	}
+}
+
+// eraseResp model
+//
+// swagger:response eraseResp
+type eraseResp struct { //nolint:unused,deadcode
+	// in: body
+	Body struct {
+		EraseResponse
+	}
+}
+
+// linkReq model
+//
+// swagger:parameters linkReq
+type linkReq struct { //nolint:unused,deadcode
+	// in: body
+	Body struct {
+		LinkRequest
+	}
+}
+
+// linkResp model
+//
+// swagger:response linkResp
+type linkResp struct { //nolint:unused,deadcode
+	// in: body
+	Body struct {
+		LinkResponse
+	}
+}
+
+// graphQLReq model
+//
+// swagger:parameters graphQLReq
+type graphQLReq struct { //nolint:unused,deadcode
+	// in: body
+	Body graphql.Request
+}
+
+// graphQLResp model
+//
+// swagger:response graphQLResp
+type graphQLResp struct { //nolint:unused,deadcode
+	// in: body
+	Body graphql.Response
+}
+
 // errorResp model
 //
 // swagger:response errorResp
@@ -159,3 +877,23 @@ type errorResp struct { //nolint:unused,deadcode
 		Message string `json:"errMessage,omitempty"`
 	}
 }
+
+// traceReq model
+//
+// swagger:parameters traceReq
+type traceReq struct { //nolint:unused,deadcode
+	// in: body
+	Body struct {
+		TraceRequest
+	}
+}
+
+// traceResp model
+//
+// swagger:response traceResp
+type traceResp struct { //nolint:unused,deadcode
+	// in: body
+	Body struct {
+		TraceResponse
+	}
+}
@@ -7,38 +7,124 @@ SPDX-License-Identifier: Apache-2.0
 package operation
 
 //nolint:lll
-//go:generate mockgen -destination gomocks_test.go -package operation_test -source=operations.go -mock_names policyService=MockPolicyService,protectService=MockProtectService,releaseService=MockReleaseService,subjectResolver=MockSubjectResolver,collectService=MockCollectService,extractService=MockExtractService
+//go:generate mockgen -destination gomocks_test.go -package operation_test -source=operations.go -mock_names policyService=MockPolicyService,referenceResolver=MockReferenceResolver,delegationService=MockDelegationService,subscriptionService=MockSubscriptionService,quotaService=MockQuotaService,purgeService=MockPurgeService,protectService=MockProtectService,releaseService=MockReleaseService,subjectResolver=MockSubjectResolver,collectService=MockCollectService,extractService=MockExtractService,presentationVerifier=MockPresentationVerifier,consentService=MockConsentService,notifier=MockNotifier,watermarker=MockWatermarker,extractEncrypter=MockExtractEncrypter,decisionMetrics=MockDecisionMetrics,workerPool=MockWorkerPool,collectQueue=MockCollectQueue,ticketWatcher=MockTicketWatcher,didcommReceiver=MockDIDCommReceiver,didcommInviter=MockDIDCommInviter,didcommIssuer=MockDIDCommIssuer,oidc4vpVerifier=MockOIDC4VPVerifier,features=MockFeatures,auditLog=MockAuditLog
 
 import (
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"math"
 	"net/http"
-	"strings"
+	"strconv"
+	"sync"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/hyperledger/aries-framework-go/pkg/common/log"
-	"github.com/hyperledger/aries-framework-go/spi/storage"
-
+	"github.com/hyperledger/aries-framework-go/pkg/doc/presexch"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
+
+	"github.com/trustbloc/ace/pkg/feature"
+	"github.com/trustbloc/ace/pkg/gatekeeper/audit"
+	"github.com/trustbloc/ace/pkg/gatekeeper/consent"
+	"github.com/trustbloc/ace/pkg/gatekeeper/delegation"
+	"github.com/trustbloc/ace/pkg/gatekeeper/didcomm"
+	"github.com/trustbloc/ace/pkg/gatekeeper/notify"
+	"github.com/trustbloc/ace/pkg/gatekeeper/oidc4vp"
 	"github.com/trustbloc/ace/pkg/gatekeeper/policy"
 	"github.com/trustbloc/ace/pkg/gatekeeper/protect"
+	"github.com/trustbloc/ace/pkg/gatekeeper/release"
 	"github.com/trustbloc/ace/pkg/gatekeeper/release/ticket"
+	"github.com/trustbloc/ace/pkg/gatekeeper/subscription"
+	"github.com/trustbloc/ace/pkg/gatekeeper/watermark"
 	"github.com/trustbloc/ace/pkg/restapi/handler"
 	"github.com/trustbloc/ace/pkg/restapi/model"
+	"github.com/trustbloc/ace/pkg/restapi/mw/errorreport"
+	"github.com/trustbloc/ace/pkg/restapi/mw/requestid"
 )
 
 const (
 	policyIDVarName      = "policy_id"
+	didVarName           = "did"
 	ticketIDVarName      = "ticket_id"
+	subscriptionVarName  = "subscription_id"
+	issuanceVarName      = "issuance_id"
 	baseV1Path           = "/v1"
 	protectEndpoint      = baseV1Path + "/protect"
-	policyEndpoint       = baseV1Path + "/policy/{" + policyIDVarName + "}"
+	rotateEndpoint       = protectEndpoint + "/rotate"
+	protectBatchEndpoint = protectEndpoint + "/batch"
+	repolicyEndpoint     = protectEndpoint + "/{" + didVarName + "}/repolicy"
+	anchoringEndpoint    = protectEndpoint + "/{" + didVarName + "}/anchoring"
+	policiesEndpoint     = baseV1Path + "/policy"
+	policyEndpoint       = policiesEndpoint + "/{" + policyIDVarName + "}"
+	evaluateEndpoint     = policiesEndpoint + "/evaluate"
+	delegationEndpoint   = policyEndpoint + "/delegation"
 	releaseEndpoint      = baseV1Path + "/release"
+	ticketEndpoint       = releaseEndpoint + "/{" + ticketIDVarName + "}"
 	authorizeEndpoint    = releaseEndpoint + "/{" + ticketIDVarName + "}/authorize"
 	ticketStatusEndpoint = releaseEndpoint + "/{" + ticketIDVarName + "}/status"
 	collectEndpoint      = releaseEndpoint + "/{" + ticketIDVarName + "}/collect"
+	rejectEndpoint       = releaseEndpoint + "/{" + ticketIDVarName + "}/reject"
+	historyEndpoint      = releaseEndpoint + "/{" + ticketIDVarName + "}/history"
+	ticketEventsEndpoint = releaseEndpoint + "/{" + ticketIDVarName + "}/events"
+	breakGlassEndpoint   = baseV1Path + "/breakglass"
+	subscribeEndpoint    = baseV1Path + "/subscriptions"
+	subscriptionEndpoint = subscribeEndpoint + "/{" + subscriptionVarName + "}"
 	extractEndpoint      = baseV1Path + "/extract"
+	extractBatchEndpoint = extractEndpoint + "/batch"
+	purgeEndpoint        = baseV1Path + "/purge"
+	dsarEndpoint         = baseV1Path + "/dsar"
+	linkEndpoint         = dsarEndpoint + "/link"
+	traceEndpoint        = baseV1Path + "/trace"
+	graphQLEndpoint      = baseV1Path + "/graphql"
+	didcommEndpoint      = baseV1Path + "/didcomm"
+	invitationsEndpoint  = didcommEndpoint + "/invitations"
+	connectionsEndpoint  = didcommEndpoint + "/connections"
+	issuancesEndpoint    = didcommEndpoint + "/issuances"
+	approveEndpoint      = issuancesEndpoint + "/{" + issuanceVarName + "}/approve"
+	oidc4vpEndpoint      = baseV1Path + "/oidc4vp"
+	auditEndpoint        = baseV1Path + "/audit"
+	auditEntriesEndpoint = auditEndpoint + "/entries"
+	checkpointsEndpoint  = auditEndpoint + "/checkpoints"
+	auditVerifyEndpoint  = auditEndpoint + "/verify"
+
+	// query parameters of the ticket listing
+	requestorParam = "requestor"
+	statusParam    = "status"
+	policyIDParam  = "policy_id"
+	fromParam      = "from"
+	toParam        = "to"
+	sortParam      = "sort"
+	limitParam     = "limit"
+
+	// maxPageLimit is the maximum number of items of a page of the tickets or of the subscriptions.
+	maxPageLimit = 1000
+
+	// maxExtractBatch is the maximum number of queries extracted by a bulk extract request.
+	maxExtractBatch = 1000
+
+	// maxProtectBatch is the maximum number of targets protected by a bulk protect request.
+	maxProtectBatch = 1000
+
+	// maxEvaluations is the maximum number of policy evaluations of a bulk evaluation request.
+	maxEvaluations = 100
+
+	// pageSize is the number of entries read at a time when listing stored data.
+	pageSize = 100
+
+	// saturatedRetryAfter is the time after which operations rejected by a saturated worker pool can be retried.
+	saturatedRetryAfter = time.Second
+
+	// ticketEventsKeepAlive is the time between two comments sent to keep the event streams of idle tickets open.
+	ticketEventsKeepAlive = 15 * time.Second
+
+	// maxDIDCommMessageSize is the maximum size of the DIDComm messages received.
+	maxDIDCommMessageSize = 64 << 10
+
+	// FailedQueriesTrailer is the trailer of a bulk extraction with the number of queries that failed to be extracted.
+	FailedQueriesTrailer = "X-Failed-Queries"
 )
 
 var logger = log.New("gatekeeper")
@@ -46,17 +132,51 @@ var logger = log.New("gatekeeper")
 type policyService interface {
 	Save(ctx context.Context, doc *policy.Policy) error
 	Check(ctx context.Context, policyID, did string, role policy.Role) error
+	Get(ctx context.Context, policyID string) (*policy.Policy, error)
+	Iterate(ctx context.Context, pageSize int, fn func(p *policy.Policy) error) error
+	Delete(ctx context.Context, policyID string) error
 }
 
 type protectService interface {
-	Protect(ctx context.Context, data, policyID string) (*protect.ProtectedData, error)
+	Protect(ctx context.Context, data, policyID string, opts ...protect.Option) (*protect.ProtectedData, error)
+	ProtectAll(ctx context.Context, policyID string, targets ...string) ([]*protect.ProtectedData, error)
 	Get(ctx context.Context, did string) (*protect.ProtectedData, error)
+	Rotate(ctx context.Context, previous *protect.ProtectedData, target string) (*protect.ProtectedData,
+		*verifiable.Credential, error)
+	Repolicy(ctx context.Context, previous *protect.ProtectedData, target, policyID string,
+		reanonymize bool) (*protect.ProtectedData, error)
+	Iterate(ctx context.Context, policyID string, pageSize int, fn func(data *protect.ProtectedData) error) error
+	Link(ctx context.Context, subject string, data []*protect.ProtectedData, targets []string) ([]*protect.ProtectedData,
+		error)
+	IterateSubject(ctx context.Context, subject string, pageSize int, fn func(data *protect.ProtectedData) error) error
+	Delete(ctx context.Context, dids ...string) error
+	Anchoring(ctx context.Context, did string) (*protect.Anchoring, error)
 }
 
 type releaseService interface {
-	Release(ctx context.Context, did string) (*ticket.Ticket, error)
+	Release(ctx context.Context, did, policyID, requestedBy, justification string) (*ticket.Ticket, error)
 	Get(ctx context.Context, ticketID string) (*ticket.Ticket, error)
-	Authorize(ctx context.Context, ticketID, approverDID string) error
+	GetByQueryID(ctx context.Context, queryID string) (*ticket.Ticket, error)
+	Authorize(ctx context.Context, ticketID, approverDID, comment string) error
+	Reject(ctx context.Context, ticketID, approverDID, comment string) error
+	Iterate(ctx context.Context, status ticket.Status, pageSize int, fn func(t *ticket.Ticket) error) error
+	SetQueryID(ctx context.Context, ticketID, queryID, handler string) error
+	BreakGlass(ctx context.Context, did, policyID, responder, justification string) (*ticket.Ticket, error)
+	IterateDID(ctx context.Context, did string, pageSize int, fn func(t *ticket.Ticket) error) error
+	IterateFilter(ctx context.Context, f *release.Filter, pageSize int, fn func(t *ticket.Ticket) error) error
+}
+
+type quotaService interface {
+	Remaining(ctx context.Context, did string, q *policy.Quota, at time.Time) (int, error)
+	Use(ctx context.Context, did string, q *policy.Quota, at time.Time) (int, error)
+}
+
+type workerPool interface {
+	Do(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type collectQueue interface {
+	Enqueue(payload []byte) (<-chan error, error)
 }
 
 type collectService interface {
@@ -67,36 +187,302 @@ type extractService interface {
 	Extract(ctx context.Context, authToken string) (string, error)
 }
 
+type presentationVerifier interface {
+	Verify(ctx context.Context, definition *presexch.PresentationDefinition, vp []byte, holder string) error
+}
+
+type oidc4vpVerifier interface {
+	Initiate(ctx context.Context, definition *presexch.PresentationDefinition) (*oidc4vp.Interaction, error)
+	Verify(ctx context.Context, definition *presexch.PresentationDefinition, txID, holder string) error
+}
+
+type consentService interface {
+	Issue(ctx context.Context, r *consent.Receipt) (*verifiable.Credential, error)
+	IterateSubject(ctx context.Context, subject string, pageSize int, fn func(r *consent.Receipt) error) error
+	Subject(ctx context.Context, did string) (string, error)
+}
+
+type notifier interface {
+	Notify(ctx context.Context, n *policy.Notification, e *notify.Event) error
+}
+
+type watermarker interface {
+	Embed(ctx context.Context, data string, w *policy.Watermark, r *watermark.Record) (string, error)
+	Trace(ctx context.Context, data string) (*watermark.Record, error)
+}
+
+type extractEncrypter interface {
+	Encrypt(ctx context.Context, recipientDID string, data []byte) (json.RawMessage, error)
+}
+
+type decisionMetrics interface {
+	QuorumFailed(policyID string)
+	Extracted(t *ticket.Ticket, policyID string)
+}
+
+type purgeService interface {
+	Purge(now time.Time)
+}
+
 type subjectResolver interface {
 	Resolve(ctx context.Context) (string, error)
 }
 
+type referenceResolver interface {
+	Reference(ctx context.Context) string
+}
+
+type delegationService interface {
+	Delegate(ctx context.Context, d *delegation.Delegation) error
+	Active(ctx context.Context, policyID, delegate string, at time.Time) ([]*delegation.Delegation, error)
+}
+
+type ticketWatcher interface {
+	Watch(ticketID string) (<-chan *ticket.Ticket, func())
+}
+
+type didcommReceiver interface {
+	Receive(ctx context.Context, signed []byte) (*didcomm.Message, error)
+}
+
+type didcommInviter interface {
+	Invite(ctx context.Context, role, label string) (*didcomm.Invitation, error)
+	Accept(ctx context.Context, msg *didcomm.Message) (*didcomm.Connection, error)
+	Connections(ctx context.Context, did string) ([]*didcomm.Connection, error)
+}
+
+type didcommIssuer interface {
+	Propose(ctx context.Context, msg *didcomm.Message) (*didcomm.Issuance, error)
+	Request(ctx context.Context, msg *didcomm.Message) (*didcomm.Issuance, error)
+	ApproveIssuance(ctx context.Context, id string) (*didcomm.Issuance, error)
+	Issuances(ctx context.Context, status string) ([]*didcomm.Issuance, error)
+}
+
+type subscriptionService interface {
+	Subscribe(ctx context.Context, sub *subscription.Subscription) error
+	List(ctx context.Context, did string) ([]*subscription.Subscription, error)
+	Unsubscribe(ctx context.Context, did, id string) error
+}
+
+type features interface {
+	Enabled(name string) bool
+}
+
+type leader interface {
+	Held() bool
+}
+
+type auditLog interface {
+	Head(ctx context.Context) (*audit.Head, error)
+	Entries(ctx context.Context, after, limit int) ([]*audit.Entry, error)
+	Checkpoints(ctx context.Context) ([]json.RawMessage, error)
+	Verify(ctx context.Context) (*audit.Report, error)
+	Append(ctx context.Context, e *audit.Entry) error
+}
+
 // Operation defines handlers for Gatekeeper operations.
 type Operation struct {
-	SubjectResolver subjectResolver
-	PolicyService   policyService
-	ProtectService  protectService
-	ReleaseService  releaseService
-	CollectService  collectService
-	ExtractService  extractService
+	SubjectResolver      subjectResolver
+	PolicyService        policyService
+	ProtectService       protectService
+	ReleaseService       releaseService
+	CollectService       collectService
+	ExtractService       extractService
+	PresentationVerifier presentationVerifier
+	// OIDC4VPVerifier, if set, lets the handlers present the credentials required by the policies with OIDC4VP, from
+	// their wallets to a verifier, instead of posting the presentations. The OIDC4VP endpoint is not served if it is
+	// not set.
+	OIDC4VPVerifier oidc4vpVerifier
+	// ConsentService, if set, issues the consent receipts of the data protected with policies defining a consent.
+	// The DSAR, link and erasure endpoints are not served if it is not set.
+	ConsentService consentService
+	// Notifier, if set, notifies the subjects of protected data when it is released or extracted, if the policy
+	// defines a notification. Subjects are only known with the ConsentService. The break-glass endpoint is only
+	// served if it is set, as the subjects and the approvers must be notified of break-glass accesses.
+	Notifier notifier
+	// Watermarker, if set, embeds watermarks in extracted data if the policy defines a watermark. The trace endpoint
+	// is not served if it is not set.
+	Watermarker watermarker
+	// ExtractEncrypter, if set, encrypts extracted data to the key agreement keys of the handlers when they request it
+	// or the policy requires it. Data cannot be extracted encrypted if it is not set.
+	ExtractEncrypter extractEncrypter
+	// DecisionMetrics, if set, records the collections refused for lack of approvals and the extractions, by policy.
+	// The other release decisions are recorded by observing the tickets of the ReleaseService.
+	DecisionMetrics decisionMetrics
+	// ReferenceResolver, if set, resolves the reference of the signature or the capability the subject is
+	// authenticated with, recorded in the history of the tickets.
+	ReferenceResolver referenceResolver
+	// DelegationService, if set, lets the approvers of policies delegate their approval authority to other DIDs for a
+	// time window. The delegation endpoint is not served if it is not set.
+	DelegationService delegationService
+	// SubscriptionService, if set, lets the handlers and the approvers subscribe to the events of the tickets they are
+	// involved in. The subscription endpoints are not served if it is not set.
+	SubscriptionService subscriptionService
+	// QuotaService, if set, enforces the extraction quotas of the policies: data whose quota is used up is neither
+	// collected nor extracted.
+	QuotaService quotaService
+	// PurgeService purges deleted data on request. The purge endpoint is not served if it is not set.
+	PurgeService purgeService
+	// ProtectPool, if set, bounds the number of protect operations run at a time. Requests are answered with
+	// 503 Service Unavailable and a Retry-After header once it is saturated.
+	ProtectPool workerPool
+	// CollectQueue, if set, queues the creation of the queries collecting released data, to be processed with
+	// ProcessCollect. Requests are answered with 503 Service Unavailable and a Retry-After header once it is full.
+	CollectQueue collectQueue
+	// StrictJSON, if set, rejects the policy and protect requests with unknown fields, such as misspelled ones,
+	// instead of ignoring the fields.
+	StrictJSON bool
+	// RequestLimits bound the size, nesting and string length of the JSON request bodies, which are checked as they
+	// are decoded. Requests exceeding them are answered with 413 Request Entity Too Large.
+	RequestLimits model.Limits
+	// TicketWatcher, if set, lets the handlers stream the updates of the tickets as server-sent events. The events
+	// endpoint is not served if it is not set.
+	TicketWatcher ticketWatcher
+	// DIDCommReceiver, if set, lets the approvers authorize and reject tickets with signed DIDComm messages, on behalf
+	// of their verified senders. The DIDComm endpoint is not served if it is not set.
+	DIDCommReceiver didcommReceiver
+	// DIDCommInviter, if set, creates out-of-band invitations of new handlers and approvers, accepted with connect
+	// messages received with the DIDCommReceiver. The invitation and connection endpoints are not served if it is not
+	// set.
+	DIDCommInviter didcommInviter
+	// DIDCommIssuer, if set, issues authorized handler credentials to the handlers with the WACI issuance protocol,
+	// once an admin approves the issuances they propose. The issuance endpoints are not served if it is not set.
+	DIDCommIssuer didcommIssuer
+	// Region is the residency region the gatekeeper runs in. Data protected in another region is not extracted.
+	Region string
+	// Features, if set, are the feature flags of the experimental capabilities, which can be toggled while the
+	// endpoints are served. The DIDComm endpoints are answered with 404 Not Found while feature.DIDComm is disabled.
+	Features features
+	// AuditLog, if set, serves the tamper-evident log of the actions taken on tickets, its signed checkpoints and its
+	// verification. The audit endpoints are not served if it is not set.
+	AuditLog auditLog
+	// Leader, if set, is the lease of the instance updating the tickets, if the store doesn't write them conditionally.
+	// The collections and the break-glass accesses are answered with 503 Service Unavailable on the other instances,
+	// before any query is created.
+	Leader leader
+
+	// policyLocks are the locks serializing the saves of each policy, keyed by policy ID.
+	policyLocks sync.Map
 }
 
 // GetRESTHandlers get all controller API handler available for this service.
 func (o *Operation) GetRESTHandlers() []handler.Handler {
-	return []handler.Handler{
+	graphQL := o.graphQLHandler(o.graphQLSchema())
+
+	handlers := []handler.Handler{
 		handler.NewHTTPHandler(policyEndpoint, http.MethodPut, o.createPolicyHandler, handler.WithAuth(handler.AuthToken)),
+		handler.NewHTTPHandler(policyEndpoint, http.MethodGet, o.getPolicyHandler, handler.WithAuth(handler.AuthToken)),
+		handler.NewHTTPHandler(policyEndpoint, http.MethodDelete, o.deletePolicyHandler, handler.WithAuth(handler.AuthToken)),
+		handler.NewHTTPHandler(policiesEndpoint, http.MethodGet, o.listPoliciesHandler, handler.WithAuth(handler.AuthToken)),
+		handler.NewHTTPHandler(evaluateEndpoint, http.MethodPost, o.evaluateHandler, handler.WithAuth(handler.AuthToken)),
 		handler.NewHTTPHandler(protectEndpoint, http.MethodPost, o.protectHandler, handler.WithAuth(handler.AuthHTTPSig)),
+		handler.NewHTTPHandler(protectBatchEndpoint, http.MethodPost, o.protectBatchHandler, handler.WithAuth(handler.AuthHTTPSig)), //nolint:lll
+		handler.NewHTTPHandler(rotateEndpoint, http.MethodPost, o.rotateHandler, handler.WithAuth(handler.AuthHTTPSig)),
+		handler.NewHTTPHandler(repolicyEndpoint, http.MethodPost, o.repolicyHandler, handler.WithAuth(handler.AuthHTTPSig)),
+		handler.NewHTTPHandler(anchoringEndpoint, http.MethodGet, o.anchoringHandler, handler.WithAuth(handler.AuthHTTPSig)),
 		handler.NewHTTPHandler(releaseEndpoint, http.MethodPost, o.releaseHandler, handler.WithAuth(handler.AuthHTTPSig)),
+		handler.NewHTTPHandler(releaseEndpoint, http.MethodGet, o.listTicketsHandler, handler.WithAuth(handler.AuthToken)),
 		handler.NewHTTPHandler(authorizeEndpoint, http.MethodPost, o.authorizeHandler, handler.WithAuth(handler.AuthHTTPSig)),
+		handler.NewHTTPHandler(rejectEndpoint, http.MethodPost, o.rejectHandler, handler.WithAuth(handler.AuthHTTPSig)),
 		handler.NewHTTPHandler(ticketStatusEndpoint, http.MethodGet, o.ticketStatusHandler, handler.WithAuth(handler.AuthHTTPSig)), //nolint:lll
+		handler.NewHTTPHandler(ticketEndpoint, http.MethodGet, o.getTicketHandler, handler.WithAuth(handler.AuthToken)),
+		handler.NewHTTPHandler(historyEndpoint, http.MethodGet, o.ticketHistoryHandler, handler.WithAuth(handler.AuthToken)),
 		handler.NewHTTPHandler(collectEndpoint, http.MethodPost, o.collectHandler, handler.WithAuth(handler.AuthHTTPSig)),
 		handler.NewHTTPHandler(extractEndpoint, http.MethodPost, o.extractHandler),
+		handler.NewHTTPHandler(extractBatchEndpoint, http.MethodPost, o.extractBatchHandler),
+		handler.NewHTTPHandler(graphQLEndpoint, http.MethodGet, graphQL, handler.WithAuth(handler.AuthToken)),
+		handler.NewHTTPHandler(graphQLEndpoint, http.MethodPost, graphQL, handler.WithAuth(handler.AuthToken)),
+	}
+
+	if o.PurgeService != nil {
+		handlers = append(handlers,
+			handler.NewHTTPHandler(purgeEndpoint, http.MethodPost, o.purgeHandler, handler.WithAuth(handler.AuthToken)))
+	}
+
+	if o.ConsentService != nil {
+		handlers = append(handlers,
+			handler.NewHTTPHandler(dsarEndpoint, http.MethodGet, o.dsarHandler, handler.WithAuth(handler.AuthHTTPSig)),
+			handler.NewHTTPHandler(dsarEndpoint, http.MethodDelete, o.eraseHandler, handler.WithAuth(handler.AuthHTTPSig)),
+			handler.NewHTTPHandler(linkEndpoint, http.MethodPost, o.linkHandler, handler.WithAuth(handler.AuthHTTPSig)))
+	}
+
+	if o.DelegationService != nil {
+		handlers = append(handlers,
+			handler.NewHTTPHandler(delegationEndpoint, http.MethodPost, o.delegateHandler, handler.WithAuth(handler.AuthHTTPSig))) //nolint:lll
+	}
+
+	if o.SubscriptionService != nil {
+		handlers = append(handlers,
+			handler.NewHTTPHandler(subscribeEndpoint, http.MethodPost, o.subscribeHandler, handler.WithAuth(handler.AuthHTTPSig)),        //nolint:lll
+			handler.NewHTTPHandler(subscribeEndpoint, http.MethodGet, o.listSubscriptionsHandler, handler.WithAuth(handler.AuthHTTPSig)), //nolint:lll
+			handler.NewHTTPHandler(subscriptionEndpoint, http.MethodDelete, o.unsubscribeHandler, handler.WithAuth(handler.AuthHTTPSig))) //nolint:lll
+	}
+
+	if o.TicketWatcher != nil {
+		handlers = append(handlers,
+			handler.NewHTTPHandler(ticketEventsEndpoint, http.MethodGet, o.ticketEventsHandler, handler.WithAuth(handler.AuthHTTPSig))) //nolint:lll
+	}
+
+	if o.OIDC4VPVerifier != nil {
+		handlers = append(handlers,
+			handler.NewHTTPHandler(oidc4vpEndpoint, http.MethodPost, o.oidc4vpHandler, handler.WithAuth(handler.AuthHTTPSig)))
+	}
+
+	if o.DIDCommReceiver != nil {
+		handlers = append(handlers,
+			handler.NewHTTPHandler(didcommEndpoint, http.MethodPost, o.gated(feature.DIDComm, o.didcommHandler)))
+	}
+
+	if o.DIDCommReceiver != nil && o.DIDCommInviter != nil {
+		handlers = append(handlers,
+			handler.NewHTTPHandler(invitationsEndpoint, http.MethodPost, o.gated(feature.DIDComm, o.inviteHandler), handler.WithAuth(handler.AuthToken)),     //nolint:lll
+			handler.NewHTTPHandler(connectionsEndpoint, http.MethodGet, o.gated(feature.DIDComm, o.connectionsHandler), handler.WithAuth(handler.AuthToken))) //nolint:lll
+	}
+
+	if o.DIDCommReceiver != nil && o.DIDCommIssuer != nil {
+		handlers = append(handlers,
+			handler.NewHTTPHandler(issuancesEndpoint, http.MethodGet, o.gated(feature.DIDComm, o.issuancesHandler), handler.WithAuth(handler.AuthToken)),      //nolint:lll
+			handler.NewHTTPHandler(approveEndpoint, http.MethodPost, o.gated(feature.DIDComm, o.approveIssuanceHandler), handler.WithAuth(handler.AuthToken))) //nolint:lll
+	}
+
+	if o.Notifier != nil {
+		handlers = append(handlers,
+			handler.NewHTTPHandler(breakGlassEndpoint, http.MethodPost, o.breakGlassHandler, handler.WithAuth(handler.AuthHTTPSig))) //nolint:lll
+	}
+
+	if o.Watermarker != nil {
+		handlers = append(handlers,
+			handler.NewHTTPHandler(traceEndpoint, http.MethodPost, o.traceHandler, handler.WithAuth(handler.AuthToken)))
+	}
+
+	if o.AuditLog != nil {
+		handlers = append(handlers,
+			handler.NewHTTPHandler(auditEntriesEndpoint, http.MethodGet, o.auditEntriesHandler, handler.WithAuth(handler.AuthToken)), //nolint:lll
+			handler.NewHTTPHandler(checkpointsEndpoint, http.MethodGet, o.checkpointsHandler, handler.WithAuth(handler.AuthToken)),   //nolint:lll
+			handler.NewHTTPHandler(auditVerifyEndpoint, http.MethodGet, o.auditVerifyHandler, handler.WithAuth(handler.AuthToken)))   //nolint:lll
+	}
+
+	return handlers
+}
+
+// gated serves the handler while the feature flag is enabled. The endpoint is not found while it is disabled, as if
+// it was not served.
+func (o *Operation) gated(flag string, h http.HandlerFunc) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		if o.Features != nil && !o.Features.Enabled(flag) {
+			http.NotFound(rw, r)
+
+			return
+		}
+
+		h(rw, r)
 	}
 }
 
 // createPolicyHandler swagger:route PUT /v1/policy/{policy_id} gatekeeper createPolicyReq
 //
-// Creates policy configuration for storing and releasing protected data.
+// Creates policy configuration for storing and releasing protected data. Existing policies are only replaced if the
+// If-Match header matches their ETag.
 //
 // Authorization: Bearer token
 //
@@ -106,317 +492,1285 @@ func (o *Operation) GetRESTHandlers() []handler.Handler {
 func (o *Operation) createPolicyHandler(rw http.ResponseWriter, r *http.Request) {
 	var p policy.Policy
 
-	err := json.NewDecoder(r.Body).Decode(&p)
+	err := o.decodeRequest(r, &p)
 	if err != nil {
-		respondError(rw, http.StatusBadRequest, err)
+		respondError(rw, decodeErrorStatus(err), err)
 
 		return
 	}
 
-	p.ID = strings.ToLower(mux.Vars(r)[policyIDVarName])
-
-	err = o.PolicyService.Save(r.Context(), &p)
+	etag, err := o.SavePolicy(r.Context(), mux.Vars(r)[policyIDVarName], r.Header.Get("If-Match"), &p)
 	if err != nil {
-		respondError(rw, http.StatusInternalServerError, fmt.Errorf("save policy: %w", err))
+		respondError(rw, ErrorStatus(err), err)
 
 		return
 	}
 
+	rw.Header().Set("ETag", etag)
 	respond(rw, http.StatusOK, nil)
 }
 
-// protectHandler swagger:route POST /v1/protect gatekeeper protectReq
+// getPolicyHandler swagger:route GET /v1/policy/{policy_id} gatekeeper getPolicyReq
 //
-// Converts a social media handle (or other sensitive string data) into a DID.
+// Gets policy configuration.
 //
-// Authorization: HTTP Signatures (headers="(request-target) date digest")
+// Authorization: Bearer token
 //
 // Responses:
-//     200: protectResp
+//     200: getPolicyResp
+//     304: notModifiedResp
 //     default: errorResp
-func (o *Operation) protectHandler(rw http.ResponseWriter, r *http.Request) {
-	var req ProtectRequest
-
-	err := json.NewDecoder(r.Body).Decode(&req)
+func (o *Operation) getPolicyHandler(rw http.ResponseWriter, r *http.Request) {
+	p, err := o.GetPolicy(r.Context(), mux.Vars(r)[policyIDVarName])
 	if err != nil {
-		respondError(rw, http.StatusBadRequest, err)
+		respondError(rw, ErrorStatus(err), err)
 
 		return
 	}
 
-	if _, err = o.checkPolicy(r.Context(), req.Policy, policy.Collector); err != nil {
-		respondError(rw, err.(*policyError).status, err) //nolint:errorlint,forcetypeassert
+	respondCacheable(rw, r, p, time.Time{})
+}
+
+// deletePolicyHandler swagger:route DELETE /v1/policy/{policy_id} gatekeeper deletePolicyReq
+//
+// Deletes policy configuration. The policy can be restored until it is purged.
+//
+// Authorization: Bearer token
+//
+// Responses:
+//     200: deletePolicyResp
+//     default: errorResp
+func (o *Operation) deletePolicyHandler(rw http.ResponseWriter, r *http.Request) {
+	if err := o.DeletePolicy(r.Context(), mux.Vars(r)[policyIDVarName]); err != nil {
+		respondError(rw, ErrorStatus(err), err)
 
 		return
 	}
 
-	protectedData, err := o.ProtectService.Protect(r.Context(), req.Target, req.Policy)
+	respond(rw, http.StatusOK, nil)
+}
+
+// listPoliciesHandler swagger:route GET /v1/policy gatekeeper listPoliciesReq
+//
+// Lists policy configurations.
+//
+// Authorization: Bearer token
+//
+// Responses:
+//     200: listPoliciesResp
+//     304: notModifiedResp
+//     default: errorResp
+func (o *Operation) listPoliciesHandler(rw http.ResponseWriter, r *http.Request) {
+	policies := []*policy.Policy{}
+
+	err := o.ListPolicies(r.Context(), func(p *policy.Policy) error {
+		policies = append(policies, p)
+
+		return nil
+	})
 	if err != nil {
-		respondError(rw, http.StatusInternalServerError, err)
+		respondError(rw, ErrorStatus(err), err)
 
 		return
 	}
 
-	respond(rw, http.StatusOK, &ProtectResponse{DID: protectedData.DID})
+	respondCacheable(rw, r, &ListPoliciesResponse{Policies: policies}, time.Time{})
 }
 
-// releaseHandler swagger:route POST /v1/release gatekeeper releaseReq
+// evaluateHandler swagger:route POST /v1/policy/evaluate gatekeeper evaluatePoliciesReq
 //
-// Creates a new release transaction (ticket) on a DID.
+// Tells whether an actor may run operations under policies, with a result per policy and operation.
 //
-// Authorization: HTTP Signatures (headers="(request-target) date")
+// Authorization: Bearer token
 //
 // Responses:
-//     200: releaseResp
+//     200: evaluatePoliciesResp
 //     default: errorResp
-func (o *Operation) releaseHandler(rw http.ResponseWriter, r *http.Request) {
-	var req ReleaseRequest
+func (o *Operation) evaluateHandler(rw http.ResponseWriter, r *http.Request) {
+	var req EvaluateRequest
 
-	err := json.NewDecoder(r.Body).Decode(&req)
-	if err != nil {
-		respondError(rw, http.StatusBadRequest, err)
+	if err := o.decode(r, &req); err != nil {
+		respondError(rw, decodeErrorStatus(err), err)
 
 		return
 	}
 
-	protectedData, err := o.ProtectService.Get(r.Context(), req.DID)
+	resp, err := o.EvaluatePolicies(r.Context(), &req)
 	if err != nil {
-		respondError(rw, http.StatusInternalServerError, err)
+		respondError(rw, ErrorStatus(err), err)
 
 		return
 	}
 
-	if _, err = o.checkPolicy(r.Context(), protectedData.PolicyID, policy.Handler); err != nil {
-		respondError(rw, err.(*policyError).status, err) //nolint:errorlint,forcetypeassert
+	respond(rw, http.StatusOK, resp)
+}
+
+// protectHandler swagger:route POST /v1/protect gatekeeper protectReq
+//
+// Converts a social media handle (or other sensitive string data) into a DID.
+//
+// Authorization: HTTP Signatures (headers="(request-target) date digest")
+//
+// Responses:
+//     200: protectResp
+//     default: errorResp
+func (o *Operation) protectHandler(rw http.ResponseWriter, r *http.Request) {
+	var req ProtectRequest
+
+	err := o.decodeRequest(r, &req)
+	if err != nil {
+		respondError(rw, decodeErrorStatus(err), err)
 
 		return
 	}
 
-	t, err := o.ReleaseService.Release(r.Context(), req.DID)
+	resp, err := o.Protect(r.Context(), &req)
 	if err != nil {
-		respondError(rw, http.StatusInternalServerError, err)
+		respondError(rw, ErrorStatus(err), err)
 
 		return
 	}
 
-	respond(rw, http.StatusOK, &ReleaseResponse{TicketID: t.ID})
+	respond(rw, http.StatusOK, resp)
 }
 
-// authorizeHandler swagger:route POST /v1/release/{ticket_id}/authorize gatekeeper authorizeReq
+// protectBatchHandler swagger:route POST /v1/protect/batch gatekeeper protectBatchReq
 //
-// Authorizes release transaction (ticket).
+// Converts several sensitive string data into DIDs under the same policy, saved in one batch.
 //
-// Authorization: HTTP Signatures (headers="(request-target) date")
+// Authorization: HTTP Signatures (headers="(request-target) date digest")
 //
 // Responses:
-//     200: authorizeResp
+//     200: protectBatchResp
 //     default: errorResp
-func (o *Operation) authorizeHandler(rw http.ResponseWriter, r *http.Request) {
-	ticketID := mux.Vars(r)[ticketIDVarName]
+func (o *Operation) protectBatchHandler(rw http.ResponseWriter, r *http.Request) {
+	var req ProtectBatchRequest
 
-	t, err := o.ReleaseService.Get(r.Context(), ticketID)
+	err := o.decodeRequest(r, &req)
 	if err != nil {
-		if errors.Is(err, storage.ErrDataNotFound) {
-			respondError(rw, http.StatusBadRequest, err)
-		}
-
-		respondError(rw, http.StatusInternalServerError, err)
+		respondError(rw, decodeErrorStatus(err), err)
 
 		return
 	}
 
-	protectedData, err := o.ProtectService.Get(r.Context(), t.DID)
+	resp, err := o.ProtectBatch(r.Context(), &req)
 	if err != nil {
-		respondError(rw, http.StatusInternalServerError, err)
+		respondError(rw, ErrorStatus(err), err)
 
 		return
 	}
 
-	var sub string
+	respond(rw, http.StatusOK, resp)
+}
+
+// rotateHandler swagger:route POST /v1/protect/rotate gatekeeper rotateReq
+//
+// Replaces the DID of protected data with a new one, issuing a credential linking the previous DID to the new one.
+//
+// Authorization: HTTP Signatures (headers="(request-target) date digest")
+//
+// Responses:
+//     200: rotateResp
+//     default: errorResp
+func (o *Operation) rotateHandler(rw http.ResponseWriter, r *http.Request) {
+	var req RotateRequest
 
-	if sub, err = o.checkPolicy(r.Context(), protectedData.PolicyID, policy.Approver); err != nil {
-		respondError(rw, err.(*policyError).status, err) //nolint:errorlint,forcetypeassert
+	err := o.decodeRequest(r, &req)
+	if err != nil {
+		respondError(rw, decodeErrorStatus(err), err)
 
 		return
 	}
 
-	if err = o.ReleaseService.Authorize(r.Context(), ticketID, sub); err != nil {
-		respondError(rw, http.StatusInternalServerError, err)
+	resp, err := o.Rotate(r.Context(), &req)
+	if err != nil {
+		respondError(rw, ErrorStatus(err), err)
 
 		return
 	}
 
-	respond(rw, http.StatusOK, nil)
+	respond(rw, http.StatusOK, resp)
 }
 
-// ticketStatusHandler swagger:route GET /v1/release/{ticket_id}/status gatekeeper ticketStatusReq
+// repolicyHandler swagger:route POST /v1/protect/{did}/repolicy gatekeeper repolicyReq
 //
-// Gets the status of the ticket.
+// Moves protected data under another policy, optionally with a new DID.
 //
-// Authorization: HTTP Signatures (headers="(request-target) date")
+// Authorization: HTTP Signatures (headers="(request-target) date digest")
 //
 // Responses:
-//     200: ticketStatusResp
+//     200: repolicyResp
 //     default: errorResp
-func (o *Operation) ticketStatusHandler(rw http.ResponseWriter, r *http.Request) {
-	ticketID := mux.Vars(r)[ticketIDVarName]
+func (o *Operation) repolicyHandler(rw http.ResponseWriter, r *http.Request) {
+	var req RepolicyRequest
 
-	t, err := o.ReleaseService.Get(r.Context(), ticketID)
+	err := o.decodeRequest(r, &req)
 	if err != nil {
-		if errors.Is(err, storage.ErrDataNotFound) {
-			respondError(rw, http.StatusBadRequest, err)
-		}
-
-		respondError(rw, http.StatusInternalServerError, err)
+		respondError(rw, decodeErrorStatus(err), err)
 
 		return
 	}
 
-	protectedData, err := o.ProtectService.Get(r.Context(), t.DID)
+	resp, err := o.Repolicy(r.Context(), mux.Vars(r)[didVarName], &req)
 	if err != nil {
-		respondError(rw, http.StatusInternalServerError, err)
+		respondError(rw, ErrorStatus(err), err)
 
 		return
 	}
 
-	if _, err = o.checkPolicy(r.Context(), protectedData.PolicyID, policy.Handler); err != nil {
-		respondError(rw, err.(*policyError).status, err) //nolint:errorlint,forcetypeassert
+	respond(rw, http.StatusOK, resp)
+}
+
+// anchoringHandler swagger:route GET /v1/protect/{did}/anchoring gatekeeper anchoringReq
+//
+// Gets the status of the anchoring of the DID of protected data on a ledger.
+//
+// Authorization: HTTP Signatures (headers="(request-target) date")
+//
+// Responses:
+//     200: anchoringResp
+//     304: notModifiedResp
+//     default: errorResp
+func (o *Operation) anchoringHandler(rw http.ResponseWriter, r *http.Request) {
+	resp, err := o.Anchoring(r.Context(), mux.Vars(r)[didVarName])
+	if err != nil {
+		respondError(rw, ErrorStatus(err), err)
 
 		return
 	}
 
-	respond(rw, http.StatusOK, &TicketStatusResponse{Status: t.Status.String()})
+	var updated time.Time
+
+	if resp.Updated != nil {
+		updated = *resp.Updated
+	}
+
+	respondCacheable(rw, r, resp, updated)
 }
 
-// collectHandler swagger:route POST /v1/release/{ticket_id}/collect gatekeeper collectReq
+// releaseHandler swagger:route POST /v1/release gatekeeper releaseReq
 //
-// Generates extract query for the ticket that has completed authorization process.
+// Creates a new release transaction (ticket) on a DID.
 //
 // Authorization: HTTP Signatures (headers="(request-target) date")
 //
 // Responses:
-//     200: collectResp
+//     200: releaseResp
 //     default: errorResp
-func (o *Operation) collectHandler(rw http.ResponseWriter, r *http.Request) {
-	ticketID := strings.ToLower(mux.Vars(r)[ticketIDVarName])
+func (o *Operation) releaseHandler(rw http.ResponseWriter, r *http.Request) {
+	var req ReleaseRequest
 
-	t, err := o.ReleaseService.Get(r.Context(), ticketID)
+	err := o.decode(r, &req)
 	if err != nil {
-		respondError(rw, http.StatusBadRequest, err)
+		respondError(rw, decodeErrorStatus(err), err)
 
 		return
 	}
 
-	protectedData, err := o.ProtectService.Get(r.Context(), t.DID)
+	resp, err := o.Release(r.Context(), &req)
 	if err != nil {
-		respondError(rw, http.StatusInternalServerError, err)
+		respondError(rw, ErrorStatus(err), err)
 
 		return
 	}
 
-	if t.Status != ticket.ReadyToCollect {
-		respondError(rw, http.StatusUnauthorized, errors.New("not authorized to access ticket"))
+	respond(rw, http.StatusOK, resp)
+}
 
-		return
-	}
+// oidc4vpHandler swagger:route POST /v1/oidc4vp gatekeeper oidc4vpReq
+//
+// Initiates an OIDC4VP interaction with the verifier, requesting a presentation satisfying the presentation
+// definition of the policy of the protected data from the wallet of the handler. The ID of the interaction is set in
+// the release request once the presentation is made.
+//
+// Authorization: HTTP Signatures (headers="(request-target) date")
+//
+// Responses:
+//     200: oidc4vpResp
+//     default: errorResp
+func (o *Operation) oidc4vpHandler(rw http.ResponseWriter, r *http.Request) {
+	var req OIDC4VPRequest
 
-	subDID, err := o.checkPolicy(r.Context(), protectedData.PolicyID, policy.Handler)
-	if err != nil {
-		respondError(rw, err.(*policyError).status, err) //nolint:errorlint,forcetypeassert
+	if err := o.decode(r, &req); err != nil {
+		respondError(rw, decodeErrorStatus(err), err)
 
 		return
 	}
 
-	queryID, err := o.CollectService.Collect(r.Context(), protectedData, subDID)
+	interaction, err := o.InitiateOIDC4VP(r.Context(), &req)
 	if err != nil {
-		respondError(rw, http.StatusInternalServerError, fmt.Errorf("fail to collect data: %w", err))
+		respondError(rw, ErrorStatus(err), err)
 
 		return
 	}
 
-	respond(rw, http.StatusOK, &CollectResponse{QueryID: queryID})
+	respond(rw, http.StatusOK, interaction)
 }
 
-// extractHandler swagger:route POST /v1/extract gatekeeper extractReq
+// authorizeHandler swagger:route POST /v1/release/{ticket_id}/authorize gatekeeper authorizeReq
 //
-// Extracts protected data.
+// Authorizes release transaction (ticket), with an optional comment of the approver.
+//
+// Authorization: HTTP Signatures (headers="(request-target) date")
 //
 // Responses:
-//     200: extractResp
+//     200: authorizeResp
 //     default: errorResp
-func (o *Operation) extractHandler(rw http.ResponseWriter, r *http.Request) {
-	var req ExtractRequest
-
-	err := json.NewDecoder(r.Body).Decode(&req)
+func (o *Operation) authorizeHandler(rw http.ResponseWriter, r *http.Request) {
+	req, err := o.decodeDecision(r)
 	if err != nil {
-		respondError(rw, http.StatusBadRequest, err)
+		respondError(rw, decodeErrorStatus(err), err)
 
 		return
 	}
 
-	target, err := o.ExtractService.Extract(r.Context(), req.QueryID)
-	if err != nil {
-		respondError(rw, http.StatusInternalServerError, fmt.Errorf("fail to resolve extract data: %w", err))
+	if err = o.Authorize(r.Context(), mux.Vars(r)[ticketIDVarName], req); err != nil {
+		respondError(rw, ErrorStatus(err), err)
 
 		return
 	}
 
-	respond(rw, http.StatusOK, &ExtractResponse{Target: target})
+	respond(rw, http.StatusOK, nil)
 }
 
-type policyError struct {
-	status int
-	err    error
-}
+// rejectHandler swagger:route POST /v1/release/{ticket_id}/reject gatekeeper rejectReq
+//
+// Rejects release transaction (ticket), with an optional comment of the approver.
+//
+// Authorization: HTTP Signatures (headers="(request-target) date")
+//
+// Responses:
+//     200: rejectResp
+//     default: errorResp
+func (o *Operation) rejectHandler(rw http.ResponseWriter, r *http.Request) {
+	req, err := o.decodeDecision(r)
+	if err != nil {
+		respondError(rw, decodeErrorStatus(err), err)
+
+		return
+	}
+
+	if err = o.Reject(r.Context(), mux.Vars(r)[ticketIDVarName], req); err != nil {
+		respondError(rw, ErrorStatus(err), err)
 
-func (e *policyError) Error() string {
-	if e.err != nil {
-		return e.err.Error()
+		return
 	}
 
-	return ""
+	respond(rw, http.StatusOK, nil)
 }
 
-func (o *Operation) checkPolicy(ctx context.Context, policyID string, role policy.Role) (string, error) {
-	sub, err := o.SubjectResolver.Resolve(ctx)
+// didcommHandler swagger:route POST /v1/didcomm gatekeeper didcommReq
+//
+// Receives a DIDComm v2 message of an approver authorizing or rejecting a ticket, signed with an authentication key
+// of the approver in the JWS compact serialization. The body of the message is the decision of the approver, with
+// the ID of the ticket. Connect messages accepting an invitation are received too, if invitations are enabled, and
+// the propose-credential and request-credential messages of the handlers, if issuances are enabled.
+//
+// Consumes:
+// - application/didcomm-signed+json
+//
+// Responses:
+//     202: didcommResp
+//     default: errorResp
+func (o *Operation) didcommHandler(rw http.ResponseWriter, r *http.Request) {
+	signed, err := io.ReadAll(http.MaxBytesReader(rw, r.Body, maxDIDCommMessageSize))
 	if err != nil {
-		return "", &policyError{status: http.StatusUnauthorized, err: err}
+		respondError(rw, http.StatusBadRequest, fmt.Errorf("read message: %w", err))
+
+		return
 	}
 
-	err = o.PolicyService.Check(ctx, policyID, sub, role)
+	msg, err := o.DIDCommReceiver.Receive(r.Context(), signed)
 	if err != nil {
-		if errors.Is(err, policy.ErrNotAllowed) {
-			return "", &policyError{status: http.StatusUnauthorized, err: err}
+		status := http.StatusBadRequest
+		if errors.Is(err, didcomm.ErrInvalidSignature) {
+			status = http.StatusUnauthorized
 		}
 
-		return "", &policyError{status: http.StatusInternalServerError, err: err}
+		respondError(rw, status, err)
+
+		return
 	}
 
-	return sub, nil
-}
+	decide := o.Authorize
 
-func respond(w http.ResponseWriter, statusCode int, payload interface{}) { //nolint:unparam
-	w.Header().Add("Content-Type", "application/json")
+	switch {
+	case msg.Type == didcomm.AuthorizeType:
+	case msg.Type == didcomm.RejectType:
+		decide = o.Reject
+	case msg.Type == didcomm.ConnectType && o.DIDCommInviter != nil:
+		o.connect(rw, r, msg)
 
-	w.WriteHeader(statusCode)
+		return
+	case msg.Type == didcomm.ProposeCredentialType && o.DIDCommIssuer != nil:
+		o.issue(rw, r, msg, o.DIDCommIssuer.Propose)
 
-	if payload != nil {
-		if err := json.NewEncoder(w).Encode(payload); err != nil {
-			logger.Errorf("Failed to write response: %s", err.Error())
-		}
+		return
+	case msg.Type == didcomm.RequestCredentialType && o.DIDCommIssuer != nil:
+		o.issue(rw, r, msg, o.DIDCommIssuer.Request)
+
+		return
+	default:
+		respondError(rw, http.StatusBadRequest, fmt.Errorf("unsupported message type %s", msg.Type))
+
+		return
 	}
-}
 
-func respondError(w http.ResponseWriter, statusCode int, err error) {
-	w.Header().Add("Content-Type", "application/json")
+	var d didcomm.Decision
+
+	if err = json.Unmarshal(msg.Body, &d); err != nil {
+		respondError(rw, http.StatusBadRequest, fmt.Errorf("decode decision: %w", err))
+
+		return
+	}
+
+	err = decide(didcomm.WithMessage(r.Context(), msg), d.TicketID,
+		&DecisionRequest{Comment: d.Comment, OnBehalfOf: d.OnBehalfOf})
+	if err != nil {
+		respondError(rw, ErrorStatus(err), err)
+
+		return
+	}
+
+	respond(rw, http.StatusAccepted, nil)
+}
+
+// connect accepts the invitation the connect message replies to.
+func (o *Operation) connect(rw http.ResponseWriter, r *http.Request, msg *didcomm.Message) {
+	c, err := o.DIDCommInviter.Accept(r.Context(), msg)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, didcomm.ErrInvalidMessage) {
+			status = http.StatusBadRequest
+		}
+
+		respondError(rw, status, err)
+
+		return
+	}
+
+	logger.Infof("Connection %s of %s %s established", c.ID, c.Role, c.DID)
+
+	respond(rw, http.StatusAccepted, nil)
+}
+
+// issue proposes an issuance or requests its credential.
+func (o *Operation) issue(rw http.ResponseWriter, r *http.Request, msg *didcomm.Message,
+	fn func(context.Context, *didcomm.Message) (*didcomm.Issuance, error)) {
+	iss, err := fn(r.Context(), msg)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, didcomm.ErrInvalidMessage) {
+			status = http.StatusBadRequest
+		}
+
+		respondError(rw, status, err)
+
+		return
+	}
+
+	logger.Infof("Issuance %s to %s %s", iss.ID, iss.DID, iss.Status)
+
+	respond(rw, http.StatusAccepted, nil)
+}
+
+// inviteHandler swagger:route POST /v1/didcomm/invitations gatekeeper inviteReq
+//
+// Creates a single-use DIDComm out-of-band invitation of a new handler or approver, accepted by posting a signed
+// connect message replying to it to /v1/didcomm.
+//
+// Authorization: Bearer token
+//
+// Responses:
+//     200: inviteResp
+//     default: errorResp
+func (o *Operation) inviteHandler(rw http.ResponseWriter, r *http.Request) {
+	var req InvitationRequest
+
+	if err := o.decode(r, &req); err != nil {
+		respondError(rw, decodeErrorStatus(err), err)
+
+		return
+	}
+
+	inv, err := o.DIDCommInviter.Invite(r.Context(), req.Role, req.Label)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, didcomm.ErrInvalidInvitation) {
+			status = http.StatusBadRequest
+		}
+
+		respondError(rw, status, err)
+
+		return
+	}
+
+	respond(rw, http.StatusOK, inv)
+}
+
+// connectionsHandler swagger:route GET /v1/didcomm/connections gatekeeper connectionsReq
+//
+// Lists the connections of the participants that accepted an invitation, or of the participant with the did query
+// parameter.
+//
+// Authorization: Bearer token
+//
+// Responses:
+//     200: connectionsResp
+//     default: errorResp
+func (o *Operation) connectionsHandler(rw http.ResponseWriter, r *http.Request) {
+	connections, err := o.DIDCommInviter.Connections(r.Context(), r.URL.Query().Get(didVarName))
+	if err != nil {
+		respondError(rw, http.StatusInternalServerError, err)
+
+		return
+	}
+
+	respond(rw, http.StatusOK, &ConnectionsResponse{Connections: connections})
+}
+
+// issuancesHandler swagger:route GET /v1/didcomm/issuances gatekeeper issuancesReq
+//
+// Lists the issuances of authorized handler credentials, or the issuances with the status query parameter, such as
+// the proposed issuances waiting for approval.
+//
+// Authorization: Bearer token
+//
+// Responses:
+//     200: issuancesResp
+//     default: errorResp
+func (o *Operation) issuancesHandler(rw http.ResponseWriter, r *http.Request) {
+	issuances, err := o.DIDCommIssuer.Issuances(r.Context(), r.URL.Query().Get("status"))
+	if err != nil {
+		respondError(rw, http.StatusInternalServerError, err)
+
+		return
+	}
+
+	respond(rw, http.StatusOK, &IssuancesResponse{Issuances: issuances})
+}
+
+// approveIssuanceHandler swagger:route POST /v1/didcomm/issuances/{issuance_id}/approve gatekeeper approveIssuanceReq
+//
+// Approves an issuance proposed by a handler, offering the authorized handler credential to the handler.
+//
+// Authorization: Bearer token
+//
+// Responses:
+//     200: approveIssuanceResp
+//     default: errorResp
+func (o *Operation) approveIssuanceHandler(rw http.ResponseWriter, r *http.Request) {
+	iss, err := o.DIDCommIssuer.ApproveIssuance(r.Context(), mux.Vars(r)[issuanceVarName])
+	if err != nil {
+		status := http.StatusInternalServerError
+
+		switch {
+		case errors.Is(err, didcomm.ErrIssuanceNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, didcomm.ErrInvalidIssuance):
+			status = http.StatusConflict
+		}
+
+		respondError(rw, status, err)
+
+		return
+	}
+
+	respond(rw, http.StatusOK, iss)
+}
+
+// decodeRequest decodes the JSON body of the request into v within the RequestLimits, strictly if StrictJSON is set.
+func (o *Operation) decodeRequest(r *http.Request, v interface{}) error {
+	if o.StrictJSON {
+		return model.DecodeStrictLimited(r.Body, v, o.RequestLimits)
+	}
+
+	return o.decode(r, v)
+}
+
+// decode decodes the JSON body of the request into v within the RequestLimits.
+func (o *Operation) decode(r *http.Request, v interface{}) error {
+	return model.NewDecoder(r.Body, o.RequestLimits).Decode(v)
+}
+
+// decodeErrorStatus is the status of the response to a request whose body failed to decode with the error.
+func decodeErrorStatus(err error) int {
+	if errors.Is(err, model.ErrPayloadTooLarge) {
+		return http.StatusRequestEntityTooLarge
+	}
+
+	return http.StatusBadRequest
+}
+
+// decodeDecision decodes the decision of an approver, sent in the body of the request if they comment on it or decide
+// as a delegate.
+func (o *Operation) decodeDecision(r *http.Request) (*DecisionRequest, error) {
+	var req DecisionRequest
+
+	if r.Body == nil {
+		return &req, nil
+	}
+
+	if err := o.decode(r, &req); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	return &req, nil
+}
+
+// delegateHandler swagger:route POST /v1/policy/{policy_id}/delegation gatekeeper delegateReq
+//
+// Delegates the approval authority of the approver under the policy to another DID for a time window.
+//
+// Authorization: HTTP Signatures (headers="(request-target) date digest")
+//
+// Responses:
+//     200: delegateResp
+//     default: errorResp
+func (o *Operation) delegateHandler(rw http.ResponseWriter, r *http.Request) {
+	var req DelegateRequest
+
+	err := o.decode(r, &req)
+	if err != nil {
+		respondError(rw, decodeErrorStatus(err), err)
+
+		return
+	}
+
+	resp, err := o.Delegate(r.Context(), mux.Vars(r)[policyIDVarName], &req)
+	if err != nil {
+		respondError(rw, ErrorStatus(err), err)
+
+		return
+	}
+
+	respond(rw, http.StatusOK, resp)
+}
+
+// subscribeHandler swagger:route POST /v1/subscriptions gatekeeper subscribeReq
+//
+// Subscribes the participant to the events of the tickets they requested, approve or took actions on.
+//
+// Authorization: HTTP Signatures (headers="(request-target) date digest")
+//
+// Responses:
+//     200: subscribeResp
+//     default: errorResp
+func (o *Operation) subscribeHandler(rw http.ResponseWriter, r *http.Request) {
+	var req SubscribeRequest
+
+	err := o.decode(r, &req)
+	if err != nil {
+		respondError(rw, decodeErrorStatus(err), err)
+
+		return
+	}
+
+	resp, err := o.Subscribe(r.Context(), &req)
+	if err != nil {
+		respondError(rw, ErrorStatus(err), err)
+
+		return
+	}
+
+	respond(rw, http.StatusOK, resp)
+}
+
+// listSubscriptionsHandler swagger:route GET /v1/subscriptions gatekeeper listSubscriptionsReq
+//
+// Lists the subscriptions of the participant.
+//
+// Authorization: HTTP Signatures (headers="(request-target) date")
+//
+// Responses:
+//     200: listSubscriptionsResp
+//     default: errorResp
+func (o *Operation) listSubscriptionsHandler(rw http.ResponseWriter, r *http.Request) {
+	page, err := model.ParsePageRequest(r.URL.Query())
+	if err != nil {
+		respondError(rw, http.StatusBadRequest, err)
+
+		return
+	}
+
+	resp, err := o.Subscriptions(r.Context(), page)
+	if err != nil {
+		respondError(rw, ErrorStatus(err), err)
+
+		return
+	}
+
+	respond(rw, http.StatusOK, resp)
+}
+
+// unsubscribeHandler swagger:route DELETE /v1/subscriptions/{subscription_id} gatekeeper unsubscribeReq
+//
+// Deletes the subscription of the participant.
+//
+// Authorization: HTTP Signatures (headers="(request-target) date")
+//
+// Responses:
+//     200: unsubscribeResp
+//     default: errorResp
+func (o *Operation) unsubscribeHandler(rw http.ResponseWriter, r *http.Request) {
+	if err := o.Unsubscribe(r.Context(), mux.Vars(r)[subscriptionVarName]); err != nil {
+		respondError(rw, ErrorStatus(err), err)
+
+		return
+	}
+
+	respond(rw, http.StatusOK, nil)
+}
+
+// ticketStatusHandler swagger:route GET /v1/release/{ticket_id}/status gatekeeper ticketStatusReq
+//
+// Gets the status of the ticket.
+//
+// Authorization: HTTP Signatures (headers="(request-target) date")
+//
+// Responses:
+//     200: ticketStatusResp
+//     default: errorResp
+func (o *Operation) ticketStatusHandler(rw http.ResponseWriter, r *http.Request) {
+	resp, err := o.TicketStatus(r.Context(), mux.Vars(r)[ticketIDVarName])
+	if err != nil {
+		respondError(rw, ErrorStatus(err), err)
+
+		return
+	}
+
+	respond(rw, http.StatusOK, resp)
+}
+
+// listTicketsHandler swagger:route GET /v1/release gatekeeper listTicketsReq
+//
+// Lists the tickets selected by the filters of the query, e.g. the outstanding requests of a handler.
+//
+// Authorization: Bearer token
+//
+// Responses:
+//     200: listTicketsResp
+//     default: errorResp
+func (o *Operation) listTicketsHandler(rw http.ResponseWriter, r *http.Request) {
+	req, err := parseListTicketsRequest(r.URL.Query())
+	if err != nil {
+		respondError(rw, http.StatusBadRequest, err)
+
+		return
+	}
+
+	resp, err := o.ListTickets(r.Context(), req)
+	if err != nil {
+		respondError(rw, ErrorStatus(err), err)
+
+		return
+	}
+
+	respond(rw, http.StatusOK, resp)
+}
+
+// ticketEventsHandler swagger:route GET /v1/release/{ticket_id}/events gatekeeper ticketEventsReq
+//
+// Streams the status of the ticket as server-sent events, starting with its current status and followed by its
+// status after every update, until the ticket is rejected.
+//
+// Authorization: HTTP Signatures (headers="(request-target) date")
+//
+// Produces:
+// - text/event-stream
+//
+// Responses:
+//     200: ticketEventsResp
+//     default: errorResp
+func (o *Operation) ticketEventsHandler(rw http.ResponseWriter, r *http.Request) {
+	ticketID := mux.Vars(r)[ticketIDVarName]
+
+	// the ticket is watched before its status is read, so that no update is missed
+	updates, stop := o.TicketWatcher.Watch(ticketID)
+	defer stop()
+
+	resp, err := o.TicketStatus(r.Context(), ticketID)
+	if err != nil {
+		respondError(rw, ErrorStatus(err), err)
+
+		return
+	}
+
+	rw.Header().Set("Content-Type", "text/event-stream")
+	rw.Header().Set("Cache-Control", "no-cache")
+	rw.WriteHeader(http.StatusOK)
+
+	keepAlive := time.NewTicker(ticketEventsKeepAlive)
+	defer keepAlive.Stop()
+
+	err = writeEvent(rw, "status", resp)
+
+	for err == nil && resp.Status != ticket.Rejected.String() {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			err = writeStream(rw, []byte(": keep-alive\n\n"))
+		case _, ok := <-updates:
+			if !ok {
+				logger.Warnf("Ended the events of ticket %s: too many updates waiting to be sent", ticketID)
+
+				return
+			}
+
+			if resp, err = o.TicketStatus(r.Context(), ticketID); err == nil {
+				err = writeEvent(rw, "status", resp)
+			}
+		}
+	}
+
+	if err != nil {
+		logger.Errorf("Failed to stream the events of ticket %s: %s", ticketID, err.Error())
+	}
+}
+
+// writeEvent writes a server-sent event with the payload as its data.
+func writeEvent(w http.ResponseWriter, event string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("marshal event: %w", err)
+	}
+
+	return writeStream(w, []byte(fmt.Sprintf("event: %s\ndata: %s\n\n", event, data)))
+}
+
+// writeStream writes the message to the event stream and flushes it.
+func writeStream(w http.ResponseWriter, msg []byte) error {
+	if _, err := w.Write(msg); err != nil {
+		return fmt.Errorf("write event: %w", err)
+	}
+
+	if flusher, ok := w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+
+	return nil
+}
+
+// ticketHistoryHandler swagger:route GET /v1/release/{ticket_id}/history gatekeeper ticketHistoryReq
+//
+// Gets the history of the ticket, with every action taken on it.
+//
+// Authorization: Bearer token
+//
+// Responses:
+//     200: ticketHistoryResp
+//     default: errorResp
+func (o *Operation) ticketHistoryHandler(rw http.ResponseWriter, r *http.Request) {
+	resp, err := o.TicketHistory(r.Context(), mux.Vars(r)[ticketIDVarName])
+	if err != nil {
+		respondError(rw, ErrorStatus(err), err)
+
+		return
+	}
+
+	respond(rw, http.StatusOK, resp)
+}
+
+// getTicketHandler swagger:route GET /v1/release/{ticket_id} gatekeeper getTicketReq
+//
+// Gets the ticket with its approvals.
+//
+// Authorization: Bearer token
+//
+// Responses:
+//     200: getTicketResp
+//     default: errorResp
+func (o *Operation) getTicketHandler(rw http.ResponseWriter, r *http.Request) {
+	resp, err := o.GetTicket(r.Context(), mux.Vars(r)[ticketIDVarName])
+	if err != nil {
+		respondError(rw, ErrorStatus(err), err)
+
+		return
+	}
+
+	respond(rw, http.StatusOK, resp)
+}
+
+// collectHandler swagger:route POST /v1/release/{ticket_id}/collect gatekeeper collectReq
+//
+// Generates extract query for the ticket that has completed authorization process.
+//
+// Authorization: HTTP Signatures (headers="(request-target) date")
+//
+// Responses:
+//     200: collectResp
+//     default: errorResp
+func (o *Operation) collectHandler(rw http.ResponseWriter, r *http.Request) {
+	resp, err := o.Collect(r.Context(), mux.Vars(r)[ticketIDVarName])
+	if err != nil {
+		respondError(rw, ErrorStatus(err), err)
+
+		return
+	}
+
+	respond(rw, http.StatusOK, resp)
+}
+
+// breakGlassHandler swagger:route POST /v1/breakglass gatekeeper breakGlassReq
+//
+// Collects protected data in an emergency, without the approvals of the approvers, if the policy defines a
+// break-glass access. The subject and the approvers are notified.
+//
+// Authorization: HTTP Signatures (headers="(request-target) date")
+//
+// Responses:
+//     200: breakGlassResp
+//     default: errorResp
+func (o *Operation) breakGlassHandler(rw http.ResponseWriter, r *http.Request) {
+	var req BreakGlassRequest
+
+	err := o.decode(r, &req)
+	if err != nil {
+		respondError(rw, decodeErrorStatus(err), err)
+
+		return
+	}
+
+	resp, err := o.BreakGlass(r.Context(), &req)
+	if err != nil {
+		respondError(rw, ErrorStatus(err), err)
+
+		return
+	}
+
+	respond(rw, http.StatusOK, resp)
+}
+
+// extractHandler swagger:route POST /v1/extract gatekeeper extractReq
+//
+// Extracts protected data.
+//
+// Responses:
+//     200: extractResp
+//     default: errorResp
+func (o *Operation) extractHandler(rw http.ResponseWriter, r *http.Request) {
+	var req ExtractRequest
+
+	err := o.decode(r, &req)
+	if err != nil {
+		respondError(rw, decodeErrorStatus(err), err)
+
+		return
+	}
+
+	resp, err := o.Extract(r.Context(), &req)
+	if err != nil {
+		respondError(rw, ErrorStatus(err), err)
+
+		return
+	}
+
+	respond(rw, http.StatusOK, resp)
+}
+
+// auditEntriesHandler swagger:route GET /v1/audit/entries gatekeeper auditEntriesReq
+//
+// Lists a page of the entries of the audit log, the actions taken on tickets chained with their hashes, from the first
+// entry, with the head of the log.
+//
+// Authorization: Bearer token
+//
+// Responses:
+//     200: auditEntriesResp
+//     default: errorResp
+func (o *Operation) auditEntriesHandler(rw http.ResponseWriter, r *http.Request) {
+	page, err := model.ParsePageRequest(r.URL.Query())
+	if err != nil {
+		respondError(rw, http.StatusBadRequest, err)
+
+		return
+	}
+
+	// entries are chained by sequence number from 1, so the entries before the page are the entries it follows
+	after, err := page.Offset()
+	if err != nil {
+		respondError(rw, http.StatusBadRequest, err)
+
+		return
+	}
+
+	limit := page.PageLimit(audit.MaxEntries, audit.MaxEntries)
+
+	head, err := o.AuditLog.Head(r.Context())
+	if err != nil {
+		respondError(rw, http.StatusInternalServerError, err)
+
+		return
+	}
+
+	entries, err := o.AuditLog.Entries(r.Context(), after, limit)
+	if err != nil {
+		respondError(rw, http.StatusInternalServerError, err)
+
+		return
+	}
+
+	respond(rw, http.StatusOK, &AuditEntriesResponse{
+		Items: entries,
+		Head:  head,
+		Page:  model.NewPage(after, limit, head.Seq).WithTotal(head.Seq),
+	})
+}
+
+// checkpointsHandler swagger:route GET /v1/audit/checkpoints gatekeeper checkpointsReq
+//
+// Lists the checkpoints of the audit log, credentials signed by the gatekeeper with the head of the log at the time
+// they were issued, oldest first.
+//
+// Authorization: Bearer token
+//
+// Responses:
+//     200: checkpointsResp
+//     default: errorResp
+func (o *Operation) checkpointsHandler(rw http.ResponseWriter, r *http.Request) {
+	checkpoints, err := o.AuditLog.Checkpoints(r.Context())
+	if err != nil {
+		respondError(rw, http.StatusInternalServerError, err)
+
+		return
+	}
+
+	respond(rw, http.StatusOK, &CheckpointsResponse{Checkpoints: checkpoints})
+}
+
+// auditVerifyHandler swagger:route GET /v1/audit/verify gatekeeper auditVerifyReq
+//
+// Verifies the audit log: the hashes chaining its entries, and the signatures of its checkpoints, which must match
+// the entries. The first mismatch found is reported.
+//
+// Authorization: Bearer token
+//
+// Responses:
+//     200: auditVerifyResp
+//     default: errorResp
+func (o *Operation) auditVerifyHandler(rw http.ResponseWriter, r *http.Request) {
+	report, err := o.AuditLog.Verify(r.Context())
+	if err != nil {
+		respondError(rw, http.StatusInternalServerError, err)
+
+		return
+	}
+
+	respond(rw, http.StatusOK, report)
+}
+
+// purgeHandler swagger:route POST /v1/purge gatekeeper purgeReq
+//
+// Purges deleted policies and protected data past their retention window without waiting for the next scheduled purge.
+//
+// Authorization: Bearer token
+//
+// Responses:
+//     200: purgeResp
+//     default: errorResp
+func (o *Operation) purgeHandler(rw http.ResponseWriter, _ *http.Request) {
+	o.Purge()
+
+	respond(rw, http.StatusOK, nil)
+}
+
+// dsarHandler swagger:route GET /v1/dsar gatekeeper dsarReq
+//
+// Reports the data protected about the subject, under which policies and consents, and the tickets releasing it.
+//
+// Authorization: HTTP signature of the subject
+//
+// Responses:
+//     200: dsarResp
+//     default: errorResp
+func (o *Operation) dsarHandler(rw http.ResponseWriter, r *http.Request) {
+	resp, err := o.DSAR(r.Context())
+	if err != nil {
+		respondError(rw, ErrorStatus(err), err)
+
+		return
+	}
+
+	respond(rw, http.StatusOK, resp)
+}
+
+// eraseHandler swagger:route DELETE /v1/dsar gatekeeper eraseReq
+//
+// Deletes the data protected about the subject, for data subject erasure requests.
+//
+// Authorization: HTTP signature of the subject
+//
+// Responses:
+//     200: eraseResp
+//     default: errorResp
+func (o *Operation) eraseHandler(rw http.ResponseWriter, r *http.Request) {
+	resp, err := o.Erase(r.Context())
+	if err != nil {
+		respondError(rw, ErrorStatus(err), err)
+
+		return
+	}
+
+	respond(rw, http.StatusOK, resp)
+}
+
+// linkHandler swagger:route POST /v1/dsar/link gatekeeper linkReq
+//
+// Links protected data to the subject, with the targets it was protected from as the proof that it belongs to them.
+//
+// Authorization: HTTP signature of the subject
+//
+// Responses:
+//     200: linkResp
+//     default: errorResp
+func (o *Operation) linkHandler(rw http.ResponseWriter, r *http.Request) {
+	var req LinkRequest
+
+	err := o.decode(r, &req)
+	if err != nil {
+		respondError(rw, decodeErrorStatus(err), err)
+
+		return
+	}
+
+	resp, err := o.Link(r.Context(), &req)
+	if err != nil {
+		respondError(rw, ErrorStatus(err), err)
+
+		return
+	}
+
+	respond(rw, http.StatusOK, resp)
+}
+
+// traceHandler swagger:route POST /v1/trace gatekeeper traceReq
+//
+// Traces watermarked data back to the extraction it was released by.
+//
+// Authorization: Bearer token
+//
+// Responses:
+//     200: traceResp
+//     default: errorResp
+func (o *Operation) traceHandler(rw http.ResponseWriter, r *http.Request) {
+	var req TraceRequest
+
+	err := o.decode(r, &req)
+	if err != nil {
+		respondError(rw, decodeErrorStatus(err), err)
+
+		return
+	}
+
+	resp, err := o.Trace(r.Context(), &req)
+	if err != nil {
+		respondError(rw, ErrorStatus(err), err)
+
+		return
+	}
+
+	respond(rw, http.StatusOK, resp)
+}
+
+// extractBatchHandler swagger:route POST /v1/extract/batch gatekeeper extractBatchReq
+//
+// Extracts the protected data of several queries, streamed back as newline-delimited JSON with an item per query.
+// Queries that cannot be extracted are reported in their items without failing the request, and counted in the
+// X-Failed-Queries trailer once all queries are streamed.
+//
+// Produces:
+// - application/x-ndjson
+//
+// Responses:
+//     200: extractBatchResp
+//     default: errorResp
+func (o *Operation) extractBatchHandler(rw http.ResponseWriter, r *http.Request) {
+	var req ExtractBatchRequest
+
+	err := o.decode(r, &req)
+	if err != nil {
+		respondError(rw, decodeErrorStatus(err), err)
+
+		return
+	}
+
+	var (
+		enc        = json.NewEncoder(rw)
+		flusher, _ = rw.(http.Flusher) //nolint:errcheck
+		started    bool
+		failed     int
+	)
+
+	err = o.ExtractBatch(r.Context(), &req, func(item *ExtractBatchItem) error {
+		if !started {
+			rw.Header().Add("Content-Type", "application/x-ndjson")
+			rw.Header().Set("Trailer", FailedQueriesTrailer)
+			rw.WriteHeader(http.StatusOK)
+
+			started = true
+		}
+
+		if item.Error != "" {
+			failed++
+		}
+
+		if e := enc.Encode(item); e != nil {
+			return e
+		}
+
+		if flusher != nil {
+			flusher.Flush()
+		}
+
+		return nil
+	})
+	if err != nil && !started {
+		respondError(rw, ErrorStatus(err), err)
+
+		return
+	}
+
+	if err != nil {
+		logger.Errorf("Failed to stream extracted data: %s", err.Error())
+
+		return
+	}
+
+	rw.Header().Set(FailedQueriesTrailer, strconv.Itoa(failed))
+}
+
+func respond(w http.ResponseWriter, statusCode int, payload interface{}) { //nolint:unparam
+	w.Header().Add("Content-Type", "application/json")
+
+	w.WriteHeader(statusCode)
+
+	if payload != nil {
+		if err := json.NewEncoder(w).Encode(payload); err != nil {
+			logger.Errorf("Failed to write response: %s", err.Error())
+		}
+	}
+}
+
+func respondError(w http.ResponseWriter, statusCode int, err error) {
+	w.Header().Add("Content-Type", "application/json")
+
+	var e *Error
+	if errors.As(err, &e) && e.RetryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(e.RetryAfter.Seconds()))))
+	}
 
 	errorMessage := err.Error()
 
 	logger.Errorf(errorMessage)
+	errorreport.Record(w, err)
 
 	w.WriteHeader(statusCode)
 
-	if encErr := json.NewEncoder(w).Encode(&model.ErrorResponse{Message: errorMessage}); encErr != nil {
+	if encErr := json.NewEncoder(w).Encode(&model.ErrorResponse{
+		Message:   errorMessage,
+		RequestID: requestid.FromResponse(w),
+	}); encErr != nil {
 		logger.Errorf("Failed to write error response: %s", err.Error())
 	}
 }
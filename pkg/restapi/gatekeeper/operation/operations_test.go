@@ -11,20 +11,44 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/gorilla/mux"
+	"github.com/hyperledger/aries-framework-go/component/storageutil/mem"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/presexch"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
 	"github.com/hyperledger/aries-framework-go/spi/storage"
 	"github.com/stretchr/testify/require"
 
+	"github.com/trustbloc/ace/pkg/feature"
+	"github.com/trustbloc/ace/pkg/gatekeeper/audit"
+	"github.com/trustbloc/ace/pkg/gatekeeper/consent"
+	"github.com/trustbloc/ace/pkg/gatekeeper/delegation"
+	"github.com/trustbloc/ace/pkg/gatekeeper/didcomm"
+	"github.com/trustbloc/ace/pkg/gatekeeper/encrypt"
+	"github.com/trustbloc/ace/pkg/gatekeeper/notify"
+	"github.com/trustbloc/ace/pkg/gatekeeper/oidc4vp"
 	"github.com/trustbloc/ace/pkg/gatekeeper/policy"
 	"github.com/trustbloc/ace/pkg/gatekeeper/protect"
+	"github.com/trustbloc/ace/pkg/gatekeeper/quota"
+	"github.com/trustbloc/ace/pkg/gatekeeper/release"
 	"github.com/trustbloc/ace/pkg/gatekeeper/release/ticket"
+	"github.com/trustbloc/ace/pkg/gatekeeper/subscription"
+	"github.com/trustbloc/ace/pkg/gatekeeper/watermark"
+	"github.com/trustbloc/ace/pkg/queue"
 	"github.com/trustbloc/ace/pkg/restapi/gatekeeper/operation"
+	"github.com/trustbloc/ace/pkg/restapi/model"
+	"github.com/trustbloc/ace/pkg/restapi/mw/requestid"
+	"github.com/trustbloc/ace/pkg/storage/cursor"
+	"github.com/trustbloc/ace/pkg/workerpool"
 )
 
 const (
@@ -74,6 +98,67 @@ func TestProtectHandler(t *testing.T) {
 			bytes.NewBufferString("invalid json"))
 
 		require.Equal(t, http.StatusBadRequest, rr.Code)
+
+		var errResp model.ErrorResponse
+
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&errResp))
+		require.NotEmpty(t, errResp.RequestID)
+		require.Equal(t, rr.Header().Get(requestid.Header), errResp.RequestID)
+	})
+
+	t.Run("Strict mode", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		protectService := NewMockProtectService(ctrl)
+		protectService.EXPECT().Protect(gomock.Any(), gomock.Any(), gomock.Any()).Return(&protect.ProtectedData{}, nil)
+
+		policyService := NewMockPolicyService(ctrl)
+		policyService.EXPECT().Check(gomock.Any(), req.Policy, subjectDID, policy.Collector).Return(nil)
+
+		subjectResolver := NewMockSubjectResolver(ctrl)
+		subjectResolver.EXPECT().Resolve(gomock.Any()).Return(subjectDID, nil)
+
+		op := &operation.Operation{
+			ProtectService:  protectService,
+			PolicyService:   policyService,
+			SubjectResolver: subjectResolver,
+			StrictJSON:      true,
+		}
+
+		body, err := json.Marshal(req)
+		require.NoError(t, err)
+
+		rr := handleRequest(t, op, "/v1/protect", http.MethodPost, bytes.NewReader(body))
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		rr = handleRequest(t, op, "/v1/protect", http.MethodPost,
+			bytes.NewBufferString(`{"policy":"10","taget":"test ssn"}`))
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "unknown field")
+	})
+
+	t.Run("Request exceeding the limits", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		protectService := NewMockProtectService(ctrl)
+		protectService.EXPECT().Protect(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+		op := &operation.Operation{
+			ProtectService: protectService,
+			RequestLimits:  model.Limits{MaxSize: 128, MaxStringLength: 16},
+		}
+
+		rr := handleRequest(t, op, "/v1/protect", http.MethodPost,
+			strings.NewReader(`{"policy":"10","target":"`+strings.Repeat("1", 1<<20)+`"}`))
+		require.Equal(t, http.StatusRequestEntityTooLarge, rr.Code)
+		require.Contains(t, rr.Body.String(), "string longer than 16 bytes")
+
+		rr = handleRequest(t, op, "/v1/protect", http.MethodPost,
+			strings.NewReader(`{"policy":"10","target":"1","x":`+strings.Repeat("[", 64)))
+		require.Equal(t, http.StatusRequestEntityTooLarge, rr.Code)
+		require.Contains(t, rr.Body.String(), "nested deeper than 32 levels")
 	})
 
 	t.Run("Fail to resolve subject DID from context", func(t *testing.T) {
@@ -186,789 +271,6018 @@ func TestProtectHandler(t *testing.T) {
 
 		require.Equal(t, http.StatusInternalServerError, rr.Code)
 	})
-}
-
-func TestCreatePolicyHandler(t *testing.T) {
-	p := &policy.Policy{
-		Collectors:   []string{"did:example:ray_stantz"},
-		Handlers:     []string{"did:example:alter_peck"},
-		Approvers:    []string{"did:example:peter_venkman", "did:example:eon_spengler", "did:example:winton_zeddemore"},
-		MinApprovers: 2,
-	}
 
-	t.Run("Success", func(t *testing.T) {
+	t.Run("Protect with worker pool", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		defer ctrl.Finish()
 
-		policyService := NewMockPolicyService(ctrl)
-		policyService.EXPECT().Save(gomock.Any(), gomock.Any()).Return(nil).Times(1)
-
-		op := &operation.Operation{
-			PolicyService: policyService,
-		}
-
-		body, err := json.Marshal(p)
-		require.NoError(t, err)
-
-		rr := handleRequest(t, op, "/v1/policy/containment-policy", http.MethodPut, bytes.NewReader(body))
-
-		require.Equal(t, http.StatusOK, rr.Code)
-	})
-
-	t.Run("Fail to unmarshal request body", func(t *testing.T) {
-		ctrl := gomock.NewController(t)
-		defer ctrl.Finish()
+		protectService := NewMockProtectService(ctrl)
+		protectService.EXPECT().Protect(gomock.Any(), gomock.Any(), gomock.Any()).Return(&protect.ProtectedData{}, nil)
 
 		policyService := NewMockPolicyService(ctrl)
-		policyService.EXPECT().Save(gomock.Any(), gomock.Any()).Times(0)
-
-		op := &operation.Operation{
-			PolicyService: policyService,
-		}
-
-		rr := handleRequest(t, op, "/v1/policy/containment-policy", http.MethodPut,
-			bytes.NewBufferString("invalid json"))
-
-		require.Equal(t, http.StatusBadRequest, rr.Code)
-	})
+		policyService.EXPECT().Check(gomock.Any(), req.Policy, subjectDID, policy.Collector).Return(nil)
 
-	t.Run("Fail to store policy", func(t *testing.T) {
-		ctrl := gomock.NewController(t)
-		defer ctrl.Finish()
+		subjectResolver := NewMockSubjectResolver(ctrl)
+		subjectResolver.EXPECT().Resolve(gomock.Any()).Return(subjectDID, nil)
 
-		policyService := NewMockPolicyService(ctrl)
-		policyService.EXPECT().Save(gomock.Any(), gomock.Any()).Return(errors.New("save error")).Times(1)
+		pool := NewMockWorkerPool(ctrl)
+		pool.EXPECT().Do(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(ctx context.Context, fn func(ctx context.Context) error) error {
+				return fn(ctx)
+			})
 
 		op := &operation.Operation{
-			PolicyService: policyService,
+			ProtectService:  protectService,
+			PolicyService:   policyService,
+			SubjectResolver: subjectResolver,
+			ProtectPool:     pool,
 		}
 
-		body, err := json.Marshal(p)
+		body, err := json.Marshal(req)
 		require.NoError(t, err)
 
-		rr := handleRequest(t, op, "/v1/policy/containment-policy", http.MethodPut, bytes.NewReader(body))
+		rr := handleRequest(t, op, "/v1/protect", http.MethodPost, bytes.NewReader(body))
 
-		require.Equal(t, http.StatusInternalServerError, rr.Code)
+		require.Equal(t, http.StatusOK, rr.Code)
 	})
-}
-
-func TestReleaseHandler(t *testing.T) {
-	req := operation.ReleaseRequest{
-		DID: targetDID,
-	}
 
-	t.Run("Success", func(t *testing.T) {
+	t.Run("Worker pool saturated", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
-
-		releaseService := NewMockReleaseService(ctrl)
-		releaseService.EXPECT().Release(gomock.Any(), targetDID).Return(&ticket.Ticket{}, nil).Times(1)
+		defer ctrl.Finish()
 
 		protectService := NewMockProtectService(ctrl)
-		protectService.EXPECT().Get(gomock.Any(), targetDID).
-			Return(&protect.ProtectedData{PolicyID: testPolicyID}, nil).Times(1)
+		protectService.EXPECT().Protect(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
 
 		policyService := NewMockPolicyService(ctrl)
-		policyService.EXPECT().Check(gomock.Any(), testPolicyID, subjectDID, policy.Handler).Return(nil).Times(1)
+		policyService.EXPECT().Check(gomock.Any(), req.Policy, subjectDID, policy.Collector).Return(nil)
 
 		subjectResolver := NewMockSubjectResolver(ctrl)
 		subjectResolver.EXPECT().Resolve(gomock.Any()).Return(subjectDID, nil)
 
+		pool := NewMockWorkerPool(ctrl)
+		pool.EXPECT().Do(gomock.Any(), gomock.Any()).Return(workerpool.ErrSaturated)
+
 		op := &operation.Operation{
-			ReleaseService:  releaseService,
-			PolicyService:   policyService,
 			ProtectService:  protectService,
+			PolicyService:   policyService,
 			SubjectResolver: subjectResolver,
+			ProtectPool:     pool,
 		}
 
 		body, err := json.Marshal(req)
 		require.NoError(t, err)
 
-		rr := handleRequest(t, op, "/v1/release", http.MethodPost, bytes.NewReader(body))
+		rr := handleRequest(t, op, "/v1/protect", http.MethodPost, bytes.NewReader(body))
 
-		require.Equal(t, http.StatusOK, rr.Code)
+		require.Equal(t, http.StatusServiceUnavailable, rr.Code)
+		require.Equal(t, "1", rr.Header().Get("Retry-After"))
+		require.Contains(t, rr.Body.String(), "worker pool saturated")
 	})
 
-	t.Run("Fail to unmarshal request body", func(t *testing.T) {
-		ctrl := gomock.NewController(t)
+	t.Run("Protect in a region", func(t *testing.T) {
+		tests := []struct {
+			err    error
+			status int
+		}{
+			{nil, http.StatusOK},
+			{fmt.Errorf("%w: eu", protect.ErrUnknownRegion), http.StatusBadRequest},
+			{fmt.Errorf("%w: \"us\"", protect.ErrRegionMismatch), http.StatusConflict},
+		}
 
-		svc := NewMockReleaseService(ctrl)
-		svc.EXPECT().Release(gomock.Any(), targetDID).Times(0)
+		for _, tt := range tests {
+			ctrl := gomock.NewController(t)
 
-		op := &operation.Operation{
-			ReleaseService: svc,
-		}
+			protectService := NewMockProtectService(ctrl)
+			protectService.EXPECT().Protect(gomock.Any(), req.Target, req.Policy, gomock.Any()).
+				Return(&protect.ProtectedData{DID: targetDID, Region: "eu"}, tt.err)
 
-		rr := handleRequest(t, op, "/v1/release", http.MethodPost, bytes.NewBufferString("invalid json"))
+			policyService := NewMockPolicyService(ctrl)
+			policyService.EXPECT().Check(gomock.Any(), req.Policy, subjectDID, policy.Collector).Return(nil)
 
-		require.Equal(t, http.StatusBadRequest, rr.Code)
+			subjectResolver := NewMockSubjectResolver(ctrl)
+			subjectResolver.EXPECT().Resolve(gomock.Any()).Return(subjectDID, nil)
+
+			op := &operation.Operation{
+				ProtectService:  protectService,
+				PolicyService:   policyService,
+				SubjectResolver: subjectResolver,
+			}
+
+			body, err := json.Marshal(&operation.ProtectRequest{Policy: req.Policy, Target: req.Target, Region: "eu"})
+			require.NoError(t, err)
+
+			rr := handleRequest(t, op, "/v1/protect", http.MethodPost, bytes.NewReader(body))
+
+			require.Equal(t, tt.status, rr.Code, rr.Body.String())
+
+			ctrl.Finish()
+		}
 	})
 
-	t.Run("Fail to get protected data", func(t *testing.T) {
+	t.Run("Success with consent receipt", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
-
-		releaseService := NewMockReleaseService(ctrl)
-		releaseService.EXPECT().Release(gomock.Any(), gomock.Any()).Times(0)
+		defer ctrl.Finish()
 
 		protectService := NewMockProtectService(ctrl)
-		protectService.EXPECT().Get(gomock.Any(), targetDID).Return(nil, errors.New("get error")).Times(1)
+		protectService.EXPECT().Protect(gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(&protect.ProtectedData{DID: targetDID}, nil)
 
 		policyService := NewMockPolicyService(ctrl)
-		policyService.EXPECT().Check(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+		policyService.EXPECT().Check(gomock.Any(), req.Policy, subjectDID, policy.Collector).Return(nil)
+		policyService.EXPECT().Get(gomock.Any(), req.Policy).Return(&policy.Policy{
+			ID:      req.Policy,
+			Consent: &policy.Consent{Purposes: []string{"fraud prevention"}, Retention: "P1Y"},
+		}, nil)
 
 		subjectResolver := NewMockSubjectResolver(ctrl)
-		subjectResolver.EXPECT().Resolve(gomock.Any()).Times(0)
+		subjectResolver.EXPECT().Resolve(gomock.Any()).Return(subjectDID, nil)
+
+		consentService := NewMockConsentService(ctrl)
+		consentService.EXPECT().Issue(gomock.Any(), &consent.Receipt{
+			DID:        targetDID,
+			Subject:    "did:example:data_subject",
+			Controller: subjectDID,
+			PolicyID:   req.Policy,
+			Purposes:   []string{"fraud prevention"},
+			Retention:  "P1Y",
+		}).Return(&verifiable.Credential{
+			ID:      "urn:uuid:receipt",
+			Context: []string{"https://www.w3.org/2018/credentials/v1"},
+			Types:   []string{"VerifiableCredential", consent.CredentialType},
+			Issuer:  verifiable.Issuer{ID: "did:example:gatekeeper"},
+			Subject: targetDID,
+		}, nil)
 
 		op := &operation.Operation{
-			ReleaseService:  releaseService,
-			PolicyService:   policyService,
 			ProtectService:  protectService,
+			PolicyService:   policyService,
 			SubjectResolver: subjectResolver,
+			ConsentService:  consentService,
 		}
 
-		body, err := json.Marshal(req)
+		body, err := json.Marshal(&operation.ProtectRequest{
+			Policy:  req.Policy,
+			Target:  req.Target,
+			Subject: "did:example:data_subject",
+		})
 		require.NoError(t, err)
 
-		rr := handleRequest(t, op, "/v1/release", http.MethodPost, bytes.NewReader(body))
+		rr := handleRequest(t, op, "/v1/protect", http.MethodPost, bytes.NewReader(body))
 
-		require.Equal(t, http.StatusInternalServerError, rr.Code)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var resp operation.ProtectResponse
+
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		require.Equal(t, targetDID, resp.DID)
+		require.Contains(t, string(resp.ConsentReceipt), "urn:uuid:receipt")
 	})
 
-	t.Run("Fail to check policy: ErrNotAllowed", func(t *testing.T) {
+	t.Run("No consent receipt for policy without consent", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
-
-		releaseService := NewMockReleaseService(ctrl)
-		releaseService.EXPECT().Release(gomock.Any(), gomock.Any()).Times(0)
+		defer ctrl.Finish()
 
 		protectService := NewMockProtectService(ctrl)
-		protectService.EXPECT().Get(gomock.Any(), targetDID).
-			Return(&protect.ProtectedData{PolicyID: testPolicyID}, nil).Times(1)
+		protectService.EXPECT().Protect(gomock.Any(), gomock.Any(), gomock.Any()).Return(&protect.ProtectedData{}, nil)
 
 		policyService := NewMockPolicyService(ctrl)
-		policyService.EXPECT().Check(gomock.Any(), testPolicyID, subjectDID, policy.Handler).
-			Return(policy.ErrNotAllowed).Times(1)
+		policyService.EXPECT().Check(gomock.Any(), req.Policy, subjectDID, policy.Collector).Return(nil)
+		policyService.EXPECT().Get(gomock.Any(), req.Policy).Return(&policy.Policy{ID: req.Policy}, nil)
 
 		subjectResolver := NewMockSubjectResolver(ctrl)
 		subjectResolver.EXPECT().Resolve(gomock.Any()).Return(subjectDID, nil)
 
+		consentService := NewMockConsentService(ctrl)
+		consentService.EXPECT().Issue(gomock.Any(), gomock.Any()).Times(0)
+
 		op := &operation.Operation{
-			ReleaseService:  releaseService,
-			PolicyService:   policyService,
 			ProtectService:  protectService,
+			PolicyService:   policyService,
 			SubjectResolver: subjectResolver,
+			ConsentService:  consentService,
 		}
 
 		body, err := json.Marshal(req)
 		require.NoError(t, err)
 
-		rr := handleRequest(t, op, "/v1/release", http.MethodPost, bytes.NewReader(body))
+		rr := handleRequest(t, op, "/v1/protect", http.MethodPost, bytes.NewReader(body))
 
-		require.Equal(t, http.StatusUnauthorized, rr.Code)
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.NotContains(t, rr.Body.String(), "consent_receipt")
 	})
 
-	t.Run("Fail to create release transaction on a DID", func(t *testing.T) {
+	t.Run("Fail to issue consent receipt", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
-
-		releaseService := NewMockReleaseService(ctrl)
-		releaseService.EXPECT().Release(gomock.Any(), targetDID).Return(nil, errors.New("release error"))
+		defer ctrl.Finish()
 
 		protectService := NewMockProtectService(ctrl)
-		protectService.EXPECT().Get(gomock.Any(), targetDID).
-			Return(&protect.ProtectedData{PolicyID: testPolicyID}, nil).Times(1)
+		protectService.EXPECT().Protect(gomock.Any(), gomock.Any(), gomock.Any()).Return(&protect.ProtectedData{}, nil)
 
 		policyService := NewMockPolicyService(ctrl)
-		policyService.EXPECT().Check(gomock.Any(), testPolicyID, subjectDID, policy.Handler).Return(nil).Times(1)
+		policyService.EXPECT().Check(gomock.Any(), req.Policy, subjectDID, policy.Collector).Return(nil)
+		policyService.EXPECT().Get(gomock.Any(), req.Policy).Return(&policy.Policy{
+			ID:      req.Policy,
+			Consent: &policy.Consent{Purposes: []string{"fraud prevention"}},
+		}, nil)
 
 		subjectResolver := NewMockSubjectResolver(ctrl)
 		subjectResolver.EXPECT().Resolve(gomock.Any()).Return(subjectDID, nil)
 
+		consentService := NewMockConsentService(ctrl)
+		consentService.EXPECT().Issue(gomock.Any(), gomock.Any()).Return(nil, errors.New("issue error"))
+
 		op := &operation.Operation{
-			ReleaseService:  releaseService,
-			PolicyService:   policyService,
 			ProtectService:  protectService,
+			PolicyService:   policyService,
 			SubjectResolver: subjectResolver,
+			ConsentService:  consentService,
 		}
 
 		body, err := json.Marshal(req)
 		require.NoError(t, err)
 
-		rr := handleRequest(t, op, "/v1/release", http.MethodPost, bytes.NewReader(body))
+		rr := handleRequest(t, op, "/v1/protect", http.MethodPost, bytes.NewReader(body))
 
 		require.Equal(t, http.StatusInternalServerError, rr.Code)
+		require.Contains(t, rr.Body.String(), "issue consent receipt: issue error")
 	})
 }
 
-func TestAuthorizeHandler(t *testing.T) {
-	t.Run("Success", func(t *testing.T) {
-		ctrl := gomock.NewController(t)
+func TestProtectBatchHandler(t *testing.T) {
+	req := &operation.ProtectBatchRequest{
+		Policy:  "10",
+		Targets: []string{"test ssn 1", "test ssn 2"},
+	}
 
-		releaseService := NewMockReleaseService(ctrl)
-		releaseService.EXPECT().Get(gomock.Any(), testTicketID).Return(&ticket.Ticket{
-			ID:     testTicketID,
-			DID:    targetDID,
-			Status: 0,
-		}, nil)
-		releaseService.EXPECT().Authorize(gomock.Any(), testTicketID, subjectDID).Return(nil)
+	body, err := json.Marshal(req)
+	require.NoError(t, err)
 
-		protectService := NewMockProtectService(ctrl)
-		protectService.EXPECT().Get(gomock.Any(), targetDID).Return(&protect.ProtectedData{
-			PolicyID: testPolicyID,
-		}, nil)
+	newOperation := func(t *testing.T) (*operation.Operation, *MockProtectService, *MockPolicyService) {
+		t.Helper()
+
+		ctrl := gomock.NewController(t)
 
+		protectService := NewMockProtectService(ctrl)
 		policyService := NewMockPolicyService(ctrl)
-		policyService.EXPECT().Check(gomock.Any(), testPolicyID, subjectDID, policy.Approver).Return(nil)
 
 		subjectResolver := NewMockSubjectResolver(ctrl)
-		subjectResolver.EXPECT().Resolve(gomock.Any()).Return(subjectDID, nil)
+		subjectResolver.EXPECT().Resolve(gomock.Any()).Return(subjectDID, nil).AnyTimes()
 
-		op := &operation.Operation{
-			ReleaseService:  releaseService,
-			PolicyService:   policyService,
+		return &operation.Operation{
 			ProtectService:  protectService,
+			PolicyService:   policyService,
 			SubjectResolver: subjectResolver,
-		}
+		}, protectService, policyService
+	}
 
-		rr := handleRequest(t, op, "/v1/release/test-ticket/authorize", http.MethodPost, nil)
+	t.Run("Success with consent receipts", func(t *testing.T) {
+		op, protectService, policyService := newOperation(t)
+
+		protectService.EXPECT().ProtectAll(gomock.Any(), req.Policy, req.Targets[0], req.Targets[1]).
+			Return([]*protect.ProtectedData{{DID: "did:example:1"}, {DID: "did:example:2"}}, nil)
+		policyService.EXPECT().Check(gomock.Any(), req.Policy, subjectDID, policy.Collector).Return(nil)
+		policyService.EXPECT().Get(gomock.Any(), req.Policy).Return(&policy.Policy{
+			ID:      req.Policy,
+			Consent: &policy.Consent{Purposes: []string{"fraud prevention"}},
+		}, nil)
+
+		consentService := NewMockConsentService(gomock.NewController(t))
+		consentService.EXPECT().Issue(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, r *consent.Receipt) (*verifiable.Credential, error) {
+				require.Equal(t, subjectDID, r.Controller)
+
+				return &verifiable.Credential{
+					ID:      "urn:uuid:" + r.DID,
+					Context: []string{"https://www.w3.org/2018/credentials/v1"},
+					Types:   []string{"VerifiableCredential", consent.CredentialType},
+					Issuer:  verifiable.Issuer{ID: "did:example:gatekeeper"},
+					Subject: r.DID,
+				}, nil
+			}).Times(2)
+
+		op.ConsentService = consentService
+
+		rr := handleRequest(t, op, "/v1/protect/batch", http.MethodPost, bytes.NewReader(body))
 
 		require.Equal(t, http.StatusOK, rr.Code)
-	})
 
-	t.Run("Ticket not found", func(t *testing.T) {
-		ctrl := gomock.NewController(t)
+		var resp operation.ProtectBatchResponse
 
-		releaseService := NewMockReleaseService(ctrl)
-		releaseService.EXPECT().Get(gomock.Any(), testTicketID).Return(nil, storage.ErrDataNotFound)
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		require.Equal(t, []string{"did:example:1", "did:example:2"}, resp.DIDs)
+		require.Len(t, resp.ConsentReceipts, 2)
+		require.Contains(t, string(resp.ConsentReceipts[1]), "urn:uuid:did:example:2")
+	})
 
-		op := &operation.Operation{
-			ReleaseService: releaseService,
-		}
+	t.Run("No targets or too many targets", func(t *testing.T) {
+		op, _, _ := newOperation(t)
 
-		rr := handleRequest(t, op, "/v1/release/test-ticket/authorize", http.MethodPost, nil)
+		rr := handleRequest(t, op, "/v1/protect/batch", http.MethodPost, strings.NewReader(`{"policy":"10"}`))
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "no targets to protect")
+
+		b, e := json.Marshal(&operation.ProtectBatchRequest{Policy: "10", Targets: make([]string, 1001)})
+		require.NoError(t, e)
 
+		rr = handleRequest(t, op, "/v1/protect/batch", http.MethodPost, bytes.NewReader(b))
 		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "too many targets to protect: 1001, at most 1000")
 	})
 
-	t.Run("Fail to get ticket", func(t *testing.T) {
-		ctrl := gomock.NewController(t)
-
-		releaseService := NewMockReleaseService(ctrl)
-		releaseService.EXPECT().Get(gomock.Any(), testTicketID).Return(nil, errors.New("get error"))
+	t.Run("Not a collector", func(t *testing.T) {
+		op, _, policyService := newOperation(t)
 
-		op := &operation.Operation{
-			ReleaseService: releaseService,
-		}
+		policyService.EXPECT().Check(gomock.Any(), req.Policy, subjectDID, policy.Collector).
+			Return(policy.ErrNotAllowed)
 
-		rr := handleRequest(t, op, "/v1/release/test-ticket/authorize", http.MethodPost, nil)
+		rr := handleRequest(t, op, "/v1/protect/batch", http.MethodPost, bytes.NewReader(body))
 
-		require.Equal(t, http.StatusInternalServerError, rr.Code)
+		require.Equal(t, http.StatusUnauthorized, rr.Code)
 	})
 
-	t.Run("Fail to get protected data", func(t *testing.T) {
-		ctrl := gomock.NewController(t)
-
-		releaseService := NewMockReleaseService(ctrl)
-		releaseService.EXPECT().Get(gomock.Any(), testTicketID).Return(&ticket.Ticket{
-			ID:     testTicketID,
-			DID:    targetDID,
-			Status: 0,
-		}, nil)
+	t.Run("Fail to protect", func(t *testing.T) {
+		op, protectService, policyService := newOperation(t)
 
-		protectService := NewMockProtectService(ctrl)
-		protectService.EXPECT().Get(gomock.Any(), targetDID).Return(nil, errors.New("get error"))
-
-		op := &operation.Operation{
-			ReleaseService: releaseService,
-			ProtectService: protectService,
-		}
+		policyService.EXPECT().Check(gomock.Any(), req.Policy, subjectDID, policy.Collector).Return(nil)
+		protectService.EXPECT().ProtectAll(gomock.Any(), req.Policy, req.Targets[0], req.Targets[1]).
+			Return(nil, errors.New("save protected data: batch error"))
 
-		rr := handleRequest(t, op, "/v1/release/test-ticket/authorize", http.MethodPost, nil)
+		rr := handleRequest(t, op, "/v1/protect/batch", http.MethodPost, bytes.NewReader(body))
 
 		require.Equal(t, http.StatusInternalServerError, rr.Code)
+		require.Contains(t, rr.Body.String(), "save protected data: batch error")
 	})
+}
 
-	t.Run("Not allowed per policy", func(t *testing.T) {
-		ctrl := gomock.NewController(t)
-
-		releaseService := NewMockReleaseService(ctrl)
-		releaseService.EXPECT().Get(gomock.Any(), testTicketID).Return(&ticket.Ticket{
-			ID:     testTicketID,
-			DID:    targetDID,
-			Status: 0,
-		}, nil)
-
-		protectService := NewMockProtectService(ctrl)
-		protectService.EXPECT().Get(gomock.Any(), targetDID).Return(&protect.ProtectedData{
-			PolicyID: testPolicyID,
-		}, nil)
-
-		policyService := NewMockPolicyService(ctrl)
-		policyService.EXPECT().Check(gomock.Any(), testPolicyID, subjectDID, policy.Approver).Return(policy.ErrNotAllowed)
-
-		subjectResolver := NewMockSubjectResolver(ctrl)
-		subjectResolver.EXPECT().Resolve(gomock.Any()).Return(subjectDID, nil)
+func TestRotateHandler(t *testing.T) {
+	req := &operation.RotateRequest{
+		DID:    targetDID,
+		Target: "test ssn",
+	}
 
-		op := &operation.Operation{
-			ReleaseService:  releaseService,
-			PolicyService:   policyService,
-			ProtectService:  protectService,
-			SubjectResolver: subjectResolver,
-		}
+	previous := &protect.ProtectedData{DID: targetDID, PolicyID: testPolicyID}
+	rotated := &protect.ProtectedData{DID: "did:example:rotated", PolicyID: testPolicyID}
 
-		rr := handleRequest(t, op, "/v1/release/test-ticket/authorize", http.MethodPost, nil)
+	continuity := &verifiable.Credential{
+		ID:      "urn:uuid:continuity",
+		Context: []string{"https://www.w3.org/2018/credentials/v1"},
+		Types:   []string{"VerifiableCredential", protect.ContinuityCredentialType},
+		Issuer:  verifiable.Issuer{ID: "did:example:gatekeeper"},
+		Subject: rotated.DID,
+	}
 
-		require.Equal(t, http.StatusUnauthorized, rr.Code)
-	})
+	body, err := json.Marshal(req)
+	require.NoError(t, err)
 
-	t.Run("Fail to authorize ticket", func(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
-
-		releaseService := NewMockReleaseService(ctrl)
-		releaseService.EXPECT().Get(gomock.Any(), testTicketID).Return(&ticket.Ticket{
-			ID:     testTicketID,
-			DID:    targetDID,
-			Status: 0,
-		}, nil)
-		releaseService.EXPECT().Authorize(gomock.Any(), testTicketID, subjectDID).Return(errors.New("authorize error"))
+		defer ctrl.Finish()
 
 		protectService := NewMockProtectService(ctrl)
-		protectService.EXPECT().Get(gomock.Any(), targetDID).Return(&protect.ProtectedData{
-			PolicyID: testPolicyID,
-		}, nil)
+		protectService.EXPECT().Get(gomock.Any(), targetDID).Return(previous, nil)
+		protectService.EXPECT().Rotate(gomock.Any(), previous, req.Target).Return(rotated, continuity, nil)
 
 		policyService := NewMockPolicyService(ctrl)
-		policyService.EXPECT().Check(gomock.Any(), testPolicyID, subjectDID, policy.Approver).Return(nil)
+		policyService.EXPECT().Check(gomock.Any(), testPolicyID, subjectDID, policy.Collector).Return(nil)
 
 		subjectResolver := NewMockSubjectResolver(ctrl)
 		subjectResolver.EXPECT().Resolve(gomock.Any()).Return(subjectDID, nil)
 
 		op := &operation.Operation{
-			ReleaseService:  releaseService,
-			PolicyService:   policyService,
 			ProtectService:  protectService,
+			PolicyService:   policyService,
 			SubjectResolver: subjectResolver,
 		}
 
-		rr := handleRequest(t, op, "/v1/release/test-ticket/authorize", http.MethodPost, nil)
+		rr := handleRequest(t, op, "/v1/protect/rotate", http.MethodPost, bytes.NewReader(body))
 
-		require.Equal(t, http.StatusInternalServerError, rr.Code)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var resp operation.RotateResponse
+
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		require.Equal(t, rotated.DID, resp.DID)
+		require.Contains(t, string(resp.ContinuityCredential), "urn:uuid:continuity")
+		require.Empty(t, resp.ConsentReceipt)
 	})
-}
 
-func TestTicketStatusHandler(t *testing.T) {
-	t.Run("Success", func(t *testing.T) {
+	t.Run("Success with consent receipt", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
-
-		releaseService := NewMockReleaseService(ctrl)
-		releaseService.EXPECT().Get(gomock.Any(), testTicketID).Return(&ticket.Ticket{
-			ID:     testTicketID,
-			DID:    targetDID,
-			Status: 0,
-		}, nil)
+		defer ctrl.Finish()
 
 		protectService := NewMockProtectService(ctrl)
-		protectService.EXPECT().Get(gomock.Any(), targetDID).Return(&protect.ProtectedData{
-			PolicyID: testPolicyID,
-		}, nil)
+		protectService.EXPECT().Get(gomock.Any(), targetDID).Return(previous, nil)
+		protectService.EXPECT().Rotate(gomock.Any(), previous, req.Target).Return(rotated, continuity, nil)
 
 		policyService := NewMockPolicyService(ctrl)
-		policyService.EXPECT().Check(gomock.Any(), testPolicyID, subjectDID, policy.Handler).Return(nil)
+		policyService.EXPECT().Check(gomock.Any(), testPolicyID, subjectDID, policy.Collector).Return(nil)
+		policyService.EXPECT().Get(gomock.Any(), testPolicyID).Return(&policy.Policy{
+			ID:      testPolicyID,
+			Consent: &policy.Consent{Purposes: []string{"fraud prevention"}},
+		}, nil)
 
 		subjectResolver := NewMockSubjectResolver(ctrl)
 		subjectResolver.EXPECT().Resolve(gomock.Any()).Return(subjectDID, nil)
 
+		consentService := NewMockConsentService(ctrl)
+		consentService.EXPECT().Subject(gomock.Any(), targetDID).Return("did:example:data_subject", nil)
+		consentService.EXPECT().Issue(gomock.Any(), &consent.Receipt{
+			DID:        rotated.DID,
+			Subject:    "did:example:data_subject",
+			Controller: subjectDID,
+			PolicyID:   testPolicyID,
+			Purposes:   []string{"fraud prevention"},
+		}).Return(&verifiable.Credential{
+			ID:      "urn:uuid:receipt",
+			Context: []string{"https://www.w3.org/2018/credentials/v1"},
+			Types:   []string{"VerifiableCredential", consent.CredentialType},
+			Issuer:  verifiable.Issuer{ID: "did:example:gatekeeper"},
+			Subject: rotated.DID,
+		}, nil)
+
 		op := &operation.Operation{
-			ReleaseService:  releaseService,
-			PolicyService:   policyService,
 			ProtectService:  protectService,
+			PolicyService:   policyService,
 			SubjectResolver: subjectResolver,
+			ConsentService:  consentService,
 		}
 
-		rr := handleRequest(t, op, "/v1/release/test-ticket/status", http.MethodGet, nil)
+		rr := handleRequest(t, op, "/v1/protect/rotate", http.MethodPost, bytes.NewReader(body))
 
 		require.Equal(t, http.StatusOK, rr.Code)
-	})
 
-	t.Run("Ticket not found", func(t *testing.T) {
-		ctrl := gomock.NewController(t)
-
-		releaseService := NewMockReleaseService(ctrl)
-		releaseService.EXPECT().Get(gomock.Any(), testTicketID).Return(nil, storage.ErrDataNotFound)
-
-		op := &operation.Operation{
-			ReleaseService: releaseService,
-		}
-
-		rr := handleRequest(t, op, "/v1/release/test-ticket/status", http.MethodGet, nil)
+		var resp operation.RotateResponse
 
-		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		require.Contains(t, string(resp.ConsentReceipt), "urn:uuid:receipt")
 	})
 
-	t.Run("Fail to get ticket", func(t *testing.T) {
-		ctrl := gomock.NewController(t)
-
-		releaseService := NewMockReleaseService(ctrl)
-		releaseService.EXPECT().Get(gomock.Any(), testTicketID).Return(nil, errors.New("get error"))
-
-		op := &operation.Operation{
-			ReleaseService: releaseService,
-		}
-
-		rr := handleRequest(t, op, "/v1/release/test-ticket/status", http.MethodGet, nil)
+	t.Run("Fail to unmarshal request body", func(t *testing.T) {
+		rr := handleRequest(t, &operation.Operation{}, "/v1/protect/rotate", http.MethodPost,
+			bytes.NewReader([]byte("")))
 
-		require.Equal(t, http.StatusInternalServerError, rr.Code)
+		require.Equal(t, http.StatusBadRequest, rr.Code)
 	})
 
-	t.Run("Fail to get protected data", func(t *testing.T) {
+	t.Run("Protected data not found", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
-
-		releaseService := NewMockReleaseService(ctrl)
-		releaseService.EXPECT().Get(gomock.Any(), testTicketID).Return(&ticket.Ticket{
-			ID:     testTicketID,
-			DID:    targetDID,
-			Status: 0,
-		}, nil)
+		defer ctrl.Finish()
 
 		protectService := NewMockProtectService(ctrl)
-		protectService.EXPECT().Get(gomock.Any(), targetDID).Return(nil, errors.New("get error"))
+		protectService.EXPECT().Get(gomock.Any(), targetDID).Return(nil, storage.ErrDataNotFound)
 
-		op := &operation.Operation{
-			ReleaseService: releaseService,
-			ProtectService: protectService,
-		}
+		op := &operation.Operation{ProtectService: protectService}
 
-		rr := handleRequest(t, op, "/v1/release/test-ticket/status", http.MethodGet, nil)
+		rr := handleRequest(t, op, "/v1/protect/rotate", http.MethodPost, bytes.NewReader(body))
 
-		require.Equal(t, http.StatusInternalServerError, rr.Code)
+		require.Equal(t, http.StatusNotFound, rr.Code)
 	})
 
-	t.Run("Not allowed per policy", func(t *testing.T) {
+	t.Run("Fail to check policy: ErrNotAllowed", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
-
-		releaseService := NewMockReleaseService(ctrl)
-		releaseService.EXPECT().Get(gomock.Any(), testTicketID).Return(&ticket.Ticket{
-			ID:     testTicketID,
-			DID:    targetDID,
-			Status: 0,
-		}, nil)
+		defer ctrl.Finish()
 
 		protectService := NewMockProtectService(ctrl)
-		protectService.EXPECT().Get(gomock.Any(), targetDID).Return(&protect.ProtectedData{
-			PolicyID: testPolicyID,
-		}, nil)
+		protectService.EXPECT().Get(gomock.Any(), targetDID).Return(previous, nil)
 
 		policyService := NewMockPolicyService(ctrl)
-		policyService.EXPECT().Check(gomock.Any(), testPolicyID, subjectDID, policy.Handler).Return(policy.ErrNotAllowed)
+		policyService.EXPECT().Check(gomock.Any(), testPolicyID, subjectDID, policy.Collector).
+			Return(policy.ErrNotAllowed)
 
 		subjectResolver := NewMockSubjectResolver(ctrl)
 		subjectResolver.EXPECT().Resolve(gomock.Any()).Return(subjectDID, nil)
 
 		op := &operation.Operation{
-			ReleaseService:  releaseService,
-			PolicyService:   policyService,
 			ProtectService:  protectService,
+			PolicyService:   policyService,
 			SubjectResolver: subjectResolver,
 		}
 
-		rr := handleRequest(t, op, "/v1/release/test-ticket/status", http.MethodGet, nil)
+		rr := handleRequest(t, op, "/v1/protect/rotate", http.MethodPost, bytes.NewReader(body))
 
 		require.Equal(t, http.StatusUnauthorized, rr.Code)
 	})
-}
-
-func TestCollectHandler(t *testing.T) {
-	const (
-		testDID      = "did:example:test"
-		testPolicyID = "test-policy"
-		testTicketID = "ticket1234"
-		testQueryID  = "queryID1234"
-	)
-
-	protectedData := &protect.ProtectedData{PolicyID: testPolicyID}
 
-	t.Run("Success", func(t *testing.T) {
+	t.Run("Target does not match", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		defer ctrl.Finish()
 
-		releaseService := NewMockReleaseService(ctrl)
-		releaseService.EXPECT().Get(gomock.Any(), testTicketID).
-			Return(&ticket.Ticket{DID: testDID, Status: ticket.ReadyToCollect}, nil)
-
-		collectService := NewMockCollectService(ctrl)
-		collectService.EXPECT().Collect(gomock.Any(), protectedData, subjectDID).Return(testQueryID, nil)
-
 		protectService := NewMockProtectService(ctrl)
-		protectService.EXPECT().Get(gomock.Any(), testDID).
-			Return(protectedData, nil)
+		protectService.EXPECT().Get(gomock.Any(), targetDID).Return(previous, nil)
+		protectService.EXPECT().Rotate(gomock.Any(), previous, req.Target).Return(nil, nil, protect.ErrTargetMismatch)
 
 		policyService := NewMockPolicyService(ctrl)
-		policyService.EXPECT().Check(gomock.Any(), testPolicyID, subjectDID, policy.Handler).Return(nil)
+		policyService.EXPECT().Check(gomock.Any(), testPolicyID, subjectDID, policy.Collector).Return(nil)
 
 		subjectResolver := NewMockSubjectResolver(ctrl)
-		subjectResolver.EXPECT().Resolve(gomock.Any()).Return(subjectDID, nil).AnyTimes()
+		subjectResolver.EXPECT().Resolve(gomock.Any()).Return(subjectDID, nil)
 
 		op := &operation.Operation{
-			ReleaseService:  releaseService,
-			PolicyService:   policyService,
 			ProtectService:  protectService,
+			PolicyService:   policyService,
 			SubjectResolver: subjectResolver,
-			CollectService:  collectService,
 		}
 
-		rr := handleRequest(t, op, "/v1/release/"+testTicketID+"/collect", http.MethodPost, bytes.NewReader([]byte{}))
+		rr := handleRequest(t, op, "/v1/protect/rotate", http.MethodPost, bytes.NewReader(body))
 
-		require.Equal(t, http.StatusOK, rr.Code)
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), protect.ErrTargetMismatch.Error())
 	})
 
-	t.Run("Fail to get protected data", func(t *testing.T) {
+	t.Run("Fail to rotate DID", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		defer ctrl.Finish()
 
-		releaseService := NewMockReleaseService(ctrl)
-		releaseService.EXPECT().Get(gomock.Any(), testTicketID).
-			Return(&ticket.Ticket{DID: testDID, Status: ticket.ReadyToCollect}, nil).AnyTimes()
-
-		collectService := NewMockCollectService(ctrl)
-		collectService.EXPECT().Collect(gomock.Any(), protectedData, subjectDID).
-			Return(testQueryID, nil).AnyTimes()
-
 		protectService := NewMockProtectService(ctrl)
-		protectService.EXPECT().Get(gomock.Any(), testDID).Return(nil, errors.New("get error"))
+		protectService.EXPECT().Get(gomock.Any(), targetDID).Return(previous, nil)
+		protectService.EXPECT().Rotate(gomock.Any(), previous, req.Target).
+			Return(nil, nil, errors.New("create vault: error"))
 
 		policyService := NewMockPolicyService(ctrl)
-		policyService.EXPECT().Check(gomock.Any(), testPolicyID, subjectDID, policy.Handler).
-			Return(nil).AnyTimes()
+		policyService.EXPECT().Check(gomock.Any(), testPolicyID, subjectDID, policy.Collector).Return(nil)
 
 		subjectResolver := NewMockSubjectResolver(ctrl)
-		subjectResolver.EXPECT().Resolve(gomock.Any()).Return(subjectDID, nil).AnyTimes()
+		subjectResolver.EXPECT().Resolve(gomock.Any()).Return(subjectDID, nil)
 
 		op := &operation.Operation{
-			ReleaseService:  releaseService,
-			PolicyService:   policyService,
 			ProtectService:  protectService,
+			PolicyService:   policyService,
 			SubjectResolver: subjectResolver,
-			CollectService:  collectService,
 		}
 
-		rr := handleRequest(t, op, "/v1/release/"+testTicketID+"/collect", http.MethodPost, bytes.NewReader([]byte{}))
+		rr := handleRequest(t, op, "/v1/protect/rotate", http.MethodPost, bytes.NewReader(body))
 
 		require.Equal(t, http.StatusInternalServerError, rr.Code)
+		require.Contains(t, rr.Body.String(), "rotate did: create vault: error")
 	})
+}
 
-	t.Run("Fail to get ticket", func(t *testing.T) {
-		ctrl := gomock.NewController(t)
-		defer ctrl.Finish()
+func TestRepolicyHandler(t *testing.T) {
+	const (
+		newPolicyID = "new-policy"
+		path        = "/v1/protect/" + targetDID + "/repolicy"
+	)
 
-		releaseService := NewMockReleaseService(ctrl)
-		releaseService.EXPECT().Get(gomock.Any(), testTicketID).
-			Return(nil, errors.New("get error"))
+	req := &operation.RepolicyRequest{
+		Target: "test ssn",
+		Policy: newPolicyID,
+	}
 
-		collectService := NewMockCollectService(ctrl)
-		collectService.EXPECT().Collect(gomock.Any(), protectedData, subjectDID).
-			Return(testQueryID, nil).AnyTimes()
+	previous := &protect.ProtectedData{DID: targetDID, PolicyID: testPolicyID}
+	moved := &protect.ProtectedData{DID: targetDID, PolicyID: newPolicyID}
 
-		protectService := NewMockProtectService(ctrl)
-		protectService.EXPECT().Get(gomock.Any(), testDID).Return(protectedData, nil).AnyTimes()
+	body, err := json.Marshal(req)
+	require.NoError(t, err)
 
+	// newOperation returns an operation with a subject allowed to collect under both policies.
+	newOperation := func(ctrl *gomock.Controller, protectService *MockProtectService) (*operation.Operation,
+		*MockPolicyService) {
 		policyService := NewMockPolicyService(ctrl)
-		policyService.EXPECT().Check(gomock.Any(), testPolicyID, subjectDID, policy.Handler).
-			Return(nil).AnyTimes()
+		policyService.EXPECT().Check(gomock.Any(), testPolicyID, subjectDID, policy.Collector).Return(nil)
+		policyService.EXPECT().Check(gomock.Any(), newPolicyID, subjectDID, policy.Collector).Return(nil)
 
 		subjectResolver := NewMockSubjectResolver(ctrl)
-		subjectResolver.EXPECT().Resolve(gomock.Any()).Return(subjectDID, nil).AnyTimes()
+		subjectResolver.EXPECT().Resolve(gomock.Any()).Return(subjectDID, nil).Times(2)
 
-		op := &operation.Operation{
-			ReleaseService:  releaseService,
-			PolicyService:   policyService,
+		return &operation.Operation{
 			ProtectService:  protectService,
+			PolicyService:   policyService,
 			SubjectResolver: subjectResolver,
-			CollectService:  collectService,
-		}
+		}, policyService
+	}
 
-		rr := handleRequest(t, op, "/v1/release/"+testTicketID+"/collect", http.MethodPost, bytes.NewReader([]byte{}))
+	t.Run("Success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
 
-		require.Equal(t, http.StatusBadRequest, rr.Code)
+		protectService := NewMockProtectService(ctrl)
+		protectService.EXPECT().Get(gomock.Any(), targetDID).Return(previous, nil)
+		protectService.EXPECT().Repolicy(gomock.Any(), previous, req.Target, newPolicyID, false).Return(moved, nil)
+
+		op, _ := newOperation(ctrl, protectService)
+
+		rr := handleRequest(t, op, path, http.MethodPost, bytes.NewReader(body))
+
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var resp operation.RepolicyResponse
+
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		require.Equal(t, targetDID, resp.DID)
+		require.Empty(t, resp.ConsentReceipt)
 	})
 
-	t.Run("Fail to check policy: ErrNotAllowed", func(t *testing.T) {
+	t.Run("Success with re-anonymization and consent receipt", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		defer ctrl.Finish()
 
-		releaseService := NewMockReleaseService(ctrl)
-		releaseService.EXPECT().Get(gomock.Any(), testTicketID).
-			Return(&ticket.Ticket{DID: testDID, Status: ticket.ReadyToCollect}, nil).AnyTimes()
-
-		collectService := NewMockCollectService(ctrl)
-		collectService.EXPECT().Collect(gomock.Any(), protectedData, subjectDID).
-			Return(testQueryID, nil).AnyTimes()
+		reanonymized := &protect.ProtectedData{DID: "did:example:reanonymized", PolicyID: newPolicyID}
 
 		protectService := NewMockProtectService(ctrl)
-		protectService.EXPECT().Get(gomock.Any(), testDID).Return(protectedData, nil).AnyTimes()
+		protectService.EXPECT().Get(gomock.Any(), targetDID).Return(previous, nil)
+		protectService.EXPECT().Repolicy(gomock.Any(), previous, req.Target, newPolicyID, true).
+			Return(reanonymized, nil)
 
-		policyService := NewMockPolicyService(ctrl)
-		policyService.EXPECT().Check(gomock.Any(), testPolicyID, subjectDID, policy.Handler).
-			Return(policy.ErrNotAllowed)
+		op, policyService := newOperation(ctrl, protectService)
 
-		subjectResolver := NewMockSubjectResolver(ctrl)
-		subjectResolver.EXPECT().Resolve(gomock.Any()).Return(subjectDID, nil).AnyTimes()
+		policyService.EXPECT().Get(gomock.Any(), newPolicyID).Return(&policy.Policy{
+			ID:      newPolicyID,
+			Consent: &policy.Consent{Purposes: []string{"research"}},
+		}, nil)
+
+		consentService := NewMockConsentService(ctrl)
+		consentService.EXPECT().Subject(gomock.Any(), targetDID).Return("did:example:data_subject", nil)
+		consentService.EXPECT().Issue(gomock.Any(), &consent.Receipt{
+			DID:        reanonymized.DID,
+			Subject:    "did:example:data_subject",
+			Controller: subjectDID,
+			PolicyID:   newPolicyID,
+			Purposes:   []string{"research"},
+		}).Return(&verifiable.Credential{
+			ID:      "urn:uuid:receipt",
+			Context: []string{"https://www.w3.org/2018/credentials/v1"},
+			Types:   []string{"VerifiableCredential", consent.CredentialType},
+			Issuer:  verifiable.Issuer{ID: "did:example:gatekeeper"},
+			Subject: reanonymized.DID,
+		}, nil)
+
+		op.ConsentService = consentService
+
+		reanonymize, e := json.Marshal(&operation.RepolicyRequest{Target: req.Target, Policy: newPolicyID, Reanonymize: true})
+		require.NoError(t, e)
+
+		rr := handleRequest(t, op, path, http.MethodPost, bytes.NewReader(reanonymize))
+
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var resp operation.RepolicyResponse
+
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		require.Equal(t, reanonymized.DID, resp.DID)
+		require.Contains(t, string(resp.ConsentReceipt), "urn:uuid:receipt")
+	})
+
+	t.Run("Fail to unmarshal request body", func(t *testing.T) {
+		rr := handleRequest(t, &operation.Operation{}, path, http.MethodPost, bytes.NewReader([]byte("")))
+
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("Protected data not found", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		protectService := NewMockProtectService(ctrl)
+		protectService.EXPECT().Get(gomock.Any(), targetDID).Return(nil, storage.ErrDataNotFound)
+
+		op := &operation.Operation{ProtectService: protectService}
+
+		rr := handleRequest(t, op, path, http.MethodPost, bytes.NewReader(body))
+
+		require.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("Not a collector of the new policy", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		protectService := NewMockProtectService(ctrl)
+		protectService.EXPECT().Get(gomock.Any(), targetDID).Return(previous, nil)
+
+		policyService := NewMockPolicyService(ctrl)
+		policyService.EXPECT().Check(gomock.Any(), testPolicyID, subjectDID, policy.Collector).Return(nil)
+		policyService.EXPECT().Check(gomock.Any(), newPolicyID, subjectDID, policy.Collector).
+			Return(policy.ErrNotAllowed)
+
+		subjectResolver := NewMockSubjectResolver(ctrl)
+		subjectResolver.EXPECT().Resolve(gomock.Any()).Return(subjectDID, nil).Times(2)
 
 		op := &operation.Operation{
-			ReleaseService:  releaseService,
-			PolicyService:   policyService,
 			ProtectService:  protectService,
+			PolicyService:   policyService,
 			SubjectResolver: subjectResolver,
-			CollectService:  collectService,
 		}
 
-		rr := handleRequest(t, op, "/v1/release/"+testTicketID+"/collect", http.MethodPost, bytes.NewReader([]byte{}))
+		rr := handleRequest(t, op, path, http.MethodPost, bytes.NewReader(body))
 
 		require.Equal(t, http.StatusUnauthorized, rr.Code)
 	})
 
-	t.Run("Unauthorized to collect data", func(t *testing.T) {
+	t.Run("Target does not match", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		defer ctrl.Finish()
 
-		releaseService := NewMockReleaseService(ctrl)
-		releaseService.EXPECT().Get(gomock.Any(), testTicketID).
-			Return(&ticket.Ticket{DID: testDID, Status: ticket.New}, nil)
+		protectService := NewMockProtectService(ctrl)
+		protectService.EXPECT().Get(gomock.Any(), targetDID).Return(previous, nil)
+		protectService.EXPECT().Repolicy(gomock.Any(), previous, req.Target, newPolicyID, false).
+			Return(nil, protect.ErrTargetMismatch)
 
-		collectService := NewMockCollectService(ctrl)
-		collectService.EXPECT().Collect(gomock.Any(), protectedData, subjectDID).
-			Times(0)
+		op, _ := newOperation(ctrl, protectService)
+
+		rr := handleRequest(t, op, path, http.MethodPost, bytes.NewReader(body))
+
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), protect.ErrTargetMismatch.Error())
+	})
+
+	t.Run("Already protected under the new policy", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
 
 		protectService := NewMockProtectService(ctrl)
-		protectService.EXPECT().Get(gomock.Any(), testDID).Return(protectedData, nil)
+		protectService.EXPECT().Get(gomock.Any(), targetDID).Return(previous, nil)
+		protectService.EXPECT().Repolicy(gomock.Any(), previous, req.Target, newPolicyID, false).
+			Return(nil, protect.ErrAlreadyProtected)
+
+		op, _ := newOperation(ctrl, protectService)
+
+		rr := handleRequest(t, op, path, http.MethodPost, bytes.NewReader(body))
+
+		require.Equal(t, http.StatusConflict, rr.Code)
+	})
+
+	t.Run("Fail to move protected data", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		protectService := NewMockProtectService(ctrl)
+		protectService.EXPECT().Get(gomock.Any(), targetDID).Return(previous, nil)
+		protectService.EXPECT().Repolicy(gomock.Any(), previous, req.Target, newPolicyID, false).
+			Return(nil, errors.New("move protected data: error"))
+
+		op, _ := newOperation(ctrl, protectService)
+
+		rr := handleRequest(t, op, path, http.MethodPost, bytes.NewReader(body))
+
+		require.Equal(t, http.StatusInternalServerError, rr.Code)
+		require.Contains(t, rr.Body.String(), "repolicy data: move protected data: error")
+	})
+}
+
+func TestAnchoringHandler(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		updated := time.Now().UTC()
+
+		protectService := NewMockProtectService(ctrl)
+		protectService.EXPECT().Get(gomock.Any(), targetDID).Return(&protect.ProtectedData{
+			DID:      targetDID,
+			PolicyID: testPolicyID,
+		}, nil)
+		protectService.EXPECT().Anchoring(gomock.Any(), targetDID).Return(&protect.Anchoring{
+			DID:          targetDID,
+			Status:       protect.AnchorAnchored,
+			CanonicalDID: "did:orb:canonical",
+			Updated:      updated,
+		}, nil)
 
 		policyService := NewMockPolicyService(ctrl)
-		policyService.EXPECT().Check(gomock.Any(), testPolicyID, subjectDID, policy.Handler).
-			Return(nil).AnyTimes()
+		policyService.EXPECT().Check(gomock.Any(), testPolicyID, subjectDID, policy.Collector).Return(nil)
 
 		subjectResolver := NewMockSubjectResolver(ctrl)
-		subjectResolver.EXPECT().Resolve(gomock.Any()).Return(subjectDID, nil).AnyTimes()
+		subjectResolver.EXPECT().Resolve(gomock.Any()).Return(subjectDID, nil)
 
 		op := &operation.Operation{
-			ReleaseService:  releaseService,
 			PolicyService:   policyService,
 			ProtectService:  protectService,
 			SubjectResolver: subjectResolver,
-			CollectService:  collectService,
 		}
 
-		rr := handleRequest(t, op, "/v1/release/"+testTicketID+"/collect", http.MethodPost, bytes.NewReader([]byte{}))
+		rr := handleRequest(t, op, "/v1/protect/"+targetDID+"/anchoring", http.MethodGet, nil)
 
-		require.Equal(t, http.StatusUnauthorized, rr.Code)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var resp operation.AnchoringResponse
+
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		require.Equal(t, targetDID, resp.DID)
+		require.Equal(t, "anchored", resp.Status)
+		require.Equal(t, "did:orb:canonical", resp.CanonicalDID)
+		require.True(t, updated.Equal(*resp.Updated))
+		require.Equal(t, updated.Format(http.TimeFormat), rr.Header().Get("Last-Modified"))
+		require.Equal(t, "private, no-cache", rr.Header().Get("Cache-Control"))
 	})
 
-	t.Run("Fail to collect data", func(t *testing.T) {
+	t.Run("Not modified since", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
-		defer ctrl.Finish()
 
-		releaseService := NewMockReleaseService(ctrl)
-		releaseService.EXPECT().Get(gomock.Any(), testTicketID).
-			Return(&ticket.Ticket{DID: testDID, Status: ticket.ReadyToCollect}, nil)
+		updated := time.Now().UTC()
 
-		collectService := NewMockCollectService(ctrl)
-		collectService.EXPECT().Collect(gomock.Any(), protectedData, subjectDID).
-			Return("", errors.New("collect failed"))
+		protectService := NewMockProtectService(ctrl)
+		protectService.EXPECT().Get(gomock.Any(), targetDID).Return(&protect.ProtectedData{
+			DID:      targetDID,
+			PolicyID: testPolicyID,
+		}, nil).Times(2)
+		protectService.EXPECT().Anchoring(gomock.Any(), targetDID).Return(&protect.Anchoring{
+			DID:     targetDID,
+			Status:  protect.AnchorAnchored,
+			Updated: updated,
+		}, nil).Times(2)
+
+		policyService := NewMockPolicyService(ctrl)
+		policyService.EXPECT().Check(gomock.Any(), testPolicyID, subjectDID, policy.Collector).Return(nil).Times(2)
+
+		subjectResolver := NewMockSubjectResolver(ctrl)
+		subjectResolver.EXPECT().Resolve(gomock.Any()).Return(subjectDID, nil).Times(2)
+
+		op := &operation.Operation{
+			PolicyService:   policyService,
+			ProtectService:  protectService,
+			SubjectResolver: subjectResolver,
+		}
+
+		rr := handleRequestWithHeader(t, op, "/v1/protect/"+targetDID+"/anchoring", http.MethodGet, nil,
+			http.Header{"If-Modified-Since": []string{updated.Format(http.TimeFormat)}})
+
+		require.Equal(t, http.StatusNotModified, rr.Code)
+		require.Empty(t, rr.Body.String())
+
+		rr = handleRequestWithHeader(t, op, "/v1/protect/"+targetDID+"/anchoring", http.MethodGet, nil,
+			http.Header{"If-Modified-Since": []string{updated.Add(-time.Hour).Format(http.TimeFormat)}})
+
+		require.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("DID not anchored", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
 
 		protectService := NewMockProtectService(ctrl)
-		protectService.EXPECT().Get(gomock.Any(), testDID).Return(protectedData, nil)
+		protectService.EXPECT().Get(gomock.Any(), targetDID).Return(&protect.ProtectedData{
+			DID:      targetDID,
+			PolicyID: testPolicyID,
+		}, nil)
+		protectService.EXPECT().Anchoring(gomock.Any(), targetDID).Return(&protect.Anchoring{
+			DID:    targetDID,
+			Status: protect.AnchorNone,
+		}, nil)
 
 		policyService := NewMockPolicyService(ctrl)
-		policyService.EXPECT().Check(gomock.Any(), testPolicyID, subjectDID, policy.Handler).
-			Return(nil)
+		policyService.EXPECT().Check(gomock.Any(), testPolicyID, subjectDID, policy.Collector).Return(nil)
 
 		subjectResolver := NewMockSubjectResolver(ctrl)
-		subjectResolver.EXPECT().Resolve(gomock.Any()).Return(subjectDID, nil).AnyTimes()
+		subjectResolver.EXPECT().Resolve(gomock.Any()).Return(subjectDID, nil)
 
 		op := &operation.Operation{
-			ReleaseService:  releaseService,
 			PolicyService:   policyService,
 			ProtectService:  protectService,
 			SubjectResolver: subjectResolver,
-			CollectService:  collectService,
 		}
 
-		rr := handleRequest(t, op, "/v1/release/"+testTicketID+"/collect", http.MethodPost, bytes.NewReader([]byte{}))
+		rr := handleRequest(t, op, "/v1/protect/"+targetDID+"/anchoring", http.MethodGet, nil)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.JSONEq(t, `{"did":"`+targetDID+`","status":"none"}`, rr.Body.String())
+	})
+
+	t.Run("Protected data not found", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		protectService := NewMockProtectService(ctrl)
+		protectService.EXPECT().Get(gomock.Any(), targetDID).Return(nil, storage.ErrDataNotFound)
+
+		op := &operation.Operation{ProtectService: protectService}
+
+		rr := handleRequest(t, op, "/v1/protect/"+targetDID+"/anchoring", http.MethodGet, nil)
+
+		require.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("Not allowed per policy", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		protectService := NewMockProtectService(ctrl)
+		protectService.EXPECT().Get(gomock.Any(), targetDID).Return(&protect.ProtectedData{
+			DID:      targetDID,
+			PolicyID: testPolicyID,
+		}, nil)
+
+		policyService := NewMockPolicyService(ctrl)
+		policyService.EXPECT().Check(gomock.Any(), testPolicyID, subjectDID, policy.Collector).
+			Return(policy.ErrNotAllowed)
+
+		subjectResolver := NewMockSubjectResolver(ctrl)
+		subjectResolver.EXPECT().Resolve(gomock.Any()).Return(subjectDID, nil)
+
+		op := &operation.Operation{
+			PolicyService:   policyService,
+			ProtectService:  protectService,
+			SubjectResolver: subjectResolver,
+		}
+
+		rr := handleRequest(t, op, "/v1/protect/"+targetDID+"/anchoring", http.MethodGet, nil)
+
+		require.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+
+	t.Run("Fail to get anchoring", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		protectService := NewMockProtectService(ctrl)
+		protectService.EXPECT().Get(gomock.Any(), targetDID).Return(&protect.ProtectedData{
+			DID:      targetDID,
+			PolicyID: testPolicyID,
+		}, nil)
+		protectService.EXPECT().Anchoring(gomock.Any(), targetDID).Return(nil, errors.New("get error"))
+
+		policyService := NewMockPolicyService(ctrl)
+		policyService.EXPECT().Check(gomock.Any(), testPolicyID, subjectDID, policy.Collector).Return(nil)
+
+		subjectResolver := NewMockSubjectResolver(ctrl)
+		subjectResolver.EXPECT().Resolve(gomock.Any()).Return(subjectDID, nil)
+
+		op := &operation.Operation{
+			PolicyService:   policyService,
+			ProtectService:  protectService,
+			SubjectResolver: subjectResolver,
+		}
+
+		rr := handleRequest(t, op, "/v1/protect/"+targetDID+"/anchoring", http.MethodGet, nil)
 
 		require.Equal(t, http.StatusInternalServerError, rr.Code)
 	})
 }
 
-func TestExtractHandler(t *testing.T) {
-	const (
-		testQueryID = "queryID1234"
-	)
-
-	req := operation.ExtractRequest{
-		QueryID: testQueryID,
+func TestCreatePolicyHandler(t *testing.T) {
+	p := &policy.Policy{
+		Collectors:   []string{"did:example:ray_stantz"},
+		Handlers:     []string{"did:example:alter_peck"},
+		Approvers:    []string{"did:example:peter_venkman", "did:example:eon_spengler", "did:example:winton_zeddemore"},
+		MinApprovers: 2,
 	}
 
 	t.Run("Success", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		defer ctrl.Finish()
 
-		extractService := NewMockExtractService(ctrl)
-		extractService.EXPECT().Extract(gomock.Any(), testQueryID).Return("target", nil)
+		policyService := NewMockPolicyService(ctrl)
+		policyService.EXPECT().Get(gomock.Any(), "containment-policy").Return(nil, policy.ErrPolicyNotFound)
+		policyService.EXPECT().Save(gomock.Any(), gomock.Any()).Return(nil).Times(1)
 
 		op := &operation.Operation{
-			ExtractService: extractService,
+			PolicyService: policyService,
 		}
 
-		body, err := json.Marshal(req)
+		body, err := json.Marshal(p)
 		require.NoError(t, err)
 
-		rr := handleRequest(t, op, "/v1/extract", http.MethodPost, bytes.NewReader(body))
+		rr := handleRequest(t, op, "/v1/policy/containment-policy", http.MethodPut, bytes.NewReader(body))
 
 		require.Equal(t, http.StatusOK, rr.Code)
+		require.NotEmpty(t, rr.Header().Get("ETag"))
 	})
 
-	t.Run("Fail to unmarshal request body", func(t *testing.T) {
+	current := &policy.Policy{ID: "containment-policy", Approvers: []string{"did:example:peter_venkman"}}
+
+	etag, e := operation.ETag(current)
+	require.NoError(t, e)
+
+	t.Run("Replace policy with matching If-Match", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
 
-		extractService := NewMockExtractService(ctrl)
-		extractService.EXPECT().Extract(gomock.Any(), testQueryID).Return("target", nil).Times(0)
+		policyService := NewMockPolicyService(ctrl)
+		policyService.EXPECT().Get(gomock.Any(), "containment-policy").Return(current, nil)
+		policyService.EXPECT().Save(gomock.Any(), gomock.Any()).Return(nil).Times(1)
 
 		op := &operation.Operation{
-			ExtractService: extractService,
+			PolicyService: policyService,
 		}
 
-		rr := handleRequest(t, op, "/v1/extract", http.MethodPost, bytes.NewBufferString("invalid json"))
+		body, err := json.Marshal(p)
+		require.NoError(t, err)
 
-		require.Equal(t, http.StatusBadRequest, rr.Code)
+		rr := handleRequestWithHeader(t, op, "/v1/policy/containment-policy", http.MethodPut, bytes.NewReader(body),
+			http.Header{"If-Match": []string{`"other", ` + etag}})
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.NotEmpty(t, rr.Header().Get("ETag"))
+		require.NotEqual(t, etag, rr.Header().Get("ETag"))
 	})
 
-	t.Run("Fail to extract", func(t *testing.T) {
+	t.Run("Replace policy without If-Match", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		defer ctrl.Finish()
 
-		extractService := NewMockExtractService(ctrl)
-		extractService.EXPECT().Extract(gomock.Any(), testQueryID).Return("", errors.New("extract failed"))
+		policyService := NewMockPolicyService(ctrl)
+		policyService.EXPECT().Get(gomock.Any(), "containment-policy").Return(current, nil)
+		policyService.EXPECT().Save(gomock.Any(), gomock.Any()).Times(0)
 
 		op := &operation.Operation{
-			ExtractService: extractService,
+			PolicyService: policyService,
 		}
 
-		body, err := json.Marshal(req)
+		body, err := json.Marshal(p)
 		require.NoError(t, err)
 
-		rr := handleRequest(t, op, "/v1/extract", http.MethodPost, bytes.NewReader(body))
+		rr := handleRequest(t, op, "/v1/policy/containment-policy", http.MethodPut, bytes.NewReader(body))
 
-		require.Equal(t, http.StatusInternalServerError, rr.Code)
+		require.Equal(t, http.StatusPreconditionRequired, rr.Code)
+		require.Contains(t, rr.Body.String(), "If-Match header not set")
 	})
-}
 
-func handleRequest(t *testing.T, op *operation.Operation, path, method string, body io.Reader,
-) *httptest.ResponseRecorder {
-	t.Helper()
+	t.Run("Replace policy modified since it was read", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
 
-	router := mux.NewRouter()
+		policyService := NewMockPolicyService(ctrl)
+		policyService.EXPECT().Get(gomock.Any(), "containment-policy").Return(current, nil)
+		policyService.EXPECT().Save(gomock.Any(), gomock.Any()).Times(0)
 
-	for _, h := range op.GetRESTHandlers() {
-		router.HandleFunc(h.Path(), h.Handle()).Methods(h.Method())
-	}
+		op := &operation.Operation{
+			PolicyService: policyService,
+		}
 
-	req, err := http.NewRequestWithContext(context.Background(), method, path, body)
-	require.NoError(t, err)
+		body, err := json.Marshal(p)
+		require.NoError(t, err)
+
+		rr := handleRequestWithHeader(t, op, "/v1/policy/containment-policy", http.MethodPut, bytes.NewReader(body),
+			http.Header{"If-Match": []string{"W/" + etag}})
+
+		require.Equal(t, http.StatusPreconditionFailed, rr.Code)
+		require.Contains(t, rr.Body.String(), "modified since it was read")
+	})
+
+	t.Run("If-Match on missing policy", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		policyService := NewMockPolicyService(ctrl)
+		policyService.EXPECT().Get(gomock.Any(), "containment-policy").Return(nil, policy.ErrPolicyNotFound)
+		policyService.EXPECT().Save(gomock.Any(), gomock.Any()).Times(0)
+
+		op := &operation.Operation{
+			PolicyService: policyService,
+		}
+
+		body, err := json.Marshal(p)
+		require.NoError(t, err)
+
+		rr := handleRequestWithHeader(t, op, "/v1/policy/containment-policy", http.MethodPut, bytes.NewReader(body),
+			http.Header{"If-Match": []string{"*"}})
+
+		require.Equal(t, http.StatusPreconditionFailed, rr.Code)
+	})
+
+	t.Run("Concurrent replacements with the same If-Match", func(t *testing.T) {
+		policyService, err := policy.NewService(mem.NewProvider())
+		require.NoError(t, err)
+
+		require.NoError(t, policyService.Save(context.Background(), current))
+
+		op := &operation.Operation{
+			PolicyService: &slowPolicyService{Service: policyService},
+		}
+
+		const n = 10
+
+		var (
+			wg       sync.WaitGroup
+			mu       sync.Mutex
+			statuses = map[int]int{}
+		)
+
+		for i := 0; i < n; i++ {
+			wg.Add(1)
+
+			go func(i int) {
+				defer wg.Done()
+
+				status := http.StatusOK
+
+				_, e := op.SavePolicy(context.Background(), "containment-policy", etag, &policy.Policy{MinApprovers: i})
+				if e != nil {
+					status = operation.ErrorStatus(e)
+				}
+
+				mu.Lock()
+				statuses[status]++
+				mu.Unlock()
+			}(i)
+		}
+
+		wg.Wait()
+
+		require.Equal(t, map[int]int{http.StatusOK: 1, http.StatusPreconditionFailed: n - 1}, statuses)
+	})
+
+	t.Run("Fail to read current policy", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		policyService := NewMockPolicyService(ctrl)
+		policyService.EXPECT().Get(gomock.Any(), "containment-policy").Return(nil, errors.New("get error"))
+		policyService.EXPECT().Save(gomock.Any(), gomock.Any()).Times(0)
+
+		op := &operation.Operation{
+			PolicyService: policyService,
+		}
+
+		body, err := json.Marshal(p)
+		require.NoError(t, err)
+
+		rr := handleRequest(t, op, "/v1/policy/containment-policy", http.MethodPut, bytes.NewReader(body))
+
+		require.Equal(t, http.StatusInternalServerError, rr.Code)
+	})
+
+	t.Run("Fail to unmarshal request body", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		policyService := NewMockPolicyService(ctrl)
+		policyService.EXPECT().Save(gomock.Any(), gomock.Any()).Times(0)
+
+		op := &operation.Operation{
+			PolicyService: policyService,
+		}
+
+		rr := handleRequest(t, op, "/v1/policy/containment-policy", http.MethodPut,
+			bytes.NewBufferString("invalid json"))
+
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("Unknown field in strict mode", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		policyService := NewMockPolicyService(ctrl)
+		policyService.EXPECT().Save(gomock.Any(), gomock.Any()).Times(0)
+
+		op := &operation.Operation{
+			PolicyService: policyService,
+			StrictJSON:    true,
+		}
+
+		rr := handleRequest(t, op, "/v1/policy/containment-policy", http.MethodPut,
+			bytes.NewBufferString(`{"approverss":["did:example:peter_venkman"],"min_approvers":1}`))
+
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), `unknown field \"approverss\"`)
+	})
+
+	t.Run("Fail to store policy", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		policyService := NewMockPolicyService(ctrl)
+		policyService.EXPECT().Get(gomock.Any(), "containment-policy").Return(nil, policy.ErrPolicyNotFound)
+		policyService.EXPECT().Save(gomock.Any(), gomock.Any()).Return(errors.New("save error")).Times(1)
+
+		op := &operation.Operation{
+			PolicyService: policyService,
+		}
+
+		body, err := json.Marshal(p)
+		require.NoError(t, err)
+
+		rr := handleRequest(t, op, "/v1/policy/containment-policy", http.MethodPut, bytes.NewReader(body))
+
+		require.Equal(t, http.StatusInternalServerError, rr.Code)
+	})
+}
+
+// slowPolicyService reads the policies slowly, for the updates racing between the read and the write to overlap.
+type slowPolicyService struct {
+	*policy.Service
+}
+
+func (s *slowPolicyService) Get(ctx context.Context, policyID string) (*policy.Policy, error) {
+	p, err := s.Service.Get(ctx, policyID)
+
+	time.Sleep(10 * time.Millisecond)
+
+	return p, err
+}
+
+func TestGetPolicyHandler(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		policyService := NewMockPolicyService(ctrl)
+		policyService.EXPECT().Get(gomock.Any(), testPolicyID).Return(&policy.Policy{
+			ID:           testPolicyID,
+			MinApprovers: 2,
+		}, nil)
+
+		op := &operation.Operation{
+			PolicyService: policyService,
+		}
+
+		rr := handleRequest(t, op, "/v1/policy/Test-Policy", http.MethodGet, nil)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var p policy.Policy
+
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &p))
+		require.Equal(t, testPolicyID, p.ID)
+		require.Equal(t, 2, p.MinApprovers)
+
+		etag, err := operation.ETag(&p)
+		require.NoError(t, err)
+		require.Equal(t, etag, rr.Header().Get("ETag"))
+		require.Empty(t, rr.Header().Get("Last-Modified"))
+	})
+
+	t.Run("Not modified", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		p := &policy.Policy{ID: testPolicyID, MinApprovers: 2}
+
+		policyService := NewMockPolicyService(ctrl)
+		policyService.EXPECT().Get(gomock.Any(), testPolicyID).Return(p, nil).Times(2)
+
+		op := &operation.Operation{
+			PolicyService: policyService,
+		}
+
+		etag, err := operation.ETag(p)
+		require.NoError(t, err)
+
+		rr := handleRequestWithHeader(t, op, "/v1/policy/test-policy", http.MethodGet, nil,
+			http.Header{"If-None-Match": []string{`"other", W/` + etag}})
+
+		require.Equal(t, http.StatusNotModified, rr.Code)
+		require.Equal(t, etag, rr.Header().Get("ETag"))
+		require.Empty(t, rr.Body.String())
+
+		rr = handleRequestWithHeader(t, op, "/v1/policy/test-policy", http.MethodGet, nil,
+			http.Header{"If-None-Match": []string{`"other"`}})
+
+		require.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("Policy not found", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		policyService := NewMockPolicyService(ctrl)
+		policyService.EXPECT().Get(gomock.Any(), testPolicyID).Return(nil, policy.ErrPolicyNotFound)
+
+		op := &operation.Operation{
+			PolicyService: policyService,
+		}
+
+		rr := handleRequest(t, op, "/v1/policy/test-policy", http.MethodGet, nil)
+
+		require.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("Fail to get policy", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		policyService := NewMockPolicyService(ctrl)
+		policyService.EXPECT().Get(gomock.Any(), testPolicyID).Return(nil, errors.New("get error"))
+
+		op := &operation.Operation{
+			PolicyService: policyService,
+		}
+
+		rr := handleRequest(t, op, "/v1/policy/test-policy", http.MethodGet, nil)
+
+		require.Equal(t, http.StatusInternalServerError, rr.Code)
+	})
+}
+
+func TestDeletePolicyHandler(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		policyService := NewMockPolicyService(ctrl)
+		policyService.EXPECT().Get(gomock.Any(), testPolicyID).Return(&policy.Policy{ID: testPolicyID}, nil)
+		policyService.EXPECT().Delete(gomock.Any(), testPolicyID).Return(nil)
+
+		op := &operation.Operation{
+			PolicyService: policyService,
+		}
+
+		rr := handleRequest(t, op, "/v1/policy/Test-Policy", http.MethodDelete, nil)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("Policy not found", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		policyService := NewMockPolicyService(ctrl)
+		policyService.EXPECT().Get(gomock.Any(), testPolicyID).Return(nil, policy.ErrPolicyNotFound)
+
+		op := &operation.Operation{
+			PolicyService: policyService,
+		}
+
+		rr := handleRequest(t, op, "/v1/policy/test-policy", http.MethodDelete, nil)
+
+		require.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("Fail to delete policy", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		policyService := NewMockPolicyService(ctrl)
+		policyService.EXPECT().Get(gomock.Any(), testPolicyID).Return(&policy.Policy{ID: testPolicyID}, nil)
+		policyService.EXPECT().Delete(gomock.Any(), testPolicyID).Return(errors.New("delete error"))
+
+		op := &operation.Operation{
+			PolicyService: policyService,
+		}
+
+		rr := handleRequest(t, op, "/v1/policy/test-policy", http.MethodDelete, nil)
+
+		require.Equal(t, http.StatusInternalServerError, rr.Code)
+		require.Contains(t, rr.Body.String(), "delete error")
+	})
+}
+
+func TestListPoliciesHandler(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		policyService := NewMockPolicyService(ctrl)
+		policyService.EXPECT().Iterate(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, _ int, fn func(p *policy.Policy) error) error {
+				for _, id := range []string{"policy-1", "policy-2"} {
+					if err := fn(&policy.Policy{ID: id}); err != nil {
+						return err
+					}
+				}
+
+				return nil
+			})
+
+		op := &operation.Operation{
+			PolicyService: policyService,
+		}
+
+		rr := handleRequest(t, op, "/v1/policy", http.MethodGet, nil)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var resp operation.ListPoliciesResponse
+
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		require.Len(t, resp.Policies, 2)
+		require.Equal(t, "policy-2", resp.Policies[1].ID)
+	})
+
+	t.Run("No policies", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		policyService := NewMockPolicyService(ctrl)
+		policyService.EXPECT().Iterate(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+
+		op := &operation.Operation{
+			PolicyService: policyService,
+		}
+
+		rr := handleRequest(t, op, "/v1/policy", http.MethodGet, nil)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.JSONEq(t, `{"policies":[]}`, rr.Body.String())
+	})
+
+	t.Run("Fail to list policies", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		policyService := NewMockPolicyService(ctrl)
+		policyService.EXPECT().Iterate(gomock.Any(), gomock.Any(), gomock.Any()).Return(errors.New("query error"))
+
+		op := &operation.Operation{
+			PolicyService: policyService,
+		}
+
+		rr := handleRequest(t, op, "/v1/policy", http.MethodGet, nil)
+
+		require.Equal(t, http.StatusInternalServerError, rr.Code)
+		require.Contains(t, rr.Body.String(), "list policies: query error")
+	})
+}
+
+func TestEvaluateHandler(t *testing.T) {
+	const actor = "did:example:actor"
+
+	t.Run("Success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		policyService := NewMockPolicyService(ctrl)
+		policyService.EXPECT().Get(gomock.Any(), "policy-1").Return(&policy.Policy{
+			ID:         "policy-1",
+			Collectors: []string{actor},
+			Approvers:  []string{actor},
+		}, nil)
+		policyService.EXPECT().Get(gomock.Any(), "policy-2").Return(nil, policy.ErrPolicyNotFound)
+
+		op := &operation.Operation{
+			PolicyService: policyService,
+		}
+
+		body, err := json.Marshal(&operation.EvaluateRequest{
+			Actor: actor,
+			Evaluations: []*operation.Evaluation{
+				{PolicyID: "policy-1", Operation: "protect"},
+				{PolicyID: "Policy-1", Operation: "release"},
+				{PolicyID: "policy-1", Operation: "approve"},
+				{PolicyID: "policy-1", Operation: "break_glass"},
+				{PolicyID: "policy-1", Operation: "delete"},
+				{PolicyID: "policy-2", Operation: "protect"},
+			},
+		})
+		require.NoError(t, err)
+
+		rr := handleRequest(t, op, "/v1/policy/evaluate", http.MethodPost, bytes.NewReader(body))
+
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var resp operation.EvaluateResponse
+
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		require.Equal(t, []*operation.EvaluationResult{
+			{PolicyID: "policy-1", Operation: "protect", Allowed: true},
+			{PolicyID: "Policy-1", Operation: "release"},
+			{PolicyID: "policy-1", Operation: "approve", Allowed: true},
+			{PolicyID: "policy-1", Operation: "break_glass"},
+			{PolicyID: "policy-1", Operation: "delete", Error: `unknown operation "delete"`},
+			{PolicyID: "policy-2", Operation: "protect", Error: "policy not found"},
+		}, resp.Results)
+	})
+
+	t.Run("Missing actor", func(t *testing.T) {
+		op := &operation.Operation{}
+
+		rr := handleRequest(t, op, "/v1/policy/evaluate", http.MethodPost,
+			bytes.NewBufferString(`{"evaluations":[{"policy_id":"policy-1","operation":"protect"}]}`))
+
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "actor is required")
+	})
+
+	t.Run("Too many evaluations", func(t *testing.T) {
+		op := &operation.Operation{}
+
+		req := &operation.EvaluateRequest{Actor: actor}
+
+		for i := 0; i <= 100; i++ {
+			req.Evaluations = append(req.Evaluations, &operation.Evaluation{PolicyID: "policy-1", Operation: "protect"})
+		}
+
+		body, err := json.Marshal(req)
+		require.NoError(t, err)
+
+		rr := handleRequest(t, op, "/v1/policy/evaluate", http.MethodPost, bytes.NewReader(body))
+
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "more than 100 evaluations")
+	})
+
+	t.Run("Invalid request", func(t *testing.T) {
+		op := &operation.Operation{}
+
+		rr := handleRequest(t, op, "/v1/policy/evaluate", http.MethodPost, bytes.NewBufferString("invalid json"))
+
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("Fail to get policy", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		policyService := NewMockPolicyService(ctrl)
+		policyService.EXPECT().Get(gomock.Any(), "policy-1").Return(nil, errors.New("get error"))
+
+		op := &operation.Operation{
+			PolicyService: policyService,
+		}
+
+		rr := handleRequest(t, op, "/v1/policy/evaluate", http.MethodPost, bytes.NewBufferString(
+			`{"actor":"did:example:actor","evaluations":[{"policy_id":"policy-1","operation":"protect"}]}`))
+
+		require.Equal(t, http.StatusInternalServerError, rr.Code)
+	})
+}
+
+func TestReleaseHandler(t *testing.T) {
+	req := operation.ReleaseRequest{
+		DID: targetDID,
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		releaseService := NewMockReleaseService(ctrl)
+		releaseService.EXPECT().Release(gomock.Any(), targetDID, testPolicyID, subjectDID, "fraud investigation").
+			Return(&ticket.Ticket{}, nil).Times(1)
+
+		protectService := NewMockProtectService(ctrl)
+		protectService.EXPECT().Get(gomock.Any(), targetDID).
+			Return(&protect.ProtectedData{PolicyID: testPolicyID}, nil).Times(1)
+
+		policyService := NewMockPolicyService(ctrl)
+		policyService.EXPECT().Check(gomock.Any(), testPolicyID, subjectDID, policy.Handler).Return(nil).Times(1)
+		policyService.EXPECT().Get(gomock.Any(), testPolicyID).Return(&policy.Policy{ID: testPolicyID}, nil).Times(1)
+
+		subjectResolver := NewMockSubjectResolver(ctrl)
+		subjectResolver.EXPECT().Resolve(gomock.Any()).Return(subjectDID, nil)
+
+		op := &operation.Operation{
+			ReleaseService:  releaseService,
+			PolicyService:   policyService,
+			ProtectService:  protectService,
+			SubjectResolver: subjectResolver,
+		}
+
+		body, err := json.Marshal(&operation.ReleaseRequest{DID: targetDID, Justification: "fraud investigation"})
+		require.NoError(t, err)
+
+		rr := handleRequest(t, op, "/v1/release", http.MethodPost, bytes.NewReader(body))
+
+		require.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("Justification required by policy", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		releaseService := NewMockReleaseService(ctrl)
+		releaseService.EXPECT().Release(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+		protectService := NewMockProtectService(ctrl)
+		protectService.EXPECT().Get(gomock.Any(), targetDID).
+			Return(&protect.ProtectedData{PolicyID: testPolicyID}, nil).Times(1)
+
+		policyService := NewMockPolicyService(ctrl)
+		policyService.EXPECT().Check(gomock.Any(), testPolicyID, subjectDID, policy.Handler).Return(nil).Times(1)
+		policyService.EXPECT().Get(gomock.Any(), testPolicyID).
+			Return(&policy.Policy{ID: testPolicyID, RequireJustification: true}, nil)
+
+		subjectResolver := NewMockSubjectResolver(ctrl)
+		subjectResolver.EXPECT().Resolve(gomock.Any()).Return(subjectDID, nil)
+
+		op := &operation.Operation{
+			ReleaseService:  releaseService,
+			PolicyService:   policyService,
+			ProtectService:  protectService,
+			SubjectResolver: subjectResolver,
+		}
+
+		body, err := json.Marshal(&operation.ReleaseRequest{DID: targetDID})
+		require.NoError(t, err)
+
+		rr := handleRequest(t, op, "/v1/release", http.MethodPost, bytes.NewReader(body))
+
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "justification is required by the policy")
+	})
+
+	t.Run("Fail to unmarshal request body", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		svc := NewMockReleaseService(ctrl)
+		svc.EXPECT().Release(gomock.Any(), targetDID, gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+		op := &operation.Operation{
+			ReleaseService: svc,
+		}
+
+		rr := handleRequest(t, op, "/v1/release", http.MethodPost, bytes.NewBufferString("invalid json"))
+
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("Fail to get protected data", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		releaseService := NewMockReleaseService(ctrl)
+		releaseService.EXPECT().Release(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+		protectService := NewMockProtectService(ctrl)
+		protectService.EXPECT().Get(gomock.Any(), targetDID).Return(nil, errors.New("get error")).Times(1)
+
+		policyService := NewMockPolicyService(ctrl)
+		policyService.EXPECT().Check(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+		subjectResolver := NewMockSubjectResolver(ctrl)
+		subjectResolver.EXPECT().Resolve(gomock.Any()).Times(0)
+
+		op := &operation.Operation{
+			ReleaseService:  releaseService,
+			PolicyService:   policyService,
+			ProtectService:  protectService,
+			SubjectResolver: subjectResolver,
+		}
+
+		body, err := json.Marshal(req)
+		require.NoError(t, err)
+
+		rr := handleRequest(t, op, "/v1/release", http.MethodPost, bytes.NewReader(body))
+
+		require.Equal(t, http.StatusInternalServerError, rr.Code)
+	})
+
+	t.Run("Fail to check policy: ErrNotAllowed", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		releaseService := NewMockReleaseService(ctrl)
+		releaseService.EXPECT().Release(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+		protectService := NewMockProtectService(ctrl)
+		protectService.EXPECT().Get(gomock.Any(), targetDID).
+			Return(&protect.ProtectedData{PolicyID: testPolicyID}, nil).Times(1)
+
+		policyService := NewMockPolicyService(ctrl)
+		policyService.EXPECT().Check(gomock.Any(), testPolicyID, subjectDID, policy.Handler).
+			Return(policy.ErrNotAllowed).Times(1)
+
+		subjectResolver := NewMockSubjectResolver(ctrl)
+		subjectResolver.EXPECT().Resolve(gomock.Any()).Return(subjectDID, nil)
+
+		op := &operation.Operation{
+			ReleaseService:  releaseService,
+			PolicyService:   policyService,
+			ProtectService:  protectService,
+			SubjectResolver: subjectResolver,
+		}
+
+		body, err := json.Marshal(req)
+		require.NoError(t, err)
+
+		rr := handleRequest(t, op, "/v1/release", http.MethodPost, bytes.NewReader(body))
+
+		require.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+
+	t.Run("Fail to create release transaction on a DID", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		releaseService := NewMockReleaseService(ctrl)
+		releaseService.EXPECT().Release(gomock.Any(), targetDID, gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(nil, errors.New("release error"))
+
+		protectService := NewMockProtectService(ctrl)
+		protectService.EXPECT().Get(gomock.Any(), targetDID).
+			Return(&protect.ProtectedData{PolicyID: testPolicyID}, nil).Times(1)
+
+		policyService := NewMockPolicyService(ctrl)
+		policyService.EXPECT().Check(gomock.Any(), testPolicyID, subjectDID, policy.Handler).Return(nil).Times(1)
+		policyService.EXPECT().Get(gomock.Any(), testPolicyID).Return(&policy.Policy{ID: testPolicyID}, nil).Times(1)
+
+		subjectResolver := NewMockSubjectResolver(ctrl)
+		subjectResolver.EXPECT().Resolve(gomock.Any()).Return(subjectDID, nil)
+
+		op := &operation.Operation{
+			ReleaseService:  releaseService,
+			PolicyService:   policyService,
+			ProtectService:  protectService,
+			SubjectResolver: subjectResolver,
+		}
+
+		body, err := json.Marshal(req)
+		require.NoError(t, err)
+
+		rr := handleRequest(t, op, "/v1/release", http.MethodPost, bytes.NewReader(body))
+
+		require.Equal(t, http.StatusInternalServerError, rr.Code)
+	})
+
+	t.Run("Success with presentation", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		pd := &presexch.PresentationDefinition{ID: "test-pd"}
+		vp := []byte(`{"type":"VerifiablePresentation"}`)
+
+		releaseService := NewMockReleaseService(ctrl)
+		releaseService.EXPECT().Release(gomock.Any(), targetDID, gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(&ticket.Ticket{}, nil).Times(1)
+
+		protectService := NewMockProtectService(ctrl)
+		protectService.EXPECT().Get(gomock.Any(), targetDID).
+			Return(&protect.ProtectedData{PolicyID: testPolicyID}, nil).Times(1)
+
+		policyService := NewMockPolicyService(ctrl)
+		policyService.EXPECT().Check(gomock.Any(), testPolicyID, subjectDID, policy.Handler).Return(nil).Times(1)
+		policyService.EXPECT().Get(gomock.Any(), testPolicyID).
+			Return(&policy.Policy{ID: testPolicyID, PresentationDefinition: pd}, nil).Times(1)
+
+		presentationVerifier := NewMockPresentationVerifier(ctrl)
+		presentationVerifier.EXPECT().Verify(gomock.Any(), pd, gomock.Any(), subjectDID).Return(nil).Times(1)
+
+		subjectResolver := NewMockSubjectResolver(ctrl)
+		subjectResolver.EXPECT().Resolve(gomock.Any()).Return(subjectDID, nil)
+
+		op := &operation.Operation{
+			ReleaseService:       releaseService,
+			PolicyService:        policyService,
+			ProtectService:       protectService,
+			PresentationVerifier: presentationVerifier,
+			SubjectResolver:      subjectResolver,
+		}
+
+		body, err := json.Marshal(operation.ReleaseRequest{DID: targetDID, Presentation: vp})
+		require.NoError(t, err)
+
+		rr := handleRequest(t, op, "/v1/release", http.MethodPost, bytes.NewReader(body))
+
+		require.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("Fail to get policy", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		releaseService := NewMockReleaseService(ctrl)
+		releaseService.EXPECT().Release(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+		protectService := NewMockProtectService(ctrl)
+		protectService.EXPECT().Get(gomock.Any(), targetDID).
+			Return(&protect.ProtectedData{PolicyID: testPolicyID}, nil).Times(1)
+
+		policyService := NewMockPolicyService(ctrl)
+		policyService.EXPECT().Check(gomock.Any(), testPolicyID, subjectDID, policy.Handler).Return(nil).Times(1)
+		policyService.EXPECT().Get(gomock.Any(), testPolicyID).Return(nil, errors.New("get error")).Times(1)
+
+		subjectResolver := NewMockSubjectResolver(ctrl)
+		subjectResolver.EXPECT().Resolve(gomock.Any()).Return(subjectDID, nil)
+
+		op := &operation.Operation{
+			ReleaseService:  releaseService,
+			PolicyService:   policyService,
+			ProtectService:  protectService,
+			SubjectResolver: subjectResolver,
+		}
+
+		body, err := json.Marshal(req)
+		require.NoError(t, err)
+
+		rr := handleRequest(t, op, "/v1/release", http.MethodPost, bytes.NewReader(body))
+
+		require.Equal(t, http.StatusInternalServerError, rr.Code)
+	})
+
+	t.Run("Missing presentation", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		releaseService := NewMockReleaseService(ctrl)
+		releaseService.EXPECT().Release(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+		protectService := NewMockProtectService(ctrl)
+		protectService.EXPECT().Get(gomock.Any(), targetDID).
+			Return(&protect.ProtectedData{PolicyID: testPolicyID}, nil).Times(1)
+
+		policyService := NewMockPolicyService(ctrl)
+		policyService.EXPECT().Check(gomock.Any(), testPolicyID, subjectDID, policy.Handler).Return(nil).Times(1)
+		policyService.EXPECT().Get(gomock.Any(), testPolicyID).
+			Return(&policy.Policy{ID: testPolicyID, PresentationDefinition: &presexch.PresentationDefinition{}}, nil)
+
+		subjectResolver := NewMockSubjectResolver(ctrl)
+		subjectResolver.EXPECT().Resolve(gomock.Any()).Return(subjectDID, nil)
+
+		op := &operation.Operation{
+			ReleaseService:  releaseService,
+			PolicyService:   policyService,
+			ProtectService:  protectService,
+			SubjectResolver: subjectResolver,
+		}
+
+		body, err := json.Marshal(req)
+		require.NoError(t, err)
+
+		rr := handleRequest(t, op, "/v1/release", http.MethodPost, bytes.NewReader(body))
+
+		require.Equal(t, http.StatusUnauthorized, rr.Code)
+		require.Contains(t, rr.Body.String(), "missing presentation")
+	})
+
+	t.Run("Fail to verify presentation", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		releaseService := NewMockReleaseService(ctrl)
+		releaseService.EXPECT().Release(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+		protectService := NewMockProtectService(ctrl)
+		protectService.EXPECT().Get(gomock.Any(), targetDID).
+			Return(&protect.ProtectedData{PolicyID: testPolicyID}, nil).Times(1)
+
+		policyService := NewMockPolicyService(ctrl)
+		policyService.EXPECT().Check(gomock.Any(), testPolicyID, subjectDID, policy.Handler).Return(nil).Times(1)
+		policyService.EXPECT().Get(gomock.Any(), testPolicyID).
+			Return(&policy.Policy{ID: testPolicyID, PresentationDefinition: &presexch.PresentationDefinition{}}, nil)
+
+		presentationVerifier := NewMockPresentationVerifier(ctrl)
+		presentationVerifier.EXPECT().Verify(gomock.Any(), gomock.Any(), gomock.Any(), subjectDID).
+			Return(errors.New("verify error")).Times(1)
+
+		subjectResolver := NewMockSubjectResolver(ctrl)
+		subjectResolver.EXPECT().Resolve(gomock.Any()).Return(subjectDID, nil)
+
+		op := &operation.Operation{
+			ReleaseService:       releaseService,
+			PolicyService:        policyService,
+			ProtectService:       protectService,
+			PresentationVerifier: presentationVerifier,
+			SubjectResolver:      subjectResolver,
+		}
+
+		body, err := json.Marshal(operation.ReleaseRequest{DID: targetDID, Presentation: []byte(`{}`)})
+		require.NoError(t, err)
+
+		rr := handleRequest(t, op, "/v1/release", http.MethodPost, bytes.NewReader(body))
+
+		require.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+}
+
+func TestOIDC4VP(t *testing.T) {
+	definition := &presexch.PresentationDefinition{ID: "authorized-handler"}
+
+	newOperation := func(t *testing.T, p *policy.Policy) (*operation.Operation, *MockOIDC4VPVerifier) {
+		t.Helper()
+
+		ctrl := gomock.NewController(t)
+
+		releaseService := NewMockReleaseService(ctrl)
+		releaseService.EXPECT().Release(gomock.Any(), targetDID, testPolicyID, subjectDID, gomock.Any()).
+			Return(&ticket.Ticket{ID: testTicketID}, nil).AnyTimes()
+
+		protectService := NewMockProtectService(ctrl)
+		protectService.EXPECT().Get(gomock.Any(), targetDID).
+			Return(&protect.ProtectedData{PolicyID: testPolicyID}, nil).AnyTimes()
+
+		policyService := NewMockPolicyService(ctrl)
+		policyService.EXPECT().Check(gomock.Any(), testPolicyID, subjectDID, policy.Handler).Return(nil).AnyTimes()
+		policyService.EXPECT().Get(gomock.Any(), testPolicyID).Return(p, nil).AnyTimes()
+
+		subjectResolver := NewMockSubjectResolver(ctrl)
+		subjectResolver.EXPECT().Resolve(gomock.Any()).Return(subjectDID, nil).AnyTimes()
+
+		verifier := NewMockOIDC4VPVerifier(ctrl)
+
+		return &operation.Operation{
+			ReleaseService:  releaseService,
+			PolicyService:   policyService,
+			ProtectService:  protectService,
+			SubjectResolver: subjectResolver,
+			OIDC4VPVerifier: verifier,
+		}, verifier
+	}
+
+	initiate := `{"did":"` + targetDID + `"}`
+
+	t.Run("Initiate", func(t *testing.T) {
+		op, verifier := newOperation(t, &policy.Policy{ID: testPolicyID, PresentationDefinition: definition})
+		verifier.EXPECT().Initiate(gomock.Any(), definition).
+			Return(&oidc4vp.Interaction{TxID: "tx", AuthorizationRequest: "openid4vp://?request_uri=..."}, nil)
+		verifier.EXPECT().Initiate(gomock.Any(), definition).Return(nil, errors.New("unexpected status 503"))
+
+		rr := handleRequest(t, op, "/v1/oidc4vp", http.MethodPost, bytes.NewBufferString(initiate))
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var interaction oidc4vp.Interaction
+
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &interaction))
+		require.Equal(t, "tx", interaction.TxID)
+
+		rr = handleRequest(t, op, "/v1/oidc4vp", http.MethodPost, bytes.NewBufferString(initiate))
+		require.Equal(t, http.StatusInternalServerError, rr.Code)
+
+		rr = handleRequest(t, op, "/v1/oidc4vp", http.MethodPost, bytes.NewBufferString("invalid json"))
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("Initiate without presentation definition", func(t *testing.T) {
+		op, _ := newOperation(t, &policy.Policy{ID: testPolicyID})
+
+		rr := handleRequest(t, op, "/v1/oidc4vp", http.MethodPost, bytes.NewBufferString(initiate))
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("Release", func(t *testing.T) {
+		op, verifier := newOperation(t, &policy.Policy{ID: testPolicyID, PresentationDefinition: definition})
+		verifier.EXPECT().Verify(gomock.Any(), definition, "tx", subjectDID).Return(nil)
+		verifier.EXPECT().Verify(gomock.Any(), definition, "tx", subjectDID).
+			Return(fmt.Errorf("%w: interaction tx was already used", oidc4vp.ErrNotVerified))
+		verifier.EXPECT().Verify(gomock.Any(), definition, "tx", subjectDID).Return(errors.New("unexpected status 503"))
+
+		releaseStatus := func() int {
+			b, err := json.Marshal(&operation.ReleaseRequest{DID: targetDID, OIDC4VPTxID: "tx"})
+			require.NoError(t, err)
+
+			return handleRequest(t, op, "/v1/release", http.MethodPost, bytes.NewReader(b)).Code
+		}
+
+		require.Equal(t, http.StatusOK, releaseStatus())
+		require.Equal(t, http.StatusUnauthorized, releaseStatus())
+		require.Equal(t, http.StatusInternalServerError, releaseStatus())
+	})
+
+	t.Run("Not served without verifier", func(t *testing.T) {
+		rr := handleRequest(t, &operation.Operation{}, "/v1/oidc4vp", http.MethodPost, bytes.NewBufferString(initiate))
+		require.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
+
+func TestAuthorizeHandler(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		releaseService := NewMockReleaseService(ctrl)
+		releaseService.EXPECT().Get(gomock.Any(), testTicketID).Return(&ticket.Ticket{
+			ID:     testTicketID,
+			DID:    targetDID,
+			Status: 0,
+		}, nil)
+		releaseService.EXPECT().Authorize(gomock.Any(), testTicketID, subjectDID, "").Return(nil)
+
+		protectService := NewMockProtectService(ctrl)
+		protectService.EXPECT().Get(gomock.Any(), targetDID).Return(&protect.ProtectedData{
+			PolicyID: testPolicyID,
+		}, nil)
+
+		policyService := NewMockPolicyService(ctrl)
+		policyService.EXPECT().Check(gomock.Any(), testPolicyID, subjectDID, policy.Approver).Return(nil)
+
+		subjectResolver := NewMockSubjectResolver(ctrl)
+		subjectResolver.EXPECT().Resolve(gomock.Any()).Return(subjectDID, nil)
+
+		op := &operation.Operation{
+			ReleaseService:  releaseService,
+			PolicyService:   policyService,
+			ProtectService:  protectService,
+			SubjectResolver: subjectResolver,
+		}
+
+		rr := handleRequest(t, op, "/v1/release/test-ticket/authorize", http.MethodPost, nil)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("Success with comment", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		releaseService := NewMockReleaseService(ctrl)
+		releaseService.EXPECT().Get(gomock.Any(), testTicketID).Return(&ticket.Ticket{
+			ID:  testTicketID,
+			DID: targetDID,
+		}, nil)
+		releaseService.EXPECT().Authorize(gomock.Any(), testTicketID, subjectDID, "legal request checked").Return(nil)
+
+		protectService := NewMockProtectService(ctrl)
+		protectService.EXPECT().Get(gomock.Any(), targetDID).Return(&protect.ProtectedData{
+			PolicyID: testPolicyID,
+		}, nil)
+
+		policyService := NewMockPolicyService(ctrl)
+		policyService.EXPECT().Check(gomock.Any(), testPolicyID, subjectDID, policy.Approver).Return(nil)
+
+		subjectResolver := NewMockSubjectResolver(ctrl)
+		subjectResolver.EXPECT().Resolve(gomock.Any()).Return(subjectDID, nil)
+
+		op := &operation.Operation{
+			ReleaseService:  releaseService,
+			PolicyService:   policyService,
+			ProtectService:  protectService,
+			SubjectResolver: subjectResolver,
+		}
+
+		body, err := json.Marshal(&operation.DecisionRequest{Comment: "legal request checked"})
+		require.NoError(t, err)
+
+		rr := handleRequest(t, op, "/v1/release/test-ticket/authorize", http.MethodPost, bytes.NewReader(body))
+
+		require.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("Fail to unmarshal request body", func(t *testing.T) {
+		op := &operation.Operation{}
+
+		rr := handleRequest(t, op, "/v1/release/test-ticket/authorize", http.MethodPost,
+			bytes.NewBufferString("invalid json"))
+
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("Ticket not found", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		releaseService := NewMockReleaseService(ctrl)
+		releaseService.EXPECT().Get(gomock.Any(), testTicketID).Return(nil, storage.ErrDataNotFound)
+
+		op := &operation.Operation{
+			ReleaseService: releaseService,
+		}
+
+		rr := handleRequest(t, op, "/v1/release/test-ticket/authorize", http.MethodPost, nil)
+
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("Fail to get ticket", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		releaseService := NewMockReleaseService(ctrl)
+		releaseService.EXPECT().Get(gomock.Any(), testTicketID).Return(nil, errors.New("get error"))
+
+		op := &operation.Operation{
+			ReleaseService: releaseService,
+		}
+
+		rr := handleRequest(t, op, "/v1/release/test-ticket/authorize", http.MethodPost, nil)
+
+		require.Equal(t, http.StatusInternalServerError, rr.Code)
+	})
+
+	t.Run("Fail to get protected data", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		releaseService := NewMockReleaseService(ctrl)
+		releaseService.EXPECT().Get(gomock.Any(), testTicketID).Return(&ticket.Ticket{
+			ID:     testTicketID,
+			DID:    targetDID,
+			Status: 0,
+		}, nil)
+
+		protectService := NewMockProtectService(ctrl)
+		protectService.EXPECT().Get(gomock.Any(), targetDID).Return(nil, errors.New("get error"))
+
+		op := &operation.Operation{
+			ReleaseService: releaseService,
+			ProtectService: protectService,
+		}
+
+		rr := handleRequest(t, op, "/v1/release/test-ticket/authorize", http.MethodPost, nil)
+
+		require.Equal(t, http.StatusInternalServerError, rr.Code)
+	})
+
+	t.Run("Not allowed per policy", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		releaseService := NewMockReleaseService(ctrl)
+		releaseService.EXPECT().Get(gomock.Any(), testTicketID).Return(&ticket.Ticket{
+			ID:     testTicketID,
+			DID:    targetDID,
+			Status: 0,
+		}, nil)
+
+		protectService := NewMockProtectService(ctrl)
+		protectService.EXPECT().Get(gomock.Any(), targetDID).Return(&protect.ProtectedData{
+			PolicyID: testPolicyID,
+		}, nil)
+
+		policyService := NewMockPolicyService(ctrl)
+		policyService.EXPECT().Check(gomock.Any(), testPolicyID, subjectDID, policy.Approver).Return(policy.ErrNotAllowed)
+
+		subjectResolver := NewMockSubjectResolver(ctrl)
+		subjectResolver.EXPECT().Resolve(gomock.Any()).Return(subjectDID, nil)
+
+		op := &operation.Operation{
+			ReleaseService:  releaseService,
+			PolicyService:   policyService,
+			ProtectService:  protectService,
+			SubjectResolver: subjectResolver,
+		}
+
+		rr := handleRequest(t, op, "/v1/release/test-ticket/authorize", http.MethodPost, nil)
+
+		require.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+
+	t.Run("Fail to authorize ticket", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		releaseService := NewMockReleaseService(ctrl)
+		releaseService.EXPECT().Get(gomock.Any(), testTicketID).Return(&ticket.Ticket{
+			ID:     testTicketID,
+			DID:    targetDID,
+			Status: 0,
+		}, nil)
+		releaseService.EXPECT().Authorize(gomock.Any(), testTicketID, subjectDID, "").Return(errors.New("authorize error"))
+
+		protectService := NewMockProtectService(ctrl)
+		protectService.EXPECT().Get(gomock.Any(), targetDID).Return(&protect.ProtectedData{
+			PolicyID: testPolicyID,
+		}, nil)
+
+		policyService := NewMockPolicyService(ctrl)
+		policyService.EXPECT().Check(gomock.Any(), testPolicyID, subjectDID, policy.Approver).Return(nil)
+
+		subjectResolver := NewMockSubjectResolver(ctrl)
+		subjectResolver.EXPECT().Resolve(gomock.Any()).Return(subjectDID, nil)
+
+		op := &operation.Operation{
+			ReleaseService:  releaseService,
+			PolicyService:   policyService,
+			ProtectService:  protectService,
+			SubjectResolver: subjectResolver,
+		}
+
+		rr := handleRequest(t, op, "/v1/release/test-ticket/authorize", http.MethodPost, nil)
+
+		require.Equal(t, http.StatusInternalServerError, rr.Code)
+	})
+}
+
+func TestRejectHandler(t *testing.T) {
+	newOperation := func(t *testing.T, rejectErr error) *operation.Operation {
+		t.Helper()
+
+		ctrl := gomock.NewController(t)
+
+		releaseService := NewMockReleaseService(ctrl)
+		releaseService.EXPECT().Get(gomock.Any(), testTicketID).Return(&ticket.Ticket{
+			ID:  testTicketID,
+			DID: targetDID,
+		}, nil)
+		releaseService.EXPECT().Reject(gomock.Any(), testTicketID, subjectDID, "no legal basis").Return(rejectErr)
+
+		protectService := NewMockProtectService(ctrl)
+		protectService.EXPECT().Get(gomock.Any(), targetDID).Return(&protect.ProtectedData{
+			PolicyID: testPolicyID,
+		}, nil)
+
+		policyService := NewMockPolicyService(ctrl)
+		policyService.EXPECT().Check(gomock.Any(), testPolicyID, subjectDID, policy.Approver).Return(nil)
+
+		subjectResolver := NewMockSubjectResolver(ctrl)
+		subjectResolver.EXPECT().Resolve(gomock.Any()).Return(subjectDID, nil)
+
+		return &operation.Operation{
+			ReleaseService:  releaseService,
+			PolicyService:   policyService,
+			ProtectService:  protectService,
+			SubjectResolver: subjectResolver,
+		}
+	}
+
+	body, err := json.Marshal(&operation.DecisionRequest{Comment: "no legal basis"})
+	require.NoError(t, err)
+
+	t.Run("Success", func(t *testing.T) {
+		rr := handleRequest(t, newOperation(t, nil), "/v1/release/test-ticket/reject", http.MethodPost,
+			bytes.NewReader(body))
+
+		require.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("Ticket already rejected", func(t *testing.T) {
+		rr := handleRequest(t, newOperation(t, release.ErrRejected), "/v1/release/test-ticket/reject",
+			http.MethodPost, bytes.NewReader(body))
+
+		require.Equal(t, http.StatusConflict, rr.Code)
+	})
+
+	t.Run("Not the instance holding the leader's lease", func(t *testing.T) {
+		rr := handleRequest(t, newOperation(t, release.ErrNotLeader), "/v1/release/test-ticket/reject",
+			http.MethodPost, bytes.NewReader(body))
+
+		require.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	})
+
+	t.Run("Fail to reject ticket", func(t *testing.T) {
+		rr := handleRequest(t, newOperation(t, errors.New("reject error")), "/v1/release/test-ticket/reject",
+			http.MethodPost, bytes.NewReader(body))
+
+		require.Equal(t, http.StatusInternalServerError, rr.Code)
+	})
+
+	t.Run("Ticket not found", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		releaseService := NewMockReleaseService(ctrl)
+		releaseService.EXPECT().Get(gomock.Any(), testTicketID).Return(nil, storage.ErrDataNotFound)
+
+		op := &operation.Operation{
+			ReleaseService: releaseService,
+		}
+
+		rr := handleRequest(t, op, "/v1/release/test-ticket/reject", http.MethodPost, nil)
+
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("Fail to unmarshal request body", func(t *testing.T) {
+		rr := handleRequest(t, &operation.Operation{}, "/v1/release/test-ticket/reject", http.MethodPost,
+			bytes.NewBufferString("invalid json"))
+
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
+
+func TestDelegatedDecisions(t *testing.T) {
+	const approverDID = "did:example:approver"
+
+	// setup returns an operation with real release and delegation services, and a new ticket. The subject is not an
+	// approver of the policy.
+	setup := func(t *testing.T) (*operation.Operation, *delegation.Service, *MockPolicyService, string) {
+		t.Helper()
+
+		ctrl := gomock.NewController(t)
+
+		protectService := NewMockProtectService(ctrl)
+		protectService.EXPECT().Get(gomock.Any(), targetDID).
+			Return(&protect.ProtectedData{DID: targetDID, PolicyID: testPolicyID}, nil).AnyTimes()
+
+		policyService := NewMockPolicyService(ctrl)
+		policyService.EXPECT().Check(gomock.Any(), testPolicyID, subjectDID, policy.Approver).
+			Return(policy.ErrNotAllowed).AnyTimes()
+		policyService.EXPECT().Get(gomock.Any(), testPolicyID).Return(&policy.Policy{
+			ID:           testPolicyID,
+			Approvers:    []string{approverDID, "did:example:other"},
+			MinApprovers: 1,
+		}, nil).AnyTimes()
+
+		releaseService, err := release.NewService(&release.Config{
+			StoreProvider:  mem.NewProvider(),
+			PolicyService:  policyService,
+			ProtectService: protectService,
+		})
+		require.NoError(t, err)
+
+		delegationService, err := delegation.NewService(mem.NewProvider())
+		require.NoError(t, err)
+
+		tk, err := releaseService.Release(context.Background(), targetDID, testPolicyID, "did:example:handler", "")
+		require.NoError(t, err)
+
+		subjectResolver := NewMockSubjectResolver(ctrl)
+		subjectResolver.EXPECT().Resolve(gomock.Any()).Return(subjectDID, nil).AnyTimes()
+
+		return &operation.Operation{
+			ReleaseService:    releaseService,
+			PolicyService:     policyService,
+			ProtectService:    protectService,
+			SubjectResolver:   subjectResolver,
+			DelegationService: delegationService,
+		}, delegationService, policyService, tk.ID
+	}
+
+	delegate := func(t *testing.T, svc *delegation.Service, delegator string) *delegation.Delegation {
+		t.Helper()
+
+		d := &delegation.Delegation{
+			PolicyID:  testPolicyID,
+			Delegator: delegator,
+			Delegate:  subjectDID,
+			NotAfter:  time.Now().Add(time.Hour),
+		}
+
+		require.NoError(t, svc.Delegate(context.Background(), d))
+
+		return d
+	}
+
+	t.Run("Delegate authorizes on behalf of the approver", func(t *testing.T) {
+		op, delegationService, policyService, ticketID := setup(t)
+
+		d := delegate(t, delegationService, approverDID)
+
+		policyService.EXPECT().Check(gomock.Any(), testPolicyID, approverDID, policy.Approver).Return(nil)
+
+		rr := handleRequest(t, op, "/v1/release/"+ticketID+"/authorize", http.MethodPost, nil)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		tk, err := op.GetTicket(context.Background(), ticketID)
+		require.NoError(t, err)
+		require.Equal(t, []string{approverDID}, tk.ApprovedBy)
+		require.Equal(t, "READY_TO_COLLECT", tk.Status)
+
+		history, err := op.TicketHistory(context.Background(), ticketID)
+		require.NoError(t, err)
+		require.Len(t, history.History, 2)
+		require.Equal(t, subjectDID, history.History[1].Actor)
+		require.Equal(t, approverDID, history.History[1].OnBehalfOf)
+		require.Equal(t, d.ID, history.History[1].Delegation)
+	})
+
+	t.Run("Delegate of several approvers decides on behalf of one of them", func(t *testing.T) {
+		op, delegationService, policyService, ticketID := setup(t)
+
+		delegate(t, delegationService, approverDID)
+		delegate(t, delegationService, "did:example:other")
+
+		rr := handleRequest(t, op, "/v1/release/"+ticketID+"/reject", http.MethodPost, nil)
+
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "on_behalf_of is required")
+
+		policyService.EXPECT().Check(gomock.Any(), testPolicyID, "did:example:other", policy.Approver).Return(nil)
+
+		body, err := json.Marshal(&operation.DecisionRequest{OnBehalfOf: "did:example:other"})
+		require.NoError(t, err)
+
+		rr = handleRequest(t, op, "/v1/release/"+ticketID+"/reject", http.MethodPost, bytes.NewReader(body))
+
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		tk, err := op.GetTicket(context.Background(), ticketID)
+		require.NoError(t, err)
+		require.Equal(t, "did:example:other", tk.RejectedBy)
+	})
+
+	t.Run("No active delegation", func(t *testing.T) {
+		op, _, _, ticketID := setup(t)
+
+		rr := handleRequest(t, op, "/v1/release/"+ticketID+"/authorize", http.MethodPost, nil)
+
+		require.Equal(t, http.StatusUnauthorized, rr.Code)
+
+		body, err := json.Marshal(&operation.DecisionRequest{OnBehalfOf: approverDID})
+		require.NoError(t, err)
+
+		rr = handleRequest(t, op, "/v1/release/"+ticketID+"/authorize", http.MethodPost, bytes.NewReader(body))
+
+		require.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+
+	t.Run("Delegator is no longer an approver", func(t *testing.T) {
+		op, delegationService, policyService, ticketID := setup(t)
+
+		delegate(t, delegationService, approverDID)
+
+		policyService.EXPECT().Check(gomock.Any(), testPolicyID, approverDID, policy.Approver).
+			Return(policy.ErrNotAllowed)
+
+		rr := handleRequest(t, op, "/v1/release/"+ticketID+"/authorize", http.MethodPost, nil)
+
+		require.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+
+	t.Run("Delegations are not supported", func(t *testing.T) {
+		op, _, _, ticketID := setup(t)
+
+		op.DelegationService = nil
+
+		body, err := json.Marshal(&operation.DecisionRequest{OnBehalfOf: approverDID})
+		require.NoError(t, err)
+
+		rr := handleRequest(t, op, "/v1/release/"+ticketID+"/authorize", http.MethodPost, bytes.NewReader(body))
+
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "delegations are not supported")
+	})
+
+	t.Run("Fail to get delegations", func(t *testing.T) {
+		op, _, _, ticketID := setup(t)
+
+		delegationService := NewMockDelegationService(gomock.NewController(t))
+		delegationService.EXPECT().Active(gomock.Any(), testPolicyID, subjectDID, gomock.Any()).
+			Return(nil, errors.New("query error"))
+
+		op.DelegationService = delegationService
+
+		rr := handleRequest(t, op, "/v1/release/"+ticketID+"/authorize", http.MethodPost, nil)
+
+		require.Equal(t, http.StatusInternalServerError, rr.Code)
+		require.Contains(t, rr.Body.String(), "get delegations: query error")
+	})
+}
+
+func TestDelegateHandler(t *testing.T) {
+	notAfter := time.Now().Add(time.Hour).UTC()
+
+	body, err := json.Marshal(&operation.DelegateRequest{Delegate: "did:example:delegate", NotAfter: notAfter})
+	require.NoError(t, err)
+
+	newOperation := func(ctrl *gomock.Controller, checkErr error) (*operation.Operation, *MockDelegationService) {
+		policyService := NewMockPolicyService(ctrl)
+		policyService.EXPECT().Check(gomock.Any(), testPolicyID, subjectDID, policy.Approver).Return(checkErr)
+
+		subjectResolver := NewMockSubjectResolver(ctrl)
+		subjectResolver.EXPECT().Resolve(gomock.Any()).Return(subjectDID, nil)
+
+		delegationService := NewMockDelegationService(ctrl)
+
+		return &operation.Operation{
+			PolicyService:     policyService,
+			SubjectResolver:   subjectResolver,
+			DelegationService: delegationService,
+		}, delegationService
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		op, delegationService := newOperation(ctrl, nil)
+
+		delegationService.EXPECT().Delegate(gomock.Any(), &delegation.Delegation{
+			PolicyID:  testPolicyID,
+			Delegator: subjectDID,
+			Delegate:  "did:example:delegate",
+			NotAfter:  notAfter,
+		}).DoAndReturn(func(_ context.Context, d *delegation.Delegation) error {
+			d.ID = "delegation"
+
+			return nil
+		})
+
+		rr := handleRequest(t, op, "/v1/policy/test-policy/delegation", http.MethodPost, bytes.NewReader(body))
+
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var resp operation.DelegateResponse
+
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		require.Equal(t, "delegation", resp.Delegation.ID)
+		require.Equal(t, subjectDID, resp.Delegation.Delegator)
+	})
+
+	t.Run("Fail to unmarshal request body", func(t *testing.T) {
+		op := &operation.Operation{DelegationService: NewMockDelegationService(gomock.NewController(t))}
+
+		rr := handleRequest(t, op, "/v1/policy/test-policy/delegation", http.MethodPost,
+			bytes.NewReader([]byte("")))
+
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("Not an approver", func(t *testing.T) {
+		op, _ := newOperation(gomock.NewController(t), policy.ErrNotAllowed)
+
+		rr := handleRequest(t, op, "/v1/policy/test-policy/delegation", http.MethodPost, bytes.NewReader(body))
+
+		require.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+
+	t.Run("Invalid delegation", func(t *testing.T) {
+		op, delegationService := newOperation(gomock.NewController(t), nil)
+
+		delegationService.EXPECT().Delegate(gomock.Any(), gomock.Any()).
+			Return(fmt.Errorf("%w: the delegate must be another DID", delegation.ErrInvalidDelegation))
+
+		rr := handleRequest(t, op, "/v1/policy/test-policy/delegation", http.MethodPost, bytes.NewReader(body))
+
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "the delegate must be another DID")
+	})
+
+	t.Run("Fail to store delegation", func(t *testing.T) {
+		op, delegationService := newOperation(gomock.NewController(t), nil)
+
+		delegationService.EXPECT().Delegate(gomock.Any(), gomock.Any()).Return(errors.New("put error"))
+
+		rr := handleRequest(t, op, "/v1/policy/test-policy/delegation", http.MethodPost, bytes.NewReader(body))
+
+		require.Equal(t, http.StatusInternalServerError, rr.Code)
+		require.Contains(t, rr.Body.String(), "delegate approval: put error")
+	})
+
+	t.Run("Not served without delegation service", func(t *testing.T) {
+		rr := handleRequest(t, &operation.Operation{}, "/v1/policy/test-policy/delegation", http.MethodPost,
+			bytes.NewReader(body))
+
+		require.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
+
+func TestSubscriptionHandlers(t *testing.T) {
+	body, err := json.Marshal(&operation.SubscribeRequest{
+		Notification: policy.Notification{Webhook: "https://handler.example.com/events"},
+		Actions:      []string{"approve", "reject"},
+	})
+	require.NoError(t, err)
+
+	newOperation := func(ctrl *gomock.Controller) (*operation.Operation, *MockSubscriptionService) {
+		subjectResolver := NewMockSubjectResolver(ctrl)
+		subjectResolver.EXPECT().Resolve(gomock.Any()).Return(subjectDID, nil)
+
+		subscriptionService := NewMockSubscriptionService(ctrl)
+
+		return &operation.Operation{
+			SubjectResolver:     subjectResolver,
+			SubscriptionService: subscriptionService,
+		}, subscriptionService
+	}
+
+	t.Run("Subscribe", func(t *testing.T) {
+		op, subscriptionService := newOperation(gomock.NewController(t))
+
+		subscriptionService.EXPECT().Subscribe(gomock.Any(), &subscription.Subscription{
+			DID:          subjectDID,
+			Notification: policy.Notification{Webhook: "https://handler.example.com/events"},
+			Actions:      []string{"approve", "reject"},
+		}).DoAndReturn(func(_ context.Context, sub *subscription.Subscription) error {
+			sub.ID = "subscription"
+
+			return nil
+		})
+
+		rr := handleRequest(t, op, "/v1/subscriptions", http.MethodPost, bytes.NewReader(body))
+
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var resp operation.SubscribeResponse
+
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		require.Equal(t, "subscription", resp.Subscription.ID)
+		require.Equal(t, subjectDID, resp.Subscription.DID)
+	})
+
+	t.Run("Fail to unmarshal request body", func(t *testing.T) {
+		op := &operation.Operation{SubscriptionService: NewMockSubscriptionService(gomock.NewController(t))}
+
+		rr := handleRequest(t, op, "/v1/subscriptions", http.MethodPost, bytes.NewReader([]byte("")))
+
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("Invalid subscription", func(t *testing.T) {
+		op, subscriptionService := newOperation(gomock.NewController(t))
+
+		subscriptionService.EXPECT().Subscribe(gomock.Any(), gomock.Any()).
+			Return(fmt.Errorf("%w: webhook must be an absolute URL", subscription.ErrInvalidSubscription))
+
+		rr := handleRequest(t, op, "/v1/subscriptions", http.MethodPost, bytes.NewReader(body))
+
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "webhook must be an absolute URL")
+	})
+
+	t.Run("Fail to store subscription", func(t *testing.T) {
+		op, subscriptionService := newOperation(gomock.NewController(t))
+
+		subscriptionService.EXPECT().Subscribe(gomock.Any(), gomock.Any()).Return(errors.New("put error"))
+
+		rr := handleRequest(t, op, "/v1/subscriptions", http.MethodPost, bytes.NewReader(body))
+
+		require.Equal(t, http.StatusInternalServerError, rr.Code)
+		require.Contains(t, rr.Body.String(), "subscribe: put error")
+	})
+
+	t.Run("List subscriptions", func(t *testing.T) {
+		op, subscriptionService := newOperation(gomock.NewController(t))
+
+		subscriptionService.EXPECT().List(gomock.Any(), subjectDID).
+			Return([]*subscription.Subscription{{ID: "subscription", DID: subjectDID}}, nil)
+
+		rr := handleRequest(t, op, "/v1/subscriptions", http.MethodGet, nil)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var resp operation.ListSubscriptionsResponse
+
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		require.Len(t, resp.Items, 1)
+		require.Equal(t, "subscription", resp.Items[0].ID)
+		require.Equal(t, 1, *resp.Total)
+		require.Empty(t, resp.NextCursor)
+	})
+
+	t.Run("List a page of subscriptions", func(t *testing.T) {
+		op, subscriptionService := newOperation(gomock.NewController(t))
+
+		subscriptionService.EXPECT().List(gomock.Any(), subjectDID).Return([]*subscription.Subscription{
+			{ID: "s1", DID: subjectDID}, {ID: "s2", DID: subjectDID}, {ID: "s3", DID: subjectDID},
+		}, nil)
+
+		rr := handleRequest(t, op, "/v1/subscriptions?limit=1&cursor="+model.EncodeCursor(1), http.MethodGet, nil)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var resp operation.ListSubscriptionsResponse
+
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		require.Len(t, resp.Items, 1)
+		require.Equal(t, "s2", resp.Items[0].ID)
+		require.Equal(t, model.EncodeCursor(2), resp.NextCursor)
+		require.Equal(t, 3, *resp.Total)
+	})
+
+	t.Run("Invalid page of subscriptions", func(t *testing.T) {
+		op := &operation.Operation{SubscriptionService: NewMockSubscriptionService(gomock.NewController(t))}
+
+		rr := handleRequest(t, op, "/v1/subscriptions?cursor=invalid", http.MethodGet, nil)
+
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "invalid cursor")
+	})
+
+	t.Run("No subscriptions", func(t *testing.T) {
+		op, subscriptionService := newOperation(gomock.NewController(t))
+
+		subscriptionService.EXPECT().List(gomock.Any(), subjectDID).Return(nil, nil)
+
+		rr := handleRequest(t, op, "/v1/subscriptions", http.MethodGet, nil)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.JSONEq(t, `{"items":[],"total":0}`, rr.Body.String())
+	})
+
+	t.Run("Fail to list subscriptions", func(t *testing.T) {
+		op, subscriptionService := newOperation(gomock.NewController(t))
+
+		subscriptionService.EXPECT().List(gomock.Any(), subjectDID).Return(nil, errors.New("query error"))
+
+		rr := handleRequest(t, op, "/v1/subscriptions", http.MethodGet, nil)
+
+		require.Equal(t, http.StatusInternalServerError, rr.Code)
+		require.Contains(t, rr.Body.String(), "list subscriptions: query error")
+	})
+
+	t.Run("Unsubscribe", func(t *testing.T) {
+		op, subscriptionService := newOperation(gomock.NewController(t))
+
+		subscriptionService.EXPECT().Unsubscribe(gomock.Any(), subjectDID, "subscription").Return(nil)
+
+		rr := handleRequest(t, op, "/v1/subscriptions/subscription", http.MethodDelete, nil)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("Unsubscribe from unknown subscription", func(t *testing.T) {
+		op, subscriptionService := newOperation(gomock.NewController(t))
+
+		subscriptionService.EXPECT().Unsubscribe(gomock.Any(), subjectDID, "subscription").
+			Return(fmt.Errorf("get subscription: %w", storage.ErrDataNotFound))
+
+		rr := handleRequest(t, op, "/v1/subscriptions/subscription", http.MethodDelete, nil)
+
+		require.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("Fail to resolve subject", func(t *testing.T) {
+		subjectResolver := NewMockSubjectResolver(gomock.NewController(t))
+		subjectResolver.EXPECT().Resolve(gomock.Any()).Return("", errors.New("no signature")).Times(3)
+
+		op := &operation.Operation{
+			SubjectResolver:     subjectResolver,
+			SubscriptionService: NewMockSubscriptionService(gomock.NewController(t)),
+		}
+
+		rr := handleRequest(t, op, "/v1/subscriptions", http.MethodPost, bytes.NewReader(body))
+		require.Equal(t, http.StatusUnauthorized, rr.Code)
+
+		rr = handleRequest(t, op, "/v1/subscriptions", http.MethodGet, nil)
+		require.Equal(t, http.StatusUnauthorized, rr.Code)
+
+		rr = handleRequest(t, op, "/v1/subscriptions/subscription", http.MethodDelete, nil)
+		require.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+
+	t.Run("Not served without subscription service", func(t *testing.T) {
+		rr := handleRequest(t, &operation.Operation{}, "/v1/subscriptions", http.MethodPost, bytes.NewReader(body))
+
+		require.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
+
+func TestTicketStatusHandler(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		releaseService := NewMockReleaseService(ctrl)
+		releaseService.EXPECT().Get(gomock.Any(), testTicketID).Return(&ticket.Ticket{
+			ID:     testTicketID,
+			DID:    targetDID,
+			Status: 0,
+		}, nil)
+
+		protectService := NewMockProtectService(ctrl)
+		protectService.EXPECT().Get(gomock.Any(), targetDID).Return(&protect.ProtectedData{
+			PolicyID: testPolicyID,
+		}, nil)
+
+		policyService := NewMockPolicyService(ctrl)
+		policyService.EXPECT().Check(gomock.Any(), testPolicyID, subjectDID, policy.Handler).Return(nil)
+
+		subjectResolver := NewMockSubjectResolver(ctrl)
+		subjectResolver.EXPECT().Resolve(gomock.Any()).Return(subjectDID, nil)
+
+		op := &operation.Operation{
+			ReleaseService:  releaseService,
+			PolicyService:   policyService,
+			ProtectService:  protectService,
+			SubjectResolver: subjectResolver,
+		}
+
+		rr := handleRequest(t, op, "/v1/release/test-ticket/status", http.MethodGet, nil)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("Ticket not found", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		releaseService := NewMockReleaseService(ctrl)
+		releaseService.EXPECT().Get(gomock.Any(), testTicketID).Return(nil, storage.ErrDataNotFound)
+
+		op := &operation.Operation{
+			ReleaseService: releaseService,
+		}
+
+		rr := handleRequest(t, op, "/v1/release/test-ticket/status", http.MethodGet, nil)
+
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("Fail to get ticket", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		releaseService := NewMockReleaseService(ctrl)
+		releaseService.EXPECT().Get(gomock.Any(), testTicketID).Return(nil, errors.New("get error"))
+
+		op := &operation.Operation{
+			ReleaseService: releaseService,
+		}
+
+		rr := handleRequest(t, op, "/v1/release/test-ticket/status", http.MethodGet, nil)
+
+		require.Equal(t, http.StatusInternalServerError, rr.Code)
+	})
+
+	t.Run("Fail to get protected data", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		releaseService := NewMockReleaseService(ctrl)
+		releaseService.EXPECT().Get(gomock.Any(), testTicketID).Return(&ticket.Ticket{
+			ID:     testTicketID,
+			DID:    targetDID,
+			Status: 0,
+		}, nil)
+
+		protectService := NewMockProtectService(ctrl)
+		protectService.EXPECT().Get(gomock.Any(), targetDID).Return(nil, errors.New("get error"))
+
+		op := &operation.Operation{
+			ReleaseService: releaseService,
+			ProtectService: protectService,
+		}
+
+		rr := handleRequest(t, op, "/v1/release/test-ticket/status", http.MethodGet, nil)
+
+		require.Equal(t, http.StatusInternalServerError, rr.Code)
+	})
+
+	t.Run("Not allowed per policy", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		releaseService := NewMockReleaseService(ctrl)
+		releaseService.EXPECT().Get(gomock.Any(), testTicketID).Return(&ticket.Ticket{
+			ID:     testTicketID,
+			DID:    targetDID,
+			Status: 0,
+		}, nil)
+
+		protectService := NewMockProtectService(ctrl)
+		protectService.EXPECT().Get(gomock.Any(), targetDID).Return(&protect.ProtectedData{
+			PolicyID: testPolicyID,
+		}, nil)
+
+		policyService := NewMockPolicyService(ctrl)
+		policyService.EXPECT().Check(gomock.Any(), testPolicyID, subjectDID, policy.Handler).Return(policy.ErrNotAllowed)
+
+		subjectResolver := NewMockSubjectResolver(ctrl)
+		subjectResolver.EXPECT().Resolve(gomock.Any()).Return(subjectDID, nil)
+
+		op := &operation.Operation{
+			ReleaseService:  releaseService,
+			PolicyService:   policyService,
+			ProtectService:  protectService,
+			SubjectResolver: subjectResolver,
+		}
+
+		rr := handleRequest(t, op, "/v1/release/test-ticket/status", http.MethodGet, nil)
+
+		require.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+}
+
+func TestTicketEventsHandler(t *testing.T) {
+	newOperation := func(t *testing.T, updates chan *ticket.Ticket, statuses ...ticket.Status) *operation.Operation {
+		t.Helper()
+
+		ctrl := gomock.NewController(t)
+
+		releaseService := NewMockReleaseService(ctrl)
+		for _, s := range statuses {
+			releaseService.EXPECT().Get(gomock.Any(), testTicketID).
+				Return(&ticket.Ticket{ID: testTicketID, DID: targetDID, Status: s}, nil)
+		}
+
+		protectService := NewMockProtectService(ctrl)
+		protectService.EXPECT().Get(gomock.Any(), targetDID).
+			Return(&protect.ProtectedData{PolicyID: testPolicyID}, nil).AnyTimes()
+
+		policyService := NewMockPolicyService(ctrl)
+		policyService.EXPECT().Check(gomock.Any(), testPolicyID, subjectDID, policy.Handler).Return(nil).AnyTimes()
+
+		subjectResolver := NewMockSubjectResolver(ctrl)
+		subjectResolver.EXPECT().Resolve(gomock.Any()).Return(subjectDID, nil).AnyTimes()
+
+		ticketWatcher := NewMockTicketWatcher(ctrl)
+		ticketWatcher.EXPECT().Watch(testTicketID).Return(updates, func() {})
+
+		return &operation.Operation{
+			ReleaseService:  releaseService,
+			PolicyService:   policyService,
+			ProtectService:  protectService,
+			SubjectResolver: subjectResolver,
+			TicketWatcher:   ticketWatcher,
+		}
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		updates := make(chan *ticket.Ticket, 2)
+		updates <- &ticket.Ticket{ID: testTicketID}
+		updates <- &ticket.Ticket{ID: testTicketID}
+
+		op := newOperation(t, updates, ticket.New, ticket.Collecting, ticket.Rejected)
+
+		rr := handleRequest(t, op, "/v1/release/test-ticket/events", http.MethodGet, nil)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.Equal(t, "text/event-stream", rr.Header().Get("Content-Type"))
+		require.True(t, rr.Flushed)
+		require.Equal(t, "event: status\ndata: {\"status\":\"NEW\"}\n\n"+
+			"event: status\ndata: {\"status\":\"COLLECTING\"}\n\n"+
+			"event: status\ndata: {\"status\":\"REJECTED\"}\n\n", rr.Body.String())
+	})
+
+	t.Run("Watcher falls behind", func(t *testing.T) {
+		updates := make(chan *ticket.Ticket)
+		close(updates)
+
+		op := newOperation(t, updates, ticket.ReadyToCollect)
+
+		rr := handleRequest(t, op, "/v1/release/test-ticket/events", http.MethodGet, nil)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.Equal(t, "event: status\ndata: {\"status\":\"READY_TO_COLLECT\"}\n\n", rr.Body.String())
+	})
+
+	t.Run("Client disconnects", func(t *testing.T) {
+		op := newOperation(t, make(chan *ticket.Ticket), ticket.New)
+
+		router := mux.NewRouter()
+
+		for _, h := range op.GetRESTHandlers() {
+			router.HandleFunc(h.Path(), h.Handle()).Methods(h.Method())
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, "/v1/release/test-ticket/events", nil)
+		require.NoError(t, err)
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		require.Equal(t, "event: status\ndata: {\"status\":\"NEW\"}\n\n", rr.Body.String())
+	})
+
+	t.Run("Ticket not found", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		releaseService := NewMockReleaseService(ctrl)
+		releaseService.EXPECT().Get(gomock.Any(), testTicketID).Return(nil, storage.ErrDataNotFound)
+
+		stopped := false
+
+		ticketWatcher := NewMockTicketWatcher(ctrl)
+		ticketWatcher.EXPECT().Watch(testTicketID).Return(make(chan *ticket.Ticket), func() { stopped = true })
+
+		op := &operation.Operation{
+			ReleaseService: releaseService,
+			TicketWatcher:  ticketWatcher,
+		}
+
+		rr := handleRequest(t, op, "/v1/release/test-ticket/events", http.MethodGet, nil)
+
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.True(t, stopped)
+	})
+
+	t.Run("Not served without a watcher", func(t *testing.T) {
+		rr := handleRequest(t, &operation.Operation{}, "/v1/release/test-ticket/events", http.MethodGet, nil)
+
+		require.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
+
+func TestDIDCommHandler(t *testing.T) {
+	const signed = "header.payload.signature"
+
+	newOperation := func(t *testing.T, msg *didcomm.Message, receiveErr error) (*operation.Operation, *gomock.Controller) {
+		t.Helper()
+
+		ctrl := gomock.NewController(t)
+
+		receiver := NewMockDIDCommReceiver(ctrl)
+		receiver.EXPECT().Receive(gomock.Any(), []byte(signed)).Return(msg, receiveErr)
+
+		return &operation.Operation{DIDCommReceiver: receiver}, ctrl
+	}
+
+	decide := func(t *testing.T, ctrl *gomock.Controller, op *operation.Operation) *MockReleaseService {
+		t.Helper()
+
+		releaseService := NewMockReleaseService(ctrl)
+		releaseService.EXPECT().Get(gomock.Any(), testTicketID).Return(&ticket.Ticket{ID: testTicketID, DID: targetDID}, nil)
+
+		protectService := NewMockProtectService(ctrl)
+		protectService.EXPECT().Get(gomock.Any(), targetDID).Return(&protect.ProtectedData{PolicyID: testPolicyID}, nil)
+
+		policyService := NewMockPolicyService(ctrl)
+		policyService.EXPECT().Check(gomock.Any(), testPolicyID, subjectDID, policy.Approver).Return(nil)
+
+		subjectResolver := NewMockSubjectResolver(ctrl)
+		subjectResolver.EXPECT().Resolve(gomock.Any()).DoAndReturn(func(ctx context.Context) (string, error) {
+			sender, ok := didcomm.Sender(ctx)
+			require.True(t, ok)
+
+			return sender, nil
+		})
+
+		op.ReleaseService = releaseService
+		op.ProtectService = protectService
+		op.PolicyService = policyService
+		op.SubjectResolver = subjectResolver
+
+		return releaseService
+	}
+
+	body := json.RawMessage(`{"ticket_id":"` + testTicketID + `","comment":"legal request checked"}`)
+
+	t.Run("Authorize", func(t *testing.T) {
+		op, ctrl := newOperation(t, &didcomm.Message{Type: didcomm.AuthorizeType, From: subjectDID, Body: body}, nil)
+		decide(t, ctrl, op).EXPECT().Authorize(gomock.Any(), testTicketID, subjectDID, "legal request checked").
+			Return(nil)
+
+		rr := handleRequest(t, op, "/v1/didcomm", http.MethodPost, bytes.NewBufferString(signed))
+
+		require.Equal(t, http.StatusAccepted, rr.Code)
+	})
+
+	t.Run("Reject", func(t *testing.T) {
+		op, ctrl := newOperation(t, &didcomm.Message{Type: didcomm.RejectType, From: subjectDID, Body: body}, nil)
+		decide(t, ctrl, op).EXPECT().Reject(gomock.Any(), testTicketID, subjectDID, "legal request checked").
+			Return(release.ErrRejected)
+
+		rr := handleRequest(t, op, "/v1/didcomm", http.MethodPost, bytes.NewBufferString(signed))
+
+		require.Equal(t, http.StatusConflict, rr.Code)
+	})
+
+	t.Run("Invalid messages", func(t *testing.T) {
+		for status, tc := range map[int]struct {
+			msg *didcomm.Message
+			err error
+		}{
+			http.StatusUnauthorized: {err: fmt.Errorf("%w: invalid JWS", didcomm.ErrInvalidSignature)},
+			http.StatusBadRequest:   {err: fmt.Errorf("%w: expired", didcomm.ErrInvalidMessage)},
+		} {
+			op, _ := newOperation(t, tc.msg, tc.err)
+
+			rr := handleRequest(t, op, "/v1/didcomm", http.MethodPost, bytes.NewBufferString(signed))
+
+			require.Equal(t, status, rr.Code)
+		}
+
+		for _, msg := range []*didcomm.Message{
+			{Type: "https://example.com/other", Body: body},
+			{Type: didcomm.AuthorizeType, Body: json.RawMessage(`[]`)},
+		} {
+			op, _ := newOperation(t, msg, nil)
+
+			rr := handleRequest(t, op, "/v1/didcomm", http.MethodPost, bytes.NewBufferString(signed))
+
+			require.Equal(t, http.StatusBadRequest, rr.Code)
+		}
+	})
+
+	t.Run("Connect", func(t *testing.T) {
+		msg := &didcomm.Message{Type: didcomm.ConnectType, From: subjectDID, ParentThreadID: "invitation"}
+
+		ctrl := gomock.NewController(t)
+
+		receiver := NewMockDIDCommReceiver(ctrl)
+		receiver.EXPECT().Receive(gomock.Any(), []byte(signed)).Return(msg, nil).Times(2)
+
+		inviter := NewMockDIDCommInviter(ctrl)
+		inviter.EXPECT().Accept(gomock.Any(), msg).Return(&didcomm.Connection{ID: "1", DID: subjectDID}, nil)
+		inviter.EXPECT().Accept(gomock.Any(), msg).Return(nil, fmt.Errorf("%w: expired", didcomm.ErrInvalidMessage))
+
+		op := &operation.Operation{DIDCommReceiver: receiver, DIDCommInviter: inviter}
+
+		rr := handleRequest(t, op, "/v1/didcomm", http.MethodPost, bytes.NewBufferString(signed))
+		require.Equal(t, http.StatusAccepted, rr.Code)
+
+		rr = handleRequest(t, op, "/v1/didcomm", http.MethodPost, bytes.NewBufferString(signed))
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("Not served without receiver", func(t *testing.T) {
+		rr := handleRequest(t, &operation.Operation{}, "/v1/didcomm", http.MethodPost, bytes.NewBufferString(signed))
+
+		require.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("Not found while feature disabled", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		flags := feature.New(map[string]bool{feature.DIDComm: false})
+
+		receiver := NewMockDIDCommReceiver(ctrl)
+		receiver.EXPECT().Receive(gomock.Any(), []byte(signed)).
+			Return(&didcomm.Message{Type: "https://example.com/other", Body: body}, nil)
+
+		op := &operation.Operation{
+			DIDCommReceiver: receiver,
+			DIDCommInviter:  NewMockDIDCommInviter(ctrl),
+			DIDCommIssuer:   NewMockDIDCommIssuer(ctrl),
+			Features:        flags,
+		}
+
+		for _, r := range []struct{ method, path string }{
+			{http.MethodPost, "/v1/didcomm"},
+			{http.MethodPost, "/v1/didcomm/invitations"},
+			{http.MethodGet, "/v1/didcomm/connections"},
+			{http.MethodGet, "/v1/didcomm/issuances"},
+			{http.MethodPost, "/v1/didcomm/issuances/1/approve"},
+		} {
+			rr := handleRequest(t, op, r.path, r.method, bytes.NewBufferString(signed))
+			require.Equal(t, http.StatusNotFound, rr.Code, r.path)
+		}
+
+		// toggled while the endpoints are served
+		require.NoError(t, flags.Set(feature.DIDComm, true))
+
+		rr := handleRequest(t, op, "/v1/didcomm", http.MethodPost, bytes.NewBufferString(signed))
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
+
+func TestDIDCommInvitationHandlers(t *testing.T) {
+	newOperation := func(t *testing.T) (*operation.Operation, *MockDIDCommInviter) {
+		t.Helper()
+
+		ctrl := gomock.NewController(t)
+		inviter := NewMockDIDCommInviter(ctrl)
+
+		return &operation.Operation{DIDCommReceiver: NewMockDIDCommReceiver(ctrl), DIDCommInviter: inviter}, inviter
+	}
+
+	t.Run("Invite", func(t *testing.T) {
+		op, inviter := newOperation(t)
+		inviter.EXPECT().Invite(gomock.Any(), didcomm.ApproverRole, "Compliance").
+			Return(&didcomm.Invitation{Message: &didcomm.Message{ID: "1"}, URL: "https://example.com?_oob=e30"}, nil)
+		inviter.EXPECT().Invite(gomock.Any(), "collector", "").
+			Return(nil, fmt.Errorf("%w: unknown role", didcomm.ErrInvalidInvitation))
+
+		rr := handleRequest(t, op, "/v1/didcomm/invitations", http.MethodPost,
+			bytes.NewBufferString(`{"role":"approver","label":"Compliance"}`))
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var inv didcomm.Invitation
+
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &inv))
+		require.Equal(t, "https://example.com?_oob=e30", inv.URL)
+		require.Equal(t, "1", inv.Message.ID)
+
+		rr = handleRequest(t, op, "/v1/didcomm/invitations", http.MethodPost, bytes.NewBufferString(`{"role":"collector"}`))
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+
+		rr = handleRequest(t, op, "/v1/didcomm/invitations", http.MethodPost, bytes.NewBufferString("invalid json"))
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("Connections", func(t *testing.T) {
+		op, inviter := newOperation(t)
+		inviter.EXPECT().Connections(gomock.Any(), subjectDID).Return([]*didcomm.Connection{{ID: "1"}}, nil)
+		inviter.EXPECT().Connections(gomock.Any(), "").Return(nil, errors.New("storage error"))
+
+		rr := handleRequest(t, op, "/v1/didcomm/connections?did="+subjectDID, http.MethodGet, nil)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var resp operation.ConnectionsResponse
+
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		require.Len(t, resp.Connections, 1)
+
+		rr = handleRequest(t, op, "/v1/didcomm/connections", http.MethodGet, nil)
+		require.Equal(t, http.StatusInternalServerError, rr.Code)
+	})
+}
+
+func TestDIDCommIssuanceHandlers(t *testing.T) {
+	newOperation := func(t *testing.T, msg *didcomm.Message) (*operation.Operation, *MockDIDCommIssuer) {
+		t.Helper()
+
+		ctrl := gomock.NewController(t)
+
+		receiver := NewMockDIDCommReceiver(ctrl)
+		receiver.EXPECT().Receive(gomock.Any(), gomock.Any()).Return(msg, nil).AnyTimes()
+
+		issuer := NewMockDIDCommIssuer(ctrl)
+
+		return &operation.Operation{DIDCommReceiver: receiver, DIDCommIssuer: issuer}, issuer
+	}
+
+	t.Run("Propose and request", func(t *testing.T) {
+		propose := &didcomm.Message{ID: "1", Type: didcomm.ProposeCredentialType, From: subjectDID}
+		request := &didcomm.Message{ID: "2", Type: didcomm.RequestCredentialType, From: subjectDID, ThreadID: "1"}
+
+		op, issuer := newOperation(t, propose)
+		issuer.EXPECT().Propose(gomock.Any(), propose).Return(&didcomm.Issuance{ID: "1", DID: subjectDID}, nil)
+		issuer.EXPECT().Propose(gomock.Any(), propose).Return(nil, fmt.Errorf("%w: not a handler", didcomm.ErrInvalidMessage))
+
+		rr := handleRequest(t, op, "/v1/didcomm", http.MethodPost, bytes.NewBufferString("signed"))
+		require.Equal(t, http.StatusAccepted, rr.Code)
+
+		rr = handleRequest(t, op, "/v1/didcomm", http.MethodPost, bytes.NewBufferString("signed"))
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+
+		op, issuer = newOperation(t, request)
+		issuer.EXPECT().Request(gomock.Any(), request).Return(nil, errors.New("issue credential: vcs error"))
+
+		rr = handleRequest(t, op, "/v1/didcomm", http.MethodPost, bytes.NewBufferString("signed"))
+		require.Equal(t, http.StatusInternalServerError, rr.Code)
+	})
+
+	t.Run("Issuances", func(t *testing.T) {
+		op, issuer := newOperation(t, nil)
+		issuer.EXPECT().Issuances(gomock.Any(), didcomm.IssuanceProposed).Return([]*didcomm.Issuance{{ID: "1"}}, nil)
+		issuer.EXPECT().Issuances(gomock.Any(), "").Return(nil, errors.New("storage error"))
+
+		rr := handleRequest(t, op, "/v1/didcomm/issuances?status=proposed", http.MethodGet, nil)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var resp operation.IssuancesResponse
+
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		require.Len(t, resp.Issuances, 1)
+
+		rr = handleRequest(t, op, "/v1/didcomm/issuances", http.MethodGet, nil)
+		require.Equal(t, http.StatusInternalServerError, rr.Code)
+	})
+
+	t.Run("Approve", func(t *testing.T) {
+		op, issuer := newOperation(t, nil)
+		issuer.EXPECT().ApproveIssuance(gomock.Any(), "1").
+			Return(&didcomm.Issuance{ID: "1", Status: didcomm.IssuanceOffered}, nil)
+		issuer.EXPECT().ApproveIssuance(gomock.Any(), "2").
+			Return(nil, fmt.Errorf("%w: issuance 2 is issued", didcomm.ErrInvalidIssuance))
+		issuer.EXPECT().ApproveIssuance(gomock.Any(), "3").Return(nil, didcomm.ErrIssuanceNotFound)
+
+		rr := handleRequest(t, op, "/v1/didcomm/issuances/1/approve", http.MethodPost, nil)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var iss didcomm.Issuance
+
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &iss))
+		require.Equal(t, didcomm.IssuanceOffered, iss.Status)
+
+		rr = handleRequest(t, op, "/v1/didcomm/issuances/2/approve", http.MethodPost, nil)
+		require.Equal(t, http.StatusConflict, rr.Code)
+
+		rr = handleRequest(t, op, "/v1/didcomm/issuances/3/approve", http.MethodPost, nil)
+		require.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
+
+func TestListTicketsHandler(t *testing.T) {
+	created := time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tickets := []*ticket.Ticket{
+		{ID: "ticket-1", CreatedAt: created},
+		{ID: "ticket-3", CreatedAt: created.Add(2 * time.Hour)},
+		{ID: "ticket-2", CreatedAt: created.Add(time.Hour)},
+	}
+
+	newOperation := func(t *testing.T, expected *release.Filter) *operation.Operation {
+		t.Helper()
+
+		ctrl := gomock.NewController(t)
+
+		releaseService := NewMockReleaseService(ctrl)
+		releaseService.EXPECT().IterateFilter(gomock.Any(), expected, gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, _ *release.Filter, _ int, fn func(t *ticket.Ticket) error) error {
+				for _, tk := range tickets {
+					if err := fn(tk); err != nil {
+						return err
+					}
+				}
+
+				return nil
+			})
+
+		return &operation.Operation{ReleaseService: releaseService}
+	}
+
+	list := func(t *testing.T, op *operation.Operation, query string) *operation.ListTicketsResponse {
+		t.Helper()
+
+		rr := handleRequest(t, op, "/v1/release?"+query, http.MethodGet, nil)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+		var resp operation.ListTicketsResponse
+
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+		return &resp
+	}
+
+	ids := func(resp *operation.ListTicketsResponse) []string {
+		var result []string
+
+		for _, tk := range resp.Items {
+			result = append(result, tk.ID)
+		}
+
+		return result
+	}
+
+	t.Run("Success: oldest tickets first", func(t *testing.T) {
+		resp := list(t, newOperation(t, &release.Filter{}), "")
+
+		require.Equal(t, 3, *resp.Total)
+		require.Equal(t, []string{"ticket-1", "ticket-2", "ticket-3"}, ids(resp))
+		require.Equal(t, created, resp.Items[0].CreatedAt)
+		require.Empty(t, resp.NextCursor)
+	})
+
+	t.Run("Success: filtered, newest tickets first, paginated", func(t *testing.T) {
+		op := newOperation(t, &release.Filter{
+			RequestedBy: subjectDID,
+			Statuses:    []ticket.Status{ticket.New, ticket.Collecting},
+			PolicyID:    testPolicyID,
+			From:        created,
+			To:          created.Add(24 * time.Hour),
+		})
+
+		req := &operation.ListTicketsRequest{
+			Requestor: subjectDID,
+			Statuses:  []string{"NEW,COLLECTING"},
+			PolicyID:  testPolicyID,
+			From:      created,
+			To:        created.Add(24 * time.Hour),
+			Sort:      "-created_at",
+			PageRequest: model.PageRequest{
+				Cursor: model.EncodeCursor(1),
+				Limit:  1,
+			},
+		}
+
+		resp := list(t, op, req.Values().Encode())
+
+		require.Equal(t, 3, *resp.Total)
+		require.Equal(t, []string{"ticket-2"}, ids(resp))
+		require.Equal(t, model.EncodeCursor(2), resp.NextCursor)
+	})
+
+	t.Run("Success: cursor after the last ticket", func(t *testing.T) {
+		resp := list(t, newOperation(t, &release.Filter{}), "cursor="+model.EncodeCursor(5))
+
+		require.Equal(t, 3, *resp.Total)
+		require.Empty(t, resp.Items)
+		require.Empty(t, resp.NextCursor)
+	})
+
+	t.Run("Invalid query", func(t *testing.T) {
+		for _, query := range []string{"status=DONE", "sort=did", "from=yesterday", "to=tomorrow", "cursor=-1",
+			"limit=all"} {
+			rr := handleRequest(t, &operation.Operation{}, "/v1/release?"+query, http.MethodGet, nil)
+
+			require.Equal(t, http.StatusBadRequest, rr.Code, query)
+		}
+	})
+
+	t.Run("Fail to list tickets", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		releaseService := NewMockReleaseService(ctrl)
+		releaseService.EXPECT().IterateFilter(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(errors.New("query error"))
+
+		rr := handleRequest(t, &operation.Operation{ReleaseService: releaseService}, "/v1/release", http.MethodGet, nil)
+
+		require.Equal(t, http.StatusInternalServerError, rr.Code)
+		require.Contains(t, rr.Body.String(), "list tickets: query error")
+	})
+}
+
+func TestTicketHistoryHandler(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		created := time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)
+
+		releaseService := NewMockReleaseService(ctrl)
+		releaseService.EXPECT().Get(gomock.Any(), testTicketID).Return(&ticket.Ticket{
+			ID: testTicketID,
+			History: []*ticket.Event{{
+				Action:    ticket.ReleaseAction,
+				Status:    ticket.New,
+				Actor:     subjectDID,
+				Reference: "test-signature",
+				Timestamp: created,
+			}},
+		}, nil)
+
+		op := &operation.Operation{
+			ReleaseService: releaseService,
+		}
+
+		rr := handleRequest(t, op, "/v1/release/test-ticket/history", http.MethodGet, nil)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.JSONEq(t, `{"ticket_id":"test-ticket","history":[{"action":"release","status":"NEW",`+
+			`"actor":"`+subjectDID+`","reference":"test-signature","timestamp":"2022-01-02T03:04:05Z"}]}`,
+			rr.Body.String())
+	})
+
+	t.Run("Success: reference of the subject is recorded", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		releaseService, err := release.NewService(&release.Config{StoreProvider: mem.NewProvider()})
+		require.NoError(t, err)
+
+		protectService := NewMockProtectService(ctrl)
+		protectService.EXPECT().Get(gomock.Any(), targetDID).Return(&protect.ProtectedData{PolicyID: testPolicyID}, nil)
+
+		policyService := NewMockPolicyService(ctrl)
+		policyService.EXPECT().Check(gomock.Any(), testPolicyID, subjectDID, policy.Handler).Return(nil)
+		policyService.EXPECT().Get(gomock.Any(), testPolicyID).Return(&policy.Policy{ID: testPolicyID}, nil)
+
+		subjectResolver := NewMockSubjectResolver(ctrl)
+		subjectResolver.EXPECT().Resolve(gomock.Any()).Return(subjectDID, nil)
+
+		referenceResolver := NewMockReferenceResolver(ctrl)
+		referenceResolver.EXPECT().Reference(gomock.Any()).Return("test-signature")
+
+		op := &operation.Operation{
+			ReleaseService:    releaseService,
+			PolicyService:     policyService,
+			ProtectService:    protectService,
+			SubjectResolver:   subjectResolver,
+			ReferenceResolver: referenceResolver,
+		}
+
+		resp, err := op.Release(context.Background(), &operation.ReleaseRequest{DID: targetDID})
+		require.NoError(t, err)
+
+		history, err := op.TicketHistory(context.Background(), resp.TicketID)
+		require.NoError(t, err)
+		require.Len(t, history.History, 1)
+		require.Equal(t, "release", history.History[0].Action)
+		require.Equal(t, subjectDID, history.History[0].Actor)
+		require.Equal(t, "test-signature", history.History[0].Reference)
+	})
+
+	t.Run("Ticket not found", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		releaseService := NewMockReleaseService(ctrl)
+		releaseService.EXPECT().Get(gomock.Any(), testTicketID).Return(nil, storage.ErrDataNotFound)
+
+		op := &operation.Operation{
+			ReleaseService: releaseService,
+		}
+
+		rr := handleRequest(t, op, "/v1/release/test-ticket/history", http.MethodGet, nil)
+
+		require.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("Fail to get ticket", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		releaseService := NewMockReleaseService(ctrl)
+		releaseService.EXPECT().Get(gomock.Any(), testTicketID).Return(nil, errors.New("get error"))
+
+		op := &operation.Operation{
+			ReleaseService: releaseService,
+		}
+
+		rr := handleRequest(t, op, "/v1/release/test-ticket/history", http.MethodGet, nil)
+
+		require.Equal(t, http.StatusInternalServerError, rr.Code)
+	})
+}
+
+func TestGetTicketHandler(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		releaseService := NewMockReleaseService(ctrl)
+		releaseService.EXPECT().Get(gomock.Any(), testTicketID).Return(&ticket.Ticket{
+			ID:         testTicketID,
+			DID:        targetDID,
+			Status:     ticket.ReadyToCollect,
+			ApprovedBy: []string{"did:example:approver"},
+		}, nil)
+
+		op := &operation.Operation{
+			ReleaseService: releaseService,
+		}
+
+		rr := handleRequest(t, op, "/v1/release/test-ticket", http.MethodGet, nil)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var resp operation.TicketResponse
+
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		require.Equal(t, operation.TicketResponse{
+			ID:         testTicketID,
+			DID:        targetDID,
+			Status:     ticket.ReadyToCollect.String(),
+			ApprovedBy: []string{"did:example:approver"},
+		}, resp)
+	})
+
+	t.Run("Ticket not found", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		releaseService := NewMockReleaseService(ctrl)
+		releaseService.EXPECT().Get(gomock.Any(), testTicketID).Return(nil, storage.ErrDataNotFound)
+
+		op := &operation.Operation{
+			ReleaseService: releaseService,
+		}
+
+		rr := handleRequest(t, op, "/v1/release/test-ticket", http.MethodGet, nil)
+
+		require.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("Fail to get ticket", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		releaseService := NewMockReleaseService(ctrl)
+		releaseService.EXPECT().Get(gomock.Any(), testTicketID).Return(nil, errors.New("get error"))
+
+		op := &operation.Operation{
+			ReleaseService: releaseService,
+		}
+
+		rr := handleRequest(t, op, "/v1/release/test-ticket", http.MethodGet, nil)
+
+		require.Equal(t, http.StatusInternalServerError, rr.Code)
+	})
+}
+
+func TestCollectHandler(t *testing.T) {
+	const (
+		testDID      = "did:example:test"
+		testPolicyID = "test-policy"
+		testTicketID = "ticket1234"
+		testQueryID  = "queryID1234"
+	)
+
+	protectedData := &protect.ProtectedData{PolicyID: testPolicyID}
+
+	t.Run("Success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		releaseService := NewMockReleaseService(ctrl)
+		releaseService.EXPECT().Get(gomock.Any(), testTicketID).
+			Return(&ticket.Ticket{ID: testTicketID, DID: testDID, Status: ticket.ReadyToCollect}, nil)
+		releaseService.EXPECT().SetQueryID(gomock.Any(), testTicketID, testQueryID, subjectDID).Return(nil)
+
+		collectService := NewMockCollectService(ctrl)
+		collectService.EXPECT().Collect(gomock.Any(), protectedData, subjectDID).Return(testQueryID, nil)
+
+		protectService := NewMockProtectService(ctrl)
+		protectService.EXPECT().Get(gomock.Any(), testDID).
+			Return(protectedData, nil)
+
+		policyService := NewMockPolicyService(ctrl)
+		policyService.EXPECT().Check(gomock.Any(), testPolicyID, subjectDID, policy.Handler).Return(nil)
+
+		subjectResolver := NewMockSubjectResolver(ctrl)
+		subjectResolver.EXPECT().Resolve(gomock.Any()).Return(subjectDID, nil).AnyTimes()
+
+		op := &operation.Operation{
+			ReleaseService:  releaseService,
+			PolicyService:   policyService,
+			ProtectService:  protectService,
+			SubjectResolver: subjectResolver,
+			CollectService:  collectService,
+		}
+
+		rr := handleRequest(t, op, "/v1/release/"+testTicketID+"/collect", http.MethodPost, bytes.NewReader([]byte{}))
+
+		require.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("Not the instance holding the leader's lease", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		releaseService := NewMockReleaseService(ctrl)
+		releaseService.EXPECT().Get(gomock.Any(), testTicketID).
+			Return(&ticket.Ticket{ID: testTicketID, DID: testDID, Status: ticket.ReadyToCollect}, nil)
+
+		protectService := NewMockProtectService(ctrl)
+		protectService.EXPECT().Get(gomock.Any(), testDID).Return(protectedData, nil)
+
+		policyService := NewMockPolicyService(ctrl)
+		policyService.EXPECT().Check(gomock.Any(), testPolicyID, subjectDID, policy.Handler).Return(nil)
+
+		subjectResolver := NewMockSubjectResolver(ctrl)
+		subjectResolver.EXPECT().Resolve(gomock.Any()).Return(subjectDID, nil).AnyTimes()
+
+		op := &operation.Operation{
+			ReleaseService:  releaseService,
+			PolicyService:   policyService,
+			ProtectService:  protectService,
+			SubjectResolver: subjectResolver,
+			CollectService:  NewMockCollectService(ctrl),
+			Leader:          &testLeader{},
+		}
+
+		rr := handleRequest(t, op, "/v1/release/"+testTicketID+"/collect", http.MethodPost, bytes.NewReader([]byte{}))
+
+		require.Equal(t, http.StatusServiceUnavailable, rr.Code)
+		require.Contains(t, rr.Body.String(), release.ErrNotLeader.Error())
+	})
+
+	t.Run("Success: subject is notified", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		releaseService := NewMockReleaseService(ctrl)
+		releaseService.EXPECT().Get(gomock.Any(), testTicketID).Return(&ticket.Ticket{
+			ID:            testTicketID,
+			DID:           testDID,
+			Status:        ticket.ReadyToCollect,
+			RequestedBy:   subjectDID,
+			Justification: "fraud investigation",
+		}, nil)
+		releaseService.EXPECT().SetQueryID(gomock.Any(), testTicketID, testQueryID, subjectDID).Return(nil)
+
+		collectService := NewMockCollectService(ctrl)
+		collectService.EXPECT().Collect(gomock.Any(), protectedData, subjectDID).Return(testQueryID, nil)
+
+		protectService := NewMockProtectService(ctrl)
+		protectService.EXPECT().Get(gomock.Any(), testDID).Return(protectedData, nil)
+
+		notification := &policy.Notification{Webhook: "https://subjects.example.com/notify"}
+
+		policyService := NewMockPolicyService(ctrl)
+		policyService.EXPECT().Check(gomock.Any(), testPolicyID, subjectDID, policy.Handler).Return(nil)
+		policyService.EXPECT().Get(gomock.Any(), testPolicyID).
+			Return(&policy.Policy{ID: testPolicyID, Notification: notification}, nil)
+
+		subjectResolver := NewMockSubjectResolver(ctrl)
+		subjectResolver.EXPECT().Resolve(gomock.Any()).Return(subjectDID, nil).AnyTimes()
+
+		consentService := NewMockConsentService(ctrl)
+		consentService.EXPECT().Subject(gomock.Any(), testDID).Return("did:example:data_subject", nil)
+
+		notifier := NewMockNotifier(ctrl)
+		notifier.EXPECT().Notify(gomock.Any(), notification, gomock.Any()).DoAndReturn(
+			func(_ context.Context, _ *policy.Notification, e *notify.Event) error {
+				require.Equal(t, notify.Released, e.Type)
+				require.Equal(t, testDID, e.DID)
+				require.Equal(t, testPolicyID, e.PolicyID)
+				require.Equal(t, "did:example:data_subject", e.Subject)
+				require.Equal(t, testTicketID, e.TicketID)
+				require.Equal(t, subjectDID, e.RequestingParty)
+				require.Equal(t, "fraud investigation", e.Justification)
+
+				return errors.New("notify error")
+			})
+
+		op := &operation.Operation{
+			ReleaseService:  releaseService,
+			PolicyService:   policyService,
+			ProtectService:  protectService,
+			SubjectResolver: subjectResolver,
+			CollectService:  collectService,
+			ConsentService:  consentService,
+			Notifier:        notifier,
+		}
+
+		rr := handleRequest(t, op, "/v1/release/"+testTicketID+"/collect", http.MethodPost, bytes.NewReader([]byte{}))
+
+		// notifications are best-effort
+		require.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("Success: collect is queued", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		releaseService := NewMockReleaseService(ctrl)
+		gomock.InOrder(
+			releaseService.EXPECT().Get(gomock.Any(), testTicketID).
+				Return(&ticket.Ticket{ID: testTicketID, DID: testDID, Status: ticket.ReadyToCollect}, nil),
+			releaseService.EXPECT().Get(gomock.Any(), testTicketID).
+				Return(&ticket.Ticket{DID: testDID, Status: ticket.ReadyToCollect, QueryID: testQueryID}, nil),
+		)
+
+		protectService := NewMockProtectService(ctrl)
+		protectService.EXPECT().Get(gomock.Any(), testDID).Return(protectedData, nil)
+
+		policyService := NewMockPolicyService(ctrl)
+		policyService.EXPECT().Check(gomock.Any(), testPolicyID, subjectDID, policy.Handler).Return(nil)
+
+		subjectResolver := NewMockSubjectResolver(ctrl)
+		subjectResolver.EXPECT().Resolve(gomock.Any()).Return(subjectDID, nil).AnyTimes()
+
+		collectQueue := NewMockCollectQueue(ctrl)
+		collectQueue.EXPECT().Enqueue(gomock.Any()).DoAndReturn(func(payload []byte) (<-chan error, error) {
+			require.JSONEq(t, `{"ticket_id":"`+testTicketID+`","requesting_party":"`+subjectDID+`"}`, string(payload))
+
+			done := make(chan error, 1)
+			done <- nil
+
+			return done, nil
+		})
+
+		op := &operation.Operation{
+			ReleaseService:  releaseService,
+			PolicyService:   policyService,
+			ProtectService:  protectService,
+			SubjectResolver: subjectResolver,
+			CollectQueue:    collectQueue,
+		}
+
+		rr := handleRequest(t, op, "/v1/release/"+testTicketID+"/collect", http.MethodPost, bytes.NewReader([]byte{}))
+
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var resp operation.CollectResponse
+
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		require.Equal(t, testQueryID, resp.QueryID)
+	})
+
+	t.Run("Collect queue is full", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		releaseService := NewMockReleaseService(ctrl)
+		releaseService.EXPECT().Get(gomock.Any(), testTicketID).
+			Return(&ticket.Ticket{ID: testTicketID, DID: testDID, Status: ticket.ReadyToCollect}, nil)
+
+		protectService := NewMockProtectService(ctrl)
+		protectService.EXPECT().Get(gomock.Any(), testDID).Return(protectedData, nil)
+
+		policyService := NewMockPolicyService(ctrl)
+		policyService.EXPECT().Check(gomock.Any(), testPolicyID, subjectDID, policy.Handler).Return(nil)
+
+		subjectResolver := NewMockSubjectResolver(ctrl)
+		subjectResolver.EXPECT().Resolve(gomock.Any()).Return(subjectDID, nil).AnyTimes()
+
+		collectQueue := NewMockCollectQueue(ctrl)
+		collectQueue.EXPECT().Enqueue(gomock.Any()).Return(nil, queue.ErrFull)
+
+		op := &operation.Operation{
+			ReleaseService:  releaseService,
+			PolicyService:   policyService,
+			ProtectService:  protectService,
+			SubjectResolver: subjectResolver,
+			CollectQueue:    collectQueue,
+		}
+
+		rr := handleRequest(t, op, "/v1/release/"+testTicketID+"/collect", http.MethodPost, bytes.NewReader([]byte{}))
+
+		require.Equal(t, http.StatusServiceUnavailable, rr.Code)
+		require.Equal(t, "1", rr.Header().Get("Retry-After"))
+	})
+
+	t.Run("Fail to process queued collect", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		releaseService := NewMockReleaseService(ctrl)
+		releaseService.EXPECT().Get(gomock.Any(), testTicketID).
+			Return(&ticket.Ticket{ID: testTicketID, DID: testDID, Status: ticket.ReadyToCollect}, nil)
+
+		protectService := NewMockProtectService(ctrl)
+		protectService.EXPECT().Get(gomock.Any(), testDID).Return(protectedData, nil)
+
+		policyService := NewMockPolicyService(ctrl)
+		policyService.EXPECT().Check(gomock.Any(), testPolicyID, subjectDID, policy.Handler).Return(nil)
+
+		subjectResolver := NewMockSubjectResolver(ctrl)
+		subjectResolver.EXPECT().Resolve(gomock.Any()).Return(subjectDID, nil).AnyTimes()
+
+		done := make(chan error, 1)
+		done <- errors.New("collect failed")
+
+		collectQueue := NewMockCollectQueue(ctrl)
+		collectQueue.EXPECT().Enqueue(gomock.Any()).Return(done, nil)
+
+		op := &operation.Operation{
+			ReleaseService:  releaseService,
+			PolicyService:   policyService,
+			ProtectService:  protectService,
+			SubjectResolver: subjectResolver,
+			CollectQueue:    collectQueue,
+		}
+
+		rr := handleRequest(t, op, "/v1/release/"+testTicketID+"/collect", http.MethodPost, bytes.NewReader([]byte{}))
+
+		require.Equal(t, http.StatusInternalServerError, rr.Code)
+		require.Contains(t, rr.Body.String(), "fail to collect data: collect failed")
+	})
+
+	t.Run("Fail to get protected data", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		releaseService := NewMockReleaseService(ctrl)
+		releaseService.EXPECT().Get(gomock.Any(), testTicketID).
+			Return(&ticket.Ticket{ID: testTicketID, DID: testDID, Status: ticket.ReadyToCollect}, nil).AnyTimes()
+
+		collectService := NewMockCollectService(ctrl)
+		collectService.EXPECT().Collect(gomock.Any(), protectedData, subjectDID).
+			Return(testQueryID, nil).AnyTimes()
+
+		protectService := NewMockProtectService(ctrl)
+		protectService.EXPECT().Get(gomock.Any(), testDID).Return(nil, errors.New("get error"))
+
+		policyService := NewMockPolicyService(ctrl)
+		policyService.EXPECT().Check(gomock.Any(), testPolicyID, subjectDID, policy.Handler).
+			Return(nil).AnyTimes()
+
+		subjectResolver := NewMockSubjectResolver(ctrl)
+		subjectResolver.EXPECT().Resolve(gomock.Any()).Return(subjectDID, nil).AnyTimes()
+
+		op := &operation.Operation{
+			ReleaseService:  releaseService,
+			PolicyService:   policyService,
+			ProtectService:  protectService,
+			SubjectResolver: subjectResolver,
+			CollectService:  collectService,
+		}
+
+		rr := handleRequest(t, op, "/v1/release/"+testTicketID+"/collect", http.MethodPost, bytes.NewReader([]byte{}))
+
+		require.Equal(t, http.StatusInternalServerError, rr.Code)
+	})
+
+	t.Run("Fail to get ticket", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		releaseService := NewMockReleaseService(ctrl)
+		releaseService.EXPECT().Get(gomock.Any(), testTicketID).
+			Return(nil, errors.New("get error"))
+
+		collectService := NewMockCollectService(ctrl)
+		collectService.EXPECT().Collect(gomock.Any(), protectedData, subjectDID).
+			Return(testQueryID, nil).AnyTimes()
+
+		protectService := NewMockProtectService(ctrl)
+		protectService.EXPECT().Get(gomock.Any(), testDID).Return(protectedData, nil).AnyTimes()
+
+		policyService := NewMockPolicyService(ctrl)
+		policyService.EXPECT().Check(gomock.Any(), testPolicyID, subjectDID, policy.Handler).
+			Return(nil).AnyTimes()
+
+		subjectResolver := NewMockSubjectResolver(ctrl)
+		subjectResolver.EXPECT().Resolve(gomock.Any()).Return(subjectDID, nil).AnyTimes()
+
+		op := &operation.Operation{
+			ReleaseService:  releaseService,
+			PolicyService:   policyService,
+			ProtectService:  protectService,
+			SubjectResolver: subjectResolver,
+			CollectService:  collectService,
+		}
+
+		rr := handleRequest(t, op, "/v1/release/"+testTicketID+"/collect", http.MethodPost, bytes.NewReader([]byte{}))
+
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("Fail to check policy: ErrNotAllowed", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		releaseService := NewMockReleaseService(ctrl)
+		releaseService.EXPECT().Get(gomock.Any(), testTicketID).
+			Return(&ticket.Ticket{ID: testTicketID, DID: testDID, Status: ticket.ReadyToCollect}, nil).AnyTimes()
+
+		collectService := NewMockCollectService(ctrl)
+		collectService.EXPECT().Collect(gomock.Any(), protectedData, subjectDID).
+			Return(testQueryID, nil).AnyTimes()
+
+		protectService := NewMockProtectService(ctrl)
+		protectService.EXPECT().Get(gomock.Any(), testDID).Return(protectedData, nil).AnyTimes()
+
+		policyService := NewMockPolicyService(ctrl)
+		policyService.EXPECT().Check(gomock.Any(), testPolicyID, subjectDID, policy.Handler).
+			Return(policy.ErrNotAllowed)
+
+		subjectResolver := NewMockSubjectResolver(ctrl)
+		subjectResolver.EXPECT().Resolve(gomock.Any()).Return(subjectDID, nil).AnyTimes()
+
+		op := &operation.Operation{
+			ReleaseService:  releaseService,
+			PolicyService:   policyService,
+			ProtectService:  protectService,
+			SubjectResolver: subjectResolver,
+			CollectService:  collectService,
+		}
+
+		rr := handleRequest(t, op, "/v1/release/"+testTicketID+"/collect", http.MethodPost, bytes.NewReader([]byte{}))
+
+		require.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+
+	t.Run("Unauthorized to collect data", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		releaseService := NewMockReleaseService(ctrl)
+		releaseService.EXPECT().Get(gomock.Any(), testTicketID).
+			Return(&ticket.Ticket{DID: testDID, Status: ticket.New}, nil)
+
+		collectService := NewMockCollectService(ctrl)
+		collectService.EXPECT().Collect(gomock.Any(), protectedData, subjectDID).
+			Times(0)
+
+		decisionMetrics := NewMockDecisionMetrics(ctrl)
+		decisionMetrics.EXPECT().QuorumFailed(testPolicyID)
+
+		protectService := NewMockProtectService(ctrl)
+		protectService.EXPECT().Get(gomock.Any(), testDID).Return(protectedData, nil)
+
+		policyService := NewMockPolicyService(ctrl)
+		policyService.EXPECT().Check(gomock.Any(), testPolicyID, subjectDID, policy.Handler).
+			Return(nil).AnyTimes()
+
+		subjectResolver := NewMockSubjectResolver(ctrl)
+		subjectResolver.EXPECT().Resolve(gomock.Any()).Return(subjectDID, nil).AnyTimes()
+
+		op := &operation.Operation{
+			ReleaseService:  releaseService,
+			PolicyService:   policyService,
+			ProtectService:  protectService,
+			SubjectResolver: subjectResolver,
+			CollectService:  collectService,
+			DecisionMetrics: decisionMetrics,
+		}
+
+		rr := handleRequest(t, op, "/v1/release/"+testTicketID+"/collect", http.MethodPost, bytes.NewReader([]byte{}))
+
+		require.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+
+	t.Run("Quorum not met", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		releaseService := NewMockReleaseService(ctrl)
+		releaseService.EXPECT().Get(gomock.Any(), testTicketID).
+			Return(&ticket.Ticket{DID: testDID, Status: ticket.Collecting}, nil)
+
+		protectService := NewMockProtectService(ctrl)
+		protectService.EXPECT().Get(gomock.Any(), testDID).Return(protectedData, nil)
+
+		op := &operation.Operation{
+			ReleaseService: releaseService,
+			ProtectService: protectService,
+		}
+
+		_, err := op.Collect(context.Background(), testTicketID)
+		require.True(t, errors.Is(err, release.ErrQuorumNotMet))
+		require.True(t, errors.Is(err, operation.ErrNotAuthorized))
+		require.Equal(t, http.StatusUnauthorized, operation.ErrorStatus(err))
+	})
+
+	t.Run("Fail to collect data", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		releaseService := NewMockReleaseService(ctrl)
+		releaseService.EXPECT().Get(gomock.Any(), testTicketID).
+			Return(&ticket.Ticket{ID: testTicketID, DID: testDID, Status: ticket.ReadyToCollect}, nil)
+
+		collectService := NewMockCollectService(ctrl)
+		collectService.EXPECT().Collect(gomock.Any(), protectedData, subjectDID).
+			Return("", errors.New("collect failed"))
+
+		protectService := NewMockProtectService(ctrl)
+		protectService.EXPECT().Get(gomock.Any(), testDID).Return(protectedData, nil)
+
+		policyService := NewMockPolicyService(ctrl)
+		policyService.EXPECT().Check(gomock.Any(), testPolicyID, subjectDID, policy.Handler).
+			Return(nil)
+
+		subjectResolver := NewMockSubjectResolver(ctrl)
+		subjectResolver.EXPECT().Resolve(gomock.Any()).Return(subjectDID, nil).AnyTimes()
+
+		op := &operation.Operation{
+			ReleaseService:  releaseService,
+			PolicyService:   policyService,
+			ProtectService:  protectService,
+			SubjectResolver: subjectResolver,
+			CollectService:  collectService,
+		}
+
+		rr := handleRequest(t, op, "/v1/release/"+testTicketID+"/collect", http.MethodPost, bytes.NewReader([]byte{}))
+
+		require.Equal(t, http.StatusInternalServerError, rr.Code)
+	})
+}
+
+func TestProcessCollect(t *testing.T) {
+	const (
+		testDID      = "did:example:test"
+		testTicketID = "ticket1234"
+		testQueryID  = "queryID1234"
+	)
+
+	protectedData := &protect.ProtectedData{DID: testDID}
+	payload := []byte(`{"ticket_id":"` + testTicketID + `","requesting_party":"` + subjectDID + `"}`)
+
+	t.Run("Success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		releaseService := NewMockReleaseService(ctrl)
+		releaseService.EXPECT().Get(gomock.Any(), testTicketID).
+			Return(&ticket.Ticket{ID: testTicketID, DID: testDID, Status: ticket.ReadyToCollect}, nil)
+		releaseService.EXPECT().SetQueryID(gomock.Any(), testTicketID, testQueryID, subjectDID).Return(nil)
+
+		protectService := NewMockProtectService(ctrl)
+		protectService.EXPECT().Get(gomock.Any(), testDID).Return(protectedData, nil)
+
+		collectService := NewMockCollectService(ctrl)
+		collectService.EXPECT().Collect(gomock.Any(), protectedData, subjectDID).Return(testQueryID, nil)
+
+		op := &operation.Operation{
+			ReleaseService: releaseService,
+			ProtectService: protectService,
+			CollectService: collectService,
+		}
+
+		require.NoError(t, op.ProcessCollect(context.Background(), payload))
+	})
+
+	t.Run("Invalid payload", func(t *testing.T) {
+		op := &operation.Operation{}
+
+		err := op.ProcessCollect(context.Background(), []byte("invalid"))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "unmarshal collect job")
+	})
+
+	t.Run("Fail to get ticket", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		releaseService := NewMockReleaseService(ctrl)
+		releaseService.EXPECT().Get(gomock.Any(), testTicketID).Return(nil, errors.New("get error"))
+
+		op := &operation.Operation{ReleaseService: releaseService}
+
+		require.EqualError(t, op.ProcessCollect(context.Background(), payload), "get ticket: get error")
+	})
+
+	t.Run("Fail to record query", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		releaseService := NewMockReleaseService(ctrl)
+		releaseService.EXPECT().Get(gomock.Any(), testTicketID).
+			Return(&ticket.Ticket{ID: testTicketID, DID: testDID, Status: ticket.ReadyToCollect}, nil)
+		releaseService.EXPECT().SetQueryID(gomock.Any(), testTicketID, testQueryID, subjectDID).
+			Return(errors.New("put error"))
+
+		protectService := NewMockProtectService(ctrl)
+		protectService.EXPECT().Get(gomock.Any(), testDID).Return(protectedData, nil)
+
+		collectService := NewMockCollectService(ctrl)
+		collectService.EXPECT().Collect(gomock.Any(), protectedData, subjectDID).Return(testQueryID, nil)
+
+		op := &operation.Operation{
+			ReleaseService: releaseService,
+			ProtectService: protectService,
+			CollectService: collectService,
+		}
+
+		require.EqualError(t, op.ProcessCollect(context.Background(), payload), "record query: put error")
+	})
+}
+
+func TestExtractionQuota(t *testing.T) {
+	const testQueryID = "test-query"
+
+	q := &policy.Quota{Extractions: 3, Days: 30}
+	protectedData := &protect.ProtectedData{DID: targetDID, PolicyID: testPolicyID}
+	readyTicket := &ticket.Ticket{ID: testTicketID, DID: targetDID, Status: ticket.ReadyToCollect}
+
+	type mocks struct {
+		releaseService *MockReleaseService
+		extractService *MockExtractService
+		policyService  *MockPolicyService
+		quotaService   *MockQuotaService
+	}
+
+	newOperation := func(t *testing.T) (*operation.Operation, *mocks) {
+		t.Helper()
+
+		ctrl := gomock.NewController(t)
+
+		m := &mocks{
+			releaseService: NewMockReleaseService(ctrl),
+			extractService: NewMockExtractService(ctrl),
+			policyService:  NewMockPolicyService(ctrl),
+			quotaService:   NewMockQuotaService(ctrl),
+		}
+
+		protectService := NewMockProtectService(ctrl)
+		protectService.EXPECT().Get(gomock.Any(), targetDID).Return(protectedData, nil).AnyTimes()
+
+		m.policyService.EXPECT().Check(gomock.Any(), testPolicyID, subjectDID, policy.Handler).Return(nil).AnyTimes()
+		m.policyService.EXPECT().Get(gomock.Any(), testPolicyID).
+			Return(&policy.Policy{ID: testPolicyID, Quota: q}, nil).AnyTimes()
+
+		subjectResolver := NewMockSubjectResolver(ctrl)
+		subjectResolver.EXPECT().Resolve(gomock.Any()).Return(subjectDID, nil).AnyTimes()
+
+		return &operation.Operation{
+			ReleaseService:  m.releaseService,
+			ExtractService:  m.extractService,
+			ProtectService:  protectService,
+			PolicyService:   m.policyService,
+			SubjectResolver: subjectResolver,
+			QuotaService:    m.quotaService,
+		}, m
+	}
+
+	extract := func(t *testing.T, op *operation.Operation) *httptest.ResponseRecorder {
+		t.Helper()
+
+		body, err := json.Marshal(&operation.ExtractRequest{QueryID: testQueryID})
+		require.NoError(t, err)
+
+		return handleRequest(t, op, "/v1/extract", http.MethodPost, bytes.NewReader(body))
+	}
+
+	t.Run("Ticket status has the remaining extractions", func(t *testing.T) {
+		op, m := newOperation(t)
+
+		m.releaseService.EXPECT().Get(gomock.Any(), testTicketID).Return(readyTicket, nil)
+		m.quotaService.EXPECT().Remaining(gomock.Any(), targetDID, q, gomock.Any()).Return(2, nil)
+
+		rr := handleRequest(t, op, "/v1/release/"+testTicketID+"/status", http.MethodGet, nil)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var resp operation.TicketStatusResponse
+
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		require.Equal(t, &operation.QuotaStatus{Extractions: 3, Days: 30, Remaining: 2}, resp.Quota)
+	})
+
+	t.Run("Fail to get the remaining extractions", func(t *testing.T) {
+		op, m := newOperation(t)
+
+		m.releaseService.EXPECT().Get(gomock.Any(), testTicketID).Return(readyTicket, nil)
+		m.quotaService.EXPECT().Remaining(gomock.Any(), targetDID, q, gomock.Any()).Return(0, errors.New("get error"))
+
+		rr := handleRequest(t, op, "/v1/release/"+testTicketID+"/status", http.MethodGet, nil)
+
+		require.Equal(t, http.StatusInternalServerError, rr.Code)
+		require.Contains(t, rr.Body.String(), "get extraction quota: get error")
+	})
+
+	t.Run("Data is not collected once the quota is used up", func(t *testing.T) {
+		op, m := newOperation(t)
+
+		m.releaseService.EXPECT().Get(gomock.Any(), testTicketID).Return(readyTicket, nil)
+		m.quotaService.EXPECT().Remaining(gomock.Any(), targetDID, q, gomock.Any()).Return(0, nil)
+
+		rr := handleRequest(t, op, "/v1/release/"+testTicketID+"/collect", http.MethodPost, nil)
+
+		require.Equal(t, http.StatusTooManyRequests, rr.Code)
+		require.Contains(t, rr.Body.String(), quota.ErrExceeded.Error())
+	})
+
+	t.Run("Extraction is counted", func(t *testing.T) {
+		op, m := newOperation(t)
+
+		m.extractService.EXPECT().Extract(gomock.Any(), testQueryID).Return("data", nil)
+		m.releaseService.EXPECT().GetByQueryID(gomock.Any(), testQueryID).Return(readyTicket, nil)
+		m.quotaService.EXPECT().Use(gomock.Any(), targetDID, q, gomock.Any()).Return(2, nil)
+
+		rr := extract(t, op)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.Contains(t, rr.Body.String(), "data")
+	})
+
+	t.Run("Data is not extracted once the quota is used up", func(t *testing.T) {
+		op, m := newOperation(t)
+
+		m.extractService.EXPECT().Extract(gomock.Any(), testQueryID).Return("data", nil)
+		m.releaseService.EXPECT().GetByQueryID(gomock.Any(), testQueryID).Return(readyTicket, nil)
+		m.quotaService.EXPECT().Use(gomock.Any(), targetDID, q, gomock.Any()).Return(0, quota.ErrExceeded)
+
+		rr := extract(t, op)
+
+		require.Equal(t, http.StatusTooManyRequests, rr.Code)
+		require.NotContains(t, rr.Body.String(), "data")
+	})
+
+	t.Run("Fail to count extraction", func(t *testing.T) {
+		op, m := newOperation(t)
+
+		m.extractService.EXPECT().Extract(gomock.Any(), testQueryID).Return("data", nil)
+		m.releaseService.EXPECT().GetByQueryID(gomock.Any(), testQueryID).Return(readyTicket, nil)
+		m.quotaService.EXPECT().Use(gomock.Any(), targetDID, q, gomock.Any()).Return(0, errors.New("store error"))
+
+		rr := extract(t, op)
+
+		require.Equal(t, http.StatusInternalServerError, rr.Code)
+		require.Contains(t, rr.Body.String(), "use extraction quota: store error")
+	})
+
+	t.Run("Break-glass extraction is not counted", func(t *testing.T) {
+		op, m := newOperation(t)
+
+		m.extractService.EXPECT().Extract(gomock.Any(), testQueryID).Return("data", nil)
+		m.releaseService.EXPECT().GetByQueryID(gomock.Any(), testQueryID).
+			Return(&ticket.Ticket{ID: testTicketID, DID: targetDID, Status: ticket.ReadyToCollect, BreakGlass: true}, nil)
+
+		rr := extract(t, op)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+	})
+}
+
+func TestBreakGlassHandler(t *testing.T) {
+	const (
+		responderDID = "did:example:responder"
+		testTicketID = "ticket1234"
+		testQueryID  = "queryID1234"
+	)
+
+	protectedData := &protect.ProtectedData{DID: targetDID, PolicyID: testPolicyID}
+
+	breakGlassTicket := &ticket.Ticket{
+		ID:            testTicketID,
+		DID:           targetDID,
+		Status:        ticket.ReadyToCollect,
+		PolicyID:      testPolicyID,
+		RequestedBy:   responderDID,
+		Justification: "cardiac arrest",
+		BreakGlass:    true,
+		History:       []*ticket.Event{{Action: ticket.BreakGlassAction, Reference: "signature1234"}},
+	}
+
+	p := &policy.Policy{
+		ID:           testPolicyID,
+		Approvers:    []string{"did:example:approver1", "did:example:approver2"},
+		MinApprovers: 1,
+		Notification: &policy.Notification{Webhook: "https://subjects.example.com/notify"},
+		BreakGlass: &policy.BreakGlass{
+			Responders:   []string{responderDID},
+			Notification: &policy.Notification{Webhook: "https://approvers.example.com/notify", DIDComm: true},
+		},
+	}
+
+	body, err := json.Marshal(&operation.BreakGlassRequest{DID: targetDID, Justification: "cardiac arrest"})
+	require.NoError(t, err)
+
+	type mocks struct {
+		releaseService *MockReleaseService
+		protectService *MockProtectService
+		policyService  *MockPolicyService
+		collectService *MockCollectService
+		notifier       *MockNotifier
+		auditLog       *MockAuditLog
+	}
+
+	newOperation := func(t *testing.T) (*operation.Operation, *mocks) {
+		t.Helper()
+
+		ctrl := gomock.NewController(t)
+
+		m := &mocks{
+			releaseService: NewMockReleaseService(ctrl),
+			protectService: NewMockProtectService(ctrl),
+			policyService:  NewMockPolicyService(ctrl),
+			collectService: NewMockCollectService(ctrl),
+			notifier:       NewMockNotifier(ctrl),
+			auditLog:       NewMockAuditLog(ctrl),
+		}
+
+		subjectResolver := NewMockSubjectResolver(ctrl)
+		subjectResolver.EXPECT().Resolve(gomock.Any()).Return(responderDID, nil).AnyTimes()
+
+		return &operation.Operation{
+			ReleaseService:  m.releaseService,
+			ProtectService:  m.protectService,
+			PolicyService:   m.policyService,
+			CollectService:  m.collectService,
+			SubjectResolver: subjectResolver,
+			Notifier:        m.notifier,
+			AuditLog:        m.auditLog,
+		}, m
+	}
+
+	t.Run("Not the instance holding the leader's lease", func(t *testing.T) {
+		op, m := newOperation(t)
+		op.Leader = &testLeader{}
+
+		m.protectService.EXPECT().Get(gomock.Any(), targetDID).Return(protectedData, nil)
+		m.policyService.EXPECT().Check(gomock.Any(), testPolicyID, responderDID, policy.Responder).Return(nil)
+		m.policyService.EXPECT().Get(gomock.Any(), testPolicyID).Return(p, nil)
+
+		rr := handleRequest(t, op, "/v1/breakglass", http.MethodPost, bytes.NewReader(body))
+
+		require.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	})
+
+	t.Run("Success: subject and approvers are notified", func(t *testing.T) {
+		op, m := newOperation(t)
+
+		m.protectService.EXPECT().Get(gomock.Any(), targetDID).Return(protectedData, nil)
+		m.policyService.EXPECT().Check(gomock.Any(), testPolicyID, responderDID, policy.Responder).Return(nil)
+		m.policyService.EXPECT().Get(gomock.Any(), testPolicyID).Return(p, nil)
+		m.releaseService.EXPECT().BreakGlass(gomock.Any(), targetDID, testPolicyID, responderDID, "cardiac arrest").
+			Return(breakGlassTicket, nil)
+
+		var notified []*notify.Event
+
+		m.notifier.EXPECT().Notify(gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, n *policy.Notification, e *notify.Event) error {
+				switch {
+				case n.Webhook == p.Notification.Webhook:
+					require.Empty(t, e.Recipient)
+				case n.Webhook == p.BreakGlass.Notification.Webhook:
+					require.False(t, n.DIDComm)
+				default:
+					require.True(t, n.DIDComm)
+					require.NotEmpty(t, e.Recipient)
+				}
+
+				notified = append(notified, e)
+
+				return nil
+			}).Times(4)
+
+		audited := m.auditLog.EXPECT().Append(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, e *audit.Entry) error {
+				require.Len(t, notified, 4)
+				require.Equal(t, testTicketID, e.TicketID)
+				require.Equal(t, ticket.BreakGlassNotifiedAction, e.Event.Action)
+				require.Equal(t, responderDID, e.Event.Actor)
+				require.Equal(t, "signature1234", e.Event.Reference)
+
+				return nil
+			})
+
+		m.collectService.EXPECT().Collect(gomock.Any(), protectedData, responderDID).Return(testQueryID, nil).
+			After(audited)
+		m.releaseService.EXPECT().SetQueryID(gomock.Any(), testTicketID, testQueryID, responderDID).Return(nil)
+
+		rr := handleRequest(t, op, "/v1/breakglass", http.MethodPost, bytes.NewReader(body))
+
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var resp operation.BreakGlassResponse
+
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		require.Equal(t, testTicketID, resp.TicketID)
+		require.Equal(t, testQueryID, resp.QueryID)
+
+		require.Len(t, notified, 4)
+
+		for _, e := range notified {
+			require.Equal(t, notify.BreakGlass, e.Type)
+			require.Equal(t, responderDID, e.RequestingParty)
+			require.Equal(t, "cardiac arrest", e.Justification)
+		}
+
+		require.Equal(t, "did:example:approver1", notified[2].Recipient)
+		require.Equal(t, "did:example:approver2", notified[3].Recipient)
+	})
+
+	t.Run("Justification is required", func(t *testing.T) {
+		op, _ := newOperation(t)
+
+		b, e := json.Marshal(&operation.BreakGlassRequest{DID: targetDID})
+		require.NoError(t, e)
+
+		rr := handleRequest(t, op, "/v1/breakglass", http.MethodPost, bytes.NewReader(b))
+
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "justification is required for break-glass access")
+	})
+
+	t.Run("Fail to unmarshal request body", func(t *testing.T) {
+		op, _ := newOperation(t)
+
+		rr := handleRequest(t, op, "/v1/breakglass", http.MethodPost, bytes.NewReader([]byte("")))
+
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("Protected data not found", func(t *testing.T) {
+		op, m := newOperation(t)
+
+		m.protectService.EXPECT().Get(gomock.Any(), targetDID).Return(nil, storage.ErrDataNotFound)
+
+		rr := handleRequest(t, op, "/v1/breakglass", http.MethodPost, bytes.NewReader(body))
+
+		require.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("Not a responder", func(t *testing.T) {
+		op, m := newOperation(t)
+
+		m.protectService.EXPECT().Get(gomock.Any(), targetDID).Return(protectedData, nil)
+		m.policyService.EXPECT().Check(gomock.Any(), testPolicyID, responderDID, policy.Responder).
+			Return(policy.ErrNotAllowed)
+
+		rr := handleRequest(t, op, "/v1/breakglass", http.MethodPost, bytes.NewReader(body))
+
+		require.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+
+	t.Run("Fail to create ticket", func(t *testing.T) {
+		op, m := newOperation(t)
+
+		m.protectService.EXPECT().Get(gomock.Any(), targetDID).Return(protectedData, nil)
+		m.policyService.EXPECT().Check(gomock.Any(), testPolicyID, responderDID, policy.Responder).Return(nil)
+		m.policyService.EXPECT().Get(gomock.Any(), testPolicyID).Return(p, nil)
+		m.releaseService.EXPECT().BreakGlass(gomock.Any(), targetDID, testPolicyID, responderDID, "cardiac arrest").
+			Return(nil, errors.New("store error"))
+
+		rr := handleRequest(t, op, "/v1/breakglass", http.MethodPost, bytes.NewReader(body))
+
+		require.Equal(t, http.StatusInternalServerError, rr.Code)
+		require.Contains(t, rr.Body.String(), "break glass: store error")
+	})
+
+	t.Run("Data is not collected if a notification fails", func(t *testing.T) {
+		op, m := newOperation(t)
+
+		m.protectService.EXPECT().Get(gomock.Any(), targetDID).Return(protectedData, nil)
+		m.policyService.EXPECT().Check(gomock.Any(), testPolicyID, responderDID, policy.Responder).Return(nil)
+		m.policyService.EXPECT().Get(gomock.Any(), testPolicyID).Return(p, nil)
+		m.releaseService.EXPECT().BreakGlass(gomock.Any(), targetDID, testPolicyID, responderDID, "cardiac arrest").
+			Return(breakGlassTicket, nil)
+		m.notifier.EXPECT().Notify(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(3)
+		m.notifier.EXPECT().Notify(gomock.Any(), gomock.Any(), gomock.Any()).Return(errors.New("webhook error"))
+
+		rr := handleRequest(t, op, "/v1/breakglass", http.MethodPost, bytes.NewReader(body))
+
+		require.Equal(t, http.StatusBadGateway, rr.Code)
+		require.Contains(t, rr.Body.String(), "notify break-glass access: webhook error")
+	})
+
+	t.Run("Data is not collected if the notifications are not audited", func(t *testing.T) {
+		op, m := newOperation(t)
+
+		m.protectService.EXPECT().Get(gomock.Any(), targetDID).Return(protectedData, nil)
+		m.policyService.EXPECT().Check(gomock.Any(), testPolicyID, responderDID, policy.Responder).Return(nil)
+		m.policyService.EXPECT().Get(gomock.Any(), testPolicyID).Return(p, nil)
+		m.releaseService.EXPECT().BreakGlass(gomock.Any(), targetDID, testPolicyID, responderDID, "cardiac arrest").
+			Return(breakGlassTicket, nil)
+		m.notifier.EXPECT().Notify(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(4)
+		m.auditLog.EXPECT().Append(gomock.Any(), gomock.Any()).Return(errors.New("store error"))
+
+		rr := handleRequest(t, op, "/v1/breakglass", http.MethodPost, bytes.NewReader(body))
+
+		require.Equal(t, http.StatusBadGateway, rr.Code)
+		require.Contains(t, rr.Body.String(), "append break_glass_notified to audit log: store error")
+	})
+
+	t.Run("Fail to collect data", func(t *testing.T) {
+		op, m := newOperation(t)
+
+		m.protectService.EXPECT().Get(gomock.Any(), targetDID).Return(protectedData, nil)
+		m.policyService.EXPECT().Check(gomock.Any(), testPolicyID, responderDID, policy.Responder).Return(nil)
+		m.policyService.EXPECT().Get(gomock.Any(), testPolicyID).Return(p, nil)
+		m.releaseService.EXPECT().BreakGlass(gomock.Any(), targetDID, testPolicyID, responderDID, "cardiac arrest").
+			Return(breakGlassTicket, nil)
+		m.notifier.EXPECT().Notify(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(4)
+		m.auditLog.EXPECT().Append(gomock.Any(), gomock.Any()).Return(nil)
+		m.collectService.EXPECT().Collect(gomock.Any(), protectedData, responderDID).
+			Return("", errors.New("collect error"))
+
+		rr := handleRequest(t, op, "/v1/breakglass", http.MethodPost, bytes.NewReader(body))
+
+		require.Equal(t, http.StatusInternalServerError, rr.Code)
+		require.Contains(t, rr.Body.String(), "fail to collect data: collect error")
+	})
+
+	t.Run("Break-glass ticket is not collected by handlers", func(t *testing.T) {
+		op, m := newOperation(t)
+
+		m.releaseService.EXPECT().Get(gomock.Any(), testTicketID).Return(breakGlassTicket, nil)
+		m.protectService.EXPECT().Get(gomock.Any(), targetDID).Return(protectedData, nil)
+
+		rr := handleRequest(t, op, "/v1/release/"+testTicketID+"/collect", http.MethodPost, nil)
+
+		require.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+
+	t.Run("Break-glass ticket is not decided on", func(t *testing.T) {
+		op, m := newOperation(t)
+
+		m.releaseService.EXPECT().Get(gomock.Any(), testTicketID).Return(breakGlassTicket, nil)
+
+		rr := handleRequest(t, op, "/v1/release/"+testTicketID+"/authorize", http.MethodPost, nil)
+
+		require.Equal(t, http.StatusConflict, rr.Code)
+	})
+
+	t.Run("Not served without notifier", func(t *testing.T) {
+		rr := handleRequest(t, &operation.Operation{}, "/v1/breakglass", http.MethodPost, bytes.NewReader(body))
+
+		require.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
+
+func TestExtractHandler(t *testing.T) {
+	const (
+		testQueryID = "queryID1234"
+	)
+
+	req := operation.ExtractRequest{
+		QueryID: testQueryID,
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		extractService := NewMockExtractService(ctrl)
+		extractService.EXPECT().Extract(gomock.Any(), testQueryID).Return("target", nil)
+
+		releaseService := NewMockReleaseService(ctrl)
+		releaseService.EXPECT().GetByQueryID(gomock.Any(), testQueryID).Return(nil, storage.ErrDataNotFound)
+
+		op := &operation.Operation{
+			ExtractService: extractService,
+			ReleaseService: releaseService,
+		}
+
+		body, err := json.Marshal(req)
+		require.NoError(t, err)
+
+		rr := handleRequest(t, op, "/v1/extract", http.MethodPost, bytes.NewReader(body))
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.Contains(t, rr.Body.String(), `"target":"target"`)
+	})
+
+	t.Run("Success: data is masked", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		extractService := NewMockExtractService(ctrl)
+		extractService.EXPECT().Extract(gomock.Any(), testQueryID).Return("123-45-6789", nil)
+
+		releaseService := NewMockReleaseService(ctrl)
+		releaseService.EXPECT().GetByQueryID(gomock.Any(), testQueryID).
+			Return(&ticket.Ticket{ID: "ticket", DID: targetDID}, nil)
+
+		protectService := NewMockProtectService(ctrl)
+		protectService.EXPECT().Get(gomock.Any(), targetDID).
+			Return(&protect.ProtectedData{DID: targetDID, PolicyID: testPolicyID}, nil)
+
+		policyService := NewMockPolicyService(ctrl)
+		policyService.EXPECT().Get(gomock.Any(), testPolicyID).Return(&policy.Policy{
+			ID:   testPolicyID,
+			Mask: &policy.Mask{Rule: policy.MaskLast, Count: 4},
+		}, nil)
+
+		op := &operation.Operation{
+			ExtractService: extractService,
+			ReleaseService: releaseService,
+			ProtectService: protectService,
+			PolicyService:  policyService,
+		}
+
+		body, err := json.Marshal(req)
+		require.NoError(t, err)
+
+		rr := handleRequest(t, op, "/v1/extract", http.MethodPost, bytes.NewReader(body))
+
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var resp operation.ExtractResponse
+
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		require.Equal(t, "*******6789", resp.Target)
+	})
+
+	t.Run("Data protected in another region", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		extractService := NewMockExtractService(ctrl)
+		extractService.EXPECT().Extract(gomock.Any(), testQueryID).Return("target", nil).Times(2)
+
+		releaseService := NewMockReleaseService(ctrl)
+		releaseService.EXPECT().GetByQueryID(gomock.Any(), testQueryID).
+			Return(&ticket.Ticket{ID: "ticket", DID: targetDID}, nil).Times(2)
+
+		protectService := NewMockProtectService(ctrl)
+		protectService.EXPECT().Get(gomock.Any(), targetDID).
+			Return(&protect.ProtectedData{DID: targetDID, PolicyID: testPolicyID, Region: "eu"}, nil).Times(2)
+
+		policyService := NewMockPolicyService(ctrl)
+		policyService.EXPECT().Get(gomock.Any(), testPolicyID).Return(&policy.Policy{ID: testPolicyID}, nil)
+
+		op := &operation.Operation{
+			ExtractService: extractService,
+			ReleaseService: releaseService,
+			ProtectService: protectService,
+			PolicyService:  policyService,
+			Region:         "us",
+		}
+
+		body, err := json.Marshal(req)
+		require.NoError(t, err)
+
+		rr := handleRequest(t, op, "/v1/extract", http.MethodPost, bytes.NewReader(body))
+
+		require.Equal(t, http.StatusMisdirectedRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), `data is protected in region \"eu\"`)
+
+		op.Region = "eu"
+
+		rr = handleRequest(t, op, "/v1/extract", http.MethodPost, bytes.NewReader(body))
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.Contains(t, rr.Body.String(), `"target":"target"`)
+	})
+
+	t.Run("Success: extraction is recorded", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		extractService := NewMockExtractService(ctrl)
+		extractService.EXPECT().Extract(gomock.Any(), testQueryID).Return("target", nil)
+
+		tk := &ticket.Ticket{ID: "ticket", DID: targetDID, PolicyID: testPolicyID}
+
+		releaseService := NewMockReleaseService(ctrl)
+		releaseService.EXPECT().GetByQueryID(gomock.Any(), testQueryID).Return(tk, nil)
+
+		protectService := NewMockProtectService(ctrl)
+		protectService.EXPECT().Get(gomock.Any(), targetDID).
+			Return(&protect.ProtectedData{DID: targetDID, PolicyID: testPolicyID}, nil)
+
+		policyService := NewMockPolicyService(ctrl)
+		policyService.EXPECT().Get(gomock.Any(), testPolicyID).Return(&policy.Policy{ID: testPolicyID}, nil)
+
+		decisionMetrics := NewMockDecisionMetrics(ctrl)
+		decisionMetrics.EXPECT().Extracted(tk, testPolicyID)
+
+		op := &operation.Operation{
+			ExtractService:  extractService,
+			ReleaseService:  releaseService,
+			ProtectService:  protectService,
+			PolicyService:   policyService,
+			DecisionMetrics: decisionMetrics,
+		}
+
+		body, err := json.Marshal(req)
+		require.NoError(t, err)
+
+		rr := handleRequest(t, op, "/v1/extract", http.MethodPost, bytes.NewReader(body))
+
+		require.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("Success: data is watermarked", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		extractService := NewMockExtractService(ctrl)
+		extractService.EXPECT().Extract(gomock.Any(), testQueryID).Return("jane@example.com", nil)
+
+		releaseService := NewMockReleaseService(ctrl)
+		releaseService.EXPECT().GetByQueryID(gomock.Any(), testQueryID).Return(&ticket.Ticket{
+			ID:          "ticket",
+			DID:         targetDID,
+			RequestedBy: "did:example:handler",
+		}, nil)
+
+		protectService := NewMockProtectService(ctrl)
+		protectService.EXPECT().Get(gomock.Any(), targetDID).
+			Return(&protect.ProtectedData{DID: targetDID, PolicyID: testPolicyID}, nil)
+
+		w := &policy.Watermark{Type: policy.WatermarkEmailTag}
+
+		policyService := NewMockPolicyService(ctrl)
+		policyService.EXPECT().Get(gomock.Any(), testPolicyID).Return(&policy.Policy{
+			ID:        testPolicyID,
+			Mask:      &policy.Mask{Rule: policy.MaskEmailDomain},
+			Watermark: w,
+		}, nil)
+
+		watermarker := NewMockWatermarker(ctrl)
+		watermarker.EXPECT().Embed(gomock.Any(), "****@example.com", w, &watermark.Record{
+			DID:      targetDID,
+			TicketID: "ticket",
+			QueryID:  testQueryID,
+			Handler:  "did:example:handler",
+		}).Return("****+mark@example.com", nil)
+
+		op := &operation.Operation{
+			ExtractService: extractService,
+			ReleaseService: releaseService,
+			ProtectService: protectService,
+			PolicyService:  policyService,
+			Watermarker:    watermarker,
+		}
+
+		body, err := json.Marshal(req)
+		require.NoError(t, err)
+
+		rr := handleRequest(t, op, "/v1/extract", http.MethodPost, bytes.NewReader(body))
+
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var resp operation.ExtractResponse
+
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		require.Equal(t, "****+mark@example.com", resp.Target)
+	})
+
+	t.Run("Fail to embed watermark", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		extractService := NewMockExtractService(ctrl)
+		extractService.EXPECT().Extract(gomock.Any(), testQueryID).Return("target", nil)
+
+		releaseService := NewMockReleaseService(ctrl)
+		releaseService.EXPECT().GetByQueryID(gomock.Any(), testQueryID).
+			Return(&ticket.Ticket{ID: "ticket", DID: targetDID}, nil)
+
+		protectService := NewMockProtectService(ctrl)
+		protectService.EXPECT().Get(gomock.Any(), targetDID).
+			Return(&protect.ProtectedData{DID: targetDID, PolicyID: testPolicyID}, nil)
+
+		policyService := NewMockPolicyService(ctrl)
+		policyService.EXPECT().Get(gomock.Any(), testPolicyID).Return(&policy.Policy{
+			ID:        testPolicyID,
+			Watermark: &policy.Watermark{Type: policy.WatermarkZeroWidth},
+		}, nil)
+
+		watermarker := NewMockWatermarker(ctrl)
+		watermarker.EXPECT().Embed(gomock.Any(), "target", gomock.Any(), gomock.Any()).
+			Return("", errors.New("store error"))
+
+		op := &operation.Operation{
+			ExtractService: extractService,
+			ReleaseService: releaseService,
+			ProtectService: protectService,
+			PolicyService:  policyService,
+			Watermarker:    watermarker,
+		}
+
+		body, err := json.Marshal(req)
+		require.NoError(t, err)
+
+		rr := handleRequest(t, op, "/v1/extract", http.MethodPost, bytes.NewReader(body))
+
+		require.Equal(t, http.StatusInternalServerError, rr.Code)
+		require.Contains(t, rr.Body.String(), "embed watermark: store error")
+		require.NotContains(t, rr.Body.String(), `"target"`)
+	})
+
+	t.Run("Fail to get ticket", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		extractService := NewMockExtractService(ctrl)
+		extractService.EXPECT().Extract(gomock.Any(), testQueryID).Return("target", nil)
+
+		releaseService := NewMockReleaseService(ctrl)
+		releaseService.EXPECT().GetByQueryID(gomock.Any(), testQueryID).Return(nil, errors.New("query error"))
+
+		op := &operation.Operation{
+			ExtractService: extractService,
+			ReleaseService: releaseService,
+		}
+
+		body, err := json.Marshal(req)
+		require.NoError(t, err)
+
+		rr := handleRequest(t, op, "/v1/extract", http.MethodPost, bytes.NewReader(body))
+
+		require.Equal(t, http.StatusInternalServerError, rr.Code)
+		require.Contains(t, rr.Body.String(), "get ticket: query error")
+		require.NotContains(t, rr.Body.String(), `"target"`)
+	})
+
+	t.Run("Fail to get policy", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		extractService := NewMockExtractService(ctrl)
+		extractService.EXPECT().Extract(gomock.Any(), testQueryID).Return("target", nil)
+
+		releaseService := NewMockReleaseService(ctrl)
+		releaseService.EXPECT().GetByQueryID(gomock.Any(), testQueryID).
+			Return(&ticket.Ticket{ID: "ticket", DID: targetDID}, nil)
+
+		protectService := NewMockProtectService(ctrl)
+		protectService.EXPECT().Get(gomock.Any(), targetDID).
+			Return(&protect.ProtectedData{DID: targetDID, PolicyID: testPolicyID}, nil)
+
+		policyService := NewMockPolicyService(ctrl)
+		policyService.EXPECT().Get(gomock.Any(), testPolicyID).Return(nil, errors.New("get error"))
+
+		op := &operation.Operation{
+			ExtractService: extractService,
+			ReleaseService: releaseService,
+			ProtectService: protectService,
+			PolicyService:  policyService,
+		}
+
+		body, err := json.Marshal(req)
+		require.NoError(t, err)
+
+		rr := handleRequest(t, op, "/v1/extract", http.MethodPost, bytes.NewReader(body))
+
+		require.Equal(t, http.StatusInternalServerError, rr.Code)
+		require.Contains(t, rr.Body.String(), "get policy: get error")
+	})
+
+	t.Run("Success: subject is notified", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		extractService := NewMockExtractService(ctrl)
+		extractService.EXPECT().Extract(gomock.Any(), testQueryID).Return("target", nil)
+
+		releaseService := NewMockReleaseService(ctrl)
+		releaseService.EXPECT().GetByQueryID(gomock.Any(), testQueryID).Return(&ticket.Ticket{
+			ID:          "ticket",
+			DID:         targetDID,
+			RequestedBy: "did:example:handler",
+		}, nil)
+
+		protectService := NewMockProtectService(ctrl)
+		protectService.EXPECT().Get(gomock.Any(), targetDID).
+			Return(&protect.ProtectedData{DID: targetDID, PolicyID: testPolicyID}, nil)
+
+		notification := &policy.Notification{DIDComm: true}
+
+		policyService := NewMockPolicyService(ctrl)
+		policyService.EXPECT().Get(gomock.Any(), testPolicyID).
+			Return(&policy.Policy{ID: testPolicyID, Notification: notification}, nil)
+
+		notifier := NewMockNotifier(ctrl)
+		notifier.EXPECT().Notify(gomock.Any(), notification, gomock.Any()).DoAndReturn(
+			func(_ context.Context, _ *policy.Notification, e *notify.Event) error {
+				require.Equal(t, notify.Extracted, e.Type)
+				require.Equal(t, "ticket", e.TicketID)
+				require.Equal(t, "did:example:handler", e.RequestingParty)
+				require.Empty(t, e.Subject)
+
+				return nil
+			})
+
+		op := &operation.Operation{
+			ExtractService: extractService,
+			ReleaseService: releaseService,
+			ProtectService: protectService,
+			PolicyService:  policyService,
+			Notifier:       notifier,
+		}
+
+		body, err := json.Marshal(req)
+		require.NoError(t, err)
+
+		rr := handleRequest(t, op, "/v1/extract", http.MethodPost, bytes.NewReader(body))
+
+		require.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("Success: no notification without ticket or policy notification", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		extractService := NewMockExtractService(ctrl)
+		extractService.EXPECT().Extract(gomock.Any(), gomock.Any()).Return("target", nil).Times(2)
+
+		releaseService := NewMockReleaseService(ctrl)
+		releaseService.EXPECT().GetByQueryID(gomock.Any(), "unknown").
+			Return(nil, fmt.Errorf("get ticket by query: %w", storage.ErrDataNotFound))
+		releaseService.EXPECT().GetByQueryID(gomock.Any(), testQueryID).
+			Return(&ticket.Ticket{ID: "ticket", DID: targetDID}, nil)
+
+		protectService := NewMockProtectService(ctrl)
+		protectService.EXPECT().Get(gomock.Any(), targetDID).
+			Return(&protect.ProtectedData{DID: targetDID, PolicyID: testPolicyID}, nil)
+
+		policyService := NewMockPolicyService(ctrl)
+		policyService.EXPECT().Get(gomock.Any(), testPolicyID).Return(&policy.Policy{ID: testPolicyID}, nil)
+
+		op := &operation.Operation{
+			ExtractService: extractService,
+			ReleaseService: releaseService,
+			ProtectService: protectService,
+			PolicyService:  policyService,
+			Notifier:       NewMockNotifier(ctrl),
+		}
+
+		for _, queryID := range []string{"unknown", testQueryID} {
+			body, err := json.Marshal(&operation.ExtractRequest{QueryID: queryID})
+			require.NoError(t, err)
+
+			rr := handleRequest(t, op, "/v1/extract", http.MethodPost, bytes.NewReader(body))
+
+			require.Equal(t, http.StatusOK, rr.Code)
+		}
+	})
+
+	t.Run("Fail to unmarshal request body", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		extractService := NewMockExtractService(ctrl)
+		extractService.EXPECT().Extract(gomock.Any(), testQueryID).Return("target", nil).Times(0)
+
+		op := &operation.Operation{
+			ExtractService: extractService,
+		}
+
+		rr := handleRequest(t, op, "/v1/extract", http.MethodPost, bytes.NewBufferString("invalid json"))
+
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("Fail to extract", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		extractService := NewMockExtractService(ctrl)
+		extractService.EXPECT().Extract(gomock.Any(), testQueryID).Return("", errors.New("extract failed"))
+
+		op := &operation.Operation{
+			ExtractService: extractService,
+		}
+
+		body, err := json.Marshal(req)
+		require.NoError(t, err)
+
+		rr := handleRequest(t, op, "/v1/extract", http.MethodPost, bytes.NewReader(body))
+
+		require.Equal(t, http.StatusInternalServerError, rr.Code)
+	})
+}
+
+func TestExtractEncryption(t *testing.T) {
+	const (
+		testQueryID = "queryID1234"
+		handlerDID  = "did:example:handler"
+	)
+
+	jwe := json.RawMessage(`{"protected":"eyJ0eXAiOiJKT1NFK0pTT04ifQ","ciphertext":"Y2lwaGVy"}`)
+
+	type mocks struct {
+		policy    *policy.Policy
+		encrypter *MockExtractEncrypter
+	}
+
+	newOperation := func(t *testing.T, m *mocks) *operation.Operation {
+		t.Helper()
+
+		ctrl := gomock.NewController(t)
+
+		extractService := NewMockExtractService(ctrl)
+		extractService.EXPECT().Extract(gomock.Any(), testQueryID).Return("123-45-6789", nil)
+
+		releaseService := NewMockReleaseService(ctrl)
+		releaseService.EXPECT().GetByQueryID(gomock.Any(), testQueryID).
+			Return(&ticket.Ticket{ID: testTicketID, DID: targetDID, RequestedBy: handlerDID}, nil)
+
+		protectService := NewMockProtectService(ctrl)
+		protectService.EXPECT().Get(gomock.Any(), targetDID).
+			Return(&protect.ProtectedData{DID: targetDID, PolicyID: testPolicyID}, nil)
+
+		policyService := NewMockPolicyService(ctrl)
+		policyService.EXPECT().Get(gomock.Any(), testPolicyID).Return(m.policy, nil)
+
+		op := &operation.Operation{
+			ExtractService: extractService,
+			ReleaseService: releaseService,
+			ProtectService: protectService,
+			PolicyService:  policyService,
+		}
+
+		if m.encrypter != nil {
+			op.ExtractEncrypter = m.encrypter
+		}
+
+		return op
+	}
+
+	extract := func(t *testing.T, op *operation.Operation, req *operation.ExtractRequest,
+	) *httptest.ResponseRecorder {
+		t.Helper()
+
+		body, err := json.Marshal(req)
+		require.NoError(t, err)
+
+		return handleRequest(t, op, "/v1/extract", http.MethodPost, bytes.NewReader(body))
+	}
+
+	t.Run("Success: encryption requested", func(t *testing.T) {
+		encrypter := NewMockExtractEncrypter(gomock.NewController(t))
+		encrypter.EXPECT().Encrypt(gomock.Any(), handlerDID, []byte("*******6789")).Return(jwe, nil)
+
+		op := newOperation(t, &mocks{
+			policy: &policy.Policy{
+				ID:   testPolicyID,
+				Mask: &policy.Mask{Rule: policy.MaskLast, Count: 4},
+			},
+			encrypter: encrypter,
+		})
+
+		rr := extract(t, op, &operation.ExtractRequest{QueryID: testQueryID, Encrypt: true})
+
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var resp operation.ExtractResponse
+
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		require.Empty(t, resp.Target)
+		require.JSONEq(t, string(jwe), string(resp.JWE))
+	})
+
+	t.Run("Success: encryption required by the policy", func(t *testing.T) {
+		encrypter := NewMockExtractEncrypter(gomock.NewController(t))
+		encrypter.EXPECT().Encrypt(gomock.Any(), handlerDID, []byte("123-45-6789")).Return(jwe, nil)
+
+		op := newOperation(t, &mocks{
+			policy:    &policy.Policy{ID: testPolicyID, EncryptExtract: true},
+			encrypter: encrypter,
+		})
+
+		rr := extract(t, op, &operation.ExtractRequest{QueryID: testQueryID})
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.NotContains(t, rr.Body.String(), "123-45-6789")
+		require.Contains(t, rr.Body.String(), `"jwe":`)
+	})
+
+	t.Run("Fail: no encrypter", func(t *testing.T) {
+		op := newOperation(t, &mocks{policy: &policy.Policy{ID: testPolicyID, EncryptExtract: true}})
+
+		rr := extract(t, op, &operation.ExtractRequest{QueryID: testQueryID})
+
+		require.Equal(t, http.StatusNotImplemented, rr.Code)
+		require.Contains(t, rr.Body.String(), "extracted data cannot be encrypted")
+	})
+
+	t.Run("Fail: handler has no key agreement key", func(t *testing.T) {
+		encrypter := NewMockExtractEncrypter(gomock.NewController(t))
+		encrypter.EXPECT().Encrypt(gomock.Any(), handlerDID, gomock.Any()).
+			Return(nil, fmt.Errorf("recipient %s: %w", handlerDID, encrypt.ErrNoKeyAgreementKey))
+
+		op := newOperation(t, &mocks{policy: &policy.Policy{ID: testPolicyID}, encrypter: encrypter})
+
+		rr := extract(t, op, &operation.ExtractRequest{QueryID: testQueryID, Encrypt: true})
+
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "no X25519 or NIST P key agreement key")
+	})
+
+	t.Run("Fail to encrypt", func(t *testing.T) {
+		encrypter := NewMockExtractEncrypter(gomock.NewController(t))
+		encrypter.EXPECT().Encrypt(gomock.Any(), handlerDID, gomock.Any()).
+			Return(nil, errors.New("resolve recipient: not found"))
+
+		op := newOperation(t, &mocks{policy: &policy.Policy{ID: testPolicyID}, encrypter: encrypter})
+
+		rr := extract(t, op, &operation.ExtractRequest{QueryID: testQueryID, Encrypt: true})
+
+		require.Equal(t, http.StatusInternalServerError, rr.Code)
+		require.Contains(t, rr.Body.String(), "encrypt extracted data: resolve recipient: not found")
+	})
+
+	t.Run("Fail: handler of the query is unknown", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		extractService := NewMockExtractService(ctrl)
+		extractService.EXPECT().Extract(gomock.Any(), testQueryID).Return("123-45-6789", nil)
+
+		releaseService := NewMockReleaseService(ctrl)
+		releaseService.EXPECT().GetByQueryID(gomock.Any(), testQueryID).Return(nil, storage.ErrDataNotFound)
+
+		op := &operation.Operation{
+			ExtractService:   extractService,
+			ReleaseService:   releaseService,
+			ExtractEncrypter: NewMockExtractEncrypter(ctrl),
+		}
+
+		rr := extract(t, op, &operation.ExtractRequest{QueryID: testQueryID, Encrypt: true})
+
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.NotContains(t, rr.Body.String(), "123-45-6789")
+	})
+}
+
+func TestExtractBatchHandler(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		extractService := NewMockExtractService(ctrl)
+		extractService.EXPECT().Extract(gomock.Any(), "query-1").Return("target 1", nil)
+		extractService.EXPECT().Extract(gomock.Any(), "query-2").Return("", errors.New("query expired"))
+		extractService.EXPECT().Extract(gomock.Any(), "query-3").Return("target 3", nil)
+
+		releaseService := NewMockReleaseService(ctrl)
+		releaseService.EXPECT().GetByQueryID(gomock.Any(), gomock.Any()).Return(nil, storage.ErrDataNotFound).Times(2)
+
+		op := &operation.Operation{
+			ExtractService: extractService,
+			ReleaseService: releaseService,
+		}
+
+		body, err := json.Marshal(&operation.ExtractBatchRequest{QueryIDs: []string{"query-1", "query-2", "query-3"}})
+		require.NoError(t, err)
+
+		rr := handleRequest(t, op, "/v1/extract/batch", http.MethodPost, bytes.NewReader(body))
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.Equal(t, "application/x-ndjson", rr.Header().Get("Content-Type"))
+
+		var items []*operation.ExtractBatchItem
+
+		dec := json.NewDecoder(rr.Body)
+
+		for dec.More() {
+			var item operation.ExtractBatchItem
+
+			require.NoError(t, dec.Decode(&item))
+
+			items = append(items, &item)
+		}
+
+		require.Equal(t, []*operation.ExtractBatchItem{
+			{QueryID: "query-1", Target: "target 1"},
+			{
+				QueryID: "query-2",
+				Error:   "fail to resolve extract data: query expired",
+				Status:  http.StatusInternalServerError,
+			},
+			{QueryID: "query-3", Target: "target 3"},
+		}, items)
+
+		res := rr.Result()
+		require.NoError(t, res.Body.Close())
+		require.Equal(t, "1", res.Trailer.Get(operation.FailedQueriesTrailer))
+	})
+
+	t.Run("Encryption requested", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		extractService := NewMockExtractService(ctrl)
+		extractService.EXPECT().Extract(gomock.Any(), "query-1").Return("target 1", nil)
+
+		releaseService := NewMockReleaseService(ctrl)
+		releaseService.EXPECT().GetByQueryID(gomock.Any(), "query-1").Return(nil, storage.ErrDataNotFound)
+
+		op := &operation.Operation{
+			ExtractService:   extractService,
+			ReleaseService:   releaseService,
+			ExtractEncrypter: NewMockExtractEncrypter(ctrl),
+		}
+
+		body, err := json.Marshal(&operation.ExtractBatchRequest{QueryIDs: []string{"query-1"}, Encrypt: true})
+		require.NoError(t, err)
+
+		rr := handleRequest(t, op, "/v1/extract/batch", http.MethodPost, bytes.NewReader(body))
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.NotContains(t, rr.Body.String(), "target 1")
+		require.Contains(t, rr.Body.String(), `"status":400`)
+	})
+
+	t.Run("Fail to unmarshal request body", func(t *testing.T) {
+		rr := handleRequest(t, &operation.Operation{}, "/v1/extract/batch", http.MethodPost,
+			bytes.NewReader([]byte("")))
+
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("No queries", func(t *testing.T) {
+		rr := handleRequest(t, &operation.Operation{}, "/v1/extract/batch", http.MethodPost,
+			bytes.NewReader([]byte(`{"query_ids":[]}`)))
+
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "no queries to extract")
+	})
+
+	t.Run("Too many queries", func(t *testing.T) {
+		body, err := json.Marshal(&operation.ExtractBatchRequest{QueryIDs: make([]string, 1001)})
+		require.NoError(t, err)
+
+		rr := handleRequest(t, &operation.Operation{}, "/v1/extract/batch", http.MethodPost, bytes.NewReader(body))
+
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "too many queries to extract: 1001, at most 1000")
+	})
+
+	t.Run("Stops when the context is done", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		ctx, cancel := context.WithCancel(context.Background())
+
+		extractService := NewMockExtractService(ctrl)
+		extractService.EXPECT().Extract(gomock.Any(), "query-1").DoAndReturn(func(context.Context, string) (string, error) {
+			cancel()
+
+			return "target 1", nil
+		})
+
+		releaseService := NewMockReleaseService(ctrl)
+		releaseService.EXPECT().GetByQueryID(gomock.Any(), "query-1").Return(nil, storage.ErrDataNotFound)
+
+		op := &operation.Operation{
+			ExtractService: extractService,
+			ReleaseService: releaseService,
+		}
+
+		var items []*operation.ExtractBatchItem
+
+		err := op.ExtractBatch(ctx, &operation.ExtractBatchRequest{QueryIDs: []string{"query-1", "query-2"}},
+			func(item *operation.ExtractBatchItem) error {
+				items = append(items, item)
+
+				return nil
+			})
+		require.ErrorIs(t, err, context.Canceled)
+		require.Len(t, items, 1)
+	})
+}
+
+func TestPurgeHandler(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		purgeService := NewMockPurgeService(ctrl)
+		purgeService.EXPECT().Purge(gomock.Any()).Times(1)
+
+		op := &operation.Operation{
+			PurgeService: purgeService,
+		}
+
+		rr := handleRequest(t, op, "/v1/purge", http.MethodPost, nil)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("Not served without purge service", func(t *testing.T) {
+		op := &operation.Operation{}
+
+		rr := handleRequest(t, op, "/v1/purge", http.MethodPost, nil)
+
+		require.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
+
+func TestDSARHandler(t *testing.T) {
+	receipts := []*consent.Receipt{
+		{ID: "receipt-1", DID: targetDID, Subject: subjectDID, PolicyID: testPolicyID, Purposes: []string{"marketing"}},
+		{ID: "receipt-2", DID: targetDID, Subject: subjectDID, PolicyID: testPolicyID, Purposes: []string{"marketing"}},
+		{ID: "receipt-3", DID: "did:example:deleted", Subject: subjectDID, PolicyID: testPolicyID},
+	}
+
+	iterateReceipts := func(_ context.Context, _ string, _ int, fn func(r *consent.Receipt) error) error {
+		for _, r := range receipts {
+			if err := fn(r); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		subjectResolver := NewMockSubjectResolver(ctrl)
+		subjectResolver.EXPECT().Resolve(gomock.Any()).Return(subjectDID, nil)
+
+		consentService := NewMockConsentService(ctrl)
+		consentService.EXPECT().IterateSubject(gomock.Any(), subjectDID, gomock.Any(), gomock.Any()).
+			DoAndReturn(iterateReceipts)
+
+		protectService := NewMockProtectService(ctrl)
+		protectService.EXPECT().IterateSubject(gomock.Any(), subjectDID, gomock.Any(), gomock.Any()).Return(nil)
+		protectService.EXPECT().Get(gomock.Any(), targetDID).
+			Return(&protect.ProtectedData{DID: targetDID, PolicyID: testPolicyID}, nil)
+		protectService.EXPECT().Get(gomock.Any(), "did:example:deleted").
+			Return(nil, fmt.Errorf("get protected data: %w", storage.ErrDataNotFound))
+
+		releaseService := NewMockReleaseService(ctrl)
+		releaseService.EXPECT().IterateDID(gomock.Any(), targetDID, gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, did string, _ int, fn func(t *ticket.Ticket) error) error {
+				return fn(&ticket.Ticket{ID: "ticket", DID: did, Status: ticket.ReadyToCollect,
+					ApprovedBy: []string{"did:example:approver"}})
+			})
+
+		op := &operation.Operation{
+			SubjectResolver: subjectResolver,
+			ConsentService:  consentService,
+			ProtectService:  protectService,
+			ReleaseService:  releaseService,
+		}
+
+		rr := handleRequest(t, op, "/v1/dsar", http.MethodGet, nil)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var resp operation.DSARResponse
+
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		require.Equal(t, subjectDID, resp.Subject)
+		require.Len(t, resp.Data, 1)
+		require.Equal(t, targetDID, resp.Data[0].DID)
+		require.Equal(t, testPolicyID, resp.Data[0].PolicyID)
+		require.Len(t, resp.Data[0].Consents, 2)
+		require.Equal(t, []*operation.TicketResponse{{
+			ID:         "ticket",
+			DID:        targetDID,
+			Status:     "READY_TO_COLLECT",
+			ApprovedBy: []string{"did:example:approver"},
+		}}, resp.Data[0].Releases)
+	})
+
+	t.Run("Success with linked data", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		subjectResolver := NewMockSubjectResolver(ctrl)
+		subjectResolver.EXPECT().Resolve(gomock.Any()).Return(subjectDID, nil)
+
+		consentService := NewMockConsentService(ctrl)
+		consentService.EXPECT().IterateSubject(gomock.Any(), subjectDID, gomock.Any(), gomock.Any()).
+			DoAndReturn(iterateReceipts)
+
+		protectService := NewMockProtectService(ctrl)
+		protectService.EXPECT().IterateSubject(gomock.Any(), subjectDID, gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, _ string, _ int, fn func(data *protect.ProtectedData) error) error {
+				return fn(&protect.ProtectedData{DID: "did:example:linked", PolicyID: "other-policy", Subject: subjectDID})
+			})
+		protectService.EXPECT().Get(gomock.Any(), targetDID).
+			Return(&protect.ProtectedData{DID: targetDID, PolicyID: testPolicyID}, nil)
+		protectService.EXPECT().Get(gomock.Any(), "did:example:deleted").
+			Return(nil, fmt.Errorf("get protected data: %w", storage.ErrDataNotFound))
+
+		releaseService := NewMockReleaseService(ctrl)
+		releaseService.EXPECT().IterateDID(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(2)
+
+		op := &operation.Operation{
+			SubjectResolver: subjectResolver,
+			ConsentService:  consentService,
+			ProtectService:  protectService,
+			ReleaseService:  releaseService,
+		}
+
+		rr := handleRequest(t, op, "/v1/dsar", http.MethodGet, nil)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var resp operation.DSARResponse
+
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		require.Len(t, resp.Data, 2)
+		require.Equal(t, "did:example:linked", resp.Data[0].DID)
+		require.Equal(t, "other-policy", resp.Data[0].PolicyID)
+		require.True(t, resp.Data[0].Linked)
+		require.Empty(t, resp.Data[0].Consents)
+		require.Equal(t, targetDID, resp.Data[1].DID)
+		require.False(t, resp.Data[1].Linked)
+	})
+
+	t.Run("Fail to read linked data", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		subjectResolver := NewMockSubjectResolver(ctrl)
+		subjectResolver.EXPECT().Resolve(gomock.Any()).Return(subjectDID, nil)
+
+		protectService := NewMockProtectService(ctrl)
+		protectService.EXPECT().IterateSubject(gomock.Any(), subjectDID, gomock.Any(), gomock.Any()).
+			Return(errors.New("query error"))
+
+		op := &operation.Operation{
+			SubjectResolver: subjectResolver,
+			ConsentService:  NewMockConsentService(ctrl),
+			ProtectService:  protectService,
+		}
+
+		rr := handleRequest(t, op, "/v1/dsar", http.MethodGet, nil)
+
+		require.Equal(t, http.StatusInternalServerError, rr.Code)
+		require.Contains(t, rr.Body.String(), "read linked data: query error")
+	})
+
+	t.Run("No protected data", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		subjectResolver := NewMockSubjectResolver(ctrl)
+		subjectResolver.EXPECT().Resolve(gomock.Any()).Return(subjectDID, nil)
+
+		consentService := NewMockConsentService(ctrl)
+		consentService.EXPECT().IterateSubject(gomock.Any(), subjectDID, gomock.Any(), gomock.Any()).Return(nil)
+
+		protectService := NewMockProtectService(ctrl)
+		protectService.EXPECT().IterateSubject(gomock.Any(), subjectDID, gomock.Any(), gomock.Any()).Return(nil)
+
+		op := &operation.Operation{
+			SubjectResolver: subjectResolver,
+			ConsentService:  consentService,
+			ProtectService:  protectService,
+		}
+
+		rr := handleRequest(t, op, "/v1/dsar", http.MethodGet, nil)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.JSONEq(t, `{"subject":"`+subjectDID+`","data":[]}`, rr.Body.String())
+	})
+
+	t.Run("Fail to resolve subject", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		subjectResolver := NewMockSubjectResolver(ctrl)
+		subjectResolver.EXPECT().Resolve(gomock.Any()).Return("", errors.New("resolve error"))
+
+		op := &operation.Operation{
+			SubjectResolver: subjectResolver,
+			ConsentService:  NewMockConsentService(ctrl),
+		}
+
+		rr := handleRequest(t, op, "/v1/dsar", http.MethodGet, nil)
+
+		require.Equal(t, http.StatusUnauthorized, rr.Code)
+		require.Contains(t, rr.Body.String(), "resolve error")
+	})
+
+	t.Run("Fail to get protected data", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		subjectResolver := NewMockSubjectResolver(ctrl)
+		subjectResolver.EXPECT().Resolve(gomock.Any()).Return(subjectDID, nil)
+
+		consentService := NewMockConsentService(ctrl)
+		consentService.EXPECT().IterateSubject(gomock.Any(), subjectDID, gomock.Any(), gomock.Any()).
+			DoAndReturn(iterateReceipts)
+
+		protectService := NewMockProtectService(ctrl)
+		protectService.EXPECT().IterateSubject(gomock.Any(), subjectDID, gomock.Any(), gomock.Any()).Return(nil)
+		protectService.EXPECT().Get(gomock.Any(), targetDID).Return(nil, errors.New("get error"))
+
+		op := &operation.Operation{
+			SubjectResolver: subjectResolver,
+			ConsentService:  consentService,
+			ProtectService:  protectService,
+		}
+
+		rr := handleRequest(t, op, "/v1/dsar", http.MethodGet, nil)
+
+		require.Equal(t, http.StatusInternalServerError, rr.Code)
+		require.Contains(t, rr.Body.String(), "read consent receipts: get error")
+	})
+
+	t.Run("Fail to read tickets", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		subjectResolver := NewMockSubjectResolver(ctrl)
+		subjectResolver.EXPECT().Resolve(gomock.Any()).Return(subjectDID, nil)
+
+		consentService := NewMockConsentService(ctrl)
+		consentService.EXPECT().IterateSubject(gomock.Any(), subjectDID, gomock.Any(), gomock.Any()).
+			DoAndReturn(iterateReceipts)
+
+		protectService := NewMockProtectService(ctrl)
+		protectService.EXPECT().IterateSubject(gomock.Any(), subjectDID, gomock.Any(), gomock.Any()).Return(nil)
+		protectService.EXPECT().Get(gomock.Any(), gomock.Any()).
+			Return(&protect.ProtectedData{DID: targetDID, PolicyID: testPolicyID}, nil).AnyTimes()
+
+		releaseService := NewMockReleaseService(ctrl)
+		releaseService.EXPECT().IterateDID(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(errors.New("query error"))
+
+		op := &operation.Operation{
+			SubjectResolver: subjectResolver,
+			ConsentService:  consentService,
+			ProtectService:  protectService,
+			ReleaseService:  releaseService,
+		}
+
+		rr := handleRequest(t, op, "/v1/dsar", http.MethodGet, nil)
+
+		require.Equal(t, http.StatusInternalServerError, rr.Code)
+		require.Contains(t, rr.Body.String(), "read tickets: query error")
+	})
+
+	t.Run("Not served without consent service", func(t *testing.T) {
+		rr := handleRequest(t, &operation.Operation{}, "/v1/dsar", http.MethodGet, nil)
+
+		require.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
+
+func TestEraseHandler(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		subjectResolver := NewMockSubjectResolver(ctrl)
+		subjectResolver.EXPECT().Resolve(gomock.Any()).Return(subjectDID, nil)
+
+		consentService := NewMockConsentService(ctrl)
+		consentService.EXPECT().IterateSubject(gomock.Any(), subjectDID, gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, _ string, _ int, fn func(r *consent.Receipt) error) error {
+				return fn(&consent.Receipt{DID: targetDID, Subject: subjectDID, PolicyID: testPolicyID})
+			})
+
+		protectService := NewMockProtectService(ctrl)
+		protectService.EXPECT().IterateSubject(gomock.Any(), subjectDID, gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, _ string, _ int, fn func(data *protect.ProtectedData) error) error {
+				return fn(&protect.ProtectedData{DID: "did:example:linked", PolicyID: testPolicyID, Subject: subjectDID})
+			})
+		protectService.EXPECT().Get(gomock.Any(), targetDID).
+			Return(&protect.ProtectedData{DID: targetDID, PolicyID: testPolicyID}, nil)
+		protectService.EXPECT().Delete(gomock.Any(), "did:example:linked", targetDID).Return(nil)
+
+		op := &operation.Operation{
+			SubjectResolver: subjectResolver,
+			ConsentService:  consentService,
+			ProtectService:  protectService,
+		}
+
+		rr := handleRequest(t, op, "/v1/dsar", http.MethodDelete, nil)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.JSONEq(t, `{"subject":"`+subjectDID+`","dids":["did:example:linked","`+targetDID+`"]}`,
+			rr.Body.String())
+	})
+
+	t.Run("Fail to resolve subject", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		subjectResolver := NewMockSubjectResolver(ctrl)
+		subjectResolver.EXPECT().Resolve(gomock.Any()).Return("", errors.New("resolve error"))
+
+		op := &operation.Operation{
+			SubjectResolver: subjectResolver,
+			ConsentService:  NewMockConsentService(ctrl),
+		}
+
+		rr := handleRequest(t, op, "/v1/dsar", http.MethodDelete, nil)
+
+		require.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+
+	t.Run("Fail to read linked data", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		subjectResolver := NewMockSubjectResolver(ctrl)
+		subjectResolver.EXPECT().Resolve(gomock.Any()).Return(subjectDID, nil)
+
+		protectService := NewMockProtectService(ctrl)
+		protectService.EXPECT().IterateSubject(gomock.Any(), subjectDID, gomock.Any(), gomock.Any()).
+			Return(errors.New("query error"))
+
+		op := &operation.Operation{
+			SubjectResolver: subjectResolver,
+			ConsentService:  NewMockConsentService(ctrl),
+			ProtectService:  protectService,
+		}
+
+		rr := handleRequest(t, op, "/v1/dsar", http.MethodDelete, nil)
+
+		require.Equal(t, http.StatusInternalServerError, rr.Code)
+		require.Contains(t, rr.Body.String(), "read linked data: query error")
+	})
+
+	t.Run("Fail to delete data", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		subjectResolver := NewMockSubjectResolver(ctrl)
+		subjectResolver.EXPECT().Resolve(gomock.Any()).Return(subjectDID, nil)
+
+		consentService := NewMockConsentService(ctrl)
+		consentService.EXPECT().IterateSubject(gomock.Any(), subjectDID, gomock.Any(), gomock.Any()).Return(nil)
+
+		protectService := NewMockProtectService(ctrl)
+		protectService.EXPECT().IterateSubject(gomock.Any(), subjectDID, gomock.Any(), gomock.Any()).Return(nil)
+		protectService.EXPECT().Delete(gomock.Any()).Return(errors.New("batch error"))
+
+		op := &operation.Operation{
+			SubjectResolver: subjectResolver,
+			ConsentService:  consentService,
+			ProtectService:  protectService,
+		}
+
+		rr := handleRequest(t, op, "/v1/dsar", http.MethodDelete, nil)
+
+		require.Equal(t, http.StatusInternalServerError, rr.Code)
+		require.Contains(t, rr.Body.String(), "erase data: batch error")
+	})
+}
+
+func TestLinkHandler(t *testing.T) {
+	req := &operation.LinkRequest{Data: []*operation.LinkedData{{DID: targetDID, Target: "test ssn"}}}
+	data := &protect.ProtectedData{DID: targetDID, PolicyID: testPolicyID}
+
+	body, err := json.Marshal(req)
+	require.NoError(t, err)
+
+	newOperation := func(ctrl *gomock.Controller, protectService *MockProtectService) *operation.Operation {
+		subjectResolver := NewMockSubjectResolver(ctrl)
+		subjectResolver.EXPECT().Resolve(gomock.Any()).Return(subjectDID, nil)
+
+		return &operation.Operation{
+			SubjectResolver: subjectResolver,
+			ConsentService:  NewMockConsentService(ctrl),
+			ProtectService:  protectService,
+		}
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		protectService := NewMockProtectService(ctrl)
+		protectService.EXPECT().Get(gomock.Any(), targetDID).Return(data, nil)
+		protectService.EXPECT().Link(gomock.Any(), subjectDID, []*protect.ProtectedData{data}, []string{"test ssn"}).
+			Return([]*protect.ProtectedData{data}, nil)
+		protectService.EXPECT().IterateSubject(gomock.Any(), subjectDID, gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, _ string, _ int, fn func(data *protect.ProtectedData) error) error {
+				for _, did := range []string{"did:example:linked", targetDID} {
+					if e := fn(&protect.ProtectedData{DID: did, Subject: subjectDID}); e != nil {
+						return e
+					}
+				}
+
+				return nil
+			})
+
+		rr := handleRequest(t, newOperation(ctrl, protectService), "/v1/dsar/link", http.MethodPost,
+			bytes.NewReader(body))
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.JSONEq(t, `{"subject":"`+subjectDID+`","dids":["did:example:linked","`+targetDID+`"]}`,
+			rr.Body.String())
+	})
+
+	t.Run("Fail to unmarshal request body", func(t *testing.T) {
+		rr := handleRequest(t, &operation.Operation{ConsentService: NewMockConsentService(gomock.NewController(t))},
+			"/v1/dsar/link", http.MethodPost, bytes.NewReader([]byte("")))
+
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("No data to link", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		rr := handleRequest(t, newOperation(ctrl, NewMockProtectService(ctrl)), "/v1/dsar/link", http.MethodPost,
+			bytes.NewReader([]byte(`{"data":[]}`)))
+
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "no data to link")
+	})
+
+	t.Run("Protected data not found", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		protectService := NewMockProtectService(ctrl)
+		protectService.EXPECT().Get(gomock.Any(), targetDID).Return(nil, storage.ErrDataNotFound)
+
+		rr := handleRequest(t, newOperation(ctrl, protectService), "/v1/dsar/link", http.MethodPost,
+			bytes.NewReader(body))
+
+		require.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	for _, tc := range []struct {
+		name   string
+		err    error
+		status int
+	}{
+		{name: "Target does not match", err: protect.ErrTargetMismatch, status: http.StatusBadRequest},
+		{name: "Linked to another subject", err: protect.ErrAlreadyLinked, status: http.StatusConflict},
+		{name: "Fail to link data", err: errors.New("batch error"), status: http.StatusInternalServerError},
+	} {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+
+			protectService := NewMockProtectService(ctrl)
+			protectService.EXPECT().Get(gomock.Any(), targetDID).Return(data, nil)
+			protectService.EXPECT().Link(gomock.Any(), subjectDID, gomock.Any(), gomock.Any()).Return(nil, tc.err)
+
+			rr := handleRequest(t, newOperation(ctrl, protectService), "/v1/dsar/link", http.MethodPost,
+				bytes.NewReader(body))
+
+			require.Equal(t, tc.status, rr.Code)
+			require.Contains(t, rr.Body.String(), tc.err.Error())
+		})
+	}
+
+	t.Run("Fail to read linked data", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		protectService := NewMockProtectService(ctrl)
+		protectService.EXPECT().Get(gomock.Any(), targetDID).Return(data, nil)
+		protectService.EXPECT().Link(gomock.Any(), subjectDID, gomock.Any(), gomock.Any()).Return(nil, nil)
+		protectService.EXPECT().IterateSubject(gomock.Any(), subjectDID, gomock.Any(), gomock.Any()).
+			Return(errors.New("query error"))
+
+		rr := handleRequest(t, newOperation(ctrl, protectService), "/v1/dsar/link", http.MethodPost,
+			bytes.NewReader(body))
+
+		require.Equal(t, http.StatusInternalServerError, rr.Code)
+		require.Contains(t, rr.Body.String(), "read linked data: query error")
+	})
+}
+
+func TestTraceHandler(t *testing.T) {
+	body, err := json.Marshal(&operation.TraceRequest{Data: "jane+mark@example.com"})
+	require.NoError(t, err)
+
+	t.Run("Success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		watermarker := NewMockWatermarker(ctrl)
+		watermarker.EXPECT().Trace(gomock.Any(), "jane+mark@example.com").Return(&watermark.Record{
+			Mark:     "mark",
+			DID:      targetDID,
+			TicketID: testTicketID,
+			Handler:  "did:example:handler",
+		}, nil)
+
+		rr := handleRequest(t, &operation.Operation{Watermarker: watermarker}, "/v1/trace", http.MethodPost,
+			bytes.NewReader(body))
+
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var resp operation.TraceResponse
+
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		require.Equal(t, testTicketID, resp.Extraction.TicketID)
+		require.Equal(t, "did:example:handler", resp.Extraction.Handler)
+	})
+
+	t.Run("Not found", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		watermarker := NewMockWatermarker(ctrl)
+		watermarker.EXPECT().Trace(gomock.Any(), gomock.Any()).Return(nil, watermark.ErrNoWatermark)
+		watermarker.EXPECT().Trace(gomock.Any(), gomock.Any()).
+			Return(nil, fmt.Errorf("get record: %w", storage.ErrDataNotFound))
+
+		for i := 0; i < 2; i++ {
+			rr := handleRequest(t, &operation.Operation{Watermarker: watermarker}, "/v1/trace", http.MethodPost,
+				bytes.NewReader(body))
+
+			require.Equal(t, http.StatusNotFound, rr.Code)
+		}
+	})
+
+	t.Run("Fail to trace data", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		watermarker := NewMockWatermarker(ctrl)
+		watermarker.EXPECT().Trace(gomock.Any(), gomock.Any()).Return(nil, errors.New("get error"))
+
+		rr := handleRequest(t, &operation.Operation{Watermarker: watermarker}, "/v1/trace", http.MethodPost,
+			bytes.NewReader(body))
+
+		require.Equal(t, http.StatusInternalServerError, rr.Code)
+		require.Contains(t, rr.Body.String(), "trace data: get error")
+	})
+
+	t.Run("Fail to unmarshal request body", func(t *testing.T) {
+		rr := handleRequest(t, &operation.Operation{Watermarker: NewMockWatermarker(gomock.NewController(t))},
+			"/v1/trace", http.MethodPost, bytes.NewBufferString("invalid json"))
+
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("Not served without watermarker", func(t *testing.T) {
+		rr := handleRequest(t, &operation.Operation{}, "/v1/trace", http.MethodPost, bytes.NewReader(body))
+
+		require.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
+
+func TestAuditHandlers(t *testing.T) {
+	entries := []*audit.Entry{
+		{Seq: 3, TicketID: testTicketID, DID: targetDID, Event: &ticket.Event{Status: ticket.ReadyToCollect}},
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		auditLog := NewMockAuditLog(ctrl)
+		auditLog.EXPECT().Head(gomock.Any()).Return(&audit.Head{Seq: 3, Hash: "hash"}, nil)
+		auditLog.EXPECT().Entries(gomock.Any(), 2, 10).Return(entries, nil)
+		auditLog.EXPECT().Checkpoints(gomock.Any()).Return([]json.RawMessage{json.RawMessage(`{"id":"vc"}`)}, nil)
+		auditLog.EXPECT().Verify(gomock.Any()).Return(&audit.Report{Verified: true, Entries: 3}, nil)
+
+		op := &operation.Operation{AuditLog: auditLog}
+
+		rr := handleRequest(t, op, "/v1/audit/entries?limit=10&cursor="+model.EncodeCursor(2), http.MethodGet, nil)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var entriesResp operation.AuditEntriesResponse
+
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &entriesResp))
+		require.Equal(t, entries, entriesResp.Items)
+		require.Equal(t, &audit.Head{Seq: 3, Hash: "hash"}, entriesResp.Head)
+		require.Equal(t, 3, *entriesResp.Total)
+		require.Empty(t, entriesResp.NextCursor)
+
+		rr = handleRequest(t, op, "/v1/audit/checkpoints", http.MethodGet, nil)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var checkpointsResp operation.CheckpointsResponse
+
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &checkpointsResp))
+		require.Len(t, checkpointsResp.Checkpoints, 1)
+
+		rr = handleRequest(t, op, "/v1/audit/verify", http.MethodGet, nil)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var report audit.Report
+
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &report))
+		require.True(t, report.Verified)
+		require.Equal(t, 3, report.Entries)
+	})
+
+	t.Run("Not served without audit log", func(t *testing.T) {
+		rr := handleRequest(t, &operation.Operation{}, "/v1/audit/verify", http.MethodGet, nil)
+
+		require.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("Invalid paging", func(t *testing.T) {
+		op := &operation.Operation{AuditLog: NewMockAuditLog(gomock.NewController(t))}
+
+		rr := handleRequest(t, op, "/v1/audit/entries?cursor=-1", http.MethodGet, nil)
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "invalid cursor")
+
+		rr = handleRequest(t, op, "/v1/audit/entries?limit=many", http.MethodGet, nil)
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "invalid limit")
+	})
+
+	t.Run("Fail to read audit log", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		auditLog := NewMockAuditLog(ctrl)
+		auditLog.EXPECT().Head(gomock.Any()).Return(nil, errors.New("head error"))
+		auditLog.EXPECT().Head(gomock.Any()).Return(&audit.Head{}, nil)
+		auditLog.EXPECT().Entries(gomock.Any(), 0, audit.MaxEntries).Return(nil, errors.New("entries error"))
+		auditLog.EXPECT().Checkpoints(gomock.Any()).Return(nil, errors.New("checkpoints error"))
+		auditLog.EXPECT().Verify(gomock.Any()).Return(nil, errors.New("verify error"))
+
+		op := &operation.Operation{AuditLog: auditLog}
+
+		for path, msg := range map[string][]string{
+			"/v1/audit/entries":     {"head error", "entries error"},
+			"/v1/audit/checkpoints": {"checkpoints error"},
+			"/v1/audit/verify":      {"verify error"},
+		} {
+			for _, m := range msg {
+				rr := handleRequest(t, op, path, http.MethodGet, nil)
+
+				require.Equal(t, http.StatusInternalServerError, rr.Code)
+				require.Contains(t, rr.Body.String(), m)
+			}
+		}
+	})
+}
+
+func TestGraphQLHandler(t *testing.T) {
+	t.Run("Query policy with protected resources", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		policyService := NewMockPolicyService(ctrl)
+		policyService.EXPECT().Get(gomock.Any(), testPolicyID).Return(&policy.Policy{
+			ID:           testPolicyID,
+			Collectors:   []string{"did:example:collector"},
+			MinApprovers: 1,
+		}, nil)
+
+		protectService := NewMockProtectService(ctrl)
+		protectService.EXPECT().Iterate(gomock.Any(), testPolicyID, gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, policyID string, _ int, fn func(data *protect.ProtectedData) error) error {
+				for _, did := range []string{targetDID, "did:example:other"} {
+					if err := fn(&protect.ProtectedData{DID: did, PolicyID: policyID}); errors.Is(err, cursor.ErrStop) {
+						break
+					}
+				}
+
+				return nil
+			})
+
+		op := &operation.Operation{
+			PolicyService:  policyService,
+			ProtectService: protectService,
+		}
+
+		rr := handleRequest(t, op, "/v1/graphql", http.MethodPost, bytes.NewBufferString(
+			`{"query":"query($id:String!){policy(id:$id){id collectors minApprovers protectedResources(first:1){did}}}",`+
+				`"variables":{"id":"`+testPolicyID+`"}}`))
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.JSONEq(t, `{"data":{"policy":{"id":"test-policy","collectors":["did:example:collector"],`+
+			`"minApprovers":1,"protectedResources":[{"did":"did:example:target"}]}}}`, rr.Body.String())
+	})
+
+	t.Run("Query tickets with protected resources", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		releaseService := NewMockReleaseService(ctrl)
+		releaseService.EXPECT().Iterate(gomock.Any(), ticket.ReadyToCollect, gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, status ticket.Status, _ int, fn func(t *ticket.Ticket) error) error {
+				return fn(&ticket.Ticket{ID: testTicketID, DID: targetDID, Status: status})
+			})
+
+		protectService := NewMockProtectService(ctrl)
+		protectService.EXPECT().Get(gomock.Any(), targetDID).Return(
+			&protect.ProtectedData{DID: targetDID, PolicyID: testPolicyID}, nil)
+
+		op := &operation.Operation{
+			ReleaseService: releaseService,
+			ProtectService: protectService,
+		}
+
+		rr := handleRequest(t, op, "/v1/graphql?query="+
+			"%7Btickets(status%3A%22READY_TO_COLLECT%22)%7Bid%20status%20protectedResource%7BpolicyId%7D%7D%7D",
+			http.MethodGet, nil)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.JSONEq(t, `{"data":{"tickets":[{"id":"test-ticket","status":"READY_TO_COLLECT",`+
+			`"protectedResource":{"policyId":"test-policy"}}]}}`, rr.Body.String())
+	})
+
+	t.Run("Query rejected ticket with comments", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		releaseService := NewMockReleaseService(ctrl)
+		releaseService.EXPECT().Get(gomock.Any(), testTicketID).Return(&ticket.Ticket{
+			ID:         testTicketID,
+			Status:     ticket.Rejected,
+			RejectedBy: subjectDID,
+			Comments: []*ticket.Comment{{
+				Approver:  subjectDID,
+				Decision:  ticket.Reject,
+				Comment:   "no legal basis",
+				Timestamp: time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC),
+			}},
+		}, nil)
+
+		op := &operation.Operation{
+			ReleaseService: releaseService,
+		}
+
+		rr := handleRequest(t, op, "/v1/graphql", http.MethodPost, bytes.NewBufferString(
+			`{"query":"{ticket(id:\"test-ticket\"){status rejectedBy comments{approver decision comment timestamp}}}"}`))
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.JSONEq(t, `{"data":{"ticket":{"status":"REJECTED","rejectedBy":"`+subjectDID+`",`+
+			`"comments":[{"approver":"`+subjectDID+`","decision":"reject","comment":"no legal basis",`+
+			`"timestamp":"2022-01-02T03:04:05Z"}]}}}`, rr.Body.String())
+	})
+
+	t.Run("Not found", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		releaseService := NewMockReleaseService(ctrl)
+		releaseService.EXPECT().Get(gomock.Any(), testTicketID).Return(nil, storage.ErrDataNotFound)
+
+		op := &operation.Operation{
+			ReleaseService: releaseService,
+		}
+
+		rr := handleRequest(t, op, "/v1/graphql", http.MethodPost,
+			bytes.NewBufferString(`{"query":"{ticket(id:\"test-ticket\"){id}}"}`))
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.JSONEq(t, `{"data":{"ticket":null}}`, rr.Body.String())
+	})
+
+	t.Run("Fail to list policies", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		policyService := NewMockPolicyService(ctrl)
+		policyService.EXPECT().Iterate(gomock.Any(), gomock.Any(), gomock.Any()).Return(errors.New("query error"))
+
+		op := &operation.Operation{
+			PolicyService: policyService,
+		}
+
+		rr := handleRequest(t, op, "/v1/graphql", http.MethodPost,
+			bytes.NewBufferString(`{"query":"{policies{id}}"}`))
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.JSONEq(t, `{"data":{"policies":null},`+
+			`"errors":[{"message":"list policies: query error","path":["policies"]}]}`, rr.Body.String())
+	})
+
+	t.Run("Invalid ticket status", func(t *testing.T) {
+		op := &operation.Operation{}
+
+		rr := handleRequest(t, op, "/v1/graphql", http.MethodPost,
+			bytes.NewBufferString(`{"query":"{tickets(status:\"DONE\"){id}}"}`))
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.Contains(t, rr.Body.String(), `invalid ticket status \"DONE\"`)
+	})
+
+	t.Run("Query audit events with tickets", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		auditLog := NewMockAuditLog(ctrl)
+		auditLog.EXPECT().Entries(gomock.Any(), 1, 2).Return([]*audit.Entry{
+			{Seq: 2, TicketID: testTicketID, DID: targetDID, Hash: "hash", Event: &ticket.Event{
+				Action:    ticket.ApproveAction,
+				Status:    ticket.ReadyToCollect,
+				Actor:     "did:example:approver",
+				Timestamp: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+			}},
+		}, nil)
+
+		releaseService := NewMockReleaseService(ctrl)
+		releaseService.EXPECT().Get(gomock.Any(), testTicketID).Return(&ticket.Ticket{ID: testTicketID}, nil)
+
+		op := &operation.Operation{AuditLog: auditLog, ReleaseService: releaseService}
+
+		rr := handleRequest(t, op, "/v1/graphql", http.MethodPost, bytes.NewBufferString(
+			`{"query":"{auditEvents(after:1,first:2){seq did action status actor timestamp hash ticket{id}}}"}`))
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.JSONEq(t, `{"data":{"auditEvents":[{"seq":2,"did":"did:example:target","action":"approve",`+
+			`"status":"READY_TO_COLLECT","actor":"did:example:approver","timestamp":"2026-01-02T03:04:05Z",`+
+			`"hash":"hash","ticket":{"id":"test-ticket"}}]}}`, rr.Body.String())
+	})
+
+	t.Run("Fail to list audit events", func(t *testing.T) {
+		auditLog := NewMockAuditLog(gomock.NewController(t))
+		auditLog.EXPECT().Entries(gomock.Any(), 0, 0).Return(nil, errors.New("read error"))
+
+		rr := handleRequest(t, &operation.Operation{AuditLog: auditLog}, "/v1/graphql", http.MethodPost,
+			bytes.NewBufferString(`{"query":"{auditEvents{seq}}"}`))
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.Contains(t, rr.Body.String(), "list audit events: read error")
+	})
+
+	t.Run("Audit events without the audit log", func(t *testing.T) {
+		rr := handleRequest(t, &operation.Operation{}, "/v1/graphql", http.MethodPost,
+			bytes.NewBufferString(`{"query":"{auditEvents{seq}}"}`))
+
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), `cannot query field \"auditEvents\" on type Query`)
+	})
+
+	t.Run("Invalid query", func(t *testing.T) {
+		op := &operation.Operation{}
+
+		rr := handleRequest(t, op, "/v1/graphql", http.MethodPost,
+			bytes.NewBufferString(`{"query":"{policies{id policy{id}}}"}`))
+
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), `cannot query field \"policy\" on type Policy`)
+	})
+}
+
+func handleRequest(t *testing.T, op *operation.Operation, path, method string, body io.Reader,
+) *httptest.ResponseRecorder {
+	t.Helper()
+
+	return handleRequestWithHeader(t, op, path, method, body, nil)
+}
+
+func handleRequestWithHeader(t *testing.T, op *operation.Operation, path, method string, body io.Reader,
+	header http.Header) *httptest.ResponseRecorder {
+	t.Helper()
+
+	router := mux.NewRouter()
+	router.Use(requestid.New())
+
+	for _, h := range op.GetRESTHandlers() {
+		router.HandleFunc(h.Path(), h.Handle()).Methods(h.Method())
+	}
+
+	req, err := http.NewRequestWithContext(context.Background(), method, path, body)
+	require.NoError(t, err)
+
+	for k, v := range header {
+		req.Header[k] = v
+	}
 
 	rr := httptest.NewRecorder()
 
@@ -976,3 +6290,11 @@ func handleRequest(t *testing.T, op *operation.Operation, path, method string, b
 
 	return rr
 }
+
+type testLeader struct {
+	held bool
+}
+
+func (l *testLeader) Held() bool {
+	return l.held
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/hyperledger/aries-framework-go/pkg/common/log"
 
 	"github.com/trustbloc/ace/pkg/restapi/handler"
+	"github.com/trustbloc/ace/pkg/version"
 )
 
 var logger = log.New("healthcheck")
@@ -20,6 +21,7 @@ var logger = log.New("healthcheck")
 // API endpoints.
 const (
 	healthCheckEndpoint = "/healthcheck"
+	versionEndpoint     = "/version"
 )
 
 type healthCheckResp struct {
@@ -39,6 +41,7 @@ type Operation struct{}
 func (o *Operation) GetRESTHandlers() []handler.Handler {
 	return []handler.Handler{
 		handler.NewHTTPHandler(healthCheckEndpoint, http.MethodGet, o.healthCheckHandler),
+		handler.NewHTTPHandler(versionEndpoint, http.MethodGet, o.versionHandler),
 	}
 }
 
@@ -53,3 +56,14 @@ func (o *Operation) healthCheckHandler(rw http.ResponseWriter, r *http.Request)
 		logger.Errorf("healthcheck response failure, %s", err)
 	}
 }
+
+// versionHandler returns the version, the git commit, the build date and the feature flags of the build, so that
+// operators and tests can check which build is deployed.
+func (o *Operation) versionHandler(rw http.ResponseWriter, r *http.Request) {
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(rw).Encode(version.Get()); err != nil {
+		logger.Errorf("version response failure, %s", err)
+	}
+}
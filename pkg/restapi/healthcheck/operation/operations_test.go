@@ -6,6 +6,7 @@ SPDX-License-Identifier: Apache-2.0
 package operation_test
 
 import (
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -14,11 +15,12 @@ import (
 
 	"github.com/trustbloc/ace/pkg/restapi/handler"
 	"github.com/trustbloc/ace/pkg/restapi/healthcheck/operation"
+	"github.com/trustbloc/ace/pkg/version"
 )
 
 func TestGetRESTHandlers(t *testing.T) {
 	c := operation.New()
-	require.Equal(t, 1, len(c.GetRESTHandlers()))
+	require.Equal(t, 2, len(c.GetRESTHandlers()))
 }
 
 func TestHealthCheck(t *testing.T) {
@@ -39,3 +41,28 @@ func TestHealthCheck(t *testing.T) {
 
 	require.Equal(t, http.StatusOK, b.Code)
 }
+
+func TestVersion(t *testing.T) {
+	c := operation.New()
+
+	var hndl handler.Handler
+
+	for _, h := range c.GetRESTHandlers() {
+		if h.Path() == "/version" {
+			hndl = h
+		}
+	}
+
+	require.NotNil(t, hndl)
+
+	rr := httptest.NewRecorder()
+
+	hndl.Handle()(rr, nil)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var info version.Info
+
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &info))
+	require.Equal(t, version.Version, info.Version)
+}
@@ -0,0 +1,146 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	// DefaultMaxJSONSize is the default maximum size, in bytes, of the decoded payloads.
+	DefaultMaxJSONSize = 4 << 20
+	// DefaultMaxJSONDepth is the default maximum nesting of the objects and arrays of the decoded payloads.
+	DefaultMaxJSONDepth = 32
+	// DefaultMaxJSONStringLength is the default maximum length, in bytes, of the strings of the decoded payloads.
+	DefaultMaxJSONStringLength = 1 << 20
+)
+
+// ErrPayloadTooLarge is returned when a payload exceeds the limits it is decoded with.
+var ErrPayloadTooLarge = errors.New("payload too large")
+
+// Limits bound the payloads decoded with NewDecoder. Limits that are not positive default to DefaultMaxJSONSize,
+// DefaultMaxJSONDepth and DefaultMaxJSONStringLength.
+type Limits struct {
+	// MaxSize is the maximum size of the payload in bytes.
+	MaxSize int64
+	// MaxDepth is the maximum nesting of the objects and arrays of the payload.
+	MaxDepth int
+	// MaxStringLength is the maximum length of the strings of the payload in bytes, escape sequences included.
+	MaxStringLength int
+}
+
+// NewDecoder returns a decoder of the JSON payloads read from r, failing with ErrPayloadTooLarge as soon as the
+// payload read exceeds the limits. The payload is checked as it is read, without buffering it whole.
+func NewDecoder(r io.Reader, limits Limits) *json.Decoder {
+	if limits.MaxSize <= 0 {
+		limits.MaxSize = DefaultMaxJSONSize
+	}
+
+	if limits.MaxDepth <= 0 {
+		limits.MaxDepth = DefaultMaxJSONDepth
+	}
+
+	if limits.MaxStringLength <= 0 {
+		limits.MaxStringLength = DefaultMaxJSONStringLength
+	}
+
+	return json.NewDecoder(&limitedReader{r: r, limits: limits})
+}
+
+// DecodeStrict decodes the JSON payload into v, rejecting the payloads with fields v does not have, with data after
+// the JSON value, or with objects and arrays nested deeper than maxDepth, or DefaultMaxJSONDepth if it is not
+// positive. Fields of values with their own UnmarshalJSON method are not checked.
+func DecodeStrict(r io.Reader, v interface{}, maxDepth int) error {
+	return DecodeStrictLimited(r, v, Limits{MaxDepth: maxDepth})
+}
+
+// DecodeStrictLimited decodes the JSON payload into v like DecodeStrict, within the limits.
+func DecodeStrictLimited(r io.Reader, v interface{}, limits Limits) error {
+	dec := NewDecoder(r, limits)
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("decode payload: %w", err)
+	}
+
+	if dec.More() {
+		return errors.New("decode payload: unexpected data after the JSON value")
+	}
+
+	return nil
+}
+
+// limitedReader reads a JSON payload, failing once it exceeds the limits. The payload is not validated, which is left
+// to its decoding.
+type limitedReader struct {
+	r      io.Reader
+	limits Limits
+	err    error
+
+	size     int64
+	depth    int
+	strLen   int
+	inString bool
+	escaped  bool
+}
+
+// Read reads the payload up to the byte exceeding the limits, the following reads fail.
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.err != nil {
+		return 0, l.err
+	}
+
+	n, err := l.r.Read(p)
+
+	for i, c := range p[:n] {
+		if l.err = l.check(c); l.err != nil {
+			return i, l.err
+		}
+	}
+
+	return n, err
+}
+
+func (l *limitedReader) check(c byte) error {
+	l.size++
+
+	if l.size > l.limits.MaxSize {
+		return fmt.Errorf("%w: larger than %d bytes", ErrPayloadTooLarge, l.limits.MaxSize)
+	}
+
+	switch {
+	case l.escaped:
+		l.escaped = false
+	case l.inString:
+		l.escaped = c == '\\'
+		l.inString = c != '"'
+	case c == '"':
+		l.inString = true
+		l.strLen = -1
+	case c == '{' || c == '[':
+		l.depth++
+
+		if l.depth > l.limits.MaxDepth {
+			return fmt.Errorf("%w: nested deeper than %d levels", ErrPayloadTooLarge, l.limits.MaxDepth)
+		}
+	case c == '}' || c == ']':
+		l.depth--
+	}
+
+	if l.inString {
+		l.strLen++
+
+		if l.strLen > l.limits.MaxStringLength {
+			return fmt.Errorf("%w: string longer than %d bytes", ErrPayloadTooLarge, l.limits.MaxStringLength)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,108 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package model_test
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/ace/pkg/restapi/model"
+)
+
+type payload struct {
+	Approvers []string               `json:"approvers"`
+	Options   map[string]interface{} `json:"options"`
+}
+
+func TestDecodeStrict(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		var p payload
+
+		err := model.DecodeStrict(strings.NewReader(`{"approvers":["did:example:1","[{\"}"],"options":{"a":[1]}} `),
+			&p, 3)
+		require.NoError(t, err)
+		require.Equal(t, []string{"did:example:1", `[{"}`}, p.Approvers)
+	})
+
+	t.Run("Invalid payloads", func(t *testing.T) {
+		for s, msg := range map[string]string{
+			`{"approverss":[]}`:              `unknown field "approverss"`,
+			`{"approvers":[]}{}`:             "unexpected data after the JSON value",
+			`{"options":{"a":[[1]]}}`:        "nested deeper than 3 levels",
+			`{"approvers":[]`:                "decode payload",
+			`{"approvers":["\\"],"x":[[[]]]`: "nested deeper than 3 levels",
+		} {
+			var p payload
+
+			err := model.DecodeStrict(strings.NewReader(s), &p, 3)
+			require.Error(t, err, s)
+			require.Contains(t, err.Error(), msg, s)
+		}
+	})
+
+	t.Run("Default depth", func(t *testing.T) {
+		var v interface{}
+
+		err := model.DecodeStrict(strings.NewReader(strings.Repeat("[", 33)+strings.Repeat("]", 33)), &v, 0)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "nested deeper than 32 levels")
+	})
+}
+
+func TestNewDecoder(t *testing.T) {
+	limits := model.Limits{MaxSize: 64, MaxDepth: 2, MaxStringLength: 10}
+
+	t.Run("Success", func(t *testing.T) {
+		var p payload
+
+		err := model.NewDecoder(strings.NewReader(`{"approvers":["1234567890","\\\"34567"],"options":{"a":1}}`),
+			limits).Decode(&p)
+		require.NoError(t, err)
+		require.Equal(t, []string{"1234567890", `\"34567`}, p.Approvers)
+	})
+
+	t.Run("Payloads exceeding the limits", func(t *testing.T) {
+		for s, msg := range map[string]string{
+			`{"approvers":["12345678901"]}`:                "string longer than 10 bytes",
+			`{"options":{"a":[1]}}`:                        "nested deeper than 2 levels",
+			`{"approvers":["` + strings.Repeat(`1","`, 16): "larger than 64 bytes",
+		} {
+			var p payload
+
+			err := model.NewDecoder(strings.NewReader(s), limits).Decode(&p)
+			require.Error(t, err, s)
+			require.True(t, errors.Is(err, model.ErrPayloadTooLarge), s)
+			require.Contains(t, err.Error(), msg, s)
+		}
+	})
+
+	t.Run("Reads stop at the limit", func(t *testing.T) {
+		r := &countingReader{r: strings.NewReader(`"` + strings.Repeat("1", 1<<20))}
+
+		var s string
+
+		err := model.NewDecoder(io.LimitReader(r, 1<<20), model.Limits{MaxStringLength: 16}).Decode(&s)
+		require.True(t, errors.Is(err, model.ErrPayloadTooLarge))
+		require.Less(t, r.n, 1<<16)
+	})
+}
+
+type countingReader struct {
+	r io.Reader
+	n int
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += n
+
+	return n, err
+}
@@ -16,4 +16,6 @@ type UNIRegistrar struct {
 type ErrorResponse struct {
 	// error message
 	Message string `json:"errMessage,omitempty"`
+	// ID of the request, to be quoted when reporting the error
+	RequestID string `json:"requestID,omitempty"`
 }
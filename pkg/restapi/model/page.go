@@ -0,0 +1,132 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package model
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+const (
+	// CursorParam is the query parameter of the cursor of the page requested.
+	CursorParam = "cursor"
+	// LimitParam is the query parameter of the maximum number of items of the page requested.
+	LimitParam = "limit"
+
+	cursorPrefix = "o:"
+)
+
+// ErrInvalidCursor is returned for a cursor that was not returned by a listing endpoint.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Page is the envelope of a page of the items of a listing endpoint, embedded in its response next to the items of
+// the page, under "items". The next page is requested with its cursor, until a page has none.
+type Page struct {
+	// NextCursor is the cursor of the next page, empty on the last page.
+	NextCursor string `json:"next_cursor,omitempty"`
+	// Total is the number of items of all pages, set only where they are cheap to count.
+	Total *int `json:"total,omitempty"`
+}
+
+// PageRequest is a request for a page of the items of a listing endpoint.
+type PageRequest struct {
+	// Cursor is the cursor of the page, empty for the first page.
+	Cursor string
+	// Limit is the maximum number of items of the page, 0 for the default of the endpoint.
+	Limit int
+}
+
+// ParsePageRequest parses the cursor and limit query parameters of a page request.
+func ParsePageRequest(v url.Values) (*PageRequest, error) {
+	r := &PageRequest{Cursor: v.Get(CursorParam)}
+
+	if s := v.Get(LimitParam); s != "" {
+		limit, err := strconv.Atoi(s)
+		if err != nil || limit < 0 {
+			return nil, fmt.Errorf("invalid %s %q", LimitParam, s)
+		}
+
+		r.Limit = limit
+	}
+
+	if _, err := r.Offset(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// SetValues sets the query parameters of the page request.
+func (r *PageRequest) SetValues(v url.Values) {
+	if r.Cursor != "" {
+		v.Set(CursorParam, r.Cursor)
+	}
+
+	if r.Limit > 0 {
+		v.Set(LimitParam, strconv.Itoa(r.Limit))
+	}
+}
+
+// Offset returns the number of items before the page requested.
+func (r *PageRequest) Offset() (int, error) {
+	if r.Cursor == "" {
+		return 0, nil
+	}
+
+	b, err := base64.RawURLEncoding.DecodeString(r.Cursor)
+	if err != nil || len(b) <= len(cursorPrefix) || string(b[:len(cursorPrefix)]) != cursorPrefix {
+		return 0, ErrInvalidCursor
+	}
+
+	offset, err := strconv.Atoi(string(b[len(cursorPrefix):]))
+	if err != nil || offset < 0 {
+		return 0, ErrInvalidCursor
+	}
+
+	return offset, nil
+}
+
+// PageLimit returns the limit of the page request, defaulting to defaultLimit and capped to maxLimit.
+func (r *PageRequest) PageLimit(defaultLimit, maxLimit int) int {
+	switch {
+	case r.Limit == 0:
+		return defaultLimit
+	case r.Limit > maxLimit:
+		return maxLimit
+	default:
+		return r.Limit
+	}
+}
+
+// NewPage returns the envelope of the page requested with the offset and the limit, among n items listed, with the
+// cursor of the next page if items remain after it. Endpoints that do not count their items may read one more item
+// than the limit, and pass the offset plus the number of items read.
+func NewPage(offset, limit, n int) Page {
+	var p Page
+
+	if offset+limit < n {
+		p.NextCursor = EncodeCursor(offset + limit)
+	}
+
+	return p
+}
+
+// WithTotal returns the page with the number of the items of all pages, for the endpoints where they are cheap to
+// count.
+func (p Page) WithTotal(total int) Page {
+	p.Total = &total
+
+	return p
+}
+
+// EncodeCursor returns the opaque cursor of the page starting after offset items.
+func EncodeCursor(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(cursorPrefix + strconv.Itoa(offset)))
+}
@@ -0,0 +1,104 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package model_test
+
+import (
+	"encoding/json"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/ace/pkg/restapi/model"
+)
+
+func TestPageRequest(t *testing.T) {
+	t.Run("Pages are listed with their cursors", func(t *testing.T) {
+		items := []string{"a", "b", "c", "d", "e"}
+
+		var (
+			listed []string
+			req    = &model.PageRequest{Limit: 2}
+		)
+
+		for {
+			v := url.Values{}
+			req.SetValues(v)
+
+			r, err := model.ParsePageRequest(v)
+			require.NoError(t, err)
+
+			offset, err := r.Offset()
+			require.NoError(t, err)
+
+			limit := r.PageLimit(10, 100)
+			end := offset + limit
+
+			if end > len(items) {
+				end = len(items)
+			}
+
+			p := model.NewPage(offset, limit, len(items))
+			listed = append(listed, items[offset:end]...)
+
+			if p.NextCursor == "" {
+				break
+			}
+
+			req.Cursor = p.NextCursor
+		}
+
+		require.Equal(t, items, listed)
+	})
+
+	t.Run("Limit defaults and is capped", func(t *testing.T) {
+		require.Equal(t, 10, (&model.PageRequest{}).PageLimit(10, 100))
+		require.Equal(t, 100, (&model.PageRequest{Limit: 1000}).PageLimit(10, 100))
+		require.Equal(t, 5, (&model.PageRequest{Limit: 5}).PageLimit(10, 100))
+	})
+
+	t.Run("Invalid requests", func(t *testing.T) {
+		for _, v := range []url.Values{
+			{model.LimitParam: {"ten"}},
+			{model.LimitParam: {"-1"}},
+			{model.CursorParam: {"???"}},
+			{model.CursorParam: {"MTA"}},
+			{model.CursorParam: {model.EncodeCursor(-1)}},
+		} {
+			_, err := model.ParsePageRequest(v)
+			require.Error(t, err, v)
+		}
+
+		_, err := (&model.PageRequest{Cursor: "MTA"}).Offset()
+		require.ErrorIs(t, err, model.ErrInvalidCursor)
+	})
+}
+
+type intPage struct {
+	Items []int `json:"items"`
+	model.Page
+}
+
+func TestPage(t *testing.T) {
+	t.Run("Last page has no cursor", func(t *testing.T) {
+		b, err := json.Marshal(&intPage{Items: []int{3}, Page: model.NewPage(2, 2, 3)})
+		require.NoError(t, err)
+		require.JSONEq(t, `{"items":[3]}`, string(b))
+	})
+
+	t.Run("Page with total", func(t *testing.T) {
+		b, err := json.Marshal(&intPage{Items: []int{1, 2}, Page: model.NewPage(0, 2, 3).WithTotal(3)})
+		require.NoError(t, err)
+		require.JSONEq(t, `{"items":[1,2],"next_cursor":"`+model.EncodeCursor(2)+`","total":3}`, string(b))
+
+		var p intPage
+
+		require.NoError(t, json.Unmarshal(b, &p))
+		require.Equal(t, model.EncodeCursor(2), p.NextCursor)
+		require.Equal(t, 3, *p.Total)
+	})
+}
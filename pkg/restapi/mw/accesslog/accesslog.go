@@ -0,0 +1,78 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package accesslog logs the requests served, with their method, path, status, duration, size and caller.
+package accesslog
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/hyperledger/aries-framework-go/pkg/common/log"
+
+	"github.com/trustbloc/ace/pkg/restapi/mw/internal/response"
+)
+
+var logger = log.New("access-log")
+
+// anonymous is the caller of the requests that are not authenticated.
+const anonymous = "-"
+
+// Logger logs the requests.
+type Logger interface {
+	Infof(msg string, args ...interface{})
+}
+
+// Config defines the configuration of the middleware.
+type Config struct {
+	// SampleRate is the fraction of the requests logged, from 0 to 1. Server errors are always logged.
+	SampleRate float64
+	// Logger logs the requests. Defaults to the logger of the package.
+	Logger Logger
+}
+
+// New returns middleware logging every request, or a sample of them, once served. The caller is the identity set
+// with SetCaller by the authentication middleware of the request, if any.
+func New(config *Config) mux.MiddlewareFunc {
+	l := config.Logger
+	if l == nil {
+		l = logger
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			start := time.Now()
+			e := &entry{caller: anonymous}
+			rec := response.NewRecorder(w)
+
+			next.ServeHTTP(rec, req.WithContext(context.WithValue(req.Context(), entryKey{}, e)))
+
+			if rec.Status() < http.StatusInternalServerError && rand.Float64() >= config.SampleRate { //nolint:gosec
+				return
+			}
+
+			l.Infof("Access: method=%s path=%s status=%d duration=%s bytes=%d caller=%s",
+				req.Method, req.URL.Path, rec.Status(), time.Since(start), rec.Bytes(), e.caller)
+		})
+	}
+}
+
+// SetCaller sets the identity of the caller of the request of the context, e.g. the DID it is signed by, to be logged
+// by the middleware. It does nothing if the request is not served by the middleware.
+func SetCaller(ctx context.Context, caller string) {
+	if e, ok := ctx.Value(entryKey{}).(*entry); ok {
+		e.caller = caller
+	}
+}
+
+type entryKey struct{}
+
+type entry struct {
+	caller string
+}
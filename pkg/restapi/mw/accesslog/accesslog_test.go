@@ -0,0 +1,94 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package accesslog_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/hyperledger/aries-framework-go/pkg/common/log/mocklogger"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/ace/pkg/restapi/mw/accesslog"
+	"github.com/trustbloc/ace/pkg/restapi/mw/tokenauth"
+)
+
+func TestMiddleware(t *testing.T) {
+	t.Run("Request is logged", func(t *testing.T) {
+		l := &mocklogger.MockLogger{}
+
+		router := mux.NewRouter()
+		router.Use(accesslog.New(&accesslog.Config{SampleRate: 1, Logger: l}))
+		router.Handle("/v1/tickets/{id}", tokenauth.New("test_tkn")(http.HandlerFunc(
+			func(w http.ResponseWriter, req *http.Request) {
+				w.WriteHeader(http.StatusCreated)
+
+				_, err := w.Write([]byte("ticket"))
+				require.NoError(t, err)
+
+				w.(http.Flusher).Flush() //nolint:forcetypeassert,errcheck
+			})))
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/tickets/123?token=abc", nil)
+		req.Header.Set("Authorization", "Bearer test_tkn")
+
+		rw := httptest.NewRecorder()
+		router.ServeHTTP(rw, req)
+
+		require.Equal(t, http.StatusCreated, rw.Code)
+		require.True(t, rw.Flushed)
+		require.Contains(t, l.InfoLogContents, "Access: method=POST path=/v1/tickets/123 status=201 duration=")
+		require.Contains(t, l.InfoLogContents, "bytes=6 caller="+tokenauth.TokenCaller)
+		require.NotContains(t, l.InfoLogContents, "abc")
+	})
+
+	t.Run("Anonymous request", func(t *testing.T) {
+		l := &mocklogger.MockLogger{}
+
+		accesslog.New(&accesslog.Config{SampleRate: 1, Logger: l})(http.HandlerFunc(
+			func(w http.ResponseWriter, req *http.Request) {},
+		)).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/healthcheck", nil))
+
+		require.Contains(t, l.InfoLogContents, "path=/healthcheck status=200")
+		require.Contains(t, l.InfoLogContents, "bytes=0 caller=-")
+	})
+
+	t.Run("Request is not sampled", func(t *testing.T) {
+		l := &mocklogger.MockLogger{}
+
+		h := accesslog.New(&accesslog.Config{Logger: l})(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		}))
+
+		for i := 0; i < 10; i++ {
+			h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		}
+
+		require.Empty(t, l.AllLogContents)
+	})
+
+	t.Run("Server error is always logged", func(t *testing.T) {
+		l := &mocklogger.MockLogger{}
+
+		accesslog.New(&accesslog.Config{Logger: l})(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		})).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+		require.Contains(t, l.InfoLogContents, "status=500")
+	})
+}
+
+func TestSetCaller(t *testing.T) {
+	t.Run("Request not served by the middleware", func(t *testing.T) {
+		require.NotPanics(t, func() {
+			accesslog.SetCaller(context.Background(), "did:example:123")
+		})
+	})
+}
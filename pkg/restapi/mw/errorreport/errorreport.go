@@ -0,0 +1,352 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package errorreport reports the server errors and the panics of HTTP handlers, with their stack traces and the
+// context of the requests, to a Sentry-compatible endpoint. The requests are scrubbed of their credentials and their
+// bodies are never sent.
+package errorreport
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/hyperledger/aries-framework-go/pkg/common/log"
+
+	"github.com/trustbloc/ace/pkg/restapi/mw/internal/response"
+)
+
+var logger = log.New("error-report")
+
+const (
+	sentryVersion = 7
+	sentryClient  = "ace-errorreport/1.0"
+	filtered      = "[Filtered]"
+
+	queueSize   = 100
+	sendTimeout = 10 * time.Second
+	maxFrames   = 64
+)
+
+// sensitiveParts are the parts of the names of headers and query parameters whose values are filtered.
+var sensitiveParts = []string{"auth", "cookie", "signature", "capability", "token", "secret", "password", "key"}
+
+// Reporter reports errors to a Sentry-compatible endpoint. Events are sent in the background, in order, and dropped
+// if too many are waiting to be sent.
+type Reporter struct {
+	storeURL string
+	auth     string
+	client   *http.Client
+	events   chan *event
+	closing  sync.Once
+	done     chan struct{}
+}
+
+// New returns a reporter sending events to the endpoint of the DSN, e.g. https://<key>@sentry.example.com/42, with
+// the HTTP client. Close must be called to send the pending events and stop it.
+func New(dsn string, client *http.Client) (*Reporter, error) {
+	storeURL, key, err := parseDSN(dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Reporter{
+		storeURL: storeURL,
+		auth:     fmt.Sprintf("Sentry sentry_version=%d, sentry_client=%s, sentry_key=%s", sentryVersion, sentryClient, key),
+		client:   client,
+		events:   make(chan *event, queueSize),
+		done:     make(chan struct{}),
+	}
+
+	go r.send()
+
+	return r, nil
+}
+
+// ValidateDSN returns an error if the DSN is not of the form <scheme>://<key>@<host>/<project>.
+func ValidateDSN(dsn string) error {
+	_, _, err := parseDSN(dsn)
+
+	return err
+}
+
+// parseDSN returns the URL of the store endpoint of the DSN and its public key.
+func parseDSN(dsn string) (string, string, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return "", "", fmt.Errorf("parse DSN: %w", err)
+	}
+
+	i := strings.LastIndex(u.Path, "/")
+	if u.Scheme == "" || u.User == nil || u.User.Username() == "" || i < 0 || u.Path[i+1:] == "" {
+		return "", "", errors.New("parse DSN: a DSN must be of the form <scheme>://<key>@<host>/<project>")
+	}
+
+	return fmt.Sprintf("%s://%s%s/api/%s/store/", u.Scheme, u.Host, u.Path[:i], u.Path[i+1:]), u.User.Username(), nil
+}
+
+// Close sends the pending events and stops the reporter.
+func (r *Reporter) Close() {
+	r.closing.Do(func() {
+		close(r.events)
+	})
+
+	<-r.done
+}
+
+// Middleware reports the errors the handlers record with Record when they respond with a server error, and the
+// panics of the handlers, which are answered with 500 Internal Server Error.
+func (r *Reporter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		rec := &recorder{Recorder: response.NewRecorder(w)}
+
+		defer func() {
+			v := recover()
+			if v == nil {
+				if rec.err != nil && rec.Status() >= http.StatusInternalServerError {
+					r.enqueue(newEvent(req, rec.Status(), "error", rec.err, rec.frames))
+				}
+
+				return
+			}
+
+			// the sentinel panic aborting the response is not an error
+			if v == http.ErrAbortHandler {
+				panic(v)
+			}
+
+			r.enqueue(newEvent(req, http.StatusInternalServerError, "fatal", fmt.Errorf("panic: %v", v), callers(3)))
+
+			if !rec.WroteHeader() {
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			}
+		}()
+
+		next.ServeHTTP(rec, req)
+	})
+}
+
+// Record records the error the response is written with, with the stack trace of its caller, to be reported by the
+// middleware if the response is a server error. It does nothing if the response is not served by the middleware.
+// Writers wrapping the response of the middleware are unwrapped with their Unwrap method.
+func Record(w http.ResponseWriter, err error) {
+	for {
+		switch rw := w.(type) {
+		case *recorder:
+			rw.err, rw.frames = err, callers(3)
+
+			return
+		case interface{ Unwrap() http.ResponseWriter }:
+			w = rw.Unwrap()
+		default:
+			return
+		}
+	}
+}
+
+func (r *Reporter) enqueue(e *event) {
+	select {
+	case r.events <- e:
+	default:
+		logger.Warnf("Dropped error report %s: too many reports waiting to be sent", e.EventID)
+	}
+}
+
+func (r *Reporter) send() {
+	defer close(r.done)
+
+	for e := range r.events {
+		if err := r.post(e); err != nil {
+			logger.Warnf("Failed to send error report %s: %s", e.EventID, err)
+		}
+	}
+}
+
+func (r *Reporter) post(e *event) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("marshal event: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.storeURL, bytes.NewReader(b))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Sentry-Auth", r.auth)
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("post event: %w", err)
+	}
+
+	defer resp.Body.Close() // nolint: errcheck
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("post event: status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// recorder records the error the response is written with, in addition to its status.
+type recorder struct {
+	*response.Recorder
+	err    error
+	frames []*frame
+}
+
+// event is a Sentry event.
+type event struct {
+	EventID     string            `json:"event_id"`
+	Timestamp   string            `json:"timestamp"`
+	Level       string            `json:"level"`
+	Platform    string            `json:"platform"`
+	Message     string            `json:"message"`
+	Transaction string            `json:"transaction,omitempty"`
+	Exception   []*exception      `json:"exception"`
+	Request     *request          `json:"request"`
+	Tags        map[string]string `json:"tags"`
+}
+
+type exception struct {
+	Type       string      `json:"type"`
+	Value      string      `json:"value"`
+	Stacktrace *stacktrace `json:"stacktrace,omitempty"`
+}
+
+type stacktrace struct {
+	Frames []*frame `json:"frames"`
+}
+
+type frame struct {
+	Function string `json:"function"`
+	Filename string `json:"filename"`
+	Lineno   int    `json:"lineno"`
+}
+
+type request struct {
+	Method      string            `json:"method"`
+	URL         string            `json:"url"`
+	QueryString string            `json:"query_string,omitempty"`
+	Headers     map[string]string `json:"headers"`
+}
+
+func newEvent(req *http.Request, status int, level string, err error, frames []*frame) *event {
+	e := &event{
+		EventID:   strings.ReplaceAll(uuid.New().String(), "-", ""),
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		Level:     level,
+		Platform:  "go",
+		Message:   err.Error(),
+		Exception: []*exception{{Type: errorType(err), Value: err.Error()}},
+		Request:   scrub(req),
+		Tags:      map[string]string{"status_code": fmt.Sprint(status)},
+	}
+
+	if len(frames) > 0 {
+		e.Exception[0].Stacktrace = &stacktrace{Frames: frames}
+	}
+
+	// the route template does not have the DIDs and the IDs of the request path
+	if route := mux.CurrentRoute(req); route != nil {
+		e.Transaction, _ = route.GetPathTemplate() //nolint:errcheck
+	}
+
+	return e
+}
+
+// errorType returns the type of the error the error wraps, if any, as the wrapping types are not meaningful.
+func errorType(err error) string {
+	for errors.Unwrap(err) != nil {
+		err = errors.Unwrap(err)
+	}
+
+	return fmt.Sprintf("%T", err)
+}
+
+// scrub returns the context of the request, with the values of its sensitive headers and query parameters filtered.
+func scrub(req *http.Request) *request {
+	r := &request{Method: req.Method, URL: req.URL.Path, Headers: map[string]string{}}
+
+	if req.Host != "" {
+		scheme := "http"
+		if req.TLS != nil {
+			scheme = "https"
+		}
+
+		r.URL = scheme + "://" + req.Host + req.URL.Path
+	}
+
+	for name, values := range req.Header {
+		r.Headers[name] = strings.Join(values, ", ")
+
+		if sensitive(name) {
+			r.Headers[name] = filtered
+		}
+	}
+
+	query := req.URL.Query()
+
+	for name := range query {
+		if sensitive(name) {
+			query.Set(name, filtered)
+		}
+	}
+
+	r.QueryString = query.Encode()
+
+	return r
+}
+
+func sensitive(name string) bool {
+	name = strings.ToLower(name)
+
+	for _, part := range sensitiveParts {
+		if strings.Contains(name, part) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// callers returns the stack trace of the caller, skipping the given number of frames, oldest call first as Sentry
+// expects. Frames of the runtime are left out.
+func callers(skip int) []*frame {
+	pcs := make([]uintptr, maxFrames)
+	n := runtime.Callers(skip, pcs)
+
+	var frames []*frame
+
+	it := runtime.CallersFrames(pcs[:n])
+
+	for {
+		f, more := it.Next()
+
+		if !strings.HasPrefix(f.Function, "runtime.") {
+			frames = append([]*frame{{Function: f.Function, Filename: f.File, Lineno: f.Line}}, frames...)
+		}
+
+		if !more {
+			return frames
+		}
+	}
+}
@@ -0,0 +1,261 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package errorreport_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/ace/pkg/restapi/mw/errorreport"
+)
+
+type event struct {
+	Level       string            `json:"level"`
+	Message     string            `json:"message"`
+	Transaction string            `json:"transaction"`
+	Tags        map[string]string `json:"tags"`
+	Exception   []struct {
+		Type       string `json:"type"`
+		Value      string `json:"value"`
+		Stacktrace *struct {
+			Frames []struct {
+				Function string `json:"function"`
+			} `json:"frames"`
+		} `json:"stacktrace"`
+	} `json:"exception"`
+	Request struct {
+		Method      string            `json:"method"`
+		URL         string            `json:"url"`
+		QueryString string            `json:"query_string"`
+		Headers     map[string]string `json:"headers"`
+	} `json:"request"`
+}
+
+type notFoundError struct{}
+
+func (notFoundError) Error() string { return "not found" }
+
+type wrapper struct {
+	http.ResponseWriter
+}
+
+func (w *wrapper) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func TestNew(t *testing.T) {
+	t.Run("Invalid DSN", func(t *testing.T) {
+		for _, dsn := range []string{
+			"https://sentry.example.com/42",
+			"https://key@sentry.example.com/",
+			"key@sentry.example.com/42",
+			"https://key@sentry.example.com/%zz",
+		} {
+			_, err := errorreport.New(dsn, http.DefaultClient)
+			require.Error(t, err, dsn)
+			require.Contains(t, err.Error(), "parse DSN", dsn)
+			require.Equal(t, err, errorreport.ValidateDSN(dsn))
+		}
+	})
+}
+
+func TestMiddleware(t *testing.T) {
+	t.Run("Server error is reported", func(t *testing.T) {
+		events, dsn := sentry(t)
+
+		r, err := errorreport.New(dsn, http.DefaultClient)
+		require.NoError(t, err)
+
+		router := mux.NewRouter()
+		router.Use(r.Middleware)
+		router.HandleFunc("/v1/protect/{id}", func(w http.ResponseWriter, req *http.Request) {
+			err := fmt.Errorf("get data: %w", notFoundError{})
+
+			errorreport.Record(w, err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		})
+
+		req := httptest.NewRequest(http.MethodPost, "https://gatekeeper.example.com/v1/protect/123?token=abc&page=2",
+			strings.NewReader("sensitive data"))
+		req.Header.Set("Authorization", "Bearer abc")
+		req.Header.Set("Signature", `keyId="did:example:123"`)
+		req.Header.Set("Content-Type", "application/json")
+
+		rw := httptest.NewRecorder()
+		router.ServeHTTP(rw, req)
+		r.Close()
+
+		require.Equal(t, http.StatusInternalServerError, rw.Code)
+		require.Len(t, events, 1)
+
+		e := <-events
+		require.Equal(t, "error", e.Level)
+		require.Equal(t, "get data: not found", e.Message)
+		require.Equal(t, "/v1/protect/{id}", e.Transaction)
+		require.Equal(t, "500", e.Tags["status_code"])
+		require.Equal(t, "errorreport_test.notFoundError", e.Exception[0].Type)
+		require.Equal(t, "get data: not found", e.Exception[0].Value)
+
+		frames := e.Exception[0].Stacktrace.Frames
+		require.Contains(t, frames[len(frames)-1].Function, "TestMiddleware")
+
+		require.Equal(t, http.MethodPost, e.Request.Method)
+		require.Equal(t, "https://gatekeeper.example.com/v1/protect/123", e.Request.URL)
+		require.Equal(t, "page=2&token=%5BFiltered%5D", e.Request.QueryString)
+		require.Equal(t, "[Filtered]", e.Request.Headers["Authorization"])
+		require.Equal(t, "[Filtered]", e.Request.Headers["Signature"])
+		require.Equal(t, "application/json", e.Request.Headers["Content-Type"])
+	})
+
+	t.Run("Error recorded through a wrapping writer is reported", func(t *testing.T) {
+		events, dsn := sentry(t)
+
+		r, err := errorreport.New(dsn, http.DefaultClient)
+		require.NoError(t, err)
+
+		r.Middleware(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w = &wrapper{ResponseWriter: w}
+
+			errorreport.Record(w, errors.New("server error"))
+			w.WriteHeader(http.StatusInternalServerError)
+		})).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		r.Close()
+
+		require.Len(t, events, 1)
+		require.Equal(t, "server error", (<-events).Message)
+	})
+
+	t.Run("Client error is not reported", func(t *testing.T) {
+		events, dsn := sentry(t)
+
+		r, err := errorreport.New(dsn, http.DefaultClient)
+		require.NoError(t, err)
+
+		rw := httptest.NewRecorder()
+		r.Middleware(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			errorreport.Record(w, errors.New("invalid request"))
+			w.WriteHeader(http.StatusBadRequest)
+		})).ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/", nil))
+		r.Close()
+
+		require.Equal(t, http.StatusBadRequest, rw.Code)
+		require.Empty(t, events)
+	})
+
+	t.Run("Panic is reported", func(t *testing.T) {
+		events, dsn := sentry(t)
+
+		r, err := errorreport.New(dsn, http.DefaultClient)
+		require.NoError(t, err)
+
+		rw := httptest.NewRecorder()
+		r.Middleware(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			panic("nil map")
+		})).ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/", nil))
+		r.Close()
+
+		require.Equal(t, http.StatusInternalServerError, rw.Code)
+		require.Len(t, events, 1)
+
+		e := <-events
+		require.Equal(t, "fatal", e.Level)
+		require.Equal(t, "panic: nil map", e.Message)
+		require.NotEmpty(t, e.Exception[0].Stacktrace.Frames)
+	})
+
+	t.Run("Aborted response is not reported", func(t *testing.T) {
+		events, dsn := sentry(t)
+
+		r, err := errorreport.New(dsn, http.DefaultClient)
+		require.NoError(t, err)
+
+		h := r.Middleware(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			panic(http.ErrAbortHandler)
+		}))
+
+		require.PanicsWithValue(t, http.ErrAbortHandler, func() {
+			h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		})
+		r.Close()
+
+		require.Empty(t, events)
+	})
+
+	t.Run("Response is flushed", func(t *testing.T) {
+		_, dsn := sentry(t)
+
+		r, err := errorreport.New(dsn, http.DefaultClient)
+		require.NoError(t, err)
+
+		defer r.Close()
+
+		rw := httptest.NewRecorder()
+		r.Middleware(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			_, err := w.Write([]byte("event"))
+			require.NoError(t, err)
+
+			w.(http.Flusher).Flush() //nolint:forcetypeassert,errcheck
+		})).ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		require.True(t, rw.Flushed)
+	})
+
+	t.Run("Endpoint fails", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.WriteHeader(http.StatusTooManyRequests)
+		}))
+		defer srv.Close()
+
+		r, err := errorreport.New(strings.Replace(srv.URL, "://", "://key@", 1)+"/42", http.DefaultClient)
+		require.NoError(t, err)
+
+		rw := httptest.NewRecorder()
+		r.Middleware(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			panic("nil map")
+		})).ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/", nil))
+		r.Close()
+
+		require.Equal(t, http.StatusInternalServerError, rw.Code)
+	})
+}
+
+func TestRecord(t *testing.T) {
+	t.Run("Response not served by the middleware", func(t *testing.T) {
+		require.NotPanics(t, func() {
+			errorreport.Record(httptest.NewRecorder(), errors.New("server error"))
+		})
+	})
+}
+
+// sentry starts a Sentry-compatible endpoint and returns the events it receives and its DSN.
+func sentry(t *testing.T) (chan *event, string) {
+	t.Helper()
+
+	events := make(chan *event, 10)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		require.Equal(t, "/api/42/store/", req.URL.Path)
+		require.Contains(t, req.Header.Get("X-Sentry-Auth"), "sentry_key=public-key")
+
+		e := &event{}
+		require.NoError(t, json.NewDecoder(req.Body).Decode(e))
+
+		events <- e
+	}))
+
+	t.Cleanup(srv.Close)
+
+	return events, strings.Replace(srv.URL, "://", "://public-key@", 1) + "/42"
+}
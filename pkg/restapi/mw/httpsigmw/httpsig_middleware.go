@@ -19,13 +19,17 @@ import (
 	"github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdr"
 
 	"github.com/trustbloc/ace/pkg/httpsig"
+	"github.com/trustbloc/ace/pkg/restapi/mw/accesslog"
 )
 
 const (
 	unauthorizedResponse = "Unauthorized.\n"
 )
 
-var contextKeySubjectDID = contextKey("subject-did") //nolint:gochecknoglobals
+var (
+	contextKeySubjectDID = contextKey("subject-did") //nolint:gochecknoglobals
+	contextKeySignature  = contextKey("signature")   //nolint:gochecknoglobals
+)
 
 var logger = log.New("httpsig-middleware")
 
@@ -52,6 +56,14 @@ func SubjectDID(ctx context.Context) (string, bool) {
 	return subjectDID, ok
 }
 
+// Signature reads from context the signature the subject DID was verified with, as the value of the Signature header
+// of the request.
+func Signature(ctx context.Context) (string, bool) {
+	signature, ok := ctx.Value(contextKeySignature).(string)
+
+	return signature, ok
+}
+
 // New returns httpsig auth middleware.
 func New(cfg *Config) mux.MiddlewareFunc {
 	return func(h http.Handler) http.Handler {
@@ -63,9 +75,7 @@ func New(cfg *Config) mux.MiddlewareFunc {
 }
 
 func (h *mwHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	signVerifier := httpsig.NewVerifier(&pubKeyResolver{
-		vdr: h.vdr,
-	})
+	signVerifier := httpsig.NewVerifier(NewKeyResolver(h.vdr))
 
 	verified, subjectDID := signVerifier.VerifyRequest(r)
 	if !verified {
@@ -78,16 +88,26 @@ func (h *mwHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	accesslog.SetCaller(r.Context(), subjectDID)
+
 	ctx := context.WithValue(r.Context(), contextKeySubjectDID, subjectDID)
+	ctx = context.WithValue(ctx, contextKeySignature, r.Header.Get("Signature"))
 
 	h.next.ServeHTTP(w, r.WithContext(ctx))
 }
 
-type pubKeyResolver struct {
+// KeyResolver resolves the authentication keys of the subject DIDs signing requests.
+type KeyResolver struct {
 	vdr vdrRegistry
 }
 
-func (r *pubKeyResolver) Resolve(keyID string) (*verifier.PublicKey, error) {
+// NewKeyResolver returns a KeyResolver resolving the DIDs with the VDR.
+func NewKeyResolver(vdr vdrRegistry) *KeyResolver {
+	return &KeyResolver{vdr: vdr}
+}
+
+// Resolve returns the authentication key of the DID with the given ID.
+func (r *KeyResolver) Resolve(keyID string) (*verifier.PublicKey, error) {
 	keyIDParts := strings.Split(keyID, "#")
 
 	if len(keyIDParts) != 2 { //nolint:gomnd
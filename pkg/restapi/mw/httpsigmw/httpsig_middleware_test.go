@@ -52,6 +52,10 @@ func TestMiddleware(t *testing.T) {
 		subjectDID, ok := httpsigmw.SubjectDID(handler.requestsCaptured[0].Context())
 		require.True(t, ok)
 		require.Equal(t, didDoc.ID, subjectDID)
+
+		signature, ok := httpsigmw.Signature(handler.requestsCaptured[0].Context())
+		require.True(t, ok)
+		require.Equal(t, req.Header.Get("Signature"), signature)
 	})
 
 	t.Run("did resolve error", func(t *testing.T) {
@@ -0,0 +1,94 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package response records the responses served through the middlewares, for the ones logging or reporting them.
+package response
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+)
+
+// Recorder records the status and the size of the response written through it. The flushes and the hijacks are passed
+// through to the writer it wraps, for streamed responses and upgraded connections.
+type Recorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	bytes       int
+}
+
+// NewRecorder returns a recorder of the response written to w.
+func NewRecorder(w http.ResponseWriter) *Recorder {
+	return &Recorder{ResponseWriter: w, status: http.StatusOK}
+}
+
+// Status returns the status of the response, 200 OK if the header isn't written explicitly.
+func (r *Recorder) Status() int {
+	return r.status
+}
+
+// Bytes returns the size of the body written so far.
+func (r *Recorder) Bytes() int {
+	return r.bytes
+}
+
+// WroteHeader tells if the header of the response is written.
+func (r *Recorder) WroteHeader() bool {
+	return r.wroteHeader
+}
+
+// WriteHeader records the status of the first header written and writes it.
+func (r *Recorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status, r.wroteHeader = status, true
+	}
+
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Write writes the body and records its size. The header is written with 200 OK if it isn't yet.
+func (r *Recorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += n
+
+	return n, err
+}
+
+// Unwrap returns the writer of the response, for the middleware it wraps.
+func (r *Recorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// Flush flushes the response if the underlying writer supports it, for streamed responses.
+func (r *Recorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack takes over the connection if the underlying writer supports it, and records the response as switching
+// protocols. It returns http.ErrNotSupported otherwise.
+func (r *Recorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := r.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+
+	conn, rw, err := h.Hijack()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !r.wroteHeader {
+		r.status, r.wroteHeader = http.StatusSwitchingProtocols, true
+	}
+
+	return conn, rw, nil
+}
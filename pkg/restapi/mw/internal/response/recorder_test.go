@@ -0,0 +1,92 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package response_test
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/ace/pkg/restapi/mw/internal/response"
+)
+
+func TestRecorder(t *testing.T) {
+	t.Run("records the status and the size of the response", func(t *testing.T) {
+		rw := httptest.NewRecorder()
+		rec := response.NewRecorder(rw)
+
+		require.Equal(t, http.StatusOK, rec.Status())
+		require.False(t, rec.WroteHeader())
+
+		rec.WriteHeader(http.StatusCreated)
+		rec.WriteHeader(http.StatusInternalServerError)
+
+		_, err := rec.Write([]byte("ticket"))
+		require.NoError(t, err)
+
+		rec.Flush()
+
+		require.Equal(t, http.StatusCreated, rec.Status())
+		require.True(t, rec.WroteHeader())
+		require.Equal(t, 6, rec.Bytes())
+		require.True(t, rw.Flushed)
+		require.Equal(t, rw, rec.Unwrap())
+	})
+
+	t.Run("a body written first is 200 OK", func(t *testing.T) {
+		rec := response.NewRecorder(httptest.NewRecorder())
+
+		_, err := rec.Write([]byte("ok"))
+		require.NoError(t, err)
+
+		require.Equal(t, http.StatusOK, rec.Status())
+		require.True(t, rec.WroteHeader())
+	})
+
+	t.Run("passes the hijack through", func(t *testing.T) {
+		rec := response.NewRecorder(&hijacker{ResponseWriter: httptest.NewRecorder()})
+
+		_, _, err := rec.Hijack()
+		require.NoError(t, err)
+		require.Equal(t, http.StatusSwitchingProtocols, rec.Status())
+		require.True(t, rec.WroteHeader())
+	})
+
+	t.Run("fails to hijack", func(t *testing.T) {
+		_, _, err := response.NewRecorder(httptest.NewRecorder()).Hijack()
+		require.ErrorIs(t, err, http.ErrNotSupported)
+
+		rec := response.NewRecorder(&hijacker{ResponseWriter: httptest.NewRecorder(), err: errors.New("test")})
+
+		_, _, err = rec.Hijack()
+		require.EqualError(t, err, "test")
+		require.False(t, rec.WroteHeader())
+	})
+
+	t.Run("does nothing on flush if the writer doesn't flush", func(t *testing.T) {
+		response.NewRecorder(&hijacker{ResponseWriter: httptest.NewRecorder()}).Flush()
+	})
+}
+
+// hijacker is a writer that can be hijacked, but not flushed.
+type hijacker struct {
+	http.ResponseWriter
+	err error
+}
+
+func (h *hijacker) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if h.err != nil {
+		return nil, nil, h.err
+	}
+
+	return nil, nil, nil
+}
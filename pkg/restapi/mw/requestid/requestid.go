@@ -0,0 +1,140 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package requestid correlates the requests served with the calls made to serve them. Every request is given an ID,
+// the X-Request-ID it is sent with or a generated one, echoed in the response and sent with the downstream calls
+// along with a W3C traceparent continuing the trace of the request.
+package requestid
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+const (
+	// Header is the header of the ID of the request, in requests and responses.
+	Header = "X-Request-ID"
+	// TraceparentHeader is the header of the W3C trace context of the request.
+	TraceparentHeader = "traceparent"
+
+	maxIDLength  = 128
+	spanIDLength = 16
+	zeroTraceID  = "00000000000000000000000000000000"
+)
+
+var (
+	validID          = regexp.MustCompile(`^[A-Za-z0-9._:-]+$`)
+	validTraceparent = regexp.MustCompile(`^([0-9a-f]{2})-([0-9a-f]{32})-([0-9a-f]{16})-([0-9a-f]{2})`)
+	validTraceID     = regexp.MustCompile(`^[0-9a-f]{32}$`)
+)
+
+type contextKey struct{}
+
+// correlation is the ID and the trace of a request.
+type correlation struct {
+	id          string
+	traceID     string
+	flags       string
+	traceparent string
+}
+
+// New returns middleware giving every request an ID, the X-Request-ID header of the request if it is valid, or else
+// the ID of its trace. The trace is the one of the traceparent header of the request if it is valid, or else a new
+// one, with the X-Request-ID of the request as its ID if it is a valid trace ID. The ID and the traceparent are set
+// on the response before the handler is called.
+func New() mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			c := newCorrelation(req)
+
+			w.Header().Set(Header, c.id)
+			w.Header().Set(TraceparentHeader, c.traceparent)
+
+			next.ServeHTTP(w, req.WithContext(context.WithValue(req.Context(), contextKey{}, c)))
+		})
+	}
+}
+
+func newCorrelation(req *http.Request) *correlation {
+	c := &correlation{id: req.Header.Get(Header)}
+
+	if len(c.id) > maxIDLength || !validID.MatchString(c.id) {
+		c.id = ""
+	}
+
+	m := validTraceparent.FindStringSubmatch(req.Header.Get(TraceparentHeader))
+	if m != nil && m[1] != "ff" && m[2] != zeroTraceID {
+		c.traceID, c.flags, c.traceparent = m[2], m[4], m[0]
+	} else {
+		c.traceID, c.flags = strings.ToLower(c.id), "00"
+		if !validTraceID.MatchString(c.traceID) || c.traceID == zeroTraceID {
+			c.traceID = newTraceID()
+		}
+
+		c.traceparent = c.newSpan()
+	}
+
+	if c.id == "" {
+		c.id = c.traceID
+	}
+
+	return c
+}
+
+// FromContext returns the ID of the request of the context, or an empty string if the request is not served by the
+// middleware.
+func FromContext(ctx context.Context) string {
+	if c, ok := ctx.Value(contextKey{}).(*correlation); ok {
+		return c.id
+	}
+
+	return ""
+}
+
+// FromResponse returns the ID of the request of the response, set by the middleware, for the error responses.
+func FromResponse(w http.ResponseWriter) string {
+	return w.Header().Get(Header)
+}
+
+// Transport returns a round tripper sending the ID of the request of the context of the calls, and a traceparent
+// with a new span of its trace, with the calls made through the next round tripper. Calls made outside of a request
+// served by the middleware are sent as they are.
+func Transport(next http.RoundTripper) http.RoundTripper {
+	return roundTripper(func(req *http.Request) (*http.Response, error) {
+		c, ok := req.Context().Value(contextKey{}).(*correlation)
+		if !ok {
+			return next.RoundTrip(req)
+		}
+
+		// a round tripper must not modify the request
+		req = req.Clone(req.Context())
+		req.Header.Set(Header, c.id)
+		req.Header.Set(TraceparentHeader, c.newSpan())
+
+		return next.RoundTrip(req)
+	})
+}
+
+type roundTripper func(*http.Request) (*http.Response, error)
+
+func (f roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// newSpan returns a traceparent of a new span of the trace.
+func (c *correlation) newSpan() string {
+	return "00-" + c.traceID + "-" + newTraceID()[:spanIDLength] + "-" + c.flags
+}
+
+// newTraceID returns a random trace ID.
+func newTraceID() string {
+	return strings.ReplaceAll(uuid.NewString(), "-", "")
+}
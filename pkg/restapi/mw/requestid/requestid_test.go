@@ -0,0 +1,144 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package requestid_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/ace/pkg/restapi/mw/requestid"
+)
+
+const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+var validTraceparent = regexp.MustCompile(`^00-[0-9a-f]{32}-[0-9a-f]{16}-[0-9a-f]{2}$`)
+
+func TestMiddleware(t *testing.T) {
+	serve := func(t *testing.T, header http.Header) (*httptest.ResponseRecorder, string) {
+		t.Helper()
+
+		var id string
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header = header
+
+		rw := httptest.NewRecorder()
+		requestid.New()(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			id = requestid.FromContext(req.Context())
+			require.Equal(t, id, requestid.FromResponse(w))
+		})).ServeHTTP(rw, req)
+
+		return rw, id
+	}
+
+	t.Run("ID is generated", func(t *testing.T) {
+		rw, id := serve(t, http.Header{})
+
+		require.Len(t, id, 32)
+		require.Equal(t, id, rw.Header().Get(requestid.Header))
+		require.Regexp(t, validTraceparent, rw.Header().Get(requestid.TraceparentHeader))
+		require.Contains(t, rw.Header().Get(requestid.TraceparentHeader), "-"+id+"-")
+	})
+
+	t.Run("ID is accepted", func(t *testing.T) {
+		rw, id := serve(t, header(requestid.Header, "req-123"))
+
+		require.Equal(t, "req-123", id)
+		require.Equal(t, "req-123", rw.Header().Get(requestid.Header))
+		require.Regexp(t, validTraceparent, rw.Header().Get(requestid.TraceparentHeader))
+	})
+
+	t.Run("Trace is accepted", func(t *testing.T) {
+		rw, id := serve(t, header(requestid.TraceparentHeader, traceparent))
+
+		require.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", id)
+		require.Equal(t, traceparent, rw.Header().Get(requestid.TraceparentHeader))
+	})
+
+	t.Run("ID of a trace ID form starts the trace", func(t *testing.T) {
+		rw, id := serve(t, header(requestid.Header, "4BF92F3577B34DA6A3CE929D0E0E4736"))
+
+		require.Equal(t, "4BF92F3577B34DA6A3CE929D0E0E4736", id)
+		require.True(t, strings.HasPrefix(rw.Header().Get(requestid.TraceparentHeader),
+			"00-4bf92f3577b34da6a3ce929d0e0e4736-"))
+	})
+
+	t.Run("Invalid headers are replaced", func(t *testing.T) {
+		for _, h := range []http.Header{
+			header(requestid.Header, "id\nwith a new line"),
+			header(requestid.Header, strings.Repeat("a", 129)),
+			header(requestid.TraceparentHeader, "00-00000000000000000000000000000000-00f067aa0ba902b7-01"),
+			header(requestid.TraceparentHeader, "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"),
+			header(requestid.TraceparentHeader, "trace"),
+		} {
+			rw, id := serve(t, h)
+
+			require.Len(t, id, 32, h)
+			require.NotContains(t, rw.Header().Get(requestid.TraceparentHeader), "4bf92f3577b34da6a3ce929d0e0e4736")
+		}
+	})
+}
+
+func TestTransport(t *testing.T) {
+	var received http.Header
+
+	downstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		received = req.Header
+	}))
+	defer downstream.Close()
+
+	client := &http.Client{Transport: requestid.Transport(http.DefaultTransport)}
+
+	call := func(t *testing.T, served *http.Request) {
+		t.Helper()
+
+		req, err := http.NewRequestWithContext(served.Context(), http.MethodGet, downstream.URL, http.NoBody)
+		require.NoError(t, err)
+
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+		require.Empty(t, req.Header, "the request must not be modified")
+	}
+
+	t.Run("ID and trace are propagated", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(requestid.Header, "req-123")
+		req.Header.Set(requestid.TraceparentHeader, traceparent)
+
+		requestid.New()(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			call(t, req)
+		})).ServeHTTP(httptest.NewRecorder(), req)
+
+		require.Equal(t, "req-123", received.Get(requestid.Header))
+
+		parent := received.Get(requestid.TraceparentHeader)
+		require.Regexp(t, validTraceparent, parent)
+		require.True(t, strings.HasPrefix(parent, "00-4bf92f3577b34da6a3ce929d0e0e4736-"))
+		require.True(t, strings.HasSuffix(parent, "-01"))
+		require.NotEqual(t, traceparent, parent)
+	})
+
+	t.Run("Calls outside of requests are sent as they are", func(t *testing.T) {
+		call(t, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		require.Empty(t, received.Get(requestid.Header))
+		require.Empty(t, received.Get(requestid.TraceparentHeader))
+	})
+}
+
+func header(key, value string) http.Header {
+	h := http.Header{}
+	h.Set(key, value)
+
+	return h
+}
@@ -0,0 +1,168 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package slowrequest logs the requests taking longer than a threshold, with the breakdown of the downstream calls
+// made while serving them, so that the tail latency can be investigated without tracing.
+package slowrequest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/hyperledger/aries-framework-go/pkg/common/log"
+
+	"github.com/trustbloc/ace/pkg/restapi/mw/internal/response"
+)
+
+var logger = log.New("slow-request")
+
+const eventStream = "text/event-stream"
+
+// Logger logs the slow requests.
+type Logger interface {
+	Warnf(msg string, args ...interface{})
+}
+
+// Config defines the configuration of the middleware.
+type Config struct {
+	// Threshold is the duration from which requests are logged.
+	Threshold time.Duration
+	// Logger logs the slow requests. Defaults to the logger of the package.
+	Logger Logger
+}
+
+// New returns middleware logging a warning for every request taking longer than the threshold, with its route, its
+// status, its duration and the downstream calls made with its context through Transport. Server-sent event streams
+// are not logged.
+func New(config *Config) mux.MiddlewareFunc {
+	l := config.Logger
+	if l == nil {
+		l = logger
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			start := time.Now()
+			t := &trace{calls: map[string]*calls{}}
+			rec := response.NewRecorder(w)
+
+			next.ServeHTTP(rec, req.WithContext(context.WithValue(req.Context(), traceKey{}, t)))
+
+			// event streams are open as long as their clients listen to them
+			if rec.Header().Get("Content-Type") == eventStream {
+				return
+			}
+
+			if d := time.Since(start); d > config.Threshold {
+				l.Warnf("Slow request: method=%s route=%s status=%d duration=%s downstream=%s",
+					req.Method, route(req), rec.Status(), d, t)
+			}
+		})
+	}
+}
+
+// Transport returns a round tripper recording, by host, the calls made through the next round tripper with the
+// context of a request served by the middleware. The duration of a call is the time to its response headers.
+func Transport(next http.RoundTripper) http.RoundTripper {
+	return roundTripper(func(req *http.Request) (*http.Response, error) {
+		t, ok := req.Context().Value(traceKey{}).(*trace)
+		if !ok {
+			return next.RoundTrip(req)
+		}
+
+		start := time.Now()
+
+		defer func() {
+			t.record(req.URL.Host, time.Since(start))
+		}()
+
+		return next.RoundTrip(req)
+	})
+}
+
+type roundTripper func(*http.Request) (*http.Response, error)
+
+func (f roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+type traceKey struct{}
+
+// trace is the record of the downstream calls made while serving a request, by host.
+type trace struct {
+	mu    sync.Mutex
+	calls map[string]*calls
+}
+
+type calls struct {
+	host     string
+	count    int
+	duration time.Duration
+}
+
+func (t *trace) record(host string, d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	c, ok := t.calls[host]
+	if !ok {
+		c = &calls{host: host}
+		t.calls[host] = c
+	}
+
+	c.count++
+	c.duration += d
+}
+
+// String returns the downstream calls as a list of host(count):duration, longest first, or none.
+func (t *trace) String() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(t.calls) == 0 {
+		return "none"
+	}
+
+	all := make([]*calls, 0, len(t.calls))
+
+	for _, c := range t.calls {
+		all = append(all, c)
+	}
+
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].duration != all[j].duration {
+			return all[i].duration > all[j].duration
+		}
+
+		return all[i].host < all[j].host
+	})
+
+	s := make([]string, len(all))
+
+	for i, c := range all {
+		s[i] = fmt.Sprintf("%s(%d):%s", c.host, c.count, c.duration)
+	}
+
+	return strings.Join(s, ",")
+}
+
+// route returns the template of the route of the request, which does not have the DIDs and the IDs of its path, or
+// its path if it does not match a route.
+func route(req *http.Request) string {
+	if r := mux.CurrentRoute(req); r != nil {
+		if tpl, err := r.GetPathTemplate(); err == nil {
+			return tpl
+		}
+	}
+
+	return req.URL.Path
+}
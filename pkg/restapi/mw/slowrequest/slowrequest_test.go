@@ -0,0 +1,114 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package slowrequest_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/hyperledger/aries-framework-go/pkg/common/log/mocklogger"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/ace/pkg/restapi/mw/slowrequest"
+)
+
+func TestMiddleware(t *testing.T) {
+	downstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		time.Sleep(10 * time.Millisecond)
+	}))
+	defer downstream.Close()
+
+	u, err := url.Parse(downstream.URL)
+	require.NoError(t, err)
+
+	client := &http.Client{Transport: slowrequest.Transport(http.DefaultTransport)}
+
+	call := func(t *testing.T, req *http.Request) {
+		t.Helper()
+
+		r, err := http.NewRequestWithContext(req.Context(), http.MethodGet, downstream.URL, http.NoBody)
+		require.NoError(t, err)
+
+		resp, err := client.Do(r)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+	}
+
+	t.Run("Slow request is logged", func(t *testing.T) {
+		l := &mocklogger.MockLogger{}
+
+		router := mux.NewRouter()
+		router.Use(slowrequest.New(&slowrequest.Config{Threshold: 15 * time.Millisecond, Logger: l}))
+		router.HandleFunc("/v1/protect/{id}", func(w http.ResponseWriter, req *http.Request) {
+			call(t, req)
+			call(t, req)
+
+			w.WriteHeader(http.StatusAccepted)
+		})
+
+		rw := httptest.NewRecorder()
+		router.ServeHTTP(rw, httptest.NewRequest(http.MethodPost, "/v1/protect/123", nil))
+
+		require.Equal(t, http.StatusAccepted, rw.Code)
+		require.Contains(t, l.WarnLogContents, "Slow request: method=POST route=/v1/protect/{id} status=202 duration=")
+		require.Contains(t, l.WarnLogContents, "downstream="+u.Host+"(2):")
+	})
+
+	t.Run("Request without downstream calls", func(t *testing.T) {
+		l := &mocklogger.MockLogger{}
+
+		rw := httptest.NewRecorder()
+		slowrequest.New(&slowrequest.Config{Logger: l})(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			_, err := w.Write([]byte("data"))
+			require.NoError(t, err)
+
+			w.(http.Flusher).Flush() //nolint:forcetypeassert,errcheck
+		})).ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/healthcheck", nil))
+
+		require.True(t, rw.Flushed)
+		require.Contains(t, l.WarnLogContents, "route=/healthcheck status=200")
+		require.Contains(t, l.WarnLogContents, "downstream=none")
+	})
+
+	t.Run("Fast request is not logged", func(t *testing.T) {
+		l := &mocklogger.MockLogger{}
+
+		rw := httptest.NewRecorder()
+		slowrequest.New(&slowrequest.Config{Threshold: time.Minute, Logger: l})(
+			http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				call(t, req)
+			})).ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		require.Empty(t, l.AllLogContents)
+	})
+
+	t.Run("Event stream is not logged", func(t *testing.T) {
+		l := &mocklogger.MockLogger{}
+
+		rw := httptest.NewRecorder()
+		slowrequest.New(&slowrequest.Config{Logger: l})(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.Header().Set("Content-Type", "text/event-stream")
+			call(t, req)
+		})).ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/v1/release/123/events", nil))
+
+		require.Empty(t, l.AllLogContents)
+	})
+
+	t.Run("Calls outside of requests are not recorded", func(t *testing.T) {
+		req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, downstream.URL, http.NoBody)
+		require.NoError(t, err)
+
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+	})
+}
@@ -11,8 +11,13 @@ import (
 	"net/http"
 
 	"github.com/gorilla/mux"
+
+	"github.com/trustbloc/ace/pkg/restapi/mw/accesslog"
 )
 
+// TokenCaller is the caller of the requests authorized by the token in the access logs.
+const TokenCaller = "api-token"
+
 func validateAuthorizationBearerToken(w http.ResponseWriter, r *http.Request, token string) bool {
 	actHdr := r.Header.Get("Authorization")
 	expHdr := "Bearer " + token
@@ -32,6 +37,8 @@ func New(token string) mux.MiddlewareFunc {
 	middleware := func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			if validateAuthorizationBearerToken(w, r, token) {
+				accesslog.SetCaller(r.Context(), TokenCaller)
+
 				next.ServeHTTP(w, r)
 			}
 		})
@@ -9,6 +9,8 @@ package vault
 import (
 	"bytes"
 	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
@@ -21,6 +23,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/hyperledger/aries-framework-go-ext/component/vdr/orb"
 	"github.com/hyperledger/aries-framework-go-ext/component/vdr/sidetree/doc"
+	"github.com/hyperledger/aries-framework-go/pkg/common/log"
 	ariescrypto "github.com/hyperledger/aries-framework-go/pkg/crypto"
 	"github.com/hyperledger/aries-framework-go/pkg/crypto/tinkcrypto"
 	webcrypto "github.com/hyperledger/aries-framework-go/pkg/crypto/webkms"
@@ -47,6 +50,8 @@ import (
 
 	"github.com/trustbloc/ace/pkg/doc/vc/crypto"
 	"github.com/trustbloc/ace/pkg/internal/zcapldutil"
+	"github.com/trustbloc/ace/pkg/storage/index"
+	"github.com/trustbloc/ace/pkg/zkp"
 )
 
 const (
@@ -55,13 +60,27 @@ const (
 	authorizationFormat = "authorization_%s_%s"
 	metaDocInfoFormat   = "meta_doc_info_%s_%s"
 	infoFormat          = "info_%s"
+	docIndexFormat      = "doc_index_%s_%s"
+
+	indexKeyLabel = "vault-server document index"
+	indexHMACType = "Sha256HmacKey2019"
+
+	// vaultTag tags the entries of a vault, so that they are deleted with it.
+	vaultTag = "vault"
 )
 
+var logger = log.New("vault-client")
+
+// ErrVaultNotFound is returned when the vault does not exist, or was deleted.
+var ErrVaultNotFound = errors.New("vault not found")
+
 // Vault defines vault client interface.
 type Vault interface {
 	CreateVault() (*CreatedVault, error)
-	SaveDoc(vaultID, id string, content []byte) (*DocumentMetadata, error)
+	DeleteVault(vaultID string) error
+	SaveDoc(vaultID, id string, content []byte, tags ...string) (*DocumentMetadata, error)
 	GetDocMetadata(vaultID, docID string) (*DocumentMetadata, error)
+	QueryDocs(vaultID, tag string) ([]*DocumentMetadata, error)
 	CreateAuthorization(vaultID, requestingParty string, scope *AuthorizationsScope) (*CreatedAuthorization, error)
 	GetAuthorization(vaultID, id string) (*CreatedAuthorization, error)
 }
@@ -125,6 +144,8 @@ type DocumentMetadata struct {
 	ID        string `json:"docID"`
 	URI       string `json:"edvDocURI"`
 	EncKeyURI string `json:"encKeyURI"`
+	// Digest of the encrypted document. It changes whenever the document is updated or re-encrypted.
+	Digest string `json:"digest,omitempty"`
 }
 
 // Client vault`s client.
@@ -196,7 +217,7 @@ func NewClient(kmsURL, edvURL string, kmsClient kms.KeyManager, db storage.Provi
 		return nil, fmt.Errorf("url parse: %w", err)
 	}
 
-	store, err := db.OpenStore(storeName)
+	store, err := index.OpenStore(db, &index.Declaration{Store: storeName, TagNames: []string{vaultTag}})
 	if err != nil {
 		return nil, fmt.Errorf("open store: %w", err)
 	}
@@ -273,6 +294,97 @@ func (c *Client) CreateVault() (*CreatedVault, error) {
 	}, nil
 }
 
+// DeleteVault deletes the documents of the vault from the EDV server, then the vault with its documents, indexes and
+// authorizations from the store, so that the vault no longer exists. Returns ErrVaultNotFound if it does not exist.
+func (c *Client) DeleteVault(vaultID string) error {
+	info, err := c.getVaultInfo(vaultID)
+	if errors.Is(err, storage.ErrDataNotFound) {
+		return ErrVaultNotFound
+	}
+
+	if err != nil {
+		return fmt.Errorf("get vault info: %w", err)
+	}
+
+	keys, err := c.vaultEntries(vaultID)
+	if err != nil {
+		return err
+	}
+
+	edvVaultID := lastElm(info.Auth.EDV.URI, "/")
+	docPrefix := fmt.Sprintf(metaDocInfoFormat, vaultID, "")
+
+	for _, key := range keys {
+		if !strings.HasPrefix(key, docPrefix) {
+			continue
+		}
+
+		dInfo, e := c.getMetaDocInfo(vaultID, strings.TrimPrefix(key, docPrefix))
+		if e != nil {
+			return fmt.Errorf("get meta doc info: %w", e)
+		}
+
+		e = c.edvClient.DeleteDocument(edvVaultID, dInfo.EdvID,
+			edv.WithRequestHeader(c.edvSign(info.DidURL, info.Auth.EDV)))
+		if e != nil && !strings.Contains(e.Error(), messages.ErrDocumentNotFound.Error()) {
+			return fmt.Errorf("delete document: %w", e)
+		}
+	}
+
+	// the vault info is deleted last, so that a failed deletion can be retried
+	for _, key := range keys {
+		if e := c.store.Delete(key); e != nil {
+			return fmt.Errorf("delete %s: %w", key, e)
+		}
+	}
+
+	return nil
+}
+
+// vaultEntries returns the keys of the entries of the vault, with the vault info last.
+func (c *Client) vaultEntries(vaultID string) ([]string, error) {
+	iter, err := c.store.Query(vaultTag + ":" + index.TagValue(vaultID))
+	if err != nil {
+		return nil, fmt.Errorf("query vault entries: %w", err)
+	}
+
+	defer func() {
+		if e := iter.Close(); e != nil {
+			logger.Warnf("Failed to close iterator: %s", e)
+		}
+	}()
+
+	infoKey := fmt.Sprintf(infoFormat, vaultID)
+
+	var keys []string
+
+	for {
+		ok, e := iter.Next()
+		if e != nil {
+			return nil, fmt.Errorf("next vault entry: %w", e)
+		}
+
+		if !ok {
+			break
+		}
+
+		key, e := iter.Key()
+		if e != nil {
+			return nil, fmt.Errorf("vault entry key: %w", e)
+		}
+
+		if key != infoKey {
+			keys = append(keys, key)
+		}
+	}
+
+	return append(keys, infoKey), nil
+}
+
+func vaultEntry(vaultID string) storage.Tag {
+	return storage.Tag{Name: vaultTag, Value: index.TagValue(vaultID)}
+}
+
 // CreateAuthorization creates a new authorization.
 // nolint: funlen
 func (c *Client) CreateAuthorization(vaultID, requestingParty string, scope *AuthorizationsScope,
@@ -377,7 +489,7 @@ func (c *Client) saveAuthorization(vID string, a *CreatedAuthorization) error {
 		return fmt.Errorf("marshal: %w", err)
 	}
 
-	return c.store.Put(fmt.Sprintf(authorizationFormat, vID, a.ID), src)
+	return c.store.Put(fmt.Sprintf(authorizationFormat, vID, a.ID), src, vaultEntry(vID))
 }
 
 func (c *Client) getAuthorization(vID, id string) (*CreatedAuthorization, error) {
@@ -410,7 +522,7 @@ func (c *Client) GetDocMetadata(vaultID, docID string) (*DocumentMetadata, error
 		return nil, fmt.Errorf("get meta doc info: %w", err)
 	}
 
-	_, err = c.edvClient.ReadDocument(edvVaultID, dInfo.EdvID, edv.WithRequestHeader(
+	encDoc, err := c.edvClient.ReadDocument(edvVaultID, dInfo.EdvID, edv.WithRequestHeader(
 		c.edvSign(info.DidURL, info.Auth.EDV)),
 	)
 	if err != nil {
@@ -421,16 +533,24 @@ func (c *Client) GetDocMetadata(vaultID, docID string) (*DocumentMetadata, error
 		ID:        docID,
 		URI:       buildEDVDocURI(c.edvScheme, c.edvHost, edvVaultID, dInfo.EdvID),
 		EncKeyURI: dInfo.KidURL,
+		Digest:    digest(encDoc.JWE),
 	}, nil
 }
 
-// SaveDoc saves a document by encrypting it and storing it in the vault.
-func (c *Client) SaveDoc(vaultID, id string, content []byte) (*DocumentMetadata, error) { // nolint:funlen
+// SaveDoc saves a document by encrypting it and storing it in the vault. The document is indexed with the tags, as
+// name:value or name, blinded so that the EDV server learns neither their names nor their values.
+func (c *Client) SaveDoc(vaultID, id string, content []byte, // nolint:funlen
+	tags ...string) (*DocumentMetadata, error) {
 	info, err := c.getVaultInfo(vaultID)
 	if err != nil {
 		return nil, fmt.Errorf("get vault info: %w", err)
 	}
 
+	indexed, err := c.indexedAttributes(info, tags)
+	if err != nil {
+		return nil, fmt.Errorf("index tags: %w", err)
+	}
+
 	docID, err := edvutils.GenerateEDVCompatibleID()
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate an EDV document ID: %w", err)
@@ -443,11 +563,17 @@ func (c *Client) SaveDoc(vaultID, id string, content []byte) (*DocumentMetadata,
 		return nil, fmt.Errorf("failed to decode content: %w", err)
 	}
 
+	commitment, err := c.commit(info, docContents)
+	if err != nil {
+		return nil, fmt.Errorf("commit to content: %w", err)
+	}
+
 	kidURL, encContent, err := encryptContent(
 		c.webKMS(info.DidURL, info.Auth.KMS),
 		c.webCrypto(info.DidURL, info.Auth.KMS),
 		&models.StructuredDocument{
 			ID:      docID,
+			Meta:    map[string]interface{}{zkp.MetaKey: commitment},
 			Content: docContents,
 		},
 	)
@@ -467,17 +593,23 @@ func (c *Client) SaveDoc(vaultID, id string, content []byte) (*DocumentMetadata,
 		}
 	}
 
+	if err = c.saveDocIndex(vaultID, id, dInfo.EdvID, tags); err != nil {
+		return nil, fmt.Errorf("save doc index: %w", err)
+	}
+
 	edvVaultID := lastElm(info.Auth.EDV.URI, "/")
 
 	_, err = c.edvClient.CreateDocument(edvVaultID, &models.EncryptedDocument{
-		ID:  dInfo.EdvID,
-		JWE: []byte(encContent),
+		ID:                          dInfo.EdvID,
+		IndexedAttributeCollections: indexed,
+		JWE:                         []byte(encContent),
 	}, edv.WithRequestHeader(c.edvSign(info.DidURL, info.Auth.EDV)))
 	if err == nil {
 		return &DocumentMetadata{
 			URI:       buildEDVDocURI(c.edvScheme, c.edvHost, edvVaultID, dInfo.EdvID),
 			ID:        id,
 			EncKeyURI: dInfo.KidURL,
+			Digest:    digest([]byte(encContent)),
 		}, nil
 	}
 
@@ -486,8 +618,9 @@ func (c *Client) SaveDoc(vaultID, id string, content []byte) (*DocumentMetadata,
 	}
 
 	err = c.edvClient.UpdateDocument(edvVaultID, dInfo.EdvID, &models.EncryptedDocument{
-		ID:  dInfo.EdvID,
-		JWE: []byte(encContent),
+		ID:                          dInfo.EdvID,
+		IndexedAttributeCollections: indexed,
+		JWE:                         []byte(encContent),
 	}, edv.WithRequestHeader(c.edvSign(info.DidURL, info.Auth.EDV)))
 	if err != nil {
 		return nil, fmt.Errorf("update document: %w", err)
@@ -497,6 +630,174 @@ func (c *Client) SaveDoc(vaultID, id string, content []byte) (*DocumentMetadata,
 		ID:        id,
 		URI:       buildEDVDocURI(c.edvScheme, c.edvHost, edvVaultID, dInfo.EdvID),
 		EncKeyURI: dInfo.KidURL,
+		Digest:    digest([]byte(encContent)),
+	}, nil
+}
+
+// QueryDocs returns the metadata of the documents of the vault saved with the tag, as name:value, or with any value of
+// the tag if it is only a name. The digests of the documents are not returned.
+func (c *Client) QueryDocs(vaultID, tag string) ([]*DocumentMetadata, error) {
+	info, err := c.getVaultInfo(vaultID)
+	if err != nil {
+		return nil, fmt.Errorf("get vault info: %w", err)
+	}
+
+	key, err := c.indexKey(info)
+	if err != nil {
+		return nil, fmt.Errorf("index key: %w", err)
+	}
+
+	name, value := splitTag(tag)
+	if name == "" {
+		return nil, errors.New("tag name must be set")
+	}
+
+	query := &models.Query{Index: info.DidURL}
+
+	if value == "" {
+		query.Has = blind(key, name)
+	} else {
+		query.Equals = []map[string]string{{blind(key, name): blind(key, value)}}
+	}
+
+	edvVaultID := lastElm(info.Auth.EDV.URI, "/")
+
+	docURLs, _, err := c.edvClient.QueryVault(edvVaultID, query,
+		edv.WithRequestHeader(c.edvSign(info.DidURL, info.Auth.EDV)))
+	if err != nil {
+		return nil, fmt.Errorf("query vault: %w", err)
+	}
+
+	docs := make([]*DocumentMetadata, 0, len(docURLs))
+
+	for _, u := range docURLs {
+		edvID := lastElm(u, "/")
+
+		id, e := c.store.Get(fmt.Sprintf(docIndexFormat, vaultID, edvID))
+		if e != nil {
+			return nil, fmt.Errorf("get doc index: %w", e)
+		}
+
+		dInfo, e := c.getMetaDocInfo(vaultID, string(id))
+		if e != nil {
+			return nil, fmt.Errorf("get meta doc info: %w", e)
+		}
+
+		docs = append(docs, &DocumentMetadata{
+			ID:        string(id),
+			URI:       buildEDVDocURI(c.edvScheme, c.edvHost, edvVaultID, edvID),
+			EncKeyURI: dInfo.KidURL,
+		})
+	}
+
+	return docs, nil
+}
+
+// saveDocIndex maps the EDV document of a tagged document back to its ID, for the documents found by tag.
+func (c *Client) saveDocIndex(vaultID, id, edvID string, tags []string) error {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	return c.store.Put(fmt.Sprintf(docIndexFormat, vaultID, edvID), []byte(id), vaultEntry(vaultID))
+}
+
+// indexedAttributes returns the attributes the EDV server indexes the document with, from its tags.
+func (c *Client) indexedAttributes(info *vaultInfo, tags []string) ([]models.IndexedAttributeCollection, error) {
+	if len(tags) == 0 {
+		return nil, nil
+	}
+
+	key, err := c.indexKey(info)
+	if err != nil {
+		return nil, err
+	}
+
+	attrs := make([]models.IndexedAttribute, 0, len(tags))
+
+	for _, tag := range tags {
+		name, value := splitTag(tag)
+		if name == "" {
+			return nil, fmt.Errorf("invalid tag %q: name must be set", tag)
+		}
+
+		attr := models.IndexedAttribute{Name: blind(key, name)}
+
+		if value != "" {
+			attr.Value = blind(key, value)
+		}
+
+		attrs = append(attrs, attr)
+	}
+
+	return []models.IndexedAttributeCollection{{
+		HMAC:              models.IDTypePair{ID: info.DidURL, Type: indexHMACType},
+		IndexedAttributes: attrs,
+	}}, nil
+}
+
+// indexKey returns the HMAC key blinding the tags of the documents of the vault. It is derived from a signature with
+// the key of the vault, Ed25519 signatures being deterministic, so that it is neither stored nor sent anywhere.
+func (c *Client) indexKey(info *vaultInfo) ([]byte, error) {
+	kh, err := c.kms.Get(info.KID)
+	if err != nil {
+		return nil, fmt.Errorf("kms get: %w", err)
+	}
+
+	sig, err := c.crypto.Sign([]byte(indexKeyLabel), kh)
+	if err != nil {
+		return nil, fmt.Errorf("sign: %w", err)
+	}
+
+	key := sha256.Sum256(sig)
+
+	return key[:], nil
+}
+
+func blind(key []byte, s string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(s)) //nolint:errcheck,gosec // hash writes never fail
+
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func splitTag(tag string) (string, string) {
+	if i := strings.Index(tag, ":"); i >= 0 {
+		return tag[:i], tag[i+1:]
+	}
+
+	return tag, ""
+}
+
+// commit commits to the content of a document, with its point signed by the key of the vault, so that the equality
+// proofs over the document are verified against the commitment created when it was protected.
+func (c *Client) commit(info *vaultInfo, content map[string]interface{}) (*zkp.SignedCommitment, error) {
+	raw, err := json.Marshal(content)
+	if err != nil {
+		return nil, fmt.Errorf("marshal: %w", err)
+	}
+
+	commitment, err := zkp.Commit(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	kh, err := c.kms.Get(info.KID)
+	if err != nil {
+		return nil, fmt.Errorf("kms get: %w", err)
+	}
+
+	signature, err := c.crypto.Sign(zkp.SigningInput(commitment.Point), kh)
+	if err != nil {
+		return nil, fmt.Errorf("sign: %w", err)
+	}
+
+	return &zkp.SignedCommitment{
+		Scheme:             zkp.Scheme,
+		Point:              base64.RawURLEncoding.EncodeToString(commitment.Point),
+		Blinding:           base64.RawURLEncoding.EncodeToString(commitment.Blinding()),
+		VerificationMethod: info.DidURL,
+		Signature:          base64.RawURLEncoding.EncodeToString(signature),
 	}, nil
 }
 
@@ -512,7 +813,7 @@ func (c *Client) saveVaultInfo(id string, info *vaultInfo) error {
 		return fmt.Errorf("marshal: %w", err)
 	}
 
-	return c.store.Put(fmt.Sprintf(infoFormat, id), src)
+	return c.store.Put(fmt.Sprintf(infoFormat, id), src, vaultEntry(id))
 }
 
 type metaDocInfo struct {
@@ -533,7 +834,7 @@ func (c *Client) createMetaDocInfo(vid, id, kid string) (*metaDocInfo, error) {
 		return nil, fmt.Errorf("marshal: %w", err)
 	}
 
-	err = c.store.Put(fmt.Sprintf(metaDocInfoFormat, vid, id), src)
+	err = c.store.Put(fmt.Sprintf(metaDocInfoFormat, vid, id), src, vaultEntry(vid))
 	if err != nil {
 		return nil, fmt.Errorf("store put: %w", err)
 	}
@@ -756,6 +1057,12 @@ func lastElm(s, sep string) string { // nolint: unparam
 	return all[len(all)-1]
 }
 
+func digest(jwe []byte) string {
+	sum := sha256.Sum256(jwe)
+
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
 func buildEDVDocURI(s, h, vid, did string) string {
 	return fmt.Sprintf("%s/documents/%s", buildEDVURI(s, h, vid), did)
 }
@@ -12,6 +12,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/google/uuid"
@@ -30,9 +31,11 @@ import (
 	"github.com/stretchr/testify/require"
 	"github.com/trustbloc/edge-core/pkg/zcapld"
 	"github.com/trustbloc/edv/pkg/restapi/messages"
+	"github.com/trustbloc/edv/pkg/restapi/models"
 
 	"github.com/trustbloc/ace/pkg/internal/testutil"
 	"github.com/trustbloc/ace/pkg/restapi/vault"
+	"github.com/trustbloc/ace/pkg/storage/index"
 )
 
 const kmsResponse = `
@@ -359,10 +362,11 @@ func TestClient_SaveDoc(t *testing.T) {
 		client, err := vault.NewClient(remoteKMS.URL, "", lKMS, store, loader)
 		require.NoError(t, err)
 
-		vID, dURL, _ := createVaultID(t, lKMS)
+		vID, dURL, kid := createVaultID(t, lKMS)
 
 		data["info_"+vID] = mockstorage.DBEntry{
-			Value: []byte(`{"did_url":"` + dURL + `", "auth":{"edv":{},"kms":{"uri":"/v1/keystores/c0ekinlioud42c84qs7g"}}}`),
+			Value: []byte(`{"did_url":"` + dURL + `", "kid":"` + kid +
+				`", "auth":{"edv":{},"kms":{"uri":"/v1/keystores/c0ekinlioud42c84qs7g"}}}`),
 		}
 
 		_, err = client.SaveDoc(vID, docID, data["info_"+vID].Value)
@@ -370,19 +374,21 @@ func TestClient_SaveDoc(t *testing.T) {
 		require.Contains(t, err.Error(), "create meta doc info: store put: text")
 	})
 
-	t.Run("Encrypt key (create error)", func(t *testing.T) {
-		client, err := vault.NewClient("", "", nil, &mockstorage.MockStoreProvider{
+	t.Run("Commit to content (error)", func(t *testing.T) {
+		store := &mockstorage.MockStoreProvider{
 			Store: &mockstorage.MockStore{
 				Store: map[string]mockstorage.DBEntry{
 					"info_v_id": {Value: []byte(`{"auth":{"edv":{},"kms":{}}}`)},
 				},
 			},
-		}, loader)
+		}
+
+		client, err := vault.NewClient("", "", newLocalKms(t, store), store, loader)
 		require.NoError(t, err)
 
 		_, err = client.SaveDoc(vaultID, docID, []byte(`{"auth":{"edv":{},"kms":{}}}`))
 		require.Error(t, err)
-		require.Contains(t, err.Error(), "encrypt key: create: posting Create key failed")
+		require.Contains(t, err.Error(), "commit to content: kms get")
 	})
 
 	t.Run("Get meta doc info (error)", func(t *testing.T) {
@@ -434,10 +440,11 @@ func TestClient_SaveDoc(t *testing.T) {
 		client, err := vault.NewClient(remoteKMS.URL, "", lKMS, store, loader)
 		require.NoError(t, err)
 
-		vID, dURL, _ := createVaultID(t, lKMS)
+		vID, dURL, kid := createVaultID(t, lKMS)
 
 		data["info_"+vID] = mockstorage.DBEntry{
-			Value: []byte(`{"did_url":"` + dURL + `", "auth":{"edv":{},"kms":{"uri":"/v1/keystores/c0ekinlioud42c84qs7g"}}}`),
+			Value: []byte(`{"did_url":"` + dURL + `", "kid":"` + kid +
+				`", "auth":{"edv":{},"kms":{"uri":"/v1/keystores/c0ekinlioud42c84qs7g"}}}`),
 		}
 
 		_, err = client.SaveDoc(vID, docID, data["info_"+vID].Value)
@@ -446,16 +453,22 @@ func TestClient_SaveDoc(t *testing.T) {
 	})
 
 	t.Run("Encrypt key (create error)", func(t *testing.T) {
-		client, err := vault.NewClient("", "", nil, &mockstorage.MockStoreProvider{
-			Store: &mockstorage.MockStore{
-				Store: map[string]mockstorage.DBEntry{
-					"info_v_id": {Value: []byte(`{"auth":{"edv":{},"kms":{}}}`)},
-				},
-			},
-		}, loader)
+		data := map[string]mockstorage.DBEntry{}
+		store := &mockstorage.MockStoreProvider{
+			Store: &mockstorage.MockStore{Store: data},
+		}
+
+		lKMS := newLocalKms(t, store)
+		client, err := vault.NewClient("", "", lKMS, store, loader)
 		require.NoError(t, err)
 
-		_, err = client.SaveDoc(vaultID, docID, []byte(`{"auth":{"edv":{},"kms":{}}}`))
+		vID, dURL, kid := createVaultID(t, lKMS)
+
+		data["info_"+vID] = mockstorage.DBEntry{
+			Value: []byte(`{"did_url":"` + dURL + `", "kid":"` + kid + `", "auth":{"edv":{},"kms":{}}}`),
+		}
+
+		_, err = client.SaveDoc(vID, docID, []byte(`{"auth":{"edv":{},"kms":{}}}`))
 		require.Error(t, err)
 		require.Contains(t, err.Error(), "encrypt key: create: posting Create key failed")
 	})
@@ -515,16 +528,18 @@ func TestClient_SaveDoc(t *testing.T) {
 		client, err := vault.NewClient(remoteKMS.URL, edv.URL, lKMS, store, loader)
 		require.NoError(t, err)
 
-		vID, dURL, _ := createVaultID(t, lKMS)
+		vID, dURL, kid := createVaultID(t, lKMS)
 
 		data["info_"+vID] = mockstorage.DBEntry{
-			Value: []byte(`{"did_url":"` + dURL + `", "auth":{"edv":{},"kms":{"uri":"/v1/keystores/c0ekinlioud42c84qs7g"}}}`),
+			Value: []byte(`{"did_url":"` + dURL + `", "kid":"` + kid +
+				`", "auth":{"edv":{},"kms":{"uri":"/v1/keystores/c0ekinlioud42c84qs7g"}}}`),
 		}
 
 		docMeta, err := client.SaveDoc(vID, docID, data["info_"+vID].Value)
 		require.NoError(t, err)
 		require.NotEmpty(t, docMeta.ID)
 		require.NotEmpty(t, docMeta.URI)
+		require.NotEmpty(t, docMeta.Digest)
 	})
 
 	t.Run("Success (update)", func(t *testing.T) {
@@ -600,16 +615,18 @@ func TestClient_SaveDoc(t *testing.T) {
 		client, err := vault.NewClient(remoteKMS.URL, edv.URL, lKMS, store, loader)
 		require.NoError(t, err)
 
-		vID, dURL, _ := createVaultID(t, lKMS)
+		vID, dURL, kid := createVaultID(t, lKMS)
 
 		data["info_"+vID] = mockstorage.DBEntry{
-			Value: []byte(`{"did_url":"` + dURL + `", "auth":{"edv":{},"kms":{"uri":"/v1/keystores/c0ekinlioud42c84qs7g"}}}`),
+			Value: []byte(`{"did_url":"` + dURL + `", "kid":"` + kid +
+				`", "auth":{"edv":{},"kms":{"uri":"/v1/keystores/c0ekinlioud42c84qs7g"}}}`),
 		}
 
 		docMeta, err := client.SaveDoc(vID, docID, data["info_"+vID].Value)
 		require.NoError(t, err)
 		require.NotEmpty(t, docMeta.ID)
 		require.NotEmpty(t, docMeta.URI)
+		require.NotEmpty(t, docMeta.Digest)
 	})
 
 	t.Run("error if doc contents are not JSON", func(t *testing.T) {
@@ -836,6 +853,230 @@ func TestClient_GetDocMetadata(t *testing.T) {
 		require.NotEmpty(t, docMeta.ID)
 		require.NotEmpty(t, docMeta.URI)
 		require.NotEmpty(t, docMeta.EncKeyURI)
+		require.NotEmpty(t, docMeta.Digest)
+	})
+}
+
+func TestClient_DeleteVault(t *testing.T) {
+	loader := testutil.DocumentLoader(t)
+
+	newVault := func(t *testing.T, edvURL string) (*vault.Client, storage.Store, string) {
+		t.Helper()
+
+		provider := mem.NewProvider()
+
+		lKMS := newLocalKms(t, provider)
+		client, err := vault.NewClient("", edvURL, lKMS, provider, loader)
+		require.NoError(t, err)
+
+		store, err := provider.OpenStore("vault")
+		require.NoError(t, err)
+
+		vID, dURL, _ := createVaultID(t, lKMS)
+		tag := storage.Tag{Name: "vault", Value: index.TagValue(vID)}
+
+		require.NoError(t, store.Put("info_"+vID, []byte(`{"did_url":"`+dURL+
+			`","auth":{"edv":{"uri":"/encrypted-data-vaults/eVaultID"},"kms":{}}}`), tag))
+		require.NoError(t, store.Put("meta_doc_info_"+vID+"_docID", []byte(`{"edv_id":"eDocID"}`), tag))
+		require.NoError(t, store.Put("doc_index_"+vID+"_eDocID", []byte("docID"), tag))
+		require.NoError(t, store.Put("authorization_"+vID+"_authID", []byte(`{}`), tag))
+		require.NoError(t, store.Put("info_did:example:other", []byte(`{}`),
+			storage.Tag{Name: "vault", Value: index.TagValue("did:example:other")}))
+
+		return client, store, vID
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		var deleted []string
+
+		edv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, http.MethodDelete, r.Method)
+
+			deleted = append(deleted, r.URL.Path)
+		}))
+		defer edv.Close()
+
+		client, store, vID := newVault(t, edv.URL)
+
+		require.NoError(t, client.DeleteVault(vID))
+		require.Equal(t, []string{"/eVaultID/documents/eDocID"}, deleted)
+
+		for _, key := range []string{
+			"info_" + vID, "meta_doc_info_" + vID + "_docID", "doc_index_" + vID + "_eDocID",
+			"authorization_" + vID + "_authID",
+		} {
+			_, err := store.Get(key)
+			require.ErrorIs(t, err, storage.ErrDataNotFound, key)
+		}
+
+		_, err := store.Get("info_did:example:other")
+		require.NoError(t, err)
+
+		_, err = client.GetDocMetadata(vID, "docID")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "get vault info: get: data not found")
+
+		require.ErrorIs(t, client.DeleteVault(vID), vault.ErrVaultNotFound)
+	})
+
+	t.Run("Documents already deleted", func(t *testing.T) {
+		edv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+
+			_, err := w.Write([]byte(messages.ErrDocumentNotFound.Error()))
+			require.NoError(t, err)
+		}))
+		defer edv.Close()
+
+		client, _, vID := newVault(t, edv.URL)
+
+		require.NoError(t, client.DeleteVault(vID))
+		require.ErrorIs(t, client.DeleteVault(vID), vault.ErrVaultNotFound)
+	})
+
+	t.Run("EDV error", func(t *testing.T) {
+		edv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer edv.Close()
+
+		client, store, vID := newVault(t, edv.URL)
+
+		err := client.DeleteVault(vID)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "delete document")
+
+		// the vault is left as it was, so that the deletion can be retried
+		_, err = store.Get("info_" + vID)
+		require.NoError(t, err)
+	})
+
+	t.Run("Store error", func(t *testing.T) {
+		client, err := vault.NewClient("", "", nil, &mockstorage.MockStoreProvider{
+			Store: &mockstorage.MockStore{ErrGet: errors.New("get error")},
+		}, loader)
+		require.NoError(t, err)
+
+		err = client.DeleteVault("vID")
+		require.EqualError(t, err, "get vault info: get: get error")
+	})
+}
+
+func TestClient_QueryDocs(t *testing.T) {
+	loader := testutil.DocumentLoader(t)
+
+	kmsHandlers := make(chan func(w http.ResponseWriter, r *http.Request), 3)
+	kmsHandlers <- func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+
+		_, err := w.Write([]byte(`{"key_url":"/v1/keystores/c0ekinlioud42c84qs7g/keys/GKszTDQcWrFlMS-BO7-asfNgaFfMZ96t6eeTjI__Y1c"}`)) //nolint:lll
+		require.NoError(t, err)
+	}
+
+	kmsHandlers <- func(w http.ResponseWriter, _ *http.Request) {
+		payload, err := json.Marshal(map[string][]byte{"public_key": []byte(`{"kid":"GKszTDQcWrFlMS-BO7-asfNgaFfMZ96t6eeTjI__Y1c","x":"IM1/HfveJ4rbqAYzBOmVOnpys4h3J0yA3I238AjYzZc=","y":"S+h2S7IbWCZiQjOaNIhSvyqNcRnRKavdiC1BU8F2UU4=","curve":"NIST_P256","type":"EC"}`)}) // nolint: lll
+		require.NoError(t, err)
+
+		w.WriteHeader(http.StatusOK)
+
+		_, err = w.Write(payload)
+		require.NoError(t, err)
+	}
+
+	kmsHandlers <- func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+
+		_, err := w.Write([]byte(kmsResponse))
+		require.NoError(t, err)
+	}
+
+	remoteKMS := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case fn := <-kmsHandlers:
+			fn(w, r)
+		default:
+			t.Error("no handler")
+		}
+	}))
+
+	var (
+		saved models.EncryptedDocument
+		query models.Query
+	)
+
+	edv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/query") {
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&query))
+
+			_, err := w.Write([]byte(`["https://edv.example.com/encrypted-data-vaults/vID/documents/` + saved.ID + `"]`))
+			require.NoError(t, err)
+
+			return
+		}
+
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&saved))
+
+		w.Header().Set("Location", "localhost:7777/encrypted-data-vaults/vID/documents/"+saved.ID)
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	data := map[string]mockstorage.DBEntry{}
+
+	store := &mockstorage.MockStoreProvider{
+		Store: &mockstorage.MockStore{Store: data},
+	}
+
+	lKMS := newLocalKms(t, store)
+	client, err := vault.NewClient(remoteKMS.URL, edv.URL, lKMS, store, loader)
+	require.NoError(t, err)
+
+	vID, dURL, kid := createVaultID(t, lKMS)
+
+	data["info_"+vID] = mockstorage.DBEntry{
+		Value: []byte(`{"kid":"` + kid + `","did_url":"` + dURL +
+			`","auth":{"edv":{"uri":"/encrypted-data-vaults/vID"},"kms":{"uri":"/v1/keystores/c0ekinlioud42c84qs7g"}}}`),
+	}
+
+	_, err = client.SaveDoc(vID, "docID", []byte(`{"ssn":"123-45-6789"}`), "type:consent", "pending")
+	require.NoError(t, err)
+
+	require.Len(t, saved.IndexedAttributeCollections, 1)
+
+	attrs := saved.IndexedAttributeCollections[0].IndexedAttributes
+	require.Len(t, attrs, 2)
+	require.NotEqual(t, "type", attrs[0].Name)
+	require.NotEqual(t, "consent", attrs[0].Value)
+	require.NotEmpty(t, attrs[0].Value)
+	require.Empty(t, attrs[1].Value)
+
+	t.Run("Query by name and value", func(t *testing.T) {
+		docs, err := client.QueryDocs(vID, "type:consent")
+		require.NoError(t, err)
+		require.Len(t, docs, 1)
+		require.Equal(t, "docID", docs[0].ID)
+		require.Contains(t, docs[0].URI, saved.ID)
+		require.Equal(t, []map[string]string{{attrs[0].Name: attrs[0].Value}}, query.Equals)
+	})
+
+	t.Run("Query by name", func(t *testing.T) {
+		docs, err := client.QueryDocs(vID, "pending")
+		require.NoError(t, err)
+		require.Len(t, docs, 1)
+		require.Equal(t, attrs[1].Name, query.Has)
+	})
+
+	t.Run("Invalid tags", func(t *testing.T) {
+		_, err := client.QueryDocs(vID, ":consent")
+		require.EqualError(t, err, "tag name must be set")
+
+		_, err = client.SaveDoc(vID, "docID", []byte(`{}`), ":consent")
+		require.EqualError(t, err, `index tags: invalid tag ":consent": name must be set`)
+	})
+
+	t.Run("No vault info", func(t *testing.T) {
+		_, err := client.QueryDocs("unknown", "type:consent")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "get vault info")
 	})
 }
 
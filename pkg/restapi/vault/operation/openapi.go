@@ -49,7 +49,8 @@ type saveDocReq struct {
 type SaveDocRequestBody struct {
 	ID      string          `json:"id"`
 	Content json.RawMessage `json:"content"`
-	Tags    []string        `json:"tags"`
+	// Tags the document is indexed with, as name:value or name. They are blinded before they reach the EDV server.
+	Tags []string `json:"tags,omitempty"`
 }
 
 // saveDocResp model
@@ -78,6 +79,26 @@ type getDocMetadataResp struct {
 	Body *vault.DocumentMetadata
 }
 
+// queryDocsReq model
+//
+// swagger:parameters queryDocsReq
+type queryDocsReq struct { // nolint: unused,deadcode
+	// in: path
+	VaultID string `json:"vaultID"`
+	// Tag of the documents, as name:value, or name for any value.
+	// in: query
+	// required: true
+	Tag string `json:"tag"`
+}
+
+// queryDocsResp model
+//
+// swagger:response queryDocsResp
+type queryDocsResp struct {
+	// in: body
+	Body []*vault.DocumentMetadata
+}
+
 // createAuthorizationsReq model
 //
 // swagger:parameters createAuthorizationsReq
@@ -29,6 +29,7 @@ const (
 	CreateVaultPath         = operationID
 	DeleteVaultPath         = operationID + "/{vaultID}"
 	SaveDocPath             = operationID + "/{vaultID}/docs"
+	QueryDocsPath           = operationID + "/{vaultID}/docs"
 	GetDocMetadataPath      = operationID + "/{vaultID}/docs/{docID}/metadata"
 	CreateAuthorizationPath = operationID + "/{vaultID}/authorizations"
 	GetAuthorizationPath    = operationID + "/{vaultID}/authorizations/{authID}"
@@ -57,6 +58,7 @@ func (o *Operation) GetRESTHandlers() []handler.Handler {
 		handler.NewHTTPHandler(CreateVaultPath, http.MethodPost, o.CreateVault),
 		handler.NewHTTPHandler(DeleteVaultPath, http.MethodDelete, o.DeleteVault),
 		handler.NewHTTPHandler(SaveDocPath, http.MethodPost, o.SaveDoc),
+		handler.NewHTTPHandler(QueryDocsPath, http.MethodGet, o.QueryDocs),
 		handler.NewHTTPHandler(GetDocMetadataPath, http.MethodGet, o.GetDocMetadata),
 		handler.NewHTTPHandler(CreateAuthorizationPath, http.MethodPost, o.CreateAuthorization),
 		handler.NewHTTPHandler(GetAuthorizationPath, http.MethodGet, o.GetAuthorization),
@@ -92,13 +94,27 @@ func (o *Operation) CreateVault(rw http.ResponseWriter, _ *http.Request) {
 // Responses:
 //    default: genericError
 //        200: deleteVaultResp
-func (o *Operation) DeleteVault(rw http.ResponseWriter, _ *http.Request) {
+//        404: genericError
+func (o *Operation) DeleteVault(rw http.ResponseWriter, req *http.Request) {
+	err := o.vault.DeleteVault(mux.Vars(req)["vaultID"])
+	if errors.Is(err, vault.ErrVaultNotFound) {
+		o.writeErrorResponse(rw, err, http.StatusNotFound)
+
+		return
+	}
+
+	if err != nil {
+		o.writeErrorResponse(rw, err, http.StatusInternalServerError)
+
+		return
+	}
+
 	rw.WriteHeader(http.StatusOK)
 }
 
 // SaveDoc swagger:route POST /vaults/{vaultID}/docs vault saveDocReq
 //
-// Creates or updates a document by encrypting it and storing it in the vault.
+// Creates or updates a document by encrypting it and storing it in the vault, indexed with its tags.
 //
 // Responses:
 //    default: genericError
@@ -129,7 +145,7 @@ func (o *Operation) SaveDoc(rw http.ResponseWriter, req *http.Request) {
 		}
 	}
 
-	result, err := o.vault.SaveDoc(vaultID, docID, docContent)
+	result, err := o.vault.SaveDoc(vaultID, docID, docContent, doc.Request.Tags...)
 	if err != nil {
 		o.writeErrorResponse(rw, err, http.StatusInternalServerError)
 
@@ -142,6 +158,34 @@ func (o *Operation) SaveDoc(rw http.ResponseWriter, req *http.Request) {
 	o.WriteResponse(rw, resp.Body, http.StatusCreated)
 }
 
+// QueryDocs swagger:route GET /vaults/{vaultID}/docs vault queryDocsReq
+//
+// Returns the metadata of the documents saved with the tag.
+//
+// Responses:
+//    default: genericError
+//        200: queryDocsResp
+func (o *Operation) QueryDocs(rw http.ResponseWriter, req *http.Request) {
+	tag := req.URL.Query().Get("tag")
+	if tag == "" || strings.HasPrefix(tag, ":") {
+		o.writeErrorResponse(rw, errors.New("tag name must be set"), http.StatusBadRequest)
+
+		return
+	}
+
+	result, err := o.vault.QueryDocs(mux.Vars(req)["vaultID"], tag)
+	if err != nil {
+		o.writeErrorResponse(rw, err, http.StatusInternalServerError)
+
+		return
+	}
+
+	var resp queryDocsResp
+	resp.Body = result
+
+	o.WriteResponse(rw, resp.Body, http.StatusOK)
+}
+
 // GetDocMetadata swagger:route GET /vaults/{vaultID}/docs/{docID}/metadata vault getDocMetadataReq
 //
 // Returns the document`s metadata by given docID.
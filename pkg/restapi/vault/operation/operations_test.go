@@ -143,6 +143,73 @@ func TestSaveDoc(t *testing.T) {
 		require.NotEmpty(t, resp.ID)
 		require.NotEmpty(t, resp.URI)
 	})
+	t.Run("Success (tags)", func(t *testing.T) {
+		const path = "/vaults/vaultID1/docs"
+
+		v := newVaultMock()
+		operation := vaultoperation.New(v)
+
+		h := handlerLookup(t, operation, vaultoperation.SaveDocPath, http.MethodPost)
+		_, code := sendRequestToHandler(t, h, strings.NewReader(`{"tags":["type:consent","pending"]}`), path)
+
+		require.Equal(t, http.StatusCreated, code)
+		require.Equal(t, []string{"type:consent", "pending"}, v.savedTags)
+	})
+}
+
+func TestQueryDocs(t *testing.T) {
+	const path = "/vaults/vaultID1/docs"
+
+	t.Run("Success", func(t *testing.T) {
+		v := newVaultMock()
+		v.queryDocsFn = func(vaultID, tag string) ([]*vault.DocumentMetadata, error) {
+			require.Equal(t, "vaultID1", vaultID)
+			require.Equal(t, "type:consent", tag)
+
+			return []*vault.DocumentMetadata{{ID: "docID1"}}, nil
+		}
+
+		operation := vaultoperation.New(v)
+
+		h := handlerLookup(t, operation, vaultoperation.QueryDocsPath, http.MethodGet)
+
+		respBody, code := sendRequestToHandler(t, h, nil, path+"?tag=type:consent")
+
+		require.Equal(t, http.StatusOK, code)
+
+		var resp []*vault.DocumentMetadata
+
+		require.NoError(t, json.NewDecoder(respBody).Decode(&resp))
+		require.Equal(t, []*vault.DocumentMetadata{{ID: "docID1"}}, resp)
+	})
+
+	t.Run("Tag name not set", func(t *testing.T) {
+		operation := vaultoperation.New(newVaultMock())
+
+		h := handlerLookup(t, operation, vaultoperation.QueryDocsPath, http.MethodGet)
+
+		for _, p := range []string{path, path + "?tag=:consent"} {
+			respBody, code := sendRequestToHandler(t, h, nil, p)
+
+			require.Equal(t, http.StatusBadRequest, code)
+			require.Contains(t, respBody.String(), "tag name must be set")
+		}
+	})
+
+	t.Run("Internal error", func(t *testing.T) {
+		v := newVaultMock()
+		v.queryDocsFn = func(_, _ string) ([]*vault.DocumentMetadata, error) {
+			return nil, errors.New("test")
+		}
+
+		operation := vaultoperation.New(v)
+
+		h := handlerLookup(t, operation, vaultoperation.QueryDocsPath, http.MethodGet)
+
+		_, code := sendRequestToHandler(t, h, nil, path+"?tag=type")
+
+		require.Equal(t, http.StatusInternalServerError, code)
+	})
 }
 
 func TestGetDocMetadata(t *testing.T) {
@@ -331,12 +398,51 @@ func TestGetAuthorization(t *testing.T) {
 func TestDeleteVault(t *testing.T) {
 	const path = "/vaults/vaultID1"
 
-	operation := vaultoperation.New(newVaultMock())
+	t.Run("Delete vault", func(t *testing.T) {
+		var deleted string
 
-	h := handlerLookup(t, operation, vaultoperation.DeleteVaultPath, http.MethodDelete)
-	_, code := sendRequestToHandler(t, h, nil, path)
+		v := newVaultMock()
+		v.deleteVaultFn = func(vaultID string) error {
+			deleted = vaultID
 
-	require.Equal(t, http.StatusOK, code)
+			return nil
+		}
+
+		h := handlerLookup(t, vaultoperation.New(v), vaultoperation.DeleteVaultPath, http.MethodDelete)
+		_, code := sendRequestToHandler(t, h, nil, path)
+
+		require.Equal(t, http.StatusOK, code)
+		require.Equal(t, "vaultID1", deleted)
+	})
+
+	t.Run("Not found", func(t *testing.T) {
+		v := newVaultMock()
+		v.deleteVaultFn = func(string) error {
+			return vault.ErrVaultNotFound
+		}
+
+		h := handlerLookup(t, vaultoperation.New(v), vaultoperation.DeleteVaultPath, http.MethodDelete)
+		_, code := sendRequestToHandler(t, h, nil, path)
+
+		require.Equal(t, http.StatusNotFound, code)
+	})
+
+	t.Run("Internal error", func(t *testing.T) {
+		v := newVaultMock()
+		v.deleteVaultFn = func(string) error {
+			return errors.New("test")
+		}
+
+		h := handlerLookup(t, vaultoperation.New(v), vaultoperation.DeleteVaultPath, http.MethodDelete)
+		respBody, code := sendRequestToHandler(t, h, nil, path)
+
+		require.Equal(t, http.StatusInternalServerError, code)
+
+		var errResp *model.ErrorResponse
+
+		require.NoError(t, json.NewDecoder(respBody).Decode(&errResp))
+		require.Equal(t, "test", errResp.Message)
+	})
 }
 
 func TestWriteResponse(t *testing.T) {
@@ -433,6 +539,9 @@ func newVaultMock() *vaultMock {
 		getAuthorizationFn: func(vaultID, id string) (*vault.CreatedAuthorization, error) {
 			return &vault.CreatedAuthorization{ID: uuid.New().String()}, nil
 		},
+		deleteVaultFn: func(vaultID string) error {
+			return nil
+		},
 	}
 }
 
@@ -442,16 +551,29 @@ type vaultMock struct {
 	getDocMetadataFn      func(vaultID, docID string) (*vault.DocumentMetadata, error)
 	createAuthorizationFn func(vID, rp string, scope *vault.AuthorizationsScope) (*vault.CreatedAuthorization, error)
 	getAuthorizationFn    func(vaultID, id string) (*vault.CreatedAuthorization, error)
+	queryDocsFn           func(vaultID, tag string) ([]*vault.DocumentMetadata, error)
+	deleteVaultFn         func(vaultID string) error
+	savedTags             []string
+}
+
+func (v *vaultMock) DeleteVault(vaultID string) error {
+	return v.deleteVaultFn(vaultID)
 }
 
 func (v *vaultMock) CreateVault() (*vault.CreatedVault, error) {
 	return v.createVaultFn()
 }
 
-func (v *vaultMock) SaveDoc(vaultID, id string, content []byte) (*vault.DocumentMetadata, error) {
+func (v *vaultMock) SaveDoc(vaultID, id string, content []byte, tags ...string) (*vault.DocumentMetadata, error) {
+	v.savedTags = tags
+
 	return v.saveDocFn(vaultID, id, content)
 }
 
+func (v *vaultMock) QueryDocs(vaultID, tag string) ([]*vault.DocumentMetadata, error) {
+	return v.queryDocsFn(vaultID, tag)
+}
+
 func (v *vaultMock) GetDocMetadata(vaultID, docID string) (*vault.DocumentMetadata, error) {
 	return v.getDocMetadataFn(vaultID, docID)
 }
@@ -0,0 +1,111 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/trustbloc/ace/pkg/internal/awsutil"
+)
+
+const (
+	awsService       = "secretsmanager"
+	awsTarget        = "secretsmanager.GetSecretValue"
+	awsContentType   = "application/x-amz-json-1.1"
+	awsTargetHeader  = "X-Amz-Target"
+	awsDefaultRegion = "us-east-1"
+)
+
+// AWS reads the secrets of AWS Secrets Manager. The name of a secret is its name or ARN. Requests are signed with
+// Signature Version 4, with the access key if it is set, or else with the credentials of the IAM role of the ECS task
+// or of the EC2 instance.
+type AWS struct {
+	Region          string
+	AccessKeyID     string
+	SecretAccessKey string
+	// SessionToken is only set with temporary credentials.
+	SessionToken string
+	// Endpoint defaults to the endpoint of the region.
+	Endpoint string
+	// MetadataURL defaults to the URL of the instance metadata service.
+	MetadataURL string
+	HTTPClient  httpClient
+	// Now returns the time requests are signed at. Defaults to time.Now.
+	Now func() time.Time
+
+	once        sync.Once
+	credentials awsutil.CredentialsProvider
+}
+
+// Get returns the string of the secret, or its binary if it has no string.
+func (a *AWS) Get(ctx context.Context, name string) ([]byte, error) {
+	region := a.Region
+	if region == "" {
+		region = awsDefaultRegion
+	}
+
+	endpoint := a.Endpoint
+	if endpoint == "" {
+		endpoint = "https://" + awsService + "." + region + ".amazonaws.com"
+	}
+
+	body, err := json.Marshal(map[string]string{"SecretId": name})
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(endpoint, "/")+"/",
+		bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", awsContentType)
+	req.Header.Set(awsTargetHeader, awsTarget)
+
+	now := time.Now
+	if a.Now != nil {
+		now = a.Now
+	}
+
+	a.once.Do(func() {
+		a.credentials = awsutil.NewCredentials(&awsutil.Credentials{
+			AccessKeyID:     a.AccessKeyID,
+			SecretAccessKey: a.SecretAccessKey,
+			SessionToken:    a.SessionToken,
+		}, a.MetadataURL, a.HTTPClient, now)
+	})
+
+	credentials, err := a.credentials.Retrieve(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	awsutil.Sign(req, body, credentials, region, awsService, now())
+
+	var resp struct {
+		SecretString *string `json:"SecretString"`
+		SecretBinary []byte  `json:"SecretBinary"`
+	}
+
+	if err = send(a.HTTPClient, req, &resp); err != nil {
+		return nil, err
+	}
+
+	if resp.SecretString != nil {
+		return []byte(*resp.SecretString), nil
+	}
+
+	return resp.SecretBinary, nil
+}
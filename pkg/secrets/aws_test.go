@@ -0,0 +1,104 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package secrets_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/ace/pkg/secrets"
+)
+
+func TestAWS_Get(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		require.Equal(t, "secretsmanager.GetSecretValue", r.Header.Get("X-Amz-Target"))
+		require.Equal(t, "20220830T123600Z", r.Header.Get("X-Amz-Date"))
+		require.True(t, strings.HasPrefix(r.Header.Get("Authorization"),
+			"AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20220830/eu-west-1/secretsmanager/aws4_request, "+
+				"SignedHeaders=content-type;host;x-amz-date;x-amz-security-token;x-amz-target, Signature="))
+		require.Equal(t, "session", r.Header.Get("X-Amz-Security-Token"))
+
+		var req struct {
+			SecretID string `json:"SecretId"`
+		}
+
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+
+		switch req.SecretID {
+		case "ace/db":
+			_, _ = rw.Write([]byte(`{"Name":"ace/db","SecretString":"{\"url\":\"mongodb://mongodb:27017\"}"}`))
+		case "ace/key":
+			_, _ = rw.Write([]byte(`{"Name":"ace/key","SecretBinary":"bWFzdGVyIGtleQ=="}`))
+		default:
+			rw.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+
+	aws := &secrets.AWS{
+		Region:          "eu-west-1",
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+		SessionToken:    "session",
+		Endpoint:        srv.URL,
+		HTTPClient:      srv.Client(),
+		Now: func() time.Time {
+			return time.Date(2022, 8, 30, 12, 36, 0, 0, time.UTC)
+		},
+	}
+
+	r := secrets.NewResolver(secrets.WithBackend(secrets.AWSScheme, aws))
+
+	v, err := r.Resolve(ctx, "aws-sm://ace/db#url")
+	require.NoError(t, err)
+	require.Equal(t, "mongodb://mongodb:27017", v)
+
+	v, err = r.Resolve(ctx, "aws-sm://ace/key")
+	require.NoError(t, err)
+	require.Equal(t, "master key", v)
+
+	_, err = aws.Get(ctx, "ace/unknown")
+	require.EqualError(t, err, "unexpected response status 400")
+
+	t.Run("Role credentials", func(t *testing.T) {
+		t.Setenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI", "")
+		t.Setenv("AWS_CONTAINER_CREDENTIALS_FULL_URI", "")
+
+		metadata := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/latest/api/token":
+				_, _ = rw.Write([]byte("imds-token"))
+			case "/latest/meta-data/iam/security-credentials/":
+				_, _ = rw.Write([]byte("gatekeeper"))
+			default:
+				_, _ = rw.Write([]byte(`{"AccessKeyId":"AKIDEXAMPLE","SecretAccessKey":"secret","Token":"session",` +
+					`"Expiration":"2022-08-30T18:00:00Z"}`))
+			}
+		}))
+		defer metadata.Close()
+
+		v, err := (&secrets.AWS{
+			Region:      "eu-west-1",
+			Endpoint:    srv.URL,
+			MetadataURL: metadata.URL,
+			HTTPClient:  srv.Client(),
+			Now:         aws.Now,
+		}).Get(ctx, "ace/key")
+		require.NoError(t, err)
+		require.Equal(t, "master key", string(v))
+	})
+}
@@ -0,0 +1,122 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	gcpEndpoint = "https://secretmanager.googleapis.com"
+	// gcpMetadataTokenURL is the URL of the access tokens of the default service account on GCP compute instances.
+	gcpMetadataTokenURL = "http://metadata.google.internal/computeMetadata/v1" +
+		"/instance/service-accounts/default/token" //nolint:gosec
+	gcpLatestVersion = "/versions/latest"
+	// gcpRefreshWindow is the time before its expiration the access token is requested again.
+	gcpRefreshWindow = time.Minute
+)
+
+// GCP reads the secrets of GCP Secret Manager. The name of a secret is its resource name, e.g.
+// projects/ace/secrets/gatekeeper, optionally with a version. The latest version is read if the name has none.
+type GCP struct {
+	// Token is the OAuth 2.0 access token used to authenticate. If it is not set, a token of the default
+	// service account is requested from the metadata server of the compute instance, and cached until shortly
+	// before it expires.
+	Token string
+	// Endpoint defaults to the global endpoint of Secret Manager.
+	Endpoint string
+	// MetadataTokenURL defaults to the token URL of the metadata server.
+	MetadataTokenURL string
+	HTTPClient       httpClient
+
+	mu          sync.Mutex
+	cachedToken string
+	expiration  time.Time
+}
+
+// Get returns the payload of the secret version.
+func (g *GCP) Get(ctx context.Context, name string) ([]byte, error) {
+	token, err := g.token(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := g.Endpoint
+	if endpoint == "" {
+		endpoint = gcpEndpoint
+	}
+
+	name = strings.Trim(name, "/")
+
+	if !strings.Contains(name, "/versions/") {
+		name += gcpLatestVersion
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		strings.TrimSuffix(endpoint, "/")+"/v1/"+name+":access", http.NoBody)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	var resp struct {
+		Payload struct {
+			Data []byte `json:"data"`
+		} `json:"payload"`
+	}
+
+	if err = send(g.HTTPClient, req, &resp); err != nil {
+		return nil, err
+	}
+
+	return resp.Payload.Data, nil
+}
+
+func (g *GCP) token(ctx context.Context) (string, error) {
+	if g.Token != "" {
+		return g.Token, nil
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.cachedToken != "" && time.Now().Before(g.expiration.Add(-gcpRefreshWindow)) {
+		return g.cachedToken, nil
+	}
+
+	tokenURL := g.MetadataTokenURL
+	if tokenURL == "" {
+		tokenURL = gcpMetadataTokenURL
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, tokenURL, http.NoBody)
+	if err != nil {
+		return "", fmt.Errorf("create token request: %w", err)
+	}
+
+	req.Header.Set("Metadata-Flavor", "Google")
+
+	var resp struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+
+	if err = send(g.HTTPClient, req, &resp); err != nil {
+		return "", fmt.Errorf("get access token: %w", err)
+	}
+
+	g.cachedToken = resp.AccessToken
+	g.expiration = time.Now().Add(time.Duration(resp.ExpiresIn) * time.Second)
+
+	return g.cachedToken, nil
+}
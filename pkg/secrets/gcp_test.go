@@ -0,0 +1,81 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package secrets_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/ace/pkg/secrets"
+)
+
+func TestGCP_Get(t *testing.T) {
+	var tokens int
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(rw http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "Google", r.Header.Get("Metadata-Flavor"))
+
+		tokens++
+
+		_, _ = rw.Write([]byte(`{"access_token":"metadata-token","expires_in":3599,"token_type":"Bearer"}`))
+	})
+	mux.HandleFunc("/v1/", func(rw http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer metadata-token" &&
+			r.Header.Get("Authorization") != "Bearer token" {
+			rw.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		switch r.URL.Path {
+		case "/v1/projects/ace/secrets/key/versions/latest:access", "/v1/projects/ace/secrets/key/versions/2:access":
+			_, _ = rw.Write([]byte(`{"name":"projects/ace/secrets/key/versions/2","payload":{"data":"bWFzdGVyIGtleQ=="}}`))
+		default:
+			rw.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	ctx := context.Background()
+
+	gcp := &secrets.GCP{Endpoint: srv.URL, MetadataTokenURL: srv.URL + "/token", HTTPClient: srv.Client()}
+
+	r := secrets.NewResolver(secrets.WithBackend(secrets.GCPScheme, gcp))
+
+	for _, ref := range []string{"gcp-sm://projects/ace/secrets/key", "gcp-sm://projects/ace/secrets/key/versions/2"} {
+		v, err := r.Resolve(ctx, ref)
+		require.NoError(t, err)
+		require.Equal(t, "master key", v)
+	}
+
+	require.Equal(t, 1, tokens)
+
+	t.Run("Static token", func(t *testing.T) {
+		v, err := (&secrets.GCP{Token: "token", Endpoint: srv.URL, HTTPClient: srv.Client()}).
+			Get(ctx, "projects/ace/secrets/key")
+		require.NoError(t, err)
+		require.Equal(t, "master key", string(v))
+	})
+
+	t.Run("Secret not found", func(t *testing.T) {
+		_, err := gcp.Get(ctx, "projects/ace/secrets/unknown")
+		require.EqualError(t, err, "unexpected response status 404")
+	})
+
+	t.Run("Fail to get access token", func(t *testing.T) {
+		_, err := (&secrets.GCP{Endpoint: srv.URL, MetadataTokenURL: srv.URL + "/none", HTTPClient: srv.Client()}).
+			Get(ctx, "projects/ace/secrets/key")
+		require.EqualError(t, err, "get access token: unexpected response status 404")
+	})
+}
@@ -0,0 +1,148 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package secrets resolves references to secrets stored in HashiCorp Vault, AWS Secrets Manager or GCP Secret
+// Manager, so that master keys, credentials and TLS material do not have to be set in plain environment variables.
+//
+// A reference is the scheme of the backend followed by the name of the secret in the backend and, for secrets that
+// are JSON objects, the key of the value in the object:
+//
+//	vault://secret/data/gatekeeper#database_url
+//	aws-sm://prod/gatekeeper#database_url
+//	gcp-sm://projects/ace/secrets/gatekeeper-tls-key
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Schemes of the secret references.
+const (
+	// VaultScheme is the scheme of the secrets stored in the KV secrets engine of HashiCorp Vault.
+	VaultScheme = "vault"
+	// AWSScheme is the scheme of the secrets stored in AWS Secrets Manager.
+	AWSScheme = "aws-sm"
+	// GCPScheme is the scheme of the secrets stored in GCP Secret Manager.
+	GCPScheme = "gcp-sm"
+
+	schemeSeparator = "://"
+	keySeparator    = "#"
+)
+
+// ErrNoBackend is returned when resolving a reference to a backend that is not configured.
+var ErrNoBackend = errors.New("secrets backend not configured")
+
+// Backend reads secrets by name.
+type Backend interface {
+	Get(ctx context.Context, name string) ([]byte, error)
+}
+
+type httpClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// Resolver resolves secret references with the backends of their schemes.
+type Resolver struct {
+	backends map[string]Backend
+}
+
+// Option configures the Resolver.
+type Option func(r *Resolver)
+
+// WithBackend sets the backend of the references with the given scheme.
+func WithBackend(scheme string, b Backend) Option {
+	return func(r *Resolver) {
+		r.backends[scheme] = b
+	}
+}
+
+// NewResolver returns a new instance of Resolver.
+func NewResolver(opts ...Option) *Resolver {
+	r := &Resolver{backends: map[string]Backend{}}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
+}
+
+// IsReference tells if the value is a secret reference.
+func IsReference(value string) bool {
+	for _, scheme := range []string{VaultScheme, AWSScheme, GCPScheme} {
+		if strings.HasPrefix(value, scheme+schemeSeparator) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Resolve returns the value of the secret the reference refers to.
+func (r *Resolver) Resolve(ctx context.Context, ref string) (string, error) {
+	i := strings.Index(ref, schemeSeparator)
+	if i < 0 || len(ref) == i+len(schemeSeparator) {
+		return "", fmt.Errorf("invalid secret reference %q", ref)
+	}
+
+	scheme, name, key := ref[:i], ref[i+len(schemeSeparator):], ""
+
+	if i = strings.Index(name, keySeparator); i >= 0 {
+		name, key = name[:i], name[i+len(keySeparator):]
+	}
+
+	b, ok := r.backends[scheme]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrNoBackend, scheme)
+	}
+
+	secret, err := b.Get(ctx, name)
+	if err != nil {
+		return "", fmt.Errorf("get secret %s: %w", name, err)
+	}
+
+	if key == "" {
+		return string(secret), nil
+	}
+
+	var values map[string]interface{}
+
+	if err = json.Unmarshal(secret, &values); err != nil {
+		return "", fmt.Errorf("secret %s is not a JSON object: %w", name, err)
+	}
+
+	v, ok := values[key].(string)
+	if !ok {
+		return "", fmt.Errorf("secret %s has no %s string value", name, key)
+	}
+
+	return v, nil
+}
+
+// send sends the request and decodes its successful response into result.
+func send(client httpClient, req *http.Request, result interface{}) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("send request: %w", err)
+	}
+
+	defer resp.Body.Close() // nolint: errcheck
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected response status %d", resp.StatusCode)
+	}
+
+	if err = json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+
+	return nil
+}
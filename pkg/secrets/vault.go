@@ -0,0 +1,59 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const vaultTokenHeader = "X-Vault-Token" //nolint:gosec
+
+// Vault reads the secrets of the KV secrets engine of HashiCorp Vault. The name of a secret is its path in the Vault
+// API, e.g. secret/data/gatekeeper for version 2 of the engine mounted at secret. Secrets are JSON objects: the key
+// of the value must be set in the references.
+type Vault struct {
+	// Address of the Vault server, e.g. https://vault.example.com:8200.
+	Address string
+	// Token used to authenticate to Vault.
+	Token      string
+	HTTPClient httpClient
+}
+
+// Get returns the data of the secret as a JSON object.
+func (v *Vault) Get(ctx context.Context, name string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		strings.TrimSuffix(v.Address, "/")+"/v1/"+strings.TrimPrefix(name, "/"), http.NoBody)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+
+	req.Header.Set(vaultTokenHeader, v.Token)
+
+	var resp struct {
+		Data json.RawMessage `json:"data"`
+	}
+
+	if err = send(v.HTTPClient, req, &resp); err != nil {
+		return nil, err
+	}
+
+	// version 2 of the engine nests the data of the secret with its metadata
+	var versioned struct {
+		Data     json.RawMessage `json:"data"`
+		Metadata json.RawMessage `json:"metadata"`
+	}
+
+	if json.Unmarshal(resp.Data, &versioned) == nil && versioned.Metadata != nil {
+		return versioned.Data, nil
+	}
+
+	return resp.Data, nil
+}
@@ -0,0 +1,74 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package secrets_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/ace/pkg/secrets"
+)
+
+func TestVault_Get(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "token" {
+			rw.WriteHeader(http.StatusForbidden)
+
+			return
+		}
+
+		switch r.URL.Path {
+		case "/v1/secret/data/ace":
+			_, _ = rw.Write([]byte(`{"data":{"data":{"key":"v2"},"metadata":{"version":3}}}`))
+		case "/v1/kv/ace":
+			_, _ = rw.Write([]byte(`{"data":{"key":"v1"}}`))
+		case "/v1/kv/invalid":
+			_, _ = rw.Write([]byte(`{"data":`))
+		default:
+			rw.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	vault := &secrets.Vault{Address: srv.URL + "/", Token: "token", HTTPClient: srv.Client()}
+	r := secrets.NewResolver(secrets.WithBackend(secrets.VaultScheme, vault))
+
+	v, err := r.Resolve(ctx, "vault://secret/data/ace#key")
+	require.NoError(t, err)
+	require.Equal(t, "v2", v)
+
+	v, err = r.Resolve(ctx, "vault://kv/ace#key")
+	require.NoError(t, err)
+	require.Equal(t, "v1", v)
+
+	t.Run("Secret not found", func(t *testing.T) {
+		_, e := vault.Get(ctx, "kv/unknown")
+		require.EqualError(t, e, "unexpected response status 404")
+	})
+
+	t.Run("Invalid response", func(t *testing.T) {
+		_, e := vault.Get(ctx, "kv/invalid")
+		require.Error(t, e)
+		require.Contains(t, e.Error(), "decode response")
+	})
+
+	t.Run("Invalid token", func(t *testing.T) {
+		_, e := (&secrets.Vault{Address: srv.URL, HTTPClient: srv.Client()}).Get(ctx, "kv/ace")
+		require.EqualError(t, e, "unexpected response status 403")
+	})
+
+	t.Run("Vault unavailable", func(t *testing.T) {
+		_, e := (&secrets.Vault{Address: "http://localhost:0", HTTPClient: srv.Client()}).Get(ctx, "kv/ace")
+		require.Error(t, e)
+		require.Contains(t, e.Error(), "send request")
+	})
+}
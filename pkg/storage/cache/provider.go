@@ -0,0 +1,296 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package cache
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hyperledger/aries-framework-go/pkg/common/log"
+	"github.com/hyperledger/aries-framework-go/spi/storage"
+
+	"github.com/trustbloc/ace/pkg/storage/conditional"
+)
+
+const (
+	// DefaultTTL is the default time to live of cached values.
+	DefaultTTL = 5 * time.Minute
+	// leaseTTL is the time a read filling the cache has to read the value from the underlying store.
+	leaseTTL = 30 * time.Second
+	// leasePrefix starts the placeholders of the values being read, told apart from the values of the stores.
+	leasePrefix = "\x00cache-lease:"
+)
+
+var logger = log.New("storage-cache")
+
+// ErrCacheMiss is returned by a Cache when there is no value for a key.
+var ErrCacheMiss = errors.New("cache miss")
+
+// Cache is a key-value cache with expiring entries.
+type Cache interface {
+	Get(key string) ([]byte, error)
+	// Add stores value under key, expiring after ttl, unless the key is set. It tells if the value is stored.
+	Add(key string, value []byte, ttl time.Duration) (bool, error)
+	// Replace stores value under key, expiring after ttl, if the key holds old. It tells if the value is stored.
+	Replace(key string, old, value []byte, ttl time.Duration) (bool, error)
+	Delete(keys ...string) error
+}
+
+// Option configures the caching provider.
+type Option func(p *Provider)
+
+// WithTTL sets the time to live of cached values. Defaults to DefaultTTL.
+func WithTTL(ttl time.Duration) Option {
+	return func(p *Provider) {
+		p.ttl = ttl
+	}
+}
+
+// WithStores limits caching to the stores with the given names. Defaults to caching all stores.
+func WithStores(names ...string) Option {
+	return func(p *Provider) {
+		p.stores = make(map[string]struct{}, len(names))
+
+		for _, name := range names {
+			p.stores[strings.ToLower(name)] = struct{}{}
+		}
+	}
+}
+
+// WithKeyPrefix sets a prefix for the cache keys, allowing several deployments to share a cache.
+func WithKeyPrefix(prefix string) Option {
+	return func(p *Provider) {
+		p.keyPrefix = prefix
+	}
+}
+
+// Provider fronts a storage.Provider with a cache. Reads are served from the cache when possible and fill it on a
+// miss, and writes go to the underlying provider before evicting the cached values. A read fills the cache through a
+// lease on the key, which writes revoke, so that a value read before a write is never cached after it. Cache
+// failures are logged and never fail an operation, the underlying provider is the source of truth.
+type Provider struct {
+	storage.Provider
+	cache     Cache
+	ttl       time.Duration
+	stores    map[string]struct{}
+	keyPrefix string
+}
+
+// NewProvider returns a provider caching the stores of primary in cache.
+func NewProvider(primary storage.Provider, cache Cache, opts ...Option) *Provider {
+	p := &Provider{
+		Provider: primary,
+		cache:    cache,
+		ttl:      DefaultTTL,
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p
+}
+
+// OpenStore opens the store in the underlying provider and wraps it with the cache if caching is enabled for it.
+func (p *Provider) OpenStore(name string) (storage.Store, error) {
+	s, err := p.Provider.OpenStore(name)
+	if err != nil {
+		return nil, err
+	}
+
+	name = strings.ToLower(name)
+
+	if p.stores != nil {
+		if _, cached := p.stores[name]; !cached {
+			return s, nil
+		}
+	}
+
+	cached := &store{
+		Store:     s,
+		cache:     p.cache,
+		ttl:       p.ttl,
+		keyPrefix: p.keyPrefix + name + ":",
+	}
+
+	if putter, ok := s.(conditional.Putter); ok {
+		return &conditionalStore{store: cached, putter: putter}, nil
+	}
+
+	return cached, nil
+}
+
+// store is a read-through cache in front of a storage.Store, invalidated on write.
+type store struct {
+	storage.Store
+	cache     Cache
+	ttl       time.Duration
+	keyPrefix string
+}
+
+// Put stores the value in the underlying store and then evicts it from the cache.
+func (s *store) Put(key string, value []byte, tags ...storage.Tag) error {
+	err := s.Store.Put(key, value, tags...)
+	if err != nil {
+		return err
+	}
+
+	s.evict(key)
+
+	return nil
+}
+
+// Get returns the cached value for key, falling back to the underlying store on a cache miss. The value read is
+// cached unless the key was written while it was read.
+func (s *store) Get(key string) ([]byte, error) {
+	if key == "" {
+		return nil, errors.New("key cannot be empty")
+	}
+
+	value, err := s.cache.Get(s.keyPrefix + key)
+	if err == nil && !bytes.HasPrefix(value, []byte(leasePrefix)) {
+		return value, nil
+	}
+
+	if err != nil && !errors.Is(err, ErrCacheMiss) {
+		logger.Warnf("failed to read %s from cache: %s", key, err)
+	}
+
+	var lease []byte
+
+	// the value is already being read when the key holds a lease
+	if errors.Is(err, ErrCacheMiss) {
+		lease = s.lease(key)
+	}
+
+	value, err = s.Store.Get(key)
+	if err != nil {
+		if lease != nil {
+			s.evict(key)
+		}
+
+		return nil, err
+	}
+
+	if lease != nil {
+		s.fill(key, lease, value)
+	}
+
+	return value, nil
+}
+
+// GetBulk returns the values for keys, reading through the cache. Missing values are returned as nil.
+func (s *store) GetBulk(keys ...string) ([][]byte, error) {
+	values := make([][]byte, len(keys))
+
+	for i, key := range keys {
+		value, err := s.Get(key)
+		if err != nil && !errors.Is(err, storage.ErrDataNotFound) {
+			return nil, err
+		}
+
+		values[i] = value
+	}
+
+	return values, nil
+}
+
+// Delete deletes the value from the underlying store and evicts it from the cache.
+func (s *store) Delete(key string) error {
+	err := s.Store.Delete(key)
+	if err != nil {
+		return err
+	}
+
+	s.evict(key)
+
+	return nil
+}
+
+// Batch performs the operations on the underlying store and evicts the affected keys from the cache.
+func (s *store) Batch(operations []storage.Operation) error {
+	err := s.Store.Batch(operations)
+
+	keys := make([]string, len(operations))
+
+	for i, op := range operations {
+		keys[i] = op.Key
+	}
+
+	// evict even on failure since the batch may have been partially applied
+	s.evict(keys...)
+
+	if err != nil {
+		return fmt.Errorf("failed to perform batch: %w", err)
+	}
+
+	return nil
+}
+
+// conditionalStore is the cache in front of a store writing conditionally.
+type conditionalStore struct {
+	*store
+	putter conditional.Putter
+}
+
+// PutIf stores the value in the underlying store if the entry meets the condition, and then evicts it from the cache.
+func (s *conditionalStore) PutIf(key string, value []byte, condition storage.Tag, tags ...storage.Tag) error {
+	err := s.putter.PutIf(key, value, condition, tags...)
+	if err != nil {
+		return err
+	}
+
+	s.evict(key)
+
+	return nil
+}
+
+// lease takes a lease on the key to fill the cache with its value, or returns nil if the key is already set.
+func (s *store) lease(key string) []byte {
+	lease := []byte(leasePrefix + uuid.New().String())
+
+	ok, err := s.cache.Add(s.keyPrefix+key, lease, leaseTTL)
+	if err != nil {
+		logger.Warnf("failed to lease %s in cache: %s", key, err)
+
+		return nil
+	}
+
+	if !ok {
+		return nil
+	}
+
+	return lease
+}
+
+// fill caches the value if the key still holds the lease, that is if it was not written since the lease was taken.
+func (s *store) fill(key string, lease, value []byte) {
+	if _, err := s.cache.Replace(s.keyPrefix+key, lease, value, s.ttl); err != nil {
+		logger.Warnf("failed to cache %s: %s", key, err)
+	}
+}
+
+func (s *store) evict(keys ...string) {
+	if len(keys) == 0 {
+		return
+	}
+
+	cacheKeys := make([]string, len(keys))
+
+	for i, key := range keys {
+		cacheKeys[i] = s.keyPrefix + key
+	}
+
+	err := s.cache.Delete(cacheKeys...)
+	if err != nil {
+		logger.Warnf("failed to evict %s from cache: %s", strings.Join(keys, ", "), err)
+	}
+}
@@ -0,0 +1,387 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package cache_test
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/aries-framework-go/component/storageutil/mem"
+	"github.com/hyperledger/aries-framework-go/spi/storage"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/ace/pkg/storage/cache"
+	"github.com/trustbloc/ace/pkg/storage/conditional"
+)
+
+func TestProvider_OpenStore(t *testing.T) {
+	t.Run("caches only the configured stores", func(t *testing.T) {
+		c := newMockCache()
+		p := cache.NewProvider(mem.NewProvider(), c, cache.WithStores("Policy"))
+
+		cached, err := p.OpenStore("policy")
+		require.NoError(t, err)
+
+		uncached, err := p.OpenStore("config")
+		require.NoError(t, err)
+
+		require.NoError(t, cached.Put("key", []byte("value")))
+		require.NoError(t, uncached.Put("key", []byte("value")))
+
+		_, err = cached.Get("key")
+		require.NoError(t, err)
+
+		_, err = uncached.Get("key")
+		require.NoError(t, err)
+
+		require.Equal(t, map[string][]byte{"policy:key": []byte("value")}, c.values)
+	})
+
+	t.Run("caches all stores by default", func(t *testing.T) {
+		c := newMockCache()
+		p := cache.NewProvider(mem.NewProvider(), c, cache.WithKeyPrefix("gk:"))
+
+		s, err := p.OpenStore("config")
+		require.NoError(t, err)
+
+		require.NoError(t, s.Put("key", []byte("value")))
+
+		_, err = s.Get("key")
+		require.NoError(t, err)
+		require.Contains(t, c.values, "gk:config:key")
+	})
+
+	t.Run("error if store cannot be opened", func(t *testing.T) {
+		p := cache.NewProvider(mem.NewProvider(), newMockCache())
+
+		_, err := p.OpenStore("")
+		require.Error(t, err)
+	})
+}
+
+func TestStore(t *testing.T) {
+	t.Run("caches read values with ttl", func(t *testing.T) {
+		c := newMockCache()
+		s := openStore(t, c, cache.WithTTL(time.Minute))
+
+		require.NoError(t, s.Put("key", []byte("value")))
+		require.Empty(t, c.values)
+
+		value, err := s.Get("key")
+		require.NoError(t, err)
+		require.Equal(t, []byte("value"), value)
+		require.Equal(t, []byte("value"), c.values["test:key"])
+		require.Equal(t, time.Minute, c.ttls["test:key"])
+
+		value, err = s.Get("key")
+		require.NoError(t, err)
+		require.Equal(t, []byte("value"), value)
+		require.Equal(t, 1, c.hits)
+	})
+
+	t.Run("invalidates written keys", func(t *testing.T) {
+		c := newMockCache()
+		s := openStore(t, c)
+
+		require.NoError(t, s.Put("key", []byte("value")))
+
+		_, err := s.Get("key")
+		require.NoError(t, err)
+
+		require.NoError(t, s.Put("key", []byte("updated")))
+		require.Empty(t, c.values)
+
+		value, err := s.Get("key")
+		require.NoError(t, err)
+		require.Equal(t, []byte("updated"), value)
+	})
+
+	t.Run("invalidates keys written conditionally", func(t *testing.T) {
+		c := newMockCache()
+		s := openStoreWithProvider(t, conditional.NewLockingProvider(mem.NewProvider()), c)
+		condition, revision := storage.Tag{Name: "revision"}, storage.Tag{Name: "revision", Value: "1"}
+
+		require.True(t, conditional.Supported(s))
+		require.NoError(t, conditional.PutIf(s, "key", []byte("value"), condition, revision))
+
+		_, err := s.Get("key")
+		require.NoError(t, err)
+
+		require.ErrorIs(t, conditional.PutIf(s, "key", []byte("updated"), condition), conditional.ErrConflict)
+		require.NotEmpty(t, c.values)
+
+		require.NoError(t, conditional.PutIf(s, "key", []byte("updated"), revision))
+		require.Empty(t, c.values)
+
+		value, err := s.Get("key")
+		require.NoError(t, err)
+		require.Equal(t, []byte("updated"), value)
+
+		require.False(t, conditional.Supported(openStore(t, c)))
+	})
+
+	t.Run("does not cache a value written while it is read", func(t *testing.T) {
+		c := newMockCache()
+		primary := &writingProvider{Provider: mem.NewProvider()}
+		s := openStoreWithProvider(t, primary, c)
+
+		require.NoError(t, s.Put("key", []byte("value")))
+
+		// the key is written once the value is read from the underlying store, before the cache is filled
+		primary.write = func() {
+			primary.write = nil
+
+			require.NoError(t, s.Put("key", []byte("updated")))
+		}
+
+		value, err := s.Get("key")
+		require.NoError(t, err)
+		require.Equal(t, []byte("value"), value)
+		require.Empty(t, c.values)
+
+		value, err = s.Get("key")
+		require.NoError(t, err)
+		require.Equal(t, []byte("updated"), value)
+		require.Equal(t, []byte("updated"), c.values["test:key"])
+	})
+
+	t.Run("reads through while another read fills the cache", func(t *testing.T) {
+		c := newMockCache()
+		s := openStore(t, c)
+
+		require.NoError(t, s.Put("key", []byte("value")))
+
+		c.values["test:key"] = []byte("\x00cache-lease:other")
+
+		value, err := s.Get("key")
+		require.NoError(t, err)
+		require.Equal(t, []byte("value"), value)
+		require.Equal(t, []byte("\x00cache-lease:other"), c.values["test:key"])
+	})
+
+	t.Run("reads through on a cache miss", func(t *testing.T) {
+		c := newMockCache()
+		primary := mem.NewProvider()
+		s := openStoreWithProvider(t, primary, c)
+
+		underlying, err := primary.OpenStore("test")
+		require.NoError(t, err)
+		require.NoError(t, underlying.Put("key", []byte("value")))
+
+		value, err := s.Get("key")
+		require.NoError(t, err)
+		require.Equal(t, []byte("value"), value)
+		require.Equal(t, 0, c.hits)
+		require.Equal(t, []byte("value"), c.values["test:key"])
+
+		values, err := s.GetBulk("key", "missing")
+		require.NoError(t, err)
+		require.Equal(t, [][]byte{[]byte("value"), nil}, values)
+	})
+
+	t.Run("falls back to the underlying store if the cache fails", func(t *testing.T) {
+		c := newMockCache()
+		s := openStore(t, c)
+
+		c.err = errors.New("test")
+
+		require.NoError(t, s.Put("key", []byte("value")))
+
+		value, err := s.Get("key")
+		require.NoError(t, err)
+		require.Equal(t, []byte("value"), value)
+
+		require.NoError(t, s.Delete("key"))
+	})
+
+	t.Run("evicts deleted keys", func(t *testing.T) {
+		c := newMockCache()
+		s := openStore(t, c)
+
+		require.NoError(t, s.Put("key", []byte("value")))
+		require.NoError(t, s.Delete("key"))
+		require.Empty(t, c.values)
+
+		_, err := s.Get("key")
+		require.ErrorIs(t, err, storage.ErrDataNotFound)
+		require.Empty(t, c.values)
+	})
+
+	t.Run("evicts keys affected by a batch", func(t *testing.T) {
+		c := newMockCache()
+		s := openStore(t, c)
+
+		require.NoError(t, s.Put("k1", []byte("v1")))
+		require.NoError(t, s.Put("k2", []byte("v2")))
+
+		_, err := s.GetBulk("k1", "k2")
+		require.NoError(t, err)
+		require.Len(t, c.values, 2)
+
+		err = s.Batch([]storage.Operation{
+			{Key: "k1", Value: []byte("updated")},
+			{Key: "k2"},
+		})
+		require.NoError(t, err)
+		require.Empty(t, c.values)
+
+		value, err := s.Get("k1")
+		require.NoError(t, err)
+		require.Equal(t, []byte("updated"), value)
+
+		err = s.Batch(nil)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to perform batch")
+	})
+
+	t.Run("error if arguments are invalid", func(t *testing.T) {
+		s := openStore(t, newMockCache())
+
+		_, err := s.Get("")
+		require.EqualError(t, err, "key cannot be empty")
+
+		require.Error(t, s.Put("", []byte("value")))
+		require.Error(t, s.Delete(""))
+
+		_, err = s.GetBulk("")
+		require.Error(t, err)
+	})
+}
+
+func openStore(t *testing.T, c cache.Cache, opts ...cache.Option) storage.Store {
+	t.Helper()
+
+	return openStoreWithProvider(t, mem.NewProvider(), c, opts...)
+}
+
+func openStoreWithProvider(t *testing.T, primary storage.Provider, c cache.Cache,
+	opts ...cache.Option) storage.Store {
+	t.Helper()
+
+	s, err := cache.NewProvider(primary, c, opts...).OpenStore("test")
+	require.NoError(t, err)
+
+	return s
+}
+
+type mockCache struct {
+	mutex  sync.Mutex
+	values map[string][]byte
+	ttls   map[string]time.Duration
+	hits   int
+	err    error
+}
+
+func newMockCache() *mockCache {
+	return &mockCache{
+		values: make(map[string][]byte),
+		ttls:   make(map[string]time.Duration),
+	}
+}
+
+func (m *mockCache) Get(key string) ([]byte, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	value, ok := m.values[key]
+	if !ok {
+		return nil, cache.ErrCacheMiss
+	}
+
+	m.hits++
+
+	return value, nil
+}
+
+func (m *mockCache) Add(key string, value []byte, ttl time.Duration) (bool, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.err != nil {
+		return false, m.err
+	}
+
+	if _, ok := m.values[key]; ok {
+		return false, nil
+	}
+
+	m.values[key] = value
+	m.ttls[key] = ttl
+
+	return true, nil
+}
+
+func (m *mockCache) Replace(key string, old, value []byte, ttl time.Duration) (bool, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.err != nil {
+		return false, m.err
+	}
+
+	if current, ok := m.values[key]; !ok || !bytes.Equal(current, old) {
+		return false, nil
+	}
+
+	m.values[key] = value
+	m.ttls[key] = ttl
+
+	return true, nil
+}
+
+func (m *mockCache) Delete(keys ...string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.err != nil {
+		return m.err
+	}
+
+	for _, key := range keys {
+		delete(m.values, key)
+		delete(m.ttls, key)
+	}
+
+	return nil
+}
+
+// writingProvider opens stores calling write, if set, after a value is read.
+type writingProvider struct {
+	storage.Provider
+	write func()
+}
+
+func (p *writingProvider) OpenStore(name string) (storage.Store, error) {
+	s, err := p.Provider.OpenStore(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &writingStore{Store: s, provider: p}, nil
+}
+
+type writingStore struct {
+	storage.Store
+	provider *writingProvider
+}
+
+func (s *writingStore) Get(key string) ([]byte, error) {
+	value, err := s.Store.Get(key)
+
+	if write := s.provider.write; write != nil {
+		write()
+	}
+
+	return value, err
+}
@@ -0,0 +1,58 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package conditional writes the entries of stores conditionally on a tag of the entry they replace, so that
+// instances sharing a store can check and write an entry atomically, e.g. the revision of a ticket.
+package conditional
+
+import (
+	"errors"
+
+	"github.com/hyperledger/aries-framework-go/spi/storage"
+)
+
+// ErrConflict is returned when the entry doesn't meet the condition it is put on.
+var ErrConflict = errors.New("entry does not meet the condition")
+
+// ErrNotSupported is returned when putting an entry conditionally in a store that doesn't write conditionally.
+var ErrNotSupported = errors.New("store does not write conditionally")
+
+// Putter is a store writing entries conditionally.
+type Putter interface {
+	// PutIf stores the value and tags under key if the entry stored under key has the tag named condition.Name with
+	// condition.Value, or, if condition.Value is empty, if there is no entry under key or it doesn't have the tag.
+	// It returns an error wrapping ErrConflict otherwise, and nothing is stored.
+	PutIf(key string, value []byte, condition storage.Tag, tags ...storage.Tag) error
+}
+
+// Supported tells if the store writes conditionally.
+func Supported(store storage.Store) bool {
+	_, ok := store.(Putter)
+
+	return ok
+}
+
+// PutIf puts the entry in the store conditionally, see Putter, or returns ErrNotSupported if the store doesn't write
+// conditionally.
+func PutIf(store storage.Store, key string, value []byte, condition storage.Tag, tags ...storage.Tag) error {
+	p, ok := store.(Putter)
+	if !ok {
+		return ErrNotSupported
+	}
+
+	return p.PutIf(key, value, condition, tags...)
+}
+
+// Met tells if the tags of an entry meet the condition, see Putter. Tags are nil for an absent entry.
+func Met(tags []storage.Tag, condition storage.Tag) bool {
+	for _, tag := range tags {
+		if tag.Name == condition.Name {
+			return condition.Value != "" && tag.Value == condition.Value
+		}
+	}
+
+	return condition.Value == ""
+}
@@ -0,0 +1,145 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package conditional_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/hyperledger/aries-framework-go/component/storageutil/mem"
+	mockstorage "github.com/hyperledger/aries-framework-go/pkg/mock/storage"
+	"github.com/hyperledger/aries-framework-go/spi/storage"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/ace/pkg/storage/conditional"
+)
+
+func revision(v string) storage.Tag {
+	return storage.Tag{Name: "revision", Value: v}
+}
+
+func TestMet(t *testing.T) {
+	tags := []storage.Tag{{Name: "status", Value: "NEW"}, revision("1")}
+
+	require.True(t, conditional.Met(tags, revision("1")))
+	require.False(t, conditional.Met(tags, revision("2")))
+	require.False(t, conditional.Met(tags, revision("")))
+	require.True(t, conditional.Met(tags[:1], revision("")))
+	require.True(t, conditional.Met(nil, revision("")))
+	require.False(t, conditional.Met(nil, revision("1")))
+}
+
+func TestPutIf(t *testing.T) {
+	t.Run("error if the store doesn't write conditionally", func(t *testing.T) {
+		s, err := mem.NewProvider().OpenStore("test")
+		require.NoError(t, err)
+
+		require.False(t, conditional.Supported(s))
+		require.ErrorIs(t, conditional.PutIf(s, "key", []byte("value"), revision("")), conditional.ErrNotSupported)
+	})
+}
+
+func TestLockingProvider(t *testing.T) {
+	t.Run("puts the entry if it meets the condition", func(t *testing.T) {
+		s := openLockingStore(t, conditional.NewLockingProvider(mem.NewProvider()))
+
+		require.True(t, conditional.Supported(s))
+		require.NoError(t, conditional.PutIf(s, "key", []byte("v1"), revision(""), revision("1")))
+		require.NoError(t, conditional.PutIf(s, "key", []byte("v2"), revision("1"), revision("2")))
+		require.ErrorIs(t, conditional.PutIf(s, "key", []byte("v3"), revision("1")), conditional.ErrConflict)
+		require.ErrorIs(t, conditional.PutIf(s, "key", []byte("v3"), revision("")), conditional.ErrConflict)
+
+		value, err := s.Get("key")
+		require.NoError(t, err)
+		require.Equal(t, []byte("v2"), value)
+
+		require.NoError(t, s.Batch([]storage.Operation{{Key: "key"}}))
+		require.NoError(t, conditional.PutIf(s, "key", []byte("v1"), revision("")))
+		require.NoError(t, s.Delete("key"))
+	})
+
+	t.Run("serializes the writes of the stores opened with the same name", func(t *testing.T) {
+		p := conditional.NewLockingProvider(mem.NewProvider())
+		stores := []storage.Store{openLockingStore(t, p), openLockingStore(t, p)}
+
+		const writes = 20
+
+		var (
+			wg        sync.WaitGroup
+			mu        sync.Mutex
+			conflicts int
+		)
+
+		for i := 0; i < writes; i++ {
+			wg.Add(1)
+
+			go func(s storage.Store) {
+				defer wg.Done()
+
+				err := conditional.PutIf(s, "key", []byte("value"), revision(""), revision("1"))
+				if errors.Is(err, conditional.ErrConflict) {
+					mu.Lock()
+					conflicts++
+					mu.Unlock()
+
+					return
+				}
+
+				require.NoError(t, err)
+			}(stores[i%len(stores)])
+		}
+
+		wg.Wait()
+
+		require.Equal(t, writes-1, conflicts)
+	})
+
+	t.Run("error if the tags cannot be read", func(t *testing.T) {
+		s := openLockingStore(t, conditional.NewLockingProvider(&failingProvider{Provider: mem.NewProvider()}))
+
+		err := conditional.PutIf(s, "key", []byte("value"), revision(""))
+		require.EqualError(t, err, "get tags of key: test")
+	})
+
+	t.Run("error if store cannot be opened", func(t *testing.T) {
+		p := conditional.NewLockingProvider(&mockstorage.MockStoreProvider{ErrOpenStoreHandle: errors.New("test")})
+
+		_, err := p.OpenStore("test")
+		require.Error(t, err)
+	})
+}
+
+func openLockingStore(t *testing.T, p storage.Provider) storage.Store {
+	t.Helper()
+
+	s, err := p.OpenStore("test")
+	require.NoError(t, err)
+
+	return s
+}
+
+type failingProvider struct {
+	storage.Provider
+}
+
+func (p *failingProvider) OpenStore(name string) (storage.Store, error) {
+	s, err := p.Provider.OpenStore(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &failingStore{Store: s}, nil
+}
+
+type failingStore struct {
+	storage.Store
+}
+
+func (s *failingStore) GetTags(string) ([]storage.Tag, error) {
+	return nil, errors.New("test")
+}
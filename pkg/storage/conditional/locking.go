@@ -0,0 +1,95 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package conditional
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/hyperledger/aries-framework-go/spi/storage"
+)
+
+// LockingProvider writes the stores of a storage.Provider conditionally by serializing their writes with a lock per
+// store. The lock is held by the process, so it is only meant for providers whose stores are not shared with another
+// process, such as the in-memory provider.
+type LockingProvider struct {
+	storage.Provider
+	mu    sync.Mutex
+	locks map[string]*sync.Mutex
+}
+
+// NewLockingProvider returns a provider writing the stores of provider conditionally.
+func NewLockingProvider(provider storage.Provider) *LockingProvider {
+	return &LockingProvider{Provider: provider, locks: make(map[string]*sync.Mutex)}
+}
+
+// OpenStore opens the store in the underlying provider and wraps it with its lock, shared by the stores opened with
+// the same name.
+func (p *LockingProvider) OpenStore(name string) (storage.Store, error) {
+	s, err := p.Provider.OpenStore(name)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	name = strings.ToLower(name)
+
+	lock, ok := p.locks[name]
+	if !ok {
+		lock = &sync.Mutex{}
+		p.locks[name] = lock
+	}
+
+	return &lockingStore{Store: s, mu: lock}, nil
+}
+
+type lockingStore struct {
+	storage.Store
+	mu *sync.Mutex
+}
+
+// PutIf checks the tags of the entry and stores the value under the lock of the store.
+func (s *lockingStore) PutIf(key string, value []byte, condition storage.Tag, tags ...storage.Tag) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, err := s.Store.GetTags(key)
+	if err != nil && !errors.Is(err, storage.ErrDataNotFound) {
+		return fmt.Errorf("get tags of %s: %w", key, err)
+	}
+
+	if !Met(current, condition) {
+		return fmt.Errorf("%w: %s of %s", ErrConflict, condition.Name, key)
+	}
+
+	return s.Store.Put(key, value, tags...)
+}
+
+func (s *lockingStore) Put(key string, value []byte, tags ...storage.Tag) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.Store.Put(key, value, tags...)
+}
+
+func (s *lockingStore) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.Store.Delete(key)
+}
+
+func (s *lockingStore) Batch(operations []storage.Operation) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.Store.Batch(operations)
+}
@@ -0,0 +1,113 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package conditional
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hyperledger/aries-framework-go/spi/storage"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	mongooptions "go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// duplicateKey is in the errors of the MongoDB store for an upsert inserting a key that is already stored. The store
+// formats the error of the driver into its own, so it can't be unwrapped.
+const duplicateKey = "duplicate key"
+
+type bulkWriter interface {
+	BulkWrite(models []mongo.WriteModel, opts ...*mongooptions.BulkWriteOptions) error
+}
+
+// MongoDBProvider writes the stores of the MongoDB storage provider conditionally, with an upsert of the entry
+// filtered on the tag of the condition: the entry isn't matched if it doesn't meet the condition, and inserting it
+// anew fails on its key.
+//
+// The store doesn't return the result of the write, so a key that is absent can't be told apart from a key that
+// meets the condition: an entry deleted while it is put on a condition with a value is stored anew.
+type MongoDBProvider struct {
+	storage.Provider
+}
+
+// NewMongoDBProvider returns a provider writing the stores of the MongoDB provider conditionally.
+func NewMongoDBProvider(provider storage.Provider) *MongoDBProvider {
+	return &MongoDBProvider{Provider: provider}
+}
+
+// OpenStore opens the store in the MongoDB provider and wraps it, if it is a MongoDB store.
+func (p *MongoDBProvider) OpenStore(name string) (storage.Store, error) {
+	s, err := p.Provider.OpenStore(name)
+	if err != nil {
+		return nil, err
+	}
+
+	w, ok := s.(bulkWriter)
+	if !ok {
+		return s, nil
+	}
+
+	return &mongoDBStore{Store: s, writer: w}, nil
+}
+
+type mongoDBStore struct {
+	storage.Store
+	writer bulkWriter
+}
+
+// PutIf replaces the entry under key if it meets the condition. The entry is stored in the format of the MongoDB
+// store, with the value as binary data, so that it is read back as it is put.
+func (s *mongoDBStore) PutIf(key string, value []byte, condition storage.Tag, tags ...storage.Tag) error {
+	if key == "" {
+		return errors.New("key cannot be empty")
+	}
+
+	if value == nil {
+		return errors.New("value cannot be nil")
+	}
+
+	tagMap := make(bson.M, len(tags))
+
+	for _, tag := range tags {
+		tagMap[tag.Name] = tagValue(tag.Value)
+	}
+
+	filter := bson.M{"_id": key}
+
+	if condition.Value == "" {
+		filter["tags."+condition.Name] = bson.M{"$exists": false}
+	} else {
+		filter["tags."+condition.Name] = tagValue(condition.Value)
+	}
+
+	model := mongo.NewReplaceOneModel().
+		SetFilter(filter).
+		SetReplacement(bson.M{"_id": key, "bin": value, "tags": tagMap}).
+		SetUpsert(true)
+
+	err := s.writer.BulkWrite([]mongo.WriteModel{model})
+	if err != nil && strings.Contains(err.Error(), duplicateKey) {
+		return fmt.Errorf("%w: %s of %s", ErrConflict, condition.Name, key)
+	}
+
+	if err != nil {
+		return fmt.Errorf("put %s: %w", key, err)
+	}
+
+	return nil
+}
+
+// tagValue returns the tag value as the MongoDB store stores it, as an integer if it is one, so that it can be sorted.
+func tagValue(value string) interface{} {
+	if i, err := strconv.Atoi(value); err == nil {
+		return i
+	}
+
+	return value
+}
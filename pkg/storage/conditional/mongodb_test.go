@@ -0,0 +1,117 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package conditional_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/hyperledger/aries-framework-go/component/storageutil/mem"
+	"github.com/hyperledger/aries-framework-go/spi/storage"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	mongooptions "go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/trustbloc/ace/pkg/storage/conditional"
+)
+
+func TestMongoDBProvider(t *testing.T) {
+	t.Run("upserts the entry filtered on the condition", func(t *testing.T) {
+		s, w := openMongoDBStore(t)
+
+		require.True(t, conditional.Supported(s))
+		require.NoError(t, conditional.PutIf(s, "key", []byte("value"), revision("1"),
+			revision("2"), storage.Tag{Name: "status", Value: "NEW"}))
+
+		require.Len(t, w.models, 1)
+
+		model, ok := w.models[0].(*mongo.ReplaceOneModel)
+		require.True(t, ok)
+		require.True(t, *model.Upsert)
+		require.Equal(t, bson.M{"_id": "key", "tags.revision": 1}, model.Filter)
+		require.Equal(t, bson.M{
+			"_id":  "key",
+			"bin":  []byte("value"),
+			"tags": bson.M{"revision": 2, "status": "NEW"},
+		}, model.Replacement)
+
+		require.NoError(t, conditional.PutIf(s, "key", []byte("value"), revision("")))
+
+		model, ok = w.models[1].(*mongo.ReplaceOneModel)
+		require.True(t, ok)
+		require.Equal(t, bson.M{"_id": "key", "tags.revision": bson.M{"$exists": false}}, model.Filter)
+	})
+
+	t.Run("conflict if the key is stored without meeting the condition", func(t *testing.T) {
+		s, w := openMongoDBStore(t)
+		w.err = errors.New("failed to perform batch operations after 4 attempts: duplicate key")
+
+		err := conditional.PutIf(s, "key", []byte("value"), revision("1"))
+		require.ErrorIs(t, err, conditional.ErrConflict)
+	})
+
+	t.Run("error if the write fails", func(t *testing.T) {
+		s, w := openMongoDBStore(t)
+		w.err = errors.New("test")
+
+		require.EqualError(t, conditional.PutIf(s, "key", []byte("value"), revision("1")), "put key: test")
+		require.EqualError(t, conditional.PutIf(s, "", []byte("value"), revision("1")), "key cannot be empty")
+		require.EqualError(t, conditional.PutIf(s, "key", nil, revision("1")), "value cannot be nil")
+	})
+
+	t.Run("other stores are not wrapped", func(t *testing.T) {
+		s, err := conditional.NewMongoDBProvider(mem.NewProvider()).OpenStore("test")
+		require.NoError(t, err)
+		require.False(t, conditional.Supported(s))
+	})
+}
+
+func openMongoDBStore(t *testing.T) (storage.Store, *bulkWriter) {
+	t.Helper()
+
+	w := &bulkWriter{}
+
+	s, err := conditional.NewMongoDBProvider(&bulkWriterProvider{Provider: mem.NewProvider(), writer: w}).
+		OpenStore("test")
+	require.NoError(t, err)
+
+	return s, w
+}
+
+type bulkWriterProvider struct {
+	storage.Provider
+	writer *bulkWriter
+}
+
+func (p *bulkWriterProvider) OpenStore(name string) (storage.Store, error) {
+	s, err := p.Provider.OpenStore(name)
+	if err != nil {
+		return nil, err
+	}
+
+	p.writer.Store = s
+
+	return p.writer, nil
+}
+
+// bulkWriter records the models of the bulk writes, like the store of the MongoDB provider.
+type bulkWriter struct {
+	storage.Store
+	models []mongo.WriteModel
+	err    error
+}
+
+func (w *bulkWriter) BulkWrite(models []mongo.WriteModel, _ ...*mongooptions.BulkWriteOptions) error {
+	if w.err != nil {
+		return w.err
+	}
+
+	w.models = append(w.models, models...)
+
+	return nil
+}
@@ -0,0 +1,105 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package cursor
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/hyperledger/aries-framework-go/spi/storage"
+)
+
+// DefaultPageSize is the default number of values returned by a page.
+const DefaultPageSize = 100
+
+// Cursor reads the results of a store query a page at a time, so that only one page is held in memory.
+type Cursor struct {
+	iter     storage.Iterator
+	pageSize int
+	done     bool
+}
+
+// New queries the store and returns a cursor over the results. If pageSize is not positive, DefaultPageSize is used.
+func New(store storage.Store, expression string, pageSize int) (*Cursor, error) {
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+
+	iter, err := store.Query(expression, storage.WithPageSize(pageSize))
+	if err != nil {
+		return nil, fmt.Errorf("query %s: %w", expression, err)
+	}
+
+	return &Cursor{iter: iter, pageSize: pageSize}, nil
+}
+
+// Next returns the values of the next page. It returns no values once all results have been read.
+func (c *Cursor) Next() ([][]byte, error) {
+	if c.done {
+		return nil, nil
+	}
+
+	var values [][]byte
+
+	for len(values) < c.pageSize {
+		ok, err := c.iter.Next()
+		if err != nil {
+			return nil, fmt.Errorf("next entry: %w", err)
+		}
+
+		if !ok {
+			c.done = true
+
+			break
+		}
+
+		v, err := c.iter.Value()
+		if err != nil {
+			return nil, fmt.Errorf("get value: %w", err)
+		}
+
+		values = append(values, v)
+	}
+
+	return values, nil
+}
+
+// Close releases the underlying iterator.
+func (c *Cursor) Close() error {
+	return c.iter.Close()
+}
+
+// ErrStop can be returned by the callback of Iterate to stop the iteration without an error.
+var ErrStop = errors.New("stop iteration")
+
+// Iterate calls fn with the values of every page of the cursor and closes it.
+func Iterate(c *Cursor, fn func(values [][]byte) error) (err error) {
+	defer func() {
+		if closeErr := c.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("close cursor: %w", closeErr)
+		}
+	}()
+
+	for {
+		values, nextErr := c.Next()
+		if nextErr != nil {
+			return nextErr
+		}
+
+		if len(values) == 0 {
+			return nil
+		}
+
+		if fnErr := fn(values); fnErr != nil {
+			if errors.Is(fnErr, ErrStop) {
+				return nil
+			}
+
+			return fnErr
+		}
+	}
+}
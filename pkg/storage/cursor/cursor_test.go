@@ -0,0 +1,158 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package cursor_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/aries-framework-go/component/storageutil/mem"
+	mockstorage "github.com/hyperledger/aries-framework-go/pkg/mock/storage"
+	"github.com/hyperledger/aries-framework-go/spi/storage"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/ace/pkg/storage/cursor"
+)
+
+func TestCursor(t *testing.T) {
+	t.Run("reads results a page at a time", func(t *testing.T) {
+		c, err := cursor.New(newStore(t, 5), "tag", 2)
+		require.NoError(t, err)
+
+		defer func() {
+			require.NoError(t, c.Close())
+		}()
+
+		var sizes []int
+
+		for {
+			values, err := c.Next()
+			require.NoError(t, err)
+
+			if len(values) == 0 {
+				break
+			}
+
+			sizes = append(sizes, len(values))
+		}
+
+		require.Equal(t, []int{2, 2, 1}, sizes)
+
+		values, err := c.Next()
+		require.NoError(t, err)
+		require.Empty(t, values)
+	})
+
+	t.Run("uses the default page size", func(t *testing.T) {
+		c, err := cursor.New(newStore(t, cursor.DefaultPageSize+1), "tag", 0)
+		require.NoError(t, err)
+
+		values, err := c.Next()
+		require.NoError(t, err)
+		require.Len(t, values, cursor.DefaultPageSize)
+	})
+
+	t.Run("error if query fails", func(t *testing.T) {
+		store := &mockstorage.MockStore{ErrQuery: errors.New("query error")}
+
+		_, err := cursor.New(store, "tag", 1)
+		require.EqualError(t, err, "query tag: query error")
+	})
+
+	t.Run("error if iteration fails", func(t *testing.T) {
+		store := &mockstorage.MockStore{
+			Store:   map[string]mockstorage.DBEntry{"key": {Tags: []storage.Tag{{Name: "tag"}}}},
+			ErrNext: errors.New("next error"),
+		}
+
+		c, err := cursor.New(store, "tag", 1)
+		require.NoError(t, err)
+
+		_, err = c.Next()
+		require.EqualError(t, err, "next entry: next error")
+
+		store.ErrNext = nil
+		store.ErrValue = errors.New("value error")
+
+		c, err = cursor.New(store, "tag", 1)
+		require.NoError(t, err)
+
+		_, err = c.Next()
+		require.EqualError(t, err, "get value: value error")
+	})
+}
+
+func TestIterate(t *testing.T) {
+	t.Run("calls fn for every page", func(t *testing.T) {
+		c, err := cursor.New(newStore(t, 5), "tag", 2)
+		require.NoError(t, err)
+
+		count := 0
+
+		err = cursor.Iterate(c, func(values [][]byte) error {
+			count += len(values)
+
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, 5, count)
+	})
+
+	t.Run("stops the iteration", func(t *testing.T) {
+		c, err := cursor.New(newStore(t, 5), "tag", 2)
+		require.NoError(t, err)
+
+		pages := 0
+
+		err = cursor.Iterate(c, func(values [][]byte) error {
+			pages++
+
+			return cursor.ErrStop
+		})
+		require.NoError(t, err)
+		require.Equal(t, 1, pages)
+	})
+
+	t.Run("returns the error of fn", func(t *testing.T) {
+		c, err := cursor.New(newStore(t, 1), "tag", 2)
+		require.NoError(t, err)
+
+		err = cursor.Iterate(c, func(values [][]byte) error {
+			return errors.New("fn error")
+		})
+		require.EqualError(t, err, "fn error")
+	})
+
+	t.Run("returns the error of the cursor", func(t *testing.T) {
+		store := &mockstorage.MockStore{
+			Store:   map[string]mockstorage.DBEntry{"key": {Tags: []storage.Tag{{Name: "tag"}}}},
+			ErrNext: errors.New("next error"),
+		}
+
+		c, err := cursor.New(store, "tag", 1)
+		require.NoError(t, err)
+
+		err = cursor.Iterate(c, func(values [][]byte) error {
+			return nil
+		})
+		require.EqualError(t, err, "next entry: next error")
+	})
+}
+
+func newStore(t *testing.T, n int) storage.Store {
+	t.Helper()
+
+	store, err := mem.NewProvider().OpenStore("test")
+	require.NoError(t, err)
+
+	for i := 0; i < n; i++ {
+		require.NoError(t, store.Put(fmt.Sprintf("key%d", i), []byte("value"), storage.Tag{Name: "tag"}))
+	}
+
+	return store
+}
@@ -0,0 +1,454 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package encrypted
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/aries-framework-go/pkg/kms"
+	"github.com/hyperledger/aries-framework-go/spi/storage"
+
+	"github.com/trustbloc/ace/pkg/storage/conditional"
+)
+
+const (
+	keyStoreName = "storage_encryption"
+	keyIDKey     = "key_id"
+	tagKeyIDKey  = "tag_key_id"
+)
+
+var errRangeQuery = errors.New("tag values of encrypted stores cannot be queried by range")
+
+type aead interface {
+	Encrypt(msg, aad []byte, kh interface{}) ([]byte, []byte, error)
+	Decrypt(cipher, aad, nonce []byte, kh interface{}) ([]byte, error)
+	ComputeMAC(data []byte, kh interface{}) ([]byte, error)
+}
+
+type keyManager interface {
+	Create(kt kms.KeyType) (string, interface{}, error)
+	Get(keyID string) (interface{}, error)
+}
+
+// Option configures the encrypting provider.
+type Option func(p *Provider)
+
+// WithStores limits encryption to the stores with the given names. Defaults to encrypting all stores.
+func WithStores(names ...string) Option {
+	return func(p *Provider) {
+		p.stores = make(map[string]struct{}, len(names))
+
+		for _, name := range names {
+			p.stores[strings.ToLower(name)] = struct{}{}
+		}
+	}
+}
+
+// Provider encrypts the values of the stores of an underlying storage.Provider with an AES-256-GCM key held by the
+// KMS. Tag values are replaced by their HMAC-SHA256 with a second key of the KMS, so that stores can still be queried
+// by equality without revealing the values; the tags themselves are sealed with the value and read back from it. Keys
+// and tag names are stored in plaintext.
+type Provider struct {
+	storage.Provider
+	crypto aead
+	kh     interface{}
+	tagKH  interface{}
+	stores map[string]struct{}
+}
+
+// NewProvider returns a provider encrypting the stores of primary. The encryption and tag keys are created in the KMS
+// on first use and their IDs are kept in the underlying provider, so the same keys are used across restarts.
+func NewProvider(primary storage.Provider, km keyManager, crypto aead, opts ...Option) (*Provider, error) {
+	keyStore, err := primary.OpenStore(keyStoreName)
+	if err != nil {
+		return nil, fmt.Errorf("open encryption key store: %w", err)
+	}
+
+	kh, err := keyHandle(keyStore, km, keyIDKey, kms.AES256GCMType, "encryption")
+	if err != nil {
+		return nil, err
+	}
+
+	tagKH, err := keyHandle(keyStore, km, tagKeyIDKey, kms.HMACSHA256Tag256Type, "tag")
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Provider{
+		Provider: primary,
+		crypto:   crypto,
+		kh:       kh,
+		tagKH:    tagKH,
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p, nil
+}
+
+// OpenStore opens the store in the underlying provider and wraps it with encryption if it is enabled for it.
+func (p *Provider) OpenStore(name string) (storage.Store, error) {
+	s, err := p.Provider.OpenStore(name)
+	if err != nil {
+		return nil, err
+	}
+
+	name = strings.ToLower(name)
+
+	if p.stores != nil {
+		if _, encrypted := p.stores[name]; !encrypted {
+			return s, nil
+		}
+	}
+
+	encrypted := &store{Store: s, name: name, crypto: p.crypto, kh: p.kh, tagKH: p.tagKH}
+
+	if putter, ok := s.(conditional.Putter); ok {
+		return &conditionalStore{store: encrypted, putter: putter}, nil
+	}
+
+	return encrypted, nil
+}
+
+// keyHandle returns the key whose ID is kept under idKey in the key store, creating it with type kt if it is missing.
+func keyHandle(keyStore storage.Store, km keyManager, idKey string, kt kms.KeyType,
+	name string) (interface{}, error) {
+	keyID, err := keyStore.Get(idKey)
+	if err == nil {
+		kh, getErr := km.Get(string(keyID))
+		if getErr != nil {
+			return nil, fmt.Errorf("get %s key: %w", name, getErr)
+		}
+
+		return kh, nil
+	}
+
+	if !errors.Is(err, storage.ErrDataNotFound) {
+		return nil, fmt.Errorf("get %s key id: %w", name, err)
+	}
+
+	id, kh, err := km.Create(kt)
+	if err != nil {
+		return nil, fmt.Errorf("create %s key: %w", name, err)
+	}
+
+	err = keyStore.Put(idKey, []byte(id))
+	if err != nil {
+		return nil, fmt.Errorf("save %s key id: %w", name, err)
+	}
+
+	return kh, nil
+}
+
+// envelope is what is sealed under a key: the value with its tags, whose values are stored as HMACs.
+type envelope struct {
+	Value []byte        `json:"value"`
+	Tags  []storage.Tag `json:"tags,omitempty"`
+}
+
+// store encrypts values before they are passed to the underlying storage.Store.
+type store struct {
+	storage.Store
+	name   string
+	crypto aead
+	kh     interface{}
+	tagKH  interface{}
+}
+
+// Put encrypts the value with its tags and stores it in the underlying store, tagged with the HMACs of the tag values.
+func (s *store) Put(key string, value []byte, tags ...storage.Tag) error {
+	if key == "" || value == nil {
+		return s.Store.Put(key, value, tags...)
+	}
+
+	ciphertext, err := s.encrypt(key, value, tags)
+	if err != nil {
+		return err
+	}
+
+	hashed, err := s.hashTags(tags)
+	if err != nil {
+		return err
+	}
+
+	return s.Store.Put(key, ciphertext, hashed...)
+}
+
+// Get returns the decrypted value stored under key.
+func (s *store) Get(key string) ([]byte, error) {
+	ciphertext, err := s.Store.Get(key)
+	if err != nil {
+		return nil, err
+	}
+
+	e, err := s.decrypt(key, ciphertext)
+	if err != nil {
+		return nil, err
+	}
+
+	return e.Value, nil
+}
+
+// GetTags returns the tags stored under key, as sealed with its value.
+func (s *store) GetTags(key string) ([]storage.Tag, error) {
+	ciphertext, err := s.Store.Get(key)
+	if err != nil {
+		return nil, err
+	}
+
+	e, err := s.decrypt(key, ciphertext)
+	if err != nil {
+		return nil, err
+	}
+
+	return e.Tags, nil
+}
+
+// GetBulk returns the decrypted values stored under keys. Missing values are returned as nil.
+func (s *store) GetBulk(keys ...string) ([][]byte, error) {
+	values, err := s.Store.GetBulk(keys...)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, ciphertext := range values {
+		if ciphertext == nil {
+			continue
+		}
+
+		e, err := s.decrypt(keys[i], ciphertext)
+		if err != nil {
+			return nil, err
+		}
+
+		values[i] = e.Value
+	}
+
+	return values, nil
+}
+
+// Query returns an iterator over the entries matching expression, decrypting their values. The tag values of the
+// expression are replaced by their HMACs, so only tag names and equality of tag values can be queried.
+func (s *store) Query(expression string, options ...storage.QueryOption) (storage.Iterator, error) {
+	hashed, err := s.hashExpression(expression)
+	if err != nil {
+		return nil, err
+	}
+
+	iter, err := s.Store.Query(hashed, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &iterator{Iterator: iter, store: s}, nil
+}
+
+// Batch encrypts the values of the put operations with their tags, hashes their tag values and performs the
+// operations on the underlying store.
+func (s *store) Batch(operations []storage.Operation) error {
+	encrypted := make([]storage.Operation, len(operations))
+
+	for i, op := range operations {
+		encrypted[i] = op
+
+		if op.Key == "" || op.Value == nil {
+			continue
+		}
+
+		ciphertext, err := s.encrypt(op.Key, op.Value, op.Tags)
+		if err != nil {
+			return err
+		}
+
+		encrypted[i].Value = ciphertext
+
+		encrypted[i].Tags, err = s.hashTags(op.Tags)
+		if err != nil {
+			return err
+		}
+	}
+
+	return s.Store.Batch(encrypted)
+}
+
+// conditionalStore encrypts the values of a store writing conditionally.
+type conditionalStore struct {
+	*store
+	putter conditional.Putter
+}
+
+// PutIf encrypts the value with its tags and stores it in the underlying store if the entry meets the condition,
+// whose value is hashed as the tag values are.
+func (s *conditionalStore) PutIf(key string, value []byte, condition storage.Tag, tags ...storage.Tag) error {
+	if key == "" || value == nil {
+		return s.putter.PutIf(key, value, condition, tags...)
+	}
+
+	ciphertext, err := s.encrypt(key, value, tags)
+	if err != nil {
+		return err
+	}
+
+	hashed, err := s.hashTags(append([]storage.Tag{condition}, tags...))
+	if err != nil {
+		return err
+	}
+
+	return s.putter.PutIf(key, ciphertext, hashed[0], hashed[1:]...)
+}
+
+// encrypt seals value and its tags with the store name and key as additional data, so a value cannot be moved to
+// another entry. The result is the nonce length, the nonce and the ciphertext.
+func (s *store) encrypt(key string, value []byte, tags []storage.Tag) ([]byte, error) {
+	plaintext, err := json.Marshal(&envelope{Value: value, Tags: tags})
+	if err != nil {
+		return nil, fmt.Errorf("encrypt %s: %w", key, err)
+	}
+
+	ciphertext, nonce, err := s.crypto.Encrypt(plaintext, s.aad(key), s.kh)
+	if err != nil {
+		return nil, fmt.Errorf("encrypt %s: %w", key, err)
+	}
+
+	sealed := make([]byte, 0, 1+len(nonce)+len(ciphertext))
+	sealed = append(sealed, byte(len(nonce)))
+	sealed = append(sealed, nonce...)
+
+	return append(sealed, ciphertext...), nil
+}
+
+func (s *store) decrypt(key string, sealed []byte) (*envelope, error) {
+	if len(sealed) == 0 || len(sealed) < 1+int(sealed[0]) {
+		return nil, fmt.Errorf("decrypt %s: malformed ciphertext", key)
+	}
+
+	nonceSize := int(sealed[0])
+
+	plaintext, err := s.crypto.Decrypt(sealed[1+nonceSize:], s.aad(key), sealed[1:1+nonceSize], s.kh)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt %s: %w", key, err)
+	}
+
+	e := &envelope{}
+
+	if err = json.Unmarshal(plaintext, e); err != nil {
+		return nil, fmt.Errorf("decrypt %s: %w", key, err)
+	}
+
+	return e, nil
+}
+
+func (s *store) aad(key string) []byte {
+	return []byte(s.name + "/" + key)
+}
+
+// hashTags returns the tags with their values replaced by their HMACs. Tags without a value are left as they are.
+func (s *store) hashTags(tags []storage.Tag) ([]storage.Tag, error) {
+	if len(tags) == 0 {
+		return tags, nil
+	}
+
+	hashed := make([]storage.Tag, len(tags))
+
+	for i, tag := range tags {
+		hashed[i] = tag
+
+		if tag.Value == "" {
+			continue
+		}
+
+		v, err := s.hashTag(tag.Name, tag.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		hashed[i].Value = v
+	}
+
+	return hashed, nil
+}
+
+// hashTag returns the HMAC of the tag value, keyed to the store and the tag name so that equal values of different
+// tags cannot be linked. It is encoded as base64url, which is a valid tag value.
+func (s *store) hashTag(name, value string) (string, error) {
+	mac, err := s.crypto.ComputeMAC([]byte(s.name+"/"+name+"/"+value), s.tagKH)
+	if err != nil {
+		return "", fmt.Errorf("hash tag %s: %w", name, err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(mac), nil
+}
+
+// hashExpression replaces the tag values of the terms of the query expression with their HMACs.
+func (s *store) hashExpression(expression string) (string, error) {
+	terms := strings.Split(expression, "&&")
+
+	for i, term := range terms {
+		parts := strings.SplitN(term, ":", 2) //nolint:gomnd
+		if len(parts) == 1 {
+			if strings.ContainsAny(term, "<>") {
+				return "", errRangeQuery
+			}
+
+			continue
+		}
+
+		v, err := s.hashTag(parts[0], parts[1])
+		if err != nil {
+			return "", err
+		}
+
+		terms[i] = parts[0] + ":" + v
+	}
+
+	return strings.Join(terms, "&&"), nil
+}
+
+type iterator struct {
+	storage.Iterator
+	store *store
+}
+
+// Value returns the decrypted value of the current entry.
+func (i *iterator) Value() ([]byte, error) {
+	e, err := i.envelope()
+	if err != nil {
+		return nil, err
+	}
+
+	return e.Value, nil
+}
+
+// Tags returns the tags of the current entry, as sealed with its value.
+func (i *iterator) Tags() ([]storage.Tag, error) {
+	e, err := i.envelope()
+	if err != nil {
+		return nil, err
+	}
+
+	return e.Tags, nil
+}
+
+func (i *iterator) envelope() (*envelope, error) {
+	key, err := i.Iterator.Key()
+	if err != nil {
+		return nil, err
+	}
+
+	ciphertext, err := i.Iterator.Value()
+	if err != nil {
+		return nil, err
+	}
+
+	return i.store.decrypt(key, ciphertext)
+}
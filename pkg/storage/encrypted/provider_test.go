@@ -0,0 +1,421 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package encrypted_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/hyperledger/aries-framework-go/component/storageutil/mem"
+	"github.com/hyperledger/aries-framework-go/pkg/crypto/tinkcrypto"
+	"github.com/hyperledger/aries-framework-go/pkg/kms"
+	"github.com/hyperledger/aries-framework-go/pkg/kms/localkms"
+	mockstorage "github.com/hyperledger/aries-framework-go/pkg/mock/storage"
+	"github.com/hyperledger/aries-framework-go/pkg/secretlock"
+	"github.com/hyperledger/aries-framework-go/pkg/secretlock/noop"
+	"github.com/hyperledger/aries-framework-go/spi/storage"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/ace/pkg/storage/conditional"
+	"github.com/trustbloc/ace/pkg/storage/encrypted"
+)
+
+const keystorePrimaryKeyURI = "local-lock://kms"
+
+func TestNewProvider(t *testing.T) {
+	t.Run("reuses the encryption key across restarts", func(t *testing.T) {
+		primary := mem.NewProvider()
+		km := newLocalKMS(t, primary)
+
+		p1 := newProvider(t, primary, km)
+
+		s1, err := p1.OpenStore("test")
+		require.NoError(t, err)
+		require.NoError(t, s1.Put("key", []byte("value")))
+
+		p2 := newProvider(t, primary, km)
+
+		s2, err := p2.OpenStore("test")
+		require.NoError(t, err)
+
+		value, err := s2.Get("key")
+		require.NoError(t, err)
+		require.Equal(t, []byte("value"), value)
+	})
+
+	t.Run("error if key store cannot be opened", func(t *testing.T) {
+		primary := mockstorage.NewMockStoreProvider()
+		primary.ErrOpenStoreHandle = errors.New("open error")
+
+		_, err := encrypted.NewProvider(primary, &mockKeyManager{}, newCrypto(t))
+		require.EqualError(t, err, "open encryption key store: open error")
+	})
+
+	t.Run("error if key id cannot be read", func(t *testing.T) {
+		primary := mockstorage.NewMockStoreProvider()
+		primary.Store.ErrGet = errors.New("get error")
+
+		_, err := encrypted.NewProvider(primary, &mockKeyManager{}, newCrypto(t))
+		require.EqualError(t, err, "get encryption key id: get error")
+	})
+
+	t.Run("error if key cannot be created", func(t *testing.T) {
+		_, err := encrypted.NewProvider(mem.NewProvider(), &mockKeyManager{err: errors.New("create error")},
+			newCrypto(t))
+		require.EqualError(t, err, "create encryption key: create error")
+	})
+
+	t.Run("error if key id cannot be saved", func(t *testing.T) {
+		primary := mockstorage.NewMockStoreProvider()
+		primary.Store.ErrPut = errors.New("put error")
+
+		_, err := encrypted.NewProvider(primary, newLocalKMS(t, mem.NewProvider()), newCrypto(t))
+		require.EqualError(t, err, "save encryption key id: put error")
+	})
+
+	t.Run("error if key cannot be fetched", func(t *testing.T) {
+		primary := mem.NewProvider()
+		newProvider(t, primary, newLocalKMS(t, primary))
+
+		_, err := encrypted.NewProvider(primary, &mockKeyManager{err: errors.New("get error")}, newCrypto(t))
+		require.EqualError(t, err, "get encryption key: get error")
+	})
+}
+
+func TestStore(t *testing.T) {
+	t.Run("encrypts values at rest", func(t *testing.T) {
+		primary := mem.NewProvider()
+		p := newProvider(t, primary, newLocalKMS(t, primary), encrypted.WithStores("Ticket"))
+
+		s, err := p.OpenStore("ticket")
+		require.NoError(t, err)
+
+		tags := []storage.Tag{{Name: "status", Value: "NEW"}}
+
+		require.NoError(t, s.Put("key", []byte("secret"), tags...))
+
+		underlying, err := primary.OpenStore("ticket")
+		require.NoError(t, err)
+
+		raw, err := underlying.Get("key")
+		require.NoError(t, err)
+		require.NotContains(t, string(raw), "secret")
+
+		value, err := s.Get("key")
+		require.NoError(t, err)
+		require.Equal(t, []byte("secret"), value)
+
+		result, err := s.GetTags("key")
+		require.NoError(t, err)
+		require.Equal(t, tags, result)
+
+		plain, err := p.OpenStore("policy")
+		require.NoError(t, err)
+		require.NoError(t, plain.Put("key", []byte("plaintext")))
+
+		underlying, err = primary.OpenStore("policy")
+		require.NoError(t, err)
+
+		raw, err = underlying.Get("key")
+		require.NoError(t, err)
+		require.Equal(t, []byte("plaintext"), raw)
+	})
+
+	t.Run("puts values conditionally on hashed tag values", func(t *testing.T) {
+		primary := conditional.NewLockingProvider(mem.NewProvider())
+		s := openStore(t, primary)
+		revision := func(v string) storage.Tag {
+			return storage.Tag{Name: "revision", Value: v}
+		}
+
+		require.NoError(t, conditional.PutIf(s, "key", []byte("v1"), revision(""), revision("1")))
+		require.NoError(t, conditional.PutIf(s, "key", []byte("v2"), revision("1"), revision("2")))
+		require.ErrorIs(t, conditional.PutIf(s, "key", []byte("v3"), revision("1")), conditional.ErrConflict)
+
+		value, err := s.Get("key")
+		require.NoError(t, err)
+		require.Equal(t, []byte("v2"), value)
+
+		underlying, err := primary.OpenStore("test")
+		require.NoError(t, err)
+
+		tags, err := underlying.GetTags("key")
+		require.NoError(t, err)
+		require.Len(t, tags, 1)
+		require.NotEqual(t, "2", tags[0].Value)
+
+		require.False(t, conditional.Supported(openStore(t, mem.NewProvider())))
+	})
+
+	t.Run("hashes tag values at rest", func(t *testing.T) {
+		primary := mem.NewProvider()
+		s := openStore(t, primary)
+
+		tags := []storage.Tag{{Name: "did", Value: "secret-did"}, {Name: "policy"}}
+
+		require.NoError(t, s.Put("k1", []byte("v1"), tags...))
+		require.NoError(t, s.Batch([]storage.Operation{
+			{Key: "k2", Value: []byte("v2"), Tags: []storage.Tag{{Name: "did", Value: "other-did"}}},
+		}))
+
+		underlying, err := primary.OpenStore("test")
+		require.NoError(t, err)
+
+		raw, err := underlying.GetTags("k1")
+		require.NoError(t, err)
+		require.Len(t, raw, 2)
+		require.Equal(t, "did", raw[0].Name)
+		require.NotEqual(t, "secret-did", raw[0].Value)
+		require.Equal(t, storage.Tag{Name: "policy"}, raw[1])
+
+		result, err := s.GetTags("k1")
+		require.NoError(t, err)
+		require.Equal(t, tags, result)
+
+		iter, err := s.Query("did:secret-did")
+		require.NoError(t, err)
+
+		defer storage.Close(iter, nil)
+
+		ok, err := iter.Next()
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		key, err := iter.Key()
+		require.NoError(t, err)
+		require.Equal(t, "k1", key)
+
+		result, err = iter.Tags()
+		require.NoError(t, err)
+		require.Equal(t, tags, result)
+
+		ok, err = iter.Next()
+		require.NoError(t, err)
+		require.False(t, ok)
+
+		iter, err = underlying.Query("did:secret-did")
+		require.NoError(t, err)
+
+		defer storage.Close(iter, nil)
+
+		ok, err = iter.Next()
+		require.NoError(t, err)
+		require.False(t, ok)
+
+		_, err = s.Query("did<1")
+		require.EqualError(t, err, "tag values of encrypted stores cannot be queried by range")
+	})
+
+	t.Run("tag values are bound to their store and tag", func(t *testing.T) {
+		primary := mem.NewProvider()
+		p := newProvider(t, primary, newLocalKMS(t, primary))
+
+		s1, err := p.OpenStore("s1")
+		require.NoError(t, err)
+		require.NoError(t, s1.Put("key", []byte("value"),
+			storage.Tag{Name: "a", Value: "v"}, storage.Tag{Name: "b", Value: "v"}))
+
+		s2, err := p.OpenStore("s2")
+		require.NoError(t, err)
+		require.NoError(t, s2.Put("key", []byte("value"), storage.Tag{Name: "a", Value: "v"}))
+
+		u1, err := primary.OpenStore("s1")
+		require.NoError(t, err)
+
+		t1, err := u1.GetTags("key")
+		require.NoError(t, err)
+
+		u2, err := primary.OpenStore("s2")
+		require.NoError(t, err)
+
+		t2, err := u2.GetTags("key")
+		require.NoError(t, err)
+
+		require.NotEqual(t, t1[0].Value, t1[1].Value)
+		require.NotEqual(t, t1[0].Value, t2[0].Value)
+	})
+
+	t.Run("values are bound to their key", func(t *testing.T) {
+		primary := mem.NewProvider()
+		s := openStore(t, primary)
+
+		require.NoError(t, s.Put("k1", []byte("value")))
+
+		underlying, err := primary.OpenStore("test")
+		require.NoError(t, err)
+
+		raw, err := underlying.Get("k1")
+		require.NoError(t, err)
+		require.NoError(t, underlying.Put("k2", raw))
+
+		_, err = s.Get("k2")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "decrypt k2")
+
+		require.NoError(t, underlying.Put("k3", []byte{}))
+
+		_, err = s.Get("k3")
+		require.EqualError(t, err, "decrypt k3: malformed ciphertext")
+
+		_, err = s.GetBulk("k1", "k2")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "decrypt k2")
+	})
+
+	t.Run("bulk get, query and batch", func(t *testing.T) {
+		s := openStore(t, mem.NewProvider())
+
+		err := s.Batch([]storage.Operation{
+			{Key: "k1", Value: []byte("v1"), Tags: []storage.Tag{{Name: "tag"}}},
+			{Key: "k2", Value: []byte("v2"), Tags: []storage.Tag{{Name: "tag"}}},
+			{Key: "k3", Value: []byte("v3")},
+			{Key: "k3"},
+		})
+		require.NoError(t, err)
+
+		values, err := s.GetBulk("k1", "k2", "k3")
+		require.NoError(t, err)
+		require.Equal(t, [][]byte{[]byte("v1"), []byte("v2"), nil}, values)
+
+		iter, err := s.Query("tag")
+		require.NoError(t, err)
+
+		defer storage.Close(iter, nil)
+
+		result := map[string]string{}
+
+		for {
+			ok, err := iter.Next()
+			require.NoError(t, err)
+
+			if !ok {
+				break
+			}
+
+			key, err := iter.Key()
+			require.NoError(t, err)
+
+			value, err := iter.Value()
+			require.NoError(t, err)
+
+			result[key] = string(value)
+		}
+
+		require.Equal(t, map[string]string{"k1": "v1", "k2": "v2"}, result)
+	})
+
+	t.Run("errors from the underlying store are returned", func(t *testing.T) {
+		s := openStore(t, mem.NewProvider())
+
+		_, err := s.Get("missing")
+		require.ErrorIs(t, err, storage.ErrDataNotFound)
+
+		require.Error(t, s.Put("", []byte("value")))
+		require.Error(t, s.Put("key", nil))
+		require.Error(t, s.Batch([]storage.Operation{{Key: "", Value: []byte("value")}}))
+
+		_, err = s.GetBulk("")
+		require.Error(t, err)
+
+		_, err = s.Query("")
+		require.Error(t, err)
+
+		_, err = encrypted.NewProvider(mem.NewProvider(), newLocalKMS(t, mem.NewProvider()), newCrypto(t))
+		require.NoError(t, err)
+	})
+
+	t.Run("error if encryption fails", func(t *testing.T) {
+		primary := mem.NewProvider()
+
+		p, err := encrypted.NewProvider(primary, &mockKeyManager{}, newCrypto(t))
+		require.NoError(t, err)
+
+		s, err := p.OpenStore("test")
+		require.NoError(t, err)
+
+		err = s.Put("key", []byte("value"))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "encrypt key")
+
+		err = s.Batch([]storage.Operation{{Key: "key", Value: []byte("value")}})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "encrypt key")
+	})
+
+	t.Run("error if store cannot be opened", func(t *testing.T) {
+		p := newProvider(t, mem.NewProvider(), newLocalKMS(t, mem.NewProvider()))
+
+		_, err := p.OpenStore("")
+		require.Error(t, err)
+	})
+}
+
+func openStore(t *testing.T, primary storage.Provider) storage.Store {
+	t.Helper()
+
+	s, err := newProvider(t, primary, newLocalKMS(t, primary)).OpenStore("test")
+	require.NoError(t, err)
+
+	return s
+}
+
+func newProvider(t *testing.T, primary storage.Provider, km kms.KeyManager,
+	opts ...encrypted.Option) *encrypted.Provider {
+	t.Helper()
+
+	p, err := encrypted.NewProvider(primary, km, newCrypto(t), opts...)
+	require.NoError(t, err)
+
+	return p
+}
+
+func newCrypto(t *testing.T) *tinkcrypto.Crypto {
+	t.Helper()
+
+	c, err := tinkcrypto.New()
+	require.NoError(t, err)
+
+	return c
+}
+
+func newLocalKMS(t *testing.T, db storage.Provider) kms.KeyManager { //nolint:ireturn,nolintlint
+	t.Helper()
+
+	keyManager, err := localkms.New(keystorePrimaryKeyURI, &kmsProvider{
+		storageProvider: db,
+		secretLock:      &noop.NoLock{},
+	})
+	require.NoError(t, err)
+
+	return keyManager
+}
+
+type kmsProvider struct {
+	storageProvider storage.Provider
+	secretLock      secretlock.Service
+}
+
+func (k kmsProvider) StorageProvider() storage.Provider { //nolint:ireturn
+	return k.storageProvider
+}
+
+func (k kmsProvider) SecretLock() secretlock.Service { //nolint:ireturn
+	return k.secretLock
+}
+
+// mockKeyManager returns err, or an invalid key handle if err is not set.
+type mockKeyManager struct {
+	err error
+}
+
+func (m *mockKeyManager) Create(kms.KeyType) (string, interface{}, error) {
+	return "key-id", "invalid", m.err
+}
+
+func (m *mockKeyManager) Get(string) (interface{}, error) {
+	return "invalid", m.err
+}
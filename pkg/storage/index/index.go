@@ -0,0 +1,46 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package index
+
+import (
+	"encoding/base64"
+	"fmt"
+
+	"github.com/hyperledger/aries-framework-go/spi/storage"
+)
+
+// Declaration declares the tag names a store is queried by.
+type Declaration struct {
+	Store    string
+	TagNames []string
+}
+
+// OpenStore opens the declared store and sets its configuration, so that providers backed by a database such as
+// CouchDB or MongoDB create indexes for the declared tag names when the service starts.
+func OpenStore(provider storage.Provider, d *Declaration) (storage.Store, error) {
+	store, err := provider.OpenStore(d.Store)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(d.TagNames) == 0 {
+		return store, nil
+	}
+
+	err = provider.SetStoreConfig(d.Store, storage.StoreConfiguration{TagNames: d.TagNames})
+	if err != nil {
+		return nil, fmt.Errorf("create indexes: %w", err)
+	}
+
+	return store, nil
+}
+
+// TagValue encodes value for use as a tag value. Tag values cannot contain ':' characters, which are common in
+// indexed values such as DIDs.
+func TagValue(value string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(value))
+}
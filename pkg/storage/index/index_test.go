@@ -0,0 +1,65 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package index_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/aries-framework-go/component/storageutil/mem"
+	"github.com/hyperledger/aries-framework-go/component/storageutil/mock"
+	"github.com/hyperledger/aries-framework-go/pkg/mock/storage"
+	storageapi "github.com/hyperledger/aries-framework-go/spi/storage"
+	"github.com/stretchr/testify/require"
+
+	mockstorage "github.com/trustbloc/ace/pkg/internal/mock/storage"
+	"github.com/trustbloc/ace/pkg/storage/index"
+)
+
+func TestOpenStore(t *testing.T) {
+	t.Run("creates indexes for the declared tag names", func(t *testing.T) {
+		provider := mem.NewProvider()
+
+		store, err := index.OpenStore(provider, &index.Declaration{Store: "test", TagNames: []string{"a", "b"}})
+		require.NoError(t, err)
+		require.NotNil(t, store)
+
+		config, err := provider.GetStoreConfig("test")
+		require.NoError(t, err)
+		require.Equal(t, []string{"a", "b"}, config.TagNames)
+	})
+
+	t.Run("skips store configuration without tag names", func(t *testing.T) {
+		// the mock provider panics on SetStoreConfig
+		provider := &mockstorage.MockProvider{Stores: map[string]storageapi.Store{"test": &mock.Store{}}}
+
+		store, err := index.OpenStore(provider, &index.Declaration{Store: "test"})
+		require.NoError(t, err)
+		require.NotNil(t, store)
+	})
+
+	t.Run("error if store cannot be opened", func(t *testing.T) {
+		provider := storage.NewMockStoreProvider()
+		provider.ErrOpenStoreHandle = errors.New("open error")
+
+		_, err := index.OpenStore(provider, &index.Declaration{Store: "test", TagNames: []string{"a"}})
+		require.EqualError(t, err, "open error")
+	})
+
+	t.Run("error if indexes cannot be created", func(t *testing.T) {
+		_, err := index.OpenStore(mem.NewProvider(), &index.Declaration{Store: "test", TagNames: []string{"a:b"}})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "create indexes")
+	})
+}
+
+func TestTagValue(t *testing.T) {
+	value := index.TagValue("did:example:123")
+	require.False(t, strings.Contains(value, ":"))
+	require.NotEqual(t, value, index.TagValue("did:example:456"))
+}
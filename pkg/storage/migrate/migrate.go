@@ -0,0 +1,372 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package migrate
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/hyperledger/aries-framework-go/pkg/common/log"
+	"github.com/hyperledger/aries-framework-go/spi/storage"
+)
+
+// DefaultBatchSize is the default number of entries written to the target in one batch.
+const DefaultBatchSize = 100
+
+var logger = log.New("storage-migrate")
+
+// Store describes how the entries of a store are found.
+type Store struct {
+	Name string
+	// Expressions are tag queries matching the entries of the store.
+	Expressions []string
+	// Keys of entries not matched by Expressions, such as entries written before the store was tagged.
+	Keys []string
+}
+
+// Result summarizes the migration of a store.
+type Result struct {
+	Store string
+	// Copied is the number of entries written to the target.
+	Copied int
+	// Skipped is the number of entries already present in the target with the same value and tags.
+	Skipped int
+	// Verified is the number of entries read back from the target and found equal to the source.
+	Verified int
+}
+
+// Option configures the Migrator.
+type Option func(m *Migrator)
+
+// WithBatchSize sets the number of entries written to the target in one batch. Defaults to DefaultBatchSize.
+func WithBatchSize(size int) Option {
+	return func(m *Migrator) {
+		m.batchSize = size
+	}
+}
+
+// Migrator copies stores from a source to a target storage provider.
+type Migrator struct {
+	source    storage.Provider
+	target    storage.Provider
+	batchSize int
+}
+
+// New returns a new instance of Migrator.
+func New(source, target storage.Provider, opts ...Option) *Migrator {
+	m := &Migrator{
+		source:    source,
+		target:    target,
+		batchSize: DefaultBatchSize,
+	}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	return m
+}
+
+// Entry is an entry of a store, with its tags.
+type Entry struct {
+	Key   string
+	Value []byte
+	Tags  []storage.Tag
+}
+
+// Migrate copies the entries of the store with their tags, then reads them back from the target to verify them.
+// Entries already present in the target with the same value and tags are not written again, so an interrupted
+// migration is resumed by running it again.
+func (m *Migrator) Migrate(s *Store) (*Result, error) {
+	src, err := m.source.OpenStore(s.Name)
+	if err != nil {
+		return nil, fmt.Errorf("open source store %s: %w", s.Name, err)
+	}
+
+	dst, err := m.target.OpenStore(s.Name)
+	if err != nil {
+		return nil, fmt.Errorf("open target store %s: %w", s.Name, err)
+	}
+
+	if err = m.copyConfig(s.Name); err != nil {
+		return nil, err
+	}
+
+	result := &Result{Store: s.Name}
+
+	err = each(src, s, m.batchSize, func(batch []*Entry) error {
+		return copyBatch(dst, batch, result)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("copy %s: %w", s.Name, err)
+	}
+
+	err = each(src, s, m.batchSize, func(batch []*Entry) error {
+		return verifyBatch(dst, batch, result)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("verify %s: %w", s.Name, err)
+	}
+
+	logger.Infof("migrated store %s: copied=%d skipped=%d verified=%d",
+		s.Name, result.Copied, result.Skipped, result.Verified)
+
+	return result, nil
+}
+
+// copyConfig sets the store configuration of the source on the target, so that the target creates the same indexes.
+func (m *Migrator) copyConfig(name string) error {
+	config, err := m.source.GetStoreConfig(name)
+	if errors.Is(err, storage.ErrStoreNotFound) {
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("get store config %s: %w", name, err)
+	}
+
+	if len(config.TagNames) == 0 {
+		return nil
+	}
+
+	if err = m.target.SetStoreConfig(name, config); err != nil {
+		return fmt.Errorf("set store config %s: %w", name, err)
+	}
+
+	return nil
+}
+
+// Each calls fn with batches of at most batchSize entries of the store of the provider, as they are read, first those
+// matching the expressions and then the remaining explicit keys. Entries matching more than one expression are only
+// passed once. The batch is reused once fn returns.
+func Each(provider storage.Provider, s *Store, batchSize int, fn func(batch []*Entry) error) error {
+	src, err := provider.OpenStore(s.Name)
+	if err != nil {
+		return fmt.Errorf("open store %s: %w", s.Name, err)
+	}
+
+	return each(src, s, batchSize, fn)
+}
+
+func each(src storage.Store, s *Store, batchSize int, fn func(batch []*Entry) error) error {
+	keys := make(map[string]struct{}, len(s.Keys))
+
+	for _, key := range s.Keys {
+		keys[key] = struct{}{}
+	}
+
+	batch := make([]*Entry, 0, batchSize)
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+
+		err := fn(batch)
+		batch = batch[:0]
+
+		return err
+	}
+
+	add := func(e *Entry) error {
+		batch = append(batch, e)
+
+		if len(batch) < batchSize {
+			return nil
+		}
+
+		return flush()
+	}
+
+	seen := make(map[string]struct{})
+
+	for _, expression := range s.Expressions {
+		err := query(src, expression, batchSize, func(e *Entry) error {
+			if _, ok := seen[e.Key]; ok {
+				return nil
+			}
+
+			seen[e.Key] = struct{}{}
+
+			delete(keys, e.Key)
+
+			return add(e)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, key := range s.Keys {
+		if _, ok := keys[key]; !ok {
+			continue
+		}
+
+		delete(keys, key)
+
+		e, err := get(src, key)
+		if errors.Is(err, storage.ErrDataNotFound) {
+			logger.Warnf("entry %s not found in source store", key)
+
+			continue
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if err = add(e); err != nil {
+			return err
+		}
+	}
+
+	return flush()
+}
+
+func query(src storage.Store, expression string, pageSize int, fn func(e *Entry) error) error {
+	iter, err := src.Query(expression, storage.WithPageSize(pageSize))
+	if err != nil {
+		return fmt.Errorf("query %s: %w", expression, err)
+	}
+
+	defer func() {
+		if closeErr := iter.Close(); closeErr != nil {
+			logger.Errorf("Failed to close iterator: %s", closeErr.Error())
+		}
+	}()
+
+	for {
+		ok, err := iter.Next()
+		if err != nil {
+			return fmt.Errorf("next entry: %w", err)
+		}
+
+		if !ok {
+			return nil
+		}
+
+		e := &Entry{}
+
+		if e.Key, err = iter.Key(); err != nil {
+			return fmt.Errorf("get key: %w", err)
+		}
+
+		if e.Value, err = iter.Value(); err != nil {
+			return fmt.Errorf("get value: %w", err)
+		}
+
+		if e.Tags, err = iter.Tags(); err != nil {
+			return fmt.Errorf("get tags: %w", err)
+		}
+
+		if err = fn(e); err != nil {
+			return err
+		}
+	}
+}
+
+func get(src storage.Store, key string) (*Entry, error) {
+	value, err := src.Get(key)
+	if err != nil {
+		return nil, fmt.Errorf("get %s: %w", key, err)
+	}
+
+	tags, err := src.GetTags(key)
+	if err != nil {
+		return nil, fmt.Errorf("get tags %s: %w", key, err)
+	}
+
+	return &Entry{Key: key, Value: value, Tags: tags}, nil
+}
+
+func copyBatch(dst storage.Store, batch []*Entry, result *Result) error {
+	existing, err := dst.GetBulk(keys(batch)...)
+	if err != nil {
+		return fmt.Errorf("get target entries: %w", err)
+	}
+
+	var operations []storage.Operation
+
+	for i, e := range batch {
+		if existing[i] != nil && bytes.Equal(existing[i], e.Value) {
+			tags, err := dst.GetTags(e.Key)
+			if err != nil {
+				return fmt.Errorf("get target tags %s: %w", e.Key, err)
+			}
+
+			if equalTags(tags, e.Tags) {
+				result.Skipped++
+
+				continue
+			}
+		}
+
+		operations = append(operations, storage.Operation{Key: e.Key, Value: e.Value, Tags: e.Tags})
+	}
+
+	if len(operations) == 0 {
+		return nil
+	}
+
+	if err = dst.Batch(operations); err != nil {
+		return fmt.Errorf("write target entries: %w", err)
+	}
+
+	result.Copied += len(operations)
+
+	return nil
+}
+
+func verifyBatch(dst storage.Store, batch []*Entry, result *Result) error {
+	values, err := dst.GetBulk(keys(batch)...)
+	if err != nil {
+		return fmt.Errorf("get target entries: %w", err)
+	}
+
+	for i, e := range batch {
+		if values[i] == nil || !bytes.Equal(values[i], e.Value) {
+			return fmt.Errorf("entry %s differs from source", e.Key)
+		}
+
+		result.Verified++
+	}
+
+	return nil
+}
+
+func keys(batch []*Entry) []string {
+	k := make([]string, len(batch))
+
+	for i, e := range batch {
+		k[i] = e.Key
+	}
+
+	return k
+}
+
+func equalTags(a, b []storage.Tag) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	count := make(map[storage.Tag]int, len(a))
+
+	for _, t := range a {
+		count[t]++
+	}
+
+	for _, t := range b {
+		if count[t] == 0 {
+			return false
+		}
+
+		count[t]--
+	}
+
+	return true
+}
@@ -0,0 +1,264 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package migrate_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/hyperledger/aries-framework-go/component/storageutil/mem"
+	mockstorage "github.com/hyperledger/aries-framework-go/pkg/mock/storage"
+	"github.com/hyperledger/aries-framework-go/spi/storage"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/ace/pkg/storage/migrate"
+)
+
+func TestMigrator_Migrate(t *testing.T) {
+	t.Run("copies and verifies entries", func(t *testing.T) {
+		source := mem.NewProvider()
+		target := mem.NewProvider()
+
+		src := openStore(t, source, "test")
+		require.NoError(t, source.SetStoreConfig("test", storage.StoreConfiguration{TagNames: []string{"tag"}}))
+
+		require.NoError(t, src.Put("k1", []byte("v1"), storage.Tag{Name: "tag", Value: "a"}))
+		require.NoError(t, src.Put("k2", []byte("v2"), storage.Tag{Name: "tag", Value: "b"}))
+		require.NoError(t, src.Put("k3", []byte("v3"), storage.Tag{Name: "tag"}))
+		require.NoError(t, src.Put("legacy", []byte("v4")))
+
+		result, err := migrate.New(source, target, migrate.WithBatchSize(2)).Migrate(&migrate.Store{
+			Name:        "test",
+			Expressions: []string{"tag"},
+			Keys:        []string{"legacy", "k1", "missing"},
+		})
+		require.NoError(t, err)
+		require.Equal(t, &migrate.Result{Store: "test", Copied: 4, Verified: 4}, result)
+
+		dst := openStore(t, target, "test")
+
+		value, err := dst.Get("legacy")
+		require.NoError(t, err)
+		require.Equal(t, []byte("v4"), value)
+
+		tags, err := dst.GetTags("k2")
+		require.NoError(t, err)
+		require.Equal(t, []storage.Tag{{Name: "tag", Value: "b"}}, tags)
+
+		config, err := target.GetStoreConfig("test")
+		require.NoError(t, err)
+		require.Equal(t, []string{"tag"}, config.TagNames)
+	})
+
+	t.Run("copies the entries matching any of the expressions once", func(t *testing.T) {
+		source := mem.NewProvider()
+		target := mem.NewProvider()
+
+		src := openStore(t, source, "test")
+
+		require.NoError(t, src.Put("k1", []byte("v1"), storage.Tag{Name: "a"}))
+		require.NoError(t, src.Put("k2", []byte("v2"), storage.Tag{Name: "b"}))
+		require.NoError(t, src.Put("k3", []byte("v3"), storage.Tag{Name: "a"}, storage.Tag{Name: "b"}))
+
+		result, err := migrate.New(source, target).Migrate(&migrate.Store{
+			Name:        "test",
+			Expressions: []string{"a", "b"},
+		})
+		require.NoError(t, err)
+		require.Equal(t, &migrate.Result{Store: "test", Copied: 3, Verified: 3}, result)
+	})
+
+	t.Run("resumes an interrupted migration", func(t *testing.T) {
+		source := mem.NewProvider()
+		target := mem.NewProvider()
+
+		src := openStore(t, source, "test")
+		dst := openStore(t, target, "test")
+
+		require.NoError(t, src.Put("k1", []byte("v1"), storage.Tag{Name: "tag"}))
+		require.NoError(t, src.Put("k2", []byte("v2"), storage.Tag{Name: "tag"}))
+		require.NoError(t, src.Put("k3", []byte("v3"), storage.Tag{Name: "tag", Value: "new"}))
+
+		require.NoError(t, dst.Put("k1", []byte("v1"), storage.Tag{Name: "tag"}))
+		require.NoError(t, dst.Put("k2", []byte("stale"), storage.Tag{Name: "tag"}))
+		require.NoError(t, dst.Put("k3", []byte("v3"), storage.Tag{Name: "tag", Value: "old"}))
+
+		m := migrate.New(source, target)
+
+		result, err := m.Migrate(&migrate.Store{Name: "test", Expressions: []string{"tag"}})
+		require.NoError(t, err)
+		require.Equal(t, &migrate.Result{Store: "test", Copied: 2, Skipped: 1, Verified: 3}, result)
+
+		result, err = m.Migrate(&migrate.Store{Name: "test", Expressions: []string{"tag"}})
+		require.NoError(t, err)
+		require.Equal(t, &migrate.Result{Store: "test", Skipped: 3, Verified: 3}, result)
+	})
+
+	t.Run("error if store cannot be opened", func(t *testing.T) {
+		failing := mockstorage.NewMockStoreProvider()
+		failing.ErrOpenStoreHandle = errors.New("open error")
+
+		_, err := migrate.New(failing, mem.NewProvider()).Migrate(&migrate.Store{Name: "test"})
+		require.EqualError(t, err, "open source store test: open error")
+
+		_, err = migrate.New(mem.NewProvider(), failing).Migrate(&migrate.Store{Name: "test"})
+		require.EqualError(t, err, "open target store test: open error")
+	})
+
+	t.Run("error if store config cannot be copied", func(t *testing.T) {
+		source := newFailingProvider()
+		source.errGetStoreConfig = errors.New("config error")
+
+		_, err := migrate.New(source, mem.NewProvider()).Migrate(&migrate.Store{Name: "test"})
+		require.EqualError(t, err, "get store config test: config error")
+
+		source = newFailingProvider()
+		openStore(t, source, "test")
+		require.NoError(t, source.SetStoreConfig("test", storage.StoreConfiguration{TagNames: []string{"tag"}}))
+
+		target := newFailingProvider()
+		target.errSetStoreConfig = errors.New("config error")
+
+		_, err = migrate.New(source, target).Migrate(&migrate.Store{Name: "test"})
+		require.EqualError(t, err, "set store config test: config error")
+	})
+
+	t.Run("error if source cannot be read", func(t *testing.T) {
+		source := newFailingProvider()
+		source.store.errQuery = errors.New("query error")
+
+		_, err := migrate.New(source, mem.NewProvider()).Migrate(&migrate.Store{Name: "test", Expressions: []string{"tag"}})
+		require.EqualError(t, err, "copy test: query tag: query error")
+
+		source = newFailingProvider()
+		source.store.errGet = errors.New("get error")
+
+		_, err = migrate.New(source, mem.NewProvider()).Migrate(&migrate.Store{Name: "test", Keys: []string{"key"}})
+		require.EqualError(t, err, "copy test: get key: get error")
+	})
+
+	t.Run("error if target cannot be written", func(t *testing.T) {
+		source := mem.NewProvider()
+		require.NoError(t, openStore(t, source, "test").Put("key", []byte("value"), storage.Tag{Name: "tag"}))
+
+		target := newFailingProvider()
+		target.store.errBatch = errors.New("batch error")
+
+		_, err := migrate.New(source, target).Migrate(&migrate.Store{Name: "test", Expressions: []string{"tag"}})
+		require.EqualError(t, err, "copy test: write target entries: batch error")
+
+		target = newFailingProvider()
+		target.store.errGetBulk = errors.New("get error")
+
+		_, err = migrate.New(source, target).Migrate(&migrate.Store{Name: "test", Expressions: []string{"tag"}})
+		require.EqualError(t, err, "copy test: get target entries: get error")
+	})
+
+	t.Run("error if verification fails", func(t *testing.T) {
+		source := mem.NewProvider()
+		require.NoError(t, openStore(t, source, "test").Put("key", []byte("value"), storage.Tag{Name: "tag"}))
+
+		target := newFailingProvider()
+		target.store.dropWrites = true
+
+		_, err := migrate.New(source, target).Migrate(&migrate.Store{Name: "test", Expressions: []string{"tag"}})
+		require.EqualError(t, err, "verify test: entry key differs from source")
+	})
+}
+
+func openStore(t *testing.T, provider storage.Provider, name string) storage.Store {
+	t.Helper()
+
+	s, err := provider.OpenStore(name)
+	require.NoError(t, err)
+
+	return s
+}
+
+// failingProvider is a mem provider returning the configured errors.
+type failingProvider struct {
+	storage.Provider
+	store             *failingStore
+	errGetStoreConfig error
+	errSetStoreConfig error
+}
+
+func newFailingProvider() *failingProvider {
+	return &failingProvider{Provider: mem.NewProvider(), store: &failingStore{}}
+}
+
+func (p *failingProvider) OpenStore(name string) (storage.Store, error) {
+	s, err := p.Provider.OpenStore(name)
+	if err != nil {
+		return nil, err
+	}
+
+	p.store.Store = s
+
+	return p.store, nil
+}
+
+func (p *failingProvider) GetStoreConfig(name string) (storage.StoreConfiguration, error) {
+	if p.errGetStoreConfig != nil {
+		return storage.StoreConfiguration{}, p.errGetStoreConfig
+	}
+
+	return p.Provider.GetStoreConfig(name)
+}
+
+func (p *failingProvider) SetStoreConfig(name string, config storage.StoreConfiguration) error {
+	if p.errSetStoreConfig != nil {
+		return p.errSetStoreConfig
+	}
+
+	return p.Provider.SetStoreConfig(name, config)
+}
+
+type failingStore struct {
+	storage.Store
+	errGet     error
+	errGetBulk error
+	errQuery   error
+	errBatch   error
+	dropWrites bool
+}
+
+func (s *failingStore) Get(key string) ([]byte, error) {
+	if s.errGet != nil {
+		return nil, s.errGet
+	}
+
+	return s.Store.Get(key)
+}
+
+func (s *failingStore) GetBulk(keys ...string) ([][]byte, error) {
+	if s.errGetBulk != nil {
+		return nil, s.errGetBulk
+	}
+
+	return s.Store.GetBulk(keys...)
+}
+
+func (s *failingStore) Query(expression string, options ...storage.QueryOption) (storage.Iterator, error) {
+	if s.errQuery != nil {
+		return nil, s.errQuery
+	}
+
+	return s.Store.Query(expression, options...)
+}
+
+func (s *failingStore) Batch(operations []storage.Operation) error {
+	if s.errBatch != nil {
+		return s.errBatch
+	}
+
+	if s.dropWrites {
+		return nil
+	}
+
+	return s.Store.Batch(operations)
+}
@@ -0,0 +1,676 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/aries-framework-go/spi/storage"
+
+	"github.com/trustbloc/ace/pkg/storage/conditional"
+)
+
+const (
+	// DriverName is the default database/sql driver name used to connect to PostgreSQL.
+	DriverName = "postgres"
+
+	defaultTimeout  = 10 * time.Second
+	defaultPageSize = 25
+
+	expressionTagNameOnlyLength     = 1
+	expressionTagNameAndValueLength = 2
+)
+
+// Option configures the PostgreSQL provider.
+type Option func(p *Provider)
+
+// WithDBPrefix sets a prefix for the names of the tables created by the provider.
+func WithDBPrefix(dbPrefix string) Option {
+	return func(p *Provider) {
+		p.dbPrefix = dbPrefix
+	}
+}
+
+// WithDriverName sets the database/sql driver used to connect to PostgreSQL. Defaults to DriverName.
+// The driver must be registered with database/sql by the caller, e.g. by importing github.com/lib/pq.
+func WithDriverName(driverName string) Option {
+	return func(p *Provider) {
+		p.driverName = driverName
+	}
+}
+
+// WithTimeout sets the timeout applied to every database call. Defaults to 10 seconds.
+func WithTimeout(timeout time.Duration) Option {
+	return func(p *Provider) {
+		p.timeout = timeout
+	}
+}
+
+// Provider is a PostgreSQL implementation of the aries storage.Provider interface. Each store is kept in its own
+// table with key, value and JSONB tags columns.
+type Provider struct {
+	db         *sql.DB
+	driverName string
+	dbPrefix   string
+	timeout    time.Duration
+	lock       sync.RWMutex
+	openStores map[string]*Store
+	configs    map[string]storage.StoreConfiguration
+}
+
+// NewProvider connects to the PostgreSQL database at connString.
+func NewProvider(connString string, opts ...Option) (*Provider, error) {
+	p := &Provider{
+		driverName: DriverName,
+		timeout:    defaultTimeout,
+		openStores: make(map[string]*Store),
+		configs:    make(map[string]storage.StoreConfiguration),
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	db, err := sql.Open(p.driverName, connString)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open connection to PostgreSQL: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+	defer cancel()
+
+	err = db.PingContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to PostgreSQL: %w", err)
+	}
+
+	p.db = db
+
+	return p, nil
+}
+
+// OpenStore opens the store with the given name, creating its table if it doesn't exist yet.
+func (p *Provider) OpenStore(name string) (storage.Store, error) {
+	if name == "" {
+		return nil, errors.New("store name cannot be empty")
+	}
+
+	name = strings.ToLower(p.dbPrefix + name)
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if s, ok := p.openStores[name]; ok {
+		return s, nil
+	}
+
+	s := &Store{name: name, table: quoteIdentifier(name), db: p.db, timeout: p.timeout, close: p.removeStore}
+
+	ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+	defer cancel()
+
+	_, err := p.db.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS "+s.table+
+		" (key TEXT PRIMARY KEY, value BYTEA NOT NULL, tags JSONB NOT NULL DEFAULT '[]')")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create table for store %s: %w", name, err)
+	}
+
+	p.openStores[name] = s
+
+	return s, nil
+}
+
+// SetStoreConfig creates an index on the tags of the store's table. The store must be open.
+func (p *Provider) SetStoreConfig(name string, config storage.StoreConfiguration) error {
+	for _, tagName := range config.TagNames {
+		if strings.Contains(tagName, ":") {
+			return fmt.Errorf("invalid tag name %s: tag names cannot contain any ':' characters", tagName)
+		}
+	}
+
+	name = strings.ToLower(p.dbPrefix + name)
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	s, ok := p.openStores[name]
+	if !ok {
+		return storage.ErrStoreNotFound
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+	defer cancel()
+
+	_, err := p.db.ExecContext(ctx, "CREATE INDEX IF NOT EXISTS "+quoteIdentifier(name+"_tags")+
+		" ON "+s.table+" USING GIN (tags)")
+	if err != nil {
+		return fmt.Errorf("failed to create tags index for store %s: %w", name, err)
+	}
+
+	p.configs[name] = config
+
+	return nil
+}
+
+// GetStoreConfig returns the configuration of the store. It fails with storage.ErrStoreNotFound if the store's table
+// doesn't exist.
+func (p *Provider) GetStoreConfig(name string) (storage.StoreConfiguration, error) {
+	name = strings.ToLower(p.dbPrefix + name)
+
+	ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+	defer cancel()
+
+	var table sql.NullString
+
+	err := p.db.QueryRowContext(ctx, "SELECT to_regclass($1)::text", quoteIdentifier(name)).Scan(&table)
+	if err != nil {
+		return storage.StoreConfiguration{}, fmt.Errorf("failed to look up table for store %s: %w", name, err)
+	}
+
+	if !table.Valid {
+		return storage.StoreConfiguration{}, storage.ErrStoreNotFound
+	}
+
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	return p.configs[name], nil
+}
+
+// GetOpenStores returns the stores opened by this provider.
+func (p *Provider) GetOpenStores() []storage.Store {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	stores := make([]storage.Store, 0, len(p.openStores))
+
+	for _, s := range p.openStores {
+		stores = append(stores, s)
+	}
+
+	return stores
+}
+
+// Close closes all stores and the connection to the database.
+func (p *Provider) Close() error {
+	p.lock.Lock()
+	p.openStores = make(map[string]*Store)
+	p.lock.Unlock()
+
+	err := p.db.Close()
+	if err != nil {
+		return fmt.Errorf("failed to close connection to PostgreSQL: %w", err)
+	}
+
+	return nil
+}
+
+func (p *Provider) removeStore(name string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	delete(p.openStores, name)
+}
+
+// Store is a PostgreSQL implementation of the aries storage.Store interface.
+type Store struct {
+	name    string
+	table   string
+	db      *sql.DB
+	timeout time.Duration
+	close   func(name string)
+}
+
+// Put stores the value and tags under key, replacing existing ones.
+func (s *Store) Put(key string, value []byte, tags ...storage.Tag) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	return s.put(ctx, s.db, key, value, tags)
+}
+
+// PutIf stores the value and tags under key if the entry stored under key meets the condition, see
+// conditional.Putter: the entry is only written by a statement whose filter still matches it.
+func (s *Store) PutIf(key string, value []byte, condition storage.Tag, tags ...storage.Tag) error {
+	if key == "" {
+		return errors.New("key cannot be empty")
+	}
+
+	if value == nil {
+		return errors.New("value cannot be nil")
+	}
+
+	raw, err := marshalTags(tags)
+	if err != nil {
+		return err
+	}
+
+	filter, err := marshalTags([]storage.Tag{condition})
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	var result sql.Result
+
+	if condition.Value == "" {
+		// the tag without a value is contained by the tags with any value
+		result, err = s.db.ExecContext(ctx, "INSERT INTO "+s.table+" AS t (key, value, tags) VALUES ($1, $2, $3)"+
+			" ON CONFLICT (key) DO UPDATE SET value = EXCLUDED.value, tags = EXCLUDED.tags WHERE NOT t.tags @> $4",
+			key, value, raw, filter)
+	} else {
+		result, err = s.db.ExecContext(ctx, "UPDATE "+s.table+" SET value = $2, tags = $3 WHERE key = $1"+
+			" AND tags @> $4", key, value, raw, filter)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to put %s in store %s: %w", key, s.name, err)
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to put %s in store %s: %w", key, s.name, err)
+	}
+
+	if n == 0 {
+		return fmt.Errorf("%w: %s of %s", conditional.ErrConflict, condition.Name, key)
+	}
+
+	return nil
+}
+
+// Get returns the value stored under key.
+func (s *Store) Get(key string) ([]byte, error) {
+	if key == "" {
+		return nil, errors.New("key cannot be empty")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	var value []byte
+
+	err := s.db.QueryRowContext(ctx, "SELECT value FROM "+s.table+" WHERE key = $1", key).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, storage.ErrDataNotFound
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s from store %s: %w", key, s.name, err)
+	}
+
+	return value, nil
+}
+
+// GetTags returns the tags stored under key.
+func (s *Store) GetTags(key string) ([]storage.Tag, error) {
+	if key == "" {
+		return nil, errors.New("key cannot be empty")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	var raw []byte
+
+	err := s.db.QueryRowContext(ctx, "SELECT tags FROM "+s.table+" WHERE key = $1", key).Scan(&raw)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, storage.ErrDataNotFound
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tags of %s from store %s: %w", key, s.name, err)
+	}
+
+	return unmarshalTags(raw)
+}
+
+// GetBulk returns the values stored under keys. Missing values are returned as nil.
+func (s *Store) GetBulk(keys ...string) ([][]byte, error) {
+	values := make([][]byte, len(keys))
+
+	for i, key := range keys {
+		value, err := s.Get(key)
+		if err != nil && !errors.Is(err, storage.ErrDataNotFound) {
+			return nil, err
+		}
+
+		values[i] = value
+	}
+
+	return values, nil
+}
+
+// Query returns the entries matching the basic expression TagName[:TagValue], paged with the query options.
+// Sort options are not supported; entries are returned in key order.
+func (s *Store) Query(expression string, options ...storage.QueryOption) (storage.Iterator, error) {
+	filter, err := tagFilter(expression)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := &storage.QueryOptions{PageSize: defaultPageSize}
+
+	for _, option := range options {
+		option(opts)
+	}
+
+	if opts.PageSize <= 0 {
+		opts.PageSize = defaultPageSize
+	}
+
+	return &iterator{
+		store:    s,
+		filter:   filter,
+		pageSize: opts.PageSize,
+		offset:   opts.InitialPageNum * opts.PageSize,
+	}, nil
+}
+
+// Delete deletes the entry stored under key.
+func (s *Store) Delete(key string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	return s.delete(ctx, s.db, key)
+}
+
+// Batch performs the operations in order within a single transaction. Operations with a nil value are deletes.
+func (s *Store) Batch(operations []storage.Operation) error {
+	if len(operations) == 0 {
+		return errors.New("batch requires at least one operation")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction on store %s: %w", s.name, err)
+	}
+
+	for _, op := range operations {
+		if op.Value == nil {
+			err = s.delete(ctx, tx, op.Key)
+		} else {
+			err = s.put(ctx, tx, op.Key, op.Value, op.Tags)
+		}
+
+		if err != nil {
+			_ = tx.Rollback() // nolint:errcheck // the operation error is more relevant
+
+			return err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("failed to commit transaction on store %s: %w", s.name, err)
+	}
+
+	return nil
+}
+
+// Flush is a no-op since operations are not queued.
+func (s *Store) Flush() error {
+	return nil
+}
+
+// Close removes the store from the provider's open stores.
+func (s *Store) Close() error {
+	s.close(s.name)
+
+	return nil
+}
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+func (s *Store) put(ctx context.Context, db execer, key string, value []byte, tags []storage.Tag) error {
+	if key == "" {
+		return errors.New("key cannot be empty")
+	}
+
+	if value == nil {
+		return errors.New("value cannot be nil")
+	}
+
+	raw, err := marshalTags(tags)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.ExecContext(ctx, "INSERT INTO "+s.table+" (key, value, tags) VALUES ($1, $2, $3)"+
+		" ON CONFLICT (key) DO UPDATE SET value = EXCLUDED.value, tags = EXCLUDED.tags", key, value, raw)
+	if err != nil {
+		return fmt.Errorf("failed to put %s in store %s: %w", key, s.name, err)
+	}
+
+	return nil
+}
+
+func (s *Store) delete(ctx context.Context, db execer, key string) error {
+	if key == "" {
+		return errors.New("key cannot be empty")
+	}
+
+	_, err := db.ExecContext(ctx, "DELETE FROM "+s.table+" WHERE key = $1", key)
+	if err != nil {
+		return fmt.Errorf("failed to delete %s from store %s: %w", key, s.name, err)
+	}
+
+	return nil
+}
+
+type entry struct {
+	key   string
+	value []byte
+	tags  []byte
+}
+
+// iterator fetches query results page by page.
+type iterator struct {
+	store    *Store
+	filter   []byte
+	pageSize int
+	offset   int
+	page     []entry
+	current  int
+	done     bool
+}
+
+func (i *iterator) Next() (bool, error) {
+	if i.current+1 < len(i.page) {
+		i.current++
+
+		return true, nil
+	}
+
+	if i.done {
+		return false, nil
+	}
+
+	page, err := i.fetchPage()
+	if err != nil {
+		return false, err
+	}
+
+	i.page, i.current = page, 0
+	i.offset += len(page)
+	i.done = len(page) < i.pageSize
+
+	return len(page) > 0, nil
+}
+
+func (i *iterator) Key() (string, error) {
+	e, err := i.entry()
+	if err != nil {
+		return "", err
+	}
+
+	return e.key, nil
+}
+
+func (i *iterator) Value() ([]byte, error) {
+	e, err := i.entry()
+	if err != nil {
+		return nil, err
+	}
+
+	return e.value, nil
+}
+
+func (i *iterator) Tags() ([]storage.Tag, error) {
+	e, err := i.entry()
+	if err != nil {
+		return nil, err
+	}
+
+	return unmarshalTags(e.tags)
+}
+
+func (i *iterator) TotalItems() (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), i.store.timeout)
+	defer cancel()
+
+	var total int
+
+	err := i.store.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+i.store.table+" WHERE tags @> $1", i.filter).
+		Scan(&total)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count query results in store %s: %w", i.store.name, err)
+	}
+
+	return total, nil
+}
+
+func (i *iterator) Close() error {
+	i.page, i.done = nil, true
+
+	return nil
+}
+
+func (i *iterator) entry() (*entry, error) {
+	if i.current >= len(i.page) {
+		return nil, errors.New("iterator is exhausted")
+	}
+
+	return &i.page[i.current], nil
+}
+
+func (i *iterator) fetchPage() ([]entry, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), i.store.timeout)
+	defer cancel()
+
+	rows, err := i.store.db.QueryContext(ctx, "SELECT key, value, tags FROM "+i.store.table+
+		" WHERE tags @> $1 ORDER BY key LIMIT $2 OFFSET $3", i.filter, i.pageSize, i.offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query store %s: %w", i.store.name, err)
+	}
+
+	defer func() {
+		_ = rows.Close() // nolint:errcheck // rows.Err reports iteration errors
+	}()
+
+	page := make([]entry, 0, i.pageSize)
+
+	for rows.Next() {
+		var e entry
+
+		err = rows.Scan(&e.key, &e.value, &e.tags)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read query result from store %s: %w", i.store.name, err)
+		}
+
+		page = append(page, e)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("failed to query store %s: %w", i.store.name, err)
+	}
+
+	return page, nil
+}
+
+// tagFilter converts a basic query expression into a JSONB containment filter on the tags column.
+func tagFilter(expression string) ([]byte, error) {
+	if expression == "" {
+		return nil, errors.New("expression cannot be empty")
+	}
+
+	if strings.Contains(expression, "&&") || strings.Contains(expression, "||") {
+		return nil, errors.New("advanced query expressions are not supported")
+	}
+
+	parts := strings.Split(expression, ":")
+
+	var tag storage.Tag
+
+	switch len(parts) {
+	case expressionTagNameOnlyLength:
+		tag = storage.Tag{Name: parts[0]}
+	case expressionTagNameAndValueLength:
+		tag = storage.Tag{Name: parts[0], Value: parts[1]}
+	default:
+		return nil, fmt.Errorf("invalid expression format: %s", expression)
+	}
+
+	return marshalTags([]storage.Tag{tag})
+}
+
+func marshalTags(tags []storage.Tag) ([]byte, error) {
+	names := make(map[string]struct{}, len(tags))
+
+	for _, tag := range tags {
+		if strings.Contains(tag.Name, ":") || strings.Contains(tag.Value, ":") {
+			return nil, fmt.Errorf("invalid tag %s: tags cannot contain any ':' characters", tag.Name)
+		}
+
+		if _, exists := names[tag.Name]; exists {
+			return nil, fmt.Errorf("tag name %s is used more than once", tag.Name)
+		}
+
+		names[tag.Name] = struct{}{}
+	}
+
+	if tags == nil {
+		tags = []storage.Tag{}
+	}
+
+	raw, err := json.Marshal(tags)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal tags: %w", err)
+	}
+
+	return raw, nil
+}
+
+func unmarshalTags(raw []byte) ([]storage.Tag, error) {
+	var tags []storage.Tag
+
+	err := json.Unmarshal(raw, &tags)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal tags: %w", err)
+	}
+
+	return tags, nil
+}
+
+func quoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
@@ -0,0 +1,699 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package postgres_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/hyperledger/aries-framework-go/spi/storage"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/ace/pkg/storage/conditional"
+	"github.com/trustbloc/ace/pkg/storage/postgres"
+)
+
+func TestNewProvider(t *testing.T) {
+	t.Run("connects to the database", func(t *testing.T) {
+		p := newProvider(t)
+		require.NoError(t, p.Close())
+	})
+
+	t.Run("error if driver is not registered", func(t *testing.T) {
+		_, err := postgres.NewProvider("postgres://localhost", postgres.WithDriverName("unregistered"))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to open connection to PostgreSQL")
+	})
+
+	t.Run("error if database is unreachable", func(t *testing.T) {
+		db := newFakeDB(t)
+		db.err = errors.New("test")
+
+		_, err := postgres.NewProvider("postgres://localhost", postgres.WithDriverName(db.name))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to connect to PostgreSQL")
+	})
+}
+
+func TestProvider_OpenStore(t *testing.T) {
+	t.Run("creates a table per store", func(t *testing.T) {
+		db := newFakeDB(t)
+		p := newProviderWithDB(t, db, postgres.WithDBPrefix("prefix_"))
+
+		s1, err := p.OpenStore("Policies")
+		require.NoError(t, err)
+
+		s2, err := p.OpenStore("policies")
+		require.NoError(t, err)
+		require.Same(t, s1, s2)
+		require.Contains(t, db.tables, `"prefix_policies"`)
+		require.Len(t, p.GetOpenStores(), 1)
+
+		require.NoError(t, s1.Close())
+		require.Empty(t, p.GetOpenStores())
+	})
+
+	t.Run("error if name is empty", func(t *testing.T) {
+		_, err := newProvider(t).OpenStore("")
+		require.EqualError(t, err, "store name cannot be empty")
+	})
+
+	t.Run("error if table cannot be created", func(t *testing.T) {
+		db := newFakeDB(t)
+		p := newProviderWithDB(t, db)
+		db.err = errors.New("test")
+
+		_, err := p.OpenStore("test")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to create table for store test")
+	})
+}
+
+func TestProvider_StoreConfig(t *testing.T) {
+	t.Run("sets and gets store config", func(t *testing.T) {
+		db := newFakeDB(t)
+		p := newProviderWithDB(t, db)
+
+		_, err := p.OpenStore("test")
+		require.NoError(t, err)
+
+		config := storage.StoreConfiguration{TagNames: []string{"policy"}}
+
+		require.NoError(t, p.SetStoreConfig("test", config))
+		require.Contains(t, db.indexes, `"test_tags"`)
+
+		result, err := p.GetStoreConfig("test")
+		require.NoError(t, err)
+		require.Equal(t, config, result)
+	})
+
+	t.Run("error if store is not open", func(t *testing.T) {
+		p := newProvider(t)
+
+		err := p.SetStoreConfig("test", storage.StoreConfiguration{})
+		require.ErrorIs(t, err, storage.ErrStoreNotFound)
+
+		_, err = p.GetStoreConfig("test")
+		require.ErrorIs(t, err, storage.ErrStoreNotFound)
+	})
+
+	t.Run("error if tag name is invalid", func(t *testing.T) {
+		p := newProvider(t)
+
+		err := p.SetStoreConfig("test", storage.StoreConfiguration{TagNames: []string{"a:b"}})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid tag name a:b")
+	})
+
+	t.Run("error if index cannot be created", func(t *testing.T) {
+		db := newFakeDB(t)
+		p := newProviderWithDB(t, db)
+
+		_, err := p.OpenStore("test")
+		require.NoError(t, err)
+
+		db.err = errors.New("test")
+
+		err = p.SetStoreConfig("test", storage.StoreConfiguration{TagNames: []string{"policy"}})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to create tags index for store test")
+
+		_, err = p.GetStoreConfig("test")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to look up table for store test")
+	})
+}
+
+func TestStore_PutGetDelete(t *testing.T) {
+	t.Run("round trip", func(t *testing.T) {
+		s := openStore(t)
+		tags := []storage.Tag{{Name: "policy", Value: "123"}}
+
+		require.NoError(t, s.Put("key", []byte("value"), tags...))
+
+		value, err := s.Get("key")
+		require.NoError(t, err)
+		require.Equal(t, []byte("value"), value)
+
+		result, err := s.GetTags("key")
+		require.NoError(t, err)
+		require.Equal(t, tags, result)
+
+		require.NoError(t, s.Put("key", []byte("updated")))
+
+		value, err = s.Get("key")
+		require.NoError(t, err)
+		require.Equal(t, []byte("updated"), value)
+
+		values, err := s.GetBulk("key", "missing")
+		require.NoError(t, err)
+		require.Equal(t, [][]byte{[]byte("updated"), nil}, values)
+
+		require.NoError(t, s.Delete("key"))
+
+		_, err = s.Get("key")
+		require.ErrorIs(t, err, storage.ErrDataNotFound)
+
+		_, err = s.GetTags("key")
+		require.ErrorIs(t, err, storage.ErrDataNotFound)
+		require.NoError(t, s.Flush())
+	})
+
+	t.Run("error if arguments are invalid", func(t *testing.T) {
+		s := openStore(t)
+
+		require.EqualError(t, s.Put("", []byte("value")), "key cannot be empty")
+		require.EqualError(t, s.Put("key", nil), "value cannot be nil")
+		require.EqualError(t, s.Delete(""), "key cannot be empty")
+
+		err := s.Put("key", []byte("value"), storage.Tag{Name: "a:b"})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "tags cannot contain any ':' characters")
+
+		err = s.Put("key", []byte("value"), storage.Tag{Name: "a"}, storage.Tag{Name: "a"})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "tag name a is used more than once")
+
+		_, err = s.Get("")
+		require.EqualError(t, err, "key cannot be empty")
+
+		_, err = s.GetTags("")
+		require.EqualError(t, err, "key cannot be empty")
+
+		_, err = s.GetBulk("key", "")
+		require.EqualError(t, err, "key cannot be empty")
+	})
+
+	t.Run("error if database fails", func(t *testing.T) {
+		db := newFakeDB(t)
+		s := openStoreWithDB(t, db)
+		db.err = errors.New("test")
+
+		err := s.Put("key", []byte("value"))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to put key in store test")
+
+		_, err = s.Get("key")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to get key from store test")
+
+		_, err = s.GetTags("key")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to get tags of key from store test")
+
+		err = s.Delete("key")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to delete key from store test")
+	})
+}
+
+func TestStore_PutIf(t *testing.T) {
+	revision := func(v string) storage.Tag {
+		return storage.Tag{Name: "revision", Value: v}
+	}
+
+	t.Run("puts the entry if it meets the condition", func(t *testing.T) {
+		s := openStore(t)
+
+		require.NoError(t, conditional.PutIf(s, "key", []byte("v1"), revision(""), revision("1")))
+		require.NoError(t, conditional.PutIf(s, "key", []byte("v2"), revision("1"), revision("2")))
+
+		value, err := s.Get("key")
+		require.NoError(t, err)
+		require.Equal(t, []byte("v2"), value)
+
+		require.NoError(t, s.Put("other", []byte("v1")))
+		require.NoError(t, conditional.PutIf(s, "other", []byte("v2"), revision(""), revision("1")))
+	})
+
+	t.Run("conflict if the entry doesn't meet the condition", func(t *testing.T) {
+		s := openStore(t)
+
+		require.ErrorIs(t, conditional.PutIf(s, "key", []byte("v1"), revision("1")), conditional.ErrConflict)
+
+		require.NoError(t, s.Put("key", []byte("v1"), revision("1")))
+		require.ErrorIs(t, conditional.PutIf(s, "key", []byte("v2"), revision("")), conditional.ErrConflict)
+		require.ErrorIs(t, conditional.PutIf(s, "key", []byte("v2"), revision("2")), conditional.ErrConflict)
+
+		value, err := s.Get("key")
+		require.NoError(t, err)
+		require.Equal(t, []byte("v1"), value)
+	})
+
+	t.Run("error if arguments are invalid", func(t *testing.T) {
+		s := openStore(t)
+
+		require.EqualError(t, conditional.PutIf(s, "", []byte("value"), revision("")), "key cannot be empty")
+		require.EqualError(t, conditional.PutIf(s, "key", nil, revision("")), "value cannot be nil")
+
+		err := conditional.PutIf(s, "key", []byte("value"), revision(""), storage.Tag{Name: "a:b"})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "tags cannot contain any ':' characters")
+	})
+
+	t.Run("error if database fails", func(t *testing.T) {
+		db := newFakeDB(t)
+		s := openStoreWithDB(t, db)
+		db.err = errors.New("test")
+
+		err := conditional.PutIf(s, "key", []byte("value"), revision(""))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to put key in store test")
+	})
+}
+
+func TestStore_Query(t *testing.T) {
+	t.Run("pages through matching entries", func(t *testing.T) {
+		s := openStore(t)
+
+		for i := 0; i < 5; i++ {
+			require.NoError(t, s.Put(fmt.Sprintf("key%d", i), []byte("value"),
+				storage.Tag{Name: "policy", Value: fmt.Sprintf("%d", i%2)}))
+		}
+
+		require.NoError(t, s.Put("other", []byte("value"), storage.Tag{Name: "other"}))
+
+		require.Equal(t, []string{"key0", "key1", "key2", "key3", "key4"},
+			queryKeys(t, s, "policy", storage.WithPageSize(2)))
+		require.Equal(t, []string{"key1", "key3"}, queryKeys(t, s, "policy:1"))
+		require.Equal(t, []string{"key2", "key3", "key4"},
+			queryKeys(t, s, "policy", storage.WithPageSize(2), storage.WithInitialPageNum(1)))
+		require.Empty(t, queryKeys(t, s, "policy:2"))
+
+		iter, err := s.Query("policy:0")
+		require.NoError(t, err)
+
+		total, err := iter.TotalItems()
+		require.NoError(t, err)
+		require.Equal(t, 3, total)
+
+		ok, err := iter.Next()
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		value, err := iter.Value()
+		require.NoError(t, err)
+		require.Equal(t, []byte("value"), value)
+
+		tags, err := iter.Tags()
+		require.NoError(t, err)
+		require.Equal(t, []storage.Tag{{Name: "policy", Value: "0"}}, tags)
+
+		require.NoError(t, iter.Close())
+
+		_, err = iter.Key()
+		require.EqualError(t, err, "iterator is exhausted")
+	})
+
+	t.Run("error if expression is invalid", func(t *testing.T) {
+		s := openStore(t)
+
+		_, err := s.Query("")
+		require.EqualError(t, err, "expression cannot be empty")
+
+		_, err = s.Query("a:b&&c:d")
+		require.EqualError(t, err, "advanced query expressions are not supported")
+
+		_, err = s.Query("a:b:c")
+		require.EqualError(t, err, "invalid expression format: a:b:c")
+	})
+
+	t.Run("error if database fails", func(t *testing.T) {
+		db := newFakeDB(t)
+		s := openStoreWithDB(t, db)
+
+		iter, err := s.Query("policy")
+		require.NoError(t, err)
+
+		db.err = errors.New("test")
+
+		_, err = iter.Next()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to query store test")
+
+		_, err = iter.TotalItems()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to count query results in store test")
+	})
+}
+
+func TestStore_Batch(t *testing.T) {
+	t.Run("applies operations in order", func(t *testing.T) {
+		s := openStore(t)
+
+		require.NoError(t, s.Put("deleted", []byte("value")))
+
+		err := s.Batch([]storage.Operation{
+			{Key: "key", Value: []byte("first")},
+			{Key: "key", Value: []byte("second"), Tags: []storage.Tag{{Name: "policy"}}},
+			{Key: "deleted"},
+		})
+		require.NoError(t, err)
+
+		value, err := s.Get("key")
+		require.NoError(t, err)
+		require.Equal(t, []byte("second"), value)
+
+		_, err = s.Get("deleted")
+		require.ErrorIs(t, err, storage.ErrDataNotFound)
+	})
+
+	t.Run("rolls back on failure", func(t *testing.T) {
+		s := openStore(t)
+
+		err := s.Batch([]storage.Operation{
+			{Key: "key", Value: []byte("value")},
+			{Key: "", Value: []byte("value")},
+		})
+		require.EqualError(t, err, "key cannot be empty")
+
+		_, err = s.Get("key")
+		require.ErrorIs(t, err, storage.ErrDataNotFound)
+	})
+
+	t.Run("error if there are no operations", func(t *testing.T) {
+		require.EqualError(t, openStore(t).Batch(nil), "batch requires at least one operation")
+	})
+
+	t.Run("error if transaction cannot begin", func(t *testing.T) {
+		db := newFakeDB(t)
+		s := openStoreWithDB(t, db)
+		db.err = errors.New("test")
+
+		err := s.Batch([]storage.Operation{{Key: "key", Value: []byte("value")}})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to begin transaction on store test")
+	})
+}
+
+func queryKeys(t *testing.T, s storage.Store, expression string, opts ...storage.QueryOption) []string {
+	t.Helper()
+
+	iter, err := s.Query(expression, opts...)
+	require.NoError(t, err)
+
+	defer storage.Close(iter, nil)
+
+	var keys []string
+
+	for {
+		ok, err := iter.Next()
+		require.NoError(t, err)
+
+		if !ok {
+			return keys
+		}
+
+		key, err := iter.Key()
+		require.NoError(t, err)
+
+		keys = append(keys, key)
+	}
+}
+
+func newProvider(t *testing.T) *postgres.Provider {
+	t.Helper()
+
+	return newProviderWithDB(t, newFakeDB(t))
+}
+
+func newProviderWithDB(t *testing.T, db *fakeDB, opts ...postgres.Option) *postgres.Provider {
+	t.Helper()
+
+	p, err := postgres.NewProvider("postgres://localhost", append(opts, postgres.WithDriverName(db.name))...)
+	require.NoError(t, err)
+
+	return p
+}
+
+func openStore(t *testing.T) storage.Store {
+	t.Helper()
+
+	return openStoreWithDB(t, newFakeDB(t))
+}
+
+func openStoreWithDB(t *testing.T, db *fakeDB) storage.Store {
+	t.Helper()
+
+	s, err := newProviderWithDB(t, db).OpenStore("test")
+	require.NoError(t, err)
+
+	return s
+}
+
+type fakeRow struct {
+	value []byte
+	tags  []byte
+}
+
+// fakeDB is an in-memory database/sql driver that understands the statements issued by the provider.
+type fakeDB struct {
+	name    string
+	err     error
+	mutex   sync.Mutex
+	tables  map[string]map[string]fakeRow
+	indexes map[string]struct{}
+}
+
+func newFakeDB(t *testing.T) *fakeDB {
+	t.Helper()
+
+	db := &fakeDB{
+		name:    "fake-postgres-" + t.Name(),
+		tables:  make(map[string]map[string]fakeRow),
+		indexes: make(map[string]struct{}),
+	}
+
+	sql.Register(db.name, db)
+
+	return db
+}
+
+func (d *fakeDB) Open(string) (driver.Conn, error) {
+	return &fakeConn{db: d}, nil
+}
+
+type fakeConn struct {
+	db       *fakeDB
+	snapshot map[string]map[string]fakeRow
+}
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("not supported")
+}
+
+func (c *fakeConn) Close() error {
+	return nil
+}
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *fakeConn) BeginTx(context.Context, driver.TxOptions) (driver.Tx, error) {
+	c.db.mutex.Lock()
+	defer c.db.mutex.Unlock()
+
+	if c.db.err != nil {
+		return nil, c.db.err
+	}
+
+	c.snapshot = make(map[string]map[string]fakeRow)
+
+	for table, rows := range c.db.tables {
+		c.snapshot[table] = make(map[string]fakeRow)
+
+		for key, row := range rows {
+			c.snapshot[table][key] = row
+		}
+	}
+
+	return c, nil
+}
+
+func (c *fakeConn) Commit() error {
+	c.snapshot = nil
+
+	return nil
+}
+
+func (c *fakeConn) Rollback() error {
+	c.db.mutex.Lock()
+	defer c.db.mutex.Unlock()
+
+	c.db.tables, c.snapshot = c.snapshot, nil
+
+	return nil
+}
+
+func (c *fakeConn) Ping(context.Context) error {
+	return c.db.err
+}
+
+func (c *fakeConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.db.mutex.Lock()
+	defer c.db.mutex.Unlock()
+
+	if c.db.err != nil {
+		return nil, c.db.err
+	}
+
+	fields := strings.Fields(query)
+
+	switch {
+	case strings.HasPrefix(query, "CREATE TABLE IF NOT EXISTS "):
+		if _, ok := c.db.tables[fields[5]]; !ok {
+			c.db.tables[fields[5]] = make(map[string]fakeRow)
+		}
+	case strings.HasPrefix(query, "CREATE INDEX IF NOT EXISTS "):
+		c.db.indexes[fields[5]] = struct{}{}
+	case strings.HasPrefix(query, "INSERT INTO "):
+		row, ok := c.db.tables[fields[2]][args[0].Value.(string)]
+		// the conditional insert doesn't update the rows with the tag of the condition
+		if ok && len(args) == 4 && c.contains(row, args[3].Value.([]byte)) {
+			return driver.RowsAffected(0), nil
+		}
+
+		c.db.tables[fields[2]][args[0].Value.(string)] = fakeRow{
+			value: args[1].Value.([]byte),
+			tags:  args[2].Value.([]byte),
+		}
+	case strings.HasPrefix(query, "UPDATE "):
+		row, ok := c.db.tables[fields[1]][args[0].Value.(string)]
+		if !ok || !c.contains(row, args[3].Value.([]byte)) {
+			return driver.RowsAffected(0), nil
+		}
+
+		c.db.tables[fields[1]][args[0].Value.(string)] = fakeRow{
+			value: args[1].Value.([]byte),
+			tags:  args[2].Value.([]byte),
+		}
+	case strings.HasPrefix(query, "DELETE FROM "):
+		delete(c.db.tables[fields[2]], args[0].Value.(string))
+	default:
+		return nil, fmt.Errorf("unexpected statement: %s", query)
+	}
+
+	return driver.RowsAffected(1), nil
+}
+
+func (c *fakeConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.db.mutex.Lock()
+	defer c.db.mutex.Unlock()
+
+	if c.db.err != nil {
+		return nil, c.db.err
+	}
+
+	fields := strings.Fields(query)
+
+	switch {
+	case strings.HasPrefix(query, "SELECT to_regclass($1)::text"):
+		name := args[0].Value.(string)
+		if _, ok := c.db.tables[name]; ok {
+			return &fakeRows{values: [][]driver.Value{{name}}}, nil
+		}
+
+		return &fakeRows{values: [][]driver.Value{{nil}}}, nil
+	case strings.HasPrefix(query, "SELECT value FROM "), strings.HasPrefix(query, "SELECT tags FROM "):
+		row, ok := c.db.tables[fields[3]][args[0].Value.(string)]
+		if !ok {
+			return &fakeRows{}, nil
+		}
+
+		if fields[1] == "value" {
+			return &fakeRows{values: [][]driver.Value{{row.value}}}, nil
+		}
+
+		return &fakeRows{values: [][]driver.Value{{row.tags}}}, nil
+	case strings.HasPrefix(query, "SELECT COUNT(*) FROM "):
+		return &fakeRows{values: [][]driver.Value{{int64(len(c.matches(fields[3], args[0].Value.([]byte))))}}}, nil
+	case strings.HasPrefix(query, "SELECT key, value, tags FROM "):
+		keys := c.matches(fields[5], args[0].Value.([]byte))
+		limit, offset := int(args[1].Value.(int64)), int(args[2].Value.(int64))
+
+		rows := &fakeRows{}
+
+		for i := offset; i < len(keys) && i < offset+limit; i++ {
+			row := c.db.tables[fields[5]][keys[i]]
+			rows.values = append(rows.values, []driver.Value{keys[i], row.value, row.tags})
+		}
+
+		return rows, nil
+	default:
+		return nil, fmt.Errorf("unexpected query: %s", query)
+	}
+}
+
+// matches returns the sorted keys of the rows whose tags contain the filter.
+func (c *fakeConn) matches(table string, filter []byte) []string {
+	var keys []string
+
+	for key, row := range c.db.tables[table] {
+		if c.contains(row, filter) {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+// contains tells if the tags of the row contain the filter, a tag without a value being contained by the tags of any
+// value.
+func (c *fakeConn) contains(row fakeRow, filter []byte) bool {
+	var want, tags []storage.Tag
+
+	_ = json.Unmarshal(filter, &want)   // nolint:errcheck // the provider always sends valid json
+	_ = json.Unmarshal(row.tags, &tags) // nolint:errcheck // the provider always sends valid json
+
+	for _, tag := range tags {
+		if tag.Name == want[0].Name && (want[0].Value == "" || tag.Value == want[0].Value) {
+			return true
+		}
+	}
+
+	return false
+}
+
+type fakeRows struct {
+	values [][]driver.Value
+	next   int
+}
+
+func (r *fakeRows) Columns() []string {
+	if len(r.values) == 0 {
+		return []string{"value"}
+	}
+
+	return make([]string, len(r.values[0]))
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.next >= len(r.values) {
+		return io.EOF
+	}
+
+	copy(dest, r.values[r.next])
+	r.next++
+
+	return nil
+}
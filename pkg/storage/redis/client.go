@@ -0,0 +1,326 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package redis
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/trustbloc/ace/pkg/storage/cache"
+)
+
+const (
+	defaultTimeout  = 5 * time.Second
+	defaultPoolSize = 10
+)
+
+// replaceScript sets the key to ARGV[2], expiring after ARGV[3] milliseconds unless it is 0, if it holds ARGV[1].
+const replaceScript = `if redis.call('GET', KEYS[1]) ~= ARGV[1] then return 0 end
+if ARGV[3] == '0' then redis.call('SET', KEYS[1], ARGV[2]) else redis.call('SET', KEYS[1], ARGV[2], 'PX', ARGV[3]) end
+return 1`
+
+// Option configures the Redis client.
+type Option func(c *Client)
+
+// WithTimeout sets the timeout for dialing and for each command. Defaults to 5 seconds.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.timeout = timeout
+	}
+}
+
+// WithPoolSize sets the maximum number of idle connections kept open. Defaults to 10.
+func WithPoolSize(size int) Option {
+	return func(c *Client) {
+		c.poolSize = size
+	}
+}
+
+// Client is a minimal Redis client implementing cache.Cache with the GET, SET, DEL and EVAL commands.
+type Client struct {
+	addr     string
+	password string
+	db       int
+	timeout  time.Duration
+	poolSize int
+	pool     chan *conn
+}
+
+// New returns a client for the Redis server at rawURL. Format: redis://[:password@]host:port[/db].
+// Connections are established lazily.
+func New(rawURL string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse redis url: %w", err)
+	}
+
+	if u.Scheme != "redis" || u.Host == "" {
+		return nil, fmt.Errorf("invalid redis url %s: format must be redis://[:password@]host:port[/db]", rawURL)
+	}
+
+	c := &Client{
+		addr:     u.Host,
+		timeout:  defaultTimeout,
+		poolSize: defaultPoolSize,
+	}
+
+	if password, ok := u.User.Password(); ok {
+		c.password = password
+	}
+
+	if db := strings.TrimPrefix(u.Path, "/"); db != "" {
+		c.db, err = strconv.Atoi(db)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redis database %s: %w", db, err)
+		}
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	c.pool = make(chan *conn, c.poolSize)
+
+	return c, nil
+}
+
+// Get returns the value stored under key, or cache.ErrCacheMiss.
+func (c *Client) Get(key string) ([]byte, error) {
+	reply, err := c.do("GET", []byte(key))
+	if err != nil {
+		return nil, err
+	}
+
+	if reply == nil {
+		return nil, cache.ErrCacheMiss
+	}
+
+	value, ok := reply.([]byte)
+	if !ok {
+		return nil, fmt.Errorf("unexpected reply to GET: %v", reply)
+	}
+
+	return value, nil
+}
+
+// Set stores value under key, expiring after ttl. A zero ttl never expires.
+func (c *Client) Set(key string, value []byte, ttl time.Duration) error {
+	_, err := c.do("SET", setArgs(key, value, ttl)...)
+
+	return err
+}
+
+// Add stores value under key, expiring after ttl, unless the key is set. It tells if the value is stored.
+func (c *Client) Add(key string, value []byte, ttl time.Duration) (bool, error) {
+	reply, err := c.do("SET", append(setArgs(key, value, ttl), []byte("NX"))...)
+	if err != nil {
+		return false, err
+	}
+
+	return reply != nil, nil
+}
+
+// Replace stores value under key, expiring after ttl, if the key holds old. It tells if the value is stored.
+func (c *Client) Replace(key string, old, value []byte, ttl time.Duration) (bool, error) {
+	reply, err := c.do("EVAL", []byte(replaceScript), []byte("1"), []byte(key), old, value,
+		[]byte(strconv.FormatInt(ttl.Milliseconds(), 10)))
+	if err != nil {
+		return false, err
+	}
+
+	replaced, ok := reply.(int64)
+	if !ok {
+		return false, fmt.Errorf("unexpected reply to EVAL: %v", reply)
+	}
+
+	return replaced == 1, nil
+}
+
+func setArgs(key string, value []byte, ttl time.Duration) [][]byte {
+	args := [][]byte{[]byte(key), value}
+
+	if ttl > 0 {
+		args = append(args, []byte("PX"), []byte(strconv.FormatInt(ttl.Milliseconds(), 10)))
+	}
+
+	return args
+}
+
+// Delete deletes the keys.
+func (c *Client) Delete(keys ...string) error {
+	args := make([][]byte, len(keys))
+
+	for i, key := range keys {
+		args[i] = []byte(key)
+	}
+
+	_, err := c.do("DEL", args...)
+
+	return err
+}
+
+// Close closes the idle connections.
+func (c *Client) Close() error {
+	for {
+		select {
+		case cn := <-c.pool:
+			_ = cn.Close() // nolint:errcheck // nothing to do about it
+		default:
+			return nil
+		}
+	}
+}
+
+// do sends a command and reads its reply, which is nil, a string, an int64 or a []byte.
+func (c *Client) do(command string, args ...[]byte) (interface{}, error) {
+	cn, err := c.conn()
+	if err != nil {
+		return nil, err
+	}
+
+	reply, err := cn.do(c.timeout, command, args...)
+
+	var redisErr Error
+	if err != nil && !errors.As(err, &redisErr) {
+		// the connection is in an unknown state
+		_ = cn.Close() // nolint:errcheck // the command error is more relevant
+
+		return nil, fmt.Errorf("redis %s failed: %w", command, err)
+	}
+
+	c.release(cn)
+
+	if err != nil {
+		return nil, fmt.Errorf("redis %s failed: %w", command, err)
+	}
+
+	return reply, nil
+}
+
+func (c *Client) conn() (*conn, error) {
+	select {
+	case cn := <-c.pool:
+		return cn, nil
+	default:
+	}
+
+	netConn, err := net.DialTimeout("tcp", c.addr, c.timeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to redis at %s: %w", c.addr, err)
+	}
+
+	cn := &conn{Conn: netConn, r: bufio.NewReader(netConn)}
+
+	if c.password != "" {
+		if _, err = cn.do(c.timeout, "AUTH", []byte(c.password)); err != nil {
+			_ = cn.Close() // nolint:errcheck // the auth error is more relevant
+
+			return nil, fmt.Errorf("failed to authenticate to redis: %w", err)
+		}
+	}
+
+	if c.db != 0 {
+		if _, err = cn.do(c.timeout, "SELECT", []byte(strconv.Itoa(c.db))); err != nil {
+			_ = cn.Close() // nolint:errcheck // the select error is more relevant
+
+			return nil, fmt.Errorf("failed to select redis database %d: %w", c.db, err)
+		}
+	}
+
+	return cn, nil
+}
+
+func (c *Client) release(cn *conn) {
+	select {
+	case c.pool <- cn:
+	default:
+		_ = cn.Close() // nolint:errcheck // the pool is full
+	}
+}
+
+// Error is an error reply from the Redis server.
+type Error string
+
+func (e Error) Error() string {
+	return string(e)
+}
+
+type conn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (cn *conn) do(timeout time.Duration, command string, args ...[]byte) (interface{}, error) {
+	err := cn.SetDeadline(time.Now().Add(timeout))
+	if err != nil {
+		return nil, err
+	}
+
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "*%d\r\n$%d\r\n%s\r\n", len(args)+1, len(command), command)
+
+	for _, arg := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+
+	_, err = io.WriteString(cn.Conn, b.String())
+	if err != nil {
+		return nil, err
+	}
+
+	return cn.readReply()
+}
+
+func (cn *conn) readReply() (interface{}, error) {
+	line, err := cn.r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+
+	line = strings.TrimSuffix(line, "\r\n")
+
+	if line == "" {
+		return nil, errors.New("empty reply")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, Error(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		size, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid bulk reply length: %w", err)
+		}
+
+		if size < 0 {
+			return nil, nil
+		}
+
+		value := make([]byte, size+2) // includes the trailing \r\n
+
+		_, err = io.ReadFull(cn.r, value)
+		if err != nil {
+			return nil, err
+		}
+
+		return value[:size], nil
+	default:
+		return nil, fmt.Errorf("unsupported reply: %s", line)
+	}
+}
@@ -0,0 +1,303 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package redis_test
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/ace/pkg/storage/cache"
+	"github.com/trustbloc/ace/pkg/storage/redis"
+)
+
+func TestNew(t *testing.T) {
+	t.Run("error if url is invalid", func(t *testing.T) {
+		_, err := redis.New("://invalid")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to parse redis url")
+
+		_, err = redis.New("http://localhost:6379")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "format must be redis://")
+
+		_, err = redis.New("redis://localhost:6379/first")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid redis database first")
+	})
+}
+
+func TestClient(t *testing.T) {
+	t.Run("get, set and delete", func(t *testing.T) {
+		srv := newMockServer(t)
+
+		c, err := redis.New("redis://"+srv.addr, redis.WithPoolSize(1))
+		require.NoError(t, err)
+
+		defer func() {
+			require.NoError(t, c.Close())
+		}()
+
+		_, err = c.Get("key")
+		require.ErrorIs(t, err, cache.ErrCacheMiss)
+
+		require.NoError(t, c.Set("key", []byte("value\r\nwith line breaks"), time.Minute))
+		require.Equal(t, "60000", srv.expiry["key"])
+
+		value, err := c.Get("key")
+		require.NoError(t, err)
+		require.Equal(t, []byte("value\r\nwith line breaks"), value)
+
+		require.NoError(t, c.Set("key2", []byte("value"), 0))
+		require.Empty(t, srv.expiry["key2"])
+
+		require.NoError(t, c.Delete("key", "key2"))
+
+		_, err = c.Get("key")
+		require.ErrorIs(t, err, cache.ErrCacheMiss)
+
+		require.Equal(t, 1, srv.connections)
+	})
+
+	t.Run("add and replace", func(t *testing.T) {
+		srv := newMockServer(t)
+
+		c, err := redis.New("redis://" + srv.addr)
+		require.NoError(t, err)
+
+		defer func() {
+			require.NoError(t, c.Close())
+		}()
+
+		added, err := c.Add("key", []byte("lease"), time.Second)
+		require.NoError(t, err)
+		require.True(t, added)
+		require.Equal(t, "1000", srv.expiry["key"])
+
+		added, err = c.Add("key", []byte("other"), time.Second)
+		require.NoError(t, err)
+		require.False(t, added)
+
+		replaced, err := c.Replace("key", []byte("other"), []byte("value"), time.Minute)
+		require.NoError(t, err)
+		require.False(t, replaced)
+
+		replaced, err = c.Replace("key", []byte("lease"), []byte("value"), time.Minute)
+		require.NoError(t, err)
+		require.True(t, replaced)
+		require.Equal(t, "60000", srv.expiry["key"])
+
+		value, err := c.Get("key")
+		require.NoError(t, err)
+		require.Equal(t, []byte("value"), value)
+	})
+
+	t.Run("authenticates and selects the database", func(t *testing.T) {
+		srv := newMockServer(t)
+		srv.password = "secret"
+
+		c, err := redis.New("redis://:secret@"+srv.addr+"/2", redis.WithTimeout(time.Second))
+		require.NoError(t, err)
+
+		require.NoError(t, c.Set("key", []byte("value"), 0))
+		require.Equal(t, "2", srv.db)
+	})
+
+	t.Run("error if authentication fails", func(t *testing.T) {
+		srv := newMockServer(t)
+		srv.password = "secret"
+
+		c, err := redis.New("redis://:wrong@" + srv.addr)
+		require.NoError(t, err)
+
+		_, err = c.Get("key")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to authenticate to redis: ERR invalid password")
+	})
+
+	t.Run("error replies keep the connection", func(t *testing.T) {
+		srv := newMockServer(t)
+
+		c, err := redis.New("redis://" + srv.addr)
+		require.NoError(t, err)
+
+		err = c.Delete()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "redis DEL failed: ERR wrong number of arguments")
+
+		require.NoError(t, c.Set("key", []byte("value"), 0))
+		require.Equal(t, 1, srv.connections)
+	})
+
+	t.Run("error if server is unreachable", func(t *testing.T) {
+		c, err := redis.New("redis://127.0.0.1:1", redis.WithTimeout(time.Second))
+		require.NoError(t, err)
+
+		_, err = c.Get("key")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to connect to redis")
+	})
+}
+
+// mockServer implements the subset of the Redis protocol used by the client.
+type mockServer struct {
+	addr        string
+	password    string
+	mutex       sync.Mutex
+	values      map[string]string
+	expiry      map[string]string
+	db          string
+	connections int
+}
+
+func newMockServer(t *testing.T) *mockServer {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		require.NoError(t, l.Close())
+	})
+
+	srv := &mockServer{addr: l.Addr().String(), values: map[string]string{}, expiry: map[string]string{}}
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+
+			srv.mutex.Lock()
+			srv.connections++
+			srv.mutex.Unlock()
+
+			go srv.serve(conn)
+		}
+	}()
+
+	return srv
+}
+
+func (s *mockServer) serve(conn net.Conn) {
+	defer conn.Close() // nolint:errcheck // test server
+
+	r := bufio.NewReader(conn)
+
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+
+		_, err = io.WriteString(conn, s.handle(args))
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (s *mockServer) handle(args []string) string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	switch {
+	case args[0] == "AUTH" && len(args) == 2:
+		if args[1] != s.password {
+			return "-ERR invalid password\r\n"
+		}
+
+		return "+OK\r\n"
+	case args[0] == "SELECT" && len(args) == 2:
+		s.db = args[1]
+
+		return "+OK\r\n"
+	case args[0] == "GET" && len(args) == 2:
+		value, ok := s.values[args[1]]
+		if !ok {
+			return "$-1\r\n"
+		}
+
+		return fmt.Sprintf("$%d\r\n%s\r\n", len(value), value)
+	case args[0] == "SET" && len(args) >= 3 && len(args) <= 6:
+		if _, ok := s.values[args[1]]; ok && args[len(args)-1] == "NX" {
+			return "$-1\r\n"
+		}
+
+		s.values[args[1]] = args[2]
+
+		if len(args) >= 5 {
+			s.expiry[args[1]] = args[4]
+		}
+
+		return "+OK\r\n"
+	case args[0] == "EVAL" && len(args) == 7:
+		// the replace script
+		if value, ok := s.values[args[3]]; !ok || value != args[4] {
+			return ":0\r\n"
+		}
+
+		s.values[args[3]] = args[5]
+		s.expiry[args[3]] = args[6]
+
+		return ":1\r\n"
+	case args[0] == "DEL" && len(args) > 1:
+		for _, key := range args[1:] {
+			delete(s.values, key)
+		}
+
+		return ":" + strconv.Itoa(len(args)-1) + "\r\n"
+	default:
+		return "-ERR wrong number of arguments\r\n"
+	}
+}
+
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+
+	n, err := strconv.Atoi(strings.TrimSuffix(line[1:], "\r\n"))
+	if err != nil {
+		return nil, err
+	}
+
+	args := make([]string, n)
+
+	for i := range args {
+		line, err = r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+
+		size, err := strconv.Atoi(strings.TrimSuffix(line[1:], "\r\n"))
+		if err != nil {
+			return nil, err
+		}
+
+		arg := make([]byte, size+2)
+
+		_, err = io.ReadFull(r, arg)
+		if err != nil {
+			return nil, err
+		}
+
+		args[i] = string(arg[:size])
+	}
+
+	return args, nil
+}
@@ -0,0 +1,110 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package tombstone
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/aries-framework-go/pkg/common/log"
+	"github.com/hyperledger/aries-framework-go/spi/storage"
+
+	"github.com/trustbloc/ace/pkg/lease"
+)
+
+const (
+	// DefaultRetention is the default time deleted entries are kept before they are purged.
+	DefaultRetention = 30 * 24 * time.Hour
+	// DefaultPurgeInterval is the default time between two purges.
+	DefaultPurgeInterval = time.Hour
+)
+
+var logger = log.New("storage-tombstone")
+
+// Purger periodically purges the tombstones of stores once their retention window has passed.
+type Purger struct {
+	stores    map[string]storage.Store
+	retention time.Duration
+	interval  time.Duration
+	leader    *lease.Lease
+	done      chan struct{}
+	wg        sync.WaitGroup
+}
+
+// NewPurger returns a purger for the tombstones of the named stores of the provider.
+func NewPurger(provider storage.Provider, retention, interval time.Duration, names ...string) (*Purger, error) {
+	stores := make(map[string]storage.Store, len(names))
+
+	for _, name := range names {
+		store, err := provider.OpenStore(StoreName(name))
+		if err != nil {
+			return nil, fmt.Errorf("open tombstone store %s: %w", name, err)
+		}
+
+		stores[name] = store
+	}
+
+	return &Purger{
+		stores:    stores,
+		retention: retention,
+		interval:  interval,
+		done:      make(chan struct{}),
+	}, nil
+}
+
+// SetLeader makes the purger purge the stores only while the instance holds the lease, so that the instances sharing
+// the stores don't all purge them.
+func (p *Purger) SetLeader(l *lease.Lease) {
+	p.leader = l
+}
+
+// Start purges the stores every interval until Stop is called.
+func (p *Purger) Start() {
+	p.wg.Add(1)
+
+	go func() {
+		defer p.wg.Done()
+
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if p.leader == nil || p.leader.Held() {
+					p.Purge(time.Now())
+				}
+			case <-p.done:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops purging and waits for a running purge to finish.
+func (p *Purger) Stop() {
+	close(p.done)
+	p.wg.Wait()
+}
+
+// Purge removes the tombstones of the entries deleted more than the retention window before now. Failures are
+// logged and do not prevent the other stores from being purged.
+func (p *Purger) Purge(now time.Time) {
+	for name, store := range p.stores {
+		n, err := Purge(store, now.Add(-p.retention))
+		if err != nil {
+			logger.Errorf("failed to purge deleted entries from store %s: %s", name, err)
+
+			continue
+		}
+
+		if n > 0 {
+			logger.Infof("purged %d deleted entries from store %s", n, name)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package tombstone_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/aries-framework-go/component/storageutil/mem"
+	mockstorage "github.com/hyperledger/aries-framework-go/pkg/mock/storage"
+	"github.com/hyperledger/aries-framework-go/spi/storage"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/ace/pkg/lease"
+	"github.com/trustbloc/ace/pkg/storage/tombstone"
+)
+
+func TestPurger(t *testing.T) {
+	t.Run("purges tombstones after the retention window", func(t *testing.T) {
+		provider, store, tombstones := newTombstones(t)
+
+		require.NoError(t, store.Put("key", []byte("value")))
+		require.NoError(t, tombstones.Delete(time.Now().Add(-2*time.Hour), "key"))
+
+		purger, err := tombstone.NewPurger(provider, time.Hour, time.Millisecond, storeName, "other")
+		require.NoError(t, err)
+
+		purger.Start()
+
+		require.Eventually(t, func() bool {
+			_, err = tombstones.Get("key")
+
+			return errors.Is(err, storage.ErrDataNotFound)
+		}, time.Second, time.Millisecond)
+
+		purger.Stop()
+	})
+
+	t.Run("purges only while the instance holds the lease", func(t *testing.T) {
+		provider, store, tombstones := newTombstones(t)
+
+		require.NoError(t, store.Put("key", []byte("value")))
+		require.NoError(t, tombstones.Delete(time.Now().Add(-2*time.Hour), "key"))
+
+		other, err := lease.New(provider, "purge")
+		require.NoError(t, err)
+
+		other.Start()
+
+		leader, err := lease.New(provider, "purge", lease.WithTTL(30*time.Millisecond))
+		require.NoError(t, err)
+
+		leader.Start()
+		defer leader.Stop()
+
+		purger, err := tombstone.NewPurger(provider, time.Hour, time.Millisecond, storeName)
+		require.NoError(t, err)
+
+		purger.SetLeader(leader)
+		purger.Start()
+
+		defer purger.Stop()
+
+		time.Sleep(10 * time.Millisecond)
+
+		_, err = tombstones.Get("key")
+		require.NoError(t, err)
+
+		other.Stop()
+
+		require.Eventually(t, func() bool {
+			_, err = tombstones.Get("key")
+
+			return errors.Is(err, storage.ErrDataNotFound)
+		}, 2*time.Second, time.Millisecond)
+	})
+
+	t.Run("keeps tombstones within the retention window", func(t *testing.T) {
+		provider, store, tombstones := newTombstones(t)
+
+		require.NoError(t, store.Put("key", []byte("value")))
+		require.NoError(t, tombstones.Delete(time.Now(), "key"))
+
+		purger, err := tombstone.NewPurger(provider, tombstone.DefaultRetention, tombstone.DefaultPurgeInterval, storeName)
+		require.NoError(t, err)
+
+		purger.Purge(time.Now())
+
+		_, err = tombstones.Get("key")
+		require.NoError(t, err)
+
+		purger.Purge(time.Now().Add(tombstone.DefaultRetention + time.Minute))
+
+		_, err = tombstones.Get("key")
+		require.ErrorIs(t, err, storage.ErrDataNotFound)
+	})
+
+	t.Run("continues if a store cannot be purged", func(t *testing.T) {
+		provider := &failingProvider{
+			Provider:      mem.NewProvider(),
+			errBatch:      errors.New("batch error"),
+			errBatchStore: tombstone.StoreName("failing"),
+		}
+
+		store, err := provider.OpenStore(storeName)
+		require.NoError(t, err)
+
+		tombstones, err := tombstone.New(provider, storeName, store)
+		require.NoError(t, err)
+
+		failing, err := provider.OpenStore(tombstone.StoreName("failing"))
+		require.NoError(t, err)
+
+		require.NoError(t, failing.Put("key", []byte("{}"), storage.Tag{Name: tombstone.Tag, Value: "0"}))
+		require.NoError(t, store.Put("key", []byte("value")))
+		require.NoError(t, tombstones.Delete(time.Unix(0, 0), "key"))
+
+		purger, err := tombstone.NewPurger(provider, time.Hour, time.Hour, "failing", storeName)
+		require.NoError(t, err)
+
+		purger.Purge(time.Now())
+
+		_, err = failing.Get("key")
+		require.NoError(t, err)
+
+		_, err = tombstones.Get("key")
+		require.ErrorIs(t, err, storage.ErrDataNotFound)
+	})
+
+	t.Run("error if tombstone store cannot be opened", func(t *testing.T) {
+		provider := mockstorage.NewMockStoreProvider()
+		provider.FailNamespace = tombstone.StoreName(storeName)
+
+		_, err := tombstone.NewPurger(provider, time.Hour, time.Hour, storeName)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "open tombstone store test")
+	})
+}
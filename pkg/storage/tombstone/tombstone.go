@@ -0,0 +1,212 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package tombstone
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/hyperledger/aries-framework-go/spi/storage"
+)
+
+// Tag is the name of the tag of tombstones. Its value is the deletion time in Unix seconds.
+const Tag = "deleted"
+
+// StoreName returns the name of the store keeping the tombstones of the named store.
+func StoreName(name string) string {
+	return name + "_deleted"
+}
+
+// ErrEntryExists is returned when restoring an entry that has been created again since it was deleted.
+var ErrEntryExists = errors.New("entry exists")
+
+// Tombstone is a deleted entry.
+type Tombstone struct {
+	Deleted   bool          `json:"deleted"`
+	DeletedAt time.Time     `json:"deleted_at"`
+	Value     []byte        `json:"value"`
+	Tags      []storage.Tag `json:"tags,omitempty"`
+}
+
+// Tombstones soft deletes the entries of a store. Deleted entries are moved to a tombstone store, from which they
+// can be restored until they are purged.
+type Tombstones struct {
+	store      storage.Store
+	tombstones storage.Store
+}
+
+// New returns tombstones for the named store of the provider.
+func New(provider storage.Provider, name string, store storage.Store) (*Tombstones, error) {
+	tombstones, err := provider.OpenStore(StoreName(name))
+	if err != nil {
+		return nil, fmt.Errorf("open tombstone store: %w", err)
+	}
+
+	return &Tombstones{store: store, tombstones: tombstones}, nil
+}
+
+// Delete moves the entries to the tombstone store, recording the deletion time. Missing entries are ignored.
+func (t *Tombstones) Delete(deletedAt time.Time, keys ...string) error {
+	var (
+		tombstones []storage.Operation
+		deletes    []storage.Operation
+	)
+
+	tag := storage.Tag{Name: Tag, Value: strconv.FormatInt(deletedAt.Unix(), 10)}
+
+	for _, key := range keys {
+		value, err := t.store.Get(key)
+		if err != nil {
+			if errors.Is(err, storage.ErrDataNotFound) {
+				continue
+			}
+
+			return fmt.Errorf("get entry %s: %w", key, err)
+		}
+
+		tags, err := t.store.GetTags(key)
+		if err != nil {
+			return fmt.Errorf("get tags %s: %w", key, err)
+		}
+
+		b, err := json.Marshal(&Tombstone{Deleted: true, DeletedAt: deletedAt.UTC(), Value: value, Tags: tags})
+		if err != nil {
+			return fmt.Errorf("marshal tombstone: %w", err)
+		}
+
+		tombstones = append(tombstones, storage.Operation{Key: key, Value: b, Tags: []storage.Tag{tag}})
+		deletes = append(deletes, storage.Operation{Key: key})
+	}
+
+	if len(tombstones) == 0 {
+		return nil
+	}
+
+	// the tombstones are saved first so that an entry is never lost if the second batch fails
+	if err := t.tombstones.Batch(tombstones); err != nil {
+		return fmt.Errorf("save tombstones: %w", err)
+	}
+
+	if err := t.store.Batch(deletes); err != nil {
+		return fmt.Errorf("delete entries: %w", err)
+	}
+
+	return nil
+}
+
+// Get returns the tombstone of a deleted entry.
+func (t *Tombstones) Get(key string) (*Tombstone, error) {
+	b, err := t.tombstones.Get(key)
+	if err != nil {
+		return nil, fmt.Errorf("get tombstone: %w", err)
+	}
+
+	var tombstone Tombstone
+
+	if err = json.Unmarshal(b, &tombstone); err != nil {
+		return nil, fmt.Errorf("unmarshal tombstone: %w", err)
+	}
+
+	return &tombstone, nil
+}
+
+// Restore moves a deleted entry back to the store with its tags.
+func (t *Tombstones) Restore(key string) error {
+	tombstone, err := t.Get(key)
+	if err != nil {
+		return err
+	}
+
+	_, err = t.store.Get(key)
+	if err == nil {
+		return ErrEntryExists
+	}
+
+	if !errors.Is(err, storage.ErrDataNotFound) {
+		return fmt.Errorf("get entry: %w", err)
+	}
+
+	if err = t.store.Put(key, tombstone.Value, tombstone.Tags...); err != nil {
+		return fmt.Errorf("restore entry: %w", err)
+	}
+
+	if err = t.tombstones.Delete(key); err != nil {
+		return fmt.Errorf("delete tombstone: %w", err)
+	}
+
+	return nil
+}
+
+// Purge permanently removes the tombstones of the entries deleted before the given time and returns their number.
+func Purge(tombstones storage.Store, before time.Time) (int, error) {
+	iter, err := tombstones.Query(Tag)
+	if err != nil {
+		return 0, fmt.Errorf("query tombstones: %w", err)
+	}
+
+	defer storage.Close(iter, logger)
+
+	var operations []storage.Operation
+
+	for {
+		ok, err := iter.Next()
+		if err != nil {
+			return 0, fmt.Errorf("next tombstone: %w", err)
+		}
+
+		if !ok {
+			break
+		}
+
+		tags, err := iter.Tags()
+		if err != nil {
+			return 0, fmt.Errorf("get tags: %w", err)
+		}
+
+		if !deletedBefore(tags, before) {
+			continue
+		}
+
+		key, err := iter.Key()
+		if err != nil {
+			return 0, fmt.Errorf("get key: %w", err)
+		}
+
+		operations = append(operations, storage.Operation{Key: key})
+	}
+
+	if len(operations) == 0 {
+		return 0, nil
+	}
+
+	if err = tombstones.Batch(operations); err != nil {
+		return 0, fmt.Errorf("purge tombstones: %w", err)
+	}
+
+	return len(operations), nil
+}
+
+func deletedBefore(tags []storage.Tag, before time.Time) bool {
+	for _, tag := range tags {
+		if tag.Name != Tag {
+			continue
+		}
+
+		sec, err := strconv.ParseInt(tag.Value, 10, 64) //nolint:gomnd
+		if err != nil {
+			// a tombstone with an unreadable deletion time would otherwise never be purged
+			return true
+		}
+
+		return time.Unix(sec, 0).Before(before)
+	}
+
+	return false
+}
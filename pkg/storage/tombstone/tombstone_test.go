@@ -0,0 +1,318 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package tombstone_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/aries-framework-go/component/storageutil/mem"
+	mockstorage "github.com/hyperledger/aries-framework-go/pkg/mock/storage"
+	"github.com/hyperledger/aries-framework-go/spi/storage"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/ace/pkg/storage/tombstone"
+)
+
+const storeName = "test"
+
+func TestNew(t *testing.T) {
+	t.Run("error if tombstone store cannot be opened", func(t *testing.T) {
+		provider := mockstorage.NewMockStoreProvider()
+		provider.FailNamespace = tombstone.StoreName(storeName)
+
+		_, err := tombstone.New(provider, storeName, provider.Store)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "open tombstone store")
+	})
+}
+
+func TestTombstones(t *testing.T) {
+	t.Run("deletes and restores entries", func(t *testing.T) {
+		provider, store, tombstones := newTombstones(t)
+
+		tag := storage.Tag{Name: "tag", Value: "value"}
+
+		require.NoError(t, store.Put("key1", []byte("value1"), tag))
+		require.NoError(t, store.Put("key2", []byte("value2")))
+
+		deletedAt := time.Now()
+
+		require.NoError(t, tombstones.Delete(deletedAt))
+		require.NoError(t, tombstones.Delete(deletedAt, "key1", "key2", "missing"))
+
+		_, err := store.Get("key1")
+		require.ErrorIs(t, err, storage.ErrDataNotFound)
+
+		_, err = store.Get("key2")
+		require.ErrorIs(t, err, storage.ErrDataNotFound)
+
+		ts, err := tombstones.Get("key1")
+		require.NoError(t, err)
+		require.True(t, ts.Deleted)
+		require.Equal(t, deletedAt.Unix(), ts.DeletedAt.Unix())
+		require.Equal(t, []byte("value1"), ts.Value)
+		require.Equal(t, []storage.Tag{tag}, ts.Tags)
+
+		_, err = tombstones.Get("missing")
+		require.ErrorIs(t, err, storage.ErrDataNotFound)
+
+		require.NoError(t, tombstones.Restore("key1"))
+
+		value, err := store.Get("key1")
+		require.NoError(t, err)
+		require.Equal(t, []byte("value1"), value)
+
+		tags, err := store.GetTags("key1")
+		require.NoError(t, err)
+		require.Equal(t, []storage.Tag{tag}, tags)
+
+		_, err = tombstones.Get("key1")
+		require.ErrorIs(t, err, storage.ErrDataNotFound)
+
+		deleted, err := provider.OpenStore(tombstone.StoreName(storeName))
+		require.NoError(t, err)
+
+		_, err = deleted.Get("key2")
+		require.NoError(t, err)
+	})
+
+	t.Run("error if entry exists again", func(t *testing.T) {
+		_, store, tombstones := newTombstones(t)
+
+		require.NoError(t, store.Put("key", []byte("value")))
+		require.NoError(t, tombstones.Delete(time.Now(), "key"))
+		require.NoError(t, store.Put("key", []byte("new value")))
+
+		err := tombstones.Restore("key")
+		require.ErrorIs(t, err, tombstone.ErrEntryExists)
+
+		value, err := store.Get("key")
+		require.NoError(t, err)
+		require.Equal(t, []byte("new value"), value)
+	})
+
+	t.Run("error if tombstone is invalid", func(t *testing.T) {
+		provider, _, tombstones := newTombstones(t)
+
+		deleted, err := provider.OpenStore(tombstone.StoreName(storeName))
+		require.NoError(t, err)
+
+		require.NoError(t, deleted.Put("key", []byte("invalid")))
+
+		_, err = tombstones.Get("key")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "unmarshal tombstone")
+
+		err = tombstones.Restore("key")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "unmarshal tombstone")
+	})
+
+	t.Run("error if entry cannot be read", func(t *testing.T) {
+		provider := mem.NewProvider()
+
+		store := &mockstorage.MockStore{
+			Store:  map[string]mockstorage.DBEntry{},
+			ErrGet: errors.New("get error"),
+		}
+
+		tombstones, err := tombstone.New(provider, storeName, store)
+		require.NoError(t, err)
+
+		err = tombstones.Delete(time.Now(), "key")
+		require.EqualError(t, err, "get entry key: get error")
+
+		deleted, err := provider.OpenStore(tombstone.StoreName(storeName))
+		require.NoError(t, err)
+
+		require.NoError(t, deleted.Put("key", []byte("{}")))
+
+		err = tombstones.Restore("key")
+		require.EqualError(t, err, "get entry: get error")
+	})
+
+	t.Run("error if entry cannot be deleted", func(t *testing.T) {
+		provider := &failingProvider{Provider: mem.NewProvider()}
+
+		store, err := provider.OpenStore(storeName)
+		require.NoError(t, err)
+
+		tombstones, err := tombstone.New(provider, storeName, store)
+		require.NoError(t, err)
+
+		require.NoError(t, store.Put("key", []byte("value")))
+
+		provider.errBatch = errors.New("batch error")
+
+		err = tombstones.Delete(time.Now(), "key")
+		require.EqualError(t, err, "save tombstones: batch error")
+
+		provider.errBatchStore = storeName
+
+		err = tombstones.Delete(time.Now(), "key")
+		require.EqualError(t, err, "delete entries: batch error")
+	})
+
+	t.Run("error if entry cannot be restored", func(t *testing.T) {
+		provider := &failingProvider{Provider: mem.NewProvider()}
+
+		store, err := provider.OpenStore(storeName)
+		require.NoError(t, err)
+
+		tombstones, err := tombstone.New(provider, storeName, store)
+		require.NoError(t, err)
+
+		require.NoError(t, store.Put("key", []byte("value")))
+		require.NoError(t, tombstones.Delete(time.Now(), "key"))
+
+		provider.errPut = errors.New("put error")
+		provider.errPutStore = storeName
+
+		err = tombstones.Restore("key")
+		require.EqualError(t, err, "restore entry: put error")
+
+		provider.errPut = nil
+		provider.errDelete = errors.New("delete error")
+
+		err = tombstones.Restore("key")
+		require.EqualError(t, err, "delete tombstone: delete error")
+	})
+}
+
+func TestPurge(t *testing.T) {
+	t.Run("purges tombstones deleted before the given time", func(t *testing.T) {
+		provider, store, tombstones := newTombstones(t)
+
+		now := time.Now()
+
+		require.NoError(t, store.Put("old", []byte("value")))
+		require.NoError(t, store.Put("recent", []byte("value")))
+		require.NoError(t, tombstones.Delete(now.Add(-time.Hour), "old"))
+		require.NoError(t, tombstones.Delete(now, "recent"))
+
+		deleted, err := provider.OpenStore(tombstone.StoreName(storeName))
+		require.NoError(t, err)
+
+		require.NoError(t, deleted.Put("invalid", []byte("{}"), storage.Tag{Name: tombstone.Tag, Value: "yesterday"}))
+
+		n, err := tombstone.Purge(deleted, now.Add(-time.Minute))
+		require.NoError(t, err)
+		require.Equal(t, 2, n)
+
+		_, err = tombstones.Get("old")
+		require.ErrorIs(t, err, storage.ErrDataNotFound)
+
+		_, err = tombstones.Get("invalid")
+		require.ErrorIs(t, err, storage.ErrDataNotFound)
+
+		_, err = tombstones.Get("recent")
+		require.NoError(t, err)
+
+		n, err = tombstone.Purge(deleted, now.Add(-time.Minute))
+		require.NoError(t, err)
+		require.Zero(t, n)
+	})
+
+	t.Run("error if tombstones cannot be queried", func(t *testing.T) {
+		_, err := tombstone.Purge(&mockstorage.MockStore{ErrQuery: errors.New("query error")}, time.Now())
+		require.EqualError(t, err, "query tombstones: query error")
+	})
+
+	t.Run("error if tombstones cannot be read", func(t *testing.T) {
+		store := &mockstorage.MockStore{
+			Store: map[string]mockstorage.DBEntry{"key": {
+				Tags: []storage.Tag{{Name: tombstone.Tag, Value: "0"}},
+			}},
+			ErrNext: errors.New("next error"),
+		}
+
+		_, err := tombstone.Purge(store, time.Now())
+		require.EqualError(t, err, "next tombstone: next error")
+
+		store.ErrNext = nil
+		store.ErrKey = errors.New("key error")
+
+		_, err = tombstone.Purge(store, time.Now())
+		require.EqualError(t, err, "get key: key error")
+
+		store.ErrKey = nil
+		store.ErrBatch = errors.New("batch error")
+
+		_, err = tombstone.Purge(store, time.Now())
+		require.EqualError(t, err, "purge tombstones: batch error")
+	})
+}
+
+func newTombstones(t *testing.T) (storage.Provider, storage.Store, *tombstone.Tombstones) {
+	t.Helper()
+
+	provider := mem.NewProvider()
+
+	store, err := provider.OpenStore(storeName)
+	require.NoError(t, err)
+
+	tombstones, err := tombstone.New(provider, storeName, store)
+	require.NoError(t, err)
+
+	return provider, store, tombstones
+}
+
+// failingProvider is a mem provider whose stores return the configured errors. An error is returned by all
+// stores unless the name of a store is given for it.
+type failingProvider struct {
+	storage.Provider
+	errBatch      error
+	errBatchStore string
+	errPut        error
+	errPutStore   string
+	errDelete     error
+}
+
+func (p *failingProvider) OpenStore(name string) (storage.Store, error) {
+	s, err := p.Provider.OpenStore(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &failingStore{Store: s, name: name, provider: p}, nil
+}
+
+type failingStore struct {
+	storage.Store
+	name     string
+	provider *failingProvider
+}
+
+func (s *failingStore) Batch(operations []storage.Operation) error {
+	if s.provider.errBatch != nil && s.fails(s.provider.errBatchStore) {
+		return s.provider.errBatch
+	}
+
+	return s.Store.Batch(operations)
+}
+
+func (s *failingStore) Put(key string, value []byte, tags ...storage.Tag) error {
+	if s.provider.errPut != nil && s.fails(s.provider.errPutStore) {
+		return s.provider.errPut
+	}
+
+	return s.Store.Put(key, value, tags...)
+}
+
+func (s *failingStore) Delete(key string) error {
+	if s.provider.errDelete != nil {
+		return s.provider.errDelete
+	}
+
+	return s.Store.Delete(key)
+}
+
+func (s *failingStore) fails(name string) bool {
+	return name == "" || name == s.name
+}
@@ -11,7 +11,6 @@ package vcissuer
 
 import (
 	"context"
-	"crypto/ed25519"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -20,6 +19,7 @@ import (
 	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
 	"github.com/piprate/json-gold/ld"
 
+	vccrypto "github.com/trustbloc/ace/pkg/doc/vc/crypto"
 	vcprofile "github.com/trustbloc/ace/pkg/doc/vc/profile"
 	"github.com/trustbloc/ace/pkg/internal/httputil"
 	issueroperation "github.com/trustbloc/ace/pkg/restapi/issuer/operation"
@@ -41,6 +41,8 @@ type Config struct {
 	ProfileName    string
 	DocumentLoader ld.DocumentLoader
 	HTTPClient     httpClient
+	// Signature suite used by the issuer profile. Defaults to Ed25519Signature2018 if not set.
+	SignatureType string
 }
 
 // Service is a service to issue verifiable credentials.
@@ -50,16 +52,23 @@ type Service struct {
 	profileName    string
 	documentLoader ld.DocumentLoader
 	httpClient     httpClient
+	signatureType  string
 }
 
 // New creates a new instance of issuer Service.
 func New(config *Config) *Service {
+	signatureType := config.SignatureType
+	if signatureType == "" {
+		signatureType = vccrypto.Ed25519Signature2018
+	}
+
 	return &Service{
 		vcIssuerURL:    config.VCIssuerURL,
 		authToken:      config.AuthToken,
 		profileName:    config.ProfileName,
 		documentLoader: config.DocumentLoader,
 		httpClient:     config.HTTPClient,
+		signatureType:  signatureType,
 	}
 }
 
@@ -100,17 +109,25 @@ func (s *Service) IssueCredential(ctx context.Context, cred []byte) (*verifiable
 
 // CreateIssuerProfile create gatekeeper profile on vs issuer service.
 func (s *Service) CreateIssuerProfile(
-	ctx context.Context, did, publicKeyID string, privateKey ed25519.PrivateKey) error {
+	ctx context.Context, did, publicKeyID, keyType string, privateKey []byte) error {
+	if keyType == "" {
+		keyType = vccrypto.Ed25519KeyType
+	}
+
+	if err := vccrypto.ValidateSignatureSuite(s.signatureType, keyType); err != nil {
+		return fmt.Errorf("create issuer profile: %w", err)
+	}
+
 	profileRequest := issueroperation.ProfileRequest{}
 
 	profileRequest.Name = s.profileName
 	profileRequest.URI = "http://example.com"
-	profileRequest.SignatureType = "Ed25519Signature2018"
+	profileRequest.SignatureType = s.signatureType
 	profileRequest.DID = did
 	profileRequest.DIDPrivateKey = base58.Encode(privateKey)
 	profileRequest.DIDKeyID = fmt.Sprintf("%s#%s", did, publicKeyID)
 	profileRequest.SignatureRepresentation = 1
-	profileRequest.DIDKeyType = "Ed25519"
+	profileRequest.DIDKeyType = keyType
 
 	req, err := json.Marshal(profileRequest)
 	if err != nil {
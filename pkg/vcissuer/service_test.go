@@ -8,6 +8,7 @@ package vcissuer_test
 
 import (
 	"context"
+	"encoding/json"
 	"io"
 	"net/http"
 	"strings"
@@ -19,6 +20,8 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/trustbloc/ace/cmd/common"
+	vccrypto "github.com/trustbloc/ace/pkg/doc/vc/crypto"
+	issueroperation "github.com/trustbloc/ace/pkg/restapi/issuer/operation"
 	"github.com/trustbloc/ace/pkg/vcissuer"
 )
 
@@ -126,3 +129,54 @@ func TestIssueCredential_Success(t *testing.T) {
 	require.NoError(t, err)
 	require.NotNil(t, cred)
 }
+
+func TestCreateIssuerProfile(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		httpClient := NewMockHTTPClient(ctrl)
+
+		httpClient.EXPECT().Do(gomock.Any()).DoAndReturn(func(req *http.Request) (*http.Response, error) {
+			var profile issueroperation.ProfileRequest
+
+			require.NoError(t, json.NewDecoder(req.Body).Decode(&profile))
+			require.Equal(t, vccrypto.JSONWebSignature2020, profile.SignatureType)
+			require.Equal(t, vccrypto.P256KeyType, profile.DIDKeyType)
+			require.Equal(t, "did:example:gk#key1", profile.DIDKeyID)
+
+			return &http.Response{
+				Body:       io.NopCloser(strings.NewReader(`{"did":"did:example:gk"}`)),
+				StatusCode: http.StatusCreated,
+			}, nil
+		})
+
+		vcIssuer := vcissuer.New(&vcissuer.Config{
+			VCIssuerURL:   "http://base-url",
+			HTTPClient:    httpClient,
+			SignatureType: vccrypto.JSONWebSignature2020,
+		})
+
+		err := vcIssuer.CreateIssuerProfile(context.Background(), "did:example:gk", "key1",
+			vccrypto.P256KeyType, []byte("key"))
+		require.NoError(t, err)
+	})
+
+	t.Run("Key type is not supported by signature type", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		httpClient := NewMockHTTPClient(ctrl)
+		httpClient.EXPECT().Do(gomock.Any()).Times(0)
+
+		vcIssuer := vcissuer.New(&vcissuer.Config{
+			VCIssuerURL: "http://base-url",
+			HTTPClient:  httpClient,
+		})
+
+		err := vcIssuer.CreateIssuerProfile(context.Background(), "did:example:gk", "key1",
+			vccrypto.BLS12381G2KeyType, []byte("key"))
+		require.EqualError(t, err,
+			"create issuer profile: key type BLS12381G2 is not supported by signature type Ed25519Signature2018")
+	})
+}
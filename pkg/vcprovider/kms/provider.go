@@ -0,0 +1,132 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package kms
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	ariescrypto "github.com/hyperledger/aries-framework-go/pkg/crypto"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/util"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
+	vdrapi "github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdr"
+	arieskms "github.com/hyperledger/aries-framework-go/pkg/kms"
+	"github.com/piprate/json-gold/ld"
+
+	vccrypto "github.com/trustbloc/ace/pkg/doc/vc/crypto"
+	vcprofile "github.com/trustbloc/ace/pkg/doc/vc/profile"
+	"github.com/trustbloc/ace/pkg/gatekeeper/config"
+)
+
+// errKeyNotInKMS is returned if the private key of the gatekeeper's DID was generated outside of the KMS.
+var errKeyNotInKMS = errors.New("the DID key of the gatekeeper is not in the signing KMS")
+
+type configService interface {
+	Get() (*config.Config, error)
+}
+
+// Config contains configuration of the KMS provider.
+type Config struct {
+	// KeyManager holds the gatekeeper's DID key.
+	KeyManager arieskms.KeyManager
+	// Crypto signs with the keys of the KeyManager.
+	Crypto ariescrypto.Crypto
+	// VDR resolves the gatekeeper's DID to check that the key is one of its assertion methods.
+	VDR vdrapi.Registry
+	// ConfigService returns the gatekeeper's DID and the ID of its key.
+	ConfigService  configService
+	DocumentLoader ld.DocumentLoader
+	// Signature suite of issued credentials. Defaults to Ed25519Signature2018.
+	SignatureType string
+}
+
+// Provider issues credentials in process, signed with the gatekeeper's DID key in its KMS. The private key is never
+// exported from the KMS.
+type Provider struct {
+	crypto         *vccrypto.Crypto
+	configService  configService
+	documentLoader ld.DocumentLoader
+	signatureType  string
+}
+
+// New returns a new instance of Provider.
+func New(config *Config) *Provider {
+	signatureType := config.SignatureType
+	if signatureType == "" {
+		signatureType = vccrypto.Ed25519Signature2018
+	}
+
+	return &Provider{
+		crypto:         vccrypto.New(config.KeyManager, config.Crypto, config.VDR, config.DocumentLoader),
+		configService:  config.ConfigService,
+		documentLoader: config.DocumentLoader,
+		signatureType:  signatureType,
+	}
+}
+
+// IssueCredential issues the credential with the gatekeeper's DID.
+func (p *Provider) IssueCredential(_ context.Context, cred []byte) (*verifiable.Credential, error) {
+	vc, err := verifiable.ParseCredential(cred, verifiable.WithDisabledProofCheck(),
+		verifiable.WithJSONLDDocumentLoader(p.documentLoader))
+	if err != nil {
+		return nil, fmt.Errorf("parse credential: %w", err)
+	}
+
+	cfg, err := p.configService.Get()
+	if err != nil {
+		return nil, fmt.Errorf("get config: %w", err)
+	}
+
+	if len(cfg.PrivateKey) != 0 {
+		return nil, errKeyNotInKMS
+	}
+
+	vc.Issuer.ID = cfg.DID
+
+	if vc.Issued == nil {
+		vc.Issued = util.NewTime(time.Now().UTC())
+	}
+
+	representation := verifiable.SignatureJWS
+
+	// BBS+ signatures support selective disclosure of the proof value only
+	if p.signatureType == vccrypto.BbsBlsSignature2020 {
+		representation = verifiable.SignatureProofValue
+	}
+
+	vc, err = p.crypto.SignCredential(&vcprofile.DataProfile{
+		DID:                     cfg.DID,
+		SignatureType:           p.signatureType,
+		SignatureRepresentation: representation,
+		Creator:                 cfg.DID + "#" + cfg.PubKeyID,
+	}, vc)
+	if err != nil {
+		return nil, fmt.Errorf("sign credential: %w", err)
+	}
+
+	return vc, nil
+}
+
+// CreateIssuerProfile checks that the gatekeeper's DID key is in the KMS and fits the signature suite. There is no
+// profile to register: the key is read from the KMS when credentials are issued.
+func (p *Provider) CreateIssuerProfile(_ context.Context, _, _, keyType string, privateKey []byte) error {
+	if len(privateKey) != 0 {
+		return fmt.Errorf("create issuer profile: %w", errKeyNotInKMS)
+	}
+
+	if keyType == "" {
+		keyType = vccrypto.Ed25519KeyType
+	}
+
+	if err := vccrypto.ValidateSignatureSuite(p.signatureType, keyType); err != nil {
+		return fmt.Errorf("create issuer profile: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,139 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package kms_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"testing"
+
+	"github.com/hyperledger/aries-framework-go/component/storageutil/mem"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/did"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
+	"github.com/hyperledger/aries-framework-go/pkg/framework/aries"
+	arieskms "github.com/hyperledger/aries-framework-go/pkg/kms"
+	vdrmock "github.com/hyperledger/aries-framework-go/pkg/mock/vdr"
+	"github.com/stretchr/testify/require"
+
+	vccrypto "github.com/trustbloc/ace/pkg/doc/vc/crypto"
+	"github.com/trustbloc/ace/pkg/gatekeeper/config"
+	"github.com/trustbloc/ace/pkg/internal/testutil"
+	"github.com/trustbloc/ace/pkg/vcprovider/kms"
+)
+
+const (
+	gatekeeperDID = "did:example:gatekeeper"
+	credential    = `{
+  "@context": ["https://www.w3.org/2018/credentials/v1"],
+  "credentialSubject": {"id": "did:example:subject"},
+  "id": "urn:uuid:4d1f25ab-cf2f-498f-b9bd-d38ce5e426a1",
+  "issuanceDate": "2022-03-30T14:16:36.547716722Z",
+  "issuer": "urn:uuid:9a7bb3e4-a2d3-4a84-9d78-2d360ee62bc4",
+  "type": "VerifiableCredential"
+}`
+)
+
+func TestProvider_IssueCredential(t *testing.T) {
+	a, err := aries.New(aries.WithStoreProvider(mem.NewProvider()))
+	require.NoError(t, err)
+
+	ctx, err := a.Context()
+	require.NoError(t, err)
+
+	keyID, pubKey, err := ctx.KMS().CreateAndExportPubKeyBytes(arieskms.ED25519Type)
+	require.NoError(t, err)
+
+	loader := testutil.DocumentLoader(t)
+
+	cfg := &config.Config{DID: gatekeeperDID, PubKeyID: keyID, KeyType: vccrypto.Ed25519KeyType}
+
+	p := kms.New(&kms.Config{
+		KeyManager:     ctx.KMS(),
+		Crypto:         ctx.Crypto(),
+		VDR:            &vdrmock.MockVDRegistry{ResolveValue: newDIDDoc(keyID, pubKey)},
+		ConfigService:  &configService{config: cfg},
+		DocumentLoader: loader,
+	})
+
+	require.NoError(t, p.CreateIssuerProfile(context.Background(), gatekeeperDID, keyID, "", nil))
+
+	vc, err := p.IssueCredential(context.Background(), []byte(credential))
+	require.NoError(t, err)
+	require.Equal(t, gatekeeperDID, vc.Issuer.ID)
+	require.Len(t, vc.Proofs, 1)
+	require.Equal(t, gatekeeperDID+"#"+keyID, vc.Proofs[0]["verificationMethod"])
+
+	b, err := vc.MarshalJSON()
+	require.NoError(t, err)
+
+	_, err = verifiable.ParseCredential(b, verifiable.WithJSONLDDocumentLoader(loader),
+		verifiable.WithPublicKeyFetcher(verifiable.SingleKey(pubKey, arieskms.ED25519)))
+	require.NoError(t, err)
+
+	t.Run("Key not in KMS", func(t *testing.T) {
+		err = p.CreateIssuerProfile(context.Background(), gatekeeperDID, keyID, "", []byte("private key"))
+		require.EqualError(t, err, "create issuer profile: the DID key of the gatekeeper is not in the signing KMS")
+
+		_, err = kms.New(&kms.Config{
+			ConfigService:  &configService{config: &config.Config{PrivateKey: []byte("private key")}},
+			DocumentLoader: loader,
+		}).IssueCredential(context.Background(), []byte(credential))
+		require.EqualError(t, err, "the DID key of the gatekeeper is not in the signing KMS")
+	})
+
+	t.Run("Signature suite not supported by key type", func(t *testing.T) {
+		err = p.CreateIssuerProfile(context.Background(), gatekeeperDID, keyID, vccrypto.P256KeyType, nil)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "create issuer profile")
+	})
+
+	t.Run("Invalid credential", func(t *testing.T) {
+		_, err = p.IssueCredential(context.Background(), []byte("{}"))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "parse credential")
+	})
+
+	t.Run("Fail to get config", func(t *testing.T) {
+		_, err = kms.New(&kms.Config{
+			ConfigService:  &configService{err: errors.New("not found")},
+			DocumentLoader: loader,
+		}).IssueCredential(context.Background(), []byte(credential))
+		require.EqualError(t, err, "get config: not found")
+	})
+
+	t.Run("Key not found", func(t *testing.T) {
+		_, err = kms.New(&kms.Config{
+			KeyManager:     ctx.KMS(),
+			Crypto:         ctx.Crypto(),
+			ConfigService:  &configService{config: &config.Config{DID: gatekeeperDID, PubKeyID: "unknown"}},
+			DocumentLoader: loader,
+		}).IssueCredential(context.Background(), []byte(credential))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "sign credential")
+	})
+}
+
+type configService struct {
+	config *config.Config
+	err    error
+}
+
+func (s *configService) Get() (*config.Config, error) {
+	return s.config, s.err
+}
+
+func newDIDDoc(keyID string, pubKey []byte) *did.Doc {
+	vm := did.NewVerificationMethodFromBytes(gatekeeperDID+"#"+keyID, vccrypto.Ed25519VerificationKey2018,
+		gatekeeperDID, ed25519.PublicKey(pubKey))
+
+	return &did.Doc{
+		ID:                 gatekeeperDID,
+		VerificationMethod: []did.VerificationMethod{*vm},
+		AssertionMethod:    []did.Verification{*did.NewReferencedVerification(vm, did.AssertionMethod)},
+	}
+}
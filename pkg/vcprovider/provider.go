@@ -0,0 +1,62 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vcprovider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
+
+	"github.com/trustbloc/ace/pkg/vcprovider/kms"
+	"github.com/trustbloc/ace/pkg/vcprovider/vcs"
+)
+
+const (
+	// VCS is a provider type that delegates credential issuance to an external TrustBloc VCS issuer.
+	VCS = "vcs"
+	// KMS is a provider type that issues credentials in process, signed with the DID key in the signing KMS.
+	KMS = "kms"
+)
+
+// Provider issues verifiable credentials on behalf of the gatekeeper.
+type Provider interface {
+	// IssueCredential issues verifiable credential.
+	IssueCredential(ctx context.Context, cred []byte) (*verifiable.Credential, error)
+	// CreateIssuerProfile registers the gatekeeper's DID and signing key with the provider.
+	CreateIssuerProfile(ctx context.Context, did, publicKeyID, keyType string, privateKey []byte) error
+}
+
+// Config contains configuration for the selected Provider.
+type Config struct {
+	// Type of the provider. Supported values: [vcs] [kms].
+	Type string
+	// VCS contains the VCS issuer configuration. Required for VCS type.
+	VCS *vcs.Config
+	// KMS contains the signing KMS configuration. Required for KMS type.
+	KMS *kms.Config
+}
+
+// New returns a new Provider of the configured type.
+func New(config *Config) (Provider, error) { //nolint:ireturn
+	switch config.Type {
+	case VCS:
+		if config.VCS == nil {
+			return nil, fmt.Errorf("missing configuration for %q provider", VCS)
+		}
+
+		return vcs.New(config.VCS), nil
+	case KMS:
+		if config.KMS == nil {
+			return nil, fmt.Errorf("missing configuration for %q provider", KMS)
+		}
+
+		return kms.New(config.KMS), nil
+	default:
+		return nil, fmt.Errorf("unsupported vc provider type: %q", config.Type)
+	}
+}
@@ -0,0 +1,60 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vcprovider_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/ace/pkg/vcprovider"
+	"github.com/trustbloc/ace/pkg/vcprovider/kms"
+	"github.com/trustbloc/ace/pkg/vcprovider/vcs"
+)
+
+func TestNew(t *testing.T) {
+	t.Run("VCS provider", func(t *testing.T) {
+		p, err := vcprovider.New(&vcprovider.Config{
+			Type: vcprovider.VCS,
+			VCS:  &vcs.Config{URL: "https://vcs.example.com"},
+		})
+
+		require.NoError(t, err)
+		require.IsType(t, &vcs.Provider{}, p)
+	})
+
+	t.Run("Missing VCS config", func(t *testing.T) {
+		p, err := vcprovider.New(&vcprovider.Config{Type: vcprovider.VCS})
+
+		require.EqualError(t, err, `missing configuration for "vcs" provider`)
+		require.Nil(t, p)
+	})
+
+	t.Run("KMS provider", func(t *testing.T) {
+		p, err := vcprovider.New(&vcprovider.Config{
+			Type: vcprovider.KMS,
+			KMS:  &kms.Config{},
+		})
+
+		require.NoError(t, err)
+		require.IsType(t, &kms.Provider{}, p)
+	})
+
+	t.Run("Missing KMS config", func(t *testing.T) {
+		p, err := vcprovider.New(&vcprovider.Config{Type: vcprovider.KMS})
+
+		require.EqualError(t, err, `missing configuration for "kms" provider`)
+		require.Nil(t, p)
+	})
+
+	t.Run("Unsupported type", func(t *testing.T) {
+		p, err := vcprovider.New(&vcprovider.Config{Type: "unknown"})
+
+		require.EqualError(t, err, `unsupported vc provider type: "unknown"`)
+		require.Nil(t, p)
+	})
+}
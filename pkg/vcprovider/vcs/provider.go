@@ -0,0 +1,149 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vcs
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/hyperledger/aries-framework-go/pkg/common/log"
+	"github.com/piprate/json-gold/ld"
+
+	"github.com/trustbloc/ace/pkg/vcissuer"
+)
+
+const (
+	defaultMaxRetries    = 3
+	defaultRetryInterval = 500 * time.Millisecond
+)
+
+var logger = log.New("vcs-provider")
+
+type httpClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// Config contains configuration of the VCS issuer provider.
+type Config struct {
+	// Base URL of the VCS issuer.
+	URL string
+	// Bearer token used to authenticate requests to the VCS issuer.
+	AuthToken string
+	// Name of the issuer profile on the VCS issuer.
+	ProfileName string
+	// Maximum number of retries for failed requests. Defaults to 3.
+	MaxRetries uint64
+	// Initial interval between retries, grows exponentially. Defaults to 500ms.
+	RetryInterval  time.Duration
+	DocumentLoader ld.DocumentLoader
+	HTTPClient     httpClient
+	// Signature suite of the issuer profile. Defaults to Ed25519Signature2018.
+	SignatureType string
+}
+
+// Provider issues credentials by calling an external TrustBloc VCS issuer over REST.
+type Provider struct {
+	*vcissuer.Service
+}
+
+// New returns a new instance of Provider.
+func New(config *Config) *Provider {
+	maxRetries := config.MaxRetries
+	if maxRetries == 0 {
+		maxRetries = defaultMaxRetries
+	}
+
+	retryInterval := config.RetryInterval
+	if retryInterval == 0 {
+		retryInterval = defaultRetryInterval
+	}
+
+	var client httpClient = http.DefaultClient
+
+	if config.HTTPClient != nil {
+		client = config.HTTPClient
+	}
+
+	return &Provider{
+		Service: vcissuer.New(&vcissuer.Config{
+			VCIssuerURL:    config.URL,
+			AuthToken:      config.AuthToken,
+			ProfileName:    config.ProfileName,
+			DocumentLoader: config.DocumentLoader,
+			SignatureType:  config.SignatureType,
+			HTTPClient: &retryClient{
+				next:          client,
+				maxRetries:    maxRetries,
+				retryInterval: retryInterval,
+			},
+		}),
+	}
+}
+
+// retryClient retries requests that failed with a transport error or a server-side (5xx, 429) status.
+type retryClient struct {
+	next          httpClient
+	maxRetries    uint64
+	retryInterval time.Duration
+}
+
+func (c *retryClient) Do(req *http.Request) (*http.Response, error) {
+	var body []byte
+
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+
+		body, err = io.ReadAll(req.Body)
+		if err != nil {
+			return nil, fmt.Errorf("read request body: %w", err)
+		}
+	}
+
+	var resp *http.Response
+
+	b := backoff.NewExponentialBackOff()
+	b.InitialInterval = c.retryInterval
+
+	err := backoff.RetryNotify(
+		func() error {
+			r := req.Clone(req.Context())
+			if body != nil {
+				r.Body = io.NopCloser(bytes.NewReader(body))
+			}
+
+			var err error
+
+			resp, err = c.next.Do(r)
+			if err != nil {
+				return err
+			}
+
+			if resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusTooManyRequests {
+				if closeErr := resp.Body.Close(); closeErr != nil {
+					logger.Warnf("Failed to close response body: %s", closeErr)
+				}
+
+				return fmt.Errorf("vcs issuer response status: %d", resp.StatusCode)
+			}
+
+			return nil
+		},
+		backoff.WithContext(backoff.WithMaxRetries(b, c.maxRetries), req.Context()),
+		func(err error, d time.Duration) {
+			logger.Warnf("VCS issuer request to %s failed, retrying in %s: %s", req.URL, d, err)
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,119 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vcs_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/ace/pkg/internal/testutil"
+	"github.com/trustbloc/ace/pkg/vcprovider/vcs"
+)
+
+const vcContent = `{
+  "@context": ["https://www.w3.org/2018/credentials/v1"],
+  "credentialSubject": {
+    "data": "@thanos27",
+    "id": "did:example:subject"
+  },
+  "id": "urn:uuid:4d1f25ab-cf2f-498f-b9bd-d38ce5e426a1",
+  "issuanceDate": "2022-03-30T14:16:36.547716722Z",
+  "issuer": "did:example:issuer",
+  "type": "VerifiableCredential"
+}`
+
+func TestProvider_IssueCredential(t *testing.T) {
+	t.Run("Success after retries", func(t *testing.T) {
+		var calls int32
+
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, "/test-profile/credentials/issue", r.URL.Path)
+			require.Equal(t, "Bearer test-token", r.Header.Get("Authorization"))
+
+			body, err := io.ReadAll(r.Body)
+			require.NoError(t, err)
+			require.Contains(t, string(body), "credential")
+
+			if atomic.AddInt32(&calls, 1) < 3 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+
+				return
+			}
+
+			w.WriteHeader(http.StatusCreated)
+			_, err = w.Write([]byte(vcContent))
+			require.NoError(t, err)
+		}))
+		defer srv.Close()
+
+		p := vcs.New(&vcs.Config{
+			URL:            srv.URL,
+			AuthToken:      "test-token",
+			ProfileName:    "test-profile",
+			RetryInterval:  time.Millisecond,
+			DocumentLoader: testutil.DocumentLoader(t),
+		})
+
+		vc, err := p.IssueCredential(context.Background(), []byte(`{"id":"urn:uuid:test"}`))
+
+		require.NoError(t, err)
+		require.NotNil(t, vc)
+		require.Equal(t, int32(3), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("Retries exhausted", func(t *testing.T) {
+		var calls int32
+
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer srv.Close()
+
+		p := vcs.New(&vcs.Config{
+			URL:           srv.URL,
+			ProfileName:   "test-profile",
+			MaxRetries:    2,
+			RetryInterval: time.Millisecond,
+		})
+
+		vc, err := p.IssueCredential(context.Background(), []byte(`{}`))
+
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "vcs issuer response status: 500")
+		require.Nil(t, vc)
+		require.Equal(t, int32(3), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("No retry on client error", func(t *testing.T) {
+		var calls int32
+
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			w.WriteHeader(http.StatusBadRequest)
+		}))
+		defer srv.Close()
+
+		p := vcs.New(&vcs.Config{
+			URL:           srv.URL,
+			ProfileName:   "test-profile",
+			RetryInterval: time.Millisecond,
+		})
+
+		_, err := p.IssueCredential(context.Background(), []byte(`{}`))
+
+		require.EqualError(t, err, "issue vc response status: 400")
+		require.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	})
+}
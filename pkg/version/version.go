@@ -0,0 +1,55 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package version has the version of the build, injected with ldflags, e.g.
+//
+//	go build -ldflags "-X github.com/trustbloc/ace/pkg/version.Version=v0.1.8 \
+//	  -X github.com/trustbloc/ace/pkg/version.GitCommit=$(git rev-parse HEAD)"
+//
+// The Makefile sets them when building the binaries and the images.
+package version
+
+import "strings"
+
+// The values injected with ldflags.
+//
+//nolint:gochecknoglobals
+var (
+	// Version is the version of the build, e.g. the tag it is built from.
+	Version = "dev"
+	// GitCommit is the commit the build is built from.
+	GitCommit = ""
+	// BuildDate is the time of the build, in RFC 3339 format.
+	BuildDate = ""
+	// Features is the comma-separated list of the feature flags enabled in the build.
+	Features = ""
+)
+
+// Info is the version of the build.
+type Info struct {
+	Version   string   `json:"version"`
+	GitCommit string   `json:"gitCommit,omitempty"`
+	BuildDate string   `json:"buildDate,omitempty"`
+	Features  []string `json:"features"`
+}
+
+// Get returns the version of the build.
+func Get() *Info {
+	info := &Info{
+		Version:   Version,
+		GitCommit: GitCommit,
+		BuildDate: BuildDate,
+		Features:  []string{},
+	}
+
+	for _, f := range strings.Split(Features, ",") {
+		if f = strings.TrimSpace(f); f != "" {
+			info.Features = append(info.Features, f)
+		}
+	}
+
+	return info
+}
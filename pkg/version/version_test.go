@@ -0,0 +1,48 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package version_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/ace/pkg/version"
+)
+
+func TestGet(t *testing.T) {
+	t.Run("test dev build", func(t *testing.T) {
+		info := version.Get()
+		require.Equal(t, "dev", info.Version)
+		require.Empty(t, info.GitCommit)
+		require.Empty(t, info.Features)
+		require.NotNil(t, info.Features)
+	})
+
+	t.Run("test injected version", func(t *testing.T) {
+		set(t, &version.Version, "v0.1.8")
+		set(t, &version.GitCommit, "3f2a9c1")
+		set(t, &version.BuildDate, "2022-05-04T10:00:00Z")
+		set(t, &version.Features, "async_protect, bbs_issuance,")
+
+		info := version.Get()
+		require.Equal(t, "v0.1.8", info.Version)
+		require.Equal(t, "3f2a9c1", info.GitCommit)
+		require.Equal(t, "2022-05-04T10:00:00Z", info.BuildDate)
+		require.Equal(t, []string{"async_protect", "bbs_issuance"}, info.Features)
+	})
+}
+
+// set sets the injected value for the test.
+func set(t *testing.T, v *string, value string) {
+	t.Helper()
+
+	prev := *v
+	*v = value
+
+	t.Cleanup(func() { *v = prev })
+}
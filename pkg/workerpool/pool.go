@@ -0,0 +1,54 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package workerpool bounds the number of operations run at a time.
+package workerpool
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrSaturated is returned when all workers are busy and the queue is full.
+var ErrSaturated = errors.New("worker pool saturated")
+
+// Pool runs up to a number of operations at a time, with a bounded number of other operations waiting for a worker.
+// Operations are run on the goroutine of their caller, so that bursts do not spawn goroutines, and are rejected with
+// ErrSaturated once the queue is full.
+type Pool struct {
+	workers chan struct{}
+	pending chan struct{}
+}
+
+// New returns a pool of the given number of workers, with up to queueSize operations waiting for a worker.
+func New(workers, queueSize int) *Pool {
+	return &Pool{
+		workers: make(chan struct{}, workers),
+		pending: make(chan struct{}, workers+queueSize),
+	}
+}
+
+// Do runs fn once a worker is free. It returns ErrSaturated without running fn if the queue is full, and the error of
+// the context if it is done before a worker is free.
+func (p *Pool) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	select {
+	case p.pending <- struct{}{}:
+	default:
+		return ErrSaturated
+	}
+
+	defer func() { <-p.pending }()
+
+	select {
+	case p.workers <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	defer func() { <-p.workers }()
+
+	return fn(ctx)
+}
@@ -0,0 +1,99 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package workerpool_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/ace/pkg/workerpool"
+)
+
+func TestPool_Do(t *testing.T) {
+	t.Run("Runs the operation", func(t *testing.T) {
+		pool := workerpool.New(1, 0)
+
+		err := pool.Do(context.Background(), func(context.Context) error {
+			return errors.New("operation error")
+		})
+		require.EqualError(t, err, "operation error")
+
+		require.NoError(t, pool.Do(context.Background(), func(context.Context) error { return nil }))
+	})
+
+	t.Run("Queues operations while the workers are busy", func(t *testing.T) {
+		pool := workerpool.New(1, 1)
+
+		started, release := make(chan struct{}), make(chan struct{})
+
+		busy := make(chan error)
+
+		go func() {
+			busy <- pool.Do(context.Background(), func(context.Context) error {
+				close(started)
+				<-release
+
+				return nil
+			})
+		}()
+
+		<-started
+
+		queued := make(chan error)
+
+		go func() {
+			queued <- pool.Do(context.Background(), func(context.Context) error { return nil })
+		}()
+
+		// with a done context, operations that are queued return right away instead of waiting for the worker
+		done, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		require.Eventually(t, func() bool {
+			return errors.Is(pool.Do(done, func(context.Context) error { return nil }), workerpool.ErrSaturated)
+		}, time.Second, time.Millisecond)
+
+		close(release)
+
+		require.NoError(t, <-busy)
+		require.NoError(t, <-queued)
+	})
+
+	t.Run("Stops waiting when the context is done", func(t *testing.T) {
+		pool := workerpool.New(1, 1)
+
+		release := make(chan struct{})
+		defer close(release)
+
+		started := make(chan struct{})
+
+		go func() {
+			_ = pool.Do(context.Background(), func(context.Context) error { //nolint:errcheck
+				close(started)
+				<-release
+
+				return nil
+			})
+		}()
+
+		<-started
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := pool.Do(ctx, func(context.Context) error {
+			t.Error("operation must not run")
+
+			return nil
+		})
+		require.ErrorIs(t, err, context.Canceled)
+	})
+}
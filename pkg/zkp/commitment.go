@@ -0,0 +1,67 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package zkp
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+
+	bls12381 "github.com/kilic/bls12-381"
+)
+
+// MetaKey is the key of the commitment in the metadata of a structured document committed to when it is protected.
+const MetaKey = "commitment"
+
+const commitmentDomain = "ACE-ZKP-V01-COMMITMENT"
+
+// ErrNotOpened is returned when opening a commitment with a value it does not commit to.
+var ErrNotOpened = errors.New("commitment does not open to the value")
+
+// SignedCommitment is a commitment to the content of a document, created when the document is protected and stored
+// with it along with its opening. The point of the commitment is signed with the verification method, so that proofs
+// over the document are verified against the commitment created then. Its fields are base64url-encoded.
+type SignedCommitment struct {
+	Scheme             string `json:"scheme"`
+	Point              string `json:"point"`
+	Blinding           string `json:"blinding"`
+	VerificationMethod string `json:"verificationMethod"`
+	Signature          string `json:"signature"`
+}
+
+// SigningInput returns the data signed over the point of a commitment.
+func SigningInput(point []byte) []byte {
+	return append([]byte(commitmentDomain), point...)
+}
+
+// Blinding returns the blinding factor of the commitment, which opens it along with the committed value.
+func (c *Commitment) Blinding() []byte {
+	return c.blinding.Bytes()
+}
+
+// Open returns the commitment with the point and blinding factor of a commitment created earlier, to prove equality
+// with. It fails with ErrNotOpened if they do not commit to value.
+func Open(value, point, blinding []byte) (*Commitment, error) {
+	g := bls12381.NewG1()
+
+	h, err := generatorH(g)
+	if err != nil {
+		return nil, err
+	}
+
+	r := new(big.Int).SetBytes(blinding)
+	if r.Sign() == 0 || r.Cmp(g.Q()) >= 0 {
+		return nil, errors.New("invalid blinding factor: not a scalar")
+	}
+
+	c := commit(g, h, value, r)
+	if !bytes.Equal(c.Point, point) {
+		return nil, ErrNotOpened
+	}
+
+	return c, nil
+}
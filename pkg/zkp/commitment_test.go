@@ -0,0 +1,46 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package zkp_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/ace/pkg/zkp"
+)
+
+func TestOpen(t *testing.T) {
+	t.Run("opens a commitment created earlier", func(t *testing.T) {
+		c := commit(t, "alice")
+
+		opened, err := zkp.Open([]byte("alice"), c.Point, c.Blinding())
+		require.NoError(t, err)
+		require.Equal(t, c.Point, opened.Point)
+
+		other := commit(t, "alice")
+
+		proof, err := zkp.ProveEquality(opened, other)
+		require.NoError(t, err)
+		require.NoError(t, zkp.VerifyEquality(c.Point, other.Point, proof))
+	})
+
+	t.Run("does not open to another value", func(t *testing.T) {
+		c := commit(t, "alice")
+
+		_, err := zkp.Open([]byte("bob"), c.Point, c.Blinding())
+		require.ErrorIs(t, err, zkp.ErrNotOpened)
+	})
+
+	t.Run("invalid blinding factor", func(t *testing.T) {
+		c := commit(t, "alice")
+
+		_, err := zkp.Open([]byte("alice"), c.Point, nil)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid blinding factor")
+	})
+}
@@ -0,0 +1,206 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package zkp
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/big"
+
+	bls12381 "github.com/kilic/bls12-381"
+)
+
+// Scheme identifies the commitment and proof scheme implemented by this package: Pedersen commitments over the
+// BLS12-381 G1 group with Fiat-Shamir Schnorr proofs of equality.
+const Scheme = "PedersenBLS12381G1Schnorr"
+
+const (
+	generatorDomain = "ACE-ZKP-V01-PEDERSEN-H_BLS12381G1_XMD:SHA-256_SSWU_RO_"
+	challengeDomain = "ACE-ZKP-V01-EQUALITY-CHALLENGE"
+)
+
+// ErrNotEqual is returned when proving equality of commitments to different values.
+var ErrNotEqual = errors.New("commitments are to different values")
+
+// Commitment is a Pedersen commitment C = xG + rH to a value, where x is the SHA-256 hash of the value and r is a
+// random blinding factor. Only Point is public; the opening stays with the prover.
+type Commitment struct {
+	Point []byte
+
+	message  *big.Int
+	blinding *big.Int
+}
+
+// EqualityProof is a non-interactive proof of knowledge of r1 - r2 such that C1 - C2 = (r1 - r2)H, which shows
+// that C1 and C2 commit to the same value without revealing it.
+type EqualityProof struct {
+	T []byte
+	S []byte
+}
+
+// Commit commits to value with a fresh random blinding factor.
+func Commit(value []byte) (*Commitment, error) {
+	g := bls12381.NewG1()
+
+	h, err := generatorH(g)
+	if err != nil {
+		return nil, err
+	}
+
+	blinding, err := randomScalar(g.Q())
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate blinding factor: %w", err)
+	}
+
+	return commit(g, h, value, blinding), nil
+}
+
+// commit returns the commitment to value with the blinding factor.
+func commit(g *bls12381.G1, h *bls12381.PointG1, value []byte, blinding *big.Int) *Commitment {
+	digest := sha256.Sum256(value)
+	message := new(big.Int).Mod(new(big.Int).SetBytes(digest[:]), g.Q())
+
+	xG := g.MulScalarBig(g.New(), g.One(), message)
+	rH := g.MulScalarBig(g.New(), h, blinding)
+
+	return &Commitment{
+		Point:    g.ToCompressed(g.Add(g.New(), xG, rH)),
+		message:  message,
+		blinding: blinding,
+	}
+}
+
+// ProveEquality proves that c1 and c2 commit to the same value.
+func ProveEquality(c1, c2 *Commitment) (*EqualityProof, error) {
+	if c1.message == nil || c2.message == nil {
+		return nil, errors.New("missing commitment opening")
+	}
+
+	if c1.message.Cmp(c2.message) != 0 {
+		return nil, ErrNotEqual
+	}
+
+	g := bls12381.NewG1()
+	q := g.Q()
+
+	h, err := generatorH(g)
+	if err != nil {
+		return nil, err
+	}
+
+	k, err := randomScalar(q)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	t := g.ToCompressed(g.MulScalarBig(g.New(), h, k))
+	c := challenge(g, h, c1.Point, c2.Point, t)
+
+	// s = k + c(r1 - r2) mod q
+	s := new(big.Int).Sub(c1.blinding, c2.blinding)
+	s.Mul(s, c)
+	s.Add(s, k)
+	s.Mod(s, q)
+
+	return &EqualityProof{T: t, S: s.Bytes()}, nil
+}
+
+// VerifyEquality verifies that the commitments c1 and c2 commit to the same value.
+func VerifyEquality(c1, c2 []byte, proof *EqualityProof) error {
+	if proof == nil {
+		return errors.New("missing proof")
+	}
+
+	g := bls12381.NewG1()
+
+	h, err := generatorH(g)
+	if err != nil {
+		return err
+	}
+
+	p1, err := decodePoint(g, c1)
+	if err != nil {
+		return fmt.Errorf("invalid first commitment: %w", err)
+	}
+
+	p2, err := decodePoint(g, c2)
+	if err != nil {
+		return fmt.Errorf("invalid second commitment: %w", err)
+	}
+
+	t, err := decodePoint(g, proof.T)
+	if err != nil {
+		return fmt.Errorf("invalid proof commitment: %w", err)
+	}
+
+	s := new(big.Int).SetBytes(proof.S)
+	if s.Cmp(g.Q()) >= 0 {
+		return errors.New("invalid proof response: not a scalar")
+	}
+
+	c := challenge(g, h, c1, c2, proof.T)
+
+	// sH == T + c(C1 - C2)
+	lhs := g.MulScalarBig(g.New(), h, s)
+	rhs := g.MulScalarBig(g.New(), g.Sub(g.New(), p1, p2), c)
+	g.Add(rhs, rhs, t)
+
+	if !g.Equal(lhs, rhs) {
+		return errors.New("invalid equality proof")
+	}
+
+	return nil
+}
+
+// generatorH returns the second Pedersen generator. It is derived by hashing to the curve so that nobody knows its
+// discrete logarithm with respect to the group generator.
+func generatorH(g *bls12381.G1) (*bls12381.PointG1, error) {
+	h, err := g.HashToCurve([]byte("H"), []byte(generatorDomain))
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive pedersen generator: %w", err)
+	}
+
+	return h, nil
+}
+
+func challenge(g *bls12381.G1, h *bls12381.PointG1, c1, c2, t []byte) *big.Int {
+	hash := sha256.New()
+
+	for _, b := range [][]byte{[]byte(challengeDomain), g.ToCompressed(h), c1, c2, t} {
+		_, _ = hash.Write(b) // nolint:errcheck // hash.Write never returns an error
+	}
+
+	return new(big.Int).Mod(new(big.Int).SetBytes(hash.Sum(nil)), g.Q())
+}
+
+func decodePoint(g *bls12381.G1, raw []byte) (*bls12381.PointG1, error) {
+	p, err := g.FromCompressed(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	if !g.InCorrectSubgroup(p) {
+		return nil, errors.New("point is not in the prime order subgroup")
+	}
+
+	return p, nil
+}
+
+func randomScalar(q *big.Int) (*big.Int, error) {
+	for {
+		k, err := rand.Int(rand.Reader, q)
+		if err != nil {
+			return nil, err
+		}
+
+		if k.Sign() != 0 {
+			return k, nil
+		}
+	}
+}
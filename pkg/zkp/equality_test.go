@@ -0,0 +1,120 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package zkp_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/ace/pkg/zkp"
+)
+
+func TestCommit(t *testing.T) {
+	t.Run("commitments to the same value are hiding", func(t *testing.T) {
+		c1, err := zkp.Commit([]byte("alice"))
+		require.NoError(t, err)
+
+		c2, err := zkp.Commit([]byte("alice"))
+		require.NoError(t, err)
+
+		require.Len(t, c1.Point, 48)
+		require.NotEqual(t, c1.Point, c2.Point)
+	})
+}
+
+func TestEqualityProof(t *testing.T) {
+	t.Run("proves and verifies equality", func(t *testing.T) {
+		c1, c2 := commit(t, "alice"), commit(t, "alice")
+
+		proof, err := zkp.ProveEquality(c1, c2)
+		require.NoError(t, err)
+
+		require.NoError(t, zkp.VerifyEquality(c1.Point, c2.Point, proof))
+	})
+
+	t.Run("cannot prove equality of different values", func(t *testing.T) {
+		_, err := zkp.ProveEquality(commit(t, "alice"), commit(t, "bob"))
+		require.ErrorIs(t, err, zkp.ErrNotEqual)
+	})
+
+	t.Run("cannot prove equality without openings", func(t *testing.T) {
+		c := commit(t, "alice")
+
+		_, err := zkp.ProveEquality(c, &zkp.Commitment{Point: c.Point})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "missing commitment opening")
+	})
+
+	t.Run("proof does not verify for other commitments", func(t *testing.T) {
+		c1, c2, c3 := commit(t, "alice"), commit(t, "alice"), commit(t, "alice")
+
+		proof, err := zkp.ProveEquality(c1, c2)
+		require.NoError(t, err)
+
+		err = zkp.VerifyEquality(c1.Point, c3.Point, proof)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid equality proof")
+
+		err = zkp.VerifyEquality(c2.Point, c1.Point, proof)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid equality proof")
+	})
+
+	t.Run("tampered proof does not verify", func(t *testing.T) {
+		c1, c2 := commit(t, "alice"), commit(t, "alice")
+
+		proof, err := zkp.ProveEquality(c1, c2)
+		require.NoError(t, err)
+
+		s := new(big.Int).SetBytes(proof.S)
+		proof.S = s.Add(s, big.NewInt(1)).Bytes()
+
+		err = zkp.VerifyEquality(c1.Point, c2.Point, proof)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid equality proof")
+	})
+
+	t.Run("rejects malformed inputs", func(t *testing.T) {
+		c1, c2 := commit(t, "alice"), commit(t, "alice")
+
+		proof, err := zkp.ProveEquality(c1, c2)
+		require.NoError(t, err)
+
+		err = zkp.VerifyEquality(c1.Point, c2.Point, nil)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "missing proof")
+
+		err = zkp.VerifyEquality([]byte("invalid"), c2.Point, proof)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid first commitment")
+
+		err = zkp.VerifyEquality(c1.Point, []byte("invalid"), proof)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid second commitment")
+
+		err = zkp.VerifyEquality(c1.Point, c2.Point, &zkp.EqualityProof{T: []byte("invalid"), S: proof.S})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid proof commitment")
+
+		tooLarge := new(big.Int).Lsh(big.NewInt(1), 256).Bytes()
+
+		err = zkp.VerifyEquality(c1.Point, c2.Point, &zkp.EqualityProof{T: proof.T, S: tooLarge})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "not a scalar")
+	})
+}
+
+func commit(t *testing.T, value string) *zkp.Commitment {
+	t.Helper()
+
+	c, err := zkp.Commit([]byte(value))
+	require.NoError(t, err)
+
+	return c
+}
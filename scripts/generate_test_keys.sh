@@ -31,6 +31,14 @@ openssl ecparam -name prime256v1 -genkey -noout -out test/bdd/fixtures/keys/tls/
 openssl req -new -key test/bdd/fixtures/keys/tls/ec-key.pem -subj "/C=CA/ST=ON/O=Example Inc.:hub-auth/OU=hub-auth/CN=localhost" -out test/bdd/fixtures/keys/tls/ec-key.csr
 openssl x509 -req -in test/bdd/fixtures/keys/tls/ec-key.csr -CA test/bdd/fixtures/keys/tls/ec-cacert.pem -CAkey test/bdd/fixtures/keys/tls/ec-cakey.pem -CAcreateserial -extfile "$tmp" -out test/bdd/fixtures/keys/tls/ec-pubCert.pem -days 365
 
+#create client TLS credentials, for the scenarios authenticating with a client certificate
+client=$(mktemp)
+echo "extendedKeyUsage = clientAuth
+keyUsage = Digital Signature" >> "$client"
+openssl ecparam -name prime256v1 -genkey -noout -out test/bdd/fixtures/keys/tls/ec-client-key.pem
+openssl req -new -key test/bdd/fixtures/keys/tls/ec-client-key.pem -subj "/C=CA/ST=ON/O=Example Inc.:bdd/OU=bdd/CN=bdd-client" -out test/bdd/fixtures/keys/tls/ec-client-key.csr
+openssl x509 -req -in test/bdd/fixtures/keys/tls/ec-client-key.csr -CA test/bdd/fixtures/keys/tls/ec-cacert.pem -CAkey test/bdd/fixtures/keys/tls/ec-cakey.pem -CAcreateserial -extfile "$client" -out test/bdd/fixtures/keys/tls/ec-client-cert.pem -days 365
+
 #create master key for secret lock
 openssl rand 32 | base64 | sed 's/+/-/g; s/\//_/g' > test/bdd/fixtures/keys/tls/secret-lock.key
 
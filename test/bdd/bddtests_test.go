@@ -21,10 +21,8 @@ import (
 	tlsutil "github.com/trustbloc/edge-core/pkg/utils/tls"
 
 	"github.com/trustbloc/ace/test/bdd/pkg/common"
-	"github.com/trustbloc/ace/test/bdd/pkg/comparator"
-	"github.com/trustbloc/ace/test/bdd/pkg/csh"
-	"github.com/trustbloc/ace/test/bdd/pkg/gatekeeper"
-	"github.com/trustbloc/ace/test/bdd/pkg/vault"
+	"github.com/trustbloc/ace/test/bdd/pkg/compose"
+	"github.com/trustbloc/ace/test/bdd/pkg/stub"
 )
 
 const (
@@ -36,6 +34,10 @@ const (
 var (
 	logger    = log.New("ace-bdd")
 	tlsConfig *tls.Config //nolint:gochecknoglobals
+	// environments are the environments of the features run, set up for their scenarios only.
+	environments []*environment //nolint:gochecknoglobals
+	// update is whether the golden files are updated with the responses of the scenarios, e.g. go test . -update.
+	update = flag.Bool("update", false, "update golden files") //nolint:gochecknoglobals
 )
 
 func TestMain(m *testing.M) {
@@ -58,6 +60,8 @@ func TestMain(m *testing.M) {
 		tags = runArg
 	}
 
+	environments = selectEnvironments(tags)
+
 	status := runBDDTests(tags, format)
 
 	os.Exit(status)
@@ -104,7 +108,8 @@ func initializeTestSuite(ctx *godog.TestSuiteContext) {
 }
 
 func beforeSuiteHook() {
-	dockerComposeUp := []string{"docker-compose", "-f", composeFilePath, "up", "--force-recreate", "-d"}
+	dockerComposeUp := append([]string{"docker-compose", "-f", composeFilePath, "up", "--force-recreate", "-d"},
+		composeServices(environments)...)
 
 	logger.Infof("Running %s", strings.Join(dockerComposeUp, " "))
 
@@ -144,29 +149,29 @@ type feature interface {
 	RegisterSteps(sc *godog.ScenarioContext)
 }
 
+// initializeScenario creates the steps of a scenario, so that the state of the steps is not shared with the scenarios
+// run at the same time. Only the steps of the environments of the features run are created.
 func initializeScenario(sc *godog.ScenarioContext) {
 	commonSteps, err := common.NewSteps(tlsConfig)
 	if err != nil {
 		panic(err)
 	}
 
+	commonSteps.UpdateGoldenFiles = *update
 	commonSteps.RegisterSteps(sc)
 
-	vaultSteps, err := vault.NewSteps(tlsConfig)
-	if err != nil {
-		panic(err)
+	features := []feature{
+		stub.NewSteps(commonSteps),
+		compose.NewSteps(composeFilePath),
 	}
 
-	comparatorSteps, err := comparator.NewSteps(tlsConfig)
-	if err != nil {
-		panic(err)
-	}
+	for _, env := range environments {
+		envFeatures, e := env.steps(commonSteps)
+		if e != nil {
+			panic(e)
+		}
 
-	features := []feature{
-		gatekeeper.NewSteps(commonSteps),
-		vaultSteps,
-		comparatorSteps,
-		csh.NewSteps(tlsConfig),
+		features = append(features, envFeatures...)
 	}
 
 	for _, f := range features {
@@ -180,5 +185,24 @@ func buildOptions(tags, format string) *godog.Options {
 		Format:        format,
 		Strict:        true,
 		StopOnFailure: true,
+		Concurrency:   concurrency(),
+	}
+}
+
+// concurrency returns the number of scenarios run at a time, set with BDD_CONCURRENCY. Scenarios don't share step
+// state, as the steps are created for every scenario, but scenarios stopping services disrupt the ones run with them.
+func concurrency() int {
+	v := os.Getenv("BDD_CONCURRENCY")
+	if v == "" {
+		return 1
 	}
+
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 1 {
+		logger.Errorf("invalid 'BDD_CONCURRENCY' value: %s", v)
+
+		return 1
+	}
+
+	return n
 }
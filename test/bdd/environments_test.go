@@ -0,0 +1,153 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package bdd_test
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/trustbloc/ace/test/bdd/pkg/common"
+	"github.com/trustbloc/ace/test/bdd/pkg/comparator"
+	"github.com/trustbloc/ace/test/bdd/pkg/csh"
+	"github.com/trustbloc/ace/test/bdd/pkg/didcomm"
+	"github.com/trustbloc/ace/test/bdd/pkg/gatekeeper"
+	"github.com/trustbloc/ace/test/bdd/pkg/vault"
+)
+
+// environment is the compose services and the steps the scenarios of a feature need.
+type environment struct {
+	// tag is the tag of the feature, which the tags of its scenarios start with, e.g. @gatekeeper_e2e.
+	tag string
+	// services are the compose services the feature needs, with the services they depend on started by compose.
+	services []string
+	steps    func(commonSteps *common.Steps) ([]feature, error)
+}
+
+//nolint:gochecknoglobals
+var allEnvironments = []*environment{
+	{
+		tag: "@gatekeeper",
+		services: []string{
+			"gatekeeper.trustbloc.local", "vault.trustbloc.local", "kms.trustbloc.local", "testnet.orb.local",
+		},
+		steps: func(commonSteps *common.Steps) ([]feature, error) {
+			vaultSteps, err := vault.NewSteps(commonSteps, tlsConfig)
+			if err != nil {
+				return nil, err
+			}
+
+			return []feature{
+				gatekeeper.NewSteps(commonSteps), vaultSteps, didcomm.NewSteps(commonSteps),
+			}, nil
+		},
+	},
+	{
+		tag:      "@vault_server",
+		services: []string{"vault.trustbloc.local", "kms.trustbloc.local", "testnet.orb.local"},
+		steps: func(commonSteps *common.Steps) ([]feature, error) {
+			vaultSteps, err := vault.NewSteps(commonSteps, tlsConfig)
+			if err != nil {
+				return nil, err
+			}
+
+			return []feature{vaultSteps}, nil
+		},
+	},
+	{
+		tag: "@comparator",
+		services: []string{
+			"comparator.trustbloc.local", "vault.trustbloc.local", "kms.trustbloc.local", "testnet.orb.local",
+		},
+		steps: func(*common.Steps) ([]feature, error) {
+			comparatorSteps, err := comparator.NewSteps(tlsConfig)
+			if err != nil {
+				return nil, err
+			}
+
+			return []feature{comparatorSteps}, nil
+		},
+	},
+	{
+		tag: "@confidential-storage-hub",
+		services: []string{
+			"csh.trustbloc.local", "vault.trustbloc.local", "kms.trustbloc.local", "testnet.orb.local",
+		},
+		steps: func(commonSteps *common.Steps) ([]feature, error) {
+			return []feature{csh.NewSteps(commonSteps, tlsConfig)}, nil
+		},
+	},
+}
+
+// tagPattern matches the tags of a tag expression, with the negation of the tag, if any, e.g. ~@wip or not @wip.
+var tagPattern = regexp.MustCompile(`(~|\bnot\s+)?@[\w.-]+`)
+
+// selectEnvironments returns the environments of the features the tag expression selects scenarios of, e.g.
+// "@gatekeeper_e2e" selects the gatekeeper environment only. All the environments are returned if the expression
+// selects scenarios by other tags, e.g. @all, or by excluding tags only.
+func selectEnvironments(tags string) []*environment {
+	var selected []*environment
+
+	for _, m := range tagPattern.FindAllStringSubmatch(tags, -1) {
+		if m[1] != "" {
+			continue
+		}
+
+		env := environmentOf(m[0])
+		if env == nil {
+			return allEnvironments
+		}
+
+		if !containsEnvironment(selected, env) {
+			selected = append(selected, env)
+		}
+	}
+
+	if len(selected) == 0 {
+		return allEnvironments
+	}
+
+	return selected
+}
+
+// environmentOf returns the environment of the feature the tag belongs to, or nil if it belongs to none.
+func environmentOf(tag string) *environment {
+	for _, env := range allEnvironments {
+		if strings.HasPrefix(tag, env.tag) {
+			return env
+		}
+	}
+
+	return nil
+}
+
+func containsEnvironment(envs []*environment, env *environment) bool {
+	for _, e := range envs {
+		if e == env {
+			return true
+		}
+	}
+
+	return false
+}
+
+// composeServices returns the compose services of the environments, which may share services.
+func composeServices(envs []*environment) []string {
+	var services []string
+
+	seen := map[string]bool{}
+
+	for _, env := range envs {
+		for _, service := range env.services {
+			if !seen[service] {
+				seen[service] = true
+				services = append(services, service)
+			}
+		}
+	}
+
+	return services
+}
@@ -28,3 +28,21 @@ Feature: Comparator_REST API
     Then Extract docs from auth tokens received from comparator authorization for docIDs "M3aS9xwj8ybCwHkEiCJJR2", "M3aS9xwj8ybCwHkEiCJJR3", "M3aS9xwj8ybCwHkEiCJJR4" and validate data equal "data1", "data1", "data2" respectively
     Then Compare two docs with doc1 id "M3aS9xwj8ybCwHkEiCJJR2" and ref for doc2 id "M3aS9xwj8ybCwHkEiCJJR3" with compare result "true"
     Then Compare two docs with doc1 id "M3aS9xwj8ybCwHkEiCJJR4" and ref for doc2 id "M3aS9xwj8ybCwHkEiCJJR3" with compare result "false"
+
+  @comparator_authorized_compare
+  Scenario: Comparator compares authorized docs
+    Then Check comparator config is created
+    When Create a new vault for comparator "https://localhost:9099"
+    Then Save a document with id "M3aS9xwj8ybCwHkEiCJJR5" with data "data1" for comparator
+    Then Save a document with id "M3aS9xwj8ybCwHkEiCJJR6" with data "data1" for comparator
+    Then Save a document with id "M3aS9xwj8ybCwHkEiCJJR7" with data "data2" for comparator
+    Then Create vault authorization with duration "100"
+    Then Create comparator authorization for doc "M3aS9xwj8ybCwHkEiCJJR5"
+    Then Create comparator authorization for doc "M3aS9xwj8ybCwHkEiCJJR6"
+    Then Create comparator authorization for doc "M3aS9xwj8ybCwHkEiCJJR7"
+    When Compare docs "M3aS9xwj8ybCwHkEiCJJR5" and "M3aS9xwj8ybCwHkEiCJJR6" with the "eq" operator
+    Then the docs match
+    When Compare docs "M3aS9xwj8ybCwHkEiCJJR5" and "M3aS9xwj8ybCwHkEiCJJR7" with the "eq" operator
+    Then the docs do not match
+    When Compare docs "M3aS9xwj8ybCwHkEiCJJR5" and "M3aS9xwj8ybCwHkEiCJJR7" with the "levenshtein" fuzzy operator and threshold "0.7"
+    Then the docs match
@@ -25,6 +25,13 @@ Feature: Confidential Storage Hub
     When the user requests a new confidential-storage-hub profile
     Then the confidential-storage-hub profile is created
 
+  Scenario: Read a document with an invocation of the EDV capability of the user
+    When the user has a profile
+     And the user saves a Confidential Storage document with content "Hello World!"
+     And I authenticate as "user" using ZCAP "edvZCAP" with action "read"
+     And an HTTP GET is sent to "{documentURL}"
+    Then response status is "200 OK"
+
   Scenario: Comparison between two equal documents with doc queries
     When the user has a profile
      And the user saves a Confidential Storage document with content "Hello World!"
@@ -105,3 +112,32 @@ Feature: Confidential Storage Hub
      And the user authorizes the CSH to read the documents
      And the user requests extraction of all documents
     Then the CSH returns the decrypted documents
+
+  Scenario: Comparison between several equal documents
+    When the user has a profile
+     And the user saves a Confidential Storage document with content "Hello World!"
+     And the user saves a Confidential Storage document with content "Hello World!"
+     And the user saves a Confidential Storage document with content "Hello World!"
+     And the user authorizes the CSH to read the documents
+     And the user creates a RefQuery for one document
+     And the user requests a comparison between all documents
+    Then the result is "true"
+
+  Scenario: Comparison between several documents, one of them unequal
+    When the user has a profile
+     And the user saves a Confidential Storage document with content "Hello World!"
+     And the user saves a Confidential Storage document with content "Hello World!"
+     And the user saves a Confidential Storage document with content "Goodbye Bob!"
+     And the user authorizes the CSH to read the documents
+     And the user requests a comparison between all documents
+    Then the result is "false"
+
+  Scenario: Comparison with revoked authorizations
+    When the user has a profile
+     And the user saves a Confidential Storage document with content "Hello World!"
+     And the user saves a Confidential Storage document with content "Hello World!"
+     And the user authorizes the CSH to read the documents
+     And the user requests a comparison between the two documents
+    Then the result is "true"
+    When the user revokes the CSH's authorization to read the documents
+    Then the comparison between the documents is rejected as revoked
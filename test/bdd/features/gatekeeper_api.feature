@@ -9,10 +9,38 @@
 Feature: Gatekeeper API
   Scenario: Service health check
     Given Gatekeeper is running on "localhost" port "9014"
+      And requests time out after "5s"
     When  an HTTP GET is sent to "https://localhost:9014/healthcheck"
     Then  response status is "200 OK"
      And  response contains "status" with value "success"
 
+    When  an HTTP GET is sent to "https://localhost:9014/version"
+    Then  response status code is "200"
+     And  response contains non-empty "version"
+
+  Scenario: Service health check under load
+    When  "50" concurrent HTTP GET requests are sent to "https://localhost:9014/healthcheck"
+    Then  at most "0" of the requests failed
+     And  the "p99" latency is under "5s"
+
+  Scenario: Call Gatekeeper with a client certificate
+    Given I authenticate as "bdd-client" using client certificate "fixtures/keys/tls/ec-client-cert.pem" and key "fixtures/keys/tls/ec-client-key.pem"
+    When  an HTTP GET is sent to "https://localhost:9014/healthcheck"
+    Then  response status is "200 OK"
+
+  Scenario: Reject Gatekeeper when its CA is not trusted
+    When  an HTTP GET to "https://localhost:9014/healthcheck" is rejected with an untrusted CA
+
+  Scenario: Call the admin listener with a client certificate
+    Given I authenticate as "bdd-client" using client certificate "fixtures/keys/tls/ec-client-cert.pem" and key "fixtures/keys/tls/ec-client-key.pem"
+    When  an HTTP GET is sent to "https://localhost:9015/healthcheck"
+    Then  response status is "200 OK"
+
+  Scenario: Reject clients of the admin listener without a valid client certificate
+    When  an HTTP GET to "https://localhost:9015/healthcheck" is rejected without a client certificate
+     And  an HTTP GET to "https://localhost:9015/healthcheck" is rejected with an expired client certificate
+     And  an HTTP GET to "https://localhost:9015/healthcheck" is rejected with a client certificate of an untrusted CA
+
   Scenario: Create policy configuration for storing/releasing protected data
     When  an HTTP PUT with bearer token "gk_token" is sent to "https://localhost:9014/v1/policy/containment-policy"
           """
@@ -25,6 +53,53 @@ Feature: Gatekeeper API
           """
     Then  response status is "200 OK"
 
+    Given I authenticate as "Administrator" using bearer token "gk_token"
+    When  an HTTP GET is sent to "https://localhost:9014/v1/policy/containment-policy"
+    Then  response status is "200 OK"
+     And  response body matches golden file "fixtures/policy-get.json"
+     And  response "approvers" has "3" elements
+     And  response "approvers" contains element "did:example:eon_spengler"
+     And  response "collectors" element "0" is "did:example:ray_stantz"
+     And  response header "ETag" is non-empty
+
+    When  an HTTP PUT with bearer token "gk_token" is sent to "https://localhost:9014/v1/policy/containment-policy"
+          """
+          {
+            "collectors": ["did:example:ray_stantz"],
+            "handlers": ["did:example:alter_peck"],
+            "approvers": ["did:example:peter_venkman"],
+            "min_approvers": 1
+          }
+          """
+    Then  response status is "428 Precondition Required"
+
+    When  an HTTP DELETE is sent to "https://localhost:9014/v1/policy/containment-policy"
+    Then  response status is "200 OK"
+
+  Scenario: Delete a policy with a request body
+    Given I authenticate as "Administrator" using bearer token "gk_token"
+    When  an HTTP PUT with bearer token "gk_token" is sent to "https://localhost:9014/v1/policy/superseded-policy"
+          """
+          {
+            "collectors": ["did:example:ray_stantz"],
+            "handlers": ["did:example:alter_peck"],
+            "approvers": ["did:example:peter_venkman"],
+            "min_approvers": 1
+          }
+          """
+    Then  response status is "200 OK"
+
+    When  an HTTP DELETE is sent to "https://localhost:9014/v1/policy/superseded-policy" with body
+          """
+          {
+            "reason": "superseded by containment-policy"
+          }
+          """
+    Then  response status is "200 OK"
+
+    When  an HTTP GET is sent to "https://localhost:9014/v1/policy/superseded-policy"
+    Then  response status is "404 Not Found"
+
   Scenario: Protect a social media handle
     Given did owner with name "Intake Processor"
       And policy configuration with ID "intake-policy"
@@ -42,6 +117,49 @@ Feature: Gatekeeper API
           """
     Then  response status is "200 OK"
      And  response contains non-empty "did"
+     And  response header "Content-Type" is "application/json"
+     And  response matches schema "fixtures/schemas/protect-response.schema.json"
+
+  Scenario: Issue a consent receipt when protecting a social media handle
+    Given did owner with name "Intake Processor"
+      And policy configuration with ID "consent-policy"
+          """
+          {
+            "collectors": ["{{ .GetDID "Intake Processor" }}"],
+            "consent": {"purposes": ["fraud prevention"], "retention": "P1Y"}
+          }
+          """
+      And the DID of Gatekeeper is saved as "gatekeeperDID"
+    When  an HTTP POST with "(request-target),date,digest" headers signed by "Intake Processor" is sent to "https://localhost:9014/v1/protect" with body
+          """
+          {
+            "target": "@vision",
+            "policy": "consent-policy"
+          }
+          """
+    Then  response status is "200 OK"
+     And  save response value "did" as "resourceDID"
+     And  response "consent_receipt" is a credential issued by "gatekeeperDID"
+     And  credential claim "credentialSubject.id" is "resourceDID"
+     And  credential claim "credentialSubject.policy_id" is "consent-policy"
+
+  Scenario: Protect social media handles under load
+    Given did owner with name "Intake Processor"
+      And policy configuration with ID "load-policy"
+          """
+          {
+            "collectors": ["{{ .GetDID "Intake Processor" }}"]
+          }
+          """
+    When  "20" concurrent HTTP POST requests with "(request-target),date,digest" headers signed by "Intake Processor" are sent to "https://localhost:9014/v1/protect" with body
+          """
+          {
+            "target": "@loki",
+            "policy": "load-policy"
+          }
+          """
+    Then  at most "0" of the requests failed
+     And  the "p99" latency is under "10s"
 
   Scenario: Create a new release transaction on a DID
     Given did owner with name "Intake Processor"
@@ -94,9 +212,157 @@ Feature: Gatekeeper API
     Then  response status is "200 OK"
      And  response contains "status" with value "READY_TO_COLLECT"
 
+  Scenario: Follow the status of a ticket
+    Given did owner with name "Intake Processor"
+      And did owner with name "Handler"
+      And did owner with name "Approver"
+      And policy configuration with ID "events-policy"
+          """
+          {
+            "collectors": ["{{ .GetDID "Intake Processor" }}"],
+            "handlers": ["{{ .GetDID "Handler" }}"],
+            "approvers": ["{{ .GetDID "Approver" }}"],
+            "min_approvers": 1
+          }
+          """
+      And social media handle "@ultron" converted into DID by "Intake Processor"
+      And release transaction created on DID by "Handler"
+
+    When  I subscribe with "(request-target),date" headers signed by "Handler" to the event stream at "https://localhost:9014/v1/release/{ticket_id}/events"
+    Then  event "status" is received within "10s"
+     And  event "status" with "status" value "NEW" is received within "10s"
+
+    When  an HTTP POST with "(request-target),date" headers signed by "Approver" is sent to "https://localhost:9014/v1/release/{ticket_id}/authorize"
+    Then  response status is "200 OK"
+     And  event "status" with "status" value "READY_TO_COLLECT" is received within "10s"
+
+  Scenario: Authorize a ticket over DIDComm
+    Given did owner with name "Intake Processor"
+      And did owner with name "Handler"
+      And DIDComm agent "Approver" is started
+      And DIDComm agent "Approver" accepts an invitation of Gatekeeper as "approver"
+      And policy configuration with ID "didcomm-policy"
+          """
+          {
+            "collectors": ["{{ .GetDID "Intake Processor" }}"],
+            "handlers": ["{{ .GetDID "Handler" }}"],
+            "approvers": ["{{ .Param "Approver" }}"],
+            "min_approvers": 1
+          }
+          """
+      And social media handle "@vision" converted into DID by "Intake Processor"
+      And release transaction created on DID by "Handler"
+
+    Then  DIDComm agent "Approver" receives an "approval-request" message within "10s"
+     And  the "approval-request" message received by DIDComm agent "Approver" contains "body.policy_id" with value "didcomm-policy"
+
+    When  DIDComm agent "Approver" replies to the "approval-request" message with an "authorize" message signed by another key
+    Then  response status is "401 Unauthorized"
+
+    When  an HTTP GET with "(request-target),date" headers signed by "Handler" is sent to "https://localhost:9014/v1/release/{ticket_id}/status"
+    Then  response status is "200 OK"
+     And  response contains "status" with value "NEW"
+
+    When  DIDComm agent "Approver" replies to the "approval-request" message with an "authorize" message
+    Then  response status is "202 Accepted"
+
+    When  an HTTP GET with "(request-target),date" headers signed by "Handler" is sent to "https://localhost:9014/v1/release/{ticket_id}/status"
+    Then  response status is "200 OK"
+     And  response contains "status" with value "READY_TO_COLLECT"
+
+  Scenario: Refuse a break-glass access when the approvers can't be notified
+    Given a stub service "webhook" is started
+      And stub service "webhook" responds to "POST /subjects" with status "200"
+      And stub service "webhook" responds to "POST /approvers" with status "503" and body
+          """
+          {"error": "unavailable"}
+          """
+      And did owner with name "Intake Processor"
+      And did owner with name "Handler"
+      And did owner with name "Responder"
+      And policy configuration with ID "break-glass-policy"
+          """
+          {
+            "collectors": ["{{ .GetDID "Intake Processor" }}"],
+            "handlers": ["{{ .GetDID "Handler" }}"],
+            "notification": {"webhook": "{{ .Param "webhook" }}/subjects"},
+            "break_glass": {
+              "responders": ["{{ .GetDID "Responder" }}"],
+              "notification": {"webhook": "{{ .Param "webhook" }}/approvers"}
+            }
+          }
+          """
+      And social media handle "@loki" converted into DID by "Intake Processor"
+
+    When  an HTTP POST with "(request-target),date,digest" headers signed by "Responder" is sent to "https://localhost:9014/v1/breakglass" with body
+          """
+          {
+            "did": "{{ .Value "targetDID" }}",
+            "justification": "Ongoing incident"
+          }
+          """
+    Then  response status is "502 Bad Gateway"
+     And  stub service "webhook" received "1" requests to "POST /subjects"
+     And  stub service "webhook" received "1" requests to "POST /approvers"
+     And  the last request to stub service "webhook" contains "event" with value "break_glass"
+
+  Scenario: Refuse a break-glass access when the notification times out
+    Given a stub service "webhook" is started
+      And stub service "webhook" responds to "POST /subjects" with status "200"
+      And stub service "webhook" responds to "POST /approvers" with status "200"
+      And stub service "webhook" delays responses by "15s"
+      And did owner with name "Intake Processor"
+      And did owner with name "Handler"
+      And did owner with name "Responder"
+      And policy configuration with ID "break-glass-timeout-policy"
+          """
+          {
+            "collectors": ["{{ .GetDID "Intake Processor" }}"],
+            "handlers": ["{{ .GetDID "Handler" }}"],
+            "notification": {"webhook": "{{ .Param "webhook" }}/subjects"},
+            "break_glass": {
+              "responders": ["{{ .GetDID "Responder" }}"],
+              "notification": {"webhook": "{{ .Param "webhook" }}/approvers"}
+            }
+          }
+          """
+      And social media handle "@hela" converted into DID by "Intake Processor"
+
+    When  an HTTP POST with "(request-target),date,digest" headers signed by "Responder" is sent to "https://localhost:9014/v1/breakglass" with body
+          """
+          {
+            "did": "{{ .Value "targetDID" }}",
+            "justification": "Ongoing incident"
+          }
+          """
+    Then  response status is "502 Bad Gateway"
+     And  stub service "webhook" received "1" requests to "POST /subjects"
+
+  @gatekeeper_restart
+  Scenario: Keep policies over restarts of Gatekeeper
+    Given policy configuration with ID "restart-policy"
+          """
+          {
+            "collectors": ["did:example:ray_stantz"]
+          }
+          """
+      And I authenticate as "Administrator" using bearer token "gk_token"
+
+    When  the "gatekeeper.trustbloc.local" service is restarted
+     And  Gatekeeper is running on "localhost" port "9014"
+     And  an HTTP GET is sent to "https://localhost:9014/v1/policy/restart-policy"
+    Then  response status is "200 OK"
+
+    When  the "gatekeeper.trustbloc.local" service is stopped
+     And  the "gatekeeper.trustbloc.local" service is started
+     And  an HTTP GET is sent to "https://localhost:9014/healthcheck" until "status" equals "success" within "60s"
+     And  an HTTP GET is sent to "https://localhost:9014/v1/policy/restart-policy"
+    Then  response status is "200 OK"
+
   @gatekeeper_e2e
   Scenario: Protect and extract social media handle (e2e flow)
-    Given did owner with name "Intake Processor"
+    Given a generated "social media handle" saved as "handle"
+      And did owner with name "Intake Processor"
       And did owner with name "Handler"
       And did owner with name "Approver 1"
       And did owner with name "Approver 2"
@@ -113,17 +379,17 @@ Feature: Gatekeeper API
     When  an HTTP POST with "(request-target),date,digest" headers signed by "Intake Processor" is sent to "https://GATEKEEPER_HOST/v1/protect" with body
           """
           {
-            "target": "@thanos27",
+            "target": "{{ .handle }}",
             "policy": "full-scenario-policy"
           }
           """
     Then  response status is "200 OK"
-     And  response contains non-empty "did"
+     And  save response value "did" as "resourceDID"
 
     When  an HTTP POST with "(request-target),date,digest" headers signed by "Handler" is sent to "https://GATEKEEPER_HOST/v1/release" with body
           """
           {
-            "did": "{{ .Value "did" }}"
+            "did": "{{ .resourceDID }}"
           }
           """
     Then  response status is "200 OK"
@@ -146,4 +412,19 @@ Feature: Gatekeeper API
           }
           """
     Then  response status is "200 OK"
-     And  response contains "target" with value "@thanos27"
+     And  response contains "target" with saved value "handle"
+
+  Scenario: Reject an extract request uploaded as a form
+    When  an HTTP POST is sent to "https://localhost:9014/v1/extract" with file "fixtures/extract-request.json" in form field "request"
+    Then  response status is "400 Bad Request"
+
+  Scenario: Count the queries that failed to be extracted in bulk
+    When  an HTTP POST is sent to "https://localhost:9014/v1/extract/batch"
+          """
+          {
+            "query_ids": ["unknown-query"]
+          }
+          """
+    Then  response status is "200 OK"
+     And  response header "Content-Type" is "application/x-ndjson"
+     And  response trailer "X-Failed-Queries" is "1"
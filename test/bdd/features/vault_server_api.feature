@@ -33,6 +33,7 @@ Feature: Vault server API
     And Check that a document with id "M3aS9xwj8ybCwHkEiCJJR1" is stored
     Then Create a new "key" authorization with duration "100" and save the result as "auth"
     And Check that a document with id "M3aS9xwj8ybCwHkEiCJJR1" is available for "auth"
+    And Read a document with id "M3aS9xwj8ybCwHkEiCJJR1" for "auth" and check that its data is "data1"
 
   @vault_server_create_authorization_trustbloc
   Scenario: Creates an authorization (trustbloc)
@@ -0,0 +1,44 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package common
+
+import (
+	"context"
+
+	"github.com/cucumber/godog"
+	"github.com/hyperledger/aries-framework-go/pkg/common/log"
+)
+
+var cleanupLogger = log.New("ace-bdd-cleanup")
+
+// cleanup deletes a resource created by the scenario.
+type cleanup struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+// AddCleanup registers the function deleting the resource created by the scenario, e.g. "policy intake-policy", so
+// that repeated runs against a shared environment don't accumulate state. The functions run after the scenario in
+// the reverse order they were registered in.
+func (s *Steps) AddCleanup(name string, fn func(ctx context.Context) error) {
+	s.cleanups = append(s.cleanups, cleanup{name: name, fn: fn})
+}
+
+func (s *Steps) registerCleanupSteps(sc *godog.ScenarioContext) {
+	sc.After(func(ctx context.Context, _ *godog.Scenario, err error) (context.Context, error) {
+		for i := len(s.cleanups) - 1; i >= 0; i-- {
+			// the resources left behind don't fail the scenario
+			if e := s.cleanups[i].fn(ctx); e != nil {
+				cleanupLogger.Warnf("Failed to clean up %s: %s", s.cleanups[i].name, e)
+			}
+		}
+
+		s.cleanups = nil
+
+		return ctx, err
+	})
+}
@@ -11,45 +11,79 @@ import (
 	"context"
 	"crypto/ed25519"
 	"crypto/tls"
+	"crypto/x509"
 	"errors"
 	"fmt"
 	"net/http"
 	"os"
+	"path/filepath"
 	"strings"
 	"text/template"
+	"time"
 
+	"github.com/cenkalti/backoff/v4"
 	"github.com/cucumber/godog"
+	"github.com/hyperledger/aries-framework-go/pkg/common/log"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
 	vdrapi "github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdr"
+	spilog "github.com/hyperledger/aries-framework-go/spi/log"
+	"github.com/igor-pavlenko/httpsignatures-go"
 	"github.com/tidwall/gjson"
+	"github.com/xeipuuv/gojsonschema"
 
+	"github.com/trustbloc/ace/pkg/bench"
+	"github.com/trustbloc/ace/pkg/fakedata"
 	"github.com/trustbloc/ace/pkg/httpsig"
 	"github.com/trustbloc/ace/test/bdd/pkg/internal/httputil"
 	"github.com/trustbloc/ace/test/bdd/pkg/internal/vdrutil"
 )
 
+// httpLogger logs the requests of the steps, with credentials redacted, if HTTP_CLIENT_DEBUG_ON is set.
+var httpLogger = log.New(httpLoggerModule)
+
 const (
-	healthCheckURL = "https://%s:%d/healthcheck"
+	healthCheckURL   = "https://%s:%d/healthcheck"
+	httpLoggerModule = "ace-bdd-http"
+	// connectRetries is the number of times GET requests failing to connect are sent again, e.g. to restarted services.
+	connectRetries = 5
+	connectBackoff = 500 * time.Millisecond
 )
 
 // Steps defines context for common scenario steps.
 type Steps struct {
-	HTTPClient         *http.Client
-	VDR                vdrapi.Registry
+	HTTPClient *http.Client
+	VDR        vdrapi.Registry
+	// UpdateGoldenFiles is whether the golden files are written with the responses instead of compared with them.
+	UpdateGoldenFiles  bool
 	responseStatus     string
 	responseStatusCode int
+	responseHeader     http.Header
+	responseTrailer    http.Header
 	responseBody       []byte
+	// params are the response values saved by the scenario to be used in later requests.
+	params map[string]string
+	// auth are the credentials set by the authentication steps, attached to the requests sent after them.
+	auth []httputil.Opt
+	// timeout is the time the requests sent after the timeout step are done within, if set.
+	timeout   time.Duration
+	tlsConfig *tls.Config
+	// loadResult is the result of the last load sent by the scenario.
+	loadResult *bench.Result
+	// events is the event stream the scenario subscribed to last.
+	events *eventStream
+	// fakeData generates the sensitive data of the scenario.
+	fakeData *fakedata.Generator
+	// credential is the credential verified last by the scenario.
+	credential *verifiable.Credential
+	// cleanups delete the resources created by the scenario once it ends.
+	cleanups []cleanup
 }
 
 // NewSteps returns new Steps context.
 func NewSteps(tlsConfig *tls.Config) (*Steps, error) {
-	httpClient := &http.Client{
-		Transport: &http.Transport{
-			TLSClientConfig: tlsConfig,
-		},
-	}
-
-	if os.Getenv("HTTP_CLIENT_TRACE_ON") == "true" {
-		httpClient = httputil.WrapWithDumpTransport(httpClient)
+	httpClient, err := newHTTPClient(tlsConfig)
+	if err != nil {
+		return nil, err
 	}
 
 	vdr, err := vdrutil.CreateVDR(httpClient)
@@ -60,20 +94,71 @@ func NewSteps(tlsConfig *tls.Config) (*Steps, error) {
 	return &Steps{
 		HTTPClient: httpClient,
 		VDR:        vdr,
+		params:     map[string]string{},
+		tlsConfig:  tlsConfig,
 	}, nil
 }
 
+// newHTTPClient returns the HTTP client of the steps, sending requests through the proxy set with BDD_PROXY_URL, if
+// any, e.g. to inject network failures with toxiproxy.
+func newHTTPClient(tlsConfig *tls.Config) (*http.Client, error) {
+	transport := &http.Transport{
+		TLSClientConfig: tlsConfig,
+	}
+
+	if v := os.Getenv("BDD_PROXY_URL"); v != "" {
+		proxy, err := httputil.ProxyURL(v)
+		if err != nil {
+			return nil, fmt.Errorf("set proxy of BDD_PROXY_URL: %w", err)
+		}
+
+		transport.Proxy = proxy
+	}
+
+	httpClient := &http.Client{Transport: transport}
+
+	if os.Getenv("HTTP_CLIENT_TRACE_ON") == "true" {
+		httpClient = httputil.WrapWithDumpTransport(httpClient)
+	}
+
+	return httpClient, nil
+}
+
 // RegisterSteps registers common scenario steps.
 func (s *Steps) RegisterSteps(sc *godog.ScenarioContext) {
 	sc.Step(`^an HTTP GET is sent to "([^"]*)"$`, s.httpGet)
+	sc.Step(`^an HTTP GET is sent to "([^"]*)" until "([^"]*)" equals "([^"]*)" within "([^"]*)"$`, s.httpGetUntil)
 	sc.Step(`^an HTTP PUT with bearer token "([^"]*)" is sent to "([^"]*)"$`, s.httpPutWithToken)
 	sc.Step(`^an HTTP POST is sent to "([^"]*)"$`, s.httpPost)
+	sc.Step(`^an HTTP POST is sent to "([^"]*)" with file "([^"]*)" in form field "([^"]*)"$`, s.httpPostFile)
+	sc.Step(`^an HTTP DELETE is sent to "([^"]*)"$`, s.httpDelete)
+	sc.Step(`^an HTTP DELETE is sent to "([^"]*)" with body$`, s.httpDeleteWithBody)
 	sc.Step(`^an HTTP GET with "([^"]*)" headers signed by "([^"]*)" is sent to "([^"]*)"$`, s.httpGetSigned)
 	sc.Step(`^an HTTP POST with "([^"]*)" headers signed by "([^"]*)" is sent to "([^"]*)"$`, s.httpPostSigned)
 	sc.Step(`^an HTTP POST with "([^"]*)" headers signed by "([^"]*)" is sent to "([^"]*)" with body$`, s.httpPostSignedWithBody) //nolint:lll
+	sc.Step(`^I authenticate as "([^"]*)" using bearer token "([^"]*)"$`, s.authenticateWithToken)
+	sc.Step(`^I authenticate as "([^"]*)" using client certificate "([^"]*)" and key "([^"]*)"$`, s.authenticateWithCert)
+	sc.Step(`^I authenticate as "([^"]*)" using ZCAP "([^"]*)" with action "([^"]*)"$`, s.authenticateWithZCAP)
+	sc.Step(`^requests time out after "([^"]*)"$`, s.setRequestTimeout)
+	s.registerLoadSteps(sc)
+	s.registerEventSteps(sc)
+	s.registerTLSSteps(sc)
+	s.registerFakeDataSteps(sc)
+	s.registerGoldenSteps(sc)
+	s.registerCredentialSteps(sc)
+	s.registerCleanupSteps(sc)
 	sc.Step(`^response status is "([^"]*)"$`, s.checkResponseStatus)
+	sc.Step(`^response status code is "(\d+)"$`, s.checkResponseStatusCode)
 	sc.Step(`^response contains "([^"]*)" with value "([^"]*)"$`, s.checkResponseValue)
 	sc.Step(`^response contains non-empty "([^"]*)"$`, s.checkNonEmptyResponseValue)
+	sc.Step(`^response "([^"]*)" has "(\d+)" elements$`, s.checkResponseArrayLength)
+	sc.Step(`^response "([^"]*)" contains element "([^"]*)"$`, s.checkResponseArrayContains)
+	sc.Step(`^response "([^"]*)" element "(\d+)" is "([^"]*)"$`, s.checkResponseArrayElement)
+	sc.Step(`^save response value "([^"]*)" as "([^"]*)"$`, s.saveResponseValue)
+	sc.Step(`^response header "([^"]*)" is "([^"]*)"$`, s.checkResponseHeader)
+	sc.Step(`^response header "([^"]*)" is non-empty$`, s.checkNonEmptyResponseHeader)
+	sc.Step(`^response trailer "([^"]*)" is "([^"]*)"$`, s.checkResponseTrailer)
+	sc.Step(`^response matches schema "([^"]*)"$`, s.checkResponseSchema)
 }
 
 type healthCheckResponse struct {
@@ -87,12 +172,15 @@ func (s *Steps) HealthCheck(ctx context.Context, host string, port int) error {
 	var resp healthCheckResponse
 
 	r, err := httputil.DoRequest(ctx, url, httputil.WithHTTPClient(s.HTTPClient),
-		httputil.WithParsedResponse(&resp))
+		httputil.WithRetry(connectRetries, connectBackoff), httputil.WithParsedResponse(&resp))
 	if err != nil {
 		return fmt.Errorf("do request: %w", err)
 	}
 
 	s.responseStatus = r.Status
+	s.responseStatusCode = r.StatusCode
+	s.responseHeader = r.Header
+	s.responseTrailer = r.Trailer
 	s.responseBody = r.Body
 
 	if r.StatusCode == http.StatusOK && resp.Status == "success" {
@@ -119,6 +207,35 @@ func (s *Steps) httpGet(ctx context.Context, url string) error {
 	return s.httpDo(ctx, http.MethodGet, url, nil)
 }
 
+// httpGetUntil polls the URL with an exponential backoff until the response value at the path equals the value, or
+// the timeout is reached. Failed requests are retried as well, e.g. while the resource is not created yet.
+func (s *Steps) httpGetUntil(ctx context.Context, url, path, value, timeout string) error {
+	d, err := time.ParseDuration(timeout)
+	if err != nil {
+		return fmt.Errorf("parse timeout: %w", err)
+	}
+
+	b := backoff.NewExponentialBackOff()
+	b.MaxElapsedTime = d
+
+	err = backoff.Retry(func() error {
+		if e := s.httpDo(ctx, http.MethodGet, url, nil); e != nil {
+			return e
+		}
+
+		if got := gjson.Get(string(s.responseBody), path).String(); got != value {
+			return fmt.Errorf("got %q", got)
+		}
+
+		return nil
+	}, backoff.WithContext(b, ctx))
+	if err != nil {
+		return fmt.Errorf("%s did not equal %q within %s: %w", path, value, timeout, err)
+	}
+
+	return nil
+}
+
 func (s *Steps) httpPutWithToken(ctx context.Context, token, url string, docStr *godog.DocString) error {
 	return s.httpDo(ctx, http.MethodPut, url, docStr, httputil.WithAuthToken(token))
 }
@@ -127,13 +244,32 @@ func (s *Steps) httpPost(ctx context.Context, url string, docStr *godog.DocStrin
 	return s.httpDo(ctx, http.MethodPost, url, docStr)
 }
 
+// httpPostFile posts the fixture file as multipart form data.
+func (s *Steps) httpPostFile(ctx context.Context, url, path, field string) error {
+	content, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return fmt.Errorf("read file: %w", err)
+	}
+
+	return s.httpDo(ctx, http.MethodPost, url, nil,
+		httputil.WithMultipart(&httputil.Part{Name: field, FileName: filepath.Base(path), Content: content}))
+}
+
+func (s *Steps) httpDelete(ctx context.Context, url string) error {
+	return s.httpDo(ctx, http.MethodDelete, url, nil)
+}
+
+func (s *Steps) httpDeleteWithBody(ctx context.Context, url string, bodyTemplate *godog.DocString) error {
+	return s.httpDo(ctx, http.MethodDelete, url, bodyTemplate)
+}
+
 func (s *Steps) httpGetSigned(ctx context.Context, headers, signer, url string) error {
 	sig, err := getSigner(ctx, headers, signer)
 	if err != nil {
 		return fmt.Errorf("get signer for http get: %w", err)
 	}
 
-	return s.httpDo(ctx, http.MethodGet, url, nil, httputil.WithSigner(sig))
+	return s.httpDo(ctx, http.MethodGet, url, nil, httputil.WithHTTPSignature(sig))
 }
 
 func (s *Steps) httpPostSigned(ctx context.Context, headers, signer, url string) error {
@@ -142,7 +278,7 @@ func (s *Steps) httpPostSigned(ctx context.Context, headers, signer, url string)
 		return fmt.Errorf("get signer for http post: %w", err)
 	}
 
-	return s.httpDo(ctx, http.MethodPost, url, nil, httputil.WithSigner(sig))
+	return s.httpDo(ctx, http.MethodPost, url, nil, httputil.WithHTTPSignature(sig))
 }
 
 func (s *Steps) httpPostSignedWithBody(ctx context.Context, headers, signer, url string,
@@ -152,9 +288,92 @@ func (s *Steps) httpPostSignedWithBody(ctx context.Context, headers, signer, url
 		return fmt.Errorf("get signer for http post: %w", err)
 	}
 
-	return s.httpDo(ctx, http.MethodPost, url, bodyTemplate, httputil.WithSigner(sig))
+	return s.httpDo(ctx, http.MethodPost, url, bodyTemplate, httputil.WithHTTPSignature(sig))
+}
+
+// setRequestTimeout sets the time the requests sent later in the scenario are done within.
+func (s *Steps) setRequestTimeout(timeout string) error {
+	d, err := time.ParseDuration(timeout)
+	if err != nil {
+		return fmt.Errorf("parse timeout: %w", err)
+	}
+
+	s.timeout = d
+
+	return nil
+}
+
+// authenticateWithToken sends the requests of the scenario with the bearer token of the identity.
+func (s *Steps) authenticateWithToken(identity, token string) error {
+	s.authenticate(identity, httputil.WithAuthToken(token))
+
+	return nil
+}
+
+// authenticateWithCert sends the requests of the scenario with the client certificate, for mutual TLS. The
+// certificate must be issued to the identity, as its common name.
+func (s *Steps) authenticateWithCert(identity, certFile, keyFile string) error {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return fmt.Errorf("load client certificate: %w", err)
+	}
+
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return fmt.Errorf("parse client certificate: %w", err)
+	}
+
+	if leaf.Subject.CommonName != identity {
+		return fmt.Errorf("client certificate is issued to %q, not %q", leaf.Subject.CommonName, identity)
+	}
+
+	tlsConfig := s.cloneTLSConfig()
+	tlsConfig.Certificates = []tls.Certificate{cert}
+
+	if s.HTTPClient, err = newHTTPClient(tlsConfig); err != nil {
+		return err
+	}
+
+	s.authenticate(identity)
+
+	return nil
 }
 
+// authenticateWithZCAP signs the requests of the scenario as an invocation of the capability with the key of the
+// identity, a signer of the scenario. The capability is a saved value, or else the compressed capability itself.
+func (s *Steps) authenticateWithZCAP(ctx context.Context, identity, capability, action string) error {
+	sig, err := getSigner(ctx, zcapHeaders, identity)
+	if err != nil {
+		return fmt.Errorf("get signer for zcap: %w", err)
+	}
+
+	// capabilities are verified by the EDV and the KMS, which only accept signatures of the ZCAP-LD algorithm
+	sig.Algorithm = zcapAlgorithm
+
+	if v, ok := s.params[capability]; ok {
+		capability = v
+	}
+
+	s.authenticate(identity, httputil.WithZCAPInvocation(sig, capability, action))
+
+	return nil
+}
+
+// authenticate attaches the credentials of the identity to the requests sent later in the scenario, and sets the
+// identity as the identity parameter of their bodies, e.g. {{ .identity }}.
+func (s *Steps) authenticate(identity string, auth ...httputil.Opt) {
+	s.auth = auth
+	s.params[identityParam] = identity
+}
+
+const (
+	// zcapHeaders are the headers signed in a capability invocation.
+	zcapHeaders = "(request-target),date,capability-invocation"
+	// zcapAlgorithm is the identifier of the HTTP signatures of capability invocations verified with ZCAP-LD.
+	zcapAlgorithm = "https://github.com/hyperledger/aries-framework-go/zcaps"
+	identityParam = "identity"
+)
+
 func getSigner(ctx context.Context, headers, signer string) (*RequestSigner, error) {
 	opts, ok := ctx.Value(contextKey(signer)).(*SignerOpts)
 	if !ok {
@@ -170,8 +389,60 @@ func getSigner(ctx context.Context, headers, signer string) (*RequestSigner, err
 
 func (s *Steps) httpDo(ctx context.Context, method, url string, bodyTemplate *godog.DocString,
 	opts ...httputil.Opt) error {
+	url, opts, err := s.request(ctx, method, url, bodyTemplate, opts...)
+	if err != nil {
+		return err
+	}
+
+	// the responses of error statuses are recorded for the response steps, e.g. response status is "400 Bad Request"
+	r, err := httputil.DoRequest(ctx, url, append(opts, httputil.WithErrorResponse())...)
+	if err != nil {
+		return fmt.Errorf("do request: %w", err)
+	}
+
+	s.responseStatus = r.Status
+	s.responseStatusCode = r.StatusCode
+	s.responseHeader = r.Header
+	s.responseTrailer = r.Trailer
+	s.responseBody = r.Body
+
+	return nil
+}
+
+// Do sends a request of the steps of a feature, and records its response for the response steps of the scenario.
+func (s *Steps) Do(ctx context.Context, method, url string, opts ...httputil.Opt) error {
+	return s.httpDo(ctx, method, url, nil, opts...)
+}
+
+// request returns the URL and the options of a request of the scenario, with the body executed from the template.
+func (s *Steps) request(ctx context.Context, method, url string, bodyTemplate *godog.DocString,
+	opts ...httputil.Opt) (string, []httputil.Opt, error) {
+	// only GET requests are sent again by default, the steps sending other requests opt in with a retry option
+	var retry []httputil.Opt
+
+	if method == http.MethodGet {
+		retry = []httputil.Opt{httputil.WithRetry(connectRetries, connectBackoff)}
+	}
+
+	// the options of the step take precedence over the credentials of the scenario
+	opts = append(append(retry, s.auth...), opts...)
 	opts = append(opts, httputil.WithHTTPClient(s.HTTPClient), httputil.WithMethod(method))
 
+	if s.timeout > 0 {
+		opts = append(opts, httputil.WithTimeout(s.timeout))
+	}
+
+	if os.Getenv("HTTP_CLIENT_DEBUG_ON") == "true" {
+		log.SetLevel(httpLoggerModule, spilog.DEBUG)
+
+		opts = append(opts, httputil.WithDebugLogging(httpLogger))
+	}
+
+	// saved values are referenced in URLs by name, e.g. {documentURL}
+	for name, value := range s.params {
+		url = strings.ReplaceAll(url, "{"+name+"}", value)
+	}
+
 	if strings.Contains(url, "{ticket_id}") {
 		url = strings.ReplaceAll(url, "{ticket_id}", ctx.Value("ticket_id").(string)) //nolint:forcetypeassert
 	}
@@ -183,34 +454,33 @@ func (s *Steps) httpDo(ctx context.Context, method, url string, bodyTemplate *go
 	if bodyTemplate != nil {
 		t, err := template.New("body").Parse(bodyTemplate.Content)
 		if err != nil {
-			return fmt.Errorf("parse body template: %w", err)
+			return "", nil, fmt.Errorf("parse body template: %w", err)
 		}
 
 		var buf bytes.Buffer
 
-		err = t.Execute(&buf, ctx)
+		err = t.Execute(&buf, s.templateParams(ctx))
 		if err != nil {
-			return fmt.Errorf("execute body template: %w", err)
+			return "", nil, fmt.Errorf("execute body template: %w", err)
 		}
 
 		opts = append(opts, httputil.WithBody(buf.Bytes()))
 	}
 
-	r, err := httputil.DoRequest(ctx, url, opts...)
-	if err != nil {
-		return fmt.Errorf("do request: %w", err)
-	}
+	return url, opts, nil
+}
 
-	s.responseStatus = r.Status
-	s.responseStatusCode = r.StatusCode
-	s.responseBody = r.Body
+func (s *Steps) checkResponseStatus(status string) error {
+	if s.responseStatus != status {
+		return fmt.Errorf("expected %q, got %q: %s", status, s.responseStatus, s.responseBody)
+	}
 
 	return nil
 }
 
-func (s *Steps) checkResponseStatus(status string) error {
-	if s.responseStatus != status {
-		return fmt.Errorf("expected %q, got %q", status, s.responseStatus)
+func (s *Steps) checkResponseStatusCode(code int) error {
+	if s.responseStatusCode != code {
+		return fmt.Errorf("expected status code %d, got %d", code, s.responseStatusCode)
 	}
 
 	return nil
@@ -236,15 +506,174 @@ func (s *Steps) checkNonEmptyResponseValue(ctx context.Context, path string) (co
 	return context.WithValue(ctx, path, res.Str), nil //nolint:revive,staticcheck
 }
 
+func (s *Steps) responseArray(path string) ([]gjson.Result, error) {
+	res := gjson.Get(string(s.responseBody), path)
+
+	if !res.IsArray() {
+		return nil, fmt.Errorf("%q is not an array: got %q", path, res.Raw)
+	}
+
+	return res.Array(), nil
+}
+
+func (s *Steps) checkResponseArrayLength(path string, length int) error {
+	elements, err := s.responseArray(path)
+	if err != nil {
+		return err
+	}
+
+	if len(elements) != length {
+		return fmt.Errorf("expected %d elements, got %d", length, len(elements))
+	}
+
+	return nil
+}
+
+func (s *Steps) checkResponseArrayContains(path, value string) error {
+	elements, err := s.responseArray(path)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range elements {
+		if e.String() == value {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%q does not contain %q", path, value)
+}
+
+func (s *Steps) checkResponseArrayElement(path string, index int, value string) error {
+	elements, err := s.responseArray(path)
+	if err != nil {
+		return err
+	}
+
+	if index >= len(elements) {
+		return fmt.Errorf("%q has %d elements, no element %d", path, len(elements), index)
+	}
+
+	if got := elements[index].String(); got != value {
+		return fmt.Errorf("expected %q, got %q", value, got)
+	}
+
+	return nil
+}
+
+func (s *Steps) saveResponseValue(ctx context.Context, path, name string) (context.Context, error) {
+	res := gjson.Get(string(s.responseBody), path)
+
+	if !res.Exists() {
+		return ctx, fmt.Errorf("missing %q in response", path)
+	}
+
+	s.params[name] = res.String()
+
+	return context.WithValue(ctx, name, res.String()), nil //nolint:revive,staticcheck
+}
+
+// SetParam sets a parameter of the request bodies sent later in the scenario, e.g. {{ .name }}.
+func (s *Steps) SetParam(name, value string) {
+	s.params[name] = value
+}
+
+// Param returns a parameter of the request bodies, set with SetParam or saved from a response.
+func (s *Steps) Param(name string) string {
+	return s.params[name]
+}
+
+// templateParams returns the parameters request bodies are executed with: the saved response values, e.g.
+// {{ .resourceDID }}, and the values of the scenario context, e.g. {{ .Value "targetDID" }}.
+func (s *Steps) templateParams(ctx context.Context) templateParams {
+	params := templateParams{contextParam: ctx}
+
+	for k, v := range s.params {
+		params[k] = v
+	}
+
+	return params
+}
+
+// contextParam is the key of the scenario context in the template params, which templates can't reference as a field.
+const contextParam = ""
+
+type templateParams map[string]interface{}
+
+// Value returns the value of the scenario context with the given key.
+func (p templateParams) Value(key string) interface{} {
+	ctx, ok := p[contextParam].(context.Context)
+	if !ok {
+		return nil
+	}
+
+	return ctx.Value(key)
+}
+
+func (s *Steps) checkResponseHeader(name, value string) error {
+	if v := s.responseHeader.Get(name); v != value {
+		return fmt.Errorf("expected header %s %q, got %q", name, value, v)
+	}
+
+	return nil
+}
+
+func (s *Steps) checkNonEmptyResponseHeader(ctx context.Context, name string) (context.Context, error) {
+	v := s.responseHeader.Get(name)
+	if v == "" {
+		return ctx, fmt.Errorf("got empty header %s", name)
+	}
+
+	return context.WithValue(ctx, name, v), nil //nolint:revive,staticcheck
+}
+
+func (s *Steps) checkResponseTrailer(name, value string) error {
+	if v := s.responseTrailer.Get(name); v != value {
+		return fmt.Errorf("expected trailer %s %q, got %q", name, value, v)
+	}
+
+	return nil
+}
+
+func (s *Steps) checkResponseSchema(path string) error {
+	schema, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return fmt.Errorf("read schema: %w", err)
+	}
+
+	res, err := gojsonschema.Validate(gojsonschema.NewBytesLoader(schema), gojsonschema.NewBytesLoader(s.responseBody))
+	if err != nil {
+		return fmt.Errorf("validate response: %w", err)
+	}
+
+	if !res.Valid() {
+		var errs []string
+
+		for _, e := range res.Errors() {
+			errs = append(errs, e.String())
+		}
+
+		return fmt.Errorf("response does not match schema %s: %s", path, strings.Join(errs, "; "))
+	}
+
+	return nil
+}
+
 // RequestSigner is a signer in HTTP Signatures auth method.
 type RequestSigner struct {
 	Headers     []string
 	PublicKeyID string
 	PrivateKey  ed25519.PrivateKey
+	// Algorithm, if set, is the name of the algorithm of the signatures, signed with the Ed25519 key all the same.
+	Algorithm string
 }
 
 // Sign signs an HTTP request.
 func (s *RequestSigner) Sign(req *http.Request) error {
+	if s.Algorithm != "" {
+		return s.signWithAlgorithm(req)
+	}
+
 	signer := httpsig.NewSigner(httpsig.SignerConfig{Headers: s.Headers}, s.PrivateKey)
 
 	if err := signer.SignRequest(s.PublicKeyID, req); err != nil {
@@ -253,3 +682,39 @@ func (s *RequestSigner) Sign(req *http.Request) error {
 
 	return nil
 }
+
+func (s *RequestSigner) signWithAlgorithm(req *http.Request) error {
+	req.Header.Set("Date", time.Now().UTC().Format(http.TimeFormat))
+
+	hs := httpsignatures.NewHTTPSignatures(&namedSecrets{algorithm: s.Algorithm})
+	hs.SetDefaultSignatureHeaders(s.Headers)
+	hs.SetSignatureHashAlgorithm(&namedAlgorithm{
+		SignatureHashAlgorithm: httpsig.NewSignerAlgorithm(s.PrivateKey),
+		name:                   s.Algorithm,
+	})
+
+	if err := hs.Sign(s.PublicKeyID, req); err != nil {
+		return fmt.Errorf("sign request: %w", err)
+	}
+
+	return nil
+}
+
+// namedSecrets returns the secrets of the keys signing with the algorithm.
+type namedSecrets struct {
+	algorithm string
+}
+
+func (n *namedSecrets) Get(keyID string) (httpsignatures.Secret, error) {
+	return httpsignatures.Secret{KeyID: keyID, Algorithm: n.algorithm}, nil
+}
+
+// namedAlgorithm is an Ed25519 signature algorithm with another name.
+type namedAlgorithm struct {
+	*httpsig.SignatureHashAlgorithm
+	name string
+}
+
+func (a *namedAlgorithm) Algorithm() string {
+	return a.name
+}
@@ -0,0 +1,115 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package common
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/cucumber/godog"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
+	"github.com/tidwall/gjson"
+
+	"github.com/trustbloc/ace/test/bdd/pkg/internal/ldutil"
+)
+
+func (s *Steps) registerCredentialSteps(sc *godog.ScenarioContext) {
+	sc.Step(`^response "([^"]*)" is a credential issued by "([^"]*)"$`, s.checkResponseCredential)
+	sc.Step(`^credential claim "([^"]*)" is "([^"]*)"$`, s.checkCredentialClaim)
+}
+
+// checkResponseCredential verifies the proof of the credential at the path of the response against the VDR, and
+// checks its issuer: a saved response value, e.g. "gatekeeperDID", or a DID. The claims of the credential are then
+// checked with checkCredentialClaim.
+func (s *Steps) checkResponseCredential(path, issuer string) error {
+	raw, err := s.responseDocument(path)
+	if err != nil {
+		return err
+	}
+
+	vc, err := s.parseCredential(raw)
+	if err != nil {
+		return err
+	}
+
+	if want := s.savedOr(issuer); vc.Issuer.ID != want {
+		return fmt.Errorf("expected credential issued by %q, got %q", want, vc.Issuer.ID)
+	}
+
+	s.credential = vc
+
+	return nil
+}
+
+// checkCredentialClaim checks the value at the path of the credential verified last, e.g. "credentialSubject.id".
+func (s *Steps) checkCredentialClaim(path, value string) error {
+	if s.credential == nil {
+		return errors.New("no credential verified")
+	}
+
+	b, err := s.credential.MarshalJSON()
+	if err != nil {
+		return fmt.Errorf("marshal credential: %w", err)
+	}
+
+	res := gjson.GetBytes(b, path)
+	if !res.Exists() {
+		return fmt.Errorf("missing claim %q in credential", path)
+	}
+
+	if want := s.savedOr(value); res.String() != want {
+		return fmt.Errorf("expected claim %q to be %q, got %q", path, want, res.String())
+	}
+
+	return nil
+}
+
+func (s *Steps) parseCredential(raw []byte) (*verifiable.Credential, error) {
+	loader, err := ldutil.DocumentLoader()
+	if err != nil {
+		return nil, fmt.Errorf("create document loader: %w", err)
+	}
+
+	vc, err := verifiable.ParseCredential(raw,
+		verifiable.WithPublicKeyFetcher(verifiable.NewVDRKeyResolver(s.VDR).PublicKeyFetcher()),
+		verifiable.WithJSONLDDocumentLoader(loader),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("verify credential: %w", err)
+	}
+
+	// the signature of JWT credentials is verified when they are parsed
+	if len(vc.Proofs) == 0 && json.Valid(raw) {
+		return nil, errors.New("credential is not signed")
+	}
+
+	return vc, nil
+}
+
+// responseDocument returns the JSON document at the path of the response, or the JWT if it is a string.
+func (s *Steps) responseDocument(path string) ([]byte, error) {
+	res := gjson.GetBytes(s.responseBody, path)
+	if !res.Exists() {
+		return nil, fmt.Errorf("missing %q in response", path)
+	}
+
+	if res.Type == gjson.String {
+		return []byte(res.Str), nil
+	}
+
+	return []byte(res.Raw), nil
+}
+
+// savedOr returns the response value saved with the name, or the name itself if no value was saved with it.
+func (s *Steps) savedOr(name string) string {
+	if v, ok := s.params[name]; ok {
+		return v
+	}
+
+	return name
+}
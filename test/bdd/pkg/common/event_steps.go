@@ -0,0 +1,208 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package common
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cucumber/godog"
+	"github.com/tidwall/gjson"
+
+	"github.com/trustbloc/ace/test/bdd/pkg/internal/httputil"
+)
+
+const eventPollInterval = 100 * time.Millisecond
+
+// eventStream records the server-sent events received from a subscription of the scenario.
+type eventStream struct {
+	cancel context.CancelFunc
+
+	mu     sync.Mutex
+	events []*event
+	err    error
+}
+
+type event struct {
+	name string
+	data string
+}
+
+func (s *Steps) registerEventSteps(sc *godog.ScenarioContext) {
+	sc.Step(`^I subscribe with "([^"]*)" headers signed by "([^"]*)" to the event stream at "([^"]*)"$`,
+		s.subscribeSigned)
+	sc.Step(`^event "([^"]*)" is received within "([^"]*)"$`, s.checkEvent)
+	sc.Step(`^event "([^"]*)" with "([^"]*)" value "([^"]*)" is received within "([^"]*)"$`, s.checkEventValue)
+	sc.After(func(ctx context.Context, _ *godog.Scenario, err error) (context.Context, error) {
+		if s.events != nil {
+			s.events.cancel()
+		}
+
+		return ctx, err
+	})
+}
+
+func (s *Steps) subscribeSigned(ctx context.Context, headers, signer, url string) error {
+	sig, err := getSigner(ctx, headers, signer)
+	if err != nil {
+		return fmt.Errorf("get signer for event stream: %w", err)
+	}
+
+	return s.subscribe(ctx, url, httputil.WithHTTPSignature(sig))
+}
+
+// subscribe opens the event stream with the credentials of the scenario, or of the options, and records its events
+// in the background.
+func (s *Steps) subscribe(ctx context.Context, url string, opts ...httputil.Opt) error {
+	if s.events != nil {
+		s.events.cancel()
+	}
+
+	url, opts, err := s.request(ctx, http.MethodGet, url, nil, opts...)
+	if err != nil {
+		return err
+	}
+
+	// the stream outlives the step, until the end of the scenario
+	streamCtx, cancel := context.WithCancel(context.Background())
+
+	req, err := httputil.NewRequest(streamCtx, url, opts...)
+	if err != nil {
+		cancel()
+
+		return err
+	}
+
+	req.Header.Set("Accept", "text/event-stream")
+
+	resp, err := s.HTTPClient.Do(req)
+	if err != nil {
+		cancel()
+
+		return fmt.Errorf("open event stream: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close() //nolint:errcheck,gosec
+		cancel()
+
+		return fmt.Errorf("open event stream: %s", resp.Status)
+	}
+
+	s.events = &eventStream{cancel: cancel}
+
+	go s.events.read(resp)
+
+	return nil
+}
+
+func (e *eventStream) read(resp *http.Response) {
+	defer resp.Body.Close() //nolint:errcheck
+
+	var (
+		name string
+		data []string
+	)
+
+	scanner := bufio.NewScanner(resp.Body)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		switch {
+		case line == "":
+			if len(data) > 0 {
+				if name == "" {
+					name = "message"
+				}
+
+				e.add(&event{name: name, data: strings.Join(data, "\n")})
+			}
+
+			name, data = "", nil
+		case strings.HasPrefix(line, "event:"):
+			name = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data = append(data, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.err = scanner.Err()
+}
+
+func (e *eventStream) add(ev *event) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.events = append(e.events, ev)
+}
+
+// find returns the first event with the name whose data matches, and the error the stream ended with, if any.
+func (e *eventStream) find(name string, match func(data string) bool) (*event, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, ev := range e.events {
+		if ev.name == name && match(ev.data) {
+			return ev, nil
+		}
+	}
+
+	return nil, e.err
+}
+
+func (s *Steps) checkEvent(ctx context.Context, name, timeout string) error {
+	return s.waitForEvent(ctx, name, timeout, func(string) bool { return true })
+}
+
+func (s *Steps) checkEventValue(ctx context.Context, name, path, value, timeout string) error {
+	return s.waitForEvent(ctx, name, timeout, func(data string) bool {
+		return gjson.Get(data, path).String() == value
+	})
+}
+
+func (s *Steps) waitForEvent(ctx context.Context, name, timeout string, match func(data string) bool) error {
+	if s.events == nil {
+		return fmt.Errorf("not subscribed to an event stream")
+	}
+
+	d, err := time.ParseDuration(timeout)
+	if err != nil {
+		return fmt.Errorf("parse timeout: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, d)
+	defer cancel()
+
+	ticker := time.NewTicker(eventPollInterval)
+	defer ticker.Stop()
+
+	for {
+		ev, streamErr := s.events.find(name, match)
+		if ev != nil {
+			return nil
+		}
+
+		if streamErr != nil {
+			return fmt.Errorf("event stream failed: %w", streamErr)
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("no matching %s event received within %s", name, timeout)
+		case <-ticker.C:
+		}
+	}
+}
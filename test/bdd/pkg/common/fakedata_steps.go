@@ -0,0 +1,87 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"strconv"
+
+	"github.com/cucumber/godog"
+	"github.com/tidwall/gjson"
+
+	"github.com/trustbloc/ace/pkg/fakedata"
+)
+
+func (s *Steps) registerFakeDataSteps(sc *godog.ScenarioContext) {
+	sc.Step(`^a generated "(ssn|email|social media handle|did)" saved as "([^"]*)"$`, s.generateValue)
+	sc.Step(`^response contains "([^"]*)" with saved value "([^"]*)"$`, s.checkResponseSavedValue)
+	sc.Before(func(ctx context.Context, scenario *godog.Scenario) (context.Context, error) {
+		seed, err := fakeDataSeed(scenario.Name)
+		if err != nil {
+			return ctx, err
+		}
+
+		s.fakeData = fakedata.New(seed)
+
+		return ctx, nil
+	})
+}
+
+// fakeDataSeed returns the seed of the values generated by the scenario: BDD_SEED, which defaults to 0, mixed with
+// the name of the scenario, so that scenarios generate different values that are the same from run to run.
+func fakeDataSeed(scenario string) (int64, error) {
+	var seed int64
+
+	if v := os.Getenv("BDD_SEED"); v != "" {
+		var err error
+
+		seed, err = strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("parse BDD_SEED: %w", err)
+		}
+	}
+
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(scenario)) //nolint:errcheck
+
+	return seed ^ int64(h.Sum64()), nil //nolint:gosec
+}
+
+func (s *Steps) generateValue(ctx context.Context, kind, name string) (context.Context, error) {
+	var value string
+
+	switch kind {
+	case "ssn":
+		value = s.fakeData.SSN()
+	case "email":
+		value = s.fakeData.Email()
+	case "social media handle":
+		value = s.fakeData.SocialHandle()
+	case "did":
+		value = s.fakeData.DID()
+	}
+
+	s.params[name] = value
+
+	return context.WithValue(ctx, name, value), nil //nolint:revive,staticcheck
+}
+
+func (s *Steps) checkResponseSavedValue(path, name string) error {
+	value, ok := s.params[name]
+	if !ok {
+		return fmt.Errorf("no value saved as %q", name)
+	}
+
+	if got := gjson.Get(string(s.responseBody), path).Str; got != value {
+		return fmt.Errorf("expected %q, got %q", value, got)
+	}
+
+	return nil
+}
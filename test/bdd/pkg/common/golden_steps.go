@@ -0,0 +1,106 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/cucumber/godog"
+)
+
+const goldenFilePerm = 0o600
+
+// uuidPattern matches the UUIDs in strings, e.g. the IDs of tickets.
+var uuidPattern = regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`)
+
+func (s *Steps) registerGoldenSteps(sc *godog.ScenarioContext) {
+	sc.Step(`^response body matches golden file "([^"]*)"$`, s.checkGoldenFile)
+}
+
+// checkGoldenFile compares the response body with the golden file once their volatile values are normalized. The
+// golden file is written with the normalized response body instead if the steps update golden files.
+func (s *Steps) checkGoldenFile(path string) error {
+	var got interface{}
+
+	if err := json.Unmarshal(s.responseBody, &got); err != nil {
+		return fmt.Errorf("unmarshal response: %w", err)
+	}
+
+	got = normalize("", got)
+
+	gotJSON, err := json.MarshalIndent(got, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal response: %w", err)
+	}
+
+	if s.UpdateGoldenFiles {
+		if err = os.WriteFile(filepath.Clean(path), append(gotJSON, '\n'), goldenFilePerm); err != nil {
+			return fmt.Errorf("write golden file: %w", err)
+		}
+
+		return nil
+	}
+
+	golden, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return fmt.Errorf("read golden file: %w", err)
+	}
+
+	var want interface{}
+
+	if err = json.Unmarshal(golden, &want); err != nil {
+		return fmt.Errorf("unmarshal golden file: %w", err)
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		return fmt.Errorf("response does not match golden file %s, got:\n%s", path, gotJSON)
+	}
+
+	return nil
+}
+
+// normalize replaces the volatile values of the JSON value, which change from run to run: the values of ID fields,
+// e.g. "id" or "ticket_id", with <id>, timestamps with <timestamp> and UUIDs with <uuid>.
+func normalize(key string, v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, e := range v {
+			v[k] = normalize(k, e)
+		}
+
+		return v
+	case []interface{}:
+		for i, e := range v {
+			v[i] = normalize(key, e)
+		}
+
+		return v
+	case string:
+		if isIDField(key) {
+			return "<id>"
+		}
+
+		if _, err := time.Parse(time.RFC3339Nano, v); err == nil {
+			return "<timestamp>"
+		}
+
+		return uuidPattern.ReplaceAllString(v, "<uuid>")
+	default:
+		return v
+	}
+}
+
+func isIDField(key string) bool {
+	return key == "id" || key == "did" || strings.HasSuffix(key, "_id") || strings.HasSuffix(key, "_did")
+}
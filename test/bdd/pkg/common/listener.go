@@ -0,0 +1,35 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package common
+
+import (
+	"fmt"
+	"net"
+	"os"
+)
+
+const (
+	// serviceHostEnvKey is the env var of the host the services in containers reach the servers of the steps at.
+	serviceHostEnvKey  = "BDD_SERVICE_HOST"
+	defaultServiceHost = "host.docker.internal"
+)
+
+// ListenForServices returns a listener on all interfaces, so that the services in containers can reach the servers of
+// the steps, such as stub services and DIDComm agents, with its URL at the host of the containers.
+func ListenForServices() (net.Listener, string, error) {
+	listener, err := net.Listen("tcp", "0.0.0.0:0")
+	if err != nil {
+		return nil, "", fmt.Errorf("listen: %w", err)
+	}
+
+	host := os.Getenv(serviceHostEnvKey)
+	if host == "" {
+		host = defaultServiceHost
+	}
+
+	return listener, fmt.Sprintf("http://%s:%d", host, listener.Addr().(*net.TCPAddr).Port), nil //nolint:forcetypeassert
+}
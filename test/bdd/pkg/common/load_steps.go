@@ -0,0 +1,99 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/cucumber/godog"
+
+	"github.com/trustbloc/ace/pkg/bench"
+	"github.com/trustbloc/ace/test/bdd/pkg/internal/httputil"
+)
+
+func (s *Steps) registerLoadSteps(sc *godog.ScenarioContext) {
+	sc.Step(`^"(\d+)" concurrent HTTP POST requests with "([^"]*)" headers signed by "([^"]*)" are sent to "([^"]*)" with body$`, s.loadPostSigned) //nolint:lll
+	sc.Step(`^"(\d+)" concurrent HTTP GET requests are sent to "([^"]*)"$`, s.loadGet)
+	sc.Step(`^the "(mean|p50|p90|p99|max)" latency is under "([^"]*)"$`, s.checkLoadLatency)
+	sc.Step(`^at most "(\d+)" of the requests failed$`, s.checkLoadErrors)
+}
+
+// loadPostSigned sends the number of requests, all at a time, with a signature of their own.
+func (s *Steps) loadPostSigned(ctx context.Context, requests int, headers, signer, url string,
+	bodyTemplate *godog.DocString) error {
+	sig, err := getSigner(ctx, headers, signer)
+	if err != nil {
+		return fmt.Errorf("get signer for http post: %w", err)
+	}
+
+	return s.load(ctx, requests, http.MethodPost, url, bodyTemplate, httputil.WithHTTPSignature(sig))
+}
+
+func (s *Steps) loadGet(ctx context.Context, requests int, url string) error {
+	return s.load(ctx, requests, http.MethodGet, url, nil)
+}
+
+func (s *Steps) load(ctx context.Context, requests int, method, url string, bodyTemplate *godog.DocString,
+	opts ...httputil.Opt) error {
+	url, opts, err := s.request(ctx, method, url, bodyTemplate, opts...)
+	if err != nil {
+		return err
+	}
+
+	s.loadResult, err = bench.Run(ctx, func(ctx context.Context) error {
+		_, e := httputil.DoRequest(ctx, url, opts...)
+
+		return e
+	}, bench.WithRequests(requests), bench.WithConcurrency(requests))
+	if err != nil {
+		return fmt.Errorf("run load: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Steps) checkLoadLatency(percentile, limit string) error {
+	if s.loadResult == nil {
+		return errors.New("no load sent")
+	}
+
+	maxLatency, err := time.ParseDuration(limit)
+	if err != nil {
+		return fmt.Errorf("parse latency: %w", err)
+	}
+
+	latency := map[string]time.Duration{
+		"mean": s.loadResult.Mean,
+		"p50":  s.loadResult.P50,
+		"p90":  s.loadResult.P90,
+		"p99":  s.loadResult.P99,
+		"max":  s.loadResult.Max,
+	}[percentile]
+
+	if latency >= maxLatency {
+		return fmt.Errorf("%s latency is %s", percentile, latency)
+	}
+
+	return nil
+}
+
+func (s *Steps) checkLoadErrors(maxErrors int) error {
+	if s.loadResult == nil {
+		return errors.New("no load sent")
+	}
+
+	if s.loadResult.Errors > maxErrors {
+		return fmt.Errorf("%d of %d requests failed, first with: %s", s.loadResult.Errors, s.loadResult.Requests,
+			s.loadResult.Error)
+	}
+
+	return nil
+}
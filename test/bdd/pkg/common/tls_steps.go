@@ -0,0 +1,197 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package common
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/cucumber/godog"
+)
+
+const (
+	caCertPath = "fixtures/keys/tls/ec-cacert.pem"
+	caKeyPath  = "fixtures/keys/tls/ec-cakey.pem"
+)
+
+func (s *Steps) registerTLSSteps(sc *godog.ScenarioContext) {
+	sc.Step(`^an HTTP GET to "([^"]*)" is rejected with an untrusted CA$`, s.checkUntrustedCARejected)
+	sc.Step(`^an HTTP GET to "([^"]*)" is rejected without a client certificate$`, s.checkNoClientCertRejected)
+	sc.Step(`^an HTTP GET to "([^"]*)" is rejected with an expired client certificate$`,
+		s.checkExpiredClientCertRejected)
+	sc.Step(`^an HTTP GET to "([^"]*)" is rejected with a client certificate of an untrusted CA$`,
+		s.checkUntrustedClientCertRejected)
+}
+
+// checkUntrustedCARejected checks that the client rejects the server when it doesn't trust the CA of the server.
+func (s *Steps) checkUntrustedCARejected(url string) error {
+	tlsConfig := s.cloneTLSConfig()
+	tlsConfig.RootCAs = x509.NewCertPool()
+
+	err := get(tlsConfig, url)
+
+	var unknownAuthority x509.UnknownAuthorityError
+
+	if !errors.As(err, &unknownAuthority) {
+		return fmt.Errorf("expected unknown authority error, got %v", err)
+	}
+
+	return nil
+}
+
+// checkNoClientCertRejected checks that a server requiring mutual TLS rejects a client without a certificate.
+func (s *Steps) checkNoClientCertRejected(url string) error {
+	tlsConfig := s.cloneTLSConfig()
+	tlsConfig.Certificates = nil
+
+	return rejected(get(tlsConfig, url))
+}
+
+// checkExpiredClientCertRejected checks that a server requiring mutual TLS rejects an expired client certificate.
+func (s *Steps) checkExpiredClientCertRejected(url string) error {
+	caCert, caKey, err := loadCA()
+	if err != nil {
+		return err
+	}
+
+	// the certificate is issued by the trusted CA, but expired an hour ago
+	cert, err := newClientCert(caCert, caKey, time.Now().Add(-time.Hour))
+	if err != nil {
+		return err
+	}
+
+	tlsConfig := s.cloneTLSConfig()
+	tlsConfig.Certificates = []tls.Certificate{*cert}
+
+	return rejected(get(tlsConfig, url))
+}
+
+// checkUntrustedClientCertRejected checks that a server requiring mutual TLS rejects a client certificate issued by a
+// CA it doesn't trust.
+func (s *Steps) checkUntrustedClientCertRejected(url string) error {
+	// the certificate is self-signed
+	cert, err := newClientCert(nil, nil, time.Now().Add(time.Hour))
+	if err != nil {
+		return err
+	}
+
+	tlsConfig := s.cloneTLSConfig()
+	tlsConfig.Certificates = []tls.Certificate{*cert}
+
+	return rejected(get(tlsConfig, url))
+}
+
+// cloneTLSConfig returns a copy of the TLS config of the scenario to change.
+func (s *Steps) cloneTLSConfig() *tls.Config {
+	if s.tlsConfig == nil {
+		return &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+
+	return s.tlsConfig.Clone()
+}
+
+// get sends a GET request with a client of the TLS config and returns an error if the request fails or the server
+// responds with 401 or 403.
+func get(tlsConfig *tls.Config, url string) error {
+	client := &http.Client{
+		Timeout:   10 * time.Second, //nolint:gomnd
+		Transport: &http.Transport{TLSClientConfig: tlsConfig},
+	}
+
+	resp, err := client.Get(url) //nolint:noctx
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close() //nolint:errcheck
+
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		return errors.New(resp.Status)
+	}
+
+	return nil
+}
+
+// rejected returns an error if the request wasn't rejected.
+func rejected(err error) error {
+	if err == nil {
+		return errors.New("expected the request to be rejected")
+	}
+
+	return nil
+}
+
+// loadCA loads the CA of the test PKI, which issues the server and client certificates.
+func loadCA() (*x509.Certificate, *ecdsa.PrivateKey, error) {
+	certPEM, err := os.ReadFile(caCertPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read ca cert: %w", err)
+	}
+
+	keyPEM, err := os.ReadFile(caKeyPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read ca key: %w", err)
+	}
+
+	certBlock, _ := pem.Decode(certPEM)
+	keyBlock, _ := pem.Decode(keyPEM)
+
+	if certBlock == nil || keyBlock == nil {
+		return nil, nil, errors.New("decode ca pem")
+	}
+
+	cert, err := x509.ParseCertificate(certBlock.Bytes)
+	if err != nil {
+		return nil, nil, fmt.Errorf("parse ca cert: %w", err)
+	}
+
+	key, err := x509.ParseECPrivateKey(keyBlock.Bytes)
+	if err != nil {
+		return nil, nil, fmt.Errorf("parse ca key: %w", err)
+	}
+
+	return cert, key, nil
+}
+
+// newClientCert returns a client certificate valid until notAfter, issued by the CA, or self-signed if the CA is nil.
+func newClientCert(ca *x509.Certificate, caKey *ecdsa.PrivateKey, notAfter time.Time) (*tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("generate key: %w", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: "bdd-client"},
+		NotBefore:    notAfter.AddDate(0, 0, -1),
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+
+	if ca == nil {
+		ca, caKey = template, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+	if err != nil {
+		return nil, fmt.Errorf("create certificate: %w", err)
+	}
+
+	return &tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, nil
+}
@@ -7,6 +7,7 @@ SPDX-License-Identifier: Apache-2.0
 package comparator
 
 import (
+	"context"
 	"crypto/tls"
 	"errors"
 	"fmt"
@@ -59,6 +60,8 @@ type Steps struct {
 	kmsToken       string
 	authorizations map[string]*models.Authorization
 	vaultHost      string
+	// result is the result of the last comparison of authorized docs.
+	result *bool
 }
 
 // NewSteps returns new steps.
@@ -113,10 +116,14 @@ func (e *Steps) RegisterSteps(s *godog.ScenarioContext) {
 	s.Step(`^Compare two docs with doc1 id "([^"]*)" and ref for doc2 id "([^"]*)" with compare result "([^"]*)"$`, e.compare)                                                                                  // nolint:lll
 	s.Step(`^Extract docs from auth tokens received from comparator authorization for docIDs "([^"]*)", "([^"]*)", "([^"]*)" and validate data equal "([^"]*)", "([^"]*)", "([^"]*)" respectively$`, e.extract) // nolint:lll
 	s.Step(`^Create vault authorization with duration "([^"]*)"$`, e.createVaultAuthorization)
+	s.Step(`^Compare docs "([^"]*)" and "([^"]*)" with the "(eq|gt|lt)" operator$`, e.compareAuthorized)
+	s.Step(`^Compare docs "([^"]*)" and "([^"]*)" with the "(levenshtein|soundex)" fuzzy operator and threshold "([^"]*)"$`, e.compareFuzzy) // nolint:lll
+	s.Step(`^the docs match$`, e.checkMatch)
+	s.Step(`^the docs do not match$`, e.checkNoMatch)
 }
 
 func (e *Steps) createVaultForComparator(endpoint string) error {
-	result, err := vaultclient.New(endpoint, vaultclient.WithHTTPClient(e.httpClient)).CreateVault()
+	result, err := vaultclient.New(endpoint, vaultclient.WithHTTPClient(e.httpClient)).CreateVault(context.Background())
 	if err != nil {
 		return err
 	}
@@ -137,7 +144,8 @@ func (e *Steps) createVaultForComparator(endpoint string) error {
 }
 
 func (e *Steps) saveDocumentForComparator(docID, data string) error {
-	res, err := vaultclient.New(e.vaultURL, vaultclient.WithHTTPClient(e.httpClient)).SaveDoc(e.vaultID, docID,
+	res, err := vaultclient.New(e.vaultURL, vaultclient.WithHTTPClient(e.httpClient)).SaveDoc(context.Background(),
+		e.vaultID, docID,
 		map[string]interface{}{
 			"contents": data,
 		})
@@ -310,6 +318,79 @@ func (e *Steps) compare(doc1, doc2, result string) error {
 	return nil
 }
 
+// compareAuthorized compares the docs with the comparator authorizations created for them.
+func (e *Steps) compareAuthorized(doc1, doc2, operator string) error {
+	var op queryOperator
+
+	switch operator {
+	case "gt":
+		op = &models.GtOp{}
+	case "lt":
+		op = &models.LtOp{}
+	default:
+		op = &models.EqOp{}
+	}
+
+	return e.compareWith(op, doc1, doc2)
+}
+
+func (e *Steps) compareFuzzy(doc1, doc2, algorithm, threshold string) error {
+	t, err := strconv.ParseFloat(threshold, 64)
+	if err != nil {
+		return fmt.Errorf("parse threshold: %w", err)
+	}
+
+	return e.compareWith(&models.FuzzyOp{Algorithm: algorithm, Threshold: &t}, doc1, doc2)
+}
+
+func (e *Steps) compareWith(op queryOperator, doc1, doc2 string) error {
+	var args []models.Query
+
+	for _, docID := range []string{doc1, doc2} {
+		authz, ok := e.authorizations[docID]
+		if !ok {
+			return fmt.Errorf("no comparator authorization for doc %s", docID)
+		}
+
+		args = append(args, &models.AuthorizedQuery{AuthToken: &authz.AuthToken})
+	}
+
+	op.SetArgs(args)
+
+	cr := models.Comparison{}
+	cr.SetOp(op)
+
+	r, err := e.client.Operations.PostCompare(operations.NewPostCompareParams().
+		WithTimeout(requestTimeout).WithComparison(&cr))
+	if err != nil {
+		return err
+	}
+
+	e.result = &r.Payload.Result
+
+	return nil
+}
+
+func (e *Steps) checkMatch() error {
+	return e.checkResult(true)
+}
+
+func (e *Steps) checkNoMatch() error {
+	return e.checkResult(false)
+}
+
+func (e *Steps) checkResult(match bool) error {
+	if e.result == nil {
+		return errors.New("no docs compared")
+	}
+
+	if *e.result != match {
+		return fmt.Errorf("compare result not %t", match)
+	}
+
+	return nil
+}
+
 func (e *Steps) createVaultAuthorization(duration string) error {
 	sec, err := strconv.Atoi(duration)
 	if err != nil {
@@ -317,6 +398,7 @@ func (e *Steps) createVaultAuthorization(duration string) error {
 	}
 
 	result, err := vaultclient.New("https://"+e.vaultHost, vaultclient.WithHTTPClient(e.httpClient)).CreateAuthorization(
+		context.Background(),
 		e.vaultID,
 		e.cshAuthKey,
 		&vault.AuthorizationsScope{
@@ -355,6 +437,12 @@ func (e *Steps) checkConfig() error {
 	return nil
 }
 
+// queryOperator is a comparison operator of queries.
+type queryOperator interface {
+	models.Operator
+	SetArgs(val []models.Query)
+}
+
 type mockKMSProvider struct {
 	sp ariesstorage.Provider
 	sl secretlock.Service
@@ -0,0 +1,124 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package compose starts and stops the services of the Docker Compose file of the tests from within scenarios.
+package compose
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"github.com/cucumber/godog"
+	"github.com/hyperledger/aries-framework-go/pkg/common/log"
+)
+
+var logger = log.New("ace-bdd-compose")
+
+// Compose runs Docker Compose commands on the services of a compose file.
+type Compose struct {
+	file string
+}
+
+// New returns a new Compose for the compose file.
+func New(file string) *Compose {
+	return &Compose{file: file}
+}
+
+// Stop stops the service.
+func (c *Compose) Stop(ctx context.Context, service string) error {
+	return c.run(ctx, "stop", service)
+}
+
+// Start starts the stopped service.
+func (c *Compose) Start(ctx context.Context, service string) error {
+	return c.run(ctx, "start", service)
+}
+
+// Restart restarts the service.
+func (c *Compose) Restart(ctx context.Context, service string) error {
+	return c.run(ctx, "restart", service)
+}
+
+func (c *Compose) run(ctx context.Context, command, service string) error {
+	args := []string{"-f", c.file, command, service}
+
+	logger.Infof("Running docker-compose %s", strings.Join(args, " "))
+
+	out, err := exec.CommandContext(ctx, "docker-compose", args...).CombinedOutput() //nolint:gosec
+	if err != nil {
+		return fmt.Errorf("docker-compose %s %s: %w: %s", command, service, err, string(out))
+	}
+
+	return nil
+}
+
+// Steps defines context for the steps starting and stopping services.
+type Steps struct {
+	compose *Compose
+
+	mu      sync.Mutex
+	stopped map[string]struct{}
+}
+
+// NewSteps returns new Steps context for the services of the compose file.
+func NewSteps(file string) *Steps {
+	return &Steps{
+		compose: New(file),
+		stopped: make(map[string]struct{}),
+	}
+}
+
+// RegisterSteps registers compose scenario steps. The services stopped by a scenario are started again once it ends.
+func (s *Steps) RegisterSteps(sc *godog.ScenarioContext) {
+	sc.Step(`^the "([^"]*)" service is stopped$`, s.stop)
+	sc.Step(`^the "([^"]*)" service is started$`, s.start)
+	sc.Step(`^the "([^"]*)" service is restarted$`, s.compose.Restart)
+	sc.After(func(ctx context.Context, _ *godog.Scenario, err error) (context.Context, error) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		for service := range s.stopped {
+			if e := s.compose.Start(ctx, service); e != nil {
+				logger.Errorf("Failed to start stopped service: %s", e)
+
+				continue
+			}
+
+			delete(s.stopped, service)
+		}
+
+		return ctx, err
+	})
+}
+
+func (s *Steps) stop(ctx context.Context, service string) error {
+	if err := s.compose.Stop(ctx, service); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stopped[service] = struct{}{}
+
+	return nil
+}
+
+func (s *Steps) start(ctx context.Context, service string) error {
+	if err := s.compose.Start(ctx, service); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.stopped, service)
+
+	return nil
+}
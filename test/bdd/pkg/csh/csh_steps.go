@@ -9,6 +9,7 @@ package csh
 import (
 	"bytes"
 	"compress/gzip"
+	"context"
 	"crypto/tls"
 	"encoding/base64"
 	"errors"
@@ -18,10 +19,12 @@ import (
 	"strconv"
 
 	"github.com/cucumber/godog"
+	"github.com/go-openapi/runtime"
 	"github.com/google/uuid"
 	"github.com/trustbloc/edge-core/pkg/zcapld"
 
 	"github.com/trustbloc/ace/pkg/client/csh/models"
+	"github.com/trustbloc/ace/test/bdd/pkg/common"
 )
 
 const (
@@ -35,7 +38,7 @@ const (
 )
 
 // NewSteps returns BDD test steps for the confidential storage hub.
-func NewSteps(tlsConfig *tls.Config) *Steps {
+func NewSteps(commonSteps *common.Steps, tlsConfig *tls.Config) *Steps {
 	httpClient := &http.Client{
 		Transport: &http.Transport{
 			TLSClientConfig: tlsConfig,
@@ -43,6 +46,7 @@ func NewSteps(tlsConfig *tls.Config) *Steps {
 	}
 
 	return &Steps{
+		cs:          commonSteps,
 		httpClient:  httpClient,
 		docs:        make([]*docCoords, 0),
 		refs:        make([]*ref, 0),
@@ -73,6 +77,7 @@ type extraction struct {
 
 // Steps BDD test steps for the confidential storage hub.
 type Steps struct {
+	cs               *common.Steps
 	httpClient       *http.Client
 	user             *user
 	docs             []*docCoords
@@ -90,29 +95,39 @@ func (s *Steps) RegisterSteps(gs *godog.ScenarioContext) {
 	gs.Step(`^the user creates a RefQuery for one document$`, s.userCreatesRefQuery)
 	gs.Step(`^the user authorizes the CSH to read the documents$`, s.userAuthorizesCSHToReadDocuments)
 	gs.Step(`^the user requests a comparison between the two documents$`, s.userRequestsComparison)
+	gs.Step(`^the user requests a comparison between all documents$`, s.userRequestsComparison)
+	gs.Step(`^the user revokes the CSH's authorization to read the documents$`, s.userRevokesAuthorization)
+	gs.Step(`^the comparison between the documents is rejected as revoked$`, s.confirmComparisonRevoked)
 	gs.Step(`^the user requests extraction of all documents$`, s.userRequestsExtraction)
 	gs.Step(`^the result is "([^"]*)"$`, s.confirmComparisonResult)
 	gs.Step(`^the CSH returns the decrypted documents$`, s.confirmExtractionResults)
 }
 
-func (s *Steps) userCreatesProfile() error {
+// userCreatesProfile creates the user and its profile. The user signs the requests of the common steps as "user",
+// invoking its EDV capability, saved as edvZCAP.
+func (s *Steps) userCreatesProfile(ctx context.Context) (context.Context, error) {
 	var err error
 
 	s.user, err = newUser(cshHost, edvBaseURL, kmsBaseURL, s.httpClient)
 	if err != nil {
-		return fmt.Errorf("failed to create new user: %w", err)
+		return ctx, fmt.Errorf("failed to create new user: %w", err)
 	}
 
 	err = s.user.requestNewProfile()
 	if err != nil {
-		return fmt.Errorf("user failed to create a profile: %w", err)
+		return ctx, fmt.Errorf("user failed to create a profile: %w", err)
 	}
 
 	s.docs = make([]*docCoords, 0)
 	s.refs = make([]*ref, 0)
 	s.extractions = make(map[string]*extraction)
 
-	return nil
+	s.cs.SetParam("edvZCAP", s.user.edvRootZCAP)
+
+	return common.ContextWithSignerOpts(ctx, "user", &common.SignerOpts{
+		PublicKeyID: s.user.controller,
+		PrivateKey:  s.user.privateKey,
+	}), nil
 }
 
 func (s *Steps) userProfileIsCreated() error {
@@ -150,20 +165,21 @@ func (s *Steps) userProfileIsCreated() error {
 	return nil
 }
 
-func (s *Steps) userHasProfile() error {
-	err := s.userCreatesProfile()
+func (s *Steps) userHasProfile(ctx context.Context) (context.Context, error) {
+	ctx, err := s.userCreatesProfile(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to create new user: %w", err)
+		return ctx, fmt.Errorf("failed to create new user: %w", err)
 	}
 
 	err = s.userProfileIsCreated()
 	if err != nil {
-		return fmt.Errorf("failed to validate new user csh profile: %w", err)
+		return ctx, fmt.Errorf("failed to validate new user csh profile: %w", err)
 	}
 
-	return nil
+	return ctx, nil
 }
 
+// userSavesDocument saves the document in the EDV vault of the user, with its URL saved as documentURL.
 func (s *Steps) userSavesDocument(contents string) error {
 	coords, err := s.user.saveInConfidentialStorage(contents)
 	if err != nil {
@@ -175,6 +191,8 @@ func (s *Steps) userSavesDocument(contents string) error {
 
 	s.docs = append(s.docs, coords)
 
+	s.cs.SetParam("documentURL", fmt.Sprintf("%s/%s/documents/%s", edvBaseURL, coords.vaultID, coords.docID))
+
 	return nil
 }
 
@@ -245,6 +263,37 @@ func (s *Steps) userRequestsComparison() error {
 	return nil
 }
 
+func (s *Steps) userRevokesAuthorization() error {
+	if len(s.docs) == 0 {
+		return errors.New("BDD test steps error: user has not authorized the CSH to read any docs yet")
+	}
+
+	for i := range s.docs {
+		if err := s.user.revoke(s.docs[i].edvZCAP); err != nil {
+			return fmt.Errorf("user failed to revoke authorization for document: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (s *Steps) confirmComparisonRevoked() error {
+	queries, _ := s.buildAllQueries()
+
+	_, err := s.user.compare(queries...)
+	if err == nil {
+		return errors.New("expected the comparison to be rejected")
+	}
+
+	var apiErr *runtime.APIError
+
+	if !errors.As(err, &apiErr) || apiErr.Code != http.StatusForbidden {
+		return fmt.Errorf("expected the comparison to be forbidden: %w", err)
+	}
+
+	return nil
+}
+
 func (s *Steps) userRequestsExtraction() error {
 	queries, contents := s.buildAllQueries()
 	s.extractions = make(map[string]*extraction)
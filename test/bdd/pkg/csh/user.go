@@ -7,6 +7,10 @@ SPDX-License-Identifier: Apache-2.0
 package csh
 
 import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
@@ -64,7 +68,9 @@ func newUser(cshBaseURL, edvURL, hubkmsBaseURL string, httpClient *http.Client)
 	)
 
 	user := &user{
-		cshClient: client.New(transport, strfmt.Default),
+		cshClient:  client.New(transport, strfmt.Default),
+		cshURL:     "https://" + cshBaseURL,
+		httpClient: httpClient,
 	}
 
 	err := user.initKeystore(hubkmsBaseURL, httpClient)
@@ -93,7 +99,10 @@ type user struct {
 	profile          *models.Profile
 	controller       string
 	signer           signature.Signer
+	privateKey       ed25519.PrivateKey
 	cshClient        *client.ConfidentialStorageHub
+	cshURL           string
+	httpClient       *http.Client
 	keystoreURL      string
 	keystoreRootZCAP string
 	edvVaultID       string
@@ -121,7 +130,7 @@ func (u *user) initKeystore(baseURL string, httpClient webkms.HTTPClient) error
 		return fmt.Errorf("failed to init local crypto: %w", err)
 	}
 
-	u.signer, err = signature.NewCryptoSigner(u.localcrypto, u.localkms, kms.ED25519Type)
+	u.signer, err = u.newSigner()
 	if err != nil {
 		return fmt.Errorf("failed to create a new signer: %w", err)
 	}
@@ -186,6 +195,29 @@ func (u *user) initKeystore(baseURL string, httpClient webkms.HTTPClient) error
 	return nil
 }
 
+// newSigner returns the signer of the controller, with its key imported into the local KMS, so that the steps signing
+// the requests of the controller with the private key sign with the same key.
+func (u *user) newSigner() (signature.Signer, error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate key: %w", err)
+	}
+
+	kid, err := localkms.CreateKID(pub, kms.ED25519Type)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kid: %w", err)
+	}
+
+	_, _, err = u.localkms.ImportPrivateKey(priv, kms.ED25519Type, kms.WithKeyID(kid))
+	if err != nil {
+		return nil, fmt.Errorf("failed to import key: %w", err)
+	}
+
+	u.privateKey = priv
+
+	return signature.GetEd25519Signer(priv, pub), nil
+}
+
 func (u *user) initVDR(httpClient *http.Client) error {
 	trustblocVDR, err := orb.New(
 		nil,
@@ -526,6 +558,43 @@ func (u *user) extract(queries ...models.Query) (models.ExtractionResponse, erro
 	return response.Payload, nil
 }
 
+// revoke revokes the zcap delegated under the user's profile. The generated client has no revocation operation.
+func (u *user) revoke(compressedZCAP string) error {
+	zcap, err := zcapld.DecompressZCAP(compressedZCAP)
+	if err != nil {
+		return fmt.Errorf("failed to parse zcap: %w", err)
+	}
+
+	body, err := json.Marshal(&models.Revocation{ZcapID: &zcap.ID})
+	if err != nil {
+		return fmt.Errorf("failed to marshal revocation: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		u.cshURL+"/hubstore/profiles/"+url.PathEscape(u.profile.ID)+"/revocations", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create revocation request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := u.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to execute revocation: %w", err)
+	}
+
+	defer resp.Body.Close() //nolint:errcheck
+
+	if resp.StatusCode != http.StatusNoContent {
+		return fmt.Errorf("unexpected revocation response status: %s", resp.Status)
+	}
+
+	return nil
+}
+
 func didKeyURL(pubKeyBytes []byte) string {
 	_, didKeyURL := fingerprint.CreateDIDKey(pubKeyBytes)
 
@@ -0,0 +1,475 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package didcomm
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cucumber/godog"
+	"github.com/google/uuid"
+	"github.com/hyperledger/aries-framework-go/component/storageutil/mem"
+	cryptoapi "github.com/hyperledger/aries-framework-go/pkg/crypto"
+	"github.com/hyperledger/aries-framework-go/pkg/crypto/tinkcrypto"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/jose"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/jose/kid/resolver"
+	"github.com/hyperledger/aries-framework-go/pkg/kms"
+	"github.com/hyperledger/aries-framework-go/pkg/kms/localkms"
+	"github.com/hyperledger/aries-framework-go/pkg/secretlock"
+	"github.com/hyperledger/aries-framework-go/pkg/secretlock/noop"
+	"github.com/hyperledger/aries-framework-go/spi/storage"
+	"github.com/tidwall/gjson"
+
+	"github.com/trustbloc/ace/pkg/gatekeeper/didcomm"
+	"github.com/trustbloc/ace/test/bdd/pkg/common"
+	"github.com/trustbloc/ace/test/bdd/pkg/internal/httputil"
+	"github.com/trustbloc/ace/test/bdd/pkg/internal/vdrutil"
+)
+
+const (
+	authToken             = "gk_token"
+	keystorePrimaryKeyURI = "local-lock://didcommkms"
+	// pollInterval is the interval the messages received by the agents are checked at.
+	pollInterval = 100 * time.Millisecond
+)
+
+// messageTypes are the DIDComm message types the steps refer to by name, e.g. "approval-request".
+var messageTypes = map[string]string{ //nolint:gochecknoglobals
+	"approval-request": didcomm.ApprovalRequestType,
+	"authorize":        didcomm.AuthorizeType,
+	"reject":           didcomm.RejectType,
+}
+
+// Steps defines context for the steps of DIDComm v2 agents, which stand for the agents of the participants of
+// Gatekeeper: they receive its encrypted messages and reply with messages signed with their DIDs.
+type Steps struct {
+	cs     *common.Steps
+	host   string
+	agents map[string]*agent
+}
+
+// NewSteps returns new Steps context. The DIDs of the agents started are set as parameters of the request bodies of
+// the common steps, e.g. {{ .Param "Approver" }} in the policies of the Gatekeeper steps.
+func NewSteps(commonSteps *common.Steps) *Steps {
+	return &Steps{
+		cs:     commonSteps,
+		host:   os.Getenv("GATEKEEPER_HOST"),
+		agents: make(map[string]*agent),
+	}
+}
+
+// RegisterSteps registers DIDComm scenario steps.
+func (s *Steps) RegisterSteps(sc *godog.ScenarioContext) {
+	sc.Step(`^DIDComm agent "([^"]*)" is started$`, s.startAgent)
+	sc.Step(`^DIDComm agent "([^"]*)" accepts an invitation of Gatekeeper as "([^"]*)"$`, s.acceptInvitation)
+	sc.Step(`^DIDComm agent "([^"]*)" receives an? "([^"]*)" message within "([^"]*)"$`, s.waitForMessage)
+	sc.Step(`^the "([^"]*)" message received by DIDComm agent "([^"]*)" contains "([^"]*)" with value "([^"]*)"$`,
+		s.checkMessageValue)
+	sc.Step(`^DIDComm agent "([^"]*)" replies to the "([^"]*)" message with an? "([^"]*)" message$`, s.reply)
+	sc.Step(`^DIDComm agent "([^"]*)" replies to the "([^"]*)" message with an? "([^"]*)" message signed by another key$`, //nolint:lll
+		s.replyWithAnotherKey)
+	sc.After(func(ctx context.Context, _ *godog.Scenario, err error) (context.Context, error) {
+		for _, a := range s.agents {
+			a.server.Close()
+		}
+
+		return ctx, err
+	})
+}
+
+// agent is a DIDComm v2 agent with a DID, receiving the messages encrypted to its key agreement key on its endpoint.
+type agent struct {
+	did        string
+	keyID      string
+	privateKey ed25519.PrivateKey
+	endpoint   string
+	server     *httptest.Server
+	decrypter  *jose.JWEDecrypt
+
+	mu       sync.Mutex
+	received map[string][]*didcomm.Message
+}
+
+// kmsKeyResolver resolves the key agreement key of the DID of the agent to the key of its KMS, which the recipient
+// keys of the messages are unwrapped with.
+type kmsKeyResolver struct {
+	kid string
+}
+
+func (r *kmsKeyResolver) Resolve(string) (*cryptoapi.PublicKey, error) {
+	return &cryptoapi.PublicKey{KID: r.kid}, nil
+}
+
+// startAgent creates the DID of the agent, with an X25519 key agreement key of a local KMS, and starts its endpoint
+// where Gatekeeper can reach it.
+func (s *Steps) startAgent(name string) error {
+	if _, ok := s.agents[name]; ok {
+		return fmt.Errorf("DIDComm agent %q already started", name)
+	}
+
+	keyManager, err := localkms.New(keystorePrimaryKeyURI, &kmsProvider{
+		storageProvider: mem.NewProvider(),
+		secretLock:      &noop.NoLock{},
+	})
+	if err != nil {
+		return fmt.Errorf("create kms: %w", err)
+	}
+
+	cryptoService, err := tinkcrypto.New()
+	if err != nil {
+		return fmt.Errorf("create crypto: %w", err)
+	}
+
+	kid, b, err := keyManager.CreateAndExportPubKeyBytes(kms.X25519ECDHKWType)
+	if err != nil {
+		return fmt.Errorf("create key agreement key: %w", err)
+	}
+
+	var keyAgreementKey cryptoapi.PublicKey
+
+	if err = json.Unmarshal(b, &keyAgreementKey); err != nil {
+		return fmt.Errorf("unmarshal key agreement key: %w", err)
+	}
+
+	doc, privateKey, err := vdrutil.CreateDIDDocWithKeyAgreement(s.cs.VDR, keyAgreementKey.X)
+	if err != nil {
+		return fmt.Errorf("create did doc: %w", err)
+	}
+
+	if _, err = vdrutil.ResolveDID(s.cs.VDR, doc.ID, 10); err != nil { //nolint:gomnd
+		return fmt.Errorf("resolve did: %w", err)
+	}
+
+	listener, endpoint, err := common.ListenForServices()
+	if err != nil {
+		return err
+	}
+
+	// the messages are signed with the absolute ID of the key, which starts with the DID of their sender
+	keyID := doc.Authentication[0].VerificationMethod.ID
+	if strings.HasPrefix(keyID, "#") {
+		keyID = doc.ID + keyID
+	}
+
+	a := &agent{
+		did:        doc.ID,
+		keyID:      keyID,
+		privateKey: privateKey,
+		endpoint:   endpoint,
+		decrypter: jose.NewJWEDecrypt([]resolver.KIDResolver{&kmsKeyResolver{kid: kid}},
+			cryptoService, keyManager),
+		received: make(map[string][]*didcomm.Message),
+	}
+
+	a.server = httptest.NewUnstartedServer(http.HandlerFunc(a.serveHTTP))
+	a.server.Listener = listener
+	a.server.Start()
+
+	s.agents[name] = a
+	s.cs.SetParam(name, a.did)
+
+	return nil
+}
+
+// acceptInvitation creates an invitation of Gatekeeper with the role, and accepts it with a connect message with the
+// endpoint of the agent, which Gatekeeper reaches the agent at.
+func (s *Steps) acceptInvitation(ctx context.Context, name, role string) error {
+	a, err := s.agent(name)
+	if err != nil {
+		return err
+	}
+
+	var inv didcomm.Invitation
+
+	_, err = httputil.DoRequest(ctx, fmt.Sprintf("https://%s/v1/didcomm/invitations", s.host),
+		httputil.WithHTTPClient(s.cs.HTTPClient),
+		httputil.WithMethod(http.MethodPost),
+		httputil.WithAuthToken(authToken),
+		httputil.WithJSONBody(map[string]string{"role": role, "label": name}),
+		httputil.WithParsedResponse(&inv))
+	if err != nil {
+		return fmt.Errorf("create invitation: %w", err)
+	}
+
+	var invBody didcomm.InvitationBody
+
+	if err = json.Unmarshal(inv.Message.Body, &invBody); err != nil {
+		return fmt.Errorf("unmarshal invitation body: %w", err)
+	}
+
+	body, err := json.Marshal(&didcomm.ConnectBody{Label: name, Endpoint: a.endpoint})
+	if err != nil {
+		return fmt.Errorf("marshal connect body: %w", err)
+	}
+
+	msg := &didcomm.Message{
+		ID:             uuid.New().String(),
+		Type:           didcomm.ConnectType,
+		ParentThreadID: inv.Message.ID,
+		Body:           body,
+	}
+
+	r, err := httputil.DoRequest(ctx, invBody.Endpoint,
+		httputil.WithHTTPClient(s.cs.HTTPClient),
+		httputil.WithMethod(http.MethodPost),
+		httputil.WithBody(a.sign(msg, a.privateKey)),
+		httputil.WithContentType(didcomm.SignedMediaType))
+	if err != nil {
+		return fmt.Errorf("send connect message: %w", err)
+	}
+
+	if r.StatusCode != http.StatusAccepted {
+		return fmt.Errorf("connect message not accepted: %s", r.Status)
+	}
+
+	return nil
+}
+
+func (s *Steps) waitForMessage(ctx context.Context, name, messageType, timeout string) error {
+	a, err := s.agent(name)
+	if err != nil {
+		return err
+	}
+
+	typ, err := typeOf(messageType)
+	if err != nil {
+		return err
+	}
+
+	d, err := time.ParseDuration(timeout)
+	if err != nil {
+		return fmt.Errorf("parse timeout: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, d)
+	defer cancel()
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for a.lastMessage(typ) == nil {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return fmt.Errorf("no %s message received by %q within %s", messageType, name, timeout)
+		}
+	}
+
+	return nil
+}
+
+func (s *Steps) checkMessageValue(messageType, name, path, value string) error {
+	msg, err := s.receivedMessage(name, messageType)
+	if err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("marshal message: %w", err)
+	}
+
+	if got := gjson.GetBytes(b, path).String(); got != value {
+		return fmt.Errorf("expected %q, got %q", value, got)
+	}
+
+	return nil
+}
+
+func (s *Steps) reply(ctx context.Context, name, receivedType, replyType string) error {
+	a, err := s.agent(name)
+	if err != nil {
+		return err
+	}
+
+	return s.sendReply(ctx, name, receivedType, replyType, a.privateKey)
+}
+
+// replyWithAnotherKey replies with a message signed with a key that is not a key of the DID of the agent, but with
+// the ID of the authentication key of the DID, which Gatekeeper rejects.
+func (s *Steps) replyWithAnotherKey(ctx context.Context, name, receivedType, replyType string) error {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return fmt.Errorf("generate key: %w", err)
+	}
+
+	return s.sendReply(ctx, name, receivedType, replyType, privateKey)
+}
+
+// sendReply sends the decision of the agent on the ticket of the received message, in the thread of the message, and
+// records the response for the response steps of the scenario, e.g. response status is "202 Accepted".
+func (s *Steps) sendReply(ctx context.Context, name, receivedType, replyType string,
+	privateKey ed25519.PrivateKey) error {
+	a, err := s.agent(name)
+	if err != nil {
+		return err
+	}
+
+	received, err := s.receivedMessage(name, receivedType)
+	if err != nil {
+		return err
+	}
+
+	typ, err := typeOf(replyType)
+	if err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(&didcomm.Decision{
+		TicketID: gjson.GetBytes(received.Body, "ticket_id").String(),
+		Comment:  "replied by " + name + " over DIDComm",
+	})
+	if err != nil {
+		return fmt.Errorf("marshal decision: %w", err)
+	}
+
+	msg := &didcomm.Message{
+		ID:       uuid.New().String(),
+		Type:     typ,
+		ThreadID: received.ID,
+		Body:     body,
+	}
+
+	return s.cs.Do(ctx, http.MethodPost, fmt.Sprintf("https://%s/v1/didcomm", s.host),
+		httputil.WithBody(a.sign(msg, privateKey)),
+		httputil.WithContentType(didcomm.SignedMediaType))
+}
+
+func (s *Steps) receivedMessage(name, messageType string) (*didcomm.Message, error) {
+	a, err := s.agent(name)
+	if err != nil {
+		return nil, err
+	}
+
+	typ, err := typeOf(messageType)
+	if err != nil {
+		return nil, err
+	}
+
+	msg := a.lastMessage(typ)
+	if msg == nil {
+		return nil, fmt.Errorf("no %s message received by %q", messageType, name)
+	}
+
+	return msg, nil
+}
+
+func (s *Steps) agent(name string) (*agent, error) {
+	a, ok := s.agents[name]
+	if !ok {
+		return nil, fmt.Errorf("DIDComm agent %q not started", name)
+	}
+
+	return a, nil
+}
+
+func typeOf(messageType string) (string, error) {
+	typ, ok := messageTypes[messageType]
+	if !ok {
+		return "", fmt.Errorf("unknown message type %q", messageType)
+	}
+
+	return typ, nil
+}
+
+// sign returns the message from the DID of the agent, signed with the private key and the ID of the authentication
+// key of the DID in the JWS compact serialization.
+func (a *agent) sign(msg *didcomm.Message, privateKey ed25519.PrivateKey) []byte {
+	msg.From = a.did
+	msg.CreatedTime = time.Now().Unix()
+
+	header, _ := json.Marshal(map[string]string{ //nolint:errchkjson
+		"alg": "EdDSA",
+		"kid": a.keyID,
+		"typ": didcomm.SignedMediaType,
+	})
+	payload, _ := json.Marshal(msg) //nolint:errchkjson
+
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	signature := ed25519.Sign(privateKey, []byte(signingInput))
+
+	return []byte(signingInput + "." + base64.RawURLEncoding.EncodeToString(signature))
+}
+
+// serveHTTP decrypts the message received and records it by type, or responds 400 if it cannot be decrypted.
+func (a *agent) serveHTTP(rw http.ResponseWriter, r *http.Request) {
+	b, err := io.ReadAll(r.Body)
+	if err != nil {
+		rw.WriteHeader(http.StatusBadRequest)
+
+		return
+	}
+
+	msg, err := a.decrypt(b)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	a.mu.Lock()
+	a.received[msg.Type] = append(a.received[msg.Type], msg)
+	a.mu.Unlock()
+
+	rw.WriteHeader(http.StatusAccepted)
+}
+
+func (a *agent) decrypt(envelope []byte) (*didcomm.Message, error) {
+	jwe, err := jose.Deserialize(string(envelope))
+	if err != nil {
+		return nil, fmt.Errorf("deserialize message: %w", err)
+	}
+
+	plaintext, err := a.decrypter.Decrypt(jwe)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt message: %w", err)
+	}
+
+	var msg didcomm.Message
+
+	if err = json.Unmarshal(plaintext, &msg); err != nil {
+		return nil, fmt.Errorf("unmarshal message: %w", err)
+	}
+
+	return &msg, nil
+}
+
+func (a *agent) lastMessage(typ string) *didcomm.Message {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	messages := a.received[typ]
+	if len(messages) == 0 {
+		return nil
+	}
+
+	return messages[len(messages)-1]
+}
+
+type kmsProvider struct {
+	storageProvider storage.Provider
+	secretLock      secretlock.Service
+}
+
+func (k kmsProvider) StorageProvider() storage.Provider {
+	return k.storageProvider
+}
+
+func (k kmsProvider) SecretLock() secretlock.Service {
+	return k.secretLock
+}
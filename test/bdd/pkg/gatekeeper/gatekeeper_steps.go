@@ -10,7 +10,6 @@ import (
 	"bytes"
 	"context"
 	"crypto/ed25519"
-	"encoding/json"
 	"fmt"
 	"net/http"
 	"os"
@@ -25,6 +24,9 @@ import (
 
 const (
 	authToken = "gk_token"
+	// issuerProfileURL is the profile of the VC issuer service Gatekeeper creates with its DID to issue credentials.
+	issuerProfileURL = "http://localhost:8070/profile/vc-issuer-gk"
+	issuerAuthToken  = "vcs_issuer_rw_token" //nolint:gosec
 )
 
 // DIDOwner defines parameters of a DID owner.
@@ -60,6 +62,7 @@ func (s *Steps) RegisterSteps(sc *godog.ScenarioContext) {
 	sc.Step(`^policy configuration with ID "([^"]*)"$`, s.createPolicy)
 	sc.Step(`^social media handle "([^"]*)" converted into DID by "([^"]*)"$`, s.convertIntoDID)
 	sc.Step(`^release transaction created on DID by "([^"]*)"$`, s.createTicket)
+	sc.Step(`^the DID of Gatekeeper is saved as "([^"]*)"$`, s.saveGatekeeperDID)
 }
 
 func (s *Steps) createDIDOwner(ctx context.Context, name string) (context.Context, error) {
@@ -100,29 +103,50 @@ func (s *Steps) createPolicy(ctx context.Context, policyID string, policy *godog
 		return fmt.Errorf("execute template: %w", err)
 	}
 
-	_, err = httputil.DoRequest(ctx, fmt.Sprintf("https://%s/v1/policy/%s", s.host, policyID),
+	policyURL := fmt.Sprintf("https://%s/v1/policy/%s", s.host, policyID)
+
+	_, err = httputil.DoRequest(ctx, policyURL,
 		httputil.WithHTTPClient(s.cs.HTTPClient), httputil.WithMethod(http.MethodPut), httputil.WithBody(buf.Bytes()),
 		httputil.WithAuthToken(authToken))
 	if err != nil {
 		return fmt.Errorf("do request: %w", err)
 	}
 
+	s.cs.AddCleanup("policy "+policyID, func(ctx context.Context) error {
+		_, e := httputil.DoRequest(ctx, policyURL,
+			httputil.WithHTTPClient(s.cs.HTTPClient), httputil.WithMethod(http.MethodDelete),
+			httputil.WithAuthToken(authToken))
+
+		return e
+	})
+
 	s.policyID = policyID
 
 	return nil
 }
 
+// saveGatekeeperDID saves the DID of Gatekeeper, the issuer of its credentials, found in its VC issuer profile.
+func (s *Steps) saveGatekeeperDID(ctx context.Context, name string) error {
+	var resp issuerProfileResponse
+
+	_, err := httputil.DoRequest(ctx, issuerProfileURL,
+		httputil.WithHTTPClient(s.cs.HTTPClient), httputil.WithAuthToken(issuerAuthToken),
+		httputil.WithParsedResponse(&resp))
+	if err != nil {
+		return fmt.Errorf("get issuer profile: %w", err)
+	}
+
+	s.cs.SetParam(name, resp.DID)
+
+	return nil
+}
+
 func (s *Steps) convertIntoDID(ctx context.Context, handle, didOwner string) (context.Context, error) {
 	req := &protectRequest{
 		Policy: s.policyID,
 		Target: handle,
 	}
 
-	reqBytes, err := json.Marshal(req)
-	if err != nil {
-		return context.Background(), fmt.Errorf("marshal request: %w", err)
-	}
-
 	owner, ok := s.didOwners[didOwner]
 	if !ok {
 		return context.Background(), fmt.Errorf("missing did owner %q", didOwner)
@@ -130,12 +154,12 @@ func (s *Steps) convertIntoDID(ctx context.Context, handle, didOwner string) (co
 
 	var resp protectResponse
 
-	_, err = httputil.DoRequest(ctx, fmt.Sprintf("https://%s/v1/protect", s.host),
+	_, err := httputil.DoRequest(ctx, fmt.Sprintf("https://%s/v1/protect", s.host),
 		httputil.WithMethod(http.MethodPost),
-		httputil.WithBody(reqBytes),
+		httputil.WithJSONBody(req),
 		httputil.WithHTTPClient(s.cs.HTTPClient),
 		httputil.WithParsedResponse(&resp),
-		httputil.WithSigner(&common.RequestSigner{
+		httputil.WithHTTPSignature(&common.RequestSigner{
 			Headers:     []string{"(request-target)", "date", "digest"},
 			PublicKeyID: owner.PublicKeyID,
 			PrivateKey:  owner.PrivateKey,
@@ -154,11 +178,6 @@ func (s *Steps) createTicket(ctx context.Context, didOwner string) (context.Cont
 		DID: s.targetDID,
 	}
 
-	reqBytes, err := json.Marshal(req)
-	if err != nil {
-		return context.Background(), fmt.Errorf("marshal release request: %w", err)
-	}
-
 	owner, ok := s.didOwners[didOwner]
 	if !ok {
 		return context.Background(), fmt.Errorf("missing did owner %q", didOwner)
@@ -166,12 +185,12 @@ func (s *Steps) createTicket(ctx context.Context, didOwner string) (context.Cont
 
 	var resp releaseResponse
 
-	_, err = httputil.DoRequest(ctx, fmt.Sprintf("https://%s/v1/release", s.host),
+	_, err := httputil.DoRequest(ctx, fmt.Sprintf("https://%s/v1/release", s.host),
 		httputil.WithHTTPClient(s.cs.HTTPClient),
 		httputil.WithMethod(http.MethodPost),
-		httputil.WithBody(reqBytes),
+		httputil.WithJSONBody(req),
 		httputil.WithParsedResponse(&resp),
-		httputil.WithSigner(&common.RequestSigner{
+		httputil.WithHTTPSignature(&common.RequestSigner{
 			Headers:     []string{"(request-target)", "date", "digest"},
 			PublicKeyID: owner.PublicKeyID,
 			PrivateKey:  owner.PrivateKey,
@@ -189,3 +208,8 @@ func (s *Steps) createTicket(ctx context.Context, didOwner string) (context.Cont
 func (s *Steps) GetDID(didOwner string) string {
 	return s.didOwners[didOwner].DID
 }
+
+// Param is a helper function used in template to get a parameter of the scenario, e.g. the URL of a stub service.
+func (s *Steps) Param(name string) string {
+	return s.cs.Param(name)
+}
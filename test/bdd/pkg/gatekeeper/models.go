@@ -15,6 +15,10 @@ type protectResponse struct {
 	DID string `json:"did"`
 }
 
+type issuerProfileResponse struct {
+	DID string `json:"did"`
+}
+
 type releaseRequest struct {
 	DID string `json:"did"`
 }
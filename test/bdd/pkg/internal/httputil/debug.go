@@ -0,0 +1,154 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package httputil
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// maxLoggedBody is the number of bytes of the bodies logged.
+	maxLoggedBody = 1024
+	redacted      = "[REDACTED]"
+)
+
+//nolint:gochecknoglobals
+var (
+	// secretHeaders are the headers of credentials, which are redacted from the logs.
+	secretHeaders = []string{"Authorization", "Signature", "Capability-Invocation", "Cookie", "Set-Cookie"}
+	// secretFields are the words of the names of the JSON fields of credentials, e.g. auth_tokens, which are
+	// redacted from the logs.
+	secretFields = []string{"token", "password", "secret", "private", "jwe", "zcap"}
+)
+
+// DebugLogger logs debug messages, e.g. the logger of the aries log package.
+type DebugLogger interface {
+	Debugf(msg string, args ...interface{})
+}
+
+// WithDebugLogging specifies the logger the request and the response are logged with: their method, URL, status,
+// headers and the start of their bodies, with credentials redacted. The bodies of streamed responses are not logged.
+func WithDebugLogging(l DebugLogger) Opt {
+	return func(o *options) {
+		o.debugLogger = l
+	}
+}
+
+func logExchange(l DebugLogger, req *request, r *Response, elapsed time.Duration, err error) {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "%s %s\n", req.method, req.url)
+	writeHeader(&sb, req.header)
+	writeBody(&sb, req.body)
+
+	if err != nil && r == nil {
+		fmt.Fprintf(&sb, "-> error after %s: %s", elapsed, err)
+		l.Debugf("%s", sb.String())
+
+		return
+	}
+
+	fmt.Fprintf(&sb, "-> %s in %s\n", r.Status, elapsed)
+	writeHeader(&sb, r.Header)
+	writeBody(&sb, r.Body)
+
+	l.Debugf("%s", sb.String())
+}
+
+// request is the request logged.
+type request struct {
+	method string
+	url    string
+	header http.Header
+	body   []byte
+}
+
+func writeHeader(sb *strings.Builder, h http.Header) {
+	for name, values := range redactHeader(h) {
+		fmt.Fprintf(sb, "%s: %s\n", name, strings.Join(values, ", "))
+	}
+}
+
+func writeBody(sb *strings.Builder, body []byte) {
+	if len(body) == 0 {
+		return
+	}
+
+	b := redactBody(body)
+
+	if len(b) > maxLoggedBody {
+		fmt.Fprintf(sb, "%s... (%d bytes)\n", b[:maxLoggedBody], len(b))
+
+		return
+	}
+
+	fmt.Fprintf(sb, "%s\n", b)
+}
+
+func redactHeader(h http.Header) http.Header {
+	h = h.Clone()
+
+	for _, name := range secretHeaders {
+		if h.Get(name) != "" {
+			h.Set(name, redacted)
+		}
+	}
+
+	return h
+}
+
+// redactBody returns the JSON body with the values of the credential fields redacted, or the body itself if it is
+// not JSON.
+func redactBody(body []byte) []byte {
+	var v interface{}
+
+	if err := json.Unmarshal(body, &v); err != nil {
+		return body
+	}
+
+	b, err := json.Marshal(redactValue(v))
+	if err != nil {
+		return body
+	}
+
+	return b
+}
+
+func redactValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, e := range v {
+			if isSecretField(k) {
+				v[k] = redacted
+			} else {
+				v[k] = redactValue(e)
+			}
+		}
+	case []interface{}:
+		for i, e := range v {
+			v[i] = redactValue(e)
+		}
+	}
+
+	return v
+}
+
+func isSecretField(name string) bool {
+	name = strings.ToLower(name)
+
+	for _, w := range secretFields {
+		if strings.Contains(name, w) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -13,10 +13,18 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"mime/multipart"
+	"net"
 	"net/http"
 	"net/http/httputil"
+	"net/textproto"
+	"net/url"
+	"syscall"
+	"time"
 
+	"github.com/cenkalti/backoff/v4"
 	"github.com/hyperledger/aries-framework-go/pkg/common/log"
+	"github.com/trustbloc/edge-core/pkg/zcapld"
 )
 
 const (
@@ -29,69 +37,105 @@ var logger = log.New("ace-bdd")
 
 // Response is an HTTP response.
 type Response struct {
-	Status       string
-	StatusCode   int
-	Body         []byte
+	Status     string
+	StatusCode int
+	Header     http.Header
+	// Trailer is the trailer of the response, which is set once the body is read: on return, unless the response is
+	// streamed.
+	Trailer http.Header
+	Body    []byte
+	// BodyReader is the body of a successful response requested with WithStreamedResponse, instead of Body. The
+	// caller must close it.
+	BodyReader   io.ReadCloser
 	ErrorMessage string
 }
 
+// streamedBody is the body of a streamed response, which releases the context of the request once closed.
+type streamedBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+// Close closes the body.
+func (b *streamedBody) Close() error {
+	defer b.cancel()
+
+	return b.ReadCloser.Close()
+}
+
 // DoRequest makes an HTTP request.
-func DoRequest(ctx context.Context, url string, opts ...Opt) (*Response, error) { //nolint:funlen
+func DoRequest(ctx context.Context, targetURL string, opts ...Opt) (*Response, error) {
 	op := &options{
-		httpClient: http.DefaultClient,
-		method:     http.MethodGet,
+		httpClient:  http.DefaultClient,
+		method:      http.MethodGet,
+		contentType: applicationJSON,
 	}
 
 	for _, fn := range opts {
 		fn(op)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, op.method, url, op.body)
-	if err != nil {
-		return nil, fmt.Errorf("new request: %w", err)
+	cancel := func() {}
+
+	if op.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, op.timeout)
 	}
 
-	req.Header.Add(contentType, applicationJSON)
+	start := time.Now()
 
-	if op.authToken != "" {
-		req.Header.Add(authorization, "Bearer "+op.authToken)
+	resp, err := do(ctx, targetURL, op)
+	if err != nil {
+		cancel()
+		op.logExchange(targetURL, nil, start, err)
+
+		return nil, err
 	}
 
-	if op.signer != nil {
-		if err = op.signer.Sign(req); err != nil {
-			return nil, fmt.Errorf("sign http request: %w", err)
-		}
+	r := &Response{
+		Status:     resp.Status,
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Trailer:    resp.Trailer,
 	}
 
-	resp, err := op.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("http do: %w", err)
+	// the streamed body is read by the caller, within the timeout of the request
+	if op.streamResponse && resp.StatusCode == http.StatusOK {
+		r.BodyReader = &streamedBody{ReadCloser: resp.Body, cancel: cancel}
+		op.logExchange(targetURL, r, start, nil)
+
+		return r, nil
 	}
 
+	defer cancel()
+
 	defer func() {
 		if closeErr := resp.Body.Close(); closeErr != nil {
 			logger.Errorf("Failed to close response body: %s\n", closeErr.Error())
 		}
 	}()
 
-	r := &Response{
-		Status:     resp.Status,
-		StatusCode: resp.StatusCode,
-	}
-
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return nil, fmt.Errorf("read response body: %w", err)
 	}
 
-	if len(body) > 0 {
-		r.Body = body
+	r.Body = body
+	op.logExchange(targetURL, r, start, nil)
 
+	if len(body) > 0 {
 		if resp.StatusCode != http.StatusOK {
 			var errResp errorResponse
 
 			if err = json.Unmarshal(body, &errResp); err == nil && errResp.Message != "" {
-				return nil, errors.New(errResp.Message)
+				r.ErrorMessage = errResp.Message
+			}
+
+			if op.errorResponse {
+				return r, nil
+			}
+
+			if r.ErrorMessage != "" {
+				return nil, errors.New(r.ErrorMessage)
 			}
 
 			return nil, errors.New(resp.Status)
@@ -107,6 +151,177 @@ func DoRequest(ctx context.Context, url string, opts ...Opt) (*Response, error)
 	return r, nil
 }
 
+// logExchange logs the request and its response, or the error it failed with, if a debug logger is set.
+func (o *options) logExchange(targetURL string, r *Response, start time.Time, err error) {
+	if o.debugLogger == nil {
+		return
+	}
+
+	logExchange(o.debugLogger, &request{
+		method: o.method,
+		url:    targetURL,
+		header: o.sentHeader,
+		body:   o.body,
+	}, r, time.Since(start), err)
+}
+
+// do sends the request, again with the backoff of the options on connection failures, if any.
+func do(ctx context.Context, targetURL string, op *options) (*http.Response, error) {
+	var resp *http.Response
+
+	client, err := op.client()
+	if err != nil {
+		return nil, err
+	}
+
+	send := func() error {
+		req, e := newRequest(ctx, targetURL, op)
+		if e != nil {
+			return backoff.Permanent(e)
+		}
+
+		resp, e = client.Do(req) //nolint:bodyclose
+		if e != nil {
+			e = fmt.Errorf("http do: %w", e)
+
+			if !isConnectionFailure(e, op.method) {
+				return backoff.Permanent(e)
+			}
+
+			return e
+		}
+
+		return nil
+	}
+
+	if op.retries == 0 {
+		return resp, send()
+	}
+
+	b := backoff.NewExponentialBackOff()
+	b.InitialInterval = op.retryBackoff
+	b.MaxElapsedTime = 0
+
+	err = backoff.Retry(send, backoff.WithContext(backoff.WithMaxRetries(b, op.retries), ctx))
+
+	return resp, err
+}
+
+// client returns the HTTP client of the request, with a transport of its own if the request is sent through a proxy
+// or dialer of its own. The transport does not keep connections alive, as it is not used by other requests.
+func (o *options) client() (*http.Client, error) {
+	if o.proxy == nil && o.dialContext == nil {
+		return o.httpClient, nil
+	}
+
+	transport := o.httpClient.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	dump, dumped := transport.(*DumpTransport)
+	if dumped {
+		transport = dump.r
+	}
+
+	t, ok := transport.(*http.Transport)
+	if !ok {
+		return nil, fmt.Errorf("proxy and dialer options require an *http.Transport, got %T", transport)
+	}
+
+	t = t.Clone()
+	t.DisableKeepAlives = true
+
+	if o.proxy != nil {
+		t.Proxy = http.ProxyURL(o.proxy)
+	}
+
+	if o.dialContext != nil {
+		t.DialContext = o.dialContext
+	}
+
+	c := *o.httpClient
+	c.Transport = t
+
+	if dumped {
+		c.Transport = &DumpTransport{r: t}
+	}
+
+	return &c, nil
+}
+
+// isConnectionFailure returns whether the request failed to connect to the server, e.g. while it starts, or, for the
+// safe methods, whether the server closed the connection. A request of another method may have been handled by the
+// server before its connection was closed, so it isn't sent again.
+func isConnectionFailure(err error, method string) bool {
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		return true
+	}
+
+	safe := method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+
+	return safe && (errors.Is(err, syscall.ECONNRESET) || errors.Is(err, io.EOF))
+}
+
+// NewRequest returns the HTTP request DoRequest would send with the options, e.g. to read a streamed response. The
+// timeout option is not applied, as the request outlives the call: the deadline is set with the context instead.
+func NewRequest(ctx context.Context, targetURL string, opts ...Opt) (*http.Request, error) {
+	op := &options{
+		method:      http.MethodGet,
+		contentType: applicationJSON,
+	}
+
+	for _, fn := range opts {
+		fn(op)
+	}
+
+	return newRequest(ctx, targetURL, op)
+}
+
+func newRequest(ctx context.Context, targetURL string, op *options) (*http.Request, error) {
+	if op.encodeBody != nil {
+		b, ct, err := op.encodeBody()
+		if err != nil {
+			return nil, fmt.Errorf("encode body: %w", err)
+		}
+
+		op.body, op.contentType, op.encodeBody = b, ct, nil
+	}
+
+	var body io.Reader
+
+	// the body is read again if the request is retried
+	if op.body != nil {
+		body = bytes.NewReader(op.body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, op.method, targetURL, body)
+	if err != nil {
+		return nil, fmt.Errorf("new request: %w", err)
+	}
+
+	req.Header.Add(contentType, op.contentType)
+
+	if op.authToken != "" {
+		req.Header.Add(authorization, "Bearer "+op.authToken)
+	}
+
+	if op.capability != "" {
+		req.Header.Set(zcapld.CapabilityInvocationHTTPHeader,
+			fmt.Sprintf(`zcap capability=%q,action=%q`, op.capability, op.action))
+	}
+
+	if op.signer != nil {
+		if err = op.signer.Sign(req); err != nil {
+			return nil, fmt.Errorf("sign http request: %w", err)
+		}
+	}
+
+	op.sentHeader = req.Header
+
+	return req, nil
+}
+
 type errorResponse struct {
 	Message string `json:"errMessage,omitempty"`
 }
@@ -118,10 +333,24 @@ type requestSigner interface {
 type options struct {
 	httpClient     *http.Client
 	method         string
-	body           io.Reader
+	body           []byte
+	encodeBody     func() ([]byte, string, error)
+	contentType    string
 	authToken      string
 	signer         requestSigner
+	capability     string
+	action         string
 	parsedResponse interface{}
+	retries        uint64
+	retryBackoff   time.Duration
+	timeout        time.Duration
+	streamResponse bool
+	errorResponse  bool
+	proxy          *url.URL
+	dialContext    func(ctx context.Context, network, addr string) (net.Conn, error)
+	debugLogger    DebugLogger
+	// sentHeader is the header of the last request sent, for the debug logs.
+	sentHeader http.Header
 }
 
 // Opt configures HTTP request options.
@@ -144,7 +373,99 @@ func WithMethod(val string) Opt {
 // WithBody specifies HTTP request body.
 func WithBody(val []byte) Opt {
 	return func(o *options) {
-		o.body = bytes.NewBuffer(val)
+		o.body = val
+		o.encodeBody = nil
+	}
+}
+
+// WithJSONBody specifies the JSON encoding of the value as HTTP request body.
+func WithJSONBody(v interface{}) Opt {
+	return func(o *options) {
+		o.body = nil
+		o.encodeBody = func() ([]byte, string, error) {
+			b, err := json.Marshal(v)
+			if err != nil {
+				return nil, "", fmt.Errorf("marshal json: %w", err)
+			}
+
+			return b, applicationJSON, nil
+		}
+	}
+}
+
+// WithFormBody specifies the values of a URL-encoded form as HTTP request body.
+func WithFormBody(values url.Values) Opt {
+	return func(o *options) {
+		o.body = []byte(values.Encode())
+		o.encodeBody = nil
+		o.contentType = "application/x-www-form-urlencoded"
+	}
+}
+
+// Part is a part of a multipart form.
+type Part struct {
+	// Name is the name of the form field.
+	Name string
+	// FileName, if set, is the name of the uploaded file the part is the content of.
+	FileName string
+	// ContentType is the content type of the content. Defaults to application/octet-stream for files.
+	ContentType string
+	Content     []byte
+}
+
+// WithMultipart specifies the parts of a multipart form as HTTP request body, e.g. to upload files.
+func WithMultipart(parts ...*Part) Opt {
+	return func(o *options) {
+		o.body = nil
+		o.encodeBody = func() ([]byte, string, error) {
+			return encodeMultipart(parts)
+		}
+	}
+}
+
+func encodeMultipart(parts []*Part) ([]byte, string, error) {
+	var buf bytes.Buffer
+
+	w := multipart.NewWriter(&buf)
+
+	for _, p := range parts {
+		h := make(textproto.MIMEHeader)
+
+		disposition := fmt.Sprintf(`form-data; name=%q`, p.Name)
+		if p.FileName != "" {
+			disposition += fmt.Sprintf(`; filename=%q`, p.FileName)
+		}
+
+		h.Set("Content-Disposition", disposition)
+
+		switch {
+		case p.ContentType != "":
+			h.Set(contentType, p.ContentType)
+		case p.FileName != "":
+			h.Set(contentType, "application/octet-stream")
+		}
+
+		pw, err := w.CreatePart(h)
+		if err != nil {
+			return nil, "", fmt.Errorf("create part %s: %w", p.Name, err)
+		}
+
+		if _, err = pw.Write(p.Content); err != nil {
+			return nil, "", fmt.Errorf("write part %s: %w", p.Name, err)
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, "", fmt.Errorf("close multipart writer: %w", err)
+	}
+
+	return buf.Bytes(), w.FormDataContentType(), nil
+}
+
+// WithContentType specifies the content type of the body. Default is application/json.
+func WithContentType(val string) Opt {
+	return func(o *options) {
+		o.contentType = val
 	}
 }
 
@@ -155,10 +476,20 @@ func WithAuthToken(token string) Opt {
 	}
 }
 
-// WithSigner specifies a request signer for HTTP Signatures.
-func WithSigner(signer requestSigner) Opt {
+// WithHTTPSignature specifies a request signer for HTTP Signatures.
+func WithHTTPSignature(signer requestSigner) Opt {
+	return func(o *options) {
+		o.signer = signer
+	}
+}
+
+// WithZCAPInvocation specifies the request is an invocation of the compressed capability with the action, signed
+// with the signer for HTTP Signatures. The signer must sign the capability-invocation header.
+func WithZCAPInvocation(signer requestSigner, capability, action string) Opt {
 	return func(o *options) {
 		o.signer = signer
+		o.capability = capability
+		o.action = action
 	}
 }
 
@@ -169,6 +500,69 @@ func WithParsedResponse(r interface{}) Opt {
 	}
 }
 
+// WithRetry specifies the number of times the request is sent again when it fails to connect to the server, e.g.
+// while the container of the server starts, first after the backoff, then after exponentially longer intervals.
+// Requests of the safe methods are also sent again when the server closes the connection. Requests are not sent again
+// on other errors.
+func WithRetry(n uint64, backoff time.Duration) Opt {
+	return func(o *options) {
+		o.retries = n
+		o.retryBackoff = backoff
+	}
+}
+
+// WithTimeout specifies the time the request, including its retries and the read of the response, is done within.
+// The deadline of the context of the request applies as well.
+func WithTimeout(d time.Duration) Opt {
+	return func(o *options) {
+		o.timeout = d
+	}
+}
+
+// WithStreamedResponse specifies that the body of a successful response is returned as Response.BodyReader to be
+// read by the caller, instead of buffered in Response.Body, e.g. for large payloads. The parsed response option
+// does not apply to streamed responses.
+func WithStreamedResponse() Opt {
+	return func(o *options) {
+		o.streamResponse = true
+	}
+}
+
+// WithErrorResponse specifies that the response of an error status is returned, with the error message of its body
+// in Response.ErrorMessage, instead of an error, e.g. for the steps checking the status of the response.
+func WithErrorResponse() Opt {
+	return func(o *options) {
+		o.errorResponse = true
+	}
+}
+
+// ProxyURL returns the proxy function of a transport sending requests through the HTTP or SOCKS5 proxy, e.g.
+// socks5://localhost:1080, to send all the requests of a client through it.
+func ProxyURL(rawURL string) (func(*http.Request) (*url.URL, error), error) {
+	proxyURL, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("parse proxy url: %w", err)
+	}
+
+	return http.ProxyURL(proxyURL), nil
+}
+
+// WithProxy specifies the HTTP or SOCKS5 proxy the request is sent through, e.g. socks5://localhost:1080, instead of
+// the proxy of the HTTP client, if any.
+func WithProxy(proxyURL *url.URL) Opt {
+	return func(o *options) {
+		o.proxy = proxyURL
+	}
+}
+
+// WithDialContext specifies the function the connection of the request is dialed with, e.g. to connect through a
+// proxy injecting network failures such as toxiproxy.
+func WithDialContext(dial func(ctx context.Context, network, addr string) (net.Conn, error)) Opt {
+	return func(o *options) {
+		o.dialContext = dial
+	}
+}
+
 // WrapWithDumpTransport wraps existing http.Client's transport with transport that dumps requests and responses.
 func WrapWithDumpTransport(client *http.Client) *http.Client {
 	client.Transport = &DumpTransport{r: client.Transport}
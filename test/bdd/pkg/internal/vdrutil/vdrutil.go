@@ -10,6 +10,7 @@ import (
 	"crypto"
 	"crypto/ed25519"
 	"crypto/rand"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
@@ -48,7 +49,7 @@ func ResolveDID(vdrRegistry vdrapi.Registry, did string, maxRetry int) (*docdid.
 		docResolution, err = vdrRegistry.Resolve(did)
 
 		if err != nil {
-			if !strings.Contains(err.Error(), "DID does not exist") {
+			if !errors.Is(err, vdrapi.ErrNotFound) {
 				return nil, err
 			}
 
@@ -114,6 +115,34 @@ func CreateDIDDoc(vdr vdrapi.Registry) (*docdid.Doc, ed25519.PrivateKey, error)
 		return nil, nil, fmt.Errorf("failed to create public keys : %w", err)
 	}
 
+	return createDIDDoc(vdr, didDoc, privateKey)
+}
+
+// CreateDIDDocWithKeyAgreement creates did document in vdrapi.Registry with the X25519 public key as key agreement
+// key, e.g. for the DIDComm messages encrypted to the DID.
+func CreateDIDDocWithKeyAgreement(vdr vdrapi.Registry, x25519Key []byte) (*docdid.Doc, ed25519.PrivateKey, error) {
+	didDoc, privateKey, err := newDIDKeys()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create public keys : %w", err)
+	}
+
+	jwk, err := jwksupport.JWKFromX25519Key(x25519Key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create key agreement key : %w", err)
+	}
+
+	vm, err := docdid.NewVerificationMethodFromJWK("#"+uuid.New().String(), vccrypto.JSONWebKey2020, "", jwk)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create key agreement key : %w", err)
+	}
+
+	didDoc.KeyAgreement = append(didDoc.KeyAgreement, *docdid.NewReferencedVerification(vm, docdid.KeyAgreement))
+
+	return createDIDDoc(vdr, didDoc, privateKey)
+}
+
+func createDIDDoc(vdr vdrapi.Registry, didDoc *docdid.Doc,
+	privateKey ed25519.PrivateKey) (*docdid.Doc, ed25519.PrivateKey, error) {
 	recoverKey, _, err := newKey()
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to create recover key : %w", err)
@@ -0,0 +1,240 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package stub
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/cucumber/godog"
+	"github.com/tidwall/gjson"
+
+	"github.com/trustbloc/ace/test/bdd/pkg/common"
+)
+
+// Steps defines context for the steps of stub services, which stand for external services in failure scenarios.
+type Steps struct {
+	cs    *common.Steps
+	stubs map[string]*stub
+}
+
+// NewSteps returns new Steps context.
+func NewSteps(commonSteps *common.Steps) *Steps {
+	return &Steps{
+		cs:    commonSteps,
+		stubs: make(map[string]*stub),
+	}
+}
+
+// RegisterSteps registers stub scenario steps.
+func (s *Steps) RegisterSteps(sc *godog.ScenarioContext) {
+	sc.Step(`^a stub service "([^"]*)" is started$`, s.startStub)
+	sc.Step(`^stub service "([^"]*)" responds to "([A-Z]+) ([^"]*)" with status "(\d+)"$`, s.respondWithStatus)
+	sc.Step(`^stub service "([^"]*)" responds to "([A-Z]+) ([^"]*)" with status "(\d+)" and body$`, s.respondWithBody)
+	sc.Step(`^stub service "([^"]*)" delays responses by "([^"]*)"$`, s.delayResponses)
+	sc.Step(`^stub service "([^"]*)" received "(\d+)" requests to "([A-Z]+) ([^"]*)"$`, s.checkRequestCount)
+	sc.Step(`^the last request to stub service "([^"]*)" contains "([^"]*)" with value "([^"]*)"$`,
+		s.checkLastRequestValue)
+	sc.After(func(ctx context.Context, _ *godog.Scenario, err error) (context.Context, error) {
+		for _, st := range s.stubs {
+			st.server.Close()
+		}
+
+		return ctx, err
+	})
+}
+
+// stub is an HTTP server responding with canned responses and capturing the requests it receives.
+type stub struct {
+	server *httptest.Server
+
+	mu        sync.Mutex
+	responses map[string]*response
+	delay     time.Duration
+	requests  map[string][][]byte
+	last      []byte
+}
+
+type response struct {
+	status int
+	body   []byte
+}
+
+// startStub starts the stub where the services in containers can reach it, and sets its URL as a parameter of the
+// request bodies, e.g. {{ .webhook }}.
+func (s *Steps) startStub(name string) error {
+	if _, ok := s.stubs[name]; ok {
+		return fmt.Errorf("stub service %q already started", name)
+	}
+
+	listener, serverURL, err := common.ListenForServices()
+	if err != nil {
+		return err
+	}
+
+	st := &stub{
+		responses: make(map[string]*response),
+		requests:  make(map[string][][]byte),
+	}
+
+	st.server = httptest.NewUnstartedServer(http.HandlerFunc(st.serveHTTP))
+	st.server.Listener = listener
+	st.server.Start()
+
+	s.stubs[name] = st
+	s.cs.SetParam(name, serverURL)
+
+	return nil
+}
+
+func (s *Steps) respondWithStatus(name, method, path, status string) error {
+	return s.respond(name, method, path, status, nil)
+}
+
+func (s *Steps) respondWithBody(name, method, path, status string, body *godog.DocString) error {
+	return s.respond(name, method, path, status, []byte(body.Content))
+}
+
+func (s *Steps) respond(name, method, path, status string, body []byte) error {
+	st, err := s.stub(name)
+	if err != nil {
+		return err
+	}
+
+	code, err := strconv.Atoi(status)
+	if err != nil {
+		return fmt.Errorf("parse status: %w", err)
+	}
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	st.responses[method+" "+path] = &response{status: code, body: body}
+
+	return nil
+}
+
+func (s *Steps) delayResponses(name, delay string) error {
+	st, err := s.stub(name)
+	if err != nil {
+		return err
+	}
+
+	d, err := time.ParseDuration(delay)
+	if err != nil {
+		return fmt.Errorf("parse delay: %w", err)
+	}
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	st.delay = d
+
+	return nil
+}
+
+func (s *Steps) checkRequestCount(name string, count int, method, path string) error {
+	st, err := s.stub(name)
+	if err != nil {
+		return err
+	}
+
+	if n := len(st.received(method + " " + path)); n != count {
+		return fmt.Errorf("expected %d requests to %s %s, got %d", count, method, path, n)
+	}
+
+	return nil
+}
+
+func (s *Steps) checkLastRequestValue(name, path, value string) error {
+	st, err := s.stub(name)
+	if err != nil {
+		return err
+	}
+
+	last := st.lastRequest()
+	if last == nil {
+		return fmt.Errorf("stub service %q received no requests", name)
+	}
+
+	if got := gjson.GetBytes(last, path).String(); got != value {
+		return fmt.Errorf("expected %q, got %q", value, got)
+	}
+
+	return nil
+}
+
+func (s *Steps) stub(name string) (*stub, error) {
+	st, ok := s.stubs[name]
+	if !ok {
+		return nil, fmt.Errorf("stub service %q not started", name)
+	}
+
+	return st, nil
+}
+
+// serveHTTP responds with the response set for the method and path of the request, or 404 if none is set.
+func (st *stub) serveHTTP(rw http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		rw.WriteHeader(http.StatusBadRequest)
+
+		return
+	}
+
+	resp, delay := st.record(r.Method+" "+r.URL.Path, body)
+
+	select {
+	case <-time.After(delay):
+	case <-r.Context().Done():
+		return
+	}
+
+	if resp == nil {
+		rw.WriteHeader(http.StatusNotFound)
+
+		return
+	}
+
+	if resp.body != nil {
+		rw.Header().Set("Content-Type", "application/json")
+	}
+
+	rw.WriteHeader(resp.status)
+	rw.Write(resp.body) //nolint:errcheck,gosec
+}
+
+// record records the request and returns the response set for it and the delay of the response.
+func (st *stub) record(key string, body []byte) (*response, time.Duration) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	st.requests[key] = append(st.requests[key], body)
+	st.last = body
+
+	return st.responses[key], st.delay
+}
+
+func (st *stub) lastRequest() []byte {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	return st.last
+}
+
+func (st *stub) received(key string) [][]byte {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	return st.requests[key]
+}
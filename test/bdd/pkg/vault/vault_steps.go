@@ -7,9 +7,11 @@ SPDX-License-Identifier: Apache-2.0
 package vault
 
 import (
+	"context"
 	"crypto"
 	"crypto/ed25519"
 	"crypto/tls"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
@@ -43,17 +45,25 @@ import (
 	"github.com/igor-pavlenko/httpsignatures-go"
 	"github.com/trustbloc/edge-core/pkg/zcapld"
 	edv "github.com/trustbloc/edv/pkg/client"
+	"github.com/trustbloc/edv/pkg/restapi/models"
 
 	vaultclient "github.com/trustbloc/ace/pkg/client/vault"
 	vccrypto "github.com/trustbloc/ace/pkg/doc/vc/crypto"
 	"github.com/trustbloc/ace/pkg/restapi/vault"
+	"github.com/trustbloc/ace/test/bdd/pkg/common"
 	"github.com/trustbloc/ace/test/bdd/pkg/internal/vdrutil"
 )
 
-const keystorePrimaryKeyURI = "local-lock://keystorekms"
+const (
+	keystorePrimaryKeyURI = "local-lock://keystorekms"
+
+	// vaultIDParam is the request body parameter the ID of the vault created is set as, e.g. {{ .vaultID }}.
+	vaultIDParam = "vaultID"
+)
 
 // Steps is steps for vault tests.
 type Steps struct {
+	cs             *common.Steps
 	httpClient     *http.Client
 	vaultID        string
 	vaultURL       string
@@ -66,8 +76,9 @@ type Steps struct {
 	vdrRegistry    vdrapi.Registry
 }
 
-// NewSteps returns new vault steps.
-func NewSteps(tlsConfig *tls.Config) (*Steps, error) {
+// NewSteps returns new vault steps. The ID of the vault created and the IDs of the documents saved without ID are set
+// as parameters of the request bodies of the common steps, so that the scenarios of other features refer to them.
+func NewSteps(commonSteps *common.Steps, tlsConfig *tls.Config) (*Steps, error) {
 	httpClient := &http.Client{
 		Transport: &http.Transport{
 			TLSClientConfig: tlsConfig,
@@ -95,6 +106,7 @@ func NewSteps(tlsConfig *tls.Config) (*Steps, error) {
 	}
 
 	return &Steps{
+		cs:             commonSteps,
 		crypto:         cryptoService,
 		kms:            keyManager,
 		variableMapper: map[string]string{},
@@ -114,19 +126,46 @@ func (e *Steps) RegisterSteps(s *godog.ScenarioContext) {
 	s.Step(`^Create a new "([^"]*)" authorization with duration "([^"]*)" and save the result as "([^"]*)"$`,
 		e.createAuthorization)
 	s.Step(`^Check that a document with id "([^"]*)" is available for "([^"]*)"$`, e.checkAccessibility)
+	s.Step(`^Read a document with id "([^"]*)" for "([^"]*)" and check that its data is "([^"]*)"$`, e.readDocument)
 	s.Step(`^Check that a document with id "([^"]*)" is not available for "([^"]*)"$`, e.checkNotAvailable)
 	s.Step(`^Check that an authorization "([^"]*)" was stored$`, e.checkAuthorization)
 }
 
 func (e *Steps) checkAccessibility(docID, auth string) error {
+	_, err := e.readDoc(docID, auth)
+
+	return err
+}
+
+func (e *Steps) readDocument(docID, auth, data string) error {
+	content, err := e.readDoc(docID, auth)
+	if err != nil {
+		return err
+	}
+
+	var doc models.StructuredDocument
+
+	if err = json.Unmarshal(content, &doc); err != nil {
+		return fmt.Errorf("failed to unmarshal document: %w", err)
+	}
+
+	if got := doc.Content["contents"]; got != data {
+		return fmt.Errorf("expected data %q, got %q", data, got)
+	}
+
+	return nil
+}
+
+// readDoc reads the document from the EDV with the authorization and returns its decrypted content.
+func (e *Steps) readDoc(docID, auth string) ([]byte, error) {
 	authorization, ok := e.authorizations[auth]
 	if !ok {
-		return errors.New("no authorization")
+		return nil, errors.New("no authorization")
 	}
 
 	docMeta, err := e.getDoc(docID)
 	if err != nil {
-		return fmt.Errorf("failed to fetch doc: %w", err)
+		return nil, fmt.Errorf("failed to fetch doc: %w", err)
 	}
 
 	URIParts := strings.Split(docMeta.URI, "/")
@@ -137,12 +176,12 @@ func (e *Steps) checkAccessibility(docID, auth string) error {
 		e.edvSign(authorization.RequestingParty, authorization.Tokens.EDV)),
 	)
 	if err != nil {
-		return fmt.Errorf("edvClient failed to read document: %w", err)
+		return nil, fmt.Errorf("edvClient failed to read document: %w", err)
 	}
 
 	store, err := mem.NewProvider().OpenStore("test")
 	if err != nil {
-		return fmt.Errorf("failed to open mem store: %w", err)
+		return nil, fmt.Errorf("failed to open mem store: %w", err)
 	}
 
 	decrypter := ariesjose.NewJWEDecrypt(
@@ -161,12 +200,10 @@ func (e *Steps) checkAccessibility(docID, auth string) error {
 
 	JWE, err := ariesjose.Deserialize(string(eDoc.JWE))
 	if err != nil {
-		return fmt.Errorf("failed to decrypt JWE: %w", err)
+		return nil, fmt.Errorf("failed to decrypt JWE: %w", err)
 	}
 
-	_, err = decrypter.Decrypt(JWE)
-
-	return err
+	return decrypter.Decrypt(JWE)
 }
 
 func (e *Steps) checkNotAvailable(docID, auth string) error {
@@ -223,6 +260,7 @@ func (e *Steps) createAuthorization(method, duration, name string) error {
 	}
 
 	result, err := vaultclient.New(e.vaultURL, vaultclient.WithHTTPClient(e.httpClient)).CreateAuthorization(
+		context.Background(),
 		e.vaultID,
 		requestingParty,
 		&vault.AuthorizationsScope{
@@ -245,7 +283,7 @@ func (e *Steps) createAuthorization(method, duration, name string) error {
 }
 
 func (e *Steps) createVault(endpoint string) error {
-	result, err := vaultclient.New(endpoint, vaultclient.WithHTTPClient(e.httpClient)).CreateVault()
+	result, err := vaultclient.New(endpoint, vaultclient.WithHTTPClient(e.httpClient)).CreateVault(context.Background())
 	if err != nil {
 		return err
 	}
@@ -257,6 +295,10 @@ func (e *Steps) createVault(endpoint string) error {
 	e.vaultID = result.ID
 	e.vaultURL = endpoint
 	e.kmsURI = result.KMS.URI
+	e.cs.SetParam(vaultIDParam, result.ID)
+	e.cs.AddCleanup("vault "+result.ID, func(ctx context.Context) error {
+		return vaultclient.New(endpoint, vaultclient.WithHTTPClient(e.httpClient)).DeleteVault(ctx, result.ID)
+	})
 
 	_, err = vdrutil.ResolveDID(e.vdrRegistry, e.vaultID, 10) //nolint: gomnd
 	if err != nil {
@@ -267,7 +309,8 @@ func (e *Steps) createVault(endpoint string) error {
 }
 
 func (e *Steps) saveDoc(docID, data string) (*vault.DocumentMetadata, error) {
-	res, err := vaultclient.New(e.vaultURL, vaultclient.WithHTTPClient(e.httpClient)).SaveDoc(e.vaultID, docID,
+	res, err := vaultclient.New(e.vaultURL, vaultclient.WithHTTPClient(e.httpClient)).SaveDoc(context.Background(),
+		e.vaultID, docID,
 		map[string]interface{}{
 			"contents": data,
 		})
@@ -289,6 +332,7 @@ func (e *Steps) saveDocumentWithoutID(name string) error {
 	}
 
 	e.variableMapper[name] = result.ID
+	e.cs.SetParam(name, result.ID)
 
 	return nil
 }
@@ -317,7 +361,7 @@ func (e *Steps) checkAuthorization(auth string) error {
 	}
 
 	result, err := vaultclient.New(e.vaultURL, vaultclient.WithHTTPClient(e.httpClient)).
-		GetAuthorization(e.vaultID, authorization.ID)
+		GetAuthorization(context.Background(), e.vaultID, authorization.ID)
 	if err != nil {
 		return err
 	}
@@ -350,7 +394,8 @@ func (e *Steps) getDoc(id string) (*vault.DocumentMetadata, error) {
 		docID = id
 	}
 
-	result, err := vaultclient.New(e.vaultURL, vaultclient.WithHTTPClient(e.httpClient)).GetDocMetaData(e.vaultID, docID)
+	result, err := vaultclient.New(e.vaultURL, vaultclient.WithHTTPClient(e.httpClient)).
+		GetDocMetaData(context.Background(), e.vaultID, docID)
 	if err != nil {
 		return nil, err
 	}